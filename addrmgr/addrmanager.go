@@ -18,6 +18,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -83,7 +84,7 @@ const (
 	// BoundPrio signifies the address has been explicitly bounded to.
 	BoundPrio
 
-	// UpnpPrio signifies the address was obtained from UPnP.
+	// UpnpPrio signifies the address was obtained from UPnP or NAT-PMP.
 	UpnpPrio
 
 	// HTTPPrio signifies the address was obtained from an external HTTP service.
@@ -964,6 +965,42 @@ func (a *AddrManager) AddLocalAddress(na *wire.NetAddress, priority AddressPrior
 	return nil
 }
 
+// RemoveLocalAddress removes na from the list of known local addresses to
+// advertise, such as when the external address obtained from a NAT changed.
+func (a *AddrManager) RemoveLocalAddress(na *wire.NetAddress) {
+	a.lamtx.Lock()
+	delete(a.localAddresses, NetAddressKey(na))
+	a.lamtx.Unlock()
+}
+
+// LocalAddress is a known local address to advertise along with its score,
+// which is the priority of the method it was discovered with, incremented when
+// it was discovered by several methods.
+type LocalAddress struct {
+	NetAddress *wire.NetAddress
+	Score      AddressPriority
+}
+
+// LocalAddresses returns the known local addresses to advertise ordered by
+// address.
+func (a *AddrManager) LocalAddresses() []LocalAddress {
+	a.lamtx.Lock()
+	defer a.lamtx.Unlock()
+
+	addrs := make([]LocalAddress, 0, len(a.localAddresses))
+	for _, la := range a.localAddresses {
+		addrs = append(addrs, LocalAddress{
+			NetAddress: la.na,
+			Score:      la.score,
+		})
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return NetAddressKey(addrs[i].NetAddress) <
+			NetAddressKey(addrs[j].NetAddress)
+	})
+	return addrs
+}
+
 // getReachabilityFrom returns the relative reachability of the provided local
 // address to the provided remote address.
 func getReachabilityFrom(localAddr, remoteAddr *wire.NetAddress) int {
@@ -210,6 +210,48 @@ func TestAddLocalAddress(t *testing.T) {
 	}
 }
 
+func TestLocalAddresses(t *testing.T) {
+	amgr := addrmgr.New("testlocaladdresses", nil)
+	ipv4Addr := wire.NetAddress{IP: net.ParseIP("204.124.1.1")}
+	ipv6Addr := wire.NetAddress{IP: net.ParseIP("2620:100::1")}
+	amgr.AddLocalAddress(&ipv6Addr, addrmgr.InterfacePrio)
+	amgr.AddLocalAddress(&ipv4Addr, addrmgr.InterfacePrio)
+	amgr.AddLocalAddress(&ipv4Addr, addrmgr.BoundPrio)
+
+	// The address added twice is scored above the priority it was last
+	// added with.
+	localAddrs := amgr.LocalAddresses()
+	if len(localAddrs) != 2 {
+		t.Fatalf("LocalAddresses: got %d addresses, want 2",
+			len(localAddrs))
+	}
+	want := []struct {
+		ip    string
+		score addrmgr.AddressPriority
+	}{
+		{"204.124.1.1", addrmgr.BoundPrio + 1},
+		{"2620:100::1", addrmgr.InterfacePrio},
+	}
+	for i, la := range localAddrs {
+		if la.NetAddress.IP.String() != want[i].ip ||
+			la.Score != want[i].score {
+
+			t.Errorf("LocalAddresses #%d: got %s with score %d, "+
+				"want %s with score %d", i, la.NetAddress.IP,
+				la.Score, want[i].ip, want[i].score)
+		}
+	}
+
+	amgr.RemoveLocalAddress(&ipv4Addr)
+	localAddrs = amgr.LocalAddresses()
+	if len(localAddrs) != 1 || localAddrs[0].NetAddress.IP.String() !=
+		"2620:100::1" {
+
+		t.Errorf("LocalAddresses: unexpected addresses %v after "+
+			"removal", localAddrs)
+	}
+}
+
 func TestAttempt(t *testing.T) {
 	n := addrmgr.New("testattempt", lookupFunc)
 
@@ -0,0 +1,374 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package auditlog
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/txscript/adminop"
+)
+
+// Event types of the records in the audit log.
+const (
+	// EventAdminTx is recorded for every admin transaction connected to
+	// the main chain.
+	EventAdminTx = "admintx"
+
+	// EventKeySetChange is recorded for every key added to or revoked
+	// from an admin key set by a connected admin transaction.
+	EventKeySetChange = "keysetchange"
+
+	// EventIssuance is recorded for every issuance or destruction of
+	// coins connected to the main chain.
+	EventIssuance = "issuance"
+
+	// EventAdminReorg is recorded for every admin transaction disconnected
+	// from the main chain, which reverts its changes to the admin state.
+	EventAdminReorg = "adminreorg"
+
+	// EventRPC is recorded for every RPC call which alters the signing
+	// configuration of the node.
+	EventRPC = "rpc"
+
+	// EventTemplateExclusion is recorded for every transaction or address
+	// added to or removed from the exclusion list of block templates.
+	EventTemplateExclusion = "templateexclusion"
+)
+
+// Record is a single entry of the audit log.  Each record commits to the hash
+// of the record before it, so altering, removing or reordering records breaks
+// the chain of hashes.
+type Record struct {
+	Seq      uint64          `json:"seq"`
+	Time     int64           `json:"time"`
+	Event    string          `json:"event"`
+	Data     json.RawMessage `json:"data"`
+	PrevHash string          `json:"prevhash"`
+	Hash     string          `json:"hash,omitempty"`
+}
+
+// hash returns the hex-encoded hash of the record.  It covers the serialized
+// record without its own hash.
+func (r *Record) hash() (string, error) {
+	unhashed := *r
+	unhashed.Hash = ""
+	serialized, err := json.Marshal(&unhashed)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(serialized)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// AdminTxData describes an admin transaction connected to or disconnected from
+// the main chain.
+type AdminTxData struct {
+	TxID      string   `json:"txid"`
+	BlockHash string   `json:"blockhash"`
+	Height    uint32   `json:"height"`
+	Thread    uint32   `json:"thread"`
+	Ops       []string `json:"ops,omitempty"`
+}
+
+// KeySetChangeData describes a key added to or revoked from an admin key set.
+type KeySetChangeData struct {
+	TxID   string `json:"txid"`
+	Height uint32 `json:"height"`
+	Op     string `json:"op"`
+}
+
+// IssuanceData describes an issuance or destruction of coins.
+type IssuanceData struct {
+	TxID        string `json:"txid"`
+	Height      uint32 `json:"height"`
+	AssetID     uint32 `json:"assetid"`
+	Amount      int64  `json:"amount"`
+	Destruction bool   `json:"destruction"`
+}
+
+// RPCData describes an RPC call which alters the signing configuration of the
+// node.
+type RPCData struct {
+	Method  string   `json:"method"`
+	PubKeys []string `json:"pubkeys,omitempty"`
+}
+
+// TemplateExclusionData describes a transaction or address added to or removed
+// from the exclusion list of block templates.
+type TemplateExclusionData struct {
+	Command string `json:"command"`
+	TxID    string `json:"txid,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+// Verify reads the records of an audit log from r and returns an error when
+// their chain of hashes is broken, which is the case when any record was
+// altered, removed or reordered after it was appended.  The last record is
+// returned, or nil when there are none.
+func Verify(r io.Reader) (*Record, error) {
+	var last *Record
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			return last, nil
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, fmt.Errorf("record after %d: %v",
+				seqOf(last), err)
+		}
+		if record.Seq != seqOf(last)+1 {
+			return nil, fmt.Errorf("record %d follows record %d",
+				record.Seq, seqOf(last))
+		}
+		prevHash := ""
+		if last != nil {
+			prevHash = last.Hash
+		}
+		if record.PrevHash != prevHash {
+			return nil, fmt.Errorf("record %d does not commit to "+
+				"the previous record", record.Seq)
+		}
+		hash, err := record.hash()
+		if err != nil {
+			return nil, err
+		}
+		if record.Hash != hash {
+			return nil, fmt.Errorf("record %d has hash %s, want %s",
+				record.Seq, record.Hash, hash)
+		}
+		last = &record
+	}
+}
+
+// seqOf returns the sequence number of the passed record, or zero when it is
+// nil.
+func seqOf(record *Record) uint64 {
+	if record == nil {
+		return 0
+	}
+	return record.Seq
+}
+
+// Log is an append-only, hash-chained audit log file.
+type Log struct {
+	mtx         sync.Mutex
+	file        *os.File
+	seq         uint64
+	prevHash    string
+	chainParams *chaincfg.Params
+}
+
+// Open opens the audit log at the passed path for appending, creating it when
+// it does not exist.  The records already in the file are verified, and an
+// error is returned when their chain of hashes is broken.  The blocks logged
+// are of the network of the passed parameters.
+func Open(path string, chainParams *chaincfg.Params) (*Log, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND,
+		0600)
+	if err != nil {
+		return nil, err
+	}
+	last, err := Verify(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("audit log %s: %v", path, err)
+	}
+
+	l := &Log{file: file, chainParams: chainParams}
+	if last != nil {
+		l.seq = last.Seq
+		l.prevHash = last.Hash
+	}
+	return l, nil
+}
+
+// Append adds a record of the passed event with the passed data to the log and
+// syncs it to disk.
+//
+// This function is safe for concurrent access.
+func (l *Log) Append(event string, data interface{}) error {
+	serializedData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if l.file == nil {
+		return errors.New("audit log is closed")
+	}
+	record := Record{
+		Seq:      l.seq + 1,
+		Time:     time.Now().Unix(),
+		Event:    event,
+		Data:     serializedData,
+		PrevHash: l.prevHash,
+	}
+	record.Hash, err = record.hash()
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(&record)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := l.file.Sync(); err != nil {
+		return err
+	}
+
+	l.seq = record.Seq
+	l.prevHash = record.Hash
+	return nil
+}
+
+// BlockConnected records the admin transactions of the passed block, which
+// has been connected to the main chain, along with their key set changes and
+// issuances.
+//
+// This function is safe for concurrent access.
+func (l *Log) BlockConnected(block *provautil.Block) error {
+	for _, tx := range block.Transactions() {
+		threadInt, adminOutputs := txscript.GetAdminDetails(tx)
+		if threadInt < 0 {
+			continue
+		}
+		threadID := provautil.ThreadID(threadInt)
+		err := l.Append(EventAdminTx, adminTxData(block, tx, threadID))
+		if err != nil {
+			return err
+		}
+
+		// Issue thread transactions issue or destroy coins instead of
+		// carrying admin ops.
+		msgTx := tx.MsgTx()
+		if threadID == provautil.IssueThread {
+			err := l.Append(EventIssuance, issuanceData(block, tx,
+				l.chainParams))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		for i, pops := range adminOutputs {
+			payload, err := txscript.ExtractAdminPayload(pops)
+			if err != nil || payload.Schema().Kind != adminop.KindKey {
+				continue
+			}
+			err = l.Append(EventKeySetChange, &KeySetChangeData{
+				TxID:   tx.Hash().String(),
+				Height: block.Height(),
+				Op:     txscript.AdminOpString(msgTx.TxOut[i+1].PkScript),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// BlockDisconnected records the admin transactions of the passed block, which
+// has been disconnected from the main chain.
+//
+// This function is safe for concurrent access.
+func (l *Log) BlockDisconnected(block *provautil.Block) error {
+	for _, tx := range block.Transactions() {
+		threadInt, _ := txscript.GetAdminDetails(tx)
+		if threadInt < 0 {
+			continue
+		}
+		err := l.Append(EventAdminReorg, adminTxData(block, tx,
+			provautil.ThreadID(threadInt)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the audit log.  Records can no longer be appended afterwards.
+//
+// This function is safe for concurrent access.
+func (l *Log) Close() error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// adminTxData returns the description of the passed admin transaction of the
+// passed block on the passed thread.
+func adminTxData(block *provautil.Block, tx *provautil.Tx, threadID provautil.ThreadID) *AdminTxData {
+	data := &AdminTxData{
+		TxID:      tx.Hash().String(),
+		BlockHash: block.Hash().String(),
+		Height:    block.Height(),
+		Thread:    uint32(threadID),
+	}
+	if threadID == provautil.IssueThread {
+		return data
+	}
+	for _, txOut := range tx.MsgTx().TxOut[1:] {
+		data.Ops = append(data.Ops, txscript.AdminOpString(txOut.PkScript))
+	}
+	return data
+}
+
+// issuanceData returns the description of the passed issue thread transaction
+// of the passed block.  Destructions spend coins next to the thread output and
+// destroy the value of their null data outputs, while issuances create the
+// value of all but the thread output.
+func issuanceData(block *provautil.Block, tx *provautil.Tx,
+	chainParams *chaincfg.Params) *IssuanceData {
+
+	msgTx := tx.MsgTx()
+	data := &IssuanceData{
+		TxID:        tx.Hash().String(),
+		Height:      block.Height(),
+		AssetID:     blockchain.TxAssetID(msgTx, block.Height(), chainParams),
+		Destruction: len(msgTx.TxIn) > 1,
+	}
+	for _, txOut := range msgTx.TxOut[1:] {
+		if data.Destruction {
+			class := txscript.GetScriptClass(txOut.PkScript)
+			if class != txscript.NullDataTy &&
+				class != txscript.CommitmentTy {
+
+				continue
+			}
+		}
+		data.Amount += txOut.Value
+	}
+	return data
+}
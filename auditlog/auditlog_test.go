@@ -0,0 +1,193 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package auditlog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/auditlog"
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// adminTx returns a transaction on the passed thread with the passed
+// additional outputs.
+func adminTx(t *testing.T, threadID provautil.ThreadID, numTxIn int, txOuts ...*wire.TxOut) *wire.MsgTx {
+	threadScript, err := txscript.ProvaThreadScript(threadID)
+	if err != nil {
+		t.Fatalf("ProvaThreadScript: %v", err)
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	for i := 0; i < numTxIn; i++ {
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: wire.OutPoint{Index: uint32(i)},
+		})
+	}
+	tx.AddTxOut(wire.NewTxOut(0, threadScript))
+	for _, txOut := range txOuts {
+		tx.AddTxOut(txOut)
+	}
+	return tx
+}
+
+// TestLog ensures admin events are appended to the log with an intact chain of
+// hashes, across reopening the log, and that tampering is detected.
+func TestLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auditlog")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	// Build a block holding a regular transaction, a root thread
+	// transaction provisioning a key and an issue thread transaction.
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("NewPrivateKey: %v", err)
+	}
+	opData := append([]byte{txscript.AdminOpProvisionKeyAdd},
+		privKey.PubKey().SerializeCompressed()...)
+	opScript, err := txscript.NullDataScript(opData)
+	if err != nil {
+		t.Fatalf("NullDataScript: %v", err)
+	}
+	msgBlock := wire.NewMsgBlock(&wire.BlockHeader{Height: 7})
+	regularTx := wire.NewMsgTx(wire.TxVersion)
+	regularTx.AddTxIn(&wire.TxIn{})
+	regularTx.AddTxOut(wire.NewTxOut(1, []byte{txscript.OP_TRUE}))
+	msgBlock.AddTransaction(regularTx)
+	msgBlock.AddTransaction(adminTx(t, provautil.RootThread, 1,
+		wire.NewTxOut(0, opScript)))
+	msgBlock.AddTransaction(adminTx(t, provautil.IssueThread, 1,
+		wire.NewTxOut(1000, []byte{txscript.OP_TRUE}),
+		wire.NewTxOut(500, []byte{txscript.OP_TRUE})))
+	block := provautil.NewBlock(msgBlock)
+
+	log, err := auditlog.Open(path, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := log.BlockConnected(block); err != nil {
+		t.Fatalf("BlockConnected: %v", err)
+	}
+	if err := log.BlockDisconnected(block); err != nil {
+		t.Fatalf("BlockDisconnected: %v", err)
+	}
+	if err := log.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := log.Append(auditlog.EventRPC, nil); err == nil {
+		t.Fatalf("Append: expected error for closed log")
+	}
+
+	// Records appended after reopening the log continue the chain.
+	log, err = auditlog.Open(path, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	err = log.Append(auditlog.EventRPC, &auditlog.RPCData{
+		Method: "setvalidatekeys",
+	})
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	log.Close()
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	last, err := auditlog.Verify(bytes.NewReader(contents))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if last == nil || last.Seq != 7 {
+		t.Fatalf("Verify: got last record %+v, want seq 7", last)
+	}
+
+	lines := strings.SplitAfter(string(contents), "\n")
+	lines = lines[:len(lines)-1]
+	wantEvents := []string{
+		auditlog.EventAdminTx,
+		auditlog.EventKeySetChange,
+		auditlog.EventAdminTx,
+		auditlog.EventIssuance,
+		auditlog.EventAdminReorg,
+		auditlog.EventAdminReorg,
+		auditlog.EventRPC,
+	}
+	if len(lines) != len(wantEvents) {
+		t.Fatalf("got %d records, want %d", len(lines), len(wantEvents))
+	}
+	for i, line := range lines {
+		var record auditlog.Record
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Unmarshal: %v", err)
+		}
+		if record.Event != wantEvents[i] {
+			t.Errorf("record %d: got event %s, want %s", i+1,
+				record.Event, wantEvents[i])
+		}
+	}
+
+	var issuance auditlog.IssuanceData
+	var record auditlog.Record
+	if err := json.Unmarshal([]byte(lines[3]), &record); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if err := json.Unmarshal(record.Data, &issuance); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if issuance.Amount != 1500 || issuance.Destruction ||
+		issuance.Height != 7 {
+
+		t.Errorf("got issuance %+v, want 1500 issued at height 7",
+			issuance)
+	}
+
+	// Altering, removing or reordering records breaks the chain.
+	tests := []struct {
+		name     string
+		contents string
+	}{
+		{
+			name: "altered record",
+			contents: strings.Replace(string(contents),
+				`"amount":1500`, `"amount":1501`, 1),
+		},
+		{
+			name:     "removed record",
+			contents: lines[0] + strings.Join(lines[2:], ""),
+		},
+		{
+			name: "reordered records",
+			contents: lines[1] + lines[0] +
+				strings.Join(lines[2:], ""),
+		},
+	}
+	for _, test := range tests {
+		_, err := auditlog.Verify(strings.NewReader(test.contents))
+		if err == nil {
+			t.Errorf("%s: expected error", test.name)
+		}
+		err = ioutil.WriteFile(path, []byte(test.contents), 0600)
+		if err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		if _, err := auditlog.Open(path, &chaincfg.RegressionNetParams); err == nil {
+			t.Errorf("%s: expected error opening log", test.name)
+		}
+	}
+}
@@ -69,6 +69,16 @@ func (b *BlockChain) maybeAcceptBlock(block *provautil.Block, flags BehaviorFlag
 		newNode.workSum.Add(prevNode.workSum, newNode.workSum)
 	}
 
+	// Record the evidence of the validate key of the block signing another
+	// block at the same height.
+	var violation *ValidatorViolation
+	if !dryRun {
+		violation, err = b.detectDoubleSign(newNode)
+		if err != nil {
+			return false, err
+		}
+	}
+
 	// Connect the passed block to the chain while respecting proper chain
 	// selection according to the chain with the most proof of work.  This
 	// also handles validation of the transaction scripts.
@@ -83,6 +93,9 @@ func (b *BlockChain) maybeAcceptBlock(block *provautil.Block, flags BehaviorFlag
 	if !dryRun {
 		b.chainLock.Unlock()
 		b.sendNotification(NTBlockAccepted, block)
+		if violation != nil {
+			b.sendNotification(NTValidatorViolation, violation)
+		}
 		b.chainLock.Lock()
 	}
 
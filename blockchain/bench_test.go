@@ -10,13 +10,18 @@ import (
 	"testing"
 
 	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/blockchain/fullblocktests"
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
 	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
 )
 
 // BenchmarkIsCoinBase performs a simple benchmark against the IsCoinBase
 // function.
 func BenchmarkIsCoinBase(b *testing.B) {
-	tx, _ := provautil.NewBlock(&SomeBlock).Tx(1)
+	tx, _ := provautil.NewBlock(&SomeBlock).Tx(0)
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		blockchain.IsCoinBase(tx)
@@ -26,9 +31,107 @@ func BenchmarkIsCoinBase(b *testing.B) {
 // BenchmarkIsCoinBaseTx performs a simple benchmark against the IsCoinBaseTx
 // function.
 func BenchmarkIsCoinBaseTx(b *testing.B) {
-	tx := SomeBlock.Transactions[1]
+	tx := SomeBlock.Transactions[0]
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		blockchain.IsCoinBaseTx(tx)
 	}
 }
+
+// BenchmarkProcessFullBlocks benchmarks processing the blocks of the full block
+// tests on a new chain, which checks and connects blocks with admin, issue and
+// payment transactions, including side chains and reorganizations.
+func BenchmarkProcessFullBlocks(b *testing.B) {
+	tests, err := fullblocktests.Generate(false)
+	if err != nil {
+		b.Fatalf("failed to generate tests: %v", err)
+	}
+	var blocks []*provautil.Block
+	for _, test := range tests {
+		for _, instance := range test {
+			var block *wire.MsgBlock
+			var height uint32
+			switch item := instance.(type) {
+			case fullblocktests.AcceptedBlock:
+				block, height = item.Block, item.Height
+			case fullblocktests.RejectedBlock:
+				block, height = item.Block, item.Height
+			case fullblocktests.OrphanOrRejectedBlock:
+				block, height = item.Block, item.Height
+			default:
+				continue
+			}
+			blk := provautil.NewBlock(block)
+			blk.SetHeight(height)
+			blocks = append(blocks, blk)
+		}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		chain, teardown, err := chainSetup("benchfullblocks",
+			&chaincfg.RegressionNetParams)
+		if err != nil {
+			b.Fatalf("failed to setup chain instance: %v", err)
+		}
+		b.StartTimer()
+
+		// The rejected blocks are part of the workload, so the errors
+		// are not checked here.
+		for _, block := range blocks {
+			chain.ProcessBlock(block, blockchain.BFNone)
+		}
+
+		b.StopTimer()
+		teardown()
+		b.StartTimer()
+	}
+}
+
+// benchASPAdminTx returns a provision thread admin transaction with an output
+// adding or revoking an ASP key for each of the passed keys.
+func benchASPAdminTx(b *testing.B, op byte, keys []*btcec.PublicKey) *provautil.Tx {
+	threadPkScript, err := txscript.ProvaThreadScript(provautil.ProvisionThread)
+	if err != nil {
+		b.Fatalf("ProvaThreadScript: %v", err)
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil))
+	tx.AddTxOut(wire.NewTxOut(0, threadPkScript))
+	for i, key := range keys {
+		data := make([]byte, 1+btcec.PubKeyBytesLenCompressed+btcec.KeyIDSize)
+		data[0] = op
+		copy(data[1:], key.SerializeCompressed())
+		btcec.KeyID(i + 1).ToAddressFormat(data[1+btcec.PubKeyBytesLenCompressed:])
+		pkScript, err := txscript.NewScriptBuilder().
+			AddOp(txscript.OP_RETURN).AddData(data).Script()
+		if err != nil {
+			b.Fatalf("NewScriptBuilder: %v", err)
+		}
+		tx.AddTxOut(wire.NewTxOut(0, pkScript))
+	}
+	return provautil.NewTx(tx)
+}
+
+// BenchmarkProcessAdminOuts performs a benchmark on how long it takes to apply
+// the ops of admin transactions adding and then revoking ten ASP keys to a key
+// view.
+func BenchmarkProcessAdminOuts(b *testing.B) {
+	var keys []*btcec.PublicKey
+	for i := byte(1); i <= 10; i++ {
+		_, pubKey := btcec.PrivKeyFromBytes(btcec.S256(), []byte{i})
+		keys = append(keys, pubKey)
+	}
+	addTx := benchASPAdminTx(b, txscript.AdminOpASPKeyAdd, keys)
+	revokeTx := benchASPAdminTx(b, txscript.AdminOpASPKeyRevoke, keys)
+	view := blockchain.NewKeyViewpoint()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		view.ProcessAdminOuts(addTx, 1, &chaincfg.RegressionNetParams)
+		view.ProcessAdminOuts(revokeTx, 2, &chaincfg.RegressionNetParams)
+	}
+}
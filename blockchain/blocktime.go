@@ -0,0 +1,130 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// BlockTime describes the main chain block found for a time by BlockAtTime.
+type BlockTime struct {
+	Hash       chainhash.Hash
+	Height     uint32
+	Timestamp  time.Time
+	MedianTime time.Time
+}
+
+// headerSearch finds main chain blocks by their median time past.  It caches
+// the headers it fetches, since the median times of nearby heights share most
+// of them.
+type headerSearch struct {
+	fetch   func(height uint32) (*wire.BlockHeader, error)
+	headers map[uint32]*wire.BlockHeader
+}
+
+// header returns the main chain header at the passed height.
+func (s *headerSearch) header(height uint32) (*wire.BlockHeader, error) {
+	if header, ok := s.headers[height]; ok {
+		return header, nil
+	}
+	header, err := s.fetch(height)
+	if err != nil {
+		return nil, err
+	}
+	s.headers[height] = header
+	return header, nil
+}
+
+// medianTime returns the median time past of the main chain block at the
+// passed height, calculated the same way as calcPastMedianTime.
+func (s *headerSearch) medianTime(height uint32) (int64, error) {
+	timestamps := make([]int64, 0, medianTimeBlocks)
+	for i := 0; i < medianTimeBlocks && uint32(i) <= height; i++ {
+		header, err := s.header(height - uint32(i))
+		if err != nil {
+			return 0, err
+		}
+		timestamps = append(timestamps, header.Timestamp.Unix())
+	}
+	sort.Sort(timeSorter(timestamps))
+	return timestamps[len(timestamps)/2], nil
+}
+
+// blockAtTime returns the height of the last block up to the passed best
+// height whose median time past is not after the passed time, and whether
+// there is one.  The median time past never decreases along a chain, since
+// every block must have a timestamp after the median time past of its parent,
+// so the blocks are searched by bisection.
+func (s *headerSearch) blockAtTime(bestHeight uint32, t int64) (uint32, bool, error) {
+	medianTime, err := s.medianTime(0)
+	if err != nil || medianTime > t {
+		return 0, false, err
+	}
+
+	// The block at low is always at or before the time, and the block
+	// after high is always after it.
+	low, high := uint32(0), bestHeight
+	for low < high {
+		mid := low + (high-low+1)/2
+		medianTime, err := s.medianTime(mid)
+		if err != nil {
+			return 0, false, err
+		}
+		if medianTime <= t {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	return low, true, nil
+}
+
+// BlockAtTime returns the last block of the main chain whose median time past
+// is not after the passed time, or nil when the median time past of the
+// genesis block is after it.  Since the median time past of a block never
+// decreases along the chain, unlike its timestamp, every block up to the
+// returned one has a median time past at or before the time and every later
+// block one after it.  This maps a time to a block height deterministically.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) BlockAtTime(t time.Time) (*BlockTime, error) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	var blockTime *BlockTime
+	err := b.db.View(func(dbTx database.Tx) error {
+		s := &headerSearch{
+			fetch: func(height uint32) (*wire.BlockHeader, error) {
+				return dbFetchHeaderByHeight(dbTx, height)
+			},
+			headers: make(map[uint32]*wire.BlockHeader),
+		}
+		height, ok, err := s.blockAtTime(b.bestNode.height, t.Unix())
+		if err != nil || !ok {
+			return err
+		}
+		medianTime, err := s.medianTime(height)
+		if err != nil {
+			return err
+		}
+		header, err := s.header(height)
+		if err != nil {
+			return err
+		}
+		blockTime = &BlockTime{
+			Hash:       header.BlockHash(),
+			Height:     height,
+			Timestamp:  header.Timestamp,
+			MedianTime: time.Unix(medianTime, 0),
+		}
+		return nil
+	})
+	return blockTime, err
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// TestBlockAtTime ensures blocks are found by their median time past, which
+// never decreases even when the timestamps of the blocks do.
+func TestBlockAtTime(t *testing.T) {
+	// Every block is 10 seconds after its parent except the blocks at
+	// heights 20 and 21, which are timestamped before their parents.
+	var headers []*wire.BlockHeader
+	for height := uint32(0); height < 40; height++ {
+		timestamp := int64(1000 + 10*height)
+		if height == 20 || height == 21 {
+			timestamp -= 40
+		}
+		headers = append(headers, &wire.BlockHeader{
+			Height:    height,
+			Timestamp: time.Unix(timestamp, 0),
+		})
+	}
+	newSearch := func() *headerSearch {
+		return &headerSearch{
+			fetch: func(height uint32) (*wire.BlockHeader, error) {
+				if height >= uint32(len(headers)) {
+					return nil, fmt.Errorf("no block at height %d",
+						height)
+				}
+				return headers[height], nil
+			},
+			headers: make(map[uint32]*wire.BlockHeader),
+		}
+	}
+
+	// The median time past of each block must not decrease and must match
+	// the one calculated from the block nodes.
+	s := newSearch()
+	medianTimes := make([]int64, len(headers))
+	var parent *blockNode
+	chain := &BlockChain{chainParams: &chaincfg.RegressionNetParams}
+	for height := range headers {
+		node := newBlockNode(headers[height],
+			&chainhash.Hash{byte(height + 1)})
+		node.parent = parent
+		parent = node
+		want, err := chain.calcPastMedianTime(node)
+		if err != nil {
+			t.Fatalf("calcPastMedianTime: %v", err)
+		}
+		medianTime, err := s.medianTime(uint32(height))
+		if err != nil {
+			t.Fatalf("medianTime: %v", err)
+		}
+		if medianTime != want.Unix() {
+			t.Fatalf("height %d: got median time %d, want %d",
+				height, medianTime, want.Unix())
+		}
+		if height > 0 && medianTime < medianTimes[height-1] {
+			t.Fatalf("height %d: median time decreased", height)
+		}
+		medianTimes[height] = medianTime
+	}
+
+	tests := []struct {
+		time   int64
+		found  bool
+		height uint32
+	}{
+		{999, false, 0},
+		{1000, true, 0},
+		{medianTimes[25] - 1, true, 24},
+		{medianTimes[25], true, 25},
+		{medianTimes[30] + 5, true, 30},
+		{medianTimes[39], true, 39},
+		{medianTimes[39] + 1000, true, 39},
+	}
+	for _, test := range tests {
+		height, found, err := newSearch().blockAtTime(39, test.time)
+		if err != nil {
+			t.Fatalf("blockAtTime: %v", err)
+		}
+		if found != test.found || height != test.height {
+			t.Errorf("time %d: got height %d (found %v), want %d "+
+				"(found %v)", test.time, height, found,
+				test.height, test.found)
+		}
+	}
+
+	// Blocks sharing a median time resolve to the last of them.
+	for height := 1; height < len(headers); height++ {
+		if medianTimes[height] != medianTimes[height-1] {
+			continue
+		}
+		got, _, err := newSearch().blockAtTime(39, medianTimes[height])
+		if err != nil {
+			t.Fatalf("blockAtTime: %v", err)
+		}
+		last := uint32(height)
+		for last < 39 && medianTimes[last+1] == medianTimes[height] {
+			last++
+		}
+		if got != last {
+			t.Errorf("median time %d: got height %d, want %d",
+				medianTimes[height], got, last)
+		}
+	}
+}
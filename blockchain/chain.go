@@ -9,6 +9,7 @@ package blockchain
 import (
 	"container/list"
 	"fmt"
+	"github.com/btcsuite/golangcrypto/ripemd160"
 	"github.com/pyx-partners/dmgd/btcec"
 	"github.com/pyx-partners/dmgd/chaincfg"
 	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
@@ -96,6 +97,8 @@ func newBlockNode(blockHeader *wire.BlockHeader, blockHash *chainhash.Hash) *blo
 		nonce:            blockHeader.Nonce,
 		timestamp:        blockHeader.Timestamp.Unix(),
 		merkleRoot:       blockHeader.MerkleRoot,
+		size:             blockHeader.Size,
+		signature:        blockHeader.Signature,
 		validatingPubKey: blockHeader.ValidatingPubKey,
 	}
 	return &node
@@ -193,6 +196,7 @@ type BestState struct {
 	NumTxns    uint64          // The number of txns in the block.
 	TotalTxns  uint64          // The total number of txns in the chain.
 	MedianTime time.Time       // Median time as per CalcPastMedianTime.
+	WorkSum    *big.Int        // The total work of the chain.
 }
 
 // newBestState returns a new best stats instance for the given parameters.
@@ -206,6 +210,7 @@ func newBestState(node *blockNode, blockSize, numTxns, totalTxns uint64,
 		NumTxns:    numTxns,
 		TotalTxns:  totalTxns,
 		MedianTime: medianTime,
+		WorkSum:    new(big.Int).Set(node.workSum),
 	}
 }
 
@@ -226,6 +231,7 @@ type BlockChain struct {
 	sigCache            *txscript.SigCache
 	hashCache           *txscript.HashCache
 	indexManager        IndexManager
+	utxoCache           *utxoCache
 
 	// The following fields are calculated based upon the provided chain
 	// parameters.  They are also set when the instance is created and
@@ -255,6 +261,28 @@ type BlockChain struct {
 	index    map[chainhash.Hash]*blockNode
 	depNodes map[chainhash.Hash][]*blockNode
 
+	// These fields are related to rebuilding the chain state from the
+	// blocks stored in the database.  They are protected by the chain lock.
+	//
+	// reindexBlocks holds the hashes of the stored blocks which remain to
+	// be connected, in order, while a reindex is in progress.
+	// reindexHeight is the height of the last of them, and reindexIndexes
+	// is whether the optional indexes are rebuilt along with the chain
+	// state rather than caught up once the reindex is complete.
+	reindexBlocks  []chainhash.Hash
+	reindexHeight  uint32
+	reindexIndexes bool
+
+	// signedBlocks holds the hash of the first block accepted at each
+	// recent height for each validate key, to detect keys which sign two
+	// blocks at the same height.  It is protected by the chain lock.
+	signedBlocks map[uint32]map[wire.BlockValidatingPubKey]chainhash.Hash
+
+	// unknownRules is the unknown rules status of the main chain, which
+	// reports whether recent blocks signal consensus rules this version
+	// does not know.  It is protected by the chain lock.
+	unknownRules UnknownRulesStatus
+
 	// These fields are related to the admin state of the chain. They are
 	// protected by the chain lock.
 
@@ -270,6 +298,16 @@ type BlockChain struct {
 	adminKeySets map[btcec.KeySetType]btcec.PublicKeySet
 	// a mapping of all keyIDs and related ASP public keys.
 	aspKeyIdMap btcec.KeyIdMap
+	// destinations newly issued tokens may be paid to.
+	issueDests IssueDestSet
+	// pubKeyHashes of accounts which can not spend their outputs.
+	frozen FrozenSet
+	// supplies of the assets other than DMG.
+	assetSupplies AssetSupplies
+	// expiries of the keys provisioned with an expiry height.
+	keyExpiries KeyExpiries
+	// delegate keys and the scope of the authority granted to them.
+	delegates Delegates
 
 	// These fields are related to handling of orphan blocks.  They are
 	// protected by a combination of the chain lock and the orphan lock.
@@ -283,6 +321,11 @@ type BlockChain struct {
 	nextCheckpoint  *chaincfg.Checkpoint
 	checkpointBlock *provautil.Block
 
+	// These fields are related to the signed checkpoints.  The signed
+	// checkpoints are sorted by height and protected by the chain lock.
+	checkpointPubKeys []*btcec.PublicKey
+	signedCheckpoints []*SignedCheckpoint
+
 	// The state is used as a fairly efficient way to cache information
 	// about the current best chain state that is returned to callers when
 	// requested.  It operates on the principle of MVCC such that any time a
@@ -417,7 +460,7 @@ func (b *BlockChain) removeOrphanBlock(orphan *orphanBlock) {
 func (b *BlockChain) addOrphanBlock(block *provautil.Block) {
 	// Remove expired orphan blocks.
 	for _, oBlock := range b.orphans {
-		if time.Now().After(oBlock.expiration) {
+		if b.timeSource.AdjustedTime().After(oBlock.expiration) {
 			b.removeOrphanBlock(oBlock)
 			continue
 		}
@@ -444,7 +487,7 @@ func (b *BlockChain) addOrphanBlock(block *provautil.Block) {
 
 	// Insert the block into the orphan map with an expiration time
 	// 1 hour from now.
-	expiration := time.Now().Add(time.Hour)
+	expiration := b.timeSource.AdjustedTime().Add(time.Hour)
 	oBlock := &orphanBlock{
 		block:      block,
 		expiration: expiration,
@@ -968,6 +1011,12 @@ func (b *BlockChain) connectBlock(node *blockNode, block *provautil.Block, utxoV
 	blockSize := uint64(block.MsgBlock().SerializeSize())
 	state := newBestState(node, blockSize, numTxns, curTotalTxns+numTxns,
 		medianTime)
+
+	// The utxo changes are kept in the utxo cache unless it has grown past
+	// its memory budget or has not been flushed for a while, in which case
+	// it is flushed along with the block.
+	flushUtxos := b.utxoCache.needsFlush()
+
 	// Atomically insert info into the database.
 	err = b.db.Update(func(dbTx database.Tx) error {
 		// Update best block state.
@@ -983,12 +1032,14 @@ func (b *BlockChain) connectBlock(node *blockNode, block *provautil.Block, utxoV
 			return err
 		}
 
-		// Update the utxo set using the state of the utxo view.  This
-		// entails removing all of the utxos spent and adding the new
-		// ones created by the block.
-		err = dbPutUtxoView(dbTx, utxoView)
-		if err != nil {
-			return err
+		// Update the utxo set using the state of the utxo view when the
+		// utxo cache is flushed.  This entails removing all of the utxos
+		// spent and adding the new ones created by the block.
+		if flushUtxos {
+			err = b.utxoCache.dbFlush(dbTx, utxoView, block.Hash())
+			if err != nil {
+				return err
+			}
 		}
 
 		// Update the admin key set using the state of the key view.
@@ -998,6 +1049,36 @@ func (b *BlockChain) connectBlock(node *blockNode, block *provautil.Block, utxoV
 			return err
 		}
 
+		// Update the issuance destination allowlist as well.
+		err = dbPutIssueDests(dbTx, keyView.IssueDests())
+		if err != nil {
+			return err
+		}
+
+		// Update the frozen accounts as well.
+		err = dbPutFrozen(dbTx, keyView.Frozen())
+		if err != nil {
+			return err
+		}
+
+		// Update the asset supplies as well.
+		err = dbPutAssetSupplies(dbTx, keyView.AssetSupplies())
+		if err != nil {
+			return err
+		}
+
+		// Update the key expiries as well.
+		err = dbPutKeyExpiries(dbTx, keyView.KeyExpiries())
+		if err != nil {
+			return err
+		}
+
+		// Update the delegates as well.
+		err = dbPutDelegates(dbTx, keyView.Delegates())
+		if err != nil {
+			return err
+		}
+
 		// Update the transaction spend journal by adding a record for
 		// the block that contains all txos spent by it.
 		err = dbPutSpendJournalEntry(dbTx, block.Hash(), stxos)
@@ -1008,7 +1089,7 @@ func (b *BlockChain) connectBlock(node *blockNode, block *provautil.Block, utxoV
 		// Allow the index manager to call each of the currently active
 		// optional indexes with the block being connected so they can
 		// update themselves accordingly.
-		if b.indexManager != nil {
+		if b.indexManager != nil && b.indexesConnected() {
 			err := b.indexManager.ConnectBlock(dbTx, block, utxoView)
 			if err != nil {
 				return err
@@ -1021,6 +1102,14 @@ func (b *BlockChain) connectBlock(node *blockNode, block *provautil.Block, utxoV
 		return err
 	}
 
+	// Keep the utxo changes in the cache, or clear it when they were
+	// written to the database along with the block.
+	if flushUtxos {
+		b.utxoCache.flushed()
+	} else {
+		b.utxoCache.commit(utxoView)
+	}
+
 	// Prune fully spent entries and mark all entries in the view unmodified
 	// now that the modifications have been committed to the database.
 	utxoView.commit()
@@ -1041,6 +1130,11 @@ func (b *BlockChain) connectBlock(node *blockNode, block *provautil.Block, utxoV
 	b.lastKeyID = keyView.LastKeyID()
 	b.adminKeySets = keyView.Keys()
 	b.aspKeyIdMap = keyView.KeyIDs()
+	b.issueDests = keyView.IssueDests()
+	b.frozen = keyView.Frozen()
+	b.assetSupplies = keyView.AssetSupplies()
+	b.keyExpiries = keyView.KeyExpiries()
+	b.delegates = keyView.Delegates()
 	b.stateLock.Unlock()
 
 	// Update the state for the best block.  Notice how this replaces the
@@ -1054,10 +1148,18 @@ func (b *BlockChain) connectBlock(node *blockNode, block *provautil.Block, utxoV
 
 	// Notify the caller that the block was connected to the main chain.
 	// The caller would typically want to react with actions such as
-	// updating wallets.
-	b.chainLock.Unlock()
-	b.sendNotification(NTBlockConnected, block)
-	b.chainLock.Lock()
+	// updating wallets.  Blocks reconnected by a reindex were already
+	// announced when they were first connected.
+	unknownRulesChanged := b.updateUnknownRules()
+	if len(b.reindexBlocks) == 0 {
+		unknownRules := b.unknownRules
+		b.chainLock.Unlock()
+		b.sendNotification(NTBlockConnected, block)
+		if unknownRulesChanged {
+			b.sendNotification(NTUnknownRules, &unknownRules)
+		}
+		b.chainLock.Lock()
+	}
 
 	return nil
 }
@@ -1124,6 +1226,36 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *provautil.Block, ut
 			return err
 		}
 
+		// Update the issuance destination allowlist as well.
+		err = dbPutIssueDests(dbTx, keyView.IssueDests())
+		if err != nil {
+			return err
+		}
+
+		// Update the frozen accounts as well.
+		err = dbPutFrozen(dbTx, keyView.Frozen())
+		if err != nil {
+			return err
+		}
+
+		// Update the asset supplies as well.
+		err = dbPutAssetSupplies(dbTx, keyView.AssetSupplies())
+		if err != nil {
+			return err
+		}
+
+		// Update the key expiries as well.
+		err = dbPutKeyExpiries(dbTx, keyView.KeyExpiries())
+		if err != nil {
+			return err
+		}
+
+		// Update the delegates as well.
+		err = dbPutDelegates(dbTx, keyView.Delegates())
+		if err != nil {
+			return err
+		}
+
 		// Remove the block hash and height from the block index which
 		// tracks the main chain.
 		err = dbRemoveBlockIndex(dbTx, block.Hash(), node.height)
@@ -1133,8 +1265,10 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *provautil.Block, ut
 
 		// Update the utxo set using the state of the utxo view.  This
 		// entails restoring all of the utxos spent and removing the new
-		// ones created by the block.
-		err = dbPutUtxoView(dbTx, utxoView)
+		// ones created by the block.  The utxo cache is always flushed
+		// along with it, so the block the utxo set in the database is
+		// consistent with remains in the main chain.
+		err = b.utxoCache.dbFlush(dbTx, utxoView, prevNode.hash)
 		if err != nil {
 			return err
 		}
@@ -1149,7 +1283,7 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *provautil.Block, ut
 		// Allow the index manager to call each of the currently active
 		// optional indexes with the block being disconnected so they
 		// can update themselves accordingly.
-		if b.indexManager != nil {
+		if b.indexManager != nil && b.indexesConnected() {
 			err := b.indexManager.DisconnectBlock(dbTx, block, utxoView)
 			if err != nil {
 				return err
@@ -1161,6 +1295,7 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *provautil.Block, ut
 	if err != nil {
 		return err
 	}
+	b.utxoCache.flushed()
 
 	// Prune fully spent entries and mark all entries in the view unmodified
 	// now that the modifications have been committed to the database.
@@ -1184,8 +1319,13 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *provautil.Block, ut
 	// Notify the caller that the block was disconnected from the main
 	// chain.  The caller would typically want to react with actions such as
 	// updating wallets.
+	unknownRulesChanged := b.updateUnknownRules()
+	unknownRules := b.unknownRules
 	b.chainLock.Unlock()
 	b.sendNotification(NTBlockDisconnected, block)
+	if unknownRulesChanged {
+		b.sendNotification(NTUnknownRules, &unknownRules)
+	}
 	b.chainLock.Lock()
 
 	return nil
@@ -1239,6 +1379,11 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List, flags
 	keyView.SetTotalSupply(b.totalSupply)
 	keyView.SetKeys(b.adminKeySets)
 	keyView.SetKeyIDs(b.aspKeyIdMap)
+	keyView.SetIssueDests(b.issueDests)
+	keyView.SetFrozen(b.frozen)
+	keyView.SetAssetSupplies(b.assetSupplies)
+	keyView.SetKeyExpiries(b.keyExpiries)
+	keyView.SetDelegates(b.delegates)
 	for e := detachNodes.Front(); e != nil; e = e.Next() {
 		n := e.Value.(*blockNode)
 		var block *provautil.Block
@@ -1253,7 +1398,7 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List, flags
 
 		// Load all of the utxos referenced by the block that aren't
 		// already in the view.
-		err = utxoView.fetchInputUtxos(b.db, block)
+		err = utxoView.fetchInputUtxos(b.utxoCache, block)
 		if err != nil {
 			return err
 		}
@@ -1277,7 +1422,7 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List, flags
 		if err != nil {
 			return err
 		}
-		err = keyView.disconnectTransactions(block)
+		err = keyView.disconnectTransactions(block, b.chainParams)
 		if err != nil {
 			return err
 		}
@@ -1352,7 +1497,7 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List, flags
 
 		// Load all of the utxos referenced by the block that aren't
 		// already in the view.
-		err := utxoView.fetchInputUtxos(b.db, block)
+		err := utxoView.fetchInputUtxos(b.utxoCache, block)
 		if err != nil {
 			return err
 		}
@@ -1378,7 +1523,7 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List, flags
 
 		// Load all of the utxos referenced by the block that aren't
 		// already in the view.
-		err := utxoView.fetchInputUtxos(b.db, block)
+		err := utxoView.fetchInputUtxos(b.utxoCache, block)
 		if err != nil {
 			return err
 		}
@@ -1392,7 +1537,9 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List, flags
 		if err != nil {
 			return err
 		}
-		keyView.connectTransactions(block)
+		keyView.connectTransactions(block,
+			b.chainParams.MinValidateKeySetSize(),
+			b.chainParams)
 
 		// Update the database and chain state.
 		err = b.connectBlock(n, block, utxoView, keyView, stxos)
@@ -1414,6 +1561,29 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List, flags
 	log.Infof("REORGANIZE: Old best chain head was %v", firstDetachNode.hash)
 	log.Infof("REORGANIZE: New best chain head is %v", lastAttachNode.hash)
 
+	// Record the reorganization in the journal and notify the caller about
+	// it.  The chain is already reorganized at this point, so failing to
+	// record it is only logged.
+	if forkNode == nil {
+		return nil
+	}
+	event := newReorgEvent(b.timeSource.AdjustedTime(), forkNode,
+		detachBlocks, attachBlocks)
+	err = b.db.Update(func(dbTx database.Tx) error {
+		return dbPutReorgEvent(dbTx, event)
+	})
+	if err != nil {
+		log.Errorf("Unable to record reorganization to %v in the "+
+			"journal: %v", lastAttachNode.hash, err)
+	}
+
+	// Notify the caller that the main chain was reorganized.  The caller
+	// would typically want to react with actions such as notifying the
+	// clients which have to report settlement-affecting reorganizations.
+	b.chainLock.Unlock()
+	b.sendNotification(NTReorganization, event)
+	b.chainLock.Lock()
+
 	return nil
 }
 
@@ -1457,6 +1627,11 @@ func (b *BlockChain) connectBestChain(node *blockNode, block *provautil.Block, f
 		keyView.SetTotalSupply(b.totalSupply)
 		keyView.SetKeys(b.adminKeySets)
 		keyView.SetKeyIDs(b.aspKeyIdMap)
+		keyView.SetIssueDests(b.issueDests)
+		keyView.SetFrozen(b.frozen)
+		keyView.SetAssetSupplies(b.assetSupplies)
+		keyView.SetKeyExpiries(b.keyExpiries)
+		keyView.SetDelegates(b.delegates)
 		stxos := make([]spentTxOut, 0, countSpentOutputs(block))
 		if !fastAdd {
 			err := b.checkConnectBlock(node, block, utxoView, keyView, &stxos)
@@ -1475,7 +1650,7 @@ func (b *BlockChain) connectBestChain(node *blockNode, block *provautil.Block, f
 		// utxos, spend them, and add the new utxos being created by
 		// this block.
 		if fastAdd {
-			err := utxoView.fetchInputUtxos(b.db, block)
+			err := utxoView.fetchInputUtxos(b.utxoCache, block)
 			if err != nil {
 				return false, err
 			}
@@ -1483,7 +1658,9 @@ func (b *BlockChain) connectBestChain(node *blockNode, block *provautil.Block, f
 			if err != nil {
 				return false, err
 			}
-			keyView.connectTransactions(block)
+			keyView.connectTransactions(block,
+				b.chainParams.MinValidateKeySetSize(),
+				b.chainParams)
 		}
 
 		// Connect the block to the main chain.
@@ -1641,6 +1818,45 @@ func (b *BlockChain) TotalSupply() uint64 {
 	return totalSupply
 }
 
+// AssetSupplies returns the total spendable supply of atoms of each asset
+// other than DMG in the best chain.  As with TotalSupply, the supplies are not
+// consensus critical.
+// The returned instance must be treated as immutable since it is shared by all
+// callers.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) AssetSupplies() AssetSupplies {
+	b.stateLock.RLock()
+	assetSupplies := b.assetSupplies
+	b.stateLock.RUnlock()
+	return assetSupplies
+}
+
+// KeyExpiries returns the expiries of the keys provisioned with an expiry
+// height in the best chain, including those which were revoked or expired.
+// The returned instance must be treated as immutable since it is shared by all
+// callers.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) KeyExpiries() KeyExpiries {
+	b.stateLock.RLock()
+	keyExpiries := b.keyExpiries
+	b.stateLock.RUnlock()
+	return keyExpiries
+}
+
+// Delegates returns the delegate keys of the best chain together with the
+// scope of the authority granted to each of them.  The returned instance must
+// be treated as immutable since it is shared by all callers.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) Delegates() Delegates {
+	b.stateLock.RLock()
+	delegates := b.delegates
+	b.stateLock.RUnlock()
+	return delegates
+}
+
 // LastKeyID returns the number for the last added ASP Key ID in the best
 // chain.  ASP Key IDs are atomically increasing, this number can be used to
 // check the current number.  ASP Key IDs start from 1, so this number should
@@ -1679,13 +1895,55 @@ func (b *BlockChain) KeyIDs() btcec.KeyIdMap {
 	return aspKeyIdMap
 }
 
+// IssueDests returns the allowlist of destinations newly issued atoms may be
+// paid to in the best chain.  An empty allowlist places no restriction on
+// issuance destinations.
+// The returned instance must be treated as immutable since it is shared by all
+// callers.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) IssueDests() IssueDestSet {
+	b.stateLock.RLock()
+	issueDests := b.issueDests
+	b.stateLock.RUnlock()
+	return issueDests
+}
+
+// Frozen returns the pubKeyHashes of frozen accounts in the best chain.
+// The returned instance must be treated as immutable since it is shared by all
+// callers.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) Frozen() FrozenSet {
+	b.stateLock.RLock()
+	frozen := b.frozen
+	b.stateLock.RUnlock()
+	return frozen
+}
+
+// IsFrozen returns whether the account of the passed pubKeyHash is frozen in
+// the best chain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) IsFrozen(pkHash []byte) bool {
+	var key [ripemd160.Size]byte
+	copy(key[:], pkHash)
+	b.stateLock.RLock()
+	_, frozen := b.frozen[key]
+	b.stateLock.RUnlock()
+	return frozen
+}
+
 // IndexManager provides a generic interface that the is called when blocks are
 // connected and disconnected to and from the tip of the main chain for the
 // purpose of supporting optional indexes.
 type IndexManager interface {
 	// Init is invoked during chain initialize in order to allow the index
-	// manager to initialize itself and any indexes it is managing.
-	Init(*BlockChain) error
+	// manager to initialize itself and any indexes it is managing.  The
+	// channel parameter specifies a channel the caller can close to signal
+	// that the process should be interrupted.  It can be nil if that
+	// behavior is not desired.
+	Init(*BlockChain, <-chan struct{}) error
 
 	// ConnectBlock is invoked when a new block has been connected to the
 	// main chain.
@@ -1760,6 +2018,41 @@ type Config struct {
 	// This field can be nil if the caller does not wish to make use of an
 	// index manager.
 	IndexManager IndexManager
+
+	// Interrupt specifies a channel the caller can close to signal that
+	// long running operations during initialization, such as catching up
+	// the indexes, should be interrupted.
+	//
+	// This field can be nil if the caller does not desire the behavior.
+	Interrupt <-chan struct{}
+
+	// UtxoCacheMaxSize is the maximum number of bytes of memory the cache
+	// of unspent transaction outputs may use before it is flushed to the
+	// database.  Callers which set it should call FlushUtxoCache before
+	// closing the database.
+	//
+	// This field can be zero to flush the cache along with every block.
+	UtxoCacheMaxSize uint64
+
+	// Reindex discards the chain state and rebuilds it by reconnecting the
+	// blocks of the main chain which are already stored in the database.
+	// New only prepares the reindex, the blocks are reconnected with
+	// ReindexNextBlock.  An interrupted reindex resumes when the chain is
+	// loaded again, whether or not this field is set.
+	Reindex bool
+
+	// ReindexIndexes rebuilds the optional indexes along with the chain
+	// state when Reindex is set.  The caller must have dropped the indexes
+	// beforehand.  Otherwise the indexes are left untouched and caught up
+	// once the reindex is complete.
+	ReindexIndexes bool
+
+	// CheckpointPubKeys are the keys whose signed checkpoints are accepted
+	// by AddSignedCheckpoint and enforced.  Stored signed checkpoints of
+	// other keys are ignored.
+	//
+	// This field can be nil if no signed checkpoints are enforced.
+	CheckpointPubKeys []*btcec.PublicKey
 }
 
 // New returns a BlockChain instance using the provided configuration details.
@@ -1795,6 +2088,7 @@ func New(config *Config) (*BlockChain, error) {
 	b := BlockChain{
 		checkpoints:         config.Checkpoints,
 		checkpointsByHeight: checkpointsByHeight,
+		checkpointPubKeys:   config.CheckpointPubKeys,
 		db:                  config.DB,
 		chainParams:         config.ChainParams,
 		timeSource:          config.TimeSource,
@@ -1802,6 +2096,7 @@ func New(config *Config) (*BlockChain, error) {
 		sigCache:            config.SigCache,
 		hashCache:           config.HashCache,
 		indexManager:        config.IndexManager,
+		utxoCache:           newUtxoCache(config.DB, config.UtxoCacheMaxSize),
 		blocksPerRetarget:   int32(config.ChainParams.PowAveragingWindow),
 		minMemoryNodes:      int32(config.ChainParams.PowAveragingWindow),
 		bestNode:            nil,
@@ -1810,23 +2105,46 @@ func New(config *Config) (*BlockChain, error) {
 		totalSupply:         uint64(0),
 		adminKeySets:        make(map[btcec.KeySetType]btcec.PublicKeySet),
 		aspKeyIdMap:         make(map[btcec.KeyID]*btcec.PublicKey),
+		issueDests:          make(IssueDestSet),
+		frozen:              make(FrozenSet),
+		assetSupplies:       make(AssetSupplies),
+		keyExpiries:         make(KeyExpiries),
+		delegates:           make(Delegates),
 		index:               make(map[chainhash.Hash]*blockNode),
 		depNodes:            make(map[chainhash.Hash][]*blockNode),
+		signedBlocks:        make(map[uint32]map[wire.BlockValidatingPubKey]chainhash.Hash),
 		orphans:             make(map[chainhash.Hash]*orphanBlock),
 		prevOrphans:         make(map[chainhash.Hash][]*orphanBlock),
 	}
 
+	// Discard the chain state when a reindex is requested, so it is
+	// rebuilt from the blocks stored in the database.
+	if config.Reindex {
+		if err := b.resetChainState(config.ReindexIndexes); err != nil {
+			return nil, err
+		}
+	}
+
 	// Initialize the chain state from the passed database.  When the db
 	// does not yet contain any chain state, both it and the chain state
 	// will be initialized to contain only the genesis block.
 	if err := b.initChainState(); err != nil {
 		return nil, err
 	}
+	if err := b.loadReindexBlocks(); err != nil {
+		return nil, err
+	}
+	if err := b.loadSignedCheckpoints(); err != nil {
+		return nil, err
+	}
+	b.updateUnknownRules()
 
 	// Initialize and catch up all of the currently active optional indexes
-	// as needed.
-	if config.IndexManager != nil {
-		if err := config.IndexManager.Init(&b); err != nil {
+	// as needed.  Indexes which are not rebuilt along with the chain state
+	// are initialized once the reindex is complete.
+	if config.IndexManager != nil && b.indexesConnected() {
+		err := config.IndexManager.Init(&b, config.Interrupt)
+		if err != nil {
 			return nil, err
 		}
 	}
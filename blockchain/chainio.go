@@ -10,10 +10,13 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"github.com/btcsuite/golangcrypto/ripemd160"
 	"github.com/pyx-partners/dmgd/btcec"
 	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
 	"github.com/pyx-partners/dmgd/database"
 	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/txscript/adminop"
 	"github.com/pyx-partners/dmgd/wire"
 	"math/big"
 	"sort"
@@ -41,10 +44,38 @@ var (
 	// unspent transaction output set.
 	utxoSetBucketName = []byte("utxoset")
 
+	// utxoStateKeyName is the name of the db key used to store the hash of
+	// the block the utxo set is consistent with.
+	utxoStateKeyName = []byte("utxostate")
+
+	// reindexTargetKeyName is the name of the db key used to store the
+	// block a reindex rebuilds the chain state up to.
+	reindexTargetKeyName = []byte("reindextarget")
+
 	// keySetBucketName is the name of the db bucket used to house the
 	// admin key sets.
 	keySetBucketName = []byte("keyset")
 
+	// issueDestsKeyName is the name of the db key used to store the
+	// allowlist of issuance destinations.
+	issueDestsKeyName = []byte("issuedests")
+
+	// frozenKeyName is the name of the db key used to store the
+	// pubKeyHashes of frozen accounts.
+	frozenKeyName = []byte("frozen")
+
+	// assetSuppliesKeyName is the name of the db key used to store the
+	// supplies of assets other than DMG.
+	assetSuppliesKeyName = []byte("assetsupplies")
+
+	// keyExpiriesKeyName is the name of the db key used to store the
+	// expiries of keys provisioned with an expiry height.
+	keyExpiriesKeyName = []byte("keyexpiries")
+
+	// delegatesKeyName is the name of the db key used to store the
+	// delegations of the delegate keys.
+	delegatesKeyName = []byte("delegates")
+
 	// byteOrder is the preferred byte order used for serializing numeric
 	// fields for storage in the database.
 	byteOrder = binary.LittleEndian
@@ -116,7 +147,8 @@ func isDeserializeErr(err error) bool {
 //
 // The serialized header code format is:
 //   bit 0 - containing transaction is a coinbase
-//   bits 1-x - height of the block that contains the spent txout
+//   bits 1-32 - height of the block that contains the spent txout
+//   bits 33-x - asset ID of the spent txout, which is 0 for DMG
 //
 //   NOTE: The header code and version are only encoded when the spent txout was
 //   the final unspent output of the containing transaction.  Otherwise, the
@@ -182,6 +214,7 @@ type spentTxOut struct {
 	// the creating tx.
 	height     uint32 // Height of the the block containing the creating tx.
 	isCoinBase bool   // Whether creating tx is a coinbase.
+	assetID    uint32 // Asset of the creating tx.
 }
 
 // spentTxOutHeaderCode returns the calculated header code to be used when
@@ -193,9 +226,9 @@ func spentTxOutHeaderCode(stxo *spentTxOut) uint64 {
 	}
 
 	// As described in the serialization format comments, the header code
-	// encodes the height shifted over one bit and the coinbase flag in the
-	// lowest bit.
-	headerCode := uint64(stxo.height) << 1
+	// encodes the asset ID above the height, the height shifted over one
+	// bit and the coinbase flag in the lowest bit.
+	headerCode := uint64(stxo.assetID)<<33 | uint64(stxo.height)<<1
 	if stxo.isCoinBase {
 		headerCode |= 0x01
 	}
@@ -258,7 +291,8 @@ func decodeSpentTxOut(serialized []byte, stxo *spentTxOut, txVersion int32) (int
 	// version if needed.
 	//
 	// Bit 0 indicates containing transaction is a coinbase.
-	// Bits 1-x encode height of containing transaction.
+	// Bits 1-32 encode height of containing transaction.
+	// Bits 33-x encode asset ID of containing transaction.
 	if code != 0 {
 		version, bytesRead := deserializeVLQ(serialized[offset:])
 		offset += bytesRead
@@ -269,6 +303,7 @@ func decodeSpentTxOut(serialized []byte, stxo *spentTxOut, txVersion int32) (int
 
 		stxo.isCoinBase = code&0x01 != 0
 		stxo.height = uint32(code >> 1)
+		stxo.assetID = uint32(code >> 33)
 		stxo.version = int32(version)
 	} else {
 		// Ensure a tx version was specified if the stxo did not encode
@@ -473,6 +508,10 @@ func dbRemoveSpendJournalEntry(dbTx database.Tx, blockHash *chainhash.Hash) erro
 //     compressed amount  VLQ      variable
 //     compressed script  []byte   variable
 //
+// The serialized block height format is:
+//   bits 0-31 - height of the block that contains the transaction
+//   bits 32-x - asset ID of the transaction outputs, which is 0 for DMG
+//
 // The serialized header code format is:
 //   bit 0 - containing transaction is a coinbase
 //   bit 1 - output zero is unspent
@@ -623,9 +662,11 @@ func serializeUtxoEntry(entry *UtxoEntry) ([]byte, error) {
 		return nil, err
 	}
 
-	// Calculate the size needed to serialize the entry.
+	// Calculate the size needed to serialize the entry.  The asset ID is
+	// encoded above the block height.
+	heightCode := uint64(entry.assetID)<<32 | uint64(entry.blockHeight)
 	size := serializeSizeVLQ(uint64(entry.version)) +
-		serializeSizeVLQ(uint64(entry.blockHeight)) +
+		serializeSizeVLQ(heightCode) +
 		serializeSizeVLQ(headerCode) + numBitmapBytes
 	for _, outputIndex := range outputOrder {
 		out := entry.sparseOutputs[uint32(outputIndex)]
@@ -640,7 +681,7 @@ func serializeUtxoEntry(entry *UtxoEntry) ([]byte, error) {
 	// and header code.
 	serialized := make([]byte, size)
 	offset := putVLQ(serialized, uint64(entry.version))
-	offset += putVLQ(serialized[offset:], uint64(entry.blockHeight))
+	offset += putVLQ(serialized[offset:], heightCode)
 	offset += putVLQ(serialized[offset:], headerCode)
 
 	// Serialize the unspentness bitmap.
@@ -684,8 +725,8 @@ func deserializeUtxoEntry(serialized []byte) (*UtxoEntry, error) {
 		return nil, errDeserialize("unexpected end of data after version")
 	}
 
-	// Deserialize the block height.
-	blockHeight, bytesRead := deserializeVLQ(serialized[offset:])
+	// Deserialize the block height and asset ID.
+	heightCode, bytesRead := deserializeVLQ(serialized[offset:])
 	offset += bytesRead
 	if offset >= len(serialized) {
 		return nil, errDeserialize("unexpected end of data after height")
@@ -722,7 +763,8 @@ func deserializeUtxoEntry(serialized []byte) (*UtxoEntry, error) {
 
 	// Create a new utxo entry with the details deserialized above to house
 	// all of the utxos.
-	entry := newUtxoEntry(int32(version), isCoinBase, uint32(blockHeight))
+	entry := newUtxoEntry(int32(version), isCoinBase, uint32(heightCode),
+		uint32(heightCode>>32))
 
 	// Add sparse output for unspent outputs 0 and 1 as needed based on the
 	// details provided by the header code.
@@ -822,8 +864,15 @@ func dbFetchUtxoEntry(dbTx database.Tx, hash *chainhash.Hash) (*UtxoEntry, error
 // particular, only the entries that have been marked as modified are written
 // to the database.
 func dbPutUtxoView(dbTx database.Tx, view *UtxoViewpoint) error {
+	return dbPutUtxoEntries(dbTx, view.entries)
+}
+
+// dbPutUtxoEntries uses an existing database transaction to update the utxo set
+// in the database with the provided utxo entries which have been marked as
+// modified.
+func dbPutUtxoEntries(dbTx database.Tx, entries map[chainhash.Hash]*UtxoEntry) error {
 	utxoBucket := dbTx.Metadata().Bucket(utxoSetBucketName)
-	for txHashIter, entry := range view.entries {
+	for txHashIter, entry := range entries {
 		// No need to update the database if the entry was not modified.
 		if entry == nil || !entry.modified {
 			continue
@@ -861,6 +910,58 @@ func dbPutUtxoView(dbTx database.Tx, view *UtxoViewpoint) error {
 	return nil
 }
 
+// dbPutUtxoStateHash uses an existing database transaction to record the hash
+// of the block the utxo set in the database is consistent with.
+func dbPutUtxoStateHash(dbTx database.Tx, hash *chainhash.Hash) error {
+	return dbTx.Metadata().Put(utxoStateKeyName, hash[:])
+}
+
+// dbFetchUtxoStateHash uses an existing database transaction to fetch the hash
+// of the block the utxo set in the database is consistent with.  It returns nil
+// when the database does not record it.
+func dbFetchUtxoStateHash(dbTx database.Tx) *chainhash.Hash {
+	serialized := dbTx.Metadata().Get(utxoStateKeyName)
+	if len(serialized) != chainhash.HashSize {
+		return nil
+	}
+
+	var hash chainhash.Hash
+	copy(hash[:], serialized)
+	return &hash
+}
+
+// dbPutReindexTarget uses an existing database transaction to record the block
+// a reindex rebuilds the chain state up to, and whether the optional indexes are
+// rebuilt along with it.
+func dbPutReindexTarget(dbTx database.Tx, hash *chainhash.Hash, rebuildIndexes bool) error {
+	serialized := make([]byte, chainhash.HashSize+1)
+	copy(serialized, hash[:])
+	if rebuildIndexes {
+		serialized[chainhash.HashSize] = 1
+	}
+	return dbTx.Metadata().Put(reindexTargetKeyName, serialized)
+}
+
+// dbFetchReindexTarget uses an existing database transaction to fetch the block
+// a reindex rebuilds the chain state up to, and whether the optional indexes are
+// rebuilt along with it.  It returns a nil hash when no reindex is in progress.
+func dbFetchReindexTarget(dbTx database.Tx) (*chainhash.Hash, bool) {
+	serialized := dbTx.Metadata().Get(reindexTargetKeyName)
+	if len(serialized) != chainhash.HashSize+1 {
+		return nil, false
+	}
+
+	var hash chainhash.Hash
+	copy(hash[:], serialized)
+	return &hash, serialized[chainhash.HashSize] != 0
+}
+
+// dbRemoveReindexTarget uses an existing database transaction to record that
+// no reindex is in progress.
+func dbRemoveReindexTarget(dbTx database.Tx) error {
+	return dbTx.Metadata().Delete(reindexTargetKeyName)
+}
+
 // -----------------------------------------------------------------------------
 // The block index consists of two buckets with an entry for every block in the
 // main chain.  One bucket is for the hash to height mapping and the other is
@@ -1164,6 +1265,419 @@ func dbPutKeySet(dbTx database.Tx,
 	return dbTx.Metadata().Put(keySetBucketName, serializedData)
 }
 
+// -----------------------------------------------------------------------------
+// The issuance destination allowlist consists of the destinations newly issued
+// atoms may be paid to.  A missing entry is treated as an empty allowlist.
+//
+// The serialized format is:
+//
+//   <count><dest1><dest2>...<destN>
+//
+//   Field                 Type        Size
+//   count                 uint32      4 bytes
+//   destinations          []byte      count * 28
+//
+// The destinations are serialized in ascending byte order.
+// -----------------------------------------------------------------------------
+
+// serializeIssueDests returns the serialization of the passed issuance
+// destination allowlist.
+func serializeIssueDests(issueDests IssueDestSet) []byte {
+	dests := make([][]byte, 0, len(issueDests))
+	for dest := range issueDests {
+		destCopy := dest
+		dests = append(dests, destCopy[:])
+	}
+	sort.Slice(dests, func(i, j int) bool {
+		return bytes.Compare(dests[i], dests[j]) < 0
+	})
+
+	serializedData := make([]byte, 4+len(dests)*txscript.IssueDestLen)
+	byteOrder.PutUint32(serializedData, uint32(len(dests)))
+	offset := 4
+	for _, dest := range dests {
+		copy(serializedData[offset:], dest)
+		offset += txscript.IssueDestLen
+	}
+	return serializedData
+}
+
+// deserializeIssueDests deserializes the passed serialized issuance
+// destination allowlist.
+func deserializeIssueDests(serializedData []byte) (IssueDestSet, error) {
+	if len(serializedData) < 4 {
+		return nil, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt issuance destinations, no count can be read",
+		}
+	}
+	count := byteOrder.Uint32(serializedData[:4])
+	if uint32(len(serializedData[4:])) != count*txscript.IssueDestLen {
+		return nil, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt issuance destinations, unexpected length",
+		}
+	}
+	issueDests := make(IssueDestSet, count)
+	offset := 4
+	for i := uint32(0); i < count; i++ {
+		var dest [txscript.IssueDestLen]byte
+		copy(dest[:], serializedData[offset:])
+		offset += txscript.IssueDestLen
+		issueDests[dest] = struct{}{}
+	}
+	return issueDests, nil
+}
+
+// dbPutIssueDests uses an existing database transaction to update the
+// issuance destination allowlist.
+func dbPutIssueDests(dbTx database.Tx, issueDests IssueDestSet) error {
+	serializedData := serializeIssueDests(issueDests)
+	return dbTx.Metadata().Put(issueDestsKeyName, serializedData)
+}
+
+// -----------------------------------------------------------------------------
+// The frozen accounts consist of the pubKeyHashes of all accounts frozen via
+// the provision thread.  A missing entry is treated as no frozen accounts.
+//
+// The serialized format is:
+//
+//   <count><pkHash1><pkHash2>...<pkHashN>
+//
+//   Field                 Type        Size
+//   count                 uint32      4 bytes
+//   pubKeyHashes          []byte      count * 20
+//
+// The pubKeyHashes are serialized in ascending byte order.
+// -----------------------------------------------------------------------------
+
+// serializeFrozen returns the serialization of the passed frozen accounts.
+func serializeFrozen(frozen FrozenSet) []byte {
+	pkHashes := make([][]byte, 0, len(frozen))
+	for pkHash := range frozen {
+		pkHashCopy := pkHash
+		pkHashes = append(pkHashes, pkHashCopy[:])
+	}
+	sort.Slice(pkHashes, func(i, j int) bool {
+		return bytes.Compare(pkHashes[i], pkHashes[j]) < 0
+	})
+
+	serializedData := make([]byte, 4+len(pkHashes)*ripemd160.Size)
+	byteOrder.PutUint32(serializedData, uint32(len(pkHashes)))
+	offset := 4
+	for _, pkHash := range pkHashes {
+		copy(serializedData[offset:], pkHash)
+		offset += ripemd160.Size
+	}
+	return serializedData
+}
+
+// deserializeFrozen deserializes the passed serialized frozen accounts.
+func deserializeFrozen(serializedData []byte) (FrozenSet, error) {
+	if len(serializedData) < 4 {
+		return nil, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt frozen accounts, no count can be read",
+		}
+	}
+	count := byteOrder.Uint32(serializedData[:4])
+	if uint32(len(serializedData[4:])) != count*ripemd160.Size {
+		return nil, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt frozen accounts, unexpected length",
+		}
+	}
+	frozen := make(FrozenSet, count)
+	offset := 4
+	for i := uint32(0); i < count; i++ {
+		var pkHash [ripemd160.Size]byte
+		copy(pkHash[:], serializedData[offset:])
+		offset += ripemd160.Size
+		frozen[pkHash] = struct{}{}
+	}
+	return frozen, nil
+}
+
+// dbPutFrozen uses an existing database transaction to update the frozen
+// accounts.
+func dbPutFrozen(dbTx database.Tx, frozen FrozenSet) error {
+	serializedData := serializeFrozen(frozen)
+	return dbTx.Metadata().Put(frozenKeyName, serializedData)
+}
+
+// -----------------------------------------------------------------------------
+// The asset supplies consist of the total supply of each asset other than DMG
+// which has any.  The supply of DMG is stored with the admin key sets.  A
+// missing entry is treated as no supplies.
+//
+// The serialized format is:
+//
+//   <count>[<asset id><supply>,...]
+//
+//   Field                 Type        Size
+//   count                 uint32      4 bytes
+//   asset id              uint32      4 bytes
+//   supply                uint64      8 bytes
+//
+// The supplies are serialized in ascending order of asset ID.
+// -----------------------------------------------------------------------------
+
+// assetSupplySize is the number of bytes of a serialized asset supply.
+const assetSupplySize = 12
+
+// serializeAssetSupplies returns the serialization of the passed asset
+// supplies.
+func serializeAssetSupplies(supplies AssetSupplies) []byte {
+	assetIDs := make([]uint32, 0, len(supplies))
+	for assetID := range supplies {
+		assetIDs = append(assetIDs, assetID)
+	}
+	sort.Slice(assetIDs, func(i, j int) bool {
+		return assetIDs[i] < assetIDs[j]
+	})
+
+	serializedData := make([]byte, 4+len(assetIDs)*assetSupplySize)
+	byteOrder.PutUint32(serializedData, uint32(len(assetIDs)))
+	offset := 4
+	for _, assetID := range assetIDs {
+		byteOrder.PutUint32(serializedData[offset:], assetID)
+		byteOrder.PutUint64(serializedData[offset+4:], supplies[assetID])
+		offset += assetSupplySize
+	}
+	return serializedData
+}
+
+// deserializeAssetSupplies deserializes the passed serialized asset supplies.
+func deserializeAssetSupplies(serializedData []byte) (AssetSupplies, error) {
+	if len(serializedData) < 4 {
+		return nil, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt asset supplies, no count can be read",
+		}
+	}
+	count := byteOrder.Uint32(serializedData[:4])
+	if uint64(len(serializedData[4:])) != uint64(count)*assetSupplySize {
+		return nil, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt asset supplies, unexpected length",
+		}
+	}
+	supplies := make(AssetSupplies, count)
+	offset := 4
+	for i := uint32(0); i < count; i++ {
+		assetID := byteOrder.Uint32(serializedData[offset:])
+		supplies[assetID] = byteOrder.Uint64(serializedData[offset+4:])
+		offset += assetSupplySize
+	}
+	return supplies, nil
+}
+
+// dbPutAssetSupplies uses an existing database transaction to update the
+// supplies of assets other than DMG.
+func dbPutAssetSupplies(dbTx database.Tx, supplies AssetSupplies) error {
+	serializedData := serializeAssetSupplies(supplies)
+	return dbTx.Metadata().Put(assetSuppliesKeyName, serializedData)
+}
+
+// -----------------------------------------------------------------------------
+// The key expiries consist of the expiries of all ASP and validate keys
+// provisioned with an expiry height, keyed by the outpoint of the admin op
+// which provisioned them.  A missing entry is treated as no key expiries.
+//
+// The serialized format is:
+//
+//   <count>[<hash><index><key set><pubkey><key id><add height><height>
+//   <revoked height><expired height>,...]
+//
+//   Field                 Type             Size
+//   count                 uint32           4 bytes
+//   hash                  chainhash.Hash   chainhash.HashSize
+//   index                 uint32           4 bytes
+//   key set               byte             1 byte
+//   pubkey                []byte           33 bytes
+//   key id                uint32           4 bytes
+//   add height            uint32           4 bytes
+//   height                uint32           4 bytes
+//   revoked height        uint32           4 bytes
+//   expired height        uint32           4 bytes
+//
+// The expiries are serialized in ascending order of their outpoint.
+// -----------------------------------------------------------------------------
+
+// keyExpirySize is the number of bytes of a serialized key expiry.
+const keyExpirySize = chainhash.HashSize + 4 + 1 +
+	btcec.PubKeyBytesLenCompressed + 4*5
+
+// serializeKeyExpiries returns the serialization of the passed key expiries.
+func serializeKeyExpiries(expiries KeyExpiries) []byte {
+	outPoints := make([]wire.OutPoint, 0, len(expiries))
+	for outPoint := range expiries {
+		outPoints = append(outPoints, outPoint)
+	}
+	sort.Slice(outPoints, func(i, j int) bool {
+		return outPointLess(&outPoints[i], &outPoints[j])
+	})
+
+	serializedData := make([]byte, 4+len(outPoints)*keyExpirySize)
+	byteOrder.PutUint32(serializedData, uint32(len(outPoints)))
+	offset := 4
+	for _, outPoint := range outPoints {
+		expiry := expiries[outPoint]
+		offset += copy(serializedData[offset:], outPoint.Hash[:])
+		byteOrder.PutUint32(serializedData[offset:], outPoint.Index)
+		serializedData[offset+4] = byte(expiry.KeySet)
+		offset += 5
+		offset += copy(serializedData[offset:], expiry.PubKey[:])
+		for _, field := range []uint32{uint32(expiry.KeyID),
+			expiry.AddHeight, expiry.Height, expiry.RevokedHeight,
+			expiry.ExpiredHeight} {
+
+			byteOrder.PutUint32(serializedData[offset:], field)
+			offset += 4
+		}
+	}
+	return serializedData
+}
+
+// deserializeKeyExpiries deserializes the passed serialized key expiries.
+func deserializeKeyExpiries(serializedData []byte) (KeyExpiries, error) {
+	if len(serializedData) < 4 {
+		return nil, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt key expiries, no count can be read",
+		}
+	}
+	count := byteOrder.Uint32(serializedData[:4])
+	if uint64(len(serializedData[4:])) != uint64(count)*keyExpirySize {
+		return nil, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt key expiries, unexpected length",
+		}
+	}
+	expiries := make(KeyExpiries, count)
+	offset := 4
+	for i := uint32(0); i < count; i++ {
+		var outPoint wire.OutPoint
+		var expiry KeyExpiry
+		offset += copy(outPoint.Hash[:], serializedData[offset:])
+		outPoint.Index = byteOrder.Uint32(serializedData[offset:])
+		expiry.KeySet = btcec.KeySetType(serializedData[offset+4])
+		offset += 5
+		offset += copy(expiry.PubKey[:], serializedData[offset:])
+		expiry.KeyID = btcec.KeyID(byteOrder.Uint32(serializedData[offset:]))
+		offset += 4
+		for _, field := range []*uint32{&expiry.AddHeight,
+			&expiry.Height, &expiry.RevokedHeight,
+			&expiry.ExpiredHeight} {
+
+			*field = byteOrder.Uint32(serializedData[offset:])
+			offset += 4
+		}
+		expiries[outPoint] = expiry
+	}
+	return expiries, nil
+}
+
+// dbPutKeyExpiries uses an existing database transaction to update the
+// expiries of keys provisioned with an expiry height.
+func dbPutKeyExpiries(dbTx database.Tx, expiries KeyExpiries) error {
+	serializedData := serializeKeyExpiries(expiries)
+	return dbTx.Metadata().Put(keyExpiriesKeyName, serializedData)
+}
+
+// -----------------------------------------------------------------------------
+// The delegates consist of the delegate keys granted a scope of provision
+// thread ops by DelegateKeyAdd ops, along with their delegations.  A missing
+// entry is treated as no delegates.
+//
+// The serialized format is:
+//
+//   <count><delegate1><delegate2>...<delegateN>
+//
+//   Field                 Type             Size
+//   count                 uint32           4 bytes
+//   delegates             []delegate       count * 42 bytes
+//
+// The serialized format of a delegate is:
+//
+//   Field                 Type             Size
+//   pubKey                []byte           33 bytes
+//   scope                 byte             1 byte
+//   min keyID             uint32           4 bytes
+//   max keyID             uint32           4 bytes
+//
+// The delegates are serialized in ascending byte order of their pubKey.
+// -----------------------------------------------------------------------------
+
+// delegateSize is the number of bytes of a serialized delegate.
+const delegateSize = btcec.PubKeyBytesLenCompressed + 1 + 4*2
+
+// serializeDelegates returns the serialization of the passed delegates.
+func serializeDelegates(delegates Delegates) []byte {
+	pubKeys := make([][btcec.PubKeyBytesLenCompressed]byte, 0,
+		len(delegates))
+	for pubKey := range delegates {
+		pubKeys = append(pubKeys, pubKey)
+	}
+	sort.Slice(pubKeys, func(i, j int) bool {
+		return bytes.Compare(pubKeys[i][:], pubKeys[j][:]) < 0
+	})
+
+	serializedData := make([]byte, 4+len(pubKeys)*delegateSize)
+	byteOrder.PutUint32(serializedData, uint32(len(pubKeys)))
+	offset := 4
+	for _, pubKey := range pubKeys {
+		delegation := delegates[pubKey]
+		offset += copy(serializedData[offset:], pubKey[:])
+		serializedData[offset] = byte(delegation.Scope)
+		byteOrder.PutUint32(serializedData[offset+1:],
+			uint32(delegation.MinKeyID))
+		byteOrder.PutUint32(serializedData[offset+5:],
+			uint32(delegation.MaxKeyID))
+		offset += 9
+	}
+	return serializedData
+}
+
+// deserializeDelegates deserializes the passed serialized delegates.
+func deserializeDelegates(serializedData []byte) (Delegates, error) {
+	if len(serializedData) < 4 {
+		return nil, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt delegates, no count can be read",
+		}
+	}
+	count := byteOrder.Uint32(serializedData[:4])
+	if uint64(len(serializedData[4:])) != uint64(count)*delegateSize {
+		return nil, database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt delegates, unexpected length",
+		}
+	}
+	delegates := make(Delegates, count)
+	offset := 4
+	for i := uint32(0); i < count; i++ {
+		var pubKey [btcec.PubKeyBytesLenCompressed]byte
+		offset += copy(pubKey[:], serializedData[offset:])
+		delegates[pubKey] = Delegation{
+			Scope: adminop.Scope(serializedData[offset]),
+			MinKeyID: btcec.KeyID(byteOrder.Uint32(
+				serializedData[offset+1:])),
+			MaxKeyID: btcec.KeyID(byteOrder.Uint32(
+				serializedData[offset+5:])),
+		}
+		offset += 9
+	}
+	return delegates, nil
+}
+
+// dbPutDelegates uses an existing database transaction to update the
+// delegations of the delegate keys.
+func dbPutDelegates(dbTx database.Tx, delegates Delegates) error {
+	serializedData := serializeDelegates(delegates)
+	return dbTx.Metadata().Put(delegatesKeyName, serializedData)
+}
+
 // -----------------------------------------------------------------------------
 // The best chain state consists of the best block hash and height, the total
 // number of transactions up to and including those in the best block, and the
@@ -1289,8 +1803,13 @@ func (b *BlockChain) createChainState() error {
 	b.stateSnapshot = newBestState(b.bestNode, blockSize, numTxns, numTxns,
 		time.Unix(b.bestNode.timestamp, 0))
 
-	b.adminKeySets = b.chainParams.AdminKeySets
-	b.aspKeyIdMap = b.chainParams.ASPKeyIdMap
+	// Initiate the admin state with the admin keys of the chain parameters
+	// and the admin thread tips from the genesis coinbase.
+	keyView := b.genesisKeyViewpoint()
+	b.adminKeySets = keyView.Keys()
+	b.aspKeyIdMap = keyView.KeyIDs()
+	b.threadTips = keyView.ThreadTips()
+	b.lastKeyID = keyView.LastKeyID()
 
 	// Initiate the utxo set with the admin thread tips from the genesis
 	// coinbase.
@@ -1302,20 +1821,6 @@ func (b *BlockChain) createChainState() error {
 	var stxos *[]spentTxOut
 	utxoView.connectTransaction(genesisBlock.Transactions()[0], 0, stxos)
 
-	// Initiate admin thread tips
-	b.threadTips[provautil.RootThread] = wire.NewOutPoint(genesisBlock.Transactions()[0].Hash(), 0)
-	b.threadTips[provautil.ProvisionThread] = wire.NewOutPoint(genesisBlock.Transactions()[0].Hash(), 1)
-	b.threadTips[provautil.IssueThread] = wire.NewOutPoint(genesisBlock.Transactions()[0].Hash(), 2)
-
-	// Set the last key id to the highest key id in the wsp key map.
-	var lastKeyID btcec.KeyID
-	for keyID := range b.aspKeyIdMap {
-		if keyID > lastKeyID {
-			lastKeyID = keyID
-		}
-	}
-	b.lastKeyID = btcec.KeyID(lastKeyID)
-
 	// Create the initial the database chain state including creating the
 	// necessary index buckets and inserting the genesis block.
 	err := b.db.Update(func(dbTx database.Tx) error {
@@ -1350,6 +1855,10 @@ func (b *BlockChain) createChainState() error {
 		if err != nil {
 			return err
 		}
+		err = dbPutUtxoStateHash(dbTx, b.bestNode.hash)
+		if err != nil {
+			return err
+		}
 
 		// Add the genesis block hash to height and height to hash
 		// mappings to the index.
@@ -1370,8 +1879,39 @@ func (b *BlockChain) createChainState() error {
 			return err
 		}
 
-		// Store the genesis block into the database.
-		return dbTx.StoreBlock(genesisBlock)
+		// Store the empty issuance destination allowlist in the database.
+		err = dbPutIssueDests(dbTx, b.issueDests)
+		if err != nil {
+			return err
+		}
+
+		// Store the empty set of frozen accounts in the database.
+		err = dbPutFrozen(dbTx, b.frozen)
+		if err != nil {
+			return err
+		}
+
+		// Store the empty asset supplies in the database.
+		err = dbPutAssetSupplies(dbTx, b.assetSupplies)
+		if err != nil {
+			return err
+		}
+
+		// Store the empty key expiries in the database.
+		err = dbPutKeyExpiries(dbTx, b.keyExpiries)
+		if err != nil {
+			return err
+		}
+
+		// Store the empty delegates in the database.
+		err = dbPutDelegates(dbTx, b.delegates)
+		if err != nil {
+			return err
+		}
+
+		// Store the genesis block into the database.  It is already
+		// there when the chain state is rebuilt by a reindex.
+		return dbMaybeStoreBlock(dbTx, genesisBlock)
 	})
 	return err
 }
@@ -1409,6 +1949,64 @@ func (b *BlockChain) initChainState() error {
 			return err
 		}
 
+		// Fetch the issuance destination allowlist from the database.
+		// Databases created before the allowlist existed have none
+		// stored, which is the same as an empty allowlist.
+		issueDests := make(IssueDestSet)
+		serializedDests := dbTx.Metadata().Get(issueDestsKeyName)
+		if serializedDests != nil {
+			issueDests, err = deserializeIssueDests(serializedDests)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Fetch the frozen accounts from the database.  Databases
+		// created before accounts could be frozen have none stored.
+		frozen := make(FrozenSet)
+		serializedFrozen := dbTx.Metadata().Get(frozenKeyName)
+		if serializedFrozen != nil {
+			frozen, err = deserializeFrozen(serializedFrozen)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Fetch the asset supplies from the database.  Databases
+		// created before multiple assets existed have none stored.
+		assetSupplies := make(AssetSupplies)
+		serializedSupplies := dbTx.Metadata().Get(assetSuppliesKeyName)
+		if serializedSupplies != nil {
+			assetSupplies, err = deserializeAssetSupplies(
+				serializedSupplies)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Fetch the key expiries from the database.  Databases
+		// created before keys could expire have none stored.
+		keyExpiries := make(KeyExpiries)
+		serializedExpiries := dbTx.Metadata().Get(keyExpiriesKeyName)
+		if serializedExpiries != nil {
+			keyExpiries, err = deserializeKeyExpiries(
+				serializedExpiries)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Fetch the delegates from the database.  Databases created
+		// before keys could be delegated have none stored.
+		delegates := make(Delegates)
+		serializedDelegates := dbTx.Metadata().Get(delegatesKeyName)
+		if serializedDelegates != nil {
+			delegates, err = deserializeDelegates(serializedDelegates)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Load the raw block bytes for the best block.
 		blockBytes, err := dbTx.FetchBlock(&state.hash)
 		if err != nil {
@@ -1435,6 +2033,11 @@ func (b *BlockChain) initChainState() error {
 		b.totalSupply = totalSupply
 		b.adminKeySets = adminKeySets
 		b.aspKeyIdMap = aspKeyIdMap
+		b.issueDests = issueDests
+		b.frozen = frozen
+		b.assetSupplies = assetSupplies
+		b.keyExpiries = keyExpiries
+		b.delegates = delegates
 
 		// Add the new node to the indices for faster lookups.
 		prevHash := node.parentHash
@@ -1460,9 +2063,10 @@ func (b *BlockChain) initChainState() error {
 		return err
 	}
 
-	// There is nothing more to do if the chain state was initialized.
+	// The utxo set might need to be brought up to date with the chain
+	// state when the utxo cache was not flushed before the process exited.
 	if isStateInitialized {
-		return nil
+		return b.recoverUtxoState()
 	}
 
 	// At this point the database has not already been initialized, so
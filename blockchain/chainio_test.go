@@ -8,11 +8,13 @@ package blockchain
 
 import (
 	"bytes"
+	"encoding/hex"
 	"errors"
 	"github.com/pyx-partners/dmgd/btcec"
 	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
 	"github.com/pyx-partners/dmgd/database"
 	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript/adminop"
 	"github.com/pyx-partners/dmgd/wire"
 	"math/big"
 	"reflect"
@@ -89,6 +91,18 @@ func TestStxoSerialization(t *testing.T) {
 			},
 			serialized: hexToBytes("8b99700186c64700b2fb57eadf61e106a100a7445a8c3f67898841ec"),
 		},
+		{
+			name: "Spends last output of asset",
+			stxo: spentTxOut{
+				amount:     13761000000,
+				pkScript:   hexToBytes("76a914b2fb57eadf61e106a100a7445a8c3f67898841ec88ac"),
+				isCoinBase: false,
+				height:     100024,
+				assetID:    5,
+				version:    1,
+			},
+			serialized: hexToBytes("809eff8b99700186c64700b2fb57eadf61e106a100a7445a8c3f67898841ec"),
+		},
 		// Adapted from block 100025 in main blockchain.
 		{
 			name: "Does not spend last output",
@@ -557,6 +571,22 @@ func TestUtxoSerialization(t *testing.T) {
 			},
 			serialized: hexToBytes("01858c21040700ee8bd501094a7d5ca318da2506de35e1cb025ddc"),
 		},
+		{
+			name: "Only output 1, asset",
+			entry: &UtxoEntry{
+				version:     1,
+				isCoinBase:  false,
+				blockHeight: 100001,
+				assetID:     5,
+				sparseOutputs: map[uint32]*utxoOutput{
+					1: {
+						amount:   1000000,
+						pkScript: hexToBytes("76a914ee8bd501094a7d5ca318da2506de35e1cb025ddc88ac"),
+					},
+				},
+			},
+			serialized: hexToBytes("01ceff858c21040700ee8bd501094a7d5ca318da2506de35e1cb025ddc"),
+		},
 		// Adapted from tx in main blockchain:
 		// df3f3f442d9699857f7f49de4ff0b5d0f3448bec31cdc7b5bf6d25f2abd637d5
 		{
@@ -757,6 +787,12 @@ func TestUtxoSerialization(t *testing.T) {
 				test.entry.BlockHeight())
 			continue
 		}
+		if utxoEntry.AssetID() != test.entry.AssetID() {
+			t.Errorf("deserializeUtxoEntry #%d (%s) mismatched "+
+				"asset ID: got %d, want %d", i, test.name,
+				utxoEntry.AssetID(), test.entry.AssetID())
+			continue
+		}
 		if utxoEntry.IsFullySpent() != test.entry.IsFullySpent() {
 			t.Errorf("deserializeUtxoEntry #%d (%s) mismatched "+
 				"fully spent: got %v, want %v", i, test.name,
@@ -818,6 +854,34 @@ func TestUtxoSerialization(t *testing.T) {
 	}
 }
 
+// benchUtxoEntry is a serialized unspent transaction output entry with two
+// outputs, adapted from a tx in the main blockchain.
+var benchUtxoEntry = hexToBytes("0185f90b0a011200e2ccd6ec7c6e2e581349c77e067385fa8236bf8a800900b8025be1b3efc63b0ad48e7f9f10e87544528d58")
+
+// BenchmarkSerializeUtxoEntry performs a benchmark on how long it takes to
+// serialize an unspent transaction output entry.
+func BenchmarkSerializeUtxoEntry(b *testing.B) {
+	entry, err := deserializeUtxoEntry(benchUtxoEntry)
+	if err != nil {
+		b.Fatalf("deserializeUtxoEntry: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		serializeUtxoEntry(entry)
+	}
+}
+
+// BenchmarkDeserializeUtxoEntry performs a benchmark on how long it takes to
+// deserialize an unspent transaction output entry.
+func BenchmarkDeserializeUtxoEntry(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		deserializeUtxoEntry(benchUtxoEntry)
+	}
+}
+
 // TestUtxoEntryHeaderCodeErrors performs negative tests against unspent
 // transaction output header codes to ensure error paths work as expected.
 func TestUtxoEntryHeaderCodeErrors(t *testing.T) {
@@ -1101,6 +1165,224 @@ func TestKeySetSerialization(t *testing.T) {
 	}
 }
 
+// TestIssueDestsSerialization ensures serializing and deserializing the
+// issuance destination allowlist works as expected.
+func TestIssueDestsSerialization(t *testing.T) {
+	t.Parallel()
+
+	var dest1, dest2 [28]byte
+	dest1[0] = 0x01
+	dest2[0] = 0x02
+	tests := []struct {
+		name       string
+		issueDests IssueDestSet
+		serialized []byte
+	}{
+		{
+			name:       "empty",
+			issueDests: IssueDestSet{},
+			serialized: hexToBytes("00000000"),
+		},
+		{
+			name: "two destinations",
+			issueDests: IssueDestSet{
+				dest2: struct{}{},
+				dest1: struct{}{},
+			},
+			serialized: append(append(hexToBytes("02000000"),
+				dest1[:]...), dest2[:]...),
+		},
+	}
+
+	for i, test := range tests {
+		gotBytes := serializeIssueDests(test.issueDests)
+		if !bytes.Equal(gotBytes, test.serialized) {
+			t.Errorf("serializeIssueDests #%d (%s): mismatched "+
+				"bytes - got %x, want %x", i, test.name,
+				gotBytes, test.serialized)
+			continue
+		}
+
+		issueDests, err := deserializeIssueDests(test.serialized)
+		if err != nil {
+			t.Errorf("deserializeIssueDests #%d (%s) unexpected "+
+				"error: %v", i, test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(issueDests, test.issueDests) {
+			t.Errorf("deserializeIssueDests #%d (%s) mismatched "+
+				"state - got %v, want %v", i, test.name,
+				issueDests, test.issueDests)
+			continue
+		}
+	}
+
+	// Ensure truncated data is detected as corruption.
+	_, err := deserializeIssueDests(hexToBytes("0100000000"))
+	if derr, ok := err.(database.Error); !ok ||
+		derr.ErrorCode != database.ErrCorruption {
+		t.Errorf("deserializeIssueDests: expected corruption error "+
+			"for truncated data, got %v", err)
+	}
+}
+
+// TestFrozenSerialization ensures serializing and deserializing the frozen
+// accounts works as expected.
+func TestFrozenSerialization(t *testing.T) {
+	t.Parallel()
+
+	var pkHash1, pkHash2 [20]byte
+	pkHash1[19] = 0x01
+	pkHash2[0] = 0x01
+	frozen := FrozenSet{pkHash2: struct{}{}, pkHash1: struct{}{}}
+	serialized := append(append(hexToBytes("02000000"),
+		pkHash1[:]...), pkHash2[:]...)
+
+	gotBytes := serializeFrozen(frozen)
+	if !bytes.Equal(gotBytes, serialized) {
+		t.Fatalf("serializeFrozen: mismatched bytes - got %x, want %x",
+			gotBytes, serialized)
+	}
+	gotFrozen, err := deserializeFrozen(serialized)
+	if err != nil {
+		t.Fatalf("deserializeFrozen: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(gotFrozen, frozen) {
+		t.Fatalf("deserializeFrozen: mismatched state - got %v, want %v",
+			gotFrozen, frozen)
+	}
+
+	// Ensure truncated data is detected as corruption.
+	_, err = deserializeFrozen(serialized[:len(serialized)-1])
+	if derr, ok := err.(database.Error); !ok ||
+		derr.ErrorCode != database.ErrCorruption {
+		t.Errorf("deserializeFrozen: expected corruption error "+
+			"for truncated data, got %v", err)
+	}
+}
+
+// TestAssetSuppliesSerialization ensures serializing and deserializing the
+// asset supplies works as expected.
+func TestAssetSuppliesSerialization(t *testing.T) {
+	t.Parallel()
+
+	supplies := AssetSupplies{7: 1000, 2: 0x0102030405}
+	serialized := hexToBytes("02000000" + "02000000" + "0504030201000000" +
+		"07000000" + "e803000000000000")
+
+	gotBytes := serializeAssetSupplies(supplies)
+	if !bytes.Equal(gotBytes, serialized) {
+		t.Fatalf("serializeAssetSupplies: mismatched bytes - got %x, "+
+			"want %x", gotBytes, serialized)
+	}
+	gotSupplies, err := deserializeAssetSupplies(serialized)
+	if err != nil {
+		t.Fatalf("deserializeAssetSupplies: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(gotSupplies, supplies) {
+		t.Fatalf("deserializeAssetSupplies: mismatched state - got %v, "+
+			"want %v", gotSupplies, supplies)
+	}
+
+	// Ensure truncated data is detected as corruption.
+	_, err = deserializeAssetSupplies(serialized[:len(serialized)-1])
+	if derr, ok := err.(database.Error); !ok ||
+		derr.ErrorCode != database.ErrCorruption {
+		t.Errorf("deserializeAssetSupplies: expected corruption error "+
+			"for truncated data, got %v", err)
+	}
+}
+
+// TestKeyExpiriesSerialization ensures serializing and deserializing the key
+// expiries works as expected.
+func TestKeyExpiriesSerialization(t *testing.T) {
+	t.Parallel()
+
+	pubKey := hexToBytes("025ceeba2ab4a635df2c0301a3d773da06ac5a18a7c3e0d09a795d7e57d233edf1")
+	expiry := KeyExpiry{
+		KeySet:        btcec.ASPKeySet,
+		KeyID:         3,
+		AddHeight:     10,
+		Height:        1000,
+		RevokedHeight: 20,
+	}
+	copy(expiry.PubKey[:], pubKey)
+	outPoint1 := wire.OutPoint{Hash: chainhash.Hash{0x01}, Index: 2}
+	outPoint2 := wire.OutPoint{Hash: chainhash.Hash{0x01}, Index: 1}
+	expiries := KeyExpiries{outPoint1: expiry, outPoint2: expiry}
+	serializedExpiry := "04" + hex.EncodeToString(pubKey) + "03000000" +
+		"0a000000" + "e8030000" + "14000000" + "00000000"
+	serialized := hexToBytes("02000000" +
+		hex.EncodeToString(outPoint2.Hash[:]) + "01000000" +
+		serializedExpiry +
+		hex.EncodeToString(outPoint1.Hash[:]) + "02000000" +
+		serializedExpiry)
+
+	gotBytes := serializeKeyExpiries(expiries)
+	if !bytes.Equal(gotBytes, serialized) {
+		t.Fatalf("serializeKeyExpiries: mismatched bytes - got %x, "+
+			"want %x", gotBytes, serialized)
+	}
+	gotExpiries, err := deserializeKeyExpiries(serialized)
+	if err != nil {
+		t.Fatalf("deserializeKeyExpiries: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(gotExpiries, expiries) {
+		t.Fatalf("deserializeKeyExpiries: mismatched state - got %v, "+
+			"want %v", gotExpiries, expiries)
+	}
+
+	// Ensure truncated data is detected as corruption.
+	_, err = deserializeKeyExpiries(serialized[:len(serialized)-1])
+	if derr, ok := err.(database.Error); !ok ||
+		derr.ErrorCode != database.ErrCorruption {
+		t.Errorf("deserializeKeyExpiries: expected corruption error "+
+			"for truncated data, got %v", err)
+	}
+}
+
+// TestDelegatesSerialization ensures serializing and deserializing the
+// delegates works as expected.
+func TestDelegatesSerialization(t *testing.T) {
+	t.Parallel()
+
+	pubKey1 := hexToBytes("025ceeba2ab4a635df2c0301a3d773da06ac5a18a7c3e0d09a795d7e57d233edf1")
+	pubKey2 := hexToBytes("038ef4a121bcaf1b1f175557a12896f8bc93b095e84817f90e9a901cd2113a8202")
+	var key1, key2 [btcec.PubKeyBytesLenCompressed]byte
+	copy(key1[:], pubKey1)
+	copy(key2[:], pubKey2)
+	delegates := Delegates{
+		key2: {Scope: adminop.ScopeAccountFreeze},
+		key1: {Scope: adminop.ScopeASPKeyAdd | adminop.ScopeASPKeyRevoke,
+			MinKeyID: 5, MaxKeyID: 9},
+	}
+	serialized := hexToBytes("02000000" +
+		hex.EncodeToString(pubKey1) + "03" + "05000000" + "09000000" +
+		hex.EncodeToString(pubKey2) + "04" + "00000000" + "00000000")
+
+	gotBytes := serializeDelegates(delegates)
+	if !bytes.Equal(gotBytes, serialized) {
+		t.Fatalf("serializeDelegates: mismatched bytes - got %x, "+
+			"want %x", gotBytes, serialized)
+	}
+	gotDelegates, err := deserializeDelegates(serialized)
+	if err != nil {
+		t.Fatalf("deserializeDelegates: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(gotDelegates, delegates) {
+		t.Fatalf("deserializeDelegates: mismatched state - got %v, "+
+			"want %v", gotDelegates, delegates)
+	}
+
+	// Ensure truncated data is detected as corruption.
+	_, err = deserializeDelegates(serialized[:len(serialized)-1])
+	if derr, ok := err.(database.Error); !ok ||
+		derr.ErrorCode != database.ErrCorruption {
+		t.Errorf("deserializeDelegates: expected corruption error "+
+			"for truncated data, got %v", err)
+	}
+}
+
 // TestBestChainStateDeserializeErrors performs negative tests against
 // deserializing the chain state to ensure error paths work as expected.
 func TestBestChainStateDeserializeErrors(t *testing.T) {
@@ -0,0 +1,198 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// BlockWork describes the work of a block and the cumulative work of the chain
+// up to and including it, along with the validate key which signed it.
+type BlockWork struct {
+	Hash             chainhash.Hash
+	Height           uint32
+	Work             *big.Int
+	WorkSum          *big.Int
+	ValidatingPubKey wire.BlockValidatingPubKey
+	MainChain        bool
+}
+
+// ChainTip describes the tip of a branch of the block chain.  The work of the
+// blocks of a side chain after its fork from the main chain, and the number of
+// distinct validate keys which signed them, are reported along with those of
+// the main chain blocks after the fork.  This allows monitoring to detect a
+// minority branch catching up with the main chain before it causes a
+// reorganization.  The branch of the main chain tip is empty.
+type ChainTip struct {
+	Hash             chainhash.Hash
+	Height           uint32
+	WorkSum          *big.Int
+	MainChain        bool
+	ForkHeight       uint32
+	BranchLen        uint32
+	BranchWork       *big.Int
+	BranchValidators uint32
+	MainWork         *big.Int
+	MainValidators   uint32
+}
+
+// mainChainWorkAfter returns the work of the main chain blocks after the
+// passed height and the distinct validate keys which signed them.  The blocks
+// which are not in memory are read from the database without loading them.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) mainChainWorkAfter(height uint32) (*big.Int, map[wire.BlockValidatingPubKey]struct{}, error) {
+	work := new(big.Int)
+	validators := make(map[wire.BlockValidatingPubKey]struct{})
+
+	h := b.bestNode.height
+	for node := b.bestNode; node != nil && h > height; node = node.parent {
+		work.Add(work, CalcWork(node.bits))
+		validators[node.validatingPubKey] = struct{}{}
+		h--
+	}
+	if h <= height {
+		return work, validators, nil
+	}
+
+	err := b.db.View(func(dbTx database.Tx) error {
+		for ; h > height; h-- {
+			header, err := dbFetchHeaderByHeight(dbTx, h)
+			if err != nil {
+				return err
+			}
+			work.Add(work, CalcWork(header.Bits))
+			validators[header.ValidatingPubKey] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return work, validators, nil
+}
+
+// BlockWork returns the work of the block with the passed hash and the
+// cumulative work of the chain up to and including it.  The block must either
+// be in the main chain or in a side chain known to the chain instance.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) BlockWork(hash *chainhash.Hash) (*BlockWork, error) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	if node, ok := b.index[*hash]; ok {
+		return &BlockWork{
+			Hash:             *node.hash,
+			Height:           node.height,
+			Work:             CalcWork(node.bits),
+			WorkSum:          new(big.Int).Set(node.workSum),
+			ValidatingPubKey: node.validatingPubKey,
+			MainChain:        node.inMainChain,
+		}, nil
+	}
+
+	// The main chain blocks which are not in memory derive the work of
+	// the chain up to them from the work of the best chain.
+	var header *wire.BlockHeader
+	err := b.db.View(func(dbTx database.Tx) error {
+		if !dbMainChainHasBlock(dbTx, hash) {
+			str := fmt.Sprintf("block %s is not in the main chain",
+				hash)
+			return errNotInMainChain(str)
+		}
+		var err error
+		header, err = dbFetchHeaderByHash(dbTx, hash)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	work, _, err := b.mainChainWorkAfter(header.Height)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockWork{
+		Hash:             *hash,
+		Height:           header.Height,
+		Work:             CalcWork(header.Bits),
+		WorkSum:          work.Sub(b.bestNode.workSum, work),
+		ValidatingPubKey: header.ValidatingPubKey,
+		MainChain:        true,
+	}, nil
+}
+
+// ChainTips returns the tip of the main chain and the tips of the side chains
+// known to the chain instance, ordered by descending height.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ChainTips() ([]*ChainTip, error) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	tips := []*ChainTip{{
+		Hash:       *b.bestNode.hash,
+		Height:     b.bestNode.height,
+		WorkSum:    new(big.Int).Set(b.bestNode.workSum),
+		MainChain:  true,
+		ForkHeight: b.bestNode.height,
+		BranchWork: new(big.Int),
+		MainWork:   new(big.Int),
+	}}
+	for _, node := range b.index {
+		if node.inMainChain || len(node.children) != 0 {
+			continue
+		}
+
+		// Walk the side chain back to the main chain block it forks
+		// from.
+		branchWork := new(big.Int)
+		validators := make(map[wire.BlockValidatingPubKey]struct{})
+		fork := node
+		for ; fork != nil && !fork.inMainChain; fork = fork.parent {
+			branchWork.Add(branchWork, CalcWork(fork.bits))
+			validators[fork.validatingPubKey] = struct{}{}
+		}
+		if fork == nil {
+			str := fmt.Sprintf("side chain block %v does not fork "+
+				"from the main chain", node.hash)
+			return nil, AssertError(str)
+		}
+
+		mainWork, mainValidators, err := b.mainChainWorkAfter(fork.height)
+		if err != nil {
+			return nil, err
+		}
+		tips = append(tips, &ChainTip{
+			Hash:             *node.hash,
+			Height:           node.height,
+			WorkSum:          new(big.Int).Set(node.workSum),
+			ForkHeight:       fork.height,
+			BranchLen:        node.height - fork.height,
+			BranchWork:       branchWork,
+			BranchValidators: uint32(len(validators)),
+			MainWork:         mainWork,
+			MainValidators:   uint32(len(mainValidators)),
+		})
+	}
+
+	sort.Slice(tips, func(i, j int) bool {
+		if tips[i].Height != tips[j].Height {
+			return tips[i].Height > tips[j].Height
+		}
+		if tips[i].MainChain != tips[j].MainChain {
+			return tips[i].MainChain
+		}
+		return bytes.Compare(tips[i].Hash[:], tips[j].Hash[:]) < 0
+	})
+	return tips, nil
+}
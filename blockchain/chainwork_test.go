@@ -0,0 +1,104 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// TestChainTips ensures the tips of the side chains report the work of and
+// the validate keys which signed the blocks of their branch and of the main
+// chain after their fork, and the work of a block is reported along with the
+// work of the chain up to it.
+func TestChainTips(t *testing.T) {
+	const bits = 0x207fffff
+	blockWork := CalcWork(bits)
+	chain := &BlockChain{index: make(map[chainhash.Hash]*blockNode)}
+	var nextHash byte
+	addNode := func(parent *blockNode, key byte, mainChain bool) *blockNode {
+		nextHash++
+		header := &wire.BlockHeader{Bits: bits}
+		header.ValidatingPubKey[0] = key
+		if parent != nil {
+			header.PrevBlock = *parent.hash
+			header.Height = parent.height + 1
+		}
+		node := newBlockNode(header, &chainhash.Hash{nextHash})
+		node.inMainChain = mainChain
+		if parent != nil {
+			node.parent = parent
+			node.workSum.Add(parent.workSum, node.workSum)
+			parent.children = append(parent.children, node)
+		}
+		chain.index[*node.hash] = node
+		return node
+	}
+
+	// The main chain is signed by keys 1 and 2 after height 1, where a
+	// side chain signed by key 3 forks off.  Another side chain forks off
+	// the main chain at height 2.
+	genesis := addNode(nil, 1, true)
+	main1 := addNode(genesis, 1, true)
+	main2 := addNode(main1, 1, true)
+	main3 := addNode(main2, 2, true)
+	side2 := addNode(main1, 3, false)
+	side3 := addNode(side2, 3, false)
+	other3 := addNode(main2, 3, false)
+	chain.bestNode = main3
+
+	tips, err := chain.ChainTips()
+	if err != nil {
+		t.Fatalf("ChainTips: %v", err)
+	}
+	work := func(blocks int64) *big.Int {
+		return new(big.Int).Mul(blockWork, big.NewInt(blocks))
+	}
+	want := []ChainTip{
+		{Hash: *main3.hash, Height: 3, WorkSum: work(4), MainChain: true,
+			ForkHeight: 3, BranchWork: work(0), MainWork: work(0)},
+		{Hash: *side3.hash, Height: 3, WorkSum: work(4), ForkHeight: 1,
+			BranchLen: 2, BranchWork: work(2), BranchValidators: 1,
+			MainWork: work(2), MainValidators: 2},
+		{Hash: *other3.hash, Height: 3, WorkSum: work(4), ForkHeight: 2,
+			BranchLen: 1, BranchWork: work(1), BranchValidators: 1,
+			MainWork: work(1), MainValidators: 1},
+	}
+	if len(tips) != len(want) {
+		t.Fatalf("got %d chain tips, want %d", len(tips), len(want))
+	}
+	for i, tip := range tips {
+		w := want[i]
+		if tip.Hash != w.Hash || tip.Height != w.Height ||
+			tip.WorkSum.Cmp(w.WorkSum) != 0 ||
+			tip.MainChain != w.MainChain ||
+			tip.ForkHeight != w.ForkHeight ||
+			tip.BranchLen != w.BranchLen ||
+			tip.BranchWork.Cmp(w.BranchWork) != 0 ||
+			tip.BranchValidators != w.BranchValidators ||
+			tip.MainWork.Cmp(w.MainWork) != 0 ||
+			tip.MainValidators != w.MainValidators {
+
+			t.Errorf("got chain tip #%d %+v, want %+v", i, tip, w)
+		}
+	}
+
+	for _, node := range []*blockNode{main2, side2} {
+		got, err := chain.BlockWork(node.hash)
+		if err != nil {
+			t.Fatalf("BlockWork: %v", err)
+		}
+		if got.Height != 2 || got.Work.Cmp(blockWork) != 0 ||
+			got.WorkSum.Cmp(work(3)) != 0 ||
+			got.ValidatingPubKey != node.validatingPubKey ||
+			got.MainChain != node.inMainChain {
+
+			t.Errorf("got block work %+v for block %v", got, node.hash)
+		}
+	}
+}
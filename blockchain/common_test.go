@@ -114,13 +114,16 @@ func chainSetup(dbName string, params *chaincfg.Params) (*blockchain.BlockChain,
 	// the chain parameters do not affect the global instance.
 	paramsCopy := *params
 
-	// Create the main chain instance.
+	// Create the main chain instance.  The utxo cache is given a budget
+	// which lets it hold the changes of several blocks, so the tests
+	// exercise both keeping them in memory and flushing them.
 	chain, err := blockchain.New(&blockchain.Config{
-		DB:          db,
-		ChainParams: &paramsCopy,
-		Checkpoints: nil,
-		TimeSource:  blockchain.NewMedianTime(),
-		SigCache:    txscript.NewSigCache(1000),
+		DB:               db,
+		ChainParams:      &paramsCopy,
+		Checkpoints:      nil,
+		TimeSource:       blockchain.NewMedianTime(),
+		SigCache:         txscript.NewSigCache(1000),
+		UtxoCacheMaxSize: 1 << 20,
 	})
 	if err != nil {
 		teardown()
@@ -220,56 +220,114 @@ const (
 	// ErrFeeTooHigh indicates a transaction fee exceeds the limit for
 	// fee paid.
 	ErrFeeTooHigh
+
+	// ErrSupplyCapExceeded indicates an issuance would push the total
+	// supply past the maximum supply allowed by the network.
+	ErrSupplyCapExceeded
+
+	// ErrEpochIssuanceExceeded indicates the amount issued within an
+	// issuance epoch exceeds the maximum allowed by the network.
+	ErrEpochIssuanceExceeded
+
+	// ErrIssueDestNotAllowed indicates an issuance pays to a destination
+	// which is not in the issuance destination allowlist.
+	ErrIssueDestNotAllowed
+
+	// ErrFrozenOutput indicates a transaction spends an output paying to
+	// the pubKeyHash of a frozen account.
+	ErrFrozenOutput
+
+	// ErrInvalidAssetMarker indicates a transaction carries an asset
+	// marker output which is not allowed.
+	ErrInvalidAssetMarker
+
+	// ErrAssetMismatch indicates a transaction spends outputs of an asset
+	// other than the one its outputs belong to, or pays a fee in an asset
+	// other than DMG.
+	ErrAssetMismatch
+
+	// ErrBadCoinbasePayee indicates a coinbase output pays a value to a
+	// script other than the coinbase script of the network.
+	ErrBadCoinbasePayee
+
+	// ErrUntrustedCheckpoint indicates a signed checkpoint is not validly
+	// signed by one of the trusted checkpoint keys.
+	ErrUntrustedCheckpoint
+
+	// ErrUnknownRules indicates a block has a block version newer than
+	// the latest known one while the consensus rules signaled by such
+	// versions are enforced, so the block can not be fully validated.
+	ErrUnknownRules
+
+	// ErrDelegationScope indicates an admin transaction signed by a
+	// delegate key carries an admin op which is not delegated to the key.
+	ErrDelegationScope
+
+	// ErrIssueChangeNotAllowed indicates an issue thread transaction
+	// destroying tokens pays change to a script other than the ones of the
+	// outputs it destroys and the allowlisted issuance destinations.
+	ErrIssueChangeNotAllowed
 )
 
 // Map of ErrorCode values back to their constant names for pretty printing.
 var errorCodeStrings = map[ErrorCode]string{
-	ErrDuplicateBlock:       "ErrDuplicateBlock",
-	ErrBlockTooBig:          "ErrBlockTooBig",
-	ErrBlockVersionTooOld:   "ErrBlockVersionTooOld",
-	ErrInvalidTime:          "ErrInvalidTime",
-	ErrTimeTooOld:           "ErrTimeTooOld",
-	ErrTimeTooNew:           "ErrTimeTooNew",
-	ErrDifficultyTooLow:     "ErrDifficultyTooLow",
-	ErrUnexpectedDifficulty: "ErrUnexpectedDifficulty",
-	ErrBadHeight:            "ErrBadHeight",
-	ErrBadBlockSignature:    "ErrBadBlockSignature",
-	ErrHighHash:             "ErrHighHash",
-	ErrBadMerkleRoot:        "ErrBadMerkleRoot",
-	ErrBadCheckpoint:        "ErrBadCheckpoint",
-	ErrForkTooOld:           "ErrForkTooOld",
-	ErrCheckpointTimeTooOld: "ErrCheckpointTimeTooOld",
-	ErrNoTransactions:       "ErrNoTransactions",
-	ErrTooManyTransactions:  "ErrTooManyTransactions",
-	ErrNoTxInputs:           "ErrNoTxInputs",
-	ErrNoTxOutputs:          "ErrNoTxOutputs",
-	ErrTxTooBig:             "ErrTxTooBig",
-	ErrBadTxOutValue:        "ErrBadTxOutValue",
-	ErrDuplicateTxInputs:    "ErrDuplicateTxInputs",
-	ErrBadTxInput:           "ErrBadTxInput",
-	ErrMissingTx:            "ErrMissingTx",
-	ErrUnfinalizedTx:        "ErrUnfinalizedTx",
-	ErrDuplicateTx:          "ErrDuplicateTx",
-	ErrOverwriteTx:          "ErrOverwriteTx",
-	ErrImmatureSpend:        "ErrImmatureSpend",
-	ErrDoubleSpend:          "ErrDoubleSpend",
-	ErrSpendTooHigh:         "ErrSpendTooHigh",
-	ErrBadFees:              "ErrBadFees",
-	ErrTooManySigOps:        "ErrTooManySigOps",
-	ErrFirstTxNotCoinbase:   "ErrFirstTxNotCoinbase",
-	ErrMultipleCoinbases:    "ErrMultipleCoinbases",
-	ErrBadCoinbaseScriptLen: "ErrBadCoinbaseScriptLen",
-	ErrBadCoinbaseValue:     "ErrBadCoinbaseValue",
-	ErrScriptMalformed:      "ErrScriptMalformed",
-	ErrScriptValidation:     "ErrScriptValidation",
-	ErrExcessiveChainShare:  "ErrExcessiveChainShare",
-	ErrInconsistentBlkSize:  "ErrInconsistentBlkSize",
-	ErrInvalidCoinbase:      "ErrInvalidCoinbase",
-	ErrInvalidTx:            "ErrInvalidTx",
-	ErrInvalidValidateKey:   "ErrInvalidValidateKey",
-	ErrInvalidAdminTx:       "ErrInvalidAdminTx",
-	ErrInvalidAdminOp:       "ErrInvalidAdminOp",
-	ErrFeeTooHigh:           "ErrFeeTooHigh",
+	ErrDuplicateBlock:        "ErrDuplicateBlock",
+	ErrBlockTooBig:           "ErrBlockTooBig",
+	ErrBlockVersionTooOld:    "ErrBlockVersionTooOld",
+	ErrInvalidTime:           "ErrInvalidTime",
+	ErrTimeTooOld:            "ErrTimeTooOld",
+	ErrTimeTooNew:            "ErrTimeTooNew",
+	ErrDifficultyTooLow:      "ErrDifficultyTooLow",
+	ErrUnexpectedDifficulty:  "ErrUnexpectedDifficulty",
+	ErrBadHeight:             "ErrBadHeight",
+	ErrBadBlockSignature:     "ErrBadBlockSignature",
+	ErrHighHash:              "ErrHighHash",
+	ErrBadMerkleRoot:         "ErrBadMerkleRoot",
+	ErrBadCheckpoint:         "ErrBadCheckpoint",
+	ErrForkTooOld:            "ErrForkTooOld",
+	ErrCheckpointTimeTooOld:  "ErrCheckpointTimeTooOld",
+	ErrNoTransactions:        "ErrNoTransactions",
+	ErrTooManyTransactions:   "ErrTooManyTransactions",
+	ErrNoTxInputs:            "ErrNoTxInputs",
+	ErrNoTxOutputs:           "ErrNoTxOutputs",
+	ErrTxTooBig:              "ErrTxTooBig",
+	ErrBadTxOutValue:         "ErrBadTxOutValue",
+	ErrDuplicateTxInputs:     "ErrDuplicateTxInputs",
+	ErrBadTxInput:            "ErrBadTxInput",
+	ErrMissingTx:             "ErrMissingTx",
+	ErrUnfinalizedTx:         "ErrUnfinalizedTx",
+	ErrDuplicateTx:           "ErrDuplicateTx",
+	ErrOverwriteTx:           "ErrOverwriteTx",
+	ErrImmatureSpend:         "ErrImmatureSpend",
+	ErrDoubleSpend:           "ErrDoubleSpend",
+	ErrSpendTooHigh:          "ErrSpendTooHigh",
+	ErrBadFees:               "ErrBadFees",
+	ErrTooManySigOps:         "ErrTooManySigOps",
+	ErrFirstTxNotCoinbase:    "ErrFirstTxNotCoinbase",
+	ErrMultipleCoinbases:     "ErrMultipleCoinbases",
+	ErrBadCoinbaseScriptLen:  "ErrBadCoinbaseScriptLen",
+	ErrBadCoinbaseValue:      "ErrBadCoinbaseValue",
+	ErrScriptMalformed:       "ErrScriptMalformed",
+	ErrScriptValidation:      "ErrScriptValidation",
+	ErrExcessiveChainShare:   "ErrExcessiveChainShare",
+	ErrInconsistentBlkSize:   "ErrInconsistentBlkSize",
+	ErrInvalidCoinbase:       "ErrInvalidCoinbase",
+	ErrInvalidTx:             "ErrInvalidTx",
+	ErrInvalidValidateKey:    "ErrInvalidValidateKey",
+	ErrInvalidAdminTx:        "ErrInvalidAdminTx",
+	ErrInvalidAdminOp:        "ErrInvalidAdminOp",
+	ErrFeeTooHigh:            "ErrFeeTooHigh",
+	ErrSupplyCapExceeded:     "ErrSupplyCapExceeded",
+	ErrEpochIssuanceExceeded: "ErrEpochIssuanceExceeded",
+	ErrIssueDestNotAllowed:   "ErrIssueDestNotAllowed",
+	ErrFrozenOutput:          "ErrFrozenOutput",
+	ErrInvalidAssetMarker:    "ErrInvalidAssetMarker",
+	ErrAssetMismatch:         "ErrAssetMismatch",
+	ErrBadCoinbasePayee:      "ErrBadCoinbasePayee",
+	ErrUntrustedCheckpoint:   "ErrUntrustedCheckpoint",
+	ErrUnknownRules:          "ErrUnknownRules",
+	ErrDelegationScope:       "ErrDelegationScope",
+	ErrIssueChangeNotAllowed: "ErrIssueChangeNotAllowed",
 }
 
 // String returns the ErrorCode as a human-readable name.
@@ -61,6 +61,17 @@ func TestErrorCodeStringer(t *testing.T) {
 		{blockchain.ErrInconsistentBlkSize, "ErrInconsistentBlkSize"},
 		{blockchain.ErrInvalidValidateKey, "ErrInvalidValidateKey"},
 		{blockchain.ErrFeeTooHigh, "ErrFeeTooHigh"},
+		{blockchain.ErrSupplyCapExceeded, "ErrSupplyCapExceeded"},
+		{blockchain.ErrEpochIssuanceExceeded, "ErrEpochIssuanceExceeded"},
+		{blockchain.ErrIssueDestNotAllowed, "ErrIssueDestNotAllowed"},
+		{blockchain.ErrFrozenOutput, "ErrFrozenOutput"},
+		{blockchain.ErrInvalidAssetMarker, "ErrInvalidAssetMarker"},
+		{blockchain.ErrAssetMismatch, "ErrAssetMismatch"},
+		{blockchain.ErrBadCoinbasePayee, "ErrBadCoinbasePayee"},
+		{blockchain.ErrUntrustedCheckpoint, "ErrUntrustedCheckpoint"},
+		{blockchain.ErrUnknownRules, "ErrUnknownRules"},
+		{blockchain.ErrDelegationScope, "ErrDelegationScope"},
+		{blockchain.ErrIssueChangeNotAllowed, "ErrIssueChangeNotAllowed"},
 		{0xffff, "Unknown ErrorCode (65535)"},
 	}
 
@@ -27,10 +27,44 @@ func TestFullBlocks(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to generate tests: %v", err)
 	}
+	runFullBlockTests(t, "fullblocktest", tests,
+		&chaincfg.RegressionNetParams)
+}
+
+// TestFullBlocksProvaSigOps ensures the tests generated for the activation of
+// the Prova signature operation count have the expected result when processed
+// via ProcessBlock.
+func TestFullBlocksProvaSigOps(t *testing.T) {
+	tests, err := fullblocktests.GenerateProvaSigOps()
+	if err != nil {
+		t.Fatalf("failed to generate tests: %v", err)
+	}
+	params := chaincfg.RegressionNetParams
+	params.ProvaSigOpsHeight = fullblocktests.ProvaSigOpsHeight
+	runFullBlockTests(t, "fullblocksigops", tests, &params)
+}
+
+// TestFullBlocksFreeze ensures the tests generated for the activation of the
+// account freeze admin ops have the expected result when processed via
+// ProcessBlock.
+func TestFullBlocksFreeze(t *testing.T) {
+	tests, err := fullblocktests.GenerateFreeze()
+	if err != nil {
+		t.Fatalf("failed to generate tests: %v", err)
+	}
+	params := chaincfg.RegressionNetParams
+	params.FreezeHeight = fullblocktests.FreezeHeight
+	runFullBlockTests(t, "fullblocksfreeze", tests, &params)
+}
+
+// runFullBlockTests processes the passed tests generated by the fullblocktests
+// package in order on a new chain with the passed parameters, and ensures each
+// has the expected result.
+func runFullBlockTests(t *testing.T, dbName string,
+	tests [][]fullblocktests.TestInstance, params *chaincfg.Params) {
 
 	// Create a new database and chain instance to run tests against.
-	chain, teardownFunc, err := chainSetup("fullblocktest",
-		&chaincfg.RegressionNetParams)
+	chain, teardownFunc, err := chainSetup(dbName, params)
 	if err != nil {
 		t.Errorf("Failed to setup chain instance: %v", err)
 		return
@@ -83,6 +117,21 @@ func TestFullBlocks(t *testing.T) {
 				blockHeight, item.TotalSupply, chain.TotalSupply())
 		}
 
+		// Check Asset Supplies
+		assetSupplies := chain.AssetSupplies()
+		if len(assetSupplies) != len(item.AssetSupplies) {
+			t.Fatalf("block %q (hash %s, height %d) should "+
+				"have assetSupplies %v, got %v", item.Name, block.Hash(),
+				blockHeight, item.AssetSupplies, assetSupplies)
+		}
+		for assetID, supply := range item.AssetSupplies {
+			if assetSupplies[assetID] != supply {
+				t.Fatalf("block %q (hash %s, height %d) should "+
+					"have assetSupplies %v, got %v", item.Name, block.Hash(),
+					blockHeight, item.AssetSupplies, assetSupplies)
+			}
+		}
+
 		// Check ROOT KEYS
 		if item.IsMainChain && !item.AdminKeySets[btcec.RootKeySet].Equal(chain.AdminKeySets()[btcec.RootKeySet]) {
 			t.Fatalf("block %q (hash %s, height %d) should "+
@@ -175,6 +224,20 @@ func TestFullBlocks(t *testing.T) {
 				"failed to decode", item.Name, blockHash,
 				blockHeight)
 		}
+
+		// Ensure the non-canonical encoding is reported.
+		issues, err := wire.CheckCanonicalEncoding(&msgBlock,
+			item.RawBlock)
+		if err != nil {
+			t.Fatalf("block %q (hash %s, height %d) failed the "+
+				"canonical encoding check: %v", item.Name,
+				blockHash, blockHeight, err)
+		}
+		if len(issues) == 0 {
+			t.Fatalf("block %q (hash %s, height %d) should have "+
+				"non-canonical encodings", item.Name, blockHash,
+				blockHeight)
+		}
 	}
 
 	// testOrphanOrRejectedBlock attempts to process the block in the
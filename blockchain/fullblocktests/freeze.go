@@ -0,0 +1,148 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fullblocktests
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// FreezeHeight is the height from which the tests generated by
+// GenerateFreeze expect accounts to be frozen.  The tests must be processed
+// by a chain with the parameters of the regression test network, with
+// FreezeHeight set to this height.  It follows the coinbase maturity of the
+// network, after which the genesis thread outputs may be spent.
+const FreezeHeight = 102
+
+// lookupProvisionKey is a key closure returning the provision keys of the
+// genesis block of the regression test network.
+var lookupProvisionKey = func() txscript.KeyClosure {
+	var keys []txscript.PrivateKey
+	for _, keyHex := range []string{
+		"f954b388f5db3a1d2915cda434206d791b47cf3d4e78cc32fbeb77ea25d20d7d",
+		"627f6f1d5d8f38bd60b6aaea2f74c72917deffcc2a5a64f67d3e0a28a2d711c1",
+	} {
+		keyBytes, _ := hex.DecodeString(keyHex)
+		privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), keyBytes)
+		keys = append(keys, txscript.PrivateKey{Key: privKey,
+			Compressed: true})
+	}
+	return func(provautil.Address) ([]txscript.PrivateKey, error) {
+		return keys, nil
+	}
+}()
+
+// createGenesisFreezeTx creates a provision thread admin tx freezing the
+// account of the passed pubKeyHash, which spends the provision thread output
+// of the genesis block with the provision keys of the genesis block.
+func createGenesisFreezeTx(spend *spendableOut, pkHash []byte) *wire.MsgTx {
+	spendTx := wire.NewMsgTx(1)
+	spendTx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: spend.prevOut,
+		Sequence:         wire.MaxTxInSequenceNum,
+	})
+	spendTx.AddTxOut(wire.NewTxOut(0,
+		provaThreadScript(provautil.ProvisionThread)))
+	spendTx.AddTxOut(wire.NewTxOut(0,
+		provaFreezeScript(txscript.AdminOpAccountFreeze, pkHash)))
+
+	sigScript, _ := txscript.SignTxOutput(&chaincfg.RegressionNetParams,
+		spendTx, 0, int64(spend.amount), spend.pkScript,
+		txscript.SigHashAll, lookupProvisionKey, nil)
+	spendTx.TxIn[0].SignatureScript = sigScript
+	return spendTx
+}
+
+// GenerateFreeze returns a slice of tests that exercise the activation of the
+// account freeze admin ops at FreezeHeight.  See Generate for how the tests
+// are used.
+func GenerateFreeze() (tests [][]TestInstance, err error) {
+	// Convert the panics of the generation code into errors just as in
+	// Generate.
+	defer func() {
+		if r := recover(); r != nil {
+			tests = nil
+
+			switch rt := r.(type) {
+			case string:
+				err = errors.New(rt)
+			case error:
+				err = rt
+			default:
+				err = errors.New("Unknown panic")
+			}
+		}
+	}()
+
+	g, err := makeTestGenerator(&chaincfg.RegressionNetParams)
+	if err != nil {
+		return nil, err
+	}
+
+	threadTips := make(map[provautil.ThreadID]*wire.OutPoint)
+	for i, threadID := range []provautil.ThreadID{provautil.RootThread,
+		provautil.ProvisionThread, provautil.IssueThread} {
+
+		out := makeSpendableOut(g.tip, 0, uint32(i))
+		threadTips[threadID] = &out.prevOut
+	}
+	provisionOut := makeSpendableOut(g.tip, 0, 1)
+	acceptBlock := func() TestInstance {
+		return AcceptedBlock{g.tipName, g.tip, g.tipHeight, true,
+			false, threadTips, 0, make(blockchain.AssetSupplies),
+			chaincfg.RegressionNetParams.AdminKeySets,
+			chaincfg.RegressionNetParams.ASPKeyIdMap}
+	}
+
+	// ---------------------------------------------------------------------
+	// Generate blocks up to two blocks before the activation height, after
+	// which the genesis thread outputs are mature.
+	//
+	//   genesis -> bm0 -> bm1 -> ... -> bm99
+	// ---------------------------------------------------------------------
+
+	var testInstances []TestInstance
+	for i := 0; g.tipHeight < FreezeHeight-2; i++ {
+		g.nextBlock(fmt.Sprintf("bm%d", i), nil)
+		testInstances = append(testInstances, acceptBlock())
+	}
+	tests = append(tests, testInstances)
+
+	// ---------------------------------------------------------------------
+	// Account freeze activation tests.
+	//
+	//   ... -> bm99 -> b2() -> b3(freeze)
+	//              \-> b1(freeze)
+	// ---------------------------------------------------------------------
+
+	freezeTx := createGenesisFreezeTx(&provisionOut, make([]byte, 20))
+
+	// Before the activation height, the freeze op is not a valid admin op.
+	g.nextBlock("b1", nil, additionalTx(freezeTx))
+	tests = append(tests, []TestInstance{
+		RejectedBlock{g.tipName, g.tip, g.tipHeight,
+			blockchain.ErrInvalidAdminTx},
+	})
+
+	// At the activation height, the same freeze op is accepted.
+	g.setTip(fmt.Sprintf("bm%d", FreezeHeight-3))
+	g.nextBlock("b2", nil)
+	tests = append(tests, []TestInstance{acceptBlock()})
+
+	g.nextBlock("b3", nil, additionalTx(freezeTx))
+	freezeOut := makeSpendableOutForTx(freezeTx, 0)
+	threadTips[provautil.ProvisionThread] = &freezeOut.prevOut
+	tests = append(tests, []TestInstance{acceptBlock()})
+
+	return tests, nil
+}
@@ -12,6 +12,7 @@
 package fullblocktests
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"github.com/pyx-partners/dmgd/blockchain"
@@ -80,15 +81,16 @@ type TestInstance interface {
 // the blockchain either by extending the main chain, on a side chain, or as an
 // orphan.
 type AcceptedBlock struct {
-	Name         string
-	Block        *wire.MsgBlock
-	Height       uint32
-	IsMainChain  bool
-	IsOrphan     bool
-	ThreadTips   map[provautil.ThreadID]*wire.OutPoint
-	TotalSupply  uint64
-	AdminKeySets map[btcec.KeySetType]btcec.PublicKeySet
-	ASPKeyIdMap  btcec.KeyIdMap
+	Name          string
+	Block         *wire.MsgBlock
+	Height        uint32
+	IsMainChain   bool
+	IsOrphan      bool
+	ThreadTips    map[provautil.ThreadID]*wire.OutPoint
+	TotalSupply   uint64
+	AssetSupplies blockchain.AssetSupplies
+	AdminKeySets  map[btcec.KeySetType]btcec.PublicKeySet
+	ASPKeyIdMap   btcec.KeyIdMap
 }
 
 // Ensure AcceptedBlock implements the TestInstance interface.
@@ -289,6 +291,45 @@ func provaAdminASPScript(opcode byte, pubKey *btcec.PublicKey, keyID btcec.KeyID
 	return script
 }
 
+// provaIssueDestScript creates a new script that executes an admin op
+// adding or revoking the passed address as issuance destination.
+func provaIssueDestScript(opcode byte, addr *provautil.AddressProva) []byte {
+	// size as: <operation (1 byte)> <pkHash (20 bytes)> <2 key ids : 8 bytes>
+	data := make([]byte, 1+txscript.IssueDestLen)
+	data[0] = opcode
+	copy(data[1:], addr.ScriptAddress())
+	offset := 1 + len(addr.ScriptAddress())
+	for _, keyID := range addr.ScriptKeyIDs() {
+		keyID.ToAddressFormat(data[offset:])
+		offset += btcec.KeyIDSize
+	}
+	builder := txscript.NewScriptBuilder()
+	script, err := builder.
+		AddOp(txscript.OP_RETURN).
+		AddData(data).Script()
+	if err != nil {
+		panic(err)
+	}
+	return script
+}
+
+// provaFreezeScript creates a new script that executes an admin op freezing
+// or unfreezing the account of the passed pubKeyHash.
+func provaFreezeScript(opcode byte, pkHash []byte) []byte {
+	// size as: <operation (1 byte)> <pkHash (20 bytes)>
+	data := make([]byte, 1+len(pkHash))
+	data[0] = opcode
+	copy(data[1:], pkHash)
+	builder := txscript.NewScriptBuilder()
+	script, err := builder.
+		AddOp(txscript.OP_RETURN).
+		AddData(data).Script()
+	if err != nil {
+		panic(err)
+	}
+	return script
+}
+
 // opReturnScript creates an op_return pkScript.
 func opReturnScript() []byte {
 	return []byte{txscript.OP_RETURN}
@@ -425,6 +466,20 @@ func changeCoinbaseValue(delta int64) func(*wire.MsgBlock) {
 	}
 }
 
+// schnorrSign returns a function that itself takes a block and signs it with a
+// Schnorr signature of the passed key claimed to be made by the validate key.
+// The signed fields must not be changed after it.
+func schnorrSign(key *btcec.PrivateKey) func(*wire.MsgBlock) {
+	return func(b *wire.MsgBlock) {
+		b.Header.MerkleRoot = calcMerkleRoot(b.Transactions)
+		sig, err := key.SignSchnorr(b.Header.SigningHash())
+		if err != nil {
+			panic(err)
+		}
+		b.Header.SetSchnorrSignature(validatePrivKey.PubKey(), sig)
+	}
+}
+
 func makeAddr(priv *btcec.PrivateKey, kids *[2]uint) provautil.Address {
 	// Create an Prova address that has:
 	//   - a random pkHash address, so transaction hashes don't collide
@@ -511,6 +566,32 @@ func createSpendTx(spend *spendableOut, fee provautil.Amount) *wire.MsgTx {
 	return spendTx
 }
 
+// createAssetSpendTx creates a transaction that spends the tokens of the passed
+// asset from the provided spendable output and marks its outputs as holding
+// the asset.
+func createAssetSpendTx(spend *spendableOut, assetID uint32, fee provautil.Amount) *wire.MsgTx {
+	spendTx := wire.NewMsgTx(1)
+
+	spendTx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: spend.prevOut,
+		Sequence:         wire.MaxTxInSequenceNum,
+		SignatureScript:  nil,
+	})
+
+	scriptPkScript, _ := txscript.PayToAddrScript(makeAddr(nil, nil))
+	spendTx.AddTxOut(wire.NewTxOut(int64(spend.amount-fee), scriptPkScript))
+	assetScript, _ := txscript.AssetIDScript(assetID)
+	spendTx.AddTxOut(wire.NewTxOut(0, assetScript))
+
+	// Use Account Service Key and Account Recovery Key to sign tx.
+	sigScript, _ := txscript.SignTxOutput(&chaincfg.RegressionNetParams, spendTx,
+		0, int64(spend.amount), spend.pkScript, txscript.SigHashAll, txscript.KeyClosure(lookupKey), nil)
+
+	spendTx.TxIn[0].SignatureScript = sigScript
+
+	return spendTx
+}
+
 // createAdminTx creates an admin tx.
 func createAdminTx(spend *spendableOut, threadID provautil.ThreadID, op byte, pubKey *btcec.PublicKey) *wire.MsgTx {
 	spendTx := wire.NewMsgTx(1)
@@ -532,6 +613,52 @@ func createAdminTx(spend *spendableOut, threadID provautil.ThreadID, op byte, pu
 	return spendTx
 }
 
+// createIssueDestAdminTx creates a root thread admin tx that adds or revokes
+// an issuance destination.
+func createIssueDestAdminTx(spend *spendableOut, op byte, addr *provautil.AddressProva) *wire.MsgTx {
+	spendTx := wire.NewMsgTx(1)
+	spendTx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: spend.prevOut,
+		Sequence:         wire.MaxTxInSequenceNum,
+		SignatureScript:  nil,
+	})
+	txValue := int64(0) // how much the tx is spending. 0 for admin tx.
+	spendTx.AddTxOut(wire.NewTxOut(txValue,
+		provaThreadScript(provautil.RootThread)))
+	spendTx.AddTxOut(wire.NewTxOut(txValue,
+		provaIssueDestScript(op, addr)))
+
+	sigScript, _ := txscript.SignTxOutput(&chaincfg.RegressionNetParams, spendTx,
+		0, int64(spend.amount), spend.pkScript, txscript.SigHashAll, txscript.KeyClosure(lookupKey), nil)
+
+	spendTx.TxIn[0].SignatureScript = sigScript
+
+	return spendTx
+}
+
+// createFreezeAdminTx creates a provision thread admin tx that freezes or
+// unfreezes the account of the passed pubKeyHash.
+func createFreezeAdminTx(spend *spendableOut, op byte, pkHash []byte) *wire.MsgTx {
+	spendTx := wire.NewMsgTx(1)
+	spendTx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: spend.prevOut,
+		Sequence:         wire.MaxTxInSequenceNum,
+		SignatureScript:  nil,
+	})
+	txValue := int64(0) // how much the tx is spending. 0 for admin tx.
+	spendTx.AddTxOut(wire.NewTxOut(txValue,
+		provaThreadScript(provautil.ProvisionThread)))
+	spendTx.AddTxOut(wire.NewTxOut(txValue,
+		provaFreezeScript(op, pkHash)))
+
+	sigScript, _ := txscript.SignTxOutput(&chaincfg.RegressionNetParams, spendTx,
+		0, int64(spend.amount), spend.pkScript, txscript.SigHashAll, txscript.KeyClosure(lookupKey), nil)
+
+	spendTx.TxIn[0].SignatureScript = sigScript
+
+	return spendTx
+}
+
 type AspOp struct {
 	Op     byte
 	PubKey *btcec.PublicKey
@@ -605,6 +732,101 @@ func createIssueTx(thread *spendableOut, value int64, spend *spendableOut) *wire
 	return spendTx
 }
 
+// createDestroyWithChangeTx creates an issue thread admin tx destroying value
+// of the tokens of the passed spend output and paying the rest as change to
+// the passed script.
+func createDestroyWithChangeTx(thread *spendableOut, value int64, spend *spendableOut, changeScript []byte) *wire.MsgTx {
+	spendTx := wire.NewMsgTx(1)
+	// thread input
+	spendTx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: thread.prevOut,
+		Sequence:         wire.MaxTxInSequenceNum,
+		SignatureScript:  nil,
+	})
+	spendTx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: spend.prevOut,
+		Sequence:         wire.MaxTxInSequenceNum,
+		SignatureScript:  nil,
+	})
+	// thread output
+	spendTx.AddTxOut(wire.NewTxOut(int64(0), provaThreadScript(provautil.IssueThread)))
+	spendTx.AddTxOut(wire.NewTxOut(value, opReturnScript()))
+	spendTx.AddTxOut(wire.NewTxOut(int64(spend.amount)-value, changeScript))
+	// sign thread input
+	sigScript, _ := txscript.SignTxOutput(&chaincfg.RegressionNetParams, spendTx,
+		0, int64(thread.amount), thread.pkScript, txscript.SigHashAll, txscript.KeyClosure(lookupKey), nil)
+	spendTx.TxIn[0].SignatureScript = sigScript
+	// sign second input
+	sigScript2, _ := txscript.SignTxOutput(&chaincfg.RegressionNetParams, spendTx,
+		1, int64(spend.amount), spend.pkScript, txscript.SigHashAll, txscript.KeyClosure(lookupKey), nil)
+	spendTx.TxIn[1].SignatureScript = sigScript2
+	return spendTx
+}
+
+// createIssueToAddrTx creates an issue thread admin tx issuing new tokens of
+// amount in value to the passed address.
+func createIssueToAddrTx(thread *spendableOut, value int64, addr provautil.Address) *wire.MsgTx {
+	spendTx := wire.NewMsgTx(1)
+	// thread input
+	spendTx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: thread.prevOut,
+		Sequence:         wire.MaxTxInSequenceNum,
+		SignatureScript:  nil,
+	})
+	// thread output
+	spendTx.AddTxOut(wire.NewTxOut(int64(0), provaThreadScript(provautil.IssueThread)))
+	pkScript, _ := txscript.PayToAddrScript(addr)
+	spendTx.AddTxOut(wire.NewTxOut(value, pkScript))
+	// sign thread input
+	sigScript, _ := txscript.SignTxOutput(&chaincfg.RegressionNetParams, spendTx,
+		0, int64(thread.amount), thread.pkScript, txscript.SigHashAll, txscript.KeyClosure(lookupKey), nil)
+	spendTx.TxIn[0].SignatureScript = sigScript
+	return spendTx
+}
+
+// createAssetIssueTx creates an issue thread admin tx for the passed asset.
+// If a spend output is passed, its tokens are destroyed, otherwise new tokens
+// of amount in value are issued.
+func createAssetIssueTx(thread *spendableOut, assetID uint32, value int64, spend *spendableOut) *wire.MsgTx {
+	spendTx := wire.NewMsgTx(1)
+	// thread input
+	spendTx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: thread.prevOut,
+		Sequence:         wire.MaxTxInSequenceNum,
+		SignatureScript:  nil,
+	})
+	// thread output
+	spendTx.AddTxOut(wire.NewTxOut(int64(0), provaThreadScript(provautil.IssueThread)))
+	if spend == nil {
+		scriptPkScript, _ := txscript.PayToAddrScript(makeAddr(nil, nil))
+		spendTx.AddTxOut(wire.NewTxOut(value, scriptPkScript))
+	} else {
+		spendTx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: spend.prevOut,
+			Sequence:         wire.MaxTxInSequenceNum,
+			SignatureScript:  nil,
+		})
+		spendTx.AddTxOut(wire.NewTxOut(
+			int64(spend.amount),
+			opReturnScript(),
+		))
+	}
+	// asset marker output
+	assetScript, _ := txscript.AssetIDScript(assetID)
+	spendTx.AddTxOut(wire.NewTxOut(0, assetScript))
+	// sign thread input
+	sigScript, _ := txscript.SignTxOutput(&chaincfg.RegressionNetParams, spendTx,
+		0, int64(thread.amount), thread.pkScript, txscript.SigHashAll, txscript.KeyClosure(lookupKey), nil)
+	spendTx.TxIn[0].SignatureScript = sigScript
+	if spend != nil {
+		// sign second input
+		sigScript2, _ := txscript.SignTxOutput(&chaincfg.RegressionNetParams, spendTx,
+			1, int64(spend.amount), spend.pkScript, txscript.SigHashAll, txscript.KeyClosure(lookupKey), nil)
+		spendTx.TxIn[1].SignatureScript = sigScript2
+	}
+	return spendTx
+}
+
 // nextBlock builds a new block that extends the current tip associated with the
 // generator and updates the generator's tip to the newly generated block.
 //
@@ -620,6 +842,7 @@ func createIssueTx(thread *spendableOut, value int64, spend *spendableOut) *wire
 // In order to simply the logic in the munge functions, the following rules are
 // applied after all munge functions have been invoked:
 // - The merkle root will be recalculated unless it was manually changed
+// - The block will be signed by the validate key unless it was signed
 // - The block will be solved unless the nonce was changed
 func (g *testGenerator) nextBlock(blockName string, spend *spendableOut, mungers ...func(*wire.MsgBlock)) *wire.MsgBlock {
 	// Create coinbase transaction for the block using any additional
@@ -667,7 +890,9 @@ func (g *testGenerator) nextBlock(blockName string, spend *spendableOut, mungers
 		block.Header.MerkleRoot = calcMerkleRoot(block.Transactions)
 	}
 	block.Header.Size = uint32(block.SerializeSize())
-	block.Header.Sign(validatePrivKey)
+	if block.Header.Signature == (wire.BlockSignature{}) {
+		block.Header.Sign(validatePrivKey)
+	}
 
 	// Only solve the block if the nonce wasn't manually changed by a munge
 	// function.
@@ -759,6 +984,7 @@ func Generate(includeLargeReorg bool) (tests [][]TestInstance, err error) {
 	lastAdminKeySets := chaincfg.RegressionNetParams.AdminKeySets
 	lastASPKeys := chaincfg.RegressionNetParams.ASPKeyIdMap
 	lastTotalSupply := uint64(0)
+	lastAssetSupplies := make(blockchain.AssetSupplies)
 	lastThreadTips := make(map[provautil.ThreadID]*wire.OutPoint)
 	rootOut := makeSpendableOut(g.tip, 0, 0)
 	lastThreadTips[provautil.RootThread] = &rootOut.prevOut
@@ -769,7 +995,7 @@ func Generate(includeLargeReorg bool) (tests [][]TestInstance, err error) {
 
 	acceptBlock := func(blockName string, block *wire.MsgBlock, isMainChain, isOrphan bool) TestInstance {
 		blockHeight := g.blockHeights[blockName]
-		return AcceptedBlock{blockName, block, blockHeight, isMainChain, isOrphan, lastThreadTips, lastTotalSupply, lastAdminKeySets, lastASPKeys}
+		return AcceptedBlock{blockName, block, blockHeight, isMainChain, isOrphan, lastThreadTips, lastTotalSupply, lastAssetSupplies, lastAdminKeySets, lastASPKeys}
 	}
 	rejectBlock := func(blockName string, block *wire.MsgBlock, code blockchain.ErrorCode) TestInstance {
 		blockHeight := g.blockHeights[blockName]
@@ -808,6 +1034,15 @@ func Generate(includeLargeReorg bool) (tests [][]TestInstance, err error) {
 	assertTotalSupply := func(totalSupply uint64) {
 		lastTotalSupply = totalSupply
 	}
+	assertAssetSupply := func(assetID uint32, supply uint64) {
+		assetSupplies := lastAssetSupplies.DeepCopy()
+		if supply == 0 {
+			delete(assetSupplies, assetID)
+		} else {
+			assetSupplies[assetID] = supply
+		}
+		lastAssetSupplies = assetSupplies
+	}
 	assertAdminKeys := func(keySetType btcec.KeySetType, adminKeys []btcec.PublicKey) {
 		adminKeySets := btcec.DeepCopy(lastAdminKeySets)
 		if adminKeys != nil {
@@ -959,7 +1194,6 @@ func Generate(includeLargeReorg bool) (tests [][]TestInstance, err error) {
 	assertTotalSupply(8000000000)
 	accepted()
 
-	// TODO(prova): revoke with change
 	g.nextBlock("b9", nil)
 	accepted()
 
@@ -1158,5 +1392,262 @@ func Generate(includeLargeReorg bool) (tests [][]TestInstance, err error) {
 	g.nextBlock("b31", outs[12], changeCoinbaseValue(1))
 	rejected(blockchain.ErrBadCoinbaseValue)
 
+	// ---------------------------------------------------------------------
+	// Issuance limit tests.
+	// ---------------------------------------------------------------------
+	//
+	//   ... -> b27() -> b33(+4) -> bi0() -> ... -> bin() -> b35(+8)
+	//               \-> b32(+5)                          \-> b34(+9)
+	//
+	// The regression network limits the issuance within each epoch to
+	// 12000000000 atoms and the total supply to 20000000000 atoms.  The
+	// 8000000000 atoms issued in b5 are in the same epoch as b32 and b33.
+
+	// Attempt to issue more than is allowed in the epoch while staying
+	// under the supply cap.
+	g.setTip("b27")
+	issueThreadOut = makeSpendableOutForTx(issueTx, 0)
+	overEpochIssueTx := createIssueTx(&issueThreadOut, int64(5000000000), nil)
+	g.nextBlock("b32", nil, additionalTx(overEpochIssueTx))
+	rejected(blockchain.ErrEpochIssuanceExceeded)
+
+	// Issue exactly the remaining amount allowed in the epoch.
+	g.setTip("b27")
+	epochIssueTx := createIssueTx(&issueThreadOut, int64(4000000000), nil)
+	g.nextBlock("b33", nil, additionalTx(epochIssueTx))
+	assertTotalSupply(12000000000)
+	accepted()
+
+	// Extend the chain up to the start of the next issuance epoch.
+	testInstances = nil
+	nextEpoch := (g.tipHeight/g.params.IssuanceEpochBlocks + 1) *
+		g.params.IssuanceEpochBlocks
+	for i := 0; g.tipHeight < nextEpoch-1; i++ {
+		g.nextBlock(fmt.Sprintf("bi%d", i), nil)
+		testInstances = append(testInstances, acceptBlock(g.tipName,
+			g.tip, true, false))
+	}
+	tests = append(tests, testInstances)
+	epochTipName := g.tipName
+
+	// Attempt to issue past the supply cap in the new epoch while staying
+	// under the epoch limit.
+	issueThreadOut = makeSpendableOutForTx(epochIssueTx, 0)
+	overSupplyIssueTx := createIssueTx(&issueThreadOut, int64(9000000000), nil)
+	g.nextBlock("b34", nil, additionalTx(overSupplyIssueTx))
+	rejected(blockchain.ErrSupplyCapExceeded)
+
+	// Issue up to the supply cap in the new epoch.
+	g.setTip(epochTipName)
+	capIssueTx := createIssueTx(&issueThreadOut, int64(8000000000), nil)
+	g.nextBlock("b35", nil, additionalTx(capIssueTx))
+	assertTotalSupply(20000000000)
+	accepted()
+
+	// ---------------------------------------------------------------------
+	// Issuance destination allowlist tests.
+	// ---------------------------------------------------------------------
+	//
+	//   ... -> b35(+8) -> b36() -> b38(-8) -> b39(+1) -> b40()
+	//                          \-> b37(+1)
+
+	// Add an issuance destination on the root thread.
+	issueDestAddr := makeAddr(privKey3, nil).(*provautil.AddressProva)
+	issueDestAddTx := createIssueDestAdminTx(&rootThreadOutFork,
+		txscript.AdminOpIssueDestAdd, issueDestAddr)
+	rootThreadOut = makeSpendableOutForTx(issueDestAddTx, 0)
+	g.nextBlock("b36", nil, additionalTx(issueDestAddTx))
+	assertThreadTip(provautil.RootThread, rootThreadOut)
+	accepted()
+
+	// Attempt to issue to a destination which is not allowlisted.
+	issueThreadOut = makeSpendableOutForTx(capIssueTx, 0)
+	notAllowedIssueTx := createIssueToAddrTx(&issueThreadOut,
+		int64(1000000000), makeAddr(nil, nil))
+	g.nextBlock("b37", nil, additionalTx(notAllowedIssueTx))
+	rejected(blockchain.ErrIssueDestNotAllowed)
+
+	// Destruction is not restricted by the allowlist.
+	g.setTip("b36")
+	coinsToDestroy := makeSpendableOutForTx(capIssueTx, 1)
+	destroyTx := createIssueTx(&issueThreadOut, 0, &coinsToDestroy)
+	g.nextBlock("b38", nil, additionalTx(destroyTx))
+	assertTotalSupply(12000000000)
+	accepted()
+
+	// Issue to the allowlisted destination.
+	issueThreadOut = makeSpendableOutForTx(destroyTx, 0)
+	allowedIssueTx := createIssueToAddrTx(&issueThreadOut,
+		int64(1000000000), issueDestAddr)
+	g.nextBlock("b39", nil, additionalTx(allowedIssueTx))
+	assertTotalSupply(13000000000)
+	accepted()
+
+	// Revoke the issuance destination again.
+	issueDestRevokeTx := createIssueDestAdminTx(&rootThreadOut,
+		txscript.AdminOpIssueDestRevoke, issueDestAddr)
+	rootThreadOut = makeSpendableOutForTx(issueDestRevokeTx, 0)
+	g.nextBlock("b40", nil, additionalTx(issueDestRevokeTx))
+	assertThreadTip(provautil.RootThread, rootThreadOut)
+	accepted()
+
+	// ---------------------------------------------------------------------
+	// Account freeze tests.
+	// ---------------------------------------------------------------------
+	//
+	//   ... -> b40() -> b41(freeze)
+	//               |            \-> b42(spend)
+	//                \-> b43() -> b44(spend) -> b45(freeze) -> b46(unfreeze)
+
+	// Freeze the account of the issuance destination on the provision
+	// thread.
+	provThreadOut = makeSpendableOutForTx(aspKeyIdTx, 0)
+	frozenPkHash := issueDestAddr.ScriptAddress()
+	freezeTx := createFreezeAdminTx(&provThreadOut,
+		txscript.AdminOpAccountFreeze, frozenPkHash)
+	g.nextBlock("b41", nil, additionalTx(freezeTx))
+	assertThreadTip(provautil.ProvisionThread,
+		makeSpendableOutForTx(freezeTx, 0))
+	accepted()
+
+	// Attempt to spend the output of the frozen account.
+	frozenCoins := makeSpendableOutForTx(allowedIssueTx, 1)
+	frozenSpendTx := createProvaSpendTx(&frozenCoins, []ProvaOut{
+		{1000000000, makeAddr(nil, nil).String()},
+	}, privKey3)
+	g.nextBlock("b42", nil, additionalTx(frozenSpendTx))
+	rejected(blockchain.ErrFrozenOutput)
+
+	// Create a fork from b40 which does not freeze the account.
+	g.setTip("b40")
+	g.nextBlock("b43", nil)
+	acceptedToSideChainWithExpectedTip("b41")
+
+	// Spending the output on the fork reorganizes away the freeze.
+	g.nextBlock("b44", nil, additionalTx(frozenSpendTx))
+	assertThreadTip(provautil.ProvisionThread, provThreadOut)
+	accepted()
+
+	// Freeze and unfreeze the account again.
+	freezeTx = createFreezeAdminTx(&provThreadOut,
+		txscript.AdminOpAccountFreeze, frozenPkHash)
+	g.nextBlock("b45", nil, additionalTx(freezeTx))
+	accepted()
+
+	provThreadOut = makeSpendableOutForTx(freezeTx, 0)
+	unfreezeTx := createFreezeAdminTx(&provThreadOut,
+		txscript.AdminOpAccountUnfreeze, frozenPkHash)
+	provThreadOut = makeSpendableOutForTx(unfreezeTx, 0)
+	g.nextBlock("b46", nil, additionalTx(unfreezeTx))
+	assertThreadTip(provautil.ProvisionThread, provThreadOut)
+	accepted()
+
+	// ---------------------------------------------------------------------
+	// Asset tests.
+	// ---------------------------------------------------------------------
+	//
+	//   ... -> b46() -> b47(+asset) -> b50(transfer) -> b51(-asset)
+	//                            |\-> b48(fee)
+	//                             \-> b49(no marker)
+
+	// Issue an asset beyond the supply cap and epoch limit of DMG, which
+	// only apply to DMG.
+	issueThreadOut = makeSpendableOutForTx(allowedIssueTx, 0)
+	assetIssueTx := createAssetIssueTx(&issueThreadOut, 1,
+		int64(50000000000), nil)
+	g.nextBlock("b47", nil, additionalTx(assetIssueTx))
+	assertAssetSupply(1, 50000000000)
+	accepted()
+
+	// Attempt to pay a fee out of the asset.
+	assetOut := makeSpendableOutForTx(assetIssueTx, 1)
+	g.nextBlock("b48", nil, additionalTx(createAssetSpendTx(&assetOut, 1, 1)))
+	rejected(blockchain.ErrAssetMismatch)
+
+	// Attempt to spend the asset without the asset marker.
+	g.setTip("b47")
+	g.nextBlock("b49", &assetOut)
+	rejected(blockchain.ErrAssetMismatch)
+
+	// Transfer the asset.
+	g.setTip("b47")
+	assetTransferTx := createAssetSpendTx(&assetOut, 1, 0)
+	g.nextBlock("b50", nil, additionalTx(assetTransferTx))
+	accepted()
+
+	// Destroy the transferred asset.
+	issueThreadOut = makeSpendableOutForTx(assetIssueTx, 0)
+	assetOut = makeSpendableOutForTx(assetTransferTx, 0)
+	assetDestroyTx := createAssetIssueTx(&issueThreadOut, 1, 0, &assetOut)
+	g.nextBlock("b51", nil, additionalTx(assetDestroyTx))
+	assertAssetSupply(1, 0)
+	assertTotalSupply(13000000000)
+	accepted()
+
+	// ---------------------------------------------------------------------
+	// Non-canonical encoding tests.
+	// ---------------------------------------------------------------------
+	//
+	//   ... -> b51 -> b52(non-canonical transaction count)
+
+	// Encode the transaction count of a block with 5 bytes instead of 1.
+	b52 := g.nextBlock("b52", nil)
+	var buf bytes.Buffer
+	if err := b52.Serialize(&buf); err != nil {
+		panic(err)
+	}
+	serialized := buf.Bytes()
+	headerLen := b52.Header.SerializeSize()
+	rawBlock := append([]byte{}, serialized[:headerLen]...)
+	rawBlock = append(rawBlock, 0xfe, byte(len(b52.Transactions)), 0x00,
+		0x00, 0x00)
+	rawBlock = append(rawBlock, serialized[headerLen+1:]...)
+	tests = append(tests, []TestInstance{
+		RejectedNonCanonicalBlock{"b52", rawBlock,
+			g.blockHeights["b52"]},
+	})
+
+	// ---------------------------------------------------------------------
+	// Schnorr block signature tests.
+	// ---------------------------------------------------------------------
+	//
+	//   ... -> b51 -> b53(Schnorr signature)
+	//                      \-> b54(Schnorr signature by another key)
+
+	// The regression network accepts Schnorr signatures of the validate
+	// key, as made by the signers of a threshold key.
+	g.setTip("b51")
+	g.nextBlock("b53", nil, schnorrSign(validatePrivKey))
+	accepted()
+
+	otherKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), []byte{0x05})
+	g.nextBlock("b54", nil, schnorrSign(otherKey))
+	rejected(blockchain.ErrBadBlockSignature)
+
+	// ---------------------------------------------------------------------
+	// Destruction change tests.
+	// ---------------------------------------------------------------------
+	//
+	//   ... -> b53() -> b56(-6)
+	//               \-> b55(-6)
+
+	// Attempt to destroy tokens paying change to another account.
+	g.setTip("b53")
+	issueThreadOut = makeSpendableOutForTx(assetDestroyTx, 0)
+	coinsToDestroy = makeSpendableOutForTx(frozenSpendTx, 0)
+	otherPkScript, _ := txscript.PayToAddrScript(makeAddr(nil, nil))
+	otherChangeTx := createDestroyWithChangeTx(&issueThreadOut,
+		int64(600000000), &coinsToDestroy, otherPkScript)
+	g.nextBlock("b55", nil, additionalTx(otherChangeTx))
+	rejected(blockchain.ErrIssueChangeNotAllowed)
+
+	// Destroy tokens paying change back to the destroyed output.
+	g.setTip("b53")
+	changeTx := createDestroyWithChangeTx(&issueThreadOut,
+		int64(600000000), &coinsToDestroy, coinsToDestroy.pkScript)
+	g.nextBlock("b56", nil, additionalTx(changeTx))
+	assertTotalSupply(12400000000)
+	accepted()
+
 	return tests, nil
 }
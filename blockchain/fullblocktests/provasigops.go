@@ -0,0 +1,183 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fullblocktests
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+const (
+	// ProvaSigOpsHeight is the height from which the tests generated by
+	// GenerateProvaSigOps expect the signature operations of spent Prova
+	// scripts to be counted.  The tests must be processed by a chain with
+	// the parameters of the regression test network, with ProvaSigOpsHeight
+	// set to this height.
+	ProvaSigOpsHeight = 130
+
+	// sigOpsInputs is the number of inputs spending Prova outputs of the
+	// transactions padded with signature operations.  Each spends three
+	// Prova signature operations.
+	sigOpsInputs = 13
+
+	// maxPadOpsPerInput is the number of OP_CHECKMULTISIG operations
+	// padding a signature script, which fills the operation limit of the
+	// script with the OP_IF and OP_ENDIF around them.
+	maxPadOpsPerInput = txscript.MaxOpsPerScript - 2
+)
+
+// createSigOpsTx creates a transaction spending the provided Prova outputs to
+// a single Prova output, whose signature scripts are padded with the passed
+// number of OP_CHECKMULTISIG operations in total.  The operations are never
+// executed, but each counts as MaxPubKeysPerMultiSig signature operations of
+// the block.
+func createSigOpsTx(spends []spendableOut, padOps int) *wire.MsgTx {
+	spendTx := wire.NewMsgTx(1)
+	var amount provautil.Amount
+	for _, spend := range spends {
+		spendTx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: spend.prevOut,
+			Sequence:         wire.MaxTxInSequenceNum,
+			SignatureScript:  nil,
+		})
+		amount += spend.amount
+	}
+	scriptPkScript, _ := txscript.PayToAddrScript(makeAddr(nil, nil))
+	spendTx.AddTxOut(wire.NewTxOut(int64(amount), scriptPkScript))
+
+	for i, spend := range spends {
+		sigScript, _ := txscript.SignTxOutput(&chaincfg.RegressionNetParams, spendTx,
+			i, int64(spend.amount), spend.pkScript, txscript.SigHashAll, txscript.KeyClosure(lookupKey), nil)
+
+		numOps := padOps
+		if numOps > maxPadOpsPerInput {
+			numOps = maxPadOpsPerInput
+		}
+		padOps -= numOps
+		padding := make([]byte, 0, numOps+3+len(sigScript))
+		padding = append(padding, txscript.OP_0, txscript.OP_IF)
+		for j := 0; j < numOps; j++ {
+			padding = append(padding, txscript.OP_CHECKMULTISIG)
+		}
+		padding = append(padding, txscript.OP_ENDIF)
+		spendTx.TxIn[i].SignatureScript = append(padding, sigScript...)
+	}
+	if padOps > 0 {
+		panic(fmt.Sprintf("%d padding operations do not fit in the "+
+			"signature scripts", padOps))
+	}
+	return spendTx
+}
+
+// GenerateProvaSigOps returns a slice of tests that exercise the activation of
+// the rule counting the signature operations of spent Prova scripts toward the
+// maximum of the block, at ProvaSigOpsHeight.  See Generate for how the tests
+// are used.
+func GenerateProvaSigOps() (tests [][]TestInstance, err error) {
+	// Convert the panics of the generation code into errors just as in
+	// Generate.
+	defer func() {
+		if r := recover(); r != nil {
+			tests = nil
+
+			switch rt := r.(type) {
+			case string:
+				err = errors.New(rt)
+			case error:
+				err = rt
+			default:
+				err = errors.New("Unknown panic")
+			}
+		}
+	}()
+
+	g, err := makeTestGenerator(&chaincfg.RegressionNetParams)
+	if err != nil {
+		return nil, err
+	}
+
+	// No admin transactions are generated, so the admin state of the
+	// genesis block is expected throughout.
+	threadTips := make(map[provautil.ThreadID]*wire.OutPoint)
+	for i, threadID := range []provautil.ThreadID{provautil.RootThread,
+		provautil.ProvisionThread, provautil.IssueThread} {
+
+		out := makeSpendableOut(g.tip, 0, uint32(i))
+		threadTips[threadID] = &out.prevOut
+	}
+	acceptBlock := func() TestInstance {
+		return AcceptedBlock{g.tipName, g.tip, g.tipHeight, true,
+			false, threadTips, 0, make(blockchain.AssetSupplies),
+			chaincfg.RegressionNetParams.AdminKeySets,
+			chaincfg.RegressionNetParams.ASPKeyIdMap}
+	}
+	accepted := func() {
+		tests = append(tests, []TestInstance{acceptBlock()})
+	}
+	rejected := func(code blockchain.ErrorCode) {
+		tests = append(tests, []TestInstance{
+			RejectedBlock{g.tipName, g.tip, g.tipHeight, code},
+		})
+	}
+
+	// ---------------------------------------------------------------------
+	// Generate blocks up to just before the activation height, whose
+	// coinbase outputs are mature by then.
+	//
+	//   genesis -> bm0 -> bm1 -> ... -> bm127
+	// ---------------------------------------------------------------------
+
+	var testInstances []TestInstance
+	for i := 0; g.tipHeight < ProvaSigOpsHeight-2; i++ {
+		g.nextBlock(fmt.Sprintf("bm%d", i), nil)
+		g.saveTipCoinbaseOut()
+		testInstances = append(testInstances, acceptBlock())
+	}
+	tests = append(tests, testInstances)
+
+	var outs []spendableOut
+	for i := 0; i < 2*sigOpsInputs; i++ {
+		outs = append(outs, g.oldestCoinbaseOut())
+	}
+
+	// ---------------------------------------------------------------------
+	// Signature operation count activation tests.
+	//
+	//   ... -> bm127 -> b1(over with Prova) -> b3(at limit with Prova)
+	//                                      \-> b2(over with Prova)
+	// ---------------------------------------------------------------------
+
+	// Padding with one operation more than maxPadOps stays within the
+	// maximum alone, but exceeds it with the Prova signature operations.
+	maxPadOps := (blockchain.MaxSigOpsPerBlock - sigOpsInputs*3) /
+		txscript.MaxPubKeysPerMultiSig
+	overPadOps := maxPadOps + 1
+
+	// Before the activation height, only the padding is counted.
+	g.nextBlock("b1", nil, additionalTx(createSigOpsTx(
+		outs[:sigOpsInputs], overPadOps)))
+	accepted()
+
+	// At the activation height, the Prova signature operations push the
+	// block over the maximum.
+	g.nextBlock("b2", nil, additionalTx(createSigOpsTx(
+		outs[sigOpsInputs:], overPadOps)))
+	rejected(blockchain.ErrTooManySigOps)
+
+	// A block whose padding and Prova signature operations are within the
+	// maximum is accepted at the activation height.
+	g.setTip("b1")
+	g.nextBlock("b3", nil, additionalTx(createSigOpsTx(
+		outs[sigOpsInputs:], maxPadOps)))
+	accepted()
+
+	return tests, nil
+}
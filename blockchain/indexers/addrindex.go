@@ -616,6 +616,9 @@ type AddrIndex struct {
 // Ensure the AddrIndex type implements the Indexer interface.
 var _ Indexer = (*AddrIndex)(nil)
 
+// Ensure the AddrIndex type implements the ConcurrentIndexer interface.
+var _ ConcurrentIndexer = (*AddrIndex)(nil)
+
 // Ensure the AddrIndex type implements the NeedsInputser interface.
 var _ NeedsInputser = (*AddrIndex)(nil)
 
@@ -736,36 +739,52 @@ func (idx *AddrIndex) indexBlock(data writeIndexData, block *provautil.Block, vi
 //
 // This is part of the Indexer interface.
 func (idx *AddrIndex) ConnectBlock(dbTx database.Tx, block *provautil.Block, view *blockchain.UtxoViewpoint) error {
-	// The offset and length of the transactions within the serialized
-	// block.
-	txLocs, err := block.TxLoc()
+	connect, err := idx.PrepareConnectBlock(block, view)
 	if err != nil {
 		return err
 	}
+	return connect(dbTx)
+}
 
-	// Get the internal block ID associated with the block.
-	blockID, err := dbFetchBlockIDByHash(dbTx, block.Hash())
+// PrepareConnectBlock builds the mappings of the addresses the transactions in
+// the passed block involve and returns a function which adds them to the index.
+//
+// This is part of the ConcurrentIndexer interface.
+func (idx *AddrIndex) PrepareConnectBlock(block *provautil.Block, view *blockchain.UtxoViewpoint) (func(dbTx database.Tx) error, error) {
+	// The offset and length of the transactions within the serialized
+	// block.
+	txLocs, err := block.TxLoc()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Build all of the address to transaction mappings in a local map.
 	addrsToTxns := make(writeIndexData)
 	idx.indexBlock(addrsToTxns, block, view)
 
-	// Add all of the index entries for each address.
-	addrIdxBucket := dbTx.Metadata().Bucket(addrIndexKey)
-	for addrKey, txIdxs := range addrsToTxns {
-		for _, txIdx := range txIdxs {
-			err := dbPutAddrIndexEntry(addrIdxBucket, addrKey,
-				blockID, txLocs[txIdx])
-			if err != nil {
-				return err
+	return func(dbTx database.Tx) error {
+		// Get the internal block ID associated with the block.  It is
+		// added by the transaction index when the block is connected,
+		// so it is only fetched once the entries are written.
+		blockID, err := dbFetchBlockIDByHash(dbTx, block.Hash())
+		if err != nil {
+			return err
+		}
+
+		// Add all of the index entries for each address.
+		addrIdxBucket := dbTx.Metadata().Bucket(addrIndexKey)
+		for addrKey, txIdxs := range addrsToTxns {
+			for _, txIdx := range txIdxs {
+				err := dbPutAddrIndexEntry(addrIdxBucket,
+					addrKey, blockID, txLocs[txIdx])
+				if err != nil {
+					return err
+				}
 			}
 		}
-	}
 
-	return nil
+		return nil
+	}, nil
 }
 
 // DisconnectBlock is invoked by the index manager when a block has been
@@ -0,0 +1,329 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+const (
+	// commitmentIndexName is the human-readable name for the index.
+	commitmentIndexName = "commitment index"
+
+	// commitmentKeySize is the size of the keys in the commitment index.
+	// They consist of the commitment followed by the transaction hash.
+	commitmentKeySize = txscript.CommitmentSize + chainhash.HashSize
+)
+
+var (
+	// commitmentIndexKey is the key of the commitment index and the db
+	// bucket used to house it.
+	commitmentIndexKey = []byte("txbycommitmentidx")
+)
+
+// -----------------------------------------------------------------------------
+// The commitment index maps each metadata commitment to the transactions in
+// the main chain which carry an output committing to it.  Since any number of
+// transactions may commit to the same value, the transaction hash is appended
+// to the commitment to form the key, which allows all transactions for a
+// commitment to be found by seeking to the commitment.  The block ID is not
+// part of the key, so the entries of a block can be removed once the
+// transaction index has already dropped the ID of the disconnected block.
+//
+// The serialized format for keys and values in the commitment index bucket is:
+//
+//   <commitment><txhash> = <block id><start offset><tx length>
+//
+//   Field           Type              Size
+//   commitment      [32]byte          32 bytes
+//   txhash          chainhash.Hash    32 bytes
+//   block id        uint32            4 bytes
+//   start offset    uint32            4 bytes
+//   tx length       uint32            4 bytes
+//   -----
+//   Total: 76 bytes
+// -----------------------------------------------------------------------------
+
+// commitmentKey returns the key of the commitment index entry for the passed
+// commitment and transaction hash.
+func commitmentKey(commitment [txscript.CommitmentSize]byte, txHash *chainhash.Hash) [commitmentKeySize]byte {
+	var key [commitmentKeySize]byte
+	copy(key[:], commitment[:])
+	copy(key[txscript.CommitmentSize:], txHash[:])
+	return key
+}
+
+// txCommitments returns the distinct commitments of the outputs of the passed
+// transaction.
+func txCommitments(tx *provautil.Tx) [][txscript.CommitmentSize]byte {
+	var commitments [][txscript.CommitmentSize]byte
+	for _, txOut := range tx.MsgTx().TxOut {
+		commitment, ok := txscript.ExtractCommitment(txOut.PkScript)
+		if !ok {
+			continue
+		}
+		duplicate := false
+		for i := range commitments {
+			if commitments[i] == commitment {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			commitments = append(commitments, commitment)
+		}
+	}
+	return commitments
+}
+
+// dbFetchCommitmentIndexEntries uses an existing database transaction to fetch
+// the block regions of all transactions committing to the passed commitment.
+func dbFetchCommitmentIndexEntries(dbTx database.Tx, commitment [txscript.CommitmentSize]byte) ([]database.BlockRegion, error) {
+	var regions []database.BlockRegion
+	cursor := dbTx.Metadata().Bucket(commitmentIndexKey).Cursor()
+	for ok := cursor.Seek(commitment[:]); ok; ok = cursor.Next() {
+		key := cursor.Key()
+		if !bytes.HasPrefix(key, commitment[:]) {
+			break
+		}
+
+		// Ensure the serialized data has enough bytes to properly
+		// deserialize.
+		serializedData := cursor.Value()
+		if len(key) != commitmentKeySize ||
+			len(serializedData) < txEntrySize {
+
+			return nil, database.Error{
+				ErrorCode: database.ErrCorruption,
+				Description: fmt.Sprintf("corrupt commitment "+
+					"index entry for %x", key),
+			}
+		}
+
+		// Load the block hash associated with the block ID.
+		hash, err := dbFetchBlockHashBySerializedID(dbTx,
+			serializedData[0:4])
+		if err != nil {
+			return nil, database.Error{
+				ErrorCode: database.ErrCorruption,
+				Description: fmt.Sprintf("corrupt commitment "+
+					"index entry for %x: %v", key, err),
+			}
+		}
+
+		regions = append(regions, database.BlockRegion{
+			Hash:   hash,
+			Offset: byteOrder.Uint32(serializedData[4:8]),
+			Len:    byteOrder.Uint32(serializedData[8:12]),
+		})
+	}
+	return regions, nil
+}
+
+// CommitmentIndex implements a transaction by metadata commitment index.  That
+// is to say, it supports querying all transactions in the main chain which
+// carry an output committing to a given value.
+type CommitmentIndex struct {
+	db database.DB
+}
+
+// Ensure the CommitmentIndex type implements the Indexer, ConcurrentIndexer and
+// Verifier interfaces.
+var _ Indexer = (*CommitmentIndex)(nil)
+var _ ConcurrentIndexer = (*CommitmentIndex)(nil)
+var _ Verifier = (*CommitmentIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *CommitmentIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *CommitmentIndex) Key() []byte {
+	return commitmentIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *CommitmentIndex) Name() string {
+	return commitmentIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the commitment
+// index.
+//
+// This is part of the Indexer interface.
+func (idx *CommitmentIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(commitmentIndexKey)
+	return err
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds a mapping for every
+// commitment carried by the transactions in the block.
+//
+// This is part of the Indexer interface.
+func (idx *CommitmentIndex) ConnectBlock(dbTx database.Tx, block *provautil.Block, view *blockchain.UtxoViewpoint) error {
+	connect, err := idx.PrepareConnectBlock(block, view)
+	if err != nil {
+		return err
+	}
+	return connect(dbTx)
+}
+
+// PrepareConnectBlock extracts the commitments carried by the transactions in
+// the passed block and returns a function which adds a mapping for every one of
+// them.
+//
+// This is part of the ConcurrentIndexer interface.
+func (idx *CommitmentIndex) PrepareConnectBlock(block *provautil.Block, view *blockchain.UtxoViewpoint) (func(dbTx database.Tx) error, error) {
+	// The offset and length of the transactions within the serialized
+	// block.
+	txLocs, err := block.TxLoc()
+	if err != nil {
+		return nil, err
+	}
+
+	type commitmentEntry struct {
+		key   [commitmentKeySize]byte
+		txLoc wire.TxLoc
+	}
+	var entries []commitmentEntry
+	for i, tx := range block.Transactions() {
+		for _, commitment := range txCommitments(tx) {
+			entries = append(entries, commitmentEntry{
+				key:   commitmentKey(commitment, tx.Hash()),
+				txLoc: txLocs[i],
+			})
+		}
+	}
+
+	return func(dbTx database.Tx) error {
+		// Get the internal block ID associated with the block.
+		blockID, err := dbFetchBlockIDByHash(dbTx, block.Hash())
+		if err != nil {
+			return err
+		}
+
+		bucket := dbTx.Metadata().Bucket(commitmentIndexKey)
+		for _, entry := range entries {
+			serializedData := make([]byte, txEntrySize)
+			putTxIndexEntry(serializedData, blockID, entry.txLoc)
+			err := bucket.Put(entry.key[:], serializedData)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, nil
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the mapping for
+// every commitment carried by the transactions in the block.
+//
+// This is part of the Indexer interface.
+func (idx *CommitmentIndex) DisconnectBlock(dbTx database.Tx, block *provautil.Block, view *blockchain.UtxoViewpoint) error {
+	bucket := dbTx.Metadata().Bucket(commitmentIndexKey)
+	for _, tx := range block.Transactions() {
+		for _, commitment := range txCommitments(tx) {
+			key := commitmentKey(commitment, tx.Hash())
+			if err := bucket.Delete(key[:]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// VerifyBlock ensures the commitment index maps every commitment carried by the
+// transactions in the passed block to the location of the transaction.
+//
+// This is part of the Verifier interface.
+func (idx *CommitmentIndex) VerifyBlock(dbTx database.Tx, block *provautil.Block) error {
+	txLocs, err := block.TxLoc()
+	if err != nil {
+		return err
+	}
+	blockID, err := dbFetchBlockIDByHash(dbTx, block.Hash())
+	if err != nil {
+		return database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("block %v is missing from the "+
+				"block ID index: %v", block.Hash(), err),
+		}
+	}
+
+	bucket := dbTx.Metadata().Bucket(commitmentIndexKey)
+	expected := make([]byte, txEntrySize)
+	for i, tx := range block.Transactions() {
+		putTxIndexEntry(expected, blockID, txLocs[i])
+		for _, commitment := range txCommitments(tx) {
+			key := commitmentKey(commitment, tx.Hash())
+			if !bytes.Equal(bucket.Get(key[:]), expected) {
+				return database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("commitment "+
+						"index entry for %x in %v is "+
+						"missing or corrupt",
+						commitment, tx.Hash()),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// TxRegionsForCommitment returns the block regions of all transactions in the
+// main chain which carry an output committing to the passed commitment.  The
+// block regions can in turn be used to load the raw transaction bytes.
+//
+// This function is safe for concurrent access.
+func (idx *CommitmentIndex) TxRegionsForCommitment(commitment [txscript.CommitmentSize]byte) ([]database.BlockRegion, error) {
+	var regions []database.BlockRegion
+	err := idx.db.View(func(dbTx database.Tx) error {
+		var err error
+		regions, err = dbFetchCommitmentIndexEntries(dbTx, commitment)
+		return err
+	})
+	return regions, err
+}
+
+// NewCommitmentIndex returns a new instance of an indexer that is used to
+// create a mapping of the metadata commitments carried by transactions in the
+// blockchain to the respective block, location within the block, and size of
+// the transactions.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewCommitmentIndex(db database.DB) *CommitmentIndex {
+	return &CommitmentIndex{db: db}
+}
+
+// DropCommitmentIndex drops the commitment index from the provided database if
+// it exists.
+func DropCommitmentIndex(db database.DB) error {
+	return dropIndex(db, commitmentIndexKey, commitmentIndexName)
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/database"
+	_ "github.com/pyx-partners/dmgd/database/ffldb"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// TestCommitmentIndex ensures the commitment index maps commitments to the
+// transactions carrying them as blocks are connected and disconnected.
+func TestCommitmentIndex(t *testing.T) {
+	dbPath, err := ioutil.TempDir("", "commitmentindex")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", filepath.Join(dbPath, "db"),
+		wire.TestNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer db.Close()
+
+	var commitment1, commitment2 [txscript.CommitmentSize]byte
+	commitment1[0] = 0x01
+	commitment2[0] = 0x02
+	commitmentScript := func(commitment [txscript.CommitmentSize]byte) []byte {
+		script, err := txscript.CommitmentScript(commitment[:])
+		if err != nil {
+			t.Fatalf("CommitmentScript: %v", err)
+		}
+		return script
+	}
+
+	// Build a block where the second transaction commits to the first
+	// commitment twice, and the third to both commitments.
+	msgBlock := wire.NewMsgBlock(&wire.BlockHeader{Height: 1})
+	for i := 0; i < 3; i++ {
+		tx := wire.NewMsgTx(wire.TxVersion)
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: wire.OutPoint{Index: uint32(i)},
+		})
+		tx.AddTxOut(wire.NewTxOut(1000, []byte{txscript.OP_TRUE}))
+		switch i {
+		case 1:
+			tx.AddTxOut(wire.NewTxOut(0, commitmentScript(commitment1)))
+			tx.AddTxOut(wire.NewTxOut(0, commitmentScript(commitment1)))
+		case 2:
+			tx.AddTxOut(wire.NewTxOut(0, commitmentScript(commitment2)))
+			tx.AddTxOut(wire.NewTxOut(0, commitmentScript(commitment1)))
+		}
+		msgBlock.AddTransaction(tx)
+	}
+	block := provautil.NewBlock(msgBlock)
+
+	idx := NewCommitmentIndex(db)
+	err = db.Update(func(dbTx database.Tx) error {
+		if err := NewTxIndex(db).Create(dbTx); err != nil {
+			return err
+		}
+		if err := idx.Create(dbTx); err != nil {
+			return err
+		}
+		if err := dbPutBlockIDIndexEntry(dbTx, block.Hash(), 1); err != nil {
+			return err
+		}
+		return idx.ConnectBlock(dbTx, block, nil)
+	})
+	if err != nil {
+		t.Fatalf("ConnectBlock: %v", err)
+	}
+
+	// Ensure the regions of the committing transactions are found.
+	txLocs, err := block.TxLoc()
+	if err != nil {
+		t.Fatalf("TxLoc: %v", err)
+	}
+	tests := []struct {
+		commitment [txscript.CommitmentSize]byte
+		txIdxs     []int
+	}{
+		{commitment1, []int{1, 2}},
+		{commitment2, []int{2}},
+		{[txscript.CommitmentSize]byte{0x03}, nil},
+	}
+	for _, test := range tests {
+		regions, err := idx.TxRegionsForCommitment(test.commitment)
+		if err != nil {
+			t.Fatalf("TxRegionsForCommitment: %v", err)
+		}
+		if len(regions) != len(test.txIdxs) {
+			t.Errorf("TxRegionsForCommitment(%x): got %d regions, "+
+				"want %d", test.commitment[:1], len(regions),
+				len(test.txIdxs))
+			continue
+		}
+
+		// Entries are ordered by transaction hash, so match them
+		// regardless of order.
+		for _, txIdx := range test.txIdxs {
+			found := false
+			for _, region := range regions {
+				if region.Hash.IsEqual(block.Hash()) &&
+					region.Offset == uint32(txLocs[txIdx].TxStart) &&
+					region.Len == uint32(txLocs[txIdx].TxLen) {
+
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("TxRegionsForCommitment(%x): missing "+
+					"transaction %d", test.commitment[:1], txIdx)
+			}
+		}
+	}
+
+	// Ensure the serialized transactions can be loaded from the regions.
+	regions, err := idx.TxRegionsForCommitment(commitment2)
+	if err != nil {
+		t.Fatalf("TxRegionsForCommitment: %v", err)
+	}
+	var serialized bytes.Buffer
+	if err := msgBlock.Transactions[2].Serialize(&serialized); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	blockBytes, err := block.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	txBytes := blockBytes[regions[0].Offset : regions[0].Offset+regions[0].Len]
+	if !bytes.Equal(txBytes, serialized.Bytes()) {
+		t.Errorf("region does not match the committing transaction")
+	}
+
+	// Disconnecting the block removes all entries.
+	err = db.Update(func(dbTx database.Tx) error {
+		return idx.DisconnectBlock(dbTx, block, nil)
+	})
+	if err != nil {
+		t.Fatalf("DisconnectBlock: %v", err)
+	}
+	for _, commitment := range [][txscript.CommitmentSize]byte{
+		commitment1, commitment2,
+	} {
+		regions, err := idx.TxRegionsForCommitment(commitment)
+		if err != nil {
+			t.Fatalf("TxRegionsForCommitment: %v", err)
+		}
+		if len(regions) != 0 {
+			t.Errorf("TxRegionsForCommitment(%x): got %d regions "+
+				"after disconnect", commitment[:1], len(regions))
+		}
+	}
+}
@@ -11,6 +11,7 @@ package indexers
 
 import (
 	"encoding/binary"
+	"errors"
 
 	"github.com/pyx-partners/dmgd/blockchain"
 	"github.com/pyx-partners/dmgd/database"
@@ -21,6 +22,10 @@ var (
 	// byteOrder is the preferred byte order used for serializing numeric
 	// fields for storage in the database.
 	byteOrder = binary.LittleEndian
+
+	// errInterruptRequested indicates that an operation was cancelled due
+	// to a user-requested interrupt.
+	errInterruptRequested = errors.New("interrupt requested")
 )
 
 // NeedsInputser provides a generic interface for an indexer to specify the it
@@ -29,6 +34,14 @@ type NeedsInputser interface {
 	NeedsInputs() bool
 }
 
+// Verifier provides a generic interface for an indexer which is able to check
+// that its entries for a block in the main chain are intact.
+type Verifier interface {
+	// VerifyBlock returns a database corruption error when the index
+	// entries for the passed block are missing or do not match the block.
+	VerifyBlock(dbTx database.Tx, block *provautil.Block) error
+}
+
 // Indexer provides a generic interface for an indexer that is managed by an
 // index manager such as the Manager type provided by this package.
 type Indexer interface {
@@ -56,6 +69,19 @@ type Indexer interface {
 	DisconnectBlock(dbTx database.Tx, block *provautil.Block, view *blockchain.UtxoViewpoint) error
 }
 
+// ConcurrentIndexer provides an interface for an indexer which is able to
+// prepare the index entries of a connected block without accessing the
+// database, so the index manager can prepare them concurrently with the entries
+// of the other indexes.
+type ConcurrentIndexer interface {
+	// PrepareConnectBlock builds the index entries for the passed block and
+	// returns a function which writes them to the database.  It is invoked
+	// concurrently with the other indexers, so it MUST only read the block
+	// and view.  The returned function is invoked serially once the
+	// entries of all of the indexes are prepared.
+	PrepareConnectBlock(block *provautil.Block, view *blockchain.UtxoViewpoint) (func(dbTx database.Tx) error, error)
+}
+
 // AssertError identifies an error that indicates an internal code consistency
 // issue and should be treated as a critical and unrecoverable error.
 type AssertError string
@@ -82,6 +108,19 @@ func isDeserializeErr(err error) bool {
 	return ok
 }
 
+// interruptRequested returns true when the provided channel has been closed.
+// This simplifies early shutdown slightly since the caller can just use an if
+// statement instead of a select.
+func interruptRequested(interrupted <-chan struct{}) bool {
+	select {
+	case <-interrupted:
+		return true
+	default:
+	}
+
+	return false
+}
+
 // internalBucket is an abstraction over a database bucket.  It is used to make
 // the code easier to test since it allows mock objects in the tests to only
 // implement these functions instead of everything a database.Bucket supports.
@@ -9,6 +9,7 @@ package indexers
 import (
 	"bytes"
 	"fmt"
+	"sync"
 
 	"github.com/pyx-partners/dmgd/blockchain"
 	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
@@ -70,7 +71,11 @@ func dbFetchIndexerTip(dbTx database.Tx, idxKey []byte) (*chainhash.Hash, int32,
 // given block using the provided indexer and updates the tip of the indexer
 // accordingly.  An error will be returned if the current tip for the indexer is
 // not the previous block for the passed block.
-func dbIndexConnectBlock(dbTx database.Tx, indexer Indexer, block *provautil.Block, view *blockchain.UtxoViewpoint) error {
+//
+// The entries are written by the passed connect function when it is not nil,
+// which is the case when they were already prepared by a ConcurrentIndexer.
+// Otherwise, the indexer is notified with the connected block as normal.
+func dbIndexConnectBlock(dbTx database.Tx, indexer Indexer, block *provautil.Block, view *blockchain.UtxoViewpoint, connect func(database.Tx) error) error {
 	// Assert that the block being connected properly connects to the
 	// current tip of the index.
 	idxKey := indexer.Key()
@@ -85,8 +90,14 @@ func dbIndexConnectBlock(dbTx database.Tx, indexer Indexer, block *provautil.Blo
 			curTipHash, block.Hash()))
 	}
 
-	// Notify the indexer with the connected block so it can index it.
-	if err := indexer.ConnectBlock(dbTx, block, view); err != nil {
+	// Notify the indexer with the connected block so it can index it,
+	// unless its entries were already prepared.
+	if connect == nil {
+		connect = func(dbTx database.Tx) error {
+			return indexer.ConnectBlock(dbTx, block, view)
+		}
+	}
+	if err := connect(dbTx); err != nil {
 		return err
 	}
 
@@ -224,10 +235,12 @@ func (m *Manager) maybeCreateIndexes(dbTx database.Tx) error {
 // current best chain tip.  This is necessary since each index can be disabled
 // and re-enabled at any time and attempting to catch-up indexes at the same
 // time new blocks are being downloaded would lead to an overall longer time to
-// catch up due to the I/O contention.
+// catch up due to the I/O contention.  Each block is indexed in its own
+// database transaction, so an interrupted catch up resumes from the last
+// indexed block on the next start.
 //
 // This is part of the blockchain.IndexManager interface.
-func (m *Manager) Init(chain *blockchain.BlockChain) error {
+func (m *Manager) Init(chain *blockchain.BlockChain, interrupt <-chan struct{}) error {
 	// Nothing to do when no indexes are enabled.
 	if len(m.enabledIndexes) == 0 {
 		return nil
@@ -294,6 +307,9 @@ func (m *Manager) Init(chain *blockchain.BlockChain) error {
 			if exists {
 				break
 			}
+			if interruptRequested(interrupt) {
+				return errInterruptRequested
+			}
 
 			// At this point the index tip is orphaned, so load the
 			// orphaned block from the database directly and
@@ -391,6 +407,10 @@ func (m *Manager) Init(chain *blockchain.BlockChain) error {
 	log.Infof("Catching up indexes from height %d to %d", lowestHeight,
 		bestHeight)
 	for height := lowestHeight + 1; height <= bestHeight; height++ {
+		if interruptRequested(interrupt) {
+			return errInterruptRequested
+		}
+
 		// Load the block for the height since it is required to index
 		// it.
 		block, err := chain.BlockByHeight(uint32(height))
@@ -420,7 +440,7 @@ func (m *Manager) Init(chain *blockchain.BlockChain) error {
 					}
 				}
 				return dbIndexConnectBlock(dbTx, indexer, block,
-					view)
+					view, nil)
 			})
 			if err != nil {
 				return err
@@ -436,6 +456,107 @@ func (m *Manager) Init(chain *blockchain.BlockChain) error {
 	return nil
 }
 
+// IndexTip describes the block an index is caught up to.
+type IndexTip struct {
+	Name   string
+	Hash   chainhash.Hash
+	Height int32
+}
+
+// Tips returns the blocks the enabled indexes are caught up to, in the order
+// the indexes are enabled.
+//
+// This function is safe for concurrent access.
+func (m *Manager) Tips() ([]IndexTip, error) {
+	tips := make([]IndexTip, 0, len(m.enabledIndexes))
+	err := m.db.View(func(dbTx database.Tx) error {
+		for _, indexer := range m.enabledIndexes {
+			hash, height, err := dbFetchIndexerTip(dbTx, indexer.Key())
+			if err != nil {
+				return err
+			}
+			tips = append(tips, IndexTip{
+				Name:   indexer.Name(),
+				Hash:   *hash,
+				Height: height,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tips, nil
+}
+
+// Verify ensures every enabled index is caught up to the end of the main chain,
+// and that the entries of the indexes which implement the Verifier interface
+// are intact for every block in it.  Closing the interrupt channel, which may
+// be nil, stops the verification early with an error.
+func (m *Manager) Verify(chain *blockchain.BlockChain, interrupt <-chan struct{}) error {
+	// Nothing to do when no indexes are enabled.
+	if len(m.enabledIndexes) == 0 {
+		return nil
+	}
+
+	best := chain.BestSnapshot()
+	var verifiers []Verifier
+	err := m.db.View(func(dbTx database.Tx) error {
+		for _, indexer := range m.enabledIndexes {
+			hash, height, err := dbFetchIndexerTip(dbTx, indexer.Key())
+			if err != nil {
+				return err
+			}
+			if height != int32(best.Height) || !hash.IsEqual(best.Hash) {
+				return database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("%s tip (height "+
+						"%d, hash %v) does not match the "+
+						"best block (height %d, hash %v)",
+						indexer.Name(), height, hash,
+						best.Height, best.Hash),
+				}
+			}
+
+			if verifier, ok := indexer.(Verifier); ok {
+				verifiers = append(verifiers, verifier)
+			}
+		}
+		return nil
+	})
+	if err != nil || len(verifiers) == 0 {
+		return err
+	}
+
+	log.Infof("Verifying indexes up to height %d", best.Height)
+	progressLogger := newBlockProgressLogger("Verified", log)
+	for height := uint32(0); height <= best.Height; height++ {
+		if interruptRequested(interrupt) {
+			return errInterruptRequested
+		}
+
+		block, err := chain.BlockByHeight(height)
+		if err != nil {
+			return err
+		}
+		err = m.db.View(func(dbTx database.Tx) error {
+			for _, verifier := range verifiers {
+				if err := verifier.VerifyBlock(dbTx, block); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		progressLogger.LogBlockHeight(block)
+	}
+
+	return nil
+}
+
 // indexNeedsInputs returns whether or not the index needs access to the txouts
 // referenced by the transaction inputs being indexed.
 func indexNeedsInputs(index Indexer) bool {
@@ -506,16 +627,82 @@ func makeUtxoView(dbTx database.Tx, block *provautil.Block) (*blockchain.UtxoVie
 	return view, nil
 }
 
+// prepareConnectBlock prepares the index entries of the passed block for each
+// of the enabled indexes which implement the ConcurrentIndexer interface in
+// separate goroutines and waits for all of them to finish.  The returned
+// functions write the prepared entries and are nil for the indexes which are
+// notified with the block as normal.
+//
+// Nothing is prepared when less than two indexes implement the interface since
+// there would be nothing to gain.
+func (m *Manager) prepareConnectBlock(block *provautil.Block, view *blockchain.UtxoViewpoint) ([]func(database.Tx) error, error) {
+	connectFuncs := make([]func(database.Tx) error, len(m.enabledIndexes))
+	var numConcurrent int
+	for _, index := range m.enabledIndexes {
+		if _, ok := index.(ConcurrentIndexer); ok {
+			numConcurrent++
+		}
+	}
+	if numConcurrent < 2 {
+		return connectFuncs, nil
+	}
+
+	// The block and its transactions lazily cache their serialization and
+	// hashes, so generate them up front to ensure they are only read while
+	// the indexes are prepared concurrently.
+	if _, err := block.Bytes(); err != nil {
+		return nil, err
+	}
+	block.Hash()
+	for _, tx := range block.Transactions() {
+		tx.Hash()
+	}
+
+	errs := make([]error, len(m.enabledIndexes))
+	var wg sync.WaitGroup
+	for i, index := range m.enabledIndexes {
+		concurrentIndex, ok := index.(ConcurrentIndexer)
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, index ConcurrentIndexer) {
+			defer wg.Done()
+			connectFuncs[i], errs[i] = index.PrepareConnectBlock(block,
+				view)
+		}(i, concurrentIndex)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return connectFuncs, nil
+}
+
 // ConnectBlock must be invoked when a block is extending the main chain.  It
 // keeps track of the state of each index it is managing, performs some sanity
 // checks, and invokes each indexer.
 //
+// The entries of the indexes which implement the ConcurrentIndexer interface
+// are prepared concurrently and then written in the order the indexes are
+// enabled once all of them are prepared.
+//
 // This is part of the blockchain.IndexManager interface.
 func (m *Manager) ConnectBlock(dbTx database.Tx, block *provautil.Block, view *blockchain.UtxoViewpoint) error {
+	connectFuncs, err := m.prepareConnectBlock(block, view)
+	if err != nil {
+		return err
+	}
+
 	// Call each of the currently active optional indexes with the block
 	// being connected so they can update accordingly.
-	for _, index := range m.enabledIndexes {
-		err := dbIndexConnectBlock(dbTx, index, block, view)
+	for i, index := range m.enabledIndexes {
+		err := dbIndexConnectBlock(dbTx, index, block, view,
+			connectFuncs[i])
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,178 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/database"
+	_ "github.com/pyx-partners/dmgd/database/ffldb"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// mockIndex is an index which records the order its entries are written in.
+type mockIndex struct {
+	name    string
+	written *[]string
+}
+
+func (idx *mockIndex) Key() []byte                   { return []byte(idx.name) }
+func (idx *mockIndex) Name() string                  { return idx.name }
+func (idx *mockIndex) Create(dbTx database.Tx) error { return nil }
+func (idx *mockIndex) Init() error                   { return nil }
+
+func (idx *mockIndex) ConnectBlock(dbTx database.Tx, block *provautil.Block, view *blockchain.UtxoViewpoint) error {
+	*idx.written = append(*idx.written, idx.name)
+	return nil
+}
+
+func (idx *mockIndex) DisconnectBlock(dbTx database.Tx, block *provautil.Block, view *blockchain.UtxoViewpoint) error {
+	return nil
+}
+
+// mockConcurrentIndex is an index whose entries are only prepared once the
+// entries of another index are being prepared at the same time.
+type mockConcurrentIndex struct {
+	mockIndex
+	started chan struct{}
+	other   chan struct{}
+	err     error
+}
+
+func (idx *mockConcurrentIndex) PrepareConnectBlock(block *provautil.Block, view *blockchain.UtxoViewpoint) (func(dbTx database.Tx) error, error) {
+	close(idx.started)
+	select {
+	case <-idx.other:
+	case <-time.After(5 * time.Second):
+		return nil, errors.New("entries not prepared concurrently")
+	}
+	if idx.err != nil {
+		return nil, idx.err
+	}
+	return func(dbTx database.Tx) error {
+		*idx.written = append(*idx.written, idx.name)
+		return nil
+	}, nil
+}
+
+// TestManagerConcurrentConnect ensures the index manager prepares the entries
+// of the concurrent indexes at the same time and writes the entries of all of
+// the indexes in the order they are enabled, which the index tips report.
+func TestManagerConcurrentConnect(t *testing.T) {
+	dbPath, err := ioutil.TempDir("", "indexmanager")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", filepath.Join(dbPath, "db"),
+		wire.TestNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer db.Close()
+
+	block := provautil.NewBlock(wire.NewMsgBlock(&wire.BlockHeader{
+		PrevBlock: chainhash.Hash{0x01},
+		Height:    1,
+	}))
+	newIndexes := func(err error) ([]Indexer, *[]string) {
+		var written []string
+		started1 := make(chan struct{})
+		started2 := make(chan struct{})
+		return []Indexer{
+			&mockConcurrentIndex{
+				mockIndex: mockIndex{"concurrent1", &written},
+				started:   started1,
+				other:     started2,
+			},
+			&mockIndex{"serial", &written},
+			&mockConcurrentIndex{
+				mockIndex: mockIndex{"concurrent2", &written},
+				started:   started2,
+				other:     started1,
+				err:       err,
+			},
+		}, &written
+	}
+	connectBlock := func(indexes []Indexer) error {
+		return db.Update(func(dbTx database.Tx) error {
+			meta := dbTx.Metadata()
+			_, err := meta.CreateBucketIfNotExists(indexTipsBucketName)
+			if err != nil {
+				return err
+			}
+			for _, index := range indexes {
+				err := dbPutIndexerTip(dbTx, index.Key(),
+					&chainhash.Hash{0x01}, 0)
+				if err != nil {
+					return err
+				}
+			}
+			return NewManager(db, indexes).ConnectBlock(dbTx, block,
+				nil)
+		})
+	}
+
+	indexes, written := newIndexes(nil)
+	if err := connectBlock(indexes); err != nil {
+		t.Fatalf("ConnectBlock: %v", err)
+	}
+	want := []string{"concurrent1", "serial", "concurrent2"}
+	if !reflect.DeepEqual(*written, want) {
+		t.Fatalf("got entries written in order %v, want %v", *written,
+			want)
+	}
+	err = db.View(func(dbTx database.Tx) error {
+		for _, index := range indexes {
+			hash, height, err := dbFetchIndexerTip(dbTx, index.Key())
+			if err != nil {
+				return err
+			}
+			if !hash.IsEqual(block.Hash()) || height != 1 {
+				t.Errorf("got %s tip %s at height %d, want %s at "+
+					"height 1", index.Name(), hash, height,
+					block.Hash())
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("View: %v", err)
+	}
+	tips, err := NewManager(db, indexes).Tips()
+	if err != nil {
+		t.Fatalf("Tips: %v", err)
+	}
+	if len(tips) != len(indexes) {
+		t.Fatalf("got %d index tips, want %d", len(tips), len(indexes))
+	}
+	for i, tip := range tips {
+		if tip.Name != indexes[i].Name() ||
+			!tip.Hash.IsEqual(block.Hash()) || tip.Height != 1 {
+
+			t.Errorf("got index tip %+v, want %s at height 1 of %s",
+				tip, block.Hash(), indexes[i].Name())
+		}
+	}
+
+	// No entries are written when preparing any of them fails.
+	prepareErr := errors.New("prepare failed")
+	indexes, written = newIndexes(prepareErr)
+	if err := connectBlock(indexes); err != prepareErr {
+		t.Fatalf("got error %v, want %v", err, prepareErr)
+	}
+	if len(*written) != 0 {
+		t.Fatalf("got entries written for %v", *written)
+	}
+}
@@ -0,0 +1,439 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+)
+
+const (
+	// statsIndexName is the human-readable name for the index.
+	statsIndexName = "chain statistics index"
+
+	// statsPrefixDay is the prefix of the keys of the daily aggregates in
+	// the chain statistics index.
+	statsPrefixDay = 'd'
+
+	// statsPrefixAddr is the prefix of the keys of the addresses active on
+	// a day in the chain statistics index.
+	statsPrefixAddr = 'a'
+
+	// statsDayKeySize is the size of the keys of the daily aggregates.
+	// They consist of the prefix followed by the day.
+	statsDayKeySize = 1 + 4
+
+	// statsAddrKeySize is the size of the keys of the active addresses.
+	// They consist of the prefix, the day and the address key.
+	statsAddrKeySize = 1 + 4 + addrKeySize
+
+	// statsDaySize is the size of the serialized daily aggregates.
+	statsDaySize = 4 + 8 + 4 + 8 + 8 + 8
+
+	// secondsPerDay is the number of seconds in a day, which the block
+	// timestamps are divided by to find the day of a block.
+	secondsPerDay = 24 * 60 * 60
+)
+
+var (
+	// statsIndexKey is the key of the chain statistics index and the db
+	// bucket used to house it.
+	statsIndexKey = []byte("chainstatsidx")
+)
+
+// -----------------------------------------------------------------------------
+// The chain statistics index aggregates the blocks in the main chain by the UTC
+// day of their timestamp, for reporting on the activity of the chain without
+// scanning the blocks.  The days are numbered from 1 Jan 1970 and serialized
+// big endian, so the aggregates of a range of days can be iterated in order.
+//
+// The serialized format for the daily aggregates is:
+//
+//   'd'<day> = <blocks><transactions><active addresses><issued><destroyed><fees>
+//
+//   Field              Type      Size
+//   day                uint32    4 bytes
+//   blocks             uint32    4 bytes
+//   transactions       uint64    8 bytes
+//   active addresses   uint32    4 bytes
+//   issued             uint64    8 bytes
+//   destroyed          uint64    8 bytes
+//   fees               uint64    8 bytes
+//   -----
+//   Total: 40 bytes
+//
+// The issued and destroyed fields are the DMG issued and destroyed by the
+// transactions of the issue thread, and the fees field is the sum of the fees
+// paid by the transactions.
+//
+// The number of active addresses can not be derived from the addresses of the
+// individual blocks, since an address is commonly active in several blocks of
+// a day.  The index therefore keeps the addresses which are active on each day,
+// along with the number of blocks of the day they are active in, so an address
+// is only counted once and is no longer counted once all blocks it is active in
+// are disconnected:
+//
+//   'a'<day><address key> = <blocks>
+//
+//   Field           Type      Size
+//   day             uint32    4 bytes
+//   address key     [21]byte  21 bytes
+//   blocks          uint32    4 bytes
+//
+// An address is active in a block when a transaction of the block pays it or
+// spends an output paying it.
+// -----------------------------------------------------------------------------
+
+// ChainStats houses the aggregate statistics of the blocks in the main chain
+// with a timestamp on a UTC day.
+type ChainStats struct {
+	Day             time.Time
+	Blocks          uint32
+	Transactions    uint64
+	ActiveAddresses uint32
+	Issued          int64
+	Destroyed       int64
+	Fees            int64
+}
+
+// statsDay returns the number of the UTC day of the passed time since 1 Jan
+// 1970.
+func statsDay(t time.Time) uint32 {
+	return uint32(t.Unix() / secondsPerDay)
+}
+
+// statsDayKey returns the key of the aggregates of the passed day.
+func statsDayKey(day uint32) [statsDayKeySize]byte {
+	var key [statsDayKeySize]byte
+	key[0] = statsPrefixDay
+	binary.BigEndian.PutUint32(key[1:], day)
+	return key
+}
+
+// statsAddrKey returns the key of the passed address active on the passed day.
+func statsAddrKey(day uint32, addrKey [addrKeySize]byte) [statsAddrKeySize]byte {
+	var key [statsAddrKeySize]byte
+	key[0] = statsPrefixAddr
+	binary.BigEndian.PutUint32(key[1:5], day)
+	copy(key[5:], addrKey[:])
+	return key
+}
+
+// serializeChainStats returns the serialized aggregates of a day.
+func serializeChainStats(stats *ChainStats) []byte {
+	serialized := make([]byte, statsDaySize)
+	byteOrder.PutUint32(serialized[0:4], stats.Blocks)
+	byteOrder.PutUint64(serialized[4:12], stats.Transactions)
+	byteOrder.PutUint32(serialized[12:16], stats.ActiveAddresses)
+	byteOrder.PutUint64(serialized[16:24], uint64(stats.Issued))
+	byteOrder.PutUint64(serialized[24:32], uint64(stats.Destroyed))
+	byteOrder.PutUint64(serialized[32:40], uint64(stats.Fees))
+	return serialized
+}
+
+// deserializeChainStats returns the aggregates of the passed day from their
+// serialized form.
+func deserializeChainStats(day uint32, serialized []byte) (*ChainStats, error) {
+	if len(serialized) != statsDaySize {
+		return nil, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt chain statistics of "+
+				"day %d", day),
+		}
+	}
+
+	return &ChainStats{
+		Day:             time.Unix(int64(day)*secondsPerDay, 0).UTC(),
+		Blocks:          byteOrder.Uint32(serialized[0:4]),
+		Transactions:    byteOrder.Uint64(serialized[4:12]),
+		ActiveAddresses: byteOrder.Uint32(serialized[12:16]),
+		Issued:          int64(byteOrder.Uint64(serialized[16:24])),
+		Destroyed:       int64(byteOrder.Uint64(serialized[24:32])),
+		Fees:            int64(byteOrder.Uint64(serialized[32:40])),
+	}, nil
+}
+
+// blockStats returns the aggregates of the passed block along with the keys of
+// the addresses active in it.  The view must contain the outputs spent by the
+// block.
+func (idx *StatsIndex) blockStats(block *provautil.Block, view *blockchain.UtxoViewpoint) (*ChainStats, map[[addrKeySize]byte]struct{}) {
+	stats := &ChainStats{
+		Blocks:       1,
+		Transactions: uint64(len(block.Transactions())),
+	}
+	addrKeys := make(map[[addrKeySize]byte]struct{})
+	addPkScript := func(pkScript []byte) {
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript,
+			idx.chainParams)
+		if err != nil {
+			return
+		}
+		for _, addr := range addrs {
+			addrKey, err := addrToKey(addr)
+			if err != nil {
+				continue
+			}
+			addrKeys[addrKey] = struct{}{}
+		}
+	}
+
+	for txIdx, tx := range block.Transactions() {
+		var atomsIn, atomsOut int64
+		for _, txOut := range tx.MsgTx().TxOut {
+			addPkScript(txOut.PkScript)
+			atomsOut += txOut.Value
+		}
+
+		// Coinbases do not reference any inputs or pay fees.
+		if txIdx == 0 {
+			continue
+		}
+		for _, txIn := range tx.MsgTx().TxIn {
+			// The view should always have the input since the index
+			// contract requires it, however, be safe and simply
+			// ignore any missing entries.
+			origin := &txIn.PreviousOutPoint
+			entry := view.LookupEntry(&origin.Hash)
+			if entry == nil {
+				continue
+			}
+			addPkScript(entry.PkScriptByIndex(origin.Index))
+			atomsIn += entry.AmountByIndex(origin.Index)
+		}
+
+		// Issuances create more value than they spend, which is not a
+		// negative fee.
+		assetID, atoms := blockchain.TxSupplyChange(tx,
+			block.Height(), idx.chainParams)
+		if assetID == 0 && atoms > 0 {
+			stats.Issued += atoms
+		} else if assetID == 0 {
+			stats.Destroyed -= atoms
+		}
+		if atomsIn > atomsOut {
+			stats.Fees += atomsIn - atomsOut
+		}
+	}
+
+	return stats, addrKeys
+}
+
+// StatsIndex implements a chain statistics index.  That is to say, it
+// maintains daily aggregates of the blocks in the main chain, such as the
+// number of transactions and active addresses, the issued and destroyed value,
+// and the fees paid.
+type StatsIndex struct {
+	db          database.DB
+	chainParams *chaincfg.Params
+}
+
+// Ensure the StatsIndex type implements the Indexer, ConcurrentIndexer and
+// NeedsInputser interfaces.
+var _ Indexer = (*StatsIndex)(nil)
+var _ ConcurrentIndexer = (*StatsIndex)(nil)
+var _ NeedsInputser = (*StatsIndex)(nil)
+
+// NeedsInputs signals that the index requires the referenced inputs in order
+// to find the addresses spending outputs and the fees paid.
+//
+// This implements the NeedsInputser interface.
+func (idx *StatsIndex) NeedsInputs() bool {
+	return true
+}
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *StatsIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *StatsIndex) Key() []byte {
+	return statsIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *StatsIndex) Name() string {
+	return statsIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the chain
+// statistics index.
+//
+// This is part of the Indexer interface.
+func (idx *StatsIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(statsIndexKey)
+	return err
+}
+
+// updateDay adds the passed aggregates of a block and the keys of the addresses
+// active in it to those of its day, or removes them from it when the block is
+// disconnected.
+func (idx *StatsIndex) updateDay(dbTx database.Tx, block *provautil.Block, blockStats *ChainStats, addrKeys map[[addrKeySize]byte]struct{}, connect bool) error {
+	bucket := dbTx.Metadata().Bucket(statsIndexKey)
+	day := statsDay(block.MsgBlock().Header.Timestamp)
+	dayKey := statsDayKey(day)
+	stats := &ChainStats{}
+	if serialized := bucket.Get(dayKey[:]); serialized != nil {
+		var err error
+		stats, err = deserializeChainStats(day, serialized)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Count the addresses which become active on the day with the block,
+	// or which are no longer active without it.
+	for addrKey := range addrKeys {
+		key := statsAddrKey(day, addrKey)
+		var blocks uint32
+		if serialized := bucket.Get(key[:]); len(serialized) == 4 {
+			blocks = byteOrder.Uint32(serialized)
+		}
+		if connect {
+			blocks++
+			if blocks == 1 {
+				stats.ActiveAddresses++
+			}
+		} else if blocks > 0 {
+			blocks--
+			if blocks == 0 {
+				stats.ActiveAddresses--
+			}
+		}
+
+		if blocks == 0 {
+			if err := bucket.Delete(key[:]); err != nil {
+				return err
+			}
+			continue
+		}
+		var serialized [4]byte
+		byteOrder.PutUint32(serialized[:], blocks)
+		if err := bucket.Put(key[:], serialized[:]); err != nil {
+			return err
+		}
+	}
+
+	if connect {
+		stats.Blocks += blockStats.Blocks
+		stats.Transactions += blockStats.Transactions
+		stats.Issued += blockStats.Issued
+		stats.Destroyed += blockStats.Destroyed
+		stats.Fees += blockStats.Fees
+	} else {
+		stats.Blocks -= blockStats.Blocks
+		stats.Transactions -= blockStats.Transactions
+		stats.Issued -= blockStats.Issued
+		stats.Destroyed -= blockStats.Destroyed
+		stats.Fees -= blockStats.Fees
+	}
+
+	// Remove the aggregates of a day once its last block is disconnected.
+	if stats.Blocks == 0 {
+		return bucket.Delete(dayKey[:])
+	}
+	return bucket.Put(dayKey[:], serializeChainStats(stats))
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds the aggregates of the block
+// to those of its day.
+//
+// This is part of the Indexer interface.
+func (idx *StatsIndex) ConnectBlock(dbTx database.Tx, block *provautil.Block, view *blockchain.UtxoViewpoint) error {
+	connect, err := idx.PrepareConnectBlock(block, view)
+	if err != nil {
+		return err
+	}
+	return connect(dbTx)
+}
+
+// PrepareConnectBlock computes the aggregates of the passed block and returns a
+// function which adds them to those of its day.
+//
+// This is part of the ConcurrentIndexer interface.
+func (idx *StatsIndex) PrepareConnectBlock(block *provautil.Block, view *blockchain.UtxoViewpoint) (func(dbTx database.Tx) error, error) {
+	blockStats, addrKeys := idx.blockStats(block, view)
+	return func(dbTx database.Tx) error {
+		return idx.updateDay(dbTx, block, blockStats, addrKeys, true)
+	}, nil
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the aggregates of the
+// block from those of its day.
+//
+// This is part of the Indexer interface.
+func (idx *StatsIndex) DisconnectBlock(dbTx database.Tx, block *provautil.Block, view *blockchain.UtxoViewpoint) error {
+	blockStats, addrKeys := idx.blockStats(block, view)
+	return idx.updateDay(dbTx, block, blockStats, addrKeys, false)
+}
+
+// DailyStats returns the aggregates of the UTC days from the day of the passed
+// from time up to and including the day of the passed to time, oldest first.
+// Days without blocks in the main chain are left out.
+//
+// This function is safe for concurrent access.
+func (idx *StatsIndex) DailyStats(from, to time.Time) ([]*ChainStats, error) {
+	var days []*ChainStats
+	err := idx.db.View(func(dbTx database.Tx) error {
+		fromKey := statsDayKey(statsDay(from))
+		lastDay := statsDay(to)
+		cursor := dbTx.Metadata().Bucket(statsIndexKey).Cursor()
+		for ok := cursor.Seek(fromKey[:]); ok; ok = cursor.Next() {
+			key := cursor.Key()
+			if len(key) != statsDayKeySize || key[0] != statsPrefixDay {
+				break
+			}
+			day := binary.BigEndian.Uint32(key[1:])
+			if day > lastDay {
+				break
+			}
+
+			stats, err := deserializeChainStats(day, cursor.Value())
+			if err != nil {
+				return err
+			}
+			days = append(days, stats)
+		}
+		return nil
+	})
+	return days, err
+}
+
+// NewStatsIndex returns a new instance of an indexer that is used to maintain
+// daily aggregates of the blocks in the main chain.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewStatsIndex(db database.DB, chainParams *chaincfg.Params) *StatsIndex {
+	return &StatsIndex{
+		db:          db,
+		chainParams: chainParams,
+	}
+}
+
+// DropStatsIndex drops the chain statistics index from the provided database if
+// it exists.
+func DropStatsIndex(db database.DB) error {
+	return dropIndex(db, statsIndexKey, statsIndexName)
+}
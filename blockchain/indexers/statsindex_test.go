@@ -0,0 +1,214 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/database"
+	_ "github.com/pyx-partners/dmgd/database/ffldb"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// TestStatsIndex ensures the chain statistics index aggregates the blocks by
+// day, counts each active address once per day, and removes the aggregates of
+// disconnected blocks.
+func TestStatsIndex(t *testing.T) {
+	dbPath, err := ioutil.TempDir("", "statsindex")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", filepath.Join(dbPath, "db"),
+		wire.TestNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer db.Close()
+
+	params := &chaincfg.RegressionNetParams
+	provaScript := func(id byte) []byte {
+		pkHash := make([]byte, 20)
+		pkHash[0] = id
+		addr, err := provautil.NewAddressProva(pkHash,
+			[]btcec.KeyID{1, 2}, params)
+		if err != nil {
+			t.Fatalf("NewAddressProva: %v", err)
+		}
+		script, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			t.Fatalf("PayToAddrScript: %v", err)
+		}
+		return script
+	}
+	issueScript, err := txscript.ProvaThreadScript(provautil.IssueThread)
+	if err != nil {
+		t.Fatalf("ProvaThreadScript: %v", err)
+	}
+	destroyScript, err := txscript.NullDataScript(nil)
+	if err != nil {
+		t.Fatalf("NullDataScript: %v", err)
+	}
+	newTx := func(prevOuts []wire.OutPoint, outputs ...*wire.TxOut) *wire.MsgTx {
+		tx := wire.NewMsgTx(wire.TxVersion)
+		for _, prevOut := range prevOuts {
+			tx.AddTxIn(&wire.TxIn{PreviousOutPoint: prevOut})
+		}
+		for _, output := range outputs {
+			tx.AddTxOut(output)
+		}
+		return tx
+	}
+	coinbaseIn := []wire.OutPoint{{Index: wire.MaxPrevOutIndex}}
+	day1 := time.Unix(100*secondsPerDay, 0).UTC()
+	day2 := day1.Add(24 * time.Hour)
+
+	// The first block pays two addresses from the coinbase, spends one of
+	// the outputs with a fee and issues to a third address.
+	msgBlock1 := wire.NewMsgBlock(&wire.BlockHeader{
+		Height:    1,
+		Timestamp: day1.Add(10 * time.Second),
+	})
+	coinbase := newTx(coinbaseIn, wire.NewTxOut(1000, provaScript(1)),
+		wire.NewTxOut(2000, provaScript(2)))
+	issue := newTx([]wire.OutPoint{{Index: 7}}, wire.NewTxOut(0, issueScript),
+		wire.NewTxOut(5000, provaScript(3)))
+	msgBlock1.AddTransaction(coinbase)
+	msgBlock1.AddTransaction(newTx([]wire.OutPoint{{Hash: coinbase.TxHash()}},
+		wire.NewTxOut(900, provaScript(2))))
+	msgBlock1.AddTransaction(issue)
+	block1 := provautil.NewBlock(msgBlock1)
+
+	// The second block of the same day pays an address which is already
+	// active on the day and destroys an output of another one.
+	msgBlock2 := wire.NewMsgBlock(&wire.BlockHeader{
+		Height:    2,
+		Timestamp: day1.Add(time.Hour),
+	})
+	msgBlock2.AddTransaction(newTx(coinbaseIn,
+		wire.NewTxOut(1000, provaScript(1))))
+	msgBlock2.AddTransaction(newTx([]wire.OutPoint{{Hash: issue.TxHash()},
+		{Hash: coinbase.TxHash(), Index: 1}}, wire.NewTxOut(0, issueScript),
+		wire.NewTxOut(2000, destroyScript)))
+	block2 := provautil.NewBlock(msgBlock2)
+
+	// The third block is on the next day.
+	msgBlock3 := wire.NewMsgBlock(&wire.BlockHeader{
+		Height:    3,
+		Timestamp: day2.Add(time.Minute),
+	})
+	msgBlock3.AddTransaction(newTx(coinbaseIn,
+		wire.NewTxOut(1000, provaScript(4))))
+	block3 := provautil.NewBlock(msgBlock3)
+
+	view := blockchain.NewUtxoViewpoint()
+	view.AddTxOuts(provautil.NewTx(coinbase), 1)
+	view.AddTxOuts(provautil.NewTx(issue), 1)
+
+	idx := NewStatsIndex(db, params)
+	err = db.Update(func(dbTx database.Tx) error {
+		return idx.Create(dbTx)
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	for _, block := range []*provautil.Block{block1, block2, block3} {
+		err = db.Update(func(dbTx database.Tx) error {
+			return idx.ConnectBlock(dbTx, block, view)
+		})
+		if err != nil {
+			t.Fatalf("ConnectBlock: %v", err)
+		}
+	}
+
+	// checkStats ensures the index holds the passed daily aggregates.
+	checkStats := func(want ...*ChainStats) {
+		days, err := idx.DailyStats(day1, day2)
+		if err != nil {
+			t.Fatalf("DailyStats: %v", err)
+		}
+		if len(days) != len(want) {
+			t.Fatalf("got %d days, want %d", len(days), len(want))
+		}
+		for i := range want {
+			if !reflect.DeepEqual(days[i], want[i]) {
+				t.Errorf("day #%d: got %+v, want %+v", i, days[i],
+					want[i])
+			}
+		}
+	}
+	checkStats(&ChainStats{
+		Day:             day1,
+		Blocks:          2,
+		Transactions:    5,
+		ActiveAddresses: 3,
+		Issued:          5000,
+		Destroyed:       2000,
+		Fees:            100,
+	}, &ChainStats{
+		Day:             day2,
+		Blocks:          1,
+		Transactions:    1,
+		ActiveAddresses: 1,
+	})
+
+	// Only the requested days are returned.
+	days, err := idx.DailyStats(day2, day2.Add(48*time.Hour))
+	if err != nil {
+		t.Fatalf("DailyStats: %v", err)
+	}
+	if len(days) != 1 || !days[0].Day.Equal(day2) {
+		t.Fatalf("got %d days for the second day, want 1", len(days))
+	}
+
+	// Disconnecting the blocks removes their aggregates, while the
+	// addresses which are active in the remaining block of the day are
+	// still counted.
+	for _, block := range []*provautil.Block{block3, block2} {
+		err = db.Update(func(dbTx database.Tx) error {
+			return idx.DisconnectBlock(dbTx, block, view)
+		})
+		if err != nil {
+			t.Fatalf("DisconnectBlock: %v", err)
+		}
+	}
+	checkStats(&ChainStats{
+		Day:             day1,
+		Blocks:          1,
+		Transactions:    3,
+		ActiveAddresses: 3,
+		Issued:          5000,
+		Fees:            100,
+	})
+
+	err = db.Update(func(dbTx database.Tx) error {
+		return idx.DisconnectBlock(dbTx, block1, view)
+	})
+	if err != nil {
+		t.Fatalf("DisconnectBlock: %v", err)
+	}
+	checkStats()
+	err = db.View(func(dbTx database.Tx) error {
+		cursor := dbTx.Metadata().Bucket(statsIndexKey).Cursor()
+		if cursor.First() {
+			t.Errorf("entry %x left after disconnecting all blocks",
+				cursor.Key())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("View: %v", err)
+	}
+}
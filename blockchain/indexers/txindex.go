@@ -7,6 +7,7 @@
 package indexers
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 
@@ -225,15 +226,10 @@ func dbFetchTxIndexEntry(dbTx database.Tx, txHash *chainhash.Hash) (*database.Bl
 }
 
 // dbAddTxIndexEntries uses an existing database transaction to add a
-// transaction index entry for every transaction in the passed block.
-func dbAddTxIndexEntries(dbTx database.Tx, block *provautil.Block, blockID uint32) error {
-	// The offset and length of the transactions within the serialized
-	// block.
-	txLocs, err := block.TxLoc()
-	if err != nil {
-		return err
-	}
-
+// transaction index entry for every transaction in the passed block.  The
+// passed locations are the offsets and lengths of the transactions within the
+// serialized block.
+func dbAddTxIndexEntries(dbTx database.Tx, block *provautil.Block, txLocs []wire.TxLoc, blockID uint32) error {
 	// As an optimization, allocate a single slice big enough to hold all
 	// of the serialized transaction index entries for the block and
 	// serialize them directly into the slice.  Then, pass the appropriate
@@ -288,8 +284,11 @@ type TxIndex struct {
 	curBlockID uint32
 }
 
-// Ensure the TxIndex type implements the Indexer interface.
+// Ensure the TxIndex type implements the Indexer, ConcurrentIndexer and
+// Verifier interfaces.
 var _ Indexer = (*TxIndex)(nil)
+var _ ConcurrentIndexer = (*TxIndex)(nil)
+var _ Verifier = (*TxIndex)(nil)
 
 // Init initializes the hash-based transaction index.  In particular, it finds
 // the highest used block ID and stores it for later use when connecting or
@@ -391,21 +390,46 @@ func (idx *TxIndex) Create(dbTx database.Tx) error {
 //
 // This is part of the Indexer interface.
 func (idx *TxIndex) ConnectBlock(dbTx database.Tx, block *provautil.Block, view *blockchain.UtxoViewpoint) error {
-	// Increment the internal block ID to use for the block being connected
-	// and add all of the transactions in the block to the index.
-	newBlockID := idx.curBlockID + 1
-	if err := dbAddTxIndexEntries(dbTx, block, newBlockID); err != nil {
+	connect, err := idx.PrepareConnectBlock(block, view)
+	if err != nil {
 		return err
 	}
+	return connect(dbTx)
+}
 
-	// Add the new block ID index entry for the block being connected and
-	// update the current internal block ID accordingly.
-	err := dbPutBlockIDIndexEntry(dbTx, block.Hash(), newBlockID)
+// PrepareConnectBlock locates the transactions within the passed serialized
+// block and returns a function which adds a hash-to-transaction mapping for
+// every one of them.
+//
+// This is part of the ConcurrentIndexer interface.
+func (idx *TxIndex) PrepareConnectBlock(block *provautil.Block, view *blockchain.UtxoViewpoint) (func(dbTx database.Tx) error, error) {
+	// The offset and length of the transactions within the serialized
+	// block.
+	txLocs, err := block.TxLoc()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	idx.curBlockID = newBlockID
-	return nil
+
+	return func(dbTx database.Tx) error {
+		// Increment the internal block ID to use for the block being
+		// connected and add all of the transactions in the block to
+		// the index.
+		newBlockID := idx.curBlockID + 1
+		err := dbAddTxIndexEntries(dbTx, block, txLocs, newBlockID)
+		if err != nil {
+			return err
+		}
+
+		// Add the new block ID index entry for the block being
+		// connected and update the current internal block ID
+		// accordingly.
+		err = dbPutBlockIDIndexEntry(dbTx, block.Hash(), newBlockID)
+		if err != nil {
+			return err
+		}
+		idx.curBlockID = newBlockID
+		return nil
+	}, nil
 }
 
 // DisconnectBlock is invoked by the index manager when a block has been
@@ -428,6 +452,41 @@ func (idx *TxIndex) DisconnectBlock(dbTx database.Tx, block *provautil.Block, vi
 	return nil
 }
 
+// VerifyBlock ensures the transaction index maps every transaction in the
+// passed block to its location within the block.
+//
+// This is part of the Verifier interface.
+func (idx *TxIndex) VerifyBlock(dbTx database.Tx, block *provautil.Block) error {
+	txLocs, err := block.TxLoc()
+	if err != nil {
+		return err
+	}
+	blockID, err := dbFetchBlockIDByHash(dbTx, block.Hash())
+	if err != nil {
+		return database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("block %v is missing from the "+
+				"block ID index: %v", block.Hash(), err),
+		}
+	}
+
+	txIndex := dbTx.Metadata().Bucket(txIndexKey)
+	expected := make([]byte, txEntrySize)
+	for i, tx := range block.Transactions() {
+		putTxIndexEntry(expected, blockID, txLocs[i])
+		if !bytes.Equal(txIndex.Get(tx.Hash()[:]), expected) {
+			return database.Error{
+				ErrorCode: database.ErrCorruption,
+				Description: fmt.Sprintf("transaction index "+
+					"entry for %v in block %v is missing "+
+					"or corrupt", tx.Hash(), block.Hash()),
+			}
+		}
+	}
+
+	return nil
+}
+
 // TxBlockRegion returns the block region for the provided transaction hash
 // from the transaction index.  The block region can in turn be used to load the
 // raw transaction bytes.  When there is no entry for the provided hash, nil
@@ -469,12 +528,16 @@ func dropBlockIDIndex(db database.DB) error {
 }
 
 // DropTxIndex drops the transaction index from the provided database if it
-// exists.  Since the address index relies on it, the address index will also be
-// dropped when it exists.
+// exists.  Since the address and commitment indexes rely on it, they will also
+// be dropped when they exist.
 func DropTxIndex(db database.DB) error {
 	if err := dropIndex(db, addrIndexKey, addrIndexName); err != nil {
 		return err
 	}
+	err := dropIndex(db, commitmentIndexKey, commitmentIndexName)
+	if err != nil {
+		return err
+	}
 
 	return dropIndex(db, txIndexKey, txIndexName)
 }
@@ -0,0 +1,1388 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/provautil/hdkeychain"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+const (
+	// watchIndexName is the human-readable name for the index.
+	watchIndexName = "watch-only index"
+
+	// watchKeySize is the size of the keys of the watched addresses, which
+	// are the public key hashes of the addresses.
+	watchKeySize = 20
+
+	// watchPrefixOutput is the prefix of the keys of the unspent outputs
+	// in the watch-only index.
+	watchPrefixOutput = 'u'
+
+	// watchPrefixTx is the prefix of the keys of the transaction entries
+	// in the watch-only index.
+	watchPrefixTx = 't'
+
+	// watchPrefixRemoved is the prefix of the keys of the transaction
+	// entries of blocks which were disconnected from the main chain.
+	watchPrefixRemoved = 'r'
+
+	// watchOutputKeySize is the size of the keys of the unspent outputs.
+	// They consist of the prefix followed by the outpoint.
+	watchOutputKeySize = 1 + chainhash.HashSize + 4
+
+	// watchTxKeySize is the size of the keys of the transaction entries.
+	// They consist of the prefix, the block height, the index of the
+	// transaction in the block, the category, and the index of the output
+	// or input.
+	watchTxKeySize = 1 + 4 + 4 + 1 + 4
+
+	// watchRemovedKeySize is the size of the keys of the removed
+	// transaction entries.  They consist of the prefix and the hash of the
+	// disconnected block, followed by the fields of the key of the entry.
+	watchRemovedKeySize = 1 + chainhash.HashSize + watchTxKeySize - 1
+
+	// watchTxEntryMinSize is the size of a serialized transaction entry
+	// without the public key script.
+	watchTxEntryMinSize = chainhash.HashSize + chainhash.HashSize + 4 + 4 + 8
+
+	// watchXpubMinSize is the size of a serialized watched extended key
+	// without the key ids and the label.
+	watchXpubMinSize = 4 + 4 + 1
+
+	// watchXpubBranch is the index of the external branch of an account
+	// extended key, which the deposit addresses are derived from as in
+	// BIP0044.
+	watchXpubBranch = 0
+
+	// MaxWatchGapLimit is the maximum gap limit of a watched account
+	// extended key.
+	MaxWatchGapLimit = 10000
+)
+
+var (
+	// watchIndexKey is the key of the watch-only index and the db bucket
+	// used to house it.
+	watchIndexKey = []byte("watchidx")
+
+	// watchedAddrsBucketName is the name of the db bucket which maps the
+	// watched addresses to their labels.  It is kept apart from the index,
+	// so the watched addresses survive dropping and rebuilding the index.
+	watchedAddrsBucketName = []byte("watchedaddrs")
+
+	// watchedXpubsBucketName is the name of the db bucket which holds the
+	// watched account extended public keys.  Like the watched addresses,
+	// it is kept apart from the index.
+	watchedXpubsBucketName = []byte("watchedxpubs")
+
+	// errWatchKeySize is an error that is used to signal a public key hash
+	// of the wrong size has been used.
+	errWatchKeySize = errors.New("public key hash must be 20 bytes")
+)
+
+// -----------------------------------------------------------------------------
+// The watch-only index tracks the unspent outputs and the transaction history
+// of a set of watched addresses, which are imported with a label at runtime.
+// Like the address index, it identifies Prova addresses by their public key
+// hash, so an address and all addresses sharing its public key hash with
+// different key IDs are watched together.  It does not require the
+// transaction index, since it looks up spent outputs in its own unspent
+// outputs.
+//
+// The watched addresses are kept in a separate bucket which maps the public key
+// hash of each watched address to its label:
+//
+//   <pubkey hash> = <label>
+//
+// The watched account extended public keys are kept in another separate bucket.
+// The deposit addresses of each key are derived from its external branch and
+// watched ahead of use, up to the gap limit of addresses past the last address
+// which received an output.  The derived addresses are not stored, but derived
+// again when the index is loaded:
+//
+//   <xpub> = <gap limit><used><num key ids><key ids><label>
+//
+//   Field           Type              Size
+//   xpub            string            variable
+//   gap limit       uint32            4 bytes
+//   used            uint32            4 bytes
+//   num key ids     uint8             1 byte
+//   key ids         []uint32          4 bytes each
+//   label           string            variable
+//
+// The used field is the number of addresses up to and including the last
+// derived address which received an output.
+//
+// The index bucket holds the unspent outputs paying the watched addresses and
+// the transaction entries crediting or debiting them.  The keys of the
+// transaction entries are ordered by the block height, so the entries of a
+// block can be found and removed when it is disconnected.
+//
+// The serialized format for the unspent outputs is:
+//
+//   'u'<txhash><index> = <height><amount><pkscript>
+//
+//   Field           Type              Size
+//   txhash          chainhash.Hash    32 bytes
+//   index           uint32 (BE)       4 bytes
+//   height          uint32            4 bytes
+//   amount          int64             8 bytes
+//   pkscript        []byte            variable
+//
+// The serialized format for the transaction entries is:
+//
+//   't'<height><tx index><category><index> =
+//     <txhash><out txhash><out index><out height><amount><pkscript>
+//
+//   Field           Type              Size
+//   height          uint32 (BE)       4 bytes
+//   tx index        uint32 (BE)       4 bytes
+//   category        uint8             1 byte
+//   index           uint32 (BE)       4 bytes
+//   txhash          chainhash.Hash    32 bytes
+//   out txhash      chainhash.Hash    32 bytes
+//   out index       uint32            4 bytes
+//   out height      uint32            4 bytes
+//   amount          int64             8 bytes
+//   pkscript        []byte            variable
+//
+// The index in the key is the index of the output for received outputs and the
+// index of the spending input for sent outputs.  The out fields describe the
+// watched output which is received or sent, so it can be restored to the
+// unspent outputs when the block spending it is disconnected.
+//
+// The transaction entries of a block which is disconnected from the main chain
+// are moved to removed entries, so the transactions which are no longer in the
+// main chain can be reported after a reorganization.  They are kept until the
+// block is connected again:
+//
+//   'r'<block hash><height><tx index><category><index> = <as above>
+//
+//   Field           Type              Size
+//   block hash      chainhash.Hash    32 bytes
+//   remaining fields as above
+// -----------------------------------------------------------------------------
+
+// watchKey identifies a watched address by its public key hash.
+type watchKey [watchKeySize]byte
+
+// WatchCategory identifies whether a watch-only transaction entry debits or
+// credits a watched address.
+type WatchCategory byte
+
+// These constants define the categories of watch-only transaction entries.
+const (
+	// WatchSend indicates the transaction spends an output paying a
+	// watched address.
+	WatchSend WatchCategory = iota
+
+	// WatchReceive indicates the transaction creates an output paying a
+	// watched address.
+	WatchReceive
+)
+
+// String returns the category as used by the wallet RPCs.
+func (c WatchCategory) String() string {
+	if c == WatchSend {
+		return "send"
+	}
+	return "receive"
+}
+
+// WatchedOutput describes an output paying a watched address.
+type WatchedOutput struct {
+	OutPoint wire.OutPoint
+	Height   uint32
+	Amount   int64
+	PkScript []byte
+	Label    string
+}
+
+// WatchedTxEntry describes a transaction in the main chain which creates or
+// spends an output paying a watched address.
+type WatchedTxEntry struct {
+	Category WatchCategory
+	Height   uint32
+	TxIndex  uint32
+	TxHash   chainhash.Hash
+
+	// Index is the index of the output for received outputs, and the
+	// index of the input spending the output for sent outputs.
+	Index uint32
+
+	// Output is the watched output the transaction creates or spends.
+	Output WatchedOutput
+}
+
+// watchOutputKey returns the key of the unspent output for the passed
+// outpoint.
+func watchOutputKey(outPoint *wire.OutPoint) []byte {
+	key := make([]byte, watchOutputKeySize)
+	key[0] = watchPrefixOutput
+	copy(key[1:], outPoint.Hash[:])
+	binary.BigEndian.PutUint32(key[1+chainhash.HashSize:], outPoint.Index)
+	return key
+}
+
+// watchTxKey returns the key of the passed transaction entry.
+func watchTxKey(entry *WatchedTxEntry) []byte {
+	key := make([]byte, watchTxKeySize)
+	key[0] = watchPrefixTx
+	binary.BigEndian.PutUint32(key[1:5], entry.Height)
+	binary.BigEndian.PutUint32(key[5:9], entry.TxIndex)
+	key[9] = byte(entry.Category)
+	binary.BigEndian.PutUint32(key[10:14], entry.Index)
+	return key
+}
+
+// watchRemovedKey returns the key of the passed transaction entry of the
+// disconnected block with the passed hash.
+func watchRemovedKey(blockHash *chainhash.Hash, entry *WatchedTxEntry) []byte {
+	key := make([]byte, watchRemovedKeySize)
+	key[0] = watchPrefixRemoved
+	copy(key[1:], blockHash[:])
+	copy(key[1+chainhash.HashSize:], watchTxKey(entry)[1:])
+	return key
+}
+
+// watchRemovedPrefix returns the prefix of the keys of the removed transaction
+// entries of the disconnected block with the passed hash.
+func watchRemovedPrefix(blockHash *chainhash.Hash) []byte {
+	prefix := make([]byte, 1+chainhash.HashSize)
+	prefix[0] = watchPrefixRemoved
+	copy(prefix[1:], blockHash[:])
+	return prefix
+}
+
+// watchTxHeightPrefix returns the prefix of the keys of the transaction entries
+// of the block at the passed height.
+func watchTxHeightPrefix(height uint32) []byte {
+	prefix := make([]byte, 5)
+	prefix[0] = watchPrefixTx
+	binary.BigEndian.PutUint32(prefix[1:], height)
+	return prefix
+}
+
+// serializeWatchedOutput serializes the passed unspent output.
+func serializeWatchedOutput(output *WatchedOutput) []byte {
+	serialized := make([]byte, 12+len(output.PkScript))
+	byteOrder.PutUint32(serialized[0:4], output.Height)
+	byteOrder.PutUint64(serialized[4:12], uint64(output.Amount))
+	copy(serialized[12:], output.PkScript)
+	return serialized
+}
+
+// deserializeWatchedOutput deserializes the unspent output with the passed key
+// and serialized value.
+func deserializeWatchedOutput(key, serialized []byte) (*WatchedOutput, error) {
+	if len(key) != watchOutputKeySize || len(serialized) < 12 {
+		return nil, errDeserialize(fmt.Sprintf("corrupt watched output "+
+			"%x", key))
+	}
+
+	output := &WatchedOutput{
+		Height:   byteOrder.Uint32(serialized[0:4]),
+		Amount:   int64(byteOrder.Uint64(serialized[4:12])),
+		PkScript: append([]byte(nil), serialized[12:]...),
+	}
+	copy(output.OutPoint.Hash[:], key[1:])
+	output.OutPoint.Index = binary.BigEndian.Uint32(
+		key[1+chainhash.HashSize:])
+	return output, nil
+}
+
+// serializeWatchedTxEntry serializes the value of the passed transaction entry.
+func serializeWatchedTxEntry(entry *WatchedTxEntry) []byte {
+	output := &entry.Output
+	serialized := make([]byte, watchTxEntryMinSize+len(output.PkScript))
+	copy(serialized[0:32], entry.TxHash[:])
+	copy(serialized[32:64], output.OutPoint.Hash[:])
+	byteOrder.PutUint32(serialized[64:68], output.OutPoint.Index)
+	byteOrder.PutUint32(serialized[68:72], output.Height)
+	byteOrder.PutUint64(serialized[72:80], uint64(output.Amount))
+	copy(serialized[80:], output.PkScript)
+	return serialized
+}
+
+// deserializeWatchedTxEntry deserializes the transaction entry with the passed
+// key and serialized value.
+func deserializeWatchedTxEntry(key, serialized []byte) (*WatchedTxEntry, error) {
+	if len(key) != watchTxKeySize ||
+		len(serialized) < watchTxEntryMinSize {
+
+		return nil, errDeserialize(fmt.Sprintf("corrupt watch-only "+
+			"transaction entry %x", key))
+	}
+
+	entry := &WatchedTxEntry{
+		Height:   binary.BigEndian.Uint32(key[1:5]),
+		TxIndex:  binary.BigEndian.Uint32(key[5:9]),
+		Category: WatchCategory(key[9]),
+		Index:    binary.BigEndian.Uint32(key[10:14]),
+	}
+	copy(entry.TxHash[:], serialized[0:32])
+	output := &entry.Output
+	copy(output.OutPoint.Hash[:], serialized[32:64])
+	output.OutPoint.Index = byteOrder.Uint32(serialized[64:68])
+	output.Height = byteOrder.Uint32(serialized[68:72])
+	output.Amount = int64(byteOrder.Uint64(serialized[72:80]))
+	output.PkScript = append([]byte(nil), serialized[80:]...)
+	return entry, nil
+}
+
+// dbFetchWatchedOutput fetches the unspent output for the passed outpoint from
+// the passed watch-only index bucket.  It returns nil when the outpoint is not
+// an unspent output paying a watched address.
+func dbFetchWatchedOutput(bucket internalBucket, outPoint *wire.OutPoint) (*WatchedOutput, error) {
+	key := watchOutputKey(outPoint)
+	serialized := bucket.Get(key)
+	if serialized == nil {
+		return nil, nil
+	}
+	return deserializeWatchedOutput(key, serialized)
+}
+
+// dbPutWatchedTxEntries stores the passed transaction entries in the passed
+// watch-only index bucket, and adds the received outputs to and removes the
+// sent outputs from the unspent outputs.  The entries must be in the order of
+// the transactions in the chain.
+func dbPutWatchedTxEntries(bucket internalBucket, entries []WatchedTxEntry) error {
+	for i := range entries {
+		entry := &entries[i]
+		err := bucket.Put(watchTxKey(entry), serializeWatchedTxEntry(entry))
+		if err != nil {
+			return err
+		}
+
+		outputKey := watchOutputKey(&entry.Output.OutPoint)
+		if entry.Category == WatchSend {
+			err = bucket.Delete(outputKey)
+		} else {
+			err = bucket.Put(outputKey,
+				serializeWatchedOutput(&entry.Output))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// watchedXpub describes a watched account extended public key, whose deposit
+// addresses are derived and watched ahead of use.
+type watchedXpub struct {
+	xpub     string
+	branch   *hdkeychain.ExtendedKey
+	keyIDs   []btcec.KeyID
+	label    string
+	gapLimit uint32
+
+	// used is the number of addresses up to and including the last one
+	// which received an output.  pkHashes holds the public key hashes of
+	// the derived addresses by index, which is nil for the rare indexes
+	// which do not derive a valid key.
+	used     uint32
+	pkHashes [][]byte
+}
+
+// newWatchedXpub returns a new watched extended key for the passed account
+// extended public key with no used addresses.
+func newWatchedXpub(xpub *hdkeychain.ExtendedKey, keyIDs []btcec.KeyID, gapLimit uint32, label string) (*watchedXpub, error) {
+	branch, err := xpub.Child(watchXpubBranch)
+	if err != nil {
+		return nil, err
+	}
+
+	return &watchedXpub{
+		xpub:     xpub.String(),
+		branch:   branch,
+		keyIDs:   keyIDs,
+		label:    label,
+		gapLimit: gapLimit,
+	}, nil
+}
+
+// derive derives the addresses up to the gap limit past the used addresses, and
+// returns the indexes of the newly derived addresses.
+func (x *watchedXpub) derive() ([]uint32, error) {
+	var derived []uint32
+	for uint32(len(x.pkHashes)) < x.used+x.gapLimit {
+		index := uint32(len(x.pkHashes))
+		child, err := x.branch.Child(index)
+		if err == hdkeychain.ErrInvalidChild {
+			x.pkHashes = append(x.pkHashes, nil)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		pubKey, err := child.ECPubKey()
+		if err != nil {
+			return nil, err
+		}
+
+		x.pkHashes = append(x.pkHashes,
+			provautil.Hash160(pubKey.SerializeCompressed()))
+		derived = append(derived, index)
+	}
+	return derived, nil
+}
+
+// serializeWatchedXpub serializes the passed watched extended key.
+func serializeWatchedXpub(x *watchedXpub) []byte {
+	keyIDsLen := len(x.keyIDs) * btcec.KeyIDSize
+	serialized := make([]byte, watchXpubMinSize+keyIDsLen+len(x.label))
+	byteOrder.PutUint32(serialized[0:4], x.gapLimit)
+	byteOrder.PutUint32(serialized[4:8], x.used)
+	serialized[8] = byte(len(x.keyIDs))
+	offset := watchXpubMinSize
+	for _, keyID := range x.keyIDs {
+		byteOrder.PutUint32(serialized[offset:], uint32(keyID))
+		offset += btcec.KeyIDSize
+	}
+	copy(serialized[offset:], x.label)
+	return serialized
+}
+
+// deserializeWatchedXpub deserializes the watched extended key with the passed
+// key and serialized value, and derives its addresses.
+func deserializeWatchedXpub(key, serialized []byte) (*watchedXpub, error) {
+	if len(serialized) < watchXpubMinSize ||
+		len(serialized) < watchXpubMinSize+
+			int(serialized[8])*btcec.KeyIDSize {
+
+		return nil, errDeserialize(fmt.Sprintf("corrupt watched "+
+			"extended key %s", key))
+	}
+	xpub, err := hdkeychain.NewKeyFromString(string(key))
+	if err != nil {
+		return nil, errDeserialize(fmt.Sprintf("corrupt watched "+
+			"extended key %s: %v", key, err))
+	}
+
+	keyIDs := make([]btcec.KeyID, serialized[8])
+	offset := watchXpubMinSize
+	for i := range keyIDs {
+		keyIDs[i] = btcec.KeyID(byteOrder.Uint32(serialized[offset:]))
+		offset += btcec.KeyIDSize
+	}
+	x, err := newWatchedXpub(xpub, keyIDs, byteOrder.Uint32(serialized[0:4]),
+		string(serialized[offset:]))
+	if err != nil {
+		return nil, err
+	}
+	x.used = byteOrder.Uint32(serialized[4:8])
+	if _, err := x.derive(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// markUsed marks the derived addresses the passed receive entries pay as used,
+// and derives the addresses needed to keep the gap limit.  The derived address
+// a public key script pays, if any, is looked up with the passed function.  It
+// returns whether any address was derived.
+func markUsed(entries []WatchedTxEntry, derivedAddr func([]byte) (*watchedXpub, uint32, bool), onDerive func(*watchedXpub, []uint32)) (bool, error) {
+	extended := false
+	for i := range entries {
+		if entries[i].Category != WatchReceive {
+			continue
+		}
+		x, index, ok := derivedAddr(entries[i].Output.PkScript)
+		if !ok || index < x.used {
+			continue
+		}
+
+		x.used = index + 1
+		derived, err := x.derive()
+		if err != nil {
+			return false, err
+		}
+		onDerive(x, derived)
+		extended = true
+	}
+	return extended, nil
+}
+
+// watchedChild identifies an address derived from a watched extended key.
+type watchedChild struct {
+	xpub  *watchedXpub
+	index uint32
+}
+
+// WatchedXpubInfo describes a watched account extended public key.
+type WatchedXpubInfo struct {
+	KeyIDs   []btcec.KeyID
+	Label    string
+	GapLimit uint32
+
+	// Used is the number of addresses up to and including the last one
+	// which received an output, and Derived is the number of watched
+	// addresses.
+	Used    uint32
+	Derived uint32
+
+	// NextPkHash is the public key hash of the first derived address after
+	// the used ones, which is the next address to hand out for deposits.
+	NextPkHash []byte
+}
+
+// WatchedAddrInfo describes a watched address.
+type WatchedAddrInfo struct {
+	Label string
+
+	// Xpub is the serialized watched account extended public key the
+	// address is derived from at Index, or empty when the address was
+	// imported on its own.
+	Xpub  string
+	Index uint32
+}
+
+// watchKeyForPkHash returns the key of the address with the passed public key
+// hash.
+func watchKeyForPkHash(pkHash []byte) (watchKey, error) {
+	var key watchKey
+	if len(pkHash) != watchKeySize {
+		return key, errWatchKeySize
+	}
+	copy(key[:], pkHash)
+	return key, nil
+}
+
+// WatchIndex implements a watch-only index.  That is to say, it tracks the
+// unspent outputs and the transactions of the main chain which involve a set of
+// imported addresses, each with a label, so the balance and history of the
+// addresses can be queried without an external wallet.
+type WatchIndex struct {
+	// The following fields are set when the instance is created and can't
+	// be changed afterwards, so there is no need to protect them with a
+	// separate mutex.
+	db          database.DB
+	chainParams *chaincfg.Params
+
+	// watched maps the public key hashes of the watched addresses to their
+	// labels, including the addresses derived from the watched extended
+	// keys.  xpubs maps the serialized watched extended keys to their
+	// state, and derived maps the public key hashes of the addresses
+	// derived from them to the key and index they are derived at.  They
+	// are protected by the mtx field.
+	mtx     sync.RWMutex
+	watched map[watchKey]string
+	xpubs   map[string]*watchedXpub
+	derived map[watchKey]watchedChild
+}
+
+// Ensure the WatchIndex type implements the Indexer interface.
+var _ Indexer = (*WatchIndex)(nil)
+
+// Init loads the watched addresses and their labels, and the watched extended
+// keys, whose addresses it derives.
+//
+// This is part of the Indexer interface.
+func (idx *WatchIndex) Init() error {
+	watched := make(map[watchKey]string)
+	var xpubs []*watchedXpub
+	err := idx.db.View(func(dbTx database.Tx) error {
+		xpubBucket := dbTx.Metadata().Bucket(watchedXpubsBucketName)
+		if xpubBucket != nil {
+			err := xpubBucket.ForEach(func(k, v []byte) error {
+				x, err := deserializeWatchedXpub(k, v)
+				if err != nil {
+					return database.Error{
+						ErrorCode:   database.ErrCorruption,
+						Description: err.Error(),
+					}
+				}
+				xpubs = append(xpubs, x)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		bucket := dbTx.Metadata().Bucket(watchedAddrsBucketName)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			if len(k) != watchKeySize {
+				return database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("corrupt "+
+						"watched address %x", k),
+				}
+			}
+			var key watchKey
+			copy(key[:], k)
+			watched[key] = string(v)
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	idx.mtx.Lock()
+	idx.watched = watched
+	idx.xpubs = make(map[string]*watchedXpub)
+	idx.derived = make(map[watchKey]watchedChild)
+	for _, x := range xpubs {
+		idx.addXpub(x)
+	}
+	idx.mtx.Unlock()
+	return nil
+}
+
+// addXpub adds the passed extended key and the addresses derived from it to the
+// watched keys and addresses, replacing the key when it is already watched.
+//
+// This function MUST be called with the index lock held (for writes).
+func (idx *WatchIndex) addXpub(x *watchedXpub) {
+	idx.xpubs[x.xpub] = x
+	for index := range x.pkHashes {
+		idx.addDerived(x, uint32(index))
+	}
+}
+
+// addDerived adds the address derived at the passed index of the passed
+// extended key to the watched addresses.
+//
+// This function MUST be called with the index lock held (for writes).
+func (idx *WatchIndex) addDerived(x *watchedXpub, index uint32) {
+	pkHash := x.pkHashes[index]
+	if pkHash == nil {
+		return
+	}
+	var key watchKey
+	copy(key[:], pkHash)
+	idx.watched[key] = x.label
+	idx.derived[key] = watchedChild{xpub: x, index: index}
+}
+
+// derivedAddr returns the watched extended key and index of the derived address
+// the passed public key script pays, and whether the script pays one.
+//
+// This function MUST be called with the index lock held (for reads).
+func (idx *WatchIndex) derivedAddr(pkScript []byte) (*watchedXpub, uint32, bool) {
+	key, ok := idx.watchKeyForScript(pkScript)
+	if !ok {
+		return nil, 0, false
+	}
+	child, ok := idx.derived[key]
+	return child.xpub, child.index, ok
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *WatchIndex) Key() []byte {
+	return watchIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *WatchIndex) Name() string {
+	return watchIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the watch-only
+// index.
+//
+// This is part of the Indexer interface.
+func (idx *WatchIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(watchIndexKey)
+	return err
+}
+
+// watchKeyForScript returns the key of the address the passed public key
+// script pays, and whether the script pays a Prova address.
+func (idx *WatchIndex) watchKeyForScript(pkScript []byte) (watchKey, bool) {
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript,
+		idx.chainParams)
+	if err != nil || len(addrs) == 0 {
+		return watchKey{}, false
+	}
+	addr, ok := addrs[0].(*provautil.AddressProva)
+	if !ok {
+		return watchKey{}, false
+	}
+	key, err := watchKeyForPkHash(addr.ScriptAddress())
+	return key, err == nil
+}
+
+// isWatched returns whether the passed public key script pays a watched
+// address.
+//
+// This function MUST be called with the index lock held (for reads).
+func (idx *WatchIndex) isWatched(pkScript []byte) bool {
+	key, ok := idx.watchKeyForScript(pkScript)
+	if !ok {
+		return false
+	}
+	_, ok = idx.watched[key]
+	return ok
+}
+
+// label returns the label of the watched address the passed public key script
+// pays.
+//
+// This function MUST be called with the index lock held (for reads).
+func (idx *WatchIndex) label(pkScript []byte) string {
+	key, _ := idx.watchKeyForScript(pkScript)
+	return idx.watched[key]
+}
+
+// scanBlock returns the transaction entries for the outputs of the passed block
+// which pay an address the isWatched function accepts, and for the inputs which
+// spend such outputs.  The outputs created by earlier blocks are looked up with
+// the passed fetchOutput function, which returns nil for outputs which do not
+// pay a watched address.
+func scanBlock(block *provautil.Block, isWatched func([]byte) bool, fetchOutput func(*wire.OutPoint) (*WatchedOutput, error)) ([]WatchedTxEntry, error) {
+	var entries []WatchedTxEntry
+	created := make(map[wire.OutPoint]*WatchedOutput)
+	height := block.Height()
+	for txIdx, tx := range block.Transactions() {
+		// Coinbases do not reference any inputs.  Since the block is
+		// required to have already gone through full validation, it has
+		// already been proven on the first transaction in the block is
+		// a coinbase.
+		if txIdx != 0 {
+			for i, txIn := range tx.MsgTx().TxIn {
+				origin := &txIn.PreviousOutPoint
+				output, ok := created[*origin]
+				if ok {
+					delete(created, *origin)
+				} else {
+					var err error
+					output, err = fetchOutput(origin)
+					if err != nil {
+						return nil, err
+					}
+					if output == nil {
+						continue
+					}
+				}
+
+				entries = append(entries, WatchedTxEntry{
+					Category: WatchSend,
+					Height:   height,
+					TxIndex:  uint32(txIdx),
+					TxHash:   *tx.Hash(),
+					Index:    uint32(i),
+					Output:   *output,
+				})
+			}
+		}
+
+		for i, txOut := range tx.MsgTx().TxOut {
+			if !isWatched(txOut.PkScript) {
+				continue
+			}
+
+			output := WatchedOutput{
+				OutPoint: wire.OutPoint{
+					Hash:  *tx.Hash(),
+					Index: uint32(i),
+				},
+				Height:   height,
+				Amount:   txOut.Value,
+				PkScript: txOut.PkScript,
+			}
+			entries = append(entries, WatchedTxEntry{
+				Category: WatchReceive,
+				Height:   height,
+				TxIndex:  uint32(txIdx),
+				TxHash:   *tx.Hash(),
+				Index:    uint32(i),
+				Output:   output,
+			})
+			created[output.OutPoint] = &output
+		}
+	}
+
+	return entries, nil
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds the outputs of the block
+// which pay a watched address to the unspent outputs, removes the outputs the
+// block spends from them, and records a transaction entry for each.  The
+// addresses of the watched extended keys are derived further as the block pays
+// their derived addresses.
+//
+// This is part of the Indexer interface.
+func (idx *WatchIndex) ConnectBlock(dbTx database.Tx, block *provautil.Block, view *blockchain.UtxoViewpoint) error {
+	bucket := dbTx.Metadata().Bucket(watchIndexKey)
+	fetchOutput := func(outPoint *wire.OutPoint) (*WatchedOutput, error) {
+		return dbFetchWatchedOutput(bucket, outPoint)
+	}
+
+	// Scan the block again whenever new addresses are derived, since the
+	// block may pay them as well.
+	extended := make(map[*watchedXpub]struct{})
+	idx.mtx.Lock()
+	var entries []WatchedTxEntry
+	for {
+		var err error
+		entries, err = scanBlock(block, idx.isWatched, fetchOutput)
+		if err != nil {
+			idx.mtx.Unlock()
+			return err
+		}
+		more, err := markUsed(entries, idx.derivedAddr,
+			func(x *watchedXpub, derived []uint32) {
+				for _, index := range derived {
+					idx.addDerived(x, index)
+				}
+				extended[x] = struct{}{}
+			})
+		if err != nil {
+			idx.mtx.Unlock()
+			return err
+		}
+		if !more {
+			break
+		}
+	}
+	for x := range extended {
+		xpubs := dbTx.Metadata().Bucket(watchedXpubsBucketName)
+		err := xpubs.Put([]byte(x.xpub), serializeWatchedXpub(x))
+		if err != nil {
+			idx.mtx.Unlock()
+			return err
+		}
+	}
+	idx.mtx.Unlock()
+
+	// Remove the removed entries of the block when it is connected again.
+	removed, err := dbFetchWatchedTxEntries(bucket.Cursor(),
+		watchRemovedPrefix(block.Hash()),
+		watchRemovedPrefix(block.Hash()))
+	if err != nil {
+		return err
+	}
+	for i := range removed {
+		err := bucket.Delete(watchRemovedKey(block.Hash(), &removed[i]))
+		if err != nil {
+			return err
+		}
+	}
+
+	return dbPutWatchedTxEntries(bucket, entries)
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer moves the transaction entries
+// of the block to its removed entries, removes the outputs it created from the
+// unspent outputs, and restores the outputs it spent.  The addresses derived
+// from the watched extended keys stay watched.
+//
+// This is part of the Indexer interface.
+func (idx *WatchIndex) DisconnectBlock(dbTx database.Tx, block *provautil.Block, view *blockchain.UtxoViewpoint) error {
+	bucket := dbTx.Metadata().Bucket(watchIndexKey)
+	prefix := watchTxHeightPrefix(block.Height())
+	entries, err := dbFetchWatchedTxEntries(bucket.Cursor(), prefix, prefix)
+	if err != nil {
+		return err
+	}
+
+	// Undo the entries in reverse order, so an output which is created
+	// and spent by the block is removed again.
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := &entries[i]
+		if err := bucket.Delete(watchTxKey(entry)); err != nil {
+			return err
+		}
+		err := bucket.Put(watchRemovedKey(block.Hash(), entry),
+			serializeWatchedTxEntry(entry))
+		if err != nil {
+			return err
+		}
+
+		outputKey := watchOutputKey(&entry.Output.OutPoint)
+		if entry.Category == WatchSend {
+			err = bucket.Put(outputKey,
+				serializeWatchedOutput(&entry.Output))
+		} else {
+			err = bucket.Delete(outputKey)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dbFetchWatchedTxEntries fetches the transaction entries whose keys start with
+// the passed prefix with the passed cursor of the watch-only index bucket,
+// starting with the first key at or after the passed seek key.  The prefix may
+// also select removed transaction entries.
+func dbFetchWatchedTxEntries(cursor database.Cursor, seek, prefix []byte) ([]WatchedTxEntry, error) {
+	var entries []WatchedTxEntry
+	for ok := cursor.Seek(seek); ok; ok = cursor.Next() {
+		key := cursor.Key()
+		if !bytes.HasPrefix(key, prefix) {
+			break
+		}
+
+		// The keys of removed entries end with the fields of the key
+		// the entry had in the main chain.
+		if key[0] == watchPrefixRemoved {
+			if len(key) != watchRemovedKeySize {
+				return nil, database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("corrupt "+
+						"removed watch-only "+
+						"transaction entry %x", key),
+				}
+			}
+			txKey := make([]byte, watchTxKeySize)
+			txKey[0] = watchPrefixTx
+			copy(txKey[1:], key[1+chainhash.HashSize:])
+			key = txKey
+		}
+
+		entry, err := deserializeWatchedTxEntry(key, cursor.Value())
+		if err != nil {
+			return nil, database.Error{
+				ErrorCode:   database.ErrCorruption,
+				Description: err.Error(),
+			}
+		}
+		entries = append(entries, *entry)
+	}
+	return entries, nil
+}
+
+// rescan scans the main chain up to the tip of the index for the outputs paying
+// an address the isWatched function accepts and the inputs spending them, and
+// returns the transaction entries for them.  The extend function, which may be
+// nil, is invoked with the entries of each block, and returns whether more
+// addresses are accepted since, in which case the block is scanned again.
+// Closing the interrupt channel, which may be nil, stops the scan early with an
+// error.
+func (idx *WatchIndex) rescan(chain *blockchain.BlockChain, isWatched func([]byte) bool, extend func([]WatchedTxEntry) (bool, error), interrupt <-chan struct{}) ([]WatchedTxEntry, error) {
+	var tipHeight int32
+	err := idx.db.View(func(dbTx database.Tx) error {
+		var err error
+		_, tipHeight, err = dbFetchIndexerTip(dbTx, watchIndexKey)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The outputs paying the addresses which are unspent so far.
+	outputs := make(map[wire.OutPoint]*WatchedOutput)
+	fetchOutput := func(outPoint *wire.OutPoint) (*WatchedOutput, error) {
+		return outputs[*outPoint], nil
+	}
+
+	var entries []WatchedTxEntry
+	progressLogger := newBlockProgressLogger("Rescanned", log)
+	for height := int32(1); height <= tipHeight; height++ {
+		if interruptRequested(interrupt) {
+			return nil, errInterruptRequested
+		}
+
+		block, err := chain.BlockByHeight(uint32(height))
+		if err != nil {
+			return nil, err
+		}
+		var blockEntries []WatchedTxEntry
+		for {
+			blockEntries, err = scanBlock(block, isWatched,
+				fetchOutput)
+			if err != nil {
+				return nil, err
+			}
+			if extend == nil {
+				break
+			}
+			more, err := extend(blockEntries)
+			if err != nil {
+				return nil, err
+			}
+			if !more {
+				break
+			}
+		}
+		for i := range blockEntries {
+			output := &blockEntries[i].Output
+			if blockEntries[i].Category == WatchSend {
+				delete(outputs, output.OutPoint)
+			} else {
+				outputs[output.OutPoint] = output
+			}
+		}
+		entries = append(entries, blockEntries...)
+
+		progressLogger.LogBlockHeight(block)
+	}
+
+	return entries, nil
+}
+
+// Watch adds the addresses with the passed public key hash to the watched
+// addresses with the passed label, or changes their label when they are already
+// watched.  When rescan is set, the main chain is scanned for the transactions
+// which involve newly watched addresses, which stops early with an error when
+// the interrupt channel is closed.  Otherwise only the transactions in blocks
+// connected from now on are tracked.
+//
+// The index must be caught up with the main chain, and this function must not
+// be called concurrently with connecting or disconnecting blocks, so the
+// caller must pause the processing of blocks.
+func (idx *WatchIndex) Watch(chain *blockchain.BlockChain, pkHash []byte, label string, rescan bool, interrupt <-chan struct{}) error {
+	key, err := watchKeyForPkHash(pkHash)
+	if err != nil {
+		return err
+	}
+
+	idx.mtx.RLock()
+	_, watched := idx.watched[key]
+	idx.mtx.RUnlock()
+	var entries []WatchedTxEntry
+	if rescan && !watched {
+		log.Infof("Rescanning the main chain for public key hash %x",
+			pkHash)
+		isWatched := func(pkScript []byte) bool {
+			scriptKey, ok := idx.watchKeyForScript(pkScript)
+			return ok && scriptKey == key
+		}
+		entries, err = idx.rescan(chain, isWatched, nil, interrupt)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Add the address and its transactions atomically, so an interrupted
+	// import leaves no trace.
+	err = idx.db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		addrs, err := meta.CreateBucketIfNotExists(watchedAddrsBucketName)
+		if err != nil {
+			return err
+		}
+		if err := addrs.Put(key[:], []byte(label)); err != nil {
+			return err
+		}
+
+		return dbPutWatchedTxEntries(meta.Bucket(watchIndexKey), entries)
+	})
+	if err != nil {
+		return err
+	}
+
+	idx.mtx.Lock()
+	idx.watched[key] = label
+	idx.mtx.Unlock()
+	return nil
+}
+
+// WatchXpub adds the passed account extended public key to the watched extended
+// keys with the passed key ids and label, and derives and watches its deposit
+// addresses up to the passed gap limit of addresses past the last address which
+// received an output.  The addresses are derived from the external branch of
+// the key, and are paid as Prova addresses with the passed key ids.  As blocks
+// pay the derived addresses, more addresses are derived and watched.  When the
+// key is already watched, its key ids, gap limit and label are changed.
+//
+// When rescan is set, the main chain is scanned for the transactions which
+// involve the addresses of a newly watched key, deriving more addresses as
+// they are found to be used, which stops early with an error when the
+// interrupt channel is closed.
+//
+// The index must be caught up with the main chain, and this function must not
+// be called concurrently with connecting or disconnecting blocks, so the
+// caller must pause the processing of blocks.
+func (idx *WatchIndex) WatchXpub(chain *blockchain.BlockChain, xpub *hdkeychain.ExtendedKey, keyIDs []btcec.KeyID, gapLimit uint32, label string, rescan bool, interrupt <-chan struct{}) error {
+	if xpub.IsPrivate() {
+		return errors.New("extended key must be public")
+	}
+	if gapLimit < 1 || gapLimit > MaxWatchGapLimit {
+		return fmt.Errorf("gap limit must be between 1 and %d",
+			MaxWatchGapLimit)
+	}
+
+	// Work on a copy of the key when it is already watched, so the watched
+	// key is only replaced once the changes are stored.
+	x, err := newWatchedXpub(xpub, keyIDs, gapLimit, label)
+	if err != nil {
+		return err
+	}
+	idx.mtx.RLock()
+	existing, watched := idx.xpubs[x.xpub]
+	if watched {
+		x.used = existing.used
+		x.pkHashes = append([][]byte(nil), existing.pkHashes...)
+	}
+	idx.mtx.RUnlock()
+	if _, err := x.derive(); err != nil {
+		return err
+	}
+
+	var entries []WatchedTxEntry
+	if rescan && !watched {
+		log.Infof("Rescanning the main chain for extended key %s",
+			x.xpub)
+
+		// Start from the addresses up to the gap limit, and derive
+		// more as they are found to be used.
+		derived := make(map[watchKey]uint32)
+		addDerived := func(x *watchedXpub, indexes []uint32) {
+			for _, index := range indexes {
+				if pkHash := x.pkHashes[index]; pkHash != nil {
+					var key watchKey
+					copy(key[:], pkHash)
+					derived[key] = index
+				}
+			}
+		}
+		for index := range x.pkHashes {
+			addDerived(x, []uint32{uint32(index)})
+		}
+		derivedAddr := func(pkScript []byte) (*watchedXpub, uint32, bool) {
+			key, ok := idx.watchKeyForScript(pkScript)
+			if !ok {
+				return nil, 0, false
+			}
+			index, ok := derived[key]
+			return x, index, ok
+		}
+		isWatched := func(pkScript []byte) bool {
+			_, _, ok := derivedAddr(pkScript)
+			return ok
+		}
+		extend := func(entries []WatchedTxEntry) (bool, error) {
+			return markUsed(entries, derivedAddr, addDerived)
+		}
+		entries, err = idx.rescan(chain, isWatched, extend, interrupt)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Add the key and the transactions of its addresses atomically, so an
+	// interrupted import leaves no trace.
+	err = idx.db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		xpubs, err := meta.CreateBucketIfNotExists(watchedXpubsBucketName)
+		if err != nil {
+			return err
+		}
+		err = xpubs.Put([]byte(x.xpub), serializeWatchedXpub(x))
+		if err != nil {
+			return err
+		}
+
+		return dbPutWatchedTxEntries(meta.Bucket(watchIndexKey), entries)
+	})
+	if err != nil {
+		return err
+	}
+
+	idx.mtx.Lock()
+	idx.addXpub(x)
+	idx.mtx.Unlock()
+	return nil
+}
+
+// XpubInfo returns information about the passed watched account extended public
+// key, and whether the key is watched.
+//
+// This function is safe for concurrent access.
+func (idx *WatchIndex) XpubInfo(xpub string) (*WatchedXpubInfo, bool) {
+	idx.mtx.RLock()
+	defer idx.mtx.RUnlock()
+
+	x, ok := idx.xpubs[xpub]
+	if !ok {
+		return nil, false
+	}
+	info := &WatchedXpubInfo{
+		KeyIDs:   append([]btcec.KeyID(nil), x.keyIDs...),
+		Label:    x.label,
+		GapLimit: x.gapLimit,
+		Used:     x.used,
+		Derived:  uint32(len(x.pkHashes)),
+	}
+	for _, pkHash := range x.pkHashes[x.used:] {
+		if pkHash != nil {
+			info.NextPkHash = pkHash
+			break
+		}
+	}
+	return info, true
+}
+
+// AddrInfo returns information about the watched address with the passed
+// public key hash, and whether the address is watched.
+//
+// This function is safe for concurrent access.
+func (idx *WatchIndex) AddrInfo(pkHash []byte) (*WatchedAddrInfo, bool) {
+	key, err := watchKeyForPkHash(pkHash)
+	if err != nil {
+		return nil, false
+	}
+
+	idx.mtx.RLock()
+	defer idx.mtx.RUnlock()
+
+	label, ok := idx.watched[key]
+	if !ok {
+		return nil, false
+	}
+	info := &WatchedAddrInfo{Label: label}
+	if child, ok := idx.derived[key]; ok {
+		info.Xpub = child.xpub.xpub
+		info.Index = child.index
+	}
+	return info, true
+}
+
+// UnspentOutputs returns the unspent outputs in the main chain which pay the
+// watched addresses.
+//
+// This function is safe for concurrent access.
+func (idx *WatchIndex) UnspentOutputs() ([]WatchedOutput, error) {
+	var outputs []WatchedOutput
+	err := idx.db.View(func(dbTx database.Tx) error {
+		prefix := []byte{watchPrefixOutput}
+		cursor := dbTx.Metadata().Bucket(watchIndexKey).Cursor()
+		for ok := cursor.Seek(prefix); ok; ok = cursor.Next() {
+			key := cursor.Key()
+			if !bytes.HasPrefix(key, prefix) {
+				break
+			}
+
+			output, err := deserializeWatchedOutput(key,
+				cursor.Value())
+			if err != nil {
+				return database.Error{
+					ErrorCode:   database.ErrCorruption,
+					Description: err.Error(),
+				}
+			}
+			outputs = append(outputs, *output)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	idx.mtx.RLock()
+	for i := range outputs {
+		outputs[i].Label = idx.label(outputs[i].PkScript)
+	}
+	idx.mtx.RUnlock()
+	return outputs, nil
+}
+
+// fetchTxEntries fetches the transaction entries whose keys start with the
+// passed prefix, starting at the passed seek key, and sets their labels.
+//
+// This function is safe for concurrent access.
+func (idx *WatchIndex) fetchTxEntries(seek, prefix []byte) ([]WatchedTxEntry, error) {
+	var entries []WatchedTxEntry
+	err := idx.db.View(func(dbTx database.Tx) error {
+		var err error
+		cursor := dbTx.Metadata().Bucket(watchIndexKey).Cursor()
+		entries, err = dbFetchWatchedTxEntries(cursor, seek, prefix)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	idx.mtx.RLock()
+	for i := range entries {
+		output := &entries[i].Output
+		output.Label = idx.label(output.PkScript)
+	}
+	idx.mtx.RUnlock()
+	return entries, nil
+}
+
+// TxEntries returns the transaction entries for the outputs paying the watched
+// addresses which are created or spent by transactions in the main chain, in
+// the order of the transactions in the chain.
+//
+// This function is safe for concurrent access.
+func (idx *WatchIndex) TxEntries() ([]WatchedTxEntry, error) {
+	return idx.TxEntriesAfter(0)
+}
+
+// TxEntriesAfter returns the transaction entries of the blocks in the main
+// chain above the passed height, in the order of the transactions in the chain.
+//
+// This function is safe for concurrent access.
+func (idx *WatchIndex) TxEntriesAfter(height uint32) ([]WatchedTxEntry, error) {
+	return idx.fetchTxEntries(watchTxHeightPrefix(height+1),
+		[]byte{watchPrefixTx})
+}
+
+// BlockTxEntries returns the transaction entries of the block in the main chain
+// at the passed height, in the order of the transactions in the block.
+//
+// This function is safe for concurrent access.
+func (idx *WatchIndex) BlockTxEntries(height uint32) ([]WatchedTxEntry, error) {
+	prefix := watchTxHeightPrefix(height)
+	return idx.fetchTxEntries(prefix, prefix)
+}
+
+// RemovedTxEntries returns the transaction entries the block with the passed
+// hash had when it was disconnected from the main chain, in the order of the
+// transactions in the block.  There are none unless the block was disconnected
+// and has not been connected again since.  Only the entries for addresses which
+// were watched when the block was disconnected are returned.
+//
+// This function is safe for concurrent access.
+func (idx *WatchIndex) RemovedTxEntries(blockHash *chainhash.Hash) ([]WatchedTxEntry, error) {
+	prefix := watchRemovedPrefix(blockHash)
+	return idx.fetchTxEntries(prefix, prefix)
+}
+
+// NewWatchIndex returns a new instance of an indexer that is used to track the
+// unspent outputs and transactions of imported watch-only addresses.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewWatchIndex(db database.DB, chainParams *chaincfg.Params) *WatchIndex {
+	return &WatchIndex{
+		db:          db,
+		chainParams: chainParams,
+		watched:     make(map[watchKey]string),
+		xpubs:       make(map[string]*watchedXpub),
+		derived:     make(map[watchKey]watchedChild),
+	}
+}
+
+// DropWatchIndex drops the watch-only index from the provided database if it
+// exists.  The watched addresses and extended keys are kept, so the index is
+// rebuilt for them when it is enabled again.
+func DropWatchIndex(db database.DB) error {
+	return dropIndex(db, watchIndexKey, watchIndexName)
+}
@@ -0,0 +1,395 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/database"
+	_ "github.com/pyx-partners/dmgd/database/ffldb"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/provautil/hdkeychain"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// TestWatchIndex ensures the watch-only index tracks the outputs paying the
+// watched addresses and the transactions creating and spending them as blocks
+// are connected and disconnected.
+func TestWatchIndex(t *testing.T) {
+	dbPath, err := ioutil.TempDir("", "watchindex")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", filepath.Join(dbPath, "db"),
+		wire.TestNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer db.Close()
+
+	params := &chaincfg.RegressionNetParams
+	watchedHash := make([]byte, 20)
+	watchedHash[0] = 0x01
+	otherHash := make([]byte, 20)
+	otherHash[0] = 0x02
+	provaScript := func(pkHash []byte, keyIDs ...btcec.KeyID) []byte {
+		addr, err := provautil.NewAddressProva(pkHash, keyIDs, params)
+		if err != nil {
+			t.Fatalf("NewAddressProva: %v", err)
+		}
+		script, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			t.Fatalf("PayToAddrScript: %v", err)
+		}
+		return script
+	}
+	newTx := func(prevOut wire.OutPoint, outputs ...*wire.TxOut) *wire.MsgTx {
+		tx := wire.NewMsgTx(wire.TxVersion)
+		tx.AddTxIn(&wire.TxIn{PreviousOutPoint: prevOut})
+		for _, output := range outputs {
+			tx.AddTxOut(output)
+		}
+		return tx
+	}
+
+	// Addresses with the watched public key hash are watched regardless of
+	// their key ids.
+	watched1 := provaScript(watchedHash, 1, 2)
+	watched2 := provaScript(watchedHash, 3, 4)
+	other := provaScript(otherHash, 1, 2)
+
+	// The first block pays both watched addresses from the coinbase and
+	// from an unrelated output.
+	msgBlock1 := wire.NewMsgBlock(&wire.BlockHeader{Height: 1})
+	coinbase := newTx(wire.OutPoint{Index: wire.MaxPrevOutIndex},
+		wire.NewTxOut(1000, watched1), wire.NewTxOut(2000, other))
+	msgBlock1.AddTransaction(coinbase)
+	msgBlock1.AddTransaction(newTx(wire.OutPoint{Index: 1},
+		wire.NewTxOut(500, watched2)))
+	block1 := provautil.NewBlock(msgBlock1)
+
+	// The second block spends the first watched output of the coinbase,
+	// and creates and spends another watched output.
+	msgBlock2 := wire.NewMsgBlock(&wire.BlockHeader{Height: 2})
+	msgBlock2.AddTransaction(newTx(wire.OutPoint{Index: wire.MaxPrevOutIndex},
+		wire.NewTxOut(1000, other)))
+	msgBlock2.AddTransaction(newTx(wire.OutPoint{Hash: coinbase.TxHash()},
+		wire.NewTxOut(300, watched1), wire.NewTxOut(700, other)))
+	msgBlock2.AddTransaction(newTx(wire.OutPoint{
+		Hash: msgBlock2.Transactions[1].TxHash(),
+	}, wire.NewTxOut(300, other)))
+	block2 := provautil.NewBlock(msgBlock2)
+
+	idx := NewWatchIndex(db, params)
+	err = db.Update(func(dbTx database.Tx) error {
+		return idx.Create(dbTx)
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := idx.Watch(nil, watchedHash, "deposits", false, nil); err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	for _, block := range []*provautil.Block{block1, block2} {
+		err = db.Update(func(dbTx database.Tx) error {
+			return idx.ConnectBlock(dbTx, block, nil)
+		})
+		if err != nil {
+			t.Fatalf("ConnectBlock: %v", err)
+		}
+	}
+
+	// checkOutputs ensures the unspent watched outputs have the passed
+	// amounts.
+	checkOutputs := func(amounts ...int64) {
+		outputs, err := idx.UnspentOutputs()
+		if err != nil {
+			t.Fatalf("UnspentOutputs: %v", err)
+		}
+		if len(outputs) != len(amounts) {
+			t.Fatalf("got %d unspent outputs, want %d", len(outputs),
+				len(amounts))
+		}
+		for _, amount := range amounts {
+			found := false
+			for _, output := range outputs {
+				if output.Amount == amount &&
+					output.Label == "deposits" {
+
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("missing unspent output of %d", amount)
+			}
+		}
+	}
+
+	// checkEntries ensures the transaction entries are in chain order with
+	// the passed categories and amounts.
+	type entryTest struct {
+		category WatchCategory
+		height   uint32
+		amount   int64
+	}
+	checkEntries := func(tests ...entryTest) {
+		entries, err := idx.TxEntries()
+		if err != nil {
+			t.Fatalf("TxEntries: %v", err)
+		}
+		if len(entries) != len(tests) {
+			t.Fatalf("got %d transaction entries, want %d",
+				len(entries), len(tests))
+		}
+		for i, test := range tests {
+			entry := &entries[i]
+			if entry.Category != test.category ||
+				entry.Height != test.height ||
+				entry.Output.Amount != test.amount ||
+				entry.Output.Label != "deposits" {
+
+				t.Errorf("entry %d: got %v at height %d of %d "+
+					"labeled %q, want %v at height %d of %d",
+					i, entry.Category, entry.Height,
+					entry.Output.Amount, entry.Output.Label,
+					test.category, test.height, test.amount)
+			}
+		}
+	}
+
+	checkOutputs(500)
+	checkEntries(
+		entryTest{WatchReceive, 1, 1000},
+		entryTest{WatchReceive, 1, 500},
+		entryTest{WatchSend, 2, 1000},
+		entryTest{WatchReceive, 2, 300},
+		entryTest{WatchSend, 2, 300},
+	)
+
+	entries, err := idx.TxEntriesAfter(1)
+	if err != nil {
+		t.Fatalf("TxEntriesAfter: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("got %d transaction entries after height 1, want 3",
+			len(entries))
+	}
+	entries, err = idx.BlockTxEntries(1)
+	if err != nil {
+		t.Fatalf("BlockTxEntries: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("got %d transaction entries at height 1, want 2",
+			len(entries))
+	}
+
+	// Disconnecting the second block restores the spent output and moves
+	// its entries to the removed entries of the block.
+	err = db.Update(func(dbTx database.Tx) error {
+		return idx.DisconnectBlock(dbTx, block2, nil)
+	})
+	if err != nil {
+		t.Fatalf("DisconnectBlock: %v", err)
+	}
+	checkOutputs(1000, 500)
+	checkEntries(
+		entryTest{WatchReceive, 1, 1000},
+		entryTest{WatchReceive, 1, 500},
+	)
+	checkRemoved := func(want int) {
+		removed, err := idx.RemovedTxEntries(block2.Hash())
+		if err != nil {
+			t.Fatalf("RemovedTxEntries: %v", err)
+		}
+		if len(removed) != want {
+			t.Fatalf("got %d removed entries, want %d", len(removed),
+				want)
+		}
+		if want > 0 && (removed[0].Category != WatchSend ||
+			removed[0].Output.Amount != 1000 ||
+			removed[0].Output.Label != "deposits") {
+
+			t.Errorf("got removed entry %v of %d labeled %q, want "+
+				"send of 1000 labeled \"deposits\"",
+				removed[0].Category, removed[0].Output.Amount,
+				removed[0].Output.Label)
+		}
+	}
+	checkRemoved(3)
+
+	// Connecting the block again drops its removed entries.
+	err = db.Update(func(dbTx database.Tx) error {
+		return idx.ConnectBlock(dbTx, block2, nil)
+	})
+	if err != nil {
+		t.Fatalf("ConnectBlock: %v", err)
+	}
+	checkRemoved(0)
+	checkOutputs(500)
+	err = db.Update(func(dbTx database.Tx) error {
+		return idx.DisconnectBlock(dbTx, block2, nil)
+	})
+	if err != nil {
+		t.Fatalf("DisconnectBlock: %v", err)
+	}
+
+	// The watched addresses are loaded again on start.
+	idx = NewWatchIndex(db, params)
+	if err := idx.Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	checkOutputs(1000, 500)
+}
+
+// TestWatchIndexXpub ensures the addresses of a watched extended key are
+// derived ahead of use up to the gap limit, and derived further as blocks pay
+// them.
+func TestWatchIndexXpub(t *testing.T) {
+	dbPath, err := ioutil.TempDir("", "watchindexxpub")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", filepath.Join(dbPath, "db"),
+		wire.TestNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer db.Close()
+
+	params := &chaincfg.RegressionNetParams
+	seed := make([]byte, hdkeychain.RecommendedSeedLen)
+	master, err := hdkeychain.NewMaster(seed, params)
+	if err != nil {
+		t.Fatalf("NewMaster: %v", err)
+	}
+	xpub, err := master.Neuter()
+	if err != nil {
+		t.Fatalf("Neuter: %v", err)
+	}
+	keyIDs := []btcec.KeyID{1, 2}
+
+	// depositScript returns the script paying the deposit address at the
+	// passed index of the external branch.
+	depositScript := func(index uint32) []byte {
+		branch, err := xpub.Child(0)
+		if err != nil {
+			t.Fatalf("Child: %v", err)
+		}
+		child, err := branch.Child(index)
+		if err != nil {
+			t.Fatalf("Child: %v", err)
+		}
+		addr, err := child.Address(keyIDs, params)
+		if err != nil {
+			t.Fatalf("Address: %v", err)
+		}
+		script, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			t.Fatalf("PayToAddrScript: %v", err)
+		}
+		return script
+	}
+
+	idx := NewWatchIndex(db, params)
+	err = db.Update(func(dbTx database.Tx) error {
+		return idx.Create(dbTx)
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	err = idx.WatchXpub(nil, xpub, keyIDs, 2, "exchange", false, nil)
+	if err != nil {
+		t.Fatalf("WatchXpub: %v", err)
+	}
+
+	// checkInfo ensures the watched key has the passed number of used and
+	// derived addresses, and the next address follows the used ones.
+	checkInfo := func(used, derived uint32) {
+		info, ok := idx.XpubInfo(xpub.String())
+		if !ok {
+			t.Fatalf("XpubInfo: extended key is not watched")
+		}
+		if info.Used != used || info.Derived != derived ||
+			info.Label != "exchange" {
+
+			t.Errorf("XpubInfo: got %d used and %d derived "+
+				"addresses labeled %q, want %d and %d labeled "+
+				"\"exchange\"", info.Used, info.Derived,
+				info.Label, used, derived)
+		}
+		key, _ := idx.watchKeyForScript(depositScript(used))
+		if !bytes.Equal(info.NextPkHash, key[:]) {
+			t.Errorf("XpubInfo: next address is not address %d",
+				used)
+		}
+	}
+	checkInfo(0, 2)
+
+	// The derived addresses are watched with the label of the key they
+	// are derived from.
+	key, _ := idx.watchKeyForScript(depositScript(1))
+	addrInfo, ok := idx.AddrInfo(key[:])
+	if !ok {
+		t.Fatalf("AddrInfo: derived address is not watched")
+	}
+	if addrInfo.Xpub != xpub.String() || addrInfo.Index != 1 ||
+		addrInfo.Label != "exchange" {
+
+		t.Errorf("AddrInfo: got address %d of %s labeled %q, want "+
+			"address 1 of %s labeled \"exchange\"", addrInfo.Index,
+			addrInfo.Xpub, addrInfo.Label, xpub.String())
+	}
+
+	// A block paying the second address, and then the third address which
+	// is past the gap limit until the second address is seen to be used,
+	// extends the derived addresses twice.
+	msgBlock := wire.NewMsgBlock(&wire.BlockHeader{Height: 1})
+	for i, index := range []uint32{1, 2} {
+		tx := wire.NewMsgTx(wire.TxVersion)
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: wire.OutPoint{Index: uint32(i)},
+		})
+		tx.AddTxOut(wire.NewTxOut(1000, depositScript(index)))
+		msgBlock.AddTransaction(tx)
+	}
+	block := provautil.NewBlock(msgBlock)
+	err = db.Update(func(dbTx database.Tx) error {
+		return idx.ConnectBlock(dbTx, block, nil)
+	})
+	if err != nil {
+		t.Fatalf("ConnectBlock: %v", err)
+	}
+	checkInfo(3, 5)
+	outputs, err := idx.UnspentOutputs()
+	if err != nil {
+		t.Fatalf("UnspentOutputs: %v", err)
+	}
+	if len(outputs) != 2 {
+		t.Errorf("got %d unspent outputs, want 2", len(outputs))
+	}
+
+	// The derived addresses are derived again on start, and disconnecting
+	// the block keeps them.
+	err = db.Update(func(dbTx database.Tx) error {
+		return idx.DisconnectBlock(dbTx, block, nil)
+	})
+	if err != nil {
+		t.Fatalf("DisconnectBlock: %v", err)
+	}
+	idx = NewWatchIndex(db, params)
+	if err := idx.Init(); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	checkInfo(3, 5)
+}
@@ -8,12 +8,192 @@ package blockchain
 
 import (
 	"bytes"
+	"sort"
+
+	"github.com/btcsuite/golangcrypto/ripemd160"
 	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
 	"github.com/pyx-partners/dmgd/provautil"
 	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/txscript/adminop"
 	"github.com/pyx-partners/dmgd/wire"
 )
 
+// IssueDestSet is the set of destinations newly issued atoms may be paid to.
+// An empty set places no restriction on issuance destinations.
+type IssueDestSet map[[txscript.IssueDestLen]byte]struct{}
+
+// DeepCopy returns a copy of the set, so modification does not affect the
+// source set.
+func (set IssueDestSet) DeepCopy() IssueDestSet {
+	setCopy := make(IssueDestSet, len(set))
+	for dest := range set {
+		setCopy[dest] = struct{}{}
+	}
+	return setCopy
+}
+
+// FrozenSet is the set of pubKeyHashes of frozen accounts.  Outputs paying to
+// a frozen pubKeyHash can not be spent until the account is unfrozen.
+type FrozenSet map[[ripemd160.Size]byte]struct{}
+
+// DeepCopy returns a copy of the set, so modification does not affect the
+// source set.
+func (set FrozenSet) DeepCopy() FrozenSet {
+	setCopy := make(FrozenSet, len(set))
+	for pkHash := range set {
+		setCopy[pkHash] = struct{}{}
+	}
+	return setCopy
+}
+
+// Delegation is the scope of provision thread ops a delegate key may sign on
+// its own, as granted by a DelegateKeyAdd op.  The ASP key ops it grants are
+// limited to the keyIDs from MinKeyID to MaxKeyID.
+type Delegation struct {
+	Scope    adminop.Scope
+	MinKeyID btcec.KeyID
+	MaxKeyID btcec.KeyID
+}
+
+// delegationFromPayload returns the delegation carried by the passed
+// delegation op.
+func delegationFromPayload(payload *adminop.Payload) Delegation {
+	return Delegation{
+		Scope:    payload.Scope,
+		MinKeyID: payload.KeyID,
+		MaxKeyID: payload.MaxKeyID,
+	}
+}
+
+// Allows returns whether the delegation grants the passed admin op.
+func (d Delegation) Allows(payload *adminop.Payload) bool {
+	if !d.Scope.Allows(payload.Op) {
+		return false
+	}
+	if payload.Schema().Kind == adminop.KindKey {
+		return payload.KeyID >= d.MinKeyID && payload.KeyID <= d.MaxKeyID
+	}
+	return true
+}
+
+// Delegates maps the compressed public keys of delegate keys to their
+// delegations.
+type Delegates map[[btcec.PubKeyBytesLenCompressed]byte]Delegation
+
+// DeepCopy returns a copy of the delegates, so modification does not affect
+// the source delegates.
+func (delegates Delegates) DeepCopy() Delegates {
+	delegatesCopy := make(Delegates, len(delegates))
+	for pubKey, delegation := range delegates {
+		delegatesCopy[pubKey] = delegation
+	}
+	return delegatesCopy
+}
+
+// AssetSupplies maps the IDs of assets other than DMG to their total
+// spendable supply of atoms.  Assets without supply are not held.
+type AssetSupplies map[uint32]uint64
+
+// DeepCopy returns a copy of the supplies, so modification does not affect
+// the source supplies.
+func (supplies AssetSupplies) DeepCopy() AssetSupplies {
+	suppliesCopy := make(AssetSupplies, len(supplies))
+	for assetID, supply := range supplies {
+		suppliesCopy[assetID] = supply
+	}
+	return suppliesCopy
+}
+
+// KeyExpiry is the provisioning of an ASP or validate key with an expiry
+// height.  The key is revoked at the end of the block at its expiry height
+// unless it was revoked before.  Validate keys are only revoked by expiry
+// while enough of them stay provisioned, so their expiry may be overdue.
+//
+// The heights at which the key was revoked or expired are kept to undo them
+// when blocks are disconnected, and are zero until they happen.
+type KeyExpiry struct {
+	KeySet        btcec.KeySetType
+	PubKey        [btcec.PubKeyBytesLenCompressed]byte
+	KeyID         btcec.KeyID
+	AddHeight     uint32
+	Height        uint32
+	RevokedHeight uint32
+	ExpiredHeight uint32
+}
+
+// IsPending returns whether the key is still provisioned and awaits its
+// expiry.
+func (expiry *KeyExpiry) IsPending() bool {
+	return expiry.RevokedHeight == 0 && expiry.ExpiredHeight == 0
+}
+
+// matches returns whether the expiry is of the passed key.  ASP keys are
+// identified by their keyID.
+func (expiry *KeyExpiry) matches(keySetType btcec.KeySetType,
+	pubKey *btcec.PublicKey, keyID btcec.KeyID) bool {
+	if expiry.KeySet != keySetType {
+		return false
+	}
+	if keySetType == btcec.ASPKeySet {
+		return expiry.KeyID == keyID
+	}
+	return bytes.Equal(expiry.PubKey[:], pubKey.SerializeCompressed())
+}
+
+// KeyExpiries maps the outpoints of the admin ops which provisioned keys with
+// an expiry height to their expiries.
+type KeyExpiries map[wire.OutPoint]KeyExpiry
+
+// DeepCopy returns a copy of the expiries, so modification does not affect
+// the source expiries.
+func (expiries KeyExpiries) DeepCopy() KeyExpiries {
+	expiriesCopy := make(KeyExpiries, len(expiries))
+	for outPoint, expiry := range expiries {
+		expiriesCopy[outPoint] = expiry
+	}
+	return expiriesCopy
+}
+
+// outPoints returns the outpoints of the expiries in ascending order of their
+// expiry height, then of the provisioning admin op.
+func (expiries KeyExpiries) outPoints() []wire.OutPoint {
+	outPoints := make([]wire.OutPoint, 0, len(expiries))
+	for outPoint := range expiries {
+		outPoints = append(outPoints, outPoint)
+	}
+	sort.Slice(outPoints, func(i, j int) bool {
+		a, b := expiries[outPoints[i]], expiries[outPoints[j]]
+		if a.Height != b.Height {
+			return a.Height < b.Height
+		}
+		return outPointLess(&outPoints[i], &outPoints[j])
+	})
+	return outPoints
+}
+
+// Pending returns the expiries of the keys which are still provisioned in
+// ascending order of their expiry height.
+func (expiries KeyExpiries) Pending() []KeyExpiry {
+	var pending []KeyExpiry
+	for _, outPoint := range expiries.outPoints() {
+		expiry := expiries[outPoint]
+		if expiry.IsPending() {
+			pending = append(pending, expiry)
+		}
+	}
+	return pending
+}
+
+// outPointLess returns whether the first passed outpoint sorts before the
+// second one.
+func outPointLess(a, b *wire.OutPoint) bool {
+	if cmp := bytes.Compare(a.Hash[:], b.Hash[:]); cmp != 0 {
+		return cmp < 0
+	}
+	return a.Index < b.Index
+}
+
 // KeyViewpoint represents a view into the set of admin keys from a specific
 // point of view in the chain. For example, it could be for the end of the main
 // chain, some point in the history of the main chain, or down a side chain.
@@ -21,8 +201,13 @@ type KeyViewpoint struct {
 	threadTips   map[provautil.ThreadID]*wire.OutPoint
 	lastKeyID    btcec.KeyID
 	totalSupply  uint64
+	assetSupply  AssetSupplies
 	adminKeySets map[btcec.KeySetType]btcec.PublicKeySet
 	aspKeyIdMap  btcec.KeyIdMap
+	issueDests   IssueDestSet
+	frozen       FrozenSet
+	keyExpiries  KeyExpiries
+	delegates    Delegates
 }
 
 // ThreadTips returns
@@ -58,6 +243,19 @@ func (view *KeyViewpoint) SetTotalSupply(totalSupply uint64) {
 	view.totalSupply = totalSupply
 }
 
+// SetAssetSupplies sets the supplies of assets other than DMG.
+func (view *KeyViewpoint) SetAssetSupplies(supplies AssetSupplies) {
+	if supplies != nil {
+		view.assetSupply = supplies.DeepCopy()
+	}
+}
+
+// AssetSupplies returns the supplies of assets other than DMG at the position
+// in the chain the view currently represents.
+func (view *KeyViewpoint) AssetSupplies() AssetSupplies {
+	return view.assetSupply
+}
+
 // SetKeys sets the admin key sets at the position in the chain the view
 // curretly represents.
 func (view *KeyViewpoint) SetKeys(keys map[btcec.KeySetType]btcec.PublicKeySet) {
@@ -94,6 +292,89 @@ func (view *KeyViewpoint) KeyIDs() btcec.KeyIdMap {
 	return view.aspKeyIdMap
 }
 
+// SetIssueDests sets the allowlist of issuance destinations.
+func (view *KeyViewpoint) SetIssueDests(issueDests IssueDestSet) {
+	if issueDests != nil {
+		view.issueDests = issueDests.DeepCopy()
+	}
+}
+
+// IssueDests returns the allowlist of issuance destinations at the position
+// in the chain the view currently represents.
+func (view *KeyViewpoint) IssueDests() IssueDestSet {
+	return view.issueDests
+}
+
+// SetFrozen sets the pubKeyHashes of frozen accounts.
+func (view *KeyViewpoint) SetFrozen(frozen FrozenSet) {
+	if frozen != nil {
+		view.frozen = frozen.DeepCopy()
+	}
+}
+
+// Frozen returns the pubKeyHashes of frozen accounts at the position in the
+// chain the view currently represents.
+func (view *KeyViewpoint) Frozen() FrozenSet {
+	return view.frozen
+}
+
+// IsFrozen returns whether the account of the passed pubKeyHash is frozen.
+func (view *KeyViewpoint) IsFrozen(pkHash []byte) bool {
+	var key [ripemd160.Size]byte
+	copy(key[:], pkHash)
+	_, frozen := view.frozen[key]
+	return frozen
+}
+
+// SetKeyExpiries sets the expiries of the keys provisioned with an expiry
+// height.
+func (view *KeyViewpoint) SetKeyExpiries(expiries KeyExpiries) {
+	if expiries != nil {
+		view.keyExpiries = expiries.DeepCopy()
+	}
+}
+
+// KeyExpiries returns the expiries of the keys provisioned with an expiry
+// height at the position in the chain the view currently represents.
+func (view *KeyViewpoint) KeyExpiries() KeyExpiries {
+	return view.keyExpiries
+}
+
+// SetDelegates sets the delegations of the delegate keys.
+func (view *KeyViewpoint) SetDelegates(delegates Delegates) {
+	if delegates != nil {
+		view.delegates = delegates.DeepCopy()
+	}
+}
+
+// Delegates returns the delegations of the delegate keys at the position in
+// the chain the view currently represents.
+func (view *KeyViewpoint) Delegates() Delegates {
+	return view.delegates
+}
+
+// signingDelegate returns the public key of the delegate key which signs the
+// passed signature script of a provision thread input on its own, along with
+// its delegation.  False is returned when the script does not consist of the
+// <pubKey> <sig> pair of a single delegate key.
+func (view *KeyViewpoint) signingDelegate(sigScript []byte) ([]byte,
+	Delegation, bool) {
+
+	if !txscript.IsPushOnlyScript(sigScript) {
+		return nil, Delegation{}, false
+	}
+	pushes, err := txscript.PushedData(sigScript)
+	if err != nil || len(pushes) != 2 ||
+		len(pushes[0]) != btcec.PubKeyBytesLenCompressed {
+
+		return nil, Delegation{}, false
+	}
+	var pubKey [btcec.PubKeyBytesLenCompressed]byte
+	copy(pubKey[:], pushes[0])
+	delegation, ok := view.delegates[pubKey]
+	return pushes[0], delegation, ok
+}
+
 // LookupKeyIDs returns pubKeyHashes for all registered KeyIDs
 func (view *KeyViewpoint) LookupKeyIDs(keyIDs []btcec.KeyID) map[btcec.KeyID][]byte {
 	keyIdMap := make(map[btcec.KeyID][]byte)
@@ -108,10 +389,18 @@ func (view *KeyViewpoint) LookupKeyIDs(keyIDs []btcec.KeyID) map[btcec.KeyID][]b
 	return keyIdMap
 }
 
+// LookupKeyID returns the ASP key provisioned for the passed keyID, or nil if
+// there is none.
+func (view *KeyViewpoint) LookupKeyID(keyID btcec.KeyID) *btcec.PublicKey {
+	return view.aspKeyIdMap[keyID]
+}
+
 // ProcessAdminOuts finds admin transactions and executes all ops in it.
 // This function is called after the validity of the transaction has been
 // verified.
-func (view *KeyViewpoint) ProcessAdminOuts(tx *provautil.Tx, blockHeight uint32) {
+func (view *KeyViewpoint) ProcessAdminOuts(tx *provautil.Tx, blockHeight uint32,
+	chainParams *chaincfg.Params) {
+
 	threadInt, adminOutputs := txscript.GetAdminDetails(tx)
 	if threadInt < 0 {
 		// not admin transaction
@@ -119,6 +408,7 @@ func (view *KeyViewpoint) ProcessAdminOuts(tx *provautil.Tx, blockHeight uint32)
 	}
 	if provautil.ThreadID(threadInt) == provautil.IssueThread {
 		isDestruction := len(tx.MsgTx().TxIn) > 1
+		assetID := TxAssetID(tx.MsgTx(), blockHeight, chainParams)
 		if isDestruction {
 			// if this is a destruction operation
 			// look over all non-prova outputs and sum them up.
@@ -127,8 +417,10 @@ func (view *KeyViewpoint) ProcessAdminOuts(tx *provautil.Tx, blockHeight uint32)
 				// according to previous validation, it must be
 				// admin operation (destruction)
 				scriptType := txscript.TypeOfScript(adminOutputs[i])
-				if scriptType == txscript.NullDataTy {
-					view.totalSupply -= uint64(tx.MsgTx().TxOut[i+1].Value)
+				if scriptType == txscript.NullDataTy ||
+					scriptType == txscript.CommitmentTy {
+					view.applySupplyChange(assetID,
+						tx.MsgTx().TxOut[i+1].Value, false)
 				}
 			}
 		} else {
@@ -137,16 +429,36 @@ func (view *KeyViewpoint) ProcessAdminOuts(tx *provautil.Tx, blockHeight uint32)
 			// remember that a issuing transaction is not allow to also
 			// destroy, as to previous validation.
 			for i := 1; i < len(tx.MsgTx().TxOut); i++ {
-				view.totalSupply += uint64(tx.MsgTx().TxOut[i].Value)
+				view.applySupplyChange(assetID,
+					tx.MsgTx().TxOut[i].Value, true)
 			}
 		}
 		view.threadTips[provautil.IssueThread] = wire.NewOutPoint(tx.Hash(), 0)
 		return
 	}
 	for i := 0; i < len(adminOutputs); i++ {
-		isAddOp, keySetType, pubKey,
-			keyID := txscript.ExtractAdminOpData(adminOutputs[i])
-		view.applyAdminOp(isAddOp, keySetType, pubKey, keyID)
+		// The admin ops are validated before they are applied.
+		payload, err := txscript.ExtractAdminPayload(adminOutputs[i])
+		if err != nil {
+			continue
+		}
+		schema := payload.Schema()
+		switch schema.Kind {
+		case adminop.KindIssueDest:
+			view.applyIssueDestOp(schema.IsAdd, payload.IssueDest)
+		case adminop.KindFreeze:
+			view.applyFreezeOp(schema.IsAdd, payload.PubKeyHash)
+		case adminop.KindDelegate:
+			view.applyDelegateOp(schema.IsAdd, payload)
+		case adminop.KindKey:
+			view.applyAdminOp(schema.IsAdd, schema.KeySet,
+				payload.PubKey, payload.KeyID)
+			if chainParams.KeyExpiryActive(blockHeight) {
+				view.applyKeyExpiryOp(wire.OutPoint{
+					Hash: *tx.Hash(), Index: uint32(i + 1)},
+					payload, blockHeight)
+			}
+		}
 	}
 	// this becomes the new tip of the admin thread
 	threadId := provautil.ThreadID(threadInt)
@@ -173,25 +485,240 @@ func (view *KeyViewpoint) applyAdminOp(isAddOp bool,
 	}
 }
 
+// applyKeyExpiryOp tracks the expiry of a key provisioned with an expiry
+// height by the passed admin op, or marks the expiry of a key revoked by it
+// as no longer pending.
+func (view *KeyViewpoint) applyKeyExpiryOp(outPoint wire.OutPoint,
+	payload *adminop.Payload, blockHeight uint32) {
+	schema := payload.Schema()
+	if schema.IsAdd {
+		if payload.Expiry == 0 {
+			return
+		}
+		expiry := KeyExpiry{
+			KeySet:    schema.KeySet,
+			KeyID:     payload.KeyID,
+			AddHeight: blockHeight,
+			Height:    payload.Expiry,
+		}
+		copy(expiry.PubKey[:], payload.PubKey.SerializeCompressed())
+		view.keyExpiries[outPoint] = expiry
+		return
+	}
+	for outPoint, expiry := range view.keyExpiries {
+		if expiry.IsPending() && expiry.matches(schema.KeySet,
+			payload.PubKey, payload.KeyID) {
+
+			expiry.RevokedHeight = blockHeight
+			view.keyExpiries[outPoint] = expiry
+		}
+	}
+}
+
+// expireKeys revokes the keys whose expiry height has been reached at the
+// end of the block at the passed height.  Validate keys are only revoked
+// while the validate key set keeps at least the passed minimum number of keys,
+// and remain pending otherwise.
+func (view *KeyViewpoint) expireKeys(blockHeight uint32, minValidateKeys int) {
+	for _, outPoint := range view.keyExpiries.outPoints() {
+		expiry := view.keyExpiries[outPoint]
+		if expiry.Height > blockHeight {
+			break
+		}
+		if !expiry.IsPending() {
+			continue
+		}
+		if expiry.KeySet == btcec.ASPKeySet {
+			delete(view.aspKeyIdMap, expiry.KeyID)
+		} else {
+			keySet := view.adminKeySets[expiry.KeySet]
+			if len(keySet) <= minValidateKeys {
+				continue
+			}
+			pubKey, err := btcec.ParsePubKey(expiry.PubKey[:],
+				btcec.S256())
+			if err != nil {
+				continue
+			}
+			pos := keySet.Pos(pubKey)
+			if pos < 0 {
+				continue
+			}
+			view.adminKeySets[expiry.KeySet] = keySet.Remove(pos)
+		}
+		expiry.ExpiredHeight = blockHeight
+		view.keyExpiries[outPoint] = expiry
+	}
+}
+
+// unexpireKeys provisions the keys which expired at the end of the block at
+// the passed height again.
+func (view *KeyViewpoint) unexpireKeys(blockHeight uint32) {
+	for outPoint, expiry := range view.keyExpiries {
+		if expiry.ExpiredHeight != blockHeight {
+			continue
+		}
+		pubKey, err := btcec.ParsePubKey(expiry.PubKey[:], btcec.S256())
+		if err != nil {
+			continue
+		}
+		if expiry.KeySet == btcec.ASPKeySet {
+			view.aspKeyIdMap[expiry.KeyID] = pubKey
+		} else {
+			view.adminKeySets[expiry.KeySet] =
+				view.adminKeySets[expiry.KeySet].Add(pubKey)
+		}
+		expiry.ExpiredHeight = 0
+		view.keyExpiries[outPoint] = expiry
+	}
+}
+
+// undoKeyExpiryOp undoes what applyKeyExpiryOp did for the passed admin op
+// of the block at the passed height.
+func (view *KeyViewpoint) undoKeyExpiryOp(outPoint wire.OutPoint,
+	payload *adminop.Payload, blockHeight uint32) {
+	schema := payload.Schema()
+	if schema.IsAdd {
+		delete(view.keyExpiries, outPoint)
+		return
+	}
+	for outPoint, expiry := range view.keyExpiries {
+		if expiry.RevokedHeight == blockHeight && expiry.matches(
+			schema.KeySet, payload.PubKey, payload.KeyID) {
+
+			expiry.RevokedHeight = 0
+			view.keyExpiries[outPoint] = expiry
+		}
+	}
+}
+
+// applyIssueDestOp takes a single issuance destination op and applies it to
+// the view.
+func (view *KeyViewpoint) applyIssueDestOp(isAddOp bool,
+	dest [txscript.IssueDestLen]byte) {
+	if isAddOp {
+		view.issueDests[dest] = struct{}{}
+	} else {
+		delete(view.issueDests, dest)
+	}
+}
+
+// applySupplyChange adds issued atoms of the passed asset to its supply, or
+// removes destroyed ones from it.
+func (view *KeyViewpoint) applySupplyChange(assetID uint32, atoms int64,
+	isIssuance bool) {
+	if assetID == 0 {
+		if isIssuance {
+			view.totalSupply += uint64(atoms)
+		} else {
+			view.totalSupply -= uint64(atoms)
+		}
+		return
+	}
+	supply := view.assetSupply[assetID]
+	if isIssuance {
+		supply += uint64(atoms)
+	} else {
+		supply -= uint64(atoms)
+	}
+	if supply == 0 {
+		delete(view.assetSupply, assetID)
+		return
+	}
+	view.assetSupply[assetID] = supply
+}
+
+// TxSupplyChange returns the asset ID of the passed transaction along with the
+// atoms it adds to the supply of the asset, which are negative for a
+// destruction.  Only transactions of the issue thread change the supply, so 0
+// atoms are returned for all other transactions.
+func TxSupplyChange(tx *provautil.Tx, txHeight uint32,
+	chainParams *chaincfg.Params) (uint32, int64) {
+
+	threadInt, adminOutputs := txscript.GetAdminDetails(tx)
+	if threadInt < 0 || provautil.ThreadID(threadInt) != provautil.IssueThread {
+		return 0, 0
+	}
+
+	// A destruction destroys the value of its null data and commitment
+	// outputs, while an issuance issues the value of all but its first
+	// output.
+	msgTx := tx.MsgTx()
+	assetID := TxAssetID(msgTx, txHeight, chainParams)
+	var atoms int64
+	if len(msgTx.TxIn) > 1 {
+		for i := range adminOutputs {
+			scriptType := txscript.TypeOfScript(adminOutputs[i])
+			if scriptType == txscript.NullDataTy ||
+				scriptType == txscript.CommitmentTy {
+
+				atoms -= msgTx.TxOut[i+1].Value
+			}
+		}
+		return assetID, atoms
+	}
+	for _, txOut := range msgTx.TxOut[1:] {
+		atoms += txOut.Value
+	}
+	return assetID, atoms
+}
+
+// applyFreezeOp takes a single account freeze op and applies it to the view.
+func (view *KeyViewpoint) applyFreezeOp(isFreezeOp bool,
+	pkHash [ripemd160.Size]byte) {
+	if isFreezeOp {
+		view.frozen[pkHash] = struct{}{}
+	} else {
+		delete(view.frozen, pkHash)
+	}
+}
+
+// applyDelegateOp takes a single delegation op and applies it to the view.
+func (view *KeyViewpoint) applyDelegateOp(isAddOp bool,
+	payload *adminop.Payload) {
+	var pubKey [btcec.PubKeyBytesLenCompressed]byte
+	copy(pubKey[:], payload.PubKey.SerializeCompressed())
+	if isAddOp {
+		view.delegates[pubKey] = delegationFromPayload(payload)
+	} else {
+		delete(view.delegates, pubKey)
+	}
+}
+
 // connectTransaction updates the view by processing all new admin operations in
 // the passed transaction.
-func (view *KeyViewpoint) connectTransaction(tx *provautil.Tx, blockHeight uint32) {
+func (view *KeyViewpoint) connectTransaction(tx *provautil.Tx, blockHeight uint32,
+	chainParams *chaincfg.Params) {
+
 	// Process the admin outputs that are part of this tx.
-	view.ProcessAdminOuts(tx, blockHeight)
+	view.ProcessAdminOuts(tx, blockHeight, chainParams)
 }
 
 // connectTransactions updates the view by processing all the admin operations
-// in created by all of the transactions in the passed block.
-func (view *KeyViewpoint) connectTransactions(block *provautil.Block) {
+// in created by all of the transactions in the passed block, and revoking the
+// keys which expire at its height once keys may expire.
+func (view *KeyViewpoint) connectTransactions(block *provautil.Block,
+	minValidateKeys int, chainParams *chaincfg.Params) {
 	for _, tx := range block.Transactions() {
-		view.connectTransaction(tx, block.Height())
+		view.connectTransaction(tx, block.Height(), chainParams)
+	}
+	if chainParams.KeyExpiryActive(block.Height()) {
+		view.expireKeys(block.Height(), minValidateKeys)
 	}
 }
 
 // disconnectTransactions updates the view by undoing all admin operations in
 // all of the transactions contained in the passed block, and setting the best
 // hash for the view to the block before the passed block.
-func (view *KeyViewpoint) disconnectTransactions(block *provautil.Block) error {
+func (view *KeyViewpoint) disconnectTransactions(block *provautil.Block,
+	chainParams *chaincfg.Params) error {
+
+	// The keys which expired at the end of the block are provisioned
+	// again before its admin operations are undone.
+	expiryActive := chainParams.KeyExpiryActive(block.Height())
+	if expiryActive {
+		view.unexpireKeys(block.Height())
+	}
 
 	// Loop backwards through all transactions so operations are undone in
 	// reverse order.
@@ -205,25 +732,56 @@ func (view *KeyViewpoint) disconnectTransactions(block *provautil.Block) error {
 			threadId := provautil.ThreadID(threadInt)
 			if threadId == provautil.IssueThread {
 				isDestruction := len(tx.MsgTx().TxIn) > 1
+				assetID := TxAssetID(tx.MsgTx(), block.Height(),
+					chainParams)
 				if isDestruction {
 					for i := 0; i < len(adminOutputs); i++ {
 						// if this output pk script is a NullDataTy, then,
 						// according to previous validation, it must be
 						// admin operation (destruction)
 						scriptType := txscript.TypeOfScript(adminOutputs[i])
-						if scriptType == txscript.NullDataTy {
-							view.totalSupply += uint64(tx.MsgTx().TxOut[i+1].Value)
+						if scriptType == txscript.NullDataTy ||
+							scriptType == txscript.CommitmentTy {
+							view.applySupplyChange(assetID,
+								tx.MsgTx().TxOut[i+1].Value, true)
 						}
 					}
 				} else {
 					for i := 1; i < len(tx.MsgTx().TxOut); i++ {
-						view.totalSupply -= uint64(tx.MsgTx().TxOut[i].Value)
+						view.applySupplyChange(assetID,
+							tx.MsgTx().TxOut[i].Value, false)
 					}
 				}
 			} else {
 				for i := 0; i < len(adminOutputs); i++ {
-					isAddOp, keySetType, pubKey,
-						keyID := txscript.ExtractAdminOpData(adminOutputs[i])
+					payload, err := txscript.ExtractAdminPayload(adminOutputs[i])
+					if err != nil {
+						continue
+					}
+					schema := payload.Schema()
+					isAddOp := schema.IsAdd
+					switch schema.Kind {
+					case adminop.KindIssueDest:
+						view.applyIssueDestOp(!isAddOp, payload.IssueDest)
+						continue
+					case adminop.KindFreeze:
+						view.applyFreezeOp(!isAddOp, payload.PubKeyHash)
+						continue
+					case adminop.KindDelegate:
+						view.applyDelegateOp(!isAddOp, payload)
+						continue
+					case adminop.KindKey:
+					default:
+						continue
+					}
+					keySetType, pubKey, keyID := schema.KeySet,
+						payload.PubKey, payload.KeyID
+					if expiryActive {
+						view.undoKeyExpiryOp(wire.OutPoint{
+							Hash:  *tx.Hash(),
+							Index: uint32(i + 1)},
+							payload, block.Height())
+					}
 					if keySetType == btcec.ASPKeySet {
 						if isAddOp {
 							delete(view.aspKeyIdMap, keyID)
@@ -255,7 +813,12 @@ func NewKeyViewpoint() *KeyViewpoint {
 		threadTips:   make(map[provautil.ThreadID]*wire.OutPoint),
 		lastKeyID:    btcec.KeyID(0),
 		totalSupply:  uint64(0),
+		assetSupply:  make(AssetSupplies),
 		adminKeySets: make(map[btcec.KeySetType]btcec.PublicKeySet),
 		aspKeyIdMap:  make(map[btcec.KeyID]*btcec.PublicKey),
+		issueDests:   make(IssueDestSet),
+		frozen:       make(FrozenSet),
+		keyExpiries:  make(KeyExpiries),
+		delegates:    make(Delegates),
 	}
 }
@@ -0,0 +1,334 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/txscript/adminop"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// expiryTestBlock returns a block at the passed height holding a provision
+// thread admin transaction with an output for each of the passed payloads.
+func expiryTestBlock(t *testing.T, height uint32,
+	payloads ...*adminop.Payload) *provautil.Block {
+	threadPkScript, err := txscript.ProvaThreadScript(provautil.ProvisionThread)
+	if err != nil {
+		t.Fatalf("ProvaThreadScript: %v", err)
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: height}, nil))
+	tx.AddTxOut(wire.NewTxOut(0, threadPkScript))
+	for _, payload := range payloads {
+		pkScript, err := txscript.AdminOpScript(payload)
+		if err != nil {
+			t.Fatalf("AdminOpScript: %v", err)
+		}
+		tx.AddTxOut(wire.NewTxOut(0, pkScript))
+	}
+	block := provautil.NewBlock(&wire.MsgBlock{
+		Transactions: []*wire.MsgTx{tx},
+	})
+	block.SetHeight(height)
+	return block
+}
+
+// TestKeyExpiry ensures keys provisioned with an expiry height are revoked at
+// the end of the block at that height unless revoked before, that validate
+// keys only expire while enough of them stay provisioned, and that all of it
+// is undone when the blocks are disconnected.
+func TestKeyExpiry(t *testing.T) {
+	var keys []*btcec.PublicKey
+	for i := byte(1); i <= 4; i++ {
+		_, pubKey := btcec.PrivKeyFromBytes(btcec.S256(), []byte{i})
+		keys = append(keys, pubKey)
+	}
+
+	view := NewKeyViewpoint()
+	view.SetKeys(map[btcec.KeySetType]btcec.PublicKeySet{
+		btcec.ValidateKeySet: {*keys[0]},
+	})
+	const minValidateKeys = 2
+
+	// Block 1 provisions two ASP keys and two validate keys which expire
+	// at heights 2 and 3.  Block 2 revokes the second ASP key before it
+	// expires.
+	blocks := []*provautil.Block{
+		expiryTestBlock(t, 1,
+			&adminop.Payload{Op: adminop.ASPKeyAddExp,
+				PubKey: keys[1], KeyID: 1, Expiry: 2},
+			&adminop.Payload{Op: adminop.ASPKeyAddExp,
+				PubKey: keys[2], KeyID: 2, Expiry: 2},
+			&adminop.Payload{Op: adminop.ValidateKeyAddExp,
+				PubKey: keys[2], Expiry: 2},
+			&adminop.Payload{Op: adminop.ValidateKeyAddExp,
+				PubKey: keys[3], Expiry: 3}),
+		expiryTestBlock(t, 2,
+			&adminop.Payload{Op: adminop.ASPKeyRevoke,
+				PubKey: keys[2], KeyID: 2}),
+		expiryTestBlock(t, 3),
+	}
+	tests := []struct {
+		aspKeyIDs    []btcec.KeyID
+		validateKeys int
+		pending      int
+	}{
+		{aspKeyIDs: []btcec.KeyID{1, 2}, validateKeys: 3, pending: 4},
+		{aspKeyIDs: nil, validateKeys: 2, pending: 1},
+		// The last validate key to expire stays provisioned, since the
+		// set would fall below its minimum size.
+		{aspKeyIDs: nil, validateKeys: 2, pending: 1},
+	}
+	check := func(height int) {
+		test := tests[height-1]
+		if len(view.KeyIDs()) != len(test.aspKeyIDs) {
+			t.Fatalf("height %d: got ASP keys %v, want key IDs %v",
+				height, view.KeyIDs(), test.aspKeyIDs)
+		}
+		for _, keyID := range test.aspKeyIDs {
+			if view.KeyIDs()[keyID] == nil {
+				t.Fatalf("height %d: ASP key %d missing", height,
+					keyID)
+			}
+		}
+		validateKeySet := view.Keys()[btcec.ValidateKeySet]
+		if len(validateKeySet) != test.validateKeys {
+			t.Fatalf("height %d: got %d validate keys, want %d",
+				height, len(validateKeySet), test.validateKeys)
+		}
+		pending := view.KeyExpiries().Pending()
+		if len(pending) != test.pending {
+			t.Fatalf("height %d: got %d pending expiries, want %d",
+				height, len(pending), test.pending)
+		}
+	}
+
+	for i, block := range blocks {
+		view.connectTransactions(block, minValidateKeys,
+			&chaincfg.RegressionNetParams)
+		check(i + 1)
+	}
+	if pending := view.KeyExpiries().Pending(); pending[0].Height != 3 ||
+		!pending[0].matches(btcec.ValidateKeySet, keys[3], 0) {
+
+		t.Fatalf("got pending expiry %+v, want the one of the last "+
+			"validate key", pending[0])
+	}
+
+	for i := len(blocks) - 1; i > 0; i-- {
+		if err := view.disconnectTransactions(blocks[i],
+			&chaincfg.RegressionNetParams); err != nil {
+			t.Fatalf("disconnectTransactions: %v", err)
+		}
+		check(i)
+	}
+	if err := view.disconnectTransactions(blocks[0],
+		&chaincfg.RegressionNetParams); err != nil {
+		t.Fatalf("disconnectTransactions: %v", err)
+	}
+	if len(view.KeyExpiries()) != 0 || len(view.KeyIDs()) != 0 ||
+		len(view.Keys()[btcec.ValidateKeySet]) != 1 {
+
+		t.Fatalf("disconnecting all blocks left expiries %v, ASP keys "+
+			"%v", view.KeyExpiries(), view.KeyIDs())
+	}
+}
+
+// TestKeyExpiryActivation ensures keys provisioned by blocks below the
+// KeyExpiryHeight of the network neither have their expiries tracked nor
+// expire.
+func TestKeyExpiryActivation(t *testing.T) {
+	_, pubKey := btcec.PrivKeyFromBytes(btcec.S256(), []byte{1})
+	params := chaincfg.RegressionNetParams
+	params.KeyExpiryHeight = 3
+
+	view := NewKeyViewpoint()
+	blocks := []*provautil.Block{
+		expiryTestBlock(t, 1,
+			&adminop.Payload{Op: adminop.ASPKeyAddExp,
+				PubKey: pubKey, KeyID: 1, Expiry: 2}),
+		expiryTestBlock(t, 2),
+	}
+	for _, block := range blocks {
+		view.connectTransactions(block, 0, &params)
+	}
+	if len(view.KeyExpiries()) != 0 {
+		t.Fatalf("got expiries %v before activation",
+			view.KeyExpiries())
+	}
+	if view.KeyIDs()[1] == nil {
+		t.Fatalf("ASP key expired before activation")
+	}
+
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if err := view.disconnectTransactions(blocks[i],
+			&params); err != nil {
+			t.Fatalf("disconnectTransactions: %v", err)
+		}
+	}
+	if len(view.KeyIDs()) != 0 {
+		t.Fatalf("disconnecting all blocks left ASP keys %v",
+			view.KeyIDs())
+	}
+}
+
+// TestDelegation ensures delegations are recorded and undone with the blocks
+// carrying them, and that a provision thread transaction signed by a single
+// delegate key may only carry the ops delegated to the key.
+func TestDelegation(t *testing.T) {
+	var keys []*btcec.PublicKey
+	for i := byte(1); i <= 2; i++ {
+		_, pubKey := btcec.PrivKeyFromBytes(btcec.S256(), []byte{i})
+		keys = append(keys, pubKey)
+	}
+	var delegateKey [btcec.PubKeyBytesLenCompressed]byte
+	copy(delegateKey[:], keys[0].SerializeCompressed())
+	delegateAdd := &adminop.Payload{Op: adminop.DelegateKeyAdd,
+		PubKey: keys[0], Scope: adminop.ScopeASPKeyAdd, KeyID: 5,
+		MaxKeyID: 9}
+
+	view := NewKeyViewpoint()
+	block := expiryTestBlock(t, 1, delegateAdd)
+	view.connectTransactions(block, 0, &chaincfg.RegressionNetParams)
+	want := Delegation{Scope: adminop.ScopeASPKeyAdd, MinKeyID: 5,
+		MaxKeyID: 9}
+	if got := view.Delegates()[delegateKey]; got != want {
+		t.Fatalf("got delegation %+v, want %+v", got, want)
+	}
+
+	sig := make([]byte, 71)
+	delegateSigScript, err := txscript.NewScriptBuilder().
+		AddData(keys[0].SerializeCompressed()).AddData(sig).Script()
+	if err != nil {
+		t.Fatalf("NewScriptBuilder: %v", err)
+	}
+	multiSigScript, err := txscript.NewScriptBuilder().
+		AddData(keys[0].SerializeCompressed()).AddData(sig).
+		AddData(keys[1].SerializeCompressed()).AddData(sig).Script()
+	if err != nil {
+		t.Fatalf("NewScriptBuilder: %v", err)
+	}
+	if _, _, ok := view.signingDelegate(delegateSigScript); !ok {
+		t.Fatalf("signingDelegate: delegate key not detected")
+	}
+	if _, _, ok := view.signingDelegate(multiSigScript); ok {
+		t.Fatalf("signingDelegate: detected delegate key in script " +
+			"with two signatures")
+	}
+
+	// The checks of the delegations are off below the activation height,
+	// where the delegate ops are invalid.
+	lateParams := chaincfg.RegressionNetParams
+	lateParams.DelegateHeight = 3
+
+	tests := []struct {
+		name      string
+		sigScript []byte
+		lastKeyID btcec.KeyID
+		payload   *adminop.Payload
+		params    *chaincfg.Params
+		isValid   bool
+		code      ErrorCode
+	}{
+		{
+			name:      "delegated ASP key add",
+			sigScript: delegateSigScript,
+			lastKeyID: 5,
+			payload: &adminop.Payload{Op: adminop.ASPKeyAdd,
+				PubKey: keys[1], KeyID: 6},
+			isValid: true,
+		},
+		{
+			name:      "ASP key add past delegated range",
+			sigScript: delegateSigScript,
+			lastKeyID: 9,
+			payload: &adminop.Payload{Op: adminop.ASPKeyAdd,
+				PubKey: keys[1], KeyID: 10},
+			code: ErrDelegationScope,
+		},
+		{
+			name:      "ASP key add past range by provision keys",
+			sigScript: multiSigScript,
+			lastKeyID: 9,
+			payload: &adminop.Payload{Op: adminop.ASPKeyAdd,
+				PubKey: keys[1], KeyID: 10},
+			isValid: true,
+		},
+		{
+			name:      "undelegated validate key add",
+			sigScript: delegateSigScript,
+			payload: &adminop.Payload{Op: adminop.ValidateKeyAdd,
+				PubKey: keys[1]},
+			code: ErrDelegationScope,
+		},
+		{
+			name:      "revoke of delegation with other scope",
+			sigScript: multiSigScript,
+			payload: &adminop.Payload{Op: adminop.DelegateKeyRevoke,
+				PubKey: keys[0], Scope: adminop.ScopeASPKeyRevoke,
+				KeyID: 5, MaxKeyID: 9},
+			code: ErrInvalidAdminOp,
+		},
+		{
+			name:      "revoke of delegation",
+			sigScript: multiSigScript,
+			payload: &adminop.Payload{Op: adminop.DelegateKeyRevoke,
+				PubKey: keys[0], Scope: adminop.ScopeASPKeyAdd,
+				KeyID: 5, MaxKeyID: 9},
+			isValid: true,
+		},
+		{
+			name:      "revoke of delegation before activation",
+			sigScript: multiSigScript,
+			payload: &adminop.Payload{Op: adminop.DelegateKeyRevoke,
+				PubKey: keys[0], Scope: adminop.ScopeASPKeyAdd,
+				KeyID: 5, MaxKeyID: 9},
+			params: &lateParams,
+			code:   ErrInvalidAdminTx,
+		},
+		{
+			name:      "undelegated validate key add before activation",
+			sigScript: delegateSigScript,
+			payload: &adminop.Payload{Op: adminop.ValidateKeyAdd,
+				PubKey: keys[1]},
+			params:  &lateParams,
+			isValid: true,
+		},
+	}
+	for _, test := range tests {
+		tx := expiryTestBlock(t, 2, test.payload).Transactions()[0]
+		tx.MsgTx().TxIn[0].SignatureScript = test.sigScript
+		view.SetLastKeyID(test.lastKeyID)
+		params := test.params
+		if params == nil {
+			params = &chaincfg.RegressionNetParams
+		}
+		err := CheckTransactionOutputs(tx, 2, view, params)
+		if test.isValid {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+			}
+			continue
+		}
+		if rerr, ok := err.(RuleError); !ok || rerr.ErrorCode != test.code {
+			t.Errorf("%s: got error %v, want %v", test.name, err,
+				test.code)
+		}
+	}
+
+	if err := view.disconnectTransactions(block,
+		&chaincfg.RegressionNetParams); err != nil {
+		t.Fatalf("disconnectTransactions: %v", err)
+	}
+	if len(view.Delegates()) != 0 {
+		t.Fatalf("disconnecting the block left delegates %v",
+			view.Delegates())
+	}
+}
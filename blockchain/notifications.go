@@ -31,14 +31,30 @@ const (
 	// NTBlockDisconnected indicates the associated block was disconnected
 	// from the main chain.
 	NTBlockDisconnected
+
+	// NTReorganization indicates the main chain was reorganized.  It is
+	// sent after the notifications of the disconnected and connected
+	// blocks.
+	NTReorganization
+
+	// NTValidatorViolation indicates a block signed by a validate key which
+	// already signed another block at the same height was accepted.
+	NTValidatorViolation
+
+	// NTUnknownRules indicates the main chain started or stopped
+	// signaling or enforcing consensus rules this version does not know.
+	NTUnknownRules
 )
 
 // notificationTypeStrings is a map of notification types back to their constant
 // names for pretty printing.
 var notificationTypeStrings = map[NotificationType]string{
-	NTBlockAccepted:     "NTBlockAccepted",
-	NTBlockConnected:    "NTBlockConnected",
-	NTBlockDisconnected: "NTBlockDisconnected",
+	NTBlockAccepted:      "NTBlockAccepted",
+	NTBlockConnected:     "NTBlockConnected",
+	NTBlockDisconnected:  "NTBlockDisconnected",
+	NTReorganization:     "NTReorganization",
+	NTValidatorViolation: "NTValidatorViolation",
+	NTUnknownRules:       "NTUnknownRules",
 }
 
 // String returns the NotificationType in human-readable form.
@@ -52,9 +68,12 @@ func (n NotificationType) String() string {
 // Notification defines notification that is sent to the caller via the callback
 // function provided during the call to New and consists of a notification type
 // as well as associated data that depends on the type as follows:
-// 	- NTBlockAccepted:     *provautil.Block
-// 	- NTBlockConnected:    *provautil.Block
-// 	- NTBlockDisconnected: *provautil.Block
+// 	- NTBlockAccepted:      *provautil.Block
+// 	- NTBlockConnected:     *provautil.Block
+// 	- NTBlockDisconnected:  *provautil.Block
+// 	- NTReorganization:     *ReorgEvent
+// 	- NTValidatorViolation: *ValidatorViolation
+// 	- NTUnknownRules:       *UnknownRulesStatus
 type Notification struct {
 	Type NotificationType
 	Data interface{}
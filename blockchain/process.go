@@ -49,12 +49,15 @@ func (b *BlockChain) blockExists(hash *chainhash.Hash) (bool, error) {
 		return true, nil
 	}
 
-	// Check in the database.
+	// Check the main chain in the database.  Blocks are stored before they
+	// are connected, so a block which is stored but not in the main chain
+	// might never have been connected when the process was killed in
+	// between.  Such blocks must be processed again rather than rejected
+	// as duplicates, or the chain could never advance past them.
 	var exists bool
 	err := b.db.View(func(dbTx database.Tx) error {
-		var err error
-		exists, err = dbTx.HasBlock(hash)
-		return err
+		exists = dbMainChainHasBlock(dbTx, hash)
+		return nil
 	})
 	return exists, err
 }
@@ -154,7 +157,7 @@ func (b *BlockChain) ProcessBlock(block *provautil.Block, flags BehaviorFlags) (
 	}
 
 	// Perform preliminary sanity checks on the block and its transactions.
-	err = checkBlockSanity(block, b.chainParams.PowLimit, b.timeSource, flags)
+	err = checkBlockSanity(block, b.chainParams, b.timeSource, flags)
 	if err != nil {
 		return false, false, err
 	}
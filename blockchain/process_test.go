@@ -0,0 +1,155 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/blockchain/fullblocktests"
+	"github.com/pyx-partners/dmgd/blockchain/indexers"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+)
+
+// mainChainBlocks returns the blocks of the tests generated by the
+// fullblocktests package which each extend the main chain, starting from the
+// genesis block of the regression test network.
+func mainChainBlocks(t *testing.T) []*provautil.Block {
+	tests, err := fullblocktests.Generate(false)
+	if err != nil {
+		t.Fatalf("failed to generate tests: %v", err)
+	}
+	tip := chaincfg.RegressionNetParams.GenesisBlock.BlockHash()
+	var blocks []*provautil.Block
+	for _, test := range tests {
+		for _, item := range test {
+			accepted, ok := item.(fullblocktests.AcceptedBlock)
+			if !ok || !accepted.IsMainChain ||
+				accepted.Block.Header.PrevBlock != tip {
+
+				continue
+			}
+			block := provautil.NewBlock(accepted.Block)
+			block.SetHeight(accepted.Height)
+			blocks = append(blocks, block)
+			tip = *block.Hash()
+		}
+	}
+	return blocks
+}
+
+// TestRecoveryAfterKill ensures the chain recovers cleanly when the process is
+// killed after a block was stored but before it was connected, and when it is
+// interrupted while catching up the indexes.
+func TestRecoveryAfterKill(t *testing.T) {
+	blocks := mainChainBlocks(t)
+	if len(blocks) < 3 {
+		t.Fatalf("got %d blocks extending the main chain, want 3",
+			len(blocks))
+	}
+	blocks = blocks[:3]
+	params := chaincfg.RegressionNetParams
+
+	dir, err := ioutil.TempDir("", "recovery")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	dbPath := filepath.Join(dir, "db")
+	db, err := database.Create(testDbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	newChain := func(indexManager blockchain.IndexManager, interrupt <-chan struct{}) (*blockchain.BlockChain, error) {
+		paramsCopy := params
+		return blockchain.New(&blockchain.Config{
+			DB:           db,
+			ChainParams:  &paramsCopy,
+			TimeSource:   blockchain.NewMedianTime(),
+			SigCache:     txscript.NewSigCache(1000),
+			IndexManager: indexManager,
+			Interrupt:    interrupt,
+		})
+	}
+
+	// Connect all but the last block, then store the last block without
+	// connecting it, as happens when the process is killed in between.
+	chain, err := newChain(nil, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for _, block := range blocks[:len(blocks)-1] {
+		_, _, err := chain.ProcessBlock(block, blockchain.BFNone)
+		if err != nil {
+			t.Fatalf("ProcessBlock %v: %v", block.Hash(), err)
+		}
+	}
+	last := blocks[len(blocks)-1]
+	err = db.Update(func(dbTx database.Tx) error {
+		return dbTx.StoreBlock(last)
+	})
+	if err != nil {
+		t.Fatalf("StoreBlock: %v", err)
+	}
+	db.Close()
+
+	// Restart with the transaction index enabled, and interrupt the
+	// catch up of the index.
+	db, err = database.Open(testDbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	interrupt := make(chan struct{})
+	close(interrupt)
+	txIndex := indexers.NewTxIndex(db)
+	indexManager := indexers.NewManager(db, []indexers.Indexer{txIndex})
+	if _, err := newChain(indexManager, interrupt); err == nil {
+		t.Fatalf("New: expected error when interrupted")
+	}
+
+	// Restart again, which resumes the catch up of the index.
+	chain, err = newChain(indexManager, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	coinbaseRegion := func(block *provautil.Block) *database.BlockRegion {
+		region, err := txIndex.TxBlockRegion(block.Transactions()[0].Hash())
+		if err != nil {
+			t.Fatalf("TxBlockRegion: %v", err)
+		}
+		return region
+	}
+	if coinbaseRegion(blocks[len(blocks)-2]) == nil {
+		t.Errorf("transaction index was not caught up")
+	}
+
+	// The stored block is connected when it is processed again.
+	isMainChain, _, err := chain.ProcessBlock(last, blockchain.BFNone)
+	if err != nil {
+		t.Fatalf("ProcessBlock %v after restart: %v", last.Hash(), err)
+	}
+	if !isMainChain || !chain.BestSnapshot().Hash.IsEqual(last.Hash()) {
+		t.Fatalf("stored block %v was not connected to the main chain",
+			last.Hash())
+	}
+	if coinbaseRegion(last) == nil {
+		t.Errorf("transaction index is missing the connected block")
+	}
+
+	// Now that the block is connected, it is a duplicate.
+	_, _, err = chain.ProcessBlock(last, blockchain.BFNone)
+	if rerr, ok := err.(blockchain.RuleError); !ok ||
+		rerr.ErrorCode != blockchain.ErrDuplicateBlock {
+
+		t.Errorf("ProcessBlock: got %v, want ErrDuplicateBlock", err)
+	}
+}
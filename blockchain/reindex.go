@@ -0,0 +1,235 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/provautil"
+)
+
+// resetChainState discards the chain state in the database, which consists of
+// the block index, the spend journal, the utxo set and the admin state, and
+// records the best block as the target of a reindex.  The blocks themselves are
+// kept, so the chain state can be rebuilt from them.  The target of a reindex
+// which is already in progress is kept, since the best block is on the way to
+// it.
+func (b *BlockChain) resetChainState(rebuildIndexes bool) error {
+	return b.db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		target, indexes := dbFetchReindexTarget(dbTx)
+		serializedData := meta.Get(chainStateKeyName)
+		if serializedData == nil {
+			// Nothing to discard when the database has not been
+			// initialized yet or the chain state was already
+			// discarded.
+			if target == nil {
+				return nil
+			}
+			return dbPutReindexTarget(dbTx, target,
+				indexes || rebuildIndexes)
+		}
+		if target == nil {
+			state, err := deserializeBestChainState(serializedData)
+			if err != nil {
+				return err
+			}
+			target = &state.hash
+		}
+		err := dbPutReindexTarget(dbTx, target, indexes || rebuildIndexes)
+		if err != nil {
+			return err
+		}
+
+		log.Infof("Discarding the chain state to reindex the blocks up "+
+			"to %v", target)
+		buckets := [][]byte{hashIndexBucketName, heightIndexBucketName,
+			spendJournalBucketName, utxoSetBucketName}
+		for _, bucketName := range buckets {
+			if err := meta.DeleteBucket(bucketName); err != nil {
+				return err
+			}
+		}
+		keys := [][]byte{chainStateKeyName, utxoStateKeyName,
+			keySetBucketName, issueDestsKeyName, frozenKeyName,
+			assetSuppliesKeyName, keyExpiriesKeyName, delegatesKeyName}
+		for _, key := range keys {
+			if err := meta.Delete(key); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// loadReindexBlocks loads the hashes of the stored blocks which remain to be
+// connected by a reindex in progress, by following the previous block hashes
+// of the stored headers from the target of the reindex back to the main chain.
+// The reindex is abandoned when the stored blocks do not lead back to the main
+// chain, in which case the remaining blocks are downloaded from peers.
+func (b *BlockChain) loadReindexBlocks() error {
+	var target *chainhash.Hash
+	var blocks []chainhash.Hash
+	var height uint32
+	err := b.db.View(func(dbTx database.Tx) error {
+		target, b.reindexIndexes = dbFetchReindexTarget(dbTx)
+		hash := target
+		for hash != nil && !dbMainChainHasBlock(dbTx, hash) {
+			header, err := dbFetchHeaderByHash(dbTx, hash)
+			if err != nil {
+				return err
+			}
+			if len(blocks) == 0 {
+				height = header.Height
+			}
+
+			blocks = append(blocks, *hash)
+			hash = &header.PrevBlock
+		}
+		return nil
+	})
+	if err != nil {
+		log.Warnf("Unable to resume the reindex up to block %v: %v",
+			target, err)
+		blocks = nil
+	}
+	if target == nil {
+		return nil
+	}
+
+	// Nothing is left to be connected when the reindex was interrupted
+	// right after connecting its last block or has been abandoned.
+	if len(blocks) == 0 {
+		b.reindexIndexes = false
+		return b.db.Update(func(dbTx database.Tx) error {
+			return dbRemoveReindexTarget(dbTx)
+		})
+	}
+
+	// The blocks were loaded from the target back to the main chain, so
+	// reverse them to connect them in order.
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+	b.reindexBlocks = blocks
+	b.reindexHeight = height
+
+	log.Infof("Reindexing %d stored blocks up to height %d", len(blocks),
+		height)
+	return nil
+}
+
+// indexesConnected returns whether the optional indexes are updated along with
+// the chain, which is the case unless a reindex which does not rebuild them is
+// in progress.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) indexesConnected() bool {
+	return len(b.reindexBlocks) == 0 || b.reindexIndexes
+}
+
+// finishReindex records that the reindex in progress is complete, and catches
+// up the optional indexes which were not rebuilt along with the chain state.
+func (b *BlockChain) finishReindex(interrupt <-chan struct{}) error {
+	b.chainLock.Lock()
+	err := b.db.Update(func(dbTx database.Tx) error {
+		return dbRemoveReindexTarget(dbTx)
+	})
+	indexesConnected := b.reindexIndexes
+	b.reindexBlocks = nil
+	b.reindexIndexes = false
+	height := b.bestNode.height
+	b.chainLock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Reindex complete at height %d", height)
+	if b.indexManager == nil || indexesConnected {
+		return nil
+	}
+	return b.indexManager.Init(b, interrupt)
+}
+
+// ReindexNextBlock connects the next of the stored blocks a reindex in progress
+// rebuilds the chain state from, and returns it.  It returns nil when no
+// reindex is in progress.
+//
+// The reindex is complete once its last block is connected, or when a block
+// fails to connect, in which case the remaining blocks are left to be
+// downloaded from peers.  The optional indexes which were not rebuilt along
+// with the chain state are then caught up, which stops early with an error when
+// the interrupt channel is closed.
+//
+// The blocks are connected with ProcessBlock, so this function must not be
+// called concurrently with it or with itself.
+func (b *BlockChain) ReindexNextBlock(interrupt <-chan struct{}) (*provautil.Block, error) {
+	b.chainLock.RLock()
+	if len(b.reindexBlocks) == 0 {
+		b.chainLock.RUnlock()
+		return nil, nil
+	}
+	hash := b.reindexBlocks[0]
+	b.chainLock.RUnlock()
+
+	// Load the stored block, and connect it unless it was already
+	// connected, such as when it was submitted again in the meantime.
+	var block *provautil.Block
+	var inMainChain bool
+	err := b.db.View(func(dbTx database.Tx) error {
+		blockBytes, err := dbTx.FetchBlock(&hash)
+		if err != nil {
+			return err
+		}
+		block, err = provautil.NewBlockFromBytes(blockBytes)
+		if err != nil {
+			return err
+		}
+
+		inMainChain = dbMainChainHasBlock(dbTx, &hash)
+		return nil
+	})
+	if err == nil && !inMainChain {
+		var isMainChain, isOrphan bool
+		isMainChain, isOrphan, err = b.ProcessBlock(block, BFNone)
+		if err == nil && (isOrphan || !isMainChain) {
+			err = fmt.Errorf("stored block %v does not extend the "+
+				"main chain", hash)
+		}
+	}
+	if err != nil {
+		if err := b.finishReindex(interrupt); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("unable to reindex block %v: %v", hash,
+			err)
+	}
+
+	b.chainLock.Lock()
+	b.reindexBlocks = b.reindexBlocks[1:]
+	done := len(b.reindexBlocks) == 0
+	b.chainLock.Unlock()
+	if done {
+		if err := b.finishReindex(interrupt); err != nil {
+			return block, err
+		}
+	}
+
+	return block, nil
+}
+
+// ReindexTarget returns the height of the last of the stored blocks a reindex
+// rebuilds the chain state from, and whether a reindex is in progress.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ReindexTarget() (uint32, bool) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	return b.reindexHeight, len(b.reindexBlocks) > 0
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/blockchain/indexers"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/txscript"
+)
+
+// TestReindex ensures the chain state is rebuilt from the stored blocks by a
+// reindex, that an interrupted reindex resumes on the next start, and that the
+// optional indexes are either kept or rebuilt along with the chain state.
+func TestReindex(t *testing.T) {
+	blocks := mainChainBlocks(t)
+	if len(blocks) < 2 {
+		t.Fatalf("got %d blocks extending the main chain, want 2",
+			len(blocks))
+	}
+	last := blocks[len(blocks)-1]
+
+	dir, err := ioutil.TempDir("", "reindex")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	dbPath := filepath.Join(dir, "db")
+	db, err := database.Create(testDbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	newChain := func(reindex, reindexIndexes bool) (*blockchain.BlockChain, *indexers.Manager) {
+		params := chaincfg.RegressionNetParams
+		indexManager := indexers.NewManager(db, []indexers.Indexer{
+			indexers.NewTxIndex(db),
+		})
+		chain, err := blockchain.New(&blockchain.Config{
+			DB:             db,
+			ChainParams:    &params,
+			TimeSource:     blockchain.NewMedianTime(),
+			SigCache:       txscript.NewSigCache(1000),
+			IndexManager:   indexManager,
+			Reindex:        reindex,
+			ReindexIndexes: reindexIndexes,
+		})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		return chain, indexManager
+	}
+	reopen := func() {
+		db.Close()
+		db, err = database.Open(testDbType, dbPath, blockDataNet)
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+	}
+
+	chain, _ := newChain(false, false)
+	for _, block := range blocks {
+		_, _, err := chain.ProcessBlock(block, blockchain.BFNone)
+		if err != nil {
+			t.Fatalf("ProcessBlock %v: %v", block.Hash(), err)
+		}
+	}
+	reopen()
+
+	// Discard the chain state and connect the first stored block, then
+	// restart as happens when the reindex is interrupted.
+	chain, _ = newChain(true, false)
+	height, reindexing := chain.ReindexTarget()
+	if !reindexing || height != last.Height() {
+		t.Fatalf("ReindexTarget: got %d, %v, want %d, true", height,
+			reindexing, last.Height())
+	}
+	if best := chain.BestSnapshot(); best.Height != 0 {
+		t.Fatalf("got best height %d after discarding the chain state, "+
+			"want 0", best.Height)
+	}
+	if _, err := chain.ReindexNextBlock(nil); err != nil {
+		t.Fatalf("ReindexNextBlock: %v", err)
+	}
+	reopen()
+
+	// The reindex resumes without being requested again and connects the
+	// remaining stored blocks in order.
+	chain, indexManager := newChain(false, false)
+	if best := chain.BestSnapshot(); best.Height != 1 {
+		t.Fatalf("got best height %d after resuming, want 1",
+			best.Height)
+	}
+	for {
+		block, err := chain.ReindexNextBlock(nil)
+		if err != nil {
+			t.Fatalf("ReindexNextBlock: %v", err)
+		}
+		if block == nil {
+			break
+		}
+		if best := chain.BestSnapshot(); !best.Hash.IsEqual(block.Hash()) {
+			t.Fatalf("reindexed block %v is not the best block",
+				block.Hash())
+		}
+	}
+	if _, reindexing := chain.ReindexTarget(); reindexing {
+		t.Fatalf("ReindexTarget: reindex not complete")
+	}
+	if best := chain.BestSnapshot(); !best.Hash.IsEqual(last.Hash()) {
+		t.Fatalf("got best block %v after the reindex, want %v",
+			best.Hash, last.Hash())
+	}
+
+	// The kept transaction index and the rebuilt chain state are intact.
+	if err := indexManager.Verify(chain, nil); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+	if err := chain.VerifyKeyState(nil); err != nil {
+		t.Errorf("VerifyKeyState: %v", err)
+	}
+	entry, err := chain.FetchUtxoEntry(last.Transactions()[0].Hash())
+	if err != nil || entry == nil {
+		t.Errorf("FetchUtxoEntry: got %v, %v for the last coinbase",
+			entry, err)
+	}
+
+	// Rebuild the transaction index along with the chain state.
+	if err := chain.FlushUtxoCache(); err != nil {
+		t.Fatalf("FlushUtxoCache: %v", err)
+	}
+	if err := indexers.DropTxIndex(db); err != nil {
+		t.Fatalf("DropTxIndex: %v", err)
+	}
+	chain, indexManager = newChain(true, true)
+	for {
+		block, err := chain.ReindexNextBlock(nil)
+		if err != nil {
+			t.Fatalf("ReindexNextBlock: %v", err)
+		}
+		if block == nil {
+			break
+		}
+	}
+	if err := indexManager.Verify(chain, nil); err != nil {
+		t.Errorf("Verify after rebuilding the index: %v", err)
+	}
+	db.Close()
+}
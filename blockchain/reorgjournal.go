@@ -0,0 +1,290 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+)
+
+var (
+	// reorgJournalBucketName is the name of the db bucket used to house
+	// the journal of the chain reorganizations.
+	reorgJournalBucketName = []byte("reorgjournal")
+)
+
+// RevertedAdminTx describes an admin transaction which was reverted by a
+// chain reorganization and is not part of the new best chain.
+type RevertedAdminTx struct {
+	Hash     chainhash.Hash
+	ThreadID provautil.ThreadID
+}
+
+// ReorgEvent describes a reorganization of the main chain.  The disconnected
+// transactions are the non-coinbase transactions of the disconnected blocks
+// which are not part of the blocks connected by the reorganization.  They are
+// returned to the memory pool, but are no longer confirmed.
+type ReorgEvent struct {
+	Time             time.Time
+	ForkHash         chainhash.Hash
+	ForkHeight       uint32
+	OldTip           chainhash.Hash
+	OldHeight        uint32
+	NewTip           chainhash.Hash
+	NewHeight        uint32
+	DisconnectedTxs  []chainhash.Hash
+	RevertedAdminTxs []RevertedAdminTx
+}
+
+// Depth returns the number of blocks disconnected by the reorganization.
+func (e *ReorgEvent) Depth() uint32 {
+	return e.OldHeight - e.ForkHeight
+}
+
+// newReorgEvent returns the event describing the reorganization from the
+// detached blocks to the attached blocks, which both extend the passed fork
+// node.  The detached blocks are ordered from the old tip backwards and the
+// attached blocks from the fork forwards.
+func newReorgEvent(now time.Time, forkNode *blockNode, detachBlocks, attachBlocks []*provautil.Block) *ReorgEvent {
+	oldTip := detachBlocks[0]
+	newTip := attachBlocks[len(attachBlocks)-1]
+	event := &ReorgEvent{
+		Time:       time.Unix(now.Unix(), 0),
+		ForkHash:   *forkNode.hash,
+		ForkHeight: forkNode.height,
+		OldTip:     *oldTip.Hash(),
+		OldHeight:  oldTip.Height(),
+		NewTip:     *newTip.Hash(),
+		NewHeight:  newTip.Height(),
+	}
+
+	// Collect the transactions which are confirmed again by the new best
+	// chain.
+	attached := make(map[chainhash.Hash]struct{})
+	for _, block := range attachBlocks {
+		for _, tx := range block.Transactions()[1:] {
+			attached[*tx.Hash()] = struct{}{}
+		}
+	}
+
+	// Record the transactions of the disconnected blocks from the fork
+	// forwards, so they are listed in the order they were confirmed.
+	for i := len(detachBlocks) - 1; i >= 0; i-- {
+		for _, tx := range detachBlocks[i].Transactions()[1:] {
+			if _, ok := attached[*tx.Hash()]; ok {
+				continue
+			}
+			event.DisconnectedTxs = append(event.DisconnectedTxs,
+				*tx.Hash())
+
+			threadInt, _ := txscript.GetAdminDetails(tx)
+			if threadInt < 0 {
+				continue
+			}
+			event.RevertedAdminTxs = append(event.RevertedAdminTxs,
+				RevertedAdminTx{
+					Hash:     *tx.Hash(),
+					ThreadID: provautil.ThreadID(threadInt),
+				})
+		}
+	}
+	return event
+}
+
+// -----------------------------------------------------------------------------
+// The reorg journal consists of an entry for each reorganization of the main
+// chain, keyed by a sequence number which is serialized as a big endian uint64
+// so the entries are iterated in the order they were recorded.
+//
+// The serialized format of an entry is:
+//
+//   <time><fork hash><fork height><old tip><old height><new tip><new height>
+//   <num disconnected txs>[<tx hash>,...]
+//   <num reverted admin txs>[<tx hash><thread id>,...]
+//
+//   Field                 Type             Size
+//   time                  int64            8 bytes
+//   fork hash             chainhash.Hash   chainhash.HashSize
+//   fork height           uint32           4 bytes
+//   old tip               chainhash.Hash   chainhash.HashSize
+//   old height            uint32           4 bytes
+//   new tip               chainhash.Hash   chainhash.HashSize
+//   new height            uint32           4 bytes
+//   num disconnected txs  uint32           4 bytes
+//   tx hash               chainhash.Hash   chainhash.HashSize
+//   num reverted admin    uint32           4 bytes
+//   tx hash               chainhash.Hash   chainhash.HashSize
+//   thread id             uint8            1 byte
+// -----------------------------------------------------------------------------
+
+// reorgEventHeaderSize is the number of bytes of the serialized reorg event
+// before the disconnected transactions.
+const reorgEventHeaderSize = 8 + 3*(chainhash.HashSize+4)
+
+// serializeReorgEvent returns the serialization of the passed reorg event.
+func serializeReorgEvent(event *ReorgEvent) []byte {
+	size := reorgEventHeaderSize + 4 +
+		len(event.DisconnectedTxs)*chainhash.HashSize + 4 +
+		len(event.RevertedAdminTxs)*(chainhash.HashSize+1)
+	serialized := make([]byte, size)
+	byteOrder.PutUint64(serialized, uint64(event.Time.Unix()))
+	offset := 8
+	for _, tip := range []struct {
+		hash   *chainhash.Hash
+		height uint32
+	}{
+		{&event.ForkHash, event.ForkHeight},
+		{&event.OldTip, event.OldHeight},
+		{&event.NewTip, event.NewHeight},
+	} {
+		copy(serialized[offset:], tip.hash[:])
+		offset += chainhash.HashSize
+		byteOrder.PutUint32(serialized[offset:], tip.height)
+		offset += 4
+	}
+
+	byteOrder.PutUint32(serialized[offset:], uint32(len(event.DisconnectedTxs)))
+	offset += 4
+	for i := range event.DisconnectedTxs {
+		copy(serialized[offset:], event.DisconnectedTxs[i][:])
+		offset += chainhash.HashSize
+	}
+
+	byteOrder.PutUint32(serialized[offset:], uint32(len(event.RevertedAdminTxs)))
+	offset += 4
+	for _, adminTx := range event.RevertedAdminTxs {
+		copy(serialized[offset:], adminTx.Hash[:])
+		offset += chainhash.HashSize
+		serialized[offset] = byte(adminTx.ThreadID)
+		offset++
+	}
+	return serialized
+}
+
+// deserializeReorgEvent deserializes the passed serialized reorg event.
+func deserializeReorgEvent(serialized []byte) (*ReorgEvent, error) {
+	corrupt := func(desc string) error {
+		return database.Error{
+			ErrorCode:   database.ErrCorruption,
+			Description: "corrupt reorg journal entry, " + desc,
+		}
+	}
+	if len(serialized) < reorgEventHeaderSize+4 {
+		return nil, corrupt("unexpected end of data")
+	}
+
+	event := &ReorgEvent{
+		Time: time.Unix(int64(byteOrder.Uint64(serialized)), 0),
+	}
+	offset := 8
+	for _, tip := range []struct {
+		hash   *chainhash.Hash
+		height *uint32
+	}{
+		{&event.ForkHash, &event.ForkHeight},
+		{&event.OldTip, &event.OldHeight},
+		{&event.NewTip, &event.NewHeight},
+	} {
+		copy(tip.hash[:], serialized[offset:])
+		offset += chainhash.HashSize
+		*tip.height = byteOrder.Uint32(serialized[offset:])
+		offset += 4
+	}
+
+	numTxs := byteOrder.Uint32(serialized[offset:])
+	offset += 4
+	if uint64(len(serialized[offset:])) < uint64(numTxs)*chainhash.HashSize+4 {
+		return nil, corrupt("unexpected end of disconnected transactions")
+	}
+	if numTxs > 0 {
+		event.DisconnectedTxs = make([]chainhash.Hash, numTxs)
+	}
+	for i := range event.DisconnectedTxs {
+		copy(event.DisconnectedTxs[i][:], serialized[offset:])
+		offset += chainhash.HashSize
+	}
+
+	numAdminTxs := byteOrder.Uint32(serialized[offset:])
+	offset += 4
+	if uint64(len(serialized[offset:])) != uint64(numAdminTxs)*(chainhash.HashSize+1) {
+		return nil, corrupt("unexpected length of reverted admin " +
+			"transactions")
+	}
+	if numAdminTxs > 0 {
+		event.RevertedAdminTxs = make([]RevertedAdminTx, numAdminTxs)
+	}
+	for i := range event.RevertedAdminTxs {
+		adminTx := &event.RevertedAdminTxs[i]
+		copy(adminTx.Hash[:], serialized[offset:])
+		offset += chainhash.HashSize
+		adminTx.ThreadID = provautil.ThreadID(serialized[offset])
+		offset++
+	}
+	return event, nil
+}
+
+// dbPutReorgEvent uses an existing database transaction to append the passed
+// reorg event to the journal.  The journal bucket is created with the first
+// entry.
+func dbPutReorgEvent(dbTx database.Tx, event *ReorgEvent) error {
+	bucket, err := dbTx.Metadata().CreateBucketIfNotExists(
+		reorgJournalBucketName)
+	if err != nil {
+		return err
+	}
+
+	var seq uint64
+	cursor := bucket.Cursor()
+	if cursor.Last() {
+		seq = binary.BigEndian.Uint64(cursor.Key()) + 1
+	}
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], seq)
+	return bucket.Put(key[:], serializeReorgEvent(event))
+}
+
+// dbFetchReorgEvents uses an existing database transaction to fetch up to count
+// reorg events from the journal, newest first, after skipping the passed number
+// of the newest ones.
+func dbFetchReorgEvents(dbTx database.Tx, numToSkip, count int) ([]*ReorgEvent, error) {
+	bucket := dbTx.Metadata().Bucket(reorgJournalBucketName)
+	if bucket == nil {
+		return nil, nil
+	}
+
+	var events []*ReorgEvent
+	cursor := bucket.Cursor()
+	for ok := cursor.Last(); ok && len(events) < count; ok = cursor.Prev() {
+		if numToSkip > 0 {
+			numToSkip--
+			continue
+		}
+		event, err := deserializeReorgEvent(cursor.Value())
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// ReorgHistory returns up to count of the recorded reorganizations of the main
+// chain, newest first, after skipping the passed number of the newest ones.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ReorgHistory(numToSkip, count int) ([]*ReorgEvent, error) {
+	var events []*ReorgEvent
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		events, err = dbFetchReorgEvents(dbTx, numToSkip, count)
+		return err
+	})
+	return events, err
+}
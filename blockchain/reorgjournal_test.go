@@ -0,0 +1,219 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/database"
+	_ "github.com/pyx-partners/dmgd/database/ffldb"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// TestNewReorgEvent ensures the reorg event of a reorganization lists the
+// transactions of the disconnected blocks which are not confirmed again by the
+// new best chain and the admin transactions among them.
+func TestNewReorgEvent(t *testing.T) {
+	issueScript, err := txscript.ProvaThreadScript(provautil.IssueThread)
+	if err != nil {
+		t.Fatalf("ProvaThreadScript: %v", err)
+	}
+	newTx := func(index uint32, pkScript []byte) *wire.MsgTx {
+		tx := wire.NewMsgTx(wire.TxVersion)
+		tx.AddTxIn(&wire.TxIn{PreviousOutPoint: wire.OutPoint{Index: index}})
+		tx.AddTxOut(wire.NewTxOut(0, pkScript))
+		return tx
+	}
+	newBlock := func(height uint32, txs ...*wire.MsgTx) *provautil.Block {
+		msgBlock := wire.NewMsgBlock(&wire.BlockHeader{Height: height})
+		msgBlock.AddTransaction(newTx(wire.MaxPrevOutIndex, nil))
+		for _, tx := range txs {
+			msgBlock.AddTransaction(tx)
+		}
+		return provautil.NewBlock(msgBlock)
+	}
+
+	// The old chain confirms a transaction which the new chain confirms
+	// again, a transaction which is dropped and an issuance.
+	confirmedTx := newTx(1, []byte{txscript.OP_TRUE})
+	droppedTx := newTx(2, []byte{txscript.OP_TRUE})
+	issueTx := newTx(3, issueScript)
+	detachBlocks := []*provautil.Block{
+		newBlock(12, issueTx),
+		newBlock(11, confirmedTx, droppedTx),
+	}
+	attachBlocks := []*provautil.Block{
+		newBlock(11),
+		newBlock(12, confirmedTx),
+		newBlock(13),
+	}
+	forkNode := &blockNode{hash: &chainhash.Hash{0x01}, height: 10}
+	now := time.Unix(1500000000, 0)
+
+	event := newReorgEvent(now, forkNode, detachBlocks, attachBlocks)
+	want := &ReorgEvent{
+		Time:       now,
+		ForkHash:   chainhash.Hash{0x01},
+		ForkHeight: 10,
+		OldTip:     *detachBlocks[0].Hash(),
+		OldHeight:  12,
+		NewTip:     *attachBlocks[2].Hash(),
+		NewHeight:  13,
+		DisconnectedTxs: []chainhash.Hash{
+			droppedTx.TxHash(),
+			issueTx.TxHash(),
+		},
+		RevertedAdminTxs: []RevertedAdminTx{
+			{Hash: issueTx.TxHash(), ThreadID: provautil.IssueThread},
+		},
+	}
+	if !reflect.DeepEqual(event, want) {
+		t.Fatalf("got event %+v, want %+v", event, want)
+	}
+	if event.Depth() != 2 {
+		t.Fatalf("got depth %d, want 2", event.Depth())
+	}
+}
+
+// TestReorgEventSerialization ensures serializing and deserializing reorg
+// events works as expected and corrupt entries are detected.
+func TestReorgEventSerialization(t *testing.T) {
+	tests := []struct {
+		name  string
+		event *ReorgEvent
+	}{
+		{
+			name: "no transactions",
+			event: &ReorgEvent{
+				Time:       time.Unix(1500000000, 0),
+				ForkHash:   chainhash.Hash{0x01},
+				ForkHeight: 10,
+				OldTip:     chainhash.Hash{0x02},
+				OldHeight:  11,
+				NewTip:     chainhash.Hash{0x03},
+				NewHeight:  12,
+			},
+		},
+		{
+			name: "disconnected and admin transactions",
+			event: &ReorgEvent{
+				Time:       time.Unix(1500000001, 0),
+				ForkHash:   chainhash.Hash{0x04},
+				ForkHeight: 20,
+				OldTip:     chainhash.Hash{0x05},
+				OldHeight:  23,
+				NewTip:     chainhash.Hash{0x06},
+				NewHeight:  24,
+				DisconnectedTxs: []chainhash.Hash{
+					{0x07}, {0x08},
+				},
+				RevertedAdminTxs: []RevertedAdminTx{
+					{Hash: chainhash.Hash{0x08},
+						ThreadID: provautil.ProvisionThread},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		serialized := serializeReorgEvent(test.event)
+		event, err := deserializeReorgEvent(serialized)
+		if err != nil {
+			t.Errorf("%s: deserializeReorgEvent: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(event, test.event) {
+			t.Errorf("%s: got event %+v, want %+v", test.name, event,
+				test.event)
+		}
+
+		// Truncating or extending the entry must be detected.
+		for _, corrupt := range [][]byte{
+			serialized[:len(serialized)-1],
+			append(serialized[:len(serialized):len(serialized)], 0x00),
+		} {
+			_, err := deserializeReorgEvent(corrupt)
+			if derr, ok := err.(database.Error); !ok ||
+				derr.ErrorCode != database.ErrCorruption {
+
+				t.Errorf("%s: got error %v for corrupt entry, want "+
+					"ErrCorruption", test.name, err)
+			}
+		}
+	}
+}
+
+// TestReorgJournal ensures the reorg events are appended to the journal and
+// fetched newest first.
+func TestReorgJournal(t *testing.T) {
+	dbPath, err := ioutil.TempDir("", "reorgjournal")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", filepath.Join(dbPath, "db"),
+		wire.TestNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer db.Close()
+	chain := &BlockChain{db: db}
+
+	// The journal is empty before the first reorganization.
+	events, err := chain.ReorgHistory(0, 10)
+	if err != nil {
+		t.Fatalf("ReorgHistory: %v", err)
+	}
+	if len(events) != 0 {
+		t.Fatalf("got %d events from an empty journal", len(events))
+	}
+
+	var recorded []*ReorgEvent
+	for i := uint32(0); i < 3; i++ {
+		event := &ReorgEvent{
+			Time:       time.Unix(int64(1500000000+i), 0),
+			ForkHeight: 10 * i,
+			OldHeight:  10*i + 1,
+			NewHeight:  10*i + 2,
+		}
+		err := db.Update(func(dbTx database.Tx) error {
+			return dbPutReorgEvent(dbTx, event)
+		})
+		if err != nil {
+			t.Fatalf("dbPutReorgEvent: %v", err)
+		}
+		recorded = append(recorded, event)
+	}
+
+	tests := []struct {
+		skip  int
+		count int
+		want  []*ReorgEvent
+	}{
+		{0, 10, []*ReorgEvent{recorded[2], recorded[1], recorded[0]}},
+		{0, 2, []*ReorgEvent{recorded[2], recorded[1]}},
+		{1, 1, []*ReorgEvent{recorded[1]}},
+		{2, 10, []*ReorgEvent{recorded[0]}},
+		{3, 10, nil},
+	}
+	for i, test := range tests {
+		events, err := chain.ReorgHistory(test.skip, test.count)
+		if err != nil {
+			t.Fatalf("ReorgHistory #%d: %v", i, err)
+		}
+		if !reflect.DeepEqual(events, test.want) {
+			t.Errorf("ReorgHistory #%d: got %d events, want %d", i,
+				len(events), len(test.want))
+		}
+	}
+}
@@ -8,6 +8,8 @@ package blockchain
 
 import (
 	"fmt"
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
 	"github.com/pyx-partners/dmgd/provautil"
 	"github.com/pyx-partners/dmgd/txscript"
 	"github.com/pyx-partners/dmgd/wire"
@@ -32,6 +34,8 @@ type txValidator struct {
 	resultChan   chan error
 	utxoView     *UtxoViewpoint
 	keyView      *KeyViewpoint
+	chainParams  *chaincfg.Params
+	height       uint32
 	flags        txscript.ScriptFlags
 	sigCache     *txscript.SigCache
 	hashCache    *txscript.HashCache
@@ -94,8 +98,24 @@ out:
 				break out
 			}
 			// If script is Prova script, we replace all keyIDs with pubKeyHashes.
+			// This includes the keyIDs nested in Prova HTLC, time lock and
+			// keyID scripts, but not the keyID checked by the latter.
 			scriptType := txscript.TypeOfScript(pops)
-			if scriptType == txscript.ProvaTy || scriptType == txscript.GeneralProvaTy {
+			if scriptType == txscript.ProvaHTLCTy &&
+				!v.chainParams.HTLCActive(v.height) {
+				str := fmt.Sprintf("input %s:%d spends Prova HTLC "+
+					"output %s:%d before HTLCs are active",
+					txVI.tx.Hash(), txVI.txInIndex, originTxHash,
+					originTxIndex)
+				err := ruleError(ErrScriptValidation, str)
+				v.sendResult(err)
+				break out
+			}
+			if scriptType == txscript.ProvaTy ||
+				scriptType == txscript.GeneralProvaTy ||
+				scriptType == txscript.ProvaHTLCTy ||
+				scriptType == txscript.ProvaTimeLockTy ||
+				scriptType == txscript.ProvaKeyIDTy {
 				keyIDs, err := txscript.ExtractKeyIDs(pops)
 				if err != nil {
 					str := fmt.Sprintf("failed to extract keyIDs %s: %v", originTxHash, err)
@@ -130,7 +150,22 @@ out:
 					break out
 				}
 				keyHashes := v.keyView.GetAdminKeyHashes(threadID)
-				pkScript, err = txscript.ThreadPkScript(keyHashes)
+				threshold := v.chainParams.AdminThreshold(
+					btcec.KeySetType(threadID))
+				// A delegate key signs the provision thread on its
+				// own once keys may be delegated.  The ops it may
+				// sign are checked with the transaction outputs.
+				if threadID == provautil.ProvisionThread &&
+					v.chainParams.DelegatesActive(v.height) {
+					pubKey, _, ok := v.keyView.signingDelegate(
+						txIn.SignatureScript)
+					if ok {
+						keyHashes = [][]byte{provautil.Hash160(pubKey)}
+						threshold = 1
+					}
+				}
+				pkScript, err = txscript.ThreadPkScript(keyHashes,
+					threshold)
 				if err != nil {
 					str := fmt.Sprintf("failed to replace threadID %s: %v", originTxHash, err)
 					err := ruleError(ErrScriptMalformed, str)
@@ -142,8 +177,11 @@ out:
 			// Create a new script engine for the script pair.
 			sigScript := txIn.SignatureScript
 			inputAmount := txEntry.AmountByIndex(originTxIndex)
-			vm, err := txscript.NewEngine(pkScript, txVI.tx.MsgTx(),
-				txVI.txInIndex, v.flags, v.sigCache, txVI.sigHashes, inputAmount)
+			vm, err := txscript.NewEngineWithLimits(pkScript,
+				txVI.tx.MsgTx(), txVI.txInIndex, v.flags, v.sigCache,
+				txVI.sigHashes, inputAmount,
+				v.chainParams.ScriptEngineLimits())
+
 			if err != nil {
 				str := fmt.Sprintf("failed to parse input "+
 					"%s:%d which references output %s:%d - "+
@@ -156,7 +194,9 @@ out:
 				break out
 			}
 
-			// Execute the script pair.
+			// Execute the script pair, with the ASP keys of the view
+			// checked by OP_CHECKKEYIDVERIFY.
+			vm.SetKeyIDLookup(v.keyView.LookupKeyID)
 			if err := vm.Execute(); err != nil {
 				str := fmt.Sprintf("failed to validate input "+
 					"%s:%d which references output %s:%d - "+
@@ -238,13 +278,15 @@ func (v *txValidator) Validate(items []*txValidateItem) error {
 
 // newTxValidator returns a new instance of txValidator to be used for
 // validating transaction scripts asynchronously.
-func newTxValidator(utxoView *UtxoViewpoint, keyView *KeyViewpoint, flags txscript.ScriptFlags, sigCache *txscript.SigCache, hashCache *txscript.HashCache) *txValidator {
+func newTxValidator(utxoView *UtxoViewpoint, keyView *KeyViewpoint, chainParams *chaincfg.Params, height uint32, flags txscript.ScriptFlags, sigCache *txscript.SigCache, hashCache *txscript.HashCache) *txValidator {
 	return &txValidator{
 		validateChan: make(chan *txValidateItem),
 		quitChan:     make(chan struct{}),
 		resultChan:   make(chan error),
 		utxoView:     utxoView,
 		keyView:      keyView,
+		chainParams:  chainParams,
+		height:       height,
 		sigCache:     sigCache,
 		hashCache:    hashCache,
 		flags:        flags,
@@ -252,8 +294,9 @@ func newTxValidator(utxoView *UtxoViewpoint, keyView *KeyViewpoint, flags txscri
 }
 
 // ValidateTransactionScripts validates the scripts for the passed transaction
-// using multiple goroutines.
-func ValidateTransactionScripts(tx *provautil.Tx, utxoView *UtxoViewpoint, keyView *KeyViewpoint, flags txscript.ScriptFlags, sigCache *txscript.SigCache, hashCache *txscript.HashCache) error {
+// of the block at the passed height using multiple goroutines.  Admin thread
+// outputs are spent with the admin thresholds of the passed chain parameters.
+func ValidateTransactionScripts(tx *provautil.Tx, txHeight uint32, utxoView *UtxoViewpoint, keyView *KeyViewpoint, chainParams *chaincfg.Params, flags txscript.ScriptFlags, sigCache *txscript.SigCache, hashCache *txscript.HashCache) error {
 
 	// If the hashcache doesn't yet has the sighash midstate for this
 	// transaction, then we'll compute them now so we can re-use them
@@ -288,13 +331,14 @@ func ValidateTransactionScripts(tx *provautil.Tx, utxoView *UtxoViewpoint, keyVi
 	}
 
 	// Validate all of the inputs.
-	validator := newTxValidator(utxoView, keyView, flags, sigCache, hashCache)
+	validator := newTxValidator(utxoView, keyView, chainParams, txHeight,
+		flags, sigCache, hashCache)
 	return validator.Validate(txValItems)
 }
 
 // checkBlockScripts executes and validates the scripts for all transactions in
 // the passed block using multiple goroutines.
-func checkBlockScripts(block *provautil.Block, utxoView *UtxoViewpoint, keyView *KeyViewpoint, scriptFlags txscript.ScriptFlags, sigCache *txscript.SigCache, hashCache *txscript.HashCache) error {
+func checkBlockScripts(block *provautil.Block, utxoView *UtxoViewpoint, keyView *KeyViewpoint, chainParams *chaincfg.Params, scriptFlags txscript.ScriptFlags, sigCache *txscript.SigCache, hashCache *txscript.HashCache) error {
 	// Collect all of the transaction inputs and required information for
 	// validation for all transactions in the block into a single slice.
 	numInputs := 0
@@ -337,6 +381,7 @@ func checkBlockScripts(block *provautil.Block, utxoView *UtxoViewpoint, keyView
 	}
 
 	// Validate all of the inputs.
-	validator := newTxValidator(utxoView, keyView, scriptFlags, sigCache, hashCache)
+	validator := newTxValidator(utxoView, keyView, chainParams,
+		block.Height(), scriptFlags, sigCache, hashCache)
 	return validator.Validate(txValItems)
 }
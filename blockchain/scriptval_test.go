@@ -12,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/chaincfg"
 	"github.com/pyx-partners/dmgd/txscript"
 )
 
@@ -46,8 +47,8 @@ func CheckBlockScripts(t *testing.T) {
 	}
 
 	scriptFlags := txscript.ScriptBip16
-	err = blockchain.TstCheckBlockScripts(blocks[0], utxoView, nil, scriptFlags,
-		nil, nil)
+	err = blockchain.TstCheckBlockScripts(blocks[0], utxoView, nil,
+		&chaincfg.MainNetParams, scriptFlags, nil, nil)
 	if err != nil {
 		t.Errorf("Transaction script validation failed: %v\n", err)
 		return
@@ -0,0 +1,414 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/database"
+)
+
+var (
+	// signedCheckpointsBucketName is the name of the db bucket used to
+	// house the signed checkpoints which are enforced.
+	signedCheckpointsBucketName = []byte("signedcheckpoints")
+)
+
+// SignedCheckpoint is a checkpoint of the main chain signed by a checkpoint
+// key.  Besides the height and hash of the block, it commits to the hash of the
+// utxo set and of the admin key state after the block, so operators can compare
+// the state of their nodes at the checkpoint.  Nodes which trust the signing
+// key reject any chain which does not contain the block.
+type SignedCheckpoint struct {
+	Height       uint32
+	Hash         chainhash.Hash
+	UtxoHash     chainhash.Hash
+	KeyStateHash chainhash.Hash
+	PubKey       *btcec.PublicKey
+	Signature    *btcec.Signature
+}
+
+// signedCheckpointDataSize is the number of bytes of the serialized data the
+// signature of a checkpoint commits to.
+const signedCheckpointDataSize = 4 + 3*chainhash.HashSize
+
+// sigHash returns the hash the signature of the checkpoint commits to.
+func (c *SignedCheckpoint) sigHash() []byte {
+	var data [signedCheckpointDataSize]byte
+	byteOrder.PutUint32(data[:], c.Height)
+	copy(data[4:], c.Hash[:])
+	copy(data[4+chainhash.HashSize:], c.UtxoHash[:])
+	copy(data[4+2*chainhash.HashSize:], c.KeyStateHash[:])
+	return chainhash.DoubleHashB(data[:])
+}
+
+// Sign signs the checkpoint with the passed checkpoint key.
+func (c *SignedCheckpoint) Sign(key *btcec.PrivateKey) error {
+	sig, err := key.Sign(c.sigHash())
+	if err != nil {
+		return err
+	}
+	c.PubKey = key.PubKey()
+	c.Signature = sig
+	return nil
+}
+
+// Verify returns whether the checkpoint is validly signed by its public key.
+func (c *SignedCheckpoint) Verify() bool {
+	if c.PubKey == nil || c.Signature == nil {
+		return false
+	}
+	return c.Signature.Verify(c.sigHash(), c.PubKey)
+}
+
+// -----------------------------------------------------------------------------
+// The serialized format of a signed checkpoint is:
+//
+//   <height><hash><utxo hash><key state hash><pubkey><signature>
+//
+//   Field                 Type             Size
+//   height                uint32           4 bytes
+//   hash                  chainhash.Hash   chainhash.HashSize
+//   utxo hash             chainhash.Hash   chainhash.HashSize
+//   key state hash        chainhash.Hash   chainhash.HashSize
+//   pubkey                []byte           33 bytes (compressed)
+//   signature             []byte           variable (DER encoded)
+//
+// The signed checkpoints are stored in the database keyed by their height,
+// which is serialized as a big endian uint32.
+// -----------------------------------------------------------------------------
+
+// Serialize returns the serialization of the signed checkpoint.
+func (c *SignedCheckpoint) Serialize() []byte {
+	sig := c.Signature.Serialize()
+	serialized := make([]byte, signedCheckpointDataSize+
+		btcec.PubKeyBytesLenCompressed+len(sig))
+	byteOrder.PutUint32(serialized, c.Height)
+	offset := 4
+	for _, hash := range []*chainhash.Hash{&c.Hash, &c.UtxoHash, &c.KeyStateHash} {
+		copy(serialized[offset:], hash[:])
+		offset += chainhash.HashSize
+	}
+	copy(serialized[offset:], c.PubKey.SerializeCompressed())
+	offset += btcec.PubKeyBytesLenCompressed
+	copy(serialized[offset:], sig)
+	return serialized
+}
+
+// DeserializeSignedCheckpoint deserializes the passed serialized signed
+// checkpoint.  The signature is not verified.
+func DeserializeSignedCheckpoint(serialized []byte) (*SignedCheckpoint, error) {
+	if len(serialized) <= signedCheckpointDataSize+btcec.PubKeyBytesLenCompressed {
+		return nil, errors.New("signed checkpoint is too short")
+	}
+
+	c := &SignedCheckpoint{Height: byteOrder.Uint32(serialized)}
+	offset := 4
+	for _, hash := range []*chainhash.Hash{&c.Hash, &c.UtxoHash, &c.KeyStateHash} {
+		copy(hash[:], serialized[offset:])
+		offset += chainhash.HashSize
+	}
+	pubKey, err := btcec.ParsePubKey(serialized[offset:offset+
+		btcec.PubKeyBytesLenCompressed], btcec.S256())
+	if err != nil {
+		return nil, fmt.Errorf("invalid signed checkpoint public key: %v",
+			err)
+	}
+	offset += btcec.PubKeyBytesLenCompressed
+	sig, err := btcec.ParseDERSignature(serialized[offset:], btcec.S256())
+	if err != nil {
+		return nil, fmt.Errorf("invalid signed checkpoint signature: %v",
+			err)
+	}
+	c.PubKey = pubKey
+	c.Signature = sig
+	return c, nil
+}
+
+// dbPutSignedCheckpoint uses an existing database transaction to store the
+// passed signed checkpoint.  The bucket is created with the first checkpoint.
+func dbPutSignedCheckpoint(dbTx database.Tx, checkpoint *SignedCheckpoint) error {
+	bucket, err := dbTx.Metadata().CreateBucketIfNotExists(
+		signedCheckpointsBucketName)
+	if err != nil {
+		return err
+	}
+
+	var key [4]byte
+	binary.BigEndian.PutUint32(key[:], checkpoint.Height)
+	return bucket.Put(key[:], checkpoint.Serialize())
+}
+
+// dbFetchSignedCheckpoints uses an existing database transaction to fetch all
+// stored signed checkpoints in ascending order of height.
+func dbFetchSignedCheckpoints(dbTx database.Tx) ([]*SignedCheckpoint, error) {
+	bucket := dbTx.Metadata().Bucket(signedCheckpointsBucketName)
+	if bucket == nil {
+		return nil, nil
+	}
+
+	var checkpoints []*SignedCheckpoint
+	err := bucket.ForEach(func(k, v []byte) error {
+		checkpoint, err := DeserializeSignedCheckpoint(v)
+		if err != nil {
+			return database.Error{
+				ErrorCode: database.ErrCorruption,
+				Description: fmt.Sprintf("corrupt signed "+
+					"checkpoint %x: %v", k, err),
+			}
+		}
+		checkpoints = append(checkpoints, checkpoint)
+		return nil
+	})
+	return checkpoints, err
+}
+
+// dbUtxoSetHash uses an existing database transaction to hash the utxo set
+// stored in the database, in the order of the transaction hashes.
+func dbUtxoSetHash(dbTx database.Tx) (chainhash.Hash, error) {
+	hasher := sha256.New()
+	bucket := dbTx.Metadata().Bucket(utxoSetBucketName)
+	err := bucket.ForEach(func(k, v []byte) error {
+		hasher.Write(k)
+		hasher.Write(v)
+		return nil
+	})
+	if err != nil {
+		return chainhash.Hash{}, err
+	}
+
+	var hash chainhash.Hash
+	copy(hash[:], hasher.Sum(nil))
+	return hash, nil
+}
+
+// keyStateHash returns the hash of the admin key state of the end of the main
+// chain, which consists of the admin and ASP keys, the thread tips, the supply,
+// the issuance destinations, the frozen accounts, the key expiries and the
+// delegates.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) keyStateHash() chainhash.Hash {
+	var state []byte
+	state = append(state, serializeKeySet(b.adminKeySets, b.aspKeyIdMap,
+		b.threadTips, b.lastKeyID, b.totalSupply)...)
+	state = append(state, serializeIssueDests(b.issueDests)...)
+	state = append(state, serializeFrozen(b.frozen)...)
+	state = append(state, serializeAssetSupplies(b.assetSupplies)...)
+	state = append(state, serializeKeyExpiries(b.keyExpiries)...)
+	state = append(state, serializeDelegates(b.delegates)...)
+	return chainhash.DoubleHashH(state)
+}
+
+// KeyStateHash returns the hash of the admin key state of the end of the main
+// chain, as committed to by signed checkpoints.  Nodes agree on the admin key
+// sets, thread tips and supply when their hashes are equal.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) KeyStateHash() chainhash.Hash {
+	b.stateLock.RLock()
+	defer b.stateLock.RUnlock()
+	return b.keyStateHash()
+}
+
+// CheckpointState returns an unsigned checkpoint of the end of the main chain.
+// The cached utxos are flushed to the database first and the whole utxo set is
+// hashed, which blocks the processing of blocks for a while on large chains.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) CheckpointState() (*SignedCheckpoint, error) {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	if err := b.utxoCache.flush(b.bestNode.hash); err != nil {
+		return nil, err
+	}
+	var utxoHash chainhash.Hash
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		utxoHash, err = dbUtxoSetHash(dbTx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignedCheckpoint{
+		Height:       b.bestNode.height,
+		Hash:         *b.bestNode.hash,
+		UtxoHash:     utxoHash,
+		KeyStateHash: b.keyStateHash(),
+	}, nil
+}
+
+// isCheckpointKey returns whether the passed key is one of the trusted
+// checkpoint keys.
+func (b *BlockChain) isCheckpointKey(pubKey *btcec.PublicKey) bool {
+	for _, key := range b.checkpointPubKeys {
+		if key.IsEqual(pubKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadSignedCheckpoints loads the signed checkpoints stored in the database
+// which are signed by one of the trusted checkpoint keys, so that removing a
+// key stops enforcing its checkpoints.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) loadSignedCheckpoints() error {
+	var checkpoints []*SignedCheckpoint
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		checkpoints, err = dbFetchSignedCheckpoints(dbTx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, checkpoint := range checkpoints {
+		if !b.isCheckpointKey(checkpoint.PubKey) || !checkpoint.Verify() {
+			log.Debugf("Ignoring signed checkpoint at height %d of "+
+				"untrusted key", checkpoint.Height)
+			continue
+		}
+		b.signedCheckpoints = append(b.signedCheckpoints, checkpoint)
+	}
+	if len(b.signedCheckpoints) > 0 {
+		latest := b.signedCheckpoints[len(b.signedCheckpoints)-1]
+		log.Infof("Loaded %d signed checkpoints (latest height %d, "+
+			"hash %v)", len(b.signedCheckpoints), latest.Height,
+			latest.Hash)
+	}
+	return nil
+}
+
+// AddSignedCheckpoint verifies the passed signed checkpoint is signed by one of
+// the trusted checkpoint keys and agrees with the main chain and the other
+// checkpoints, then stores and enforces it.  From then on, blocks at the
+// height of the checkpoint must match it, and once the main chain contains it,
+// blocks which fork the main chain at or before it are rejected.  Adding a
+// checkpoint which is already enforced has no effect.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) AddSignedCheckpoint(checkpoint *SignedCheckpoint) error {
+	if !b.isCheckpointKey(checkpoint.PubKey) {
+		return ruleError(ErrUntrustedCheckpoint, "checkpoint is not "+
+			"signed by a trusted checkpoint key")
+	}
+	if !checkpoint.Verify() {
+		return ruleError(ErrUntrustedCheckpoint, "checkpoint signature "+
+			"is invalid")
+	}
+
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	// Ensure the checkpoint agrees with the other checkpoints.
+	i := sort.Search(len(b.signedCheckpoints), func(i int) bool {
+		return b.signedCheckpoints[i].Height >= checkpoint.Height
+	})
+	if i < len(b.signedCheckpoints) &&
+		b.signedCheckpoints[i].Height == checkpoint.Height {
+
+		if b.signedCheckpoints[i].Hash != checkpoint.Hash {
+			str := fmt.Sprintf("checkpoint block %v at height %d "+
+				"conflicts with signed checkpoint block %v",
+				checkpoint.Hash, checkpoint.Height,
+				b.signedCheckpoints[i].Hash)
+			return ruleError(ErrBadCheckpoint, str)
+		}
+		return nil
+	}
+	if !b.verifyCheckpoint(checkpoint.Height, &checkpoint.Hash) {
+		str := fmt.Sprintf("checkpoint block %v at height %d conflicts "+
+			"with the checkpoints of the network", checkpoint.Hash,
+			checkpoint.Height)
+		return ruleError(ErrBadCheckpoint, str)
+	}
+
+	// Ensure the main chain does not contain another block at the height
+	// of the checkpoint, and store the checkpoint.
+	err := b.db.Update(func(dbTx database.Tx) error {
+		if checkpoint.Height <= b.bestNode.height {
+			hash, err := dbFetchHashByHeight(dbTx, checkpoint.Height)
+			if err != nil {
+				return err
+			}
+			if *hash != checkpoint.Hash {
+				str := fmt.Sprintf("checkpoint block %v at "+
+					"height %d conflicts with main chain "+
+					"block %v", checkpoint.Hash,
+					checkpoint.Height, hash)
+				return ruleError(ErrBadCheckpoint, str)
+			}
+		}
+		return dbPutSignedCheckpoint(dbTx, checkpoint)
+	})
+	if err != nil {
+		return err
+	}
+
+	b.signedCheckpoints = append(b.signedCheckpoints, nil)
+	copy(b.signedCheckpoints[i+1:], b.signedCheckpoints[i:])
+	b.signedCheckpoints[i] = checkpoint
+	log.Infof("Enforcing signed checkpoint at height %d/block %v",
+		checkpoint.Height, checkpoint.Hash)
+	return nil
+}
+
+// SignedCheckpoints returns the enforced signed checkpoints in ascending order
+// of height.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) SignedCheckpoints() []*SignedCheckpoint {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	checkpoints := make([]*SignedCheckpoint, len(b.signedCheckpoints))
+	copy(checkpoints, b.signedCheckpoints)
+	return checkpoints
+}
+
+// checkSignedCheckpoints ensures a block at the passed height with the passed
+// hash matches the signed checkpoint at its height, if any, and does not fork
+// the main chain at or before the latest signed checkpoint the main chain
+// contains.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) checkSignedCheckpoints(height uint32, hash *chainhash.Hash) error {
+	// The main chain contains every signed checkpoint up to its height,
+	// since blocks which do not match them are rejected.
+	i := sort.Search(len(b.signedCheckpoints), func(i int) bool {
+		return b.signedCheckpoints[i].Height >= height
+	})
+	if i < len(b.signedCheckpoints) &&
+		b.signedCheckpoints[i].Height == height &&
+		b.signedCheckpoints[i].Hash != *hash {
+
+		str := fmt.Sprintf("block at height %d does not match signed "+
+			"checkpoint hash", height)
+		return ruleError(ErrBadCheckpoint, str)
+	}
+
+	passed := sort.Search(len(b.signedCheckpoints), func(i int) bool {
+		return b.signedCheckpoints[i].Height > b.bestNode.height
+	}) - 1
+	if passed >= 0 && height <= b.signedCheckpoints[passed].Height {
+		str := fmt.Sprintf("block at height %d forks the main chain "+
+			"before the signed checkpoint at height %d", height,
+			b.signedCheckpoints[passed].Height)
+		return ruleError(ErrForkTooOld, str)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+)
+
+// TestSignedCheckpointSerialization ensures signed checkpoints survive a round
+// trip through their serialization and that tampering with the signed data is
+// detected.
+func TestSignedCheckpointSerialization(t *testing.T) {
+	key, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("NewPrivateKey: %v", err)
+	}
+	checkpoint := &SignedCheckpoint{
+		Height:       1000,
+		Hash:         chainhash.Hash{0x01},
+		UtxoHash:     chainhash.Hash{0x02},
+		KeyStateHash: chainhash.Hash{0x03},
+	}
+	if checkpoint.Verify() {
+		t.Fatalf("unsigned checkpoint verified")
+	}
+	if err := checkpoint.Sign(key); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if !checkpoint.Verify() {
+		t.Fatalf("signed checkpoint did not verify")
+	}
+
+	serialized := checkpoint.Serialize()
+	decoded, err := DeserializeSignedCheckpoint(serialized)
+	if err != nil {
+		t.Fatalf("DeserializeSignedCheckpoint: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Serialize(), serialized) ||
+		!decoded.PubKey.IsEqual(key.PubKey()) || !decoded.Verify() {
+
+		t.Fatalf("got checkpoint %+v, want %+v", decoded, checkpoint)
+	}
+
+	// Changing any of the signed fields must invalidate the signature.
+	for i, offset := range []int{0, 4, 4 + chainhash.HashSize,
+		4 + 2*chainhash.HashSize} {
+
+		tampered := make([]byte, len(serialized))
+		copy(tampered, serialized)
+		tampered[offset] ^= 0xff
+		decoded, err := DeserializeSignedCheckpoint(tampered)
+		if err != nil {
+			t.Fatalf("DeserializeSignedCheckpoint #%d: %v", i, err)
+		}
+		if decoded.Verify() {
+			t.Errorf("tampered checkpoint #%d verified", i)
+		}
+	}
+
+	// Truncated checkpoints must be rejected.
+	_, err = DeserializeSignedCheckpoint(serialized[:signedCheckpointDataSize])
+	if err == nil {
+		t.Errorf("truncated checkpoint was deserialized")
+	}
+}
+
+// TestAddSignedCheckpointUntrusted ensures checkpoints which are not validly
+// signed by a trusted checkpoint key are rejected.
+func TestAddSignedCheckpointUntrusted(t *testing.T) {
+	trusted, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("NewPrivateKey: %v", err)
+	}
+	untrusted, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("NewPrivateKey: %v", err)
+	}
+	chain := &BlockChain{
+		checkpointPubKeys: []*btcec.PublicKey{trusted.PubKey()},
+	}
+
+	untrustedCheckpoint := &SignedCheckpoint{Height: 1000}
+	if err := untrustedCheckpoint.Sign(untrusted); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	badSigCheckpoint := &SignedCheckpoint{Height: 1000}
+	if err := badSigCheckpoint.Sign(trusted); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	badSigCheckpoint.Height++
+
+	for _, checkpoint := range []*SignedCheckpoint{untrustedCheckpoint,
+		badSigCheckpoint} {
+
+		err := chain.AddSignedCheckpoint(checkpoint)
+		if rerr, ok := err.(RuleError); !ok ||
+			rerr.ErrorCode != ErrUntrustedCheckpoint {
+
+			t.Errorf("got error %v, want ErrUntrustedCheckpoint", err)
+		}
+	}
+}
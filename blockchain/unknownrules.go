@@ -0,0 +1,180 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// UnknownRulesState describes whether the main chain signals consensus rules
+// this version of the software does not know.
+type UnknownRulesState int
+
+// These constants define the states of the main chain with respect to unknown
+// consensus rules.
+const (
+	// UnknownRulesNone indicates none of the recent main chain blocks has
+	// a block version newer than the latest known one.
+	UnknownRulesNone UnknownRulesState = iota
+
+	// UnknownRulesSignaled indicates some of the recent main chain blocks
+	// have an unknown block version, but not enough for the rules they
+	// signal to be enforced.
+	UnknownRulesSignaled
+
+	// UnknownRulesActive indicates enough of the recent main chain blocks
+	// have an unknown block version for the rules they signal to be
+	// enforced by the upgraded nodes.  The chain can no longer be fully
+	// validated, so blocks with an unknown version are not connected to
+	// the main chain until the software is upgraded.
+	UnknownRulesActive
+)
+
+// Map of unknown rules states back to their names for pretty printing.
+var unknownRulesStateStrings = map[UnknownRulesState]string{
+	UnknownRulesNone:     "none",
+	UnknownRulesSignaled: "signaled",
+	UnknownRulesActive:   "active",
+}
+
+// String returns the UnknownRulesState in human-readable form.
+func (s UnknownRulesState) String() string {
+	if str, ok := unknownRulesStateStrings[s]; ok {
+		return str
+	}
+	return fmt.Sprintf("Unknown UnknownRulesState (%d)", int(s))
+}
+
+// UnknownRulesStatus reports how many of the recent main chain blocks signal
+// consensus rules this version of the software does not know, that is have a
+// block version newer than wire.BlockVersion.
+type UnknownRulesStatus struct {
+	State UnknownRulesState
+
+	// Height is the height of the main chain block the status is for.
+	Height uint32
+
+	// Blocks is the number of blocks with an unknown version among the
+	// last Window main chain blocks, and Threshold the number of them
+	// from which the rules they signal are enforced.
+	Blocks    uint64
+	Window    uint64
+	Threshold uint64
+
+	// MaxVersion is the highest block version among the last Window
+	// main chain blocks.
+	MaxVersion uint32
+}
+
+// calcUnknownRules returns the unknown rules status of the chain ending with
+// the passed node.  The rules signaled by a new block version are enforced
+// once the version is used by BlockEnforceNumRequired of the last
+// BlockUpgradeNumToCheck blocks, as for the known block version upgrades.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) calcUnknownRules(node *blockNode) UnknownRulesStatus {
+	status := UnknownRulesStatus{
+		Window:    b.chainParams.BlockUpgradeNumToCheck,
+		Threshold: b.chainParams.BlockEnforceNumRequired,
+	}
+	if node != nil {
+		status.Height = node.height
+	}
+
+	iterNode := node
+	for i := uint64(0); i < status.Window && iterNode != nil; i++ {
+		if iterNode.version > wire.BlockVersion {
+			status.Blocks++
+		}
+		if iterNode.version > status.MaxVersion {
+			status.MaxVersion = iterNode.version
+		}
+
+		var err error
+		iterNode, err = b.getPrevNodeFromNode(iterNode)
+		if err != nil {
+			break
+		}
+	}
+
+	switch {
+	case status.Blocks >= status.Threshold:
+		status.State = UnknownRulesActive
+	case status.Blocks > 0:
+		status.State = UnknownRulesSignaled
+	}
+	return status
+}
+
+// updateUnknownRules updates the unknown rules status of the main chain for
+// the best node and returns whether its state changed.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) updateUnknownRules() bool {
+	status := b.calcUnknownRules(b.bestNode)
+	changed := status.State != b.unknownRules.State
+	b.unknownRules = status
+	if !changed {
+		return false
+	}
+
+	switch status.State {
+	case UnknownRulesSignaled:
+		log.Warnf("%d of the last %d blocks have a block version newer "+
+			"than %d, which signals consensus rules this version "+
+			"does not know -- upgrade before they are enforced",
+			status.Blocks, status.Window, wire.BlockVersion)
+	case UnknownRulesActive:
+		log.Errorf("%d of the last %d blocks have a block version newer "+
+			"than %d, so consensus rules this version does not "+
+			"know are enforced -- the chain can no longer be fully "+
+			"validated and blocks with an unknown version are not "+
+			"followed until the software is upgraded",
+			status.Blocks, status.Window, wire.BlockVersion)
+	default:
+		log.Infof("The recent blocks no longer signal unknown " +
+			"consensus rules")
+	}
+	return true
+}
+
+// checkUnknownRules ensures the passed node does not extend a chain enforcing
+// consensus rules this version of the software does not know with a block
+// using the unknown rules, which it can not validate.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) checkUnknownRules(node *blockNode) error {
+	if node.version <= wire.BlockVersion {
+		return nil
+	}
+
+	var status UnknownRulesStatus
+	if node.parent == b.bestNode {
+		status = b.unknownRules
+	} else {
+		status = b.calcUnknownRules(node.parent)
+	}
+	if status.State == UnknownRulesActive {
+		str := fmt.Sprintf("block version %d signals consensus rules "+
+			"which are enforced by %d of the last %d blocks, but "+
+			"are unknown to this version", node.version,
+			status.Blocks, status.Window)
+		return ruleError(ErrUnknownRules, str)
+	}
+	return nil
+}
+
+// UnknownRules returns the unknown rules status of the main chain, which
+// reports whether recent blocks signal consensus rules this version of the
+// software does not know.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) UnknownRules() UnknownRulesStatus {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+	return b.unknownRules
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// TestUnknownRules ensures the unknown rules state of the main chain follows
+// the number of recent blocks with an unknown block version, and that blocks
+// with an unknown version are refused once the rules they signal are enforced.
+func TestUnknownRules(t *testing.T) {
+	params := chaincfg.RegressionNetParams
+	params.BlockEnforceNumRequired = 3
+	params.BlockUpgradeNumToCheck = 5
+	b := &BlockChain{chainParams: &params}
+
+	newNode := func(parent *blockNode, version uint32) *blockNode {
+		node := &blockNode{
+			hash:    &chainhash.Hash{byte(version)},
+			parent:  parent,
+			version: version,
+		}
+		if parent != nil {
+			node.height = parent.height + 1
+			node.hash[1] = byte(node.height)
+			node.parentHash = parent.hash
+		}
+		return node
+	}
+	unknownVersion := uint32(wire.BlockVersion + 1)
+
+	// The chain signals unknown rules from the first block with an unknown
+	// version and enforces them from the third.
+	tests := []struct {
+		version uint32
+		state   UnknownRulesState
+		changed bool
+		blocks  uint64
+	}{
+		{wire.BlockVersion, UnknownRulesNone, false, 0},
+		{wire.BlockVersion, UnknownRulesNone, false, 0},
+		{unknownVersion, UnknownRulesSignaled, true, 1},
+		{wire.BlockVersion, UnknownRulesSignaled, false, 1},
+		{unknownVersion, UnknownRulesSignaled, false, 2},
+		{unknownVersion, UnknownRulesActive, true, 3},
+	}
+	var nodes []*blockNode
+	for i, test := range tests {
+		b.bestNode = newNode(b.bestNode, test.version)
+		nodes = append(nodes, b.bestNode)
+		if changed := b.updateUnknownRules(); changed != test.changed {
+			t.Errorf("#%d: got changed %v, want %v", i, changed,
+				test.changed)
+		}
+		status := b.unknownRules
+		if status.State != test.state || status.Blocks != test.blocks ||
+			status.Height != uint32(i) {
+
+			t.Errorf("#%d: got state %v with %d blocks at height %d, "+
+				"want %v with %d blocks", i, status.State,
+				status.Blocks, status.Height, test.state, test.blocks)
+		}
+	}
+	if status := b.UnknownRules(); status.MaxVersion != unknownVersion ||
+		status.Window != 5 || status.Threshold != 3 {
+
+		t.Errorf("UnknownRules: got %+v", status)
+	}
+
+	// Blocks with an unknown version are refused on top of the main chain,
+	// but not on a side chain which does not enforce the unknown rules yet.
+	err := b.checkUnknownRules(newNode(b.bestNode, unknownVersion))
+	if rerr, ok := err.(RuleError); !ok || rerr.ErrorCode != ErrUnknownRules {
+		t.Errorf("checkUnknownRules: got error %v, want ErrUnknownRules",
+			err)
+	}
+	if err := b.checkUnknownRules(newNode(b.bestNode, wire.BlockVersion)); err != nil {
+		t.Errorf("checkUnknownRules: got error %v for a known version",
+			err)
+	}
+	if err := b.checkUnknownRules(newNode(nodes[4], unknownVersion)); err != nil {
+		t.Errorf("checkUnknownRules: got error %v on a side chain", err)
+	}
+
+	// The blocks with an unknown version drop out of the window.
+	for i := 0; i < 5; i++ {
+		b.bestNode = newNode(b.bestNode, wire.BlockVersion)
+		b.updateUnknownRules()
+	}
+	if state := b.UnknownRules().State; state != UnknownRulesNone {
+		t.Errorf("UnknownRules: got state %v after the unknown versions "+
+			"left the window, want %v", state, UnknownRulesNone)
+	}
+}
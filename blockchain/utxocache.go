@@ -0,0 +1,295 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/provautil"
+)
+
+const (
+	// utxoFlushInterval is the maximum amount of time the utxo cache keeps
+	// modified entries before they are flushed to the database along with
+	// the block being connected.  It limits the number of blocks which
+	// have to be replayed after an unclean shutdown.
+	utxoFlushInterval = 5 * time.Minute
+
+	// utxoEntryOverhead is the approximate number of bytes a cached utxo
+	// entry uses in memory, not counting its outputs.  It includes the
+	// hash key and pointer in the map of cached entries.
+	utxoEntryOverhead = chainhash.HashSize + 8 + 48 + 48
+
+	// utxoOutputOverhead is the approximate number of bytes an output of a
+	// cached utxo entry uses in memory, not counting its public key
+	// script.  It includes the index key and pointer in the map of
+	// outputs.
+	utxoOutputOverhead = 4 + 8 + 40
+)
+
+// utxoEntrySize returns the approximate number of bytes the passed utxo entry
+// uses in memory.
+func utxoEntrySize(entry *UtxoEntry) uint64 {
+	size := uint64(utxoEntryOverhead)
+	for _, output := range entry.sparseOutputs {
+		size += utxoOutputOverhead + uint64(len(output.pkScript))
+	}
+	return size
+}
+
+// utxoCache keeps recently used and modified utxo entries in memory in front
+// of the utxo set in the database, which avoids most database reads when
+// connecting blocks and batches the writes of many blocks together.
+//
+// Modified entries are written to the database when the cache is flushed,
+// along with the hash of the block the utxo set in the database is then
+// consistent with.  The cache is flushed when it grows past its memory budget,
+// periodically, and whenever a block is disconnected, so that block is always
+// in the main chain.  After an unclean shutdown, the blocks after it are
+// replayed to bring the utxo set up to date with the best chain.
+//
+// The entries handed out to views are copies, so views can modify them freely
+// without changing the cached state.
+type utxoCache struct {
+	db      database.DB
+	maxSize uint64
+
+	// mtx protects the following fields.  The cache is also populated by
+	// callers holding the chain lock for reads, so it needs its own lock.
+	mtx       sync.Mutex
+	entries   map[chainhash.Hash]*UtxoEntry
+	size      uint64
+	lastFlush time.Time
+}
+
+// newUtxoCache returns a new utxo cache for the utxo set in the passed
+// database which is flushed when it uses more than maxSize bytes.  A maxSize of
+// zero flushes the cache along with every block.
+func newUtxoCache(db database.DB, maxSize uint64) *utxoCache {
+	return &utxoCache{
+		db:        db,
+		maxSize:   maxSize,
+		entries:   make(map[chainhash.Hash]*UtxoEntry),
+		lastFlush: time.Now(),
+	}
+}
+
+// add adds or replaces the cached entry for the passed transaction hash.
+//
+// This function MUST be called with the cache lock held.
+func (c *utxoCache) add(hash *chainhash.Hash, entry *UtxoEntry) {
+	if old, ok := c.entries[*hash]; ok {
+		c.size -= utxoEntrySize(old)
+	}
+	c.entries[*hash] = entry
+	c.size += utxoEntrySize(entry)
+}
+
+// fetchEntries loads copies of the utxo entries for the passed set of
+// transactions from the point of view of the end of the main chain into the
+// passed view.  Entries which are not cached are loaded from the database and
+// cached while the cache is within its memory budget.  Fully spent
+// transactions, or those which otherwise don't exist, result in a nil entry in
+// the view.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) fetchEntries(view *UtxoViewpoint, txSet map[chainhash.Hash]struct{}) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	var missing []chainhash.Hash
+	for hash := range txSet {
+		entry, ok := c.entries[hash]
+		if !ok {
+			missing = append(missing, hash)
+			continue
+		}
+		if entry.IsFullySpent() {
+			view.entries[hash] = nil
+			continue
+		}
+		view.entries[hash] = entry.Clone()
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return c.db.View(func(dbTx database.Tx) error {
+		for i := range missing {
+			hash := &missing[i]
+			entry, err := dbFetchUtxoEntry(dbTx, hash)
+			if err != nil {
+				return err
+			}
+
+			view.entries[*hash] = entry
+			if entry != nil && c.size < c.maxSize {
+				c.add(hash, entry.Clone())
+			}
+		}
+
+		return nil
+	})
+}
+
+// commit stores copies of the entries the passed view modified in the cache,
+// to be written to the database when the cache is flushed.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) commit(view *UtxoViewpoint) {
+	c.mtx.Lock()
+	for txHashIter, entry := range view.entries {
+		if entry == nil || !entry.modified {
+			continue
+		}
+
+		txHash := txHashIter
+		cached := entry.Clone()
+		cached.modified = true
+		c.add(&txHash, cached)
+	}
+	c.mtx.Unlock()
+}
+
+// needsFlush returns whether the cache has grown past its memory budget or has
+// not been flushed for longer than the flush interval.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) needsFlush() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.size >= c.maxSize || time.Since(c.lastFlush) >= utxoFlushInterval
+}
+
+// dbFlush uses an existing database transaction to write the modified cached
+// entries, followed by those of the passed view, to the utxo set and to record
+// that it is consistent with the passed block.  The view may be nil.  The
+// cache must be cleared with flushed once the transaction is committed.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) dbFlush(dbTx database.Tx, view *UtxoViewpoint, hash *chainhash.Hash) error {
+	c.mtx.Lock()
+	err := dbPutUtxoEntries(dbTx, c.entries)
+	c.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if view != nil {
+		if err := dbPutUtxoView(dbTx, view); err != nil {
+			return err
+		}
+	}
+
+	return dbPutUtxoStateHash(dbTx, hash)
+}
+
+// flushed clears the cache after its modified entries have been written to
+// the database.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) flushed() {
+	c.mtx.Lock()
+	log.Debugf("Flushed %d utxo cache entries using %d bytes",
+		len(c.entries), c.size)
+	c.entries = make(map[chainhash.Hash]*UtxoEntry)
+	c.size = 0
+	c.lastFlush = time.Now()
+	c.mtx.Unlock()
+}
+
+// flush writes the modified cached entries to the database, records that the
+// utxo set is consistent with the passed block, and clears the cache.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) flush(hash *chainhash.Hash) error {
+	err := c.db.Update(func(dbTx database.Tx) error {
+		return c.dbFlush(dbTx, nil, hash)
+	})
+	if err != nil {
+		return err
+	}
+
+	c.flushed()
+	return nil
+}
+
+// FlushUtxoCache writes all modified unspent transaction outputs which are
+// cached in memory to the database.  It should be called before the database
+// is closed, otherwise the blocks connected since the last flush are replayed
+// the next time the chain is loaded.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FlushUtxoCache() error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	return b.utxoCache.flush(b.bestNode.hash)
+}
+
+// recoverUtxoState brings the utxo set up to date with the best chain when it
+// was not flushed before the process last exited, by replaying the blocks
+// connected since the last flush.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) recoverUtxoState() error {
+	// Nothing to do when the utxo set is consistent with the best chain.
+	// Databases created before the utxo cache existed do not record the
+	// block the utxo set is consistent with, but it was always written
+	// along with each block.
+	var stateHeight uint32
+	var consistent bool
+	err := b.db.View(func(dbTx database.Tx) error {
+		stateHash := dbFetchUtxoStateHash(dbTx)
+		if stateHash == nil || stateHash.IsEqual(b.bestNode.hash) {
+			consistent = true
+			return nil
+		}
+
+		var err error
+		stateHeight, err = dbFetchHeightByHash(dbTx, stateHash)
+		return err
+	})
+	if err != nil || consistent {
+		return err
+	}
+
+	log.Infof("Recovering the utxo set from height %d to %d after an "+
+		"unclean shutdown", stateHeight, b.bestNode.height)
+	for height := stateHeight + 1; height <= b.bestNode.height; height++ {
+		var block *provautil.Block
+		err := b.db.View(func(dbTx database.Tx) error {
+			var err error
+			block, err = dbFetchBlockByHeight(dbTx, height)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		// The block has already been validated, so only its effects on
+		// the utxo set need to be applied.
+		view := NewUtxoViewpoint()
+		if err := view.fetchInputUtxos(b.utxoCache, block); err != nil {
+			return err
+		}
+		if err := view.connectTransactions(block, nil); err != nil {
+			return err
+		}
+		b.utxoCache.commit(view)
+
+		if b.utxoCache.needsFlush() {
+			if err := b.utxoCache.flush(block.Hash()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return b.utxoCache.flush(b.bestNode.hash)
+}
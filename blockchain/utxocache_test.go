@@ -0,0 +1,152 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// TestUtxoCacheRecovery ensures the unspent transaction outputs kept in the
+// utxo cache match those written to the database with every block, and that
+// they are recovered when the cache was not flushed before the process exited.
+func TestUtxoCacheRecovery(t *testing.T) {
+	blocks := mainChainBlocks(t)
+	if len(blocks) < 2 {
+		t.Fatalf("got %d blocks extending the main chain, want 2",
+			len(blocks))
+	}
+
+	dir, err := ioutil.TempDir("", "utxocache")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	newChain := func(db database.DB, utxoCacheMaxSize uint64) *blockchain.BlockChain {
+		params := chaincfg.RegressionNetParams
+		chain, err := blockchain.New(&blockchain.Config{
+			DB:               db,
+			ChainParams:      &params,
+			TimeSource:       blockchain.NewMedianTime(),
+			SigCache:         txscript.NewSigCache(1000),
+			UtxoCacheMaxSize: utxoCacheMaxSize,
+		})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		return chain
+	}
+	processBlocks := func(chain *blockchain.BlockChain, blocks []*provautil.Block) {
+		for _, block := range blocks {
+			_, _, err := chain.ProcessBlock(block, blockchain.BFNone)
+			if err != nil {
+				t.Fatalf("ProcessBlock %v: %v", block.Hash(), err)
+			}
+		}
+	}
+
+	// Build the reference chain which writes the utxos to the database
+	// with every block.
+	refDB, err := database.Create(testDbType, filepath.Join(dir, "ref"),
+		blockDataNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer refDB.Close()
+	refChain := newChain(refDB, 0)
+	processBlocks(refChain, blocks)
+
+	// checkUtxos ensures the utxos of the transactions in the blocks match
+	// the reference chain.
+	checkUtxos := func(desc string, chain *blockchain.BlockChain) {
+		for _, block := range blocks {
+			for _, tx := range block.Transactions() {
+				want, err := refChain.FetchUtxoEntry(tx.Hash())
+				if err != nil {
+					t.Fatalf("FetchUtxoEntry: %v", err)
+				}
+				got, err := chain.FetchUtxoEntry(tx.Hash())
+				if err != nil {
+					t.Fatalf("FetchUtxoEntry: %v", err)
+				}
+				if (got == nil) != (want == nil) {
+					t.Errorf("%s: utxos of %v: got %v, want %v",
+						desc, tx.Hash(), got, want)
+					continue
+				}
+				if want == nil {
+					continue
+				}
+				for i := range tx.MsgTx().TxOut {
+					index := uint32(i)
+					if got.IsOutputSpent(index) !=
+						want.IsOutputSpent(index) {
+
+						t.Errorf("%s: output %v:%d spent: "+
+							"got %v", desc, tx.Hash(),
+							index, got.IsOutputSpent(index))
+					}
+				}
+			}
+		}
+	}
+
+	// Connect all but the last block with the utxos kept in the cache,
+	// then close the database without flushing it as happens when the
+	// process is killed.
+	dbPath := filepath.Join(dir, "cached")
+	db, err := database.Create(testDbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	chain := newChain(db, 1<<30)
+	processBlocks(chain, blocks[:len(blocks)-1])
+	db.Close()
+
+	// The utxos are recovered on the next start, and the last block can be
+	// connected on top of them.
+	db, err = database.Open(testDbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer db.Close()
+	chain = newChain(db, 1<<30)
+	processBlocks(chain, blocks[len(blocks)-1:])
+	checkUtxos("cached", chain)
+
+	// The utxos read back from the database after a flush match as well.
+	if err := chain.FlushUtxoCache(); err != nil {
+		t.Fatalf("FlushUtxoCache: %v", err)
+	}
+	checkUtxos("flushed", newChain(db, 0))
+}
+
+// TestUtxoViewOwnsScripts ensures the outputs added to a utxo view hold copies
+// of the scripts of their transaction, so the view does not keep alive the
+// buffers a block was decoded into.
+func TestUtxoViewOwnsScripts(t *testing.T) {
+	pkScript := []byte{txscript.OP_TRUE, txscript.OP_TRUE}
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	msgTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil))
+	msgTx.AddTxOut(wire.NewTxOut(1, pkScript))
+	tx := provautil.NewTx(msgTx)
+
+	view := blockchain.NewUtxoViewpoint()
+	view.AddTxOuts(tx, 1)
+	pkScript[0] = txscript.OP_FALSE
+	got := view.LookupEntry(tx.Hash()).PkScriptByIndex(0)
+	if len(got) != 2 || got[0] != txscript.OP_TRUE {
+		t.Fatalf("got script %x, want a copy of the original", got)
+	}
+}
@@ -7,11 +7,16 @@
 package blockchain
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+
+	"github.com/pyx-partners/dmgd/btcec"
 	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
 	"github.com/pyx-partners/dmgd/database"
 	"github.com/pyx-partners/dmgd/provautil"
 	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
 )
 
 // utxoOutput houses details about an individual unspent transaction output such
@@ -55,6 +60,7 @@ type UtxoEntry struct {
 	version       int32                  // The version of this tx.
 	isCoinBase    bool                   // Whether entry is a coinbase tx.
 	blockHeight   uint32                 // Height of block containing tx.
+	assetID       uint32                 // Asset marker of the tx, ungated.
 	sparseOutputs map[uint32]*utxoOutput // Sparse map of unspent outputs.
 }
 
@@ -63,6 +69,15 @@ func (entry *UtxoEntry) Version() int32 {
 	return entry.version
 }
 
+// AssetID returns the asset named by the asset marker of the transaction the
+// utxo entry represents, or 0 for DMG when it has none.  Entries are tagged
+// regardless of the height of their transaction, so the asset the outputs
+// belong to must only be read with UtxoAssetID, which ignores the markers of
+// transactions below the AssetMarkerHeight of the network.
+func (entry *UtxoEntry) AssetID() uint32 {
+	return entry.assetID
+}
+
 // IsCoinBase returns whether or not the transaction the utxo entry represents
 // is a coinbase.
 func (entry *UtxoEntry) IsCoinBase() bool {
@@ -154,7 +169,8 @@ func (entry *UtxoEntry) PkScriptByIndex(outputIndex uint32) []byte {
 	return output.pkScript
 }
 
-// Clone returns a deep copy of the utxo entry.
+// Clone returns a deep copy of the utxo entry.  The scripts of the outputs are
+// shared, since they are owned by the entry and never modified in place.
 func (entry *UtxoEntry) Clone() *UtxoEntry {
 	if entry == nil {
 		return nil
@@ -164,6 +180,7 @@ func (entry *UtxoEntry) Clone() *UtxoEntry {
 		version:       entry.version,
 		isCoinBase:    entry.isCoinBase,
 		blockHeight:   entry.blockHeight,
+		assetID:       entry.assetID,
 		sparseOutputs: make(map[uint32]*utxoOutput),
 	}
 	for outputIndex, output := range entry.sparseOutputs {
@@ -178,12 +195,13 @@ func (entry *UtxoEntry) Clone() *UtxoEntry {
 }
 
 // newUtxoEntry returns a new unspent transaction output entry with the provided
-// coinbase flag and block height ready to have unspent outputs added.
-func newUtxoEntry(version int32, isCoinBase bool, blockHeight uint32) *UtxoEntry {
+// coinbase flag, block height and asset ID ready to have unspent outputs added.
+func newUtxoEntry(version int32, isCoinBase bool, blockHeight uint32, assetID uint32) *UtxoEntry {
 	return &UtxoEntry{
 		version:       version,
 		isCoinBase:    isCoinBase,
 		blockHeight:   blockHeight,
+		assetID:       assetID,
 		sparseOutputs: make(map[uint32]*utxoOutput),
 	}
 }
@@ -231,23 +249,30 @@ func (view *UtxoViewpoint) LookupEntry(txHash *chainhash.Hash) *UtxoEntry {
 // existing entries since it's possible it has changed during a reorg.
 func (view *UtxoViewpoint) AddTxOuts(tx *provautil.Tx, blockHeight uint32) {
 	// When there are not already any utxos associated with the transaction,
-	// add a new entry for it to the view.
+	// add a new entry for it to the view.  The entry is tagged with the
+	// ungated asset marker, which UtxoAssetID gates by its height.
+	assetID := txscript.TxAssetID(tx.MsgTx())
 	entry := view.LookupEntry(tx.Hash())
 	if entry == nil {
 		entry = newUtxoEntry(tx.MsgTx().Version, IsCoinBase(tx),
-			blockHeight)
+			blockHeight, assetID)
 		view.entries[*tx.Hash()] = entry
 	} else {
 		entry.blockHeight = blockHeight
+		entry.assetID = assetID
 	}
 	entry.modified = true
 
 	// Loop all of the transaction outputs and add those which are not
-	// provably unspendable.
+	// provably unspendable.  Their scripts are copied, since the scripts of
+	// a decoded block share large chunks which would otherwise be kept
+	// alive by the view and the utxo cache long after the block.
 	for txOutIdx, txOut := range tx.MsgTx().TxOut {
 		if txscript.IsUnspendable(txOut.PkScript) {
 			continue
 		}
+		pkScript := make([]byte, len(txOut.PkScript))
+		copy(pkScript, txOut.PkScript)
 
 		// Update existing entries.  All fields are updated because it's
 		// possible (although extremely unlikely) that the existing
@@ -258,7 +283,7 @@ func (view *UtxoViewpoint) AddTxOuts(tx *provautil.Tx, blockHeight uint32) {
 			output.spent = false
 			output.compressed = false
 			output.amount = txOut.Value
-			output.pkScript = txOut.PkScript
+			output.pkScript = pkScript
 			continue
 		}
 
@@ -267,7 +292,7 @@ func (view *UtxoViewpoint) AddTxOuts(tx *provautil.Tx, blockHeight uint32) {
 			spent:      false,
 			compressed: false,
 			amount:     txOut.Value,
-			pkScript:   txOut.PkScript,
+			pkScript:   pkScript,
 		}
 	}
 	return
@@ -319,6 +344,7 @@ func (view *UtxoViewpoint) connectTransaction(tx *provautil.Tx, blockHeight uint
 		if entry.IsFullySpent() {
 			stxo.height = entry.BlockHeight()
 			stxo.isCoinBase = entry.IsCoinBase()
+			stxo.assetID = entry.AssetID()
 		}
 
 		// Append the entry to the provided spent txouts slice.
@@ -371,12 +397,13 @@ func (view *UtxoViewpoint) disconnectTransactions(block *provautil.Block, stxos
 		// Clear this transaction from the view if it already exists or
 		// create a new empty entry for when it does not.  This is done
 		// because the code relies on its existence in the view in order
-		// to signal modifications have happened.
+		// to signal modifications have happened.  As in AddTxOuts, the
+		// entry is tagged with the ungated asset marker.
 		isCoinbase := txIdx == 0
 		entry := view.entries[*tx.Hash()]
 		if entry == nil {
 			entry = newUtxoEntry(tx.MsgTx().Version, isCoinbase,
-				block.Height())
+				block.Height(), txscript.TxAssetID(tx.MsgTx()))
 			view.entries[*tx.Hash()] = entry
 		}
 		entry.modified = true
@@ -405,7 +432,7 @@ func (view *UtxoViewpoint) disconnectTransactions(block *provautil.Block, stxos
 			entry := view.entries[*originHash]
 			if entry == nil {
 				entry = newUtxoEntry(stxo.version,
-					stxo.isCoinBase, stxo.height)
+					stxo.isCoinBase, stxo.height, stxo.assetID)
 				view.entries[*originHash] = entry
 			}
 
@@ -465,7 +492,7 @@ func (view *UtxoViewpoint) commit() {
 // Upon completion of this function, the view will contain an entry for each
 // requested transaction.  Fully spent transactions, or those which otherwise
 // don't exist, will result in a nil entry in the view.
-func (view *UtxoViewpoint) fetchUtxosMain(db database.DB, txSet map[chainhash.Hash]struct{}) error {
+func (view *UtxoViewpoint) fetchUtxosMain(cache *utxoCache, txSet map[chainhash.Hash]struct{}) error {
 	// Nothing to do if there are no requested hashes.
 	if len(txSet) == 0 {
 		return nil
@@ -479,25 +506,13 @@ func (view *UtxoViewpoint) fetchUtxosMain(db database.DB, txSet map[chainhash.Ha
 	// since other code uses the presence of an entry in the store as a way
 	// to optimize spend and unspend updates to apply only to the specific
 	// utxos that the caller needs access to.
-	return db.View(func(dbTx database.Tx) error {
-		for hash := range txSet {
-			hashCopy := hash
-			entry, err := dbFetchUtxoEntry(dbTx, &hashCopy)
-			if err != nil {
-				return err
-			}
-
-			view.entries[hash] = entry
-		}
-
-		return nil
-	})
+	return cache.fetchEntries(view, txSet)
 }
 
 // fetchUtxos loads utxo details about provided set of transaction hashes into
-// the view from the database as needed unless they already exist in the view in
-// which case they are ignored.
-func (view *UtxoViewpoint) fetchUtxos(db database.DB, txSet map[chainhash.Hash]struct{}) error {
+// the view from the utxo cache as needed unless they already exist in the view
+// in which case they are ignored.
+func (view *UtxoViewpoint) fetchUtxos(cache *utxoCache, txSet map[chainhash.Hash]struct{}) error {
 	// Nothing to do if there are no requested hashes.
 	if len(txSet) == 0 {
 		return nil
@@ -514,15 +529,15 @@ func (view *UtxoViewpoint) fetchUtxos(db database.DB, txSet map[chainhash.Hash]s
 		txNeededSet[hash] = struct{}{}
 	}
 
-	// Request the input utxos from the database.
-	return view.fetchUtxosMain(db, txNeededSet)
+	// Request the input utxos from the utxo cache.
+	return view.fetchUtxosMain(cache, txNeededSet)
 }
 
 // fetchInputUtxos loads utxo details about the input transactions referenced
-// by the transactions in the given block into the view from the database as
+// by the transactions in the given block into the view from the utxo cache as
 // needed.  In particular, referenced entries that are earlier in the block are
 // added to the view and entries that are already in the view are not modified.
-func (view *UtxoViewpoint) fetchInputUtxos(db database.DB, block *provautil.Block) error {
+func (view *UtxoViewpoint) fetchInputUtxos(cache *utxoCache, block *provautil.Block) error {
 	// Build a map of in-flight transactions because some of the inputs in
 	// this block could be referencing other transactions earlier in this
 	// block which are not yet in the chain.
@@ -568,8 +583,8 @@ func (view *UtxoViewpoint) fetchInputUtxos(db database.DB, block *provautil.Bloc
 		}
 	}
 
-	// Request the input utxos from the database.
-	return view.fetchUtxosMain(db, txNeededSet)
+	// Request the input utxos from the utxo cache.
+	return view.fetchUtxosMain(cache, txNeededSet)
 }
 
 // NewUtxoViewpoint returns a new empty unspent transaction output view.
@@ -604,7 +619,7 @@ func (b *BlockChain) FetchUtxoView(tx *provautil.Tx) (*UtxoViewpoint, error) {
 	// Request the utxos from the point of view of the end of the main
 	// chain.
 	view := NewUtxoViewpoint()
-	err := view.fetchUtxosMain(b.db, txNeededSet)
+	err := view.fetchUtxosMain(b.utxoCache, txNeededSet)
 	return view, err
 }
 
@@ -622,15 +637,191 @@ func (b *BlockChain) FetchUtxoEntry(txHash *chainhash.Hash) (*UtxoEntry, error)
 	b.chainLock.RLock()
 	defer b.chainLock.RUnlock()
 
-	var entry *UtxoEntry
-	err := b.db.View(func(dbTx database.Tx) error {
-		var err error
-		entry, err = dbFetchUtxoEntry(dbTx, txHash)
+	view := NewUtxoViewpoint()
+	err := b.utxoCache.fetchEntries(view, map[chainhash.Hash]struct{}{
+		*txHash: {},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return view.LookupEntry(txHash), nil
+}
+
+// errKeyIDScanDone is used internally to stop iterating the utxo set once the
+// callback of forEachKeyIDOutput is no longer interested in further outputs.
+var errKeyIDScanDone = errors.New("keyID scan done")
+
+// forEachKeyIDOutput calls fn for every unspent output of the main chain whose
+// pkScript references at least one keyID, along with the distinct keyIDs it
+// references.  The scan stops early when fn returns false.  The utxo cache is
+// flushed first so the utxo set in the database is up to date with the end of
+// the main chain.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) forEachKeyIDOutput(fn func(op wire.OutPoint, entry *UtxoEntry, keyIDs []btcec.KeyID) bool) error {
+	if err := b.utxoCache.flush(b.bestNode.hash); err != nil {
 		return err
+	}
+
+	err := b.db.View(func(dbTx database.Tx) error {
+		utxoBucket := dbTx.Metadata().Bucket(utxoSetBucketName)
+		return utxoBucket.ForEach(func(k, v []byte) error {
+			entry, err := deserializeUtxoEntry(v)
+			if err != nil {
+				return err
+			}
+			var txHash chainhash.Hash
+			copy(txHash[:], k)
+			for outputIndex := range entry.sparseOutputs {
+				if entry.IsOutputSpent(outputIndex) {
+					continue
+				}
+				pkScript := entry.PkScriptByIndex(outputIndex)
+				pops, err := txscript.ParseScript(pkScript)
+				if err != nil {
+					continue
+				}
+				scriptKeyIDs, err := txscript.ExtractKeyIDs(pops)
+				if err != nil || len(scriptKeyIDs) == 0 {
+					continue
+				}
+				keyIDs := make([]btcec.KeyID, 0, len(scriptKeyIDs))
+				for _, keyID := range scriptKeyIDs {
+					duplicate := false
+					for _, seen := range keyIDs {
+						if seen == keyID {
+							duplicate = true
+							break
+						}
+					}
+					if !duplicate {
+						keyIDs = append(keyIDs, keyID)
+					}
+				}
+				if !fn(*wire.NewOutPoint(&txHash, outputIndex),
+					entry, keyIDs) {
+
+					return errKeyIDScanDone
+				}
+			}
+			return nil
+		})
+	})
+	if err == errKeyIDScanDone {
+		return nil
+	}
+	return err
+}
+
+// FetchKeyIDOutPoints scans the unspent transaction output set of the main
+// chain for outputs whose pkScript references any of the passed keyIDs and
+// returns their outpoints.  At most limit outpoints are returned, after which
+// the scan stops, unless limit is negative.  Since this may require a full scan
+// of the set, it should only be used for infrequent tasks such as reserve
+// audits.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FetchKeyIDOutPoints(keyIDs []btcec.KeyID, limit int) ([]wire.OutPoint, error) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	wanted := make(map[btcec.KeyID]struct{}, len(keyIDs))
+	for _, keyID := range keyIDs {
+		wanted[keyID] = struct{}{}
+	}
+
+	var outPoints []wire.OutPoint
+	if limit == 0 {
+		return outPoints, nil
+	}
+	err := b.forEachKeyIDOutput(func(op wire.OutPoint, entry *UtxoEntry,
+		scriptKeyIDs []btcec.KeyID) bool {
+
+		for _, keyID := range scriptKeyIDs {
+			if _, ok := wanted[keyID]; ok {
+				outPoints = append(outPoints, op)
+				break
+			}
+		}
+		return limit < 0 || len(outPoints) < limit
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return outPoints, nil
+}
+
+// KeyIDUsage holds the number and total value of the unspent outputs of an
+// asset whose pkScript references a keyID.
+type KeyIDUsage struct {
+	KeyID   btcec.KeyID
+	AssetID uint32
+	Outputs uint64
+	Amount  int64
+}
+
+// KeyIDCensus holds the usage of all keyIDs referenced by the unspent
+// transaction output set at the end of the main chain at a block.
+type KeyIDCensus struct {
+	Hash   chainhash.Hash
+	Height uint32
+
+	// Usage is sorted by keyID and then by asset.
+	Usage []KeyIDUsage
+}
+
+// FetchKeyIDCensus scans the unspent transaction output set of the main chain
+// and returns, per keyID and asset, the number and total value of the outputs
+// whose pkScript references the keyID.  An output referencing several keyIDs
+// counts towards each of them.  Since this requires a full scan of the set, it
+// should only be used for infrequent tasks such as verifying a keyID is unused
+// before revoking it.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FetchKeyIDCensus() (*KeyIDCensus, error) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	type usageKey struct {
+		keyID   btcec.KeyID
+		assetID uint32
+	}
+	usage := make(map[usageKey]*KeyIDUsage)
+	err := b.forEachKeyIDOutput(func(op wire.OutPoint, entry *UtxoEntry,
+		keyIDs []btcec.KeyID) bool {
+
+		for _, keyID := range keyIDs {
+			key := usageKey{keyID, UtxoAssetID(entry, b.chainParams)}
+			u, ok := usage[key]
+			if !ok {
+				u = &KeyIDUsage{KeyID: keyID, AssetID: key.assetID}
+				usage[key] = u
+			}
+			u.Outputs++
+			u.Amount += entry.AmountByIndex(op.Index)
+		}
+		return true
 	})
 	if err != nil {
 		return nil, err
 	}
 
-	return entry, nil
+	census := &KeyIDCensus{
+		Hash:   *b.bestNode.hash,
+		Height: b.bestNode.height,
+		Usage:  make([]KeyIDUsage, 0, len(usage)),
+	}
+	for _, u := range usage {
+		census.Usage = append(census.Usage, *u)
+	}
+	sort.Slice(census.Usage, func(i, j int) bool {
+		x, y := &census.Usage[i], &census.Usage[j]
+		if x.KeyID != y.KeyID {
+			return x.KeyID < y.KeyID
+		}
+		return x.AssetID < y.AssetID
+	})
+	return census, nil
 }
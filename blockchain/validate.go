@@ -7,16 +7,20 @@
 package blockchain
 
 import (
+	"bytes"
 	"fmt"
 	"math"
 	"math/big"
 	"time"
 
+	"github.com/btcsuite/golangcrypto/ripemd160"
 	"github.com/pyx-partners/dmgd/btcec"
 	"github.com/pyx-partners/dmgd/chaincfg"
 	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/database"
 	"github.com/pyx-partners/dmgd/provautil"
 	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/txscript/adminop"
 	"github.com/pyx-partners/dmgd/wire"
 )
 
@@ -211,7 +215,7 @@ func CheckTransactionSanity(tx *provautil.Tx) error {
 	// as an atom.  One gram is a quantity of atoms as defined by the
 	// AtomsPerGram constant.
 	var totalAtoms int64
-	threadInt, adminOutputs := txscript.GetAdminDetails(tx)
+	threadInt, _ := txscript.GetAdminDetails(tx)
 	hasAdminOut := (threadInt >= 0)
 	for txOutIndex, txOut := range msgTx.TxOut {
 		atoms := txOut.Value
@@ -266,18 +270,16 @@ func CheckTransactionSanity(tx *provautil.Tx) error {
 			} else {
 				// take care of issue thread
 				// If issuance/destruction tx, any non-nulldata outputs must be valid Prova scripts
-				if txOutIndex > 0 {
-					isDestruction := len(msgTx.TxIn) > 1
-					if scriptClass == txscript.NullDataTy {
-						if !isDestruction {
-							str := fmt.Sprintf("issue transaction %v tries to destroy funds", tx.Hash)
-							return ruleError(ErrInvalidAdminTx, str)
-						}
-						if atoms == 0 {
-							str := fmt.Sprintf("admin issue transaction %v "+
-								"trying to destroy 0 at output "+
-								"#%d.", tx.Hash, txOutIndex)
-							return ruleError(ErrInvalidAdminTx, str)
+				// The asset marker is checked by
+				// CheckTransactionAssetMarkers.
+				_, isAssetMarker := txscript.ExtractAssetID(txOut.PkScript)
+				if txOutIndex > 0 && !isAssetMarker {
+					if scriptClass == txscript.NullDataTy ||
+						scriptClass == txscript.CommitmentTy {
+						err := checkIssueNullDataOutput(tx,
+							txOutIndex)
+						if err != nil {
+							return err
 						}
 					} else {
 						if scriptClass != txscript.ProvaTy &&
@@ -343,21 +345,10 @@ func CheckTransactionSanity(tx *provautil.Tx) error {
 				str := fmt.Sprintf("admin transaction with no admin operations.")
 				return ruleError(ErrInvalidAdminTx, str)
 			}
-			//
-
-			// op pkscript
-			for _, adminOpOut := range adminOutputs {
-				// check conditions for admin ops
-				// - Admin tx additional outputs must be nulldata scripts
-				// - Key in nulldata script must be valid
-				// - Data in nulldata scripts must match proper form expected for
-				//   the thread
-				if !txscript.IsValidAdminOp(adminOpOut, threadId) {
-					str := fmt.Sprintf("admin transaction with invalid admin " +
-						"operation found.")
-					return ruleError(ErrInvalidAdminTx, str)
-				}
-			}
+
+			// The admin ops themselves depend on the height of the
+			// transaction, which introduced some of them, and are
+			// checked by CheckTransactionOutputs.
 		}
 	}
 
@@ -505,6 +496,49 @@ func CountP2SHSigOps(tx *provautil.Tx, isCoinBaseTx bool, utxoView *UtxoViewpoin
 	return totalSigOps, nil
 }
 
+// CountProvaSigOps returns the number of signature operations performed by the
+// OP_CHECKSAFEMULTISIG operations of the public key scripts the inputs of the
+// provided transaction spend, bounded by the number of keys each declares.
+// Unlike CountP2SHSigOps, it does not depend on BIP0016 and requires access to
+// the input transaction scripts.
+func CountProvaSigOps(tx *provautil.Tx, isCoinBaseTx bool, utxoView *UtxoViewpoint) (int, error) {
+	// Coinbase transactions have no interesting inputs.
+	if isCoinBaseTx {
+		return 0, nil
+	}
+
+	// Accumulate the number of signature operations in all transaction
+	// inputs.
+	msgTx := tx.MsgTx()
+	totalSigOps := 0
+	for txInIndex, txIn := range msgTx.TxIn {
+		// Ensure the referenced input transaction is available.
+		originTxHash := &txIn.PreviousOutPoint.Hash
+		originTxIndex := txIn.PreviousOutPoint.Index
+		txEntry := utxoView.LookupEntry(originTxHash)
+		if txEntry == nil || txEntry.IsOutputSpent(originTxIndex) {
+			str := fmt.Sprintf("unable to find unspent output "+
+				"%v referenced from transaction %s:%d",
+				txIn.PreviousOutPoint, tx.Hash(), txInIndex)
+			return 0, ruleError(ErrMissingTx, str)
+		}
+
+		// We could potentially overflow the accumulator so check for
+		// overflow.
+		pkScript := txEntry.PkScriptByIndex(originTxIndex)
+		lastSigOps := totalSigOps
+		totalSigOps += txscript.GetProvaSigOpCount(pkScript)
+		if totalSigOps < lastSigOps {
+			str := fmt.Sprintf("the public key script from output "+
+				"%v contains too many signature operations - "+
+				"overflow", txIn.PreviousOutPoint)
+			return 0, ruleError(ErrTooManySigOps, str)
+		}
+	}
+
+	return totalSigOps, nil
+}
+
 // checkBlockHeaderSanity performs some preliminary checks on a block header to
 // ensure it is sane before continuing with processing.  These checks are
 // context free.
@@ -547,12 +581,16 @@ func checkBlockHeaderSanity(header *wire.BlockHeader, powLimit *big.Int, timeSou
 // checkBlockSanity performs some preliminary checks on a block to ensure it is
 // sane before continuing with block processing.  These checks are context free.
 //
+// The maximum size of the block is the one the chain parameters schedule at the
+// height in its header.
+//
 // The flags do not modify the behavior of this function directly, however they
 // are needed to pass along to checkBlockHeaderSanity.
-func checkBlockSanity(block *provautil.Block, powLimit *big.Int, timeSource MedianTimeSource, flags BehaviorFlags) error {
+func checkBlockSanity(block *provautil.Block, chainParams *chaincfg.Params, timeSource MedianTimeSource, flags BehaviorFlags) error {
 	msgBlock := block.MsgBlock()
 	header := &msgBlock.Header
-	err := checkBlockHeaderSanity(header, powLimit, timeSource, flags)
+	err := checkBlockHeaderSanity(header, chainParams.PowLimit, timeSource,
+		flags)
 	if err != nil {
 		return err
 	}
@@ -564,14 +602,15 @@ func checkBlockSanity(block *provautil.Block, powLimit *big.Int, timeSource Medi
 			"any transactions")
 	}
 
-	// A block must not have more transactions than the max block payload.
-	if numTx > wire.MaxBlockPayload {
+	// A block must not have more transactions than the max block size.
+	maxBlockSize := int(chainParams.MaxBlockSizeAtHeight(header.Height))
+	if numTx > maxBlockSize {
 		str := fmt.Sprintf("block contains too many transactions - "+
-			"got %d, max %d", numTx, wire.MaxBlockPayload)
+			"got %d, max %d", numTx, maxBlockSize)
 		return ruleError(ErrTooManyTransactions, str)
 	}
 
-	// A block must not exceed the maximum allowed block payload when
+	// A block must not exceed the maximum allowed block size when
 	// serialized.  The serialized size must match the header size value.
 	serializedSize := msgBlock.SerializeSize()
 	if serializedSize != int(header.Size) {
@@ -579,9 +618,9 @@ func checkBlockSanity(block *provautil.Block, powLimit *big.Int, timeSource Medi
 			"header size %d", serializedSize, header.Size)
 		return ruleError(ErrInconsistentBlkSize, str)
 	}
-	if serializedSize > wire.MaxBlockPayload {
+	if serializedSize > maxBlockSize {
 		str := fmt.Sprintf("serialized block is too big - got %d, "+
-			"max %d", serializedSize, wire.MaxBlockPayload)
+			"max %d", serializedSize, maxBlockSize)
 		return ruleError(ErrBlockTooBig, str)
 	}
 
@@ -660,8 +699,8 @@ func checkBlockSanity(block *provautil.Block, powLimit *big.Int, timeSource Medi
 
 // CheckBlockSanity performs some preliminary checks on a block to ensure it is
 // sane before continuing with block processing.  These checks are context free.
-func CheckBlockSanity(block *provautil.Block, powLimit *big.Int, timeSource MedianTimeSource) error {
-	return checkBlockSanity(block, powLimit, timeSource, BFNone)
+func CheckBlockSanity(block *provautil.Block, chainParams *chaincfg.Params, timeSource MedianTimeSource) error {
+	return checkBlockSanity(block, chainParams, timeSource, BFNone)
 }
 
 // checkBlockHeaderContext peforms several validation checks on the block header
@@ -713,7 +752,14 @@ func (b *BlockChain) checkBlockHeaderContext(header *wire.BlockHeader, prevNode
 		if err != nil {
 			return err
 		}
-		if !header.Verify(pubKey) {
+		// From the configured height, the signature may also be a
+		// Schnorr signature, as produced by the signers of a threshold
+		// validate key.
+		schnorrHeight := b.chainParams.SchnorrBlockSigHeight
+		schnorrValid := schnorrHeight != 0 &&
+			prevNode.height+1 >= schnorrHeight &&
+			header.VerifySchnorr(pubKey)
+		if !header.Verify(pubKey) && !schnorrValid {
 			return ruleError(ErrBadBlockSignature, "unable to validate block signature")
 		}
 	}
@@ -750,6 +796,13 @@ func (b *BlockChain) checkBlockHeaderContext(header *wire.BlockHeader, prevNode
 		return ruleError(ErrForkTooOld, str)
 	}
 
+	// Ensure the chain matches the signed checkpoints of the trusted
+	// checkpoint keys and prevent blocks which fork the main chain before
+	// them.
+	if err := b.checkSignedCheckpoints(blockHeight, &blockHash); err != nil {
+		return err
+	}
+
 	// TODO(prova): clean up / remove
 	if !fastAdd {
 		// Reject version 3 blocks once a majority of the network has
@@ -848,7 +901,7 @@ func (b *BlockChain) checkBIP0030(node *blockNode, block *provautil.Block, view
 	for _, tx := range block.Transactions() {
 		fetchSet[*tx.Hash()] = struct{}{}
 	}
-	err := view.fetchUtxos(b.db, fetchSet)
+	err := view.fetchUtxos(b.utxoCache, fetchSet)
 	if err != nil {
 		return err
 	}
@@ -868,6 +921,107 @@ func (b *BlockChain) checkBIP0030(node *blockNode, block *provautil.Block, view
 	return nil
 }
 
+// checkIssueNullDataOutput checks the null data or commitment output at the
+// passed index of an issue thread transaction, which destroys its value.  Only
+// destructions may destroy funds, and each such output must destroy some.
+func checkIssueNullDataOutput(tx *provautil.Tx, txOutIndex int) error {
+	msgTx := tx.MsgTx()
+	if len(msgTx.TxIn) < 2 {
+		str := fmt.Sprintf("issue transaction %v tries to destroy "+
+			"funds", tx.Hash())
+		return ruleError(ErrInvalidAdminTx, str)
+	}
+	if msgTx.TxOut[txOutIndex].Value == 0 {
+		str := fmt.Sprintf("admin issue transaction %v trying to "+
+			"destroy 0 at output #%d.", tx.Hash(), txOutIndex)
+		return ruleError(ErrInvalidAdminTx, str)
+	}
+	return nil
+}
+
+// CheckTransactionAssetMarkers checks the asset marker outputs of the passed
+// transaction at the passed height.  Transactions moving an asset other than
+// DMG carry a single asset marker output of zero value.  Coinbase transactions
+// always pay DMG, and admin transactions outside the issue thread move no
+// funds.
+//
+// Asset markers are only recognised from the AssetMarkerHeight of the network.
+// Below it, they are plain null data outputs, which issue thread transactions
+// may only use to destroy funds.
+//
+// NOTE: The transaction MUST have already been sanity checked with the
+// CheckTransactionSanity function prior to calling this function.
+func CheckTransactionAssetMarkers(tx *provautil.Tx, txHeight uint32,
+	chainParams *chaincfg.Params) error {
+
+	msgTx := tx.MsgTx()
+	threadInt, _ := txscript.GetAdminDetails(tx)
+	hasAdminOut := (threadInt >= 0)
+	isIssueThread := hasAdminOut &&
+		provautil.ThreadID(threadInt) == provautil.IssueThread
+	active := chainParams.AssetMarkersActive(txHeight)
+	numAssetMarkers := 0
+	for txOutIndex, txOut := range msgTx.TxOut {
+		assetID, ok := txscript.ExtractAssetID(txOut.PkScript)
+		if !ok {
+			continue
+		}
+		if !active {
+			if isIssueThread && txOutIndex > 0 {
+				err := checkIssueNullDataOutput(tx, txOutIndex)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		numAssetMarkers++
+		var str string
+		switch {
+		case numAssetMarkers > 1:
+			str = "transaction has more than one asset marker"
+		case assetID == 0:
+			str = "asset marker for DMG, which is asset 0"
+		case txOut.Value != 0:
+			str = fmt.Sprintf("asset marker with non-zero value "+
+				"at output #%d", txOutIndex)
+		case IsCoinBase(tx):
+			str = "coinbase transaction has asset marker"
+		case hasAdminOut && !isIssueThread:
+			str = "admin transaction outside the issue thread " +
+				"has asset marker"
+		default:
+			continue
+		}
+		return ruleError(ErrInvalidAssetMarker, str)
+	}
+	return nil
+}
+
+// TxAssetID returns the asset the outputs of the passed transaction belong to
+// in the block at the passed height.  Asset markers are only recognised from
+// the AssetMarkerHeight of the network, so the outputs of all transactions
+// below it belong to DMG, which is asset 0.
+func TxAssetID(msgTx *wire.MsgTx, txHeight uint32,
+	chainParams *chaincfg.Params) uint32 {
+
+	if !chainParams.AssetMarkersActive(txHeight) {
+		return 0
+	}
+	return txscript.TxAssetID(msgTx)
+}
+
+// UtxoAssetID returns the asset the outputs of the passed utxo entry belong to.
+// Entries are tagged with the asset marker of their transaction regardless of
+// its height, so the outputs of transactions below the AssetMarkerHeight of
+// the network are reported as DMG here.
+func UtxoAssetID(entry *UtxoEntry, chainParams *chaincfg.Params) uint32 {
+	if !chainParams.AssetMarkersActive(entry.BlockHeight()) {
+		return 0
+	}
+	return entry.AssetID()
+}
+
 // CheckTransactionInputs performs a series of checks on the inputs to a
 // transaction to ensure they are valid.  An example of some of the checks
 // include verifying all inputs exist, ensuring the coinbase seasoning
@@ -890,6 +1044,7 @@ func CheckTransactionInputs(tx *provautil.Tx, txHeight uint32, utxoView *UtxoVie
 	threadInt, _ := txscript.GetAdminDetails(tx)
 	hasAdminOut := (threadInt >= 0)
 	hasAdminIn := false
+	assetID := TxAssetID(tx.MsgTx(), txHeight, chainParams)
 	for txInIndex, txIn := range tx.MsgTx().TxIn {
 		// Ensure the referenced input transaction is available.
 		originTxHash := &txIn.PreviousOutPoint.Hash
@@ -937,6 +1092,18 @@ func CheckTransactionInputs(tx *provautil.Tx, txHeight uint32, utxoView *UtxoVie
 			return 0, ruleError(ErrInvalidAdminTx, str)
 		}
 
+		// Ensure the transaction only spends outputs of the asset its
+		// own outputs belong to.  The thread output spent by admin
+		// transactions carries no funds and is exempt.
+		utxoAssetID := UtxoAssetID(utxoEntry, chainParams)
+		if (txInIndex != 0 || !hasAdminIn) && utxoAssetID != assetID {
+			str := fmt.Sprintf("transaction %s:%d of asset %d tried "+
+				"to spend output %v of asset %d", txHash,
+				txInIndex, assetID, txIn.PreviousOutPoint,
+				utxoAssetID)
+			return 0, ruleError(ErrAssetMismatch, str)
+		}
+
 		// Ensure the transaction is not spending coins which have not
 		// yet reached the required coinbase maturity.
 		if utxoEntry.IsCoinBase() {
@@ -1037,6 +1204,14 @@ func CheckTransactionInputs(tx *provautil.Tx, txHeight uint32, utxoView *UtxoVie
 	if isIssueThread && txFeeInAtoms < 0 {
 		txFeeInAtoms = 0
 	}
+	// Fees are collected by the coinbase, which pays DMG, so transactions
+	// moving any other asset must not pay one.
+	if assetID != 0 && txFeeInAtoms != 0 {
+		str := fmt.Sprintf("transaction %v of asset %d pays a fee of "+
+			"%v, fees can only be paid in DMG", txHash, assetID,
+			txFeeInAtoms)
+		return 0, ruleError(ErrAssetMismatch, str)
+	}
 	if txFeeInAtoms > chainParams.MaximumFeeAmount {
 		str := fmt.Sprintf("transaction fee %v is greater than the "+
 			"maximum fee limit %v", txFeeInAtoms, chainParams.MaximumFeeAmount)
@@ -1062,18 +1237,160 @@ func CheckProvaOutput(tx *provautil.Tx, txOutIndex int, keyIDs []btcec.KeyID,
 	return nil
 }
 
+// CheckCoinbasePayee ensures all outputs of the passed coinbase transaction
+// with a non-zero value pay to the coinbase script of the network, when one
+// is set.
+func CheckCoinbasePayee(tx *provautil.Tx, chainParams *chaincfg.Params) error {
+	if chainParams.CoinbasePkScript == nil {
+		return nil
+	}
+
+	for i, txOut := range tx.MsgTx().TxOut {
+		if txOut.Value == 0 {
+			continue
+		}
+		if !bytes.Equal(txOut.PkScript, chainParams.CoinbasePkScript) {
+			str := fmt.Sprintf("coinbase transaction %v output %d "+
+				"pays %v to script %x instead of the coinbase "+
+				"script of the network", tx.Hash(), i,
+				provautil.Amount(txOut.Value), txOut.PkScript)
+			return ruleError(ErrBadCoinbasePayee, str)
+		}
+	}
+	return nil
+}
+
+// CheckTransactionFrozen ensures the passed transaction does not spend any
+// outputs paying to the pubKeyHash of a frozen account, once accounts may be
+// frozen on the network.
+//
+// NOTE: The transaction MUST have already been sanity checked with the
+// CheckTransactionSanity function prior to calling this function.
+func CheckTransactionFrozen(tx *provautil.Tx, txHeight uint32,
+	utxoView *UtxoViewpoint, keyView *KeyViewpoint,
+	chainParams *chaincfg.Params) error {
+
+	// Coinbase transactions have no inputs to check, and there is nothing
+	// to check as long as no account is frozen.
+	if !chainParams.FreezeActive(txHeight) || IsCoinBase(tx) ||
+		len(keyView.Frozen()) == 0 {
+
+		return nil
+	}
+
+	for txInIndex, txIn := range tx.MsgTx().TxIn {
+		originTxHash := &txIn.PreviousOutPoint.Hash
+		originTxIndex := txIn.PreviousOutPoint.Index
+		utxoEntry := utxoView.LookupEntry(originTxHash)
+		if utxoEntry == nil {
+			str := fmt.Sprintf("output %v referenced from "+
+				"transaction %s:%d either does not exist or "+
+				"has already been spent", txIn.PreviousOutPoint,
+				tx.Hash(), txInIndex)
+			return ruleError(ErrMissingTx, str)
+		}
+		pkScript := utxoEntry.PkScriptByIndex(originTxIndex)
+		pops, err := txscript.ParseScript(pkScript)
+		if err != nil {
+			return ruleError(ErrInvalidTx, fmt.Sprintf("%v", err))
+		}
+		for _, pkHash := range txscript.ExtractPkHashes(pops) {
+			if keyView.IsFrozen(pkHash) {
+				str := fmt.Sprintf("transaction %v input %d "+
+					"spends output %v of frozen account %x",
+					tx.Hash(), txInIndex,
+					txIn.PreviousOutPoint, pkHash)
+				return ruleError(ErrFrozenOutput, str)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckIssueChange ensures the change outputs of an issue thread transaction
+// destroying tokens pay back to the script of one of the outputs it destroys,
+// or to an allowlisted issuance destination, once the network restricts them.
+// Otherwise destructions, which are signed by the issue keys, could move
+// funds to any account past the issuance destination allowlist.
+//
+// NOTE: The transaction MUST have already been sanity checked with the
+// CheckTransactionSanity function prior to calling this function.
+func CheckIssueChange(tx *provautil.Tx, txHeight uint32,
+	utxoView *UtxoViewpoint, keyView *KeyViewpoint,
+	chainParams *chaincfg.Params) error {
+
+	msgTx := tx.MsgTx()
+	threadInt, _ := txscript.GetAdminDetails(tx)
+	if !chainParams.IssueChangeRestricted(txHeight) || threadInt < 0 ||
+		provautil.ThreadID(threadInt) != provautil.IssueThread ||
+		len(msgTx.TxIn) < 2 {
+
+		return nil
+	}
+
+	// The first input spends the issue thread tip, all others are
+	// destroyed.
+	destroyed := make(map[string]struct{}, len(msgTx.TxIn)-1)
+	for txInIndex, txIn := range msgTx.TxIn[1:] {
+		originTxHash := &txIn.PreviousOutPoint.Hash
+		originTxIndex := txIn.PreviousOutPoint.Index
+		utxoEntry := utxoView.LookupEntry(originTxHash)
+		if utxoEntry == nil {
+			str := fmt.Sprintf("output %v referenced from "+
+				"transaction %s:%d either does not exist or "+
+				"has already been spent", txIn.PreviousOutPoint,
+				tx.Hash(), txInIndex+1)
+			return ruleError(ErrMissingTx, str)
+		}
+		pkScript := utxoEntry.PkScriptByIndex(originTxIndex)
+		destroyed[string(pkScript)] = struct{}{}
+	}
+
+	// The first output continues the thread, and the destroyed atoms are
+	// bound in null data outputs.
+	for txOutIndex, txOut := range msgTx.TxOut[1:] {
+		if _, ok := destroyed[string(txOut.PkScript)]; ok {
+			continue
+		}
+		if _, isAssetMarker := txscript.ExtractAssetID(
+			txOut.PkScript); isAssetMarker {
+			continue
+		}
+		pops, err := txscript.ParseScript(txOut.PkScript)
+		if err != nil {
+			return ruleError(ErrInvalidTx, fmt.Sprintf("%v", err))
+		}
+		scriptClass := txscript.TypeOfScript(pops)
+		if scriptClass == txscript.NullDataTy ||
+			scriptClass == txscript.CommitmentTy {
+			continue
+		}
+		dest, ok := txscript.ExtractIssueDest(pops)
+		if _, allowed := keyView.IssueDests()[dest]; ok && allowed {
+			continue
+		}
+		str := fmt.Sprintf("transaction %v destroys tokens and pays "+
+			"change at output %d to a script which is neither one "+
+			"of the destroyed outputs nor an allowlisted issuance "+
+			"destination", tx.Hash(), txOutIndex+1)
+		return ruleError(ErrIssueChangeNotAllowed, str)
+	}
+	return nil
+}
+
 // CheckTransactionOutputs performs a series of checks on the outputs to ensure
 // that they are valid in the context of the chain state.
 //
 // NOTE: The transaction MUST have already been sanity checked with the
 // CheckTransactionSanity function prior to calling this function.
-func CheckTransactionOutputs(tx *provautil.Tx, keyView *KeyViewpoint, chainParams *chaincfg.Params) error {
+func CheckTransactionOutputs(tx *provautil.Tx, txHeight uint32, keyView *KeyViewpoint, chainParams *chaincfg.Params) error {
 	threadInt, adminOutputs := txscript.GetAdminDetails(tx)
 	hasAdminOut := (threadInt >= 0)
 	if !hasAdminOut {
 		// When not an admin transaction, all outputs should be
 		// Prova type spending to active keyIDs. An exception is made
-		// for coinbase txs which may have null data outputs.
+		// for coinbase txs which may have null data outputs.  Prova
+		// HTLC outputs are only valid once HTLCs are active.
 		hasNullDataOutput := false
 		for i, txOut := range tx.MsgTx().TxOut {
 			output, err := txscript.ParseScript(txOut.PkScript)
@@ -1081,7 +1398,17 @@ func CheckTransactionOutputs(tx *provautil.Tx, keyView *KeyViewpoint, chainParam
 				return ruleError(ErrInvalidTx, fmt.Sprintf("%v", err))
 			}
 			scriptClass := txscript.TypeOfScript(output)
-			if txOut.Value == 0 && scriptClass == txscript.NullDataTy {
+			if scriptClass == txscript.ProvaHTLCTy &&
+				!chainParams.HTLCActive(txHeight) {
+
+				str := fmt.Sprintf("transaction %v output %d pays "+
+					"to a Prova HTLC script before HTLCs are "+
+					"active", tx.Hash(), i)
+				return ruleError(ErrInvalidTx, str)
+			}
+			isNullData := scriptClass == txscript.NullDataTy ||
+				scriptClass == txscript.CommitmentTy
+			if txOut.Value == 0 && isNullData {
 				if !hasNullDataOutput {
 					hasNullDataOutput = true
 				} else {
@@ -1104,6 +1431,11 @@ func CheckTransactionOutputs(tx *provautil.Tx, keyView *KeyViewpoint, chainParam
 	}
 	threadId := provautil.ThreadID(threadInt)
 	if threadId == provautil.IssueThread {
+		// Newly issued atoms may only be paid to allowlisted
+		// destinations, once the allowlist holds any.
+		assetID, issued := calcTxAssetIssuance(tx, txHeight,
+			chainParams)
+		checkIssueDests := issued > 0 && len(keyView.IssueDests()) > 0
 		for i, output := range adminOutputs {
 			if len(output) > 2 {
 				keyIDs, err := txscript.ExtractKeyIDs(output)
@@ -1116,19 +1448,152 @@ func CheckTransactionOutputs(tx *provautil.Tx, keyView *KeyViewpoint, chainParam
 				if err != nil {
 					return err
 				}
+				if !checkIssueDests {
+					continue
+				}
+				dest, ok := txscript.ExtractIssueDest(output)
+				if _, allowed := keyView.IssueDests()[dest]; !ok || !allowed {
+					str := fmt.Sprintf("transaction %v issues to "+
+						"output %d which does not pay to an "+
+						"allowlisted issuance destination",
+						tx.Hash(), i+1)
+					return ruleError(ErrIssueDestNotAllowed, str)
+				}
 			}
 		}
+
+		// Issuance of DMG must not push the total supply past the
+		// maximum supply of the network when one is set.
+		supply := keyView.TotalSupply()
+		maxSupply := chainParams.MaxSupply
+		if assetID == 0 && issued > 0 && maxSupply > 0 &&
+			(supply > maxSupply || issued > maxSupply-supply) {
+
+			str := fmt.Sprintf("transaction %v issues %d atoms "+
+				"which would bring the total supply of %d past "+
+				"the max allowed of %d", tx.Hash(), issued,
+				supply, maxSupply)
+			return ruleError(ErrSupplyCapExceeded, str)
+		}
 		return nil
 	}
+	// check conditions for admin ops
+	// - Admin tx additional outputs must be nulldata scripts
+	// - Key in nulldata script must be valid
+	// - Data in nulldata scripts must match proper form expected for
+	//   the thread
+	// - The op must be active at the height of the transaction
+	for _, adminOpOut := range adminOutputs {
+		if !txscript.IsValidAdminOp(adminOpOut, threadId, txHeight,
+			chainParams) {
+
+			str := fmt.Sprintf("admin transaction %v with invalid "+
+				"admin operation found.", tx.Hash())
+			return ruleError(ErrInvalidAdminTx, str)
+		}
+	}
 	// lastKeyId is a counter to validate intra-tx state changes
 	// lastKeyId verifies that add operations are strictly increasing
 	lastKeyId := keyView.LastKeyID()
 	// revokedMap is holding intra-tx state changes
 	// revokedMap prevents 2 operations on the same keyID in one tx
 	revokedMap := make(map[btcec.KeyID]bool)
+	// destOpMap prevents 2 operations on the same issuance destination
+	// in one tx
+	destOpMap := make(map[[txscript.IssueDestLen]byte]bool)
+	// freezeOpMap prevents 2 operations on the same account in one tx
+	freezeOpMap := make(map[[ripemd160.Size]byte]bool)
+	// delegateOpMap prevents 2 operations on the same delegate key in
+	// one tx
+	delegateOpMap := make(map[[btcec.PubKeyBytesLenCompressed]byte]bool)
+	// A provision thread transaction signed by a single delegate key may
+	// only carry the ops delegated to the key, once keys may be delegated.
+	var delegation *Delegation
+	if threadId == provautil.ProvisionThread &&
+		chainParams.DelegatesActive(txHeight) {
+		sigScript := tx.MsgTx().TxIn[0].SignatureScript
+		if _, d, ok := keyView.signingDelegate(sigScript); ok {
+			delegation = &d
+		}
+	}
 	for i := 0; i < len(adminOutputs); i++ {
-		isAddOp, keySetType, pubKey,
-			keyID := txscript.ExtractAdminOpData(adminOutputs[i])
+		payload, err := txscript.ExtractAdminPayload(adminOutputs[i])
+		if err != nil {
+			str := fmt.Sprintf("admin transaction %v output %d is "+
+				"not a valid admin op: %v", tx.Hash(), i+1, err)
+			return ruleError(ErrInvalidAdminOp, str)
+		}
+		if delegation != nil && !delegation.Allows(payload) {
+			str := fmt.Sprintf("admin transaction %v is signed by a "+
+				"delegate key, which is not delegated the %v at "+
+				"output %d", tx.Hash(), payload.Op, i+1)
+			return ruleError(ErrDelegationScope, str)
+		}
+		schema := payload.Schema()
+		isAddOp := schema.IsAdd
+		switch schema.Kind {
+		case adminop.KindIssueDest:
+			dest := payload.IssueDest
+			_, exists := keyView.issueDests[dest]
+			if destOpMap[dest] || isAddOp == exists {
+				str := fmt.Sprintf("issuance destination %x can not "+
+					"be added or revoked in transaction %v. It "+
+					"does not match admin state.", dest, tx.Hash())
+				return ruleError(ErrInvalidAdminOp, str)
+			}
+			destOpMap[dest] = true
+			continue
+		case adminop.KindFreeze:
+			pkHash := payload.PubKeyHash
+			_, frozen := keyView.frozen[pkHash]
+			if freezeOpMap[pkHash] || isAddOp == frozen {
+				str := fmt.Sprintf("account %x can not be frozen "+
+					"or unfrozen in transaction %v. It does not "+
+					"match admin state.", pkHash, tx.Hash())
+				return ruleError(ErrInvalidAdminOp, str)
+			}
+			freezeOpMap[pkHash] = true
+			continue
+		case adminop.KindDelegate:
+			var pubKey [btcec.PubKeyBytesLenCompressed]byte
+			copy(pubKey[:], payload.PubKey.SerializeCompressed())
+			current, exists := keyView.delegates[pubKey]
+			opDelegation := delegationFromPayload(payload)
+			if delegateOpMap[pubKey] || isAddOp == exists ||
+				(!isAddOp && current != opDelegation) {
+
+				str := fmt.Sprintf("delegation to key %x can not be "+
+					"added or revoked in transaction %v. It does "+
+					"not match admin state.", pubKey, tx.Hash())
+				return ruleError(ErrInvalidAdminOp, str)
+			}
+			if opDelegation.MinKeyID > opDelegation.MaxKeyID {
+				str := fmt.Sprintf("delegation to key %x in "+
+					"transaction %v has the empty keyID range %v "+
+					"to %v", pubKey, tx.Hash(), opDelegation.MinKeyID,
+					opDelegation.MaxKeyID)
+				return ruleError(ErrInvalidAdminOp, str)
+			}
+			delegateOpMap[pubKey] = true
+			continue
+		case adminop.KindKey:
+		default:
+			str := fmt.Sprintf("admin transaction %v output %d "+
+				"carries %v, which does not change admin state",
+				tx.Hash(), i+1, payload.Op)
+			return ruleError(ErrInvalidAdminOp, str)
+		}
+		keySetType, pubKey, keyID := schema.KeySet, payload.PubKey,
+			payload.KeyID
+		// Keys provisioned with an expiry height must be valid in at
+		// least the block of the transaction.
+		if isAddOp && payload.Expiry != 0 && payload.Expiry <= txHeight {
+			str := fmt.Sprintf("key added in transaction %v expires "+
+				"at height %d, which is not after the height %d "+
+				"of the transaction", tx.Hash(), payload.Expiry,
+				txHeight)
+			return ruleError(ErrInvalidAdminOp, str)
+		}
 		if keySetType == btcec.ASPKeySet {
 			// TODO(prova): check pubKey collisions
 			if isAddOp {
@@ -1241,6 +1706,85 @@ func (b *BlockChain) isValidateKeyRateLimited(node *blockNode, validatePubKey wi
 	return IsGenerationShareRateLimited(validatePubKey, prevPubKeys, maxBlocks, prospectiveInclusion, lastValidatePubKey), nil
 }
 
+// calcTxAssetIssuance returns the asset and the amount of atoms of it newly
+// issued by the passed transaction.  Only issue thread transactions which do
+// not destroy tokens issue new ones, and destruction is identified by the
+// transaction spending more than the thread output just as in
+// ProcessAdminOuts.
+func calcTxAssetIssuance(tx *provautil.Tx, txHeight uint32,
+	chainParams *chaincfg.Params) (uint32, uint64) {
+
+	threadInt, _ := txscript.GetAdminDetails(tx)
+	if threadInt < 0 || provautil.ThreadID(threadInt) != provautil.IssueThread {
+		return 0, 0
+	}
+	msgTx := tx.MsgTx()
+	if len(msgTx.TxIn) > 1 {
+		return 0, 0
+	}
+
+	var issued uint64
+	for _, txOut := range msgTx.TxOut[1:] {
+		issued += uint64(txOut.Value)
+	}
+	return TxAssetID(msgTx, txHeight, chainParams), issued
+}
+
+// calcTxIssuance returns the amount of DMG atoms newly issued by the passed
+// transaction.  The supply cap and issuance epoch limits only apply to DMG.
+func calcTxIssuance(tx *provautil.Tx, txHeight uint32,
+	chainParams *chaincfg.Params) uint64 {
+
+	assetID, issued := calcTxAssetIssuance(tx, txHeight, chainParams)
+	if assetID != 0 {
+		return 0
+	}
+	return issued
+}
+
+// calcEpochIssuance returns the amount of atoms issued by the ancestors of the
+// passed node which are in the same issuance epoch as it.  The node itself is
+// not included.  Blocks which are not in memory are loaded from the database
+// since the ancestors might not be part of the main chain.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) calcEpochIssuance(node *blockNode) (uint64, error) {
+	epochBlocks := b.chainParams.IssuanceEpochBlocks
+	epochStart := node.height - node.height%epochBlocks
+
+	var issued uint64
+	err := b.db.View(func(dbTx database.Tx) error {
+		iterNode := node
+		for iterNode.height > epochStart {
+			if iterNode.parent != nil {
+				iterNode = iterNode.parent
+			} else {
+				var err error
+				iterNode, err = b.loadBlockNode(dbTx,
+					iterNode.parentHash)
+				if err != nil {
+					return err
+				}
+			}
+
+			blockBytes, err := dbTx.FetchBlock(iterNode.hash)
+			if err != nil {
+				return err
+			}
+			block, err := provautil.NewBlockFromBytes(blockBytes)
+			if err != nil {
+				return err
+			}
+			for _, tx := range block.Transactions() {
+				issued += calcTxIssuance(tx,
+					iterNode.height, b.chainParams)
+			}
+		}
+		return nil
+	})
+	return issued, err
+}
+
 // checkConnectBlock performs several checks to confirm connecting the passed
 // block to the chain represented by the passed view does not violate any rules.
 // In addition, the passed view is updated to spend all of the referenced
@@ -1279,10 +1823,17 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *provautil.Block,
 			"of expected %v", utxoView.BestHash(), node.hash))
 	}
 
+	// Refuse to follow a chain enforcing consensus rules this version does
+	// not know with blocks which may use them.
+	err := b.checkUnknownRules(node)
+	if err != nil {
+		return err
+	}
+
 	// BIP0030 added a rule to prevent blocks which contain duplicate
 	// transactions that 'overwrite' older transactions which are not fully
 	// spent.  See the documentation for checkBIP0030 for more details.
-	err := b.checkBIP0030(node, block, utxoView)
+	err = b.checkBIP0030(node, block, utxoView)
 	if err != nil {
 		return err
 	}
@@ -1292,7 +1843,7 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *provautil.Block,
 	//
 	// These utxo entries are needed for verification of things such as
 	// transaction inputs, counting pay-to-script-hashes, and scripts.
-	err = utxoView.fetchInputUtxos(b.db, block)
+	err = utxoView.fetchInputUtxos(b.utxoCache, block)
 	if err != nil {
 		return err
 	}
@@ -1306,13 +1857,22 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *provautil.Block,
 	// The number of signature operations must be less than the maximum
 	// allowed per block.  Note that the preliminary sanity checks on a
 	// block also include a check similar to this one, but this check
-	// expands the count to include a precise count of pay-to-script-hash
-	// signature operations in each of the input transaction public key
-	// scripts.
+	// expands the count to include the signature operations of the
+	// pay-to-script-hash input transaction public key scripts, and of the
+	// Prova ones once the network counts them.
+	countProvaSigOps := b.chainParams.ProvaSigOpsActive(node.height)
 	transactions := block.Transactions()
 	totalSigOps := 0
 	for i, tx := range transactions {
 		numsigOps := CountSigOps(tx)
+		if countProvaSigOps {
+			numProvaSigOps, err := CountProvaSigOps(tx, i == 0,
+				utxoView)
+			if err != nil {
+				return err
+			}
+			numsigOps += numProvaSigOps
+		}
 		if enforceBIP0016 {
 			// Since the first (and only the first) transaction has
 			// already been verified to be a coinbase transaction,
@@ -1348,6 +1908,13 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *provautil.Block,
 	// bounds.
 	var totalFees int64
 	for _, tx := range transactions {
+		// Asset markers must be well formed once recognised.
+		err := CheckTransactionAssetMarkers(tx, node.height,
+			b.chainParams)
+		if err != nil {
+			return err
+		}
+
 		txFee, err := CheckTransactionInputs(tx, node.height, utxoView,
 			b.chainParams)
 		if err != nil {
@@ -1363,8 +1930,24 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *provautil.Block,
 				"overflows accumulator")
 		}
 
+		// Outputs of frozen accounts must not be spent.
+		err = CheckTransactionFrozen(tx, node.height, utxoView,
+			keyView, b.chainParams)
+		if err != nil {
+			return err
+		}
+
+		// Destructions may only return change to the destroyed
+		// outputs or allowlisted destinations.
+		err = CheckIssueChange(tx, node.height, utxoView, keyView,
+			b.chainParams)
+		if err != nil {
+			return err
+		}
+
 		// CheckTransactionOutputs checks outputs for state violations.
-		err = CheckTransactionOutputs(tx, keyView, b.chainParams)
+		err = CheckTransactionOutputs(tx, node.height, keyView,
+			b.chainParams)
 		if err != nil {
 			return err
 		}
@@ -1380,7 +1963,37 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *provautil.Block,
 
 		// Apply all the transformations of the admin state which are
 		// not provably invalid.
-		keyView.connectTransaction(tx, node.height)
+		keyView.connectTransaction(tx, node.height, b.chainParams)
+	}
+
+	// The amount issued within the issuance epoch of the block, including
+	// the block itself, must not exceed the maximum allowed by the
+	// network.  The ancestors only need to be examined when the block
+	// issues anything.
+	if b.chainParams.MaxEpochIssuance > 0 &&
+		b.chainParams.IssuanceEpochBlocks > 0 {
+
+		var blockIssuance uint64
+		for _, tx := range transactions {
+			blockIssuance += calcTxIssuance(tx, node.height,
+				b.chainParams)
+		}
+		if blockIssuance > 0 {
+			epochIssuance, err := b.calcEpochIssuance(node)
+			if err != nil {
+				return err
+			}
+			epochIssuance += blockIssuance
+			if epochIssuance > b.chainParams.MaxEpochIssuance {
+				str := fmt.Sprintf("block issues %d atoms "+
+					"bringing the issuance of its epoch to "+
+					"%d which is more than the max "+
+					"allowed of %d", blockIssuance,
+					epochIssuance,
+					b.chainParams.MaxEpochIssuance)
+				return ruleError(ErrEpochIssuanceExceeded, str)
+			}
+		}
 	}
 
 	// The total output values of the coinbase transaction must not exceed
@@ -1401,6 +2014,13 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *provautil.Block,
 		return ruleError(ErrBadCoinbaseValue, str)
 	}
 
+	// The subsidy and fees must be paid to the coinbase script of the
+	// network when one is set.
+	err = CheckCoinbasePayee(transactions[0], b.chainParams)
+	if err != nil {
+		return err
+	}
+
 	// Don't run scripts if this node is before the latest known good
 	// checkpoint since the validity is verified via the checkpoints (all
 	// transactions are included in the merkle root hash and any changes
@@ -1461,6 +2081,12 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *provautil.Block,
 		scriptFlags |= txscript.ScriptVerifyCheckLockTimeVerify
 	}
 
+	// Verify the signatures of the keys of keyIDs checked with
+	// OP_CHECKKEYIDVERIFY from the configured height on.
+	if b.chainParams.CheckKeyIDVerifyActive(node.height) {
+		scriptFlags |= txscript.ScriptVerifyCheckKeyIDVerify
+	}
+
 	// Check to see if there is a validate key rate limit breach.
 	isRateLimited, err := b.isValidateKeyRateLimited(node, blockHeader.ValidatingPubKey, false)
 	if err != nil {
@@ -1476,12 +2102,20 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *provautil.Block,
 	// expensive ECDSA signature check scripts.  Doing this last helps
 	// prevent CPU exhaustion attacks.
 	if runScripts {
-		err := checkBlockScripts(block, utxoView, keyView, scriptFlags, b.sigCache, b.hashCache)
+		err := checkBlockScripts(block, utxoView, keyView, b.chainParams,
+			scriptFlags, b.sigCache, b.hashCache)
 		if err != nil {
 			return err
 		}
 	}
 
+	// The keys which expire at the height of the block are revoked once
+	// all of its transactions are checked.
+	if b.chainParams.KeyExpiryActive(node.height) {
+		keyView.expireKeys(node.height,
+			b.chainParams.MinValidateKeySetSize())
+	}
+
 	// Update the best hash for utxoView to include this block since all of its
 	// transactions have been connected.
 	utxoView.SetBestHash(node.hash)
@@ -1524,5 +2158,10 @@ func (b *BlockChain) CheckConnectBlock(block *provautil.Block) error {
 	keyView.SetTotalSupply(b.totalSupply)
 	keyView.SetKeys(b.adminKeySets)
 	keyView.SetKeyIDs(b.aspKeyIdMap)
+	keyView.SetIssueDests(b.issueDests)
+	keyView.SetFrozen(b.frozen)
+	keyView.SetAssetSupplies(b.assetSupplies)
+	keyView.SetKeyExpiries(b.keyExpiries)
+	keyView.SetDelegates(b.delegates)
 	return b.checkConnectBlock(newNode, block, utxoView, keyView, nil)
 }
@@ -101,10 +101,10 @@ func TestCheckConnectBlock(t *testing.T) {
 // TestCheckBlockSanity tests the CheckBlockSanity function to ensure it works
 // as expected.
 func TestCheckBlockSanity(t *testing.T) {
-	powLimit := chaincfg.MainNetParams.PowLimit
+	params := &chaincfg.MainNetParams
 	block := provautil.NewBlock(&SomeBlock)
 	timeSource := blockchain.NewMedianTime()
-	err := blockchain.CheckBlockSanity(block, powLimit, timeSource)
+	err := blockchain.CheckBlockSanity(block, params, timeSource)
 	if err != nil {
 		t.Errorf("CheckBlockSanity: %v", err)
 	}
@@ -113,10 +113,33 @@ func TestCheckBlockSanity(t *testing.T) {
 	// second fails.
 	timestamp := block.MsgBlock().Header.Timestamp
 	block.MsgBlock().Header.Timestamp = timestamp.Add(time.Nanosecond)
-	err = blockchain.CheckBlockSanity(block, powLimit, timeSource)
+	err = blockchain.CheckBlockSanity(block, params, timeSource)
 	if err == nil {
 		t.Errorf("CheckBlockSanity: error is nil when it shouldn't be")
 	}
+	block.MsgBlock().Header.Timestamp = timestamp
+
+	// Ensure the max block size scheduled at the height of the block is
+	// enforced.
+	height := block.MsgBlock().Header.Height
+	size := uint32(block.MsgBlock().SerializeSize())
+	smallParams := *params
+	smallParams.MaxBlockSize = size - 1
+	smallParams.MaxBlockSizeSchedule = []chaincfg.BlockSizeStep{
+		{Height: height + 1, MaxSize: size},
+	}
+	err = blockchain.CheckBlockSanity(block, &smallParams, timeSource)
+	if rerr, ok := err.(blockchain.RuleError); !ok ||
+		rerr.ErrorCode != blockchain.ErrBlockTooBig {
+
+		t.Errorf("CheckBlockSanity: got error %v, want %v", err,
+			blockchain.ErrBlockTooBig)
+	}
+	smallParams.MaxBlockSizeSchedule[0].Height = height
+	err = blockchain.CheckBlockSanity(block, &smallParams, timeSource)
+	if err != nil {
+		t.Errorf("CheckBlockSanity: %v", err)
+	}
 }
 
 // SomeBlock is used to test Block operations.
@@ -156,6 +179,64 @@ var SomeBlock = wire.MsgBlock{
 	},
 }
 
+// TestCountProvaSigOps ensures the signature operations of the Prova public key
+// scripts spent by a transaction are counted from their declared keys.
+func TestCountProvaSigOps(t *testing.T) {
+	pkHash := make([]byte, 20)
+	addr, err := provautil.NewAddressProva(pkHash, []btcec.KeyID{1, 2},
+		&chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("NewAddressProva: %v", err)
+	}
+	provaScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("PayToAddrScript: %v", err)
+	}
+	originTx := wire.NewMsgTx(wire.TxVersion)
+	originTx.AddTxIn(&wire.TxIn{})
+	originTx.AddTxOut(wire.NewTxOut(1000, provaScript))
+	originTx.AddTxOut(wire.NewTxOut(1000, provaScript))
+	originTx.AddTxOut(wire.NewTxOut(1000, []byte{txscript.OP_TRUE}))
+	view := blockchain.NewUtxoViewpoint()
+	view.AddTxOuts(provautil.NewTx(originTx), 1)
+
+	originHash := originTx.TxHash()
+	spendTx := wire.NewMsgTx(wire.TxVersion)
+	for i := uint32(0); i < 3; i++ {
+		spendTx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: *wire.NewOutPoint(&originHash, i),
+		})
+	}
+	tx := provautil.NewTx(spendTx)
+
+	// Each Prova script declares 3 keys.
+	numSigOps, err := blockchain.CountProvaSigOps(tx, false, view)
+	if err != nil {
+		t.Fatalf("CountProvaSigOps: %v", err)
+	}
+	if numSigOps != 6 {
+		t.Errorf("CountProvaSigOps: got %d sigops, want 6", numSigOps)
+	}
+
+	// Coinbase transactions have no sigops in their inputs.
+	numSigOps, err = blockchain.CountProvaSigOps(tx, true, view)
+	if err != nil || numSigOps != 0 {
+		t.Errorf("CountProvaSigOps: got %d sigops and error %v for a "+
+			"coinbase, want none", numSigOps, err)
+	}
+
+	// Spending a missing output is an error.
+	spendTx.TxIn[0].PreviousOutPoint.Index = 3
+	_, err = blockchain.CountProvaSigOps(provautil.NewTx(spendTx), false,
+		view)
+	if rerr, ok := err.(blockchain.RuleError); !ok ||
+		rerr.ErrorCode != blockchain.ErrMissingTx {
+
+		t.Errorf("CountProvaSigOps: got error %v, want %v", err,
+			blockchain.ErrMissingTx)
+	}
+}
+
 // TestCheckTransactionSanity tests the CheckTransactionSanity API.
 func TestCheckTransactionSanity(t *testing.T) {
 	// Create some dummy, but otherwise standard, data for transactions.
@@ -210,17 +291,17 @@ func TestCheckTransactionSanity(t *testing.T) {
 		PkScript: rootPkScript,
 	}
 	// create provision tx out
-	provisionPkScript, _ := txscript.ProvaThreadScript(provautil.ProvisionThread)
-	provisionTxOut := wire.TxOut{
-		Value:    0, // 0 DMG
-		PkScript: provisionPkScript,
-	}
-	// create provision tx out
 	issuePkScript, _ := txscript.ProvaThreadScript(provautil.IssueThread)
 	issueTxOut := wire.TxOut{
 		Value:    0, // 0 DMG
 		PkScript: issuePkScript,
 	}
+	// create asset marker outs
+	assetPkScript, _ := txscript.AssetIDScript(1)
+	assetTxOut := wire.TxOut{
+		Value:    0,
+		PkScript: assetPkScript,
+	}
 
 	tests := []struct {
 		name    string
@@ -363,29 +444,37 @@ func TestCheckTransactionSanity(t *testing.T) {
 			code:    blockchain.ErrInvalidAdminTx,
 		},
 		{
-			name: "Admin transaction with operation on wrong thread",
+			name: "Asset issue transaction",
 			tx: wire.MsgTx{
 				Version:  1,
 				TxIn:     []*wire.TxIn{&dummyTxIn},
-				TxOut:    []*wire.TxOut{&provisionTxOut, &adminOpTxOut},
+				TxOut:    []*wire.TxOut{&issueTxOut, &assetTxOut, &provaTxOut},
 				LockTime: 0,
 			},
-			isValid: false,
-			code:    blockchain.ErrInvalidAdminTx,
+			isValid: true,
 		},
 		{
-			name: "Admin transaction with invalid operation",
+			name: "Asset destruction transaction",
 			tx: wire.MsgTx{
 				Version: 1,
-				TxIn:    []*wire.TxIn{&dummyTxIn},
-				TxOut: []*wire.TxOut{&rootTxOut, {
-					Value:    0,
+				TxIn:    []*wire.TxIn{&dummyTxIn, &dummyTxIn2},
+				TxOut: []*wire.TxOut{&issueTxOut, &assetTxOut, {
+					Value:    300,
 					PkScript: []byte{txscript.OP_RETURN},
 				}},
 				LockTime: 0,
 			},
-			isValid: false,
-			code:    blockchain.ErrInvalidAdminTx,
+			isValid: true,
+		},
+		{
+			name: "Asset transfer transaction",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn},
+				TxOut:    []*wire.TxOut{&provaTxOut, &assetTxOut},
+				LockTime: 0,
+			},
+			isValid: true,
 		},
 	}
 
@@ -426,6 +515,231 @@ func TestCheckTransactionSanity(t *testing.T) {
 	}
 }
 
+// TestCheckTransactionAssetMarkers tests the CheckTransactionAssetMarkers API,
+// which only recognises asset markers from the activation height.
+func TestCheckTransactionAssetMarkers(t *testing.T) {
+	// Create some dummy, but otherwise standard, data for transactions.
+	prevOutHash, err := chainhash.NewHashFromStr("01")
+	if err != nil {
+		t.Fatalf("NewShaHashFromStr: unexpected error: %v", err)
+	}
+	dummySigScript := bytes.Repeat([]byte{0x00}, 65)
+	dummyTxIn := wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Hash: *prevOutHash, Index: 1},
+		SignatureScript:  dummySigScript,
+		Sequence:         wire.MaxTxInSequenceNum,
+	}
+	dummyTxIn2 := wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Hash: *prevOutHash, Index: 2},
+		SignatureScript:  dummySigScript,
+		Sequence:         wire.MaxTxInSequenceNum,
+	}
+	coinbaseTxIn := wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: wire.MaxPrevOutIndex},
+		SignatureScript:  dummySigScript,
+		Sequence:         wire.MaxTxInSequenceNum,
+	}
+	payAddr, _ := provautil.NewAddressProva(make([]byte, 20),
+		[]btcec.KeyID{1, 2}, &chaincfg.RegressionNetParams)
+	provaPkScript, _ := txscript.PayToAddrScript(payAddr)
+	provaTxOut := wire.TxOut{
+		Value:    300,
+		PkScript: provaPkScript,
+	}
+	rootPkScript, _ := txscript.ProvaThreadScript(provautil.RootThread)
+	rootTxOut := wire.TxOut{
+		Value:    0,
+		PkScript: rootPkScript,
+	}
+	issuePkScript, _ := txscript.ProvaThreadScript(provautil.IssueThread)
+	issueTxOut := wire.TxOut{
+		Value:    0,
+		PkScript: issuePkScript,
+	}
+	assetPkScript, _ := txscript.AssetIDScript(1)
+	assetTxOut := wire.TxOut{
+		Value:    0,
+		PkScript: assetPkScript,
+	}
+	assetValueTxOut := wire.TxOut{
+		Value:    300,
+		PkScript: assetPkScript,
+	}
+	dmgAssetPkScript, _ := txscript.AssetIDScript(0)
+	dmgAssetTxOut := wire.TxOut{
+		Value:    0,
+		PkScript: dmgAssetPkScript,
+	}
+
+	// Asset markers are recognised from height 100.
+	params := chaincfg.RegressionNetParams
+	params.AssetMarkerHeight = 100
+
+	tests := []struct {
+		name    string
+		tx      wire.MsgTx
+		height  uint32
+		isValid bool
+		code    blockchain.ErrorCode
+	}{
+		{
+			name: "Asset transfer transaction",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn},
+				TxOut:    []*wire.TxOut{&provaTxOut, &assetTxOut},
+				LockTime: 0,
+			},
+			height:  100,
+			isValid: true,
+		},
+		{
+			name: "Asset issuance transaction",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn},
+				TxOut:    []*wire.TxOut{&issueTxOut, &assetTxOut, &provaTxOut},
+				LockTime: 0,
+			},
+			height:  100,
+			isValid: true,
+		},
+		{
+			name: "Asset marker with value",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn:    []*wire.TxIn{&dummyTxIn, &dummyTxIn2},
+				TxOut: []*wire.TxOut{&issueTxOut,
+					&assetValueTxOut},
+				LockTime: 0,
+			},
+			height:  100,
+			isValid: false,
+			code:    blockchain.ErrInvalidAssetMarker,
+		},
+		{
+			name: "Two asset markers",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn},
+				TxOut:    []*wire.TxOut{&issueTxOut, &assetTxOut, &assetTxOut},
+				LockTime: 0,
+			},
+			height:  100,
+			isValid: false,
+			code:    blockchain.ErrInvalidAssetMarker,
+		},
+		{
+			name: "Asset marker for DMG",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn:    []*wire.TxIn{&dummyTxIn},
+				TxOut: []*wire.TxOut{&issueTxOut, &dmgAssetTxOut,
+					&provaTxOut},
+				LockTime: 0,
+			},
+			height:  100,
+			isValid: false,
+			code:    blockchain.ErrInvalidAssetMarker,
+		},
+		{
+			name: "Coinbase with asset marker",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&coinbaseTxIn},
+				TxOut:    []*wire.TxOut{&provaTxOut, &assetTxOut},
+				LockTime: 0,
+			},
+			height:  100,
+			isValid: false,
+			code:    blockchain.ErrInvalidAssetMarker,
+		},
+		{
+			name: "Root thread with asset marker",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn},
+				TxOut:    []*wire.TxOut{&rootTxOut, &assetTxOut},
+				LockTime: 0,
+			},
+			height:  100,
+			isValid: false,
+			code:    blockchain.ErrInvalidAssetMarker,
+		},
+		{
+			name: "Asset marker for DMG before activation",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn},
+				TxOut:    []*wire.TxOut{&provaTxOut, &dmgAssetTxOut},
+				LockTime: 0,
+			},
+			height:  99,
+			isValid: true,
+		},
+		{
+			name: "Coinbase with asset marker before activation",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&coinbaseTxIn},
+				TxOut:    []*wire.TxOut{&provaTxOut, &assetTxOut},
+				LockTime: 0,
+			},
+			height:  99,
+			isValid: true,
+		},
+		{
+			name: "Destruction into asset marker before activation",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn:    []*wire.TxIn{&dummyTxIn, &dummyTxIn2},
+				TxOut: []*wire.TxOut{&issueTxOut,
+					&assetValueTxOut},
+				LockTime: 0,
+			},
+			height:  99,
+			isValid: true,
+		},
+		{
+			name: "Issuance with asset marker before activation",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn},
+				TxOut:    []*wire.TxOut{&issueTxOut, &assetTxOut, &provaTxOut},
+				LockTime: 0,
+			},
+			height:  99,
+			isValid: false,
+			code:    blockchain.ErrInvalidAdminTx,
+		},
+	}
+
+	for _, test := range tests {
+		err := blockchain.CheckTransactionAssetMarkers(
+			provautil.NewTx(&test.tx), test.height, &params)
+		if err == nil && test.isValid {
+			continue
+		}
+		if err == nil && !test.isValid {
+			t.Errorf("CheckTransactionAssetMarkers (%s): valid "+
+				"when it should not be", test.name)
+			continue
+		}
+		if err != nil && test.isValid {
+			t.Errorf("CheckTransactionAssetMarkers (%s): invalid "+
+				"when it should not be: %v", test.name, err)
+			continue
+		}
+
+		// Ensure the error code is the expected one.
+		rerr, ok := err.(blockchain.RuleError)
+		if !ok || rerr.ErrorCode != test.code {
+			t.Errorf("CheckTransactionAssetMarkers (%s): got "+
+				"error %v, want %v", test.name, err, test.code)
+		}
+	}
+}
+
 // hexToBytes converts the passed hex string into bytes and will panic if there
 // is an error.  This is only provided for the hard-coded constants so errors in
 // the source code can be detected. It will only (and must only) be called with
@@ -461,6 +775,19 @@ func TestCheckTransactionOutputs(t *testing.T) {
 		Value:    0, // 0 atoms
 		PkScript: provaPkScript,
 	}
+	// Create prova htlc txout redeemable by the prova address above and
+	// refundable to an address using keyID 3.
+	refundAddr, _ := provautil.NewAddressProva(make([]byte, 20), []btcec.KeyID{keyId1, btcec.KeyID(3)}, &chaincfg.RegressionNetParams)
+	htlcPkScript, err := txscript.PayToProvaHTLCScript(
+		bytes.Repeat([]byte{0x11}, txscript.HTLCSecretSize), 500000,
+		payAddr, refundAddr)
+	if err != nil {
+		t.Fatalf("PayToProvaHTLCScript: unexpected error: %v", err)
+	}
+	htlcTxOut := wire.TxOut{
+		Value:    0, // 0 atoms
+		PkScript: htlcPkScript,
+	}
 	// Create null txout
 	nullScript, err := txscript.NewScriptBuilder().
 		AddOp(txscript.OP_RETURN).Script()
@@ -528,17 +855,6 @@ func TestCheckTransactionOutputs(t *testing.T) {
 		Value:    0,
 		PkScript: adminOpAsp2PkScript,
 	}
-	// dummy asp script
-	data = make([]byte, 1+btcec.PubKeyBytesLenCompressed+btcec.KeyIDSize)
-	data[0] = txscript.AdminOpASPKeyAdd
-	copy(data[1:], bytes.Repeat([]byte{0x00}, btcec.PubKeyBytesLenCompressed))
-	keyID.ToAddressFormat(data[1+btcec.PubKeyBytesLenCompressed:])
-	dummyOpAspPkScript, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_RETURN).
-		AddData(data).Script()
-	dummyOpAspTxOut := wire.TxOut{
-		Value:    0,
-		PkScript: dummyOpAspPkScript,
-	}
 	// Create admin op to revoke keyID.
 	data = make([]byte, 1+btcec.PubKeyBytesLenCompressed+btcec.KeyIDSize)
 	data[0] = txscript.AdminOpASPKeyRevoke
@@ -556,12 +872,33 @@ func TestCheckTransactionOutputs(t *testing.T) {
 		Value:    0, // 0 DMG
 		PkScript: rootPkScript,
 	}
+	// create provision tx out
+	provisionPkScript, _ := txscript.ProvaThreadScript(provautil.ProvisionThread)
+	provisionTxOut := wire.TxOut{
+		Value:    0, // 0 DMG
+		PkScript: provisionPkScript,
+	}
 	// create issue tx out
 	issuePkScript, _ := txscript.ProvaThreadScript(provautil.IssueThread)
 	issueTxOut := wire.TxOut{
 		Value:    0, // 0 DMG
 		PkScript: issuePkScript,
 	}
+	// Create admin op to freeze an account.
+	data = make([]byte, 21)
+	data[0] = txscript.AdminOpAccountFreeze
+	freezeOpPkScript, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_RETURN).
+		AddData(data).Script()
+	freezeOpTxOut := wire.TxOut{
+		Value:    0,
+		PkScript: freezeOpPkScript,
+	}
+
+	// The tests are run at height 1, at which lateParams have not
+	// activated the ops introduced after launch yet.
+	lateParams := chaincfg.RegressionNetParams
+	lateParams.FreezeHeight = 2
+	lateParams.HTLCHeight = 2
 
 	tests := []struct {
 		name         string
@@ -569,10 +906,58 @@ func TestCheckTransactionOutputs(t *testing.T) {
 		lastKeyID    btcec.KeyID
 		adminKeySets map[btcec.KeySetType]btcec.PublicKeySet
 		aspKeyIdMap  btcec.KeyIdMap
+		params       *chaincfg.Params
 		isCoinbase   bool
 		isValid      bool
 		code         blockchain.ErrorCode
 	}{
+		{
+			name: "Admin transaction with operation on wrong thread",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn},
+				TxOut:    []*wire.TxOut{&provisionTxOut, &adminOpTxOut},
+				LockTime: 0,
+			},
+			isValid: false,
+			code:    blockchain.ErrInvalidAdminTx,
+		},
+		{
+			name: "Admin transaction with invalid operation",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn:    []*wire.TxIn{&dummyTxIn},
+				TxOut: []*wire.TxOut{&rootTxOut, {
+					Value:    0,
+					PkScript: []byte{txscript.OP_RETURN},
+				}},
+				LockTime: 0,
+			},
+			isValid: false,
+			code:    blockchain.ErrInvalidAdminTx,
+		},
+		{
+			name: "Freeze account.",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn},
+				TxOut:    []*wire.TxOut{&provisionTxOut, &freezeOpTxOut},
+				LockTime: 0,
+			},
+			isValid: true,
+		},
+		{
+			name: "Freeze account before activation.",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn},
+				TxOut:    []*wire.TxOut{&provisionTxOut, &freezeOpTxOut},
+				LockTime: 0,
+			},
+			params:  &lateParams,
+			isValid: false,
+			code:    blockchain.ErrInvalidAdminTx,
+		},
 		{
 			name: "Spend to regular Prova output.",
 			tx: wire.MsgTx{
@@ -603,6 +988,56 @@ func TestCheckTransactionOutputs(t *testing.T) {
 			isValid: false,
 			code:    blockchain.ErrInvalidTx,
 		},
+		{
+			name: "Spend to Prova HTLC output.",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn},
+				TxOut:    []*wire.TxOut{&htlcTxOut},
+				LockTime: 0,
+			},
+			aspKeyIdMap: func() btcec.KeyIdMap {
+				keyId1 := btcec.KeyID(1)
+				keyId2 := btcec.KeyID(2)
+				keyId3 := btcec.KeyID(3)
+				return map[btcec.KeyID]*btcec.PublicKey{keyId1: pubKey, keyId2: pubKey, keyId3: pubKey}
+			}(),
+			isValid: true,
+		},
+		{
+			name: "Spend to Prova HTLC output before activation.",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn},
+				TxOut:    []*wire.TxOut{&htlcTxOut},
+				LockTime: 0,
+			},
+			aspKeyIdMap: func() btcec.KeyIdMap {
+				keyId1 := btcec.KeyID(1)
+				keyId2 := btcec.KeyID(2)
+				keyId3 := btcec.KeyID(3)
+				return map[btcec.KeyID]*btcec.PublicKey{keyId1: pubKey, keyId2: pubKey, keyId3: pubKey}
+			}(),
+			params:  &lateParams,
+			isValid: false,
+			code:    blockchain.ErrInvalidTx,
+		},
+		{
+			name: "Spend to Prova HTLC with unknown refund keyID.",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn},
+				TxOut:    []*wire.TxOut{&htlcTxOut},
+				LockTime: 0,
+			},
+			aspKeyIdMap: func() btcec.KeyIdMap {
+				keyId1 := btcec.KeyID(1)
+				keyId2 := btcec.KeyID(2)
+				return map[btcec.KeyID]*btcec.PublicKey{keyId1: pubKey, keyId2: pubKey}
+			}(),
+			isValid: false,
+			code:    blockchain.ErrInvalidTx,
+		},
 		{
 			name: "Add key to empty admin set.",
 			tx: wire.MsgTx{
@@ -694,7 +1129,7 @@ func TestCheckTransactionOutputs(t *testing.T) {
 			tx: wire.MsgTx{
 				Version:  1,
 				TxIn:     []*wire.TxIn{&dummyTxIn},
-				TxOut:    []*wire.TxOut{&rootTxOut, &adminOpRevProvTxOut},
+				TxOut:    []*wire.TxOut{&provisionTxOut, &adminOpRevProvTxOut},
 				LockTime: 0,
 			},
 			adminKeySets: func() map[btcec.KeySetType]btcec.PublicKeySet {
@@ -714,7 +1149,7 @@ func TestCheckTransactionOutputs(t *testing.T) {
 			tx: wire.MsgTx{
 				Version:  1,
 				TxIn:     []*wire.TxIn{&dummyTxIn},
-				TxOut:    []*wire.TxOut{&rootTxOut, &adminOpAspTxOut},
+				TxOut:    []*wire.TxOut{&provisionTxOut, &adminOpAspTxOut},
 				LockTime: 0,
 			},
 			lastKeyID: btcec.KeyID(1),
@@ -737,7 +1172,7 @@ func TestCheckTransactionOutputs(t *testing.T) {
 			tx: wire.MsgTx{
 				Version:  1,
 				TxIn:     []*wire.TxIn{&dummyTxIn},
-				TxOut:    []*wire.TxOut{&rootTxOut, &adminOpAspTxOut, &dummyOpAspTxOut},
+				TxOut:    []*wire.TxOut{&provisionTxOut, &adminOpAspTxOut, &adminOpAspTxOut},
 				LockTime: 0,
 			},
 			lastKeyID: btcec.KeyID(1),
@@ -749,7 +1184,7 @@ func TestCheckTransactionOutputs(t *testing.T) {
 			tx: wire.MsgTx{
 				Version:  1,
 				TxIn:     []*wire.TxIn{&dummyTxIn},
-				TxOut:    []*wire.TxOut{&rootTxOut, &adminOpAspTxOut, &adminOpAsp2TxOut},
+				TxOut:    []*wire.TxOut{&provisionTxOut, &adminOpAspTxOut, &adminOpAsp2TxOut},
 				LockTime: 0,
 			},
 			lastKeyID: btcec.KeyID(1),
@@ -760,7 +1195,7 @@ func TestCheckTransactionOutputs(t *testing.T) {
 			tx: wire.MsgTx{
 				Version:  1,
 				TxIn:     []*wire.TxIn{&dummyTxIn},
-				TxOut:    []*wire.TxOut{&rootTxOut, &adminOpAspTxOut},
+				TxOut:    []*wire.TxOut{&provisionTxOut, &adminOpAspTxOut},
 				LockTime: 0,
 			},
 			aspKeyIdMap: func() btcec.KeyIdMap {
@@ -774,7 +1209,7 @@ func TestCheckTransactionOutputs(t *testing.T) {
 			tx: wire.MsgTx{
 				Version:  1,
 				TxIn:     []*wire.TxIn{&dummyTxIn},
-				TxOut:    []*wire.TxOut{&rootTxOut, &adminOpAspRevTxOut, &adminOpAspRevTxOut},
+				TxOut:    []*wire.TxOut{&provisionTxOut, &adminOpAspRevTxOut, &adminOpAspRevTxOut},
 				LockTime: 0,
 			},
 			aspKeyIdMap: func() btcec.KeyIdMap {
@@ -790,7 +1225,7 @@ func TestCheckTransactionOutputs(t *testing.T) {
 			tx: wire.MsgTx{
 				Version:  1,
 				TxIn:     []*wire.TxIn{&dummyTxIn},
-				TxOut:    []*wire.TxOut{&rootTxOut, &adminOpAspRevTxOut},
+				TxOut:    []*wire.TxOut{&provisionTxOut, &adminOpAspRevTxOut},
 				LockTime: 0,
 			},
 			isValid: false,
@@ -803,7 +1238,7 @@ func TestCheckTransactionOutputs(t *testing.T) {
 				TxIn:    []*wire.TxIn{&dummyTxIn},
 				// this admin op revokes keyID 65536 ( []byte{0, 0, 1, 0} )
 				// this admin op revokes pubKey 038ef4a121bcaf1b1f175557a12896f8bc93b095e84817f90e9a901cd2113a8202
-				TxOut:    []*wire.TxOut{&rootTxOut, &adminOpAspRevTxOut},
+				TxOut:    []*wire.TxOut{&provisionTxOut, &adminOpAspRevTxOut},
 				LockTime: 0,
 			},
 			aspKeyIdMap: func() btcec.KeyIdMap {
@@ -863,7 +1298,11 @@ func TestCheckTransactionOutputs(t *testing.T) {
 		if test.isCoinbase {
 			tx.SetIndex(0)
 		}
-		err := blockchain.CheckTransactionOutputs(tx, keyView, &chaincfg.RegressionNetParams)
+		params := test.params
+		if params == nil {
+			params = &chaincfg.RegressionNetParams
+		}
+		err := blockchain.CheckTransactionOutputs(tx, 1, keyView, params)
 		if err == nil && test.isValid {
 			// Test passes since function returned valid for a
 			// transaction which is intended to be valid.
@@ -941,11 +1380,38 @@ func TestCheckTransactionInputs(t *testing.T) {
 		SignatureScript:  dummySigScript,
 		Sequence:         wire.MaxTxInSequenceNum,
 	}
+	// create an output of asset 1
+	assetPkScript, _ := txscript.AssetIDScript(1)
+	assetTxOut := wire.TxOut{
+		Value:    0,
+		PkScript: assetPkScript,
+	}
+	assetPrevTx := provautil.NewTx(&wire.MsgTx{
+		Version:  1,
+		TxIn:     []*wire.TxIn{&dummyTxIn},
+		TxOut:    []*wire.TxOut{&prevOut, &assetTxOut},
+		LockTime: 0,
+	})
+	assetTxIn := wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Hash: *assetPrevTx.Hash(), Index: 0},
+		SignatureScript:  dummySigScript,
+		Sequence:         wire.MaxTxInSequenceNum,
+	}
+
+	// Asset markers are recognised from the first block unless a test
+	// uses other parameters.  The outputs spent by the tests are created
+	// at height 100, before asset markers are recognised with the late
+	// parameters.
+	params := chaincfg.MainNetParams
+	params.AssetMarkerHeight = 1
+	lateParams := chaincfg.MainNetParams
+	lateParams.AssetMarkerHeight = 150
 
 	tests := []struct {
 		name    string
 		tx      wire.MsgTx
 		height  uint32
+		params  *chaincfg.Params
 		isValid bool
 		code    blockchain.ErrorCode
 	}{
@@ -1023,14 +1489,159 @@ func TestCheckTransactionInputs(t *testing.T) {
 			isValid: false,
 			code:    blockchain.ErrFeeTooHigh,
 		},
+		{
+			name: "transfer asset.",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn:    []*wire.TxIn{&assetTxIn},
+				TxOut: []*wire.TxOut{{
+					Value:    400000000,
+					PkScript: provaPkScript,
+				}, &assetTxOut},
+			},
+			height:  200,
+			isValid: true,
+		},
+		{
+			name: "transfer asset paying a fee.",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn:    []*wire.TxIn{&assetTxIn},
+				TxOut: []*wire.TxOut{{
+					Value:    400000000 - 1,
+					PkScript: provaPkScript,
+				}, &assetTxOut},
+			},
+			height:  200,
+			isValid: false,
+			code:    blockchain.ErrAssetMismatch,
+		},
+		{
+			name: "spend asset as DMG.",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn:    []*wire.TxIn{&assetTxIn},
+				TxOut: []*wire.TxOut{{
+					Value:    400000000,
+					PkScript: provaPkScript,
+				}},
+			},
+			height:  200,
+			isValid: false,
+			code:    blockchain.ErrAssetMismatch,
+		},
+		{
+			name: "spend DMG as asset.",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn:    []*wire.TxIn{&dummyTxIn},
+				TxOut: []*wire.TxOut{{
+					Value:    400000000,
+					PkScript: provaPkScript,
+				}, &assetTxOut},
+			},
+			height:  200,
+			isValid: false,
+			code:    blockchain.ErrAssetMismatch,
+		},
+		{
+			name: "destroy asset.",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn:    []*wire.TxIn{&issueTxIn, &assetTxIn},
+				TxOut: []*wire.TxOut{&issueTxOut, &assetTxOut, {
+					Value:    400000000,
+					PkScript: []byte{txscript.OP_RETURN},
+				}},
+			},
+			height:  200,
+			isValid: true,
+		},
+		{
+			name: "destroy asset and DMG in same tx.",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn: []*wire.TxIn{&issueTxIn, &assetTxIn,
+					&dummyTxIn},
+				TxOut: []*wire.TxOut{&issueTxOut, &assetTxOut, {
+					Value:    800000000,
+					PkScript: []byte{txscript.OP_RETURN},
+				}},
+			},
+			height:  200,
+			isValid: false,
+			code:    blockchain.ErrAssetMismatch,
+		},
+		{
+			name: "spend DMG with asset marker before activation.",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn:    []*wire.TxIn{&dummyTxIn},
+				TxOut: []*wire.TxOut{{
+					Value:    400000000,
+					PkScript: provaPkScript,
+				}, &assetTxOut},
+			},
+			height:  200,
+			params:  &chaincfg.MainNetParams,
+			isValid: true,
+		},
+		{
+			name: "pay a fee with asset marker before activation.",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn:    []*wire.TxIn{&assetTxIn},
+				TxOut: []*wire.TxOut{{
+					Value:    400000000 - 1,
+					PkScript: provaPkScript,
+				}, &assetTxOut},
+			},
+			height:  200,
+			params:  &chaincfg.MainNetParams,
+			isValid: true,
+		},
+		{
+			name: "spend output created before activation as DMG.",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn:    []*wire.TxIn{&assetTxIn},
+				TxOut: []*wire.TxOut{{
+					Value:    400000000,
+					PkScript: provaPkScript,
+				}},
+			},
+			height:  200,
+			params:  &lateParams,
+			isValid: true,
+		},
+		{
+			name: "spend output created before activation as asset.",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn:    []*wire.TxIn{&assetTxIn},
+				TxOut: []*wire.TxOut{{
+					Value:    400000000,
+					PkScript: provaPkScript,
+				}, &assetTxOut},
+			},
+			height:  200,
+			params:  &lateParams,
+			isValid: false,
+			code:    blockchain.ErrAssetMismatch,
+		},
 	}
 
 	for _, test := range tests {
+		testParams := test.params
+		if testParams == nil {
+			testParams = &params
+		}
 		utxoView := blockchain.NewUtxoViewpoint()
 		utxoView.AddTxOuts(prevTx, 100)
 		utxoView.AddTxOuts(issueTipTx, 100)
+		utxoView.AddTxOuts(assetPrevTx, 100)
 		_, err := blockchain.CheckTransactionInputs(provautil.NewTx(&test.tx),
-			test.height, utxoView, &chaincfg.MainNetParams)
+			test.height, utxoView, testParams)
 		if err == nil && test.isValid {
 			// Test passes since function returned valid for a
 			// transaction which is intended to be valid.
@@ -1063,3 +1674,236 @@ func TestCheckTransactionInputs(t *testing.T) {
 		}
 	}
 }
+
+// TestCheckIssueChange tests the CheckIssueChange API.
+func TestCheckIssueChange(t *testing.T) {
+	keyIDs := []btcec.KeyID{1, 2}
+	makeScript := func(b byte) []byte {
+		addr, _ := provautil.NewAddressProva(bytes.Repeat([]byte{b}, 20),
+			keyIDs, &chaincfg.RegressionNetParams)
+		pkScript, _ := txscript.PayToAddrScript(addr)
+		return pkScript
+	}
+	holderPkScript := makeScript(0)
+	destPkScript := makeScript(1)
+	otherPkScript := makeScript(2)
+	destPops, _ := txscript.ParseScript(destPkScript)
+	dest, _ := txscript.ExtractIssueDest(destPops)
+
+	// The destroyed output and the issue thread tip.
+	dummySigScript := bytes.Repeat([]byte{0x00}, 65)
+	prevTx := provautil.NewTx(&wire.MsgTx{
+		Version: 1,
+		TxIn: []*wire.TxIn{{
+			SignatureScript: dummySigScript,
+			Sequence:        wire.MaxTxInSequenceNum,
+		}},
+		TxOut: []*wire.TxOut{{Value: 400000000, PkScript: holderPkScript}},
+	})
+	issuePkScript, _ := txscript.ProvaThreadScript(provautil.IssueThread)
+	issueTxOut := wire.TxOut{Value: 0, PkScript: issuePkScript}
+	issueTipTx := provautil.NewTx(&wire.MsgTx{
+		Version:  1,
+		TxIn:     prevTx.MsgTx().TxIn,
+		TxOut:    []*wire.TxOut{&issueTxOut},
+		LockTime: 1,
+	})
+	issueTxIn := wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Hash: *issueTipTx.Hash()},
+		SignatureScript:  dummySigScript,
+		Sequence:         wire.MaxTxInSequenceNum,
+	}
+	destroyTxIn := wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Hash: *prevTx.Hash()},
+		SignatureScript:  dummySigScript,
+		Sequence:         wire.MaxTxInSequenceNum,
+	}
+	destroyTxOut := wire.TxOut{
+		Value:    300000000,
+		PkScript: []byte{txscript.OP_RETURN},
+	}
+	assetPkScript, _ := txscript.AssetIDScript(1)
+
+	tests := []struct {
+		name    string
+		txIn    []*wire.TxIn
+		txOut   []*wire.TxOut
+		params  *chaincfg.Params
+		isValid bool
+	}{
+		{
+			name:    "destruction without change",
+			txIn:    []*wire.TxIn{&issueTxIn, &destroyTxIn},
+			txOut:   []*wire.TxOut{&issueTxOut, &destroyTxOut},
+			isValid: true,
+		},
+		{
+			name: "change to destroyed output",
+			txIn: []*wire.TxIn{&issueTxIn, &destroyTxIn},
+			txOut: []*wire.TxOut{&issueTxOut, &destroyTxOut,
+				{Value: 100000000, PkScript: holderPkScript}},
+			isValid: true,
+		},
+		{
+			name: "change to allowlisted destination",
+			txIn: []*wire.TxIn{&issueTxIn, &destroyTxIn},
+			txOut: []*wire.TxOut{&issueTxOut, &destroyTxOut,
+				{Value: 100000000, PkScript: destPkScript}},
+			isValid: true,
+		},
+		{
+			name: "change to other account",
+			txIn: []*wire.TxIn{&issueTxIn, &destroyTxIn},
+			txOut: []*wire.TxOut{&issueTxOut, &destroyTxOut,
+				{Value: 100000000, PkScript: otherPkScript}},
+			isValid: false,
+		},
+		{
+			name: "change to other account with asset marker",
+			txIn: []*wire.TxIn{&issueTxIn, &destroyTxIn},
+			txOut: []*wire.TxOut{&issueTxOut,
+				{Value: 0, PkScript: assetPkScript},
+				&destroyTxOut,
+				{Value: 100000000, PkScript: otherPkScript}},
+			isValid: false,
+		},
+		{
+			name: "change to other account before restriction",
+			txIn: []*wire.TxIn{&issueTxIn, &destroyTxIn},
+			txOut: []*wire.TxOut{&issueTxOut, &destroyTxOut,
+				{Value: 100000000, PkScript: otherPkScript}},
+			params:  &chaincfg.TestNetParams,
+			isValid: true,
+		},
+		{
+			name: "issuance to other account",
+			txIn: []*wire.TxIn{&issueTxIn},
+			txOut: []*wire.TxOut{&issueTxOut,
+				{Value: 100000000, PkScript: otherPkScript}},
+			isValid: true,
+		},
+	}
+
+	for _, test := range tests {
+		utxoView := blockchain.NewUtxoViewpoint()
+		utxoView.AddTxOuts(prevTx, 100)
+		utxoView.AddTxOuts(issueTipTx, 100)
+		keyView := blockchain.NewKeyViewpoint()
+		keyView.SetIssueDests(blockchain.IssueDestSet{dest: {}})
+		params := test.params
+		if params == nil {
+			params = &chaincfg.RegressionNetParams
+		}
+		tx := provautil.NewTx(&wire.MsgTx{
+			Version: 1,
+			TxIn:    test.txIn,
+			TxOut:   test.txOut,
+		})
+		err := blockchain.CheckIssueChange(tx, 200, utxoView, keyView,
+			params)
+		if test.isValid {
+			if err != nil {
+				t.Errorf("CheckIssueChange (%s): invalid when it "+
+					"should not be: %v", test.name, err)
+			}
+			continue
+		}
+		rerr, ok := err.(blockchain.RuleError)
+		if !ok || rerr.ErrorCode != blockchain.ErrIssueChangeNotAllowed {
+			t.Errorf("CheckIssueChange (%s): got error %v, want %v",
+				test.name, err, blockchain.ErrIssueChangeNotAllowed)
+		}
+	}
+}
+
+// TestCheckCoinbasePayee tests the CheckCoinbasePayee API.
+func TestCheckCoinbasePayee(t *testing.T) {
+	keyId1 := btcec.KeyID(1)
+	keyId2 := btcec.KeyID(2)
+	treasuryAddr, _ := provautil.NewAddressProva(make([]byte, 20), []btcec.KeyID{keyId1, keyId2}, &chaincfg.RegressionNetParams)
+	treasuryPkScript, _ := txscript.PayToAddrScript(treasuryAddr)
+	minerAddr, _ := provautil.NewAddressProva(bytes.Repeat([]byte{0x01}, 20), []btcec.KeyID{keyId1, keyId2}, &chaincfg.RegressionNetParams)
+	minerPkScript, _ := txscript.PayToAddrScript(minerAddr)
+	nullPkScript, _ := txscript.NullDataScript([]byte{0x01})
+
+	coinbaseTxIn := wire.TxIn{
+		PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{},
+			wire.MaxPrevOutIndex),
+		SignatureScript: []byte{0x51, 0x51},
+		Sequence:        wire.MaxTxInSequenceNum,
+	}
+	params := chaincfg.RegressionNetParams
+	params.CoinbasePkScript = treasuryPkScript
+
+	tests := []struct {
+		name    string
+		params  *chaincfg.Params
+		txOuts  []*wire.TxOut
+		isValid bool
+	}{
+		{
+			name:   "Pay to any script without coinbase script.",
+			params: &chaincfg.RegressionNetParams,
+			txOuts: []*wire.TxOut{
+				{Value: 5000000000, PkScript: minerPkScript},
+			},
+			isValid: true,
+		},
+		{
+			name:   "Pay to coinbase script.",
+			params: &params,
+			txOuts: []*wire.TxOut{
+				{Value: 4000000000, PkScript: treasuryPkScript},
+				{Value: 1000000000, PkScript: treasuryPkScript},
+			},
+			isValid: true,
+		},
+		{
+			name:   "Zero value output to other script.",
+			params: &params,
+			txOuts: []*wire.TxOut{
+				{Value: 5000000000, PkScript: treasuryPkScript},
+				{Value: 0, PkScript: nullPkScript},
+			},
+			isValid: true,
+		},
+		{
+			name:   "Pay fees to other script.",
+			params: &params,
+			txOuts: []*wire.TxOut{
+				{Value: 5000000000, PkScript: treasuryPkScript},
+				{Value: 1000, PkScript: minerPkScript},
+			},
+			isValid: false,
+		},
+	}
+
+	for _, test := range tests {
+		tx := provautil.NewTx(&wire.MsgTx{
+			Version: 1,
+			TxIn:    []*wire.TxIn{&coinbaseTxIn},
+			TxOut:   test.txOuts,
+		})
+		err := blockchain.CheckCoinbasePayee(tx, test.params)
+		if err == nil && test.isValid {
+			continue
+		}
+		if err == nil && !test.isValid {
+			t.Errorf("CheckCoinbasePayee (%s): valid when it should "+
+				"not be", test.name)
+			continue
+		}
+		if err != nil && test.isValid {
+			t.Errorf("CheckCoinbasePayee (%s): invalid when it "+
+				"should not be: %v", test.name, err)
+			continue
+		}
+
+		rerr, ok := err.(blockchain.RuleError)
+		if !ok || rerr.ErrorCode != blockchain.ErrBadCoinbasePayee {
+			t.Errorf("CheckCoinbasePayee (%s): unexpected error - "+
+				"got %v, want %v", test.name, err,
+				blockchain.ErrBadCoinbasePayee)
+		}
+	}
+}
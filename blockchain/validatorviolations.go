@@ -0,0 +1,370 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+var (
+	// validatorViolationsBucketName is the name of the db bucket used to
+	// house the evidence of validate keys which signed two blocks at the
+	// same height.
+	validatorViolationsBucketName = []byte("validatorviolations")
+)
+
+// signedBlocksWindow is the number of heights below the block being accepted
+// for which the blocks signed by each validate key are remembered in memory to
+// detect double signing on side chains.
+const signedBlocksWindow = 1000
+
+// ValidatorViolation is the evidence of a validate key which signed two
+// different blocks at the same height.  The evidence consists of the two signed
+// block headers, ordered by hash, so the signatures can be verified without
+// access to the blocks.  Since the signature of a header does not cover its
+// height, the heights are only established by the previous blocks the headers
+// commit to.
+type ValidatorViolation struct {
+	Time    time.Time
+	Headers [2]wire.BlockHeader
+}
+
+// newValidatorViolation returns the evidence of the validate key of the passed
+// headers signing both of them.
+func newValidatorViolation(now time.Time, header1, header2 *wire.BlockHeader) *ValidatorViolation {
+	v := &ValidatorViolation{
+		Time:    time.Unix(now.Unix(), 0),
+		Headers: [2]wire.BlockHeader{*header1, *header2},
+	}
+	hash1, hash2 := header1.BlockHash(), header2.BlockHash()
+	if bytes.Compare(hash1[:], hash2[:]) > 0 {
+		v.Headers[0], v.Headers[1] = v.Headers[1], v.Headers[0]
+	}
+	return v
+}
+
+// Height returns the height of the blocks signed twice.
+func (v *ValidatorViolation) Height() uint32 {
+	return v.Headers[0].Height
+}
+
+// ValidatingPubKey returns the validate key which signed both blocks.
+func (v *ValidatorViolation) ValidatingPubKey() wire.BlockValidatingPubKey {
+	return v.Headers[0].ValidatingPubKey
+}
+
+// signSameContent returns whether the parts of the passed headers covered by
+// their signatures are equal, in which case the headers only differ by fields
+// anybody can change without the validate key, such as the nonce.
+func signSameContent(header1, header2 *wire.BlockHeader) bool {
+	return header1.Version == header2.Version &&
+		header1.Timestamp.Equal(header2.Timestamp) &&
+		header1.PrevBlock == header2.PrevBlock &&
+		header1.MerkleRoot == header2.MerkleRoot
+}
+
+// Verify returns an error unless the headers of the evidence are two headers
+// claiming the same height whose signed contents differ, validly signed by the
+// same validate key.  The heights are not checked against the chain.
+func (v *ValidatorViolation) Verify() error {
+	header1, header2 := &v.Headers[0], &v.Headers[1]
+	if header1.Height != header2.Height {
+		return fmt.Errorf("blocks are at heights %d and %d",
+			header1.Height, header2.Height)
+	}
+	if header1.ValidatingPubKey != header2.ValidatingPubKey {
+		return errors.New("blocks are signed by different validate keys")
+	}
+	hash1, hash2 := header1.BlockHash(), header2.BlockHash()
+	if bytes.Compare(hash1[:], hash2[:]) >= 0 {
+		return errors.New("blocks are not distinct and ordered by hash")
+	}
+	if signSameContent(header1, header2) {
+		return errors.New("blocks only differ by fields which are not " +
+			"signed")
+	}
+	pubKey, err := btcec.ParsePubKey(header1.ValidatingPubKey[:],
+		btcec.S256())
+	if err != nil {
+		return fmt.Errorf("malformed validate key: %v", err)
+	}
+	// Either signature proves the key signed the header, whether or not
+	// Schnorr block signatures are active at its height.
+	for _, header := range v.Headers {
+		if !header.Verify(pubKey) && !header.VerifySchnorr(pubKey) {
+			return fmt.Errorf("block %v is not signed by validate "+
+				"key %v", header.BlockHash(), header.ValidatingPubKey)
+		}
+	}
+	return nil
+}
+
+// Evidence returns the serialization of the two signed block headers, which is
+// relayed to peers and parsed with ParseValidatorViolation.
+func (v *ValidatorViolation) Evidence() []byte {
+	var buf bytes.Buffer
+	for i := range v.Headers {
+		// Serializing to a bytes.Buffer can not fail.
+		v.Headers[i].Serialize(&buf)
+	}
+	return buf.Bytes()
+}
+
+// ParseValidatorViolation parses the passed evidence of a validate key which
+// signed two blocks at the same height, as returned by Evidence.  The time of
+// the returned violation is the current time.  The evidence is not verified.
+func ParseValidatorViolation(evidence []byte) (*ValidatorViolation, error) {
+	v := &ValidatorViolation{Time: time.Unix(time.Now().Unix(), 0)}
+	r := bytes.NewReader(evidence)
+	for i := range v.Headers {
+		if err := v.Headers[i].Deserialize(r); err != nil {
+			return nil, err
+		}
+	}
+	if r.Len() != 0 {
+		return nil, fmt.Errorf("%d bytes after the block headers",
+			r.Len())
+	}
+	return v, nil
+}
+
+// -----------------------------------------------------------------------------
+// The validator violations bucket holds an entry for each pair of blocks signed
+// by the same validate key at the same height.  The key of an entry is the
+// height of the blocks, serialized as a big endian uint32 so the entries are
+// iterated in height order, followed by the hashes of the two blocks.
+//
+// The serialized format of an entry is:
+//
+//   <time><header><header>
+//
+//   Field                 Type              Size
+//   time                  int64             8 bytes
+//   header                wire.BlockHeader  variable
+// -----------------------------------------------------------------------------
+
+// validatorViolationKey returns the key of the entry of the passed violation.
+func validatorViolationKey(v *ValidatorViolation) []byte {
+	key := make([]byte, 4+2*chainhash.HashSize)
+	byteOrder.PutUint32(key, v.Height())
+	for i := range v.Headers {
+		hash := v.Headers[i].BlockHash()
+		copy(key[4+i*chainhash.HashSize:], hash[:])
+	}
+	return key
+}
+
+// dbPutValidatorViolation uses an existing database transaction to store the
+// passed violation unless it is already stored, and returns whether it was
+// stored.  The bucket is created with the first entry.
+func dbPutValidatorViolation(dbTx database.Tx, v *ValidatorViolation) (bool, error) {
+	bucket, err := dbTx.Metadata().CreateBucketIfNotExists(
+		validatorViolationsBucketName)
+	if err != nil {
+		return false, err
+	}
+
+	key := validatorViolationKey(v)
+	if bucket.Get(key) != nil {
+		return false, nil
+	}
+	serialized := make([]byte, 8, 8+len(v.Evidence()))
+	byteOrder.PutUint64(serialized, uint64(v.Time.Unix()))
+	serialized = append(serialized, v.Evidence()...)
+	return true, bucket.Put(key, serialized)
+}
+
+// dbFetchValidatorViolations uses an existing database transaction to fetch up
+// to count violations, from the highest height down, after skipping the passed
+// number of them.
+func dbFetchValidatorViolations(dbTx database.Tx, numToSkip, count int) ([]*ValidatorViolation, error) {
+	bucket := dbTx.Metadata().Bucket(validatorViolationsBucketName)
+	if bucket == nil {
+		return nil, nil
+	}
+
+	var violations []*ValidatorViolation
+	cursor := bucket.Cursor()
+	for ok := cursor.Last(); ok && len(violations) < count; ok = cursor.Prev() {
+		if numToSkip > 0 {
+			numToSkip--
+			continue
+		}
+		serialized := cursor.Value()
+		if len(serialized) < 8 {
+			return nil, corruptionError("corrupt validator " +
+				"violation entry, unexpected end of data")
+		}
+		v, err := ParseValidatorViolation(serialized[8:])
+		if err != nil {
+			return nil, corruptionError("corrupt validator "+
+				"violation entry, %v", err)
+		}
+		v.Time = time.Unix(int64(byteOrder.Uint64(serialized)), 0)
+		violations = append(violations, v)
+	}
+	return violations, nil
+}
+
+// fetchHeader returns the header of the passed block from the block index, or
+// from the database when it is not in memory.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) fetchHeader(hash *chainhash.Hash) (*wire.BlockHeader, error) {
+	if node, ok := b.index[*hash]; ok {
+		header := node.Header()
+		return &header, nil
+	}
+	var header *wire.BlockHeader
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		header, err = dbFetchHeaderByHash(dbTx, hash)
+		return err
+	})
+	return header, err
+}
+
+// detectDoubleSign checks whether the validate key of the passed node, which is
+// being accepted, signed another block at the same height, and stores and
+// returns the evidence when it did.  The other block is either a block accepted
+// since the chain instance was created, or the main chain block at the height.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) detectDoubleSign(node *blockNode) (*ValidatorViolation, error) {
+	signers, ok := b.signedBlocks[node.height]
+	if !ok {
+		signers = make(map[wire.BlockValidatingPubKey]chainhash.Hash)
+		b.signedBlocks[node.height] = signers
+
+		// Forget the blocks which are too far below the accepted one.
+		if len(b.signedBlocks) > 2*signedBlocksWindow {
+			for height := range b.signedBlocks {
+				if height+signedBlocksWindow < node.height {
+					delete(b.signedBlocks, height)
+				}
+			}
+		}
+	}
+
+	otherHash, ok := signers[node.validatingPubKey]
+	if !ok {
+		signers[node.validatingPubKey] = *node.hash
+
+		// The main chain block at the height may have been accepted
+		// before the chain instance was created.
+		if node.height > b.bestNode.height {
+			return nil, nil
+		}
+		err := b.db.View(func(dbTx database.Tx) error {
+			hash, err := dbFetchHashByHeight(dbTx, node.height)
+			if err != nil {
+				return err
+			}
+			otherHash = *hash
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if otherHash == *node.hash {
+		return nil, nil
+	}
+	otherHeader, err := b.fetchHeader(&otherHash)
+	if err != nil {
+		return nil, err
+	}
+	header := node.Header()
+	if otherHeader.ValidatingPubKey != node.validatingPubKey ||
+		signSameContent(otherHeader, &header) {
+
+		return nil, nil
+	}
+
+	v := newValidatorViolation(time.Now(), otherHeader, &header)
+	err = b.db.Update(func(dbTx database.Tx) error {
+		_, err := dbPutValidatorViolation(dbTx, v)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Warnf("Validate key %v signed blocks %v and %v at height %d",
+		node.validatingPubKey, otherHash, node.hash, node.height)
+	return v, nil
+}
+
+// RecordValidatorViolation verifies and stores the passed evidence of a
+// validate key which signed two blocks at the same height, such as evidence
+// relayed by a peer, and returns whether it was not already stored.  Only the
+// evidence against a key of the current validate key set is recorded, and the
+// previous blocks of both headers must be known blocks at the height below
+// them.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) RecordValidatorViolation(v *ValidatorViolation) (bool, error) {
+	if err := v.Verify(); err != nil {
+		return false, err
+	}
+	if v.Height() == 0 {
+		return false, errors.New("blocks are at height 0")
+	}
+	b.chainLock.RLock()
+	for _, header := range v.Headers {
+		prevHeader, err := b.fetchHeader(&header.PrevBlock)
+		if err == nil && prevHeader.Height != header.Height-1 {
+			err = fmt.Errorf("previous block %v is at height %d",
+				header.PrevBlock, prevHeader.Height)
+		}
+		if err != nil {
+			b.chainLock.RUnlock()
+			return false, err
+		}
+	}
+	b.chainLock.RUnlock()
+
+	pubKey, err := btcec.ParsePubKey(v.Headers[0].ValidatingPubKey[:],
+		btcec.S256())
+	if err != nil {
+		return false, err
+	}
+	validateKeys := b.AdminKeySets()[btcec.ValidateKeySet]
+	if validateKeys.Pos(pubKey) < 0 {
+		return false, fmt.Errorf("%v is not a validate key",
+			v.ValidatingPubKey())
+	}
+
+	var stored bool
+	err = b.db.Update(func(dbTx database.Tx) error {
+		var err error
+		stored, err = dbPutValidatorViolation(dbTx, v)
+		return err
+	})
+	return stored, err
+}
+
+// ValidatorViolations returns up to count of the recorded violations of
+// validate keys which signed two blocks at the same height, from the highest
+// height down, after skipping the passed number of them.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ValidatorViolations(numToSkip, count int) ([]*ValidatorViolation, error) {
+	var violations []*ValidatorViolation
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		violations, err = dbFetchValidatorViolations(dbTx, numToSkip,
+			count)
+		return err
+	})
+	return violations, err
+}
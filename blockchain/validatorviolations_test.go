@@ -0,0 +1,231 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/database"
+	_ "github.com/pyx-partners/dmgd/database/ffldb"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// TestDetectDoubleSign ensures blocks signed by the same validate key at the
+// same height are detected, recorded and returned newest first, and that
+// relayed evidence is verified before it is recorded.
+func TestDetectDoubleSign(t *testing.T) {
+	dbPath, err := ioutil.TempDir("", "validatorviolations")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	db, err := database.Create("ffldb", filepath.Join(dbPath, "db"),
+		wire.TestNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer db.Close()
+	err = db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		if _, err := meta.CreateBucket(hashIndexBucketName); err != nil {
+			return err
+		}
+		_, err := meta.CreateBucket(heightIndexBucketName)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("CreateBucket: %v", err)
+	}
+
+	key, _ := btcec.PrivKeyFromBytes(btcec.S256(), []byte{0x01})
+	otherKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), []byte{0x02})
+	parent := &blockNode{hash: &chainhash.Hash{0x01}, height: 9,
+		parentHash: &chainhash.Hash{}}
+	newNode := func(height uint32, merkleRoot byte, signKey *btcec.PrivateKey) *blockNode {
+		header := wire.BlockHeader{
+			Version:    1,
+			PrevBlock:  *parent.hash,
+			MerkleRoot: chainhash.Hash{merkleRoot},
+			Timestamp:  time.Unix(1500000000, 0),
+			Height:     height,
+		}
+		if err := header.Sign(signKey); err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		hash := header.BlockHash()
+		return newBlockNode(&header, &hash)
+	}
+
+	// The main chain block at height 10 was accepted before the chain
+	// instance was created.
+	mainNode := newNode(10, 0x01, key)
+	mainNode.height = 10
+	chain := &BlockChain{
+		db:           db,
+		bestNode:     mainNode,
+		index:        map[chainhash.Hash]*blockNode{},
+		signedBlocks: make(map[uint32]map[wire.BlockValidatingPubKey]chainhash.Hash),
+		adminKeySets: map[btcec.KeySetType]btcec.PublicKeySet{
+			btcec.ValidateKeySet: {*key.PubKey()},
+		},
+	}
+	chain.index[*parent.hash] = parent
+	chain.index[*mainNode.hash] = mainNode
+	err = db.Update(func(dbTx database.Tx) error {
+		return dbPutBlockIndex(dbTx, mainNode.hash, 10)
+	})
+	if err != nil {
+		t.Fatalf("dbPutBlockIndex: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		node      *blockNode
+		violation bool
+	}{
+		{
+			name:      "other key at the main chain height",
+			node:      newNode(10, 0x02, otherKey),
+			violation: false,
+		},
+		{
+			name:      "same key at the main chain height",
+			node:      newNode(10, 0x03, key),
+			violation: true,
+		},
+		{
+			name:      "first block at a side chain height",
+			node:      newNode(11, 0x04, key),
+			violation: false,
+		},
+		{
+			name:      "second block at a side chain height",
+			node:      newNode(11, 0x05, key),
+			violation: true,
+		},
+	}
+	var recorded []*ValidatorViolation
+	for _, test := range tests {
+		test.node.height = test.node.Header().Height
+		v, err := chain.detectDoubleSign(test.node)
+		if err != nil {
+			t.Fatalf("%s: detectDoubleSign: %v", test.name, err)
+		}
+		chain.index[*test.node.hash] = test.node
+		if (v != nil) != test.violation {
+			t.Fatalf("%s: got violation %v, want %v", test.name,
+				v != nil, test.violation)
+		}
+		if v == nil {
+			continue
+		}
+		if err := v.Verify(); err != nil {
+			t.Fatalf("%s: Verify: %v", test.name, err)
+		}
+		recorded = append(recorded, v)
+	}
+
+	// A block which only differs from another by its nonce is not
+	// evidence, since the nonce is not signed.
+	regrind := tests[2].node.Header()
+	regrind.Nonce++
+	regrindHash := regrind.BlockHash()
+	v, err := chain.detectDoubleSign(newBlockNode(&regrind, &regrindHash))
+	if err != nil || v != nil {
+		t.Fatalf("detectDoubleSign: got violation %v and error %v for "+
+			"a different nonce, want none", v, err)
+	}
+
+	// The violations are returned from the highest height down, and their
+	// evidence parses back to the same headers.
+	violations, err := chain.ValidatorViolations(0, 10)
+	if err != nil {
+		t.Fatalf("ValidatorViolations: %v", err)
+	}
+	want := []*ValidatorViolation{recorded[1], recorded[0]}
+	if !reflect.DeepEqual(violations, want) {
+		t.Fatalf("ValidatorViolations: got %v, want %v", violations,
+			want)
+	}
+	violations, err = chain.ValidatorViolations(1, 10)
+	if err != nil || len(violations) != 1 ||
+		violations[0].Height() != 10 {
+
+		t.Fatalf("ValidatorViolations: got %v and error %v after "+
+			"skipping one, want the violation at height 10",
+			violations, err)
+	}
+	parsed, err := ParseValidatorViolation(recorded[0].Evidence())
+	if err != nil {
+		t.Fatalf("ParseValidatorViolation: %v", err)
+	}
+	if parsed.Headers != recorded[0].Headers {
+		t.Fatalf("ParseValidatorViolation: got headers %v, want %v",
+			parsed.Headers, recorded[0].Headers)
+	}
+
+	// Recorded evidence is not recorded again, and invalid evidence or
+	// evidence against other keys is rejected.
+	stored, err := chain.RecordValidatorViolation(parsed)
+	if err != nil || stored {
+		t.Fatalf("RecordValidatorViolation: got stored %v and error %v "+
+			"for recorded evidence, want not stored", stored, err)
+	}
+	header1, header2 := newNode(10, 0x06, key).Header(),
+		newNode(10, 0x07, key).Header()
+	relayed := newValidatorViolation(time.Now(), &header1, &header2)
+	stored, err = chain.RecordValidatorViolation(relayed)
+	if err != nil || !stored {
+		t.Fatalf("RecordValidatorViolation: got stored %v and error %v "+
+			"for new evidence, want stored", stored, err)
+	}
+	invalid := []struct {
+		name  string
+		alter func(v *ValidatorViolation)
+	}{
+		{
+			name: "different heights",
+			alter: func(v *ValidatorViolation) {
+				v.Headers[1].Height++
+			},
+		},
+		{
+			name: "unknown previous block",
+			alter: func(v *ValidatorViolation) {
+				v.Headers[0].Height++
+				v.Headers[1].Height++
+			},
+		},
+		{
+			name: "bad signature",
+			alter: func(v *ValidatorViolation) {
+				v.Headers[1].Timestamp = time.Unix(0, 0)
+			},
+		},
+	}
+	for _, test := range invalid {
+		v := *relayed
+		test.alter(&v)
+		if _, err := chain.RecordValidatorViolation(&v); err == nil {
+			t.Errorf("%s: RecordValidatorViolation succeeded",
+				test.name)
+		}
+	}
+	header1, header2 = newNode(10, 0x06, otherKey).Header(),
+		newNode(10, 0x07, otherKey).Header()
+	_, err = chain.RecordValidatorViolation(newValidatorViolation(
+		time.Now(), &header1, &header2))
+	if err == nil {
+		t.Errorf("RecordValidatorViolation succeeded for a key which " +
+			"is not a validate key")
+	}
+}
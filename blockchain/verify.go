@@ -0,0 +1,289 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// errInterruptRequested indicates that an operation was cancelled due to a
+// user-requested interrupt.
+var errInterruptRequested = errors.New("interrupt requested")
+
+// interruptRequested returns true when the provided channel has been closed.
+// This simplifies early shutdown slightly since the caller can just use an if
+// statement instead of a select.
+func interruptRequested(interrupted <-chan struct{}) bool {
+	select {
+	case <-interrupted:
+		return true
+	default:
+	}
+
+	return false
+}
+
+// corruptionError returns a database corruption error with the passed
+// formatted description.
+func corruptionError(format string, args ...interface{}) error {
+	return database.Error{
+		ErrorCode:   database.ErrCorruption,
+		Description: fmt.Sprintf(format, args...),
+	}
+}
+
+// genesisKeyViewpoint returns a key view holding the admin state of the chain
+// at the genesis block, which consists of the keys of the chain parameters and
+// the admin thread tips in the genesis coinbase.
+func (b *BlockChain) genesisKeyViewpoint() *KeyViewpoint {
+	coinbaseHash := b.chainParams.GenesisBlock.Transactions[0].TxHash()
+	keyView := NewKeyViewpoint()
+	keyView.SetKeys(b.chainParams.AdminKeySets)
+	keyView.SetKeyIDs(b.chainParams.ASPKeyIdMap)
+	keyView.SetThreadTips(map[provautil.ThreadID]*wire.OutPoint{
+		provautil.RootThread:      wire.NewOutPoint(&coinbaseHash, 0),
+		provautil.ProvisionThread: wire.NewOutPoint(&coinbaseHash, 1),
+		provautil.IssueThread:     wire.NewOutPoint(&coinbaseHash, 2),
+	})
+
+	// Set the last key id to the highest key id in the asp key map.
+	var lastKeyID btcec.KeyID
+	for keyID := range b.chainParams.ASPKeyIdMap {
+		if keyID > lastKeyID {
+			lastKeyID = keyID
+		}
+	}
+	keyView.SetLastKeyID(lastKeyID)
+	return keyView
+}
+
+// VerifyBlocks ensures every block in the main chain can be loaded from the
+// block files, matches the block index entries for its height, is well formed,
+// and connects to the block before it.  Closing the interrupt channel, which
+// may be nil, stops the verification early with an error.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) VerifyBlocks(interrupt <-chan struct{}) error {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	log.Infof("Verifying the block files and block index up to height %d",
+		b.bestNode.height)
+	var prevHash chainhash.Hash
+	for height := uint32(0); height <= b.bestNode.height; height++ {
+		if interruptRequested(interrupt) {
+			return errInterruptRequested
+		}
+
+		err := b.db.View(func(dbTx database.Tx) error {
+			hash, err := dbFetchHashByHeight(dbTx, height)
+			if err != nil {
+				return err
+			}
+			indexHeight, err := dbFetchHeightByHash(dbTx, hash)
+			if err != nil {
+				return err
+			}
+			if indexHeight != height {
+				return corruptionError("block index maps "+
+					"height %d to block %v, which it maps "+
+					"to height %d", height, hash,
+					indexHeight)
+			}
+
+			blockBytes, err := dbTx.FetchBlock(hash)
+			if err != nil {
+				return err
+			}
+			block, err := provautil.NewBlockFromBytes(blockBytes)
+			if err != nil {
+				return corruptionError("block %v at height %d "+
+					"does not deserialize: %v", hash,
+					height, err)
+			}
+
+			header := &block.MsgBlock().Header
+			if !block.Hash().IsEqual(hash) {
+				return corruptionError("block at height %d "+
+					"has hash %v instead of %v", height,
+					block.Hash(), hash)
+			}
+			if header.Height != height {
+				return corruptionError("block %v at height %d "+
+					"has height %d in its header", hash,
+					height, header.Height)
+			}
+			if height > 0 && header.PrevBlock != prevHash {
+				return corruptionError("block %v at height %d "+
+					"does not connect to block %v", hash,
+					height, prevHash)
+			}
+			merkles := BuildMerkleTreeStore(block.Transactions())
+			if !header.MerkleRoot.IsEqual(merkles[len(merkles)-1]) {
+				return corruptionError("block %v at height %d "+
+					"does not match its merkle root", hash,
+					height)
+			}
+
+			prevHash = *hash
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if prevHash != *b.bestNode.hash {
+		return corruptionError("block index ends at block %v instead of "+
+			"the best block %v", prevHash, b.bestNode.hash)
+	}
+
+	return nil
+}
+
+// VerifyKeyState ensures the admin state of the chain, which consists of the
+// admin key sets, ASP key IDs, admin thread tips, issuance destinations, frozen
+// accounts, supplies, key expiries and delegates, matches the state which results from replaying the
+// admin transactions of every block in the main chain.  Closing the interrupt
+// channel, which may be nil, stops the verification early with an error.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) VerifyKeyState(interrupt <-chan struct{}) error {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	log.Infof("Verifying the admin key state up to height %d",
+		b.bestNode.height)
+	keyView := b.genesisKeyViewpoint()
+	for height := uint32(1); height <= b.bestNode.height; height++ {
+		if interruptRequested(interrupt) {
+			return errInterruptRequested
+		}
+
+		var block *provautil.Block
+		err := b.db.View(func(dbTx database.Tx) error {
+			var err error
+			block, err = dbFetchBlockByHeight(dbTx, height)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		keyView.connectTransactions(block,
+			b.chainParams.MinValidateKeySetSize(),
+			b.chainParams)
+	}
+
+	b.stateLock.RLock()
+	defer b.stateLock.RUnlock()
+
+	keySets := keyView.Keys()
+	for keySetType, keySet := range b.adminKeySets {
+		if !keySet.Equal(keySets[keySetType]) {
+			return corruptionError("admin key set %d does not match "+
+				"the admin transactions", keySetType)
+		}
+	}
+	if len(keySets) != len(b.adminKeySets) {
+		return corruptionError("admin key sets do not match the admin " +
+			"transactions")
+	}
+	if !keyView.KeyIDs().Equal(b.aspKeyIdMap) {
+		return corruptionError("ASP key IDs do not match the admin " +
+			"transactions")
+	}
+	if keyView.LastKeyID() != b.lastKeyID {
+		return corruptionError("last key ID %d does not match %d from "+
+			"the admin transactions", b.lastKeyID,
+			keyView.LastKeyID())
+	}
+	threadTips := keyView.ThreadTips()
+	for threadID, tip := range b.threadTips {
+		if replayed := threadTips[threadID]; replayed == nil ||
+			*replayed != *tip {
+
+			return corruptionError("thread %d tip %v does not "+
+				"match the admin transactions", threadID, tip)
+		}
+	}
+	if len(threadTips) != len(b.threadTips) {
+		return corruptionError("admin thread tips do not match the " +
+			"admin transactions")
+	}
+	if keyView.TotalSupply() != b.totalSupply {
+		return corruptionError("total supply %d does not match %d "+
+			"from the admin transactions", b.totalSupply,
+			keyView.TotalSupply())
+	}
+	supplies := keyView.AssetSupplies()
+	for assetID, supply := range b.assetSupplies {
+		if supplies[assetID] != supply {
+			return corruptionError("supply %d of asset %d does "+
+				"not match the admin transactions", supply,
+				assetID)
+		}
+	}
+	if len(supplies) != len(b.assetSupplies) {
+		return corruptionError("asset supplies do not match the admin " +
+			"transactions")
+	}
+	issueDests := keyView.IssueDests()
+	for dest := range b.issueDests {
+		if _, ok := issueDests[dest]; !ok {
+			return corruptionError("issuance destination %x is not "+
+				"added by the admin transactions", dest)
+		}
+	}
+	if len(issueDests) != len(b.issueDests) {
+		return corruptionError("issuance destinations do not match " +
+			"the admin transactions")
+	}
+	frozen := keyView.Frozen()
+	for pkHash := range b.frozen {
+		if _, ok := frozen[pkHash]; !ok {
+			return corruptionError("frozen account %x is not "+
+				"frozen by the admin transactions", pkHash)
+		}
+	}
+	if len(frozen) != len(b.frozen) {
+		return corruptionError("frozen accounts do not match the " +
+			"admin transactions")
+	}
+	keyExpiries := keyView.KeyExpiries()
+	for outPoint, expiry := range b.keyExpiries {
+		if replayed, ok := keyExpiries[outPoint]; !ok ||
+			replayed != expiry {
+
+			return corruptionError("expiry of the key provisioned by "+
+				"%v does not match the admin transactions",
+				outPoint)
+		}
+	}
+	if len(keyExpiries) != len(b.keyExpiries) {
+		return corruptionError("key expiries do not match the admin " +
+			"transactions")
+	}
+	delegates := keyView.Delegates()
+	for pubKey, delegation := range b.delegates {
+		if replayed, ok := delegates[pubKey]; !ok ||
+			replayed != delegation {
+
+			return corruptionError("delegation of key %x does not "+
+				"match the admin transactions", pubKey)
+		}
+	}
+	if len(delegates) != len(b.delegates) {
+		return corruptionError("delegates do not match the admin " +
+			"transactions")
+	}
+
+	return nil
+}
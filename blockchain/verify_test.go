@@ -0,0 +1,114 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain_test
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/blockchain/indexers"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/txscript"
+)
+
+// TestVerify ensures the block files, block index, optional indexes and admin
+// key state of a healthy chain verify, and that corruption of the indexes is
+// detected.
+func TestVerify(t *testing.T) {
+	blocks := mainChainBlocks(t)
+	if len(blocks) < 2 {
+		t.Fatalf("got %d blocks extending the main chain, want 2",
+			len(blocks))
+	}
+
+	dir, err := ioutil.TempDir("", "verify")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := database.Create(testDbType, filepath.Join(dir, "db"),
+		blockDataNet)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	defer db.Close()
+
+	params := chaincfg.RegressionNetParams
+	indexManager := indexers.NewManager(db, []indexers.Indexer{
+		indexers.NewTxIndex(db), indexers.NewCommitmentIndex(db),
+	})
+	chain, err := blockchain.New(&blockchain.Config{
+		DB:           db,
+		ChainParams:  &params,
+		TimeSource:   blockchain.NewMedianTime(),
+		SigCache:     txscript.NewSigCache(1000),
+		IndexManager: indexManager,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	for _, block := range blocks {
+		_, _, err := chain.ProcessBlock(block, blockchain.BFNone)
+		if err != nil {
+			t.Fatalf("ProcessBlock %v: %v", block.Hash(), err)
+		}
+	}
+
+	if err := chain.VerifyBlocks(nil); err != nil {
+		t.Errorf("VerifyBlocks: %v", err)
+	}
+	if err := indexManager.Verify(chain, nil); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+	if err := chain.VerifyKeyState(nil); err != nil {
+		t.Errorf("VerifyKeyState: %v", err)
+	}
+
+	// Verification stops when interrupted.
+	interrupt := make(chan struct{})
+	close(interrupt)
+	if err := chain.VerifyBlocks(interrupt); err == nil {
+		t.Errorf("VerifyBlocks: expected error when interrupted")
+	}
+
+	// isCorruption returns whether the passed error is a database
+	// corruption error.
+	isCorruption := func(err error) bool {
+		dbErr, ok := err.(database.Error)
+		return ok && dbErr.ErrorCode == database.ErrCorruption
+	}
+
+	// Remove the transaction index entry of the last coinbase.
+	err = db.Update(func(dbTx database.Tx) error {
+		txIndex := dbTx.Metadata().Bucket([]byte("txbyhashidx"))
+		coinbase := blocks[len(blocks)-1].Transactions()[0]
+		return txIndex.Delete(coinbase.Hash()[:])
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := indexManager.Verify(chain, nil); !isCorruption(err) {
+		t.Errorf("Verify: got %v, want corruption error", err)
+	}
+
+	// Map the first height to the hash of the second block.
+	err = db.Update(func(dbTx database.Tx) error {
+		heightIndex := dbTx.Metadata().Bucket([]byte("heightidx"))
+		var serializedHeight [4]byte
+		binary.LittleEndian.PutUint32(serializedHeight[:], 1)
+		return heightIndex.Put(serializedHeight[:], blocks[1].Hash()[:])
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := chain.VerifyBlocks(nil); !isCorruption(err) {
+		t.Errorf("VerifyBlocks: got %v, want corruption error", err)
+	}
+}
@@ -55,6 +55,17 @@ func BenchmarkScalarBaseMult(b *testing.B) {
 	}
 }
 
+// BenchmarkScalarBaseMultSecret benchmarks the secp256k1 curve base point
+// multiplication used for secret scalars, which selects the precomputed points
+// in constant time.
+func BenchmarkScalarBaseMultSecret(b *testing.B) {
+	k := fromHex("d74bf844b0862475103d96a611cf2d898447e288d34b360bc885cb8ce7c00575")
+	curve := S256()
+	for i := 0; i < b.N; i++ {
+		curve.scalarBaseMultSecret(k.Bytes())
+	}
+}
+
 // BenchmarkScalarBaseMultLarge benchmarks the secp256k1 curve ScalarBaseMult
 // function with abnormally large k values.
 func BenchmarkScalarBaseMultLarge(b *testing.B) {
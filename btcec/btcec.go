@@ -23,6 +23,7 @@ package btcec
 
 import (
 	"crypto/elliptic"
+	"crypto/subtle"
 	"math/big"
 	"sync"
 )
@@ -880,6 +881,60 @@ func (curve *KoblitzCurve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
 	return curve.fieldJacobianToBigAffine(qx, qy, qz)
 }
 
+// selectBytePoint sets p to the precomputed byte point of the passed byte value
+// within the passed window.  Every point in the window is read and
+// conditionally copied, so the memory accessed does not depend on the byte
+// value.
+func selectBytePoint(p *[3]fieldVal, window *[256][3]fieldVal, byteVal byte) {
+	for i := range window {
+		mask := -uint32(subtle.ConstantTimeByteEq(uint8(i), byteVal))
+		for j := range p {
+			for k := range p[j].n {
+				p[j].n[k] = p[j].n[k]&^mask | window[i][j].n[k]&mask
+			}
+		}
+	}
+}
+
+// scalarBaseMultSecret returns k*G like ScalarBaseMult, but is meant for secret
+// scalars such as private keys and signature nonces.  The scalar is padded to
+// the full size of the group order so the number of windows does not reveal
+// its leading zero bytes, and the precomputed byte points are selected in
+// constant time so the memory accessed does not depend on the scalar.
+//
+// NOTE: The Jacobian additions still take a shortcut for the point at infinity,
+// which is selected for the windows where the scalar has a zero byte.
+func (curve *KoblitzCurve) scalarBaseMultSecret(k []byte) (*big.Int, *big.Int) {
+	var paddedK [32]byte
+	newK := curve.moduloReduce(k)
+	copy(paddedK[len(paddedK)-len(newK):], newK)
+
+	// Point Q = ∞ (point at infinity).
+	qx, qy, qz := new(fieldVal), new(fieldVal), new(fieldVal)
+	var p [3]fieldVal
+	for i, byteVal := range paddedK {
+		selectBytePoint(&p, &curve.bytePoints[i], byteVal)
+		curve.addJacobian(qx, qy, qz, &p[0], &p[1], &p[2], qx, qy, qz)
+	}
+
+	// Clear the copies of the secret scalar and the last selected point.
+	for i := range paddedK {
+		paddedK[i] = 0
+	}
+	p = [3]fieldVal{}
+	return curve.fieldJacobianToBigAffine(qx, qy, qz)
+}
+
+// secretScalarBaseMult returns k*G on the passed curve for a secret scalar k.
+// The precomputed points are selected in constant time when the curve is a
+// KoblitzCurve.  See scalarBaseMultSecret for details.
+func secretScalarBaseMult(curve elliptic.Curve, k []byte) (*big.Int, *big.Int) {
+	if koblitzCurve, ok := curve.(*KoblitzCurve); ok {
+		return koblitzCurve.scalarBaseMultSecret(k)
+	}
+	return curve.ScalarBaseMult(k)
+}
+
 // QPlus1Div4 returns the Q+1/4 constant for the curve for use in calculating
 // square roots via exponention.
 func (curve *KoblitzCurve) QPlus1Div4() *big.Int {
@@ -19,5 +19,19 @@ functionality is provided to parse signatures and public keys from
 standard formats.  It was designed for use with btcd, but should be
 general enough for other uses of elliptic curve crypto.  It was originally based
 on some initial work by ThePiachu, but has significantly diverged since then.
+
+Private Key Handling
+
+Multiplications of the base point by secret scalars, which are deriving the
+public key of a private key, generating a private key and computing the nonce
+point of a signature, pad the scalar to its full size and select the
+precomputed points in constant time so the memory accessed does not depend on
+the scalar.  Other operations on private keys, such as the arithmetic of
+signatures and the scalar multiplication of GenerateSharedSecret, are not
+constant time.
+
+Since validate and issue keys are long-lived secrets, PrivateKey provides a
+Zero method which clears the private key number from memory once a key is no
+longer needed.
 */
 package btcec
@@ -10,6 +10,7 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"io"
 	"math/big"
 )
 
@@ -19,10 +20,12 @@ import (
 type PrivateKey ecdsa.PrivateKey
 
 // PrivKeyFromBytes returns a private and public key for `curve' based on the
-// private key passed as an argument as a byte slice.
+// private key passed as an argument as a byte slice.  The public key is derived
+// with constant-time point selection on the secp256k1 curve.  The passed bytes
+// are not referenced by the returned key, so the caller may zero them.
 func PrivKeyFromBytes(curve elliptic.Curve, pk []byte) (*PrivateKey,
 	*PublicKey) {
-	x, y := curve.ScalarBaseMult(pk)
+	x, y := secretScalarBaseMult(curve, pk)
 
 	priv := &ecdsa.PrivateKey{
 		PublicKey: ecdsa.PublicKey{
@@ -37,13 +40,38 @@ func PrivKeyFromBytes(curve elliptic.Curve, pk []byte) (*PrivateKey,
 }
 
 // NewPrivateKey is a wrapper for ecdsa.GenerateKey that returns a PrivateKey
-// instead of the normal ecdsa.PrivateKey.
+// instead of the normal ecdsa.PrivateKey.  On the secp256k1 curve, the key is
+// generated the same way, but the public key is derived with constant-time
+// point selection.
 func NewPrivateKey(curve elliptic.Curve) (*PrivateKey, error) {
-	key, err := ecdsa.GenerateKey(curve, rand.Reader)
-	if err != nil {
+	if _, ok := curve.(*KoblitzCurve); !ok {
+		key, err := ecdsa.GenerateKey(curve, rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return (*PrivateKey)(key), nil
+	}
+
+	// Generate a random scalar in [1, N-1] from 64 extra bits of entropy
+	// as described in FIPS 186-4 appendix B.4.1, the same as the ecdsa
+	// package does.
+	params := curve.Params()
+	b := make([]byte, params.BitSize/8+8)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
 		return nil, err
 	}
-	return (*PrivateKey)(key), nil
+	d := new(big.Int).SetBytes(b)
+	n := new(big.Int).Sub(params.N, one)
+	d.Mod(d, n)
+	d.Add(d, one)
+	zeroBytes(b)
+
+	pk := paddedAppend(PrivKeyBytesLen, make([]byte, 0, PrivKeyBytesLen),
+		d.Bytes())
+	zeroBigInt(d)
+	key, _ := PrivKeyFromBytes(curve, pk)
+	zeroBytes(pk)
+	return key, nil
 }
 
 // PubKey returns the PublicKey corresponding to this private key.
@@ -73,3 +101,29 @@ func (p *PrivateKey) Serialize() []byte {
 	b := make([]byte, 0, PrivKeyBytesLen)
 	return paddedAppend(PrivKeyBytesLen, b, p.ToECDSA().D.Bytes())
 }
+
+// Zero clears the private key number d from memory.  The key must not be used
+// afterwards.  It is meant for long-lived keys, such as validate and issue
+// keys, which should not linger in memory once they are no longer needed.
+func (p *PrivateKey) Zero() {
+	if p.D != nil {
+		zeroBigInt(p.D)
+	}
+}
+
+// zeroBytes sets all of the passed bytes to zero.
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// zeroBigInt clears the words backing the passed big integer and sets it to
+// zero.
+func zeroBigInt(n *big.Int) {
+	words := n.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	n.SetInt64(0)
+}
@@ -8,6 +8,8 @@ package btcec
 
 import (
 	"bytes"
+	"crypto/rand"
+	"math/big"
 	"testing"
 )
 
@@ -55,3 +57,59 @@ func TestPrivKeys(t *testing.T) {
 		}
 	}
 }
+
+// TestScalarBaseMultSecret ensures multiplying the base point by a secret
+// scalar with constant-time point selection matches ScalarBaseMult.
+func TestScalarBaseMultSecret(t *testing.T) {
+	curve := S256()
+	scalars := [][]byte{
+		{0x01},
+		{0x00, 0x00, 0xff},
+		curve.N.Bytes(),
+		new(big.Int).Sub(curve.N, one).Bytes(),
+		bytes.Repeat([]byte{0xff}, 40),
+	}
+	for i := 0; i < 20; i++ {
+		scalar := make([]byte, 32)
+		if _, err := rand.Read(scalar); err != nil {
+			t.Fatalf("rand.Read: %v", err)
+		}
+		scalars = append(scalars, scalar)
+	}
+
+	for i, scalar := range scalars {
+		wantX, wantY := curve.ScalarBaseMult(scalar)
+		x, y := curve.scalarBaseMultSecret(scalar)
+		if x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 {
+			t.Errorf("#%d: got (%x, %x), want (%x, %x)", i, x, y,
+				wantX, wantY)
+		}
+	}
+}
+
+// TestPrivKeyZero ensures generated keys are valid and zeroing a key clears its
+// private key number.
+func TestPrivKeyZero(t *testing.T) {
+	priv, err := NewPrivateKey(S256())
+	if err != nil {
+		t.Fatalf("NewPrivateKey: %v", err)
+	}
+	if priv.D.Sign() <= 0 || priv.D.Cmp(S256().N) >= 0 {
+		t.Fatalf("generated private key %x is out of range", priv.D)
+	}
+	_, pub := PrivKeyFromBytes(S256(), priv.Serialize())
+	if !pub.IsEqual(priv.PubKey()) {
+		t.Fatalf("generated public key does not match private key")
+	}
+
+	words := priv.D.Bits()
+	priv.Zero()
+	if priv.D.Sign() != 0 {
+		t.Fatalf("got private key %x after zeroing", priv.D)
+	}
+	for i, word := range words {
+		if word != 0 {
+			t.Fatalf("word %d of private key not cleared", i)
+		}
+	}
+}
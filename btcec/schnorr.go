@@ -0,0 +1,141 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcec
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// SchnorrSignatureSize is the number of bytes of a serialized Schnorr
+// signature: the compressed nonce point R followed by the scalar s.
+const SchnorrSignatureSize = PubKeyBytesLenCompressed + 32
+
+var (
+	// schnorrChallengeTag and schnorrNonceTag separate the hashes of the
+	// Schnorr scheme from any other use of the same data.
+	schnorrChallengeTag = []byte("DMG/schnorr/challenge")
+	schnorrNonceTag     = []byte("DMG/schnorr/nonce")
+)
+
+// SchnorrSignature is a Schnorr signature (R, s) over secp256k1 which is
+// valid for the public key P and the hash m when s*G = R + e*P, where the
+// challenge e is the hash of R, P and m.  Unlike ECDSA signatures, Schnorr
+// signatures of several signers add up, so a group of signers holding shares
+// of a key can jointly produce a signature for it, see ThresholdKey.
+type SchnorrSignature struct {
+	R *PublicKey
+	S *big.Int
+}
+
+// newPoint returns the public key of the passed affine coordinates.
+func newPoint(x, y *big.Int) *PublicKey {
+	return &PublicKey{Curve: S256(), X: x, Y: y}
+}
+
+// isInfinity returns whether the passed point is the point at infinity.
+func isInfinity(p *PublicKey) bool {
+	return p.X.Sign() == 0 && p.Y.Sign() == 0
+}
+
+// addPoints returns the sum of the passed points.
+func addPoints(a, b *PublicKey) *PublicKey {
+	return newPoint(S256().Add(a.X, a.Y, b.X, b.Y))
+}
+
+// scalarMult returns the passed point multiplied by the passed scalar.
+func scalarMult(p *PublicKey, k *big.Int) *PublicKey {
+	return newPoint(S256().ScalarMult(p.X, p.Y, new(big.Int).Mod(k,
+		order).Bytes()))
+}
+
+// scalarBaseMult returns the base point multiplied by the passed scalar.  It
+// is only meant for public scalars, such as when verifying signatures.
+func scalarBaseMult(k *big.Int) *PublicKey {
+	return newPoint(S256().ScalarBaseMult(new(big.Int).Mod(k,
+		order).Bytes()))
+}
+
+// secretBaseMult returns the base point multiplied by the passed secret
+// scalar, such as a nonce, selecting the precomputed points in constant time.
+func secretBaseMult(k *big.Int) *PublicKey {
+	return newPoint(secretScalarBaseMult(S256(), k.Bytes()))
+}
+
+// taggedHash returns the sha256 hash of the passed tag followed by the passed
+// data as a scalar.
+func taggedHash(tag []byte, data ...[]byte) *big.Int {
+	h := sha256.New()
+	h.Write(tag)
+	for _, d := range data {
+		h.Write(d)
+	}
+	e := new(big.Int).SetBytes(h.Sum(nil))
+	return e.Mod(e, order)
+}
+
+// schnorrChallenge returns the challenge of a Schnorr signature with the
+// passed nonce point for the passed public key and hash.
+func schnorrChallenge(r, pubKey *PublicKey, hash []byte) *big.Int {
+	return taggedHash(schnorrChallengeTag, r.SerializeCompressed(),
+		pubKey.SerializeCompressed(), hash)
+}
+
+// SignSchnorr generates a Schnorr signature of the passed hash with the
+// private key.  The nonce is derived deterministically from the key and the
+// hash as in RFC 6979, but from a tagged hash, so it never matches the nonce
+// of an ECDSA signature of the same hash.
+func (p *PrivateKey) SignSchnorr(hash []byte) (*SchnorrSignature, error) {
+	nonceHash := sha256.Sum256(append(append([]byte{}, schnorrNonceTag...),
+		hash...))
+	k := nonceRFC6979(p.D, nonceHash[:])
+	r := secretBaseMult(k)
+	if isInfinity(r) {
+		return nil, errors.New("calculated R is infinity")
+	}
+	e := schnorrChallenge(r, p.PubKey(), hash)
+	s := new(big.Int).Mul(e, p.D)
+	s.Add(s, k)
+	s.Mod(s, order)
+	return &SchnorrSignature{R: r, S: s}, nil
+}
+
+// Verify returns whether the signature is a valid signature of the passed
+// hash by the passed public key.
+func (sig *SchnorrSignature) Verify(hash []byte, pubKey *PublicKey) bool {
+	if sig.S.Sign() < 0 || sig.S.Cmp(order) >= 0 || isInfinity(sig.R) {
+		return false
+	}
+	e := schnorrChallenge(sig.R, pubKey, hash)
+	left := scalarBaseMult(sig.S)
+	right := addPoints(sig.R, scalarMult(pubKey, e))
+	return left.IsEqual(right)
+}
+
+// Serialize returns the signature as the compressed nonce point followed by
+// the 32-byte big-endian scalar.
+func (sig *SchnorrSignature) Serialize() []byte {
+	b := make([]byte, 0, SchnorrSignatureSize)
+	b = append(b, sig.R.SerializeCompressed()...)
+	return paddedAppend(32, b, sig.S.Bytes())
+}
+
+// ParseSchnorrSignature parses a Schnorr signature serialized with Serialize.
+func ParseSchnorrSignature(sigStr []byte) (*SchnorrSignature, error) {
+	if len(sigStr) != SchnorrSignatureSize {
+		return nil, errors.New("malformed schnorr signature: wrong size")
+	}
+	r, err := ParsePubKey(sigStr[:PubKeyBytesLenCompressed], S256())
+	if err != nil {
+		return nil, err
+	}
+	s := new(big.Int).SetBytes(sigStr[PubKeyBytesLenCompressed:])
+	if s.Cmp(order) >= 0 {
+		return nil, errors.New("malformed schnorr signature: s is not " +
+			"below the curve order")
+	}
+	return &SchnorrSignature{R: r, S: s}, nil
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcec_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/btcec"
+)
+
+// TestSchnorrSignature ensures Schnorr signatures are deterministic, verify
+// for their key and hash only, and survive their serialization.
+func TestSchnorrSignature(t *testing.T) {
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), []byte{0x01, 0x02})
+	otherKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), []byte{0x03})
+	hash := sha256.Sum256([]byte("block"))
+	otherHash := sha256.Sum256([]byte("other block"))
+
+	sig, err := privKey.SignSchnorr(hash[:])
+	if err != nil {
+		t.Fatalf("SignSchnorr: %v", err)
+	}
+	again, err := privKey.SignSchnorr(hash[:])
+	if err != nil {
+		t.Fatalf("SignSchnorr: %v", err)
+	}
+	if !bytes.Equal(sig.Serialize(), again.Serialize()) {
+		t.Fatalf("signatures of the same hash differ")
+	}
+	if !sig.Verify(hash[:], privKey.PubKey()) {
+		t.Fatalf("signature does not verify")
+	}
+	if sig.Verify(otherHash[:], privKey.PubKey()) {
+		t.Fatalf("signature verifies for another hash")
+	}
+	if sig.Verify(hash[:], otherKey.PubKey()) {
+		t.Fatalf("signature verifies for another key")
+	}
+
+	serialized := sig.Serialize()
+	if len(serialized) != btcec.SchnorrSignatureSize {
+		t.Fatalf("serialized signature is %d bytes, want %d",
+			len(serialized), btcec.SchnorrSignatureSize)
+	}
+	parsed, err := btcec.ParseSchnorrSignature(serialized)
+	if err != nil {
+		t.Fatalf("ParseSchnorrSignature: %v", err)
+	}
+	if !parsed.Verify(hash[:], privKey.PubKey()) {
+		t.Fatalf("parsed signature does not verify")
+	}
+	if _, err := btcec.ParseSchnorrSignature(serialized[1:]); err == nil {
+		t.Fatalf("ParseSchnorrSignature: parsed truncated signature")
+	}
+	serialized[btcec.PubKeyBytesLenCompressed] ^= 0x01
+	parsed, err = btcec.ParseSchnorrSignature(serialized)
+	if err == nil && parsed.Verify(hash[:], privKey.PubKey()) {
+		t.Fatalf("altered signature verifies")
+	}
+}
@@ -425,7 +425,7 @@ func signRFC6979(privateKey *PrivateKey, hash []byte) (*Signature, error) {
 	N := order
 	k := nonceRFC6979(privkey.D, hash)
 	inv := new(big.Int).ModInverse(k, N)
-	r, _ := privkey.Curve.ScalarBaseMult(k.Bytes())
+	r, _ := secretScalarBaseMult(privkey.Curve, k.Bytes())
 	if r.Cmp(N) == 1 {
 		r.Sub(r, N)
 	}
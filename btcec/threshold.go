@@ -0,0 +1,447 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcec
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+var (
+	// thresholdBindingTag separates the binding factors of threshold
+	// signing sessions from any other hash of the same data.
+	thresholdBindingTag = []byte("DMG/threshold/binding")
+
+	// ErrSessionUsed is returned when a signer session signs more than
+	// once, which would leak the key share of the signer.
+	ErrSessionUsed = errors.New("signer session already signed")
+)
+
+// randScalar returns a random non-zero scalar.
+func randScalar() (*big.Int, error) {
+	for {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		k := new(big.Int).SetBytes(b)
+		zeroBytes(b)
+		if k.Sign() != 0 && k.Cmp(order) < 0 {
+			return k, nil
+		}
+	}
+}
+
+// lagrangeCoeff returns the Lagrange coefficient at zero of the participant
+// with the passed index among the participants with the passed indexes.
+func lagrangeCoeff(index uint16, indexes []uint16) *big.Int {
+	num, den := big.NewInt(1), big.NewInt(1)
+	x := big.NewInt(int64(index))
+	for _, other := range indexes {
+		if other == index {
+			continue
+		}
+		j := big.NewInt(int64(other))
+		num.Mul(num, j)
+		num.Mod(num, order)
+		den.Mul(den, new(big.Int).Sub(j, x))
+		den.Mod(den, order)
+	}
+	return num.Mul(num, den.ModInverse(den, order)).Mod(num, order)
+}
+
+// evalCommitments returns the commitment to the value at the passed index of
+// the polynomial committed to by the passed coefficient commitments.
+func evalCommitments(commitments []*PublicKey, index uint16) *PublicKey {
+	x := big.NewInt(int64(index))
+	power := big.NewInt(1)
+	sum := newPoint(new(big.Int), new(big.Int))
+	for _, c := range commitments {
+		sum = addPoints(sum, scalarMult(c, power))
+		power.Mul(power, x)
+		power.Mod(power, order)
+	}
+	return sum
+}
+
+// ThresholdKeyGen is one participant of the distributed generation of a key
+// shared by a group of count participants, any threshold of which can sign
+// for it.  No participant ever learns the private key of the group.
+//
+// Every participant deals a random polynomial of degree threshold-1: it
+// publishes commitments to the coefficients with Commitments, and sends the
+// value of the polynomial at the index of every other participant to it,
+// privately, with ShareFor.  Each participant checks the shares it receives
+// against the commitments of their dealer with AddShare, and derives its key
+// share and the public key of the group with Finish once it has received the
+// shares of all participants.  The public key of the group only depends on the
+// commitments, so all participants derive the same key.
+type ThresholdKeyGen struct {
+	index       uint16
+	threshold   int
+	count       int
+	coeffs      []*big.Int
+	commitments []*PublicKey
+	shares      map[uint16]*big.Int
+	dealt       map[uint16][]*PublicKey
+}
+
+// NewThresholdKeyGen returns the participant with the passed index, from 1 to
+// count, of the generation of a threshold out of count key.
+func NewThresholdKeyGen(index uint16, threshold, count int) (*ThresholdKeyGen, error) {
+	if threshold < 1 || threshold > count || int(index) < 1 ||
+		int(index) > count {
+
+		return nil, fmt.Errorf("invalid participant %d of a %d of %d "+
+			"key", index, threshold, count)
+	}
+	g := &ThresholdKeyGen{
+		index:     index,
+		threshold: threshold,
+		count:     count,
+		shares:    make(map[uint16]*big.Int, count),
+		dealt:     make(map[uint16][]*PublicKey, count),
+	}
+	for i := 0; i < threshold; i++ {
+		coeff, err := randScalar()
+		if err != nil {
+			return nil, err
+		}
+		g.coeffs = append(g.coeffs, coeff)
+		g.commitments = append(g.commitments, secretBaseMult(coeff))
+	}
+	// The participant deals a share to itself as well.
+	g.shares[index] = g.evalPolynomial(index)
+	g.dealt[index] = g.commitments
+	return g, nil
+}
+
+// evalPolynomial returns the value of the polynomial of the participant at
+// the passed index.
+func (g *ThresholdKeyGen) evalPolynomial(index uint16) *big.Int {
+	x := big.NewInt(int64(index))
+	value := new(big.Int)
+	for i := len(g.coeffs) - 1; i >= 0; i-- {
+		value.Mul(value, x)
+		value.Add(value, g.coeffs[i])
+		value.Mod(value, order)
+	}
+	return value
+}
+
+// Commitments returns the commitments to the coefficients of the polynomial
+// of the participant, which are published to all other participants.
+func (g *ThresholdKeyGen) Commitments() []*PublicKey {
+	return g.commitments
+}
+
+// ShareFor returns the share the participant deals to the participant with
+// the passed index.  It must be sent to that participant only.
+func (g *ThresholdKeyGen) ShareFor(index uint16) []byte {
+	return paddedAppend(32, nil, g.evalPolynomial(index).Bytes())
+}
+
+// AddShare records the share dealt to the participant by the participant with
+// the passed index, after checking it against the commitments published by
+// the dealer.
+func (g *ThresholdKeyGen) AddShare(from uint16, share []byte,
+	commitments []*PublicKey) error {
+
+	if int(from) < 1 || int(from) > g.count || from == g.index {
+		return fmt.Errorf("share from invalid participant %d", from)
+	}
+	if _, ok := g.shares[from]; ok {
+		return fmt.Errorf("share from participant %d added already",
+			from)
+	}
+	if len(commitments) != g.threshold {
+		return fmt.Errorf("participant %d committed to %d "+
+			"coefficients, want %d", from, len(commitments),
+			g.threshold)
+	}
+	value := new(big.Int).SetBytes(share)
+	if len(share) != 32 || value.Cmp(order) >= 0 ||
+		!scalarBaseMult(value).IsEqual(evalCommitments(commitments,
+			g.index)) {
+
+		return fmt.Errorf("share from participant %d does not match "+
+			"its commitments", from)
+	}
+	g.shares[from] = value
+	g.dealt[from] = commitments
+	return nil
+}
+
+// Finish returns the key share of the participant once it has received the
+// shares of all participants.  The polynomial of the participant is erased.
+func (g *ThresholdKeyGen) Finish() (*ThresholdKey, error) {
+	if len(g.shares) != g.count {
+		return nil, fmt.Errorf("received shares of %d of %d "+
+			"participants", len(g.shares), g.count)
+	}
+
+	// The polynomial of the group is the sum of the polynomials of all
+	// participants, so are its commitments.
+	groupCommitments := make([]*PublicKey, g.threshold)
+	for i := range groupCommitments {
+		groupCommitments[i] = newPoint(new(big.Int), new(big.Int))
+	}
+	share := new(big.Int)
+	for from, value := range g.shares {
+		share.Add(share, value)
+		for i, c := range g.dealt[from] {
+			groupCommitments[i] = addPoints(groupCommitments[i], c)
+		}
+	}
+	share.Mod(share, order)
+	if share.Sign() == 0 || isInfinity(groupCommitments[0]) {
+		return nil, errors.New("degenerate key share")
+	}
+
+	key := &ThresholdKey{
+		Index:              g.index,
+		Threshold:          g.threshold,
+		Share:              share,
+		PubKey:             groupCommitments[0],
+		VerificationShares: make(map[uint16]*PublicKey, g.count),
+	}
+	for i := 1; i <= g.count; i++ {
+		key.VerificationShares[uint16(i)] = evalCommitments(
+			groupCommitments, uint16(i))
+	}
+	for _, coeff := range g.coeffs {
+		zeroBigInt(coeff)
+	}
+	return key, nil
+}
+
+// ThresholdKey is the share of one participant of a key shared by a group of
+// signers, any Threshold of which produce Schnorr signatures for PubKey.  The
+// verification shares are the public keys of the shares of all participants,
+// by index, which check the signature shares of the signers.
+type ThresholdKey struct {
+	Index              uint16
+	Threshold          int
+	Share              *big.Int
+	PubKey             *PublicKey
+	VerificationShares map[uint16]*PublicKey
+}
+
+// SigningCommitment is the commitment of a signer to the two nonces of its
+// signer session, which is sent to the other signers in the first round of a
+// threshold signature.
+type SigningCommitment struct {
+	Index uint16
+	D     *PublicKey
+	E     *PublicKey
+}
+
+// SignatureShare is the share of a signer of a threshold signature, which is
+// sent to the aggregator in the second round of a threshold signature.
+type SignatureShare struct {
+	Index uint16
+	Z     *big.Int
+}
+
+// signingGroup holds the values derived from the commitments of the signers
+// of a threshold signature which all signers and the aggregator agree on.
+type signingGroup struct {
+	indexes   []uint16
+	bindings  map[uint16]*big.Int
+	r         *PublicKey
+	challenge *big.Int
+}
+
+// newSigningGroup derives the binding factors of the signers, the nonce point
+// and the challenge of a threshold signature of the passed hash by the passed
+// commitments for the passed group public key.
+func newSigningGroup(pubKey *PublicKey, hash []byte,
+	commitments []SigningCommitment) (*signingGroup, error) {
+
+	sorted := make([]SigningCommitment, len(commitments))
+	copy(sorted, commitments)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Index < sorted[j].Index
+	})
+	encoded := make([]byte, 0, len(sorted)*(2+2*PubKeyBytesLenCompressed))
+	indexes := make([]uint16, len(sorted))
+	for i, c := range sorted {
+		if i > 0 && c.Index == sorted[i-1].Index {
+			return nil, fmt.Errorf("duplicate commitment of signer %d",
+				c.Index)
+		}
+		indexes[i] = c.Index
+		var index [2]byte
+		binary.BigEndian.PutUint16(index[:], c.Index)
+		encoded = append(encoded, index[:]...)
+		encoded = append(encoded, c.D.SerializeCompressed()...)
+		encoded = append(encoded, c.E.SerializeCompressed()...)
+	}
+
+	group := &signingGroup{
+		indexes:  indexes,
+		bindings: make(map[uint16]*big.Int, len(sorted)),
+		r:        newPoint(new(big.Int), new(big.Int)),
+	}
+	for _, c := range sorted {
+		var index [2]byte
+		binary.BigEndian.PutUint16(index[:], c.Index)
+		binding := taggedHash(thresholdBindingTag, index[:], hash,
+			pubKey.SerializeCompressed(), encoded)
+		group.bindings[c.Index] = binding
+		group.r = addPoints(group.r, addPoints(c.D,
+			scalarMult(c.E, binding)))
+	}
+	if isInfinity(group.r) {
+		return nil, errors.New("nonce point is infinity")
+	}
+	group.challenge = schnorrChallenge(group.r, pubKey, hash)
+	return group, nil
+}
+
+// SignerSession is the state of one signer in the production of a single
+// threshold signature.  In the first round, the commitments of the sessions
+// of at least the threshold of signers are exchanged.  In the second round,
+// each signer signs the hash with all commitments, and the aggregator
+// combines the signature shares with CombineSignatureShares.  A session must
+// never sign twice, so the nonces are erased once it signs.
+type SignerSession struct {
+	key        *ThresholdKey
+	hash       []byte
+	d, e       *big.Int
+	commitment SigningCommitment
+}
+
+// NewSignerSession starts a session of the signer holding the key share to
+// sign the passed hash.
+func (k *ThresholdKey) NewSignerSession(hash []byte) (*SignerSession, error) {
+	d, err := randScalar()
+	if err != nil {
+		return nil, err
+	}
+	e, err := randScalar()
+	if err != nil {
+		return nil, err
+	}
+	return &SignerSession{
+		key:  k,
+		hash: hash,
+		d:    d,
+		e:    e,
+		commitment: SigningCommitment{
+			Index: k.Index,
+			D:     secretBaseMult(d),
+			E:     secretBaseMult(e),
+		},
+	}, nil
+}
+
+// Commitment returns the commitment of the session to its nonces.
+func (s *SignerSession) Commitment() SigningCommitment {
+	return s.commitment
+}
+
+// Sign returns the signature share of the signer given the commitments of all
+// signers of the signature, including its own.
+func (s *SignerSession) Sign(commitments []SigningCommitment) (*SignatureShare, error) {
+	if s.d == nil {
+		return nil, ErrSessionUsed
+	}
+	if len(commitments) < s.key.Threshold {
+		return nil, fmt.Errorf("%d signers, want at least %d",
+			len(commitments), s.key.Threshold)
+	}
+	own := false
+	for _, c := range commitments {
+		if c.Index == s.key.Index {
+			if !c.D.IsEqual(s.commitment.D) ||
+				!c.E.IsEqual(s.commitment.E) {
+
+				return nil, errors.New("commitment of the " +
+					"signer does not match its session")
+			}
+			own = true
+		}
+	}
+	if !own {
+		return nil, errors.New("commitment of the signer missing")
+	}
+	group, err := newSigningGroup(s.key.PubKey, s.hash, commitments)
+	if err != nil {
+		return nil, err
+	}
+
+	// z = d + e*binding + lambda*share*challenge
+	z := new(big.Int).Mul(s.e, group.bindings[s.key.Index])
+	z.Add(z, s.d)
+	keyPart := lagrangeCoeff(s.key.Index, group.indexes)
+	keyPart.Mul(keyPart, s.key.Share)
+	keyPart.Mul(keyPart, group.challenge)
+	z.Add(z, keyPart)
+	z.Mod(z, order)
+
+	zeroBigInt(s.d)
+	zeroBigInt(s.e)
+	s.d, s.e = nil, nil
+	return &SignatureShare{Index: s.key.Index, Z: z}, nil
+}
+
+// CombineSignatureShares checks the signature shares of the signers of a
+// threshold signature of the passed hash, which committed to their nonces with
+// the passed commitments, against their verification shares, and combines them
+// into a Schnorr signature for the group public key.
+func CombineSignatureShares(pubKey *PublicKey,
+	verificationShares map[uint16]*PublicKey, hash []byte,
+	commitments []SigningCommitment,
+	shares []*SignatureShare) (*SchnorrSignature, error) {
+
+	group, err := newSigningGroup(pubKey, hash, commitments)
+	if err != nil {
+		return nil, err
+	}
+	if len(shares) != len(commitments) {
+		return nil, fmt.Errorf("%d signature shares for %d signers",
+			len(shares), len(commitments))
+	}
+	byIndex := make(map[uint16]SigningCommitment, len(commitments))
+	for _, c := range commitments {
+		byIndex[c.Index] = c
+	}
+
+	z := new(big.Int)
+	seen := make(map[uint16]bool, len(shares))
+	for _, share := range shares {
+		c, ok := byIndex[share.Index]
+		verificationShare := verificationShares[share.Index]
+		if !ok || seen[share.Index] || verificationShare == nil {
+			return nil, fmt.Errorf("unexpected signature share of "+
+				"signer %d", share.Index)
+		}
+		seen[share.Index] = true
+
+		// z*G must equal D + binding*E + lambda*challenge*Y.
+		keyPart := lagrangeCoeff(share.Index, group.indexes)
+		keyPart.Mul(keyPart, group.challenge)
+		want := addPoints(addPoints(c.D, scalarMult(c.E,
+			group.bindings[share.Index])),
+			scalarMult(verificationShare, keyPart))
+		if !scalarBaseMult(share.Z).IsEqual(want) {
+			return nil, fmt.Errorf("invalid signature share of "+
+				"signer %d", share.Index)
+		}
+		z.Add(z, share.Z)
+	}
+	z.Mod(z, order)
+
+	sig := &SchnorrSignature{R: group.r, S: z}
+	if !sig.Verify(hash, pubKey) {
+		return nil, errors.New("combined signature is invalid")
+	}
+	return sig, nil
+}
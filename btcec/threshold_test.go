@@ -0,0 +1,149 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcec_test
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/btcec"
+)
+
+// generateThresholdKeys runs the distributed generation of a threshold out of
+// count key and returns the key shares of all participants.
+func generateThresholdKeys(t *testing.T, threshold, count int) []*btcec.ThresholdKey {
+	gens := make([]*btcec.ThresholdKeyGen, count)
+	for i := range gens {
+		var err error
+		gens[i], err = btcec.NewThresholdKeyGen(uint16(i+1), threshold,
+			count)
+		if err != nil {
+			t.Fatalf("NewThresholdKeyGen: %v", err)
+		}
+	}
+	for i, dealer := range gens {
+		for j, receiver := range gens {
+			if i == j {
+				continue
+			}
+			err := receiver.AddShare(uint16(i+1),
+				dealer.ShareFor(uint16(j+1)), dealer.Commitments())
+			if err != nil {
+				t.Fatalf("AddShare: %v", err)
+			}
+		}
+	}
+	keys := make([]*btcec.ThresholdKey, count)
+	for i, gen := range gens {
+		var err error
+		if keys[i], err = gen.Finish(); err != nil {
+			t.Fatalf("Finish: %v", err)
+		}
+		if !keys[i].PubKey.IsEqual(keys[0].PubKey) {
+			t.Fatalf("participants derived different group keys")
+		}
+	}
+	return keys
+}
+
+// thresholdSign produces a threshold signature of the passed hash by the
+// passed signers.
+func thresholdSign(t *testing.T, hash []byte,
+	signers []*btcec.ThresholdKey) (*btcec.SchnorrSignature, error) {
+
+	sessions := make([]*btcec.SignerSession, len(signers))
+	commitments := make([]btcec.SigningCommitment, len(signers))
+	for i, key := range signers {
+		var err error
+		if sessions[i], err = key.NewSignerSession(hash); err != nil {
+			t.Fatalf("NewSignerSession: %v", err)
+		}
+		commitments[i] = sessions[i].Commitment()
+	}
+	shares := make([]*btcec.SignatureShare, len(signers))
+	for i, session := range sessions {
+		var err error
+		if shares[i], err = session.Sign(commitments); err != nil {
+			return nil, err
+		}
+	}
+	return btcec.CombineSignatureShares(signers[0].PubKey,
+		signers[0].VerificationShares, hash, commitments, shares)
+}
+
+// TestThresholdKey ensures every pair of signers of a 2 of 3 key produces a
+// signature valid for the group key, that single signers and bad shares are
+// rejected and that a signer session signs only once.
+func TestThresholdKey(t *testing.T) {
+	keys := generateThresholdKeys(t, 2, 3)
+	hash := sha256.Sum256([]byte("block"))
+
+	pairs := [][]*btcec.ThresholdKey{
+		{keys[0], keys[1]},
+		{keys[0], keys[2]},
+		{keys[2], keys[1]},
+		keys,
+	}
+	for _, signers := range pairs {
+		sig, err := thresholdSign(t, hash[:], signers)
+		if err != nil {
+			t.Fatalf("thresholdSign: %v", err)
+		}
+		if !sig.Verify(hash[:], keys[0].PubKey) {
+			t.Fatalf("threshold signature does not verify")
+		}
+	}
+	if _, err := thresholdSign(t, hash[:], keys[:1]); err == nil {
+		t.Fatalf("single signer produced a signature")
+	}
+
+	// A share which does not match the commitments of its dealer is
+	// rejected.
+	dealer, err := btcec.NewThresholdKeyGen(1, 2, 3)
+	if err != nil {
+		t.Fatalf("NewThresholdKeyGen: %v", err)
+	}
+	receiver, err := btcec.NewThresholdKeyGen(2, 2, 3)
+	if err != nil {
+		t.Fatalf("NewThresholdKeyGen: %v", err)
+	}
+	err = receiver.AddShare(1, dealer.ShareFor(3), dealer.Commitments())
+	if err == nil {
+		t.Fatalf("AddShare: accepted share dealt to another participant")
+	}
+	if _, err := receiver.Finish(); err == nil {
+		t.Fatalf("Finish: finished without all shares")
+	}
+
+	// A signature share of a signer not matching its verification share
+	// is rejected, and a session can not sign twice.
+	sessions := make([]*btcec.SignerSession, 2)
+	commitments := make([]btcec.SigningCommitment, 2)
+	for i := range sessions {
+		sessions[i], err = keys[i].NewSignerSession(hash[:])
+		if err != nil {
+			t.Fatalf("NewSignerSession: %v", err)
+		}
+		commitments[i] = sessions[i].Commitment()
+	}
+	shares := make([]*btcec.SignatureShare, 2)
+	for i, session := range sessions {
+		if shares[i], err = session.Sign(commitments); err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+	}
+	if _, err := sessions[0].Sign(commitments); err != btcec.ErrSessionUsed {
+		t.Fatalf("got error %v signing twice, want %v", err,
+			btcec.ErrSessionUsed)
+	}
+	shares[1].Index = 3
+	commitments[1].Index = 3
+	_, err = btcec.CombineSignatureShares(keys[0].PubKey,
+		keys[0].VerificationShares, hash[:], commitments, shares)
+	if err == nil {
+		t.Fatalf("CombineSignatureShares: accepted share of another " +
+			"signer")
+	}
+}
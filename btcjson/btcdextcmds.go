@@ -112,6 +112,26 @@ func NewGetHeadersCmd(blockLocators []string, hashStop string) *GetHeadersCmd {
 	}
 }
 
+// GetRPCInfoCmd defines the getrpcinfo JSON-RPC command.  This command is not
+// a standard Bitcoin command.  It is an extension for dmgd.
+type GetRPCInfoCmd struct{}
+
+// NewGetRPCInfoCmd returns a new instance which can be used to issue a
+// getrpcinfo JSON-RPC command.
+func NewGetRPCInfoCmd() *GetRPCInfoCmd {
+	return &GetRPCInfoCmd{}
+}
+
+// ReloadConfigCmd defines the reloadconfig JSON-RPC command.  This command is
+// not a standard Bitcoin command.  It is an extension for dmgd.
+type ReloadConfigCmd struct{}
+
+// NewReloadConfigCmd returns a new instance which can be used to issue a
+// reloadconfig JSON-RPC command.
+func NewReloadConfigCmd() *ReloadConfigCmd {
+	return &ReloadConfigCmd{}
+}
+
 func init() {
 	// No special flags for commands in this file.
 	flags := UsageFlag(0)
@@ -122,4 +142,6 @@ func init() {
 	MustRegisterCmd("getbestblock", (*GetBestBlockCmd)(nil), flags)
 	MustRegisterCmd("getcurrentnet", (*GetCurrentNetCmd)(nil), flags)
 	MustRegisterCmd("getheaders", (*GetHeadersCmd)(nil), flags)
+	MustRegisterCmd("getrpcinfo", (*GetRPCInfoCmd)(nil), flags)
+	MustRegisterCmd("reloadconfig", (*ReloadConfigCmd)(nil), flags)
 }
@@ -177,6 +177,28 @@ func TestBtcdExtCmds(t *testing.T) {
 				HashStop: "000000000000000000ba33b33e1fad70b69e234fc24414dd47113bff38f523f7",
 			},
 		},
+		{
+			name: "getrpcinfo",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getrpcinfo")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetRPCInfoCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getrpcinfo","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetRPCInfoCmd{},
+		},
+		{
+			name: "reloadconfig",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("reloadconfig")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewReloadConfigCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"reloadconfig","params":[],"id":1}`,
+			unmarshalled: &btcjson.ReloadConfigCmd{},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
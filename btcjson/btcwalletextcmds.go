@@ -37,14 +37,16 @@ func NewDumpWalletCmd(filename string) *DumpWalletCmd {
 type ImportAddressCmd struct {
 	Address string
 	Rescan  *bool `jsonrpcdefault:"true"`
+	Label   *string
 }
 
 // NewImportAddressCmd returns a new instance which can be used to issue an
 // importaddress JSON-RPC command.
-func NewImportAddressCmd(address string, rescan *bool) *ImportAddressCmd {
+func NewImportAddressCmd(address string, rescan *bool, label *string) *ImportAddressCmd {
 	return &ImportAddressCmd{
 		Address: address,
 		Rescan:  rescan,
+		Label:   label,
 	}
 }
 
@@ -52,14 +54,16 @@ func NewImportAddressCmd(address string, rescan *bool) *ImportAddressCmd {
 type ImportPubKeyCmd struct {
 	PubKey string
 	Rescan *bool `jsonrpcdefault:"true"`
+	Label  *string
 }
 
 // NewImportPubKeyCmd returns a new instance which can be used to issue an
 // importpubkey JSON-RPC command.
-func NewImportPubKeyCmd(pubKey string, rescan *bool) *ImportPubKeyCmd {
+func NewImportPubKeyCmd(pubKey string, rescan *bool, label *string) *ImportPubKeyCmd {
 	return &ImportPubKeyCmd{
 		PubKey: pubKey,
 		Rescan: rescan,
+		Label:  label,
 	}
 }
 
@@ -63,7 +63,7 @@ func TestBtcWalletExtCmds(t *testing.T) {
 				return btcjson.NewCmd("importaddress", "1Address")
 			},
 			staticCmd: func() interface{} {
-				return btcjson.NewImportAddressCmd("1Address", nil)
+				return btcjson.NewImportAddressCmd("1Address", nil, nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"importaddress","params":["1Address"],"id":1}`,
 			unmarshalled: &btcjson.ImportAddressCmd{
@@ -77,7 +77,7 @@ func TestBtcWalletExtCmds(t *testing.T) {
 				return btcjson.NewCmd("importaddress", "1Address", false)
 			},
 			staticCmd: func() interface{} {
-				return btcjson.NewImportAddressCmd("1Address", btcjson.Bool(false))
+				return btcjson.NewImportAddressCmd("1Address", btcjson.Bool(false), nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"importaddress","params":["1Address",false],"id":1}`,
 			unmarshalled: &btcjson.ImportAddressCmd{
@@ -85,13 +85,28 @@ func TestBtcWalletExtCmds(t *testing.T) {
 				Rescan:  btcjson.Bool(false),
 			},
 		},
+		{
+			name: "importaddress optional2",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("importaddress", "1Address", false, "deposits")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewImportAddressCmd("1Address", btcjson.Bool(false), btcjson.String("deposits"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"importaddress","params":["1Address",false,"deposits"],"id":1}`,
+			unmarshalled: &btcjson.ImportAddressCmd{
+				Address: "1Address",
+				Rescan:  btcjson.Bool(false),
+				Label:   btcjson.String("deposits"),
+			},
+		},
 		{
 			name: "importpubkey",
 			newCmd: func() (interface{}, error) {
 				return btcjson.NewCmd("importpubkey", "031234")
 			},
 			staticCmd: func() interface{} {
-				return btcjson.NewImportPubKeyCmd("031234", nil)
+				return btcjson.NewImportPubKeyCmd("031234", nil, nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"importpubkey","params":["031234"],"id":1}`,
 			unmarshalled: &btcjson.ImportPubKeyCmd{
@@ -105,7 +120,7 @@ func TestBtcWalletExtCmds(t *testing.T) {
 				return btcjson.NewCmd("importpubkey", "031234", false)
 			},
 			staticCmd: func() interface{} {
-				return btcjson.NewImportPubKeyCmd("031234", btcjson.Bool(false))
+				return btcjson.NewImportPubKeyCmd("031234", btcjson.Bool(false), nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"importpubkey","params":["031234",false],"id":1}`,
 			unmarshalled: &btcjson.ImportPubKeyCmd{
@@ -113,6 +128,21 @@ func TestBtcWalletExtCmds(t *testing.T) {
 				Rescan: btcjson.Bool(false),
 			},
 		},
+		{
+			name: "importpubkey optional2",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("importpubkey", "031234", false, "deposits")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewImportPubKeyCmd("031234", btcjson.Bool(false), btcjson.String("deposits"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"importpubkey","params":["031234",false,"deposits"],"id":1}`,
+			unmarshalled: &btcjson.ImportPubKeyCmd{
+				PubKey: "031234",
+				Rescan: btcjson.Bool(false),
+				Label:  btcjson.String("deposits"),
+			},
+		},
 		{
 			name: "importwallet",
 			newCmd: func() (interface{}, error) {
@@ -469,7 +469,8 @@ func NewGetRawTransactionCmd(txHash string, verbose *int) *GetRawTransactionCmd
 type GetTxOutCmd struct {
 	Txid           string
 	Vout           uint32
-	IncludeMempool *bool `jsonrpcdefault:"true"`
+	IncludeMempool *bool   `jsonrpcdefault:"true"`
+	Sequence       *uint32 `jsonrpcdefault:"4294967295"`
 }
 
 // NewGetTxOutCmd returns a new instance which can be used to issue a gettxout
@@ -477,11 +478,12 @@ type GetTxOutCmd struct {
 //
 // The parameters which are pointers indicate they are optional.  Passing nil
 // for optional parameters will use the default value.
-func NewGetTxOutCmd(txHash string, vout uint32, includeMempool *bool) *GetTxOutCmd {
+func NewGetTxOutCmd(txHash string, vout uint32, includeMempool *bool, sequence *uint32) *GetTxOutCmd {
 	return &GetTxOutCmd{
 		Txid:           txHash,
 		Vout:           vout,
 		IncludeMempool: includeMempool,
+		Sequence:       sequence,
 	}
 }
 
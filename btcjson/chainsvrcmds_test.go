@@ -565,28 +565,31 @@ func TestChainSvrCmds(t *testing.T) {
 				return btcjson.NewCmd("gettxout", "123", 1)
 			},
 			staticCmd: func() interface{} {
-				return btcjson.NewGetTxOutCmd("123", 1, nil)
+				return btcjson.NewGetTxOutCmd("123", 1, nil, nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"gettxout","params":["123",1],"id":1}`,
 			unmarshalled: &btcjson.GetTxOutCmd{
 				Txid:           "123",
 				Vout:           1,
 				IncludeMempool: btcjson.Bool(true),
+				Sequence:       btcjson.Uint32(0xffffffff),
 			},
 		},
 		{
 			name: "gettxout optional",
 			newCmd: func() (interface{}, error) {
-				return btcjson.NewCmd("gettxout", "123", 1, true)
+				return btcjson.NewCmd("gettxout", "123", 1, true, 10)
 			},
 			staticCmd: func() interface{} {
-				return btcjson.NewGetTxOutCmd("123", 1, btcjson.Bool(true))
+				return btcjson.NewGetTxOutCmd("123", 1, btcjson.Bool(true),
+					btcjson.Uint32(10))
 			},
-			marshalled: `{"jsonrpc":"1.0","method":"gettxout","params":["123",1,true],"id":1}`,
+			marshalled: `{"jsonrpc":"1.0","method":"gettxout","params":["123",1,true,10],"id":1}`,
 			unmarshalled: &btcjson.GetTxOutCmd{
 				Txid:           "123",
 				Vout:           1,
 				IncludeMempool: btcjson.Bool(true),
+				Sequence:       btcjson.Uint32(10),
 			},
 		},
 		{
@@ -21,6 +21,8 @@ type GetBlockHeaderVerboseResult struct {
 	Nonce            uint64  `json:"nonce"`
 	Bits             string  `json:"bits"`
 	Difficulty       float64 `json:"difficulty"`
+	Work             string  `json:"work"`
+	ChainWork        string  `json:"chainwork"`
 	PreviousHash     string  `json:"previousblockhash,omitempty"`
 	NextHash         string  `json:"nextblockhash,omitempty"`
 	ValidatingPubKey string  `json:"validatingpubkey"`
@@ -93,30 +95,528 @@ type ThreadTipResult struct {
 	OutPoint string `json:"outpoint"`
 }
 
+// AssetSupplyResult models the supply of an asset other than DMG as part of
+// the getadmininfo command.
+type AssetSupplyResult struct {
+	AssetID uint32 `json:"assetid"`
+	Supply  uint64 `json:"supply"`
+}
+
+// DelegateResult models a delegate key and the scope of provision thread ops
+// delegated to it as part of the getadmininfo command.
+type DelegateResult struct {
+	PubKey   string `json:"pubkey"`
+	Scope    uint8  `json:"scope"`
+	MinKeyID uint32 `json:"minkeyid"`
+	MaxKeyID uint32 `json:"maxkeyid"`
+}
+
 // GetAdminInfoResult models the data from the getadmininfo command.
 type GetAdminInfoResult struct {
-	Hash          string            `json:"hash"`
-	Height        uint32            `json:"height"`
-	ThreadTips    []ThreadTipResult `json:"threadtips"`
-	TotalSupply   uint64            `json:"totalsupply"`
-	LastKeyID     uint32            `json:"lastkeyid"`
-	RootKeys      []string          `json:"rootkeys,omitempty"`
-	ProvisionKeys []string          `json:"provisionkeys,omitempty"`
-	IssueKeys     []string          `json:"issuekeys,omitempty"`
-	ValidateKeys  []string          `json:"validatekeys,omitempty"`
-	ASPKeys       []ASPKeyIdResult  `json:"aspkeys,omitempty"`
+	Hash          string              `json:"hash"`
+	Height        uint32              `json:"height"`
+	ThreadTips    []ThreadTipResult   `json:"threadtips"`
+	TotalSupply   uint64              `json:"totalsupply"`
+	AssetSupplies []AssetSupplyResult `json:"assetsupplies,omitempty"`
+	LastKeyID     uint32              `json:"lastkeyid"`
+	RootKeys      []string            `json:"rootkeys,omitempty"`
+	ProvisionKeys []string            `json:"provisionkeys,omitempty"`
+	IssueKeys     []string            `json:"issuekeys,omitempty"`
+	ValidateKeys  []string            `json:"validatekeys,omitempty"`
+	ASPKeys       []ASPKeyIdResult    `json:"aspkeys,omitempty"`
+	IssueDests    []string            `json:"issuedests,omitempty"`
+	Delegates     []DelegateResult    `json:"delegates,omitempty"`
+}
+
+// BroadcastAnnouncementResult models a peer which announced a locally submitted
+// transaction back to the server as part of the getunconfirmedbroadcasts
+// command.
+type BroadcastAnnouncementResult struct {
+	Addr string `json:"addr" jsonrpcdesc:"The IP address and port of the peer"`
+	Time int64  `json:"time" jsonrpcdesc:"Time the peer first announced the transaction in seconds since 1 Jan 1970 GMT"`
+}
+
+// UnconfirmedBroadcastResult models the data from the getunconfirmedbroadcasts
+// command.
+type UnconfirmedBroadcastResult struct {
+	TxID           string                        `json:"txid" jsonrpcdesc:"The hash of the transaction"`
+	FirstBroadcast int64                         `json:"firstbroadcast" jsonrpcdesc:"Time the transaction was first broadcast in seconds since 1 Jan 1970 GMT"`
+	LastBroadcast  int64                         `json:"lastbroadcast" jsonrpcdesc:"Time the transaction was last broadcast in seconds since 1 Jan 1970 GMT"`
+	Rebroadcasts   int32                         `json:"rebroadcasts" jsonrpcdesc:"The number of times the transaction has been rebroadcast"`
+	AnnouncedBy    []BroadcastAnnouncementResult `json:"announcedby" jsonrpcdesc:"The peers which announced the transaction back to the server"`
+}
+
+// FundRawTransactionResult models the data from the fundrawtransaction command.
+type FundRawTransactionResult struct {
+	Hex       string  `json:"hex" jsonrpcdesc:"Serialized, hex-encoded funded transaction"`
+	Fee       float64 `json:"fee" jsonrpcdesc:"The fee paid by the funded transaction in DMG"`
+	ChangePos int32   `json:"changepos" jsonrpcdesc:"The index of the change output or -1 if there is none"`
+}
+
+// GetFreezeStatusResult models the data returned from the getfreezestatus
+// command.
+type GetFreezeStatusResult struct {
+	Address string `json:"address" jsonrpcdesc:"The queried address"`
+	PkHash  string `json:"pkhash" jsonrpcdesc:"The hex-encoded pubKeyHash of the address accounts are frozen by"`
+	Frozen  bool   `json:"frozen" jsonrpcdesc:"Whether the account is frozen"`
+}
+
+// GetReserveProofResult models the data returned from the getreserveproof
+// command.
+type GetReserveProofResult struct {
+	Hex         string  `json:"hex" jsonrpcdesc:"Hex-encoded bytes of the serialized unsigned attestation"`
+	BlockHash   string  `json:"blockhash" jsonrpcdesc:"The hash of the block the attestation is made at"`
+	BlockHeight uint32  `json:"blockheight" jsonrpcdesc:"The height of the block the attestation is made at"`
+	Outputs     int     `json:"outputs" jsonrpcdesc:"The number of attested outputs"`
+	Total       float64 `json:"total" jsonrpcdesc:"The total value of the attested outputs in DMG"`
+}
+
+// CommitmentResult models the data of a transaction committing to a metadata
+// commitment returned from the getcommitments command.
+type CommitmentResult struct {
+	TxID        string `json:"txid" jsonrpcdesc:"The hash of the committing transaction"`
+	Vout        uint32 `json:"vout" jsonrpcdesc:"The index of the commitment output"`
+	BlockHash   string `json:"blockhash" jsonrpcdesc:"The hash of the block containing the transaction"`
+	BlockHeight uint32 `json:"blockheight" jsonrpcdesc:"The height of the block containing the transaction"`
+}
+
+// AddressHistoryEntry models a transaction of the history returned by the
+// getaddresshistory command.
+type AddressHistoryEntry struct {
+	TxID          string  `json:"txid" jsonrpcdesc:"The hash of the transaction"`
+	BlockHash     string  `json:"blockhash,omitempty" jsonrpcdesc:"The hash of the block containing the transaction, omitted for memory pool transactions"`
+	BlockHeight   uint32  `json:"blockheight,omitempty" jsonrpcdesc:"The height of the block containing the transaction, omitted for memory pool transactions"`
+	Confirmations int64   `json:"confirmations" jsonrpcdesc:"The number of confirmations of the transaction, 0 for memory pool transactions"`
+	Time          int64   `json:"time" jsonrpcdesc:"The block time, or the time the transaction was added to the memory pool, in seconds since 1 Jan 1970 GMT"`
+	Amount        float64 `json:"amount" jsonrpcdesc:"The net change of the balance of the address by the transaction in DMG"`
+	Balance       float64 `json:"balance" jsonrpcdesc:"The balance of the address after the transaction in DMG"`
+}
+
+// GetAddressHistoryResult models the data returned from the getaddresshistory
+// command.
+type GetAddressHistoryResult struct {
+	Address          string                `json:"address" jsonrpcdesc:"The address"`
+	Balance          float64               `json:"balance" jsonrpcdesc:"The balance of the address including the memory pool transactions in DMG"`
+	ConfirmedBalance float64               `json:"confirmedbalance" jsonrpcdesc:"The balance of the address in the main chain in DMG"`
+	TotalEntries     int                   `json:"totalentries" jsonrpcdesc:"The total number of transactions of the address"`
+	Page             int                   `json:"page" jsonrpcdesc:"The returned page"`
+	PageSize         int                   `json:"pagesize" jsonrpcdesc:"The number of transactions per page"`
+	Entries          []AddressHistoryEntry `json:"entries" jsonrpcdesc:"The transactions of the page, newest first"`
+}
+
+// ChainStatsResult models the aggregates of a day returned from the
+// getchainstats command.
+type ChainStatsResult struct {
+	Date            string  `json:"date" jsonrpcdesc:"The UTC day in the format YYYY-MM-DD"`
+	Time            int64   `json:"time" jsonrpcdesc:"The start of the day in seconds since 1 Jan 1970 GMT"`
+	Blocks          uint32  `json:"blocks" jsonrpcdesc:"The number of blocks"`
+	Transactions    uint64  `json:"transactions" jsonrpcdesc:"The number of transactions, including the coinbases"`
+	ActiveAddresses uint32  `json:"activeaddresses" jsonrpcdesc:"The number of distinct addresses paid or spent from by the transactions"`
+	Issued          float64 `json:"issued" jsonrpcdesc:"The DMG issued by the issue thread"`
+	Destroyed       float64 `json:"destroyed" jsonrpcdesc:"The DMG destroyed by the issue thread"`
+	Fees            float64 `json:"fees" jsonrpcdesc:"The fees paid by the transactions in DMG"`
+}
+
+// KeyChangeResult models an addition or revocation of an admin key returned
+// from the getkeystatediff command.
+type KeyChangeResult struct {
+	TxID   string `json:"txid" jsonrpcdesc:"The hash of the admin transaction"`
+	Height uint32 `json:"height" jsonrpcdesc:"The height of the block containing the transaction"`
+	KeySet string `json:"keyset" jsonrpcdesc:"The key set of the key (ROOT, PROVISION, ISSUE or VALIDATE)"`
+	Action string `json:"action" jsonrpcdesc:"Whether the key was added (add) or revoked (revoke)"`
+	PubKey string `json:"pubkey" jsonrpcdesc:"The hex-encoded compressed public key"`
+}
+
+// ASPKeyChangeResult models an addition or revocation of an ASP key returned
+// from the getkeystatediff command.
+type ASPKeyChangeResult struct {
+	TxID   string `json:"txid" jsonrpcdesc:"The hash of the admin transaction"`
+	Height uint32 `json:"height" jsonrpcdesc:"The height of the block containing the transaction"`
+	Action string `json:"action" jsonrpcdesc:"Whether the key was added (add) or revoked (revoke)"`
+	PubKey string `json:"pubkey" jsonrpcdesc:"The hex-encoded compressed public key"`
+	KeyID  uint32 `json:"keyid" jsonrpcdesc:"The key id of the key"`
+}
+
+// SupplyChangeResult models an issuance or destruction returned from the
+// getkeystatediff command.
+type SupplyChangeResult struct {
+	TxID    string `json:"txid" jsonrpcdesc:"The hash of the issue thread transaction"`
+	Height  uint32 `json:"height" jsonrpcdesc:"The height of the block containing the transaction"`
+	AssetID uint32 `json:"assetid" jsonrpcdesc:"The asset issued or destroyed, 0 for DMG"`
+	Delta   int64  `json:"delta" jsonrpcdesc:"The atoms issued, or the negated atoms destroyed"`
+}
+
+// AssetSupplyDeltaResult models the change of the supply of an asset returned
+// from the getkeystatediff command.
+type AssetSupplyDeltaResult struct {
+	AssetID uint32 `json:"assetid" jsonrpcdesc:"The asset"`
+	Delta   int64  `json:"delta" jsonrpcdesc:"The change of the supply of the asset in atoms"`
+}
+
+// GetKeyStateDiffResult models the data returned from the getkeystatediff
+// command.
+type GetKeyStateDiffResult struct {
+	Height1           uint32                   `json:"height1" jsonrpcdesc:"The height of the admin state compared from"`
+	Height2           uint32                   `json:"height2" jsonrpcdesc:"The height of the admin state compared to"`
+	KeyChanges        []KeyChangeResult        `json:"keychanges" jsonrpcdesc:"The additions and revocations of root, provision, issue and validate keys"`
+	ASPKeyChanges     []ASPKeyChangeResult     `json:"aspkeychanges" jsonrpcdesc:"The additions and revocations of ASP keys"`
+	SupplyChanges     []SupplyChangeResult     `json:"supplychanges" jsonrpcdesc:"The issuances and destructions"`
+	TotalSupplyDelta  int64                    `json:"totalsupplydelta" jsonrpcdesc:"The change of the total supply of DMG in atoms"`
+	AssetSupplyDeltas []AssetSupplyDeltaResult `json:"assetsupplydeltas,omitempty" jsonrpcdesc:"The changes of the supplies of the other assets"`
+}
+
+// RevertedAdminTxResult models an admin transaction reverted by a chain
+// reorganization as part of a ReorgEventResult.
+type RevertedAdminTxResult struct {
+	TxID     string `json:"txid" jsonrpcdesc:"The hash of the admin transaction"`
+	ThreadID uint32 `json:"threadid" jsonrpcdesc:"The admin thread of the transaction (0 for root, 1 for provision, 2 for issue)"`
+}
+
+// ReorgEventResult models a reorganization of the main chain returned from
+// the getreorghistory command and the reorganization notification.
+type ReorgEventResult struct {
+	Time             int64                   `json:"time" jsonrpcdesc:"The time the reorganization was recorded in seconds since 1 Jan 1970 GMT"`
+	Depth            uint32                  `json:"depth" jsonrpcdesc:"The number of blocks disconnected from the main chain"`
+	ForkHash         string                  `json:"forkhash" jsonrpcdesc:"The hash of the last block common to the old and new main chain"`
+	ForkHeight       uint32                  `json:"forkheight" jsonrpcdesc:"The height of the last block common to the old and new main chain"`
+	OldTip           string                  `json:"oldtip" jsonrpcdesc:"The hash of the best block before the reorganization"`
+	OldHeight        uint32                  `json:"oldheight" jsonrpcdesc:"The height of the best block before the reorganization"`
+	NewTip           string                  `json:"newtip" jsonrpcdesc:"The hash of the best block after the reorganization"`
+	NewHeight        uint32                  `json:"newheight" jsonrpcdesc:"The height of the best block after the reorganization"`
+	DisconnectedTxs  []string                `json:"disconnectedtxs" jsonrpcdesc:"The hashes of the transactions of the disconnected blocks which are not confirmed by the new main chain"`
+	RevertedAdminTxs []RevertedAdminTxResult `json:"revertedadmintxs" jsonrpcdesc:"The disconnected admin transactions"`
+}
+
+// GetFinalityStatusResult models the data returned from the getfinalitystatus
+// command.
+type GetFinalityStatusResult struct {
+	Hash              string `json:"hash" jsonrpcdesc:"The hash of the block or transaction"`
+	Type              string `json:"type" jsonrpcdesc:"Whether the hash is of a block (block) or a transaction (transaction)"`
+	BlockHash         string `json:"blockhash,omitempty" jsonrpcdesc:"The hash of the block, omitted for transactions in the memory pool"`
+	Height            uint32 `json:"height" jsonrpcdesc:"The height of the block, 0 for transactions in the memory pool"`
+	Confirmations     uint32 `json:"confirmations" jsonrpcdesc:"The number of confirmations of the block, 0 for transactions in the memory pool"`
+	Validators        uint32 `json:"validators" jsonrpcdesc:"The number of distinct validate keys which signed the blocks on top of the block, up to the irreversible depth"`
+	IrreversibleDepth uint32 `json:"irreversibledepth" jsonrpcdesc:"The number of confirmations after which a block is considered irreversible"`
+	Final             bool   `json:"final" jsonrpcdesc:"Whether the block is at or beyond the irreversible depth"`
+}
+
+// SignedCheckpointResult models a signed checkpoint returned from the
+// getsignedcheckpoints command.
+type SignedCheckpointResult struct {
+	Height       uint32 `json:"height" jsonrpcdesc:"The height of the checkpoint block"`
+	Hash         string `json:"hash" jsonrpcdesc:"The hash of the checkpoint block"`
+	UtxoHash     string `json:"utxohash" jsonrpcdesc:"The hash of the unspent transaction output set after the block"`
+	KeyStateHash string `json:"keystatehash" jsonrpcdesc:"The hash of the admin key state after the block"`
+	PubKey       string `json:"pubkey" jsonrpcdesc:"The checkpoint key which signed the checkpoint"`
+	Signature    string `json:"signature" jsonrpcdesc:"The hex-encoded signature of the checkpoint"`
+	Hex          string `json:"hex" jsonrpcdesc:"The serialized, hex-encoded signed checkpoint"`
+}
+
+// PeerEvictionResult models an inbound peer eviction returned from the
+// getpeerevictions command.
+type PeerEvictionResult struct {
+	Time      int64   `json:"time" jsonrpcdesc:"The time the peer was evicted in seconds since 1 Jan 1970 GMT"`
+	ID        int32   `json:"id" jsonrpcdesc:"The node ID of the evicted peer"`
+	Addr      string  `json:"addr" jsonrpcdesc:"The ip address and port of the evicted peer"`
+	ConnTime  int64   `json:"conntime" jsonrpcdesc:"Time the connection of the evicted peer was made in seconds since 1 Jan 1970 GMT"`
+	PingTime  float64 `json:"pingtime" jsonrpcdesc:"Number of microseconds the last ping of the evicted peer took"`
+	LastBlock int64   `json:"lastblock" jsonrpcdesc:"Time the evicted peer last relayed a block accepted to the chain in seconds since 1 Jan 1970 GMT, or 0 if it never did"`
+	LastTx    int64   `json:"lasttx" jsonrpcdesc:"Time the evicted peer last relayed a transaction accepted to the mempool in seconds since 1 Jan 1970 GMT, or 0 if it never did"`
+	Reason    string  `json:"reason" jsonrpcdesc:"Why the peer was selected for eviction"`
+	NewPeer   string  `json:"newpeer" jsonrpcdesc:"The ip address and port of the new inbound peer which replaced it"`
+}
+
+// GetSyncStatusResult models the data returned from the getsyncstatus command.
+type GetSyncStatusResult struct {
+	SyncPeer      string  `json:"syncpeer,omitempty" jsonrpcdesc:"The ip address and port of the peer the chain is synced from, if any"`
+	LastProgress  int64   `json:"lastprogress" jsonrpcdesc:"The last time the sync peer delivered a block, or was not expected to, in seconds since 1 Jan 1970 GMT, or 0 without a sync peer"`
+	HandlerBusy   float64 `json:"handlerbusy" jsonrpcdesc:"The number of seconds the block handler has been handling its current message, or 0 when it is idle"`
+	SyncStalls    uint64  `json:"syncstalls" jsonrpcdesc:"The number of times the sync peer was replaced since it stopped delivering blocks"`
+	HandlerStalls uint64  `json:"handlerstalls" jsonrpcdesc:"The number of times the block handler got stuck on a message"`
+	LastStall     int64   `json:"laststall" jsonrpcdesc:"The time of the last stall in seconds since 1 Jan 1970 GMT, or 0 if there was none"`
+	LastStallPeer string  `json:"laststallpeer,omitempty" jsonrpcdesc:"The ip address and port of the sync peer replaced at the last sync stall, if any"`
+}
+
+// NonCanonicalEncodingResult models a non-canonical encoding as returned by the
+// checkcanonicalencoding command.
+type NonCanonicalEncodingResult struct {
+	Kind        string `json:"kind" jsonrpcdesc:"The kind of the encoding (varint, push or signature)"`
+	Offset      int    `json:"offset" jsonrpcdesc:"The offset of the encoding in the serialized data"`
+	Tx          int    `json:"tx" jsonrpcdesc:"The index of the transaction holding the encoding in the block, 0 for a transaction"`
+	Field       string `json:"field" jsonrpcdesc:"The field holding the encoding"`
+	Description string `json:"description" jsonrpcdesc:"Why the encoding is not canonical"`
+}
+
+// CheckCanonicalEncodingResult models the data returned from the
+// checkcanonicalencoding command.
+type CheckCanonicalEncodingResult struct {
+	Hash          string                       `json:"hash" jsonrpcdesc:"The hash of the transaction or block"`
+	Canonical     bool                         `json:"canonical" jsonrpcdesc:"Whether the encoding is canonical"`
+	NonCanonicals []NonCanonicalEncodingResult `json:"noncanonicals" jsonrpcdesc:"The non-canonical encodings"`
+}
+
+// ValidatorViolationResult models the evidence of a validate key which signed
+// two blocks at the same height returned from the getvalidatorviolations
+// command.
+type ValidatorViolationResult struct {
+	Time             int64    `json:"time" jsonrpcdesc:"The time the violation was recorded in seconds since 1 Jan 1970 GMT"`
+	Height           uint32   `json:"height" jsonrpcdesc:"The height of the blocks"`
+	ValidatingPubKey string   `json:"validatingpubkey" jsonrpcdesc:"The validate key which signed both blocks"`
+	BlockHashes      []string `json:"blockhashes" jsonrpcdesc:"The hashes of the two blocks"`
+	Evidence         string   `json:"evidence" jsonrpcdesc:"The two serialized, hex-encoded signed block headers"`
+}
+
+// AnnouncementResult models a governance announcement returned from the
+// getannouncements command and the announcement notification.
+type AnnouncementResult struct {
+	Hash       string   `json:"hash" jsonrpcdesc:"The hash of the announcement, which its signatures sign"`
+	Category   string   `json:"category" jsonrpcdesc:"The category of the announcement (notice, upgrade, keyceremony or emergency)"`
+	Time       int64    `json:"time" jsonrpcdesc:"The time of the announcement in seconds since 1 Jan 1970 GMT"`
+	Expiration int64    `json:"expiration" jsonrpcdesc:"The time the announcement expires in seconds since 1 Jan 1970 GMT"`
+	Message    string   `json:"message" jsonrpcdesc:"The text of the announcement"`
+	KeySet     string   `json:"keyset" jsonrpcdesc:"The admin key set whose keys signed the announcement (ROOT or PROVISION)"`
+	Signers    []string `json:"signers" jsonrpcdesc:"The public keys which signed the announcement"`
+	Hex        string   `json:"hex" jsonrpcdesc:"The serialized, hex-encoded signed announcement"`
+}
+
+// KeyExpiryResult models an upcoming key expiry returned from the
+// getkeyexpiries command.
+type KeyExpiryResult struct {
+	KeySet       string `json:"keyset" jsonrpcdesc:"The key set of the key (ASP or VALIDATE)"`
+	PubKey       string `json:"pubkey" jsonrpcdesc:"The hex-encoded compressed public key"`
+	KeyID        uint32 `json:"keyid,omitempty" jsonrpcdesc:"The key ID of an ASP key"`
+	AddHeight    uint32 `json:"addheight" jsonrpcdesc:"The height of the block which provisioned the key"`
+	ExpiryHeight uint32 `json:"expiryheight" jsonrpcdesc:"The height of the last block the key is valid in"`
+	BlocksLeft   uint32 `json:"blocksleft" jsonrpcdesc:"The number of blocks after the best block the key remains valid for"`
+	Overdue      bool   `json:"overdue" jsonrpcdesc:"Whether the expiry height has passed but the key stays provisioned to keep the minimum number of validate keys"`
+}
+
+// AddressUtxoResult models a spendable output returned from the
+// getaddressutxos command.
+type AddressUtxoResult struct {
+	Address       string  `json:"address" jsonrpcdesc:"The address the output pays to"`
+	TxID          string  `json:"txid" jsonrpcdesc:"The hash of the transaction creating the output"`
+	Vout          uint32  `json:"vout" jsonrpcdesc:"The index of the output"`
+	ScriptPubKey  string  `json:"scriptPubKey" jsonrpcdesc:"The hex-encoded public key script of the output"`
+	Amount        float64 `json:"amount" jsonrpcdesc:"The value of the output in DMG"`
+	AssetID       uint32  `json:"assetid,omitempty" jsonrpcdesc:"The ID of the asset of the output, omitted for DMG"`
+	Height        uint32  `json:"height" jsonrpcdesc:"The height of the block containing the output"`
+	Confirmations int64   `json:"confirmations" jsonrpcdesc:"The number of confirmations of the output"`
+}
+
+// SweepTxResult models an unsigned transaction of the plan returned from the
+// getsweepplan command.
+type SweepTxResult struct {
+	Hex     string  `json:"hex" jsonrpcdesc:"The hex-encoded unsigned transaction"`
+	AssetID uint32  `json:"assetid,omitempty" jsonrpcdesc:"The ID of the asset of the swept outputs, omitted for DMG"`
+	Inputs  int     `json:"inputs" jsonrpcdesc:"The number of outputs the transaction spends"`
+	Amount  float64 `json:"amount" jsonrpcdesc:"The total value of the new outputs"`
+	Fee     float64 `json:"fee" jsonrpcdesc:"The fee paid by the transaction"`
+}
+
+// GetSweepPlanResult models the data returned from the getsweepplan command.
+type GetSweepPlanResult struct {
+	Txs     []SweepTxResult `json:"txs" jsonrpcdesc:"The unsigned sweep transactions"`
+	Skipped []string        `json:"skipped" jsonrpcdesc:"The outpoints referencing the old key ID which are not swept, because they are immature, frozen, spent in the memory pool, not standard Prova outputs or too small to pay the fee"`
+}
+
+// KeyIDUsageResult models the usage of a key ID by the outputs of an asset
+// returned from the getkeyidcensus command.
+type KeyIDUsageResult struct {
+	KeyID       uint32  `json:"keyid" jsonrpcdesc:"The key ID"`
+	Provisioned bool    `json:"provisioned" jsonrpcdesc:"Whether the key ID is provisioned to an ASP key in the best chain"`
+	AssetID     uint32  `json:"assetid,omitempty" jsonrpcdesc:"The ID of the asset of the outputs, omitted for DMG"`
+	Outputs     uint64  `json:"outputs" jsonrpcdesc:"The number of unspent outputs referencing the key ID"`
+	Amount      float64 `json:"amount" jsonrpcdesc:"The total value of the outputs"`
+}
+
+// GetKeyIDCensusResult models the data returned from the getkeyidcensus
+// command.
+type GetKeyIDCensusResult struct {
+	Running  bool               `json:"running" jsonrpcdesc:"Whether a census is running"`
+	Hash     string             `json:"hash,omitempty" jsonrpcdesc:"The hash of the best block at the time of the last census, omitted when no census finished"`
+	Height   uint32             `json:"height,omitempty" jsonrpcdesc:"The height of the best block at the time of the last census"`
+	Time     int64              `json:"time,omitempty" jsonrpcdesc:"The time the last census finished in seconds since 1 Jan 1970 GMT"`
+	Duration int64              `json:"duration,omitempty" jsonrpcdesc:"The number of milliseconds the last census took"`
+	Error    string             `json:"error,omitempty" jsonrpcdesc:"The error of the last census, if it failed"`
+	KeyIDs   []KeyIDUsageResult `json:"keyids" jsonrpcdesc:"The usage of the key IDs in ascending order of key ID and asset"`
+}
+
+// TransactionStatusResult models the status of a transaction returned from the
+// gettransactionstatus command.  The block fields are set for confirmed
+// transactions and for transactions of blocks disconnected by a
+// reorganization, and the thread fields for admin transactions.
+type TransactionStatusResult struct {
+	TxID           string   `json:"txid" jsonrpcdesc:"The hash of the transaction"`
+	Status         string   `json:"status" jsonrpcdesc:"The status of the transaction (mempool, held, orphan, confirmed, disconnected, conflicted or unknown)"`
+	BlockHash      string   `json:"blockhash,omitempty" jsonrpcdesc:"The hash of the block confirming the transaction, or of the disconnected block which contained it"`
+	Height         uint32   `json:"height,omitempty" jsonrpcdesc:"The height of the block"`
+	Confirmations  uint32   `json:"confirmations,omitempty" jsonrpcdesc:"The number of confirmations of a confirmed transaction"`
+	BlockTime      int64    `json:"blocktime,omitempty" jsonrpcdesc:"The timestamp of the block in seconds since 1 Jan 1970 GMT"`
+	ConflictingTxs []string `json:"conflictingtxs,omitempty" jsonrpcdesc:"The hashes of the transactions spending an output a conflicted transaction spends"`
+	ThreadID       *uint32  `json:"threadid,omitempty" jsonrpcdesc:"The admin thread an admin transaction spends, omitted for other transactions"`
+	Thread         string   `json:"thread,omitempty" jsonrpcdesc:"The name of the admin thread (root, provision or issue)"`
+}
+
+// GetTemplateExclusionsResult models the data returned from the
+// gettemplateexclusions command.
+type GetTemplateExclusionsResult struct {
+	TxIDs     []string `json:"txids" jsonrpcdesc:"The hashes of the excluded transactions"`
+	Addresses []string `json:"addresses" jsonrpcdesc:"The excluded addresses"`
+}
+
+// GetBlockAtHeightByTimeResult models the data returned from the
+// getblockatheightbytime command.
+type GetBlockAtHeightByTimeResult struct {
+	Hash       string `json:"hash" jsonrpcdesc:"The hash of the block"`
+	Height     uint32 `json:"height" jsonrpcdesc:"The height of the block"`
+	Time       int64  `json:"time" jsonrpcdesc:"The timestamp of the block in seconds since 1 Jan 1970 GMT"`
+	MedianTime int64  `json:"mediantime" jsonrpcdesc:"The median time past of the block in seconds since 1 Jan 1970 GMT"`
+}
+
+// GetBlockPropagationResult models the data returned from the
+// getblockpropagation command.  The average times are in milliseconds and only
+// include the blocks which reached both stages they are measured between.
+type GetBlockPropagationResult struct {
+	Blocks        int                      `json:"blocks" jsonrpcdesc:"The number of recently announced blocks whose propagation is tracked"`
+	AvgDownload   float64                  `json:"avgdownload" jsonrpcdesc:"The average number of milliseconds from the first announcement of a block to its reception"`
+	AvgValidation float64                  `json:"avgvalidation" jsonrpcdesc:"The average number of milliseconds from the reception of a block to the end of its validation"`
+	AvgRelay      float64                  `json:"avgrelay" jsonrpcdesc:"The average number of milliseconds from the end of the validation of a block to its first relay to a peer"`
+	Peers         []PeerPropagationResult  `json:"peers" jsonrpcdesc:"The announcements of the tracked blocks by each peer, slowest first"`
+	Recent        []BlockPropagationResult `json:"recent" jsonrpcdesc:"The propagation of the most recently announced blocks, newest first"`
+}
+
+// PeerPropagationResult models the announcements of blocks by a peer returned
+// from the getblockpropagation command.  The delays are in milliseconds from
+// the first announcement of each block by any peer.
+type PeerPropagationResult struct {
+	Addr      string  `json:"addr" jsonrpcdesc:"The ip address and port of the peer"`
+	Announced int     `json:"announced" jsonrpcdesc:"The number of tracked blocks the peer announced"`
+	FirstSeen int     `json:"firstseen" jsonrpcdesc:"The number of tracked blocks the peer announced first"`
+	AvgDelay  float64 `json:"avgdelay" jsonrpcdesc:"The average number of milliseconds the announcements of the peer were behind the first ones"`
+	MaxDelay  float64 `json:"maxdelay" jsonrpcdesc:"The largest number of milliseconds an announcement of the peer was behind the first one"`
+}
+
+// BlockPropagationResult models the propagation of a block returned from the
+// getblockpropagation command.  The times are in milliseconds since 1 Jan 1970
+// GMT, or 0 when the block has not reached the stage yet.
+type BlockPropagationResult struct {
+	Hash          string `json:"hash" jsonrpcdesc:"The hash of the block"`
+	Height        uint32 `json:"height,omitempty" jsonrpcdesc:"The height of the block, omitted until it is validated"`
+	FirstSeen     int64  `json:"firstseen" jsonrpcdesc:"The time the block was first announced by a peer or submitted locally"`
+	FirstPeer     string `json:"firstpeer,omitempty" jsonrpcdesc:"The ip address and port of the peer which announced the block first, omitted for blocks submitted locally"`
+	Received      int64  `json:"received" jsonrpcdesc:"The time the block was received"`
+	ReceivedFrom  string `json:"receivedfrom,omitempty" jsonrpcdesc:"The ip address and port of the peer the block was received from, omitted for blocks submitted locally"`
+	Validated     int64  `json:"validated" jsonrpcdesc:"The time the validation of the block finished and it was accepted"`
+	Relayed       int64  `json:"relayed" jsonrpcdesc:"The time the block was first announced to a peer"`
+	RelayedTo     int    `json:"relayedto" jsonrpcdesc:"The number of peers the block was announced to"`
+	Announcements int    `json:"announcements" jsonrpcdesc:"The number of peers which announced the block"`
+}
+
+// GetXpubInfoResult models the data returned from the getxpubinfo command.
+type GetXpubInfoResult struct {
+	Xpub        string   `json:"xpub" jsonrpcdesc:"The watched account extended public key"`
+	KeyIDs      []uint32 `json:"keyids" jsonrpcdesc:"The key ids of the deposit addresses"`
+	Label       string   `json:"label" jsonrpcdesc:"The label of the deposit addresses"`
+	GapLimit    uint32   `json:"gaplimit" jsonrpcdesc:"The number of addresses watched past the last used address"`
+	Used        uint32   `json:"used" jsonrpcdesc:"The number of addresses up to and including the last address which received an output"`
+	Derived     uint32   `json:"derived" jsonrpcdesc:"The number of derived and watched addresses"`
+	NextAddress string   `json:"nextaddress" jsonrpcdesc:"The first address after the used addresses, which is the next address to hand out for deposits"`
+}
+
+// VerifyReserveAttestationResult models the data returned from the
+// verifyreserveattestation command.
+type VerifyReserveAttestationResult struct {
+	Valid       bool    `json:"valid" jsonrpcdesc:"Whether or not the attestation verified"`
+	Error       string  `json:"error,omitempty" jsonrpcdesc:"The reason the attestation did not verify"`
+	BlockHash   string  `json:"blockhash" jsonrpcdesc:"The hash of the block the attestation is made at"`
+	BlockHeight uint32  `json:"blockheight" jsonrpcdesc:"The height of the block the attestation is made at"`
+	Outputs     int     `json:"outputs" jsonrpcdesc:"The number of attested outputs"`
+	Total       float64 `json:"total" jsonrpcdesc:"The total value of the attested outputs in DMG"`
+	PubKey      string  `json:"pubkey,omitempty" jsonrpcdesc:"The hex-encoded public key the attestation is signed with"`
 }
 
 // GetBlockChainInfoResult models the data returned from the getblockchaininfo
 // command.
 type GetBlockChainInfoResult struct {
-	Chain                string  `json:"chain"`
-	Blocks               int32   `json:"blocks"`
-	Headers              int32   `json:"headers"`
-	BestBlockHash        string  `json:"bestblockhash"`
-	Difficulty           float64 `json:"difficulty"`
-	VerificationProgress float64 `json:"verificationprogress"`
-	ChainWork            string  `json:"chainwork"`
+	Chain                string             `json:"chain"`
+	Blocks               int32              `json:"blocks"`
+	Headers              int32              `json:"headers"`
+	BestBlockHash        string             `json:"bestblockhash"`
+	Difficulty           float64            `json:"difficulty"`
+	VerificationProgress float64            `json:"verificationprogress"`
+	ChainWork            string             `json:"chainwork"`
+	Reindexing           bool               `json:"reindexing"`
+	KeyStateHash         string             `json:"keystatehash"`
+	ThreadTips           []ThreadTipResult  `json:"threadtips"`
+	LastKeyID            uint32             `json:"lastkeyid"`
+	Pruned               bool               `json:"pruned"`
+	Indexes              []IndexTipResult   `json:"indexes"`
+	UnknownRules         UnknownRulesResult `json:"unknownrules"`
+	Warnings             string             `json:"warnings"`
+}
+
+// UnknownRulesResult models whether the recent main chain blocks signal
+// consensus rules the node does not know as part of the getblockchaininfo
+// command and the unknownrules notification.
+type UnknownRulesResult struct {
+	State      string `json:"state"`
+	Height     int32  `json:"height"`
+	Blocks     uint64 `json:"blocks"`
+	Window     uint64 `json:"window"`
+	Threshold  uint64 `json:"threshold"`
+	MaxVersion uint32 `json:"maxversion"`
+}
+
+// HeaderConnectedResult models the header of a block connected to the main
+// chain as part of the headerconnected notification.  The validate key and
+// signature are decoded from the serialized header.
+type HeaderConnectedResult struct {
+	Hash             string `json:"hash"`
+	Height           uint32 `json:"height"`
+	Header           string `json:"header"`
+	ValidatingPubKey string `json:"validatingpubkey"`
+	Signature        string `json:"signature"`
+}
+
+// IssuanceAlertResult models an alert of the issuance monitor as part of the
+// issuancealert notification.  The window and amounts are only set for
+// threshold alerts.
+type IssuanceAlertResult struct {
+	Kind         string  `json:"kind"`
+	TxID         string  `json:"txid"`
+	BlockHash    string  `json:"blockhash"`
+	Height       uint32  `json:"height"`
+	Time         int64   `json:"time"`
+	AssetID      uint32  `json:"assetid"`
+	Amount       float64 `json:"amount"`
+	Window       int64   `json:"window,omitempty"`
+	WindowAmount float64 `json:"windowamount,omitempty"`
+	MaxAmount    float64 `json:"maxamount,omitempty"`
+	Message      string  `json:"message"`
+}
+
+// IndexTipResult models the block an optional index is caught up to as part
+// of the getblockchaininfo command.
+type IndexTipResult struct {
+	Name   string `json:"name"`
+	Hash   string `json:"hash"`
+	Height int32  `json:"height"`
+	Synced bool   `json:"synced"`
+}
+
+// GetChainTipsResult models the data returned from the getchaintips command.
+type GetChainTipsResult struct {
+	Height           uint32 `json:"height"`
+	Hash             string `json:"hash"`
+	BranchLen        uint32 `json:"branchlen"`
+	Status           string `json:"status"`
+	ChainWork        string `json:"chainwork"`
+	ForkHeight       uint32 `json:"forkheight"`
+	BranchWork       string `json:"branchwork"`
+	BranchValidators uint32 `json:"branchvalidators"`
+	MainWork         string `json:"mainwork"`
+	MainValidators   uint32 `json:"mainvalidators"`
 }
 
 // GetBlockTemplateResultTx models the transactions field of the
@@ -176,20 +676,22 @@ type GetBlockTemplateResult struct {
 // GetMempoolEntryResult models the data returned from the getmempoolentry
 // command.
 type GetMempoolEntryResult struct {
-	Size             int32    `json:"size"`
-	Fee              float64  `json:"fee"`
-	ModifiedFee      float64  `json:"modifiedfee"`
-	Time             int64    `json:"time"`
-	Height           int64    `json:"height"`
-	StartingPriority float64  `json:"startingpriority"`
-	CurrentPriority  float64  `json:"currentpriority"`
-	DescendantCount  int64    `json:"descendantcount"`
-	DescendantSize   int64    `json:"descendantsize"`
-	DescendantFees   float64  `json:"descendantfees"`
-	AncestorCount    int64    `json:"ancestorcount"`
-	AncestorSize     int64    `json:"ancestorsize"`
-	AncestorFees     float64  `json:"ancestorfees"`
-	Depends          []string `json:"depends"`
+	Size              int32    `json:"size"`
+	Fee               float64  `json:"fee"`
+	ModifiedFee       float64  `json:"modifiedfee"`
+	Time              int64    `json:"time"`
+	Height            int64    `json:"height"`
+	StartingPriority  float64  `json:"startingpriority"`
+	CurrentPriority   float64  `json:"currentpriority"`
+	DescendantCount   int64    `json:"descendantcount"`
+	DescendantSize    int64    `json:"descendantsize"`
+	DescendantFees    float64  `json:"descendantfees"`
+	AncestorCount     int64    `json:"ancestorcount"`
+	AncestorSize      int64    `json:"ancestorsize"`
+	AncestorFees      float64  `json:"ancestorfees"`
+	SpendsAdminThread bool     `json:"spendsadminthread"`
+	Depends           []string `json:"depends"`
+	SpentBy           []string `json:"spentby"`
 }
 
 // GetMempoolInfoResult models the data returned from the getmempoolinfo
@@ -199,6 +701,23 @@ type GetMempoolInfoResult struct {
 	Bytes int64 `json:"bytes"`
 }
 
+// RPCCallResult models an RPC call in the data returned from the getrpcinfo
+// command.  Durations are in microseconds.
+type RPCCallResult struct {
+	ID         uint64 `json:"id"`
+	Method     string `json:"method"`
+	RemoteAddr string `json:"remoteaddr"`
+	StartTime  int64  `json:"starttime"`
+	Duration   int64  `json:"duration"`
+}
+
+// GetRPCInfoResult models the data returned from the getrpcinfo command.
+type GetRPCInfoResult struct {
+	ActiveCommands []RPCCallResult `json:"activecommands"`
+	SlowCalls      []RPCCallResult `json:"slowcalls"`
+	SlowThreshold  int64           `json:"slowthreshold"`
+}
+
 // GetNetworkInfoResult models the data returned from the getnetworkinfo
 // command.
 type GetNetworkInfoResult struct {
@@ -209,6 +728,7 @@ type GetNetworkInfoResult struct {
 	Networks        []NetworksResult       `json:"networks"`
 	RelayFee        float64                `json:"relayfee"`
 	LocalAddresses  []LocalAddressesResult `json:"localaddresses"`
+	NAT             *NATStatusResult       `json:"nat,omitempty"`
 }
 
 // GetPeerInfoResult models the data returned from the getpeerinfo command.
@@ -234,19 +754,38 @@ type GetPeerInfoResult struct {
 	BanScore       int32   `json:"banscore"`
 	FeeFilter      int64   `json:"feefilter"`
 	SyncNode       bool    `json:"syncnode"`
+
+	BytesSentPerMsg map[string]uint64 `json:"bytessentpermsg"`
+	BytesRecvPerMsg map[string]uint64 `json:"bytesrecvpermsg"`
+	OutboundGroup   string            `json:"outboundgroup,omitempty"`
+
+	BestBlock       string    `json:"bestblock,omitempty"`
+	LastBlock       int64     `json:"lastblock,omitempty"`
+	LastTransaction int64     `json:"lasttransaction,omitempty"`
+	BlocksAccepted  uint64    `json:"blocksaccepted"`
+	MinPing         float64   `json:"minping,omitempty"`
+	PingHistory     []float64 `json:"pinghistory,omitempty"`
 }
 
 // GetRawMempoolVerboseResult models the data returned from the getrawmempool
 // command when the verbose flag is set.  When the verbose flag is not set,
 // getrawmempool returns an array of transaction hashes.
 type GetRawMempoolVerboseResult struct {
-	Size             int32    `json:"size"`
-	Fee              float64  `json:"fee"`
-	Time             int64    `json:"time"`
-	Height           int64    `json:"height"`
-	StartingPriority float64  `json:"startingpriority"`
-	CurrentPriority  float64  `json:"currentpriority"`
-	Depends          []string `json:"depends"`
+	Size              int32    `json:"size"`
+	Fee               float64  `json:"fee"`
+	Time              int64    `json:"time"`
+	Height            int64    `json:"height"`
+	StartingPriority  float64  `json:"startingpriority"`
+	CurrentPriority   float64  `json:"currentpriority"`
+	DescendantCount   int64    `json:"descendantcount"`
+	DescendantSize    int64    `json:"descendantsize"`
+	DescendantFees    float64  `json:"descendantfees"`
+	AncestorCount     int64    `json:"ancestorcount"`
+	AncestorSize      int64    `json:"ancestorsize"`
+	AncestorFees      float64  `json:"ancestorfees"`
+	SpendsAdminThread bool     `json:"spendsadminthread"`
+	Depends           []string `json:"depends"`
+	SpentBy           []string `json:"spentby"`
 }
 
 // ScriptPubKeyResult models the scriptPubKey data of a tx script.  It is
@@ -262,19 +801,37 @@ type ScriptPubKeyResult struct {
 
 // GetTxOutResult models the data from the gettxout command.
 type GetTxOutResult struct {
-	BestBlock     string             `json:"bestblock"`
-	Confirmations int64              `json:"confirmations"`
-	Value         float64            `json:"value"`
-	ScriptPubKey  ScriptPubKeyResult `json:"scriptPubKey"`
-	Version       int32              `json:"version"`
-	Coinbase      bool               `json:"coinbase"`
+	BestBlock       string             `json:"bestblock"`
+	Confirmations   int64              `json:"confirmations"`
+	Value           float64            `json:"value"`
+	ScriptPubKey    ScriptPubKeyResult `json:"scriptPubKey"`
+	Version         int32              `json:"version"`
+	Coinbase        bool               `json:"coinbase"`
+	AssetID         uint32             `json:"assetid,omitempty"`
+	KeyIDs          []uint32           `json:"keyids,omitempty"`
+	Mature          bool               `json:"mature"`
+	SpendableHeight uint32             `json:"spendableheight"`
+	SpendableTime   int64              `json:"spendabletime,omitempty"`
+	Spendable       bool               `json:"spendable"`
+}
+
+// UploadTargetResult models the upload target returned from the getnettotals
+// command.
+type UploadTargetResult struct {
+	TimeFrame             int64  `json:"timeframe"`
+	Target                uint64 `json:"target"`
+	TargetReached         bool   `json:"targetreached"`
+	ServeHistoricalBlocks bool   `json:"servehistoricalblocks"`
+	BytesLeftInCycle      uint64 `json:"bytesleftincycle"`
+	TimeLeftInCycle       int64  `json:"timeleftincycle"`
 }
 
 // GetNetTotalsResult models the data returned from the getnettotals command.
 type GetNetTotalsResult struct {
-	TotalBytesRecv uint64 `json:"totalbytesrecv"`
-	TotalBytesSent uint64 `json:"totalbytessent"`
-	TimeMillis     int64  `json:"timemillis"`
+	TotalBytesRecv uint64             `json:"totalbytesrecv"`
+	TotalBytesSent uint64             `json:"totalbytessent"`
+	TimeMillis     int64              `json:"timemillis"`
+	UploadTarget   UploadTargetResult `json:"uploadtarget"`
 }
 
 // ScriptSig models a signature script.  It is defined separately since it only
@@ -431,6 +988,18 @@ type LocalAddressesResult struct {
 	Score   int32  `json:"score"`
 }
 
+// NATStatusResult models the nat data from the getnetworkinfo command, which
+// describes the mapping of the listening port through the NAT of the network.
+type NATStatusResult struct {
+	Method       string `json:"method"`
+	InternalPort uint16 `json:"internalport"`
+	ExternalIP   string `json:"externalip,omitempty"`
+	ExternalPort uint16 `json:"externalport,omitempty"`
+	Mapped       bool   `json:"mapped"`
+	LastMapped   int64  `json:"lastmapped,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
 // NetworksResult models the networks data from the getnetworkinfo command.
 type NetworksResult struct {
 	Name      string `json:"name"`
@@ -477,9 +1046,27 @@ type TxRawDecodeResult struct {
 	Vout     []Vout `json:"vout"`
 }
 
+// ValidateAddressKeyIDResult models a key id embedded in an address as
+// returned by the validateaddress command.
+type ValidateAddressKeyIDResult struct {
+	KeyID       uint32 `json:"keyid"`
+	Provisioned bool   `json:"provisioned"`
+	PubKey      string `json:"pubkey,omitempty"`
+}
+
 // ValidateAddressChainResult models the data returned by the chain server
 // validateaddress command.
 type ValidateAddressChainResult struct {
-	IsValid bool   `json:"isvalid"`
-	Address string `json:"address,omitempty"`
+	IsValid      bool                         `json:"isvalid"`
+	Error        string                       `json:"error,omitempty"`
+	Address      string                       `json:"address,omitempty"`
+	Bech32m      string                       `json:"bech32m,omitempty"`
+	Network      string                       `json:"network,omitempty"`
+	ScriptType   string                       `json:"scripttype,omitempty"`
+	ScriptPubKey string                       `json:"scriptpubkey,omitempty"`
+	KeyIDs       []ValidateAddressKeyIDResult `json:"keyids,omitempty"`
+	IsWatchOnly  *bool                        `json:"iswatchonly,omitempty"`
+	Label        string                       `json:"label,omitempty"`
+	Xpub         string                       `json:"xpub,omitempty"`
+	XpubIndex    *uint32                      `json:"xpubindex,omitempty"`
 }
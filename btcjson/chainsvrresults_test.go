@@ -70,6 +70,44 @@ func TestChainSvrCustomResults(t *testing.T) {
 			},
 			expected: `{"txid":"123","vout":1,"scriptSig":{"asm":"0","hex":"00"},"prevOut":{"addresses":["addr1"],"value":0},"sequence":4294967295}`,
 		},
+		{
+			name: "gettxout marshal with keyids",
+			result: &btcjson.GetTxOutResult{
+				BestBlock:     "456",
+				Confirmations: 2,
+				Value:         1.5,
+				ScriptPubKey: btcjson.ScriptPubKeyResult{
+					Asm:  "OP_CHECKSAFEMULTISIG",
+					Hex:  "ba",
+					Type: "safe_multisig",
+				},
+				Version:         1,
+				KeyIDs:          []uint32{1, 2},
+				Mature:          true,
+				SpendableHeight: 10,
+				Spendable:       true,
+			},
+			expected: `{"bestblock":"456","confirmations":2,"value":1.5,"scriptPubKey":{"asm":"OP_CHECKSAFEMULTISIG","hex":"ba","type":"safe_multisig"},"version":1,"coinbase":false,"keyids":[1,2],"mature":true,"spendableheight":10,"spendable":true}`,
+		},
+		{
+			name: "gettxout marshal without keyids",
+			result: &btcjson.GetTxOutResult{
+				BestBlock:     "456",
+				Confirmations: 2,
+				Value:         1.5,
+				ScriptPubKey: btcjson.ScriptPubKeyResult{
+					Asm:  "OP_RETURN",
+					Hex:  "6a",
+					Type: "nulldata",
+				},
+				Version:         1,
+				KeyIDs:          []uint32{},
+				Mature:          true,
+				SpendableHeight: 10,
+				Spendable:       true,
+			},
+			expected: `{"bestblock":"456","confirmations":2,"value":1.5,"scriptPubKey":{"asm":"OP_RETURN","hex":"6a","type":"nulldata"},"version":1,"coinbase":false,"mature":true,"spendableheight":10,"spendable":true}`,
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
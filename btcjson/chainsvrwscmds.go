@@ -25,12 +25,19 @@ func NewAuthenticateCmd(username, passphrase string) *AuthenticateCmd {
 }
 
 // NotifyBlocksCmd defines the notifyblocks JSON-RPC command.
-type NotifyBlocksCmd struct{}
+type NotifyBlocksCmd struct {
+	FromHash *string
+}
 
 // NewNotifyBlocksCmd returns a new instance which can be used to issue a
 // notifyblocks JSON-RPC command.
-func NewNotifyBlocksCmd() *NotifyBlocksCmd {
-	return &NotifyBlocksCmd{}
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewNotifyBlocksCmd(fromHash *string) *NotifyBlocksCmd {
+	return &NotifyBlocksCmd{
+		FromHash: fromHash,
+	}
 }
 
 // StopNotifyBlocksCmd defines the stopnotifyblocks JSON-RPC command.
@@ -42,6 +49,32 @@ func NewStopNotifyBlocksCmd() *StopNotifyBlocksCmd {
 	return &StopNotifyBlocksCmd{}
 }
 
+// NotifyHeadersCmd defines the notifyheaders JSON-RPC command.
+//
+// NOTE: This is a prova extension.
+type NotifyHeadersCmd struct{}
+
+// NewNotifyHeadersCmd returns a new instance which can be used to issue a
+// notifyheaders JSON-RPC command.
+//
+// NOTE: This is a prova extension.
+func NewNotifyHeadersCmd() *NotifyHeadersCmd {
+	return &NotifyHeadersCmd{}
+}
+
+// StopNotifyHeadersCmd defines the stopnotifyheaders JSON-RPC command.
+//
+// NOTE: This is a prova extension.
+type StopNotifyHeadersCmd struct{}
+
+// NewStopNotifyHeadersCmd returns a new instance which can be used to issue a
+// stopnotifyheaders JSON-RPC command.
+//
+// NOTE: This is a prova extension.
+func NewStopNotifyHeadersCmd() *StopNotifyHeadersCmd {
+	return &StopNotifyHeadersCmd{}
+}
+
 // NotifyNewTransactionsCmd defines the notifynewtransactions JSON-RPC command.
 type NotifyNewTransactionsCmd struct {
 	Verbose *bool `jsonrpcdefault:"false"`
@@ -108,22 +141,30 @@ type OutPoint struct {
 //
 // NOTE: This is a btcd extension ported from github.com/decred/dcrd/dcrjson
 // and requires a websocket connection.
+//
+// The optional KeyIDs field is a Prova extension which matches any output
+// paying to an address that includes one of the given ASP key ids.
 type LoadTxFilterCmd struct {
 	Reload    bool
 	Addresses []string
 	OutPoints []OutPoint
+	KeyIDs    *[]uint32
 }
 
 // NewLoadTxFilterCmd returns a new instance which can be used to issue a
 // loadtxfilter JSON-RPC command.
 //
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+//
 // NOTE: This is a btcd extension ported from github.com/decred/dcrd/dcrjson
 // and requires a websocket connection.
-func NewLoadTxFilterCmd(reload bool, addresses []string, outPoints []OutPoint) *LoadTxFilterCmd {
+func NewLoadTxFilterCmd(reload bool, addresses []string, outPoints []OutPoint, keyIDs *[]uint32) *LoadTxFilterCmd {
 	return &LoadTxFilterCmd{
 		Reload:    reload,
 		Addresses: addresses,
 		OutPoints: outPoints,
+		KeyIDs:    keyIDs,
 	}
 }
 
@@ -222,6 +263,34 @@ func NewRescanBlocksCmd(blockHashes []string) *RescanBlocksCmd {
 	return &RescanBlocksCmd{BlockHashes: blockHashes}
 }
 
+// RescanChainCmd defines the rescanchain JSON-RPC command.
+//
+// NOTE: This is a prova extension and requires a websocket connection.
+type RescanChainCmd struct {
+	Addresses   []string
+	OutPoints   []OutPoint
+	BeginHeight int32
+	EndHeight   *int32
+	KeyIDs      *[]uint32
+}
+
+// NewRescanChainCmd returns a new instance which can be used to issue a
+// rescanchain JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+//
+// NOTE: This is a prova extension and requires a websocket connection.
+func NewRescanChainCmd(addresses []string, outPoints []OutPoint, beginHeight int32, endHeight *int32, keyIDs *[]uint32) *RescanChainCmd {
+	return &RescanChainCmd{
+		Addresses:   addresses,
+		OutPoints:   outPoints,
+		BeginHeight: beginHeight,
+		EndHeight:   endHeight,
+		KeyIDs:      keyIDs,
+	}
+}
+
 func init() {
 	// The commands in this file are only usable by websockets.
 	flags := UFWebsocketOnly
@@ -229,14 +298,17 @@ func init() {
 	MustRegisterCmd("authenticate", (*AuthenticateCmd)(nil), flags)
 	MustRegisterCmd("loadtxfilter", (*LoadTxFilterCmd)(nil), flags)
 	MustRegisterCmd("notifyblocks", (*NotifyBlocksCmd)(nil), flags)
+	MustRegisterCmd("notifyheaders", (*NotifyHeadersCmd)(nil), flags)
 	MustRegisterCmd("notifynewtransactions", (*NotifyNewTransactionsCmd)(nil), flags)
 	MustRegisterCmd("notifyreceived", (*NotifyReceivedCmd)(nil), flags)
 	MustRegisterCmd("notifyspent", (*NotifySpentCmd)(nil), flags)
 	MustRegisterCmd("session", (*SessionCmd)(nil), flags)
 	MustRegisterCmd("stopnotifyblocks", (*StopNotifyBlocksCmd)(nil), flags)
+	MustRegisterCmd("stopnotifyheaders", (*StopNotifyHeadersCmd)(nil), flags)
 	MustRegisterCmd("stopnotifynewtransactions", (*StopNotifyNewTransactionsCmd)(nil), flags)
 	MustRegisterCmd("stopnotifyspent", (*StopNotifySpentCmd)(nil), flags)
 	MustRegisterCmd("stopnotifyreceived", (*StopNotifyReceivedCmd)(nil), flags)
 	MustRegisterCmd("rescan", (*RescanCmd)(nil), flags)
 	MustRegisterCmd("rescanblocks", (*RescanBlocksCmd)(nil), flags)
+	MustRegisterCmd("rescanchain", (*RescanChainCmd)(nil), flags)
 }
@@ -48,11 +48,24 @@ func TestChainSvrWsCmds(t *testing.T) {
 				return btcjson.NewCmd("notifyblocks")
 			},
 			staticCmd: func() interface{} {
-				return btcjson.NewNotifyBlocksCmd()
+				return btcjson.NewNotifyBlocksCmd(nil)
 			},
 			marshalled:   `{"jsonrpc":"1.0","method":"notifyblocks","params":[],"id":1}`,
 			unmarshalled: &btcjson.NotifyBlocksCmd{},
 		},
+		{
+			name: "notifyblocks optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("notifyblocks", "123")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewNotifyBlocksCmd(btcjson.String("123"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"notifyblocks","params":["123"],"id":1}`,
+			unmarshalled: &btcjson.NotifyBlocksCmd{
+				FromHash: btcjson.String("123"),
+			},
+		},
 		{
 			name: "stopnotifyblocks",
 			newCmd: func() (interface{}, error) {
@@ -64,6 +77,28 @@ func TestChainSvrWsCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"stopnotifyblocks","params":[],"id":1}`,
 			unmarshalled: &btcjson.StopNotifyBlocksCmd{},
 		},
+		{
+			name: "notifyheaders",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("notifyheaders")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewNotifyHeadersCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"notifyheaders","params":[],"id":1}`,
+			unmarshalled: &btcjson.NotifyHeadersCmd{},
+		},
+		{
+			name: "stopnotifyheaders",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("stopnotifyheaders")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewStopNotifyHeadersCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"stopnotifyheaders","params":[],"id":1}`,
+			unmarshalled: &btcjson.StopNotifyHeadersCmd{},
+		},
 		{
 			name: "notifynewtransactions",
 			newCmd: func() (interface{}, error) {
@@ -205,7 +240,7 @@ func TestChainSvrWsCmds(t *testing.T) {
 					Hash:  "0000000000000000000000000000000000000000000000000000000000000123",
 					Index: 0,
 				}}
-				return btcjson.NewLoadTxFilterCmd(false, addrs, ops)
+				return btcjson.NewLoadTxFilterCmd(false, addrs, ops, nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"loadtxfilter","params":[false,["1Address"],[{"hash":"0000000000000000000000000000000000000000000000000000000000000123","index":0}]],"id":1}`,
 			unmarshalled: &btcjson.LoadTxFilterCmd{
@@ -214,6 +249,24 @@ func TestChainSvrWsCmds(t *testing.T) {
 				OutPoints: []btcjson.OutPoint{{Hash: "0000000000000000000000000000000000000000000000000000000000000123", Index: 0}},
 			},
 		},
+		{
+			name: "loadtxfilter optional keyids",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("loadtxfilter", true, `[]`, `[]`, `[2,7]`)
+			},
+			staticCmd: func() interface{} {
+				keyIDs := []uint32{2, 7}
+				return btcjson.NewLoadTxFilterCmd(true, []string{},
+					[]btcjson.OutPoint{}, &keyIDs)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"loadtxfilter","params":[true,[],[],[2,7]],"id":1}`,
+			unmarshalled: &btcjson.LoadTxFilterCmd{
+				Reload:    true,
+				Addresses: []string{},
+				OutPoints: []btcjson.OutPoint{},
+				KeyIDs:    &[]uint32{2, 7},
+			},
+		},
 		{
 			name: "rescanblocks",
 			newCmd: func() (interface{}, error) {
@@ -228,6 +281,48 @@ func TestChainSvrWsCmds(t *testing.T) {
 				BlockHashes: []string{"0000000000000000000000000000000000000000000000000000000000000123"},
 			},
 		},
+		{
+			name: "rescanchain",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("rescanchain", `["1Address"]`, `[{"hash":"0000000000000000000000000000000000000000000000000000000000000123","index":0}]`, 100)
+			},
+			staticCmd: func() interface{} {
+				addrs := []string{"1Address"}
+				ops := []btcjson.OutPoint{{
+					Hash:  "0000000000000000000000000000000000000000000000000000000000000123",
+					Index: 0,
+				}}
+				return btcjson.NewRescanChainCmd(addrs, ops, 100, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"rescanchain","params":[["1Address"],[{"hash":"0000000000000000000000000000000000000000000000000000000000000123","index":0}],100],"id":1}`,
+			unmarshalled: &btcjson.RescanChainCmd{
+				Addresses:   []string{"1Address"},
+				OutPoints:   []btcjson.OutPoint{{Hash: "0000000000000000000000000000000000000000000000000000000000000123", Index: 0}},
+				BeginHeight: 100,
+				EndHeight:   nil,
+				KeyIDs:      nil,
+			},
+		},
+		{
+			name: "rescanchain optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("rescanchain", `[]`, `[]`, 100, 200, `[3]`)
+			},
+			staticCmd: func() interface{} {
+				keyIDs := []uint32{3}
+				return btcjson.NewRescanChainCmd([]string{},
+					[]btcjson.OutPoint{}, 100, btcjson.Int32(200),
+					&keyIDs)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"rescanchain","params":[[],[],100,200,[3]],"id":1}`,
+			unmarshalled: &btcjson.RescanChainCmd{
+				Addresses:   []string{},
+				OutPoints:   []btcjson.OutPoint{},
+				BeginHeight: 100,
+				EndHeight:   btcjson.Int32(200),
+				KeyIDs:      &[]uint32{3},
+			},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -76,6 +76,38 @@ const (
 	// from the chain server that inform a client that a transaction that
 	// matches the loaded filter was accepted by the mempool.
 	RelevantTxAcceptedNtfnMethod = "relevanttxaccepted"
+
+	// RescanChainMatchNtfnMethod is the method used for notifications from
+	// the chain server that a block scanned by a rescanchain operation
+	// contains transactions relevant to the rescan.
+	RescanChainMatchNtfnMethod = "rescanchainmatch"
+
+	// RescanChainProgressNtfnMethod is the method used for notifications
+	// from the chain server that a rescanchain operation has made progress
+	// or has finished.
+	RescanChainProgressNtfnMethod = "rescanchainprogress"
+
+	// ReorganizationNtfnMethod is the method used for notifications from
+	// the chain server that the main chain has been reorganized.
+	ReorganizationNtfnMethod = "reorganization"
+
+	// AnnouncementNtfnMethod is the method used for notifications from the
+	// chain server that a governance announcement has been received.
+	AnnouncementNtfnMethod = "announcement"
+
+	// UnknownRulesNtfnMethod is the method used for notifications from the
+	// chain server that the main chain started or stopped signaling or
+	// enforcing consensus rules the chain server does not know.
+	UnknownRulesNtfnMethod = "unknownrules"
+
+	// IssuanceAlertNtfnMethod is the method used for notifications from the
+	// chain server that the issuance monitor raised an alert.
+	IssuanceAlertNtfnMethod = "issuancealert"
+
+	// HeaderConnectedNtfnMethod is the method used for notifications from
+	// the chain server that a block has been connected to the main chain,
+	// carrying only its header.
+	HeaderConnectedNtfnMethod = "headerconnected"
 )
 
 // BlockConnectedNtfn defines the blockconnected JSON-RPC notification.
@@ -286,6 +318,136 @@ func NewRelevantTxAcceptedNtfn(txHex string) *RelevantTxAcceptedNtfn {
 	return &RelevantTxAcceptedNtfn{Transaction: txHex}
 }
 
+// RescanChainMatchNtfn defines the rescanchainmatch JSON-RPC notification.
+//
+// NOTE: This is a prova extension.
+type RescanChainMatchNtfn struct {
+	Hash         string
+	Height       int32
+	Transactions []string
+}
+
+// NewRescanChainMatchNtfn returns a new instance which can be used to issue a
+// rescanchainmatch JSON-RPC notification.
+//
+// NOTE: This is a prova extension.
+func NewRescanChainMatchNtfn(hash string, height int32, transactions []string) *RescanChainMatchNtfn {
+	return &RescanChainMatchNtfn{
+		Hash:         hash,
+		Height:       height,
+		Transactions: transactions,
+	}
+}
+
+// RescanChainProgressNtfn defines the rescanchainprogress JSON-RPC
+// notification.
+//
+// NOTE: This is a prova extension.
+type RescanChainProgressNtfn struct {
+	Hash     string
+	Height   int32
+	Time     int64
+	Finished bool
+}
+
+// NewRescanChainProgressNtfn returns a new instance which can be used to issue
+// a rescanchainprogress JSON-RPC notification.
+//
+// NOTE: This is a prova extension.
+func NewRescanChainProgressNtfn(hash string, height int32, time int64, finished bool) *RescanChainProgressNtfn {
+	return &RescanChainProgressNtfn{
+		Hash:     hash,
+		Height:   height,
+		Time:     time,
+		Finished: finished,
+	}
+}
+
+// ReorganizationNtfn defines the reorganization JSON-RPC notification.
+//
+// NOTE: This is a prova extension.
+type ReorganizationNtfn struct {
+	Reorg ReorgEventResult
+}
+
+// NewReorganizationNtfn returns a new instance which can be used to issue a
+// reorganization JSON-RPC notification.
+//
+// NOTE: This is a prova extension.
+func NewReorganizationNtfn(reorg ReorgEventResult) *ReorganizationNtfn {
+	return &ReorganizationNtfn{
+		Reorg: reorg,
+	}
+}
+
+// AnnouncementNtfn defines the announcement JSON-RPC notification.
+//
+// NOTE: This is a prova extension.
+type AnnouncementNtfn struct {
+	Announcement AnnouncementResult
+}
+
+// NewAnnouncementNtfn returns a new instance which can be used to issue an
+// announcement JSON-RPC notification.
+//
+// NOTE: This is a prova extension.
+func NewAnnouncementNtfn(announcement AnnouncementResult) *AnnouncementNtfn {
+	return &AnnouncementNtfn{
+		Announcement: announcement,
+	}
+}
+
+// UnknownRulesNtfn defines the unknownrules JSON-RPC notification.
+//
+// NOTE: This is a prova extension.
+type UnknownRulesNtfn struct {
+	UnknownRules UnknownRulesResult
+}
+
+// NewUnknownRulesNtfn returns a new instance which can be used to issue an
+// unknownrules JSON-RPC notification.
+//
+// NOTE: This is a prova extension.
+func NewUnknownRulesNtfn(unknownRules UnknownRulesResult) *UnknownRulesNtfn {
+	return &UnknownRulesNtfn{
+		UnknownRules: unknownRules,
+	}
+}
+
+// IssuanceAlertNtfn defines the issuancealert JSON-RPC notification.
+//
+// NOTE: This is a prova extension.
+type IssuanceAlertNtfn struct {
+	Alert IssuanceAlertResult
+}
+
+// NewIssuanceAlertNtfn returns a new instance which can be used to issue an
+// issuancealert JSON-RPC notification.
+//
+// NOTE: This is a prova extension.
+func NewIssuanceAlertNtfn(alert IssuanceAlertResult) *IssuanceAlertNtfn {
+	return &IssuanceAlertNtfn{
+		Alert: alert,
+	}
+}
+
+// HeaderConnectedNtfn defines the headerconnected JSON-RPC notification.
+//
+// NOTE: This is a prova extension.
+type HeaderConnectedNtfn struct {
+	Header HeaderConnectedResult
+}
+
+// NewHeaderConnectedNtfn returns a new instance which can be used to issue a
+// headerconnected JSON-RPC notification.
+//
+// NOTE: This is a prova extension.
+func NewHeaderConnectedNtfn(header HeaderConnectedResult) *HeaderConnectedNtfn {
+	return &HeaderConnectedNtfn{
+		Header: header,
+	}
+}
+
 func init() {
 	// The commands in this file are only usable by websockets and are
 	// notifications.
@@ -302,4 +464,11 @@ func init() {
 	MustRegisterCmd(TxAcceptedNtfnMethod, (*TxAcceptedNtfn)(nil), flags)
 	MustRegisterCmd(TxAcceptedVerboseNtfnMethod, (*TxAcceptedVerboseNtfn)(nil), flags)
 	MustRegisterCmd(RelevantTxAcceptedNtfnMethod, (*RelevantTxAcceptedNtfn)(nil), flags)
+	MustRegisterCmd(RescanChainMatchNtfnMethod, (*RescanChainMatchNtfn)(nil), flags)
+	MustRegisterCmd(RescanChainProgressNtfnMethod, (*RescanChainProgressNtfn)(nil), flags)
+	MustRegisterCmd(ReorganizationNtfnMethod, (*ReorganizationNtfn)(nil), flags)
+	MustRegisterCmd(AnnouncementNtfnMethod, (*AnnouncementNtfn)(nil), flags)
+	MustRegisterCmd(UnknownRulesNtfnMethod, (*UnknownRulesNtfn)(nil), flags)
+	MustRegisterCmd(IssuanceAlertNtfnMethod, (*IssuanceAlertNtfn)(nil), flags)
+	MustRegisterCmd(HeaderConnectedNtfnMethod, (*HeaderConnectedNtfn)(nil), flags)
 }
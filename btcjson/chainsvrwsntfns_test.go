@@ -226,6 +226,194 @@ func TestChainSvrWsNtfns(t *testing.T) {
 				Transaction: "001122",
 			},
 		},
+		{
+			name: "rescanchainmatch",
+			newNtfn: func() (interface{}, error) {
+				return btcjson.NewCmd("rescanchainmatch", "123", 100000, `["001122"]`)
+			},
+			staticNtfn: func() interface{} {
+				return btcjson.NewRescanChainMatchNtfn("123", 100000, []string{"001122"})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"rescanchainmatch","params":["123",100000,["001122"]],"id":null}`,
+			unmarshalled: &btcjson.RescanChainMatchNtfn{
+				Hash:         "123",
+				Height:       100000,
+				Transactions: []string{"001122"},
+			},
+		},
+		{
+			name: "rescanchainprogress",
+			newNtfn: func() (interface{}, error) {
+				return btcjson.NewCmd("rescanchainprogress", "123", 100000, 12345678, true)
+			},
+			staticNtfn: func() interface{} {
+				return btcjson.NewRescanChainProgressNtfn("123", 100000, 12345678, true)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"rescanchainprogress","params":["123",100000,12345678,true],"id":null}`,
+			unmarshalled: &btcjson.RescanChainProgressNtfn{
+				Hash:     "123",
+				Height:   100000,
+				Time:     12345678,
+				Finished: true,
+			},
+		},
+		{
+			name: "reorganization",
+			newNtfn: func() (interface{}, error) {
+				return btcjson.NewCmd("reorganization", `{"time":12345678,"depth":1,"forkhash":"123","forkheight":99,"oldtip":"456","oldheight":100,"newtip":"789","newheight":101,"disconnectedtxs":["abc"],"revertedadmintxs":[{"txid":"abc","threadid":2}]}`)
+			},
+			staticNtfn: func() interface{} {
+				return btcjson.NewReorganizationNtfn(btcjson.ReorgEventResult{
+					Time:            12345678,
+					Depth:           1,
+					ForkHash:        "123",
+					ForkHeight:      99,
+					OldTip:          "456",
+					OldHeight:       100,
+					NewTip:          "789",
+					NewHeight:       101,
+					DisconnectedTxs: []string{"abc"},
+					RevertedAdminTxs: []btcjson.RevertedAdminTxResult{
+						{TxID: "abc", ThreadID: 2},
+					},
+				})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"reorganization","params":[{"time":12345678,"depth":1,"forkhash":"123","forkheight":99,"oldtip":"456","oldheight":100,"newtip":"789","newheight":101,"disconnectedtxs":["abc"],"revertedadmintxs":[{"txid":"abc","threadid":2}]}],"id":null}`,
+			unmarshalled: &btcjson.ReorganizationNtfn{
+				Reorg: btcjson.ReorgEventResult{
+					Time:            12345678,
+					Depth:           1,
+					ForkHash:        "123",
+					ForkHeight:      99,
+					OldTip:          "456",
+					OldHeight:       100,
+					NewTip:          "789",
+					NewHeight:       101,
+					DisconnectedTxs: []string{"abc"},
+					RevertedAdminTxs: []btcjson.RevertedAdminTxResult{
+						{TxID: "abc", ThreadID: 2},
+					},
+				},
+			},
+		},
+		{
+			name: "announcement",
+			newNtfn: func() (interface{}, error) {
+				return btcjson.NewCmd("announcement", `{"hash":"123","category":"upgrade","time":12345678,"expiration":12349278,"message":"upgrade","keyset":"ROOT","signers":["02ab","03cd"],"hex":"00"}`)
+			},
+			staticNtfn: func() interface{} {
+				return btcjson.NewAnnouncementNtfn(btcjson.AnnouncementResult{
+					Hash:       "123",
+					Category:   "upgrade",
+					Time:       12345678,
+					Expiration: 12349278,
+					Message:    "upgrade",
+					KeySet:     "ROOT",
+					Signers:    []string{"02ab", "03cd"},
+					Hex:        "00",
+				})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"announcement","params":[{"hash":"123","category":"upgrade","time":12345678,"expiration":12349278,"message":"upgrade","keyset":"ROOT","signers":["02ab","03cd"],"hex":"00"}],"id":null}`,
+			unmarshalled: &btcjson.AnnouncementNtfn{
+				Announcement: btcjson.AnnouncementResult{
+					Hash:       "123",
+					Category:   "upgrade",
+					Time:       12345678,
+					Expiration: 12349278,
+					Message:    "upgrade",
+					KeySet:     "ROOT",
+					Signers:    []string{"02ab", "03cd"},
+					Hex:        "00",
+				},
+			},
+		},
+		{
+			name: "unknownrules",
+			newNtfn: func() (interface{}, error) {
+				return btcjson.NewCmd("unknownrules", `{"state":"active","height":1000,"blocks":750,"window":1000,"threshold":750,"maxversion":5}`)
+			},
+			staticNtfn: func() interface{} {
+				return btcjson.NewUnknownRulesNtfn(btcjson.UnknownRulesResult{
+					State:      "active",
+					Height:     1000,
+					Blocks:     750,
+					Window:     1000,
+					Threshold:  750,
+					MaxVersion: 5,
+				})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"unknownrules","params":[{"state":"active","height":1000,"blocks":750,"window":1000,"threshold":750,"maxversion":5}],"id":null}`,
+			unmarshalled: &btcjson.UnknownRulesNtfn{
+				UnknownRules: btcjson.UnknownRulesResult{
+					State:      "active",
+					Height:     1000,
+					Blocks:     750,
+					Window:     1000,
+					Threshold:  750,
+					MaxVersion: 5,
+				},
+			},
+		},
+		{
+			name: "issuancealert",
+			newNtfn: func() (interface{}, error) {
+				return btcjson.NewCmd("issuancealert", `{"kind":"threshold","txid":"123","blockhash":"456","height":100,"time":12345678,"assetid":0,"amount":50,"window":86400,"windowamount":110,"maxamount":100,"message":"text"}`)
+			},
+			staticNtfn: func() interface{} {
+				return btcjson.NewIssuanceAlertNtfn(btcjson.IssuanceAlertResult{
+					Kind:         "threshold",
+					TxID:         "123",
+					BlockHash:    "456",
+					Height:       100,
+					Time:         12345678,
+					Amount:       50,
+					Window:       86400,
+					WindowAmount: 110,
+					MaxAmount:    100,
+					Message:      "text",
+				})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"issuancealert","params":[{"kind":"threshold","txid":"123","blockhash":"456","height":100,"time":12345678,"assetid":0,"amount":50,"window":86400,"windowamount":110,"maxamount":100,"message":"text"}],"id":null}`,
+			unmarshalled: &btcjson.IssuanceAlertNtfn{
+				Alert: btcjson.IssuanceAlertResult{
+					Kind:         "threshold",
+					TxID:         "123",
+					BlockHash:    "456",
+					Height:       100,
+					Time:         12345678,
+					Amount:       50,
+					Window:       86400,
+					WindowAmount: 110,
+					MaxAmount:    100,
+					Message:      "text",
+				},
+			},
+		},
+		{
+			name: "headerconnected",
+			newNtfn: func() (interface{}, error) {
+				return btcjson.NewCmd("headerconnected", `{"hash":"123","height":100,"header":"00","validatingpubkey":"456","signature":"789"}`)
+			},
+			staticNtfn: func() interface{} {
+				return btcjson.NewHeaderConnectedNtfn(btcjson.HeaderConnectedResult{
+					Hash:             "123",
+					Height:           100,
+					Header:           "00",
+					ValidatingPubKey: "456",
+					Signature:        "789",
+				})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"headerconnected","params":[{"hash":"123","height":100,"header":"00","validatingpubkey":"456","signature":"789"}],"id":null}`,
+			unmarshalled: &btcjson.HeaderConnectedNtfn{
+				Header: btcjson.HeaderConnectedResult{
+					Hash:             "123",
+					Height:           100,
+					Header:           "00",
+					ValidatingPubKey: "456",
+					Signature:        "789",
+				},
+			},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
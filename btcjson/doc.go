@@ -124,6 +124,14 @@ generate the final help text.
 In addition, the MethodUsageText function is provided to generate consistent
 one-line usage for registered commands and notifications using reflection.
 
+Commands may instead be documented where they are defined.  RegisterCmdDoc
+registers the version, synopsis and result types of a command, while its
+parameters and the fields of its result objects are described by jsonrpcdesc
+struct tags.  GenerateHelp falls back to this documentation for descriptions
+missing from the provided map, and GenerateOpenRPC generates a machine-readable
+OpenRPC document describing the commands from the same information, from which
+clients can be generated.
+
 Errors
 
 There are 2 distinct type of errors supported by this package:
@@ -46,6 +46,139 @@ var baseHelpDescs = map[string]string{
 // a key.
 type descLookupFunc func(string) string
 
+// descLookup looks up descriptions in a descriptions map provided by the
+// caller, then in the descriptions of the registered documentation and struct
+// tags of a method, and finally in the base help descriptions.  It tracks the
+// last key which was not found.
+type descLookup struct {
+	descs      map[string]string
+	tagDescs   map[string]string
+	missingKey string
+}
+
+// newDescLookup returns a description lookup for the passed method, its
+// documentation when it has any, and its result types.
+func newDescLookup(method string, rtp reflect.Type, doc *MethodDoc, descs map[string]string, resultTypes []interface{}) *descLookup {
+	return &descLookup{
+		descs:    descs,
+		tagDescs: tagDescs(method, rtp, doc, resultTypes),
+	}
+}
+
+// lookup returns the description of the passed key, or the key itself when
+// there is none.
+func (l *descLookup) lookup(key string) string {
+	if desc, ok := l.descs[key]; ok {
+		return desc
+	}
+	if desc, ok := l.tagDescs[key]; ok {
+		return desc
+	}
+	if desc, ok := baseHelpDescs[key]; ok {
+		return desc
+	}
+
+	l.missingKey = key
+	return key
+}
+
+// jsonFieldName returns the name of the passed struct field in JSON, which is
+// the json name when it's available, otherwise the lowercase field name.
+func jsonFieldName(rtf reflect.StructField) string {
+	if tag := rtf.Tag.Get("json"); tag != "" {
+		return strings.Split(tag, ",")[0]
+	}
+	return strings.ToLower(rtf.Name)
+}
+
+// tagDescs returns the descriptions of the passed method provided by its
+// documentation and by the jsonrpcdesc struct tags of its command type and
+// result types, keyed as in the descriptions map passed to GenerateHelp.
+func tagDescs(method string, rtp reflect.Type, doc *MethodDoc, resultTypes []interface{}) map[string]string {
+	descs := make(map[string]string)
+	if doc != nil {
+		descs[method+"--synopsis"] = doc.Synopsis
+		for i, result := range doc.Results {
+			if result.Desc != "" {
+				key := fmt.Sprintf("%s--result%d", method, i)
+				descs[key] = result.Desc
+			}
+			if result.Condition != "" {
+				key := fmt.Sprintf("%s--condition%d", method, i)
+				descs[key] = result.Condition
+			}
+		}
+	}
+
+	visited := make(map[reflect.Type]bool)
+	rt := rtp.Elem()
+	for i := 0; i < rt.NumField(); i++ {
+		rtf := rt.Field(i)
+		addFieldTagDescs(descs, method+"-"+strings.ToLower(rtf.Name), rtf)
+		addStructTagDescs(descs, rtf.Type, visited)
+	}
+	for _, resultType := range resultTypes {
+		if resultType != nil {
+			addStructTagDescs(descs, reflect.TypeOf(resultType),
+				visited)
+		}
+	}
+	return descs
+}
+
+// addFieldTagDescs adds the description of the passed struct field from its
+// jsonrpcdesc tag to the passed descriptions under the passed key.  The entries
+// of map fields are described by the same tag, with the example key and value
+// shown in the help taken from the jsonrpcmapkey and jsonrpcmapvalue tags.
+func addFieldTagDescs(descs map[string]string, key string, rtf reflect.StructField) {
+	desc := rtf.Tag.Get("jsonrpcdesc")
+	if desc == "" {
+		return
+	}
+	descs[key] = desc
+
+	rt := rtf.Type
+	if rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Map {
+		return
+	}
+	descs[key+"--desc"] = desc
+	if mapKey := rtf.Tag.Get("jsonrpcmapkey"); mapKey != "" {
+		descs[key+"--key"] = mapKey
+	}
+	if mapValue := rtf.Tag.Get("jsonrpcmapvalue"); mapValue != "" {
+		descs[key+"--value"] = mapValue
+	}
+}
+
+// addStructTagDescs adds the jsonrpcdesc struct tags of the fields of the
+// passed type, after indirecting through pointers, arrays, slices and maps, to
+// the passed descriptions keyed by "<typename>-<fieldname>".  The types of the
+// fields are handled recursively.
+func addStructTagDescs(descs map[string]string, rt reflect.Type, visited map[reflect.Type]bool) {
+	for {
+		switch rt.Kind() {
+		case reflect.Ptr, reflect.Array, reflect.Slice, reflect.Map:
+			rt = rt.Elem()
+			continue
+		}
+		break
+	}
+	if rt.Kind() != reflect.Struct || visited[rt] {
+		return
+	}
+	visited[rt] = true
+
+	typeName := strings.ToLower(rt.Name())
+	for i := 0; i < rt.NumField(); i++ {
+		rtf := rt.Field(i)
+		addFieldTagDescs(descs, typeName+"-"+jsonFieldName(rtf), rtf)
+		addStructTagDescs(descs, rtf.Type, visited)
+	}
+}
+
 // reflectTypeToJSONType returns a string that represents the JSON type
 // associated with the provided Go type.
 func reflectTypeToJSONType(xT descLookupFunc, rt reflect.Type) string {
@@ -93,12 +226,7 @@ func resultStructHelp(xT descLookupFunc, rt reflect.Type, indentLevel int) []str
 
 		// The field name to display is the json name when it's
 		// available, otherwise use the lowercase field name.
-		var fieldName string
-		if tag := rtf.Tag.Get("json"); tag != "" {
-			fieldName = strings.Split(tag, ",")[0]
-		} else {
-			fieldName = strings.ToLower(rtf.Name)
-		}
+		fieldName := jsonFieldName(rtf)
 
 		// Deference pointer if needed.
 		rtfType := rtf.Type
@@ -459,6 +587,31 @@ func isValidResultType(kind reflect.Kind) bool {
 	return false
 }
 
+// checkResultTypes returns an error when one of the passed result types is not
+// a pointer to a supported type or nil.
+func checkResultTypes(resultTypes []interface{}) error {
+	for i, resultType := range resultTypes {
+		if resultType == nil {
+			continue
+		}
+
+		rtp := reflect.TypeOf(resultType)
+		if rtp.Kind() != reflect.Ptr {
+			str := fmt.Sprintf("result #%d (%v) is not a pointer",
+				i, rtp.Kind())
+			return makeError(ErrInvalidType, str)
+		}
+
+		elemKind := rtp.Elem().Kind()
+		if !isValidResultType(elemKind) {
+			str := fmt.Sprintf("result #%d (%v) is not an allowed "+
+				"type", i, elemKind)
+			return makeError(ErrInvalidType, str)
+		}
+	}
+	return nil
+}
+
 // GenerateHelp generates and returns help output for the provided method and
 // result types given a map to provide the appropriate keys for the method
 // synopsis, field descriptions, conditions, and result descriptions.  The
@@ -487,6 +640,12 @@ func isValidResultType(kind reflect.Kind) bool {
 // Notice that the "special" keys synopsis, condition<#>, and result<#> are
 // preceded by a double dash to ensure they don't conflict with field names.
 //
+// Keys missing from the provided map are looked up in the documentation
+// registered for the method with RegisterCmdDoc and in the jsonrpcdesc struct
+// tags of the fields of the command and of the object types, so documented
+// methods may be passed a nil map.  Descriptions in the map take precedence,
+// which allows them to be translated.
+//
 // The condition keys are only required when there is more than on result type,
 // and the result key for a given result type is only required if it's not an
 // object.
@@ -510,6 +669,7 @@ func GenerateHelp(method string, descs map[string]string, resultTypes ...interfa
 	registerLock.RLock()
 	rtp, ok := methodToConcreteType[method]
 	info := methodToInfo[method]
+	doc := methodToDoc[method]
 	registerLock.RUnlock()
 	if !ok {
 		str := fmt.Sprintf("%q is not registered", method)
@@ -517,46 +677,21 @@ func GenerateHelp(method string, descs map[string]string, resultTypes ...interfa
 	}
 
 	// Validate each result type is a pointer to a supported type (or nil).
-	for i, resultType := range resultTypes {
-		if resultType == nil {
-			continue
-		}
-
-		rtp := reflect.TypeOf(resultType)
-		if rtp.Kind() != reflect.Ptr {
-			str := fmt.Sprintf("result #%d (%v) is not a pointer",
-				i, rtp.Kind())
-			return "", makeError(ErrInvalidType, str)
-		}
-
-		elemKind := rtp.Elem().Kind()
-		if !isValidResultType(elemKind) {
-			str := fmt.Sprintf("result #%d (%v) is not an allowed "+
-				"type", i, elemKind)
-			return "", makeError(ErrInvalidType, str)
-		}
+	if err := checkResultTypes(resultTypes); err != nil {
+		return "", err
 	}
 
-	// Create a closure for the description lookup function which falls back
-	// to the base help descritptions map for unrecognized keys and tracks
-	// and missing keys.
-	var missingKey string
-	xT := func(key string) string {
-		if desc, ok := descs[key]; ok {
-			return desc
-		}
-		if desc, ok := baseHelpDescs[key]; ok {
-			return desc
-		}
-
-		missingKey = key
-		return key
-	}
+	// Create the description lookup which falls back to the descriptions
+	// of the documentation and struct tags and to the base help
+	// descriptions map for unrecognized keys and tracks any missing keys.
+	descLookup := newDescLookup(method, rtp, doc, descs, resultTypes)
 
 	// Generate and return the help for the method.
-	help := methodHelp(xT, rtp, info.defaults, method, resultTypes)
-	if missingKey != "" {
-		return help, makeError(ErrMissingDescription, missingKey)
+	help := methodHelp(descLookup.lookup, rtp, info.defaults, method,
+		resultTypes)
+	if descLookup.missingKey != "" {
+		return help, makeError(ErrMissingDescription,
+			descLookup.missingKey)
 	}
 	return help, nil
 }
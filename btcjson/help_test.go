@@ -8,6 +8,7 @@ package btcjson_test
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/pyx-partners/dmgd/btcjson"
@@ -737,3 +738,60 @@ func TestGenerateHelp(t *testing.T) {
 			help, wantHelp)
 	}
 }
+
+// TestGenerateHelpFromDoc ensures GenerateHelp uses the registered
+// documentation and the struct tags of a command when descriptions are missing
+// from the passed map, and that the map takes precedence.
+func TestGenerateHelpFromDoc(t *testing.T) {
+	t.Parallel()
+
+	type helpDocTestResult struct {
+		Name  string           `json:"name" jsonrpcdesc:"The name"`
+		Sizes map[string]int64 `json:"sizes" jsonrpcdesc:"The sizes" jsonrpcmapkey:"item" jsonrpcmapvalue:"n"`
+	}
+	type helpDocTestCmd struct {
+		Name string `jsonrpcdesc:"The name to look up"`
+	}
+	btcjson.MustRegisterCmd("helpdoctestcmd", (*helpDocTestCmd)(nil), 0)
+	btcjson.MustRegisterCmdDoc("helpdoctestcmd", &btcjson.MethodDoc{
+		Version:  1,
+		Synopsis: "Looks up a name.",
+		Results: []btcjson.ResultDoc{{
+			Type: (*helpDocTestResult)(nil),
+		}},
+	})
+
+	help, err := btcjson.GenerateHelp("helpdoctestcmd", nil,
+		(*helpDocTestResult)(nil))
+	if err != nil {
+		t.Fatalf("GenerateHelp: unexpected error: %v", err)
+	}
+	wantHelp := "helpdoctestcmd \"name\"\n\n" +
+		"Looks up a name.\n\nArguments:\n" +
+		"1. name (string, required) The name to look up\n\n" +
+		"Result:\n" +
+		"{\n" +
+		" \"name\": \"value\", (string) The name\n" +
+		" \"sizes\": {       (object) The sizes\n" +
+		"  \"item\": n, (object) The sizes\n" +
+		"  ...\n" +
+		" }\n" +
+		"} \n"
+	if help != wantHelp {
+		t.Fatalf("GenerateHelp: unexpected help - got\n%q\nwant\n%q",
+			help, wantHelp)
+	}
+
+	descs := map[string]string{
+		"helpdoctestcmd--synopsis": "Translated.",
+	}
+	help, err = btcjson.GenerateHelp("helpdoctestcmd", descs,
+		(*helpDocTestResult)(nil))
+	if err != nil {
+		t.Fatalf("GenerateHelp: unexpected error: %v", err)
+	}
+	if !strings.Contains(help, "\n\nTranslated.\n\n") {
+		t.Fatalf("GenerateHelp: synopsis not taken from the passed "+
+			"descriptions - got\n%v", help)
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcjson
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// OpenRPCVersion is the version of the OpenRPC specification the documents
+// generated by GenerateOpenRPC follow.
+const OpenRPCVersion = "1.2.6"
+
+// JSONSchema is a JSON Schema describing a JSON value, such as
+// {"type": "array", "items": {"type": "string"}}.
+type JSONSchema map[string]interface{}
+
+// OpenRPCInfo models the info object of an OpenRPC document.
+type OpenRPCInfo struct {
+	Title   string `json:"title" jsonrpcdesc:"The name of the API"`
+	Version string `json:"version" jsonrpcdesc:"The version of the API"`
+}
+
+// OpenRPCContentDescriptor models a content descriptor of an OpenRPC document,
+// which describes a parameter or result.
+type OpenRPCContentDescriptor struct {
+	Name        string     `json:"name" jsonrpcdesc:"The name of the value"`
+	Description string     `json:"description,omitempty" jsonrpcdesc:"The description of the value"`
+	Required    bool       `json:"required,omitempty" jsonrpcdesc:"Whether the parameter must be passed"`
+	Schema      JSONSchema `json:"schema" jsonrpcdesc:"The JSON Schema of the value" jsonrpcmapkey:"keyword" jsonrpcmapvalue:"value"`
+}
+
+// OpenRPCMethod models a method of an OpenRPC document.  The Version field is
+// an extension holding the version of the command registered with
+// RegisterCmdDoc.
+type OpenRPCMethod struct {
+	Name           string                     `json:"name" jsonrpcdesc:"The name of the method"`
+	Summary        string                     `json:"summary,omitempty" jsonrpcdesc:"The first line of the description of the method"`
+	Description    string                     `json:"description,omitempty" jsonrpcdesc:"The description of the method when it has more than one line"`
+	ParamStructure string                     `json:"paramStructure" jsonrpcdesc:"How the parameters are passed (by-position)"`
+	Params         []OpenRPCContentDescriptor `json:"params" jsonrpcdesc:"The parameters in the order they are passed"`
+	Result         OpenRPCContentDescriptor   `json:"result" jsonrpcdesc:"The result of the method"`
+	Version        uint32                     `json:"x-version,omitempty" jsonrpcdesc:"The version of the method, incremented when it changes in a way which is not backwards compatible, omitted for undocumented methods"`
+}
+
+// OpenRPCDocument models an OpenRPC document describing a JSON-RPC API.
+type OpenRPCDocument struct {
+	OpenRPC string          `json:"openrpc" jsonrpcdesc:"The version of the OpenRPC specification the document follows"`
+	Info    OpenRPCInfo     `json:"info" jsonrpcdesc:"The API described by the document"`
+	Methods []OpenRPCMethod `json:"methods" jsonrpcdesc:"The methods of the API in alphabetical order"`
+}
+
+// isComplexType returns whether the help of the passed type describes its
+// fields instead of the value itself, so it has no description of its own.
+func isComplexType(rt reflect.Type) bool {
+	if rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	switch rt.Kind() {
+	case reflect.Struct, reflect.Map:
+		return true
+
+	case reflect.Array, reflect.Slice:
+		return isComplexType(rt.Elem())
+	}
+	return false
+}
+
+// typeSchema returns the JSON Schema of the passed type.  The descriptions of
+// the fields of structs are looked up with the passed function like in the help
+// output.  Recursive types are described as objects when they recur.
+func typeSchema(xT descLookupFunc, rt reflect.Type, visiting map[reflect.Type]bool) JSONSchema {
+	if rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	kind := rt.Kind()
+	if isNumeric(kind) {
+		if kind == reflect.Float32 || kind == reflect.Float64 {
+			return JSONSchema{"type": "number"}
+		}
+		return JSONSchema{"type": "integer"}
+	}
+
+	switch kind {
+	case reflect.String:
+		return JSONSchema{"type": "string"}
+
+	case reflect.Bool:
+		return JSONSchema{"type": "boolean"}
+
+	case reflect.Array, reflect.Slice:
+		return JSONSchema{
+			"type":  "array",
+			"items": typeSchema(xT, rt.Elem(), visiting),
+		}
+
+	case reflect.Map:
+		return JSONSchema{
+			"type":                 "object",
+			"additionalProperties": typeSchema(xT, rt.Elem(), visiting),
+		}
+
+	case reflect.Struct:
+		if visiting[rt] {
+			return JSONSchema{"type": "object"}
+		}
+		visiting[rt] = true
+		defer delete(visiting, rt)
+
+		typeName := strings.ToLower(rt.Name())
+		properties := make(map[string]interface{})
+		var required []string
+		for i := 0; i < rt.NumField(); i++ {
+			rtf := rt.Field(i)
+			fieldName := jsonFieldName(rtf)
+			if rtf.PkgPath != "" || fieldName == "-" {
+				continue
+			}
+			schema := typeSchema(xT, rtf.Type, visiting)
+			schema["description"] = xT(typeName + "-" + fieldName)
+			properties[fieldName] = schema
+			if !strings.Contains(rtf.Tag.Get("json"), ",omitempty") {
+				required = append(required, fieldName)
+			}
+		}
+		schema := JSONSchema{"type": "object", "properties": properties}
+		if len(required) != 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+
+	return JSONSchema{}
+}
+
+// methodOpenRPC returns the OpenRPC method of the passed method.  This is the
+// main work horse for the exported GenerateOpenRPC function.
+func methodOpenRPC(xT descLookupFunc, rtp reflect.Type, defaults map[int]reflect.Value, method string, resultTypes []interface{}) OpenRPCMethod {
+	m := OpenRPCMethod{
+		Name:           method,
+		ParamStructure: "by-position",
+		Params:         make([]OpenRPCContentDescriptor, 0),
+	}
+	synopsis := xT(method + "--synopsis")
+	if i := strings.Index(synopsis, "\n"); i >= 0 {
+		m.Summary = synopsis[:i]
+		m.Description = synopsis
+	} else {
+		m.Summary = synopsis
+	}
+
+	rt := rtp.Elem()
+	for i := 0; i < rt.NumField(); i++ {
+		rtf := rt.Field(i)
+		fieldName := strings.ToLower(rtf.Name)
+		param := OpenRPCContentDescriptor{
+			Name:        fieldName,
+			Description: xT(method + "-" + fieldName),
+			Required:    rtf.Type.Kind() != reflect.Ptr,
+			Schema:      typeSchema(xT, rtf.Type, make(map[reflect.Type]bool)),
+		}
+		if defaultVal, ok := defaults[i]; ok {
+			param.Schema["default"] = defaultVal.Elem().Interface()
+		}
+		m.Params = append(m.Params, param)
+	}
+
+	// Describe each result type like the help does, and combine them when
+	// there is more than one.
+	schemas := make([]interface{}, 0, len(resultTypes))
+	var resultDesc string
+	for i, resultType := range resultTypes {
+		if resultType == nil {
+			schemas = append(schemas, JSONSchema{"type": "null"})
+			continue
+		}
+		rt := reflect.TypeOf(resultType).Elem()
+		schema := typeSchema(xT, rt, make(map[reflect.Type]bool))
+		if !isComplexType(rt) {
+			resultDesc = xT(fmt.Sprintf("%s--result%d", method, i))
+			schema["description"] = resultDesc
+		}
+		if len(resultTypes) > 1 {
+			condKey := fmt.Sprintf("%s--condition%d", method, i)
+			schema["title"] = xT(condKey)
+		}
+		schemas = append(schemas, schema)
+	}
+	m.Result.Name = "result"
+	switch len(schemas) {
+	case 0:
+		m.Result.Schema = JSONSchema{"type": "null"}
+	case 1:
+		m.Result.Description = resultDesc
+		m.Result.Schema = schemas[0].(JSONSchema)
+	default:
+		m.Result.Schema = JSONSchema{"oneOf": schemas}
+	}
+	return m
+}
+
+// GenerateOpenRPC generates and returns an OpenRPC document describing the
+// provided methods with the provided API information, so client SDKs can be
+// generated from the registered commands.  The result types and descriptions
+// are provided as for GenerateHelp: resultTypes maps each method to its result
+// types, and the result types registered with RegisterCmdDoc are used for the
+// methods missing from it.  An error is returned for the last description
+// which is missing.
+func GenerateOpenRPC(info OpenRPCInfo, methods []string, descs map[string]string, resultTypes map[string][]interface{}) (*OpenRPCDocument, error) {
+	sortedMethods := make([]string, len(methods))
+	copy(sortedMethods, methods)
+	sort.Strings(sortedMethods)
+
+	doc := &OpenRPCDocument{
+		OpenRPC: OpenRPCVersion,
+		Info:    info,
+		Methods: make([]OpenRPCMethod, 0, len(methods)),
+	}
+	var missingKey string
+	for _, method := range sortedMethods {
+		registerLock.RLock()
+		rtp, ok := methodToConcreteType[method]
+		methodInfo := methodToInfo[method]
+		methodDoc := methodToDoc[method]
+		registerLock.RUnlock()
+		if !ok {
+			str := fmt.Sprintf("%q is not registered", method)
+			return nil, makeError(ErrUnregisteredMethod, str)
+		}
+
+		results, ok := resultTypes[method]
+		if !ok && methodDoc != nil {
+			results = methodDoc.ResultTypes()
+		}
+		if err := checkResultTypes(results); err != nil {
+			return nil, err
+		}
+
+		descLookup := newDescLookup(method, rtp, methodDoc, descs,
+			results)
+		m := methodOpenRPC(descLookup.lookup, rtp, methodInfo.defaults,
+			method, results)
+		if methodDoc != nil {
+			m.Version = methodDoc.Version
+		}
+		doc.Methods = append(doc.Methods, m)
+		if descLookup.missingKey != "" {
+			missingKey = descLookup.missingKey
+		}
+	}
+	if missingKey != "" {
+		return doc, makeError(ErrMissingDescription, missingKey)
+	}
+	return doc, nil
+}
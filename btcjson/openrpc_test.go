@@ -0,0 +1,109 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package btcjson_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/btcjson"
+)
+
+// TestGenerateOpenRPC ensures GenerateOpenRPC describes the parameters and
+// results of documented and undocumented commands as expected.
+func TestGenerateOpenRPC(t *testing.T) {
+	t.Parallel()
+
+	type openRPCTestResult struct {
+		Name   string   `json:"name" jsonrpcdesc:"The name"`
+		Scores []uint32 `json:"scores,omitempty" jsonrpcdesc:"The scores"`
+	}
+	type openRPCTestCmd struct {
+		Name  string
+		Count *int `jsonrpcdefault:"5" jsonrpcdesc:"The count"`
+	}
+	btcjson.MustRegisterCmd("openrpctestcmd", (*openRPCTestCmd)(nil), 0)
+
+	// The undocumented parameter must be described by the map.
+	info := btcjson.OpenRPCInfo{Title: "test", Version: "1.0.0"}
+	methods := []string{"openrpctestcmd", "help"}
+	resultTypes := map[string][]interface{}{
+		"openrpctestcmd": {(*openRPCTestResult)(nil)},
+		"help":           {(*string)(nil), (*string)(nil)},
+	}
+	_, err := btcjson.GenerateOpenRPC(info, methods, nil, resultTypes)
+	if jerr, ok := err.(btcjson.Error); !ok ||
+		jerr.ErrorCode != btcjson.ErrMissingDescription {
+
+		t.Fatalf("GenerateOpenRPC: got error %v, want %v", err,
+			btcjson.ErrMissingDescription)
+	}
+
+	descs := map[string]string{
+		"openrpctestcmd--synopsis": "Tests.\nIn detail.",
+		"openrpctestcmd-name":      "The name to test",
+		"help--synopsis":           "Help.",
+		"help-command":             "The command",
+		"help--condition0":         "no command",
+		"help--condition1":         "command",
+		"help--result0":            "The commands",
+		"help--result1":            "The help",
+	}
+	doc, err := btcjson.GenerateOpenRPC(info, methods, descs, resultTypes)
+	if err != nil {
+		t.Fatalf("GenerateOpenRPC: unexpected error: %v", err)
+	}
+	got, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("Marshal: unexpected error: %v", err)
+	}
+	want := `{"openrpc":"1.2.6","info":{"title":"test","version":"1.0.0"},` +
+		`"methods":[{"name":"help","summary":"Help.",` +
+		`"paramStructure":"by-position","params":[{"name":"command",` +
+		`"description":"The command","schema":{"type":"string"}}],` +
+		`"result":{"name":"result","schema":{"oneOf":[` +
+		`{"description":"The commands","title":"no command","type":"string"},` +
+		`{"description":"The help","title":"command","type":"string"}]}}},` +
+		`{"name":"openrpctestcmd","summary":"Tests.",` +
+		`"description":"Tests.\nIn detail.",` +
+		`"paramStructure":"by-position","params":[{"name":"name",` +
+		`"description":"The name to test","required":true,` +
+		`"schema":{"type":"string"}},{"name":"count",` +
+		`"description":"The count","schema":{"default":5,` +
+		`"type":"integer"}}],"result":{"name":"result","schema":{` +
+		`"properties":{"name":{"description":"The name","type":"string"},` +
+		`"scores":{"description":"The scores","items":{"type":"integer"},` +
+		`"type":"array"}},"required":["name"],"type":"object"}}}]}`
+	if string(got) != want {
+		t.Fatalf("GenerateOpenRPC: unexpected document - got\n%s\nwant\n%s",
+			got, want)
+	}
+
+	// Ensure the result types and version of documented commands are used.
+	doc, err = btcjson.GenerateOpenRPC(info, []string{"rpc.discover"}, nil,
+		nil)
+	if err != nil {
+		t.Fatalf("GenerateOpenRPC: unexpected error: %v", err)
+	}
+	method := doc.Methods[0]
+	if method.Version != 1 || method.Result.Schema["type"] != "object" {
+		t.Fatalf("GenerateOpenRPC: unexpected method %+v", method)
+	}
+	wantRequired := []string{"openrpc", "info", "methods"}
+	if !reflect.DeepEqual(method.Result.Schema["required"], wantRequired) {
+		t.Fatalf("GenerateOpenRPC: got required fields %v, want %v",
+			method.Result.Schema["required"], wantRequired)
+	}
+
+	_, err = btcjson.GenerateOpenRPC(info, []string{"boguscommand"}, nil,
+		nil)
+	if jerr, ok := err.(btcjson.Error); !ok ||
+		jerr.ErrorCode != btcjson.ErrUnregisteredMethod {
+
+		t.Fatalf("GenerateOpenRPC: got error %v, want %v", err,
+			btcjson.ErrUnregisteredMethod)
+	}
+}
@@ -90,6 +90,7 @@ var (
 	methodToConcreteType = make(map[string]reflect.Type)
 	methodToInfo         = make(map[string]methodInfo)
 	concreteTypeToMethod = make(map[reflect.Type]string)
+	methodToDoc          = make(map[string]*MethodDoc)
 )
 
 // baseKindString returns the base kind for a given reflect.Type after
@@ -278,6 +279,113 @@ func MustRegisterCmd(method string, cmd interface{}, flags UsageFlag) {
 	}
 }
 
+// ResultDoc documents one of the values a command returns.
+type ResultDoc struct {
+	// Type is a pointer to the type of the value, such as (*string)(nil),
+	// or nil when the command returns nothing.
+	Type interface{}
+
+	// Desc describes the value when it is not an object.  The fields of
+	// objects are described by the jsonrpcdesc struct tags of their type.
+	Desc string
+
+	// Condition describes when the value is returned.  It is only needed
+	// when the command returns one of several values.
+	Condition string
+}
+
+// MethodDoc documents a registered command, so its help and schema can be
+// generated without a separate descriptions map.  The parameters of the
+// command and the fields of its result objects are described by their
+// jsonrpcdesc struct tags, for example:
+//
+//	type FooCmd struct {
+//		Count *int `jsonrpcdefault:"10" jsonrpcdesc:"The number of foos"`
+//	}
+type MethodDoc struct {
+	// Version is the version of the command, which starts at 1 and is
+	// incremented whenever its parameters or results change in a way
+	// which is not backwards compatible, so clients can detect the
+	// change.
+	Version uint32
+
+	// Synopsis describes what the command does.
+	Synopsis string
+
+	// Results are the values the command returns.  It is empty when the
+	// command returns nothing.
+	Results []ResultDoc
+}
+
+// ResultTypes returns the result types of the documented command in the form
+// expected by GenerateHelp.
+func (d *MethodDoc) ResultTypes() []interface{} {
+	resultTypes := make([]interface{}, 0, len(d.Results))
+	for _, result := range d.Results {
+		resultTypes = append(resultTypes, result.Type)
+	}
+	return resultTypes
+}
+
+// RegisterCmdDoc registers the documentation of a registered command.  Every
+// parameter of the command must be described by a jsonrpcdesc struct tag, and
+// the result types must be valid as for GenerateHelp.
+func RegisterCmdDoc(method string, doc *MethodDoc) error {
+	registerLock.Lock()
+	defer registerLock.Unlock()
+
+	rtp, ok := methodToConcreteType[method]
+	if !ok {
+		str := fmt.Sprintf("%q is not registered", method)
+		return makeError(ErrUnregisteredMethod, str)
+	}
+	if _, ok := methodToDoc[method]; ok {
+		str := fmt.Sprintf("method %q is already documented", method)
+		return makeError(ErrDuplicateMethod, str)
+	}
+	if doc.Synopsis == "" {
+		return makeError(ErrMissingDescription, method+"--synopsis")
+	}
+
+	rt := rtp.Elem()
+	for i := 0; i < rt.NumField(); i++ {
+		rtf := rt.Field(i)
+		if rtf.Tag.Get("jsonrpcdesc") == "" {
+			key := method + "-" + strings.ToLower(rtf.Name)
+			return makeError(ErrMissingDescription, key)
+		}
+	}
+	if err := checkResultTypes(doc.ResultTypes()); err != nil {
+		return err
+	}
+
+	methodToDoc[method] = doc
+	return nil
+}
+
+// MustRegisterCmdDoc performs the same function as RegisterCmdDoc except it
+// panics if there is an error.  This should only be called from package init
+// functions.
+func MustRegisterCmdDoc(method string, doc *MethodDoc) {
+	if err := RegisterCmdDoc(method, doc); err != nil {
+		panic(fmt.Sprintf("failed to register documentation of %q: "+
+			"%v\n", method, err))
+	}
+}
+
+// RegisteredMethodDoc returns the documentation registered for the passed
+// method with RegisterCmdDoc.
+func RegisteredMethodDoc(method string) (*MethodDoc, error) {
+	registerLock.RLock()
+	doc, ok := methodToDoc[method]
+	registerLock.RUnlock()
+	if !ok {
+		str := fmt.Sprintf("%q is not documented", method)
+		return nil, makeError(ErrUnregisteredMethod, str)
+	}
+	return doc, nil
+}
+
 // RegisteredCmdMethods returns a sorted list of methods for all registered
 // commands.
 func RegisteredCmdMethods() []string {
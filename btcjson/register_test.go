@@ -263,3 +263,116 @@ func TestRegisteredCmdMethods(t *testing.T) {
 		t.Fatal("RegisteredCmdMethods: methods are not sorted")
 	}
 }
+
+// TestRegisterCmdDoc ensures the RegisterCmdDoc function returns the expected
+// errors and registers the documentation of commands.
+func TestRegisterCmdDoc(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		method string
+		doc    *btcjson.MethodDoc
+		err    btcjson.Error
+	}{
+		{
+			name:   "unregistered command",
+			method: "boguscommand",
+			doc:    &btcjson.MethodDoc{Synopsis: "test"},
+			err:    btcjson.Error{ErrorCode: btcjson.ErrUnregisteredMethod},
+		},
+		{
+			name:   "duplicate documentation",
+			method: "getxpubinfo",
+			doc:    &btcjson.MethodDoc{Synopsis: "test"},
+			err:    btcjson.Error{ErrorCode: btcjson.ErrDuplicateMethod},
+		},
+		{
+			name:   "missing synopsis",
+			method: "getblockcount",
+			doc:    &btcjson.MethodDoc{},
+			err:    btcjson.Error{ErrorCode: btcjson.ErrMissingDescription},
+		},
+		{
+			name:   "missing parameter description",
+			method: "getblock",
+			doc:    &btcjson.MethodDoc{Synopsis: "test"},
+			err:    btcjson.Error{ErrorCode: btcjson.ErrMissingDescription},
+		},
+		{
+			name:   "invalid result type",
+			method: "getblockcount",
+			doc: &btcjson.MethodDoc{
+				Synopsis: "test",
+				Results:  []btcjson.ResultDoc{{Type: 0}},
+			},
+			err: btcjson.Error{ErrorCode: btcjson.ErrInvalidType},
+		},
+	}
+
+	t.Logf("Running %d tests", len(tests))
+	for i, test := range tests {
+		err := btcjson.RegisterCmdDoc(test.method, test.doc)
+		if reflect.TypeOf(err) != reflect.TypeOf(test.err) {
+			t.Errorf("Test #%d (%s) wrong error - got %T (%[2]v), "+
+				"want %T", i, test.name, err, test.err)
+			continue
+		}
+		gotErrorCode := err.(btcjson.Error).ErrorCode
+		if gotErrorCode != test.err.ErrorCode {
+			t.Errorf("Test #%d (%s) mismatched error code - got "+
+				"%v (%v), want %v", i, test.name, gotErrorCode,
+				err, test.err.ErrorCode)
+			continue
+		}
+	}
+
+	// Ensure the documentation of a command is registered and returned.
+	type registerDocTestCmd struct {
+		Count *int `jsonrpcdesc:"The count"`
+	}
+	doc := &btcjson.MethodDoc{
+		Version:  2,
+		Synopsis: "test",
+		Results:  []btcjson.ResultDoc{{Type: (*int)(nil), Desc: "n"}},
+	}
+	btcjson.MustRegisterCmd("registerdoctestcmd",
+		(*registerDocTestCmd)(nil), 0)
+	if err := btcjson.RegisterCmdDoc("registerdoctestcmd", doc); err != nil {
+		t.Fatalf("RegisterCmdDoc: unexpected error: %v", err)
+	}
+	gotDoc, err := btcjson.RegisteredMethodDoc("registerdoctestcmd")
+	if err != nil {
+		t.Fatalf("RegisteredMethodDoc: unexpected error: %v", err)
+	}
+	if gotDoc != doc {
+		t.Fatalf("RegisteredMethodDoc: got %+v, want %+v", gotDoc, doc)
+	}
+	wantTypes := []interface{}{(*int)(nil)}
+	if !reflect.DeepEqual(doc.ResultTypes(), wantTypes) {
+		t.Fatalf("ResultTypes: got %v, want %v", doc.ResultTypes(),
+			wantTypes)
+	}
+	if _, err := btcjson.RegisteredMethodDoc("getblock"); err == nil {
+		t.Fatal("RegisteredMethodDoc: no error for undocumented " +
+			"command")
+	}
+}
+
+// TestMustRegisterCmdDocPanic ensures the MustRegisterCmdDoc function panics
+// when used to document an unregistered command.
+func TestMustRegisterCmdDocPanic(t *testing.T) {
+	t.Parallel()
+
+	// Setup a defer to catch the expected panic to ensure it actually
+	// paniced.
+	defer func() {
+		if err := recover(); err == nil {
+			t.Error("MustRegisterCmdDoc did not panic as expected")
+		}
+	}()
+
+	// Intentionally try to document an unregistered command to force a
+	// panic.
+	btcjson.MustRegisterCmdDoc("panicme", &btcjson.MethodDoc{})
+}
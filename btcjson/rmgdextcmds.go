@@ -12,22 +12,940 @@ package btcjson
 // SetValidateKeysCmd defines the setvalidatekeys JSON-RPC command.
 // This command is not a standard command, it is an extension for operating
 // prova.
+//
+// The optional Passphrase field encrypts the private keys with it and stores
+// them in the validate keystore of the node.
 type SetValidateKeysCmd struct {
-	PrivKeys []string
+	PrivKeys   []string `jsonrpcdesc:"Hex-encoded 32 byte private keys"`
+	Passphrase *string  `jsonrpcdesc:"Passphrase to encrypt the private keys with and store them on disk, replacing the keys stored before, so they can be unlocked with unlockvalidatekeys after a restart"`
 }
 
 // NewSetValidateKeysCmd returns a new SetValidateKeysCmd which can
 // be used to issue a setvalidatekeys JSON-RPC command.  This command is
 // not a standard command. It is an extension for prova.
-func NewSetValidateKeysCmd(privKeys []string) *SetValidateKeysCmd {
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSetValidateKeysCmd(privKeys []string, passphrase *string) *SetValidateKeysCmd {
 	return &SetValidateKeysCmd{
-		PrivKeys: privKeys,
+		PrivKeys:   privKeys,
+		Passphrase: passphrase,
 	}
 }
 
+// UnlockValidateKeysCmd defines the unlockvalidatekeys JSON-RPC command.  This
+// command is not a standard command, it is an extension for operating prova.
+type UnlockValidateKeysCmd struct {
+	Passphrase string `jsonrpcdesc:"The passphrase the private keys were stored with"`
+	Timeout    int64  `jsonrpcdesc:"The number of seconds after which the private keys are locked again, or 0 to keep them unlocked"`
+}
+
+// NewUnlockValidateKeysCmd returns a new UnlockValidateKeysCmd which can be
+// used to issue an unlockvalidatekeys JSON-RPC command.  This command is not a
+// standard command. It is an extension for prova.
+func NewUnlockValidateKeysCmd(passphrase string, timeout int64) *UnlockValidateKeysCmd {
+	return &UnlockValidateKeysCmd{
+		Passphrase: passphrase,
+		Timeout:    timeout,
+	}
+}
+
+// LockValidateKeysCmd defines the lockvalidatekeys JSON-RPC command.  This
+// command is not a standard command, it is an extension for operating prova.
+type LockValidateKeysCmd struct{}
+
+// NewLockValidateKeysCmd returns a new LockValidateKeysCmd which can be used to
+// issue a lockvalidatekeys JSON-RPC command.  This command is not a standard
+// command. It is an extension for prova.
+func NewLockValidateKeysCmd() *LockValidateKeysCmd {
+	return &LockValidateKeysCmd{}
+}
+
+// GetUnconfirmedBroadcastsCmd defines the getunconfirmedbroadcasts JSON-RPC
+// command.  This command is not a standard command, it is an extension for
+// operating prova.
+type GetUnconfirmedBroadcastsCmd struct{}
+
+// NewGetUnconfirmedBroadcastsCmd returns a new GetUnconfirmedBroadcastsCmd
+// which can be used to issue a getunconfirmedbroadcasts JSON-RPC command.
+// This command is not a standard command. It is an extension for prova.
+func NewGetUnconfirmedBroadcastsCmd() *GetUnconfirmedBroadcastsCmd {
+	return &GetUnconfirmedBroadcastsCmd{}
+}
+
+// FundRawTransactionCmd defines the fundrawtransaction JSON-RPC command.
+// This command is not a standard command, it is an extension for operating
+// prova.
+type FundRawTransactionCmd struct {
+	HexTx         string   `jsonrpcdesc:"Serialized, hex-encoded transaction to fund"`
+	Addresses     []string `jsonrpcdesc:"The addresses whose unspent outputs may be spent to fund the transaction"`
+	ChangeAddress string   `jsonrpcdesc:"The address to pay change to"`
+	FeeRate       *float64 `jsonrpcdesc:"The fee rate to pay in DMG/kB (default: the minimum relay fee)"`
+}
+
+// NewFundRawTransactionCmd returns a new FundRawTransactionCmd which can be
+// used to issue a fundrawtransaction JSON-RPC command.  This command is not a
+// standard command. It is an extension for prova.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewFundRawTransactionCmd(hexTx string, addresses []string, changeAddress string, feeRate *float64) *FundRawTransactionCmd {
+	return &FundRawTransactionCmd{
+		HexTx:         hexTx,
+		Addresses:     addresses,
+		ChangeAddress: changeAddress,
+		FeeRate:       feeRate,
+	}
+}
+
+// SendRawPackageCmd defines the sendrawpackage JSON-RPC command.  This
+// command is not a standard command, it is an extension for operating prova.
+type SendRawPackageCmd struct {
+	HexTxs []string `jsonrpcdesc:"Serialized, hex-encoded signed transactions ordered so each only spends outputs of those before it"`
+}
+
+// NewSendRawPackageCmd returns a new SendRawPackageCmd which can be used to
+// issue a sendrawpackage JSON-RPC command.  This command is not a standard
+// command. It is an extension for prova.
+func NewSendRawPackageCmd(hexTxs []string) *SendRawPackageCmd {
+	return &SendRawPackageCmd{
+		HexTxs: hexTxs,
+	}
+}
+
+// GetFreezeStatusCmd defines the getfreezestatus JSON-RPC command.  This
+// command is not a standard command, it is an extension for operating prova.
+type GetFreezeStatusCmd struct {
+	Address string `jsonrpcdesc:"The prova address to query the freeze status for"`
+}
+
+// NewGetFreezeStatusCmd returns a new GetFreezeStatusCmd which can be used to
+// issue a getfreezestatus JSON-RPC command.  This command is not a standard
+// command. It is an extension for prova.
+func NewGetFreezeStatusCmd(address string) *GetFreezeStatusCmd {
+	return &GetFreezeStatusCmd{
+		Address: address,
+	}
+}
+
+// GetReserveProofCmd defines the getreserveproof JSON-RPC command.  This
+// command is not a standard command, it is an extension for operating prova.
+//
+// The optional KeyIDs field adds the unspent outputs paying to an address
+// that includes one of the given ASP key ids.
+type GetReserveProofCmd struct {
+	Addresses []string  `jsonrpcdesc:"The addresses to attest the unspent outputs of"`
+	KeyIDs    *[]uint32 `jsonrpcdesc:"The ASP key ids to attest the unspent outputs of"`
+}
+
+// NewGetReserveProofCmd returns a new GetReserveProofCmd which can be used to
+// issue a getreserveproof JSON-RPC command.  This command is not a standard
+// command. It is an extension for prova.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetReserveProofCmd(addresses []string, keyIDs *[]uint32) *GetReserveProofCmd {
+	return &GetReserveProofCmd{
+		Addresses: addresses,
+		KeyIDs:    keyIDs,
+	}
+}
+
+// VerifyReserveAttestationCmd defines the verifyreserveattestation JSON-RPC
+// command.  This command is not a standard command, it is an extension for
+// operating prova.
+type VerifyReserveAttestationCmd struct {
+	HexAttestation string `jsonrpcdesc:"Hex-encoded bytes of the serialized signed attestation"`
+}
+
+// NewVerifyReserveAttestationCmd returns a new VerifyReserveAttestationCmd
+// which can be used to issue a verifyreserveattestation JSON-RPC command.  This
+// command is not a standard command. It is an extension for prova.
+func NewVerifyReserveAttestationCmd(hexAttestation string) *VerifyReserveAttestationCmd {
+	return &VerifyReserveAttestationCmd{
+		HexAttestation: hexAttestation,
+	}
+}
+
+// GetCommitmentsCmd defines the getcommitments JSON-RPC command.  This
+// command is not a standard command, it is an extension for operating prova.
+type GetCommitmentsCmd struct {
+	Commitment string `jsonrpcdesc:"The hex-encoded 32-byte commitment as pushed by the commitment script"`
+}
+
+// NewGetCommitmentsCmd returns a new GetCommitmentsCmd which can be used to
+// issue a getcommitments JSON-RPC command.  This command is not a standard
+// command. It is an extension for prova.
+func NewGetCommitmentsCmd(commitment string) *GetCommitmentsCmd {
+	return &GetCommitmentsCmd{
+		Commitment: commitment,
+	}
+}
+
+// ImportXpubCmd defines the importxpub JSON-RPC command.  This command is not a
+// standard command, it is an extension for operating prova.
+type ImportXpubCmd struct {
+	Xpub     string   `jsonrpcdesc:"The account extended public key to watch"`
+	KeyIDs   []uint32 `jsonrpcdesc:"The key ids of the deposit addresses"`
+	GapLimit *uint32  `jsonrpcdefault:"20" jsonrpcdesc:"The number of addresses to watch past the last used address"`
+	Rescan   *bool    `jsonrpcdefault:"true" jsonrpcdesc:"Scan the main chain for the transactions of the addresses before they are watched"`
+	Label    *string  `jsonrpcdesc:"The label of the addresses, which the account parameter of the watch-only RPCs selects addresses by"`
+}
+
+// NewImportXpubCmd returns a new ImportXpubCmd which can be used to issue an
+// importxpub JSON-RPC command.  This command is not a standard command. It is
+// an extension for prova.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewImportXpubCmd(xpub string, keyIDs []uint32, gapLimit *uint32, rescan *bool, label *string) *ImportXpubCmd {
+	return &ImportXpubCmd{
+		Xpub:     xpub,
+		KeyIDs:   keyIDs,
+		GapLimit: gapLimit,
+		Rescan:   rescan,
+		Label:    label,
+	}
+}
+
+// GetXpubInfoCmd defines the getxpubinfo JSON-RPC command.  This command is not
+// a standard command, it is an extension for operating prova.
+type GetXpubInfoCmd struct {
+	Xpub string `jsonrpcdesc:"The watched account extended public key"`
+}
+
+// NewGetXpubInfoCmd returns a new GetXpubInfoCmd which can be used to issue a
+// getxpubinfo JSON-RPC command.  This command is not a standard command. It is
+// an extension for prova.
+func NewGetXpubInfoCmd(xpub string) *GetXpubInfoCmd {
+	return &GetXpubInfoCmd{
+		Xpub: xpub,
+	}
+}
+
+// GetAddressHistoryCmd defines the getaddresshistory JSON-RPC command.  This
+// command is not a standard command, it is an extension for operating prova.
+type GetAddressHistoryCmd struct {
+	Address  string `jsonrpcdesc:"The address to return the history of"`
+	Page     *int   `jsonrpcdefault:"0" jsonrpcdesc:"The page to return, starting at 0 for the newest transactions"`
+	PageSize *int   `jsonrpcdefault:"100" jsonrpcdesc:"The number of transactions per page, at most 1000"`
+}
+
+// NewGetAddressHistoryCmd returns a new GetAddressHistoryCmd which can be used
+// to issue a getaddresshistory JSON-RPC command.  This command is not a
+// standard command. It is an extension for prova.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetAddressHistoryCmd(address string, page, pageSize *int) *GetAddressHistoryCmd {
+	return &GetAddressHistoryCmd{
+		Address:  address,
+		Page:     page,
+		PageSize: pageSize,
+	}
+}
+
+// GetChainStatsCmd defines the getchainstats JSON-RPC command.  This command
+// is not a standard command, it is an extension for operating prova.
+type GetChainStatsCmd struct {
+	From string `jsonrpcdesc:"The first UTC day to return in the format YYYY-MM-DD"`
+	To   string `jsonrpcdesc:"The last UTC day to return in the format YYYY-MM-DD"`
+}
+
+// NewGetChainStatsCmd returns a new GetChainStatsCmd which can be used to issue
+// a getchainstats JSON-RPC command.  This command is not a standard command. It
+// is an extension for prova.
+func NewGetChainStatsCmd(from, to string) *GetChainStatsCmd {
+	return &GetChainStatsCmd{
+		From: from,
+		To:   to,
+	}
+}
+
+// GetKeyStateDiffCmd defines the getkeystatediff JSON-RPC command.  This
+// command is not a standard command, it is an extension for operating prova.
+type GetKeyStateDiffCmd struct {
+	Height1 uint32 `jsonrpcdesc:"The height of the admin state to compare from"`
+	Height2 uint32 `jsonrpcdesc:"The height of the admin state to compare to"`
+}
+
+// NewGetKeyStateDiffCmd returns a new GetKeyStateDiffCmd which can be used to
+// issue a getkeystatediff JSON-RPC command.  This command is not a standard
+// command. It is an extension for prova.
+func NewGetKeyStateDiffCmd(height1, height2 uint32) *GetKeyStateDiffCmd {
+	return &GetKeyStateDiffCmd{
+		Height1: height1,
+		Height2: height2,
+	}
+}
+
+// GetReorgHistoryCmd defines the getreorghistory JSON-RPC command.  This
+// command is not a standard command, it is an extension for operating prova.
+type GetReorgHistoryCmd struct {
+	Count *int `jsonrpcdefault:"10" jsonrpcdesc:"The maximum number of reorganizations to return"`
+	Skip  *int `jsonrpcdefault:"0" jsonrpcdesc:"The number of the newest reorganizations to skip"`
+}
+
+// NewGetReorgHistoryCmd returns a new GetReorgHistoryCmd which can be used to
+// issue a getreorghistory JSON-RPC command.  This command is not a standard
+// command. It is an extension for prova.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetReorgHistoryCmd(count, skip *int) *GetReorgHistoryCmd {
+	return &GetReorgHistoryCmd{
+		Count: count,
+		Skip:  skip,
+	}
+}
+
+// GetFinalityStatusCmd defines the getfinalitystatus JSON-RPC command.  This
+// command is not a standard command, it is an extension for operating prova.
+type GetFinalityStatusCmd struct {
+	Hash string `jsonrpcdesc:"The hash of the block or transaction"`
+}
+
+// NewGetFinalityStatusCmd returns a new GetFinalityStatusCmd which can be used
+// to issue a getfinalitystatus JSON-RPC command.  This command is not a
+// standard command. It is an extension for prova.
+func NewGetFinalityStatusCmd(hash string) *GetFinalityStatusCmd {
+	return &GetFinalityStatusCmd{
+		Hash: hash,
+	}
+}
+
+// GetSignedCheckpointsCmd defines the getsignedcheckpoints JSON-RPC command.
+// This command is not a standard command, it is an extension for operating
+// prova.
+type GetSignedCheckpointsCmd struct {
+	FromHeight *uint32 `jsonrpcdefault:"0" jsonrpcdesc:"The height of the first checkpoint to return"`
+}
+
+// NewGetSignedCheckpointsCmd returns a new GetSignedCheckpointsCmd which can be
+// used to issue a getsignedcheckpoints JSON-RPC command.  This command is not a
+// standard command. It is an extension for prova.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetSignedCheckpointsCmd(fromHeight *uint32) *GetSignedCheckpointsCmd {
+	return &GetSignedCheckpointsCmd{
+		FromHeight: fromHeight,
+	}
+}
+
+// AddSignedCheckpointCmd defines the addsignedcheckpoint JSON-RPC command.
+// This command is not a standard command, it is an extension for operating
+// prova.
+type AddSignedCheckpointCmd struct {
+	HexCheckpoint string `jsonrpcdesc:"The serialized, hex-encoded signed checkpoint as returned by getsignedcheckpoints"`
+}
+
+// NewAddSignedCheckpointCmd returns a new AddSignedCheckpointCmd which can be
+// used to issue an addsignedcheckpoint JSON-RPC command.  This command is not a
+// standard command. It is an extension for prova.
+func NewAddSignedCheckpointCmd(hexCheckpoint string) *AddSignedCheckpointCmd {
+	return &AddSignedCheckpointCmd{
+		HexCheckpoint: hexCheckpoint,
+	}
+}
+
+// GetPeerEvictionsCmd defines the getpeerevictions JSON-RPC command.  This
+// command is not a standard command, it is an extension for operating prova.
+type GetPeerEvictionsCmd struct{}
+
+// NewGetPeerEvictionsCmd returns a new GetPeerEvictionsCmd which can be used to
+// issue a getpeerevictions JSON-RPC command.  This command is not a standard
+// command. It is an extension for prova.
+func NewGetPeerEvictionsCmd() *GetPeerEvictionsCmd {
+	return &GetPeerEvictionsCmd{}
+}
+
+// GetSyncStatusCmd defines the getsyncstatus JSON-RPC command.  This command
+// is not a standard command, it is an extension for operating prova.
+type GetSyncStatusCmd struct{}
+
+// NewGetSyncStatusCmd returns a new GetSyncStatusCmd which can be used to issue
+// a getsyncstatus JSON-RPC command.  This command is not a standard command. It
+// is an extension for prova.
+func NewGetSyncStatusCmd() *GetSyncStatusCmd {
+	return &GetSyncStatusCmd{}
+}
+
+// CheckCanonicalEncodingCmd defines the checkcanonicalencoding JSON-RPC
+// command.  This command is not a standard command, it is an extension for
+// operating prova.
+type CheckCanonicalEncodingCmd struct {
+	HexData string `jsonrpcdesc:"Serialized, hex-encoded transaction or block"`
+	Block   *bool  `jsonrpcdefault:"false" jsonrpcdesc:"Whether the data is a block rather than a transaction"`
+}
+
+// NewCheckCanonicalEncodingCmd returns a new CheckCanonicalEncodingCmd which
+// can be used to issue a checkcanonicalencoding JSON-RPC command.  This command
+// is not a standard command. It is an extension for prova.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewCheckCanonicalEncodingCmd(hexData string, block *bool) *CheckCanonicalEncodingCmd {
+	return &CheckCanonicalEncodingCmd{
+		HexData: hexData,
+		Block:   block,
+	}
+}
+
+// GetValidatorViolationsCmd defines the getvalidatorviolations JSON-RPC
+// command.  This command is not a standard command, it is an extension for
+// operating prova.
+type GetValidatorViolationsCmd struct {
+	Count *int `jsonrpcdefault:"10" jsonrpcdesc:"The maximum number of violations to return"`
+	Skip  *int `jsonrpcdefault:"0" jsonrpcdesc:"The number of the highest violations to skip"`
+}
+
+// NewGetValidatorViolationsCmd returns a new GetValidatorViolationsCmd which
+// can be used to issue a getvalidatorviolations JSON-RPC command.  This command
+// is not a standard command. It is an extension for prova.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetValidatorViolationsCmd(count, skip *int) *GetValidatorViolationsCmd {
+	return &GetValidatorViolationsCmd{
+		Count: count,
+		Skip:  skip,
+	}
+}
+
+// GetAnnouncementsCmd defines the getannouncements JSON-RPC command.  This
+// command is not a standard command, it is an extension for operating prova.
+type GetAnnouncementsCmd struct{}
+
+// NewGetAnnouncementsCmd returns a new GetAnnouncementsCmd which can be used
+// to issue a getannouncements JSON-RPC command.  This command is not a standard
+// command. It is an extension for prova.
+func NewGetAnnouncementsCmd() *GetAnnouncementsCmd {
+	return &GetAnnouncementsCmd{}
+}
+
+// SendAnnouncementCmd defines the sendannouncement JSON-RPC command.  This
+// command is not a standard command, it is an extension for operating prova.
+type SendAnnouncementCmd struct {
+	HexAnnouncement string `jsonrpcdesc:"Serialized, hex-encoded signed announcement"`
+}
+
+// NewSendAnnouncementCmd returns a new SendAnnouncementCmd which can be used
+// to issue a sendannouncement JSON-RPC command.  This command is not a standard
+// command. It is an extension for prova.
+func NewSendAnnouncementCmd(hexAnnouncement string) *SendAnnouncementCmd {
+	return &SendAnnouncementCmd{
+		HexAnnouncement: hexAnnouncement,
+	}
+}
+
+// GetKeyExpiriesCmd defines the getkeyexpiries JSON-RPC command.  This
+// command is not a standard command, it is an extension for operating prova.
+type GetKeyExpiriesCmd struct{}
+
+// NewGetKeyExpiriesCmd returns a new GetKeyExpiriesCmd which can be used to
+// issue a getkeyexpiries JSON-RPC command.  This command is not a standard
+// command. It is an extension for prova.
+func NewGetKeyExpiriesCmd() *GetKeyExpiriesCmd {
+	return &GetKeyExpiriesCmd{}
+}
+
+// GetAddressUtxosCmd defines the getaddressutxos JSON-RPC command.  This
+// command is not a standard command, it is an extension for operating prova.
+type GetAddressUtxosCmd struct {
+	Addresses []string `jsonrpcdesc:"The addresses to return the spendable outputs of"`
+	MinConf   *int     `jsonrpcdefault:"1" jsonrpcdesc:"The minimum number of confirmations of the returned outputs"`
+	KeyID     *uint32  `jsonrpcdesc:"Only return the outputs whose spending can be co-signed by the ASP key with this key ID"`
+}
+
+// NewGetAddressUtxosCmd returns a new GetAddressUtxosCmd which can be used to
+// issue a getaddressutxos JSON-RPC command.  This command is not a standard
+// command. It is an extension for prova.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetAddressUtxosCmd(addresses []string, minConf *int, keyID *uint32) *GetAddressUtxosCmd {
+	return &GetAddressUtxosCmd{
+		Addresses: addresses,
+		MinConf:   minConf,
+		KeyID:     keyID,
+	}
+}
+
+// GetSweepPlanCmd defines the getsweepplan JSON-RPC command.  This command is
+// not a standard command, it is an extension for operating prova.
+type GetSweepPlanCmd struct {
+	OldKeyID  uint32   `jsonrpcdesc:"The key ID the outputs to sweep reference"`
+	NewKeyID  uint32   `jsonrpcdesc:"The key ID replacing the old key ID in the new outputs"`
+	FeeRate   *float64 `jsonrpcdesc:"The fee rate in DMG/kB (default: the minimum relay fee)"`
+	MaxTxSize *int     `jsonrpcdesc:"The maximum estimated size of a signed transaction in bytes (default: the maximum standard transaction size)"`
+}
+
+// NewGetSweepPlanCmd returns a new GetSweepPlanCmd which can be used to issue
+// a getsweepplan JSON-RPC command.  This command is not a standard command. It
+// is an extension for prova.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetSweepPlanCmd(oldKeyID, newKeyID uint32, feeRate *float64, maxTxSize *int) *GetSweepPlanCmd {
+	return &GetSweepPlanCmd{
+		OldKeyID:  oldKeyID,
+		NewKeyID:  newKeyID,
+		FeeRate:   feeRate,
+		MaxTxSize: maxTxSize,
+	}
+}
+
+// GetKeyIDCensusCmd defines the getkeyidcensus JSON-RPC command.  This command
+// is not a standard command, it is an extension for operating prova.
+type GetKeyIDCensusCmd struct {
+	KeyID  *uint32 `jsonrpcdesc:"Only report the usage of this key ID"`
+	Rescan *bool   `jsonrpcdefault:"false" jsonrpcdesc:"Start a new census in the background unless one is running"`
+}
+
+// NewGetKeyIDCensusCmd returns a new GetKeyIDCensusCmd which can be used to
+// issue a getkeyidcensus JSON-RPC command.  This command is not a standard
+// command. It is an extension for prova.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetKeyIDCensusCmd(keyID *uint32, rescan *bool) *GetKeyIDCensusCmd {
+	return &GetKeyIDCensusCmd{
+		KeyID:  keyID,
+		Rescan: rescan,
+	}
+}
+
+// GetTransactionStatusCmd defines the gettransactionstatus JSON-RPC command.
+// This command is not a standard command, it is an extension for operating
+// prova.
+type GetTransactionStatusCmd struct {
+	TxIDs []string `jsonrpcdesc:"The hashes of the transactions"`
+}
+
+// NewGetTransactionStatusCmd returns a new GetTransactionStatusCmd which can be
+// used to issue a gettransactionstatus JSON-RPC command.  This command is not a
+// standard command. It is an extension for prova.
+func NewGetTransactionStatusCmd(txIDs []string) *GetTransactionStatusCmd {
+	return &GetTransactionStatusCmd{
+		TxIDs: txIDs,
+	}
+}
+
+// GetBlockPropagationCmd defines the getblockpropagation JSON-RPC command.
+// This command is not a standard command, it is an extension for operating
+// prova.
+type GetBlockPropagationCmd struct {
+	Count *int `jsonrpcdefault:"20" jsonrpcdesc:"The maximum number of the most recently announced blocks to return"`
+}
+
+// NewGetBlockPropagationCmd returns a new GetBlockPropagationCmd which can be
+// used to issue a getblockpropagation JSON-RPC command.  This command is not a
+// standard command. It is an extension for prova.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetBlockPropagationCmd(count *int) *GetBlockPropagationCmd {
+	return &GetBlockPropagationCmd{
+		Count: count,
+	}
+}
+
+// PrioritiseTransactionCmd defines the prioritisetransaction JSON-RPC command.
+// This command is not a standard command, it is an extension for operating
+// prova.
+type PrioritiseTransactionCmd struct {
+	TxID     string `jsonrpcdesc:"The hash of the transaction"`
+	FeeDelta int64  `jsonrpcdesc:"The fee in atoms to add to the fee of the transaction when selecting transactions for block templates, or to subtract when negative"`
+}
+
+// NewPrioritiseTransactionCmd returns a new PrioritiseTransactionCmd which can
+// be used to issue a prioritisetransaction JSON-RPC command.  This command is
+// not a standard command. It is an extension for prova.
+func NewPrioritiseTransactionCmd(txID string, feeDelta int64) *PrioritiseTransactionCmd {
+	return &PrioritiseTransactionCmd{
+		TxID:     txID,
+		FeeDelta: feeDelta,
+	}
+}
+
+// TemplateExclusionSubCmd defines the type used in the settemplateexclusion
+// JSON-RPC command for the sub command field.
+type TemplateExclusionSubCmd string
+
+const (
+	// TEAdd indicates the specified transaction or address should be
+	// excluded from block templates.
+	TEAdd TemplateExclusionSubCmd = "add"
+
+	// TERemove indicates the specified transaction or address should no
+	// longer be excluded from block templates.
+	TERemove TemplateExclusionSubCmd = "remove"
+)
+
+// SetTemplateExclusionCmd defines the settemplateexclusion JSON-RPC command.
+// This command is not a standard command, it is an extension for operating
+// prova.
+type SetTemplateExclusionCmd struct {
+	Target string                  `jsonrpcdesc:"The hash of the transaction or the address to exclude"`
+	SubCmd TemplateExclusionSubCmd `jsonrpcusage:"\"add|remove\"" jsonrpcdesc:"Whether to add the target to the exclusions or remove it"`
+}
+
+// NewSetTemplateExclusionCmd returns a new SetTemplateExclusionCmd which can be
+// used to issue a settemplateexclusion JSON-RPC command.  This command is not a
+// standard command. It is an extension for prova.
+func NewSetTemplateExclusionCmd(target string, subCmd TemplateExclusionSubCmd) *SetTemplateExclusionCmd {
+	return &SetTemplateExclusionCmd{
+		Target: target,
+		SubCmd: subCmd,
+	}
+}
+
+// GetTemplateExclusionsCmd defines the gettemplateexclusions JSON-RPC command.
+// This command is not a standard command, it is an extension for operating
+// prova.
+type GetTemplateExclusionsCmd struct{}
+
+// NewGetTemplateExclusionsCmd returns a new GetTemplateExclusionsCmd which can
+// be used to issue a gettemplateexclusions JSON-RPC command.  This command is
+// not a standard command. It is an extension for prova.
+func NewGetTemplateExclusionsCmd() *GetTemplateExclusionsCmd {
+	return &GetTemplateExclusionsCmd{}
+}
+
+// GetBlockAtHeightByTimeCmd defines the getblockatheightbytime JSON-RPC
+// command.  This command is not a standard command, it is an extension for
+// operating prova.
+type GetBlockAtHeightByTimeCmd struct {
+	Time int64 `jsonrpcdesc:"The time in seconds since 1 Jan 1970 GMT"`
+}
+
+// NewGetBlockAtHeightByTimeCmd returns a new GetBlockAtHeightByTimeCmd which
+// can be used to issue a getblockatheightbytime JSON-RPC command.  This command
+// is not a standard command. It is an extension for prova.
+func NewGetBlockAtHeightByTimeCmd(time int64) *GetBlockAtHeightByTimeCmd {
+	return &GetBlockAtHeightByTimeCmd{
+		Time: time,
+	}
+}
+
+// RPCDiscoverCmd defines the rpc.discover JSON-RPC command.  This command is
+// not a standard command, it is an extension for operating prova.
+type RPCDiscoverCmd struct{}
+
+// NewRPCDiscoverCmd returns a new RPCDiscoverCmd which can be used to issue an
+// rpc.discover JSON-RPC command.  This command is not a standard command. It
+// is an extension for prova.
+func NewRPCDiscoverCmd() *RPCDiscoverCmd {
+	return &RPCDiscoverCmd{}
+}
+
+// rmgdExtCmdDocs documents the commands in this file.  Their parameters and
+// the fields of their results are described by jsonrpcdesc struct tags.
+var rmgdExtCmdDocs = map[string]*MethodDoc{
+	"fundrawtransaction": {
+		Version: 1,
+		Synopsis: "Adds inputs spending unspent outputs of the passed addresses to a raw transaction until its outputs and fee are covered, paying any change to the change address.\n" +
+			"The inputs are left unsigned.  Requires the address index (--addrindex).",
+		Results: []ResultDoc{{Type: (*FundRawTransactionResult)(nil)}},
+	},
+	"setvalidatekeys": {
+		Version: 1,
+		Synopsis: "Sets the private keys to use to sign generated blocks.\n" +
+			"The public keys are recorded in the audit log when it is enabled.",
+	},
+	"unlockvalidatekeys": {
+		Version: 1,
+		Synopsis: "Decrypts the private keys stored on disk by setvalidatekeys and uses them to sign generated blocks.\n" +
+			"The public keys are recorded in the audit log when it is enabled.",
+	},
+	"lockvalidatekeys": {
+		Version:  1,
+		Synopsis: "Stops signing generated blocks with the private keys set by setvalidatekeys or unlockvalidatekeys until they are set or unlocked again.",
+	},
+	"getunconfirmedbroadcasts": {
+		Version:  1,
+		Synopsis: "Returns the locally submitted transactions which are being rebroadcast until they are included in a block, along with the peers which announced them back.",
+		Results:  []ResultDoc{{Type: (*[]UnconfirmedBroadcastResult)(nil)}},
+	},
+	"sendrawpackage": {
+		Version: 1,
+		Synopsis: "Submits a package of serialized, hex-encoded transactions to the local peer and relays them to the network.\n" +
+			"The transactions are accepted atomically in the passed order, so each may depend on the outputs and admin operations of those before it.",
+		Results: []ResultDoc{{
+			Type: (*[]string)(nil),
+			Desc: "The hashes of the transactions in the package",
+		}},
+	},
+	"getfreezestatus": {
+		Version: 1,
+		Synopsis: "Returns whether the account of a prova address is frozen in the best chain.\n" +
+			"Outputs paying to the pubKeyHash of a frozen account can not be spent until it is unfrozen.",
+		Results: []ResultDoc{{Type: (*GetFreezeStatusResult)(nil)}},
+	},
+	"getreserveproof": {
+		Version: 1,
+		Synopsis: "Returns an unsigned proof-of-reserves attestation of the unspent outputs paying to the passed addresses and keyids at the best block.\n" +
+			"Each output comes with a merkle proof tying its transaction to the block it was mined in.\n" +
+			"Addresses require the address index to be enabled, while keyids are found by scanning the unspent transaction output set.",
+		Results: []ResultDoc{{Type: (*GetReserveProofResult)(nil)}},
+	},
+	"verifyreserveattestation": {
+		Version: 1,
+		Synopsis: "Verifies the signature and merkle proofs of a signed proof-of-reserves attestation against the main chain.\n" +
+			"The proofs do not show that the attested outputs are still unspent.",
+		Results: []ResultDoc{{Type: (*VerifyReserveAttestationResult)(nil)}},
+	},
+	"getcommitments": {
+		Version: 1,
+		Synopsis: "Returns the transactions in the main chain with an output committing to the passed metadata commitment, in the order they were mined in.\n" +
+			"Requires the commitment index to be enabled (--commitmentindex).",
+		Results: []ResultDoc{{Type: (*[]CommitmentResult)(nil)}},
+	},
+	"importxpub": {
+		Version: 1,
+		Synopsis: "Adds an account extended public key to the extended keys watched by the watch-only index, or changes its key ids, gap limit and label when it is already watched.\n" +
+			"The deposit addresses are derived from the external branch of the key (.../0/i) as Prova addresses with the passed key ids, and are watched up to the gap limit of addresses past the last address which received an output.\n" +
+			"More addresses are derived and watched as deposits to them are seen in new blocks.\n" +
+			"Requires the watch-only index to be enabled (--watchindex).",
+	},
+	"getxpubinfo": {
+		Version: 1,
+		Synopsis: "Returns the state of an account extended public key watched by the watch-only index, including the next unused deposit address.\n" +
+			"Requires the watch-only index to be enabled (--watchindex).",
+		Results: []ResultDoc{{Type: (*GetXpubInfoResult)(nil)}},
+	},
+	"getaddresshistory": {
+		Version: 1,
+		Synopsis: "Returns a page of the transactions of an address, newest first, with the net change and running balance of the address after each transaction.\n" +
+			"The confirmed transactions are followed by those in the memory pool.\n" +
+			"Requires the address index to be enabled (--addrindex).",
+		Results: []ResultDoc{{Type: (*GetAddressHistoryResult)(nil)}},
+	},
+	"getchainstats": {
+		Version: 1,
+		Synopsis: "Returns daily aggregates of the blocks in the main chain for reporting, oldest first.\n" +
+			"The blocks are aggregated by the UTC day of their timestamp, and days without blocks are left out.\n" +
+			"Requires the chain statistics index to be enabled (--statsindex).",
+		Results: []ResultDoc{{Type: (*[]ChainStatsResult)(nil)}},
+	},
+	"getkeystatediff": {
+		Version:  1,
+		Synopsis: "Returns the admin key additions and revocations, ASP key changes and supply changes of the blocks after the first height up to and including the second height, in the order they were mined in, along with the transactions causing them.",
+		Results:  []ResultDoc{{Type: (*GetKeyStateDiffResult)(nil)}},
+	},
+	"getreorghistory": {
+		Version:  1,
+		Synopsis: "Returns the reorganizations of the main chain recorded by the node, newest first.",
+		Results: []ResultDoc{{
+			Type: (*[]ReorgEventResult)(nil),
+			Desc: "The recorded reorganizations",
+		}},
+	},
+	"getfinalitystatus": {
+		Version:  1,
+		Synopsis: "Returns the confirmations of a main chain block or of the block containing a transaction, how many distinct validate keys signed the blocks built on top of it, and whether it is beyond the irreversible depth configured with --finalitydepth.",
+		Results:  []ResultDoc{{Type: (*GetFinalityStatusResult)(nil)}},
+	},
+	"getsignedcheckpoints": {
+		Version:  1,
+		Synopsis: "Returns the enforced signed checkpoints in ascending order of height.",
+		Results: []ResultDoc{{
+			Type: (*[]SignedCheckpointResult)(nil),
+			Desc: "The signed checkpoints",
+		}},
+	},
+	"addsignedcheckpoint": {
+		Version:  1,
+		Synopsis: "Verifies a checkpoint signed by one of the keys trusted with --checkpointkey or --checkpointpubkey and enforces it from then on.",
+	},
+	"getpeerevictions": {
+		Version:  1,
+		Synopsis: "Returns the most recent inbound peers evicted to make room for new inbound peers once the maximum number of peers was reached, newest first.",
+		Results: []ResultDoc{{
+			Type: (*[]PeerEvictionResult)(nil),
+			Desc: "The peer evictions",
+		}},
+	},
+	"getsyncstatus": {
+		Version:  1,
+		Synopsis: "Returns the sync peer and the stalls of the sync detected by the watchdog, which replaces a sync peer that stops delivering blocks.",
+		Results:  []ResultDoc{{Type: (*GetSyncStatusResult)(nil)}},
+	},
+	"checkcanonicalencoding": {
+		Version: 1,
+		Synopsis: "Decodes a serialized transaction or block and reports its non-canonical encodings, which let a third party change its serialization without invalidating it.\n" +
+			"Those are the variable length integers not encoded with the fewest bytes, the script data pushes not using the smallest opcode, and the signatures in signature scripts which are not strictly DER encoded with a low S value and a defined hash type.",
+		Results: []ResultDoc{{Type: (*CheckCanonicalEncodingResult)(nil)}},
+	},
+	"getvalidatorviolations": {
+		Version: 1,
+		Synopsis: "Returns the recorded evidence of validate keys which signed two different blocks at the same height, from the highest height down.\n" +
+			"The evidence is detected when the blocks are accepted, or received from peers in alert messages and verified.",
+		Results: []ResultDoc{{
+			Type: (*[]ValidatorViolationResult)(nil),
+			Desc: "The recorded violations",
+		}},
+	},
+	"getannouncements": {
+		Version:  1,
+		Synopsis: "Returns the unexpired governance announcements known to the node, newest first.",
+		Results: []ResultDoc{{
+			Type: (*[]AnnouncementResult)(nil),
+			Desc: "The announcements",
+		}},
+	},
+	"sendannouncement": {
+		Version: 1,
+		Synopsis: "Verifies a signed governance announcement and relays it to the network.\n" +
+			"The announcement must be signed by 2 keys of the root or of the provision key set, must not be expired and must expire at most 30 days after its time.",
+		Results: []ResultDoc{{
+			Type: (*string)(nil),
+			Desc: "The hash of the announcement",
+		}},
+	},
+	"getkeyexpiries": {
+		Version: 1,
+		Synopsis: "Returns the ASP and validate keys provisioned with an expiry height which are still provisioned in the best chain, soonest expiry first.\n" +
+			"A key is revoked at the end of the block at its expiry height, unless it is a validate key and the validate key set would fall below its minimum size, in which case the expiry is overdue.",
+		Results: []ResultDoc{{
+			Type: (*[]KeyExpiryResult)(nil),
+			Desc: "The upcoming key expiries",
+		}},
+	},
+	"getaddressutxos": {
+		Version: 1,
+		Synopsis: "Returns the spendable outputs paying to the passed addresses, using the address index.\n" +
+			"Outputs are spendable when they are unspent in the main chain and the memory pool, mature and do not pay to a frozen account.",
+		Results: []ResultDoc{{
+			Type: (*[]AddressUtxoResult)(nil),
+			Desc: "The spendable outputs",
+		}},
+	},
+	"getsweepplan": {
+		Version: 1,
+		Synopsis: "Returns unsigned transactions moving all unspent outputs whose Prova script references the old key ID to the same accounts with the new key ID, for migrating accounts to a new ASP key.\n" +
+			"Each account receives a single output per transaction which pays the fee for its inputs and output.  The transactions must be signed by the account keys and the new ASP key before being sent.\n" +
+			"This scans the whole unspent transaction output set, so it should only be used for infrequent tasks.",
+		Results: []ResultDoc{{Type: (*GetSweepPlanResult)(nil)}},
+	},
+	"getkeyidcensus": {
+		Version: 1,
+		Synopsis: "Returns the number and value of the unspent outputs referencing each key ID, per asset, found by the last key ID census, so a key ID can be verified to be unused before it is revoked.\n" +
+			"A census scans the whole unspent transaction output set in the background.  It runs when requested with rescan and at the interval set with --keyidcensusinterval.\n" +
+			"Provisioned ASP key IDs without outputs are reported with no outputs, as is the requested key ID.",
+		Results: []ResultDoc{{Type: (*GetKeyIDCensusResult)(nil)}},
+	},
+	"gettransactionstatus": {
+		Version: 1,
+		Synopsis: "Returns the status of each of the passed transactions: mempool, held (an admin transaction held until the thread tip it spends has enough confirmations), orphan, confirmed, disconnected (in a block disconnected by one of the last 100 reorganizations and neither confirmed again nor in the memory pool), conflicted (disconnected, with an output it spends spent by another transaction) or unknown.\n" +
+			"Confirmed transactions are found with the transaction index when it is enabled, and otherwise only while they have unspent outputs.  The transactions conflicting with a disconnected transaction are searched in the memory pool and in the first 1000 main chain blocks after the fork.",
+		Results: []ResultDoc{{
+			Type: (*[]TransactionStatusResult)(nil),
+			Desc: "The status of the transactions in the order they were passed",
+		}},
+	},
+	"getblockpropagation": {
+		Version: 1,
+		Synopsis: "Returns when the most recently announced blocks were first announced and by which peer, received, validated and relayed onward, and how far behind the first announcement each peer announced them, to identify slow links between validators.\n" +
+			"The propagation of the last 1000 blocks announced by peers or submitted locally is tracked.  Blocks downloaded while syncing are not tracked.",
+		Results: []ResultDoc{{Type: (*GetBlockPropagationResult)(nil)}},
+	},
+	"prioritisetransaction": {
+		Version: 1,
+		Synopsis: "Adds the fee delta to the fee of the transaction when the transactions of generated blocks and block templates are selected, returning the resulting fee delta of the transaction.\n" +
+			"A positive delta expedites the transaction and a delta which makes its fee negative leaves it, and the transactions spending it, out of blocks.  The delta is not paid and does not change the policy of the memory pool.\n" +
+			"Deltas accumulate, may be set before the transaction is received and are dropped once the transaction leaves the memory pool.",
+		Results: []ResultDoc{{
+			Type: (*int64)(nil),
+			Desc: "The fee delta of the transaction in atoms",
+		}},
+	},
+	"settemplateexclusion": {
+		Version: 1,
+		Synopsis: "Adds a transaction or address to the exclusions of the block templates generated by the node, or removes it, returning whether the exclusions changed.\n" +
+			"Transactions which are excluded, pay to an excluded address or spend from one are never included in generated blocks and block templates, nor are the transactions spending their outputs.  The exclusions are a policy of the node, not a consensus rule, so blocks of other validators including the transactions are still accepted.\n" +
+			"The exclusions are stored in the data directory and recorded in the audit log when it is enabled.",
+		Results: []ResultDoc{{
+			Type: (*bool)(nil),
+			Desc: "Whether the exclusions changed",
+		}},
+	},
+	"gettemplateexclusions": {
+		Version:  1,
+		Synopsis: "Returns the transactions and addresses excluded from the block templates generated by the node.",
+		Results:  []ResultDoc{{Type: (*GetTemplateExclusionsResult)(nil)}},
+	},
+	"getblockatheightbytime": {
+		Version: 1,
+		Synopsis: "Returns the last main chain block whose median time past is at or before the passed time, to map a time such as the end of a reporting period to a block height.\n" +
+			"The median time past of a block, the median of the timestamps of the block and the 10 blocks before it, never decreases along the chain, unlike the timestamps.  Every block up to the returned one has a median time past at or before the time and every later block one after it, so the result only changes when the chain is reorganized.",
+		Results: []ResultDoc{{Type: (*GetBlockAtHeightByTimeResult)(nil)}},
+	},
+	"rpc.discover": {
+		Version: 1,
+		Synopsis: "Returns an OpenRPC document describing the parameters and results of all methods, from which clients can be generated.\n" +
+			"The schema of each parameter and result is a JSON Schema.  The version of each documented method is included as x-version.",
+		Results: []ResultDoc{{Type: (*OpenRPCDocument)(nil)}},
+	},
+}
+
 func init() {
 	// No special flags for commands in this file.
 	flags := UsageFlag(0)
 
+	MustRegisterCmd("fundrawtransaction", (*FundRawTransactionCmd)(nil), flags)
 	MustRegisterCmd("setvalidatekeys", (*SetValidateKeysCmd)(nil), flags)
+	MustRegisterCmd("unlockvalidatekeys", (*UnlockValidateKeysCmd)(nil),
+		flags)
+	MustRegisterCmd("lockvalidatekeys", (*LockValidateKeysCmd)(nil), flags)
+	MustRegisterCmd("getunconfirmedbroadcasts",
+		(*GetUnconfirmedBroadcastsCmd)(nil), flags)
+	MustRegisterCmd("sendrawpackage", (*SendRawPackageCmd)(nil), flags)
+	MustRegisterCmd("getfreezestatus", (*GetFreezeStatusCmd)(nil), flags)
+	MustRegisterCmd("getreserveproof", (*GetReserveProofCmd)(nil), flags)
+	MustRegisterCmd("verifyreserveattestation",
+		(*VerifyReserveAttestationCmd)(nil), flags)
+	MustRegisterCmd("getcommitments", (*GetCommitmentsCmd)(nil), flags)
+	MustRegisterCmd("importxpub", (*ImportXpubCmd)(nil), flags)
+	MustRegisterCmd("getxpubinfo", (*GetXpubInfoCmd)(nil), flags)
+	MustRegisterCmd("getaddresshistory", (*GetAddressHistoryCmd)(nil),
+		flags)
+	MustRegisterCmd("getchainstats", (*GetChainStatsCmd)(nil), flags)
+	MustRegisterCmd("getkeystatediff", (*GetKeyStateDiffCmd)(nil), flags)
+	MustRegisterCmd("getreorghistory", (*GetReorgHistoryCmd)(nil), flags)
+	MustRegisterCmd("getfinalitystatus", (*GetFinalityStatusCmd)(nil),
+		flags)
+	MustRegisterCmd("getsignedcheckpoints", (*GetSignedCheckpointsCmd)(nil),
+		flags)
+	MustRegisterCmd("addsignedcheckpoint", (*AddSignedCheckpointCmd)(nil),
+		flags)
+	MustRegisterCmd("getpeerevictions", (*GetPeerEvictionsCmd)(nil), flags)
+	MustRegisterCmd("getsyncstatus", (*GetSyncStatusCmd)(nil), flags)
+	MustRegisterCmd("checkcanonicalencoding",
+		(*CheckCanonicalEncodingCmd)(nil), flags)
+	MustRegisterCmd("getvalidatorviolations",
+		(*GetValidatorViolationsCmd)(nil), flags)
+	MustRegisterCmd("getannouncements", (*GetAnnouncementsCmd)(nil), flags)
+	MustRegisterCmd("sendannouncement", (*SendAnnouncementCmd)(nil), flags)
+	MustRegisterCmd("getkeyexpiries", (*GetKeyExpiriesCmd)(nil), flags)
+	MustRegisterCmd("getaddressutxos", (*GetAddressUtxosCmd)(nil), flags)
+	MustRegisterCmd("getsweepplan", (*GetSweepPlanCmd)(nil), flags)
+	MustRegisterCmd("getkeyidcensus", (*GetKeyIDCensusCmd)(nil), flags)
+	MustRegisterCmd("gettransactionstatus", (*GetTransactionStatusCmd)(nil), flags)
+	MustRegisterCmd("getblockpropagation", (*GetBlockPropagationCmd)(nil),
+		flags)
+	MustRegisterCmd("prioritisetransaction",
+		(*PrioritiseTransactionCmd)(nil), flags)
+	MustRegisterCmd("settemplateexclusion", (*SetTemplateExclusionCmd)(nil),
+		flags)
+	MustRegisterCmd("gettemplateexclusions",
+		(*GetTemplateExclusionsCmd)(nil), flags)
+	MustRegisterCmd("getblockatheightbytime",
+		(*GetBlockAtHeightByTimeCmd)(nil), flags)
+	MustRegisterCmd("rpc.discover", (*RPCDiscoverCmd)(nil), flags)
+
+	for method, doc := range rmgdExtCmdDocs {
+		MustRegisterCmdDoc(method, doc)
+	}
 }
@@ -37,13 +37,684 @@ func TestDmgdExtCmds(t *testing.T) {
 				return btcjson.NewCmd("setvalidatekeys", []string{"1234"})
 			},
 			staticCmd: func() interface{} {
-				return btcjson.NewSetValidateKeysCmd([]string{"1234"})
+				return btcjson.NewSetValidateKeysCmd([]string{"1234"}, nil)
 			},
 			marshalled: `{"jsonrpc":"1.0","method":"setvalidatekeys","params":[["1234"]],"id":1}`,
 			unmarshalled: &btcjson.SetValidateKeysCmd{
 				PrivKeys: []string{"1234"},
 			},
 		},
+		{
+			name: "setvalidatekeys optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("setvalidatekeys", []string{"1234"},
+					"pass")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewSetValidateKeysCmd([]string{"1234"},
+					btcjson.String("pass"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"setvalidatekeys","params":[["1234"],"pass"],"id":1}`,
+			unmarshalled: &btcjson.SetValidateKeysCmd{
+				PrivKeys:   []string{"1234"},
+				Passphrase: btcjson.String("pass"),
+			},
+		},
+		{
+			name: "unlockvalidatekeys",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("unlockvalidatekeys", "pass", 60)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewUnlockValidateKeysCmd("pass", 60)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"unlockvalidatekeys","params":["pass",60],"id":1}`,
+			unmarshalled: &btcjson.UnlockValidateKeysCmd{
+				Passphrase: "pass",
+				Timeout:    60,
+			},
+		},
+		{
+			name: "lockvalidatekeys",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("lockvalidatekeys")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewLockValidateKeysCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"lockvalidatekeys","params":[],"id":1}`,
+			unmarshalled: &btcjson.LockValidateKeysCmd{},
+		},
+		{
+			name: "getunconfirmedbroadcasts",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getunconfirmedbroadcasts")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetUnconfirmedBroadcastsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getunconfirmedbroadcasts","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetUnconfirmedBroadcastsCmd{},
+		},
+		{
+			name: "fundrawtransaction",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("fundrawtransaction", "001122", []string{"1Address"}, "1Change")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewFundRawTransactionCmd("001122",
+					[]string{"1Address"}, "1Change", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"fundrawtransaction","params":["001122",["1Address"],"1Change"],"id":1}`,
+			unmarshalled: &btcjson.FundRawTransactionCmd{
+				HexTx:         "001122",
+				Addresses:     []string{"1Address"},
+				ChangeAddress: "1Change",
+			},
+		},
+		{
+			name: "fundrawtransaction optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("fundrawtransaction", "001122", []string{"1Address"}, "1Change", 0.0001)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewFundRawTransactionCmd("001122",
+					[]string{"1Address"}, "1Change",
+					btcjson.Float64(0.0001))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"fundrawtransaction","params":["001122",["1Address"],"1Change",0.0001],"id":1}`,
+			unmarshalled: &btcjson.FundRawTransactionCmd{
+				HexTx:         "001122",
+				Addresses:     []string{"1Address"},
+				ChangeAddress: "1Change",
+				FeeRate:       btcjson.Float64(0.0001),
+			},
+		},
+		{
+			name: "sendrawpackage",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("sendrawpackage", []string{"0011", "2233"})
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewSendRawPackageCmd([]string{"0011", "2233"})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"sendrawpackage","params":[["0011","2233"]],"id":1}`,
+			unmarshalled: &btcjson.SendRawPackageCmd{
+				HexTxs: []string{"0011", "2233"},
+			},
+		},
+		{
+			name: "getfreezestatus",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getfreezestatus", "1Address")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetFreezeStatusCmd("1Address")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getfreezestatus","params":["1Address"],"id":1}`,
+			unmarshalled: &btcjson.GetFreezeStatusCmd{
+				Address: "1Address",
+			},
+		},
+		{
+			name: "getreserveproof",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getreserveproof", []string{"1Address"})
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetReserveProofCmd([]string{"1Address"}, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getreserveproof","params":[["1Address"]],"id":1}`,
+			unmarshalled: &btcjson.GetReserveProofCmd{
+				Addresses: []string{"1Address"},
+				KeyIDs:    nil,
+			},
+		},
+		{
+			name: "getreserveproof optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getreserveproof", []string{}, []uint32{2, 7})
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetReserveProofCmd([]string{}, &[]uint32{2, 7})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getreserveproof","params":[[],[2,7]],"id":1}`,
+			unmarshalled: &btcjson.GetReserveProofCmd{
+				Addresses: []string{},
+				KeyIDs:    &[]uint32{2, 7},
+			},
+		},
+		{
+			name: "verifyreserveattestation",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("verifyreserveattestation", "001122")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewVerifyReserveAttestationCmd("001122")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"verifyreserveattestation","params":["001122"],"id":1}`,
+			unmarshalled: &btcjson.VerifyReserveAttestationCmd{
+				HexAttestation: "001122",
+			},
+		},
+		{
+			name: "getcommitments",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getcommitments", "0b0b")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetCommitmentsCmd("0b0b")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getcommitments","params":["0b0b"],"id":1}`,
+			unmarshalled: &btcjson.GetCommitmentsCmd{
+				Commitment: "0b0b",
+			},
+		},
+		{
+			name: "importxpub",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("importxpub", "tpub", []uint32{2, 7})
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewImportXpubCmd("tpub", []uint32{2, 7}, nil, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"importxpub","params":["tpub",[2,7]],"id":1}`,
+			unmarshalled: &btcjson.ImportXpubCmd{
+				Xpub:     "tpub",
+				KeyIDs:   []uint32{2, 7},
+				GapLimit: btcjson.Uint32(20),
+				Rescan:   btcjson.Bool(true),
+				Label:    nil,
+			},
+		},
+		{
+			name: "importxpub optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("importxpub", "tpub", []uint32{2, 7}, 50, false, "deposits")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewImportXpubCmd("tpub", []uint32{2, 7},
+					btcjson.Uint32(50), btcjson.Bool(false),
+					btcjson.String("deposits"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"importxpub","params":["tpub",[2,7],50,false,"deposits"],"id":1}`,
+			unmarshalled: &btcjson.ImportXpubCmd{
+				Xpub:     "tpub",
+				KeyIDs:   []uint32{2, 7},
+				GapLimit: btcjson.Uint32(50),
+				Rescan:   btcjson.Bool(false),
+				Label:    btcjson.String("deposits"),
+			},
+		},
+		{
+			name: "getxpubinfo",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getxpubinfo", "tpub")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetXpubInfoCmd("tpub")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getxpubinfo","params":["tpub"],"id":1}`,
+			unmarshalled: &btcjson.GetXpubInfoCmd{
+				Xpub: "tpub",
+			},
+		},
+		{
+			name: "getaddresshistory",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getaddresshistory", "addr")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetAddressHistoryCmd("addr", nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getaddresshistory","params":["addr"],"id":1}`,
+			unmarshalled: &btcjson.GetAddressHistoryCmd{
+				Address:  "addr",
+				Page:     btcjson.Int(0),
+				PageSize: btcjson.Int(100),
+			},
+		},
+		{
+			name: "getaddresshistory optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getaddresshistory", "addr", 2, 50)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetAddressHistoryCmd("addr",
+					btcjson.Int(2), btcjson.Int(50))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getaddresshistory","params":["addr",2,50],"id":1}`,
+			unmarshalled: &btcjson.GetAddressHistoryCmd{
+				Address:  "addr",
+				Page:     btcjson.Int(2),
+				PageSize: btcjson.Int(50),
+			},
+		},
+		{
+			name: "getchainstats",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getchainstats", "2019-01-01",
+					"2019-01-31")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetChainStatsCmd("2019-01-01",
+					"2019-01-31")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getchainstats","params":["2019-01-01","2019-01-31"],"id":1}`,
+			unmarshalled: &btcjson.GetChainStatsCmd{
+				From: "2019-01-01",
+				To:   "2019-01-31",
+			},
+		},
+		{
+			name: "getkeystatediff",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getkeystatediff", 100, 200)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetKeyStateDiffCmd(100, 200)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getkeystatediff","params":[100,200],"id":1}`,
+			unmarshalled: &btcjson.GetKeyStateDiffCmd{
+				Height1: 100,
+				Height2: 200,
+			},
+		},
+		{
+			name: "getreorghistory",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getreorghistory")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetReorgHistoryCmd(nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getreorghistory","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetReorgHistoryCmd{
+				Count: btcjson.Int(10),
+				Skip:  btcjson.Int(0),
+			},
+		},
+		{
+			name: "getreorghistory optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getreorghistory", 5, 20)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetReorgHistoryCmd(btcjson.Int(5),
+					btcjson.Int(20))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getreorghistory","params":[5,20],"id":1}`,
+			unmarshalled: &btcjson.GetReorgHistoryCmd{
+				Count: btcjson.Int(5),
+				Skip:  btcjson.Int(20),
+			},
+		},
+		{
+			name: "getfinalitystatus",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getfinalitystatus", "123")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetFinalityStatusCmd("123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getfinalitystatus","params":["123"],"id":1}`,
+			unmarshalled: &btcjson.GetFinalityStatusCmd{
+				Hash: "123",
+			},
+		},
+		{
+			name: "getsignedcheckpoints",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getsignedcheckpoints")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetSignedCheckpointsCmd(nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getsignedcheckpoints","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetSignedCheckpointsCmd{
+				FromHeight: btcjson.Uint32(0),
+			},
+		},
+		{
+			name: "getsignedcheckpoints optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getsignedcheckpoints", 1000)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetSignedCheckpointsCmd(
+					btcjson.Uint32(1000))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getsignedcheckpoints","params":[1000],"id":1}`,
+			unmarshalled: &btcjson.GetSignedCheckpointsCmd{
+				FromHeight: btcjson.Uint32(1000),
+			},
+		},
+		{
+			name: "addsignedcheckpoint",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("addsignedcheckpoint", "0011")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewAddSignedCheckpointCmd("0011")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"addsignedcheckpoint","params":["0011"],"id":1}`,
+			unmarshalled: &btcjson.AddSignedCheckpointCmd{
+				HexCheckpoint: "0011",
+			},
+		},
+		{
+			name: "getpeerevictions",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getpeerevictions")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetPeerEvictionsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getpeerevictions","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetPeerEvictionsCmd{},
+		},
+		{
+			name: "getsyncstatus",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getsyncstatus")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetSyncStatusCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getsyncstatus","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetSyncStatusCmd{},
+		},
+		{
+			name: "checkcanonicalencoding",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("checkcanonicalencoding", "00")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewCheckCanonicalEncodingCmd("00", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"checkcanonicalencoding","params":["00"],"id":1}`,
+			unmarshalled: &btcjson.CheckCanonicalEncodingCmd{
+				HexData: "00",
+				Block:   btcjson.Bool(false),
+			},
+		},
+		{
+			name: "checkcanonicalencoding optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("checkcanonicalencoding", "00", true)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewCheckCanonicalEncodingCmd("00",
+					btcjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"checkcanonicalencoding","params":["00",true],"id":1}`,
+			unmarshalled: &btcjson.CheckCanonicalEncodingCmd{
+				HexData: "00",
+				Block:   btcjson.Bool(true),
+			},
+		},
+		{
+			name: "getvalidatorviolations",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getvalidatorviolations")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetValidatorViolationsCmd(nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getvalidatorviolations","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetValidatorViolationsCmd{
+				Count: btcjson.Int(10),
+				Skip:  btcjson.Int(0),
+			},
+		},
+		{
+			name: "getvalidatorviolations optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getvalidatorviolations", 5, 20)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetValidatorViolationsCmd(btcjson.Int(5),
+					btcjson.Int(20))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getvalidatorviolations","params":[5,20],"id":1}`,
+			unmarshalled: &btcjson.GetValidatorViolationsCmd{
+				Count: btcjson.Int(5),
+				Skip:  btcjson.Int(20),
+			},
+		},
+		{
+			name: "getannouncements",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getannouncements")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetAnnouncementsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getannouncements","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetAnnouncementsCmd{},
+		},
+		{
+			name: "sendannouncement",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("sendannouncement", "00")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewSendAnnouncementCmd("00")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"sendannouncement","params":["00"],"id":1}`,
+			unmarshalled: &btcjson.SendAnnouncementCmd{
+				HexAnnouncement: "00",
+			},
+		},
+		{
+			name: "getkeyexpiries",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getkeyexpiries")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetKeyExpiriesCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getkeyexpiries","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetKeyExpiriesCmd{},
+		},
+		{
+			name: "getaddressutxos",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getaddressutxos", []string{"1Address"})
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetAddressUtxosCmd([]string{"1Address"},
+					nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getaddressutxos","params":[["1Address"]],"id":1}`,
+			unmarshalled: &btcjson.GetAddressUtxosCmd{
+				Addresses: []string{"1Address"},
+				MinConf:   btcjson.Int(1),
+			},
+		},
+		{
+			name: "getaddressutxos optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getaddressutxos", []string{"1Address"},
+					6, 3)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetAddressUtxosCmd([]string{"1Address"},
+					btcjson.Int(6), btcjson.Uint32(3))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getaddressutxos","params":[["1Address"],6,3],"id":1}`,
+			unmarshalled: &btcjson.GetAddressUtxosCmd{
+				Addresses: []string{"1Address"},
+				MinConf:   btcjson.Int(6),
+				KeyID:     btcjson.Uint32(3),
+			},
+		},
+		{
+			name: "getsweepplan",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getsweepplan", 1, 2)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetSweepPlanCmd(1, 2, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getsweepplan","params":[1,2],"id":1}`,
+			unmarshalled: &btcjson.GetSweepPlanCmd{
+				OldKeyID: 1,
+				NewKeyID: 2,
+			},
+		},
+		{
+			name: "getsweepplan optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getsweepplan", 1, 2, 0.0001, 50000)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetSweepPlanCmd(1, 2,
+					btcjson.Float64(0.0001), btcjson.Int(50000))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getsweepplan","params":[1,2,0.0001,50000],"id":1}`,
+			unmarshalled: &btcjson.GetSweepPlanCmd{
+				OldKeyID:  1,
+				NewKeyID:  2,
+				FeeRate:   btcjson.Float64(0.0001),
+				MaxTxSize: btcjson.Int(50000),
+			},
+		},
+		{
+			name: "getkeyidcensus",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getkeyidcensus")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetKeyIDCensusCmd(nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getkeyidcensus","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetKeyIDCensusCmd{
+				Rescan: btcjson.Bool(false),
+			},
+		},
+		{
+			name: "getkeyidcensus optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getkeyidcensus", 3, true)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetKeyIDCensusCmd(btcjson.Uint32(3),
+					btcjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getkeyidcensus","params":[3,true],"id":1}`,
+			unmarshalled: &btcjson.GetKeyIDCensusCmd{
+				KeyID:  btcjson.Uint32(3),
+				Rescan: btcjson.Bool(true),
+			},
+		},
+		{
+			name: "gettransactionstatus",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("gettransactionstatus",
+					[]string{"123", "456"})
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetTransactionStatusCmd(
+					[]string{"123", "456"})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"gettransactionstatus","params":[["123","456"]],"id":1}`,
+			unmarshalled: &btcjson.GetTransactionStatusCmd{
+				TxIDs: []string{"123", "456"},
+			},
+		},
+		{
+			name: "getblockpropagation",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getblockpropagation")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetBlockPropagationCmd(nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getblockpropagation","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetBlockPropagationCmd{
+				Count: btcjson.Int(20),
+			},
+		},
+		{
+			name: "getblockpropagation optional",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getblockpropagation", 5)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetBlockPropagationCmd(btcjson.Int(5))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getblockpropagation","params":[5],"id":1}`,
+			unmarshalled: &btcjson.GetBlockPropagationCmd{
+				Count: btcjson.Int(5),
+			},
+		},
+		{
+			name: "prioritisetransaction",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("prioritisetransaction", "123",
+					-1000)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewPrioritiseTransactionCmd("123", -1000)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"prioritisetransaction","params":["123",-1000],"id":1}`,
+			unmarshalled: &btcjson.PrioritiseTransactionCmd{
+				TxID:     "123",
+				FeeDelta: -1000,
+			},
+		},
+		{
+			name: "settemplateexclusion",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("settemplateexclusion", "123",
+					btcjson.TEAdd)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewSetTemplateExclusionCmd("123",
+					btcjson.TEAdd)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"settemplateexclusion","params":["123","add"],"id":1}`,
+			unmarshalled: &btcjson.SetTemplateExclusionCmd{
+				Target: "123",
+				SubCmd: btcjson.TEAdd,
+			},
+		},
+		{
+			name: "gettemplateexclusions",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("gettemplateexclusions")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetTemplateExclusionsCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"gettemplateexclusions","params":[],"id":1}`,
+			unmarshalled: &btcjson.GetTemplateExclusionsCmd{},
+		},
+		{
+			name: "getblockatheightbytime",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("getblockatheightbytime", 1546300800)
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewGetBlockAtHeightByTimeCmd(1546300800)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getblockatheightbytime","params":[1546300800],"id":1}`,
+			unmarshalled: &btcjson.GetBlockAtHeightByTimeCmd{
+				Time: 1546300800,
+			},
+		},
+		{
+			name: "rpc.discover",
+			newCmd: func() (interface{}, error) {
+				return btcjson.NewCmd("rpc.discover")
+			},
+			staticCmd: func() interface{} {
+				return btcjson.NewRPCDiscoverCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"rpc.discover","params":[],"id":1}`,
+			unmarshalled: &btcjson.RPCDiscoverCmd{},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
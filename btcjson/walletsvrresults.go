@@ -81,6 +81,7 @@ type ListTransactionsResult struct {
 	WalletConflicts   []string `json:"walletconflicts"`
 	Comment           string   `json:"comment,omitempty"`
 	OtherAccount      string   `json:"otheraccount,omitempty"`
+	Removed           bool     `json:"removed,omitempty"`
 }
 
 // ListReceivedByAccountResult models the data from the listreceivedbyaccount
@@ -110,15 +111,18 @@ type ListSinceBlockResult struct {
 
 // ListUnspentResult models a successful response from the listunspent request.
 type ListUnspentResult struct {
-	TxID          string  `json:"txid"`
-	Vout          uint32  `json:"vout"`
-	Address       string  `json:"address"`
-	Account       string  `json:"account"`
-	ScriptPubKey  string  `json:"scriptPubKey"`
-	RedeemScript  string  `json:"redeemScript,omitempty"`
-	Amount        float64 `json:"amount"`
-	Confirmations int64   `json:"confirmations"`
-	Spendable     bool    `json:"spendable"`
+	TxID            string  `json:"txid"`
+	Vout            uint32  `json:"vout"`
+	Address         string  `json:"address"`
+	Account         string  `json:"account"`
+	ScriptPubKey    string  `json:"scriptPubKey"`
+	RedeemScript    string  `json:"redeemScript,omitempty"`
+	Amount          float64 `json:"amount"`
+	Confirmations   int64   `json:"confirmations"`
+	Spendable       bool    `json:"spendable"`
+	Coinbase        bool    `json:"coinbase,omitempty"`
+	Mature          bool    `json:"mature"`
+	SpendableHeight uint32  `json:"spendableheight"`
 }
 
 // SignRawTransactionError models the data that contains script verification
@@ -13,6 +13,7 @@ import (
 	"errors"
 	"math"
 	"math/big"
+	"strings"
 	"time"
 
 	"github.com/pyx-partners/dmgd/btcec"
@@ -56,6 +57,49 @@ type Checkpoint struct {
 	Hash   *chainhash.Hash
 }
 
+// DefaultAdminThreshold is the number of signatures required to spend an
+// admin thread whose network doesn't set a threshold for it.
+const DefaultAdminThreshold = 2
+
+// These are the consensus limits of the script engine, which apply to every
+// network whose ScriptLimits do not override them.
+const (
+	// DefaultMaxScriptSize is the maximum length in bytes of a script.
+	DefaultMaxScriptSize = 10000
+
+	// DefaultMaxStackSize is the maximum combined height of the data and
+	// alt stacks during script execution.
+	DefaultMaxStackSize = 1000
+
+	// DefaultMaxOpsPerScript is the maximum number of operations which do
+	// not push data in a script, counting each public key of a multisig
+	// operation.
+	DefaultMaxOpsPerScript = 201
+)
+
+// ScriptLimits are the limits of the script engine on a network.  A zero limit
+// defaults to the consensus limit, so only research networks experimenting
+// with the script engine need to set them.
+type ScriptLimits struct {
+	// MaxScriptSize is the maximum length in bytes of a script.
+	MaxScriptSize int
+
+	// MaxStackSize is the maximum combined height of the data and alt
+	// stacks during script execution.
+	MaxStackSize int
+
+	// MaxOpsPerScript is the maximum number of operations which do not
+	// push data in a script.
+	MaxOpsPerScript int
+}
+
+// BlockSizeStep schedules the maximum serialized size of the blocks at and
+// after a height.
+type BlockSizeStep struct {
+	Height  uint32
+	MaxSize uint32
+}
+
 // DNSSeed identifies a DNS seed.
 type DNSSeed struct {
 	// Host defines the hostname of the seed.
@@ -92,6 +136,14 @@ type Params struct {
 	// AdminKeySets is the set of keys governing the chain state.
 	AdminKeySets map[btcec.KeySetType]btcec.PublicKeySet
 
+	// AdminThresholds is the number of signatures of keys in the admin
+	// key set governing an admin thread which are required to spend the
+	// thread, keyed by the RootKeySet, ProvisionKeySet and IssueKeySet of
+	// the root, provision and issue threads.  A thread can not be spent
+	// while its key set holds fewer keys than its threshold.  A missing or
+	// zero threshold defaults to DefaultAdminThreshold.
+	AdminThresholds map[btcec.KeySetType]int
+
 	// ASPKeyIdMap are the provisioned keyIDs and respective pubKeys
 	ASPKeyIdMap btcec.KeyIdMap
 
@@ -111,10 +163,102 @@ type Params struct {
 	// is reduced.
 	SubsidyReductionInterval uint32
 
+	// MaxSupply is the maximum total supply in atoms that may be issued
+	// on the network.  Issuance which would push the total supply past
+	// this value is rejected by consensus.  A value of zero disables the
+	// cap.
+	MaxSupply uint64
+
+	// IssuanceEpochBlocks is the number of blocks in each issuance epoch.
+	// Epochs are aligned to multiples of this value starting at the
+	// genesis block.
+	IssuanceEpochBlocks uint32
+
+	// MaxEpochIssuance is the maximum amount in atoms that may be issued
+	// within a single issuance epoch.  Destroyed tokens do not offset
+	// issuance within the epoch.  A value of zero, or an
+	// IssuanceEpochBlocks of zero, disables the limit.
+	MaxEpochIssuance uint64
+
 	// TargetTimePerBlock is the desired amount of time to generate each
 	// block.
 	TargetTimePerBlock time.Duration
 
+	// MaxBlockSize is the maximum serialized size in bytes of a block
+	// allowed by consensus.  A value of zero defaults to
+	// wire.MaxBlockPayload.
+	MaxBlockSize uint32
+
+	// MaxBlockSizeSchedule schedules increases of MaxBlockSize at block
+	// heights, ordered from lowest to highest height.  No scheduled size
+	// may exceed wire.MaxBlockPayload, which bounds the block messages
+	// peers accept.
+	MaxBlockSizeSchedule []BlockSizeStep
+
+	// SchnorrBlockSigHeight is the height of the first block which may be
+	// signed with a Schnorr signature of its validate key, such as the
+	// threshold signature of a group of signers sharing the key.  Blocks
+	// are signed with ECDSA signatures only when it is zero.
+	SchnorrBlockSigHeight uint32
+
+	// ProvaSigOpsHeight is the height of the first block whose signature
+	// operations include those of the OP_CHECKSAFEMULTISIG operations of
+	// the Prova scripts its inputs spend.  They are not counted toward the
+	// maximum signature operations per block before, and always when it is
+	// zero.
+	ProvaSigOpsHeight uint32
+
+	// CheckKeyIDVerifyHeight is the height of the first block whose
+	// scripts verify with OP_CHECKKEYIDVERIFY that signatures are made by
+	// the ASP keys provisioned for keyIDs.  Before, and always when it is
+	// zero, the opcode is executed as OP_NOP4.
+	CheckKeyIDVerifyHeight uint32
+
+	// IssueChangeHeight is the height of the first block in which the
+	// change outputs of issue thread transactions destroying tokens must
+	// pay back to an output they spend or to an allowlisted issuance
+	// destination.  Change may pay to any Prova script when it is zero.
+	IssueChangeHeight uint32
+
+	// AssetMarkerHeight is the height of the first block whose transactions
+	// move the asset named by their asset marker output.  Before, and
+	// always when it is zero, asset marker outputs are plain null data
+	// outputs and all transactions move DMG.
+	AssetMarkerHeight uint32
+
+	// FreezeHeight is the height of the first block which may freeze and
+	// unfreeze accounts with provision thread admin ops, and whose
+	// transactions may not spend the outputs of frozen accounts.  The
+	// ops are invalid before, and always when it is zero.
+	FreezeHeight uint32
+
+	// IssueDestHeight is the height of the first block which may add and
+	// revoke issuance destinations with root thread admin ops.  The ops
+	// are invalid before, and always when it is zero.
+	IssueDestHeight uint32
+
+	// KeyExpiryHeight is the height of the first block which may provision
+	// ASP and validate keys with an expiry height, and from which such keys
+	// are revoked once they expire.  The ops are invalid before, and always
+	// when it is zero.
+	KeyExpiryHeight uint32
+
+	// DelegateHeight is the height of the first block which may add and
+	// revoke delegate keys with provision thread admin ops, and from which
+	// a delegate key may sign provision thread transactions on its own.
+	// The ops are invalid before, and always when it is zero.
+	DelegateHeight uint32
+
+	// HTLCHeight is the height of the first block whose transactions may
+	// pay to and spend Prova HTLC scripts.  The scripts are invalid
+	// before, and always when it is zero.
+	HTLCHeight uint32
+
+	// ScriptLimits are the limits of the script engine.  Changing them
+	// changes which transactions are valid, so they must only be set on
+	// networks whose nodes all agree on them.
+	ScriptLimits ScriptLimits
+
 	// GenerateSupported specifies whether or not CPU mining is allowed.
 	GenerateSupported bool
 
@@ -135,10 +279,29 @@ type Params struct {
 	// Mempool parameters
 	RelayNonStdTxs bool
 
+	// MaxStandardTxSize and MaxStandardSigScriptSize are the default
+	// maximum sizes in bytes of a transaction and of an input signature
+	// script, respectively, that are considered standard on the network.
+	MaxStandardTxSize        int
+	MaxStandardSigScriptSize int
+
+	// MaxRevokedKeyIDOutputs is the default maximum number of unspent
+	// outputs which may still reference the keyID of an ASP key revoked by
+	// an admin transaction for it to be relayed.  Outputs referencing a
+	// revoked keyID can no longer be spent with the co-signature of an
+	// ASP, so revoking a keyID in use strands their funds.  Checking
+	// requires scanning the unspent outputs, so a negative value disables
+	// the check, which is the default on all networks.
+	MaxRevokedKeyIDOutputs int
+
 	// Address encoding magics
 	ProvaAddrID  byte // First byte of an Prova address
 	PrivateKeyID byte // First byte of a WIF private key
 
+	// Bech32HRPProva is the human-readable part of bech32m encoded Prova
+	// addresses.
+	Bech32HRPProva string
+
 	// BIP32 hierarchical deterministic extended key magics
 	HDPrivateKeyID [4]byte
 	HDPublicKeyID  [4]byte
@@ -161,6 +324,13 @@ type Params struct {
 
 	// Maximum fee allowed in a single transaction, in atoms.
 	MaximumFeeAmount int64
+
+	// CoinbasePkScript is the public key script all coinbase outputs
+	// with a non-zero value must pay to, such as the Prova script of a
+	// treasury address of the network operator.  It routes the block
+	// subsidy and fees by consensus.  A nil value allows them to be paid
+	// to any script.
+	CoinbasePkScript []byte
 }
 
 // MaxActualTimespan returns a timespan with the down-dampening factor applied.
@@ -183,6 +353,106 @@ func (p Params) MinValidateKeySetSize() int {
 	return int(math.Ceil(powAveragingWindow / chainWindowMaxBlocks))
 }
 
+// AdminThreshold returns the number of signatures required to spend the admin
+// thread governed by the passed key set.
+func (p Params) AdminThreshold(keySetType btcec.KeySetType) int {
+	if threshold := p.AdminThresholds[keySetType]; threshold > 0 {
+		return threshold
+	}
+	return DefaultAdminThreshold
+}
+
+// ScriptEngineLimits returns the limits of the script engine on the network,
+// with the consensus limits in place of the zero limits.
+func (p Params) ScriptEngineLimits() ScriptLimits {
+	limits := p.ScriptLimits
+	if limits.MaxScriptSize == 0 {
+		limits.MaxScriptSize = DefaultMaxScriptSize
+	}
+	if limits.MaxStackSize == 0 {
+		limits.MaxStackSize = DefaultMaxStackSize
+	}
+	if limits.MaxOpsPerScript == 0 {
+		limits.MaxOpsPerScript = DefaultMaxOpsPerScript
+	}
+	return limits
+}
+
+// ProvaSigOpsActive returns whether the signature operations of spent Prova
+// scripts are counted in the block at the passed height.
+func (p Params) ProvaSigOpsActive(height uint32) bool {
+	return p.ProvaSigOpsHeight != 0 && height >= p.ProvaSigOpsHeight
+}
+
+// CheckKeyIDVerifyActive returns whether OP_CHECKKEYIDVERIFY is active in the
+// block at the passed height.
+func (p Params) CheckKeyIDVerifyActive(height uint32) bool {
+	return p.CheckKeyIDVerifyHeight != 0 &&
+		height >= p.CheckKeyIDVerifyHeight
+}
+
+// IssueChangeRestricted returns whether the change outputs of destruction
+// transactions are restricted in the block at the passed height.
+func (p Params) IssueChangeRestricted(height uint32) bool {
+	return p.IssueChangeHeight != 0 && height >= p.IssueChangeHeight
+}
+
+// AssetMarkersActive returns whether asset marker outputs are recognised in the
+// block at the passed height.
+func (p Params) AssetMarkersActive(height uint32) bool {
+	return p.AssetMarkerHeight != 0 && height >= p.AssetMarkerHeight
+}
+
+// FreezeActive returns whether accounts may be frozen in the block at the
+// passed height.
+func (p Params) FreezeActive(height uint32) bool {
+	return p.FreezeHeight != 0 && height >= p.FreezeHeight
+}
+
+// IssueDestsActive returns whether issuance destinations may be added and
+// revoked in the block at the passed height.
+func (p Params) IssueDestsActive(height uint32) bool {
+	return p.IssueDestHeight != 0 && height >= p.IssueDestHeight
+}
+
+// KeyExpiryActive returns whether keys may be provisioned with an expiry
+// height, and expire, in the block at the passed height.
+func (p Params) KeyExpiryActive(height uint32) bool {
+	return p.KeyExpiryHeight != 0 && height >= p.KeyExpiryHeight
+}
+
+// DelegatesActive returns whether delegate keys may be added, revoked and
+// sign provision thread transactions in the block at the passed height.
+func (p Params) DelegatesActive(height uint32) bool {
+	return p.DelegateHeight != 0 && height >= p.DelegateHeight
+}
+
+// HTLCActive returns whether Prova HTLC scripts may be paid to and spent in the
+// block at the passed height.
+func (p Params) HTLCActive(height uint32) bool {
+	return p.HTLCHeight != 0 && height >= p.HTLCHeight
+}
+
+// defaultMaxBlockSize is the maximum serialized size in bytes of a block on the
+// default networks until an increase is scheduled.
+const defaultMaxBlockSize = 2000000
+
+// MaxBlockSizeAtHeight returns the maximum serialized size in bytes of the
+// block at the passed height, following the scheduled increases.
+func (p Params) MaxBlockSizeAtHeight(height uint32) uint32 {
+	maxSize := p.MaxBlockSize
+	if maxSize == 0 {
+		maxSize = wire.MaxBlockPayload
+	}
+	for _, step := range p.MaxBlockSizeSchedule {
+		if step.Height > height {
+			break
+		}
+		maxSize = step.MaxSize
+	}
+	return maxSize
+}
+
 // AveragingWindowTimespan returns the difficulty timespan to be averaged over.
 func (p Params) AveragingWindowTimespan() time.Duration {
 	return time.Duration(p.PowAveragingWindow) * p.TargetTimePerBlock
@@ -278,6 +548,11 @@ var MainNetParams = Params{
 
 		return keySets
 	}(),
+	AdminThresholds: map[btcec.KeySetType]int{
+		btcec.RootKeySet:      2,
+		btcec.ProvisionKeySet: 2,
+		btcec.IssueKeySet:     2,
+	},
 	ASPKeyIdMap: func() btcec.KeyIdMap {
 		// BitGo ASP Key
 		pubKey1, _ := btcec.ParsePubKey(hexToBytes("033fa570adba7413fbe0fb90f358e823b003371c47dd4e3769028e122f40ea7496"), btcec.S256())
@@ -289,6 +564,9 @@ var MainNetParams = Params{
 	PowLimitBits:             0x1f07ffff,
 	CoinbaseMaturity:         100,
 	SubsidyReductionInterval: 210000,
+	MaxSupply:                0,
+	IssuanceEpochBlocks:      0,
+	MaxEpochIssuance:         0,
 	TargetTimePerBlock:       time.Second * 150, // 2.5 minutes
 	GenerateSupported:        true,
 
@@ -305,13 +583,23 @@ var MainNetParams = Params{
 	BlockRejectNumRequired:  950,
 	BlockUpgradeNumToCheck:  1000,
 
+	// Maximum serialized block size, in bytes.  It is below the max block
+	// message payload, leaving room for scheduled increases.
+	MaxBlockSize: defaultMaxBlockSize,
+
 	// Mempool parameters
-	RelayNonStdTxs: false,
+	RelayNonStdTxs:           false,
+	MaxStandardTxSize:        100000,
+	MaxStandardSigScriptSize: 1650,
+	MaxRevokedKeyIDOutputs:   -1,
 
 	// Address encoding magics
 	PrivateKeyID: 0x80, // starts with 5 (uncompressed) or K (compressed)
 	ProvaAddrID:  0x33, // starts with G
 
+	// Human-readable part of bech32m encoded Prova addresses
+	Bech32HRPProva: "dmg",
+
 	// BIP32 hierarchical deterministic extended key magics
 	HDPrivateKeyID: [4]byte{0x04, 0x88, 0xad, 0xe4}, // starts with xprv
 	HDPublicKeyID:  [4]byte{0x04, 0x88, 0xb2, 0x1e}, // starts with xpub
@@ -385,6 +673,11 @@ var RegressionNetParams = Params{
 
 		return keySets
 	}(),
+	AdminThresholds: map[btcec.KeySetType]int{
+		btcec.RootKeySet:      2,
+		btcec.ProvisionKeySet: 2,
+		btcec.IssueKeySet:     2,
+	},
 	ASPKeyIdMap: func() btcec.KeyIdMap {
 		pubKey1, _ := btcec.ParsePubKey(hexToBytes("025ceeba2ab4a635df2c0301a3d773da06ac5a18a7c3e0d09a795d7e57d233edf1"), btcec.S256())
 		pubKey2, _ := btcec.ParsePubKey(hexToBytes("038ef4a121bcaf1b1f175557a12896f8bc93b095e84817f90e9a901cd2113a8202"), btcec.S256())
@@ -394,6 +687,9 @@ var RegressionNetParams = Params{
 	PowLimitBits:             0x200f0f0f,
 	CoinbaseMaturity:         100,
 	SubsidyReductionInterval: 150,
+	MaxSupply:                20000000000,
+	IssuanceEpochBlocks:      144,
+	MaxEpochIssuance:         12000000000,
 	TargetTimePerBlock:       time.Minute, // 1 minute
 	GenerateSupported:        true,
 
@@ -407,13 +703,56 @@ var RegressionNetParams = Params{
 	BlockRejectNumRequired:  950,
 	BlockUpgradeNumToCheck:  1000,
 
+	// Maximum serialized block size, in bytes.  It is below the max block
+	// message payload, leaving room for scheduled increases.
+	MaxBlockSize: defaultMaxBlockSize,
+
+	// Blocks may be signed by threshold validate keys from the first
+	// block.
+	SchnorrBlockSigHeight: 1,
+
+	// The signature operations of spent Prova scripts are counted from
+	// the first block.
+	ProvaSigOpsHeight: 1,
+
+	// Scripts may check the keys of keyIDs from the first block.
+	CheckKeyIDVerifyHeight: 1,
+
+	// Destructions may only return change to the destroyed outputs or
+	// allowlisted destinations from the first block.
+	IssueChangeHeight: 1,
+
+	// Transactions may move other assets than DMG from the first block.
+	AssetMarkerHeight: 1,
+
+	// Accounts may be frozen from the first block.
+	FreezeHeight: 1,
+
+	// Issuance destinations may be allowlisted from the first block.
+	IssueDestHeight: 1,
+
+	// Keys may be provisioned with an expiry height from the first block.
+	KeyExpiryHeight: 1,
+
+	// Provision keys may be delegated from the first block.
+	DelegateHeight: 1,
+
+	// Transactions may pay to Prova HTLC scripts from the first block.
+	HTLCHeight: 1,
+
 	// Mempool parameters
-	RelayNonStdTxs: false,
+	RelayNonStdTxs:           false,
+	MaxStandardTxSize:        100000,
+	MaxStandardSigScriptSize: 1650,
+	MaxRevokedKeyIDOutputs:   -1,
 
 	// Address encoding magics
 	ProvaAddrID:  0x58, // starts with T
 	PrivateKeyID: 0xef, // starts with 9 (uncompressed) or c (compressed)
 
+	// Human-readable part of bech32m encoded Prova addresses
+	Bech32HRPProva: "rdmg",
+
 	// BIP32 hierarchical deterministic extended key magics
 	HDPrivateKeyID: [4]byte{0x04, 0x35, 0x83, 0x94}, // starts with tprv
 	HDPublicKeyID:  [4]byte{0x04, 0x35, 0x87, 0xcf}, // starts with tpub
@@ -493,6 +832,11 @@ var TestNetParams = Params{
 
 		return keySets
 	}(),
+	AdminThresholds: map[btcec.KeySetType]int{
+		btcec.RootKeySet:      2,
+		btcec.ProvisionKeySet: 2,
+		btcec.IssueKeySet:     2,
+	},
 	ASPKeyIdMap: func() btcec.KeyIdMap {
 		// Pyx Keys
 		pubKey1, _ := btcec.ParsePubKey(hexToBytes("029598303b2e05ccdd493f08b016747d7d9f9e6d39a7ba01114533e43e8c7fea81"), btcec.S256())
@@ -505,6 +849,9 @@ var TestNetParams = Params{
 	PowLimitBits:             0x2007ffff,
 	CoinbaseMaturity:         100,
 	SubsidyReductionInterval: 210000,
+	MaxSupply:                0,
+	IssuanceEpochBlocks:      0,
+	MaxEpochIssuance:         0,
 	TargetTimePerBlock:       time.Second * 150, // 2.5 minutes
 	GenerateSupported:        true,
 
@@ -521,13 +868,23 @@ var TestNetParams = Params{
 	BlockRejectNumRequired:  75,
 	BlockUpgradeNumToCheck:  100,
 
+	// Maximum serialized block size, in bytes.  It is below the max block
+	// message payload, leaving room for scheduled increases.
+	MaxBlockSize: defaultMaxBlockSize,
+
 	// Mempool parameters
-	RelayNonStdTxs: false,
+	RelayNonStdTxs:           false,
+	MaxStandardTxSize:        100000,
+	MaxStandardSigScriptSize: 1650,
+	MaxRevokedKeyIDOutputs:   -1,
 
 	// Address encoding magics
 	PrivateKeyID: 0xef, // starts with 9 (uncompressed) or c (compressed)
 	ProvaAddrID:  0x58, // starts with T
 
+	// Human-readable part of bech32m encoded Prova addresses
+	Bech32HRPProva: "tdmg",
+
 	// BIP32 hierarchical deterministic extended key magics
 	HDPrivateKeyID: [4]byte{0x04, 0x35, 0x83, 0x94}, // starts with tprv
 	HDPublicKeyID:  [4]byte{0x04, 0x35, 0x87, 0xcf}, // starts with tpub
@@ -572,6 +929,9 @@ var SimNetParams = Params{
 	PowLimitBits:             0x207fffff,
 	CoinbaseMaturity:         100,
 	SubsidyReductionInterval: 210000,
+	MaxSupply:                0,
+	IssuanceEpochBlocks:      0,
+	MaxEpochIssuance:         0,
 	TargetTimePerBlock:       time.Second * 150, // 2.5 minutes
 	GenerateSupported:        true,
 
@@ -588,12 +948,55 @@ var SimNetParams = Params{
 	BlockRejectNumRequired:  75,
 	BlockUpgradeNumToCheck:  100,
 
+	// Maximum serialized block size, in bytes.  It is below the max block
+	// message payload, leaving room for scheduled increases.
+	MaxBlockSize: defaultMaxBlockSize,
+
+	// Blocks may be signed by threshold validate keys from the first
+	// block.
+	SchnorrBlockSigHeight: 1,
+
+	// The signature operations of spent Prova scripts are counted from
+	// the first block.
+	ProvaSigOpsHeight: 1,
+
+	// Scripts may check the keys of keyIDs from the first block.
+	CheckKeyIDVerifyHeight: 1,
+
+	// Destructions may only return change to the destroyed outputs or
+	// allowlisted destinations from the first block.
+	IssueChangeHeight: 1,
+
+	// Transactions may move other assets than DMG from the first block.
+	AssetMarkerHeight: 1,
+
+	// Accounts may be frozen from the first block.
+	FreezeHeight: 1,
+
+	// Issuance destinations may be allowlisted from the first block.
+	IssueDestHeight: 1,
+
+	// Keys may be provisioned with an expiry height from the first block.
+	KeyExpiryHeight: 1,
+
+	// Provision keys may be delegated from the first block.
+	DelegateHeight: 1,
+
+	// Transactions may pay to Prova HTLC scripts from the first block.
+	HTLCHeight: 1,
+
 	// Mempool parameters
-	RelayNonStdTxs: false,
+	RelayNonStdTxs:           false,
+	MaxStandardTxSize:        100000,
+	MaxStandardSigScriptSize: 1650,
+	MaxRevokedKeyIDOutputs:   -1,
 
 	// Address encoding magics
 	PrivateKeyID: 0x64, // starts with 4 (uncompressed) or F (compressed)
 
+	// Human-readable part of bech32m encoded Prova addresses
+	Bech32HRPProva: "sdmg",
+
 	// BIP32 hierarchical deterministic extended key magics
 	HDPrivateKeyID: [4]byte{0x04, 0x20, 0xb9, 0x00}, // starts with sprv
 	HDPublicKeyID:  [4]byte{0x04, 0x20, 0xbd, 0x3a}, // starts with spub
@@ -624,6 +1027,21 @@ var (
 	// network or previously-registered into this package.
 	ErrDuplicateNet = errors.New("duplicate Bitcoin network")
 
+	// ErrInvalidBlockSizeSchedule describes an error where the scheduled
+	// maximum block sizes of a network are not increases at ascending
+	// heights, or exceed the maximum block message payload.
+	ErrInvalidBlockSizeSchedule = errors.New("invalid max block size " +
+		"schedule")
+
+	// ErrInvalidAdminThresholds describes an error where the admin
+	// thresholds of a network are not set for admin threads, or exceed
+	// the number of keys their genesis key sets hold.
+	ErrInvalidAdminThresholds = errors.New("invalid admin thresholds")
+
+	// ErrInvalidScriptLimits describes an error where a script engine
+	// limit of a network is negative.
+	ErrInvalidScriptLimits = errors.New("invalid script limits")
+
 	// ErrUnknownHDKeyID describes an error where the provided id which
 	// is intended to identify the network for a hierarchical deterministic
 	// private extended key is not registered.
@@ -635,6 +1053,7 @@ var (
 	pubKeyHashAddrIDs = make(map[byte]struct{})
 	scriptHashAddrIDs = make(map[byte]struct{})
 	provaAddrIDs      = make(map[byte]struct{})
+	provaHRPs         = make(map[string]byte)
 	hdPrivToPubKeyIDs = make(map[[4]byte][]byte)
 )
 
@@ -646,7 +1065,9 @@ func (d DNSSeed) String() string {
 // Register registers the network parameters for a Bitcoin network.  This may
 // error with ErrDuplicateNet if the network is already registered (either
 // due to a previous Register call, or the network being one of the default
-// networks).
+// networks), with ErrInvalidBlockSizeSchedule if its max block size schedule
+// is invalid, with ErrInvalidAdminThresholds if its admin thresholds are, or
+// with ErrInvalidScriptLimits if its script limits are.
 //
 // Network parameters should be registered into this package by a main package
 // as early as possible.  Then, library packages may lookup networks or network
@@ -656,14 +1077,70 @@ func Register(params *Params) error {
 	if _, ok := registeredNets[params.Net]; ok {
 		return ErrDuplicateNet
 	}
+	if !validBlockSizeSchedule(params) {
+		return ErrInvalidBlockSizeSchedule
+	}
+	if !validAdminThresholds(params) {
+		return ErrInvalidAdminThresholds
+	}
+	limits := params.ScriptLimits
+	if limits.MaxScriptSize < 0 || limits.MaxStackSize < 0 ||
+		limits.MaxOpsPerScript < 0 {
+
+		return ErrInvalidScriptLimits
+	}
 	registeredNets[params.Net] = struct{}{}
 	if params.ProvaAddrID != 0 {
 		provaAddrIDs[params.ProvaAddrID] = struct{}{}
 	}
+	if params.Bech32HRPProva != "" {
+		provaHRPs[strings.ToLower(params.Bech32HRPProva)] = params.ProvaAddrID
+	}
 	hdPrivToPubKeyIDs[params.HDPrivateKeyID] = params.HDPublicKeyID[:]
 	return nil
 }
 
+// validBlockSizeSchedule returns whether the scheduled max block sizes of the
+// passed network are increases at ascending heights which do not exceed the
+// maximum block message payload.
+func validBlockSizeSchedule(params *Params) bool {
+	maxSize := params.MaxBlockSize
+	if maxSize == 0 {
+		maxSize = wire.MaxBlockPayload
+	}
+	if maxSize > wire.MaxBlockPayload {
+		return false
+	}
+	for i, step := range params.MaxBlockSizeSchedule {
+		if step.MaxSize <= maxSize || step.MaxSize > wire.MaxBlockPayload {
+			return false
+		}
+		if i > 0 && step.Height <= params.MaxBlockSizeSchedule[i-1].Height {
+			return false
+		}
+		maxSize = step.MaxSize
+	}
+	return true
+}
+
+// validAdminThresholds returns whether the admin thresholds of the passed
+// network are set for the key sets of admin threads only, and can be met by
+// the keys of their genesis key sets.
+func validAdminThresholds(params *Params) bool {
+	for keySetType, threshold := range params.AdminThresholds {
+		switch keySetType {
+		case btcec.RootKeySet, btcec.ProvisionKeySet, btcec.IssueKeySet:
+		default:
+			return false
+		}
+		if threshold < 0 ||
+			threshold > len(params.AdminKeySets[keySetType]) {
+			return false
+		}
+	}
+	return true
+}
+
 // mustRegister performs the same function as Register except it panics if there
 // is an error.  This should only be called from package init functions.
 func mustRegister(params *Params) {
@@ -702,6 +1179,15 @@ func IsProvaAddrID(id byte) bool {
 	return ok
 }
 
+// ProvaAddrIDForHRP returns the identifier prefixing the base58 encoded Prova
+// addresses of the default or registered network whose bech32m encoded Prova
+// addresses have the passed human-readable part, and whether such a network
+// exists.  This is used when decoding a bech32m address string.
+func ProvaAddrIDForHRP(hrp string) (byte, bool) {
+	id, ok := provaHRPs[strings.ToLower(hrp)]
+	return id, ok
+}
+
 // HDPrivateKeyToPublicKeyID accepts a private hierarchical deterministic
 // extended key id and returns the associated public key id.  When the provided
 // id is not registered, the ErrUnknownHDKeyID error will be returned.
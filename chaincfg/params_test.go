@@ -9,6 +9,9 @@ package chaincfg
 import (
 	"fmt"
 	"testing"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/wire"
 )
 
 // TestInvalidHashStr ensures the newShaHashFromStr function panics when used to
@@ -81,3 +84,215 @@ func TestMinValidateKeySetSize(t *testing.T) {
 		t.Error(str)
 	}
 }
+
+// TestMaxBlockSizeAtHeight ensures the max block size follows the scheduled
+// increases, and that invalid schedules are rejected on registration.
+func TestMaxBlockSizeAtHeight(t *testing.T) {
+	params := Params{
+		Net:          1<<32 - 2,
+		MaxBlockSize: 1000000,
+		MaxBlockSizeSchedule: []BlockSizeStep{
+			{Height: 100, MaxSize: 1500000},
+			{Height: 200, MaxSize: 2000000},
+		},
+	}
+	tests := []struct {
+		height uint32
+		want   uint32
+	}{
+		{0, 1000000},
+		{99, 1000000},
+		{100, 1500000},
+		{199, 1500000},
+		{200, 2000000},
+		{1 << 31, 2000000},
+	}
+	for _, test := range tests {
+		got := params.MaxBlockSizeAtHeight(test.height)
+		if got != test.want {
+			t.Errorf("MaxBlockSizeAtHeight(%d): got %d, want %d",
+				test.height, got, test.want)
+		}
+	}
+
+	// A zero max block size defaults to the max block payload.
+	var defaults Params
+	if got := defaults.MaxBlockSizeAtHeight(0); got != wire.MaxBlockPayload {
+		t.Errorf("MaxBlockSizeAtHeight: got %d, want %d", got,
+			wire.MaxBlockPayload)
+	}
+
+	invalid := []struct {
+		name     string
+		maxSize  uint32
+		schedule []BlockSizeStep
+	}{
+		{
+			name:    "max size above max block payload",
+			maxSize: wire.MaxBlockPayload + 1,
+		},
+		{
+			name:     "scheduled decrease",
+			maxSize:  1000000,
+			schedule: []BlockSizeStep{{Height: 100, MaxSize: 500000}},
+		},
+		{
+			name:    "descending heights",
+			maxSize: 1000000,
+			schedule: []BlockSizeStep{
+				{Height: 200, MaxSize: 1500000},
+				{Height: 100, MaxSize: 2000000},
+			},
+		},
+		{
+			name:    "scheduled size above max block payload",
+			maxSize: 1000000,
+			schedule: []BlockSizeStep{
+				{Height: 100, MaxSize: wire.MaxBlockPayload + 1},
+			},
+		},
+	}
+	for _, test := range invalid {
+		params := Params{
+			Net:                  1<<32 - 3,
+			MaxBlockSize:         test.maxSize,
+			MaxBlockSizeSchedule: test.schedule,
+		}
+		if err := Register(&params); err != ErrInvalidBlockSizeSchedule {
+			t.Errorf("%s: got error %v, want %v", test.name, err,
+				ErrInvalidBlockSizeSchedule)
+		}
+	}
+	if err := Register(&params); err != nil {
+		t.Errorf("Register: %v", err)
+	}
+
+	// The default networks may schedule increases up to the max block
+	// payload.
+	for _, params := range []Params{MainNetParams, TestNetParams,
+		RegressionNetParams, SimNetParams} {
+
+		if !validBlockSizeSchedule(&params) {
+			t.Errorf("%s: invalid max block size schedule",
+				params.Name)
+		}
+		params.MaxBlockSizeSchedule = []BlockSizeStep{
+			{Height: 1000, MaxSize: wire.MaxBlockPayload},
+		}
+		if !validBlockSizeSchedule(&params) {
+			t.Errorf("%s: increase to the max block payload "+
+				"rejected", params.Name)
+		}
+		if got := params.MaxBlockSizeAtHeight(1000); got !=
+			wire.MaxBlockPayload {
+
+			t.Errorf("%s: MaxBlockSizeAtHeight: got %d, want %d",
+				params.Name, got, wire.MaxBlockPayload)
+		}
+	}
+}
+
+// TestAdminThreshold ensures admin thresholds default for admin threads without
+// one, and that invalid thresholds are rejected on registration.
+func TestAdminThreshold(t *testing.T) {
+	keySet, err := btcec.ParsePubKeySet(btcec.S256(),
+		"025ceeba2ab4a635df2c0301a3d773da06ac5a18a7c3e0d09a795d7e57d233edf1",
+		"038ef4a121bcaf1b1f175557a12896f8bc93b095e84817f90e9a901cd2113a8202",
+		"0248707c5d4267a6b340e108d14aaec1d1ec0a800fc9dd75a8318b3d7eb198590d",
+	)
+	if err != nil {
+		t.Fatalf("ParsePubKeySet: %v", err)
+	}
+	params := Params{
+		Net: 1<<32 - 4,
+		AdminKeySets: map[btcec.KeySetType]btcec.PublicKeySet{
+			btcec.RootKeySet:      keySet[:2],
+			btcec.ProvisionKeySet: keySet,
+			btcec.IssueKeySet:     keySet,
+		},
+		AdminThresholds: map[btcec.KeySetType]int{
+			btcec.ProvisionKeySet: 0,
+			btcec.IssueKeySet:     3,
+		},
+	}
+	tests := []struct {
+		keySetType btcec.KeySetType
+		want       int
+	}{
+		{btcec.RootKeySet, DefaultAdminThreshold},
+		{btcec.ProvisionKeySet, DefaultAdminThreshold},
+		{btcec.IssueKeySet, 3},
+	}
+	for _, test := range tests {
+		got := params.AdminThreshold(test.keySetType)
+		if got != test.want {
+			t.Errorf("AdminThreshold(%v): got %d, want %d",
+				test.keySetType, got, test.want)
+		}
+	}
+
+	invalid := []struct {
+		name       string
+		thresholds map[btcec.KeySetType]int
+	}{
+		{
+			name: "threshold above key set size",
+			thresholds: map[btcec.KeySetType]int{
+				btcec.RootKeySet: 3,
+			},
+		},
+		{
+			name: "negative threshold",
+			thresholds: map[btcec.KeySetType]int{
+				btcec.IssueKeySet: -1,
+			},
+		},
+		{
+			name: "threshold of non-thread key set",
+			thresholds: map[btcec.KeySetType]int{
+				btcec.ValidateKeySet: 1,
+			},
+		},
+	}
+	for _, test := range invalid {
+		invalidParams := params
+		invalidParams.Net = 1<<32 - 5
+		invalidParams.AdminThresholds = test.thresholds
+		err := Register(&invalidParams)
+		if err != ErrInvalidAdminThresholds {
+			t.Errorf("%s: got error %v, want %v", test.name, err,
+				ErrInvalidAdminThresholds)
+		}
+	}
+	if err := Register(&params); err != nil {
+		t.Errorf("Register: %v", err)
+	}
+}
+
+// TestScriptEngineLimits ensures zero script limits default to the consensus
+// limits, and that negative limits are rejected on registration.
+func TestScriptEngineLimits(t *testing.T) {
+	params := Params{
+		Net:          1<<32 - 6,
+		ScriptLimits: ScriptLimits{MaxStackSize: 2000},
+	}
+	want := ScriptLimits{
+		MaxScriptSize:   DefaultMaxScriptSize,
+		MaxStackSize:    2000,
+		MaxOpsPerScript: DefaultMaxOpsPerScript,
+	}
+	if got := params.ScriptEngineLimits(); got != want {
+		t.Errorf("ScriptEngineLimits: got %+v, want %+v", got, want)
+	}
+
+	invalidParams := params
+	invalidParams.Net = 1<<32 - 7
+	invalidParams.ScriptLimits.MaxOpsPerScript = -1
+	if err := Register(&invalidParams); err != ErrInvalidScriptLimits {
+		t.Errorf("Register: got error %v, want %v", err,
+			ErrInvalidScriptLimits)
+	}
+	if err := Register(&params); err != nil {
+		t.Errorf("Register: %v", err)
+	}
+}
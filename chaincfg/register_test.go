@@ -33,6 +33,11 @@ func TestRegister(t *testing.T) {
 		want []byte
 		err  error
 	}
+	type hrpTest struct {
+		hrp   string
+		want  byte
+		valid bool
+	}
 
 	tests := []struct {
 		name        string
@@ -40,6 +45,7 @@ func TestRegister(t *testing.T) {
 		p2pkhMagics []magicTest
 		p2shMagics  []magicTest
 		hdMagics    []hdTest
+		hrps        []hrpTest
 	}{
 		{
 			name: "default networks",
@@ -181,6 +187,22 @@ func TestRegister(t *testing.T) {
 					err:  ErrUnknownHDKeyID,
 				},
 			},
+			hrps: []hrpTest{
+				{
+					hrp:   MainNetParams.Bech32HRPProva,
+					want:  MainNetParams.ProvaAddrID,
+					valid: true,
+				},
+				{
+					hrp:   "TDMG",
+					want:  TestNetParams.ProvaAddrID,
+					valid: true,
+				},
+				{
+					hrp:   "bc",
+					valid: false,
+				},
+			},
 		},
 	}
 
@@ -218,5 +240,12 @@ func TestRegister(t *testing.T) {
 					test.name, i, pubKey, magTest.want[:])
 			}
 		}
+		for i, hrpTest := range test.hrps {
+			id, valid := ProvaAddrIDForHRP(hrpTest.hrp)
+			if valid != hrpTest.valid || id != hrpTest.want {
+				t.Errorf("%s: HRP %d mismatch: got %v, %v expected %v, %v",
+					test.name, i, id, valid, hrpTest.want, hrpTest.valid)
+			}
+		}
 	}
 }
@@ -0,0 +1,126 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// signAnnouncementCmd defines the configuration options for the
+// signannouncement command.
+type signAnnouncementCmd struct {
+	Announcement string `long:"announcement" description:"Hex of an announcement signed by another key holder to add a signature to; a new announcement is created when omitted"`
+	Category     string `long:"category" description:"Category of a new announcement: notice, upgrade, keyceremony or emergency"`
+	Hours        uint16 `long:"hours" description:"Number of hours until a new announcement expires"`
+	Message      string `long:"message" description:"Message of a new announcement"`
+	KeyFile      string `long:"keyfile" description:"File holding the hex-encoded root or provision key to sign with" required:"true"`
+}
+
+var (
+	// signAnnouncementCfg defines the configuration options for the
+	// command.
+	signAnnouncementCfg = signAnnouncementCmd{}
+)
+
+// signAnnouncementResult is the result of the signannouncement command.
+type signAnnouncementResult struct {
+	Hash       string `json:"hash"`
+	Category   string `json:"category"`
+	Time       string `json:"time"`
+	Expiration string `json:"expiration"`
+	Message    string `json:"message"`
+	Signatures int    `json:"signatures"`
+	Hex        string `json:"hex"`
+}
+
+// announcement decodes the announcement passed with the command, or creates a
+// new one at the passed time from the options when none is passed.
+func (cmd *signAnnouncementCmd) announcement(now time.Time) (*wire.MsgAnnounce, error) {
+	if cmd.Announcement != "" {
+		if cmd.Category != "" || cmd.Hours != 0 || cmd.Message != "" {
+			return nil, errors.New("the category, hours and " +
+				"message can only be passed for a new " +
+				"announcement")
+		}
+		msgBytes, err := hex.DecodeString(cmd.Announcement)
+		if err != nil {
+			return nil, fmt.Errorf("announcement is not "+
+				"hex-encoded: %v", err)
+		}
+		var msg wire.MsgAnnounce
+		err = msg.BtcDecode(bytes.NewReader(msgBytes),
+			wire.AnnounceVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid announcement: %v", err)
+		}
+		return &msg, nil
+	}
+
+	category := wire.AnnounceCategory(255)
+	for c := wire.AnnounceNotice; c <= wire.AnnounceEmergency; c++ {
+		if c.String() == cmd.Category {
+			category = c
+		}
+	}
+	if category > wire.AnnounceEmergency {
+		return nil, fmt.Errorf("unknown category %q", cmd.Category)
+	}
+	if cmd.Hours == 0 {
+		return nil, errors.New("the hours until the announcement " +
+			"expires must be specified")
+	}
+	if cmd.Message == "" {
+		return nil, errors.New("no message specified")
+	}
+	return wire.NewMsgAnnounce(category, now,
+		now.Add(time.Duration(cmd.Hours)*time.Hour), cmd.Message), nil
+}
+
+// Execute is the main entry point for the command.  It's invoked by the parser.
+func (cmd *signAnnouncementCmd) Execute(args []string) error {
+	if err := setupGlobalConfig(); err != nil {
+		return err
+	}
+	msg, err := cmd.announcement(time.Now())
+	if err != nil {
+		return err
+	}
+	privKey, err := readPrivKey(cmd.KeyFile)
+	if err != nil {
+		return err
+	}
+	defer privKey.Zero()
+	if err := msg.AddSignature(privKey); err != nil {
+		return err
+	}
+	if _, err := msg.SigningKeys(); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, wire.AnnounceVersion); err != nil {
+		return err
+	}
+
+	result := &signAnnouncementResult{
+		Hash:       msg.AnnounceHash().String(),
+		Category:   msg.Category.String(),
+		Time:       msg.Timestamp.UTC().String(),
+		Expiration: msg.Expiration.UTC().String(),
+		Message:    msg.Message,
+		Signatures: len(msg.Signatures),
+		Hex:        hex.EncodeToString(buf.Bytes()),
+	}
+	return printResult(result, fmt.Sprintf("Announcement: %s\n"+
+		"Category: %s\nTime: %s\nExpiration: %s\nMessage: %s\n"+
+		"Signatures: %d\nHex: %s\n", result.Hash, result.Category,
+		result.Time, result.Expiration, result.Message,
+		result.Signatures, result.Hex))
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// TestAnnouncement ensures new announcements are created from the options of
+// the signannouncement command, announcements signed by other key holders are
+// decoded, and inconsistent options are rejected.
+func TestAnnouncement(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	signed := wire.NewMsgAnnounce(wire.AnnounceUpgrade, now,
+		now.Add(time.Hour), "upgrade to v2")
+	if err := signed.AddSignature(testPrivKey(1)); err != nil {
+		t.Fatalf("AddSignature: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := signed.BtcEncode(&buf, wire.AnnounceVersion); err != nil {
+		t.Fatalf("BtcEncode: %v", err)
+	}
+	signedHex := hex.EncodeToString(buf.Bytes())
+
+	tests := []struct {
+		name    string
+		cmd     signAnnouncementCmd
+		want    *wire.MsgAnnounce
+		isValid bool
+	}{
+		{
+			name: "new announcement",
+			cmd: signAnnouncementCmd{Category: "keyceremony",
+				Hours: 48, Message: "rotating keys"},
+			want: wire.NewMsgAnnounce(wire.AnnounceKeyCeremony, now,
+				now.Add(48*time.Hour), "rotating keys"),
+			isValid: true,
+		},
+		{
+			name:    "signed announcement",
+			cmd:     signAnnouncementCmd{Announcement: signedHex},
+			want:    signed,
+			isValid: true,
+		},
+		{
+			name: "unknown category",
+			cmd: signAnnouncementCmd{Category: "rumor", Hours: 1,
+				Message: "m"},
+		},
+		{
+			name: "no hours",
+			cmd:  signAnnouncementCmd{Category: "notice", Message: "m"},
+		},
+		{
+			name: "no message",
+			cmd:  signAnnouncementCmd{Category: "notice", Hours: 1},
+		},
+		{
+			name: "signed announcement with a message",
+			cmd: signAnnouncementCmd{Announcement: signedHex,
+				Message: "m"},
+		},
+		{
+			name: "invalid hex",
+			cmd:  signAnnouncementCmd{Announcement: "xyz"},
+		},
+		{
+			name: "truncated announcement",
+			cmd:  signAnnouncementCmd{Announcement: signedHex[:20]},
+		},
+	}
+
+	for _, test := range tests {
+		msg, err := test.cmd.announcement(now)
+		if (err == nil) != test.isValid {
+			t.Errorf("%s: got error %v, want valid %v", test.name,
+				err, test.isValid)
+			continue
+		}
+		if !test.isValid {
+			continue
+		}
+		if msg.AnnounceHash() != test.want.AnnounceHash() ||
+			len(msg.Signatures) != len(test.want.Signatures) {
+
+			t.Errorf("%s: got announcement %v with %d signatures, "+
+				"want %v with %d", test.name, msg.AnnounceHash(),
+				len(msg.Signatures), test.want.AnnounceHash(),
+				len(test.want.Signatures))
+		}
+	}
+}
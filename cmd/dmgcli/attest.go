@@ -0,0 +1,85 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/provautil/reserve"
+)
+
+// attestReservesCmd defines the configuration options for the attestreserves
+// command.
+type attestReservesCmd struct {
+	Attestation string `long:"attestation" description:"Hex of the unsigned attestation as returned by getreserveproof" required:"true"`
+	KeyFile     string `long:"keyfile" description:"File holding the hex-encoded attestation key" required:"true"`
+}
+
+var (
+	// attestReservesCfg defines the configuration options for the
+	// command.
+	attestReservesCfg = attestReservesCmd{}
+)
+
+// attestReservesResult is the result of the attestreserves command.
+type attestReservesResult struct {
+	BlockHash   string `json:"blockhash"`
+	BlockHeight uint32 `json:"blockheight"`
+	Outputs     int    `json:"outputs"`
+	Total       string `json:"total"`
+	Hex         string `json:"hex"`
+}
+
+// signAttestation signs the passed hex-encoded attestation with the key of the
+// passed file and returns it.
+func signAttestation(attestationHex, keyFile string) (*reserve.Attestation, error) {
+	attestationBytes, err := hex.DecodeString(attestationHex)
+	if err != nil {
+		return nil, fmt.Errorf("attestation is not hex-encoded: %v", err)
+	}
+	var attestation reserve.Attestation
+	err = attestation.Deserialize(bytes.NewReader(attestationBytes))
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestation: %v", err)
+	}
+	privKey, err := readPrivKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	defer privKey.Zero()
+	if err := attestation.Sign(privKey); err != nil {
+		return nil, err
+	}
+	return &attestation, nil
+}
+
+// Execute is the main entry point for the command.  It's invoked by the parser.
+func (cmd *attestReservesCmd) Execute(args []string) error {
+	if err := setupGlobalConfig(); err != nil {
+		return err
+	}
+	attestation, err := signAttestation(cmd.Attestation, cmd.KeyFile)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := attestation.Serialize(&buf); err != nil {
+		return err
+	}
+
+	result := &attestReservesResult{
+		BlockHash:   attestation.BlockHash.String(),
+		BlockHeight: attestation.BlockHeight,
+		Outputs:     len(attestation.Proofs),
+		Total:       provautil.Amount(attestation.Total()).String(),
+		Hex:         hex.EncodeToString(buf.Bytes()),
+	}
+	return printResult(result, fmt.Sprintf("Block: %s (height %d)\n"+
+		"Outputs: %d\nTotal: %s\nHex: %s\n", result.BlockHash,
+		result.BlockHeight, result.Outputs, result.Total, result.Hex))
+}
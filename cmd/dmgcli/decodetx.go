@@ -0,0 +1,393 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// decodeTxCmd defines the configuration options for the decodetx command.
+type decodeTxCmd struct {
+	Verbose  bool     `short:"v" long:"verbose" description:"Also print the scripts and the signature hashes of the inputs with their preimages"`
+	PrevOuts []string `long:"prevout" description:"Output spent by an input as <input>:<pkscript hex>:<amount>; pass once per input"`
+}
+
+var (
+	// decodeTxCfg defines the configuration options for the command.
+	decodeTxCfg = decodeTxCmd{}
+)
+
+// threadNames maps the admin threads to their names.
+var threadNames = map[provautil.ThreadID]string{
+	provautil.RootThread:      "root",
+	provautil.ProvisionThread: "provision",
+	provautil.IssueThread:     "issue",
+}
+
+// prevOut is an output spent by an input of the decoded transaction.
+type prevOut struct {
+	pkScript []byte
+	amount   int64
+}
+
+// signersResult describes the keys which must sign an input.
+type signersResult struct {
+	Required   int      `json:"required"`
+	KeySet     string   `json:"keyset,omitempty"`
+	Addresses  []string `json:"addresses,omitempty"`
+	PubKeyHash []string `json:"pubkeyhashes,omitempty"`
+	KeyIDs     []uint32 `json:"keyids,omitempty"`
+}
+
+// adminOpResult describes an admin op carried by an output.
+type adminOpResult struct {
+	Op     string `json:"op"`
+	KeySet string `json:"keyset,omitempty"`
+	Args   string `json:"args"`
+}
+
+// decodeTxInput describes an input of the decoded transaction.
+type decodeTxInput struct {
+	OutPoint    string         `json:"outpoint"`
+	Sequence    uint32         `json:"sequence"`
+	ScriptSig   string         `json:"scriptsig,omitempty"`
+	PrevScript  string         `json:"prevscript,omitempty"`
+	PrevClass   string         `json:"prevclass,omitempty"`
+	PrevAmount  *float64       `json:"prevamount,omitempty"`
+	Thread      string         `json:"thread,omitempty"`
+	Signers     *signersResult `json:"signers,omitempty"`
+	SigHash     string         `json:"sighash,omitempty"`
+	SigHashType string         `json:"sighashtype,omitempty"`
+	Preimage    string         `json:"sighashpreimage,omitempty"`
+}
+
+// decodeTxOutput describes an output of the decoded transaction.
+type decodeTxOutput struct {
+	N         int            `json:"n"`
+	Value     float64        `json:"value"`
+	Class     string         `json:"class"`
+	Script    string         `json:"script,omitempty"`
+	Hex       string         `json:"hex,omitempty"`
+	Addresses []string       `json:"addresses,omitempty"`
+	Thread    string         `json:"thread,omitempty"`
+	AdminOp   *adminOpResult `json:"adminop,omitempty"`
+}
+
+// decodeTxResult is the result of the decodetx command.
+type decodeTxResult struct {
+	TxID     string           `json:"txid"`
+	Version  int32            `json:"version"`
+	LockTime uint32           `json:"locktime"`
+	Size     int              `json:"size"`
+	Vin      []decodeTxInput  `json:"vin"`
+	Vout     []decodeTxOutput `json:"vout"`
+}
+
+// parsePrevOut parses an output spent by an input of the form
+// <input>:<pkscript hex>:<amount>.
+func parsePrevOut(s string) (int, *prevOut, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, nil, fmt.Errorf("prevout %q is not of the form "+
+			"<input>:<pkscript hex>:<amount>", s)
+	}
+	idx, err := strconv.Atoi(parts[0])
+	if err != nil || idx < 0 {
+		return 0, nil, fmt.Errorf("prevout %q has an invalid input", s)
+	}
+	pkScript, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return 0, nil, fmt.Errorf("prevout %q has an invalid pkscript",
+			s)
+	}
+	amount, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, nil, fmt.Errorf("prevout %q has an invalid amount", s)
+	}
+	value, err := provautil.NewAmount(amount)
+	if err != nil || value < 0 {
+		return 0, nil, fmt.Errorf("prevout %q has an invalid amount", s)
+	}
+	return idx, &prevOut{pkScript: pkScript, amount: int64(value)}, nil
+}
+
+// threadOfScript returns the admin thread the passed pkScript pays to, and
+// false when it does not pay to one.
+func threadOfScript(pkScript []byte) (provautil.ThreadID, bool) {
+	for threadID := range threadNames {
+		script, err := txscript.ProvaThreadScript(threadID)
+		if err == nil && bytes.Equal(script, pkScript) {
+			return threadID, true
+		}
+	}
+	return 0, false
+}
+
+// signers returns the keys which must sign the spending of the passed pkScript.
+func signers(pkScript []byte) *signersResult {
+	class, addrs, required, err := txscript.ExtractPkScriptAddrs(pkScript,
+		activeNetParams)
+	if err != nil {
+		return nil
+	}
+	if class == txscript.ProvaAdminTy {
+		threadID, ok := threadOfScript(pkScript)
+		if !ok {
+			return nil
+		}
+		return &signersResult{
+			Required: required,
+			KeySet:   btcec.KeySetType(threadID).String(),
+		}
+	}
+	if len(addrs) == 0 {
+		return nil
+	}
+	result := &signersResult{Required: required}
+	for _, addr := range addrs {
+		result.Addresses = append(result.Addresses, addr.EncodeAddress())
+		provaAddr, ok := addr.(*provautil.AddressProva)
+		if !ok {
+			continue
+		}
+		result.PubKeyHash = append(result.PubKeyHash,
+			hex.EncodeToString(provaAddr.ScriptAddress()))
+		for _, keyID := range provaAddr.ScriptKeyIDs() {
+			result.KeyIDs = append(result.KeyIDs, uint32(keyID))
+		}
+	}
+	return result
+}
+
+// adminOp returns the admin op carried by the passed pkScript, or nil when it
+// does not carry one.
+func adminOp(pkScript []byte) *adminOpResult {
+	pops, err := txscript.ParseScript(pkScript)
+	if err != nil {
+		return nil
+	}
+	payload, err := txscript.ExtractAdminPayload(pops)
+	if err != nil {
+		return nil
+	}
+	schema := payload.Schema()
+	result := &adminOpResult{Op: schema.Name}
+	if schema.KeySet != btcec.RootKeySet {
+		result.KeySet = schema.KeySet.String()
+	}
+	if disasm, err := txscript.Disassemble(pkScript); err == nil {
+		result.Args = strings.TrimPrefix(disasm, "OP_RETURN ")
+	}
+	return result
+}
+
+// decodeTx decodes the passed transaction, whose inputs spend the passed
+// outputs where known.
+func decodeTx(tx *wire.MsgTx, prevOuts map[int]*prevOut,
+	verbose bool) (*decodeTxResult, error) {
+
+	result := &decodeTxResult{
+		TxID:     tx.TxHash().String(),
+		Version:  tx.Version,
+		LockTime: tx.LockTime,
+		Size:     tx.SerializeSize(),
+	}
+	for i, txIn := range tx.TxIn {
+		input := decodeTxInput{
+			OutPoint: txIn.PreviousOutPoint.String(),
+			Sequence: txIn.Sequence,
+		}
+		if verbose {
+			input.ScriptSig, _ = txscript.Disassemble(
+				txIn.SignatureScript)
+		}
+		prev, ok := prevOuts[i]
+		if !ok {
+			result.Vin = append(result.Vin, input)
+			continue
+		}
+		amount := provautil.Amount(prev.amount).ToDMG()
+		input.PrevAmount = &amount
+		input.PrevClass = txscript.GetScriptClass(prev.pkScript).String()
+		if threadID, ok := threadOfScript(prev.pkScript); ok {
+			input.Thread = threadNames[threadID]
+		}
+		input.Signers = signers(prev.pkScript)
+		if verbose {
+			input.PrevScript, _ = txscript.Disassemble(prev.pkScript)
+			preimage, err := txscript.CalcSignatureHashPreimage(tx,
+				i, prev.amount, prev.pkScript, txscript.SigHashAll)
+			if err != nil {
+				return nil, err
+			}
+			input.SigHash = hex.EncodeToString(
+				chainhash.DoubleHashB(preimage))
+			input.SigHashType = "ALL"
+			input.Preimage = hex.EncodeToString(preimage)
+		}
+		result.Vin = append(result.Vin, input)
+	}
+	for i, txOut := range tx.TxOut {
+		output := decodeTxOutput{
+			N:     i,
+			Value: provautil.Amount(txOut.Value).ToDMG(),
+			Class: txscript.GetScriptClass(txOut.PkScript).String(),
+		}
+		if verbose {
+			output.Script, _ = txscript.Disassemble(txOut.PkScript)
+			output.Hex = hex.EncodeToString(txOut.PkScript)
+		}
+		_, addrs, _, _ := txscript.ExtractPkScriptAddrs(txOut.PkScript,
+			activeNetParams)
+		for _, addr := range addrs {
+			output.Addresses = append(output.Addresses,
+				addr.EncodeAddress())
+		}
+		if threadID, ok := threadOfScript(txOut.PkScript); ok {
+			output.Thread = threadNames[threadID]
+		}
+		output.AdminOp = adminOp(txOut.PkScript)
+		result.Vout = append(result.Vout, output)
+	}
+	return result, nil
+}
+
+// formatDecodedTx returns the textual form of the passed decoded transaction.
+func formatDecodedTx(result *decodeTxResult) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Transaction: %s\nVersion: %d\nLock time: %d\n"+
+		"Size: %d\n", result.TxID, result.Version, result.LockTime,
+		result.Size)
+	for i, input := range result.Vin {
+		fmt.Fprintf(&buf, "Input %d: %s\n", i, input.OutPoint)
+		if input.ScriptSig != "" {
+			fmt.Fprintf(&buf, "  Signature script: %s\n",
+				input.ScriptSig)
+		}
+		if input.PrevAmount == nil {
+			fmt.Fprintf(&buf, "  Spent output unknown\n")
+			continue
+		}
+		fmt.Fprintf(&buf, "  Spends: %v DMG, %s", *input.PrevAmount,
+			input.PrevClass)
+		if input.Thread != "" {
+			fmt.Fprintf(&buf, " (%s thread)", input.Thread)
+		}
+		fmt.Fprintln(&buf)
+		if input.PrevScript != "" {
+			fmt.Fprintf(&buf, "  Spent script: %s\n", input.PrevScript)
+		}
+		if signers := input.Signers; signers != nil {
+			fmt.Fprintf(&buf, "  Required signatures: %d of", signers.Required)
+			if signers.KeySet != "" {
+				fmt.Fprintf(&buf, " the %s keys", signers.KeySet)
+			}
+			for _, pubKeyHash := range signers.PubKeyHash {
+				fmt.Fprintf(&buf, " account %s", pubKeyHash)
+			}
+			for _, keyID := range signers.KeyIDs {
+				fmt.Fprintf(&buf, " keyID %d", keyID)
+			}
+			fmt.Fprintln(&buf)
+		}
+		if input.SigHash != "" {
+			fmt.Fprintf(&buf, "  Signature hash (%s): %s\n",
+				input.SigHashType, input.SigHash)
+			fmt.Fprintf(&buf, "  Signature hash preimage: %s\n",
+				input.Preimage)
+		}
+	}
+	for _, output := range result.Vout {
+		fmt.Fprintf(&buf, "Output %d: %v DMG, %s", output.N, output.Value,
+			output.Class)
+		if output.Thread != "" {
+			fmt.Fprintf(&buf, " (%s thread)", output.Thread)
+		}
+		fmt.Fprintln(&buf)
+		for _, addr := range output.Addresses {
+			fmt.Fprintf(&buf, "  Address: %s\n", addr)
+		}
+		if output.AdminOp != nil {
+			fmt.Fprintf(&buf, "  Admin op: %s %s\n", output.AdminOp.Op,
+				output.AdminOp.Args)
+		}
+		if output.Script != "" {
+			fmt.Fprintf(&buf, "  Script: %s\n", output.Script)
+		}
+	}
+	return buf.String()
+}
+
+// Execute is the main entry point for the command.  It's invoked by the parser.
+// The transaction is decoded without contacting the network, so the outputs
+// spent by its inputs must be passed to learn their signers and signature
+// hashes.  The thread input of an admin transaction is recognized from its
+// thread output.
+func (cmd *decodeTxCmd) Execute(args []string) error {
+	if err := setupGlobalConfig(); err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return errors.New("required transaction parameter not specified")
+	}
+	hexTx := args[0]
+	if hexTx == "-" {
+		contents, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		hexTx = string(contents)
+	}
+	serializedTx, err := hex.DecodeString(strings.TrimSpace(hexTx))
+	if err != nil {
+		return err
+	}
+	var tx wire.MsgTx
+	if err := tx.Deserialize(bytes.NewReader(serializedTx)); err != nil {
+		return err
+	}
+
+	prevOuts := make(map[int]*prevOut)
+	if len(tx.TxIn) != 0 && len(tx.TxOut) != 0 {
+		if _, ok := threadOfScript(tx.TxOut[0].PkScript); ok {
+			prevOuts[0] = &prevOut{pkScript: tx.TxOut[0].PkScript}
+		}
+	}
+	for _, s := range cmd.PrevOuts {
+		idx, prev, err := parsePrevOut(s)
+		if err != nil {
+			return err
+		}
+		if idx >= len(tx.TxIn) {
+			return fmt.Errorf("prevout %q is for input %d of a "+
+				"transaction with %d inputs", s, idx, len(tx.TxIn))
+		}
+		prevOuts[idx] = prev
+	}
+
+	result, err := decodeTx(&tx, prevOuts, cmd.Verbose)
+	if err != nil {
+		return err
+	}
+	return printResult(result, formatDecodedTx(result))
+}
+
+// Usage overrides the usage display for the command.
+func (cmd *decodeTxCmd) Usage() string {
+	return "[--verbose] [--prevout <input>:<pkscript hex>:<amount>...] <hex-tx|->"
+}
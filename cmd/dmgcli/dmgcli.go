@@ -0,0 +1,324 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	flags "github.com/btcsuite/go-flags"
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/rpcclient"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// config defines the global configuration options.
+type config struct {
+	TestNet        bool   `long:"testnet" description:"Use the test network"`
+	RegressionTest bool   `long:"regtest" description:"Use the regression test network"`
+	SimNet         bool   `long:"simnet" description:"Use the simulation test network"`
+	JSON           bool   `long:"json" description:"Print the results as JSON"`
+	Offline        bool   `long:"offline" description:"Never connect to the RPC server; all chain state must be passed as options"`
+	Broadcast      bool   `long:"broadcast" description:"Send created transactions to the network through the RPC server"`
+	RPCServer      string `short:"s" long:"rpcserver" description:"RPC server to look up chain state and broadcast through"`
+	RPCUser        string `short:"u" long:"rpcuser" description:"RPC username"`
+	RPCPass        string `short:"P" long:"rpcpass" default-mask:"-" description:"RPC password"`
+	RPCCert        string `short:"c" long:"rpccert" description:"RPC server certificate chain for validation"`
+	NoTLS          bool   `long:"notls" description:"Disable TLS"`
+}
+
+var (
+	// cfg holds the global configuration options.
+	cfg = &config{}
+
+	// activeNetParams are the parameters of the network selected by the
+	// global configuration options.
+	activeNetParams = &chaincfg.MainNetParams
+)
+
+// setupGlobalConfig ensures at most one network is selected, sets the active
+// network parameters accordingly and checks the RPC options.
+func setupGlobalConfig() error {
+	numNets := 0
+	if cfg.TestNet {
+		numNets++
+		activeNetParams = &chaincfg.TestNetParams
+	}
+	if cfg.RegressionTest {
+		numNets++
+		activeNetParams = &chaincfg.RegressionNetParams
+	}
+	if cfg.SimNet {
+		numNets++
+		activeNetParams = &chaincfg.SimNetParams
+	}
+	if numNets > 1 {
+		return fmt.Errorf("the testnet, regtest, and simnet params " +
+			"can't be used together -- choose one of the three")
+	}
+	if cfg.Offline && cfg.Broadcast {
+		return errors.New("the offline and broadcast options can't be " +
+			"used together")
+	}
+	return nil
+}
+
+// newClient returns a client of the configured RPC server.  It fails in
+// offline mode, so no command contacts the network unless allowed to.
+func newClient() (*rpcclient.Client, error) {
+	if cfg.Offline {
+		return nil, errors.New("the RPC server can't be used in " +
+			"offline mode")
+	}
+	if cfg.RPCServer == "" {
+		return nil, errors.New("no RPC server specified")
+	}
+	connCfg := &rpcclient.ConnConfig{
+		Host:       cfg.RPCServer,
+		User:       cfg.RPCUser,
+		Pass:       cfg.RPCPass,
+		DisableTLS: cfg.NoTLS,
+	}
+	if !cfg.NoTLS && cfg.RPCCert != "" {
+		var err error
+		connCfg.Certificates, err = ioutil.ReadFile(cfg.RPCCert)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rpcclient.New(connCfg)
+}
+
+// readPrivKey reads a hex-encoded private key from the passed file.  Keys are
+// passed in files rather than options, so they do not end up in the shell
+// history or the process list.
+func readPrivKey(path string) (*btcec.PrivateKey, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	privKeyBytes, err := hex.DecodeString(strings.TrimSpace(
+		string(contents)))
+	if err != nil {
+		return nil, fmt.Errorf("%s does not hold a hex-encoded private "+
+			"key", path)
+	}
+	if len(privKeyBytes) != btcec.PrivKeyBytesLen {
+		return nil, fmt.Errorf("private key in %s is %d bytes, want %d",
+			path, len(privKeyBytes), btcec.PrivKeyBytesLen)
+	}
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), privKeyBytes)
+	return privKey, nil
+}
+
+// keyClosure reads the private keys of the passed files and returns a key
+// database which signs with all of them.
+func keyClosure(paths []string) (txscript.KeyClosure, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no key files specified")
+	}
+	keys := make([]txscript.PrivateKey, 0, len(paths))
+	for _, path := range paths {
+		privKey, err := readPrivKey(path)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, txscript.PrivateKey{Key: privKey,
+			Compressed: true})
+	}
+	return func(provautil.Address) ([]txscript.PrivateKey, error) {
+		return keys, nil
+	}, nil
+}
+
+// parseOutPoint parses an outpoint of the form <txid>:<index>.
+func parseOutPoint(s string) (*wire.OutPoint, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("outpoint %q is not of the form "+
+			"<txid>:<index>", s)
+	}
+	hash, err := chainhash.NewHashFromStr(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("outpoint %q has an invalid txid", s)
+	}
+	index, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("outpoint %q has an invalid index", s)
+	}
+	return wire.NewOutPoint(hash, uint32(index)), nil
+}
+
+// threadTip returns the passed outpoint of the tip of an admin thread, or looks
+// up the current tip of the thread on the RPC server when it is empty.
+func threadTip(threadID provautil.ThreadID, tip string) (*wire.OutPoint, error) {
+	if tip != "" {
+		return parseOutPoint(tip)
+	}
+	if cfg.Offline {
+		return nil, errors.New("the thread tip must be specified in " +
+			"offline mode")
+	}
+	client, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	info, err := client.GetAdminInfo()
+	if err != nil {
+		return nil, err
+	}
+	for _, threadTip := range info.ThreadTips {
+		if threadTip.ID == uint32(threadID) {
+			return parseOutPoint(threadTip.OutPoint)
+		}
+	}
+	return nil, fmt.Errorf("RPC server returned no tip of thread %d",
+		threadID)
+}
+
+// signThreadInput signs the first input of the passed transaction, which
+// spends the tip of the passed admin thread, with the passed keys.
+func signThreadInput(tx *wire.MsgTx, threadID provautil.ThreadID,
+	keys txscript.KeyClosure) error {
+
+	pkScript, err := txscript.ProvaThreadScript(threadID)
+	if err != nil {
+		return err
+	}
+	sigScript, err := txscript.SignTxOutput(activeNetParams, tx, 0, 0,
+		pkScript, txscript.SigHashAll, keys, nil)
+	if err != nil {
+		return err
+	}
+	tx.TxIn[0].SignatureScript = sigScript
+	return nil
+}
+
+// printResult prints the passed result as JSON when requested, or otherwise
+// as the passed text.
+func printResult(result interface{}, text string) error {
+	if !cfg.JSON {
+		fmt.Print(text)
+		return nil
+	}
+	out, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// txResult is the result of the commands creating a transaction.
+type txResult struct {
+	TxID      string `json:"txid"`
+	Hex       string `json:"hex"`
+	Broadcast bool   `json:"broadcast"`
+}
+
+// finishTx prints the passed signed transaction, after sending it to the
+// network when requested.
+func finishTx(tx *wire.MsgTx) error {
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return err
+	}
+	result := &txResult{
+		TxID: tx.TxHash().String(),
+		Hex:  hex.EncodeToString(buf.Bytes()),
+	}
+	if cfg.Broadcast {
+		client, err := newClient()
+		if err != nil {
+			return err
+		}
+		var txID string
+		err = client.Call(&txID, "sendrawtransaction", result.Hex)
+		if err != nil {
+			return err
+		}
+		result.Broadcast = true
+	}
+
+	text := fmt.Sprintf("Transaction: %s\nHex: %s\n", result.TxID,
+		result.Hex)
+	if result.Broadcast {
+		text += "Broadcast: yes\n"
+	}
+	return printResult(result, text)
+}
+
+func main() {
+	appName := filepath.Base(os.Args[0])
+	appName = strings.TrimSuffix(appName, filepath.Ext(appName))
+	parserFlags := flags.Options(flags.HelpFlag | flags.PassDoubleDash)
+	parser := flags.NewNamedParser(appName, parserFlags)
+	parser.AddGroup("Global Options", "", cfg)
+	parser.AddCommand("genaddress",
+		"Generate a new key and its address",
+		"Generate a new private key and print it with its public key "+
+			"and the address of the key with the passed ASP "+
+			"keyIDs.", &genAddressCfg)
+	parser.AddCommand("issue",
+		"Create an issue thread transaction issuing DMG",
+		"Create a transaction spending the issue thread tip and "+
+			"paying the passed amount of new DMG to the passed "+
+			"address, signed by the issue keys.", &issueCfg)
+	parser.AddCommand("destroy",
+		"Create an issue thread transaction destroying DMG",
+		"Create a transaction spending the issue thread tip and the "+
+			"passed output of the passed address, destroying its "+
+			"amount, signed by the issue keys and the keys of the "+
+			"address.", &destroyCfg)
+	parser.AddCommand("addkey",
+		"Create an admin transaction adding a key",
+		"Create a transaction spending the tip of the thread of the "+
+			"key set and adding the passed public key to it, "+
+			"signed by the keys of the thread.", &addKeyCfg)
+	parser.AddCommand("revokekey",
+		"Create an admin transaction revoking a key",
+		"Create a transaction spending the tip of the thread of the "+
+			"key set and revoking the passed public key from it, "+
+			"signed by the keys of the thread.", &revokeKeyCfg)
+	parser.AddCommand("decodetx",
+		"Decode a transaction without contacting the network",
+		"Decode the passed hex-encoded transaction, its script "+
+			"classes, admin ops and the keys required to sign "+
+			"its inputs, and with --verbose the hashes the keys "+
+			"sign, to verify a transaction before signing it.",
+		&decodeTxCfg)
+	parser.AddCommand("attestreserves",
+		"Sign a proof-of-reserves attestation",
+		"Sign the passed unsigned attestation returned by "+
+			"getreserveproof with the attestation key.",
+		&attestReservesCfg)
+	parser.AddCommand("signannouncement",
+		"Create or sign a governance announcement",
+		"Add a signature of the passed root or provision key to the "+
+			"passed announcement signed by another key holder, "+
+			"or create a new announcement from the passed "+
+			"category, hours and message.", &signAnnouncementCfg)
+
+	if _, err := parser.Parse(); err != nil {
+		if e, ok := err.(*flags.Error); ok && e.Type == flags.ErrHelp {
+			parser.WriteHelp(os.Stderr)
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// testPrivKey returns the private key whose scalar is the passed byte.
+func testPrivKey(b byte) *btcec.PrivateKey {
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), []byte{b})
+	return privKey
+}
+
+// writeKeyFile writes the passed contents to a key file of the passed name in
+// the passed directory and returns its path.
+func writeKeyFile(t *testing.T, dir, name, contents string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+// TestParseOutPoint ensures outpoints are parsed from the <txid>:<index> form
+// and malformed ones are rejected.
+func TestParseOutPoint(t *testing.T) {
+	txID := "a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90"
+	hash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		t.Fatalf("NewHashFromStr: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		s       string
+		want    *wire.OutPoint
+		isValid bool
+	}{
+		{
+			name:    "first output",
+			s:       txID + ":0",
+			want:    wire.NewOutPoint(hash, 0),
+			isValid: true,
+		},
+		{
+			name:    "largest index",
+			s:       txID + ":4294967295",
+			want:    wire.NewOutPoint(hash, 4294967295),
+			isValid: true,
+		},
+		{
+			name: "no index",
+			s:    txID,
+		},
+		{
+			name: "two indexes",
+			s:    txID + ":0:1",
+		},
+		{
+			name: "invalid txid",
+			s:    "xyz:0",
+		},
+		{
+			name: "txid too long",
+			s:    txID + "00:0",
+		},
+		{
+			name: "empty index",
+			s:    txID + ":",
+		},
+		{
+			name: "negative index",
+			s:    txID + ":-1",
+		},
+		{
+			name: "index overflow",
+			s:    txID + ":4294967296",
+		},
+	}
+
+	for _, test := range tests {
+		outPoint, err := parseOutPoint(test.s)
+		if !test.isValid {
+			if err == nil {
+				t.Errorf("%s: parsed %v when it should not be",
+					test.name, outPoint)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if *outPoint != *test.want {
+			t.Errorf("%s: got outpoint %v, want %v", test.name,
+				outPoint, test.want)
+		}
+	}
+}
+
+// TestReadPrivKey ensures hex-encoded private keys are read from key files and
+// files not holding one are rejected.
+func TestReadPrivKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dmgcli")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	privKey := testPrivKey(1)
+	keyHex := hex.EncodeToString(privKey.Serialize())
+
+	tests := []struct {
+		name     string
+		contents string
+		isValid  bool
+	}{
+		{
+			name:     "hex key",
+			contents: keyHex,
+			isValid:  true,
+		},
+		{
+			name:     "hex key with surrounding whitespace",
+			contents: "  " + keyHex + "\n",
+			isValid:  true,
+		},
+		{
+			name:     "upper case hex key",
+			contents: strings.ToUpper(keyHex),
+			isValid:  true,
+		},
+		{
+			name:     "empty file",
+			contents: "",
+		},
+		{
+			name:     "not hex",
+			contents: "not a key",
+		},
+		{
+			name:     "short key",
+			contents: keyHex[2:],
+		},
+		{
+			name:     "long key",
+			contents: keyHex + "00",
+		},
+	}
+
+	for i, test := range tests {
+		path := writeKeyFile(t, dir, string(rune('a'+i)), test.contents)
+		got, err := readPrivKey(path)
+		if !test.isValid {
+			if err == nil {
+				t.Errorf("%s: read key when it should not be",
+					test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if got.D.Cmp(privKey.D) != 0 {
+			t.Errorf("%s: got key %x, want %x", test.name,
+				got.Serialize(), privKey.Serialize())
+		}
+	}
+
+	if _, err := readPrivKey(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("read key from a missing file")
+	}
+}
+
+// TestKeyClosure ensures the key database returned by keyClosure signs with the
+// compressed keys of all passed files, in order.
+func TestKeyClosure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dmgcli")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	privKeys := []*btcec.PrivateKey{testPrivKey(1), testPrivKey(2)}
+	var paths []string
+	for i, privKey := range privKeys {
+		paths = append(paths, writeKeyFile(t, dir, string(rune('a'+i)),
+			hex.EncodeToString(privKey.Serialize())))
+	}
+	badPath := writeKeyFile(t, dir, "bad", "not a key")
+
+	tests := []struct {
+		name     string
+		paths    []string
+		wantKeys []*btcec.PrivateKey
+		isValid  bool
+	}{
+		{
+			name:     "one key",
+			paths:    paths[:1],
+			wantKeys: privKeys[:1],
+			isValid:  true,
+		},
+		{
+			name:     "two keys",
+			paths:    paths,
+			wantKeys: privKeys,
+			isValid:  true,
+		},
+		{
+			name:  "no key files",
+			paths: nil,
+		},
+		{
+			name:  "one bad key file",
+			paths: []string{paths[0], badPath},
+		},
+	}
+
+	for _, test := range tests {
+		kdb, err := keyClosure(test.paths)
+		if !test.isValid {
+			if err == nil {
+				t.Errorf("%s: created key database when it "+
+					"should not be", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		keys, err := kdb.GetKey(nil)
+		if err != nil {
+			t.Errorf("%s: GetKey: %v", test.name, err)
+			continue
+		}
+		if len(keys) != len(test.wantKeys) {
+			t.Errorf("%s: got %d keys, want %d", test.name,
+				len(keys), len(test.wantKeys))
+			continue
+		}
+		for i, key := range keys {
+			if key.Key.D.Cmp(test.wantKeys[i].D) != 0 ||
+				!key.Compressed {
+
+				t.Errorf("%s: key %d is %x (compressed %v), "+
+					"want compressed %x", test.name, i,
+					key.Key.Serialize(), key.Compressed,
+					test.wantKeys[i].Serialize())
+			}
+		}
+	}
+}
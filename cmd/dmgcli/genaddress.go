@@ -0,0 +1,63 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/provautil"
+)
+
+// genAddressCmd defines the configuration options for the genaddress command.
+type genAddressCmd struct {
+	KeyIDs []uint32 `long:"keyid" description:"KeyID of an ASP key of the address; pass twice"`
+}
+
+var (
+	// genAddressCfg defines the configuration options for the command.
+	genAddressCfg = genAddressCmd{}
+)
+
+// genAddressResult is the result of the genaddress command.
+type genAddressResult struct {
+	Address string `json:"address"`
+	PrivKey string `json:"privkey"`
+	PubKey  string `json:"pubkey"`
+}
+
+// Execute is the main entry point for the command.  It's invoked by the parser.
+func (cmd *genAddressCmd) Execute(args []string) error {
+	if err := setupGlobalConfig(); err != nil {
+		return err
+	}
+	if len(cmd.KeyIDs) != 2 {
+		return errors.New("two ASP keyIDs must be specified")
+	}
+
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		return err
+	}
+	defer privKey.Zero()
+	pubKey := privKey.PubKey()
+	keyIDs := []btcec.KeyID{btcec.KeyID(cmd.KeyIDs[0]),
+		btcec.KeyID(cmd.KeyIDs[1])}
+	addr, err := provautil.NewAddressProva(provautil.Hash160(
+		pubKey.SerializeCompressed()), keyIDs, activeNetParams)
+	if err != nil {
+		return err
+	}
+
+	result := &genAddressResult{
+		Address: addr.EncodeAddress(),
+		PrivKey: fmt.Sprintf("%x", privKey.Serialize()),
+		PubKey:  fmt.Sprintf("%x", pubKey.SerializeCompressed()),
+	}
+	return printResult(result, fmt.Sprintf("Address: %s\nPrivate key: "+
+		"%s\nPublic key: %s\n", result.Address, result.PrivKey,
+		result.PubKey))
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// issueCmd defines the configuration options for the issue command.
+type issueCmd struct {
+	Address   string   `long:"address" description:"Address to pay the issued DMG to" required:"true"`
+	Amount    float64  `long:"amount" description:"Amount of DMG to issue" required:"true"`
+	ThreadTip string   `long:"threadtip" description:"Outpoint <txid>:<index> of the issue thread tip; looked up on the RPC server when omitted"`
+	KeyFiles  []string `long:"keyfile" description:"File holding a hex-encoded issue key; pass once per key"`
+}
+
+// destroyCmd defines the configuration options for the destroy command.
+type destroyCmd struct {
+	Address      string   `long:"address" description:"Address holding the DMG to destroy" required:"true"`
+	Amount       float64  `long:"amount" description:"Amount of DMG held by the output" required:"true"`
+	OutPoint     string   `long:"outpoint" description:"Outpoint <txid>:<index> of the output to destroy" required:"true"`
+	ThreadTip    string   `long:"threadtip" description:"Outpoint <txid>:<index> of the issue thread tip; looked up on the RPC server when omitted"`
+	KeyFiles     []string `long:"keyfile" description:"File holding a hex-encoded issue key; pass once per key"`
+	SpendKeyFile []string `long:"spendkeyfile" description:"File holding a hex-encoded key of the address; pass once per key"`
+}
+
+var (
+	// issueCfg defines the configuration options for the issue command.
+	issueCfg = issueCmd{}
+
+	// destroyCfg defines the configuration options for the destroy
+	// command.
+	destroyCfg = destroyCmd{}
+)
+
+// parseIssueArgs decodes the address and amount shared by the issue and
+// destroy commands.
+func parseIssueArgs(address string, amount float64) (provautil.Address,
+	provautil.Amount, error) {
+
+	addr, err := provautil.DecodeAddress(address, activeNetParams)
+	if err != nil {
+		return nil, 0, err
+	}
+	value, err := provautil.NewAmount(amount)
+	if err != nil {
+		return nil, 0, err
+	}
+	if value <= 0 {
+		return nil, 0, errors.New("the amount must be positive")
+	}
+	return addr, value, nil
+}
+
+// newIssueTx returns a transaction spending the passed issue thread tip to a
+// new issue thread output.
+func newIssueTx(tip *wire.OutPoint) (*wire.MsgTx, error) {
+	threadScript, err := txscript.ProvaThreadScript(provautil.IssueThread)
+	if err != nil {
+		return nil, err
+	}
+	tx := wire.NewMsgTx(1)
+	tx.AddTxIn(wire.NewTxIn(tip, nil))
+	tx.AddTxOut(wire.NewTxOut(0, threadScript))
+	return tx, nil
+}
+
+// createIssueTx returns a transaction spending the passed issue thread tip and
+// paying the passed amount of new DMG to the passed address, signed with the
+// passed issue keys.
+func createIssueTx(tip *wire.OutPoint, addr provautil.Address,
+	amount provautil.Amount, keys txscript.KeyClosure) (*wire.MsgTx, error) {
+
+	tx, err := newIssueTx(tip)
+	if err != nil {
+		return nil, err
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return nil, err
+	}
+	tx.AddTxOut(wire.NewTxOut(int64(amount), pkScript))
+	if err := signThreadInput(tx, provautil.IssueThread, keys); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// createDestroyTx returns a transaction spending the passed issue thread tip
+// and the passed output of the passed address, destroying the passed amount
+// held by the output.  The thread input is signed with the passed issue keys,
+// and the destroyed output with the passed keys of the address.
+func createDestroyTx(tip, outPoint *wire.OutPoint, addr provautil.Address,
+	amount provautil.Amount, keys,
+	spendKeys txscript.KeyClosure) (*wire.MsgTx, error) {
+
+	tx, err := newIssueTx(tip)
+	if err != nil {
+		return nil, err
+	}
+	tx.AddTxIn(wire.NewTxIn(outPoint, nil))
+	tx.AddTxOut(wire.NewTxOut(int64(amount), []byte{txscript.OP_RETURN}))
+	if err := signThreadInput(tx, provautil.IssueThread, keys); err != nil {
+		return nil, err
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return nil, err
+	}
+	sigScript, err := txscript.SignTxOutput(activeNetParams, tx, 1,
+		int64(amount), pkScript, txscript.SigHashAll, spendKeys, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign the output to destroy: "+
+			"%v", err)
+	}
+	tx.TxIn[1].SignatureScript = sigScript
+	return tx, nil
+}
+
+// Execute is the main entry point for the command.  It's invoked by the parser.
+func (cmd *issueCmd) Execute(args []string) error {
+	if err := setupGlobalConfig(); err != nil {
+		return err
+	}
+	addr, amount, err := parseIssueArgs(cmd.Address, cmd.Amount)
+	if err != nil {
+		return err
+	}
+	keys, err := keyClosure(cmd.KeyFiles)
+	if err != nil {
+		return err
+	}
+	tip, err := threadTip(provautil.IssueThread, cmd.ThreadTip)
+	if err != nil {
+		return err
+	}
+
+	tx, err := createIssueTx(tip, addr, amount, keys)
+	if err != nil {
+		return err
+	}
+	return finishTx(tx)
+}
+
+// Execute is the main entry point for the command.  It's invoked by the parser.
+// The whole output is destroyed, so its amount must be the amount held by it.
+func (cmd *destroyCmd) Execute(args []string) error {
+	if err := setupGlobalConfig(); err != nil {
+		return err
+	}
+	addr, amount, err := parseIssueArgs(cmd.Address, cmd.Amount)
+	if err != nil {
+		return err
+	}
+	outPoint, err := parseOutPoint(cmd.OutPoint)
+	if err != nil {
+		return err
+	}
+	keys, err := keyClosure(cmd.KeyFiles)
+	if err != nil {
+		return err
+	}
+	spendKeys, err := keyClosure(cmd.SpendKeyFile)
+	if err != nil {
+		return err
+	}
+	tip, err := threadTip(provautil.IssueThread, cmd.ThreadTip)
+	if err != nil {
+		return err
+	}
+
+	tx, err := createDestroyTx(tip, outPoint, addr, amount, keys,
+		spendKeys)
+	if err != nil {
+		return err
+	}
+	return finishTx(tx)
+}
@@ -0,0 +1,335 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// testKeys returns a key database signing with the passed private keys.
+func testKeys(privKeys ...*btcec.PrivateKey) txscript.KeyClosure {
+	keys := make([]txscript.PrivateKey, 0, len(privKeys))
+	for _, privKey := range privKeys {
+		keys = append(keys, txscript.PrivateKey{Key: privKey,
+			Compressed: true})
+	}
+	return func(provautil.Address) ([]txscript.PrivateKey, error) {
+		return keys, nil
+	}
+}
+
+// testAddress returns the Prova address of the passed key with the passed ASP
+// keyIDs on the active network.
+func testAddress(t *testing.T, privKey *btcec.PrivateKey,
+	keyIDs ...btcec.KeyID) provautil.Address {
+
+	addr, err := provautil.NewAddressProva(provautil.Hash160(
+		privKey.PubKey().SerializeCompressed()), keyIDs,
+		activeNetParams)
+	if err != nil {
+		t.Fatalf("NewAddressProva: %v", err)
+	}
+	return addr
+}
+
+// verifyInput executes the signature script of the input at the passed index
+// of the passed transaction against the passed public key script, in which the
+// chain has replaced the thread ID or keyIDs with the hashes of the keys.
+func verifyInput(tx *wire.MsgTx, idx int, pkScript []byte, amount int64,
+	aspKeys btcec.KeyIdMap) error {
+
+	vm, err := txscript.NewEngine(pkScript, tx, idx,
+		txscript.StandardVerifyFlags|txscript.ScriptVerifyCheckKeyIDVerify,
+		nil, nil, amount)
+	if err != nil {
+		return err
+	}
+	vm.SetKeyIDLookup(func(keyID btcec.KeyID) *btcec.PublicKey {
+		return aspKeys[keyID]
+	})
+	return vm.Execute()
+}
+
+// issueThreadPkScript returns the public key script of the issue thread as
+// replaced by the chain for the passed issue keys.
+func issueThreadPkScript(t *testing.T, issueKeys []*btcec.PrivateKey) []byte {
+	var keyHashes [][]byte
+	for _, privKey := range issueKeys {
+		keyHashes = append(keyHashes, provautil.Hash160(
+			privKey.PubKey().SerializeCompressed()))
+	}
+	pkScript, err := txscript.ThreadPkScript(keyHashes,
+		activeNetParams.AdminThreshold(btcec.IssueKeySet))
+	if err != nil {
+		t.Fatalf("ThreadPkScript: %v", err)
+	}
+	return pkScript
+}
+
+// provaPkScript returns the public key script of the passed address as
+// replaced by the chain for the passed ASP keys.
+func provaPkScript(t *testing.T, addr provautil.Address,
+	aspKeys btcec.KeyIdMap) []byte {
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("PayToAddrScript: %v", err)
+	}
+	pops, err := txscript.ParseScript(pkScript)
+	if err != nil {
+		t.Fatalf("ParseScript: %v", err)
+	}
+	keyHashes := make(map[btcec.KeyID][]byte, len(aspKeys))
+	for keyID, pubKey := range aspKeys {
+		keyHashes[keyID] = provautil.Hash160(
+			pubKey.SerializeCompressed())
+	}
+	if err := txscript.ReplaceKeyIDs(pops, keyHashes); err != nil {
+		t.Fatalf("ReplaceKeyIDs: %v", err)
+	}
+	pkScript, err = txscript.UnparseScript(pops)
+	if err != nil {
+		t.Fatalf("UnparseScript: %v", err)
+	}
+	return pkScript
+}
+
+// TestParseIssueArgs ensures the address and amount of the issue and destroy
+// commands are decoded on the active network, and invalid ones are rejected.
+func TestParseIssueArgs(t *testing.T) {
+	defer func(params *chaincfg.Params) {
+		activeNetParams = params
+	}(activeNetParams)
+	activeNetParams = &chaincfg.RegressionNetParams
+
+	addr := testAddress(t, testPrivKey(1), 1, 2)
+	tests := []struct {
+		name      string
+		address   string
+		amount    float64
+		wantAtoms provautil.Amount
+		isValid   bool
+	}{
+		{
+			name:      "whole DMG",
+			address:   addr.EncodeAddress(),
+			amount:    2,
+			wantAtoms: 2 * provautil.AtomsPerGram,
+			isValid:   true,
+		},
+		{
+			name:      "fractional DMG",
+			address:   addr.EncodeAddress(),
+			amount:    0.5,
+			wantAtoms: provautil.AtomsPerGram / 2,
+			isValid:   true,
+		},
+		{
+			name:    "invalid address",
+			address: "not an address",
+			amount:  1,
+		},
+		{
+			name:    "empty address",
+			address: "",
+			amount:  1,
+		},
+		{
+			name:    "zero amount",
+			address: addr.EncodeAddress(),
+			amount:  0,
+		},
+		{
+			name:    "negative amount",
+			address: addr.EncodeAddress(),
+			amount:  -1,
+		},
+		{
+			name:    "amount not a number",
+			address: addr.EncodeAddress(),
+			amount:  math.NaN(),
+		},
+		{
+			name:    "infinite amount",
+			address: addr.EncodeAddress(),
+			amount:  math.Inf(1),
+		},
+	}
+
+	for _, test := range tests {
+		gotAddr, gotAmount, err := parseIssueArgs(test.address,
+			test.amount)
+		if !test.isValid {
+			if err == nil {
+				t.Errorf("%s: parsed when it should not be",
+					test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if gotAddr.EncodeAddress() != test.address {
+			t.Errorf("%s: got address %v, want %v", test.name,
+				gotAddr, test.address)
+		}
+		if gotAmount != test.wantAtoms {
+			t.Errorf("%s: got amount %d, want %d", test.name,
+				gotAmount, test.wantAtoms)
+		}
+	}
+}
+
+// TestCreateIssueTx ensures issue and destroy transactions are created offline
+// with the expected inputs and outputs, and with signatures satisfying the
+// scripts the chain checks them against.
+func TestCreateIssueTx(t *testing.T) {
+	defer func(params *chaincfg.Params) {
+		activeNetParams = params
+	}(activeNetParams)
+	activeNetParams = &chaincfg.RegressionNetParams
+
+	issueKeys := []*btcec.PrivateKey{testPrivKey(1), testPrivKey(2)}
+	spendKey := testPrivKey(3)
+	aspKey := testPrivKey(4)
+	aspKeys := btcec.KeyIdMap{1: aspKey.PubKey(),
+		2: testPrivKey(5).PubKey()}
+	addr := testAddress(t, spendKey, 1, 2)
+	payScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("PayToAddrScript: %v", err)
+	}
+	threadScript, err := txscript.ProvaThreadScript(provautil.IssueThread)
+	if err != nil {
+		t.Fatalf("ProvaThreadScript: %v", err)
+	}
+	tip := wire.NewOutPoint(&chainhash.Hash{1}, 0)
+	outPoint := wire.NewOutPoint(&chainhash.Hash{2}, 3)
+	amount := provautil.Amount(5 * provautil.AtomsPerGram)
+
+	tests := []struct {
+		name      string
+		destroy   bool
+		keys      txscript.KeyClosure
+		spendKeys txscript.KeyClosure
+		isValid   bool
+	}{
+		{
+			name:    "issue",
+			keys:    testKeys(issueKeys...),
+			isValid: true,
+		},
+		{
+			name: "issue signed by too few issue keys",
+			keys: testKeys(issueKeys[0]),
+		},
+		{
+			name: "issue signed by other keys",
+			keys: testKeys(spendKey, aspKey),
+		},
+		{
+			name:      "destroy",
+			destroy:   true,
+			keys:      testKeys(issueKeys...),
+			spendKeys: testKeys(spendKey, aspKey),
+			isValid:   true,
+		},
+		{
+			name:      "destroy without the ASP key",
+			destroy:   true,
+			keys:      testKeys(issueKeys...),
+			spendKeys: testKeys(spendKey),
+		},
+		{
+			name:      "destroy signed by the issue keys only",
+			destroy:   true,
+			keys:      testKeys(issueKeys...),
+			spendKeys: testKeys(issueKeys...),
+		},
+	}
+
+	for _, test := range tests {
+		var tx *wire.MsgTx
+		var err error
+		wantTxIns := []*wire.OutPoint{tip}
+		wantTxOuts := []*wire.TxOut{wire.NewTxOut(0, threadScript)}
+		if test.destroy {
+			tx, err = createDestroyTx(tip, outPoint, addr, amount,
+				test.keys, test.spendKeys)
+			wantTxIns = append(wantTxIns, outPoint)
+			wantTxOuts = append(wantTxOuts, wire.NewTxOut(
+				int64(amount), []byte{txscript.OP_RETURN}))
+		} else {
+			tx, err = createIssueTx(tip, addr, amount, test.keys)
+			wantTxOuts = append(wantTxOuts, wire.NewTxOut(
+				int64(amount), payScript))
+		}
+		if err != nil {
+			// Creating the transaction may fail outright when
+			// the keys can not sign it.
+			if test.isValid {
+				t.Errorf("%s: unexpected error: %v", test.name,
+					err)
+			}
+			continue
+		}
+
+		// Ensure the transaction spends and pays what is expected.
+		if len(tx.TxIn) != len(wantTxIns) {
+			t.Errorf("%s: got %d inputs, want %d", test.name,
+				len(tx.TxIn), len(wantTxIns))
+			continue
+		}
+		for i, txIn := range tx.TxIn {
+			if txIn.PreviousOutPoint != *wantTxIns[i] {
+				t.Errorf("%s: input %d spends %v, want %v",
+					test.name, i, txIn.PreviousOutPoint,
+					wantTxIns[i])
+			}
+		}
+		if len(tx.TxOut) != len(wantTxOuts) {
+			t.Errorf("%s: got %d outputs, want %d", test.name,
+				len(tx.TxOut), len(wantTxOuts))
+			continue
+		}
+		for i, txOut := range tx.TxOut {
+			if txOut.Value != wantTxOuts[i].Value ||
+				!bytes.Equal(txOut.PkScript,
+					wantTxOuts[i].PkScript) {
+
+				t.Errorf("%s: output %d is %d to %x, want %d "+
+					"to %x", test.name, i, txOut.Value,
+					txOut.PkScript, wantTxOuts[i].Value,
+					wantTxOuts[i].PkScript)
+			}
+		}
+
+		// Ensure the signatures satisfy the scripts of the spent
+		// outputs.
+		err = verifyInput(tx, 0, issueThreadPkScript(t, issueKeys), 0,
+			aspKeys)
+		if err == nil && test.destroy {
+			err = verifyInput(tx, 1, provaPkScript(t, addr, aspKeys),
+				int64(amount), aspKeys)
+		}
+		if err != nil && test.isValid {
+			t.Errorf("%s: invalid signature: %v", test.name, err)
+		}
+		if err == nil && !test.isValid {
+			t.Errorf("%s: valid signatures when they should not "+
+				"be", test.name)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/txscript/adminop"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// keyCmd defines the configuration options for the addkey and revokekey
+// commands.
+type keyCmd struct {
+	KeySet    string   `long:"keyset" description:"Key set to change: issue, provision, validate or asp" required:"true"`
+	PubKey    string   `long:"pubkey" description:"Hex-encoded compressed public key to add or revoke" required:"true"`
+	KeyID     uint32   `long:"keyid" description:"KeyID of the ASP key"`
+	Expiry    uint32   `long:"expiry" description:"Height of the last block an added validate or ASP key is valid in; the key does not expire when omitted"`
+	ThreadTip string   `long:"threadtip" description:"Outpoint <txid>:<index> of the thread tip; looked up on the RPC server when omitted"`
+	KeyFiles  []string `long:"keyfile" description:"File holding a hex-encoded key of the thread; pass once per key"`
+
+	isAdd bool
+}
+
+var (
+	// addKeyCfg defines the configuration options for the addkey command.
+	addKeyCfg = keyCmd{isAdd: true}
+
+	// revokeKeyCfg defines the configuration options for the revokekey
+	// command.
+	revokeKeyCfg = keyCmd{}
+)
+
+// keySets maps the key set names accepted by the keyset option to the key
+// sets.  Root keys are hard-coded and can not be changed.
+var keySets = map[string]btcec.KeySetType{
+	"issue":     btcec.IssueKeySet,
+	"provision": btcec.ProvisionKeySet,
+	"validate":  btcec.ValidateKeySet,
+	"asp":       btcec.ASPKeySet,
+}
+
+// hasField returns whether the passed fields hold the passed field.
+func hasField(fields []adminop.Field, field adminop.Field) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// schema returns the schema of the admin op the command creates.
+func (cmd *keyCmd) schema() (*adminop.Schema, error) {
+	keySet, ok := keySets[cmd.KeySet]
+	if !ok {
+		return nil, fmt.Errorf("unknown key set %q", cmd.KeySet)
+	}
+	if cmd.Expiry != 0 && !cmd.isAdd {
+		return nil, errors.New("the expiry can only be passed when " +
+			"adding a key")
+	}
+	for _, schema := range adminop.Schemas() {
+		if schema.Kind != adminop.KindKey || schema.KeySet != keySet ||
+			schema.IsAdd != cmd.isAdd ||
+			hasField(schema.Fields, adminop.FieldExpiry) !=
+				(cmd.Expiry != 0) {
+
+			continue
+		}
+		return schema, nil
+	}
+	return nil, fmt.Errorf("%s keys can not be added with an expiry",
+		cmd.KeySet)
+}
+
+// Execute is the main entry point for the command.  It's invoked by the parser.
+func (cmd *keyCmd) Execute(args []string) error {
+	if err := setupGlobalConfig(); err != nil {
+		return err
+	}
+	schema, err := cmd.schema()
+	if err != nil {
+		return err
+	}
+	pubKeyBytes, err := hex.DecodeString(cmd.PubKey)
+	if err != nil {
+		return err
+	}
+	pubKey, err := btcec.ParsePubKey(pubKeyBytes, btcec.S256())
+	if err != nil {
+		return err
+	}
+	if hasField(schema.Fields, adminop.FieldKeyID) && cmd.KeyID == 0 {
+		return fmt.Errorf("the keyid must be specified for %s",
+			schema.Name)
+	}
+	opScript, err := txscript.AdminOpScript(&adminop.Payload{
+		Op:     schema.Op,
+		PubKey: pubKey,
+		KeyID:  btcec.KeyID(cmd.KeyID),
+		Expiry: cmd.Expiry,
+	})
+	if err != nil {
+		return err
+	}
+	keys, err := keyClosure(cmd.KeyFiles)
+	if err != nil {
+		return err
+	}
+	threadID := schema.Threads[0]
+	tip, err := threadTip(threadID, cmd.ThreadTip)
+	if err != nil {
+		return err
+	}
+
+	threadScript, err := txscript.ProvaThreadScript(threadID)
+	if err != nil {
+		return err
+	}
+	tx := wire.NewMsgTx(1)
+	tx.AddTxIn(wire.NewTxIn(tip, nil))
+	tx.AddTxOut(wire.NewTxOut(0, threadScript))
+	tx.AddTxOut(wire.NewTxOut(0, opScript))
+	if err := signThreadInput(tx, threadID, keys); err != nil {
+		return err
+	}
+	return finishTx(tx)
+}
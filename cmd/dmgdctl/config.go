@@ -103,6 +103,10 @@ type config struct {
 	RPCPassword   string `short:"P" long:"rpcpass" default-mask:"-" description:"RPC password"`
 	RPCServer     string `short:"s" long:"rpcserver" description:"RPC server to connect to"`
 	RPCCert       string `short:"c" long:"rpccert" description:"RPC server certificate chain for validation"`
+	ClientCert    string `long:"clientcert" description:"Certificate to present to an RPC server which requires client certificates"`
+	ClientKey     string `long:"clientkey" description:"Key of the client certificate"`
+	RPCCookieFile string `long:"rpccookiefile" description:"Cookie file with the RPC credentials written by dmgd --rpccookie, used when no username and password are specified (default: .cookie in the dmgd data directory of the selected network)"`
+	RPCUnixSocket string `long:"rpcunixsocket" description:"Connect to the RPC server over the Unix domain socket at the specified path without TLS"`
 	NoTLS         bool   `long:"notls" description:"Disable TLS"`
 	Proxy         string `long:"proxy" description:"Connect via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
 	ProxyUser     string `long:"proxyuser" description:"Username for proxy server"`
@@ -274,11 +278,47 @@ func loadConfig() (*config, []string, error) {
 	// Handle environment variable expansion in the RPC certificate path.
 	cfg.RPCCert = cleanAndExpandPath(cfg.RPCCert)
 
+	// The client certificate must be specified along with its key.
+	if (cfg.ClientCert == "") != (cfg.ClientKey == "") {
+		str := "%s: the --clientcert and --clientkey options must be " +
+			"specified together"
+		err := fmt.Errorf(str, "loadConfig")
+		fmt.Fprintln(os.Stderr, err)
+		return nil, nil, err
+	}
+	if cfg.ClientCert != "" {
+		cfg.ClientCert = cleanAndExpandPath(cfg.ClientCert)
+		cfg.ClientKey = cleanAndExpandPath(cfg.ClientKey)
+	}
+
+	// The Unix domain socket is served without TLS.
+	if cfg.RPCUnixSocket != "" {
+		cfg.RPCUnixSocket = cleanAndExpandPath(cfg.RPCUnixSocket)
+		cfg.NoTLS = true
+	}
+
 	// Add default port to RPC server based on --testnet and --wallet flags
 	// if needed.
 	cfg.RPCServer = normalizeAddress(cfg.RPCServer, cfg.TestNet,
 		cfg.SimNet, cfg.Wallet)
 
+	// If no credentials are specified, use those of the cookie file written
+	// by dmgd when it exists.
+	if cfg.RPCUser == "" && cfg.RPCPassword == "" && !cfg.Wallet {
+		cookieFile := cfg.RPCCookieFile
+		if cookieFile == "" {
+			cookieFile = defaultRPCCookieFile(cfg.TestNet, cfg.SimNet)
+		}
+		user, pass, err := readCookieFile(cleanAndExpandPath(cookieFile))
+		if err == nil {
+			cfg.RPCUser, cfg.RPCPassword = user, pass
+		} else if cfg.RPCCookieFile != "" {
+			fmt.Fprintf(os.Stderr, "Error reading cookie file: %v\n",
+				err)
+			return nil, nil, err
+		}
+	}
+
 	// If no password specified, prompt the user. This allows usage where
 	// it is not acceptable to have the password in a disk-based config file
 	// or to pass it as an arg on the command line (which would be visible in
@@ -290,6 +330,33 @@ func loadConfig() (*config, []string, error) {
 	return &cfg, remainingArgs, nil
 }
 
+// defaultRPCCookieFile returns the path of the cookie file written by dmgd
+// with its default data directory for the selected network.
+func defaultRPCCookieFile(testNet, simNet bool) string {
+	netName := "mainnet"
+	switch {
+	case testNet:
+		netName = "testnet"
+	case simNet:
+		netName = "simnet"
+	}
+	return filepath.Join(provaHomeDir, "data", netName, ".cookie")
+}
+
+// readCookieFile reads the RPC username and password from the cookie file at
+// the passed path.
+func readCookieFile(path string) (string, string, error) {
+	cookie, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", "", err
+	}
+	parts := strings.SplitN(strings.TrimSpace(string(cookie)), ":", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("malformed cookie file %s", path)
+	}
+	return parts[0], parts[1], nil
+}
+
 // readPassword prompts the user for an RPC password and reads it in from stdin
 func readPassword() string {
 	fmt.Print("RPC Password: ")
@@ -34,9 +34,21 @@ func newHTTPClient(cfg *config) (*http.Client, error) {
 		}
 	}
 
+	// Connect over the Unix domain socket when one is specified.
+	if cfg.RPCUnixSocket != "" {
+		dial = func(network, addr string) (net.Conn, error) {
+			return net.Dial("unix", cfg.RPCUnixSocket)
+		}
+	}
+
 	// Configure TLS if needed.
 	var tlsConfig *tls.Config
-	if !cfg.NoTLS && cfg.RPCCert != "" {
+	if !cfg.NoTLS && (cfg.RPCCert != "" || cfg.ClientCert != "") {
+		tlsConfig = &tls.Config{
+			InsecureSkipVerify: cfg.TLSSkipVerify,
+		}
+	}
+	if tlsConfig != nil && cfg.RPCCert != "" {
 		pem, err := ioutil.ReadFile(cfg.RPCCert)
 		if err != nil {
 			return nil, err
@@ -44,10 +56,17 @@ func newHTTPClient(cfg *config) (*http.Client, error) {
 
 		pool := x509.NewCertPool()
 		pool.AppendCertsFromPEM(pem)
-		tlsConfig = &tls.Config{
-			RootCAs:            pool,
-			InsecureSkipVerify: cfg.TLSSkipVerify,
+		tlsConfig.RootCAs = pool
+	}
+
+	// Present the client certificate to servers which require one.
+	if tlsConfig != nil && cfg.ClientCert != "" {
+		keypair, err := tls.LoadX509KeyPair(cfg.ClientCert,
+			cfg.ClientKey)
+		if err != nil {
+			return nil, err
 		}
+		tlsConfig.Certificates = []tls.Certificate{keypair}
 	}
 
 	// Create and return the new HTTP client potentially configured with a
@@ -0,0 +1,266 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	flags "github.com/btcsuite/go-flags"
+	"github.com/pyx-partners/dmgd/blockchain/fullblocktests"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+const (
+	// vectorsFileName is the name of the JSON file holding the vectors.
+	vectorsFileName = "blocktests.json"
+
+	// blocksDirName is the name of the directory holding the serialized
+	// blocks when they are also written as binary files.
+	blocksDirName = "blocks"
+
+	// vectorsVersion is the version of the format of the vectors, which is
+	// increased whenever a field changes meaning or is removed.
+	vectorsVersion = 1
+)
+
+type config struct {
+	Directory  string `short:"d" long:"directory" description:"Directory to write the test vectors to"`
+	LargeReorg bool   `long:"largereorg" description:"Include the test which reorganizes the chain back to the genesis block"`
+	Binary     bool   `short:"b" long:"binary" description:"Also write each serialized block to its own file"`
+	Force      bool   `short:"f" long:"force" description:"Force overwriting of existing test vectors"`
+}
+
+// vectors is the JSON encoding of the consensus test vectors.
+type vectors struct {
+	Version     int        `json:"version"`
+	Network     string     `json:"network"`
+	GenesisHash string     `json:"genesishash"`
+	Tests       [][]vector `json:"tests"`
+}
+
+// vector is the JSON encoding of a single test instance.  The block is
+// processed on top of the blocks of the previous instances of the same test,
+// and the tests are run in order against the same chain.
+type vector struct {
+	Kind        string    `json:"kind"`
+	Name        string    `json:"name"`
+	Height      uint32    `json:"height"`
+	Hash        string    `json:"hash,omitempty"`
+	Block       string    `json:"block"`
+	File        string    `json:"file,omitempty"`
+	IsMainChain *bool     `json:"ismainchain,omitempty"`
+	IsOrphan    *bool     `json:"isorphan,omitempty"`
+	RejectCode  string    `json:"rejectcode,omitempty"`
+	KeyState    *keyState `json:"keystate,omitempty"`
+}
+
+// keyState is the JSON encoding of the admin state expected after an accepted
+// block.  The key sets and ASP key IDs are only expected to match when the
+// block extends the main chain.
+type keyState struct {
+	ThreadTips    map[string]string   `json:"threadtips"`
+	TotalSupply   uint64              `json:"totalsupply"`
+	AssetSupplies map[string]uint64   `json:"assetsupplies"`
+	AdminKeySets  map[string][]string `json:"adminkeysets"`
+	ASPKeyIDs     map[string]string   `json:"aspkeyids"`
+}
+
+// threadNames maps the admin threads to their names in the vectors.
+var threadNames = map[provautil.ThreadID]string{
+	provautil.RootThread:      "root",
+	provautil.ProvisionThread: "provision",
+	provautil.IssueThread:     "issue",
+}
+
+// newKeyState returns the JSON encoding of the admin state of the passed
+// accepted block instance.
+func newKeyState(item *fullblocktests.AcceptedBlock) *keyState {
+	state := &keyState{
+		ThreadTips:    make(map[string]string),
+		TotalSupply:   item.TotalSupply,
+		AssetSupplies: make(map[string]uint64),
+		AdminKeySets:  make(map[string][]string),
+		ASPKeyIDs:     make(map[string]string),
+	}
+	for threadID, tip := range item.ThreadTips {
+		name, ok := threadNames[threadID]
+		if !ok {
+			name = strconv.Itoa(int(threadID))
+		}
+		state.ThreadTips[name] = tip.String()
+	}
+	for assetID, supply := range item.AssetSupplies {
+		state.AssetSupplies[strconv.FormatUint(uint64(assetID), 10)] = supply
+	}
+	for keySetType, keySet := range item.AdminKeySets {
+		keys := make([]string, 0, len(keySet))
+		for i := range keySet {
+			keys = append(keys,
+				hex.EncodeToString(keySet[i].SerializeCompressed()))
+		}
+		state.AdminKeySets[keySetType.String()] = keys
+	}
+	for keyID, pubKey := range item.ASPKeyIdMap {
+		state.ASPKeyIDs[strconv.FormatUint(uint64(keyID), 10)] =
+			hex.EncodeToString(pubKey.SerializeCompressed())
+	}
+	return state
+}
+
+// newVector returns the JSON encoding of the passed test instance along with
+// its serialized block.
+func newVector(instance fullblocktests.TestInstance) (*vector, []byte, error) {
+	var v vector
+	var block *wire.MsgBlock
+	var serialized []byte
+	switch item := instance.(type) {
+	case fullblocktests.AcceptedBlock:
+		v.Kind = "accepted"
+		v.Name, v.Height, block = item.Name, item.Height, item.Block
+		v.IsMainChain, v.IsOrphan = &item.IsMainChain, &item.IsOrphan
+		v.KeyState = newKeyState(&item)
+
+	case fullblocktests.RejectedBlock:
+		v.Kind = "rejected"
+		v.Name, v.Height, block = item.Name, item.Height, item.Block
+		v.RejectCode = item.RejectCode.String()
+
+	case fullblocktests.OrphanOrRejectedBlock:
+		v.Kind = "orphanorrejected"
+		v.Name, v.Height, block = item.Name, item.Height, item.Block
+
+	case fullblocktests.ExpectedTip:
+		v.Kind = "expectedtip"
+		v.Name, v.Height, block = item.Name, item.Height, item.Block
+
+	case fullblocktests.RejectedNonCanonicalBlock:
+		// The block can not be decoded, so it has no hash.
+		v.Kind = "rejectednoncanonical"
+		v.Name, v.Height = item.Name, item.Height
+		serialized = item.RawBlock
+
+	default:
+		return nil, nil, fmt.Errorf("unknown test instance type %T",
+			instance)
+	}
+
+	if block != nil {
+		var buf bytes.Buffer
+		if err := block.Serialize(&buf); err != nil {
+			return nil, nil, err
+		}
+		serialized = buf.Bytes()
+		v.Hash = block.BlockHash().String()
+	}
+	v.Block = hex.EncodeToString(serialized)
+	return &v, serialized, nil
+}
+
+// writeVectors generates the full block tests and writes them to the
+// configured directory.
+func writeVectors(cfg *config) error {
+	tests, err := fullblocktests.Generate(cfg.LargeReorg)
+	if err != nil {
+		return fmt.Errorf("failed to generate tests: %v", err)
+	}
+
+	if cfg.Binary {
+		err := os.MkdirAll(filepath.Join(cfg.Directory, blocksDirName),
+			0755)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The tests are generated for the regression test network.
+	params := &chaincfg.RegressionNetParams
+	out := vectors{
+		Version:     vectorsVersion,
+		Network:     params.Name,
+		GenesisHash: params.GenesisHash.String(),
+		Tests:       make([][]vector, 0, len(tests)),
+	}
+	var numBlocks int
+	for testNum, test := range tests {
+		testVectors := make([]vector, 0, len(test))
+		for itemNum, instance := range test {
+			v, serialized, err := newVector(instance)
+			if err != nil {
+				return err
+			}
+			if cfg.Binary {
+				// The path of the file is relative to the
+				// vectors and slash separated on all systems.
+				v.File = path.Join(blocksDirName,
+					fmt.Sprintf("%04d-%03d.dat", testNum, itemNum))
+				err := ioutil.WriteFile(filepath.Join(cfg.Directory,
+					filepath.FromSlash(v.File)), serialized, 0644)
+				if err != nil {
+					return err
+				}
+			}
+			testVectors = append(testVectors, *v)
+			numBlocks++
+		}
+		out.Tests = append(out.Tests, testVectors)
+	}
+
+	serialized, err := json.MarshalIndent(&out, "", "  ")
+	if err != nil {
+		return err
+	}
+	vectorsFile := filepath.Join(cfg.Directory, vectorsFileName)
+	err = ioutil.WriteFile(vectorsFile, append(serialized, '\n'), 0644)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %d tests with %d blocks to %s\n", len(tests),
+		numBlocks, vectorsFile)
+	return nil
+}
+
+func main() {
+	cfg := config{}
+	parser := flags.NewParser(&cfg, flags.Default)
+	_, err := parser.Parse()
+	if err != nil {
+		if e, ok := err.(*flags.Error); !ok || e.Type != flags.ErrHelp {
+			parser.WriteHelp(os.Stderr)
+		}
+		return
+	}
+
+	if cfg.Directory == "" {
+		var err error
+		cfg.Directory, err = os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "no directory specified and cannot get working directory\n")
+			os.Exit(1)
+		}
+	}
+	if !cfg.Force {
+		_, err := os.Stat(filepath.Join(cfg.Directory, vectorsFileName))
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "%v: test vectors exist; use -f to force\n", cfg.Directory)
+			os.Exit(1)
+		}
+	}
+
+	if err := writeVectors(&cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	flags "github.com/btcsuite/go-flags"
+	"github.com/pyx-partners/dmgd/txscript/signvectors"
+)
+
+type config struct {
+	Directory string `short:"d" long:"directory" description:"Directory to write the test vectors to"`
+	Force     bool   `short:"f" long:"force" description:"Force overwriting of existing test vectors"`
+}
+
+// writeVectors generates the signing test vectors and writes them to the
+// configured directory.
+func writeVectors(cfg *config) error {
+	vectors, err := signvectors.Generate()
+	if err != nil {
+		return fmt.Errorf("failed to generate vectors: %v", err)
+	}
+	serialized, err := vectors.Encode()
+	if err != nil {
+		return err
+	}
+	vectorsFile := filepath.Join(cfg.Directory, signvectors.FileName)
+	if err := ioutil.WriteFile(vectorsFile, serialized, 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote %d vectors to %s\n", len(vectors.Vectors),
+		vectorsFile)
+	return nil
+}
+
+func main() {
+	cfg := config{}
+	parser := flags.NewParser(&cfg, flags.Default)
+	_, err := parser.Parse()
+	if err != nil {
+		if e, ok := err.(*flags.Error); !ok || e.Type != flags.ErrHelp {
+			parser.WriteHelp(os.Stderr)
+		}
+		return
+	}
+
+	if cfg.Directory == "" {
+		var err error
+		cfg.Directory, err = os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "no directory specified and cannot get working directory\n")
+			os.Exit(1)
+		}
+	}
+	if !cfg.Force {
+		_, err := os.Stat(filepath.Join(cfg.Directory, signvectors.FileName))
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "%v: test vectors exist; use -f to force\n", cfg.Directory)
+			os.Exit(1)
+		}
+	}
+
+	if err := writeVectors(&cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pyx-partners/dmgd/keyceremony"
+)
+
+// combineCmd defines the configuration options for the combine command.
+type combineCmd struct {
+	Manifest    string `short:"m" long:"manifest" description:"Manifest the recovered key must be recorded in"`
+	ShowPrivKey bool   `long:"showprivkey" description:"Print the recovered private key"`
+}
+
+var (
+	// combineCfg defines the configuration options for the command.
+	combineCfg = combineCmd{}
+)
+
+// Execute is the main entry point for the command.  It's invoked by the parser.
+func (cmd *combineCmd) Execute(args []string) error {
+	if err := setupGlobalConfig(); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return errors.New("no share files specified")
+	}
+	shares := make([]keyceremony.Share, len(args))
+	for i, path := range args {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		shares[i], err = keyceremony.ParseShare(strings.TrimSpace(
+			string(data)))
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	privKey, err := keyceremony.CombineKey(shares)
+	if err != nil {
+		return err
+	}
+	defer privKey.Zero()
+
+	pubKey := hex.EncodeToString(privKey.PubKey().SerializeCompressed())
+	fingerprint := keyceremony.Fingerprint(privKey.PubKey())
+	if cmd.Manifest != "" {
+		manifest, err := keyceremony.LoadManifest(cmd.Manifest)
+		if err != nil {
+			return err
+		}
+		role := ""
+		for _, key := range manifest.Keys {
+			if key.PubKey == pubKey {
+				role = key.Role
+			}
+		}
+		if role == "" {
+			return fmt.Errorf("recovered key %s is not recorded in "+
+				"the manifest", fingerprint)
+		}
+		fmt.Printf("Role: %s\n", role)
+	}
+	fmt.Printf("Fingerprint: %s\n", fingerprint)
+	fmt.Printf("Compressed: %s\n", pubKey)
+	if cmd.ShowPrivKey {
+		fmt.Printf("Private key: %x\n", privKey.Serialize())
+	}
+	return nil
+}
+
+// Usage overrides the usage display for the command.
+func (cmd *combineCmd) Usage() string {
+	return "<share-file>..."
+}
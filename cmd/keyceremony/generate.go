@@ -0,0 +1,120 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/keyceremony"
+)
+
+// manifestFileName is the name of the manifest written by the generate
+// command.
+const manifestFileName = "manifest.json"
+
+// generateCmd defines the configuration options for the generate command.
+type generateCmd struct {
+	Roles     []string `short:"r" long:"role" description:"Role of a key to generate (root, provision, issue, validate or asp), repeat for several keys"`
+	Threshold int      `short:"t" long:"threshold" description:"Number of shares needed to recover a key"`
+	Shares    int      `short:"n" long:"shares" description:"Number of shares each key is split into"`
+	OutDir    string   `short:"o" long:"outdir" description:"Directory the manifest and shares are written to"`
+}
+
+var (
+	// generateCfg defines the configuration options for the command.
+	generateCfg = generateCmd{
+		Threshold: 2,
+		Shares:    3,
+		OutDir:    ".",
+	}
+)
+
+// writeNewFile writes the passed data to a new file at the passed path which
+// only the owner may read.  Existing files are never overwritten.
+func writeNewFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Execute is the main entry point for the command.  It's invoked by the parser.
+func (cmd *generateCmd) Execute(args []string) error {
+	if err := setupGlobalConfig(); err != nil {
+		return err
+	}
+	if len(cmd.Roles) == 0 {
+		return errors.New("no key role specified")
+	}
+	roles := make([]btcec.KeySetType, len(cmd.Roles))
+	for i, role := range cmd.Roles {
+		var err error
+		if roles[i], err = keyceremony.ParseRole(role); err != nil {
+			return err
+		}
+	}
+	manifestPath := filepath.Join(cmd.OutDir, manifestFileName)
+	if _, err := os.Stat(manifestPath); err == nil {
+		return fmt.Errorf("manifest %s exists already", manifestPath)
+	}
+
+	manifest := keyceremony.NewManifest(activeNetParams.Name, time.Now())
+	privKeys := make([]*btcec.PrivateKey, 0, len(roles))
+	defer func() {
+		for _, privKey := range privKeys {
+			privKey.Zero()
+		}
+	}()
+	for _, role := range roles {
+		privKey, err := btcec.NewPrivateKey(btcec.S256())
+		if err != nil {
+			return err
+		}
+		privKeys = append(privKeys, privKey)
+		shares, err := keyceremony.SplitKey(privKey, cmd.Threshold,
+			cmd.Shares)
+		if err != nil {
+			return err
+		}
+
+		// Every share goes to its own custodian, so each is written
+		// to its own file named after the key and the share index.
+		fingerprint := keyceremony.Fingerprint(privKey.PubKey())
+		for _, share := range shares {
+			name := fmt.Sprintf("%s-%d.share", fingerprint[:16],
+				share.Index)
+			err := writeNewFile(filepath.Join(cmd.OutDir, name),
+				[]byte(share.String()+"\n"))
+			if err != nil {
+				return err
+			}
+		}
+		manifest.AddKey(role, privKey.PubKey(), cmd.Threshold,
+			cmd.Shares)
+	}
+
+	if err := manifest.Sign(privKeys); err != nil {
+		return err
+	}
+	if err := manifest.Save(manifestPath); err != nil {
+		return err
+	}
+	for _, key := range manifest.Keys {
+		fmt.Printf("%-9s %s %s\n", key.Role, key.Fingerprint, key.PubKey)
+	}
+	fmt.Printf("Wrote %s and %d shares of each key\n", manifestPath,
+		cmd.Shares)
+	return nil
+}
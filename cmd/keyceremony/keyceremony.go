@@ -0,0 +1,96 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	flags "github.com/btcsuite/go-flags"
+	"github.com/pyx-partners/dmgd/chaincfg"
+)
+
+// config defines the global configuration options.
+type config struct {
+	TestNet        bool `long:"testnet" description:"Use the test network"`
+	RegressionTest bool `long:"regtest" description:"Use the regression test network"`
+	SimNet         bool `long:"simnet" description:"Use the simulation test network"`
+}
+
+var (
+	// cfg holds the global configuration options.
+	cfg = &config{}
+
+	// activeNetParams are the parameters of the network selected by the
+	// global configuration options.
+	activeNetParams = &chaincfg.MainNetParams
+)
+
+// setupGlobalConfig ensures at most one network is selected and sets the
+// active network parameters accordingly.
+func setupGlobalConfig() error {
+	numNets := 0
+	if cfg.TestNet {
+		numNets++
+		activeNetParams = &chaincfg.TestNetParams
+	}
+	if cfg.RegressionTest {
+		numNets++
+		activeNetParams = &chaincfg.RegressionNetParams
+	}
+	if cfg.SimNet {
+		numNets++
+		activeNetParams = &chaincfg.SimNetParams
+	}
+	if numNets > 1 {
+		return fmt.Errorf("the testnet, regtest, and simnet params " +
+			"can't be used together -- choose one of the three")
+	}
+	return nil
+}
+
+// readLine reads a line from stdin with the surrounding whitespace removed.
+func readLine() string {
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+func main() {
+	appName := filepath.Base(os.Args[0])
+	appName = strings.TrimSuffix(appName, filepath.Ext(appName))
+	parserFlags := flags.Options(flags.HelpFlag | flags.PassDoubleDash)
+	parser := flags.NewNamedParser(appName, parserFlags)
+	parser.AddGroup("Global Options", "", cfg)
+	parser.AddCommand("generate",
+		"Generate admin keys split into Shamir shares",
+		"Generate a key for each role, split it into Shamir shares "+
+			"written to one file each and record the keys in a "+
+			"manifest signed by all of them.", &generateCfg)
+	parser.AddCommand("combine",
+		"Recover a key from its shares",
+		"Recover a key from the passed share files and print its "+
+			"public key, checking it against a manifest when one "+
+			"is passed.", &combineCfg)
+	parser.AddCommand("verify",
+		"Verify a key manifest",
+		"Verify the fingerprints and signatures of the keys of a "+
+			"manifest and, when an RPC server is passed, that all "+
+			"of them are provisioned on chain.", &verifyCfg)
+	parser.AddCommand("pubkey",
+		"Print the public key of a private key read from stdin",
+		"", &pubKeyCfg)
+
+	if _, err := parser.Parse(); err != nil {
+		if e, ok := err.(*flags.Error); ok && e.Type == flags.ErrHelp {
+			parser.WriteHelp(os.Stderr)
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+}
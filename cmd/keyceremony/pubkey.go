@@ -0,0 +1,43 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/keyceremony"
+)
+
+// pubKeyCmd defines the configuration options for the pubkey command.
+type pubKeyCmd struct{}
+
+var (
+	// pubKeyCfg defines the configuration options for the command.
+	pubKeyCfg = pubKeyCmd{}
+)
+
+// Execute is the main entry point for the command.  It's invoked by the parser.
+// The private key is read from stdin, so it does not end up in the shell
+// history.
+func (cmd *pubKeyCmd) Execute(args []string) error {
+	fmt.Println("Enter the private key:")
+	privKeyBytes, err := hex.DecodeString(readLine())
+	if err != nil {
+		return err
+	}
+	if len(privKeyBytes) != btcec.PrivKeyBytesLen {
+		return fmt.Errorf("private key is %d bytes, want %d",
+			len(privKeyBytes), btcec.PrivKeyBytesLen)
+	}
+	privKey, pubKey := btcec.PrivKeyFromBytes(btcec.S256(), privKeyBytes)
+	defer privKey.Zero()
+
+	fmt.Printf("Fingerprint: %s\n", keyceremony.Fingerprint(pubKey))
+	fmt.Printf("Compressed: %x\n", pubKey.SerializeCompressed())
+	fmt.Printf("Uncompressed: %x\n", pubKey.SerializeUncompressed())
+	return nil
+}
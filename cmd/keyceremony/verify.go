@@ -0,0 +1,91 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pyx-partners/dmgd/keyceremony"
+	"github.com/pyx-partners/dmgd/rpcclient"
+)
+
+// verifyCmd defines the configuration options for the verify command.
+type verifyCmd struct {
+	RPCServer string `short:"s" long:"rpcserver" description:"RPC server to check the provisioning of the keys against"`
+	RPCUser   string `short:"u" long:"rpcuser" description:"RPC username"`
+	RPCPass   string `short:"P" long:"rpcpass" default-mask:"-" description:"RPC password"`
+	RPCCert   string `short:"c" long:"rpccert" description:"RPC server certificate chain for validation"`
+	NoTLS     bool   `long:"notls" description:"Disable TLS"`
+}
+
+var (
+	// verifyCfg defines the configuration options for the command.
+	verifyCfg = verifyCmd{}
+)
+
+// Execute is the main entry point for the command.  It's invoked by the parser.
+func (cmd *verifyCmd) Execute(args []string) error {
+	if err := setupGlobalConfig(); err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return errors.New("required manifest parameter not specified")
+	}
+	manifest, err := keyceremony.LoadManifest(args[0])
+	if err != nil {
+		return err
+	}
+	if manifest.Network != activeNetParams.Name {
+		return fmt.Errorf("manifest is for network %s, not %s",
+			manifest.Network, activeNetParams.Name)
+	}
+	if err := manifest.Verify(); err != nil {
+		return err
+	}
+	fmt.Printf("Manifest signed by all of its %d keys\n",
+		len(manifest.Keys))
+	if cmd.RPCServer == "" {
+		return nil
+	}
+
+	connCfg := &rpcclient.ConnConfig{
+		Host:       cmd.RPCServer,
+		User:       cmd.RPCUser,
+		Pass:       cmd.RPCPass,
+		DisableTLS: cmd.NoTLS,
+	}
+	if !cmd.NoTLS && cmd.RPCCert != "" {
+		connCfg.Certificates, err = ioutil.ReadFile(cmd.RPCCert)
+		if err != nil {
+			return err
+		}
+	}
+	client, err := rpcclient.New(connCfg)
+	if err != nil {
+		return err
+	}
+	info, err := client.GetAdminInfo()
+	if err != nil {
+		return err
+	}
+	unprovisioned := manifest.Unprovisioned(info)
+	for _, key := range unprovisioned {
+		fmt.Printf("Not provisioned: %-9s %s\n", key.Role,
+			key.Fingerprint)
+	}
+	if len(unprovisioned) != 0 {
+		return fmt.Errorf("%d of %d keys are not provisioned at block "+
+			"%s", len(unprovisioned), len(manifest.Keys), info.Hash)
+	}
+	fmt.Printf("All keys provisioned at block %s\n", info.Hash)
+	return nil
+}
+
+// Usage overrides the usage display for the command.
+func (cmd *verifyCmd) Usage() string {
+	return "<manifest-file>"
+}
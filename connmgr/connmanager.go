@@ -60,6 +60,11 @@ type ConnReq struct {
 	Addr      net.Addr
 	Permanent bool
 
+	// Group is the name of the outbound group the connection request
+	// belongs to.  It is empty for the connections which count towards
+	// TargetOutbound.
+	Group string
+
 	conn       net.Conn
 	state      ConnState
 	stateMtx   sync.RWMutex
@@ -94,6 +99,22 @@ func (c *ConnReq) String() string {
 	return fmt.Sprintf("%s (reqid %d)", c.Addr, atomic.LoadUint64(&c.id))
 }
 
+// OutboundGroup defines a group of outbound connections which is maintained
+// separately from the other outbound connections, such as connections to
+// validators, so the node stays connected to each kind of peer.
+type OutboundGroup struct {
+	// Name identifies the group.  It must not be empty.
+	Name string
+
+	// TargetOutbound is the number of outbound connections of the group to
+	// maintain.
+	TargetOutbound uint32
+
+	// GetNewAddress returns the address to make a new connection of the
+	// group to.  It cannot be nil.
+	GetNewAddress func() (net.Addr, error)
+}
+
 // Config holds the configuration options related to the connection manager.
 type Config struct {
 	// Listeners defines a slice of listeners for which the connection
@@ -139,6 +160,11 @@ type Config struct {
 	// to.  If nil, no new connections will be made automatically.
 	GetNewAddress func() (net.Addr, error)
 
+	// OutboundGroups defines groups of outbound connections which are
+	// maintained in addition to the TargetOutbound connections, each with
+	// its own target and source of addresses.
+	OutboundGroups []OutboundGroup
+
 	// Dial connects to the address on the named network. It cannot be nil.
 	Dial func(net.Addr) (net.Conn, error)
 }
@@ -194,21 +220,36 @@ func (cm *ConnManager) handleFailedConn(c *ConnReq) {
 		time.AfterFunc(d, func() {
 			cm.Connect(c)
 		})
-	} else if cm.cfg.GetNewAddress != nil {
+	} else if _, getNewAddress := cm.group(c.Group); getNewAddress != nil {
 		cm.failedAttempts++
 		if cm.failedAttempts >= maxFailedAttempts {
 			log.Debugf("Max failed connection attempts reached: [%d] "+
 				"-- retrying connection in: %v", maxFailedAttempts,
 				cm.cfg.RetryDuration)
 			time.AfterFunc(cm.cfg.RetryDuration, func() {
-				cm.NewConnReq()
+				cm.newGroupConnReq(c.Group)
 			})
 		} else {
-			go cm.NewConnReq()
+			go cm.newGroupConnReq(c.Group)
 		}
 	}
 }
 
+// group returns the target number of connections of the passed outbound group
+// and the function returning new addresses to connect to for it.  The empty
+// group is made up of the connections which count towards TargetOutbound.
+func (cm *ConnManager) group(name string) (uint32, func() (net.Addr, error)) {
+	if name == "" {
+		return cm.cfg.TargetOutbound, cm.cfg.GetNewAddress
+	}
+	for _, group := range cm.cfg.OutboundGroups {
+		if group.Name == name {
+			return group.TargetOutbound, group.GetNewAddress
+		}
+	}
+	return 0, nil
+}
+
 // connHandler handles all connection related requests.  It must be run as a
 // goroutine.
 //
@@ -217,6 +258,15 @@ func (cm *ConnManager) handleFailedConn(c *ConnReq) {
 // are processed and mapped by their assigned ids.
 func (cm *ConnManager) connHandler() {
 	conns := make(map[uint64]*ConnReq, cm.cfg.TargetOutbound)
+	groupConns := func(group string) uint32 {
+		var count uint32
+		for _, connReq := range conns {
+			if connReq.Group == group {
+				count++
+			}
+		}
+		return count
+	}
 out:
 	for {
 		select {
@@ -249,7 +299,8 @@ out:
 						go cm.cfg.OnDisconnection(connReq)
 					}
 
-					if uint32(len(conns)) < cm.cfg.TargetOutbound && msg.retry {
+					target, _ := cm.group(connReq.Group)
+					if groupConns(connReq.Group) < target && msg.retry {
 						cm.handleFailedConn(connReq)
 					}
 				} else {
@@ -275,17 +326,24 @@ out:
 // NewConnReq creates a new connection request and connects to the
 // corresponding address.
 func (cm *ConnManager) NewConnReq() {
+	cm.newGroupConnReq("")
+}
+
+// newGroupConnReq creates a new connection request of the passed outbound group
+// and connects to the corresponding address.
+func (cm *ConnManager) newGroupConnReq(group string) {
 	if atomic.LoadInt32(&cm.stop) != 0 {
 		return
 	}
-	if cm.cfg.GetNewAddress == nil {
+	_, getNewAddress := cm.group(group)
+	if getNewAddress == nil {
 		return
 	}
 
-	c := &ConnReq{}
+	c := &ConnReq{Group: group}
 	atomic.StoreUint64(&c.id, atomic.AddUint64(&cm.connReqCount, 1))
 
-	addr, err := cm.cfg.GetNewAddress()
+	addr, err := getNewAddress()
 	if err != nil {
 		cm.requests <- handleFailed{c, err}
 		return
@@ -376,6 +434,11 @@ func (cm *ConnManager) Start() {
 	for i := atomic.LoadUint64(&cm.connReqCount); i < uint64(cm.cfg.TargetOutbound); i++ {
 		go cm.NewConnReq()
 	}
+	for _, group := range cm.cfg.OutboundGroups {
+		for i := uint32(0); i < group.TargetOutbound; i++ {
+			go cm.newGroupConnReq(group.Name)
+		}
+	}
 }
 
 // Wait blocks until the connection manager halts gracefully.
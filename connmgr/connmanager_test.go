@@ -9,6 +9,7 @@ import (
 	"errors"
 	"io"
 	"net"
+	"reflect"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -227,6 +228,83 @@ func TestTargetOutbound(t *testing.T) {
 	cmgr.Stop()
 }
 
+// TestOutboundGroups tests the target number of outbound connections of each
+// outbound group.
+//
+// We wait until all connections are established and test they are made to the
+// addresses of their groups, then disconnect a group connection and wait for
+// it to be replaced by a connection of the same group.
+func TestOutboundGroups(t *testing.T) {
+	newAddressFunc := func(port int) func() (net.Addr, error) {
+		return func() (net.Addr, error) {
+			return &net.TCPAddr{
+				IP:   net.ParseIP("127.0.0.1"),
+				Port: port,
+			}, nil
+		}
+	}
+	targets := map[string]uint32{"": 1, "validator": 2, "archival": 3}
+	ports := map[string]int{"": 18555, "validator": 18556, "archival": 18557}
+	connected := make(chan *ConnReq)
+	cmgr, err := New(&Config{
+		RetryDuration:  time.Millisecond,
+		TargetOutbound: targets[""],
+		Dial:           mockDialer,
+		GetNewAddress:  newAddressFunc(ports[""]),
+		OutboundGroups: []OutboundGroup{
+			{
+				Name:           "validator",
+				TargetOutbound: targets["validator"],
+				GetNewAddress:  newAddressFunc(ports["validator"]),
+			},
+			{
+				Name:           "archival",
+				TargetOutbound: targets["archival"],
+				GetNewAddress:  newAddressFunc(ports["archival"]),
+			},
+		},
+		OnConnection: func(c *ConnReq, conn net.Conn) {
+			connected <- c
+		},
+	})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	cmgr.Start()
+	counts := make(map[string]uint32)
+	var validatorConn *ConnReq
+	for i := 0; i < 6; i++ {
+		c := <-connected
+		if port := c.Addr.(*net.TCPAddr).Port; port != ports[c.Group] {
+			t.Fatalf("outbound groups: got port %d for group %q, "+
+				"want %d", port, c.Group, ports[c.Group])
+		}
+		if c.Group == "validator" {
+			validatorConn = c
+		}
+		counts[c.Group]++
+	}
+	if !reflect.DeepEqual(counts, targets) {
+		t.Fatalf("outbound groups: got connections %v, want %v", counts,
+			targets)
+	}
+
+	select {
+	case c := <-connected:
+		t.Fatalf("outbound groups: got unexpected connection - %v", c.Addr)
+	case <-time.After(time.Millisecond):
+		break
+	}
+
+	cmgr.Disconnect(validatorConn.ID())
+	c := <-connected
+	if c.Group != "validator" || c.ID() == validatorConn.ID() {
+		t.Fatalf("outbound groups: got replacement connection %v of "+
+			"group %q, want a new validator connection", c, c.Group)
+	}
+	cmgr.Stop()
+}
+
 // TestRetryPermanent tests that permanent connection requests are retried.
 //
 // We make a permanent connection request using Connect, disconnect it using
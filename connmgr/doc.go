@@ -10,5 +10,10 @@ Connection Manager Overview
 Connection Manager handles all the general connection concerns such as
 maintaining a set number of outbound connections, sourcing peers, banning,
 limiting max connections, tor lookup, etc.
+
+Besides the target number of outbound connections, groups of outbound
+connections with their own targets and address sources can be maintained, and
+SelectEviction picks the least useful inbound peer to disconnect when a new
+inbound peer needs room.
 */
 package connmgr
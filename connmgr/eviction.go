@@ -0,0 +1,148 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package connmgr
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// evictProtectPing is the number of peers with the lowest ping times
+	// which are protected from eviction.
+	evictProtectPing = 4
+
+	// evictProtectTx is the number of peers which most recently relayed a
+	// new transaction which are protected from eviction.
+	evictProtectTx = 4
+
+	// evictProtectBlock is the number of peers which most recently relayed
+	// a new block which are protected from eviction.
+	evictProtectBlock = 4
+)
+
+// EvictionCandidate describes an inbound peer which may be evicted to make
+// room for a new inbound connection.
+type EvictionCandidate struct {
+	// ConnTime is the time the peer connected.
+	ConnTime time.Time
+
+	// PingTime is the last measured round trip time to the peer.  It is
+	// zero when it has not been measured yet.
+	PingTime time.Duration
+
+	// LastBlockTime is the last time the peer relayed a block which was
+	// accepted to the chain.
+	LastBlockTime time.Time
+
+	// LastTxTime is the last time the peer relayed a transaction which was
+	// accepted to the mempool.
+	LastTxTime time.Time
+
+	// Protected marks peers which must never be evicted, such as
+	// whitelisted peers.
+	Protected bool
+}
+
+// lastRelay returns the last time the candidate relayed a new block or
+// transaction.
+func (c *EvictionCandidate) lastRelay() time.Time {
+	if c.LastBlockTime.After(c.LastTxTime) {
+		return c.LastBlockTime
+	}
+	return c.LastTxTime
+}
+
+// pingRank returns the ping time of the candidate for sorting, with unmeasured
+// ping times ranking last.
+func (c *EvictionCandidate) pingRank() time.Duration {
+	if c.PingTime <= 0 {
+		return time.Duration(1<<63 - 1)
+	}
+	return c.PingTime
+}
+
+// protectBest removes the passed number of best candidates according to the
+// passed less function from the passed candidate indices and returns the
+// remaining ones.
+func protectBest(candidates []EvictionCandidate, indices []int, count int,
+	less func(a, b *EvictionCandidate) bool) []int {
+
+	sort.SliceStable(indices, func(i, j int) bool {
+		return less(&candidates[indices[i]], &candidates[indices[j]])
+	})
+	if count > len(indices) {
+		count = len(indices)
+	}
+	return indices[count:]
+}
+
+// SelectEviction selects the inbound peer to evict in order to make room for a
+// new inbound connection.  It first protects the peers which are the most
+// useful or hardest for an attacker to imitate: the ones with the lowest ping
+// times, the ones which most recently relayed new transactions and blocks,
+// and the longest connected half of the rest.  Of the remaining peers, it
+// selects the one which least recently relayed anything useful, preferring the
+// highest ping time and then the most recent connection on ties.
+//
+// It returns the index of the selected candidate along with the reason it was
+// selected, or -1 when no candidate may be evicted.
+func SelectEviction(candidates []EvictionCandidate) (int, string) {
+	indices := make([]int, 0, len(candidates))
+	for i := range candidates {
+		if !candidates[i].Protected {
+			indices = append(indices, i)
+		}
+	}
+
+	indices = protectBest(candidates, indices, evictProtectPing,
+		func(a, b *EvictionCandidate) bool {
+			return a.pingRank() < b.pingRank()
+		})
+	indices = protectBest(candidates, indices, evictProtectTx,
+		func(a, b *EvictionCandidate) bool {
+			return a.LastTxTime.After(b.LastTxTime)
+		})
+	indices = protectBest(candidates, indices, evictProtectBlock,
+		func(a, b *EvictionCandidate) bool {
+			return a.LastBlockTime.After(b.LastBlockTime)
+		})
+	indices = protectBest(candidates, indices, len(indices)/2,
+		func(a, b *EvictionCandidate) bool {
+			return a.ConnTime.Before(b.ConnTime)
+		})
+	if len(indices) == 0 {
+		return -1, ""
+	}
+
+	worst := indices[0]
+	for _, i := range indices[1:] {
+		c, w := &candidates[i], &candidates[worst]
+		switch {
+		case !c.lastRelay().Equal(w.lastRelay()):
+			if c.lastRelay().Before(w.lastRelay()) {
+				worst = i
+			}
+		case c.pingRank() != w.pingRank():
+			if c.pingRank() > w.pingRank() {
+				worst = i
+			}
+		case c.ConnTime.After(w.ConnTime):
+			worst = i
+		}
+	}
+
+	c := &candidates[worst]
+	if c.lastRelay().IsZero() {
+		return worst, fmt.Sprintf("never relayed a new block or "+
+			"transaction (ping %v, connected %v)", c.PingTime,
+			c.ConnTime.Format(time.RFC3339))
+	}
+	return worst, fmt.Sprintf("least recently relayed a new block or "+
+		"transaction at %v (ping %v, connected %v)",
+		c.lastRelay().Format(time.RFC3339), c.PingTime,
+		c.ConnTime.Format(time.RFC3339))
+}
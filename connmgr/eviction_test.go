@@ -0,0 +1,120 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package connmgr
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSelectEviction ensures the least useful inbound peer which is not
+// protected is selected for eviction.
+func TestSelectEviction(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	ago := func(d time.Duration) time.Time {
+		return now.Add(-d)
+	}
+
+	// newCandidates returns the passed number of candidates which are
+	// equally useful, each connected a minute after the previous one.
+	newCandidates := func(count int) []EvictionCandidate {
+		candidates := make([]EvictionCandidate, count)
+		for i := range candidates {
+			candidates[i] = EvictionCandidate{
+				ConnTime: ago(time.Duration(count-i) * time.Minute),
+				PingTime: 100 * time.Millisecond,
+			}
+		}
+		return candidates
+	}
+
+	tests := []struct {
+		name       string
+		candidates func() []EvictionCandidate
+		want       int
+	}{
+		{
+			name:       "no candidates",
+			candidates: func() []EvictionCandidate { return nil },
+			want:       -1,
+		},
+		{
+			name: "all protected",
+			candidates: func() []EvictionCandidate {
+				candidates := newCandidates(20)
+				for i := range candidates {
+					candidates[i].Protected = true
+				}
+				return candidates
+			},
+			want: -1,
+		},
+		{
+			name: "too few candidates",
+			candidates: func() []EvictionCandidate {
+				return newCandidates(evictProtectPing +
+					evictProtectTx + evictProtectBlock)
+			},
+			want: -1,
+		},
+		{
+			name: "youngest connection on ties",
+			candidates: func() []EvictionCandidate {
+				return newCandidates(20)
+			},
+			want: 19,
+		},
+		{
+			name: "whitelisted peer protected",
+			candidates: func() []EvictionCandidate {
+				candidates := newCandidates(20)
+				candidates[19].Protected = true
+				return candidates
+			},
+			want: 18,
+		},
+		{
+			name: "highest ping",
+			candidates: func() []EvictionCandidate {
+				candidates := newCandidates(20)
+				candidates[17].PingTime = time.Second
+				return candidates
+			},
+			want: 17,
+		},
+		{
+			name: "least recent relay",
+			candidates: func() []EvictionCandidate {
+				candidates := newCandidates(20)
+				for i := range candidates {
+					candidates[i].LastTxTime = ago(time.Hour)
+				}
+				// The most recent relays are protected, the
+				// peer which never relayed anything is evicted
+				// even though younger peers are left.
+				for i := 0; i < 10; i++ {
+					candidates[i].LastBlockTime = ago(
+						time.Duration(i) * time.Second)
+				}
+				candidates[17].LastTxTime = time.Time{}
+				return candidates
+			},
+			want: 17,
+		},
+	}
+
+	for _, test := range tests {
+		got, reason := SelectEviction(test.candidates())
+		if got != test.want {
+			t.Errorf("%s: got candidate %d, want %d", test.name, got,
+				test.want)
+			continue
+		}
+		if (got >= 0) != (reason != "") {
+			t.Errorf("%s: got reason %q for candidate %d", test.name,
+				reason, got)
+		}
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/blockchain/indexers"
 	"github.com/pyx-partners/dmgd/database"
 	"github.com/btcsuite/btclog"
 	flags "github.com/btcsuite/go-flags"
@@ -69,6 +71,12 @@ func realMain() error {
 	dbLog := btclog.NewSubsystemLogger(backendLogger, "BCDB: ")
 	dbLog.SetLevel(btclog.DebugLvl)
 	database.UseLogger(dbLog)
+	chainLog := btclog.NewSubsystemLogger(backendLogger, "CHAN: ")
+	chainLog.SetLevel(btclog.InfoLvl)
+	blockchain.UseLogger(chainLog)
+	indxLog := btclog.NewSubsystemLogger(backendLogger, "INDX: ")
+	indxLog.SetLevel(btclog.InfoLvl)
+	indexers.UseLogger(indxLog)
 
 	// Setup the parser options and commands.
 	appName := filepath.Base(os.Args[0])
@@ -91,6 +99,10 @@ func realMain() error {
 	parser.AddCommand("fetchblockregion",
 		"Fetch the specified block region from the database", "",
 		&blockRegionCfg)
+	parser.AddCommand("rebuildindex",
+		"Rebuild an optional index from the blocks in the database",
+		"Drop the specified optional index and rebuild it from the "+
+			"blocks in the main chain.", &rebuildIndexCfg)
 
 	// Parse command line and invoke the Execute function for the specified
 	// command.
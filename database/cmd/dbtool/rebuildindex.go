@@ -0,0 +1,114 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/blockchain/indexers"
+)
+
+// rebuildIndexCmd defines the configuration options for the rebuildindex
+// command.
+type rebuildIndexCmd struct{}
+
+var (
+	// rebuildIndexCfg defines the configuration options for the command.
+	rebuildIndexCfg = rebuildIndexCmd{}
+)
+
+// Execute is the main entry point for the command.  It's invoked by the parser.
+func (cmd *rebuildIndexCmd) Execute(args []string) error {
+	// Setup the global config options and ensure they are valid.
+	if err := setupGlobalConfig(); err != nil {
+		return err
+	}
+
+	if len(args) < 1 {
+		return errors.New("required index name parameter not specified")
+	}
+
+	// Load the block database.
+	db, err := loadBlockDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Drop the existing index and choose the indexes needed to rebuild it.
+	// The address and commitment indexes rely on the transaction index.
+	var indexes []indexers.Indexer
+	switch args[0] {
+	case "txindex":
+		log.Infof("The address and commitment indexes depend on the " +
+			"transaction index, so they are dropped as well and " +
+			"rebuilt by dmgd on the next start when enabled")
+		if err := indexers.DropTxIndex(db); err != nil {
+			return err
+		}
+		indexes = append(indexes, indexers.NewTxIndex(db))
+
+	case "addrindex":
+		if err := indexers.DropAddrIndex(db); err != nil {
+			return err
+		}
+		indexes = append(indexes, indexers.NewTxIndex(db),
+			indexers.NewAddrIndex(db, activeNetParams))
+
+	case "commitmentindex":
+		if err := indexers.DropCommitmentIndex(db); err != nil {
+			return err
+		}
+		indexes = append(indexes, indexers.NewTxIndex(db),
+			indexers.NewCommitmentIndex(db))
+
+	case "watchindex":
+		if err := indexers.DropWatchIndex(db); err != nil {
+			return err
+		}
+		indexes = append(indexes, indexers.NewWatchIndex(db,
+			activeNetParams))
+
+	case "statsindex":
+		if err := indexers.DropStatsIndex(db); err != nil {
+			return err
+		}
+		indexes = append(indexes, indexers.NewTxIndex(db),
+			indexers.NewStatsIndex(db, activeNetParams))
+
+	default:
+		return fmt.Errorf("unknown index %q", args[0])
+	}
+
+	// Stop rebuilding the index on Ctrl+C.  Each block is indexed
+	// atomically, so dmgd resumes rebuilding it on the next start.
+	interrupt := make(chan struct{})
+	addInterruptHandler(func() {
+		close(interrupt)
+	})
+
+	// Loading the chain catches the indexes up to the end of the main chain
+	// by indexing the raw blocks.
+	_, err = blockchain.New(&blockchain.Config{
+		DB:           db,
+		Interrupt:    interrupt,
+		ChainParams:  activeNetParams,
+		TimeSource:   blockchain.NewMedianTime(),
+		IndexManager: indexers.NewManager(db, indexes),
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Rebuilt the %s", args[0])
+	return nil
+}
+
+// Usage overrides the usage display for the command.
+func (cmd *rebuildIndexCmd) Usage() string {
+	return "<txindex|addrindex|commitmentindex|watchindex|statsindex>"
+}
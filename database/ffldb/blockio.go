@@ -82,6 +82,11 @@ type filer interface {
 type lockableFile struct {
 	sync.RWMutex
 	file filer
+
+	// mapped is the read-only memory mapping of the file when the block
+	// files are memory-mapped.  It is always nil for the current write
+	// file since it is still being appended to.
+	mapped []byte
 }
 
 // writeCursor represents the current file and offset of the block file on disk
@@ -170,6 +175,24 @@ type blockStore struct {
 	// new blocks are written to.
 	writeCursor *writeCursor
 
+	// mmapBlocks indicates the block files which are no longer written to
+	// are memory-mapped so reads return slices of the mappings instead of
+	// copies.
+	//
+	// Callers commonly keep the returned data beyond the database
+	// transaction it was fetched in, so a mapping is not removed when its
+	// file is closed.  Instead, mappedFiles keeps the mapping of each block
+	// file for reuse when the file is opened again, and retiredMappings
+	// keeps the mappings of files which were truncated or deleted by a
+	// rollback.  All of them are unmapped once the database is closed.
+	//
+	// mmapMutex protects both of them and is locked after all of the other
+	// mutexes described above.
+	mmapBlocks      bool
+	mmapMutex       sync.Mutex
+	mappedFiles     map[uint32][]byte
+	retiredMappings [][]byte
+
 	// These functions are set to openFile, openWriteFile, and deleteFile by
 	// default, but are exposed here to allow the whitebox tests to replace
 	// them when working with mock files.
@@ -260,6 +283,9 @@ func (s *blockStore) openFile(fileNum uint32) (*lockableFile, error) {
 			err)
 	}
 	blockFile := &lockableFile{file: file}
+	if s.mmapBlocks {
+		blockFile.mapped = s.mapFile(fileNum, file)
+	}
 
 	// Close the least recently used file if the file exceeds the max
 	// allowed open files.  This is not done until after the file open in
@@ -275,18 +301,7 @@ func (s *blockStore) openFile(fileNum uint32) (*lockableFile, error) {
 	s.lruMutex.Lock()
 	lruList := s.openBlocksLRU
 	if lruList.Len() >= maxOpenFiles {
-		lruFileNum := lruList.Remove(lruList.Back()).(uint32)
-		oldBlockFile := s.openBlockFiles[lruFileNum]
-
-		// Close the old file under the write lock for the file in case
-		// any readers are currently reading from it so it's not closed
-		// out from under them.
-		oldBlockFile.Lock()
-		_ = oldBlockFile.file.Close()
-		oldBlockFile.Unlock()
-
-		delete(s.openBlockFiles, lruFileNum)
-		delete(s.fileNumToLRUElem, lruFileNum)
+		s.closeFile(lruList.Back().Value.(uint32))
 	}
 	s.fileNumToLRUElem[fileNum] = lruList.PushFront(fileNum)
 	s.lruMutex.Unlock()
@@ -297,6 +312,90 @@ func (s *blockStore) openFile(fileNum uint32) (*lockableFile, error) {
 	return blockFile, nil
 }
 
+// closeFile closes the open read-only file handle for the passed flat file
+// number and removes it from the least recently used tracking.
+//
+// This function MUST be called with the overall files mutex (s.obfMutex) locked
+// for WRITES and the LRU mutex (s.lruMutex) locked.
+func (s *blockStore) closeFile(fileNum uint32) {
+	blockFile := s.openBlockFiles[fileNum]
+
+	// Close the file under the write lock for the file in case any readers
+	// are currently reading from it so it's not closed out from under them.
+	blockFile.Lock()
+	_ = blockFile.file.Close()
+	blockFile.Unlock()
+
+	s.openBlocksLRU.Remove(s.fileNumToLRUElem[fileNum])
+	delete(s.openBlockFiles, fileNum)
+	delete(s.fileNumToLRUElem, fileNum)
+}
+
+// mapFile returns the read-only memory mapping of the passed block file,
+// mapping it as needed.  Since block files are only memory-mapped once they are
+// no longer written to, an existing mapping of the file is reused.  Nil is
+// returned when the file can't be mapped in which case it is read as normal.
+func (s *blockStore) mapFile(fileNum uint32, file *os.File) []byte {
+	s.mmapMutex.Lock()
+	defer s.mmapMutex.Unlock()
+
+	if mapped, ok := s.mappedFiles[fileNum]; ok {
+		return mapped
+	}
+
+	fi, err := file.Stat()
+	if err != nil || fi.Size() == 0 {
+		return nil
+	}
+	mapped, err := mmapFile(file, int(fi.Size()))
+	if err != nil {
+		_ = log.Warnf("Failed to memory-map block file %d: %v", fileNum,
+			err)
+		return nil
+	}
+	s.mappedFiles[fileNum] = mapped
+	return mapped
+}
+
+// retireMappings stops reusing the memory mappings of the block files starting
+// at the passed flat file number since they are about to be truncated or
+// deleted.  The mappings themselves are kept until the database is closed
+// since data returned from them might still be referenced.
+func (s *blockStore) retireMappings(fromFileNum uint32) {
+	s.mmapMutex.Lock()
+	for fileNum, mapped := range s.mappedFiles {
+		if fileNum >= fromFileNum {
+			s.retiredMappings = append(s.retiredMappings, mapped)
+			delete(s.mappedFiles, fileNum)
+		}
+	}
+	s.mmapMutex.Unlock()
+}
+
+// unmapFiles removes all of the memory mappings of the block files.
+//
+// This function MUST only be called once there are no references left to any
+// data returned from the mappings, which is the case when the database is
+// closed.
+func (s *blockStore) unmapFiles() {
+	s.mmapMutex.Lock()
+	for fileNum, mapped := range s.mappedFiles {
+		if err := munmapFile(mapped); err != nil {
+			_ = log.Warnf("Failed to unmap block file %d: %v",
+				fileNum, err)
+		}
+	}
+	for _, mapped := range s.retiredMappings {
+		if err := munmapFile(mapped); err != nil {
+			_ = log.Warnf("Failed to unmap retired block file: %v",
+				err)
+		}
+	}
+	s.mappedFiles = make(map[uint32][]byte)
+	s.retiredMappings = nil
+	s.mmapMutex.Unlock()
+}
+
 // deleteFile removes the block file for the passed flat file number.  The file
 // must already be closed and it is the responsibility of the caller to do any
 // other state cleanup necessary.
@@ -516,8 +615,20 @@ func (s *blockStore) readBlock(hash *chainhash.Hash, loc blockLocation) ([]byte,
 		return nil, err
 	}
 
-	serializedData := make([]byte, loc.blockLen)
-	n, err := blockFile.file.ReadAt(serializedData, int64(loc.fileOffset))
+	// Use the memory mapping of the file when there is one.  The block is
+	// read from the file as normal otherwise.
+	var serializedData []byte
+	var n int
+	start, end := uint64(loc.fileOffset), uint64(loc.fileOffset)+
+		uint64(loc.blockLen)
+	if end <= uint64(len(blockFile.mapped)) {
+		serializedData = blockFile.mapped[start:end:end]
+		n = len(serializedData)
+	} else {
+		serializedData = make([]byte, loc.blockLen)
+		n, err = blockFile.file.ReadAt(serializedData,
+			int64(loc.fileOffset))
+	}
 	blockFile.RUnlock()
 	if err != nil {
 		str := fmt.Sprintf("failed to read block %s from file %d, "+
@@ -576,8 +687,18 @@ func (s *blockStore) readBlockRegion(loc blockLocation, offset, numBytes uint32)
 	// data for a block includes an initial 4 bytes for network + 4 bytes
 	// for block length.  Thus, add 8 bytes to adjust.
 	readOffset := loc.fileOffset + 8 + offset
-	serializedData := make([]byte, numBytes)
-	_, err = blockFile.file.ReadAt(serializedData, int64(readOffset))
+
+	// Use the memory mapping of the file when there is one.  The region is
+	// read from the file as normal otherwise.
+	var serializedData []byte
+	end := uint64(readOffset) + uint64(numBytes)
+	if end <= uint64(len(blockFile.mapped)) {
+		serializedData = blockFile.mapped[readOffset:end:end]
+	} else {
+		serializedData = make([]byte, numBytes)
+		_, err = blockFile.file.ReadAt(serializedData,
+			int64(readOffset))
+	}
 	blockFile.RUnlock()
 	if err != nil {
 		str := fmt.Sprintf("failed to read region from block file %d, "+
@@ -643,6 +764,20 @@ func (s *blockStore) syncBlocks() error {
 // Therefore, any errors are simply logged at a warning level rather than being
 // returned since there is nothing more that could be done about it anyways.
 func (s *blockStore) handleRollback(oldBlockFileNum, oldBlockOffset uint32) {
+	// Close any read-only handles of the files which are about to be
+	// truncated or deleted and stop reusing their memory mappings so later
+	// reads don't use stale data once the files are written again.
+	s.obfMutex.Lock()
+	s.lruMutex.Lock()
+	for fileNum := range s.openBlockFiles {
+		if fileNum >= oldBlockFileNum {
+			s.closeFile(fileNum)
+		}
+	}
+	s.lruMutex.Unlock()
+	s.obfMutex.Unlock()
+	s.retireMappings(oldBlockFileNum)
+
 	// Grab the write cursor mutex since it is modified throughout this
 	// function.
 	wc := s.writeCursor
@@ -758,6 +893,7 @@ func newBlockStore(basePath string, network wire.BitcoinNet) *blockStore {
 		openBlockFiles:   make(map[uint32]*lockableFile),
 		openBlocksLRU:    list.New(),
 		fileNumToLRUElem: make(map[uint32]*list.Element),
+		mappedFiles:      make(map[uint32][]byte),
 
 		writeCursor: &writeCursor{
 			curFile:    &lockableFile{},
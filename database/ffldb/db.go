@@ -1998,6 +1998,9 @@ func (db *db) Close() error {
 	db.store.openBlocksLRU.Init()
 	db.store.fileNumToLRUElem = nil
 
+	// Unmap the block files now that all transactions have finished.
+	db.store.unmapFiles()
+
 	return closeErr
 }
 
@@ -2042,7 +2045,7 @@ func initDB(ldb *leveldb.DB) error {
 
 // openDB opens the database at the provided path.  database.ErrDbDoesNotExist
 // is returned if the database doesn't exist and the create flag is not set.
-func openDB(dbPath string, network wire.BitcoinNet, create bool) (database.DB, error) {
+func openDB(dbPath string, network wire.BitcoinNet, create bool, dbOpts Options) (database.DB, error) {
 	// Error if the database doesn't exist and the create flag is not set.
 	metadataDbPath := filepath.Join(dbPath, metadataDbName)
 	dbExists := fileExists(metadataDbPath)
@@ -2077,6 +2080,14 @@ func openDB(dbPath string, network wire.BitcoinNet, create bool) (database.DB, e
 	// database cache which wraps the underlying leveldb database to provide
 	// write caching.
 	store := newBlockStore(dbPath, network)
+	if dbOpts.MmapBlocks {
+		if mmapSupported {
+			store.mmapBlocks = true
+		} else {
+			_ = log.Warnf("Memory-mapped block files are not " +
+				"supported on this platform")
+		}
+	}
 	cache := newDbCache(ldb, store, defaultCacheSize, defaultFlushSecs)
 	pdb := &db{store: store, cache: cache}
 
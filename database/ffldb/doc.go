@@ -27,5 +27,24 @@ database path as a string and the block network:
 	if err != nil {
 		// Handle error
 	}
+
+Memory-Mapped Block Files
+
+An Options value may be passed after the block network to memory-map the flat
+block files:
+
+	db, err := database.Open("ffldb", "path/to/database", wire.MainNet,
+		ffldb.Options{MmapBlocks: true})
+	if err != nil {
+		// Handle error
+	}
+
+The blocks and block regions in the files which are no longer written to are
+then returned as read-only slices of the mappings rather than being copied, so
+serving blocks to peers and reindexing avoid copying the block bytes twice.
+Modifying the returned data causes a fault.  The mappings are kept until the
+database is closed, so the data stays valid for at least the lifetime of the
+transaction it was fetched in as required by the database interface.  Memory
+mapping is only supported on 64-bit unix systems and is ignored elsewhere.
 */
 package ffldb
@@ -20,49 +20,68 @@ const (
 	dbType = "ffldb"
 )
 
+// Options houses the optional settings which may be passed to the database
+// Open and Create methods after the database path and block network.
+type Options struct {
+	// MmapBlocks memory-maps the flat block files which are no longer
+	// written to so fetched blocks and block regions are returned as
+	// read-only slices of the mapped files instead of being copied.  It is
+	// ignored on platforms which do not support memory-mapped files.
+	MmapBlocks bool
+}
+
 // parseArgs parses the arguments from the database Open/Create methods.
-func parseArgs(funcName string, args ...interface{}) (string, wire.BitcoinNet, error) {
+func parseArgs(funcName string, args ...interface{}) (string, wire.BitcoinNet, Options, error) {
+	var opts Options
+	if len(args) == 3 {
+		var ok bool
+		opts, ok = args[2].(Options)
+		if ok {
+			args = args[:2]
+		}
+	}
 	if len(args) != 2 {
-		return "", 0, fmt.Errorf("invalid arguments to %s.%s -- "+
+		return "", 0, opts, fmt.Errorf("invalid arguments to %s.%s -- "+
 			"expected database path and block network", dbType,
 			funcName)
 	}
 
 	dbPath, ok := args[0].(string)
 	if !ok {
-		return "", 0, fmt.Errorf("first argument to %s.%s is invalid -- "+
-			"expected database path string", dbType, funcName)
+		return "", 0, opts, fmt.Errorf("first argument to %s.%s is "+
+			"invalid -- expected database path string", dbType,
+			funcName)
 	}
 
 	network, ok := args[1].(wire.BitcoinNet)
 	if !ok {
-		return "", 0, fmt.Errorf("second argument to %s.%s is invalid -- "+
-			"expected block network", dbType, funcName)
+		return "", 0, opts, fmt.Errorf("second argument to %s.%s is "+
+			"invalid -- expected block network", dbType, funcName)
 	}
 
-	return dbPath, network, nil
+	return dbPath, network, opts, nil
 }
 
 // openDBDriver is the callback provided during driver registration that opens
 // an existing database for use.
 func openDBDriver(args ...interface{}) (database.DB, error) {
-	dbPath, network, err := parseArgs("Open", args...)
+	dbPath, network, opts, err := parseArgs("Open", args...)
 	if err != nil {
 		return nil, err
 	}
 
-	return openDB(dbPath, network, false)
+	return openDB(dbPath, network, false, opts)
 }
 
 // createDBDriver is the callback provided during driver registration that
 // creates, initializes, and opens a database for use.
 func createDBDriver(args ...interface{}) (database.DB, error) {
-	dbPath, network, err := parseArgs("Create", args...)
+	dbPath, network, opts, err := parseArgs("Create", args...)
 	if err != nil {
 		return nil, err
 	}
 
-	return openDB(dbPath, network, true)
+	return openDB(dbPath, network, true, opts)
 }
 
 // useLogger is the callback provided during driver registration that sets the
@@ -0,0 +1,27 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// +build windows plan9
+
+package ffldb
+
+import (
+	"errors"
+	"os"
+)
+
+// mmapSupported indicates whether the block files can be memory-mapped.
+const mmapSupported = false
+
+// mmapFile returns an error since memory-mapped block files are not supported
+// on this platform.
+func mmapFile(file *os.File, size int) ([]byte, error) {
+	return nil, errors.New("memory-mapped files are not supported")
+}
+
+// munmapFile does nothing since memory-mapped block files are not supported on
+// this platform.
+func munmapFile(mapped []byte) error {
+	return nil
+}
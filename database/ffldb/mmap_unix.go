@@ -0,0 +1,30 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// +build !windows,!plan9
+
+package ffldb
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// mmapSupported indicates whether the block files can be memory-mapped.  Only
+// 64-bit systems are supported since the mappings are kept until the database
+// is closed and would otherwise exhaust the address space.
+const mmapSupported = strconv.IntSize == 64
+
+// mmapFile returns a read-only memory mapping of the first size bytes of the
+// passed file.
+func mmapFile(file *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(file.Fd()), 0, size, syscall.PROT_READ,
+		syscall.MAP_SHARED)
+}
+
+// munmapFile removes a memory mapping returned by mmapFile.
+func munmapFile(mapped []byte) error {
+	return syscall.Munmap(mapped)
+}
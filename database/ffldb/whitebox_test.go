@@ -10,6 +10,7 @@
 package ffldb
 
 import (
+	"bytes"
 	"compress/bzip2"
 	"encoding/binary"
 	"fmt"
@@ -181,7 +182,7 @@ func TestCornerCases(t *testing.T) {
 	// directory is needed.
 	testName := "openDB: fail due to file at target location"
 	wantErrCode := database.ErrDriverSpecific
-	idb, err := openDB(dbPath, blockDataNet, true)
+	idb, err := openDB(dbPath, blockDataNet, true, Options{})
 	if !checkDbError(t, testName, err, wantErrCode) {
 		if err == nil {
 			idb.Close()
@@ -193,7 +194,7 @@ func TestCornerCases(t *testing.T) {
 	// Remove the file and create the database to run tests against.  It
 	// should be successful this time.
 	_ = os.RemoveAll(dbPath)
-	idb, err = openDB(dbPath, blockDataNet, true)
+	idb, err = openDB(dbPath, blockDataNet, true, Options{})
 	if err != nil {
 		t.Errorf("openDB: unexpected error: %v", err)
 		return
@@ -706,3 +707,108 @@ func TestFailureScenarios(t *testing.T) {
 	// Test various corruption scenarios.
 	testCorruption(tc)
 }
+
+// TestMmapBlocks ensures blocks in the block files which are no longer written
+// to are returned from their memory mappings and the mappings are kept until
+// the database is closed, even when the files are rolled back.
+func TestMmapBlocks(t *testing.T) {
+	t.Parallel()
+
+	if !mmapSupported {
+		t.Skip("memory-mapped block files are not supported")
+	}
+
+	// Create a new database with small block files so the test blocks span
+	// multiple files.
+	dbPath := filepath.Join(os.TempDir(), "ffldb-mmapblocks")
+	_ = os.RemoveAll(dbPath)
+	idb, err := openDB(dbPath, blockDataNet, true, Options{MmapBlocks: true})
+	if err != nil {
+		t.Fatalf("openDB: unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	defer idb.Close()
+	store := idb.(*db).store
+	store.maxBlockFileSize = 1024 // 1KiB
+
+	var blocks []*provautil.Block
+	for i := uint32(0); i < 10; i++ {
+		tx := wire.NewMsgTx(wire.TxVersion)
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: wire.OutPoint{Index: wire.MaxPrevOutIndex},
+		})
+		tx.AddTxOut(wire.NewTxOut(0, make([]byte, 300)))
+		msgBlock := wire.NewMsgBlock(&wire.BlockHeader{Height: i})
+		msgBlock.AddTransaction(tx)
+		blocks = append(blocks, provautil.NewBlock(msgBlock))
+	}
+	err = idb.Update(func(tx database.Tx) error {
+		for _, block := range blocks {
+			if err := tx.StoreBlock(block); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StoreBlock: unexpected error: %v", err)
+	}
+
+	// The blocks in the files which are no longer written to must be
+	// returned from the mappings while the last one is read from the
+	// current write file.
+	var fetched [][]byte
+	err = idb.View(func(tx database.Tx) error {
+		for i, block := range blocks {
+			blockBytes, err := tx.FetchBlock(block.Hash())
+			if err != nil {
+				return err
+			}
+			wantBytes, _ := block.Bytes()
+			if !bytes.Equal(blockBytes, wantBytes) {
+				return fmt.Errorf("block #%d mismatch", i)
+			}
+			fetched = append(fetched, blockBytes)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("FetchBlock: unexpected error: %v", err)
+	}
+	lastFileNum := store.writeCursor.curFileNum
+	if len(store.mappedFiles) == 0 ||
+		len(store.mappedFiles) != len(store.openBlockFiles) {
+
+		t.Fatalf("got %d mapped files for %d open files",
+			len(store.mappedFiles), len(store.openBlockFiles))
+	}
+	for fileNum, blockFile := range store.openBlockFiles {
+		if fileNum == lastFileNum || blockFile.mapped == nil {
+			t.Fatalf("file %d is not mapped as expected", fileNum)
+		}
+	}
+
+	// Rolling the files back must close the rolled back files and retire
+	// their mappings while keeping the data before the rollback point
+	// valid.
+	store.handleRollback(1, 0)
+	if len(store.openBlockFiles) != 1 || len(store.mappedFiles) != 1 ||
+		len(store.retiredMappings) == 0 {
+
+		t.Fatalf("got %d open files, %d mapped files and %d retired "+
+			"mappings after rollback", len(store.openBlockFiles),
+			len(store.mappedFiles), len(store.retiredMappings))
+	}
+	wantBytes, _ := blocks[0].Bytes()
+	if !bytes.Equal(fetched[0], wantBytes) {
+		t.Fatalf("block #0 mismatch after rollback")
+	}
+
+	// Closing the database removes all of the mappings.
+	idb.Close()
+	if len(store.mappedFiles) != 0 || len(store.retiredMappings) != 0 {
+		t.Fatalf("got %d mapped files and %d retired mappings after "+
+			"close", len(store.mappedFiles),
+			len(store.retiredMappings))
+	}
+}
@@ -269,7 +269,8 @@ type Tx interface {
 	//
 	// NOTE: The data returned by this function is only valid during a
 	// database transaction.  Attempting to access it after a transaction
-	// has ended results in undefined behavior.  This constraint prevents
+	// has ended results in undefined behavior.  Additionally, the data
+	// must NOT be modified by the caller.  These constraints prevent
 	// additional data copies and allows support for memory-mapped database
 	// implementations.
 	FetchBlockHeader(hash *chainhash.Hash) ([]byte, error)
@@ -296,7 +297,8 @@ type Tx interface {
 	//
 	// NOTE: The data returned by this function is only valid during a
 	// database transaction.  Attempting to access it after a transaction
-	// has ended results in undefined behavior.  This constraint prevents
+	// has ended results in undefined behavior.  Additionally, the data
+	// must NOT be modified by the caller.  These constraints prevent
 	// additional data copies and allows support for memory-mapped database
 	// implementations.
 	FetchBlockHeaders(hashes []chainhash.Hash) ([][]byte, error)
@@ -313,7 +315,8 @@ type Tx interface {
 	//
 	// NOTE: The data returned by this function is only valid during a
 	// database transaction.  Attempting to access it after a transaction
-	// has ended results in undefined behavior.  This constraint prevents
+	// has ended results in undefined behavior.  Additionally, the data
+	// must NOT be modified by the caller.  These constraints prevent
 	// additional data copies and allows support for memory-mapped database
 	// implementations.
 	FetchBlock(hash *chainhash.Hash) ([]byte, error)
@@ -331,7 +334,8 @@ type Tx interface {
 	//
 	// NOTE: The data returned by this function is only valid during a
 	// database transaction.  Attempting to access it after a transaction
-	// has ended results in undefined behavior.  This constraint prevents
+	// has ended results in undefined behavior.  Additionally, the data
+	// must NOT be modified by the caller.  These constraints prevent
 	// additional data copies and allows support for memory-mapped database
 	// implementations.
 	FetchBlocks(hashes []chainhash.Hash) ([][]byte, error)
@@ -358,7 +362,8 @@ type Tx interface {
 	//
 	// NOTE: The data returned by this function is only valid during a
 	// database transaction.  Attempting to access it after a transaction
-	// has ended results in undefined behavior.  This constraint prevents
+	// has ended results in undefined behavior.  Additionally, the data
+	// must NOT be modified by the caller.  These constraints prevent
 	// additional data copies and allows support for memory-mapped database
 	// implementations.
 	FetchBlockRegion(region *BlockRegion) ([]byte, error)
@@ -386,7 +391,8 @@ type Tx interface {
 	//
 	// NOTE: The data returned by this function is only valid during a
 	// database transaction.  Attempting to access it after a transaction
-	// has ended results in undefined behavior.  This constraint prevents
+	// has ended results in undefined behavior.  Additionally, the data
+	// must NOT be modified by the caller.  These constraints prevent
 	// additional data copies and allows support for memory-mapped database
 	// implementations.
 	FetchBlockRegions(regions []BlockRegion) ([][]byte, error)
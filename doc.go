@@ -27,6 +27,24 @@ Application Options:
   -C, --configfile=         Path to configuration file
   -b, --datadir=            Directory to store data
       --logdir=             Directory to log output.
+      --auditlog=           Append a tamper-evident audit log of admin
+                            transactions, key set changes, issuances, reorgs of
+                            admin transactions, changes to the validate keys
+                            and changes to the block template exclusions to the
+                            specified file
+      --eventpublisher=     Publish the block connected and disconnected,
+                            transaction confirmed and admin operation applied
+                            events of the main chain to the JetStream streams
+                            of the NATS server at the specified URL
+                            (nats://[user:pass@]host:port)
+      --eventsubject=       Subject prefix of the published events (default:
+                            dmgd)
+      --sqlexport=          Mirror the blocks, transactions, outputs, admin
+                            operations and key changes of the main chain into
+                            the PostgreSQL database with the specified data
+                            source name
+      --sqlexportdriver=    Name of the database/sql driver used to connect to
+                            the SQL export database (default: postgres)
   -a, --addpeer=            Add a peer to connect with at startup
       --connect=            Connect only to the specified peers at startup
       --nolisten            Disable listening for incoming connections -- NOTE:
@@ -36,15 +54,36 @@ Application Options:
       --listen=             Add an interface/port to listen for connections
                             (default all interfaces port: 6464, testnet: 16464)
       --maxpeers=           Max number of inbound and outbound peers (125)
+      --validatorpeer=      Add a validator peer to keep outbound connections to
+      --validatoroutbound=  Number of outbound connections to keep to the
+                            validator peers (2)
+      --archivaloutbound=   Number of outbound connections to keep to archival
+                            peers serving the full block chain (2)
+      --explorerpeer=       Add an explorer-facing peer to keep outbound
+                            connections to
+      --exploreroutbound=   Number of outbound connections to keep to the
+                            explorer-facing peers (1)
       --nobanning           Disable banning of misbehaving peers
       --banthreshold=       Maximum allowed ban score before disconnecting and
                             banning misbehaving peers.
       --banduration=        How long to ban misbehaving peers.  Valid time units
                             are {s, m, h}.  Minimum 1 second (24h0m0s)
+      --whitelist=          Add an IP network or IP that will not be banned.
+                            (eg. 192.168.1.0/24 or ::1)
   -u, --rpcuser=            Username for RPC connections
   -P, --rpcpass=            Password for RPC connections
       --rpclimituser=       Username for limited RPC connections
       --rpclimitpass=       Password for limited RPC connections
+      --rpcauth=            Add RPC credentials allowed to call only the listed
+                            methods (<user>:<password>:<method>,<method>,...
+                            -- limited allows the methods of the limited user,
+                            * allows all methods)
+      --rpccookie           Write randomly generated admin RPC credentials to
+                            the .cookie file in the data directory at startup
+                            for use by local clients
+      --rpcunixsocket=      Also serve RPC without TLS on a Unix domain socket
+                            at the specified path which only the user running
+                            dmgd may access
       --rpclisten=          Add an interface/port to listen for RPC connections
                             (default port: 8334, testnet: 18334)
       --rpccert=            File containing the certificate file
@@ -52,6 +91,9 @@ Application Options:
       --rpcmaxclients=      Max number of RPC clients for standard connections
                             (10)
       --rpcmaxwebsockets=   Max number of RPC websocket connections (25)
+      --rpcslowcall=        Log RPC calls which take longer than this and report
+                            them in getrpcinfo.  Valid time units are {ms, s,
+                            m}.  0 disables slow call tracking (1s)
       --rpcquirks           Mirror some JSON-RPC quirks of Bitcoin Core -- NOTE:
                             Discouraged unless interoperability issues need to
                             be worked around
@@ -80,6 +122,23 @@ Application Options:
       --nocheckpoints       Disable built-in checkpoints.  Don't do this unless
                             you know what you're doing.
       --dbtype=             Database backend to use for the Block Chain (ffldb)
+      --dbcache=            The maximum size in MiB of the cache of unspent
+                            transaction outputs, which is flushed to the
+                            database when full.  0 writes them to the database
+                            with every block (250)
+      --mmapblocks          Memory-map the block files to serve and reindex
+                            blocks without copying them -- Only supported by
+                            the ffldb database on 64-bit unix systems
+      --checkdb=            Verify the database on start up at the given
+                            level: 1 checks the block files and block index, 2
+                            also checks the optional indexes and 3 also
+                            replays the admin transactions to check the admin
+                            key state
+      --reindex             Rebuild the chain state and all optional indexes
+                            from the blocks stored in the database on start up
+      --reindexchainstate   Rebuild the chain state from the blocks stored in
+                            the database on start up, keeping the optional
+                            indexes
       --profile=            Enable HTTP profiling on given port -- NOTE port
                             must be between 1024 and 65536
       --cpuprofile=         Write CPU profile to the specified file
@@ -98,6 +157,18 @@ Application Options:
                             high priority for relaying
       --maxorphantx=        Max number of orphan transactions to keep in memory
                             (100)
+      --dustrelayfee=       The fee rate in DMG/kB used to determine whether a
+                            transaction output is dust (0 uses minrelaytxfee)
+      --maxstdtxsize=       Max size in bytes of a transaction to be considered
+                            standard (0 uses the default for the active
+                            network)
+      --maxstdsigscriptsize= Max size in bytes of a transaction input
+                            signature script to be considered standard (0
+                            uses the default for the active network)
+      --minadmintxconfs=    Hold admin transactions which spend a thread tip
+                            with fewer than the given number of confirmations
+                            until it has them instead of accepting and
+                            relaying them (0 to accept them right away)
       --generate            Generate (mine) blocks using the CPU
       --miningaddr=         Add the specified payment address to the list of
                             addresses to use for generated blocks -- At least
@@ -112,11 +183,18 @@ Application Options:
       --nopeerbloomfilters  Disable bloom filtering support.
       --sigcachemaxsize=    The maximum number of entries in the signature
                             verification cache.
-      --blocksonly          Do not accept transactions from remote peers.
+      --blocksonly          Do not accept transactions other than admin
+                            transactions from remote peers.
+      --maxuploadtarget=    Maximum number of MiB to send to peers per 24 hour
+                            cycle, after which blocks older than a week are only
+                            served to whitelisted peers (0 for no limit)
       --relaynonstd         Relay non-standard transactions regardless of the
                             default settings for the active network.
       --rejectnonstd        Reject non-standard transactions regardless of the
                             default settings for the active network.
+      --relayviolations     Broadcast the evidence of validate keys which signed
+                            two blocks at the same height to peers in alert
+                            messages, and relay the evidence received from peers.
       --enableexternalrpc   Enable RPC listening on external interfaces.
 
 Help Options:
@@ -0,0 +1,382 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package eventbridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+)
+
+// Event types published by the bridge.
+const (
+	// EventBlockConnected is published for every block connected to the
+	// main chain, before the events of its transactions.
+	EventBlockConnected = "blockconnected"
+
+	// EventBlockDisconnected is published for every block disconnected
+	// from the main chain.  The events published for it when it was
+	// connected no longer apply.
+	EventBlockDisconnected = "blockdisconnected"
+
+	// EventTxConfirmed is published for every transaction of a block
+	// connected to the main chain.
+	EventTxConfirmed = "txconfirmed"
+
+	// EventAdminOpApplied is published for every admin operation of an
+	// admin transaction of a block connected to the main chain.
+	EventAdminOpApplied = "adminopapplied"
+)
+
+// defaultRetryInterval is the interval at which publishing the events is
+// retried after it failed.
+const defaultRetryInterval = 10 * time.Second
+
+// Event is a canonical event of the block chain.  Its ID is derived from the
+// hash of the block it belongs to, so publishing the events of a block again
+// after a failure or a restart yields the same IDs, which allows brokers and
+// consumers to drop the duplicates.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	BlockHash string          `json:"blockhash"`
+	Height    uint32          `json:"height"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// BlockData describes a block connected to or disconnected from the main
+// chain.
+type BlockData struct {
+	Hash     string `json:"hash"`
+	PrevHash string `json:"prevhash"`
+	Height   uint32 `json:"height"`
+	Time     int64  `json:"time"`
+	NumTx    int    `json:"numtx"`
+}
+
+// TxData describes a transaction confirmed by a block.
+type TxData struct {
+	TxID  string `json:"txid"`
+	Index int    `json:"index"`
+}
+
+// AdminOpData describes an admin operation applied by a block.
+type AdminOpData struct {
+	TxID   string `json:"txid"`
+	Thread uint32 `json:"thread"`
+	Op     string `json:"op"`
+}
+
+// Publisher publishes events to a message broker.
+type Publisher interface {
+	// Publish publishes the passed events in order and only returns
+	// without an error once the broker acknowledged all of them.
+	Publish(events []*Event) error
+
+	// Close closes the connection to the broker.
+	Close() error
+}
+
+// Chain provides the blocks of the main chain to the bridge.  It is
+// implemented by blockchain.BlockChain.
+type Chain interface {
+	BestSnapshot() *blockchain.BestState
+	BlockByHash(hash *chainhash.Hash) (*provautil.Block, error)
+	BlockByHeight(height uint32) (*provautil.Block, error)
+	MainChainHasBlock(hash *chainhash.Hash) (bool, error)
+}
+
+// Config is a descriptor containing the configuration of a bridge.
+type Config struct {
+	// Chain is the block chain whose events are published.
+	Chain Chain
+
+	// Publisher publishes the events to the broker.
+	Publisher Publisher
+
+	// CursorFile is the file which records the hash of the last block
+	// whose events were acknowledged by the broker, so publishing resumes
+	// after it on the next start.
+	CursorFile string
+
+	// RetryInterval is the interval at which publishing is retried after
+	// it failed.  It defaults to 10 seconds.
+	RetryInterval time.Duration
+}
+
+// cursor is the position of the bridge in the block chain, which is the last
+// block whose events were acknowledged.
+type cursor struct {
+	Hash   chainhash.Hash `json:"hash"`
+	Height uint32         `json:"height"`
+}
+
+// Bridge publishes the canonical events of the main chain to a message broker
+// with at-least-once delivery.  It follows the main chain from the last block
+// whose events were acknowledged, which it records in its cursor file, so the
+// events missed while the broker was unavailable or the node was stopped are
+// published once it is available again.  The events of a block are only
+// acknowledged as a whole, so after a failure all of them are published again.
+type Bridge struct {
+	cfg    Config
+	cursor *cursor
+	notify chan struct{}
+	quit   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New returns a bridge with the passed configuration, which resumes after the
+// block recorded in its cursor file.  Without a cursor file, it starts with
+// the genesis block.
+func New(cfg *Config) (*Bridge, error) {
+	b := &Bridge{
+		cfg:    *cfg,
+		notify: make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+	}
+	if b.cfg.RetryInterval == 0 {
+		b.cfg.RetryInterval = defaultRetryInterval
+	}
+
+	serialized, err := ioutil.ReadFile(cfg.CursorFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		var c cursor
+		if err := json.Unmarshal(serialized, &c); err != nil {
+			return nil, fmt.Errorf("cursor file %s: %v",
+				cfg.CursorFile, err)
+		}
+		b.cursor = &c
+	}
+	return b, nil
+}
+
+// Start begins publishing the events of the main chain.
+func (b *Bridge) Start() {
+	b.wg.Add(1)
+	go b.handler()
+}
+
+// Stop stops publishing events and closes the publisher.
+func (b *Bridge) Stop() error {
+	close(b.quit)
+	b.wg.Wait()
+	return b.cfg.Publisher.Close()
+}
+
+// Notify wakes the bridge up to publish the events of the blocks connected to
+// or disconnected from the main chain.  It does not block.
+//
+// This function is safe for concurrent access.
+func (b *Bridge) Notify() {
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+}
+
+// handler publishes the events of the main chain each time the bridge is
+// notified and retries publishing them after a failure.  It must be run as a
+// goroutine.
+func (b *Bridge) handler() {
+	defer b.wg.Done()
+
+	retry := time.NewTimer(0)
+	defer retry.Stop()
+	for {
+		select {
+		case <-b.notify:
+		case <-retry.C:
+		case <-b.quit:
+			return
+		}
+
+		if err := b.catchUp(); err != nil {
+			log.Errorf("Unable to publish events: %v", err)
+			retry.Reset(b.cfg.RetryInterval)
+		}
+	}
+}
+
+// catchUp publishes the events of the blocks disconnected from and connected
+// to the main chain since the cursor, one block at a time, and advances the
+// cursor after each block.  It returns once the cursor is at the tip of the
+// main chain or the bridge is stopped.
+func (b *Bridge) catchUp() error {
+	for {
+		select {
+		case <-b.quit:
+			return nil
+		default:
+		}
+
+		// Walk back from a cursor which is no longer in the main chain
+		// after a reorganization.
+		if b.cursor != nil {
+			inMainChain, err := b.cfg.Chain.MainChainHasBlock(
+				&b.cursor.Hash)
+			if err != nil {
+				return err
+			}
+			if !inMainChain {
+				block, err := b.cfg.Chain.BlockByHash(&b.cursor.Hash)
+				if err != nil {
+					return err
+				}
+				err = b.publish(block, disconnectedEvents(block))
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		height := uint32(0)
+		if b.cursor != nil {
+			if b.cursor.Height >= b.cfg.Chain.BestSnapshot().Height {
+				return nil
+			}
+			height = b.cursor.Height + 1
+		}
+		block, err := b.cfg.Chain.BlockByHeight(height)
+		if err != nil {
+			return err
+		}
+
+		// The main chain may have been reorganized since the cursor was
+		// found in it, in which case the block does not extend it.
+		prevHash := &block.MsgBlock().Header.PrevBlock
+		if b.cursor != nil && !prevHash.IsEqual(&b.cursor.Hash) {
+			continue
+		}
+		events, err := connectedEvents(block)
+		if err != nil {
+			return err
+		}
+		if err := b.publish(block, events); err != nil {
+			return err
+		}
+	}
+}
+
+// publish publishes the passed events of the passed block and moves the
+// cursor to the block when it was connected, or to its parent when it was
+// disconnected.
+func (b *Bridge) publish(block *provautil.Block, events []*Event) error {
+	if err := b.cfg.Publisher.Publish(events); err != nil {
+		return err
+	}
+
+	header := &block.MsgBlock().Header
+	next := &cursor{Hash: *block.Hash(), Height: header.Height}
+	if events[0].Type == EventBlockDisconnected {
+		next = &cursor{Hash: header.PrevBlock, Height: header.Height - 1}
+	}
+	if err := writeCursor(b.cfg.CursorFile, next); err != nil {
+		return err
+	}
+	b.cursor = next
+	return nil
+}
+
+// writeCursor atomically replaces the cursor file at the passed path with the
+// passed cursor.
+func writeCursor(path string, c *cursor) error {
+	serialized, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, serialized, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// newEvent returns the event of the passed type with the passed data, which is
+// the passed nth event of the passed block.
+func newEvent(eventType string, block *provautil.Block, n int, data interface{}) (*Event, error) {
+	serializedData, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{
+		ID:        fmt.Sprintf("%s:%s:%d", block.Hash(), eventType, n),
+		Type:      eventType,
+		BlockHash: block.Hash().String(),
+		Height:    block.MsgBlock().Header.Height,
+		Data:      serializedData,
+	}, nil
+}
+
+// blockData returns the description of the passed block.
+func blockData(block *provautil.Block) *BlockData {
+	header := &block.MsgBlock().Header
+	return &BlockData{
+		Hash:     block.Hash().String(),
+		PrevHash: header.PrevBlock.String(),
+		Height:   header.Height,
+		Time:     header.Timestamp.Unix(),
+		NumTx:    len(block.Transactions()),
+	}
+}
+
+// connectedEvents returns the events of the passed block connected to the main
+// chain: the block itself, followed by each of its transactions and the admin
+// operations they apply.
+func connectedEvents(block *provautil.Block) ([]*Event, error) {
+	event, err := newEvent(EventBlockConnected, block, 0, blockData(block))
+	if err != nil {
+		return nil, err
+	}
+	events := []*Event{event}
+	for i, tx := range block.Transactions() {
+		event, err := newEvent(EventTxConfirmed, block, len(events),
+			&TxData{TxID: tx.Hash().String(), Index: i})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+
+		// Issue thread transactions issue or destroy coins instead of
+		// carrying admin operations.
+		threadInt, _ := txscript.GetAdminDetails(tx)
+		threadID := provautil.ThreadID(threadInt)
+		if threadInt < 0 || threadID == provautil.IssueThread {
+			continue
+		}
+		for _, txOut := range tx.MsgTx().TxOut[1:] {
+			event, err := newEvent(EventAdminOpApplied, block,
+				len(events), &AdminOpData{
+					TxID:   tx.Hash().String(),
+					Thread: uint32(threadID),
+					Op:     txscript.AdminOpString(txOut.PkScript),
+				})
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// disconnectedEvents returns the event of the passed block disconnected from
+// the main chain.
+func disconnectedEvents(block *provautil.Block) []*Event {
+	// The block data always serializes.
+	event, _ := newEvent(EventBlockDisconnected, block, 0, blockData(block))
+	return []*Event{event}
+}
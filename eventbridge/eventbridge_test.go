@@ -0,0 +1,251 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package eventbridge_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/eventbridge"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// fakeChain is a main chain of blocks which can be reorganized.
+type fakeChain struct {
+	mtx    sync.Mutex
+	main   []*provautil.Block
+	blocks map[chainhash.Hash]*provautil.Block
+}
+
+// extend connects a block with the passed transactions on top of the passed
+// parent, which disconnects the main chain blocks after the parent.
+func (c *fakeChain) extend(parent *provautil.Block, nonce uint32, txs ...*wire.MsgTx) *provautil.Block {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	header := &wire.BlockHeader{Nonce: uint64(nonce)}
+	if parent != nil {
+		header.PrevBlock = *parent.Hash()
+		header.Height = parent.MsgBlock().Header.Height + 1
+	}
+	msgBlock := wire.NewMsgBlock(header)
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(&wire.TxIn{Sequence: nonce})
+	coinbase.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_TRUE}))
+	msgBlock.AddTransaction(coinbase)
+	for _, tx := range txs {
+		msgBlock.AddTransaction(tx)
+	}
+	block := provautil.NewBlock(msgBlock)
+
+	c.main = append(c.main[:header.Height], block)
+	c.blocks[*block.Hash()] = block
+	return block
+}
+
+func (c *fakeChain) BestSnapshot() *blockchain.BestState {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return &blockchain.BestState{Height: uint32(len(c.main) - 1)}
+}
+
+func (c *fakeChain) BlockByHash(hash *chainhash.Hash) (*provautil.Block, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if block, ok := c.blocks[*hash]; ok {
+		return block, nil
+	}
+	return nil, errors.New("unknown block")
+}
+
+func (c *fakeChain) BlockByHeight(height uint32) (*provautil.Block, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if int(height) >= len(c.main) {
+		return nil, errors.New("height beyond the main chain")
+	}
+	return c.main[height], nil
+}
+
+func (c *fakeChain) MainChainHasBlock(hash *chainhash.Hash) (bool, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for _, block := range c.main {
+		if block.Hash().IsEqual(hash) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakePublisher records the published events, and fails the requested number
+// of publishes first.
+type fakePublisher struct {
+	mtx      sync.Mutex
+	events   []*eventbridge.Event
+	failures int
+}
+
+func (p *fakePublisher) Publish(events []*eventbridge.Event) error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if p.failures > 0 {
+		p.failures--
+		return errors.New("broker unavailable")
+	}
+	p.events = append(p.events, events...)
+	return nil
+}
+
+func (p *fakePublisher) Close() error {
+	return nil
+}
+
+// waitEvents waits until the publisher recorded the passed number of events
+// and returns their types and block hashes.
+func (p *fakePublisher) waitEvents(t *testing.T, n int) ([]string, []string) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		p.mtx.Lock()
+		numEvents := len(p.events)
+		if numEvents >= n {
+			var types, hashes []string
+			for _, event := range p.events {
+				types = append(types, event.Type)
+				hashes = append(hashes, event.BlockHash)
+			}
+			p.mtx.Unlock()
+			return types, hashes
+		}
+		p.mtx.Unlock()
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d events, want %d", numEvents, n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestBridge ensures the bridge publishes the events of the main chain in
+// order, resumes after the block in its cursor file and retries publishing
+// after a failure, and that the blocks disconnected by a reorganization while
+// it was stopped are reported.
+func TestBridge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eventbridge")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	cursorFile := filepath.Join(dir, "cursor")
+
+	// The first block after the genesis block holds a root thread
+	// transaction provisioning a key.
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("NewPrivateKey: %v", err)
+	}
+	opData := append([]byte{txscript.AdminOpProvisionKeyAdd},
+		privKey.PubKey().SerializeCompressed()...)
+	opScript, err := txscript.NullDataScript(opData)
+	if err != nil {
+		t.Fatalf("NullDataScript: %v", err)
+	}
+	threadScript, err := txscript.ProvaThreadScript(provautil.RootThread)
+	if err != nil {
+		t.Fatalf("ProvaThreadScript: %v", err)
+	}
+	adminTx := wire.NewMsgTx(wire.TxVersion)
+	adminTx.AddTxIn(&wire.TxIn{})
+	adminTx.AddTxOut(wire.NewTxOut(0, threadScript))
+	adminTx.AddTxOut(wire.NewTxOut(0, opScript))
+
+	chain := &fakeChain{blocks: make(map[chainhash.Hash]*provautil.Block)}
+	genesis := chain.extend(nil, 0)
+	block1 := chain.extend(genesis, 1, adminTx)
+
+	publisher := &fakePublisher{failures: 1}
+	config := &eventbridge.Config{
+		Chain:         chain,
+		Publisher:     publisher,
+		CursorFile:    cursorFile,
+		RetryInterval: 10 * time.Millisecond,
+	}
+	bridge, err := eventbridge.New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	bridge.Start()
+	types, hashes := publisher.waitEvents(t, 6)
+	if err := bridge.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	wantTypes := []string{
+		eventbridge.EventBlockConnected, eventbridge.EventTxConfirmed,
+		eventbridge.EventBlockConnected, eventbridge.EventTxConfirmed,
+		eventbridge.EventTxConfirmed, eventbridge.EventAdminOpApplied,
+	}
+	wantHashes := []string{
+		genesis.Hash().String(), genesis.Hash().String(),
+		block1.Hash().String(), block1.Hash().String(),
+		block1.Hash().String(), block1.Hash().String(),
+	}
+	if len(types) != len(wantTypes) {
+		t.Fatalf("got events %v, want %v", types, wantTypes)
+	}
+	for i := range types {
+		if types[i] != wantTypes[i] || hashes[i] != wantHashes[i] {
+			t.Fatalf("got event #%d %s of block %s, want %s of "+
+				"block %s", i, types[i], hashes[i], wantTypes[i],
+				wantHashes[i])
+		}
+	}
+
+	// Reorganize the chain while the bridge is stopped.  The new bridge
+	// resumes after the block in the cursor file, which it reports as
+	// disconnected before publishing the new main chain blocks.
+	block1b := chain.extend(genesis, 2)
+	block2b := chain.extend(block1b, 3)
+	publisher = &fakePublisher{}
+	config.Publisher = publisher
+	bridge, err = eventbridge.New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	bridge.Start()
+	bridge.Notify()
+	types, hashes = publisher.waitEvents(t, 5)
+	if err := bridge.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	wantTypes = []string{
+		eventbridge.EventBlockDisconnected,
+		eventbridge.EventBlockConnected, eventbridge.EventTxConfirmed,
+		eventbridge.EventBlockConnected, eventbridge.EventTxConfirmed,
+	}
+	wantHashes = []string{
+		block1.Hash().String(),
+		block1b.Hash().String(), block1b.Hash().String(),
+		block2b.Hash().String(), block2b.Hash().String(),
+	}
+	if len(types) != len(wantTypes) {
+		t.Fatalf("got events %v, want %v", types, wantTypes)
+	}
+	for i := range types {
+		if types[i] != wantTypes[i] || hashes[i] != wantHashes[i] {
+			t.Fatalf("got event #%d %s of block %s, want %s of "+
+				"block %s", i, types[i], hashes[i], wantTypes[i],
+				wantHashes[i])
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package eventbridge_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/eventbridge"
+)
+
+// fakeNATSServer is a NATS server with a JetStream stream which captures all
+// subjects but those of disconnected blocks.  It records the subjects and the
+// message IDs of the published messages.
+type fakeNATSServer struct {
+	listener net.Listener
+	mtx      sync.Mutex
+	subjects []string
+	msgIDs   []string
+}
+
+// serve accepts connections until the listener is closed.
+func (s *fakeNATSServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle answers the protocol messages of the passed connection.
+func (s *fakeNATSServer) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	io.WriteString(conn, "INFO {\"headers\":true}\r\n")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "PING":
+			io.WriteString(conn, "PONG\r\n")
+
+		case "HPUB":
+			headerSize, _ := strconv.Atoi(fields[3])
+			size, _ := strconv.Atoi(fields[4])
+			data := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, data); err != nil {
+				return
+			}
+			subject, reply := fields[1], fields[2]
+			header := string(data[:headerSize])
+			msgID := strings.SplitN(header, "Nats-Msg-Id: ", 2)[1]
+			msgID = strings.SplitN(msgID, "\r\n", 2)[0]
+
+			if strings.HasSuffix(subject, "blockdisconnected") {
+				status := "NATS/1.0 503\r\n\r\n"
+				fmt.Fprintf(conn, "HMSG %s 1 %d %d\r\n%s\r\n",
+					reply, len(status), len(status), status)
+				continue
+			}
+			s.mtx.Lock()
+			s.subjects = append(s.subjects, subject)
+			s.msgIDs = append(s.msgIDs, msgID)
+			ack := fmt.Sprintf("{\"stream\":\"dmgd\",\"seq\":%d}",
+				len(s.subjects))
+			s.mtx.Unlock()
+			fmt.Fprintf(conn, "MSG %s 1 %d\r\n%s\r\n", reply, len(ack),
+				ack)
+		}
+	}
+}
+
+// TestNATSPublisher ensures events are published to the subjects of their
+// types with their IDs as message IDs, that publishing fails when no stream
+// acknowledges an event and that the publisher reconnects afterwards.
+func TestNATSPublisher(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	server := &fakeNATSServer{listener: listener}
+	go server.serve()
+	defer listener.Close()
+
+	publisher, err := eventbridge.NewNATSPublisher(
+		"nats://"+listener.Addr().String(), "dmgd", 5*time.Second)
+	if err != nil {
+		t.Fatalf("NewNATSPublisher: %v", err)
+	}
+	defer publisher.Close()
+
+	connected := []*eventbridge.Event{
+		{ID: "a:blockconnected:0", Type: eventbridge.EventBlockConnected},
+		{ID: "a:txconfirmed:1", Type: eventbridge.EventTxConfirmed},
+	}
+	if err := publisher.Publish(connected); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	disconnected := []*eventbridge.Event{
+		{ID: "a:blockdisconnected:0", Type: eventbridge.EventBlockDisconnected},
+	}
+	if err := publisher.Publish(disconnected); err == nil {
+		t.Fatalf("Publish: no error for unacknowledged event")
+	}
+	if err := publisher.Publish(connected[1:]); err != nil {
+		t.Fatalf("Publish after failure: %v", err)
+	}
+
+	server.mtx.Lock()
+	defer server.mtx.Unlock()
+	wantSubjects := []string{"dmgd.blockconnected", "dmgd.txconfirmed",
+		"dmgd.txconfirmed"}
+	wantMsgIDs := []string{"a:blockconnected:0", "a:txconfirmed:1",
+		"a:txconfirmed:1"}
+	if len(server.subjects) != len(wantSubjects) {
+		t.Fatalf("got subjects %v, want %v", server.subjects,
+			wantSubjects)
+	}
+	for i := range wantSubjects {
+		if server.subjects[i] != wantSubjects[i] ||
+			server.msgIDs[i] != wantMsgIDs[i] {
+
+			t.Fatalf("got message #%d %s on %s, want %s on %s", i,
+				server.msgIDs[i], server.subjects[i], wantMsgIDs[i],
+				wantSubjects[i])
+		}
+	}
+}
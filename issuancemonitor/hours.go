@@ -0,0 +1,126 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package issuancemonitor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// weekdayNames maps the abbreviated names of the days of the week to them.
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// BusinessHours are the times of the week issuance is expected: the same span
+// of the day on each of a set of days of the week, in a time zone.
+type BusinessHours struct {
+	Weekdays [7]bool
+	Start    time.Duration
+	End      time.Duration
+	Location *time.Location
+
+	spec string
+}
+
+// ParseBusinessHours parses business hours of the form
+// <days> <start>-<end> [<location>], such as "mon-fri 09:00-17:00 Europe/Berlin".
+// The days are a comma separated list of abbreviated day names and ranges of
+// them, the start and end are times of the day with the end after the start,
+// and the location is the name of a time zone, which defaults to UTC.
+func ParseBusinessHours(s string) (*BusinessHours, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 && len(fields) != 3 {
+		return nil, fmt.Errorf("business hours %q are not of the form "+
+			"<days> <start>-<end> [<location>]", s)
+	}
+	hours := &BusinessHours{Location: time.UTC, spec: s}
+
+	for _, days := range strings.Split(strings.ToLower(fields[0]), ",") {
+		bounds := strings.Split(days, "-")
+		if len(bounds) > 2 {
+			return nil, fmt.Errorf("business hours %q have an "+
+				"invalid range of days %q", s, days)
+		}
+		first, ok := weekdayNames[bounds[0]]
+		last := first
+		if ok && len(bounds) == 2 {
+			last, ok = weekdayNames[bounds[1]]
+		}
+		if !ok {
+			return nil, fmt.Errorf("business hours %q have an "+
+				"invalid day %q", s, days)
+		}
+		for day := first; ; day = (day + 1) % 7 {
+			hours.Weekdays[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+
+	span := strings.Split(fields[1], "-")
+	if len(span) != 2 {
+		return nil, fmt.Errorf("business hours %q have an invalid "+
+			"span of the day %q", s, fields[1])
+	}
+	var err error
+	if hours.Start, err = parseTimeOfDay(span[0]); err != nil {
+		return nil, fmt.Errorf("business hours %q: %v", s, err)
+	}
+	if hours.End, err = parseTimeOfDay(span[1]); err != nil {
+		return nil, fmt.Errorf("business hours %q: %v", s, err)
+	}
+	if hours.End <= hours.Start {
+		return nil, fmt.Errorf("business hours %q end before they "+
+			"start", s)
+	}
+
+	if len(fields) == 3 {
+		hours.Location, err = time.LoadLocation(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("business hours %q: %v", s, err)
+		}
+	}
+	return hours, nil
+}
+
+// parseTimeOfDay parses a time of the day of the form HH:MM and returns the
+// time since midnight.  24:00 is the end of the day.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	var hour, minute int
+	_, err := fmt.Sscanf(s, "%d:%d", &hour, &minute)
+	if err != nil || len(s) != 5 || hour < 0 || minute < 0 ||
+		minute > 59 || hour*60+minute > 24*60 {
+
+		return 0, fmt.Errorf("invalid time of the day %q", s)
+	}
+	return time.Duration(hour)*time.Hour +
+		time.Duration(minute)*time.Minute, nil
+}
+
+// Contains returns whether the passed time is within the business hours.
+func (h *BusinessHours) Contains(t time.Time) bool {
+	t = t.In(h.Location)
+	if !h.Weekdays[t.Weekday()] {
+		return false
+	}
+	sinceMidnight := time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+	return sinceMidnight >= h.Start && sinceMidnight < h.End
+}
+
+// String returns the business hours in the form they were parsed from.
+func (h *BusinessHours) String() string {
+	return h.spec
+}
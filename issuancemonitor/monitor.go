@@ -0,0 +1,381 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package issuancemonitor
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+)
+
+// Alert kinds.
+const (
+	// AlertThreshold is raised for an issuance which brings the amount of
+	// an asset issued within the window of a threshold above its maximum.
+	AlertThreshold = "threshold"
+
+	// AlertOutsideHours is raised for an issuance in a block whose
+	// timestamp is outside the configured business hours.
+	AlertOutsideHours = "outsidehours"
+)
+
+// Threshold limits the amount of an asset which may be issued within a
+// rolling window of block time before an alert is raised.
+type Threshold struct {
+	AssetID   uint32
+	Window    time.Duration
+	MaxAmount int64
+}
+
+// ParseThreshold parses a threshold of the form <window>:<amount>[:<assetid>],
+// where the window is a duration such as 24h and the amount is in DMG.  The
+// asset defaults to DMG.
+func ParseThreshold(s string) (*Threshold, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, fmt.Errorf("threshold %q is not of the form "+
+			"<window>:<amount>[:<assetid>]", s)
+	}
+	window, err := time.ParseDuration(parts[0])
+	if err != nil || window <= 0 {
+		return nil, fmt.Errorf("threshold %q has an invalid window", s)
+	}
+	amount, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return nil, fmt.Errorf("threshold %q has an invalid amount", s)
+	}
+	maxAmount, err := provautil.NewAmount(amount)
+	if err != nil || maxAmount < 0 {
+		return nil, fmt.Errorf("threshold %q has an invalid amount", s)
+	}
+	threshold := &Threshold{Window: window, MaxAmount: int64(maxAmount)}
+	if len(parts) == 3 {
+		assetID, err := strconv.ParseUint(parts[2], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("threshold %q has an invalid "+
+				"asset ID", s)
+		}
+		threshold.AssetID = uint32(assetID)
+	}
+	return threshold, nil
+}
+
+// Issuance is an issuance of coins connected to the main chain.
+type Issuance struct {
+	TxID      chainhash.Hash
+	BlockHash chainhash.Hash
+	Height    uint32
+	Time      time.Time
+	AssetID   uint32
+	Amount    int64
+}
+
+// Alert describes an issuance which exceeded a threshold or happened outside
+// the business hours.
+type Alert struct {
+	Kind      string `json:"kind"`
+	TxID      string `json:"txid"`
+	BlockHash string `json:"blockhash"`
+	Height    uint32 `json:"height"`
+	Time      int64  `json:"time"`
+	AssetID   uint32 `json:"assetid"`
+	Amount    int64  `json:"amount"`
+
+	// Window, WindowAmount and MaxAmount are set for threshold alerts.
+	// WindowAmount is the amount issued within the window, including the
+	// issuance itself.
+	Window       int64 `json:"window,omitempty"`
+	WindowAmount int64 `json:"windowamount,omitempty"`
+	MaxAmount    int64 `json:"maxamount,omitempty"`
+
+	Message string `json:"message"`
+}
+
+// Alerter delivers alerts, for example to a log, a webhook or the websocket
+// clients of the RPC server.
+type Alerter interface {
+	Alert(alert *Alert) error
+}
+
+// AlerterFunc is an adapter to use an ordinary function as an Alerter.
+type AlerterFunc func(alert *Alert) error
+
+// Alert calls f(alert).
+func (f AlerterFunc) Alert(alert *Alert) error {
+	return f(alert)
+}
+
+// Config is a descriptor containing the configuration of a monitor.
+type Config struct {
+	// Thresholds are the maximum amounts which may be issued within their
+	// windows.
+	Thresholds []Threshold
+
+	// BusinessHours are the times issuance is expected.  Issuance outside
+	// of them raises an alert.  It may be nil, which expects issuance at
+	// any time.
+	BusinessHours *BusinessHours
+
+	// Alerters deliver the alerts, in order.
+	Alerters []Alerter
+
+	// ChainParams are the parameters of the network, which determine
+	// from which height issuances may be of other assets than DMG.
+	ChainParams *chaincfg.Params
+}
+
+// Monitor watches the issuance of the blocks connected to the main chain and
+// raises alerts when the amount issued within the rolling window of a
+// threshold exceeds it, or when issuance happens outside of the business
+// hours, as an early warning of abuse of the issue keys.  Windows are measured
+// in block time.  Alerts are delivered to the alerters by a goroutine, so slow
+// alerters such as webhooks do not hold up the caller.
+type Monitor struct {
+	cfg       Config
+	maxWindow time.Duration
+
+	mtx       sync.Mutex
+	issuances []*Issuance
+	queue     []*Alert
+	notify    chan struct{}
+	quit      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// New returns a monitor with the passed configuration.
+func New(cfg *Config) (*Monitor, error) {
+	m := &Monitor{
+		cfg:    *cfg,
+		notify: make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+	}
+	for _, threshold := range cfg.Thresholds {
+		if threshold.Window <= 0 {
+			return nil, errors.New("threshold window must be positive")
+		}
+		if threshold.Window > m.maxWindow {
+			m.maxWindow = threshold.Window
+		}
+	}
+	return m, nil
+}
+
+// Start begins delivering alerts.
+func (m *Monitor) Start() {
+	m.wg.Add(1)
+	go m.handler()
+}
+
+// Stop stops delivering alerts.  Alerts which were not delivered yet are
+// dropped.
+func (m *Monitor) Stop() {
+	close(m.quit)
+	m.wg.Wait()
+}
+
+// MaxWindow returns the longest window of the thresholds, which is how far back
+// issuance must be recorded to check them.
+func (m *Monitor) MaxWindow() time.Duration {
+	return m.maxWindow
+}
+
+// Record records the issuance of the passed block connected to the main chain
+// without raising alerts.  It is used to load the issuance within the windows
+// of the thresholds when the node starts, and for the blocks connected while
+// the node syncs, whose issuance is not recent.
+//
+// This function is safe for concurrent access.
+func (m *Monitor) Record(block *provautil.Block) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.issuances = append(m.issuances, blockIssuances(block, m.cfg.ChainParams)...)
+	m.prune(block.MsgBlock().Header.Timestamp)
+}
+
+// BlockConnected records the issuance of the passed block connected to the main
+// chain and returns the alerts it raises, which are also queued for delivery.
+//
+// This function is safe for concurrent access.
+func (m *Monitor) BlockConnected(block *provautil.Block) []*Alert {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	var alerts []*Alert
+	for _, issuance := range blockIssuances(block, m.cfg.ChainParams) {
+		m.issuances = append(m.issuances, issuance)
+		alerts = append(alerts, m.check(issuance)...)
+	}
+	m.prune(block.MsgBlock().Header.Timestamp)
+
+	if len(alerts) != 0 {
+		m.queue = append(m.queue, alerts...)
+		select {
+		case m.notify <- struct{}{}:
+		default:
+		}
+	}
+	return alerts
+}
+
+// BlockDisconnected forgets the issuance of the passed block disconnected from
+// the main chain.
+//
+// This function is safe for concurrent access.
+func (m *Monitor) BlockDisconnected(block *provautil.Block) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	kept := m.issuances[:0]
+	for _, issuance := range m.issuances {
+		if !issuance.BlockHash.IsEqual(block.Hash()) {
+			kept = append(kept, issuance)
+		}
+	}
+	m.issuances = kept
+}
+
+// check returns the alerts raised by the passed recorded issuance.
+//
+// This function MUST be called with the monitor lock held.
+func (m *Monitor) check(issuance *Issuance) []*Alert {
+	var alerts []*Alert
+	newAlert := func(kind, message string) *Alert {
+		return &Alert{
+			Kind:      kind,
+			TxID:      issuance.TxID.String(),
+			BlockHash: issuance.BlockHash.String(),
+			Height:    issuance.Height,
+			Time:      issuance.Time.Unix(),
+			AssetID:   issuance.AssetID,
+			Amount:    issuance.Amount,
+			Message:   message,
+		}
+	}
+
+	for _, threshold := range m.cfg.Thresholds {
+		if threshold.AssetID != issuance.AssetID {
+			continue
+		}
+		start := issuance.Time.Add(-threshold.Window)
+		var windowAmount int64
+		for _, other := range m.issuances {
+			if other.AssetID == threshold.AssetID &&
+				other.Time.After(start) &&
+				!other.Time.After(issuance.Time) {
+
+				windowAmount += other.Amount
+			}
+		}
+		if windowAmount <= threshold.MaxAmount {
+			continue
+		}
+		alert := newAlert(AlertThreshold, fmt.Sprintf("issuance %v "+
+			"of %v of asset %d brings the amount issued within "+
+			"%v to %v, above the threshold of %v", issuance.TxID,
+			provautil.Amount(issuance.Amount), issuance.AssetID,
+			threshold.Window, provautil.Amount(windowAmount),
+			provautil.Amount(threshold.MaxAmount)))
+		alert.Window = int64(threshold.Window / time.Second)
+		alert.WindowAmount = windowAmount
+		alert.MaxAmount = threshold.MaxAmount
+		alerts = append(alerts, alert)
+	}
+
+	hours := m.cfg.BusinessHours
+	if hours != nil && !hours.Contains(issuance.Time) {
+		alerts = append(alerts, newAlert(AlertOutsideHours,
+			fmt.Sprintf("issuance %v of %v of asset %d at %v is "+
+				"outside the business hours %v", issuance.TxID,
+				provautil.Amount(issuance.Amount),
+				issuance.AssetID,
+				issuance.Time.In(hours.Location), hours)))
+	}
+	return alerts
+}
+
+// prune forgets the issuances older than the longest window before the passed
+// time.
+//
+// This function MUST be called with the monitor lock held.
+func (m *Monitor) prune(now time.Time) {
+	start := now.Add(-m.maxWindow)
+	kept := m.issuances[:0]
+	for _, issuance := range m.issuances {
+		if issuance.Time.After(start) {
+			kept = append(kept, issuance)
+		}
+	}
+	m.issuances = kept
+}
+
+// handler delivers the queued alerts to the alerters each time the monitor is
+// notified.  It must be run as a goroutine.
+func (m *Monitor) handler() {
+	defer m.wg.Done()
+
+	for {
+		select {
+		case <-m.notify:
+		case <-m.quit:
+			return
+		}
+
+		m.mtx.Lock()
+		queue := m.queue
+		m.queue = nil
+		m.mtx.Unlock()
+
+		for _, alert := range queue {
+			for _, alerter := range m.cfg.Alerters {
+				if err := alerter.Alert(alert); err != nil {
+					log.Errorf("Unable to deliver issuance "+
+						"alert for %s: %v", alert.TxID, err)
+				}
+			}
+		}
+	}
+}
+
+// blockIssuances returns the issuances of the passed block.  Destructions,
+// which spend more than the issue thread tip, are not issuances.
+func blockIssuances(block *provautil.Block,
+	chainParams *chaincfg.Params) []*Issuance {
+
+	var issuances []*Issuance
+	header := &block.MsgBlock().Header
+	for _, tx := range block.Transactions() {
+		threadInt, _ := txscript.GetAdminDetails(tx)
+		msgTx := tx.MsgTx()
+		if threadInt < 0 ||
+			provautil.ThreadID(threadInt) != provautil.IssueThread ||
+			len(msgTx.TxIn) > 1 {
+
+			continue
+		}
+		issuance := &Issuance{
+			TxID:      *tx.Hash(),
+			BlockHash: *block.Hash(),
+			Height:    header.Height,
+			Time:      header.Timestamp,
+			AssetID: blockchain.TxAssetID(msgTx, header.Height,
+				chainParams),
+		}
+		for _, txOut := range msgTx.TxOut[1:] {
+			issuance.Amount += txOut.Value
+		}
+		issuances = append(issuances, issuance)
+	}
+	return issuances
+}
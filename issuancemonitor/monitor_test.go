@@ -0,0 +1,253 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package issuancemonitor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// issuanceBlock returns a block at the passed height and time holding an
+// issuance of each of the passed amounts of DMG.
+func issuanceBlock(t *testing.T, height uint32, timestamp time.Time,
+	amounts ...int64) *provautil.Block {
+
+	threadScript, err := txscript.ProvaThreadScript(provautil.IssueThread)
+	if err != nil {
+		t.Fatalf("ProvaThreadScript: %v", err)
+	}
+	msgBlock := wire.NewMsgBlock(&wire.BlockHeader{
+		Height:    height,
+		Timestamp: timestamp,
+	})
+	for i, amount := range amounts {
+		tx := wire.NewMsgTx(wire.TxVersion)
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: wire.OutPoint{Index: uint32(i)},
+		})
+		tx.AddTxOut(wire.NewTxOut(0, threadScript))
+		tx.AddTxOut(wire.NewTxOut(amount, []byte{txscript.OP_TRUE}))
+		msgBlock.AddTransaction(tx)
+	}
+	return provautil.NewBlock(msgBlock)
+}
+
+// TestMonitor ensures alerts are raised for issuance above the threshold of
+// its rolling window and outside the business hours, and that disconnected
+// blocks no longer count towards the window.
+func TestMonitor(t *testing.T) {
+	hours, err := ParseBusinessHours("mon-fri 09:00-17:00")
+	if err != nil {
+		t.Fatalf("ParseBusinessHours: %v", err)
+	}
+	delivered := make(chan *Alert, 10)
+	m, err := New(&Config{
+		Thresholds: []Threshold{
+			{Window: 24 * time.Hour, MaxAmount: 100},
+		},
+		BusinessHours: hours,
+		Alerters: []Alerter{AlerterFunc(func(alert *Alert) error {
+			delivered <- alert
+			return nil
+		})},
+		ChainParams: &chaincfg.RegressionNetParams,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	m.Start()
+	defer m.Stop()
+
+	// Monday 1 January 2018, 10:00 UTC.
+	monday := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	m.Record(issuanceBlock(t, 1, monday.Add(-25*time.Hour), 1000))
+	tests := []struct {
+		name   string
+		block  *provautil.Block
+		alerts []string
+	}{
+		{"older issuance outside the window",
+			issuanceBlock(t, 2, monday, 60), nil},
+		{"window above the threshold",
+			issuanceBlock(t, 3, monday.Add(time.Hour), 50),
+			[]string{AlertThreshold}},
+		{"outside business hours and above the threshold",
+			issuanceBlock(t, 4, monday.Add(8*time.Hour), 1),
+			[]string{AlertThreshold, AlertOutsideHours}},
+		{"window moved past the earlier issuance",
+			issuanceBlock(t, 5, monday.Add(25*time.Hour), 1), nil},
+	}
+	for _, test := range tests {
+		alerts := m.BlockConnected(test.block)
+		if len(alerts) != len(test.alerts) {
+			t.Fatalf("%s: got %d alerts, want %v", test.name,
+				len(alerts), test.alerts)
+		}
+		for i, alert := range alerts {
+			if alert.Kind != test.alerts[i] {
+				t.Fatalf("%s: got alert %q, want %q", test.name,
+					alert.Kind, test.alerts[i])
+			}
+			select {
+			case got := <-delivered:
+				if got != alert {
+					t.Fatalf("%s: delivered alert %+v, want "+
+						"%+v", test.name, got, alert)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("%s: alert not delivered", test.name)
+			}
+		}
+	}
+
+	// Disconnecting the last block forgets its issuance, so the block
+	// replacing it brings the window exactly to the threshold.
+	m.BlockDisconnected(tests[len(tests)-1].block)
+	block := issuanceBlock(t, 5, monday.Add(25*time.Hour), 99)
+	if alerts := m.BlockConnected(block); len(alerts) != 0 {
+		t.Fatalf("got alerts %+v after a disconnect, want none", alerts)
+	}
+	if alerts := m.BlockConnected(issuanceBlock(t, 6,
+		monday.Add(26*time.Hour), 1)); len(alerts) != 1 ||
+		alerts[0].WindowAmount != 101 {
+
+		t.Fatalf("got alerts %+v, want a window of 101", alerts)
+	}
+}
+
+// TestBlockIssuancesAssetMarker ensures issuances carrying an asset marker are
+// of the marked asset only from the AssetMarkerHeight of the network, and of
+// DMG below it.
+func TestBlockIssuancesAssetMarker(t *testing.T) {
+	block := issuanceBlock(t, 10, time.Now(), 50)
+	markerScript, err := txscript.AssetIDScript(7)
+	if err != nil {
+		t.Fatalf("AssetIDScript: %v", err)
+	}
+	tx := block.MsgBlock().Transactions[0]
+	tx.AddTxOut(wire.NewTxOut(0, markerScript))
+	block = provautil.NewBlock(block.MsgBlock())
+
+	lateParams := chaincfg.RegressionNetParams
+	lateParams.AssetMarkerHeight = 11
+	tests := []struct {
+		name    string
+		params  *chaincfg.Params
+		assetID uint32
+	}{
+		{"asset markers active", &chaincfg.RegressionNetParams, 7},
+		{"asset markers not active", &lateParams, 0},
+	}
+	for _, test := range tests {
+		issuances := blockIssuances(block, test.params)
+		if len(issuances) != 1 ||
+			issuances[0].AssetID != test.assetID {
+
+			t.Errorf("%s: got issuances %+v, want asset %d",
+				test.name, issuances, test.assetID)
+		}
+	}
+}
+
+// TestParseThreshold ensures thresholds are parsed with amounts in DMG and an
+// optional asset.
+func TestParseThreshold(t *testing.T) {
+	threshold, err := ParseThreshold("24h:1.5:7")
+	if err != nil {
+		t.Fatalf("ParseThreshold: %v", err)
+	}
+	want := Threshold{AssetID: 7, Window: 24 * time.Hour,
+		MaxAmount: 1500000}
+	if *threshold != want {
+		t.Fatalf("got threshold %+v, want %+v", *threshold, want)
+	}
+	for _, s := range []string{"24h", "0s:1", "24h:x", "24h:-1",
+		"24h:1:x", "24h:1:2:3"} {
+
+		if _, err := ParseThreshold(s); err == nil {
+			t.Errorf("ParseThreshold(%q): no error", s)
+		}
+	}
+}
+
+// TestBusinessHours ensures business hours are parsed and contain the times
+// within them on their days in their time zone.
+func TestBusinessHours(t *testing.T) {
+	hours, err := ParseBusinessHours("sat-mon,wed 08:30-18:00 UTC")
+	if err != nil {
+		t.Fatalf("ParseBusinessHours: %v", err)
+	}
+	tests := []struct {
+		time     time.Time
+		contains bool
+	}{
+		// Monday 1 January 2018.
+		{time.Date(2018, 1, 1, 8, 30, 0, 0, time.UTC), true},
+		{time.Date(2018, 1, 1, 8, 29, 59, 0, time.UTC), false},
+		{time.Date(2018, 1, 1, 18, 0, 0, 0, time.UTC), false},
+		{time.Date(2018, 1, 2, 12, 0, 0, 0, time.UTC), false},
+		{time.Date(2018, 1, 3, 12, 0, 0, 0, time.UTC), true},
+		{time.Date(2018, 1, 6, 12, 0, 0, 0, time.UTC), true},
+		{time.Date(2018, 1, 7, 12, 0, 0, 0, time.UTC), true},
+		// 23:00 on Sunday in UTC is Monday in UTC+2.
+		{time.Date(2018, 1, 7, 23, 0, 0, 0,
+			time.FixedZone("", -2*3600)), false},
+	}
+	for _, test := range tests {
+		if got := hours.Contains(test.time); got != test.contains {
+			t.Errorf("Contains(%v) = %v, want %v", test.time, got,
+				test.contains)
+		}
+	}
+
+	for _, s := range []string{"mon-fri", "mon-xyz 09:00-17:00",
+		"mon 9:00-17:00", "mon 17:00-09:00", "mon 09:00-24:01",
+		"mon 09:00-17:00 Nowhere/Invalid"} {
+
+		if _, err := ParseBusinessHours(s); err == nil {
+			t.Errorf("ParseBusinessHours(%q): no error", s)
+		}
+	}
+}
+
+// TestWebhookAlerter ensures alerts are POSTed as JSON and that responses
+// other than 2xx are failures.
+func TestWebhookAlerter(t *testing.T) {
+	var got Alert
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+
+		if r.Method != http.MethodPost {
+			t.Errorf("got method %s, want POST", r.Method)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("Decode: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	alerter := NewWebhookAlerter(server.URL)
+	alert := &Alert{Kind: AlertThreshold, TxID: "txid", Amount: 5}
+	if err := alerter.Alert(alert); err != nil {
+		t.Fatalf("Alert: %v", err)
+	}
+	if got != *alert {
+		t.Fatalf("webhook got alert %+v, want %+v", got, *alert)
+	}
+	status = http.StatusInternalServerError
+	if err := alerter.Alert(alert); err == nil {
+		t.Fatalf("Alert: no error for status %d", status)
+	}
+}
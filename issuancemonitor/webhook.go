@@ -0,0 +1,55 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package issuancemonitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// defaultWebhookTimeout is how long delivering an alert to a webhook may take.
+const defaultWebhookTimeout = 10 * time.Second
+
+// WebhookAlerter delivers alerts by POSTing them as JSON to a URL.  Any status
+// other than 2xx is a failure.
+type WebhookAlerter struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookAlerter returns an alerter POSTing alerts to the passed URL.
+func NewWebhookAlerter(url string) *WebhookAlerter {
+	return &WebhookAlerter{
+		url:    url,
+		client: &http.Client{Timeout: defaultWebhookTimeout},
+	}
+}
+
+// Alert POSTs the passed alert to the URL of the webhook.
+func (w *WebhookAlerter) Alert(alert *Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	resp, err := w.client.Post(w.url, "application/json",
+		bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Drain the body so the connection can be reused.
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %s",
+			resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package keyceremony provides the building blocks of admin key ceremonies: the
+backup of private keys as Shamir secret shares and the signed manifests which
+record the generated keys.
+
+Shares
+
+A private key is split with SplitKey into a number of shares, any threshold of
+which recover it with CombineKey, while fewer reveal nothing about the key.
+The shares are computed byte by byte over GF(2^8).  A share is serialized as a
+hex string holding the threshold, the index of the share, its data and a
+checksum, so copying mistakes are detected when it is parsed.
+
+Manifests
+
+A manifest records the role, public key and fingerprint of every key of a
+ceremony, along with the threshold and number of its shares.  Every key signs
+the manifest, proving it was generated in the ceremony, so an auditor can check
+the manifest without access to the keys.  Unprovisioned cross-checks the keys
+of a manifest against the admin state returned by the getadmininfo RPC.
+*/
+package keyceremony
@@ -0,0 +1,173 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package keyceremony_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/btcjson"
+	"github.com/pyx-partners/dmgd/keyceremony"
+)
+
+// TestSplitCombine ensures any threshold of shares recovers a secret, that
+// fewer or mismatched shares are rejected and that shares survive their
+// serialization.
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("admin key ceremony secret")
+	if _, err := keyceremony.Split(secret, 1, 3); err !=
+		keyceremony.ErrInvalidThreshold {
+
+		t.Fatalf("got error %v splitting with threshold 1", err)
+	}
+	if _, err := keyceremony.Split(secret, 4, 3); err !=
+		keyceremony.ErrInvalidThreshold {
+
+		t.Fatalf("got error %v splitting with threshold above count",
+			err)
+	}
+
+	shares, err := keyceremony.Split(secret, 3, 5)
+	if err != nil {
+		t.Fatalf("Split: %v", err)
+	}
+	for _, share := range shares {
+		if bytes.Equal(share.Data, secret) {
+			t.Fatalf("share %d holds the secret", share.Index)
+		}
+	}
+
+	// Every combination of three shares recovers the secret.
+	for i := 0; i < len(shares); i++ {
+		for j := i + 1; j < len(shares); j++ {
+			for k := j + 1; k < len(shares); k++ {
+				got, err := keyceremony.Combine([]keyceremony.Share{
+					shares[k], shares[i], shares[j]})
+				if err != nil {
+					t.Fatalf("Combine: %v", err)
+				}
+				if !bytes.Equal(got, secret) {
+					t.Fatalf("shares %d, %d and %d recovered "+
+						"%x", i, j, k, got)
+				}
+			}
+		}
+	}
+
+	if _, err := keyceremony.Combine(shares[:2]); err !=
+		keyceremony.ErrTooFewShares {
+
+		t.Fatalf("got error %v combining too few shares", err)
+	}
+	_, err = keyceremony.Combine([]keyceremony.Share{shares[0], shares[1],
+		shares[1]})
+	if err != keyceremony.ErrShareMismatch {
+		t.Fatalf("got error %v combining a share twice", err)
+	}
+
+	str := shares[2].String()
+	parsed, err := keyceremony.ParseShare(str)
+	if err != nil {
+		t.Fatalf("ParseShare: %v", err)
+	}
+	if parsed.Threshold != 3 || parsed.Index != 3 ||
+		!bytes.Equal(parsed.Data, shares[2].Data) {
+
+		t.Fatalf("parsed share %+v, want %+v", parsed, shares[2])
+	}
+	// Mangle a hex digit of the share data into another hex digit.
+	mangled := []byte(str)
+	if mangled[6] == '0' {
+		mangled[6] = '1'
+	} else {
+		mangled[6] = '0'
+	}
+	if _, err := keyceremony.ParseShare(string(mangled)); err !=
+		keyceremony.ErrShareChecksum {
+
+		t.Fatalf("got error %v parsing a mangled share", err)
+	}
+}
+
+// TestManifest ensures keys split into shares are recovered, that manifests
+// signed by their keys verify after a round trip through a file while altered
+// ones do not, and that keys missing on chain are reported.
+func TestManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keyceremony")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A key with a leading zero byte must keep its full size.
+	privKeys := make([]*btcec.PrivateKey, 2)
+	privKeys[0], _ = btcec.PrivKeyFromBytes(btcec.S256(), []byte{0x01, 0x02})
+	privKeys[1], err = btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("NewPrivateKey: %v", err)
+	}
+	shares, err := keyceremony.SplitKey(privKeys[0], 2, 3)
+	if err != nil {
+		t.Fatalf("SplitKey: %v", err)
+	}
+	recovered, err := keyceremony.CombineKey(shares[1:])
+	if err != nil {
+		t.Fatalf("CombineKey: %v", err)
+	}
+	if !bytes.Equal(recovered.Serialize(), privKeys[0].Serialize()) {
+		t.Fatalf("recovered key %x, want %x", recovered.Serialize(),
+			privKeys[0].Serialize())
+	}
+
+	m := keyceremony.NewManifest("testnet", time.Unix(1500000000, 0))
+	m.AddKey(btcec.ProvisionKeySet, privKeys[0].PubKey(), 2, 3)
+	m.AddKey(btcec.ASPKeySet, privKeys[1].PubKey(), 2, 3)
+	if err := m.Sign(privKeys[:1]); err != keyceremony.ErrMissingPrivKey {
+		t.Fatalf("got error %v signing without all keys", err)
+	}
+	if err := m.Sign(privKeys); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	path := filepath.Join(dir, "manifest.json")
+	if err := m.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err := keyceremony.LoadManifest(path)
+	if err != nil {
+		t.Fatalf("LoadManifest: %v", err)
+	}
+	if err := loaded.Verify(); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	loaded.Keys[1].Role = "issue"
+	if err := loaded.Verify(); err == nil {
+		t.Fatalf("Verify: altered manifest verified")
+	}
+
+	pubKeys := []string{
+		hex.EncodeToString(privKeys[0].PubKey().SerializeCompressed()),
+		hex.EncodeToString(privKeys[1].PubKey().SerializeCompressed()),
+	}
+	info := &btcjson.GetAdminInfoResult{
+		ProvisionKeys: []string{pubKeys[0]},
+		// Provisioned in the wrong role.
+		IssueKeys: []string{pubKeys[1]},
+	}
+	unprovisioned := m.Unprovisioned(info)
+	if len(unprovisioned) != 1 || unprovisioned[0].PubKey != pubKeys[1] {
+		t.Fatalf("got unprovisioned keys %v, want the ASP key",
+			unprovisioned)
+	}
+	info.ASPKeys = []btcjson.ASPKeyIdResult{{PubKey: pubKeys[1], KeyID: 1}}
+	if unprovisioned := m.Unprovisioned(info); len(unprovisioned) != 0 {
+		t.Fatalf("got unprovisioned keys %v, want none", unprovisioned)
+	}
+}
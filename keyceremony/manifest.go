@@ -0,0 +1,238 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package keyceremony
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/btcjson"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/provautil"
+)
+
+// manifestVersion is the version of the key manifest format.
+const manifestVersion = 1
+
+// ErrMissingPrivKey is returned when a manifest is signed without the private
+// key of one of its keys.
+var ErrMissingPrivKey = errors.New("missing private key of manifest key")
+
+// ParseRole returns the admin key set of the passed role name, which is the
+// lowercase name of the key set, such as "provision".
+func ParseRole(role string) (btcec.KeySetType, error) {
+	for keySet := btcec.RootKeySet; keySet <= btcec.ASPKeySet; keySet++ {
+		if strings.ToLower(keySet.String()) == role {
+			return keySet, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown key role %q", role)
+}
+
+// Fingerprint returns the fingerprint of the passed public key, which is the
+// hex encoded hash160 of its compressed serialization.
+func Fingerprint(pubKey *btcec.PublicKey) string {
+	return hex.EncodeToString(provautil.Hash160(pubKey.SerializeCompressed()))
+}
+
+// SplitKey splits the passed private key into the passed number of shares,
+// any threshold of which recover it with CombineKey.
+func SplitKey(privKey *btcec.PrivateKey, threshold, count int) ([]Share, error) {
+	serialized := privKey.Serialize()
+	defer zero(serialized)
+	return Split(serialized, threshold, count)
+}
+
+// CombineKey recovers the private key split into the passed shares.
+func CombineKey(shares []Share) (*btcec.PrivateKey, error) {
+	serialized, err := Combine(shares)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(serialized)
+	if len(serialized) != btcec.PrivKeyBytesLen {
+		return nil, ErrShareMismatch
+	}
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), serialized)
+	return privKey, nil
+}
+
+// ManifestKey describes a key generated in a key ceremony.  The signature is
+// made by the key itself over the hash of the manifest, which proves that the
+// holders of its shares hold the key.
+type ManifestKey struct {
+	Role        string `json:"role"`
+	PubKey      string `json:"pubkey"`
+	Fingerprint string `json:"fingerprint"`
+	Threshold   int    `json:"threshold"`
+	Shares      int    `json:"shares"`
+	Signature   string `json:"signature,omitempty"`
+}
+
+// Manifest is the record of the keys generated in a key ceremony, which is
+// kept along with the shares of the keys and cross-checked against the keys
+// provisioned on chain.
+type Manifest struct {
+	Version uint32        `json:"version"`
+	Network string        `json:"network"`
+	Created int64         `json:"created"`
+	Keys    []ManifestKey `json:"keys"`
+}
+
+// NewManifest returns an empty manifest of a key ceremony for the passed
+// network held at the passed time.
+func NewManifest(network string, created time.Time) *Manifest {
+	return &Manifest{
+		Version: manifestVersion,
+		Network: network,
+		Created: created.Unix(),
+	}
+}
+
+// LoadManifest reads the manifest at the passed path.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	if m.Version != manifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d",
+			m.Version)
+	}
+	return &m, nil
+}
+
+// Save writes the manifest to the passed path.
+func (m *Manifest) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// AddKey adds the passed public key of the passed role, split into shares
+// with the passed threshold, to the manifest.
+func (m *Manifest) AddKey(role btcec.KeySetType, pubKey *btcec.PublicKey,
+	threshold, shares int) {
+
+	m.Keys = append(m.Keys, ManifestKey{
+		Role:        strings.ToLower(role.String()),
+		PubKey:      hex.EncodeToString(pubKey.SerializeCompressed()),
+		Fingerprint: Fingerprint(pubKey),
+		Threshold:   threshold,
+		Shares:      shares,
+	})
+}
+
+// Hash returns the hash of the manifest without the signatures of its keys,
+// which the keys sign.
+func (m *Manifest) Hash() chainhash.Hash {
+	unsigned := *m
+	unsigned.Keys = make([]ManifestKey, len(m.Keys))
+	for i, key := range m.Keys {
+		key.Signature = ""
+		unsigned.Keys[i] = key
+	}
+	// Marshalling a struct without maps can not fail.
+	data, _ := json.Marshal(&unsigned)
+	return chainhash.DoubleHashH(data)
+}
+
+// Sign signs the manifest with the private keys of all of its keys, which
+// must be among the passed private keys.
+func (m *Manifest) Sign(privKeys []*btcec.PrivateKey) error {
+	hash := m.Hash()
+	byPubKey := make(map[string]*btcec.PrivateKey, len(privKeys))
+	for _, privKey := range privKeys {
+		pubKey := privKey.PubKey().SerializeCompressed()
+		byPubKey[hex.EncodeToString(pubKey)] = privKey
+	}
+	for i, key := range m.Keys {
+		privKey, ok := byPubKey[key.PubKey]
+		if !ok {
+			return ErrMissingPrivKey
+		}
+		sig, err := privKey.Sign(hash[:])
+		if err != nil {
+			return err
+		}
+		m.Keys[i].Signature = hex.EncodeToString(sig.Serialize())
+	}
+	return nil
+}
+
+// Verify ensures all keys of the manifest have a known role, match their
+// fingerprint and signed the manifest.
+func (m *Manifest) Verify() error {
+	hash := m.Hash()
+	for _, key := range m.Keys {
+		if _, err := ParseRole(key.Role); err != nil {
+			return err
+		}
+		pubKeyBytes, err := hex.DecodeString(key.PubKey)
+		if err != nil {
+			return fmt.Errorf("key %s: %v", key.Fingerprint, err)
+		}
+		pubKey, err := btcec.ParsePubKey(pubKeyBytes, btcec.S256())
+		if err != nil {
+			return fmt.Errorf("key %s: %v", key.Fingerprint, err)
+		}
+		if Fingerprint(pubKey) != key.Fingerprint {
+			return fmt.Errorf("key %s does not match its fingerprint "+
+				"%s", key.PubKey, key.Fingerprint)
+		}
+		sigBytes, err := hex.DecodeString(key.Signature)
+		if err != nil {
+			return fmt.Errorf("key %s: %v", key.Fingerprint, err)
+		}
+		sig, err := btcec.ParseDERSignature(sigBytes, btcec.S256())
+		if err != nil || !sig.Verify(hash[:], pubKey) {
+			return fmt.Errorf("key %s did not sign the manifest",
+				key.Fingerprint)
+		}
+	}
+	return nil
+}
+
+// Unprovisioned returns the keys of the manifest which are not provisioned in
+// the key set of their role in the passed admin state, as returned by the
+// getadmininfo RPC.
+func (m *Manifest) Unprovisioned(info *btcjson.GetAdminInfoResult) []ManifestKey {
+	provisioned := map[string][]string{
+		"root":      info.RootKeys,
+		"provision": info.ProvisionKeys,
+		"issue":     info.IssueKeys,
+		"validate":  info.ValidateKeys,
+	}
+	for _, aspKey := range info.ASPKeys {
+		provisioned["asp"] = append(provisioned["asp"], aspKey.PubKey)
+	}
+
+	var unprovisioned []ManifestKey
+	for _, key := range m.Keys {
+		found := false
+		for _, pubKey := range provisioned[key.Role] {
+			if pubKey == key.PubKey {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unprovisioned = append(unprovisioned, key)
+		}
+	}
+	return unprovisioned
+}
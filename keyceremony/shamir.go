@@ -0,0 +1,206 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package keyceremony
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+)
+
+const (
+	// MaxShares is the maximum number of shares a secret can be split
+	// into, since every share needs a distinct non-zero index byte.
+	MaxShares = 255
+
+	// shareChecksumSize is the size of the checksum ending a serialized
+	// share.
+	shareChecksumSize = 4
+)
+
+var (
+	// ErrInvalidThreshold is returned when a secret would be split with a
+	// threshold below two or above the number of shares.
+	ErrInvalidThreshold = errors.New("invalid share threshold")
+
+	// ErrTooFewShares is returned when fewer shares than their threshold
+	// are combined.
+	ErrTooFewShares = errors.New("too few shares")
+
+	// ErrShareMismatch is returned when shares of different secrets, or
+	// the same share twice, are combined.
+	ErrShareMismatch = errors.New("shares do not belong together")
+
+	// ErrShareChecksum is returned when a serialized share does not match
+	// its checksum, for example because it was copied wrongly.
+	ErrShareChecksum = errors.New("share checksum mismatch")
+)
+
+// gfExp and gfLog are the exponent and logarithm tables of the field GF(2^8)
+// with the reducing polynomial x^8 + x^4 + x^3 + x + 1 and the generator 3.
+var gfExp, gfLog = func() (exp [510]byte, log [256]byte) {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		exp[i], exp[i+255] = x, x
+		log[x] = byte(i)
+		// Multiply by the generator 3, which is x * 2 + x.
+		double := x << 1
+		if x&0x80 != 0 {
+			double ^= 0x1b
+		}
+		x ^= double
+	}
+	return exp, log
+}()
+
+// gfMul returns the product of a and b in GF(2^8).
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+// gfDiv returns the quotient of a and the non-zero b in GF(2^8).
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+255-int(gfLog[b])]
+}
+
+// Share is one share of a secret split with Split.  Index is the x coordinate
+// of the share, which is never zero, and Data holds the y coordinate of the
+// polynomial of each byte of the secret.
+type Share struct {
+	Threshold uint8
+	Index     uint8
+	Data      []byte
+}
+
+// Split splits the passed secret into the passed number of shares, any
+// threshold of which recover it with Combine.  Fewer shares than the
+// threshold reveal nothing about the secret.
+func Split(secret []byte, threshold, count int) ([]Share, error) {
+	if threshold < 2 || threshold > count || count > MaxShares {
+		return nil, ErrInvalidThreshold
+	}
+
+	shares := make([]Share, count)
+	for i := range shares {
+		shares[i] = Share{
+			Threshold: uint8(threshold),
+			Index:     uint8(i + 1),
+			Data:      make([]byte, len(secret)),
+		}
+	}
+
+	// Each byte of the secret is the constant term of a random polynomial
+	// of degree threshold-1, which is evaluated at the index of each
+	// share.
+	coeffs := make([]byte, threshold-1)
+	defer zero(coeffs)
+	for pos, b := range secret {
+		if _, err := rand.Read(coeffs); err != nil {
+			return nil, err
+		}
+		for i := range shares {
+			x := shares[i].Index
+			var y byte
+			for j := len(coeffs) - 1; j >= 0; j-- {
+				y = gfMul(y^coeffs[j], x)
+			}
+			shares[i].Data[pos] = y ^ b
+		}
+	}
+	return shares, nil
+}
+
+// Combine recovers the secret from the passed shares, of which there must be
+// at least their threshold.
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) == 0 || len(shares) < int(shares[0].Threshold) {
+		return nil, ErrTooFewShares
+	}
+	threshold := int(shares[0].Threshold)
+	size := len(shares[0].Data)
+	seen := make(map[uint8]bool, threshold)
+	for _, share := range shares {
+		if share.Threshold != shares[0].Threshold ||
+			len(share.Data) != size || share.Index == 0 ||
+			seen[share.Index] {
+
+			return nil, ErrShareMismatch
+		}
+		seen[share.Index] = true
+	}
+	shares = shares[:threshold]
+
+	// Interpolate the polynomial of each byte at zero using the Lagrange
+	// basis polynomials of the share indexes.
+	basis := make([]byte, threshold)
+	for i, share := range shares {
+		basis[i] = 1
+		for j, other := range shares {
+			if i != j {
+				basis[i] = gfMul(basis[i], gfDiv(other.Index,
+					other.Index^share.Index))
+			}
+		}
+	}
+	secret := make([]byte, size)
+	for pos := range secret {
+		for i, share := range shares {
+			secret[pos] ^= gfMul(share.Data[pos], basis[i])
+		}
+	}
+	return secret, nil
+}
+
+// shareChecksum returns the checksum of the passed serialized share.
+func shareChecksum(serialized []byte) []byte {
+	return chainhash.DoubleHashB(serialized)[:shareChecksumSize]
+}
+
+// String returns the share as a hex string holding the threshold, the index,
+// the data and a checksum, so it can be written down and parsed with
+// ParseShare.
+func (s Share) String() string {
+	serialized := make([]byte, 0, 2+len(s.Data)+shareChecksumSize)
+	serialized = append(serialized, s.Threshold, s.Index)
+	serialized = append(serialized, s.Data...)
+	serialized = append(serialized, shareChecksum(serialized)...)
+	return hex.EncodeToString(serialized)
+}
+
+// ParseShare parses a share serialized with String.
+func ParseShare(str string) (Share, error) {
+	serialized, err := hex.DecodeString(str)
+	if err != nil {
+		return Share{}, err
+	}
+	if len(serialized) < 2+shareChecksumSize {
+		return Share{}, ErrShareChecksum
+	}
+	split := len(serialized) - shareChecksumSize
+	if !bytes.Equal(shareChecksum(serialized[:split]), serialized[split:]) {
+		return Share{}, ErrShareChecksum
+	}
+	return Share{
+		Threshold: serialized[0],
+		Index:     serialized[1],
+		Data:      serialized[2:split],
+	}, nil
+}
+
+// zero overwrites the passed bytes with zeros.
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
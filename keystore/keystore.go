@@ -0,0 +1,230 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package keystore
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// version is the version of the keystore file format.
+	version = 1
+
+	// privKeySize is the size of a serialized private key.
+	privKeySize = 32
+
+	// saltSize is the size of the random salt the encryption key is
+	// derived with.
+	saltSize = 32
+
+	// nonceSize is the size of the random nonce the private keys are
+	// encrypted with.
+	nonceSize = 24
+
+	// The scrypt parameters new keystores derive their encryption key
+	// with.  They are stored along with the encrypted keys, so they can
+	// be raised without breaking existing keystores.
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+
+	// The largest scrypt parameters keystores are loaded with, so a
+	// tampered keystore can not make deriving its key exhaust the memory
+	// or time of the node.  Deriving a key with them takes 128*N*R bytes,
+	// or 1 GiB, of memory.
+	maxScryptN = 1 << 20
+	maxScryptR = 8
+	maxScryptP = 16
+)
+
+var (
+	// ErrWrongPassphrase is returned when the private keys of a keystore
+	// can not be decrypted with the passed passphrase.
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+
+	// ErrNoKeys is returned when a keystore without private keys would be
+	// saved.
+	ErrNoKeys = errors.New("no private keys")
+)
+
+// storeFile is the serialized form of a keystore.  The private keys are
+// encrypted with NaCl secretbox under a key derived from the passphrase with
+// scrypt.
+type storeFile struct {
+	Version    uint32 `json:"version"`
+	Salt       string `json:"salt"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Nonce      string `json:"nonce"`
+	CipherText string `json:"ciphertext"`
+}
+
+// deriveKey derives the encryption key of a keystore from the passed
+// passphrase and scrypt parameters.
+func deriveKey(passphrase, salt []byte, n, r, p int) (*[32]byte, error) {
+	derived, err := scrypt.Key(passphrase, salt, n, r, p, 32)
+	if err != nil {
+		return nil, err
+	}
+	var key [32]byte
+	copy(key[:], derived)
+	zero(derived)
+	return &key, nil
+}
+
+// zero clears the passed bytes.
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// Save encrypts the passed private keys with the passed passphrase and writes
+// them to the keystore at the passed path, replacing any keys stored there
+// before.  The file is only readable by the owner and is replaced atomically,
+// so the previous keys are kept when writing fails.
+func Save(path string, passphrase []byte, privKeys []*btcec.PrivateKey) error {
+	if len(privKeys) == 0 {
+		return ErrNoKeys
+	}
+
+	var salt [saltSize]byte
+	if _, err := io.ReadFull(rand.Reader, salt[:]); err != nil {
+		return err
+	}
+	var nonce [nonceSize]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return err
+	}
+	key, err := deriveKey(passphrase, salt[:], scryptN, scryptR, scryptP)
+	if err != nil {
+		return err
+	}
+	defer zero(key[:])
+
+	plainText := make([]byte, 0, len(privKeys)*privKeySize)
+	for _, privKey := range privKeys {
+		serialized := privKey.Serialize()
+		plainText = append(plainText, make([]byte,
+			privKeySize-len(serialized))...)
+		plainText = append(plainText, serialized...)
+		zero(serialized)
+	}
+	cipherText := secretbox.Seal(nil, plainText, &nonce, key)
+	zero(plainText)
+
+	serialized, err := json.Marshal(&storeFile{
+		Version:    version,
+		Salt:       hex.EncodeToString(salt[:]),
+		N:          scryptN,
+		R:          scryptR,
+		P:          scryptP,
+		Nonce:      hex.EncodeToString(nonce[:]),
+		CipherText: hex.EncodeToString(cipherText),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Write the keystore to a temporary file next to it first and move it
+	// into place once it is synced to disk.
+	file, err := ioutil.TempFile(filepath.Dir(path),
+		filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := file.Name()
+	_, err = file.Write(serialized)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// Load reads the keystore at the passed path and returns its private keys
+// decrypted with the passed passphrase.  ErrWrongPassphrase is returned when
+// the passphrase does not match the one the keys were saved with.
+func Load(path string, passphrase []byte) ([]*btcec.PrivateKey, error) {
+	serialized, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var store storeFile
+	if err := json.Unmarshal(serialized, &store); err != nil {
+		return nil, fmt.Errorf("keystore %s: %v", path, err)
+	}
+	if store.Version != version {
+		return nil, fmt.Errorf("keystore %s: unsupported version %d",
+			path, store.Version)
+	}
+	salt, err := hex.DecodeString(store.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("keystore %s: salt: %v", path, err)
+	}
+	nonceBytes, err := hex.DecodeString(store.Nonce)
+	if err != nil || len(nonceBytes) != nonceSize {
+		return nil, fmt.Errorf("keystore %s: invalid nonce", path)
+	}
+	var nonce [nonceSize]byte
+	copy(nonce[:], nonceBytes)
+	cipherText, err := hex.DecodeString(store.CipherText)
+	if err != nil {
+		return nil, fmt.Errorf("keystore %s: ciphertext: %v", path, err)
+	}
+
+	if store.N > maxScryptN || store.R > maxScryptR ||
+		store.P > maxScryptP {
+
+		return nil, fmt.Errorf("keystore %s: scrypt parameters N=%d, "+
+			"r=%d, p=%d exceed the maximum N=%d, r=%d, p=%d", path,
+			store.N, store.R, store.P, maxScryptN, maxScryptR,
+			maxScryptP)
+	}
+	key, err := deriveKey(passphrase, salt, store.N, store.R, store.P)
+	if err != nil {
+		return nil, fmt.Errorf("keystore %s: %v", path, err)
+	}
+	defer zero(key[:])
+	plainText, ok := secretbox.Open(nil, cipherText, &nonce, key)
+	if !ok {
+		return nil, ErrWrongPassphrase
+	}
+	defer zero(plainText)
+	if len(plainText) == 0 || len(plainText)%privKeySize != 0 {
+		return nil, fmt.Errorf("keystore %s: invalid private keys "+
+			"size %d", path, len(plainText))
+	}
+
+	privKeys := make([]*btcec.PrivateKey, 0, len(plainText)/privKeySize)
+	for i := 0; i < len(plainText); i += privKeySize {
+		privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(),
+			plainText[i:i+privKeySize])
+		privKeys = append(privKeys, privKey)
+	}
+	return privKeys, nil
+}
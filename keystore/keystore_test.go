@@ -0,0 +1,119 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package keystore_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/keystore"
+)
+
+// TestKeystore ensures private keys survive a round trip through a keystore,
+// are only decrypted with the passphrase they were saved with and are not
+// stored in the clear.
+func TestKeystore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keystore")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "validatekeys.json")
+
+	if _, err := keystore.Load(path, []byte("pass")); !os.IsNotExist(err) {
+		t.Fatalf("got error %v loading a missing keystore", err)
+	}
+	err = keystore.Save(path, []byte("pass"), nil)
+	if err != keystore.ErrNoKeys {
+		t.Fatalf("got error %v saving no keys, want %v", err,
+			keystore.ErrNoKeys)
+	}
+
+	// A key with a leading zero byte must keep its full size.
+	privKeys := make([]*btcec.PrivateKey, 2)
+	privKeys[0], _ = btcec.PrivKeyFromBytes(btcec.S256(), []byte{0x01, 0x02})
+	privKeys[1], err = btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("NewPrivateKey: %v", err)
+	}
+	if err := keystore.Save(path, []byte("pass"), privKeys); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := keystore.Load(path, []byte("pass"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(loaded) != len(privKeys) {
+		t.Fatalf("got %d keys, want %d", len(loaded), len(privKeys))
+	}
+	for i, privKey := range loaded {
+		if privKey.D.Cmp(privKeys[i].D) != 0 {
+			t.Errorf("got key #%d %x, want %x", i, privKey.Serialize(),
+				privKeys[i].Serialize())
+		}
+	}
+
+	_, err = keystore.Load(path, []byte("wrong"))
+	if err != keystore.ErrWrongPassphrase {
+		t.Fatalf("got error %v for a wrong passphrase, want %v", err,
+			keystore.ErrWrongPassphrase)
+	}
+
+	serialized, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	for i, privKey := range privKeys {
+		for _, encoding := range [][]byte{privKey.Serialize(),
+			[]byte(fmt.Sprintf("%x", privKey.Serialize()))} {
+
+			if bytes.Contains(serialized, encoding) {
+				t.Errorf("key #%d stored in the clear", i)
+			}
+		}
+	}
+
+	// Saving replaces the stored keys and leaves no temporary files.
+	if err := keystore.Save(path, []byte("new"), privKeys[1:]); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	loaded, err = keystore.Load(path, []byte("new"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].D.Cmp(privKeys[1].D) != 0 {
+		t.Fatalf("got %d keys after replacing them, want key #1",
+			len(loaded))
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(files) != 1 || files[0].Mode().Perm() != 0600 {
+		t.Fatalf("got %d files in the keystore directory, with mode %v",
+			len(files), files[0].Mode())
+	}
+
+	// Keystores with excessive scrypt parameters are rejected before the
+	// key is derived.
+	serialized, err = ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	serialized = bytes.Replace(serialized, []byte(`"n":32768`),
+		[]byte(`"n":1073741824`), 1)
+	if err := ioutil.WriteFile(path, serialized, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := keystore.Load(path, []byte("new")); err == nil {
+		t.Fatalf("loaded a keystore with excessive scrypt parameters")
+	}
+}
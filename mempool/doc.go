@@ -56,11 +56,19 @@ be an exhaustive list.
    - Max signature operations per transaction
    - Max orphan transaction size
    - Max number of orphan transactions allowed
+   - Min confirmations of the thread tip spent by an admin transaction, with
+     admin transactions chaining off a less confirmed thread tip held until it
+     has them
+   - Additional admission policies supplied by the caller to enforce business
+     specific rules
  - Additional metadata tracking for each transaction
    - Timestamp when the transaction was added to the pool
    - Most recent block height when the transaction was added to the pool
    - The fee the transaction pays
    - The starting priority for the transaction
+   - The count, size, and fees of the unconfirmed ancestors and descendants of
+     the transaction (its packages) so dependent transaction chains can be
+     mined by package fee rate
  - Manual control of transaction removal
    - Recursive removal of all dependent transactions
 
@@ -8,8 +8,10 @@ package mempool
 
 import (
 	"container/list"
+	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -23,6 +25,7 @@ import (
 	"github.com/pyx-partners/dmgd/mining"
 	"github.com/pyx-partners/dmgd/provautil"
 	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/txscript/adminop"
 	"github.com/pyx-partners/dmgd/wire"
 )
 
@@ -41,8 +44,23 @@ const (
 	// orphanExpireScanInterval is the minimum amount of time in between
 	// scans of the orphan pool to evict expired transactions.
 	orphanExpireScanInterval = time.Minute * 5
+
+	// MaxPackageTxns is the maximum number of transactions allowed in a
+	// single package submitted via ProcessPackage.
+	MaxPackageTxns = 25
+
+	// maxHeldAdminTxns is the maximum number of admin transactions held
+	// until the thread tips they spend have the minimum number of
+	// confirmations of the policy.
+	maxHeldAdminTxns = 100
 )
 
+// errKeyIDScanNeeded is returned by maybeAcceptTransaction for a transaction
+// revoking ASP keys when the unspent outputs of the main chain referencing
+// their keyIDs have not been scanned yet.  See maybeAcceptTransactionScan.
+var errKeyIDScanNeeded = errors.New("unspent outputs of revoked keyIDs " +
+	"not scanned")
+
 // Tag represents an identifier to use for tagging orphan transactions.  The
 // caller may choose any scheme it desires, however it is common to use peer IDs
 // so that orphans can be identified by which peer first relayed them.
@@ -77,6 +95,18 @@ type Config struct {
 	// GetAdminKeySets defines the function to fetch admin key Sets.
 	GetAdminKeySets func() map[btcec.KeySetType]btcec.PublicKeySet
 
+	// GetIssueDests defines the function to fetch the issuance
+	// destination allowlist.
+	GetIssueDests func() blockchain.IssueDestSet
+
+	// GetFrozen defines the function to fetch the pubKeyHashes of frozen
+	// accounts.
+	GetFrozen func() blockchain.FrozenSet
+
+	// GetDelegates defines the function to fetch the delegate keys and
+	// the scope of their authority.
+	GetDelegates func() blockchain.Delegates
+
 	// BestHeight defines the function to use to access the block height of
 	// the current best chain.
 	BestHeight func() uint32
@@ -100,10 +130,28 @@ type Config struct {
 	// TimeSource defines the timesource to use.
 	TimeSource blockchain.MedianTimeSource
 
+	// Now defines the function to use to get the current time, which is
+	// used to rate limit free transactions, expire orphans and record when
+	// transactions are added.  It defaults to time.Now when nil, and
+	// simulations set it to a virtual clock.
+	Now func() time.Time
+
 	// AddrIndex defines the optional address index instance to use for
 	// indexing the unconfirmed transactions in the memory pool.
 	// This can be nil if the address index is not enabled.
 	AddrIndex *indexers.AddrIndex
+
+	// FetchKeyIDOutPoints defines the function to use to fetch the
+	// outpoints of the unspent outputs of the main chain which reference
+	// any of the passed keyIDs, at most the passed number of them.  It is
+	// used to count the outputs still referencing the keyIDs of revoked
+	// ASP keys, and may be nil, which disables the check.
+	FetchKeyIDOutPoints func([]btcec.KeyID, int) ([]wire.OutPoint, error)
+
+	// AdmissionPolicies defines the optional additional policies which
+	// transactions must satisfy to be accepted into the memory pool.
+	// Further policies may be registered with AddAdmissionPolicy.
+	AdmissionPolicies []AdmissionPolicy
 }
 
 // Policy houses the policy (configuration parameters) which is used to
@@ -144,6 +192,33 @@ type Policy struct {
 	// MinRelayTxFee defines the minimum transaction fee in DMG/kB to be
 	// considered a non-zero fee.
 	MinRelayTxFee provautil.Amount
+
+	// DustRelayFee defines the fee rate in Atoms/kB used to determine
+	// whether or not a transaction output is considered dust.
+	DustRelayFee provautil.Amount
+
+	// MaxStandardTxSize is the maximum serialized size in bytes of a
+	// transaction that is considered standard.
+	MaxStandardTxSize int
+
+	// MaxStandardSigScriptSize is the maximum size in bytes of a
+	// transaction input signature script that is considered standard.
+	MaxStandardSigScriptSize int
+
+	// MinAdminTxConfirmations is the minimum number of confirmations the
+	// thread tip spent by an admin transaction must have before the admin
+	// transaction is accepted and relayed.  Admin transactions chaining
+	// off a thread tip with fewer confirmations are held until it has
+	// them, so long chains of unconfirmed admin transactions, which are
+	// all invalidated when a reorg drops the first one, are not built.
+	// Zero accepts them right away.
+	MinAdminTxConfirmations uint32
+
+	// MaxRevokedKeyIDOutputs is the maximum number of unspent outputs
+	// which may still reference the keyID of an ASP key revoked by an
+	// admin transaction for it to be accepted and relayed.  A negative
+	// value disables the check.
+	MaxRevokedKeyIDOutputs int
 }
 
 // TxDesc is a descriptor containing a transaction in the mempool along with
@@ -181,6 +256,23 @@ type TxPool struct {
 	pennyTotal    float64 // exponentially decaying total for penny spends.
 	lastPennyUnix int64   // unix time of last ``penny spend''
 
+	// adminTxns houses the admin transactions in the pool in the order
+	// they were accepted so their operations can be applied on top of
+	// the main chain key view when validating dependent transactions.
+	adminTxns []*provautil.Tx
+
+	// heldAdminTxns houses the valid admin transactions which spend a
+	// thread tip with fewer than the minimum number of confirmations of
+	// the policy.  They are retried by ProcessHeldAdminTxns once new
+	// blocks are connected.
+	heldAdminTxns map[chainhash.Hash]*provautil.Tx
+
+	// feeDeltas houses the fee deltas set with PrioritiseTransaction,
+	// which are added to the fees of the transactions when they are
+	// selected for block templates.  A delta may be set before its
+	// transaction is accepted and is dropped once it leaves the pool.
+	feeDeltas map[chainhash.Hash]int64
+
 	// nextExpireScan is the time after which the orphan pool will be
 	// scanned in order to evict orphans.  This is NOT a hard deadline as
 	// the scan will only run when an orphan is added to the pool as opposed
@@ -267,7 +359,7 @@ func (mp *TxPool) limitNumOrphans() error {
 	// Scan through the orphan pool and remove any expired orphans when it's
 	// time.  This is done for efficiency so the scan only happens
 	// periodically instead of on every orphan added to the pool.
-	if now := time.Now(); now.After(mp.nextExpireScan) {
+	if now := mp.cfg.Now(); now.After(mp.nextExpireScan) {
 		origNumOrphans := len(mp.orphans)
 		for _, otx := range mp.orphans {
 			if now.After(otx.expiration) {
@@ -329,7 +421,7 @@ func (mp *TxPool) addOrphan(tx *provautil.Tx, tag Tag) {
 	mp.orphans[*tx.Hash()] = &orphanTx{
 		tx:         tx,
 		tag:        tag,
-		expiration: time.Now().Add(orphanTTL),
+		expiration: mp.cfg.Now().Add(orphanTTL),
 	}
 	for _, txIn := range tx.MsgTx().TxIn {
 		if _, exists := mp.orphansByPrev[txIn.PreviousOutPoint]; !exists {
@@ -387,6 +479,151 @@ func (mp *TxPool) removeOrphanDoubleSpends(tx *provautil.Tx) {
 	}
 }
 
+// mustHoldAdminTx returns whether the passed transaction is an admin
+// transaction which spends a thread tip with fewer than the minimum number of
+// confirmations of the policy as of the passed height of the next block.
+// Thread tips created by transactions in the pool have no confirmations.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) mustHoldAdminTx(tx *provautil.Tx, utxoView *blockchain.UtxoViewpoint, nextBlockHeight uint32) bool {
+	minConfs := mp.cfg.Policy.MinAdminTxConfirmations
+	if minConfs == 0 {
+		return false
+	}
+	if threadInt, _ := txscript.GetAdminDetails(tx); threadInt < 0 {
+		return false
+	}
+
+	// Admin transactions spend the tip of their thread with the first
+	// input.
+	prevOut := &tx.MsgTx().TxIn[0].PreviousOutPoint
+	entry := utxoView.LookupEntry(&prevOut.Hash)
+	if entry == nil || entry.BlockHeight() == mining.UnminedHeight {
+		return true
+	}
+	return nextBlockHeight-entry.BlockHeight() < minConfs
+}
+
+// revokedKeyIDs returns the keyIDs of the ASP keys revoked by the passed
+// transaction, or nil when it revokes none or the policy does not limit the
+// outputs still referencing them.
+func (mp *TxPool) revokedKeyIDs(tx *provautil.Tx) []btcec.KeyID {
+	if mp.cfg.Policy.MaxRevokedKeyIDOutputs < 0 ||
+		mp.cfg.FetchKeyIDOutPoints == nil {
+
+		return nil
+	}
+	threadInt, adminOutputs := txscript.GetAdminDetails(tx)
+	if threadInt < 0 || provautil.ThreadID(threadInt) == provautil.IssueThread {
+		return nil
+	}
+
+	var revoked []btcec.KeyID
+	for _, adminOutput := range adminOutputs {
+		payload, err := txscript.ExtractAdminPayload(adminOutput)
+		if err != nil {
+			continue
+		}
+		schema := payload.Schema()
+		if schema.Kind == adminop.KindKey &&
+			schema.KeySet == btcec.ASPKeySet && !schema.IsAdd {
+
+			revoked = append(revoked, payload.KeyID)
+		}
+	}
+	return revoked
+}
+
+// keyIDScanLimit returns the number of unspent outputs of the main chain
+// referencing a keyID after which a scan for them may stop.  Since at most all
+// of the outputs spent by transactions in the pool are discounted, any more
+// outputs than that and the policy allows exceed it.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) keyIDScanLimit() int {
+	return mp.cfg.Policy.MaxRevokedKeyIDOutputs + 1 + len(mp.outpoints)
+}
+
+// scanKeyIDOutPoints returns the outpoints of the unspent outputs of the main
+// chain which reference each of the passed keyIDs, at most the passed number
+// of them for each keyID.  It requires a full scan of the utxo set, so it
+// should not be called with the mempool lock held where avoidable.
+func (mp *TxPool) scanKeyIDOutPoints(keyIDs []btcec.KeyID,
+	limit int) (map[btcec.KeyID][]wire.OutPoint, error) {
+
+	keyIDOutPoints := make(map[btcec.KeyID][]wire.OutPoint, len(keyIDs))
+	for _, keyID := range keyIDs {
+		outPoints, err := mp.cfg.FetchKeyIDOutPoints(
+			[]btcec.KeyID{keyID}, limit)
+		if err != nil {
+			return nil, err
+		}
+		keyIDOutPoints[keyID] = outPoints
+	}
+	return keyIDOutPoints, nil
+}
+
+// checkKeyIDRevocations ensures the passed transaction does not revoke an ASP
+// key whose keyID is still referenced by more unspent outputs than the policy
+// allows, since those outputs could no longer be spent with the co-signature
+// of an ASP.  Outputs spent by transactions in the pool are not counted, while
+// the outputs they create are.  The unspent outputs of the main chain
+// referencing the keyIDs are taken from the passed scan, and
+// errKeyIDScanNeeded is returned when it lacks any of them.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) checkKeyIDRevocations(tx *provautil.Tx,
+	keyIDOutPoints map[btcec.KeyID][]wire.OutPoint) error {
+
+	revoked := mp.revokedKeyIDs(tx)
+	for _, keyID := range revoked {
+		if _, ok := keyIDOutPoints[keyID]; !ok {
+			return errKeyIDScanNeeded
+		}
+	}
+
+	maxOutputs := mp.cfg.Policy.MaxRevokedKeyIDOutputs
+	for _, keyID := range revoked {
+		var count int
+		for _, op := range keyIDOutPoints[keyID] {
+			if _, spent := mp.outpoints[op]; !spent {
+				count++
+			}
+		}
+		for txHash, txD := range mp.pool {
+			for i, txOut := range txD.Tx.MsgTx().TxOut {
+				op := wire.OutPoint{Hash: txHash, Index: uint32(i)}
+				if _, spent := mp.outpoints[op]; spent {
+					continue
+				}
+				pops, err := txscript.ParseScript(txOut.PkScript)
+				if err != nil {
+					continue
+				}
+				scriptKeyIDs, err := txscript.ExtractKeyIDs(pops)
+				if err != nil {
+					continue
+				}
+				for _, scriptKeyID := range scriptKeyIDs {
+					if scriptKeyID == keyID {
+						count++
+						break
+					}
+				}
+			}
+		}
+		if count > maxOutputs {
+			str := fmt.Sprintf("admin transaction %v revokes ASP "+
+				"key ID %d which is still referenced by %d "+
+				"unspent outputs, more than the %d allowed -- "+
+				"sweep them to another key ID first", tx.Hash(),
+				keyID, count, maxOutputs)
+			return txRuleError(wire.RejectNonstandard, str)
+		}
+	}
+	return nil
+}
+
 // isTransactionInPool returns whether or not the passed transaction already
 // exists in the main pool.
 //
@@ -437,16 +674,39 @@ func (mp *TxPool) IsOrphanInPool(hash *chainhash.Hash) bool {
 	return inPool
 }
 
+// isAdminTxHeld returns whether or not the passed admin transaction is held
+// until the thread tip it spends has the minimum number of confirmations.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) isAdminTxHeld(hash *chainhash.Hash) bool {
+	_, exists := mp.heldAdminTxns[*hash]
+	return exists
+}
+
+// IsAdminTxHeld returns whether or not the passed admin transaction is held
+// until the thread tip it spends has the minimum number of confirmations.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) IsAdminTxHeld(hash *chainhash.Hash) bool {
+	// Protect concurrent access.
+	mp.mtx.RLock()
+	held := mp.isAdminTxHeld(hash)
+	mp.mtx.RUnlock()
+
+	return held
+}
+
 // haveTransaction returns whether or not the passed transaction already exists
-// in the main pool or in the orphan pool.
+// in the main pool, in the orphan pool or among the held admin transactions.
 //
 // This function MUST be called with the mempool lock held (for reads).
 func (mp *TxPool) haveTransaction(hash *chainhash.Hash) bool {
-	return mp.isTransactionInPool(hash) || mp.isOrphanInPool(hash)
+	return mp.isTransactionInPool(hash) || mp.isOrphanInPool(hash) ||
+		mp.isAdminTxHeld(hash)
 }
 
 // HaveTransaction returns whether or not the passed transaction already exists
-// in the main pool or in the orphan pool.
+// in the main pool, in the orphan pool or among the held admin transactions.
 //
 // This function is safe for concurrent access.
 func (mp *TxPool) HaveTransaction(hash *chainhash.Hash) bool {
@@ -482,12 +742,177 @@ func (mp *TxPool) removeTransaction(tx *provautil.Tx, removeRedeemers bool) {
 			mp.cfg.AddrIndex.RemoveUnconfirmedTx(txHash)
 		}
 
+		// Look up the related transactions in the pool before the
+		// transaction is unlinked so their package statistics can be
+		// updated once it is gone.
+		ancestors := mp.txAncestors(tx)
+		descendants := mp.txDescendants(tx)
+
 		// Mark the referenced outpoints as unspent by the pool.
 		for _, txIn := range txDesc.Tx.MsgTx().TxIn {
 			delete(mp.outpoints, txIn.PreviousOutPoint)
 		}
 		delete(mp.pool, *txHash)
-		atomic.StoreInt64(&mp.lastUpdated, time.Now().Unix())
+		delete(mp.feeDeltas, *txHash)
+		mp.updatePackageStats(txDesc, ancestors, descendants, false)
+		for i, adminTx := range mp.adminTxns {
+			if adminTx.Hash().IsEqual(txHash) {
+				mp.adminTxns = append(mp.adminTxns[:i],
+					mp.adminTxns[i+1:]...)
+				break
+			}
+		}
+		atomic.StoreInt64(&mp.lastUpdated, mp.cfg.Now().Unix())
+	}
+}
+
+// txParents returns the descriptors of all transactions in the main pool
+// which the passed transaction directly spends outputs from.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) txParents(tx *provautil.Tx) map[chainhash.Hash]*TxDesc {
+	parents := make(map[chainhash.Hash]*TxDesc)
+	for _, txIn := range tx.MsgTx().TxIn {
+		originHash := txIn.PreviousOutPoint.Hash
+		if txDesc, exists := mp.pool[originHash]; exists {
+			parents[originHash] = txDesc
+		}
+	}
+	return parents
+}
+
+// txChildren returns the descriptors of all transactions in the main pool
+// which directly spend outputs of the passed transaction.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) txChildren(tx *provautil.Tx) map[chainhash.Hash]*TxDesc {
+	children := make(map[chainhash.Hash]*TxDesc)
+	prevOut := wire.OutPoint{Hash: *tx.Hash()}
+	for txOutIdx := range tx.MsgTx().TxOut {
+		prevOut.Index = uint32(txOutIdx)
+		txRedeemer, exists := mp.outpoints[prevOut]
+		if !exists {
+			continue
+		}
+		if txDesc, exists := mp.pool[*txRedeemer.Hash()]; exists {
+			children[*txRedeemer.Hash()] = txDesc
+		}
+	}
+	return children
+}
+
+// txAncestors returns the descriptors of all transactions in the main pool
+// the passed transaction depends on, directly or through other transactions
+// in the pool.  The passed transaction itself is not included.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) txAncestors(tx *provautil.Tx) map[chainhash.Hash]*TxDesc {
+	ancestors := make(map[chainhash.Hash]*TxDesc)
+	processList := list.New()
+	processList.PushBack(tx)
+	for processList.Len() > 0 {
+		processItem := processList.Remove(processList.Front()).(*provautil.Tx)
+		for hash, txDesc := range mp.txParents(processItem) {
+			if _, exists := ancestors[hash]; exists {
+				continue
+			}
+			ancestors[hash] = txDesc
+			processList.PushBack(txDesc.Tx)
+		}
+	}
+	return ancestors
+}
+
+// txDescendants returns the descriptors of all transactions in the main pool
+// which depend on the passed transaction, directly or through other
+// transactions in the pool.  The passed transaction itself is not included.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) txDescendants(tx *provautil.Tx) map[chainhash.Hash]*TxDesc {
+	descendants := make(map[chainhash.Hash]*TxDesc)
+	processList := list.New()
+	processList.PushBack(tx)
+	for processList.Len() > 0 {
+		processItem := processList.Remove(processList.Front()).(*provautil.Tx)
+		for hash, txDesc := range mp.txChildren(processItem) {
+			if _, exists := descendants[hash]; exists {
+				continue
+			}
+			descendants[hash] = txDesc
+			processList.PushBack(txDesc.Tx)
+		}
+	}
+	return descendants
+}
+
+// calcAncestorStats recalculates the ancestor package statistics of the
+// passed descriptor from the current contents of the main pool.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) calcAncestorStats(txD *TxDesc) {
+	txD.AncestorCount = 1
+	txD.AncestorSize = int64(txD.Tx.MsgTx().SerializeSize())
+	txD.AncestorFee = txD.Fee
+	for _, ancestor := range mp.txAncestors(txD.Tx) {
+		txD.AncestorCount++
+		txD.AncestorSize += int64(ancestor.Tx.MsgTx().SerializeSize())
+		txD.AncestorFee += ancestor.Fee
+	}
+}
+
+// calcDescendantStats recalculates the descendant package statistics of the
+// passed descriptor from the current contents of the main pool.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) calcDescendantStats(txD *TxDesc) {
+	txD.DescendantCount = 1
+	txD.DescendantSize = int64(txD.Tx.MsgTx().SerializeSize())
+	txD.DescendantFee = txD.Fee
+	for _, descendant := range mp.txDescendants(txD.Tx) {
+		txD.DescendantCount++
+		txD.DescendantSize += int64(descendant.Tx.MsgTx().SerializeSize())
+		txD.DescendantFee += descendant.Fee
+	}
+}
+
+// updatePackageStats updates the package statistics of the passed ancestors
+// and descendants of a transaction which was just added to or removed from the
+// main pool according to the added flag.
+//
+// In the common case the transaction only has ancestors (it was just accepted,
+// or its descendants were already removed) or only has descendants (it was
+// just mined), so the statistics are adjusted by the transaction alone.  A
+// transaction with both links others through it, which happens when
+// transactions from disconnected blocks are added back to the pool, so the
+// affected statistics are recalculated instead.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) updatePackageStats(txD *TxDesc, ancestors, descendants map[chainhash.Hash]*TxDesc, added bool) {
+	if len(ancestors) > 0 && len(descendants) > 0 {
+		for _, ancestor := range ancestors {
+			mp.calcDescendantStats(ancestor)
+		}
+		for _, descendant := range descendants {
+			mp.calcAncestorStats(descendant)
+		}
+		return
+	}
+
+	count := int64(1)
+	size := int64(txD.Tx.MsgTx().SerializeSize())
+	fee := txD.Fee
+	if !added {
+		count, size, fee = -count, -size, -fee
+	}
+	for _, ancestor := range ancestors {
+		ancestor.DescendantCount += count
+		ancestor.DescendantSize += size
+		ancestor.DescendantFee += fee
+	}
+	for _, descendant := range descendants {
+		descendant.AncestorCount += count
+		descendant.AncestorSize += size
+		descendant.AncestorFee += fee
 	}
 }
 
@@ -536,7 +961,7 @@ func (mp *TxPool) addTransaction(utxoView *blockchain.UtxoViewpoint, tx *provaut
 	txD := &TxDesc{
 		TxDesc: mining.TxDesc{
 			Tx:       tx,
-			Added:    time.Now(),
+			Added:    mp.cfg.Now(),
 			Height:   height,
 			Fee:      fee,
 			FeePerKB: fee * 1000 / int64(tx.MsgTx().SerializeSize()),
@@ -548,7 +973,17 @@ func (mp *TxPool) addTransaction(utxoView *blockchain.UtxoViewpoint, tx *provaut
 	for _, txIn := range tx.MsgTx().TxIn {
 		mp.outpoints[txIn.PreviousOutPoint] = tx
 	}
-	atomic.StoreInt64(&mp.lastUpdated, time.Now().Unix())
+	if threadInt, _ := txscript.GetAdminDetails(tx); threadInt >= 0 {
+		mp.adminTxns = append(mp.adminTxns, tx)
+	}
+
+	// Track the package statistics of the transaction and update those of
+	// any related transactions in the pool.
+	mp.calcAncestorStats(txD)
+	mp.calcDescendantStats(txD)
+	mp.updatePackageStats(txD, mp.txAncestors(tx), mp.txDescendants(tx),
+		true)
+	atomic.StoreInt64(&mp.lastUpdated, mp.cfg.Now().Unix())
 
 	// Add unconfirmed address index entries associated with the transaction
 	// if enabled.
@@ -603,6 +1038,29 @@ func (mp *TxPool) fetchInputUtxos(tx *provautil.Tx) (*blockchain.UtxoViewpoint,
 	return utxoView, nil
 }
 
+// fetchKeyView returns a key view from the point of view of the main chain
+// with the operations of all admin transactions in the pool applied in the
+// order they were accepted.  This allows transactions which depend on admin
+// state that is not yet confirmed, such as paying to an address which uses a
+// newly provisioned keyID, to be accepted.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) fetchKeyView(height uint32) *blockchain.KeyViewpoint {
+	keyView := blockchain.NewKeyViewpoint()
+	keyView.SetThreadTips(mp.cfg.ThreadTips())
+	keyView.SetTotalSupply(mp.cfg.TotalSupply())
+	keyView.SetLastKeyID(mp.cfg.LastKeyID())
+	keyView.SetKeyIDs(mp.cfg.GetKeyIDs())
+	keyView.SetKeys(mp.cfg.GetAdminKeySets())
+	keyView.SetIssueDests(mp.cfg.GetIssueDests())
+	keyView.SetFrozen(mp.cfg.GetFrozen())
+	keyView.SetDelegates(mp.cfg.GetDelegates())
+	for _, tx := range mp.adminTxns {
+		keyView.ProcessAdminOuts(tx, height, mp.cfg.ChainParams)
+	}
+	return keyView
+}
+
 // FetchTransaction returns the requested transaction from the transaction pool.
 // This only fetches from the main transaction pool and does not include
 // orphans.
@@ -621,20 +1079,54 @@ func (mp *TxPool) FetchTransaction(txHash *chainhash.Hash) (*provautil.Tx, error
 	return nil, fmt.Errorf("transaction is not in the pool")
 }
 
+// FetchHeldAdminTx returns the requested admin transaction from the admin
+// transactions held until the thread tip they spend has the minimum number of
+// confirmations.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) FetchHeldAdminTx(txHash *chainhash.Hash) (*provautil.Tx, error) {
+	// Protect concurrent access.
+	mp.mtx.RLock()
+	tx, exists := mp.heldAdminTxns[*txHash]
+	mp.mtx.RUnlock()
+
+	if exists {
+		return tx, nil
+	}
+
+	return nil, fmt.Errorf("admin transaction is not held")
+}
+
+// CheckSpend checks whether the passed outpoint is already spent by a
+// transaction in the mempool.  If that's the case the spending transaction will
+// be returned, if not nil will be returned.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) CheckSpend(op wire.OutPoint) *provautil.Tx {
+	mp.mtx.RLock()
+	txR := mp.outpoints[op]
+	mp.mtx.RUnlock()
+
+	return txR
+}
+
 // maybeAcceptTransaction is the internal function which implements the public
 // MaybeAcceptTransaction.  See the comment for MaybeAcceptTransaction for
-// more details.
+// more details.  The passed scan holds the unspent outputs of the main chain
+// referencing the keyIDs revoked by the transaction, and errKeyIDScanNeeded
+// is returned when it lacks any of them.  Use maybeAcceptTransactionScan to
+// scan them as needed.
 //
 // This function MUST be called with the mempool lock held (for writes).
-func (mp *TxPool) maybeAcceptTransaction(tx *provautil.Tx, isNew, rateLimit bool, rejectDupOrphans bool) ([]*chainhash.Hash, *TxDesc, error) {
+func (mp *TxPool) maybeAcceptTransaction(tx *provautil.Tx, isNew, rateLimit bool, rejectDupOrphans bool, keyIDOutPoints map[btcec.KeyID][]wire.OutPoint) ([]*chainhash.Hash, *TxDesc, error) {
 	txHash := tx.Hash()
 
 	// Don't accept the transaction if it already exists in the pool.  This
 	// applies to orphan transactions as well when the reject duplicate
 	// orphans flag is set.  This check is intended to be a quick check to
 	// weed out duplicates.
-	if mp.isTransactionInPool(txHash) || (rejectDupOrphans &&
-		mp.isOrphanInPool(txHash)) {
+	if mp.isTransactionInPool(txHash) || mp.isAdminTxHeld(txHash) ||
+		(rejectDupOrphans && mp.isOrphanInPool(txHash)) {
 
 		str := fmt.Sprintf("already have transaction %v", txHash)
 		return nil, nil, txRuleError(wire.RejectDuplicate, str)
@@ -680,8 +1172,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *provautil.Tx, isNew, rateLimit bool
 	// forbid their acceptance.
 	if !mp.cfg.Policy.AcceptNonStd {
 		err = checkTransactionStandard(tx, nextBlockHeight,
-			medianTimePast, mp.cfg.Policy.MinRelayTxFee,
-			mp.cfg.Policy.MaxTxVersion)
+			medianTimePast, &mp.cfg.Policy, mp.cfg.ChainParams)
 		if err != nil {
 			// Attempt to extract a reject code from the error so
 			// it can be retained.  When not possible, fall back to
@@ -721,13 +1212,9 @@ func (mp *TxPool) maybeAcceptTransaction(tx *provautil.Tx, isNew, rateLimit bool
 		return nil, nil, err
 	}
 
-	// Set the data for the keyview from chain
-	keyView := blockchain.NewKeyViewpoint()
-	keyView.SetThreadTips(mp.cfg.ThreadTips())
-	keyView.SetTotalSupply(mp.cfg.TotalSupply())
-	keyView.SetLastKeyID(mp.cfg.LastKeyID())
-	keyView.SetKeyIDs(mp.cfg.GetKeyIDs())
-	keyView.SetKeys(mp.cfg.GetAdminKeySets())
+	// Set the data for the keyview from chain and the admin transactions
+	// already in the pool.
+	keyView := mp.fetchKeyView(nextBlockHeight)
 
 	// Don't allow the transaction if it exists in the main chain and is not
 	// not already fully spent.
@@ -773,6 +1260,16 @@ func (mp *TxPool) maybeAcceptTransaction(tx *provautil.Tx, isNew, rateLimit bool
 			"transaction's sequence locks on inputs not met")
 	}
 
+	// Asset markers must be well formed once recognised.
+	err = blockchain.CheckTransactionAssetMarkers(tx, nextBlockHeight,
+		mp.cfg.ChainParams)
+	if err != nil {
+		if cerr, ok := err.(blockchain.RuleError); ok {
+			return nil, nil, chainRuleError(cerr)
+		}
+		return nil, nil, err
+	}
+
 	// Perform several checks on the transaction inputs using the invariant
 	// rules in blockchain for what transactions are allowed into blocks.
 	// Also returns the fees associated with the transaction which will be
@@ -786,8 +1283,30 @@ func (mp *TxPool) maybeAcceptTransaction(tx *provautil.Tx, isNew, rateLimit bool
 		return nil, nil, err
 	}
 
+	// Outputs of frozen accounts must not be spent.
+	err = blockchain.CheckTransactionFrozen(tx, nextBlockHeight, utxoView,
+		keyView, mp.cfg.ChainParams)
+	if err != nil {
+		if cerr, ok := err.(blockchain.RuleError); ok {
+			return nil, nil, chainRuleError(cerr)
+		}
+		return nil, nil, err
+	}
+
+	// Destructions may only return change to the destroyed outputs or
+	// allowlisted destinations.
+	err = blockchain.CheckIssueChange(tx, nextBlockHeight, utxoView,
+		keyView, mp.cfg.ChainParams)
+	if err != nil {
+		if cerr, ok := err.(blockchain.RuleError); ok {
+			return nil, nil, chainRuleError(cerr)
+		}
+		return nil, nil, err
+	}
+
 	// CheckTransactionOutputs checks outputs for state violations.
-	err = blockchain.CheckTransactionOutputs(tx, keyView, mp.cfg.ChainParams)
+	err = blockchain.CheckTransactionOutputs(tx, nextBlockHeight, keyView,
+		mp.cfg.ChainParams)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -810,6 +1329,14 @@ func (mp *TxPool) maybeAcceptTransaction(tx *provautil.Tx, isNew, rateLimit bool
 		}
 	}
 
+	// Don't allow transactions rejected by the admission policies of the
+	// embedder.  Unlike the standardness checks, they are enforced even
+	// when non-standard transactions are accepted.
+	err = checkAdmissionPolicies(tx, utxoView, mp.cfg.AdmissionPolicies)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// TODO(prova) : validate admin ops here
 
 	// NOTE: if you modify this code to accept non-standard transactions,
@@ -828,6 +1355,17 @@ func (mp *TxPool) maybeAcceptTransaction(tx *provautil.Tx, isNew, rateLimit bool
 		}
 		return nil, nil, err
 	}
+	if mp.cfg.ChainParams.ProvaSigOpsActive(nextBlockHeight) {
+		numProvaSigOps, err := blockchain.CountProvaSigOps(tx, false,
+			utxoView)
+		if err != nil {
+			if cerr, ok := err.(blockchain.RuleError); ok {
+				return nil, nil, chainRuleError(cerr)
+			}
+			return nil, nil, err
+		}
+		numSigOps += numProvaSigOps
+	}
 	numSigOps += blockchain.CountSigOps(tx)
 	if numSigOps > mp.cfg.Policy.MaxSigOpsPerTx {
 		str := fmt.Sprintf("transaction %v has too many sigops: %d > %d",
@@ -874,7 +1412,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *provautil.Tx, isNew, rateLimit bool
 	// Free-to-relay transactions are rate limited here to prevent
 	// penny-flooding with tiny transactions as a form of attack.
 	if rateLimit && txFee < minFee {
-		nowUnix := time.Now().Unix()
+		nowUnix := mp.cfg.Now().Unix()
 		// Decay passed data with an exponentially decaying ~10 minute
 		// window - matches bitcoind handling.
 		mp.pennyTotal *= math.Pow(1.0-1.0/600.0,
@@ -896,9 +1434,16 @@ func (mp *TxPool) maybeAcceptTransaction(tx *provautil.Tx, isNew, rateLimit bool
 	}
 
 	// Verify crypto signatures for each input and reject the transaction if
-	// any don't verify.
-	err = blockchain.ValidateTransactionScripts(tx, utxoView, keyView,
-		txscript.StandardVerifyFlags, mp.cfg.SigCache, mp.cfg.HashCache)
+	// any don't verify.  The signatures of the keys of keyIDs are verified
+	// once OP_CHECKKEYIDVERIFY is active at the height of the next block,
+	// and the opcode is reserved for the upgrade before.
+	scriptFlags := txscript.StandardVerifyFlags
+	if mp.cfg.ChainParams.CheckKeyIDVerifyActive(nextBlockHeight) {
+		scriptFlags |= txscript.ScriptVerifyCheckKeyIDVerify
+	}
+	err = blockchain.ValidateTransactionScripts(tx, nextBlockHeight,
+		utxoView, keyView, mp.cfg.ChainParams, scriptFlags,
+		mp.cfg.SigCache, mp.cfg.HashCache)
 	if err != nil {
 		if cerr, ok := err.(blockchain.RuleError); ok {
 			return nil, nil, chainRuleError(cerr)
@@ -906,6 +1451,34 @@ func (mp *TxPool) maybeAcceptTransaction(tx *provautil.Tx, isNew, rateLimit bool
 		return nil, nil, err
 	}
 
+	// Don't allow admin transactions which revoke ASP keys whose keyIDs are
+	// still referenced by unspent outputs, which would strand their funds.
+	// Like the admission policies, this is enforced even when non-standard
+	// transactions are accepted.
+	if err := mp.checkKeyIDRevocations(tx, keyIDOutPoints); err != nil {
+		return nil, nil, err
+	}
+
+	// Hold admin transactions which chain off a thread tip that does not
+	// have the minimum number of confirmations of the policy yet.  They are
+	// accepted by ProcessHeldAdminTxns once it does.
+	if mp.mustHoldAdminTx(tx, utxoView, nextBlockHeight) {
+		if len(mp.heldAdminTxns) >= maxHeldAdminTxns {
+			str := fmt.Sprintf("admin transaction %v spends a "+
+				"thread tip with fewer than %d confirmations "+
+				"and too many admin transactions are held "+
+				"already", txHash,
+				mp.cfg.Policy.MinAdminTxConfirmations)
+			return nil, nil, txRuleError(wire.RejectNonstandard, str)
+		}
+		mp.heldAdminTxns[*txHash] = tx
+
+		log.Debugf("Holding admin transaction %v until the thread tip "+
+			"it spends has %d confirmations", txHash,
+			mp.cfg.Policy.MinAdminTxConfirmations)
+		return nil, nil, nil
+	}
+
 	// Add to transaction pool.
 	txD := mp.addTransaction(utxoView, tx, bestHeight, txFee)
 
@@ -925,16 +1498,65 @@ func (mp *TxPool) maybeAcceptTransaction(tx *provautil.Tx, isNew, rateLimit bool
 // parent is returned.  Use ProcessTransaction instead if new orphans should
 // be added to the orphan pool.
 //
+// If the transaction is an admin transaction which spends a thread tip with
+// fewer than the minimum number of confirmations of the policy, it is held
+// until ProcessHeldAdminTxns accepts it, and neither missing parents nor a
+// transaction descriptor are returned.
+//
 // This function is safe for concurrent access.
 func (mp *TxPool) MaybeAcceptTransaction(tx *provautil.Tx, isNew, rateLimit bool) ([]*chainhash.Hash, *TxDesc, error) {
 	// Protect concurrent access.
 	mp.mtx.Lock()
-	hashes, txD, err := mp.maybeAcceptTransaction(tx, isNew, rateLimit, true)
+	hashes, txD, err := mp.maybeAcceptTransactionScan(tx, isNew, rateLimit,
+		true, true)
 	mp.mtx.Unlock()
 
 	return hashes, txD, err
 }
 
+// maybeAcceptTransactionScan calls maybeAcceptTransaction, scanning the unspent
+// outputs of the main chain which reference the keyIDs revoked by the
+// transaction when it needs them.  The scan, a full pass over the utxo set, is
+// only made once the transaction has passed all other checks.  When
+// releaseLock is set, the mempool lock is released during the scan, so the
+// pool is not blocked by it, and the transaction is checked again against the
+// pool as it is afterwards.  Callers which must not let the pool change while
+// they run scan with the lock held instead.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) maybeAcceptTransactionScan(tx *provautil.Tx, isNew, rateLimit, rejectDupOrphans, releaseLock bool) ([]*chainhash.Hash, *TxDesc, error) {
+	missingParents, txD, err := mp.maybeAcceptTransaction(tx, isNew,
+		rateLimit, rejectDupOrphans, nil)
+	if err != errKeyIDScanNeeded {
+		return missingParents, txD, err
+	}
+
+	limit := mp.keyIDScanLimit()
+	if releaseLock {
+		mp.mtx.Unlock()
+	}
+	keyIDOutPoints, err := mp.scanKeyIDOutPoints(mp.revokedKeyIDs(tx),
+		limit)
+	if releaseLock {
+		mp.mtx.Lock()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The scan may stop too early for the pool when it has grown while
+	// the lock was released, so scan again with the lock held then.
+	if poolLimit := mp.keyIDScanLimit(); poolLimit > limit {
+		keyIDOutPoints, err = mp.scanKeyIDOutPoints(
+			mp.revokedKeyIDs(tx), poolLimit)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return mp.maybeAcceptTransaction(tx, isNew, rateLimit,
+		rejectDupOrphans, keyIDOutPoints)
+}
+
 // processOrphans is the internal function which implements the public
 // ProcessOrphans.  See the comment for ProcessOrphans for more details.
 //
@@ -971,8 +1593,8 @@ func (mp *TxPool) processOrphans(acceptedTx *provautil.Tx) []*TxDesc {
 
 			// Potentially accept an orphan into the tx pool.
 			for _, tx := range orphans {
-				missing, txD, err := mp.maybeAcceptTransaction(
-					tx, true, true, false)
+				missing, txD, err := mp.maybeAcceptTransactionScan(
+					tx, true, true, false, false)
 				if err != nil {
 					// The orphan is now invalid, so there
 					// is no way any other orphans which
@@ -988,6 +1610,13 @@ func (mp *TxPool) processOrphans(acceptedTx *provautil.Tx) []*TxDesc {
 					continue
 				}
 
+				// Transaction is an admin transaction which is
+				// held now, so it is no longer an orphan.
+				if txD == nil {
+					mp.removeOrphan(tx, false)
+					break
+				}
+
 				// Transaction was accepted into the main pool.
 				//
 				// Add it to the list of accepted transactions
@@ -1036,6 +1665,56 @@ func (mp *TxPool) ProcessOrphans(acceptedTx *provautil.Tx) []*TxDesc {
 	return acceptedTxns
 }
 
+// ProcessHeldAdminTxns retries the admin transactions held until the thread
+// tips they spend have the minimum number of confirmations of the policy.  It
+// should be called whenever a block is connected to the main chain.  Held
+// transactions which are no longer valid, such as those which were mined or
+// whose thread tip was spent by another transaction, are dropped.
+//
+// It returns a slice of transactions added to the mempool, including the
+// orphans which were accepted as a result.  A nil slice means no held
+// transactions were accepted.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) ProcessHeldAdminTxns() []*TxDesc {
+	mp.mtx.Lock()
+	defer mp.mtx.Unlock()
+
+	if len(mp.heldAdminTxns) == 0 {
+		return nil
+	}
+
+	// Transactions which still spend a thread tip with too few
+	// confirmations are held again.
+	held := mp.heldAdminTxns
+	mp.heldAdminTxns = make(map[chainhash.Hash]*provautil.Tx, len(held))
+
+	var acceptedTxns []*TxDesc
+	for _, tx := range held {
+		missing, txD, err := mp.maybeAcceptTransactionScan(tx, false,
+			false, true, false)
+		if err != nil {
+			log.Debugf("Dropping held admin transaction %v: %v",
+				tx.Hash(), err)
+			continue
+		}
+		if len(missing) > 0 {
+			log.Debugf("Dropping held admin transaction %v which "+
+				"spends unknown or fully-spent transaction %v",
+				tx.Hash(), missing[0])
+			continue
+		}
+		if txD == nil {
+			continue
+		}
+
+		acceptedTxns = append(acceptedTxns, txD)
+		acceptedTxns = append(acceptedTxns, mp.processOrphans(tx)...)
+	}
+
+	return acceptedTxns
+}
+
 // ProcessTransaction is the main workhorse for handling insertion of new
 // free-standing transactions into the memory pool.  It includes functionality
 // such as rejecting duplicate transactions, ensuring transactions follow all
@@ -1044,7 +1723,9 @@ func (mp *TxPool) ProcessOrphans(acceptedTx *provautil.Tx) []*TxDesc {
 // It returns a slice of transactions added to the mempool.  When the
 // error is nil, the list will include the passed transaction itself along
 // with any additional orphan transaactions that were added as a result of
-// the passed one being accepted.
+// the passed one being accepted.  The list is empty when the passed
+// transaction is an orphan or an admin transaction which is held until the
+// thread tip it spends has the minimum number of confirmations of the policy.
 //
 // This function is safe for concurrent access.
 func (mp *TxPool) ProcessTransaction(tx *provautil.Tx, allowOrphan, rateLimit bool, tag Tag) ([]*TxDesc, error) {
@@ -1055,12 +1736,18 @@ func (mp *TxPool) ProcessTransaction(tx *provautil.Tx, allowOrphan, rateLimit bo
 	defer mp.mtx.Unlock()
 
 	// Potentially accept the transaction to the memory pool.
-	missingParents, txD, err := mp.maybeAcceptTransaction(tx, true, rateLimit,
-		true)
+	missingParents, txD, err := mp.maybeAcceptTransactionScan(tx, true,
+		rateLimit, true, true)
 	if err != nil {
 		return nil, err
 	}
 
+	// The transaction is an admin transaction which is held until the
+	// thread tip it spends has enough confirmations.
+	if len(missingParents) == 0 && txD == nil {
+		return nil, nil
+	}
+
 	if len(missingParents) == 0 {
 		// Accept any orphan transactions that depend on this
 		// transaction (they may no longer be orphans if all inputs
@@ -1100,6 +1787,124 @@ func (mp *TxPool) ProcessTransaction(tx *provautil.Tx, allowOrphan, rateLimit bo
 	return nil, err
 }
 
+// checkPackageSanity performs package-level checks on the passed transactions
+// that do not depend on the contents of the pool or the main chain.  The
+// package must not be empty or exceed the maximum allowed size, must not
+// contain duplicate or conflicting transactions, and must be ordered so that
+// each transaction only spends outputs of transactions that precede it.
+func checkPackageSanity(txns []*provautil.Tx) error {
+	if len(txns) == 0 {
+		return txRuleError(wire.RejectInvalid, "package is empty")
+	}
+	if len(txns) > MaxPackageTxns {
+		str := fmt.Sprintf("package contains %d transactions which is "+
+			"more than the max allowed of %d", len(txns),
+			MaxPackageTxns)
+		return txRuleError(wire.RejectNonstandard, str)
+	}
+
+	positions := make(map[chainhash.Hash]int, len(txns))
+	for i, tx := range txns {
+		if _, exists := positions[*tx.Hash()]; exists {
+			str := fmt.Sprintf("package contains duplicate "+
+				"transaction %v", tx.Hash())
+			return txRuleError(wire.RejectDuplicate, str)
+		}
+		positions[*tx.Hash()] = i
+	}
+
+	spent := make(map[wire.OutPoint]struct{})
+	for i, tx := range txns {
+		for _, txIn := range tx.MsgTx().TxIn {
+			prevOut := txIn.PreviousOutPoint
+			if _, exists := spent[prevOut]; exists {
+				str := fmt.Sprintf("package transaction %v "+
+					"double spends output %v", tx.Hash(),
+					prevOut)
+				return txRuleError(wire.RejectDuplicate, str)
+			}
+			spent[prevOut] = struct{}{}
+
+			if pos, exists := positions[prevOut.Hash]; exists && pos >= i {
+				str := fmt.Sprintf("package transaction %v "+
+					"spends output of transaction %v which "+
+					"does not precede it", tx.Hash(),
+					prevOut.Hash)
+				return txRuleError(wire.RejectInvalid, str)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ProcessPackage attempts to atomically accept a package of dependent
+// transactions into the memory pool.  The transactions are validated in the
+// passed order, each one from the point of view of the main chain, the pool,
+// and the transactions which precede it in the package.  This allows
+// multi-step admin flows, such as provisioning an ASP key and then issuing to
+// an address which uses it, to be submitted as a single unit.
+//
+// Either all of the transactions are accepted or none of them are.  Orphans
+// are not allowed, so every input must reference the main chain, the pool, or
+// an earlier transaction in the package.
+//
+// It returns a slice of transactions added to the mempool.  When the error is
+// nil, the list will include the package transactions in the passed order
+// followed by any orphan transactions that were added as a result.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) ProcessPackage(txns []*provautil.Tx, rateLimit bool) ([]*TxDesc, error) {
+	log.Tracef("Processing package of %d transactions", len(txns))
+
+	if err := checkPackageSanity(txns); err != nil {
+		return nil, err
+	}
+
+	// Protect concurrent access.
+	mp.mtx.Lock()
+	defer mp.mtx.Unlock()
+
+	acceptedTxs := make([]*TxDesc, 0, len(txns))
+	for _, tx := range txns {
+		missingParents, txD, err := mp.maybeAcceptTransactionScan(tx,
+			true, rateLimit, true, false)
+		if err == nil && len(missingParents) > 0 {
+			str := fmt.Sprintf("package transaction %v references "+
+				"outputs of unknown or fully-spent "+
+				"transaction %v", tx.Hash(), missingParents[0])
+			err = txRuleError(wire.RejectDuplicate, str)
+		}
+
+		// Admin transactions can not be held as part of a package, since
+		// it is accepted atomically.
+		if err == nil && txD == nil {
+			delete(mp.heldAdminTxns, *tx.Hash())
+			str := fmt.Sprintf("package admin transaction %v spends "+
+				"a thread tip with fewer than %d confirmations",
+				tx.Hash(), mp.cfg.Policy.MinAdminTxConfirmations)
+			err = txRuleError(wire.RejectNonstandard, str)
+		}
+		if err != nil {
+			// Remove the transactions accepted so far in reverse
+			// order so the package is not left partially applied.
+			for i := len(acceptedTxs) - 1; i >= 0; i-- {
+				mp.removeTransaction(acceptedTxs[i].Tx, false)
+			}
+			return nil, err
+		}
+		acceptedTxs = append(acceptedTxs, txD)
+	}
+
+	// Accept any orphan transactions that depend on the package
+	// transactions now that they are all in the pool.
+	for _, tx := range txns {
+		acceptedTxs = append(acceptedTxs, mp.processOrphans(tx)...)
+	}
+
+	return acceptedTxs, nil
+}
+
 // Count returns the number of transactions in the main pool.  It does not
 // include the orphan pool.
 //
@@ -1150,14 +1955,19 @@ func (mp *TxPool) TxDescs() []*TxDesc {
 // MiningDescs returns a slice of mining descriptors for all the transactions
 // in the pool.
 //
+// The returned descriptors are copies since the package statistics they carry
+// change as related transactions enter and leave the pool.
+//
 // This is part of the mining.TxSource interface implementation and is safe for
 // concurrent access as required by the interface contract.
 func (mp *TxPool) MiningDescs() []*mining.TxDesc {
 	mp.mtx.RLock()
 	descs := make([]*mining.TxDesc, len(mp.pool))
 	i := 0
-	for _, desc := range mp.pool {
-		descs[i] = &desc.TxDesc
+	for hash, desc := range mp.pool {
+		descCopy := desc.TxDesc
+		descCopy.FeeDelta = mp.feeDeltas[hash]
+		descs[i] = &descCopy
 		i++
 	}
 	mp.mtx.RUnlock()
@@ -1165,6 +1975,96 @@ func (mp *TxPool) MiningDescs() []*mining.TxDesc {
 	return descs
 }
 
+// PrioritiseTransaction adds the passed delta in atoms to the fee delta of the
+// transaction with the passed hash and returns the resulting fee delta.  The
+// fee delta is added to the fee of the transaction when transactions are
+// selected for block templates, so a positive delta expedites the transaction
+// and a delta which makes its fee negative leaves it out of the templates.  The
+// delta is not paid and does not affect the acceptance of the transaction to
+// the pool.  It may be set before the transaction is accepted and is dropped
+// once the transaction leaves the pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) PrioritiseTransaction(txHash *chainhash.Hash, delta int64) int64 {
+	mp.mtx.Lock()
+	feeDelta := mp.feeDeltas[*txHash] + delta
+	if feeDelta == 0 {
+		delete(mp.feeDeltas, *txHash)
+	} else {
+		mp.feeDeltas[*txHash] = feeDelta
+	}
+	mp.mtx.Unlock()
+
+	// Mark the pool as updated so cached block templates are regenerated.
+	atomic.StoreInt64(&mp.lastUpdated, mp.cfg.Now().Unix())
+	return feeDelta
+}
+
+// mempoolEntry returns the details of the passed entry of the main pool,
+// including its links to the other transactions in the pool.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) mempoolEntry(desc *TxDesc, bestHeight uint32) *btcjson.GetMempoolEntryResult {
+	// Calculate the current priority based on the inputs to the
+	// transaction.  Use zero if one or more of the input transactions
+	// can't be found for some reason.
+	tx := desc.Tx
+	var currentPriority float64
+	utxos, err := mp.fetchInputUtxos(tx)
+	if err == nil {
+		currentPriority = mining.CalcPriority(tx.MsgTx(), utxos,
+			bestHeight+1)
+	}
+
+	// Admin transactions spend the tip of their thread with the first
+	// input.
+	threadInt, _ := txscript.GetAdminDetails(tx)
+
+	entry := &btcjson.GetMempoolEntryResult{
+		Size:              int32(tx.MsgTx().SerializeSize()),
+		Fee:               provautil.Amount(desc.Fee).ToDMG(),
+		ModifiedFee:       provautil.Amount(desc.Fee + mp.feeDeltas[*tx.Hash()]).ToDMG(),
+		Time:              desc.Added.Unix(),
+		Height:            int64(desc.Height),
+		StartingPriority:  desc.StartingPriority,
+		CurrentPriority:   currentPriority,
+		DescendantCount:   desc.DescendantCount,
+		DescendantSize:    desc.DescendantSize,
+		DescendantFees:    provautil.Amount(desc.DescendantFee).ToDMG(),
+		AncestorCount:     desc.AncestorCount,
+		AncestorSize:      desc.AncestorSize,
+		AncestorFees:      provautil.Amount(desc.AncestorFee).ToDMG(),
+		SpendsAdminThread: threadInt >= 0,
+		Depends:           make([]string, 0),
+		SpentBy:           make([]string, 0),
+	}
+	for hash := range mp.txParents(tx) {
+		entry.Depends = append(entry.Depends, hash.String())
+	}
+	for hash := range mp.txChildren(tx) {
+		entry.SpentBy = append(entry.SpentBy, hash.String())
+	}
+	sort.Strings(entry.Depends)
+	sort.Strings(entry.SpentBy)
+
+	return entry
+}
+
+// MempoolEntry returns the details of the transaction with the passed hash in
+// the main pool as a fully populated btcjson result.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) MempoolEntry(txHash *chainhash.Hash) (*btcjson.GetMempoolEntryResult, error) {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	desc, exists := mp.pool[*txHash]
+	if !exists {
+		return nil, fmt.Errorf("transaction is not in the pool")
+	}
+	return mp.mempoolEntry(desc, mp.cfg.BestHeight()), nil
+}
+
 // RawMempoolVerbose returns all of the entries in the mempool as a fully
 // populated btcjson result.
 //
@@ -1178,35 +2078,26 @@ func (mp *TxPool) RawMempoolVerbose() map[string]*btcjson.GetRawMempoolVerboseRe
 	bestHeight := mp.cfg.BestHeight()
 
 	for _, desc := range mp.pool {
-		// Calculate the current priority based on the inputs to
-		// the transaction.  Use zero if one or more of the
-		// input transactions can't be found for some reason.
-		tx := desc.Tx
-		var currentPriority float64
-		utxos, err := mp.fetchInputUtxos(tx)
-		if err == nil {
-			currentPriority = mining.CalcPriority(tx.MsgTx(), utxos,
-				bestHeight+1)
-		}
-
+		entry := mp.mempoolEntry(desc, bestHeight)
 		mpd := &btcjson.GetRawMempoolVerboseResult{
-			Size:             int32(tx.MsgTx().SerializeSize()),
-			Fee:              provautil.Amount(desc.Fee).ToDMG(),
-			Time:             desc.Added.Unix(),
-			Height:           int64(desc.Height),
-			StartingPriority: desc.StartingPriority,
-			CurrentPriority:  currentPriority,
-			Depends:          make([]string, 0),
-		}
-		for _, txIn := range tx.MsgTx().TxIn {
-			hash := &txIn.PreviousOutPoint.Hash
-			if mp.haveTransaction(hash) {
-				mpd.Depends = append(mpd.Depends,
-					hash.String())
-			}
+			Size:              entry.Size,
+			Fee:               entry.Fee,
+			Time:              entry.Time,
+			Height:            entry.Height,
+			StartingPriority:  entry.StartingPriority,
+			CurrentPriority:   entry.CurrentPriority,
+			DescendantCount:   entry.DescendantCount,
+			DescendantSize:    entry.DescendantSize,
+			DescendantFees:    entry.DescendantFees,
+			AncestorCount:     entry.AncestorCount,
+			AncestorSize:      entry.AncestorSize,
+			AncestorFees:      entry.AncestorFees,
+			SpendsAdminThread: entry.SpendsAdminThread,
+			Depends:           entry.Depends,
+			SpentBy:           entry.SpentBy,
 		}
 
-		result[tx.Hash().String()] = mpd
+		result[desc.Tx.Hash().String()] = mpd
 	}
 
 	return result
@@ -1220,15 +2111,57 @@ func (mp *TxPool) LastUpdated() time.Time {
 	return time.Unix(atomic.LoadInt64(&mp.lastUpdated), 0)
 }
 
+// RelayFees returns the minimum relay fee and the dust relay fee currently used
+// by the pool's policy.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) RelayFees() (provautil.Amount, provautil.Amount) {
+	mp.mtx.RLock()
+	minRelayTxFee := mp.cfg.Policy.MinRelayTxFee
+	dustRelayFee := mp.cfg.Policy.DustRelayFee
+	mp.mtx.RUnlock()
+
+	return minRelayTxFee, dustRelayFee
+}
+
+// SetRelayFees replaces the minimum relay fee and the dust relay fee used by
+// the pool's policy.  Transactions already in the pool are kept, and the new
+// fees only apply to transactions accepted afterwards.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) SetRelayFees(minRelayTxFee, dustRelayFee provautil.Amount) {
+	mp.mtx.Lock()
+	mp.cfg.Policy.MinRelayTxFee = minRelayTxFee
+	mp.cfg.Policy.DustRelayFee = dustRelayFee
+	mp.mtx.Unlock()
+}
+
+// AddAdmissionPolicy registers an additional policy which transactions must
+// satisfy to be accepted into the memory pool.  It only applies to the
+// transactions processed after it is registered.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) AddAdmissionPolicy(policy AdmissionPolicy) {
+	mp.mtx.Lock()
+	mp.cfg.AdmissionPolicies = append(mp.cfg.AdmissionPolicies, policy)
+	mp.mtx.Unlock()
+}
+
 // New returns a new memory pool for validating and storing standalone
 // transactions until they are mined into a block.
 func New(cfg *Config) *TxPool {
-	return &TxPool{
-		cfg:            *cfg,
-		pool:           make(map[chainhash.Hash]*TxDesc),
-		orphans:        make(map[chainhash.Hash]*orphanTx),
-		orphansByPrev:  make(map[wire.OutPoint]map[chainhash.Hash]*provautil.Tx),
-		nextExpireScan: time.Now().Add(orphanExpireScanInterval),
-		outpoints:      make(map[wire.OutPoint]*provautil.Tx),
+	mp := &TxPool{
+		cfg:           *cfg,
+		pool:          make(map[chainhash.Hash]*TxDesc),
+		orphans:       make(map[chainhash.Hash]*orphanTx),
+		orphansByPrev: make(map[wire.OutPoint]map[chainhash.Hash]*provautil.Tx),
+		outpoints:     make(map[wire.OutPoint]*provautil.Tx),
+		heldAdminTxns: make(map[chainhash.Hash]*provautil.Tx),
+		feeDeltas:     make(map[chainhash.Hash]int64),
+	}
+	if mp.cfg.Now == nil {
+		mp.cfg.Now = time.Now
 	}
+	mp.nextExpireScan = mp.cfg.Now().Add(orphanExpireScanInterval)
+	return mp
 }
@@ -12,8 +12,10 @@ import (
 	"github.com/pyx-partners/dmgd/btcec"
 	"github.com/pyx-partners/dmgd/chaincfg"
 	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/mining"
 	"github.com/pyx-partners/dmgd/provautil"
 	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/txscript/adminop"
 	"github.com/pyx-partners/dmgd/wire"
 	"reflect"
 	"runtime"
@@ -92,6 +94,22 @@ func (s *fakeChain) AdminKeySets() map[btcec.KeySetType]btcec.PublicKeySet {
 	return make(map[btcec.KeySetType]btcec.PublicKeySet)
 }
 
+// IssueDests returns the issuance destination allowlist on the fake chain
+// instance.
+func (s *fakeChain) IssueDests() blockchain.IssueDestSet {
+	return make(blockchain.IssueDestSet)
+}
+
+// Frozen returns the frozen accounts on the fake chain instance.
+func (s *fakeChain) Frozen() blockchain.FrozenSet {
+	return make(blockchain.FrozenSet)
+}
+
+// Delegates returns the delegate keys on the fake chain instance.
+func (s *fakeChain) Delegates() blockchain.Delegates {
+	return make(blockchain.Delegates)
+}
+
 // KeyIDs returns all keyID to pub key mapping set on the fake chain instance.
 func (s *fakeChain) KeyIDs() btcec.KeyIdMap {
 	keyId1 := btcec.KeyIDFromAddressBuffer([]byte{0, 0, 1, 0})
@@ -374,7 +392,11 @@ func newPoolHarness(chainParams *chaincfg.Params) (*poolHarness, []spendableOutp
 				MaxOrphanTxSize:      1000,
 				MaxSigOpsPerTx:       blockchain.MaxSigOpsPerBlock / 5,
 				MinRelayTxFee:        1000, // 1 Atom per byte
+				DustRelayFee:         1000,
 				MaxTxVersion:         1,
+
+				MaxStandardTxSize:        DefaultMaxStandardTxSize,
+				MaxStandardSigScriptSize: DefaultMaxStandardSigScriptSize,
 			},
 			ChainParams:      chainParams,
 			FetchUtxoView:    chain.FetchUtxoView,
@@ -383,6 +405,9 @@ func newPoolHarness(chainParams *chaincfg.Params) (*poolHarness, []spendableOutp
 			TotalSupply:      chain.TotalSupply,
 			GetKeyIDs:        chain.KeyIDs,
 			GetAdminKeySets:  chain.AdminKeySets,
+			GetIssueDests:    chain.IssueDests,
+			GetFrozen:        chain.Frozen,
+			GetDelegates:     chain.Delegates,
 			BestHeight:       chain.BestHeight,
 			MedianTimePast:   chain.MedianTimePast,
 			CalcSequenceLock: chain.CalcSequenceLock,
@@ -863,3 +888,659 @@ func TestMultiInputOrphanDoubleSpend(t *testing.T) {
 	// was not moved to the transaction pool.
 	testPoolMembership(tc, doubleSpendTx, false, false)
 }
+
+// TestPackageStats ensures the ancestor and descendant package statistics of
+// transactions in the pool are tracked as dependent transactions are added and
+// removed.
+func TestPackageStats(t *testing.T) {
+	t.Parallel()
+
+	harness, spendableOuts, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+
+	// Create a chain of transactions rooted with the first spendable output
+	// provided by the harness and add them all to the pool.
+	const numTxns = 3
+	chainedTxns, err := harness.CreateTxChain(spendableOuts[0], numTxns)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+	for _, tx := range chainedTxns {
+		_, err := harness.txPool.ProcessTransaction(tx, false, false, 0)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: failed to accept valid "+
+				"tx %v", err)
+		}
+	}
+	sizes := make([]int64, numTxns)
+	for i, tx := range chainedTxns {
+		sizes[i] = int64(tx.MsgTx().SerializeSize())
+	}
+
+	// testStats ensures the package statistics of the passed transaction
+	// match the expected values.
+	testStats := func(tx *provautil.Tx, ancestorCount, ancestorSize,
+		descendantCount, descendantSize int64) {
+
+		harness.txPool.mtx.RLock()
+		txD := harness.txPool.pool[*tx.Hash()]
+		harness.txPool.mtx.RUnlock()
+		if txD.AncestorCount != ancestorCount ||
+			txD.AncestorSize != ancestorSize {
+
+			_, file, line, _ := runtime.Caller(1)
+			t.Fatalf("%s:%d -- ancestor stats: got (%d, %d), "+
+				"want (%d, %d)", file, line, txD.AncestorCount,
+				txD.AncestorSize, ancestorCount, ancestorSize)
+		}
+		if txD.DescendantCount != descendantCount ||
+			txD.DescendantSize != descendantSize {
+
+			_, file, line, _ := runtime.Caller(1)
+			t.Fatalf("%s:%d -- descendant stats: got (%d, %d), "+
+				"want (%d, %d)", file, line, txD.DescendantCount,
+				txD.DescendantSize, descendantCount,
+				descendantSize)
+		}
+	}
+
+	total := sizes[0] + sizes[1] + sizes[2]
+	testStats(chainedTxns[0], 1, sizes[0], 3, total)
+	testStats(chainedTxns[1], 2, sizes[0]+sizes[1], 2, sizes[1]+sizes[2])
+	testStats(chainedTxns[2], 3, total, 1, sizes[2])
+
+	// Remove the first transaction as if it had been mined and ensure it
+	// is no longer accounted for by its descendants.
+	harness.txPool.RemoveTransaction(chainedTxns[0], false)
+	testStats(chainedTxns[1], 1, sizes[1], 2, sizes[1]+sizes[2])
+	testStats(chainedTxns[2], 2, sizes[1]+sizes[2], 1, sizes[2])
+
+	// Remove the final transaction and ensure it is no longer accounted for
+	// by its ancestor.
+	harness.txPool.RemoveTransaction(chainedTxns[2], true)
+	testStats(chainedTxns[1], 1, sizes[1], 1, sizes[1])
+}
+
+// TestMempoolEntry ensures the details of transactions in the pool report
+// their links to the other transactions in the pool.
+func TestMempoolEntry(t *testing.T) {
+	t.Parallel()
+
+	harness, spendableOuts, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+
+	const numTxns = 3
+	chainedTxns, err := harness.CreateTxChain(spendableOuts[0], numTxns)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+	for _, tx := range chainedTxns {
+		_, err := harness.txPool.ProcessTransaction(tx, false, false, 0)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: failed to accept valid "+
+				"tx %v", err)
+		}
+	}
+
+	entry, err := harness.txPool.MempoolEntry(chainedTxns[1].Hash())
+	if err != nil {
+		t.Fatalf("MempoolEntry: unexpected error: %v", err)
+	}
+	if entry.AncestorCount != 2 || entry.DescendantCount != 2 {
+		t.Errorf("MempoolEntry: got %d ancestors and %d descendants, "+
+			"want 2 and 2", entry.AncestorCount,
+			entry.DescendantCount)
+	}
+	wantDepends := []string{chainedTxns[0].Hash().String()}
+	if !reflect.DeepEqual(entry.Depends, wantDepends) {
+		t.Errorf("MempoolEntry: got depends %v, want %v",
+			entry.Depends, wantDepends)
+	}
+	wantSpentBy := []string{chainedTxns[2].Hash().String()}
+	if !reflect.DeepEqual(entry.SpentBy, wantSpentBy) {
+		t.Errorf("MempoolEntry: got spentby %v, want %v",
+			entry.SpentBy, wantSpentBy)
+	}
+	if entry.SpendsAdminThread {
+		t.Errorf("MempoolEntry: transaction spends an admin thread")
+	}
+
+	// The verbose entries of the pool match the details of the
+	// transactions.
+	verbose := harness.txPool.RawMempoolVerbose()
+	if len(verbose) != numTxns {
+		t.Fatalf("RawMempoolVerbose: got %d entries, want %d",
+			len(verbose), numTxns)
+	}
+	mpd := verbose[chainedTxns[1].Hash().String()]
+	if mpd == nil || mpd.AncestorCount != entry.AncestorCount ||
+		!reflect.DeepEqual(mpd.SpentBy, entry.SpentBy) {
+		t.Errorf("RawMempoolVerbose: got %+v, want details of %+v",
+			mpd, entry)
+	}
+
+	// Transactions not in the pool have no details.
+	_, err = harness.txPool.MempoolEntry(&chainhash.Hash{})
+	if err == nil {
+		t.Errorf("MempoolEntry: expected error for unknown transaction")
+	}
+}
+
+// TestPrioritiseTransaction ensures fee deltas accumulate, are reported with
+// the mining descriptors and modified fees of the transactions, may be set
+// before the transactions are accepted and are dropped once they leave the
+// pool.
+func TestPrioritiseTransaction(t *testing.T) {
+	t.Parallel()
+
+	harness, spendableOuts, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	tx, err := harness.CreateSignedTx(spendableOuts[:1], 1)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+
+	// The delta is kept for a transaction which is not accepted yet.
+	if delta := harness.txPool.PrioritiseTransaction(tx.Hash(), 5000); delta != 5000 {
+		t.Fatalf("PrioritiseTransaction: got delta %d, want 5000", delta)
+	}
+	_, err = harness.txPool.ProcessTransaction(tx, false, false, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: failed to accept valid tx %v", err)
+	}
+	if delta := harness.txPool.PrioritiseTransaction(tx.Hash(), 2500); delta != 7500 {
+		t.Fatalf("PrioritiseTransaction: got delta %d, want 7500", delta)
+	}
+
+	descs := harness.txPool.MiningDescs()
+	if len(descs) != 1 || descs[0].FeeDelta != 7500 {
+		t.Fatalf("MiningDescs: got %d descriptors, want one with "+
+			"fee delta 7500", len(descs))
+	}
+	entry, err := harness.txPool.MempoolEntry(tx.Hash())
+	if err != nil {
+		t.Fatalf("MempoolEntry: unexpected error: %v", err)
+	}
+	wantModified := provautil.Amount(descs[0].Fee + 7500).ToDMG()
+	if entry.ModifiedFee != wantModified {
+		t.Fatalf("MempoolEntry: got modified fee %v, want %v",
+			entry.ModifiedFee, wantModified)
+	}
+
+	// The delta is dropped once the transaction leaves the pool.
+	harness.txPool.RemoveTransaction(tx, false)
+	if delta := harness.txPool.PrioritiseTransaction(tx.Hash(), -100); delta != -100 {
+		t.Fatalf("PrioritiseTransaction: got delta %d after removal, "+
+			"want -100", delta)
+	}
+}
+
+// TestSetRelayFees ensures the relay fees of the pool can be replaced at
+// runtime without dropping the transactions already in the pool.
+func TestSetRelayFees(t *testing.T) {
+	t.Parallel()
+
+	harness, spendableOuts, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	chainedTxns, err := harness.CreateTxChain(spendableOuts[0], 2)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+	_, err = harness.txPool.ProcessTransaction(chainedTxns[0], false, false, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: failed to accept valid tx %v", err)
+	}
+
+	const minRelayTxFee = provautil.Amount(1e8)
+	harness.txPool.SetRelayFees(minRelayTxFee, 2*minRelayTxFee)
+	gotMinRelayTxFee, gotDustRelayFee := harness.txPool.RelayFees()
+	if gotMinRelayTxFee != minRelayTxFee ||
+		gotDustRelayFee != 2*minRelayTxFee {
+
+		t.Fatalf("RelayFees: got %v and %v, want %v and %v",
+			gotMinRelayTxFee, gotDustRelayFee, minRelayTxFee,
+			2*minRelayTxFee)
+	}
+	if !harness.txPool.IsTransactionInPool(chainedTxns[0].Hash()) {
+		t.Fatalf("SetRelayFees: transaction dropped from the pool")
+	}
+
+	// The output of the child of the pool transaction is dust under the
+	// raised fees, so it is only accepted once they are lowered again.
+	_, err = harness.txPool.ProcessTransaction(chainedTxns[1], false, false, 0)
+	if err == nil {
+		t.Fatalf("ProcessTransaction: accepted free tx under raised " +
+			"relay fees")
+	}
+	harness.txPool.SetRelayFees(0, 0)
+	_, err = harness.txPool.ProcessTransaction(chainedTxns[1], false, false, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: failed to accept valid tx %v", err)
+	}
+}
+
+// TestAdmissionPolicy ensures transactions rejected by a registered admission
+// policy are not accepted and the reject code of the policy is retained.
+func TestAdmissionPolicy(t *testing.T) {
+	t.Parallel()
+
+	harness, spendableOuts, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	chainedTxns, err := harness.CreateTxChain(spendableOuts[0], 2)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+
+	// The policy rejects the child transaction, which is passed the view
+	// of the outputs it spends.
+	rejected := chainedTxns[1].Hash()
+	harness.txPool.AddAdmissionPolicy(func(tx *provautil.Tx,
+		utxoView *blockchain.UtxoViewpoint) error {
+
+		if !tx.Hash().IsEqual(rejected) {
+			return nil
+		}
+		prevOut := tx.MsgTx().TxIn[0].PreviousOutPoint
+		if utxoView.LookupEntry(&prevOut.Hash) == nil {
+			return txRuleError(wire.RejectNonstandard,
+				"missing input")
+		}
+		return txRuleError(wire.RejectInvalid, "untagged transaction")
+	})
+
+	_, err = harness.txPool.ProcessTransaction(chainedTxns[0], false, false, 0)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: failed to accept valid tx %v", err)
+	}
+	_, err = harness.txPool.ProcessTransaction(chainedTxns[1], false, false, 0)
+	if err == nil {
+		t.Fatalf("ProcessTransaction: accepted tx rejected by admission " +
+			"policy")
+	}
+	if code, _ := extractRejectCode(err); code != wire.RejectInvalid {
+		t.Fatalf("ProcessTransaction: got reject code %v, want %v",
+			code, wire.RejectInvalid)
+	}
+	if harness.txPool.IsTransactionInPool(rejected) {
+		t.Fatalf("ProcessTransaction: rejected tx added to the pool")
+	}
+}
+
+// TestCheckSpend tests that CheckSpend returns the expected spends found in
+// the mempool.
+func TestCheckSpend(t *testing.T) {
+	t.Parallel()
+
+	harness, outputs, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+
+	// The mempool is empty, so none of the spendable outputs should have a
+	// spend there.
+	for _, op := range outputs {
+		spend := harness.txPool.CheckSpend(op.outPoint)
+		if spend != nil {
+			t.Fatalf("unexpected spend found in pool: %v", spend)
+		}
+	}
+
+	// Create a chain of transactions rooted with the first spendable output
+	// provided by the harness.
+	const txChainLength = 5
+	chainedTxns, err := harness.CreateTxChain(outputs[0], txChainLength)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+	for _, tx := range chainedTxns {
+		_, err := harness.txPool.ProcessTransaction(tx, false, false, 0)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: failed to accept "+
+				"tx: %v", err)
+		}
+	}
+
+	// The first tx in the chain should be the spend of the spendable
+	// output.
+	op := outputs[0].outPoint
+	spend := harness.txPool.CheckSpend(op)
+	if spend != chainedTxns[0] {
+		t.Fatalf("expected %v to be spent by %v, instead "+
+			"got %v", op, chainedTxns[0], spend)
+	}
+
+	// Now all but the last tx should be spent by the next.
+	for i := 0; i < len(chainedTxns)-1; i++ {
+		op = wire.OutPoint{
+			Hash:  *chainedTxns[i].Hash(),
+			Index: 0,
+		}
+		expSpend := chainedTxns[i+1]
+		spend = harness.txPool.CheckSpend(op)
+		if spend != expSpend {
+			t.Fatalf("expected %v to be spent by %v, instead "+
+				"got %v", op, expSpend, spend)
+		}
+	}
+
+	// The last tx should have no spend.
+	op = wire.OutPoint{
+		Hash:  *chainedTxns[txChainLength-1].Hash(),
+		Index: 0,
+	}
+	spend = harness.txPool.CheckSpend(op)
+	if spend != nil {
+		t.Fatalf("unexpected spend found in pool: %v", spend)
+	}
+}
+
+// TestProcessPackage ensures that packages of dependent transactions are
+// either accepted into the pool as a whole or not at all.
+func TestProcessPackage(t *testing.T) {
+	t.Parallel()
+
+	harness, outputs, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	tc := &testContext{t, harness}
+
+	// Create a chain of transactions rooted with the first spendable output
+	// provided by the harness.  The final transaction is only used to
+	// create a package with a missing parent.
+	chainedTxns, err := harness.CreateTxChain(outputs[0], 4)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+	packageTxns := chainedTxns[:3]
+
+	// Ensure a package which is not ordered by its dependencies is
+	// rejected.
+	_, err = harness.txPool.ProcessPackage([]*provautil.Tx{chainedTxns[1],
+		chainedTxns[0]}, false)
+	if err == nil {
+		t.Fatal("ProcessPackage: accepted unordered package")
+	}
+	for _, tx := range chainedTxns {
+		testPoolMembership(tc, tx, false, false)
+	}
+
+	// Ensure a package with a transaction that fails to be accepted does
+	// not leave any of the transactions which preceded it in the pool.
+	_, err = harness.txPool.ProcessPackage([]*provautil.Tx{chainedTxns[0],
+		chainedTxns[1], chainedTxns[3]}, false)
+	if err == nil {
+		t.Fatal("ProcessPackage: accepted package with missing parent")
+	}
+	for _, tx := range chainedTxns {
+		testPoolMembership(tc, tx, false, false)
+	}
+
+	// Ensure a valid package is accepted in the passed order.
+	acceptedTxns, err := harness.txPool.ProcessPackage(packageTxns, false)
+	if err != nil {
+		t.Fatalf("ProcessPackage: failed to accept valid package: %v",
+			err)
+	}
+	if len(acceptedTxns) != len(packageTxns) {
+		t.Fatalf("ProcessPackage: reported accepted transactions "+
+			"length does not match expected -- got %d, want %d",
+			len(acceptedTxns), len(packageTxns))
+	}
+	for i, txD := range acceptedTxns {
+		if txD.Tx != packageTxns[i] {
+			t.Fatalf("ProcessPackage: accepted transaction #%d is "+
+				"%v, want %v", i, txD.Tx.Hash(),
+				packageTxns[i].Hash())
+		}
+		testPoolMembership(tc, txD.Tx, false, true)
+	}
+}
+
+// TestHeldAdminTxns ensures admin transactions spending a thread tip with fewer
+// than the minimum number of confirmations of the policy are held, and that
+// held transactions are accepted or dropped once they are retried.
+func TestHeldAdminTxns(t *testing.T) {
+	t.Parallel()
+
+	harness, outputs, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	txPool := harness.txPool
+
+	threadScript, err := txscript.ProvaThreadScript(provautil.RootThread)
+	if err != nil {
+		t.Fatalf("ProvaThreadScript: %v", err)
+	}
+	parent := wire.NewMsgTx(wire.TxVersion)
+	parent.AddTxIn(&wire.TxIn{})
+	parent.AddTxOut(wire.NewTxOut(0, threadScript))
+	child := wire.NewMsgTx(wire.TxVersion)
+	child.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Hash: parent.TxHash()},
+	})
+	child.AddTxOut(wire.NewTxOut(0, threadScript))
+	childTx := provautil.NewTx(child)
+
+	tests := []struct {
+		name         string
+		minConfs     uint32
+		parentHeight uint32
+		held         bool
+	}{
+		{"disabled", 0, mining.UnminedHeight, false},
+		{"unconfirmed thread tip", 1, mining.UnminedHeight, true},
+		{"one confirmation", 2, 10, true},
+		{"two confirmations", 2, 9, false},
+	}
+	for _, test := range tests {
+		txPool.cfg.Policy.MinAdminTxConfirmations = test.minConfs
+		utxoView := blockchain.NewUtxoViewpoint()
+		utxoView.AddTxOuts(provautil.NewTx(parent), test.parentHeight)
+		held := txPool.mustHoldAdminTx(childTx, utxoView, 11)
+		if held != test.held {
+			t.Errorf("%s: got held %v, want %v", test.name, held,
+				test.held)
+		}
+	}
+
+	// Transactions which are not admin transactions are never held.
+	txPool.cfg.Policy.MinAdminTxConfirmations = 1
+	chainedTxns, err := harness.CreateTxChain(outputs[0], 2)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+	utxoView := blockchain.NewUtxoViewpoint()
+	utxoView.AddTxOuts(chainedTxns[0], mining.UnminedHeight)
+	if txPool.mustHoldAdminTx(chainedTxns[1], utxoView, 11) {
+		t.Fatalf("regular transaction held")
+	}
+
+	// Held transactions which became valid are accepted when they are
+	// retried, while those which are no longer valid are dropped.
+	txPool.heldAdminTxns[*chainedTxns[0].Hash()] = chainedTxns[0]
+	txPool.heldAdminTxns[*childTx.Hash()] = childTx
+	if !txPool.IsAdminTxHeld(chainedTxns[0].Hash()) ||
+		!txPool.HaveTransaction(chainedTxns[0].Hash()) {
+
+		t.Fatalf("held transaction not known to the pool")
+	}
+	if tx, err := txPool.FetchHeldAdminTx(childTx.Hash()); err != nil ||
+		tx != childTx {
+
+		t.Fatalf("FetchHeldAdminTx: got %v, %v", tx, err)
+	}
+	_, err = txPool.ProcessTransaction(chainedTxns[0], false, false, 0)
+	if err == nil {
+		t.Fatalf("duplicate of a held transaction accepted")
+	}
+	acceptedTxns := txPool.ProcessHeldAdminTxns()
+	if len(acceptedTxns) != 1 || acceptedTxns[0].Tx != chainedTxns[0] {
+		t.Fatalf("got %d accepted held transactions, want %v",
+			len(acceptedTxns), chainedTxns[0].Hash())
+	}
+	if len(txPool.heldAdminTxns) != 0 {
+		t.Fatalf("got %d transactions still held, want none",
+			len(txPool.heldAdminTxns))
+	}
+	testPoolMembership(&testContext{t, harness}, chainedTxns[0], false,
+		true)
+}
+
+// TestKeyIDRevocations ensures admin transactions revoking an ASP key are only
+// accepted when its keyID is referenced by at most the allowed number of
+// unspent outputs, counting the outputs created and spent by transactions in
+// the pool.
+func TestKeyIDRevocations(t *testing.T) {
+	t.Parallel()
+
+	harness, outputs, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	txPool := harness.txPool
+
+	// The harness pays to keyIDs 1 and 65536.  The chain holds the output
+	// spent by the first transaction of the chain and one other output
+	// referencing keyID 1, and the pool holds the unspent output of the
+	// last transaction of the chain.
+	const usedKeyID = btcec.KeyID(1)
+	otherOutPoint := wire.OutPoint{Index: 7}
+	var scanLimit int
+	txPool.cfg.FetchKeyIDOutPoints = func(keyIDs []btcec.KeyID, limit int) ([]wire.OutPoint, error) {
+		scanLimit = limit
+		if len(keyIDs) != 1 || keyIDs[0] != usedKeyID {
+			return nil, nil
+		}
+		outPoints := []wire.OutPoint{outputs[0].outPoint, otherOutPoint}
+		if limit >= 0 && len(outPoints) > limit {
+			outPoints = outPoints[:limit]
+		}
+		return outPoints, nil
+	}
+	chainedTxns, err := harness.CreateTxChain(outputs[0], 2)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+	for _, tx := range chainedTxns {
+		_, err := txPool.ProcessTransaction(tx, false, false, 0)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: failed to accept tx: %v",
+				err)
+		}
+	}
+
+	revokeTx := func(keyID btcec.KeyID) *provautil.Tx {
+		threadScript, err := txscript.ProvaThreadScript(
+			provautil.ProvisionThread)
+		if err != nil {
+			t.Fatalf("ProvaThreadScript: %v", err)
+		}
+		opScript, err := txscript.AdminOpScript(&adminop.Payload{
+			Op:     adminop.ASPKeyRevoke,
+			PubKey: harness.privKey1.PubKey(),
+			KeyID:  keyID,
+		})
+		if err != nil {
+			t.Fatalf("AdminOpScript: %v", err)
+		}
+		tx := wire.NewMsgTx(wire.TxVersion)
+		tx.AddTxIn(&wire.TxIn{})
+		tx.AddTxOut(wire.NewTxOut(0, threadScript))
+		tx.AddTxOut(wire.NewTxOut(0, opScript))
+		return provautil.NewTx(tx)
+	}
+
+	tests := []struct {
+		name       string
+		keyID      btcec.KeyID
+		maxOutputs int
+		rejected   bool
+	}{
+		{"disabled", usedKeyID, -1, false},
+		{"unused key ID", 2, 0, false},
+		{"used key ID", usedKeyID, 0, true},
+		{"below the maximum", usedKeyID, 1, true},
+		{"at the maximum", usedKeyID, 2, false},
+	}
+	for _, test := range tests {
+		txPool.cfg.Policy.MaxRevokedKeyIDOutputs = test.maxOutputs
+		tx := revokeTx(test.keyID)
+
+		// Revocations require a scan of the outputs referencing the
+		// revoked keyID when the check is enabled.
+		err := txPool.checkKeyIDRevocations(tx, nil)
+		if (err == errKeyIDScanNeeded) != (test.maxOutputs >= 0) {
+			t.Errorf("%s: got error %v without a scan", test.name,
+				err)
+			continue
+		}
+		limit := txPool.keyIDScanLimit()
+		keyIDOutPoints, err := txPool.scanKeyIDOutPoints(
+			txPool.revokedKeyIDs(tx), limit)
+		if err != nil {
+			t.Errorf("%s: scanKeyIDOutPoints: %v", test.name, err)
+			continue
+		}
+		if test.maxOutputs >= 0 && scanLimit != limit {
+			t.Errorf("%s: scanned with limit %d, want %d",
+				test.name, scanLimit, limit)
+		}
+
+		err = txPool.checkKeyIDRevocations(tx, keyIDOutPoints)
+		if (err != nil) != test.rejected {
+			t.Errorf("%s: got error %v, want rejected %v",
+				test.name, err, test.rejected)
+			continue
+		}
+		if code, _ := extractRejectCode(err); test.rejected &&
+			code != wire.RejectNonstandard {
+
+			t.Errorf("%s: got reject code %v, want %v", test.name,
+				code, wire.RejectNonstandard)
+		}
+	}
+
+	// Transactions which are not admin transactions are not checked.
+	txPool.cfg.Policy.MaxRevokedKeyIDOutputs = 0
+	err = txPool.checkKeyIDRevocations(chainedTxns[1], nil)
+	if err != nil {
+		t.Fatalf("regular transaction rejected: %v", err)
+	}
+}
+
+// BenchmarkProcessTransaction performs a benchmark on how long it takes to
+// admit a signed transaction spending a confirmed output to the pool, which
+// includes checking its policy and verifying its scripts.  The transaction is
+// removed again after each admission.
+func BenchmarkProcessTransaction(b *testing.B) {
+	harness, outputs, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		b.Fatalf("unable to create test pool: %v", err)
+	}
+	tx, err := harness.CreateSignedTx(outputs[:1], 1)
+	if err != nil {
+		b.Fatalf("unable to create transaction: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := harness.txPool.ProcessTransaction(tx, false, false, 0)
+		if err != nil {
+			b.Fatalf("ProcessTransaction: %v", err)
+		}
+		harness.txPool.RemoveTransaction(tx, false)
+	}
+}
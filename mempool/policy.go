@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/chaincfg"
 	"github.com/pyx-partners/dmgd/provautil"
 	"github.com/pyx-partners/dmgd/txscript"
 	"github.com/pyx-partners/dmgd/wire"
@@ -21,14 +22,14 @@ const (
 	// that are considered standard in a pay-to-script-hash script.
 	maxStandardP2SHSigOps = 15
 
-	// MaxStandardTxSize is the maximum size allowed for transactions that
-	// are considered standard and will therefore be relayed and considered
-	// for mining.
-	MaxStandardTxSize = 100000
+	// DefaultMaxStandardTxSize is the default maximum size allowed for
+	// transactions that are considered standard and will therefore be
+	// relayed and considered for mining.
+	DefaultMaxStandardTxSize = 100000
 
-	// maxStandardSigScriptSize is the maximum size allowed for a
-	// transaction input signature script to be considered standard.  This
-	// value allows for a 15-of-15 CHECKMULTISIG pay-to-script-hash with
+	// DefaultMaxStandardSigScriptSize is the default maximum size allowed
+	// for a transaction input signature script to be considered standard.
+	// This value allows for a 15-of-15 CHECKMULTISIG pay-to-script-hash with
 	// compressed keys.
 	//
 	// The form of the overall script is: OP_0 <15 signatures> OP_PUSHDATA2
@@ -43,7 +44,7 @@ const (
 	// That brings the total to 1+(15*74)+3+513 = 1627.  This value also
 	// adds a few extra bytes to provide a little buffer.
 	// (1 + 15*74 + 3) + (15*34 + 3) + 23 = 1650
-	maxStandardSigScriptSize = 1650
+	DefaultMaxStandardSigScriptSize = 1650
 
 	// DefaultMinRelayTxFee is the minimum fee in atoms that is required
 	// for a transaction to be treated as free for relay and mining
@@ -106,6 +107,12 @@ func checkInputsStandard(tx *provautil.Tx, utxoView *blockchain.UtxoViewpoint) e
 		case txscript.ProvaTy:
 			fallthrough
 		case txscript.GeneralProvaTy:
+			fallthrough
+		case txscript.ProvaHTLCTy:
+			fallthrough
+		case txscript.ProvaTimeLockTy:
+			fallthrough
+		case txscript.ProvaKeyIDTy:
 			break
 		case txscript.ProvaAdminTy:
 			sigPops, err := txscript.ParseScript(txIn.SignatureScript)
@@ -170,6 +177,12 @@ func checkPkScriptStandard(pkScript []byte, scriptClass txscript.ScriptClass) er
 	case txscript.ProvaTy:
 		fallthrough
 	case txscript.GeneralProvaTy:
+		fallthrough
+	case txscript.ProvaHTLCTy:
+		fallthrough
+	case txscript.ProvaTimeLockTy:
+		fallthrough
+	case txscript.ProvaKeyIDTy:
 		break
 	case txscript.ProvaAdminTy:
 		// TODO(prova): apply validation rules here
@@ -182,12 +195,12 @@ func checkPkScriptStandard(pkScript []byte, scriptClass txscript.ScriptClass) er
 	return nil
 }
 
-// isDust returns whether or not the passed transaction output amount is
+// IsDust returns whether or not the passed transaction output amount is
 // considered dust or not based on the passed minimum transaction relay fee.
 // Dust is defined in terms of the minimum transaction relay fee.  In
 // particular, if the cost to the network to spend coins is more than 1/3 of the
 // minimum transaction relay fee, it is considered dust.
-func isDust(txOut *wire.TxOut, minRelayTxFee provautil.Amount) bool {
+func IsDust(txOut *wire.TxOut, minRelayTxFee provautil.Amount) bool {
 	// Unspendable outputs are considered dust.
 	if txscript.IsUnspendable(txOut.PkScript) {
 		return true
@@ -262,15 +275,19 @@ func isDust(txOut *wire.TxOut, minRelayTxFee provautil.Amount) bool {
 // TODO(prova): Notice that this code is a duplicate of transaction
 // validation code in CheckTransactionSanity() of validate.go
 // TODO(prova): extract functionality into admin tx validator.
+//
+// The size limits, maximum version and dust threshold are taken from the
+// passed policy, and the activation height of OP_CHECKKEYIDVERIFY from the
+// passed chain parameters.
 func checkTransactionStandard(tx *provautil.Tx, height uint32,
-	medianTimePast time.Time, minRelayTxFee provautil.Amount,
-	maxTxVersion int32) error {
+	medianTimePast time.Time, policy *Policy,
+	chainParams *chaincfg.Params) error {
 	// The transaction must be a currently supported version.
 	msgTx := tx.MsgTx()
-	if msgTx.Version > maxTxVersion || msgTx.Version < 1 {
+	if msgTx.Version > policy.MaxTxVersion || msgTx.Version < 1 {
 		str := fmt.Sprintf("transaction version %d is not in the "+
 			"valid range of %d-%d", msgTx.Version, 1,
-			maxTxVersion)
+			policy.MaxTxVersion)
 		return txRuleError(wire.RejectNonstandard, str)
 	}
 
@@ -286,22 +303,24 @@ func checkTransactionStandard(tx *provautil.Tx, height uint32,
 	// size of a transaction.  This also helps mitigate CPU exhaustion
 	// attacks.
 	serializedLen := msgTx.SerializeSize()
-	if serializedLen > MaxStandardTxSize {
+	if serializedLen > policy.MaxStandardTxSize {
 		str := fmt.Sprintf("transaction size of %v is larger than max "+
-			"allowed size of %v", serializedLen, MaxStandardTxSize)
+			"allowed size of %v", serializedLen,
+			policy.MaxStandardTxSize)
 		return txRuleError(wire.RejectNonstandard, str)
 	}
 
 	for i, txIn := range msgTx.TxIn {
 		// Each transaction input signature script must not exceed the
 		// maximum size allowed for a standard transaction.  See
-		// the comment on maxStandardSigScriptSize for more details.
+		// the comment on DefaultMaxStandardSigScriptSize for more
+		// details.
 		sigScriptLen := len(txIn.SignatureScript)
-		if sigScriptLen > maxStandardSigScriptSize {
+		if sigScriptLen > policy.MaxStandardSigScriptSize {
 			str := fmt.Sprintf("transaction input %d: signature "+
 				"script size of %d bytes is large than max "+
 				"allowed size of %d bytes", i, sigScriptLen,
-				maxStandardSigScriptSize)
+				policy.MaxStandardSigScriptSize)
 			return txRuleError(wire.RejectNonstandard, str)
 		}
 
@@ -334,6 +353,17 @@ func checkTransactionStandard(tx *provautil.Tx, height uint32,
 			return txRuleError(rejectCode, str)
 		}
 
+		// Prova keyID scripts are only spent with the signature of the
+		// key of their keyID once OP_CHECKKEYIDVERIFY is active, so
+		// they are not standard before.
+		if scriptClass == txscript.ProvaKeyIDTy &&
+			!chainParams.CheckKeyIDVerifyActive(height) {
+
+			str := fmt.Sprintf("transaction output %d: keyID script "+
+				"before OP_CHECKKEYIDVERIFY is active", txInIndex)
+			return txRuleError(wire.RejectNonstandard, str)
+		}
+
 		// Only first output can be admin output
 		if scriptClass == txscript.ProvaAdminTy {
 			if txInIndex != 0 {
@@ -353,12 +383,20 @@ func checkTransactionStandard(tx *provautil.Tx, height uint32,
 			}
 		}
 
-		// Accumulate the number of outputs which only carry data.  For
+		// Accumulate the number of outputs which only carry data,
+		// including metadata commitments but not asset markers.  For
 		// all other script types, ensure the output value is not
-		// "dust".
-		if scriptClass == txscript.NullDataTy {
-			numNullDataOutputs++
-		} else if !tx.IsCoinbase() && !hasAdminOut && isDust(txOut, minRelayTxFee) {
+		// "dust".  Asset markers are only recognised from their
+		// activation height.
+		_, isAssetMarker := txscript.ExtractAssetID(txOut.PkScript)
+		isAssetMarker = isAssetMarker &&
+			chainParams.AssetMarkersActive(height)
+		if scriptClass == txscript.NullDataTy ||
+			scriptClass == txscript.CommitmentTy {
+			if !isAssetMarker {
+				numNullDataOutputs++
+			}
+		} else if !tx.IsCoinbase() && !hasAdminOut && IsDust(txOut, policy.DustRelayFee) {
 			str := fmt.Sprintf("transaction output %d: payment "+
 				"of %d is dust", txInIndex, txOut.Value)
 			return txRuleError(wire.RejectDust, str)
@@ -397,7 +435,11 @@ func checkTransactionStandard(tx *provautil.Tx, height uint32,
 				// - Key in nulldata script must be valid
 				// - Data in nulldata scripts must match proper form expected for
 				//   the thread
-				if !txscript.IsValidAdminOp(adminOpOut, threadId) {
+				// - The op must be active at the height of the
+				//   next block
+				if !txscript.IsValidAdminOp(adminOpOut, threadId,
+					height, chainParams) {
+
 					str := fmt.Sprintf("admin transaction with invalid admin " +
 						"operation found.")
 					return txRuleError(wire.RejectInvalid, str)
@@ -413,3 +455,33 @@ func checkTransactionStandard(tx *provautil.Tx, height uint32,
 
 	return nil
 }
+
+// AdmissionPolicy is an additional predicate a transaction must satisfy to be
+// accepted into the memory pool.  It allows embedders to enforce business
+// specific policies, such as requiring travel rule tags, without modifying the
+// mempool.  It is passed the transaction along with a view which contains the
+// outputs it spends and returns an error to reject it.  A TxRuleError retains
+// its reject code, while any other error is reported as non-standard.
+type AdmissionPolicy func(tx *provautil.Tx, utxoView *blockchain.UtxoViewpoint) error
+
+// checkAdmissionPolicies ensures the passed transaction satisfies each of the
+// passed admission policies in turn.  The error of the first policy which
+// rejects the transaction is returned as a rule error.
+func checkAdmissionPolicies(tx *provautil.Tx, utxoView *blockchain.UtxoViewpoint,
+	policies []AdmissionPolicy) error {
+
+	for _, policy := range policies {
+		err := policy(tx, utxoView)
+		if err == nil {
+			continue
+		}
+		rejectCode, found := extractRejectCode(err)
+		if !found {
+			rejectCode = wire.RejectNonstandard
+		}
+		str := fmt.Sprintf("transaction %v rejected by admission "+
+			"policy: %v", tx.Hash(), err)
+		return txRuleError(rejectCode, str)
+	}
+	return nil
+}
@@ -44,13 +44,13 @@ func TestCalcMinRequiredTxRelayFee(t *testing.T) {
 		},
 		{
 			"max standard tx size with default minimum relay fee",
-			MaxStandardTxSize,
+			DefaultMaxStandardTxSize,
 			DefaultMinRelayTxFee,
 			0,
 		},
 		{
 			"max standard tx size with max atoms relay fee",
-			MaxStandardTxSize,
+			DefaultMaxStandardTxSize,
 			provautil.MaxAtoms,
 			provautil.MaxAtoms,
 		},
@@ -154,6 +154,49 @@ func TestCheckPkScriptStandard(t *testing.T) {
 				AddOp(txscript.OP_3).AddOp(txscript.OP_CHECKSAFEMULTISIG),
 			false,
 		},
+		{
+			"htlc of 2 of pkHash, keyID1, keyID2",
+			txscript.NewScriptBuilder().AddOp(txscript.OP_IF).
+				AddOp(txscript.OP_SIZE).AddInt64(txscript.HTLCSecretSize).
+				AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_SHA256).
+				AddData(make([]byte, 32)).AddOp(txscript.OP_EQUALVERIFY).
+				AddOp(txscript.OP_2).AddData(pubKeyHashes[0]).
+				AddInt64(int64(keyId1)).AddInt64(int64(keyId2)).
+				AddOp(txscript.OP_3).AddOp(txscript.OP_CHECKSAFEMULTISIG).
+				AddOp(txscript.OP_ELSE).AddInt64(500000).
+				AddOp(txscript.OP_CHECKLOCKTIMEVERIFY).AddOp(txscript.OP_DROP).
+				AddOp(txscript.OP_2).AddData(pubKeyHashes[1]).
+				AddInt64(int64(keyId1)).AddInt64(int64(keyId2)).
+				AddOp(txscript.OP_3).AddOp(txscript.OP_CHECKSAFEMULTISIG).
+				AddOp(txscript.OP_ENDIF),
+			true,
+		},
+		{
+			"htlc with malformed refund branch",
+			txscript.NewScriptBuilder().AddOp(txscript.OP_IF).
+				AddOp(txscript.OP_SIZE).AddInt64(txscript.HTLCSecretSize).
+				AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_SHA256).
+				AddData(make([]byte, 32)).AddOp(txscript.OP_EQUALVERIFY).
+				AddOp(txscript.OP_2).AddData(pubKeyHashes[0]).
+				AddInt64(int64(keyId1)).AddInt64(int64(keyId2)).
+				AddOp(txscript.OP_3).AddOp(txscript.OP_CHECKSAFEMULTISIG).
+				AddOp(txscript.OP_ELSE).AddInt64(500000).
+				AddOp(txscript.OP_CHECKLOCKTIMEVERIFY).AddOp(txscript.OP_DROP).
+				AddOp(txscript.OP_2).AddData(pubKeyHashes[1]).
+				AddInt64(int64(keyId1)).AddInt64(int64(keyId1)).
+				AddOp(txscript.OP_3).AddOp(txscript.OP_CHECKSAFEMULTISIG).
+				AddOp(txscript.OP_ENDIF),
+			false,
+		},
+		{
+			"timelock of 2 of pkHash, keyID1, keyID2",
+			txscript.NewScriptBuilder().AddInt64(144).
+				AddOp(txscript.OP_CHECKSEQUENCEVERIFY).AddOp(txscript.OP_DROP).
+				AddOp(txscript.OP_2).AddData(pubKeyHashes[0]).
+				AddInt64(int64(keyId1)).AddInt64(int64(keyId2)).
+				AddOp(txscript.OP_3).AddOp(txscript.OP_CHECKSAFEMULTISIG),
+			true,
+		},
 		{
 			"malformed2",
 			txscript.NewScriptBuilder().AddOp(txscript.OP_2).
@@ -209,7 +252,7 @@ func TestCheckPkScriptStandard(t *testing.T) {
 	}
 }
 
-// TestDust tests the isDust API.
+// TestDust tests the IsDust API.
 func TestDust(t *testing.T) {
 	pkScript := []byte{0x76, 0xa9, 0x21, 0x03, 0x2f, 0x7e, 0x43,
 		0x0a, 0xa4, 0xc9, 0xd1, 0x59, 0x43, 0x7e, 0x84, 0xb9,
@@ -273,7 +316,7 @@ func TestDust(t *testing.T) {
 		},
 	}
 	for _, test := range tests {
-		res := isDust(&test.txOut, test.relayFee)
+		res := IsDust(&test.txOut, test.relayFee)
 		if res != test.isDust {
 			t.Fatalf("Dust test '%s' failed: want %v got %v",
 				test.name, test.isDust, res)
@@ -312,6 +355,23 @@ func TestCheckTransactionStandard(t *testing.T) {
 		Value:    100000000, // 100 DMG
 		PkScript: dummyPkScript,
 	}
+	keyIDPkScript, err := txscript.PayToProvaKeyIDScript(keyId1, addr)
+	if err != nil {
+		t.Fatalf("PayToProvaKeyIDScript: unexpected error: %v", err)
+	}
+	keyIDTxOut := wire.TxOut{
+		Value:    100000000,
+		PkScript: keyIDPkScript,
+	}
+	commitmentPkScript, err := txscript.CommitmentScript(
+		bytes.Repeat([]byte{0x11}, txscript.CommitmentSize))
+	if err != nil {
+		t.Fatalf("CommitmentScript: unexpected error: %v", err)
+	}
+	assetPkScript, err := txscript.AssetIDScript(1)
+	if err != nil {
+		t.Fatalf("AssetIDScript: unexpected error: %v", err)
+	}
 
 	// Create some dummy admin op output.
 	_, pubKey := btcec.PrivKeyFromBytes(btcec.S256(), []byte{
@@ -341,14 +401,74 @@ func TestCheckTransactionStandard(t *testing.T) {
 		Value:    0,
 		PkScript: provisionPkScript,
 	}
+	// Create an admin op freezing an account.
+	freezeData := make([]byte, 21)
+	freezeData[0] = txscript.AdminOpAccountFreeze
+	freezeOpPkScript, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_RETURN).
+		AddData(freezeData).Script()
+	freezeOpTxOut := wire.TxOut{
+		Value:    0,
+		PkScript: freezeOpPkScript,
+	}
+	// Create an admin op adding an issuance destination.
+	destData := make([]byte, 1+txscript.IssueDestLen)
+	destData[0] = txscript.AdminOpIssueDestAdd
+	btcec.KeyID(1).ToAddressFormat(destData[21:])
+	btcec.KeyID(2).ToAddressFormat(destData[25:])
+	destOpPkScript, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_RETURN).
+		AddData(destData).Script()
+	destOpTxOut := wire.TxOut{
+		Value:    0,
+		PkScript: destOpPkScript,
+	}
+
+	// Create the default policy along with more restrictive ones to
+	// ensure the configurable limits are respected.
+	defaultPolicy := Policy{
+		MaxTxVersion:             1,
+		MinRelayTxFee:            DefaultMinRelayTxFee,
+		DustRelayFee:             DefaultMinRelayTxFee,
+		MaxStandardTxSize:        DefaultMaxStandardTxSize,
+		MaxStandardSigScriptSize: DefaultMaxStandardSigScriptSize,
+	}
+	txSizePolicy := defaultPolicy
+	txSizePolicy.MaxStandardTxSize = 100
+	sigScriptPolicy := defaultPolicy
+	sigScriptPolicy.MaxStandardSigScriptSize = 64
+	dustPolicy := defaultPolicy
+	dustPolicy.DustRelayFee = provautil.MaxAtoms
 
 	tests := []struct {
 		name       string
 		tx         wire.MsgTx
 		height     uint32
+		policy     *Policy
+		params     *chaincfg.Params
 		isStandard bool
 		code       wire.RejectCode
 	}{
+		{
+			name: "Prova keyID script once OP_CHECKKEYIDVERIFY is active",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn:    []*wire.TxIn{&dummyTxIn},
+				TxOut:   []*wire.TxOut{&keyIDTxOut},
+			},
+			height:     300000,
+			params:     &chaincfg.RegressionNetParams,
+			isStandard: true,
+		},
+		{
+			name: "Prova keyID script before OP_CHECKKEYIDVERIFY is active",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn:    []*wire.TxIn{&dummyTxIn},
+				TxOut:   []*wire.TxOut{&keyIDTxOut},
+			},
+			height:     300000,
+			isStandard: false,
+			code:       wire.RejectNonstandard,
+		},
 		{
 			name: "Typical pay-to-pubkey-hash transaction",
 			tx: wire.MsgTx{
@@ -396,7 +516,7 @@ func TestCheckTransactionStandard(t *testing.T) {
 				TxOut: []*wire.TxOut{{
 					Value: 0,
 					PkScript: bytes.Repeat([]byte{0x00},
-						MaxStandardTxSize+1),
+						DefaultMaxStandardTxSize+1),
 				}},
 				LockTime: 0,
 			},
@@ -411,7 +531,7 @@ func TestCheckTransactionStandard(t *testing.T) {
 				TxIn: []*wire.TxIn{{
 					PreviousOutPoint: dummyPrevOut,
 					SignatureScript: bytes.Repeat([]byte{0x00},
-						maxStandardSigScriptSize+1),
+						DefaultMaxStandardSigScriptSize+1),
 					Sequence: wire.MaxTxInSequenceNum,
 				}},
 				TxOut:    []*wire.TxOut{&dummyTxOut},
@@ -421,6 +541,45 @@ func TestCheckTransactionStandard(t *testing.T) {
 			isStandard: false,
 			code:       wire.RejectNonstandard,
 		},
+		{
+			name: "Transaction size exceeds configured policy",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn, &dummyTxIn},
+				TxOut:    []*wire.TxOut{&dummyTxOut},
+				LockTime: 0,
+			},
+			height:     300000,
+			policy:     &txSizePolicy,
+			isStandard: false,
+			code:       wire.RejectNonstandard,
+		},
+		{
+			name: "Signature script size exceeds configured policy",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn},
+				TxOut:    []*wire.TxOut{&dummyTxOut},
+				LockTime: 0,
+			},
+			height:     300000,
+			policy:     &sigScriptPolicy,
+			isStandard: false,
+			code:       wire.RejectNonstandard,
+		},
+		{
+			name: "Output is dust under configured policy",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn},
+				TxOut:    []*wire.TxOut{&dummyTxOut},
+				LockTime: 0,
+			},
+			height:     300000,
+			policy:     &dustPolicy,
+			isStandard: false,
+			code:       wire.RejectDust,
+		},
 		{
 			name: "Signature script that does more than push data",
 			tx: wire.MsgTx{
@@ -499,6 +658,75 @@ func TestCheckTransactionStandard(t *testing.T) {
 			height:     300000,
 			isStandard: true,
 		},
+		{
+			name: "One commitment output with 0 amount (standard)",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn:    []*wire.TxIn{&dummyTxIn},
+				TxOut: []*wire.TxOut{&dummyTxOut, {
+					Value:    0,
+					PkScript: commitmentPkScript,
+				}},
+				LockTime: 0,
+			},
+			height:     300000,
+			isStandard: true,
+		},
+		{
+			name: "Commitment and nulldata output",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn:    []*wire.TxIn{&dummyTxIn},
+				TxOut: []*wire.TxOut{{
+					Value:    0,
+					PkScript: commitmentPkScript,
+				}, {
+					Value:    0,
+					PkScript: []byte{txscript.OP_RETURN},
+				}},
+				LockTime: 0,
+			},
+			height:     300000,
+			isStandard: false,
+			code:       wire.RejectNonstandard,
+		},
+		{
+			name: "Commitment and asset marker output",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn:    []*wire.TxIn{&dummyTxIn},
+				TxOut: []*wire.TxOut{&dummyTxOut, {
+					Value:    0,
+					PkScript: commitmentPkScript,
+				}, {
+					Value:    0,
+					PkScript: assetPkScript,
+				}},
+				LockTime: 0,
+			},
+			height:     300000,
+			params:     &chaincfg.RegressionNetParams,
+			isStandard: true,
+		},
+		{
+			name: "Commitment and asset marker output before " +
+				"asset markers",
+			tx: wire.MsgTx{
+				Version: 1,
+				TxIn:    []*wire.TxIn{&dummyTxIn},
+				TxOut: []*wire.TxOut{&dummyTxOut, {
+					Value:    0,
+					PkScript: commitmentPkScript,
+				}, {
+					Value:    0,
+					PkScript: assetPkScript,
+				}},
+				LockTime: 0,
+			},
+			height:     300000,
+			isStandard: false,
+			code:       wire.RejectNonstandard,
+		},
 		{
 			name: "Typical admin transaction",
 			tx: wire.MsgTx{
@@ -510,6 +738,55 @@ func TestCheckTransactionStandard(t *testing.T) {
 			height:     300000,
 			isStandard: true,
 		},
+		{
+			name: "Admin transaction freezing account",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn},
+				TxOut:    []*wire.TxOut{&provisionTxOut, &freezeOpTxOut},
+				LockTime: 0,
+			},
+			height:     300000,
+			params:     &chaincfg.RegressionNetParams,
+			isStandard: true,
+		},
+		{
+			name: "Admin transaction freezing account before activation",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn},
+				TxOut:    []*wire.TxOut{&provisionTxOut, &freezeOpTxOut},
+				LockTime: 0,
+			},
+			height:     300000,
+			isStandard: false,
+			code:       wire.RejectInvalid,
+		},
+		{
+			name: "Admin transaction adding issuance destination",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn},
+				TxOut:    []*wire.TxOut{&rootTxOut, &destOpTxOut},
+				LockTime: 0,
+			},
+			height:     300000,
+			params:     &chaincfg.RegressionNetParams,
+			isStandard: true,
+		},
+		{
+			name: "Admin transaction adding issuance destination " +
+				"before activation",
+			tx: wire.MsgTx{
+				Version:  1,
+				TxIn:     []*wire.TxIn{&dummyTxIn},
+				TxOut:    []*wire.TxOut{&rootTxOut, &destOpTxOut},
+				LockTime: 0,
+			},
+			height:     300000,
+			isStandard: false,
+			code:       wire.RejectInvalid,
+		},
 		{
 			name: "admin transaction with thread output at pos 1.",
 			tx: wire.MsgTx{
@@ -592,9 +869,18 @@ func TestCheckTransactionStandard(t *testing.T) {
 
 	pastMedianTime := time.Now()
 	for _, test := range tests {
+		policy := test.policy
+		if policy == nil {
+			policy = &defaultPolicy
+		}
+		params := test.params
+		if params == nil {
+			params = &chaincfg.TestNetParams
+		}
+
 		// Ensure standardness is as expected.
 		err := checkTransactionStandard(provautil.NewTx(&test.tx),
-			test.height, pastMedianTime, DefaultMinRelayTxFee, 1)
+			test.height, pastMedianTime, policy, params)
 		if err == nil && test.isStandard {
 			// Test passes since function returned standard for a
 			// transaction which is intended to be standard.
@@ -327,16 +327,21 @@ out:
 		rand.Seed(time.Now().UnixNano())
 		payToAddr := m.cfg.MiningAddrs[rand.Intn(len(m.cfg.MiningAddrs))]
 
-		// Confirm that validate keys are present.
-		if len(m.validateKeys) == 0 {
-			errStr := fmt.Sprintf("Missing validate keys, set via setvalidatekeys")
+		// Confirm that validate keys are present.  They are missing
+		// while the validate keys are locked.
+		validateKeys := m.ValidateKeys()
+		if len(validateKeys) == 0 {
+			m.submitBlockLock.Unlock()
+			errStr := fmt.Sprintf("Missing validate keys, set via " +
+				"setvalidatekeys or unlockvalidatekeys")
 			log.Errorf(errStr)
+			time.Sleep(5 * time.Second)
 			continue
 		}
 
 		// Check for invalid validate keys and stop generating if there
 		// are any invalid keys detected.
-		invalidValidateKey := m.detectInvalidValidateKey()
+		invalidValidateKey := m.detectInvalidValidateKey(validateKeys)
 		if invalidValidateKey != nil {
 			str := fmt.Sprintf("invalid validate key %x",
 				invalidValidateKey.SerializeCompressed())
@@ -350,7 +355,7 @@ out:
 		var nonRateLimitedValidateKeys []*btcec.PrivateKey
 		var validateKey *btcec.PrivateKey
 		var validateKeyErr error
-		for _, privKey := range m.validateKeys {
+		for _, privKey := range validateKeys {
 			var validatePubKey wire.BlockValidatingPubKey
 			copy(validatePubKey[:wire.BlockValidatingPubKeySize], privKey.PubKey().SerializeCompressed()[:wire.BlockValidatingPubKeySize])
 			isRateLimited, validateKeyErr := m.cfg.IsValidateKeyRateLimited(validatePubKey)
@@ -405,11 +410,12 @@ out:
 }
 
 // detectInvalidValidateKey determines if there is an invalid validate key in
-// the miner's validate key set.  If there is an invalid key, it is returned.
-func (m *CPUMiner) detectInvalidValidateKey() *btcec.PublicKey {
+// the passed validate keys of the miner.  If there is an invalid key, it is
+// returned.
+func (m *CPUMiner) detectInvalidValidateKey(validateKeys []*btcec.PrivateKey) *btcec.PublicKey {
 	adminKeySets := m.cfg.AdminKeySets()
 	validateKeySet := adminKeySets[btcec.ValidateKeySet]
-	for _, validateKey := range m.validateKeys {
+	for _, validateKey := range validateKeys {
 		if validateKeySet.Pos(validateKey.PubKey()) == -1 {
 			return validateKey.PubKey()
 		}
@@ -663,8 +669,20 @@ func (m *CPUMiner) GenerateNBlocks(n uint32) ([]*chainhash.Hash, error) {
 		rand.Seed(time.Now().UnixNano())
 		payToAddr := m.cfg.MiningAddrs[rand.Intn(len(m.cfg.MiningAddrs))]
 
-		// Choose a validate key at random.
+		// Choose a validate key at random.  Stop generating when the
+		// validate keys were locked in the meantime.
 		validateKeys := m.ValidateKeys()
+		if len(validateKeys) == 0 {
+			m.submitBlockLock.Unlock()
+			m.Lock()
+			close(m.speedMonitorQuit)
+			m.wg.Wait()
+			m.started = false
+			m.discreteMining = false
+			m.Unlock()
+			return nil, errors.New("No validate keys provided via " +
+				"setvalidatekeys or unlockvalidatekeys")
+		}
 		validateKey := validateKeys[rand.Intn(len(validateKeys))]
 
 		// Create a new block template using the available transactions
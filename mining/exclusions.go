@@ -0,0 +1,190 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mining
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+)
+
+// Exclusions is a list of transactions and addresses which are never included
+// in generated block templates.  A transaction is excluded when it is listed,
+// pays to a listed address or spends an output paying to one.  Transactions
+// spending the outputs of an excluded transaction are excluded as well, since
+// they can't be included without it.
+//
+// The exclusions are a policy of the local block producer, not a consensus
+// rule, so blocks generated by other validators which include the
+// transactions are still accepted.
+type Exclusions struct {
+	mtx   sync.RWMutex
+	txIDs map[chainhash.Hash]struct{}
+	addrs map[string]struct{}
+}
+
+// NewExclusions returns a new empty exclusion list.
+func NewExclusions() *Exclusions {
+	return &Exclusions{
+		txIDs: make(map[chainhash.Hash]struct{}),
+		addrs: make(map[string]struct{}),
+	}
+}
+
+// AddTx excludes the transaction with the passed hash and returns whether it
+// was not excluded before.
+//
+// This function is safe for concurrent access.
+func (e *Exclusions) AddTx(txHash *chainhash.Hash) bool {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if _, ok := e.txIDs[*txHash]; ok {
+		return false
+	}
+	e.txIDs[*txHash] = struct{}{}
+	return true
+}
+
+// RemoveTx stops excluding the transaction with the passed hash and returns
+// whether it was excluded.
+//
+// This function is safe for concurrent access.
+func (e *Exclusions) RemoveTx(txHash *chainhash.Hash) bool {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if _, ok := e.txIDs[*txHash]; !ok {
+		return false
+	}
+	delete(e.txIDs, *txHash)
+	return true
+}
+
+// AddAddress excludes the transactions paying to or spending from the passed
+// address and returns whether it was not excluded before.
+//
+// This function is safe for concurrent access.
+func (e *Exclusions) AddAddress(addr provautil.Address) bool {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	encoded := addr.EncodeAddress()
+	if _, ok := e.addrs[encoded]; ok {
+		return false
+	}
+	e.addrs[encoded] = struct{}{}
+	return true
+}
+
+// RemoveAddress stops excluding the transactions paying to or spending from
+// the passed address and returns whether it was excluded.
+//
+// This function is safe for concurrent access.
+func (e *Exclusions) RemoveAddress(addr provautil.Address) bool {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	encoded := addr.EncodeAddress()
+	if _, ok := e.addrs[encoded]; !ok {
+		return false
+	}
+	delete(e.addrs, encoded)
+	return true
+}
+
+// TxIDs returns the hashes of the excluded transactions in ascending order of
+// their string encoding.
+//
+// This function is safe for concurrent access.
+func (e *Exclusions) TxIDs() []string {
+	e.mtx.RLock()
+	txIDs := make([]string, 0, len(e.txIDs))
+	for txHash := range e.txIDs {
+		txIDs = append(txIDs, txHash.String())
+	}
+	e.mtx.RUnlock()
+
+	sort.Strings(txIDs)
+	return txIDs
+}
+
+// Addresses returns the encoded excluded addresses in ascending order.
+//
+// This function is safe for concurrent access.
+func (e *Exclusions) Addresses() []string {
+	e.mtx.RLock()
+	addrs := make([]string, 0, len(e.addrs))
+	for addr := range e.addrs {
+		addrs = append(addrs, addr)
+	}
+	e.mtx.RUnlock()
+
+	sort.Strings(addrs)
+	return addrs
+}
+
+// excludedAddress returns the first excluded address the passed public key
+// script pays to, or an empty string when it pays to none.
+//
+// This function MUST be called with the lock held (for reads).
+func (e *Exclusions) excludedAddress(pkScript []byte, params *chaincfg.Params) string {
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, params)
+	if err != nil {
+		return ""
+	}
+	for _, addr := range addrs {
+		encoded := addr.EncodeAddress()
+		if _, ok := e.addrs[encoded]; ok {
+			return encoded
+		}
+	}
+	return ""
+}
+
+// Excludes returns why the passed transaction is excluded, or an empty string
+// when it is not.  The passed view must contain the outputs the transaction
+// spends which are not in the source pool.  Outputs spent from the source pool
+// are not checked, since their transactions are excluded when they pay to an
+// excluded address.
+//
+// This function is safe for concurrent access.
+func (e *Exclusions) Excludes(tx *provautil.Tx, utxos *blockchain.UtxoViewpoint, params *chaincfg.Params) string {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+
+	if _, ok := e.txIDs[*tx.Hash()]; ok {
+		return "excluded transaction"
+	}
+	if len(e.addrs) == 0 {
+		return ""
+	}
+
+	for _, txOut := range tx.MsgTx().TxOut {
+		if addr := e.excludedAddress(txOut.PkScript, params); addr != "" {
+			return "pays to excluded address " + addr
+		}
+	}
+	for _, txIn := range tx.MsgTx().TxIn {
+		prevOut := &txIn.PreviousOutPoint
+		entry := utxos.LookupEntry(&prevOut.Hash)
+		if entry == nil {
+			continue
+		}
+		pkScript := entry.PkScriptByIndex(prevOut.Index)
+		if pkScript == nil {
+			continue
+		}
+		if addr := e.excludedAddress(pkScript, params); addr != "" {
+			return "spends from excluded address " + addr
+		}
+	}
+	return ""
+}
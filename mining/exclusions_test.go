@@ -0,0 +1,109 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mining
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// TestExclusions ensures transactions are excluded when they are listed, pay
+// to an excluded address or spend from one.
+func TestExclusions(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	keyIDs := []btcec.KeyID{1, 2}
+	newAddr := func(b byte) (provautil.Address, []byte) {
+		pkHash := make([]byte, 20)
+		pkHash[0] = b
+		addr, err := provautil.NewAddressProva(pkHash, keyIDs, params)
+		if err != nil {
+			t.Fatalf("NewAddressProva: %v", err)
+		}
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			t.Fatalf("PayToAddrScript: %v", err)
+		}
+		return addr, pkScript
+	}
+	excludedAddr, excludedScript := newAddr(1)
+	_, otherScript := newAddr(2)
+
+	// funding pays to the excluded address and to another one.
+	funding := wire.NewMsgTx(wire.TxVersion)
+	funding.AddTxIn(&wire.TxIn{})
+	funding.AddTxOut(wire.NewTxOut(1000, excludedScript))
+	funding.AddTxOut(wire.NewTxOut(1000, otherScript))
+	fundingTx := provautil.NewTx(funding)
+	utxos := blockchain.NewUtxoViewpoint()
+	utxos.AddTxOuts(fundingTx, 1)
+
+	spend := func(index uint32) *provautil.Tx {
+		tx := wire.NewMsgTx(wire.TxVersion)
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(fundingTx.Hash(),
+			index), nil))
+		tx.AddTxOut(wire.NewTxOut(900, otherScript))
+		return provautil.NewTx(tx)
+	}
+	spendExcluded, spendOther := spend(0), spend(1)
+
+	exclusions := NewExclusions()
+	for _, tx := range []*provautil.Tx{fundingTx, spendExcluded, spendOther} {
+		if reason := exclusions.Excludes(tx, utxos, params); reason != "" {
+			t.Fatalf("Excludes: tx %s excluded without exclusions: "+
+				"%s", tx.Hash(), reason)
+		}
+	}
+
+	if !exclusions.AddAddress(excludedAddr) || exclusions.AddAddress(excludedAddr) {
+		t.Fatal("AddAddress: unexpected result")
+	}
+	if !exclusions.AddTx(spendOther.Hash()) || exclusions.AddTx(spendOther.Hash()) {
+		t.Fatal("AddTx: unexpected result")
+	}
+	tests := []struct {
+		tx   *provautil.Tx
+		want string
+	}{
+		{fundingTx, "pays to excluded address " + excludedAddr.EncodeAddress()},
+		{spendExcluded, "spends from excluded address " + excludedAddr.EncodeAddress()},
+		{spendOther, "excluded transaction"},
+	}
+	for _, test := range tests {
+		if reason := exclusions.Excludes(test.tx, utxos, params); reason != test.want {
+			t.Errorf("Excludes: got %q for tx %s, want %q", reason,
+				test.tx.Hash(), test.want)
+		}
+	}
+	if got := exclusions.Addresses(); !reflect.DeepEqual(got,
+		[]string{excludedAddr.EncodeAddress()}) {
+
+		t.Errorf("Addresses: got %v", got)
+	}
+	if got := exclusions.TxIDs(); !reflect.DeepEqual(got,
+		[]string{spendOther.Hash().String()}) {
+
+		t.Errorf("TxIDs: got %v", got)
+	}
+
+	if !exclusions.RemoveAddress(excludedAddr) || exclusions.RemoveAddress(excludedAddr) {
+		t.Fatal("RemoveAddress: unexpected result")
+	}
+	if !exclusions.RemoveTx(spendOther.Hash()) || exclusions.RemoveTx(spendOther.Hash()) {
+		t.Fatal("RemoveTx: unexpected result")
+	}
+	for _, test := range tests {
+		if reason := exclusions.Excludes(test.tx, utxos, params); reason != "" {
+			t.Errorf("Excludes: tx %s excluded after removal: %s",
+				test.tx.Hash(), reason)
+		}
+	}
+}
@@ -62,6 +62,44 @@ type TxDesc struct {
 
 	// FeePerKB is the fee the transaction pays in Satoshi per 1000 bytes.
 	FeePerKB int64
+
+	// AncestorCount, AncestorSize, and AncestorFee are the number of
+	// transactions, total serialized size, and total fees of the
+	// transaction along with all of its unconfirmed ancestors in the
+	// source pool.
+	AncestorCount int64
+	AncestorSize  int64
+	AncestorFee   int64
+
+	// DescendantCount, DescendantSize, and DescendantFee are the number of
+	// transactions, total serialized size, and total fees of the
+	// transaction along with all of its unconfirmed descendants in the
+	// source pool.
+	DescendantCount int64
+	DescendantSize  int64
+	DescendantFee   int64
+
+	// FeeDelta is the delta set with prioritisetransaction which is added
+	// to the fee of the transaction when it is selected for a block, but
+	// is not paid.
+	FeeDelta int64
+}
+
+// PackageFeePerKB returns the fee per 1000 bytes the transaction effectively
+// pays once the fees of its unconfirmed descendants are taken into account.
+// A descendant can only be mined after the transaction it spends from, so a
+// high fee child raises the effective fee rate of a low fee parent (child
+// pays for parent).  The larger of the transaction's own fee rate and the
+// fee rate of its descendant package is returned.
+func (txD *TxDesc) PackageFeePerKB() int64 {
+	feePerKB := txD.FeePerKB
+	if txD.DescendantSize > 0 {
+		pkgFeePerKB := txD.DescendantFee * 1000 / txD.DescendantSize
+		if pkgFeePerKB > feePerKB {
+			feePerKB = pkgFeePerKB
+		}
+	}
+	return feePerKB
 }
 
 // TxSource represents a source of transactions to consider for inclusion in
@@ -275,7 +313,9 @@ func standardCoinbaseScript() ([]byte, error) {
 
 // createCoinbaseTx returns a coinbase transaction paying an appropriate subsidy
 // based on the passed block height to the provided address.  When the address
-// is nil, the coinbase transaction will instead be redeemable by anyone.
+// is nil, the coinbase transaction will instead be redeemable by anyone.  When
+// the network requires coinbase outputs to pay to its coinbase script, that
+// script is paid to regardless of the address.
 //
 // See the comment for NewBlockTemplate for more information about why the nil
 // address handling is useful.
@@ -284,7 +324,9 @@ func createCoinbaseTx(params *chaincfg.Params, coinbaseScript []byte, nextBlockH
 	// specified.  Otherwise create a script that allows the coinbase to be
 	// redeemable by anyone.
 	var pkScript []byte
-	if addr != nil {
+	if params.CoinbasePkScript != nil {
+		pkScript = params.CoinbasePkScript
+	} else if addr != nil {
 		var err error
 		pkScript, err = txscript.PayToAddrScript(addr)
 		if err != nil {
@@ -440,8 +482,10 @@ func NewBlkTmplGenerator(policy *Policy, params *chaincfg.Params,
 // factors.  First, each transaction has a priority calculated based on its
 // value, age of inputs, and size.  Transactions which consist of larger
 // amounts, older inputs, and small sizes have the highest priority.  Second, a
-// fee per kilobyte is calculated for each transaction.  Transactions with a
-// higher fee per kilobyte are preferred.  Finally, the block generation related
+// fee per kilobyte is calculated for each transaction which includes the fees
+// of any descendants in the source pool when they raise it (see
+// TxDesc.PackageFeePerKB).  Transactions with a higher fee per kilobyte are
+// preferred.  Finally, the block generation related
 // policy settings are all taken into account.
 //
 // Transactions which only spend outputs from other transactions already in the
@@ -532,9 +576,14 @@ func (g *BlkTmplGenerator) NewBlockTemplate(payToAddress provautil.Address, vali
 	blockTxns = append(blockTxns, coinbaseTx)
 	blockUtxos := blockchain.NewUtxoViewpoint()
 	keyView := blockchain.NewKeyViewpoint()
+	keyView.SetThreadTips(g.chain.ThreadTips())
+	keyView.SetTotalSupply(g.chain.TotalSupply())
 	keyView.SetLastKeyID(g.chain.LastKeyID())
 	keyView.SetKeys(g.chain.AdminKeySets())
 	keyView.SetKeyIDs(g.chain.KeyIDs())
+	keyView.SetIssueDests(g.chain.IssueDests())
+	keyView.SetFrozen(g.chain.Frozen())
+	keyView.SetDelegates(g.chain.Delegates())
 
 	// dependers is used to track transactions which depend on another
 	// transaction in the source pool.  This, in conjunction with the
@@ -572,6 +621,15 @@ mempoolLoop:
 			continue
 		}
 
+		// Transactions whose fee is made negative by their fee delta
+		// are left out of the block, along with their dependents.
+		if txDesc.Fee+txDesc.FeeDelta < 0 {
+			log.Tracef("Skipping deprioritised tx %s with fee %d "+
+				"and fee delta %d", tx.Hash(), txDesc.Fee,
+				txDesc.FeeDelta)
+			continue
+		}
+
 		// Fetch all of the utxos referenced by the this transaction.
 		// NOTE: This intentionally does not fetch inputs from the
 		// mempool since a transaction which depends on other
@@ -584,6 +642,18 @@ mempoolLoop:
 			continue
 		}
 
+		// Leave out the transactions excluded by the operator.  The
+		// transactions depending on them are left out as well, since
+		// their dependencies are never satisfied.
+		if g.policy.Exclusions != nil {
+			reason := g.policy.Exclusions.Excludes(tx, utxos,
+				g.chainParams)
+			if reason != "" {
+				log.Debugf("Skipping tx %s: %s", tx.Hash(), reason)
+				continue
+			}
+		}
+
 		// Setup dependencies for any transactions which reference
 		// other transactions in the mempool so they can be properly
 		// ordered below.
@@ -628,8 +698,17 @@ mempoolLoop:
 		prioItem.priority = CalcPriority(tx.MsgTx(), utxos,
 			nextBlockHeight)
 
-		// Calculate the fee in Atoms/kB.
-		prioItem.feePerKB = txDesc.FeePerKB
+		// Calculate the fee in Atoms/kB.  The package fee rate is used
+		// so that transactions with high fee descendants are selected
+		// ahead of their own fee rate, which in turn makes the
+		// descendants available for inclusion.
+		prioItem.feePerKB = txDesc.PackageFeePerKB()
+		if txDesc.FeeDelta != 0 {
+			// The fee delta raises or lowers the fee rate the
+			// transaction is selected with, but is not paid.
+			txSize := int64(tx.MsgTx().SerializeSize())
+			prioItem.feePerKB += txDesc.FeeDelta * 1000 / txSize
+		}
 		prioItem.fee = txDesc.Fee
 		prioItem.isAdmin = isAdmin(tx.MsgTx())
 
@@ -652,9 +731,28 @@ mempoolLoop:
 	// possible transaction count size, plus the size of the coinbase
 	// transaction.
 	blockSize := blockHeaderOverhead + uint32(coinbaseTx.MsgTx().SerializeSize())
+
+	// The policy block size is capped by the max block size the chain
+	// parameters schedule at the height of the block.
+	blockMaxSize := g.policy.BlockMaxSize
+	maxBlockSize := g.chainParams.MaxBlockSizeAtHeight(nextBlockHeight)
+	if blockMaxSize > maxBlockSize {
+		blockMaxSize = maxBlockSize
+	}
 	blockSigOps := numCoinbaseSigOps
 	totalFees := int64(0)
 
+	// The signatures of the keys of keyIDs are verified once
+	// OP_CHECKKEYIDVERIFY is active at the height of the block.
+	scriptFlags := txscript.StandardVerifyFlags
+	if g.chainParams.CheckKeyIDVerifyActive(nextBlockHeight) {
+		scriptFlags |= txscript.ScriptVerifyCheckKeyIDVerify
+	}
+
+	// The signature operations of spent Prova scripts count toward the
+	// maximum of the block once the network counts them.
+	countProvaSigOps := g.chainParams.ProvaSigOpsActive(nextBlockHeight)
+
 	// Choose which transactions make it into the block.
 	for priorityQueue.Len() > 0 {
 		// Grab the highest priority (or highest fee per kilobyte
@@ -669,7 +767,7 @@ mempoolLoop:
 		txSize := uint32(tx.MsgTx().SerializeSize())
 		blockPlusTxSize := blockSize + txSize
 		if blockPlusTxSize < blockSize ||
-			blockPlusTxSize >= g.policy.BlockMaxSize {
+			blockPlusTxSize >= blockMaxSize {
 
 			log.Tracef("Skipping tx %s because it would exceed "+
 				"the max block size", tx.Hash())
@@ -704,6 +802,25 @@ mempoolLoop:
 			logSkippedDeps(tx, deps)
 			continue
 		}
+		if countProvaSigOps {
+			numProvaSigOps, err := blockchain.CountProvaSigOps(tx,
+				false, blockUtxos)
+			if err != nil {
+				log.Tracef("Skipping tx %s due to error in "+
+					"CountProvaSigOps: %v", tx.Hash(), err)
+				logSkippedDeps(tx, deps)
+				continue
+			}
+			numSigOps += int64(numProvaSigOps)
+			if blockSigOps+numSigOps < blockSigOps ||
+				blockSigOps+numSigOps > blockchain.MaxSigOpsPerBlock {
+				log.Tracef("Skipping tx %s because it would "+
+					"exceed the maximum sigops per block "+
+					"(prova)", tx.Hash())
+				logSkippedDeps(tx, deps)
+				continue
+			}
+		}
 
 		// Skip free transactions once the block is larger than the
 		// minimum block size.
@@ -749,6 +866,16 @@ mempoolLoop:
 			}
 		}
 
+		// Asset markers must be well formed once recognised.
+		err = blockchain.CheckTransactionAssetMarkers(tx,
+			nextBlockHeight, g.chainParams)
+		if err != nil {
+			log.Tracef("Skipping tx %s due to error in "+
+				"CheckTransactionAssetMarkers: %v", tx.Hash(), err)
+			logSkippedDeps(tx, deps)
+			continue
+		}
+
 		// Ensure the transaction inputs pass all of the necessary
 		// preconditions before allowing it to be added to the block.
 		_, err = blockchain.CheckTransactionInputs(tx, nextBlockHeight,
@@ -760,8 +887,30 @@ mempoolLoop:
 			continue
 		}
 
+		// Outputs of frozen accounts must not be spent.
+		err = blockchain.CheckTransactionFrozen(tx, nextBlockHeight,
+			blockUtxos, keyView, g.chainParams)
+		if err != nil {
+			log.Tracef("Skipping tx %s due to error in "+
+				"CheckTransactionFrozen: %v", tx.Hash(), err)
+			logSkippedDeps(tx, deps)
+			continue
+		}
+
+		// Destructions may only return change to the destroyed
+		// outputs or allowlisted destinations.
+		err = blockchain.CheckIssueChange(tx, nextBlockHeight,
+			blockUtxos, keyView, g.chainParams)
+		if err != nil {
+			log.Tracef("Skipping tx %s due to error in "+
+				"CheckIssueChange: %v", tx.Hash(), err)
+			logSkippedDeps(tx, deps)
+			continue
+		}
+
 		// CheckTransactionOutputs checks outputs for state violations.
-		err = blockchain.CheckTransactionOutputs(tx, keyView, g.chainParams)
+		err = blockchain.CheckTransactionOutputs(tx, nextBlockHeight,
+			keyView, g.chainParams)
 		if err != nil {
 			log.Tracef("Skipping tx %s due to error in "+
 				"CheckTransactionOutputs: %v", tx.Hash(), err)
@@ -769,8 +918,9 @@ mempoolLoop:
 			continue
 		}
 
-		err = blockchain.ValidateTransactionScripts(tx, blockUtxos, keyView,
-			txscript.StandardVerifyFlags, g.sigCache, g.hashCache)
+		err = blockchain.ValidateTransactionScripts(tx, nextBlockHeight,
+			blockUtxos, keyView, g.chainParams, scriptFlags,
+			g.sigCache, g.hashCache)
 		if err != nil {
 			log.Tracef("Skipping tx %s due to error in "+
 				"ValidateTransactionScripts: %v", tx.Hash(), err)
@@ -784,6 +934,11 @@ mempoolLoop:
 		// aren't double spending.
 		spendTransaction(blockUtxos, tx, nextBlockHeight)
 
+		// Apply any admin operations in the transaction to the key view
+		// so transactions which depend on them, such as those paying to
+		// a newly provisioned keyID, can be included in the same block.
+		keyView.ProcessAdminOuts(tx, nextBlockHeight, g.chainParams)
+
 		// Add the transaction to the block, increment counters, and
 		// save the fees and signature operation counts to the block
 		// template.
@@ -110,3 +110,39 @@ func TestTxFeePrioHeap(t *testing.T) {
 		highest = prioItem
 	}
 }
+
+// TestPackageFeePerKB ensures the package fee rate of a transaction accounts
+// for the fees paid by its descendants only when they raise it.
+func TestPackageFeePerKB(t *testing.T) {
+	tests := []struct {
+		name string
+		desc TxDesc
+		want int64
+	}{
+		{
+			name: "no descendant stats",
+			desc: TxDesc{FeePerKB: 1000},
+			want: 1000,
+		},
+		{
+			name: "descendants raise fee rate",
+			desc: TxDesc{FeePerKB: 0, DescendantFee: 5000,
+				DescendantSize: 500},
+			want: 10000,
+		},
+		{
+			name: "descendants lower fee rate",
+			desc: TxDesc{FeePerKB: 20000, DescendantFee: 5000,
+				DescendantSize: 500},
+			want: 20000,
+		},
+	}
+
+	for _, test := range tests {
+		got := test.desc.PackageFeePerKB()
+		if got != test.want {
+			t.Errorf("%s: unexpected package fee rate -- got %d, "+
+				"want %d", test.name, got, test.want)
+		}
+	}
+}
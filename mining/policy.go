@@ -39,6 +39,10 @@ type Policy struct {
 	// required for a transaction to be treated as free for mining purposes
 	// (block template generation).
 	TxMinFreeFee provautil.Amount
+
+	// Exclusions lists the transactions and addresses which are never
+	// included in block templates, or is nil when none are excluded.
+	Exclusions *Exclusions
 }
 
 // minInt is a helper function to return the minimum of two ints.  This avoids
@@ -0,0 +1,168 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+const (
+	// announcementQuorum is the number of distinct keys of the root or of
+	// the provision key set which must sign a governance announcement, the
+	// same number of signatures admin transactions require.
+	announcementQuorum = 2
+
+	// maxAnnouncements is the maximum number of unexpired announcements
+	// which are kept and relayed.
+	maxAnnouncements = 100
+
+	// maxAnnouncementLifetime is the maximum time between the timestamp
+	// and the expiration of an announcement.
+	maxAnnouncementLifetime = 30 * 24 * time.Hour
+
+	// maxAnnouncementTimeOffset is how far in the future the timestamp of
+	// an announcement may be.
+	maxAnnouncementTimeOffset = 2 * time.Hour
+)
+
+// knownAnnounceCategories is the set of announcement categories which are
+// accepted and relayed.
+var knownAnnounceCategories = map[wire.AnnounceCategory]struct{}{
+	wire.AnnounceNotice:      {},
+	wire.AnnounceUpgrade:     {},
+	wire.AnnounceKeyCeremony: {},
+	wire.AnnounceEmergency:   {},
+}
+
+// announcement is a verified governance announcement along with the admin key
+// set whose keys signed it.
+type announcement struct {
+	msg      *wire.MsgAnnounce
+	hash     chainhash.Hash
+	keySet   btcec.KeySetType
+	signers  []*btcec.PublicKey
+	received time.Time
+}
+
+// verifyAnnouncement checks the passed announcement is unexpired and signed by
+// a quorum of the keys of the root or the provision key set of the passed admin
+// key sets, and returns the verified announcement.
+func verifyAnnouncement(msg *wire.MsgAnnounce, keySets map[btcec.KeySetType]btcec.PublicKeySet, now time.Time) (*announcement, error) {
+	if _, ok := knownAnnounceCategories[msg.Category]; !ok {
+		return nil, fmt.Errorf("unknown category %d", msg.Category)
+	}
+	if msg.Timestamp.After(now.Add(maxAnnouncementTimeOffset)) {
+		return nil, errors.New("timestamp is too far in the future")
+	}
+	if !msg.Expiration.After(now) {
+		return nil, errors.New("announcement expired")
+	}
+	if msg.Expiration.Sub(msg.Timestamp) > maxAnnouncementLifetime {
+		return nil, fmt.Errorf("announcement expires more than %v "+
+			"after its timestamp", maxAnnouncementLifetime)
+	}
+
+	signers, err := msg.SigningKeys()
+	if err != nil {
+		return nil, err
+	}
+	for _, keySet := range []btcec.KeySetType{btcec.RootKeySet,
+		btcec.ProvisionKeySet} {
+
+		var signed int
+		for _, pubKey := range signers {
+			if keySets[keySet].Pos(pubKey) >= 0 {
+				signed++
+			}
+		}
+		if signed >= announcementQuorum {
+			return &announcement{
+				msg:      msg,
+				hash:     msg.AnnounceHash(),
+				keySet:   keySet,
+				signers:  signers,
+				received: now,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("announcement is not signed by %d root or "+
+		"provision keys", announcementQuorum)
+}
+
+// announcements keeps the unexpired governance announcements, which are
+// relayed to peers as they are received and sent to peers when they connect.
+type announcements struct {
+	mtx    sync.Mutex
+	byHash map[chainhash.Hash]*announcement
+}
+
+// newAnnouncements returns an empty set of announcements.
+func newAnnouncements() *announcements {
+	return &announcements{
+		byHash: make(map[chainhash.Hash]*announcement),
+	}
+}
+
+// pruneExpired removes the announcements which expired at the passed time.
+//
+// This function MUST be called with the mutex held.
+func (a *announcements) pruneExpired(now time.Time) {
+	for hash, ann := range a.byHash {
+		if !ann.msg.Expiration.After(now) {
+			delete(a.byHash, hash)
+		}
+	}
+}
+
+// add verifies the passed announcement against the passed admin key sets and
+// keeps it.  It returns the verified announcement, or nil when the
+// announcement is already kept.
+//
+// This function is safe for concurrent access.
+func (a *announcements) add(msg *wire.MsgAnnounce, keySets map[btcec.KeySetType]btcec.PublicKeySet, now time.Time) (*announcement, error) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	if _, ok := a.byHash[msg.AnnounceHash()]; ok {
+		return nil, nil
+	}
+	ann, err := verifyAnnouncement(msg, keySets, now)
+	if err != nil {
+		return nil, err
+	}
+	a.pruneExpired(now)
+	if len(a.byHash) >= maxAnnouncements {
+		return nil, fmt.Errorf("already keeping %d announcements",
+			maxAnnouncements)
+	}
+	a.byHash[ann.hash] = ann
+	return ann, nil
+}
+
+// active returns the unexpired announcements, newest first.
+//
+// This function is safe for concurrent access.
+func (a *announcements) active(now time.Time) []*announcement {
+	a.mtx.Lock()
+	a.pruneExpired(now)
+	active := make([]*announcement, 0, len(a.byHash))
+	for _, ann := range a.byHash {
+		active = append(active, ann)
+	}
+	a.mtx.Unlock()
+
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].msg.Timestamp.After(active[j].msg.Timestamp)
+	})
+	return active
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// TestAnnouncements ensures only unexpired announcements signed by a quorum of
+// root or provision keys are kept, and that they are returned newest first
+// until they expire.
+func TestAnnouncements(t *testing.T) {
+	var keys []*btcec.PrivateKey
+	for i := byte(1); i <= 5; i++ {
+		key, _ := btcec.PrivKeyFromBytes(btcec.S256(), []byte{i})
+		keys = append(keys, key)
+	}
+	keySets := map[btcec.KeySetType]btcec.PublicKeySet{
+		btcec.RootKeySet:      {*keys[0].PubKey(), *keys[1].PubKey()},
+		btcec.ProvisionKeySet: {*keys[2].PubKey(), *keys[3].PubKey()},
+		btcec.IssueKeySet:     {*keys[4].PubKey()},
+	}
+	now := time.Unix(1546300800, 0)
+
+	// newAnnouncement returns an announcement with the passed message made
+	// at the passed offset from now, expiring a day later and signed by the
+	// passed keys.
+	newAnnouncement := func(message string, offset time.Duration,
+		signKeys ...int) *wire.MsgAnnounce {
+
+		msg := wire.NewMsgAnnounce(wire.AnnounceUpgrade, now.Add(offset),
+			now.Add(offset+24*time.Hour), message)
+		for _, i := range signKeys {
+			if err := msg.AddSignature(keys[i]); err != nil {
+				t.Fatalf("AddSignature: %v", err)
+			}
+		}
+		return msg
+	}
+
+	tests := []struct {
+		name   string
+		msg    *wire.MsgAnnounce
+		keySet btcec.KeySetType
+		valid  bool
+	}{
+		{
+			name:   "root quorum",
+			msg:    newAnnouncement("root quorum", 0, 0, 1),
+			keySet: btcec.RootKeySet,
+			valid:  true,
+		},
+		{
+			name:   "provision quorum with an extra issue key",
+			msg:    newAnnouncement("provision quorum with an extra issue key", time.Hour, 4, 2, 3),
+			keySet: btcec.ProvisionKeySet,
+			valid:  true,
+		},
+		{
+			name: "single root key",
+			msg:  newAnnouncement("single root key", 0, 0),
+		},
+		{
+			name: "root and provision key",
+			msg:  newAnnouncement("root and provision key", 0, 0, 2),
+		},
+		{
+			name: "expired",
+			msg:  newAnnouncement("expired", -25*time.Hour, 0, 1),
+		},
+		{
+			name: "timestamp in the future",
+			msg:  newAnnouncement("timestamp in the future", 3*time.Hour, 0, 1),
+		},
+	}
+	a := newAnnouncements()
+	for _, test := range tests {
+		ann, err := a.add(test.msg, keySets, now)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%s: add succeeded", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: add: %v", test.name, err)
+			continue
+		}
+		if ann == nil || ann.keySet != test.keySet {
+			t.Errorf("%s: got announcement %v, want one signed by "+
+				"the %v key set", test.name, ann, test.keySet)
+		}
+	}
+
+	// Announcements which are already kept are not new.
+	ann, err := a.add(tests[0].msg, keySets, now)
+	if err != nil || ann != nil {
+		t.Fatalf("add: got announcement %v and error %v for a kept "+
+			"announcement, want neither", ann, err)
+	}
+
+	// The kept announcements are returned newest first until they expire.
+	active := a.active(now)
+	if len(active) != 2 || active[0].msg != tests[1].msg ||
+		active[1].msg != tests[0].msg {
+
+		t.Fatalf("active: got %v, want the valid announcements newest "+
+			"first", active)
+	}
+	active = a.active(now.Add(24 * time.Hour))
+	if len(active) != 1 || active[0].msg != tests[1].msg {
+		t.Fatalf("active: got %v a day later, want the newest "+
+			"announcement", active)
+	}
+}
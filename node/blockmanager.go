@@ -4,21 +4,25 @@
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
-package main
+package node
 
 import (
 	"container/list"
+	"errors"
 	"net"
 	"os"
 	"path/filepath"
 	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/btcjson"
 	"github.com/pyx-partners/dmgd/chaincfg"
 	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
 	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/database/ffldb"
 	"github.com/pyx-partners/dmgd/mempool"
 	"github.com/pyx-partners/dmgd/provautil"
 	"github.com/pyx-partners/dmgd/wire"
@@ -83,6 +87,10 @@ type getSyncPeerMsg struct {
 	reply chan *serverPeer
 }
 
+// errBlockManagerStopped is the error returned to callers of ProcessBlock when
+// the block manager stopped before the block was processed.
+var errBlockManagerStopped = errors.New("block manager is shutting down")
+
 // processBlockResponse is a response sent to the reply channel of a
 // processBlockMsg.
 type processBlockResponse struct {
@@ -131,6 +139,13 @@ type blockManager struct {
 	msgChan         chan interface{}
 	wg              sync.WaitGroup
 	quit            chan struct{}
+
+	// watchdog detects a stalled sync peer or block handler.
+	watchdog syncWatchdog
+
+	// pendingCheckpoints are the checkpoints of the main chain which are
+	// signed with the checkpoint key once they reach the finality depth.
+	pendingCheckpoints []*blockchain.SignedCheckpoint
 }
 
 // startSync will choose the best peer among the available candidate peers to
@@ -143,6 +158,12 @@ func (b *blockManager) startSync(peers *list.List) {
 		return
 	}
 
+	// Blocks are not downloaded while the chain state is rebuilt from the
+	// stored blocks.  Syncing starts once the reindex is complete.
+	if _, reindexing := b.chain.ReindexTarget(); reindexing {
+		return
+	}
+
 	best := b.chain.BestSnapshot()
 	var bestPeer *serverPeer
 	var enext *list.Element
@@ -184,6 +205,7 @@ func (b *blockManager) startSync(peers *list.List) {
 			bestPeer.LastBlock(), bestPeer.Addr())
 		bestPeer.PushGetBlocksMsg(locator, &zeroHash)
 		b.syncPeer = bestPeer
+		b.watchdog.setSyncPeer(bestPeer.Addr(), time.Now())
 	} else {
 		bmgrLog.Warnf("No sync peer candidates available")
 	}
@@ -273,10 +295,50 @@ func (b *blockManager) handleDonePeerMsg(peers *list.List, sp *serverPeer) {
 	// sync peer.
 	if b.syncPeer != nil && b.syncPeer == sp {
 		b.syncPeer = nil
+		b.watchdog.setSyncPeer("", time.Now())
 		b.startSync(peers)
 	}
 }
 
+// handleStallCheck replaces the sync peer when it stalled, which is the case
+// when it did not deliver any block for syncStallTimeout while it has blocks
+// requested or claims to have blocks after the best block.  The stalled peer
+// is disconnected and the blocks are requested again from the best of the
+// remaining candidates.  It is invoked from the syncHandler goroutine.
+func (b *blockManager) handleStallCheck(peers *list.List) {
+	sp := b.syncPeer
+	if sp == nil {
+		return
+	}
+	waiting := len(sp.requestedBlocks) > 0 ||
+		sp.LastBlock() > b.chain.BestSnapshot().Height
+	if !b.watchdog.syncStalled(waiting, time.Now()) {
+		return
+	}
+
+	bmgrLog.Warnf("Sync peer %s delivered no block for %v -- "+
+		"disconnecting", sp, syncStallTimeout)
+	sp.Disconnect()
+
+	// Remove the peer as a candidate and forget the blocks requested from
+	// it right away, instead of once it is done, so they are requested
+	// from the new sync peer.
+	for e := peers.Front(); e != nil; e = e.Next() {
+		if e.Value == sp {
+			peers.Remove(e)
+			break
+		}
+	}
+	for k := range sp.requestedBlocks {
+		delete(b.requestedBlocks, k)
+	}
+	sp.requestedBlocks = make(map[chainhash.Hash]struct{})
+
+	b.syncPeer = nil
+	b.watchdog.setSyncPeer("", time.Now())
+	b.startSync(peers)
+}
+
 // handleTxMsg handles transaction messages from all peers.
 func (b *blockManager) handleTxMsg(tmsg *txMsg) {
 	// NOTE:  BitcoinJ, and possibly other wallets, don't follow the spec of
@@ -337,6 +399,12 @@ func (b *blockManager) handleTxMsg(tmsg *txMsg) {
 		return
 	}
 
+	// Record the peer relayed a useful transaction, which protects it from
+	// eviction.
+	if len(acceptedTxs) > 0 {
+		atomic.StoreInt64(&tmsg.peer.lastTxTime, time.Now().Unix())
+	}
+
 	b.server.AnnounceNewTransactions(acceptedTxs)
 }
 
@@ -386,6 +454,9 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 	// will fail the insert and thus we'll retry next time we get an inv.
 	delete(bmsg.peer.requestedBlocks, *blockHash)
 	delete(b.requestedBlocks, *blockHash)
+	if bmsg.peer == b.syncPeer {
+		b.watchdog.progress(time.Now())
+	}
 
 	// Process the block to include validation, best chain selection, orphan
 	// handling, etc.
@@ -451,6 +522,11 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 		// update the chain state.
 		b.progressLogger.LogBlockHeight(bmsg.block)
 
+		// Record the peer relayed a useful block, which protects it
+		// from eviction.
+		atomic.StoreInt64(&bmsg.peer.lastBlockTime, time.Now().Unix())
+		atomic.AddUint64(&bmsg.peer.blocksAccepted, 1)
+
 		// Update this peer's latest block height, for future
 		// potential sync node candidacy.
 		best := b.chain.BestSnapshot()
@@ -493,7 +569,7 @@ func (b *blockManager) haveInventory(invVect *wire.InvVect) (bool, error) {
 		// chain, side chain, or orphan).
 		return b.chain.HaveBlock(&invVect.Hash)
 
-	case wire.InvTypeTx:
+	case wire.InvTypeTx, wire.InvTypeAdminTx:
 		// Ask the transaction memory pool if the transaction is known
 		// to it in any form (main pool or orphan).
 		if b.server.txMemPool.HaveTransaction(&invVect.Hash) {
@@ -537,6 +613,13 @@ func (b *blockManager) handleInvMsg(imsg *invMsg) {
 		imsg.peer.UpdateLastAnnouncedBlock(&invVects[lastBlock].Hash)
 	}
 
+	// Ignore invs while the chain state is rebuilt from the stored blocks
+	// since neither blocks nor transactions can be validated against it
+	// until the reindex is complete.
+	if _, reindexing := b.chain.ReindexTarget(); reindexing {
+		return
+	}
+
 	// Ignore invs from peers that aren't the sync if we are not current.
 	// Helps prevent fetching a mass of orphans when there are
 	// plenty of peers in a standard network configuration.
@@ -560,7 +643,9 @@ func (b *blockManager) handleInvMsg(imsg *invMsg) {
 	// we already have and request more blocks to prevent them.
 	for i, iv := range invVects {
 		// Ignore unsupported inventory types.
-		if iv.Type != wire.InvTypeBlock && iv.Type != wire.InvTypeTx {
+		switch iv.Type {
+		case wire.InvTypeBlock, wire.InvTypeTx, wire.InvTypeAdminTx:
+		default:
 			continue
 		}
 
@@ -577,7 +662,7 @@ func (b *blockManager) handleInvMsg(imsg *invMsg) {
 			continue
 		}
 		if !haveInv {
-			if iv.Type == wire.InvTypeTx {
+			if iv.Type == wire.InvTypeTx || iv.Type == wire.InvTypeAdminTx {
 				// Skip the transaction if it has already been
 				// rejected.
 				if _, exists := b.rejectedTxns[iv.Hash]; exists {
@@ -653,7 +738,7 @@ func (b *blockManager) handleInvMsg(imsg *invMsg) {
 				numRequested++
 			}
 
-		case wire.InvTypeTx:
+		case wire.InvTypeTx, wire.InvTypeAdminTx:
 			// Request the transaction if there is not already a
 			// pending request.
 			if _, exists := b.requestedTxns[iv.Hash]; !exists {
@@ -701,10 +786,42 @@ func (b *blockManager) limitMap(m map[chainhash.Hash]struct{}, limit int) {
 // the fetching should proceed.
 func (b *blockManager) blockHandler() {
 	candidatePeers := list.New()
+
+	// While the chain state is rebuilt from the stored blocks, the next
+	// block is connected whenever the closed reindex channel is selected,
+	// which alternates with handling the messages so peers and RPC clients
+	// are still served.
+	var reindex chan struct{}
+	if _, reindexing := b.chain.ReindexTarget(); reindexing {
+		reindex = make(chan struct{})
+		close(reindex)
+	}
+	stallTicker := time.NewTicker(stallCheckInterval)
+	defer stallTicker.Stop()
 out:
 	for {
 		select {
+		case <-reindex:
+			block, err := b.chain.ReindexNextBlock(b.quit)
+			if err != nil {
+				bmgrLog.Errorf("Reindex stopped: %v", err)
+			}
+			if block != nil {
+				b.progressLogger.LogBlockHeight(block)
+			}
+
+			// Start syncing the blocks after the stored ones from
+			// peers once the reindex is complete.
+			if _, reindexing := b.chain.ReindexTarget(); !reindexing {
+				reindex = nil
+				b.startSync(candidatePeers)
+			}
+
+		case <-stallTicker.C:
+			b.handleStallCheck(candidatePeers)
+
 		case m := <-b.msgChan:
+			b.watchdog.handlerBusy(m, time.Now())
 			switch msg := m.(type) {
 			case *newPeerMsg:
 				b.handleNewPeerMsg(candidatePeers, msg.peer)
@@ -753,23 +870,106 @@ out:
 				msg.reply <- b.current()
 
 			case pauseMsg:
-				// Wait until the sender unpauses the manager.
+				// Wait until the sender unpauses the manager.  The
+				// manager is paused on purpose, so the watchdog
+				// does not consider it stuck meanwhile.
+				b.watchdog.handlerIdle()
 				<-msg.unpause
 
 			default:
 				bmgrLog.Warnf("Invalid message type in block "+
 					"handler: %T", msg)
 			}
+			b.watchdog.handlerIdle()
 
 		case <-b.quit:
 			break out
 		}
 	}
 
+	// Answer the requests which were queued but not handled before the
+	// block manager stopped, so the goroutines waiting on them, such as
+	// peers and RPC clients, do not block forever.  The block being
+	// processed when the quit channel was closed has been fully connected
+	// at this point, so nothing is left half-done.
+cleanup:
+	for {
+		select {
+		case m := <-b.msgChan:
+			switch msg := m.(type) {
+			case *txMsg:
+				msg.peer.txProcessed <- struct{}{}
+
+			case *blockMsg:
+				msg.peer.blockProcessed <- struct{}{}
+
+			case getSyncPeerMsg:
+				msg.reply <- nil
+
+			case processBlockMsg:
+				msg.reply <- processBlockResponse{
+					err: errBlockManagerStopped,
+				}
+
+			case isCurrentMsg:
+				msg.reply <- false
+			}
+
+		default:
+			break cleanup
+		}
+	}
+
 	b.wg.Done()
 	bmgrLog.Trace("Block handler done")
 }
 
+// watchdogHandler checks the block handler is not stuck on a message, which
+// would halt the sync and leave the peers queueing messages blocked.  The
+// handler can not be interrupted, so the stall is logged and counted, and the
+// peer whose message stalled it is disconnected so it is replaced.  It must be
+// run as a goroutine.
+func (b *blockManager) watchdogHandler() {
+	ticker := time.NewTicker(stallCheckInterval)
+	defer ticker.Stop()
+out:
+	for {
+		select {
+		case <-ticker.C:
+			msg, busy := b.watchdog.handlerStall(time.Now())
+			if msg == nil {
+				continue
+			}
+
+			var sp *serverPeer
+			switch msg := msg.(type) {
+			case *blockMsg:
+				sp = msg.peer
+			case *txMsg:
+				sp = msg.peer
+			case *invMsg:
+				sp = msg.peer
+			}
+			if sp == nil {
+				bmgrLog.Warnf("Block handler stuck on %T for %v, "+
+					"%d messages queued", msg, busy,
+					len(b.msgChan))
+				continue
+			}
+			bmgrLog.Warnf("Block handler stuck on %T from %s for %v, "+
+				"%d messages queued -- disconnecting", msg, sp,
+				busy, len(b.msgChan))
+			sp.Disconnect()
+
+		case <-b.quit:
+			break out
+		}
+	}
+
+	b.wg.Done()
+	bmgrLog.Trace("Block manager watchdog done")
+}
+
 // handleNotifyMsg handles notifications from blockchain.  It does things such
 // as request orphan block parents and relay accepted blocks to connected peers.
 func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
@@ -777,17 +977,19 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 	// A block has been accepted into the block chain.  Relay it to other
 	// peers.
 	case blockchain.NTBlockAccepted:
-		// Don't relay if we are not current. Other peers that are
-		// current should already know about it.
-		if !b.current() {
-			return
-		}
-
 		block, ok := notification.Data.(*provautil.Block)
 		if !ok {
 			bmgrLog.Warnf("Chain accepted notification is not a block.")
 			break
 		}
+		b.server.propagations.validated(block.Hash(), block.Height(),
+			time.Now())
+
+		// Don't relay if we are not current. Other peers that are
+		// current should already know about it.
+		if !b.current() {
+			return
+		}
 
 		// Generate the inventory vector and relay it.
 		iv := wire.NewInvVect(wire.InvTypeBlock, block.Hash())
@@ -816,19 +1018,68 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 			b.server.AnnounceNewTransactions(acceptedTxs)
 		}
 
+		// Accept and relay the held admin transactions whose thread
+		// tips have enough confirmations now.
+		acceptedTxs := b.server.txMemPool.ProcessHeldAdminTxns()
+		b.server.AnnounceNewTransactions(acceptedTxs)
+
 		if r := b.server.rpcServer; r != nil {
 			// Now that this block is in the blockchain we can mark
 			// all the transactions (except the coinbase) as no
 			// longer needing rebroadcasting.
 			for _, tx := range block.Transactions()[1:] {
-				iv := wire.NewInvVect(wire.InvTypeTx, tx.Hash())
-				b.server.RemoveRebroadcastInventory(iv)
+				b.server.RemoveRebroadcastInventory(txInvVect(tx))
 			}
 
 			// Notify registered websocket clients of incoming block.
 			r.ntfnMgr.NotifyBlockConnected(block)
 		}
 
+		// Record the admin transactions of the block in the audit
+		// log.
+		if l := b.server.auditLog; l != nil {
+			if err := l.BlockConnected(block); err != nil {
+				bmgrLog.Errorf("Unable to write audit log: %v",
+					err)
+			}
+		}
+
+		// Publish the events of the block.
+		if e := b.server.eventBridge; e != nil {
+			e.Notify()
+		}
+
+		// Export the block to the SQL database.
+		if e := b.server.sqlExporter; e != nil {
+			e.Notify()
+		}
+
+		// Check the issuance of the block with the issuance monitor.
+		// The blocks connected while syncing are only recorded, so
+		// past issuance raises no alerts.
+		if m := b.server.issuanceMonitor; m != nil {
+			if b.current() {
+				m.BlockConnected(block)
+			} else {
+				m.Record(block)
+			}
+		}
+
+		// Deliver the deposits and admin operations of the block to
+		// the webhooks.
+		if b.server.webhooks != nil {
+			if err := b.server.webhookBlockConnected(block); err != nil {
+				bmgrLog.Errorf("Unable to create webhook events: %v",
+					err)
+			}
+		}
+
+		// Produce the rolling checkpoints when a checkpoint key is
+		// configured.
+		if cfg.checkpointKey != nil {
+			b.updateSignedCheckpoints(block)
+		}
+
 	// A block has been disconnected from the main block chain.
 	case blockchain.NTBlockDisconnected:
 		block, ok := notification.Data.(*provautil.Block)
@@ -854,7 +1105,128 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 		if r := b.server.rpcServer; r != nil {
 			r.ntfnMgr.NotifyBlockDisconnected(block)
 		}
+
+		// Record the admin transactions reverted by disconnecting the
+		// block in the audit log.
+		if l := b.server.auditLog; l != nil {
+			if err := l.BlockDisconnected(block); err != nil {
+				bmgrLog.Errorf("Unable to write audit log: %v",
+					err)
+			}
+		}
+
+		// Publish the disconnection of the block.
+		if e := b.server.eventBridge; e != nil {
+			e.Notify()
+		}
+
+		// Delete the block from the SQL database.
+		if e := b.server.sqlExporter; e != nil {
+			e.Notify()
+		}
+
+		// Forget the issuance of the block in the issuance monitor.
+		if m := b.server.issuanceMonitor; m != nil {
+			m.BlockDisconnected(block)
+		}
+
+	// The main block chain was reorganized.
+	case blockchain.NTReorganization:
+		event, ok := notification.Data.(*blockchain.ReorgEvent)
+		if !ok {
+			bmgrLog.Warnf("Chain reorganization notification is not " +
+				"a reorg event.")
+			break
+		}
+
+		// Notify registered websocket clients.
+		if r := b.server.rpcServer; r != nil {
+			r.ntfnMgr.NotifyReorganization(event)
+		}
+
+		// Deliver the reorganization to the webhooks.
+		if b.server.webhooks != nil {
+			if err := b.server.webhookReorg(event); err != nil {
+				bmgrLog.Errorf("Unable to create webhook event: %v",
+					err)
+			}
+		}
+
+	// A validate key signed two blocks at the same height.  Broadcast the
+	// evidence to peers when configured to do so.
+	case blockchain.NTValidatorViolation:
+		violation, ok := notification.Data.(*blockchain.ValidatorViolation)
+		if !ok {
+			bmgrLog.Warnf("Validator violation notification is not " +
+				"a validator violation.")
+			break
+		}
+
+		if cfg.RelayViolations {
+			msg := wire.NewMsgAlert(violation.Evidence(), nil)
+			b.server.BroadcastMessage(msg)
+		}
+
+	// The main chain started or stopped signaling or enforcing consensus
+	// rules this version does not know.
+	case blockchain.NTUnknownRules:
+		status, ok := notification.Data.(*blockchain.UnknownRulesStatus)
+		if !ok {
+			bmgrLog.Warnf("Unknown rules notification is not an " +
+				"unknown rules status.")
+			break
+		}
+
+		// Notify registered websocket clients.
+		if r := b.server.rpcServer; r != nil {
+			r.ntfnMgr.NotifyUnknownRules(status)
+		}
+	}
+}
+
+// updateSignedCheckpoints produces the rolling checkpoints signed with the
+// checkpoint key of the node.  The state of the main chain is captured when a
+// block at a checkpoint interval is connected, and the checkpoint is only signed
+// and enforced once the block has reached the finality depth, so checkpoints
+// never pin blocks which may still be reorganized.
+func (b *blockManager) updateSignedCheckpoints(block *provautil.Block) {
+	height := block.Height()
+	if height%cfg.CheckpointInterval == 0 && b.current() {
+		checkpoint, err := b.chain.CheckpointState()
+		if err != nil {
+			bmgrLog.Errorf("Unable to capture the checkpoint state: %v",
+				err)
+		} else {
+			b.pendingCheckpoints = append(b.pendingCheckpoints,
+				checkpoint)
+		}
 	}
+
+	// Sign the pending checkpoints which reached the finality depth and
+	// drop those which were reorganized out of the main chain.
+	pending := b.pendingCheckpoints[:0]
+	for _, checkpoint := range b.pendingCheckpoints {
+		if height < checkpoint.Height ||
+			height-checkpoint.Height+1 < cfg.FinalityDepth {
+
+			pending = append(pending, checkpoint)
+			continue
+		}
+		inMainChain, err := b.chain.MainChainHasBlock(&checkpoint.Hash)
+		if err != nil || !inMainChain {
+			bmgrLog.Debugf("Dropping checkpoint at height %d which "+
+				"is no longer in the main chain", checkpoint.Height)
+			continue
+		}
+		if err := checkpoint.Sign(cfg.checkpointKey); err != nil {
+			bmgrLog.Errorf("Unable to sign checkpoint: %v", err)
+			continue
+		}
+		if err := b.chain.AddSignedCheckpoint(checkpoint); err != nil {
+			bmgrLog.Errorf("Unable to add signed checkpoint: %v", err)
+		}
+	}
+	b.pendingCheckpoints = pending
 }
 
 // NewPeer informs the block manager of a newly active peer.
@@ -918,8 +1290,9 @@ func (b *blockManager) Start() {
 	}
 
 	bmgrLog.Trace("Starting block manager")
-	b.wg.Add(1)
+	b.wg.Add(2)
 	go b.blockHandler()
+	go b.watchdogHandler()
 }
 
 // Stop gracefully shuts down the block manager by stopping all asynchronous
@@ -934,6 +1307,13 @@ func (b *blockManager) Stop() error {
 	bmgrLog.Infof("Block manager shutting down")
 	close(b.quit)
 	b.wg.Wait()
+
+	// Write the unspent transaction outputs which are cached in memory to
+	// the database before it is closed, so they don't have to be recovered
+	// on the next start.
+	if err := b.chain.FlushUtxoCache(); err != nil {
+		bmgrLog.Errorf("Unable to flush the utxo cache: %v", err)
+	}
 	return nil
 }
 
@@ -944,10 +1324,25 @@ func (b *blockManager) SyncPeer() *serverPeer {
 	return <-reply
 }
 
+// SyncStatus returns the sync peer and the stalls of the sync detected by the
+// watchdog.  Unlike the other queries, it does not wait for the block handler,
+// so it also answers while the handler is stuck.
+func (b *blockManager) SyncStatus() *btcjson.GetSyncStatusResult {
+	return b.watchdog.status(time.Now())
+}
+
 // ProcessBlock makes use of ProcessBlock on an internal instance of a block
 // chain.  It is funneled through the block manager since btcchain is not safe
 // for concurrent access.
 func (b *blockManager) ProcessBlock(block *provautil.Block, flags blockchain.BehaviorFlags) (bool, error) {
+	// Don't process more blocks if we're shutting down.
+	if atomic.LoadInt32(&b.shutdown) != 0 {
+		return false, errBlockManagerStopped
+	}
+	if flags&blockchain.BFDryRun == 0 {
+		b.server.propagations.received(block.Hash(), "", time.Now())
+	}
+
 	reply := make(chan processBlockResponse, 1)
 	b.msgChan <- processBlockMsg{block: block, flags: flags, reply: reply}
 	response := <-reply
@@ -1027,7 +1422,7 @@ func mergeCheckpoints(defaultCheckpoints, additional []chaincfg.Checkpoint) []ch
 
 // newBlockManager returns a new bitcoin block manager.
 // Use Start to begin processing asynchronous block and inv updates.
-func newBlockManager(s *server, indexManager blockchain.IndexManager) (*blockManager, error) {
+func newBlockManager(s *server, indexManager blockchain.IndexManager, interrupt <-chan struct{}) (*blockManager, error) {
 	bm := blockManager{
 		server:          s,
 		rejectedTxns:    make(map[chainhash.Hash]struct{}),
@@ -1045,13 +1440,18 @@ func newBlockManager(s *server, indexManager blockchain.IndexManager) (*blockMan
 	// Create a new block chain instance with the appropriate configuration.
 	var err error
 	bm.chain, err = blockchain.New(&blockchain.Config{
-		DB:            s.db,
-		ChainParams:   s.chainParams,
-		Checkpoints:   checkpoints,
-		TimeSource:    s.timeSource,
-		Notifications: bm.handleNotifyMsg,
-		SigCache:      s.sigCache,
-		IndexManager:  indexManager,
+		DB:                s.db,
+		ChainParams:       s.chainParams,
+		Checkpoints:       checkpoints,
+		TimeSource:        s.timeSource,
+		Notifications:     bm.handleNotifyMsg,
+		SigCache:          s.sigCache,
+		IndexManager:      indexManager,
+		Interrupt:         interrupt,
+		UtxoCacheMaxSize:  cfg.DbCache * 1024 * 1024,
+		Reindex:           cfg.Reindex || cfg.ReindexChainState,
+		ReindexIndexes:    cfg.Reindex,
+		CheckpointPubKeys: cfg.checkpointPubKeys,
 	})
 	if err != nil {
 		return nil, err
@@ -1160,7 +1560,8 @@ func loadBlockDB() (database.DB, error) {
 	removeRegressionDB(dbPath)
 
 	btcdLog.Infof("Loading block database from '%s'", dbPath)
-	db, err := database.Open(cfg.DbType, dbPath, activeNetParams.Net)
+	dbOpts := ffldb.Options{MmapBlocks: cfg.MmapBlocks}
+	db, err := database.Open(cfg.DbType, dbPath, activeNetParams.Net, dbOpts)
 	if err != nil {
 		// Return the error if it's not because the database doesn't
 		// exist.
@@ -1175,7 +1576,8 @@ func loadBlockDB() (database.DB, error) {
 		if err != nil {
 			return nil, err
 		}
-		db, err = database.Create(cfg.DbType, dbPath, activeNetParams.Net)
+		db, err = database.Create(cfg.DbType, dbPath,
+			activeNetParams.Net, dbOpts)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,292 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcjson"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+const (
+	// maxBlockPropagations is the number of the most recently announced
+	// blocks whose propagation is kept for the getblockpropagation RPC.
+	maxBlockPropagations = 1000
+
+	// maxPropagationInvBlocks is the largest number of blocks in an
+	// inventory or headers message which is treated as an announcement of
+	// new blocks.  Larger messages answer the getblocks requests of
+	// syncing peers and are not tracked.
+	maxPropagationInvBlocks = 8
+)
+
+// blockPropagation is the propagation of a block through the node: when it
+// was first announced and by which peer, when it was received, validated and
+// relayed onward, and when each peer announced it.  Times which are zero mark
+// the stages the block has not reached yet.
+type blockPropagation struct {
+	hash         chainhash.Hash
+	height       uint32
+	firstSeen    time.Time
+	firstPeer    string
+	received     time.Time
+	receivedFrom string
+	validated    time.Time
+	relayed      time.Time
+	relayedTo    int
+	announced    map[string]time.Time
+}
+
+// blockPropagations keeps the propagation of the most recently announced
+// blocks, so the operator can identify slow links between validators.
+type blockPropagations struct {
+	mtx    sync.Mutex
+	blocks map[chainhash.Hash]*blockPropagation
+
+	// order holds the hashes of the kept blocks, oldest first.
+	order []chainhash.Hash
+}
+
+// blockInvHashes returns the hashes of the blocks in the passed inventory
+// vectors, or nil when there are none or too many to be an announcement of
+// new blocks.
+func blockInvHashes(invList []*wire.InvVect) []*chainhash.Hash {
+	var hashes []*chainhash.Hash
+	for _, iv := range invList {
+		if iv.Type != wire.InvTypeBlock {
+			continue
+		}
+		if len(hashes) == maxPropagationInvBlocks {
+			return nil
+		}
+		hashes = append(hashes, &iv.Hash)
+	}
+	return hashes
+}
+
+// fetch returns the kept propagation of the passed block.  When it is not kept
+// and create is set, a new propagation first seen at the passed time is kept,
+// dropping the oldest one when the maximum number of blocks is kept.
+//
+// This function MUST be called with the lock held.
+func (p *blockPropagations) fetch(hash *chainhash.Hash, now time.Time, create bool) (*blockPropagation, bool) {
+	if prop, ok := p.blocks[*hash]; ok {
+		return prop, false
+	}
+	if !create {
+		return nil, false
+	}
+
+	if p.blocks == nil {
+		p.blocks = make(map[chainhash.Hash]*blockPropagation)
+	}
+	if len(p.order) >= maxBlockPropagations {
+		delete(p.blocks, p.order[0])
+		copy(p.order, p.order[1:])
+		p.order = p.order[:len(p.order)-1]
+	}
+	prop := &blockPropagation{
+		hash:      *hash,
+		firstSeen: now,
+		announced: make(map[string]time.Time),
+	}
+	p.blocks[*hash] = prop
+	p.order = append(p.order, *hash)
+	return prop, true
+}
+
+// announced records the blocks in the passed inventory vectors as announced by
+// the passed peer at the passed time.
+//
+// This function is safe for concurrent access.
+func (p *blockPropagations) announced(invList []*wire.InvVect, peer string, now time.Time) {
+	hashes := blockInvHashes(invList)
+	if len(hashes) == 0 {
+		return
+	}
+
+	p.mtx.Lock()
+	for _, hash := range hashes {
+		prop, created := p.fetch(hash, now, true)
+		if created {
+			prop.firstPeer = peer
+		}
+		if _, ok := prop.announced[peer]; !ok {
+			prop.announced[peer] = now
+		}
+	}
+	p.mtx.Unlock()
+}
+
+// received records the passed block as received from the passed peer at the
+// passed time.  Only blocks which were announced are tracked, unless the peer
+// is empty, which marks a block submitted locally.
+//
+// This function is safe for concurrent access.
+func (p *blockPropagations) received(hash *chainhash.Hash, peer string, now time.Time) {
+	p.mtx.Lock()
+	prop, _ := p.fetch(hash, now, peer == "")
+	if prop != nil && prop.received.IsZero() {
+		prop.received = now
+		prop.receivedFrom = peer
+	}
+	p.mtx.Unlock()
+}
+
+// validated records the passed block as validated and accepted at the passed
+// time.
+//
+// This function is safe for concurrent access.
+func (p *blockPropagations) validated(hash *chainhash.Hash, height uint32, now time.Time) {
+	p.mtx.Lock()
+	prop, _ := p.fetch(hash, now, false)
+	if prop != nil && prop.validated.IsZero() {
+		prop.height = height
+		prop.validated = now
+	}
+	p.mtx.Unlock()
+}
+
+// relayed records the passed blocks as announced to a peer at the passed time.
+//
+// This function is safe for concurrent access.
+func (p *blockPropagations) relayed(hashes []*chainhash.Hash, now time.Time) {
+	if len(hashes) == 0 || len(hashes) > maxPropagationInvBlocks {
+		return
+	}
+
+	p.mtx.Lock()
+	for _, hash := range hashes {
+		prop, _ := p.fetch(hash, now, false)
+		if prop == nil {
+			continue
+		}
+		if prop.relayed.IsZero() {
+			prop.relayed = now
+		}
+		prop.relayedTo++
+	}
+	p.mtx.Unlock()
+}
+
+// millis returns the passed duration in milliseconds.
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// unixMillis returns the passed time in milliseconds since 1 Jan 1970 GMT, or 0
+// for the zero time.
+func unixMillis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// result returns the aggregated propagation of the kept blocks along with the
+// propagation of up to the passed number of the most recently announced ones,
+// newest first.
+//
+// This function is safe for concurrent access.
+func (p *blockPropagations) result(count int) *btcjson.GetBlockPropagationResult {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	type peerDelays struct {
+		announced int
+		firstSeen int
+		total     time.Duration
+		max       time.Duration
+	}
+	peers := make(map[string]*peerDelays)
+
+	var download, validation, relay time.Duration
+	var downloads, validations, relays int
+	for _, hash := range p.order {
+		prop := p.blocks[hash]
+		// Blocks submitted locally are not downloaded.
+		if !prop.received.IsZero() && prop.receivedFrom != "" {
+			download += prop.received.Sub(prop.firstSeen)
+			downloads++
+		}
+		if !prop.received.IsZero() && !prop.validated.IsZero() {
+			validation += prop.validated.Sub(prop.received)
+			validations++
+		}
+		if !prop.validated.IsZero() && !prop.relayed.IsZero() {
+			relay += prop.relayed.Sub(prop.validated)
+			relays++
+		}
+
+		for addr, announced := range prop.announced {
+			delays, ok := peers[addr]
+			if !ok {
+				delays = &peerDelays{}
+				peers[addr] = delays
+			}
+			delay := announced.Sub(prop.firstSeen)
+			delays.announced++
+			delays.total += delay
+			if delay > delays.max {
+				delays.max = delay
+			}
+			if addr == prop.firstPeer {
+				delays.firstSeen++
+			}
+		}
+	}
+
+	result := &btcjson.GetBlockPropagationResult{
+		Blocks: len(p.order),
+		Peers:  make([]btcjson.PeerPropagationResult, 0, len(peers)),
+		Recent: make([]btcjson.BlockPropagationResult, 0),
+	}
+	if downloads > 0 {
+		result.AvgDownload = millis(download) / float64(downloads)
+	}
+	if validations > 0 {
+		result.AvgValidation = millis(validation) / float64(validations)
+	}
+	if relays > 0 {
+		result.AvgRelay = millis(relay) / float64(relays)
+	}
+
+	for addr, delays := range peers {
+		result.Peers = append(result.Peers, btcjson.PeerPropagationResult{
+			Addr:      addr,
+			Announced: delays.announced,
+			FirstSeen: delays.firstSeen,
+			AvgDelay:  millis(delays.total) / float64(delays.announced),
+			MaxDelay:  millis(delays.max),
+		})
+	}
+	sort.Slice(result.Peers, func(i, j int) bool {
+		if result.Peers[i].AvgDelay != result.Peers[j].AvgDelay {
+			return result.Peers[i].AvgDelay > result.Peers[j].AvgDelay
+		}
+		return result.Peers[i].Addr < result.Peers[j].Addr
+	})
+
+	for i := len(p.order) - 1; i >= 0 && len(result.Recent) < count; i-- {
+		prop := p.blocks[p.order[i]]
+		result.Recent = append(result.Recent, btcjson.BlockPropagationResult{
+			Hash:          prop.hash.String(),
+			Height:        prop.height,
+			FirstSeen:     unixMillis(prop.firstSeen),
+			FirstPeer:     prop.firstPeer,
+			Received:      unixMillis(prop.received),
+			ReceivedFrom:  prop.receivedFrom,
+			Validated:     unixMillis(prop.validated),
+			Relayed:       unixMillis(prop.relayed),
+			RelayedTo:     prop.relayedTo,
+			Announcements: len(prop.announced),
+		})
+	}
+	return result
+}
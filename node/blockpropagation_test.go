@@ -0,0 +1,139 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// TestBlockPropagations ensures the stages of the propagation of blocks are
+// recorded and aggregated per block and per announcing peer.
+func TestBlockPropagations(t *testing.T) {
+	var props blockPropagations
+	start := time.Unix(1500000000, 0)
+	at := func(ms int) time.Time {
+		return start.Add(time.Duration(ms) * time.Millisecond)
+	}
+	hash1 := chainhash.Hash{1}
+	hash2 := chainhash.Hash{2}
+	inv := func(hashes ...chainhash.Hash) []*wire.InvVect {
+		invList := []*wire.InvVect{
+			wire.NewInvVect(wire.InvTypeTx, &chainhash.Hash{9}),
+		}
+		for i := range hashes {
+			invList = append(invList,
+				wire.NewInvVect(wire.InvTypeBlock, &hashes[i]))
+		}
+		return invList
+	}
+
+	// Blocks which were not announced are not tracked.
+	props.received(&hash1, "10.0.0.1:6464", at(0))
+	props.validated(&hash1, 10, at(0))
+	if result := props.result(10); result.Blocks != 0 {
+		t.Fatalf("got %d blocks before announcements", result.Blocks)
+	}
+
+	props.announced(inv(hash1), "10.0.0.1:6464", at(0))
+	props.announced(inv(hash1), "10.0.0.2:6464", at(40))
+	props.announced(inv(hash1), "10.0.0.1:6464", at(50))
+	props.received(&hash1, "10.0.0.1:6464", at(100))
+	props.validated(&hash1, 10, at(130))
+	props.relayed(blockInvHashes(inv(hash1)), at(135))
+	props.relayed(blockInvHashes(inv(hash1)), at(140))
+
+	props.announced(inv(hash2), "10.0.0.2:6464", at(1000))
+	props.announced(inv(hash2), "10.0.0.1:6464", at(1020))
+	props.received(&hash2, "10.0.0.2:6464", at(1200))
+
+	// A locally submitted block is tracked without a download.
+	hash3 := chainhash.Hash{3}
+	props.received(&hash3, "", at(2000))
+	props.validated(&hash3, 11, at(2010))
+
+	// Inventory with more blocks than announcements is ignored.
+	var many []chainhash.Hash
+	for i := 0; i <= maxPropagationInvBlocks; i++ {
+		many = append(many, chainhash.Hash{4, byte(i)})
+	}
+	props.announced(inv(many...), "10.0.0.3:6464", at(3000))
+
+	result := props.result(2)
+	if result.Blocks != 3 {
+		t.Fatalf("got %d blocks, want 3", result.Blocks)
+	}
+	if result.AvgDownload != 150 || result.AvgValidation != 20 ||
+		result.AvgRelay != 5 {
+
+		t.Fatalf("got averages %v/%v/%v, want 150/20/5",
+			result.AvgDownload, result.AvgValidation, result.AvgRelay)
+	}
+
+	if len(result.Recent) != 2 {
+		t.Fatalf("got %d recent blocks, want 2", len(result.Recent))
+	}
+	local := result.Recent[0]
+	if local.Hash != hash3.String() || local.Height != 11 ||
+		local.FirstPeer != "" || local.ReceivedFrom != "" ||
+		local.FirstSeen != unixMillis(at(2000)) ||
+		local.Validated != unixMillis(at(2010)) {
+
+		t.Fatalf("unexpected local block %+v", local)
+	}
+	pending := result.Recent[1]
+	if pending.Hash != hash2.String() || pending.Height != 0 ||
+		pending.FirstPeer != "10.0.0.2:6464" ||
+		pending.ReceivedFrom != "10.0.0.2:6464" ||
+		pending.Validated != 0 || pending.Announcements != 2 {
+
+		t.Fatalf("unexpected unvalidated block %+v", pending)
+	}
+
+	block := props.result(3).Recent[2]
+	if block.Hash != hash1.String() || block.Height != 10 ||
+		block.FirstPeer != "10.0.0.1:6464" ||
+		block.Received != unixMillis(at(100)) ||
+		block.Relayed != unixMillis(at(135)) || block.RelayedTo != 2 ||
+		block.Announcements != 2 {
+
+		t.Fatalf("unexpected relayed block %+v", block)
+	}
+
+	// The slowest peer is reported first.
+	if len(result.Peers) != 2 {
+		t.Fatalf("got %d peers, want 2", len(result.Peers))
+	}
+	slow, fast := result.Peers[0], result.Peers[1]
+	if slow.Addr != "10.0.0.2:6464" || slow.Announced != 2 ||
+		slow.FirstSeen != 1 || slow.AvgDelay != 20 || slow.MaxDelay != 40 {
+
+		t.Fatalf("unexpected slow peer %+v", slow)
+	}
+	if fast.Addr != "10.0.0.1:6464" || fast.Announced != 2 ||
+		fast.FirstSeen != 1 || fast.AvgDelay != 10 || fast.MaxDelay != 20 {
+
+		t.Fatalf("unexpected fast peer %+v", fast)
+	}
+
+	// The oldest blocks are dropped once the maximum is kept.
+	for i := 0; i < maxBlockPropagations; i++ {
+		hash := chainhash.Hash{5, byte(i), byte(i >> 8)}
+		props.announced(inv(hash), "10.0.0.1:6464", at(4000+i))
+	}
+	result = props.result(1)
+	if result.Blocks != maxBlockPropagations {
+		t.Fatalf("got %d blocks, want %d", result.Blocks,
+			maxBlockPropagations)
+	}
+	props.validated(&hash1, 10, at(9000))
+	if result := props.result(maxBlockPropagations); result.AvgValidation != 0 {
+		t.Fatalf("got average validation %v after dropping the "+
+			"validated blocks", result.AvgValidation)
+	}
+}
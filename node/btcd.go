@@ -4,20 +4,16 @@
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
-package main
+package node
 
 import (
-	"fmt"
 	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
-	"runtime"
-	"runtime/debug"
 	"runtime/pprof"
 
 	"github.com/pyx-partners/dmgd/blockchain/indexers"
-	"github.com/pyx-partners/dmgd/limits"
 )
 
 var (
@@ -36,7 +32,7 @@ var winServiceMain func() (bool, error)
 func btcdMain(serverChan chan<- *server) error {
 	// Load configuration and parse command line.  This function also
 	// initializes logging and configures it accordingly.
-	tcfg, _, err := loadConfig()
+	tcfg, _, err := loadConfig(os.Args[1:])
 	if err != nil {
 		return err
 	}
@@ -81,7 +77,48 @@ func btcdMain(serverChan chan<- *server) error {
 		return nil
 	}
 
-	// Load the block database.
+	// Drop indexes and exit if requested.
+	if cfg.DropAddrIndex || cfg.DropCommitmentIndex || cfg.DropTxIndex ||
+		cfg.DropWatchIndex || cfg.DropStatsIndex {
+
+		return dropIndexes()
+	}
+
+	// Create the node and start it.
+	n, err := newNode(interruptedChan)
+	if err != nil {
+		// Catching up the indexes and verifying the database stop early
+		// when interrupted.  Each block is indexed atomically, so the
+		// catch up resumes on the next start.
+		if interruptRequested(interruptedChan) {
+			return nil
+		}
+
+		// TODO: this logging could do with some beautifying.
+		btcdLog.Errorf("Unable to start server on %v: %v",
+			cfg.Listeners, err)
+		return err
+	}
+	defer n.Stop()
+	n.Start()
+	reloadListener(n.server, interruptedChan)
+	if serverChan != nil {
+		serverChan <- n.server
+	}
+
+	// Wait until the interrupt signal is received from an OS signal or
+	// shutdown is requested through one of the subsystems such as the RPC
+	// server.
+	<-interruptedChan
+	return nil
+}
+
+// dropIndexes drops the optional indexes selected with the drop index options
+// from the block database.
+//
+// NOTE: The order is important here because dropping the tx index also drops
+// the address and commitment indexes since they rely on it.
+func dropIndexes() error {
 	db, err := loadBlockDB()
 	if err != nil {
 		btcdLog.Errorf("%v", err)
@@ -93,15 +130,6 @@ func btcdMain(serverChan chan<- *server) error {
 		db.Close()
 	}()
 
-	// Return now if an interrupt signal was triggered.
-	if interruptRequested(interruptedChan) {
-		return nil
-	}
-
-	// Drop indexes and exit if requested.
-	//
-	// NOTE: The order is important here because dropping the tx index also
-	// drops the address index since it relies on it.
 	if cfg.DropAddrIndex {
 		if err := indexers.DropAddrIndex(db); err != nil {
 			btcdLog.Errorf("%v", err)
@@ -110,6 +138,14 @@ func btcdMain(serverChan chan<- *server) error {
 
 		return nil
 	}
+	if cfg.DropCommitmentIndex {
+		if err := indexers.DropCommitmentIndex(db); err != nil {
+			btcdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
 	if cfg.DropTxIndex {
 		if err := indexers.DropTxIndex(db); err != nil {
 			btcdLog.Errorf("%v", err)
@@ -118,65 +154,35 @@ func btcdMain(serverChan chan<- *server) error {
 
 		return nil
 	}
+	if cfg.DropWatchIndex {
+		if err := indexers.DropWatchIndex(db); err != nil {
+			btcdLog.Errorf("%v", err)
+			return err
+		}
 
-	// Create server and start it.
-	server, err := newServer(cfg.Listeners, db, activeNetParams.Params)
-	if err != nil {
-		// TODO: this logging could do with some beautifying.
-		btcdLog.Errorf("Unable to start server on %v: %v",
-			cfg.Listeners, err)
-		return err
+		return nil
 	}
-	defer func() {
-		btcdLog.Infof("Gracefully shutting down the server...")
-		server.Stop()
-		server.WaitForShutdown()
-		srvrLog.Infof("Server shutdown complete")
-	}()
-	server.Start()
-	if serverChan != nil {
-		serverChan <- server
+	if err := indexers.DropStatsIndex(db); err != nil {
+		btcdLog.Errorf("%v", err)
+		return err
 	}
 
-	// Wait until the interrupt signal is received from an OS signal or
-	// shutdown is requested through one of the subsystems such as the RPC
-	// server.
-	<-interruptedChan
 	return nil
 }
 
-func main() {
-	// Use all processor cores.
-	runtime.GOMAXPROCS(runtime.NumCPU())
-
-	// Block and transaction processing can cause bursty allocations.  This
-	// limits the garbage collector from excessively overallocating during
-	// bursts.  This value was arrived at with the help of profiling live
-	// usage.
-	debug.SetGCPercent(10)
-
-	// Up some limits.
-	if err := limits.SetLimits(); err != nil {
-		fmt.Fprintf(os.Stderr, "failed to set limits: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Call serviceMain on Windows to handle running as a service.  When
-	// the return isService flag is true, exit now since we ran as a
-	// service.  Otherwise, just fall through to normal operation.
-	if runtime.GOOS == "windows" {
-		isService, err := winServiceMain()
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		}
-		if isService {
-			os.Exit(0)
-		}
-	}
+// Main runs dmgd as the command of the same name, configured from the command
+// line and the configuration file, until it is interrupted by an OS signal or
+// a shutdown request.  It returns an error when the node failed.
+func Main() error {
+	return btcdMain(nil)
+}
 
-	// Work around defer not working after os.Exit()
-	if err := btcdMain(nil); err != nil {
-		os.Exit(1)
+// RunAsService runs dmgd as a Windows service when the process was started by
+// the service control manager, in which case it returns true once the service
+// stopped.  It returns false on other platforms.
+func RunAsService() (bool, error) {
+	if winServiceMain == nil {
+		return false, nil
 	}
+	return winServiceMain()
 }
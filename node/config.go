@@ -4,12 +4,13 @@
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
-package main
+package node
 
 import (
 	"bufio"
 	"crypto/rand"
 	"crypto/sha256"
+	"database/sql"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
@@ -24,13 +25,17 @@ import (
 	"strings"
 	"time"
 
+	"github.com/pyx-partners/dmgd/btcec"
 	"github.com/pyx-partners/dmgd/chaincfg"
 	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
 	"github.com/pyx-partners/dmgd/connmgr"
 	"github.com/pyx-partners/dmgd/database"
 	_ "github.com/pyx-partners/dmgd/database/ffldb"
+	"github.com/pyx-partners/dmgd/eventbridge"
+	"github.com/pyx-partners/dmgd/issuancemonitor"
 	"github.com/pyx-partners/dmgd/mempool"
 	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/webhook"
 	"github.com/pyx-partners/dmgd/wire"
 	flags "github.com/btcsuite/go-flags"
 	"github.com/btcsuite/go-socks/socks"
@@ -42,14 +47,26 @@ const (
 	defaultLogLevel              = "info"
 	defaultLogDirname            = "logs"
 	defaultLogFilename           = "dmgd.log"
+	rpcCookieFilename            = ".cookie"
 	defaultMaxPeers              = 125
+	defaultValidatorOutbound     = 2
+	defaultArchivalOutbound      = 2
+	defaultExplorerOutbound      = 1
 	defaultBanDuration           = time.Hour * 24
 	defaultBanThreshold          = 100
 	defaultConnectTimeout        = time.Second * 30
 	defaultMaxRPCClients         = 10
 	defaultMaxRPCWebsockets      = 25
 	defaultMaxRPCConcurrentReqs  = 20
+	defaultRPCSlowCall           = time.Second
+	defaultFinalityDepth         = 100
+	defaultCheckpointInterval    = 1000
 	defaultDbType                = "ffldb"
+	defaultDbCache               = 250
+	checkDBNone                  = 0
+	checkDBBlocks                = 1
+	checkDBIndexes               = 2
+	checkDBKeyState              = 3
 	defaultFreeTxRelayLimit      = 2500.0
 	defaultBlockMinSize          = 500000
 	defaultBlockMaxSize          = 750000
@@ -57,12 +74,20 @@ const (
 	blockMaxSizeMax              = wire.MaxBlockPayload - 1000
 	defaultGenerate              = false
 	defaultMaxOrphanTransactions = 100
-	defaultMaxOrphanTxSize       = mempool.MaxStandardTxSize
+	defaultMaxOrphanTxSize       = mempool.DefaultMaxStandardTxSize
+	defaultMaxRevokedKeyIDOuts   = -1
 	defaultSigCacheMaxSize       = 100000
 	sampleConfigFilename         = "sample-dmgd.conf"
 	defaultTxIndex               = false
 	defaultAddrIndex             = false
+	defaultCommitmentIndex       = false
+	defaultWatchIndex            = false
+	defaultStatsIndex            = false
 	defaultUseOnlySyncPeerInv    = false
+	defaultEventSubject          = "dmgd"
+	eventPublishTimeout          = time.Second * 30
+	eventCursorFilename          = "eventcursor.json"
+	defaultSQLExportDriver       = "postgres"
 )
 
 var (
@@ -96,26 +121,51 @@ type config struct {
 	ConfigFile           string        `short:"C" long:"configfile" description:"Path to configuration file"`
 	DataDir              string        `short:"b" long:"datadir" description:"Directory to store data"`
 	LogDir               string        `long:"logdir" description:"Directory to log output."`
+	AuditLogFile         string        `long:"auditlog" description:"Append a tamper-evident audit log of admin transactions, key set changes, issuances, reorgs of admin transactions, changes to the validate keys and changes to the block template exclusions to the specified file"`
+	EventPublisher       string        `long:"eventpublisher" description:"Publish the block connected and disconnected, transaction confirmed and admin operation applied events of the main chain to the JetStream streams of the NATS server at the specified URL (nats://[user:pass@]host:port)"`
+	EventSubject         string        `long:"eventsubject" description:"Subject prefix of the published events"`
+	SQLExport            string        `long:"sqlexport" description:"Mirror the blocks, transactions, outputs, admin operations and key changes of the main chain into the PostgreSQL database with the specified data source name"`
+	SQLExportDriver      string        `long:"sqlexportdriver" description:"Name of the database/sql driver used to connect to the SQL export database"`
+	IssuanceThresholds   []string      `long:"issuancethreshold" description:"Add a threshold of the issuance monitor which raises an alert when more than the amount of the asset is issued within the window of block time (<window>:<amount in DMG>[:<assetid>], eg. 24h:1000000)"`
+	IssuanceHours        string        `long:"issuancehours" description:"Raise an alert for issuance in blocks whose timestamp is outside of these business hours (<days> <start>-<end> [<time zone>], eg. mon-fri 09:00-17:00 Europe/Berlin)"`
+	IssuanceWebhook      string        `long:"issuancewebhook" description:"POST the alerts of the issuance monitor as JSON to the specified URL"`
+	Webhooks             []string      `long:"webhook" description:"Add a webhook the selected chain events are POSTed to as JSON signed with the webhook secret (<event>,...@<url> -- events: deposit, adminop, reorg -- eg. deposit,reorg@https://example.com/hook)"`
+	WebhookSecret        string        `long:"webhooksecret" default-mask:"-" description:"Secret key of the HMAC-SHA256 signatures of the webhook deliveries"`
 	AddPeers             []string      `short:"a" long:"addpeer" description:"Add a peer to connect with at startup"`
 	ConnectPeers         []string      `long:"connect" description:"Connect only to the specified peers at startup"`
 	DisableListen        bool          `long:"nolisten" description:"Disable listening for incoming connections -- NOTE: Listening is automatically disabled if the --connect or --proxy options are used without also specifying listen interfaces via --listen"`
-	Listeners            []string      `long:"listen" description:"Add an interface/port to listen for connections (default all interfaces port: 6464, testnet: 16464)"`
+	Listeners            []string      `long:"listen" description:"Add an interface/port to listen for connections, optionally followed by the comma-separated options noadvertise to not advertise it to peers or advertise=<ip>[:<port>] to advertise another address for it (default all interfaces port: 6464, testnet: 16464)"`
 	MaxPeers             int           `long:"maxpeers" description:"Max number of inbound and outbound peers"`
+	ValidatorPeers       []string      `long:"validatorpeer" description:"Add a validator peer to keep outbound connections to"`
+	ValidatorOutbound    uint32        `long:"validatoroutbound" description:"Number of outbound connections to keep to the validator peers"`
+	ArchivalOutbound     uint32        `long:"archivaloutbound" description:"Number of outbound connections to keep to archival peers serving the full block chain"`
+	ExplorerPeers        []string      `long:"explorerpeer" description:"Add an explorer-facing peer to keep outbound connections to"`
+	ExplorerOutbound     uint32        `long:"exploreroutbound" description:"Number of outbound connections to keep to the explorer-facing peers"`
 	DisableBanning       bool          `long:"nobanning" description:"Disable banning of misbehaving peers"`
 	BanDuration          time.Duration `long:"banduration" description:"How long to ban misbehaving peers.  Valid time units are {s, m, h}.  Minimum 1 second"`
 	BanThreshold         uint32        `long:"banthreshold" description:"Maximum allowed ban score before disconnecting and banning misbehaving peers."`
+	Whitelists           []string      `long:"whitelist" description:"Add an IP network or IP that will not be banned. (eg. 192.168.1.0/24 or ::1)"`
 	RPCUser              string        `short:"u" long:"rpcuser" description:"Username for RPC connections"`
 	RPCPass              string        `short:"P" long:"rpcpass" default-mask:"-" description:"Password for RPC connections"`
 	RPCHash              string        `long:"rpchash" description:"SHA2 of auth credentials (may be specified instead of user/pass)"`
 	RPCLimitUser         string        `long:"rpclimituser" description:"Username for limited RPC connections"`
 	RPCLimitPass         string        `long:"rpclimitpass" default-mask:"-" description:"Password for limited RPC connections"`
 	RPCLimitHash         string        `long:"rpclimithash" description:"SHA2 of auth credentials for limited RPC user (may be specified instead of user/pass)"`
-	RPCListeners         []string      `long:"rpclisten" description:"Add an interface/port to listen for RPC connections (default port: 8334, testnet: 18334)"`
+	RPCAuths             []string      `long:"rpcauth" description:"Add RPC credentials allowed to call only the listed methods (<user>:<password>:<method>,<method>,... -- limited allows the methods of the limited user, * allows all methods)"`
+	RPCCookie            bool          `long:"rpccookie" description:"Write randomly generated admin RPC credentials to the .cookie file in the data directory at startup for use by local clients"`
+	RPCUnixSocket        string        `long:"rpcunixsocket" description:"Also serve RPC without TLS on a Unix domain socket at the specified path which only the user running dmgd may access"`
+	RPCListeners         []string      `long:"rpclisten" description:"Add an interface/port to listen for RPC connections, optionally followed by ,limited to only allow the methods of the limited user on it (default port: 8334, testnet: 18334)"`
 	RPCCert              string        `long:"rpccert" description:"File containing the certificate file"`
 	RPCKey               string        `long:"rpckey" description:"File containing the certificate key"`
+	RPCSNICerts          []string      `long:"rpcsnicert" description:"Add a file containing a certificate served to RPC clients which request one of its names with server name indication (SNI) instead of the rpccert certificate"`
+	RPCSNIKeys           []string      `long:"rpcsnikey" description:"Add a file containing the key of the rpcsnicert certificate in the same position"`
+	RPCClientCA          string        `long:"rpcclientca" description:"File containing the certificate authorities which issue RPC client certificates -- NOTE: When set, clients must present a certificate issued by one of them in addition to their credentials"`
 	RPCMaxClients        int           `long:"rpcmaxclients" description:"Max number of RPC clients for standard connections"`
 	RPCMaxWebsockets     int           `long:"rpcmaxwebsockets" description:"Max number of RPC websocket connections"`
 	RPCMaxConcurrentReqs int           `long:"rpcmaxconcurrentreqs" description:"Max number of concurrent RPC requests that may be processed concurrently"`
+	RPCSlowCall          time.Duration `long:"rpcslowcall" description:"Log RPC calls which take longer than this and report them in getrpcinfo.  Valid time units are {ms, s, m}.  0 disables slow call tracking"`
+	KeyIDCensusInterval  time.Duration `long:"keyidcensusinterval" description:"Scan the unspent transaction output set for the usage of each key ID in the background at this interval for the getkeyidcensus RPC.  Valid time units are {s, m, h}.  0 only scans on request"`
+	FinalityDepth        uint32        `long:"finalitydepth" description:"Number of confirmations after which getfinalitystatus reports a block and its transactions as irreversible"`
 	RPCQuirks            bool          `long:"rpcquirks" description:"Mirror some JSON-RPC quirks of Bitcoin Core -- NOTE: Discouraged unless interoperability issues need to be worked around"`
 	DisableRPC           bool          `long:"norpc" description:"Disable built-in RPC server -- NOTE: The RPC server is disabled by default if no rpcuser/rpcpass or rpclimituser/rpclimitpass is specified"`
 	DisableTLS           bool          `long:"notls" description:"Disable TLS for the RPC server -- NOTE: This is only allowed if the RPC server is bound to localhost"`
@@ -133,16 +183,29 @@ type config struct {
 	RegressionTest       bool          `long:"regtest" description:"Use the regression test network"`
 	SimNet               bool          `long:"simnet" description:"Use the simulation test network"`
 	AddCheckpoints       []string      `long:"addcheckpoint" description:"Add a custom checkpoint.  Format: '<height>:<hash>'"`
+	CheckpointKey        string        `long:"checkpointkey" default-mask:"-" description:"Hex-encoded private key used to sign rolling checkpoints of the main chain"`
+	CheckpointInterval   uint32        `long:"checkpointinterval" description:"Number of blocks between the rolling checkpoints signed with the checkpoint key"`
+	CheckpointPubKeys    []string      `long:"checkpointpubkey" description:"Add a hex-encoded public key whose signed checkpoints are accepted and enforced"`
 	DbType               string        `long:"dbtype" description:"Database backend to use for the Block Chain"`
+	DbCache              uint64        `long:"dbcache" description:"The maximum size in MiB of the cache of unspent transaction outputs, which is flushed to the database when full.  0 writes them to the database with every block"`
+	MmapBlocks           bool          `long:"mmapblocks" description:"Memory-map the block files to serve and reindex blocks without copying them -- Only supported by the ffldb database on 64-bit unix systems"`
+	CheckDB              int           `long:"checkdb" description:"Verify the database on start up at the given level: 1 checks the block files and block index, 2 also checks the optional indexes and 3 also replays the admin transactions to check the admin key state"`
 	Profile              string        `long:"profile" description:"Enable HTTP profiling on given port -- NOTE port must be between 1024 and 65536"`
 	CPUProfile           string        `long:"cpuprofile" description:"Write CPU profile to the specified file"`
 	DebugLevel           string        `short:"d" long:"debuglevel" description:"Logging level for all subsystems {trace, debug, info, warn, error, critical} -- You may also specify <subsystem>=<level>,<subsystem2>=<level>,... to set the log level for individual subsystems -- Use show to list available subsystems"`
 	Upnp                 bool          `long:"upnp" description:"Use UPnP to map our listening port outside of NAT"`
+	NATPMP               bool          `long:"natpmp" description:"Use NAT-PMP to map our listening port outside of NAT -- UPnP is tried first when both are enabled"`
 	UseOnlySyncPeerInv   bool          `long:"useonlysyncpeerinv" description:"Use only sync peer inv messages to reduce orphan fetching"`
 	MinRelayTxFee        float64       `long:"minrelaytxfee" description:"The minimum transaction fee in DMG/kB to be considered a non-zero fee."`
 	FreeTxRelayLimit     float64       `long:"limitfreerelay" description:"Limit relay of transactions with no transaction fee to the given amount in thousands of bytes per minute"`
 	RelayPriority        bool          `long:"relaypriority" description:"Require free or low-fee transactions to have high priority for relaying"`
 	MaxOrphanTxs         int           `long:"maxorphantx" description:"Max number of orphan transactions to keep in memory"`
+	DustRelayFee         float64       `long:"dustrelayfee" description:"The fee rate in DMG/kB used to determine whether a transaction output is dust (0 uses minrelaytxfee)"`
+	MaxStdTxSize         int           `long:"maxstdtxsize" description:"Max size in bytes of a transaction to be considered standard (0 uses the default for the active network)"`
+	MaxStdSigScriptSize  int           `long:"maxstdsigscriptsize" description:"Max size in bytes of a transaction input signature script to be considered standard (0 uses the default for the active network)"`
+	MaxRevokedKeyIDOuts  int           `long:"maxrevokedkeyidoutputs" description:"Reject admin transactions revoking ASP keys whose key ID is still referenced by more than the given number of unspent outputs, which requires scanning the unspent transaction output set (-1 uses the default for the active network, which disables the check)"`
+	AllowUsedKeyIDRevoke bool          `long:"allowusedkeyidrevoke" description:"Accept and relay admin transactions revoking ASP keys whose key ID is still referenced by more unspent outputs than maxrevokedkeyidoutputs allows, which strands the funds of those outputs"`
+	MinAdminTxConfs      uint32        `long:"minadmintxconfs" description:"Hold admin transactions which spend a thread tip with fewer than the given number of confirmations until it has them instead of accepting and relaying them (0 to accept them right away)"`
 	Generate             bool          `long:"generate" description:"Generate (mine) blocks using the CPU"`
 	MiningAddrs          []string      `long:"miningaddr" description:"Add the specified payment address to the list of addresses to use for generated blocks -- At least one address is required if the generate option is set"`
 	BlockMinSize         uint32        `long:"blockminsize" description:"Mininum block size in bytes to be used when creating a block"`
@@ -150,20 +213,42 @@ type config struct {
 	BlockPrioritySize    uint32        `long:"blockprioritysize" description:"Size in bytes for high-priority/low-fee transactions when creating a block"`
 	NoPeerBloomFilters   bool          `long:"nopeerbloomfilters" description:"Disable bloom filtering support"`
 	SigCacheMaxSize      uint          `long:"sigcachemaxsize" description:"The maximum number of entries in the signature verification cache"`
-	BlocksOnly           bool          `long:"blocksonly" description:"Do not accept transactions from remote peers."`
+	BlocksOnly           bool          `long:"blocksonly" description:"Do not accept transactions other than admin transactions from remote peers."`
+	MaxUploadTarget      uint64        `long:"maxuploadtarget" description:"Maximum number of MiB to send to peers per 24 hour cycle, after which blocks older than a week are only served to whitelisted peers (0 for no limit)"`
 	TxIndex              bool          `long:"txindex" description:"Maintain a full hash-based transaction index which makes all transactions available via the getrawtransaction RPC"`
 	DropTxIndex          bool          `long:"droptxindex" description:"Deletes the hash-based transaction index from the database on start up and then exits."`
 	AddrIndex            bool          `long:"addrindex" description:"Maintain a full address-based transaction index which makes the searchrawtransactions RPC available"`
 	DropAddrIndex        bool          `long:"dropaddrindex" description:"Deletes the address-based transaction index from the database on start up and then exits."`
+	CommitmentIndex      bool          `long:"commitmentindex" description:"Maintain a full metadata commitment-based transaction index which makes the getcommitments RPC available"`
+	DropCommitmentIndex  bool          `long:"dropcommitmentindex" description:"Deletes the metadata commitment-based transaction index from the database on start up and then exits."`
+	WatchIndex           bool          `long:"watchindex" description:"Maintain an index of the transactions involving the addresses imported with the importaddress and importpubkey RPCs, which makes the getbalance, listtransactions and listunspent RPCs available"`
+	DropWatchIndex       bool          `long:"dropwatchindex" description:"Deletes the watch-only index from the database on start up and then exits.  The imported addresses are kept."`
+	StatsIndex           bool          `long:"statsindex" description:"Maintain daily aggregates of the blocks in the main chain which make the getchainstats RPC available"`
+	DropStatsIndex       bool          `long:"dropstatsindex" description:"Deletes the chain statistics index from the database on start up and then exits."`
+	Reindex              bool          `long:"reindex" description:"Rebuild the chain state and all optional indexes from the blocks stored in the database on start up"`
+	ReindexChainState    bool          `long:"reindexchainstate" description:"Rebuild the chain state from the blocks stored in the database on start up, keeping the optional indexes"`
 	RelayNonStd          bool          `long:"relaynonstd" description:"Relay non-standard transactions regardless of the default settings for the active network."`
 	RejectNonStd         bool          `long:"rejectnonstd" description:"Reject non-standard transactions regardless of the default settings for the active network."`
+	RelayViolations      bool          `long:"relayviolations" description:"Broadcast the evidence of validate keys which signed two blocks at the same height to peers in alert messages, and relay the evidence received from peers"`
 	EnableExternalRPC    bool          `long:"enableexternalrpc" description:"Allow external listening of the RPC API. This also requires that TLS is not disabled."`
 	lookup               func(string) ([]net.IP, error)
 	oniondial            func(string, string, time.Duration) (net.Conn, error)
 	dial                 func(string, string, time.Duration) (net.Conn, error)
 	addCheckpoints       []chaincfg.Checkpoint
+	checkpointKey        *btcec.PrivateKey
+	eventPublisher       *eventbridge.NATSPublisher
+	sqlExportDB          *sql.DB
+	issuanceThresholds   []issuancemonitor.Threshold
+	issuanceHours        *issuancemonitor.BusinessHours
+	webhookTargets       []*webhook.Target
+	checkpointPubKeys    []*btcec.PublicKey
 	miningAddrs          []provautil.Address
 	minRelayTxFee        provautil.Amount
+	dustRelayFee         provautil.Amount
+	whitelists           []*net.IPNet
+	listenAddrs          []listenAddr
+	rpcListenAddrs       []listenAddr
+	args                 []string
 }
 
 // serviceOptions defines the configuration options for the daemon as a service on
@@ -219,32 +304,34 @@ func supportedSubsystems() []string {
 	return subsystems
 }
 
-// parseAndSetDebugLevels attempts to parse the specified debug level and set
-// the levels accordingly.  An appropriate error is returned if anything is
-// invalid.
-func parseAndSetDebugLevels(debugLevel string) error {
+// parseDebugLevels attempts to parse the specified debug level into the log
+// level of each subsystem it sets.  An appropriate error is returned if
+// anything is invalid.
+func parseDebugLevels(debugLevel string) (map[string]string, error) {
 	// When the specified string doesn't have any delimters, treat it as
 	// the log level for all subsystems.
 	if !strings.Contains(debugLevel, ",") && !strings.Contains(debugLevel, "=") {
 		// Validate debug log level.
 		if !validLogLevel(debugLevel) {
 			str := "The specified debug level [%v] is invalid"
-			return fmt.Errorf(str, debugLevel)
+			return nil, fmt.Errorf(str, debugLevel)
 		}
 
-		// Change the logging level for all subsystems.
-		setLogLevels(debugLevel)
-
-		return nil
+		levels := make(map[string]string, len(subsystemLoggers))
+		for subsysID := range subsystemLoggers {
+			levels[subsysID] = debugLevel
+		}
+		return levels, nil
 	}
 
 	// Split the specified string into subsystem/level pairs while detecting
-	// issues and update the log levels accordingly.
+	// issues.
+	levels := make(map[string]string)
 	for _, logLevelPair := range strings.Split(debugLevel, ",") {
 		if !strings.Contains(logLevelPair, "=") {
 			str := "The specified debug level contains an invalid " +
 				"subsystem/level pair [%v]"
-			return fmt.Errorf(str, logLevelPair)
+			return nil, fmt.Errorf(str, logLevelPair)
 		}
 
 		// Extract the specified subsystem and log level.
@@ -255,21 +342,66 @@ func parseAndSetDebugLevels(debugLevel string) error {
 		if _, exists := subsystemLoggers[subsysID]; !exists {
 			str := "The specified subsystem [%v] is invalid -- " +
 				"supported subsytems %v"
-			return fmt.Errorf(str, subsysID, supportedSubsystems())
+			return nil, fmt.Errorf(str, subsysID, supportedSubsystems())
 		}
 
 		// Validate log level.
 		if !validLogLevel(logLevel) {
 			str := "The specified debug level [%v] is invalid"
-			return fmt.Errorf(str, logLevel)
+			return nil, fmt.Errorf(str, logLevel)
 		}
 
+		levels[subsysID] = logLevel
+	}
+
+	return levels, nil
+}
+
+// parseAndSetDebugLevels attempts to parse the specified debug level and set
+// the levels accordingly.  An appropriate error is returned if anything is
+// invalid, in which case no levels are changed.
+func parseAndSetDebugLevels(debugLevel string) error {
+	levels, err := parseDebugLevels(debugLevel)
+	if err != nil {
+		return err
+	}
+
+	for subsysID, logLevel := range levels {
 		setLogLevel(subsysID, logLevel)
 	}
 
 	return nil
 }
 
+// parseWhitelists parses the specified whitelist entries into IP networks.  A
+// bare IP is treated as a network holding only that IP.
+func parseWhitelists(whitelists []string) ([]*net.IPNet, error) {
+	ipNets := make([]*net.IPNet, 0, len(whitelists))
+	for _, addr := range whitelists {
+		_, ipnet, err := net.ParseCIDR(addr)
+		if err != nil {
+			ip := net.ParseIP(addr)
+			if ip == nil {
+				return nil, fmt.Errorf("The whitelist value of "+
+					"'%s' is invalid", addr)
+			}
+			var bits int
+			if ip.To4() == nil {
+				// IPv6
+				bits = 128
+			} else {
+				bits = 32
+			}
+			ipnet = &net.IPNet{
+				IP:   ip,
+				Mask: net.CIDRMask(bits, bits),
+			}
+		}
+		ipNets = append(ipNets, ipnet)
+	}
+	return ipNets, nil
+}
+
 // validDbType returns whether or not dbType is a supported database type.
 func validDbType(dbType string) bool {
 	for _, knownType := range knownDbTypes {
@@ -383,7 +515,7 @@ func newConfigParser(cfg *config, so *serviceOptions, options flags.Options) *fl
 }
 
 // loadConfig initializes and parses the config using a config file and command
-// line options.
+// line options passed in args, which exclude the program name.
 //
 // The configuration proceeds as follows:
 // 	1) Start with a default config with sane settings
@@ -394,20 +526,27 @@ func newConfigParser(cfg *config, so *serviceOptions, options flags.Options) *fl
 // The above results in btcd functioning properly without any config settings
 // while still allowing the user to override settings with config files and
 // command line options.  Command line options always take precedence.
-func loadConfig() (*config, []string, error) {
+func loadConfig(args []string) (*config, []string, error) {
 	// Default config.
 	cfg := config{
 		ConfigFile:           defaultConfigFile,
 		DebugLevel:           defaultLogLevel,
 		MaxPeers:             defaultMaxPeers,
+		ValidatorOutbound:    defaultValidatorOutbound,
+		ArchivalOutbound:     defaultArchivalOutbound,
+		ExplorerOutbound:     defaultExplorerOutbound,
 		BanDuration:          defaultBanDuration,
 		BanThreshold:         defaultBanThreshold,
 		RPCMaxClients:        defaultMaxRPCClients,
 		RPCMaxWebsockets:     defaultMaxRPCWebsockets,
 		RPCMaxConcurrentReqs: defaultMaxRPCConcurrentReqs,
+		RPCSlowCall:          defaultRPCSlowCall,
+		FinalityDepth:        defaultFinalityDepth,
+		CheckpointInterval:   defaultCheckpointInterval,
 		DataDir:              defaultDataDir,
 		LogDir:               defaultLogDir,
 		DbType:               defaultDbType,
+		DbCache:              defaultDbCache,
 		RPCKey:               defaultRPCKeyFile,
 		RPCCert:              defaultRPCCertFile,
 		MinRelayTxFee:        mempool.DefaultMinRelayTxFee.ToDMG(),
@@ -416,11 +555,17 @@ func loadConfig() (*config, []string, error) {
 		BlockMaxSize:         defaultBlockMaxSize,
 		BlockPrioritySize:    mempool.DefaultBlockPrioritySize,
 		MaxOrphanTxs:         defaultMaxOrphanTransactions,
+		MaxRevokedKeyIDOuts:  defaultMaxRevokedKeyIDOuts,
 		SigCacheMaxSize:      defaultSigCacheMaxSize,
 		Generate:             defaultGenerate,
 		TxIndex:              defaultTxIndex,
 		AddrIndex:            defaultAddrIndex,
+		CommitmentIndex:      defaultCommitmentIndex,
+		WatchIndex:           defaultWatchIndex,
+		StatsIndex:           defaultStatsIndex,
 		UseOnlySyncPeerInv:   defaultUseOnlySyncPeerInv,
+		EventSubject:         defaultEventSubject,
+		SQLExportDriver:      defaultSQLExportDriver,
 	}
 
 	// Service options which are only added on Windows.
@@ -432,7 +577,7 @@ func loadConfig() (*config, []string, error) {
 	// the final parse below.
 	preCfg := cfg
 	preParser := newConfigParser(&preCfg, &serviceOpts, flags.HelpFlag)
-	_, err := preParser.Parse()
+	_, err := preParser.ParseArgs(args)
 	if err != nil {
 		if e, ok := err.(*flags.Error); ok && e.Type == flags.ErrHelp {
 			fmt.Fprintln(os.Stderr, err)
@@ -492,7 +637,7 @@ func loadConfig() (*config, []string, error) {
 	}
 
 	// Parse command line options again to ensure they take precedence.
-	remainingArgs, err := parser.Parse()
+	remainingArgs, err := parser.ParseArgs(args)
 	if err != nil {
 		if e, ok := err.(*flags.Error); !ok || e.Type != flags.ErrHelp {
 			fmt.Fprintln(os.Stderr, usageMessage)
@@ -581,6 +726,47 @@ func loadConfig() (*config, []string, error) {
 	cfg.LogDir = cleanAndExpandPath(cfg.LogDir)
 	cfg.LogDir = filepath.Join(cfg.LogDir, activeNetParams.Name)
 
+	// Expand the path of the audit log when it is enabled.
+	if cfg.AuditLogFile != "" {
+		cfg.AuditLogFile = cleanAndExpandPath(cfg.AuditLogFile)
+	}
+
+	// Expand the path of the RPC Unix domain socket when it is enabled.
+	if cfg.RPCUnixSocket != "" {
+		cfg.RPCUnixSocket = cleanAndExpandPath(cfg.RPCUnixSocket)
+	}
+
+	// Expand the paths of the additional RPC TLS files.  The server
+	// certificates served with SNI must be paired with their keys, and
+	// none of the files are used without TLS.
+	for i := range cfg.RPCSNICerts {
+		cfg.RPCSNICerts[i] = cleanAndExpandPath(cfg.RPCSNICerts[i])
+	}
+	for i := range cfg.RPCSNIKeys {
+		cfg.RPCSNIKeys[i] = cleanAndExpandPath(cfg.RPCSNIKeys[i])
+	}
+	if cfg.RPCClientCA != "" {
+		cfg.RPCClientCA = cleanAndExpandPath(cfg.RPCClientCA)
+	}
+	if len(cfg.RPCSNICerts) != len(cfg.RPCSNIKeys) {
+		str := "%s: each --rpcsnicert option must be paired with an " +
+			"--rpcsnikey option"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+	if cfg.DisableTLS && (len(cfg.RPCSNICerts) != 0 ||
+		cfg.RPCClientCA != "") {
+
+		str := "%s: the --rpcsnicert and --rpcclientca options may " +
+			"not be used with --notls"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Special show command to list supported subsystems and exit.
 	if cfg.DebugLevel == "show" {
 		fmt.Println("Supported subsystems", supportedSubsystems())
@@ -609,6 +795,112 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Memory-mapped block files are only supported by ffldb.
+	if cfg.MmapBlocks && cfg.DbType != "ffldb" {
+		str := "%s: The mmapblocks option is not supported by the %v " +
+			"database"
+		err := fmt.Errorf(str, funcName, cfg.DbType)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Validate the database verification level.
+	if cfg.CheckDB < checkDBNone || cfg.CheckDB > checkDBKeyState {
+		str := "%s: The checkdb option must be between %d and %d"
+		err := fmt.Errorf(str, funcName, checkDBNone, checkDBKeyState)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Validate the URL of the NATS server events are published to.
+	if cfg.EventPublisher != "" {
+		publisher, err := eventbridge.NewNATSPublisher(cfg.EventPublisher,
+			cfg.EventSubject, eventPublishTimeout)
+		if err != nil {
+			err := fmt.Errorf("%s: %v", funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.eventPublisher = publisher
+	}
+
+	// Validate the driver of the SQL export database.  The connection is
+	// only established when the server is created.
+	if cfg.SQLExport != "" {
+		db, err := sql.Open(cfg.SQLExportDriver, cfg.SQLExport)
+		if err != nil {
+			err := fmt.Errorf("%s: %v", funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.sqlExportDB = db
+	}
+
+	// Parse the thresholds and business hours of the issuance monitor.
+	for _, s := range cfg.IssuanceThresholds {
+		threshold, err := issuancemonitor.ParseThreshold(s)
+		if err != nil {
+			err := fmt.Errorf("%s: %v", funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.issuanceThresholds = append(cfg.issuanceThresholds, *threshold)
+	}
+	if cfg.IssuanceHours != "" {
+		hours, err := issuancemonitor.ParseBusinessHours(cfg.IssuanceHours)
+		if err != nil {
+			err := fmt.Errorf("%s: %v", funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.issuanceHours = hours
+	}
+	if cfg.IssuanceWebhook != "" && len(cfg.issuanceThresholds) == 0 &&
+		cfg.issuanceHours == nil {
+
+		str := "%s: The issuancewebhook option requires the " +
+			"issuancethreshold or issuancehours option"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Parse the webhooks, whose deliveries must be signed.  Deposits to
+	// watched addresses are found with the watch-only index.
+	for _, s := range cfg.Webhooks {
+		target, err := webhook.ParseTarget(s)
+		if err != nil {
+			err := fmt.Errorf("%s: %v", funcName, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		if target.Events[webhook.EventDeposit] && !cfg.WatchIndex {
+			str := "%s: The deposit event of the webhook option " +
+				"requires the watchindex option"
+			err := fmt.Errorf(str, funcName)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.webhookTargets = append(cfg.webhookTargets, target)
+	}
+	if len(cfg.webhookTargets) != 0 && cfg.WebhookSecret == "" {
+		str := "%s: The webhook option requires the webhooksecret " +
+			"option"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Validate profile port number
 	if cfg.Profile != "" {
 		profilePort, err := strconv.Atoi(cfg.Profile)
@@ -630,6 +922,25 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Don't allow a negative key ID census interval.
+	if cfg.KeyIDCensusInterval < 0 {
+		str := "%s: The keyidcensusinterval option may not be negative " +
+			"-- parsed [%v]"
+		err := fmt.Errorf(str, funcName, cfg.KeyIDCensusInterval)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Validate any given whitelisted IP addresses and networks.
+	cfg.whitelists, err = parseWhitelists(cfg.Whitelists)
+	if err != nil {
+		err := fmt.Errorf("%s: %v", funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// --addPeer and --connect do not mix.
 	if len(cfg.AddPeers) > 0 && len(cfg.ConnectPeers) > 0 {
 		str := "%s: the --addpeer and --connect options can not be " +
@@ -640,6 +951,18 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// --validatorpeer and --explorerpeer do not mix with --connect.
+	if (len(cfg.ValidatorPeers) > 0 || len(cfg.ExplorerPeers) > 0) &&
+		len(cfg.ConnectPeers) > 0 {
+
+		str := "%s: the --validatorpeer and --explorerpeer options can " +
+			"not be mixed with --connect"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// --proxy or --connect without --listen disables listening.
 	if (cfg.Proxy != "" || len(cfg.ConnectPeers) > 0) &&
 		len(cfg.Listeners) == 0 {
@@ -696,9 +1019,19 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
-	// The RPC server is disabled if no hash or (username+password) is provided.
+	// Validate the RPC credentials with per-method permissions.
+	if _, err := rpcAuths(&cfg); err != nil {
+		err := fmt.Errorf("%s: %v", funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// The RPC server is disabled if no hash or (username+password) is provided
+	// and no cookie is generated.
 	if (cfg.RPCHash == "" && (cfg.RPCUser == "" || cfg.RPCPass == "")) &&
-		(cfg.RPCLimitHash == "" && (cfg.RPCLimitUser == "" || cfg.RPCLimitPass == "")) {
+		(cfg.RPCLimitHash == "" && (cfg.RPCLimitUser == "" || cfg.RPCLimitPass == "")) &&
+		len(cfg.RPCAuths) == 0 && !cfg.RPCCookie {
 		cfg.DisableRPC = true
 	}
 
@@ -725,6 +1058,55 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Validate the dustrelayfee and fall back to the minrelaytxfee
+	// when it is not set.
+	cfg.dustRelayFee, err = provautil.NewAmount(cfg.DustRelayFee)
+	if err != nil || cfg.dustRelayFee < 0 {
+		str := "%s: invalid dustrelayfee: %v"
+		err := fmt.Errorf(str, funcName, cfg.DustRelayFee)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+	if cfg.dustRelayFee == 0 {
+		cfg.dustRelayFee = cfg.minRelayTxFee
+	}
+
+	// Set the standard transaction and signature script size limits
+	// according to the defaults of the active network unless they have
+	// been overridden.
+	if cfg.MaxStdTxSize < 0 || cfg.MaxStdSigScriptSize < 0 {
+		str := "%s: The maxstdtxsize and maxstdsigscriptsize options " +
+			"may not be less than 0 -- parsed [%d, %d]"
+		err := fmt.Errorf(str, funcName, cfg.MaxStdTxSize,
+			cfg.MaxStdSigScriptSize)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+	if cfg.MaxStdTxSize == 0 {
+		cfg.MaxStdTxSize = activeNetParams.MaxStandardTxSize
+	}
+	if cfg.MaxStdSigScriptSize == 0 {
+		cfg.MaxStdSigScriptSize = activeNetParams.MaxStandardSigScriptSize
+	}
+
+	// Checking the outputs still referencing revoked keyIDs requires
+	// scanning the utxo set, so it is only done when opted into.
+	if cfg.MaxRevokedKeyIDOuts < 0 {
+		cfg.MaxRevokedKeyIDOuts = activeNetParams.MaxRevokedKeyIDOutputs
+	}
+
+	// A block is at least confirmed by itself, so the finality depth must
+	// be at least one.
+	if cfg.FinalityDepth == 0 {
+		str := "%s: The finalitydepth option must be greater than 0"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Limit the max block size to a sane value.
 	if cfg.BlockMaxSize < blockMaxSizeMin || cfg.BlockMaxSize >
 		blockMaxSizeMax {
@@ -784,6 +1166,60 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// --commitmentindex and --dropcommitmentindex do not mix.
+	if cfg.CommitmentIndex && cfg.DropCommitmentIndex {
+		err := fmt.Errorf("%s: the --commitmentindex and "+
+			"--dropcommitmentindex options may not be activated at "+
+			"the same time", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --commitmentindex and --droptxindex do not mix.
+	if cfg.CommitmentIndex && cfg.DropTxIndex {
+		err := fmt.Errorf("%s: the --commitmentindex and --droptxindex "+
+			"options may not be activated at the same time "+
+			"because the commitment index relies on the transaction "+
+			"index",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --watchindex and --dropwatchindex do not mix.
+	if cfg.WatchIndex && cfg.DropWatchIndex {
+		err := fmt.Errorf("%s: the --watchindex and --dropwatchindex "+
+			"options may not be activated at the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --statsindex and --dropstatsindex do not mix.
+	if cfg.StatsIndex && cfg.DropStatsIndex {
+		err := fmt.Errorf("%s: the --statsindex and --dropstatsindex "+
+			"options may not be activated at the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --statsindex and --droptxindex do not mix.
+	if cfg.StatsIndex && cfg.DropTxIndex {
+		err := fmt.Errorf("%s: the --statsindex and --droptxindex "+
+			"options may not be activated at the same time "+
+			"because the chain statistics index relies on the "+
+			"transaction index",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Check mining addresses are valid and saved parsed versions.
 	cfg.miningAddrs = make([]provautil.Address, 0, len(cfg.MiningAddrs))
 	for _, strAddr := range cfg.MiningAddrs {
@@ -816,15 +1252,28 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
-	// Add default port to all listener addresses if needed and remove
-	// duplicate addresses.
-	cfg.Listeners = normalizeAddresses(cfg.Listeners,
-		activeNetParams.DefaultPort)
+	// Parse the options of the listener addresses, add the default port
+	// to them if needed and remove duplicate addresses.
+	cfg.listenAddrs, err = parseListenAddrs(cfg.Listeners,
+		activeNetParams.DefaultPort, false)
+	if err != nil {
+		err := fmt.Errorf("%s: %v", funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+	cfg.Listeners = listenAddrStrings(cfg.listenAddrs)
 
-	// Add default port to all rpc listener addresses if needed and remove
-	// duplicate addresses.
-	cfg.RPCListeners = normalizeAddresses(cfg.RPCListeners,
-		activeNetParams.rpcPort)
+	// Do the same for the rpc listener addresses.
+	cfg.rpcListenAddrs, err = parseListenAddrs(cfg.RPCListeners,
+		activeNetParams.rpcPort, true)
+	if err != nil {
+		err := fmt.Errorf("%s: %v", funcName, err)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+	cfg.RPCListeners = listenAddrStrings(cfg.rpcListenAddrs)
 
 	// RPC listening on external interfaces is only allowed when explicitly
 	// enabled and TLS is required.
@@ -870,6 +1319,10 @@ func loadConfig() (*config, []string, error) {
 		activeNetParams.DefaultPort)
 	cfg.ConnectPeers = normalizeAddresses(cfg.ConnectPeers,
 		activeNetParams.DefaultPort)
+	cfg.ValidatorPeers = normalizeAddresses(cfg.ValidatorPeers,
+		activeNetParams.DefaultPort)
+	cfg.ExplorerPeers = normalizeAddresses(cfg.ExplorerPeers,
+		activeNetParams.DefaultPort)
 
 	// --noonion and --onion do not mix.
 	if cfg.NoOnion && cfg.OnionProxy != "" {
@@ -890,6 +1343,54 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Parse the key rolling checkpoints are signed with and the keys whose
+	// signed checkpoints are enforced.  The checkpoints signed by the node
+	// itself are always enforced.
+	if cfg.CheckpointKey != "" {
+		keyBytes, err := hex.DecodeString(cfg.CheckpointKey)
+		if err != nil || len(keyBytes) != btcec.PrivKeyBytesLen {
+			str := "%s: The checkpointkey option must be a " +
+				"hex-encoded %d-byte private key"
+			err := fmt.Errorf(str, funcName, btcec.PrivKeyBytesLen)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.checkpointKey, _ = btcec.PrivKeyFromBytes(btcec.S256(),
+			keyBytes)
+		cfg.checkpointPubKeys = append(cfg.checkpointPubKeys,
+			cfg.checkpointKey.PubKey())
+	}
+	for _, pubKeyStr := range cfg.CheckpointPubKeys {
+		pubKeyBytes, err := hex.DecodeString(pubKeyStr)
+		if err != nil {
+			str := "%s: checkpoint public key '%s' is not " +
+				"hex-encoded: %v"
+			err := fmt.Errorf(str, funcName, pubKeyStr, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		pubKey, err := btcec.ParsePubKey(pubKeyBytes, btcec.S256())
+		if err != nil {
+			str := "%s: checkpoint public key '%s' failed to " +
+				"parse: %v"
+			err := fmt.Errorf(str, funcName, pubKeyStr, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.checkpointPubKeys = append(cfg.checkpointPubKeys, pubKey)
+	}
+	if cfg.CheckpointInterval == 0 {
+		str := "%s: The checkpointinterval option must be greater " +
+			"than 0"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// Tor stream isolation requires either proxy or onion proxy to be set.
 	if cfg.TorIsolation && cfg.Proxy == "" && cfg.OnionProxy == "" {
 		str := "%s: Tor stream isolation requires either proxy or " +
@@ -1011,9 +1512,101 @@ func loadConfig() (*config, []string, error) {
 		btcdLog.Warnf("%v", configFileError)
 	}
 
+	cfg.args = args
 	return &cfg, remainingArgs, nil
 }
 
+// reloadConfig reads the config file and command line options of the running
+// node again for the options which can be changed at runtime, namely the
+// relay fees, the peer whitelist, the RPC credentials and the debug levels.
+// The passed active configuration determines the config file to read.  Only
+// the reloadable options of the returned configuration are validated, so the
+// caller must not use any of the others.
+func reloadConfig(active *config) (*config, error) {
+	cfg := config{
+		ConfigFile:    active.ConfigFile,
+		DebugLevel:    defaultLogLevel,
+		MinRelayTxFee: mempool.DefaultMinRelayTxFee.ToDMG(),
+	}
+
+	// Load the config file unless it was skipped on startup, followed by
+	// the command line options to ensure they take precedence.
+	serviceOpts := serviceOptions{}
+	parser := newConfigParser(&cfg, &serviceOpts, flags.PassDoubleDash)
+	if !(active.RegressionTest || active.SimNet) || active.ConfigFile !=
+		defaultConfigFile {
+
+		err := flags.NewIniParser(parser).ParseFile(active.ConfigFile)
+		if err != nil {
+			if _, ok := err.(*os.PathError); !ok {
+				return nil, fmt.Errorf("error parsing config "+
+					"file: %v", err)
+			}
+		}
+	}
+	if _, err := parser.ParseArgs(active.args); err != nil {
+		return nil, err
+	}
+
+	// Validate the debug levels.
+	if _, err := parseDebugLevels(cfg.DebugLevel); err != nil {
+		return nil, err
+	}
+
+	// Validate the whitelisted IP addresses and networks.
+	var err error
+	cfg.whitelists, err = parseWhitelists(cfg.Whitelists)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the RPC credentials.  The RPC server can not be enabled or
+	// disabled at runtime, so credentials must remain when it is running.
+	if cfg.RPCHash != "" && cfg.RPCPass != "" {
+		return nil, errors.New("--rpcpass may not be used if " +
+			"--rpchash is specified")
+	}
+	if cfg.RPCLimitHash != "" && cfg.RPCLimitPass != "" {
+		return nil, errors.New("--rpclimitpass may not be used if " +
+			"--rpclimithash is specified")
+	}
+	if cfg.RPCUser == cfg.RPCLimitUser && cfg.RPCUser != "" {
+		return nil, errors.New("--rpcuser and --rpclimituser must " +
+			"not specify the same username")
+	}
+	if cfg.RPCPass == cfg.RPCLimitPass && cfg.RPCPass != "" {
+		return nil, errors.New("--rpcpass and --rpclimitpass must " +
+			"not specify the same password")
+	}
+	if _, err := rpcAuths(&cfg); err != nil {
+		return nil, err
+	}
+	if !active.DisableRPC && !active.RPCCookie &&
+		(cfg.RPCHash == "" && (cfg.RPCUser == "" || cfg.RPCPass == "")) &&
+		(cfg.RPCLimitHash == "" && (cfg.RPCLimitUser == "" || cfg.RPCLimitPass == "")) &&
+		len(cfg.RPCAuths) == 0 {
+
+		return nil, errors.New("the RPC server is running, so RPC " +
+			"credentials may not be removed")
+	}
+
+	// Validate the minrelaytxfee and dustrelayfee.
+	cfg.minRelayTxFee, err = provautil.NewAmount(cfg.MinRelayTxFee)
+	if err != nil {
+		return nil, fmt.Errorf("invalid minrelaytxfee: %v", err)
+	}
+	cfg.dustRelayFee, err = provautil.NewAmount(cfg.DustRelayFee)
+	if err != nil || cfg.dustRelayFee < 0 {
+		return nil, fmt.Errorf("invalid dustrelayfee: %v",
+			cfg.DustRelayFee)
+	}
+	if cfg.dustRelayFee == 0 {
+		cfg.dustRelayFee = cfg.minRelayTxFee
+	}
+
+	return &cfg, nil
+}
+
 // createDefaultConfig copies the file sample-dmgd.conf to the given destination path,
 // and populates it with some randomly generated RPC username and password.
 func createDefaultConfigFile(destinationPath string) error {
@@ -1,7 +1,8 @@
-package main
+package node
 
 import (
 	"io/ioutil"
+	"net"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -20,7 +21,8 @@ func TestCreateDefaultConfigFile(t *testing.T) {
 	if !ok {
 		t.Fatalf("Failed finding config file path")
 	}
-	sampleConfigFile := filepath.Join(filepath.Dir(path), "sample-dmgd.conf")
+	sampleConfigFile := filepath.Join(filepath.Dir(path), "..",
+		"sample-dmgd.conf")
 
 	// Setup a temporary directory
 	tmpDir, err := ioutil.TempDir("", "dmgd")
@@ -70,3 +72,35 @@ func TestCreateDefaultConfigFile(t *testing.T) {
 		t.Error("Could not find rpcpass in generated default config file.")
 	}
 }
+
+// TestParseWhitelists ensures whitelist entries are parsed into IP networks,
+// with bare IPs covering only themselves.
+func TestParseWhitelists(t *testing.T) {
+	ipNets, err := parseWhitelists([]string{"192.168.1.0/24", "::1",
+		"10.0.0.1"})
+	if err != nil {
+		t.Fatalf("parseWhitelists: unexpected error: %v", err)
+	}
+	tests := []struct {
+		ip   string
+		want []bool
+	}{
+		{"192.168.1.77", []bool{true, false, false}},
+		{"::1", []bool{false, true, false}},
+		{"10.0.0.1", []bool{false, false, true}},
+		{"10.0.0.2", []bool{false, false, false}},
+	}
+	for _, test := range tests {
+		ip := net.ParseIP(test.ip)
+		for i, ipNet := range ipNets {
+			if got := ipNet.Contains(ip); got != test.want[i] {
+				t.Errorf("%v contains %s: got %v, want %v",
+					ipNet, test.ip, got, test.want[i])
+			}
+		}
+	}
+
+	if _, err := parseWhitelists([]string{"not an ip"}); err == nil {
+		t.Errorf("parseWhitelists: expected error for invalid entry")
+	}
+}
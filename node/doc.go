@@ -0,0 +1,27 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package node implements a DMG full node which can be embedded into other Go
+programs, such as integration test frameworks and custody appliances.  It is
+the node run by the dmgd command.
+
+A node is created with New, which loads its configuration the same way as dmgd
+does from the configuration file and the command line options passed with
+WithArgs.  Start begins connecting to peers and serving RPC clients, and Stop
+shuts the node down again:
+
+	n, err := node.New(node.WithArgs("--simnet", "--datadir="+dir))
+	if err != nil {
+		return err
+	}
+	n.Start()
+	defer n.Stop()
+
+The block chain, memory pool and RPC commands of a running node are accessed
+through the Chain, Mempool and RPC interfaces.  The configuration and logging
+of a node are kept in package level state, so only a single node may be created
+per process.
+*/
+package node
@@ -0,0 +1,93 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/mempool"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// Chain provides read access to the block chain of a node.
+type Chain interface {
+	// BestSnapshot returns information about the current best chain
+	// block.
+	BestSnapshot() *blockchain.BestState
+
+	// BlockByHash returns the block with the passed hash from the main
+	// chain or a side chain.
+	BlockByHash(hash *chainhash.Hash) (*provautil.Block, error)
+
+	// BlockByHeight returns the main chain block at the passed height.
+	BlockByHeight(height uint32) (*provautil.Block, error)
+
+	// BlockHeightByHash returns the height of the main chain block with
+	// the passed hash.
+	BlockHeightByHash(hash *chainhash.Hash) (uint32, error)
+
+	// MainChainHasBlock returns whether the block with the passed hash is
+	// in the main chain.
+	MainChainHasBlock(hash *chainhash.Hash) (bool, error)
+
+	// FetchUtxoView returns a view of the outputs spent and created by the
+	// passed transaction which are unspent in the main chain.
+	FetchUtxoView(tx *provautil.Tx) (*blockchain.UtxoViewpoint, error)
+
+	// ChainTips returns the tips of the main chain and the side chains.
+	ChainTips() ([]*blockchain.ChainTip, error)
+
+	// ThreadTips returns the outpoints of the tips of the admin threads.
+	ThreadTips() map[provautil.ThreadID]*wire.OutPoint
+
+	// LastKeyID returns the last key ID assigned to an account.
+	LastKeyID() btcec.KeyID
+
+	// KeyStateHash returns the hash of the admin key state.
+	KeyStateHash() chainhash.Hash
+}
+
+// Mempool provides access to the memory pool of a node.
+type Mempool interface {
+	// ProcessTransaction validates the passed transaction and accepts it
+	// into the memory pool, along with the orphans it made acceptable.
+	ProcessTransaction(tx *provautil.Tx, allowOrphan, rateLimit bool, tag mempool.Tag) ([]*mempool.TxDesc, error)
+
+	// HaveTransaction returns whether the transaction with the passed hash
+	// is in the memory pool or the orphan pool.
+	HaveTransaction(hash *chainhash.Hash) bool
+
+	// FetchTransaction returns the transaction with the passed hash from
+	// the memory pool.
+	FetchTransaction(txHash *chainhash.Hash) (*provautil.Tx, error)
+
+	// TxDescs returns the descriptors of the transactions in the memory
+	// pool.
+	TxDescs() []*mempool.TxDesc
+
+	// Count returns the number of transactions in the memory pool.
+	Count() int
+
+	// AddAdmissionPolicy registers an additional policy which transactions
+	// must satisfy to be accepted into the memory pool.
+	AddAdmissionPolicy(policy mempool.AdmissionPolicy)
+}
+
+// RPC provides in-process access to the RPC commands of a node.
+type RPC interface {
+	// Call runs the passed command, which must be one of the registered
+	// btcjson command types, and returns its result.
+	Call(cmd interface{}) (interface{}, error)
+}
+
+// Ensure the chain, memory pool and RPC server of a node implement the
+// interfaces.
+var (
+	_ Chain   = (*blockchain.BlockChain)(nil)
+	_ Mempool = nodeMempool{}
+	_ RPC     = (*rpcServer)(nil)
+)
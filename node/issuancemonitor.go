@@ -0,0 +1,73 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"github.com/pyx-partners/dmgd/issuancemonitor"
+	"github.com/pyx-partners/dmgd/provautil"
+)
+
+// newIssuanceMonitor returns an issuance monitor with the configured
+// thresholds and business hours which logs its alerts, sends them to the
+// websocket clients registered for block notifications and POSTs them to the
+// configured webhook.  The issuance of the main chain blocks within the longest
+// window of the thresholds before the best block is recorded, so the windows
+// are complete right away.
+func newIssuanceMonitor(s *server) (*issuancemonitor.Monitor, error) {
+	alerters := []issuancemonitor.Alerter{
+		issuancemonitor.AlerterFunc(func(alert *issuancemonitor.Alert) error {
+			imonLog.Warnf("Issuance alert: %s", alert.Message)
+			return nil
+		}),
+		issuancemonitor.AlerterFunc(func(alert *issuancemonitor.Alert) error {
+			if r := s.rpcServer; r != nil {
+				r.ntfnMgr.NotifyIssuanceAlert(alert)
+			}
+			return nil
+		}),
+	}
+	if cfg.IssuanceWebhook != "" {
+		alerters = append(alerters,
+			issuancemonitor.NewWebhookAlerter(cfg.IssuanceWebhook))
+	}
+	m, err := issuancemonitor.New(&issuancemonitor.Config{
+		Thresholds:    cfg.issuanceThresholds,
+		BusinessHours: cfg.issuanceHours,
+		Alerters:      alerters,
+		ChainParams:   s.chainParams,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect the blocks within the longest window, newest first, and
+	// record them oldest first.
+	chain := s.blockManager.chain
+	var blocks []*provautil.Block
+	height := chain.BestSnapshot().Height
+	for {
+		block, err := chain.BlockByHeight(height)
+		if err != nil {
+			return nil, err
+		}
+		if len(blocks) != 0 {
+			start := blocks[0].MsgBlock().Header.Timestamp.Add(
+				-m.MaxWindow())
+			if !block.MsgBlock().Header.Timestamp.After(start) {
+				break
+			}
+		}
+		blocks = append(blocks, block)
+		if height == 0 {
+			break
+		}
+		height--
+	}
+	for i := len(blocks) - 1; i >= 0; i-- {
+		m.Record(blocks[i])
+	}
+	imonLog.Infof("Issuance monitor loaded %d blocks", len(blocks))
+	return m, nil
+}
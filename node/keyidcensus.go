@@ -0,0 +1,159 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+)
+
+// keyIDCensus keeps the state of the background scans of the unspent
+// transaction output set for the usage of each keyID, which the getkeyidcensus
+// RPC reports.  Scans are run one at a time by the server's census handler,
+// either when requested over RPC or at the configured interval.
+type keyIDCensus struct {
+	// requests signals the census handler to start a scan.  It is
+	// buffered so a request never blocks, and since a new scan is only
+	// requested once the last one finished, it never holds more than one.
+	requests chan struct{}
+
+	mtx sync.Mutex
+
+	// running is set while a scan is requested or in progress, which
+	// started at runningSince.
+	running      bool
+	runningSince time.Time
+
+	// last is the result of the last successful scan, which finished at
+	// lastTime and took lastDuration.  lastErr is the error of the last
+	// scan, if it failed.
+	last         *blockchain.KeyIDCensus
+	lastTime     time.Time
+	lastDuration time.Duration
+	lastErr      error
+}
+
+// newKeyIDCensus returns a new census without results.
+func newKeyIDCensus() *keyIDCensus {
+	return &keyIDCensus{requests: make(chan struct{}, 1)}
+}
+
+// begin marks a scan as started at the passed time and returns true, unless a
+// scan is already running, in which case it returns false.
+//
+// This function is safe for concurrent access.
+func (c *keyIDCensus) begin(now time.Time) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.running {
+		return false
+	}
+	c.running = true
+	c.runningSince = now
+	return true
+}
+
+// request asks the census handler to start a scan and returns true, unless a
+// scan is already running, in which case it returns false.
+//
+// This function is safe for concurrent access.
+func (c *keyIDCensus) request(now time.Time) bool {
+	if !c.begin(now) {
+		return false
+	}
+	c.requests <- struct{}{}
+	return true
+}
+
+// finish records the result of the running scan, which finished at the passed
+// time.  The result of the previous scan is kept when the scan failed.
+//
+// This function is safe for concurrent access.
+func (c *keyIDCensus) finish(census *blockchain.KeyIDCensus, err error, now time.Time) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.running = false
+	c.lastErr = err
+	if err != nil {
+		return
+	}
+	c.last = census
+	c.lastTime = now
+	c.lastDuration = now.Sub(c.runningSince)
+}
+
+// keyIDCensusStatus is a snapshot of the state of a keyIDCensus.
+type keyIDCensusStatus struct {
+	running      bool
+	runningSince time.Time
+	last         *blockchain.KeyIDCensus
+	lastTime     time.Time
+	lastDuration time.Duration
+	lastErr      error
+}
+
+// status returns a snapshot of the state of the census.  The returned census
+// result must not be modified.
+//
+// This function is safe for concurrent access.
+func (c *keyIDCensus) status() keyIDCensusStatus {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return keyIDCensusStatus{
+		running:      c.running,
+		runningSince: c.runningSince,
+		last:         c.last,
+		lastTime:     c.lastTime,
+		lastDuration: c.lastDuration,
+		lastErr:      c.lastErr,
+	}
+}
+
+// keyIDCensusHandler runs the keyID census scans requested over RPC, and every
+// interval when it is not zero, until the server shuts down.  It must be run
+// as a goroutine.
+func (s *server) keyIDCensusHandler(interval time.Duration) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	scan := func() {
+		census, err := s.blockManager.chain.FetchKeyIDCensus()
+		if err != nil {
+			srvrLog.Errorf("Key ID census failed: %v", err)
+		} else {
+			srvrLog.Infof("Key ID census of block %v (height %d) "+
+				"found %d key ID and asset pairs", census.Hash,
+				census.Height, len(census.Usage))
+		}
+		s.keyIDCensus.finish(census, err, time.Now())
+	}
+
+out:
+	for {
+		select {
+		case <-s.keyIDCensus.requests:
+			scan()
+
+		case <-tick:
+			if s.keyIDCensus.begin(time.Now()) {
+				scan()
+			}
+
+		case <-s.quit:
+			break out
+		}
+	}
+
+	s.wg.Done()
+}
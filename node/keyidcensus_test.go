@@ -0,0 +1,57 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+)
+
+// TestKeyIDCensus ensures only one census runs at a time and that a failed
+// census keeps the result of the last successful one.
+func TestKeyIDCensus(t *testing.T) {
+	c := newKeyIDCensus()
+	now := time.Unix(1000000, 0)
+
+	if !c.request(now) {
+		t.Fatalf("census not requested")
+	}
+	if c.request(now) || c.begin(now) {
+		t.Fatalf("second census started while one is running")
+	}
+	select {
+	case <-c.requests:
+	default:
+		t.Fatalf("census request not sent to the handler")
+	}
+	if status := c.status(); !status.running || status.last != nil {
+		t.Fatalf("got status %+v, want a running census without "+
+			"result", status)
+	}
+
+	census := &blockchain.KeyIDCensus{Height: 10}
+	c.finish(census, nil, now.Add(time.Minute))
+	status := c.status()
+	if status.running || status.last != census ||
+		status.lastDuration != time.Minute || status.lastErr != nil {
+
+		t.Fatalf("got status %+v after a successful census", status)
+	}
+
+	if !c.begin(now.Add(time.Hour)) {
+		t.Fatalf("census not started after the last one finished")
+	}
+	scanErr := errors.New("scan failed")
+	c.finish(nil, scanErr, now.Add(2*time.Hour))
+	status = c.status()
+	if status.running || status.last != census ||
+		status.lastErr != scanErr {
+
+		t.Fatalf("got status %+v after a failed census", status)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// listenAddr is an address to listen on along with the settings of the
+// listener, parsed from a --listen or --rpclisten option of the form
+// <addr>[,<option>...].
+type listenAddr struct {
+	// addr is the address to listen on, normalized with the default port.
+	addr string
+
+	// noAdvertise is set by the noadvertise option of a peer listener to
+	// not advertise the address of the listener to peers, such as for a
+	// listener on a private interface.
+	noAdvertise bool
+
+	// advertise is set by the advertise=<host>[:<port>] option of a peer
+	// listener to the address advertised to peers instead of the address
+	// of the listener, such as its public address behind a NAT.  It is
+	// normalized with the port of the listener.
+	advertise string
+
+	// limited is set by the limited option of an RPC listener to only
+	// allow the methods of the limited user on connections to it, whatever
+	// credentials they authenticate with.
+	limited bool
+}
+
+// parseListenAddr parses a listen address of the form <addr>[,<option>...] and
+// normalizes the address with the passed default port.  The options of peer
+// listeners are noadvertise and advertise=<host>[:<port>], while RPC listeners
+// accept the limited option.
+func parseListenAddr(spec, defaultPort string, rpc bool) (listenAddr, error) {
+	fields := strings.Split(spec, ",")
+	la := listenAddr{addr: normalizeAddress(fields[0], defaultPort)}
+	_, port, err := net.SplitHostPort(la.addr)
+	if err != nil {
+		return listenAddr{}, fmt.Errorf("invalid listen address %q: %v",
+			fields[0], err)
+	}
+
+	for _, option := range fields[1:] {
+		name := option
+		var value string
+		if i := strings.Index(option, "="); i >= 0 {
+			name, value = option[:i], option[i+1:]
+		}
+		switch {
+		case !rpc && name == "noadvertise" && value == "":
+			la.noAdvertise = true
+
+		case !rpc && name == "advertise" && value != "":
+			la.advertise = normalizeAddress(value, port)
+
+		case rpc && name == "limited" && value == "":
+			la.limited = true
+
+		default:
+			return listenAddr{}, fmt.Errorf("invalid option %q for "+
+				"listen address %q", option, fields[0])
+		}
+	}
+	if la.noAdvertise && la.advertise != "" {
+		return listenAddr{}, fmt.Errorf("the noadvertise and advertise "+
+			"options of listen address %q are mutually exclusive",
+			fields[0])
+	}
+	return la, nil
+}
+
+// parseListenAddrs parses the passed listen addresses with parseListenAddr.
+// Only the first of listen addresses which are the same after normalization is
+// kept.
+func parseListenAddrs(specs []string, defaultPort string, rpc bool) ([]listenAddr, error) {
+	listenAddrs := make([]listenAddr, 0, len(specs))
+	seen := make(map[string]struct{}, len(specs))
+	for _, spec := range specs {
+		la, err := parseListenAddr(spec, defaultPort, rpc)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := seen[la.addr]; ok {
+			continue
+		}
+		seen[la.addr] = struct{}{}
+		listenAddrs = append(listenAddrs, la)
+	}
+	return listenAddrs, nil
+}
+
+// listenAddrStrings returns the addresses of the passed listen addresses.
+func listenAddrStrings(listenAddrs []listenAddr) []string {
+	addrs := make([]string, 0, len(listenAddrs))
+	for _, la := range listenAddrs {
+		addrs = append(addrs, la.addr)
+	}
+	return addrs
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseListenAddrs ensures listen addresses are normalized with their
+// options parsed, duplicates are removed and invalid options are rejected.
+func TestParseListenAddrs(t *testing.T) {
+	specs := []string{
+		"",
+		"10.0.0.1,noadvertise",
+		"[::1]:7000,advertise=203.0.113.5",
+		"192.168.1.1:7001,advertise=[2001:db8::1]:17001",
+		":6464",
+	}
+	got, err := parseListenAddrs(specs, "6464", false)
+	if err != nil {
+		t.Fatalf("parseListenAddrs: unexpected error: %v", err)
+	}
+	want := []listenAddr{
+		{addr: ":6464"},
+		{addr: "10.0.0.1:6464", noAdvertise: true},
+		{addr: "[::1]:7000", advertise: "203.0.113.5:7000"},
+		{addr: "192.168.1.1:7001", advertise: "[2001:db8::1]:17001"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseListenAddrs: got %+v, want %+v", got, want)
+	}
+	if addrs := listenAddrStrings(got); !reflect.DeepEqual(addrs,
+		[]string{":6464", "10.0.0.1:6464", "[::1]:7000",
+			"192.168.1.1:7001"}) {
+
+		t.Fatalf("listenAddrStrings: got %v", addrs)
+	}
+
+	got, err = parseListenAddrs([]string{"127.0.0.1,limited", "::1"},
+		"8334", true)
+	if err != nil {
+		t.Fatalf("parseListenAddrs: unexpected error: %v", err)
+	}
+	want = []listenAddr{
+		{addr: "127.0.0.1:8334", limited: true},
+		{addr: "[::1]:8334"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseListenAddrs: got %+v, want %+v", got, want)
+	}
+
+	invalid := []struct {
+		spec string
+		rpc  bool
+	}{
+		{"127.0.0.1,limited", false},
+		{"127.0.0.1,noadvertise", true},
+		{"127.0.0.1,advertise", false},
+		{"127.0.0.1,noadvertise=1", false},
+		{"127.0.0.1,noadvertise,advertise=10.0.0.1", false},
+		{"127.0.0.1,bogus", false},
+		{"[::1", false},
+	}
+	for _, test := range invalid {
+		_, err := parseListenAddr(test.spec, "6464", test.rpc)
+		if err == nil {
+			t.Errorf("parseListenAddr(%q, rpc %v): expected error",
+				test.spec, test.rpc)
+		}
+	}
+}
@@ -4,7 +4,7 @@
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
-package main
+package node
 
 import (
 	"fmt"
@@ -15,11 +15,15 @@ import (
 	"github.com/pyx-partners/dmgd/blockchain/indexers"
 	"github.com/pyx-partners/dmgd/connmgr"
 	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/eventbridge"
+	"github.com/pyx-partners/dmgd/issuancemonitor"
 	"github.com/pyx-partners/dmgd/mempool"
 	"github.com/pyx-partners/dmgd/mining"
 	"github.com/pyx-partners/dmgd/mining/cpuminer"
 	"github.com/pyx-partners/dmgd/peer"
+	"github.com/pyx-partners/dmgd/sqlexport"
 	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/webhook"
 	"github.com/btcsuite/btclog"
 	"github.com/btcsuite/seelog"
 )
@@ -38,11 +42,15 @@ var (
 	btcdLog    = btclog.Disabled
 	chanLog    = btclog.Disabled
 	discLog    = btclog.Disabled
+	evntLog    = btclog.Disabled
+	hookLog    = btclog.Disabled
+	imonLog    = btclog.Disabled
 	indxLog    = btclog.Disabled
 	minrLog    = btclog.Disabled
 	peerLog    = btclog.Disabled
 	rpcsLog    = btclog.Disabled
 	scrpLog    = btclog.Disabled
+	sqlxLog    = btclog.Disabled
 	srvrLog    = btclog.Disabled
 	txmpLog    = btclog.Disabled
 )
@@ -56,12 +64,16 @@ var subsystemLoggers = map[string]btclog.Logger{
 	"BMGR": bmgrLog,
 	"CHAN": chanLog,
 	"DISC": discLog,
+	"EVNT": evntLog,
+	"HOOK": hookLog,
+	"IMON": imonLog,
 	"INDX": indxLog,
 	"MINR": minrLog,
 	"PEER": peerLog,
 	"PRVA": btcdLog,
 	"RPCS": rpcsLog,
 	"SCRP": scrpLog,
+	"SQLX": sqlxLog,
 	"SRVR": srvrLog,
 	"TXMP": txmpLog,
 }
@@ -100,6 +112,18 @@ func useLogger(subsystemID string, logger btclog.Logger) {
 	case "DISC":
 		discLog = logger
 
+	case "EVNT":
+		evntLog = logger
+		eventbridge.UseLogger(logger)
+
+	case "HOOK":
+		hookLog = logger
+		webhook.UseLogger(logger)
+
+	case "IMON":
+		imonLog = logger
+		issuancemonitor.UseLogger(logger)
+
 	case "INDX":
 		indxLog = logger
 		indexers.UseLogger(logger)
@@ -123,6 +147,10 @@ func useLogger(subsystemID string, logger btclog.Logger) {
 		scrpLog = logger
 		txscript.UseLogger(logger)
 
+	case "SQLX":
+		sqlxLog = logger
+		sqlexport.UseLogger(logger)
+
 	case "SRVR":
 		srvrLog = logger
 
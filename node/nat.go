@@ -0,0 +1,203 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pyx-partners/dmgd/addrmgr"
+	"github.com/pyx-partners/dmgd/btcjson"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+const (
+	// natMappingLifetime is the lifetime in seconds requested for port
+	// mappings, which are renewed every natRenewInterval.
+	natMappingLifetime = 20 * 60
+
+	// natRenewInterval is the interval at which the port mapping is
+	// renewed and the external address is checked for changes.
+	natRenewInterval = 15 * time.Minute
+
+	// natRetryInterval is the interval at which a port mapping which
+	// failed is retried.
+	natRetryInterval = time.Minute
+)
+
+// natTraversal maps the listening port of the peer server through the NAT of
+// the network and tracks the external address it is reachable at, which is
+// reported by the getnetworkinfo RPC.
+type natTraversal struct {
+	// gateway is the NAT of the network, which is nil when no gateway
+	// supporting the enabled methods was found.
+	gateway NAT
+
+	// method is the method the gateway was discovered with, either upnp
+	// or natpmp, or none when no gateway was found.
+	method string
+
+	// internalPort is the listening port mapped through the NAT.
+	internalPort uint16
+
+	mtx          sync.Mutex
+	externalIP   net.IP
+	externalPort uint16
+	lastMapped   time.Time
+	lastErr      error
+}
+
+// discoverNAT searches the local network for a gateway supporting the NAT
+// traversal methods enabled with --upnp and --natpmp, trying UPnP first, and
+// returns the NAT traversal mapping the passed listening port.
+func discoverNAT(port uint16) *natTraversal {
+	t := &natTraversal{method: "none", internalPort: port}
+	if cfg.Upnp {
+		nat, err := Discover()
+		if err == nil {
+			t.gateway, t.method = nat, "upnp"
+			return t
+		}
+		srvrLog.Warnf("Can't discover UPnP gateway: %v", err)
+	}
+	if cfg.NATPMP {
+		nat, err := DiscoverNATPMP()
+		if err == nil {
+			t.gateway, t.method = nat, "natpmp"
+			return t
+		}
+		srvrLog.Warnf("Can't discover NAT-PMP gateway: %v", err)
+	}
+	t.lastErr = errors.New("no NAT gateway found")
+	return t
+}
+
+// mapPort maps the listening port through the gateway and queries its
+// external address.  It returns the external address the listener is
+// reachable at.
+func (t *natTraversal) mapPort() (*net.TCPAddr, error) {
+	externalPort, err := t.gateway.AddPortMapping("tcp",
+		int(t.internalPort), int(t.internalPort), "Dmgd listen port",
+		natMappingLifetime)
+	if err != nil {
+		return nil, err
+	}
+	externalIP, err := t.gateway.GetExternalAddress()
+	if err != nil {
+		return nil, err
+	}
+	return &net.TCPAddr{IP: externalIP, Port: externalPort}, nil
+}
+
+// setResult records the result of the last attempt to map the listening port
+// and returns the external address previously recorded.
+//
+// This function is safe for concurrent access.
+func (t *natTraversal) setResult(addr *net.TCPAddr, err error) *net.TCPAddr {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	var prev *net.TCPAddr
+	if t.externalIP != nil {
+		prev = &net.TCPAddr{IP: t.externalIP, Port: int(t.externalPort)}
+	}
+	t.lastErr = err
+	if err == nil {
+		t.externalIP = addr.IP
+		t.externalPort = uint16(addr.Port)
+		t.lastMapped = time.Now()
+	}
+	return prev
+}
+
+// status returns the state of the NAT traversal for the getnetworkinfo RPC.
+//
+// This function is safe for concurrent access.
+func (t *natTraversal) status() *btcjson.NATStatusResult {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	result := &btcjson.NATStatusResult{
+		Method:       t.method,
+		InternalPort: t.internalPort,
+		Mapped:       t.lastErr == nil && t.externalIP != nil,
+	}
+	if t.externalIP != nil {
+		result.ExternalIP = t.externalIP.String()
+		result.ExternalPort = t.externalPort
+		result.LastMapped = t.lastMapped.Unix()
+	}
+	if t.lastErr != nil {
+		result.Error = t.lastErr.Error()
+	}
+	return result
+}
+
+// natUpdateThread maps the listening port through the NAT and renews the
+// mapping until the server shuts down, advertising the external address of
+// the mapping to peers.  The advertised address is replaced when the external
+// address changes, such as when the ISP of the network assigns a new one.
+//
+// This MUST be run as a goroutine.
+func (s *server) natUpdateThread() {
+	// Go off immediately to prevent code duplication, thereafter we renew
+	// the mapping periodically.
+	timer := time.NewTimer(0)
+out:
+	for {
+		select {
+		case <-timer.C:
+			addr, err := s.nat.mapPort()
+			prev := s.nat.setResult(addr, err)
+			if err != nil {
+				srvrLog.Warnf("Can't map port %d via %s: %v",
+					s.nat.internalPort, s.nat.method, err)
+				timer.Reset(natRetryInterval)
+				continue
+			}
+
+			if prev == nil || !prev.IP.Equal(addr.IP) ||
+				prev.Port != addr.Port {
+
+				if prev != nil {
+					na := wire.NewNetAddressIPPort(prev.IP,
+						uint16(prev.Port), s.services)
+					s.addrManager.RemoveLocalAddress(na)
+				}
+				na := wire.NewNetAddressIPPort(addr.IP,
+					uint16(addr.Port), s.services)
+				err := s.addrManager.AddLocalAddress(na,
+					addrmgr.UpnpPrio)
+				if err != nil {
+					srvrLog.Warnf("Not advertising external "+
+						"address %s: %v", addr, err)
+				} else {
+					srvrLog.Infof("Successfully bound via %s "+
+						"to %s", s.nat.method,
+						addrmgr.NetAddressKey(na))
+				}
+			}
+			timer.Reset(natRenewInterval)
+
+		case <-s.quit:
+			break out
+		}
+	}
+
+	timer.Stop()
+
+	port := int(s.nat.internalPort)
+	if err := s.nat.gateway.DeletePortMapping("tcp", port, port); err != nil {
+		srvrLog.Warnf("Unable to remove %s port mapping: %v",
+			s.nat.method, err)
+	} else {
+		srvrLog.Debugf("Successfully disestablished %s port mapping",
+			s.nat.method)
+	}
+
+	s.wg.Done()
+}
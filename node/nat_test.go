@@ -0,0 +1,84 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+// testNAT is a NAT which maps ports to the internal port plus 1000 and reports
+// its external address, unless it fails with its error.
+type testNAT struct {
+	externalIP net.IP
+	err        error
+}
+
+func (n *testNAT) GetExternalAddress() (net.IP, error) {
+	return n.externalIP, n.err
+}
+
+func (n *testNAT) AddPortMapping(protocol string, externalPort, internalPort int, description string, timeout int) (int, error) {
+	return internalPort + 1000, n.err
+}
+
+func (n *testNAT) DeletePortMapping(protocol string, externalPort, internalPort int) error {
+	return n.err
+}
+
+// TestNATTraversalStatus ensures the state of the NAT traversal reported by
+// getnetworkinfo follows the results of the mappings.
+func TestNATTraversalStatus(t *testing.T) {
+	nat := &testNAT{externalIP: net.IPv4(203, 0, 113, 5)}
+	traversal := &natTraversal{gateway: nat, method: "natpmp",
+		internalPort: 6464}
+
+	status := traversal.status()
+	if status.Method != "natpmp" || status.InternalPort != 6464 ||
+		status.Mapped || status.ExternalIP != "" {
+
+		t.Fatalf("status: unexpected status before mapping %+v", status)
+	}
+
+	addr, err := traversal.mapPort()
+	if err != nil {
+		t.Fatalf("mapPort: %v", err)
+	}
+	if prev := traversal.setResult(addr, err); prev != nil {
+		t.Fatalf("setResult: got previous address %v, want none", prev)
+	}
+	status = traversal.status()
+	if !status.Mapped || status.ExternalIP != "203.0.113.5" ||
+		status.ExternalPort != 7464 || status.LastMapped == 0 ||
+		status.Error != "" {
+
+		t.Fatalf("status: unexpected status after mapping %+v", status)
+	}
+
+	// A failed renewal keeps the external address but reports the error.
+	nat.err = errors.New("gateway unreachable")
+	addr, err = traversal.mapPort()
+	prev := traversal.setResult(addr, err)
+	if prev == nil || prev.String() != "203.0.113.5:7464" {
+		t.Fatalf("setResult: got previous address %v, want "+
+			"203.0.113.5:7464", prev)
+	}
+	status = traversal.status()
+	if status.Mapped || status.ExternalIP != "203.0.113.5" ||
+		status.Error != "gateway unreachable" {
+
+		t.Fatalf("status: unexpected status after failure %+v", status)
+	}
+
+	// A gateway which was not found is reported with its error.
+	status = (&natTraversal{method: "none", internalPort: 6464,
+		lastErr: errors.New("no NAT gateway found")}).status()
+	if status.Method != "none" || status.Mapped ||
+		status.Error != "no NAT gateway found" {
+
+		t.Fatalf("status: unexpected status without gateway %+v", status)
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+// Just enough NAT-PMP (RFC 6886) to be able to forward ports.
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// natPMPPort is the port NAT-PMP gateways listen on.
+	natPMPPort = 5351
+
+	// natPMPVersion is the version of the NAT-PMP protocol.
+	natPMPVersion = 0
+
+	// natPMPInitialTimeout is the time waited for the first response of
+	// the gateway, which is doubled on every retransmission as specified
+	// by RFC 6886.
+	natPMPInitialTimeout = 250 * time.Millisecond
+
+	// natPMPMaxAttempts is the number of times a request is sent before
+	// giving up.  RFC 6886 specifies 9 attempts, taking a minute, but the
+	// gateway is queried at startup, so fewer attempts are made.
+	natPMPMaxAttempts = 4
+)
+
+// NAT-PMP operation codes.
+const (
+	natPMPOpExternalAddress = 0
+	natPMPOpMapUDP          = 1
+	natPMPOpMapTCP          = 2
+)
+
+// natPMPResultCodes describes the result codes of NAT-PMP responses.
+var natPMPResultCodes = map[uint16]string{
+	1: "unsupported version",
+	2: "not authorized or refused",
+	3: "network failure",
+	4: "out of resources",
+	5: "unsupported opcode",
+}
+
+// natPMP is a NAT implementation using NAT-PMP.
+type natPMP struct {
+	gateway *net.UDPAddr
+}
+
+// Ensure natPMP implements the NAT interface.
+var _ NAT = (*natPMP)(nil)
+
+// DiscoverNATPMP queries the default gateway of the network for NAT-PMP
+// support, returning a NAT for the network if so and an error if not.
+func DiscoverNATPMP() (NAT, error) {
+	gateway, err := defaultGateway()
+	if err != nil {
+		return nil, err
+	}
+	nat := &natPMP{gateway: &net.UDPAddr{IP: gateway, Port: natPMPPort}}
+	if _, err := nat.GetExternalAddress(); err != nil {
+		return nil, err
+	}
+	return nat, nil
+}
+
+// request sends the passed request to the gateway and returns its response,
+// retransmitting the request until a response with the passed operation code
+// and length is received or the attempts are exhausted.
+func (n *natPMP) request(msg []byte, respLen int) ([]byte, error) {
+	conn, err := net.DialUDP("udp4", nil, n.gateway)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	op := msg[1]
+	resp := make([]byte, 16)
+	timeout := natPMPInitialTimeout
+	for attempt := 0; attempt < natPMPMaxAttempts; attempt++ {
+		if _, err := conn.Write(msg); err != nil {
+			return nil, err
+		}
+		deadline := time.Now().Add(timeout)
+		timeout *= 2
+		for {
+			conn.SetReadDeadline(deadline)
+			size, err := conn.Read(resp)
+			if err != nil {
+				if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+					break
+				}
+				return nil, err
+			}
+
+			// Ignore malformed packets and responses to other
+			// requests, such as announcements of address changes.
+			if size < 4 || resp[0] != natPMPVersion || resp[1] != 128+op {
+				continue
+			}
+			code := binary.BigEndian.Uint16(resp[2:4])
+			if code != 0 {
+				desc, ok := natPMPResultCodes[code]
+				if !ok {
+					desc = fmt.Sprintf("result code %d", code)
+				}
+				return nil, fmt.Errorf("NAT-PMP gateway %s: %s",
+					n.gateway.IP, desc)
+			}
+			if size < respLen {
+				continue
+			}
+			return resp[:respLen], nil
+		}
+	}
+	return nil, fmt.Errorf("no response from NAT-PMP gateway %s",
+		n.gateway.IP)
+}
+
+// GetExternalAddress returns the external address of the gateway.
+func (n *natPMP) GetExternalAddress() (net.IP, error) {
+	resp, err := n.request([]byte{natPMPVersion,
+		natPMPOpExternalAddress}, 12)
+	if err != nil {
+		return nil, err
+	}
+	return net.IPv4(resp[8], resp[9], resp[10], resp[11]), nil
+}
+
+// mapPort requests the gateway to map the passed external port to the passed
+// internal port for the passed lifetime in seconds and returns the external
+// port mapped.  A lifetime of zero removes the mapping.
+func (n *natPMP) mapPort(protocol string, externalPort, internalPort, lifetime int) (int, error) {
+	var op byte
+	switch protocol {
+	case "udp":
+		op = natPMPOpMapUDP
+	case "tcp":
+		op = natPMPOpMapTCP
+	default:
+		return 0, fmt.Errorf("unsupported protocol %q", protocol)
+	}
+
+	msg := make([]byte, 12)
+	msg[0] = natPMPVersion
+	msg[1] = op
+	binary.BigEndian.PutUint16(msg[4:6], uint16(internalPort))
+	binary.BigEndian.PutUint16(msg[6:8], uint16(externalPort))
+	binary.BigEndian.PutUint32(msg[8:12], uint32(lifetime))
+	resp, err := n.request(msg, 16)
+	if err != nil {
+		return 0, err
+	}
+	return int(binary.BigEndian.Uint16(resp[10:12])), nil
+}
+
+// AddPortMapping maps the passed external port to the passed internal port
+// for timeout seconds and returns the external port mapped, which the gateway
+// may choose differently.  NAT-PMP has no descriptions for mappings.
+func (n *natPMP) AddPortMapping(protocol string, externalPort, internalPort int, description string, timeout int) (int, error) {
+	return n.mapPort(protocol, externalPort, internalPort, timeout)
+}
+
+// DeletePortMapping removes the mapping of the passed internal port.
+func (n *natPMP) DeletePortMapping(protocol string, externalPort, internalPort int) error {
+	_, err := n.mapPort(protocol, 0, internalPort, 0)
+	return err
+}
+
+// defaultGateway returns the IPv4 address of the default gateway, which is
+// read from the routing table on Linux.  Elsewhere, the gateway is assumed to
+// be the first address of the network of the first private IPv4 interface,
+// which it is on most home and office networks.
+func defaultGateway() (net.IP, error) {
+	if gateway, err := routeTableGateway("/proc/net/route"); err == nil {
+		return gateway, nil
+	}
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip := ipNet.IP.To4()
+		if ip == nil || !isPrivateIPv4(ip) {
+			continue
+		}
+		gateway := ip.Mask(ipNet.Mask)
+		gateway[3]++
+		return gateway, nil
+	}
+	return nil, errors.New("unable to determine the default gateway")
+}
+
+// routeTableGateway returns the gateway of the default route in the passed
+// routing table in the format of /proc/net/route.
+func routeTableGateway(path string) (net.IP, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// The fields are the interface, destination and gateway,
+		// followed by others, with the addresses in little endian hex.
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+		gateway, err := hex.DecodeString(fields[2])
+		if err != nil || len(gateway) != 4 {
+			continue
+		}
+		return net.IPv4(gateway[3], gateway[2], gateway[1],
+			gateway[0]), nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("no default route")
+}
+
+// isPrivateIPv4 returns whether the passed IPv4 address is in one of the
+// private networks of RFC 1918.
+func isPrivateIPv4(ip net.IP) bool {
+	return ip[0] == 10 ||
+		(ip[0] == 172 && ip[1]&0xf0 == 16) ||
+		(ip[0] == 192 && ip[1] == 168)
+}
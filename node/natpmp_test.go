@@ -0,0 +1,165 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestNATPMPGateway starts a NAT-PMP gateway on localhost which reports the
+// passed external address and maps ports to the internal port plus 1000,
+// answering requests for other ports with the passed result code.  It returns
+// the address of the gateway and a channel of the mapping requests received.
+func newTestNATPMPGateway(t *testing.T, externalIP net.IP, code uint16) (*net.UDPAddr, <-chan []byte) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	requests := make(chan []byte, 10)
+	go func() {
+		defer conn.Close()
+		buf := make([]byte, 16)
+		for {
+			n, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			req := append([]byte(nil), buf[:n]...)
+
+			var resp []byte
+			switch req[1] {
+			case natPMPOpExternalAddress:
+				resp = make([]byte, 12)
+				copy(resp[8:12], externalIP.To4())
+
+			case natPMPOpMapTCP:
+				// Announce the external address first, which
+				// must be ignored by the client.
+				announcement := make([]byte, 12)
+				announcement[1] = 128 + natPMPOpExternalAddress
+				copy(announcement[8:12], externalIP.To4())
+				conn.WriteToUDP(announcement, addr)
+
+				requests <- req
+				resp = make([]byte, 16)
+				internalPort := binary.BigEndian.Uint16(req[4:6])
+				if internalPort != 6464 {
+					binary.BigEndian.PutUint16(resp[2:4], code)
+				}
+				copy(resp[8:10], req[4:6])
+				binary.BigEndian.PutUint16(resp[10:12],
+					internalPort+1000)
+				copy(resp[12:16], req[8:12])
+
+			default:
+				continue
+			}
+			resp[1] = 128 + req[1]
+			conn.WriteToUDP(resp, addr)
+			if req[1] == natPMPOpMapTCP &&
+				binary.BigEndian.Uint32(req[8:12]) == 0 {
+
+				return
+			}
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr), requests
+}
+
+// TestNATPMP ensures the NAT-PMP client queries the external address, maps and
+// unmaps ports and reports the errors of the gateway.
+func TestNATPMP(t *testing.T) {
+	externalIP := net.IPv4(203, 0, 113, 5)
+	gateway, requests := newTestNATPMPGateway(t, externalIP, 2)
+	nat := &natPMP{gateway: gateway}
+
+	ip, err := nat.GetExternalAddress()
+	if err != nil {
+		t.Fatalf("GetExternalAddress: %v", err)
+	}
+	if !ip.Equal(externalIP) {
+		t.Fatalf("GetExternalAddress: got %v, want %v", ip, externalIP)
+	}
+
+	port, err := nat.AddPortMapping("tcp", 6464, 6464, "test", 1200)
+	if err != nil {
+		t.Fatalf("AddPortMapping: %v", err)
+	}
+	if port != 7464 {
+		t.Fatalf("AddPortMapping: got external port %d, want 7464", port)
+	}
+	req := <-requests
+	if binary.BigEndian.Uint16(req[6:8]) != 6464 ||
+		binary.BigEndian.Uint32(req[8:12]) != 1200 {
+
+		t.Fatalf("AddPortMapping: unexpected request %x", req)
+	}
+
+	_, err = nat.AddPortMapping("tcp", 6465, 6465, "test", 1200)
+	if err == nil || err.Error() != "NAT-PMP gateway 127.0.0.1: not "+
+		"authorized or refused" {
+
+		t.Fatalf("AddPortMapping: unexpected error %v", err)
+	}
+	<-requests
+
+	if _, err := nat.AddPortMapping("sctp", 6464, 6464, "test", 1200); err == nil {
+		t.Fatal("AddPortMapping: no error for unsupported protocol")
+	}
+
+	if err := nat.DeletePortMapping("tcp", 6464, 6464); err != nil {
+		t.Fatalf("DeletePortMapping: %v", err)
+	}
+	req = <-requests
+	if binary.BigEndian.Uint16(req[6:8]) != 0 ||
+		binary.BigEndian.Uint32(req[8:12]) != 0 {
+
+		t.Fatalf("DeletePortMapping: unexpected request %x", req)
+	}
+
+	// The gateway stopped after the deletion, so requests time out.
+	if _, err := nat.GetExternalAddress(); err == nil {
+		t.Fatal("GetExternalAddress: no error without a gateway")
+	}
+}
+
+// TestRouteTableGateway ensures the gateway of the default route is read from
+// routing tables in the format of /proc/net/route.
+func TestRouteTableGateway(t *testing.T) {
+	dir, err := ioutil.TempDir("", "routetable")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "route")
+	table := "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\n" +
+		"eth0\t0001A8C0\t00000000\t0001\t0\t0\t0\t00FFFFFF\n" +
+		"eth0\t00000000\t0101A8C0\t0003\t0\t0\t0\t00000000\n"
+	if err := ioutil.WriteFile(path, []byte(table), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	gateway, err := routeTableGateway(path)
+	if err != nil {
+		t.Fatalf("routeTableGateway: %v", err)
+	}
+	if want := net.IPv4(192, 168, 1, 1); !gateway.Equal(want) {
+		t.Fatalf("routeTableGateway: got %v, want %v", gateway, want)
+	}
+
+	table = "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\n" +
+		"eth0\t0001A8C0\t00000000\t0001\t0\t0\t0\t00FFFFFF\n"
+	if err := ioutil.WriteFile(path, []byte(table), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := routeTableGateway(path); err == nil {
+		t.Fatal("routeTableGateway: no error without a default route")
+	}
+}
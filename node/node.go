@@ -0,0 +1,210 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/pyx-partners/dmgd/blockchain/indexers"
+	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/mempool"
+	"github.com/pyx-partners/dmgd/provautil"
+)
+
+// options houses the options a node is created with.
+type options struct {
+	args      []string
+	interrupt <-chan struct{}
+}
+
+// Option is a functional option which configures a node created with New.
+type Option func(*options)
+
+// WithArgs configures the node with the passed command line options of dmgd,
+// for example "--simnet" or "--datadir=/path".  They are parsed as they are
+// by dmgd, so they take precedence over the options of the configuration file.
+func WithArgs(args ...string) Option {
+	return func(o *options) {
+		o.args = append(o.args, args...)
+	}
+}
+
+// WithInterrupt passes a channel which is closed to interrupt the node.  It
+// aborts catching up the indexes and verifying the database while the node is
+// created, and closes the channel returned by Done.  Without it, the node is
+// only interrupted by a shutdown request, such as the stop RPC.
+func WithInterrupt(interrupt <-chan struct{}) Option {
+	return func(o *options) {
+		o.interrupt = interrupt
+	}
+}
+
+// nodeShutdown is closed once a shutdown of the node created with New is
+// requested.  Its listener is only started once per process, so a node which
+// failed to be created does not leave a listener behind which would consume
+// the shutdown request of the next node.
+var nodeShutdown <-chan struct{}
+
+// Node is a DMG full node which can be embedded into other Go programs, such
+// as integration test frameworks and custody appliances.  The configuration
+// and logging of a node are kept in package level state, so only a single node
+// may be created per process.
+type Node struct {
+	started   int32
+	shutdown  int32
+	db        database.DB
+	server    *server
+	interrupt <-chan struct{}
+}
+
+// newNode loads the block database and creates the server of a node using the
+// loaded configuration.  Closing the interrupt channel interrupts catching up
+// the indexes and verifying the database, in which case an error is returned.
+func newNode(interrupt <-chan struct{}) (*Node, error) {
+	db, err := loadBlockDB()
+	if err != nil {
+		btcdLog.Errorf("%v", err)
+		return nil, err
+	}
+
+	// Drop the optional indexes when a full reindex is requested, so they
+	// are rebuilt along with the chain state.
+	if cfg.Reindex {
+		for _, drop := range []func(database.DB) error{
+			indexers.DropTxIndex, indexers.DropWatchIndex,
+			indexers.DropStatsIndex,
+		} {
+			if err := drop(db); err != nil {
+				btcdLog.Errorf("%v", err)
+				db.Close()
+				return nil, err
+			}
+		}
+	}
+
+	server, err := newServer(cfg.listenAddrs, db, activeNetParams.Params,
+		interrupt)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Node{
+		db:        db,
+		server:    server,
+		interrupt: interrupt,
+	}, nil
+}
+
+// New loads the configuration and the block database of a node and returns it
+// ready to be started.  The configuration is loaded the same way as by dmgd,
+// with the command line options passed with WithArgs.
+func New(opts ...Option) (*Node, error) {
+	if cfg != nil {
+		return nil, errors.New("a node was already created in this " +
+			"process")
+	}
+
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	tcfg, _, err := loadConfig(o.args)
+	if err != nil {
+		return nil, err
+	}
+
+	// The subsystems of the node read the global configuration while it
+	// is created, so it is only kept once the node was created.
+	cfg = tcfg
+	interrupt := o.interrupt
+	if interrupt == nil {
+		if nodeShutdown == nil {
+			nodeShutdown = shutdownListener()
+		}
+		interrupt = nodeShutdown
+	}
+	n, err := newNode(interrupt)
+	if err != nil {
+		cfg = nil
+		return nil, err
+	}
+	return n, nil
+}
+
+// Start begins connecting to peers and serving RPC clients.
+func (n *Node) Start() {
+	// Already started?
+	if atomic.AddInt32(&n.started, 1) != 1 {
+		return
+	}
+
+	n.server.Start()
+}
+
+// Stop gracefully shuts down the node, waits for its subsystems to finish and
+// closes the block database.
+func (n *Node) Stop() {
+	// Make sure this only happens once.
+	if atomic.AddInt32(&n.shutdown, 1) != 1 {
+		return
+	}
+
+	btcdLog.Infof("Gracefully shutting down the server...")
+	n.server.Stop()
+	n.server.WaitForShutdown()
+	srvrLog.Infof("Server shutdown complete")
+
+	// Ensure the database is sync'd and closed on shutdown.
+	btcdLog.Infof("Gracefully shutting down the database...")
+	n.db.Close()
+}
+
+// Done returns a channel which is closed when the node is interrupted or its
+// shutdown is requested, such as with the stop RPC.  The node keeps running
+// until Stop is called.
+func (n *Node) Done() <-chan struct{} {
+	return n.interrupt
+}
+
+// Chain returns the block chain of the node.
+func (n *Node) Chain() Chain {
+	return n.server.blockManager.chain
+}
+
+// Mempool returns the memory pool of the node.  The transactions it accepts
+// are relayed to the network.
+func (n *Node) Mempool() Mempool {
+	return nodeMempool{TxPool: n.server.txMemPool, server: n.server}
+}
+
+// RPC returns the RPC server of the node, or nil when RPC is disabled.
+func (n *Node) RPC() RPC {
+	if n.server.rpcServer == nil {
+		return nil
+	}
+	return n.server.rpcServer
+}
+
+// nodeMempool provides the memory pool of a node, relaying the transactions it
+// accepts to the network like the sendrawtransaction RPC does.
+type nodeMempool struct {
+	*mempool.TxPool
+	server *server
+}
+
+// ProcessTransaction processes the passed transaction like the memory pool,
+// and relays the accepted transactions to the network.
+//
+// This function is safe for concurrent access.
+func (m nodeMempool) ProcessTransaction(tx *provautil.Tx, allowOrphan, rateLimit bool, tag mempool.Tag) ([]*mempool.TxDesc, error) {
+	acceptedTxs, err := m.TxPool.ProcessTransaction(tx, allowOrphan,
+		rateLimit, tag)
+	if err != nil {
+		return nil, err
+	}
+	m.server.AnnounceNewTransactions(acceptedTxs)
+	return acceptedTxs, nil
+}
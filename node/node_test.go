@@ -0,0 +1,78 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcjson"
+)
+
+// TestNode ensures an embedded node can be created, started and stopped, gives
+// access to its chain, memory pool and RPC commands and reports a shutdown
+// requested with the stop RPC.
+func TestNode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A node which fails to be created does not prevent creating another
+	// one.  The data directory of the failing node is a file, so its block
+	// database can not be created.
+	dataFile := filepath.Join(dir, "datafile")
+	if err := ioutil.WriteFile(dataFile, nil, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	_, err = New(WithArgs("--simnet", "--nolisten", "--datadir="+dataFile,
+		"--logdir="+filepath.Join(dir, "logs"), "--norpc"))
+	if err == nil {
+		t.Fatalf("New: created a node without a data directory")
+	}
+
+	n, err := New(WithArgs("--simnet", "--nolisten",
+		"--datadir="+filepath.Join(dir, "data"),
+		"--logdir="+filepath.Join(dir, "logs"),
+		"--rpcuser=user", "--rpcpass=pass", "--rpclisten=127.0.0.1:0",
+		"--rpccert="+filepath.Join(dir, "rpc.cert"),
+		"--rpckey="+filepath.Join(dir, "rpc.key")))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	n.Start()
+	defer n.Stop()
+
+	if _, err := New(); err == nil {
+		t.Fatalf("New: created a second node in the process")
+	}
+
+	if height := n.Chain().BestSnapshot().Height; height != 0 {
+		t.Fatalf("got best height %d, want 0", height)
+	}
+	if count := n.Mempool().Count(); count != 0 {
+		t.Fatalf("got %d mempool transactions, want 0", count)
+	}
+	count, err := n.RPC().Call(btcjson.NewGetBlockCountCmd())
+	if err != nil {
+		t.Fatalf("getblockcount: %v", err)
+	}
+	if count != int64(0) {
+		t.Fatalf("getblockcount: got %v, want 0", count)
+	}
+
+	if _, err := n.RPC().Call(&btcjson.StopCmd{}); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+	select {
+	case <-n.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("shutdown requested with the stop RPC not reported")
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"sync"
+
+	"github.com/pyx-partners/dmgd/btcjson"
+)
+
+// maxPeerEvictions is the number of the most recent inbound peer evictions
+// which are kept for the getpeerevictions RPC.
+const maxPeerEvictions = 100
+
+// peerEvictions keeps the most recent inbound peer evictions made to make room
+// for new inbound peers once the maximum number of peers is reached.
+type peerEvictions struct {
+	mtx       sync.Mutex
+	evictions []btcjson.PeerEvictionResult
+}
+
+// add records the passed eviction, dropping the oldest one when the maximum
+// number of evictions is kept.
+//
+// This function is safe for concurrent access.
+func (e *peerEvictions) add(eviction btcjson.PeerEvictionResult) {
+	e.mtx.Lock()
+	if len(e.evictions) >= maxPeerEvictions {
+		copy(e.evictions, e.evictions[1:])
+		e.evictions = e.evictions[:len(e.evictions)-1]
+	}
+	e.evictions = append(e.evictions, eviction)
+	e.mtx.Unlock()
+}
+
+// results returns the kept evictions, newest first.
+//
+// This function is safe for concurrent access.
+func (e *peerEvictions) results() []btcjson.PeerEvictionResult {
+	e.mtx.Lock()
+	results := make([]btcjson.PeerEvictionResult, len(e.evictions))
+	for i, eviction := range e.evictions {
+		results[len(results)-1-i] = eviction
+	}
+	e.mtx.Unlock()
+	return results
+}
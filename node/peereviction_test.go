@@ -0,0 +1,35 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"testing"
+
+	"github.com/pyx-partners/dmgd/btcjson"
+)
+
+// TestPeerEvictions ensures the most recent peer evictions are kept and
+// returned newest first.
+func TestPeerEvictions(t *testing.T) {
+	var evictions peerEvictions
+	if results := evictions.results(); len(results) != 0 {
+		t.Fatalf("got %d evictions before the first one", len(results))
+	}
+
+	for i := 0; i < maxPeerEvictions+5; i++ {
+		evictions.add(btcjson.PeerEvictionResult{ID: int32(i)})
+	}
+	results := evictions.results()
+	if len(results) != maxPeerEvictions {
+		t.Fatalf("got %d evictions, want %d", len(results),
+			maxPeerEvictions)
+	}
+	for i, result := range results {
+		if want := int32(maxPeerEvictions + 4 - i); result.ID != want {
+			t.Fatalf("got eviction %d at #%d, want %d", result.ID, i,
+				want)
+		}
+	}
+}
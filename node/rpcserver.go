@@ -0,0 +1,7889 @@
+// Copyright (c) 2013-2016 The btcsuite developers
+// Copyright (c) 2015-2017 The Decred developers
+// Copyright (c) 2017 BitGo
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/pyx-partners/dmgd/auditlog"
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/blockchain/indexers"
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/btcjson"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/keystore"
+	"github.com/pyx-partners/dmgd/mempool"
+	"github.com/pyx-partners/dmgd/mining"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/provautil/hdkeychain"
+	"github.com/pyx-partners/dmgd/provautil/reserve"
+	"github.com/pyx-partners/dmgd/provautil/sweep"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/txscript/adminop"
+	"github.com/pyx-partners/dmgd/wire"
+	"github.com/btcsuite/websocket"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// rpcAuthTimeoutSeconds is the number of seconds a connection to the
+	// RPC server is allowed to stay open without authenticating before it
+	// is closed.
+	rpcAuthTimeoutSeconds = 10
+
+	// uint256Size is the number of bytes needed to represent an unsigned
+	// 256-bit integer.
+	uint256Size = 32
+
+	// gbtNonceRange is two 32-bit big-endian hexadecimal integers which
+	// represent the valid ranges of nonces returned by the getblocktemplate
+	// RPC.
+	gbtNonceRange = "00000000ffffffff"
+
+	// gbtRegenerateSeconds is the number of seconds that must pass before
+	// a new template is generated when the previous block hash has not
+	// changed and there have been changes to the available transactions
+	// in the memory pool.
+	gbtRegenerateSeconds = 60
+
+	// maxProtocolVersion is the max protocol version the server supports.
+	maxProtocolVersion = 70002
+
+	// fundRawTxInputSize is the estimated serialized size of a signed input
+	// spending a standard Prova output, used when funding transactions.
+	//
+	//   36 prev outpoint, 3 script len, 216 script [2x (1 OP_DATA_33,
+	//   33 compressed pubkey, 1 OP_DATA_73, 73 sig)], 4 sequence
+	fundRawTxInputSize = 36 + 3 + 216 + 4
+
+	// fundRawTxEmptyInputSize is the serialized size of an input with an
+	// empty signature script.
+	fundRawTxEmptyInputSize = 36 + 1 + 4
+
+	// maxAddressHistoryPageSize is the maximum number of entries returned
+	// by a single getaddresshistory call.
+	maxAddressHistoryPageSize = 1000
+
+	// chainStatsDateFormat is the format of the UTC dates of the days
+	// returned by the getchainstats RPC.
+	chainStatsDateFormat = "2006-01-02"
+
+	// maxValidateKeysUnlockTimeout is the maximum number of seconds the
+	// validate keys can be unlocked for by a single unlockvalidatekeys
+	// call, which matches the limit of walletpassphrase in bitcoind.
+	maxValidateKeysUnlockTimeout = 100000000
+)
+
+var (
+	// gbtMutableFields are the manipulations the server allows to be made
+	// to block templates generated by the getblocktemplate RPC.  It is
+	// declared here to avoid the overhead of creating the slice on every
+	// invocation for constant data.
+	gbtMutableFields = []string{
+		"time", "transactions/add", "prevblock", "coinbase/append",
+	}
+
+	// gbtCoinbaseAux describes additional data that miners should include
+	// in the coinbase signature script.  It is declared here to avoid the
+	// overhead of creating a new object on every invocation for constant
+	// data.
+	gbtCoinbaseAux = &btcjson.GetBlockTemplateResultAux{
+		Flags: hex.EncodeToString(builderScript(txscript.
+			NewScriptBuilder().
+			AddData([]byte(mining.CoinbaseFlags)))),
+	}
+
+	// gbtCapabilities describes additional capabilities returned with a
+	// block template generated by the getblocktemplate RPC.    It is
+	// declared here to avoid the overhead of creating the slice on every
+	// invocation for constant data.
+	gbtCapabilities = []string{"proposal"}
+)
+
+// Errors
+var (
+	// ErrRPCUnimplemented is an error returned to RPC clients when the
+	// provided command is recognized, but not implemented.
+	ErrRPCUnimplemented = &btcjson.RPCError{
+		Code:    btcjson.ErrRPCUnimplemented,
+		Message: "Command unimplemented",
+	}
+
+	// ErrRPCNoWallet is an error returned to RPC clients when the provided
+	// command is recognized as a wallet command.
+	ErrRPCNoWallet = &btcjson.RPCError{
+		Code:    btcjson.ErrRPCNoWallet,
+		Message: "This implementation does not implement wallet commands",
+	}
+)
+
+type commandHandler func(*rpcServer, interface{}, <-chan struct{}) (interface{}, error)
+
+// rpcHandlers maps RPC command strings to appropriate handler functions.
+// This is set by init because help references rpcHandlers and thus causes
+// a dependency loop.
+var rpcHandlers map[string]commandHandler
+var rpcHandlersBeforeInit = map[string]commandHandler{
+	"addnode":                  handleAddNode,
+	"addsignedcheckpoint":      handleAddSignedCheckpoint,
+	"checkcanonicalencoding":   handleCheckCanonicalEncoding,
+	"createrawtransaction":     handleCreateRawTransaction,
+	"debuglevel":               handleDebugLevel,
+	"decoderawtransaction":     handleDecodeRawTransaction,
+	"fundrawtransaction":       handleFundRawTransaction,
+	"generate":                 handleGenerate,
+	"getaddednodeinfo":         handleGetAddedNodeInfo,
+	"getaddresshistory":        handleGetAddressHistory,
+	"getaddresstxids":          handleGetAddressTxIds,
+	"getaddressutxos":          handleGetAddressUtxos,
+	"getadmininfo":             handleGetAdminInfo,
+	"getannouncements":         handleGetAnnouncements,
+	"getbalance":               handleGetBalance,
+	"getbestblock":             handleGetBestBlock,
+	"getbestblockhash":         handleGetBestBlockHash,
+	"getblock":                 handleGetBlock,
+	"getblockatheightbytime":   handleGetBlockAtHeightByTime,
+	"getblockchaininfo":        handleGetBlockChainInfo,
+	"getblockcount":            handleGetBlockCount,
+	"getblockhash":             handleGetBlockHash,
+	"getblockheader":           handleGetBlockHeader,
+	"getblockpropagation":      handleGetBlockPropagation,
+	"getblocktemplate":         handleGetBlockTemplate,
+	"getchainstats":            handleGetChainStats,
+	"getchaintips":             handleGetChainTips,
+	"getcommitments":           handleGetCommitments,
+	"getconnectioncount":       handleGetConnectionCount,
+	"getcurrentnet":            handleGetCurrentNet,
+	"getdifficulty":            handleGetDifficulty,
+	"getfinalitystatus":        handleGetFinalityStatus,
+	"getfreezestatus":          handleGetFreezeStatus,
+	"getgenerate":              handleGetGenerate,
+	"gethashespersec":          handleGetHashesPerSec,
+	"getheaders":               handleGetHeaders,
+	"getinfo":                  handleGetInfo,
+	"getkeyexpiries":           handleGetKeyExpiries,
+	"getkeyidcensus":           handleGetKeyIDCensus,
+	"getkeystatediff":          handleGetKeyStateDiff,
+	"getmempoolentry":          handleGetMempoolEntry,
+	"getmempoolinfo":           handleGetMempoolInfo,
+	"getmininginfo":            handleGetMiningInfo,
+	"getnettotals":             handleGetNetTotals,
+	"getnetworkinfo":           handleGetNetworkInfo,
+	"getnetworkhashps":         handleGetNetworkHashPS,
+	"getpeerevictions":         handleGetPeerEvictions,
+	"getpeerinfo":              handleGetPeerInfo,
+	"getrawmempool":            handleGetRawMempool,
+	"getrawtransaction":        handleGetRawTransaction,
+	"getreorghistory":          handleGetReorgHistory,
+	"getreserveproof":          handleGetReserveProof,
+	"getrpcinfo":               handleGetRPCInfo,
+	"getsignedcheckpoints":     handleGetSignedCheckpoints,
+	"getsweepplan":             handleGetSweepPlan,
+	"getsyncstatus":            handleGetSyncStatus,
+	"gettemplateexclusions":    handleGetTemplateExclusions,
+	"gettransactionstatus":     handleGetTransactionStatus,
+	"gettxout":                 handleGetTxOut,
+	"getunconfirmedbroadcasts": handleGetUnconfirmedBroadcasts,
+	"getvalidatorviolations":   handleGetValidatorViolations,
+	"getxpubinfo":              handleGetXpubInfo,
+	"help":                     handleHelp,
+	"importaddress":            handleImportAddress,
+	"importpubkey":             handleImportPubKey,
+	"importxpub":               handleImportXpub,
+	"listsinceblock":           handleListSinceBlock,
+	"listtransactions":         handleListTransactions,
+	"listunspent":              handleListUnspent,
+	"lockvalidatekeys":         handleLockValidateKeys,
+	"node":                     handleNode,
+	"ping":                     handlePing,
+	"prioritisetransaction":    handlePrioritiseTransaction,
+	"reloadconfig":             handleReloadConfig,
+	"rpc.discover":             handleRPCDiscover,
+	"searchrawtransactions":    handleSearchRawTransactions,
+	"sendannouncement":         handleSendAnnouncement,
+	"sendrawpackage":           handleSendRawPackage,
+	"sendrawtransaction":       handleSendRawTransaction,
+	"setgenerate":              handleSetGenerate,
+	"settemplateexclusion":     handleSetTemplateExclusion,
+	"setvalidatekeys":          handleSetValidateKeys,
+	"stop":                     handleStop,
+	"submitblock":              handleSubmitBlock,
+	"unlockvalidatekeys":       handleUnlockValidateKeys,
+	"validateaddress":          handleValidateAddress,
+	"verifychain":              handleVerifyChain,
+	"verifyreserveattestation": handleVerifyReserveAttestation,
+}
+
+// list of commands that we recognize, but for which there is no support because
+// of lack of support for wallet functionality.
+var rpcAskWallet = map[string]struct{}{
+	"addmultisigaddress":     {},
+	"backupwallet":           {},
+	"createencryptedwallet":  {},
+	"createmultisig":         {},
+	"dumpprivkey":            {},
+	"dumpwallet":             {},
+	"encryptwallet":          {},
+	"getaccount":             {},
+	"getaccountaddress":      {},
+	"getaddressesbyaccount":  {},
+	"getnewaddress":          {},
+	"getrawchangeaddress":    {},
+	"getreceivedbyaccount":   {},
+	"getreceivedbyaddress":   {},
+	"gettransaction":         {},
+	"gettxoutsetinfo":        {},
+	"getunconfirmedbalance":  {},
+	"getwalletinfo":          {},
+	"importprivkey":          {},
+	"importwallet":           {},
+	"keypoolrefill":          {},
+	"listaccounts":           {},
+	"listaddressgroupings":   {},
+	"listlockunspent":        {},
+	"listreceivedbyaccount":  {},
+	"listreceivedbyaddress":  {},
+	"lockunspent":            {},
+	"move":                   {},
+	"sendfrom":               {},
+	"sendmany":               {},
+	"sendtoaddress":          {},
+	"setaccount":             {},
+	"settxfee":               {},
+	"signmessage":            {},
+	"signrawtransaction":     {},
+	"walletlock":             {},
+	"walletpassphrase":       {},
+	"walletpassphrasechange": {},
+}
+
+// Commands that are currently unimplemented, but should ultimately be.
+var rpcUnimplemented = map[string]struct{}{
+	"estimatefee":      {},
+	"estimatepriority": {},
+	"getwork":          {},
+	"invalidateblock":  {},
+	"preciousblock":    {},
+	"reconsiderblock":  {},
+}
+
+// Commands that are available to a limited user
+var rpcLimited = map[string]struct{}{
+	// Websockets commands
+	"loadtxfilter":          {},
+	"notifyblocks":          {},
+	"notifyheaders":         {},
+	"notifynewtransactions": {},
+	"notifyreceived":        {},
+	"notifyspent":           {},
+	"rescan":                {},
+	"rescanblocks":          {},
+	"rescanchain":           {},
+	"session":               {},
+
+	// Websockets AND HTTP/S commands
+	"help": {},
+
+	// HTTP/S-only commands
+	"checkcanonicalencoding":   {},
+	"createrawtransaction":     {},
+	"decoderawtransaction":     {},
+	"decodescript":             {},
+	"fundrawtransaction":       {},
+	"getaddresshistory":        {},
+	"getaddresstxids":          {},
+	"getaddressutxos":          {},
+	"getadmininfo":             {},
+	"getannouncements":         {},
+	"getbalance":               {},
+	"getbestblock":             {},
+	"getbestblockhash":         {},
+	"getblock":                 {},
+	"getblockatheightbytime":   {},
+	"getblockchaininfo":        {},
+	"getblockcount":            {},
+	"getblockhash":             {},
+	"getchainstats":            {},
+	"getchaintips":             {},
+	"getcommitments":           {},
+	"getcurrentnet":            {},
+	"getdifficulty":            {},
+	"getfinalitystatus":        {},
+	"getfreezestatus":          {},
+	"getheaders":               {},
+	"getinfo":                  {},
+	"getkeyexpiries":           {},
+	"getkeyidcensus":           {},
+	"getkeystatediff":          {},
+	"getmempoolentry":          {},
+	"getnettotals":             {},
+	"getnetworkhashps":         {},
+	"getnetworkinfo":           {},
+	"getrawmempool":            {},
+	"getrawtransaction":        {},
+	"getreorghistory":          {},
+	"getreserveproof":          {},
+	"getsignedcheckpoints":     {},
+	"getsweepplan":             {},
+	"gettransactionstatus":     {},
+	"gettxout":                 {},
+	"getvalidatorviolations":   {},
+	"getxpubinfo":              {},
+	"listsinceblock":           {},
+	"listtransactions":         {},
+	"listunspent":              {},
+	"rpc.discover":             {},
+	"searchrawtransactions":    {},
+	"sendannouncement":         {},
+	"sendrawpackage":           {},
+	"sendrawtransaction":       {},
+	"submitblock":              {},
+	"validateaddress":          {},
+	"verifymessage":            {},
+	"verifyreserveattestation": {},
+}
+
+// builderScript is a convenience function which is used for hard-coded scripts
+// built with the script builder.   Any errors are converted to a panic since it
+// is only, and must only, be used with hard-coded, and therefore, known good,
+// scripts.
+func builderScript(builder *txscript.ScriptBuilder) []byte {
+	script, err := builder.Script()
+	if err != nil {
+		panic(err)
+	}
+	return script
+}
+
+// internalRPCError is a convenience function to convert an internal error to
+// an RPC error with the appropriate code set.  It also logs the error to the
+// RPC server subsystem since internal errors really should not occur.  The
+// context parameter is only used in the log message and may be empty if it's
+// not needed.
+func internalRPCError(errStr, context string) *btcjson.RPCError {
+	logStr := errStr
+	if context != "" {
+		logStr = context + ": " + errStr
+	}
+	rpcsLog.Error(logStr)
+	return btcjson.NewRPCError(btcjson.ErrRPCInternal.Code, errStr)
+}
+
+// rpcDecodeHexError is a convenience function for returning a nicely formatted
+// RPC error which indicates the provided hex string failed to decode.
+func rpcDecodeHexError(gotHex string) *btcjson.RPCError {
+	return btcjson.NewRPCError(btcjson.ErrRPCDecodeHexString,
+		fmt.Sprintf("Argument must be hexadecimal string (not %q)",
+			gotHex))
+}
+
+// rpcNoTxInfoError is a convenience function for returning a nicely formatted
+// RPC error which indicates there is no information available for the provided
+// transaction hash.
+func rpcNoTxInfoError(txHash *chainhash.Hash) *btcjson.RPCError {
+	return btcjson.NewRPCError(btcjson.ErrRPCNoTxInfo,
+		fmt.Sprintf("No information available about transaction %v",
+			txHash))
+}
+
+// rpcNoWatchIndexError is a convenience function for returning an RPC error
+// which indicates the watch-only index the watch-only RPCs rely on is not
+// enabled.
+func rpcNoWatchIndexError() *btcjson.RPCError {
+	return &btcjson.RPCError{
+		Code:    btcjson.ErrRPCMisc,
+		Message: "Watch-only index must be enabled (--watchindex)",
+	}
+}
+
+// gbtWorkState houses state that is used in between multiple RPC invocations to
+// getblocktemplate.
+type gbtWorkState struct {
+	sync.Mutex
+	lastTxUpdate  time.Time
+	lastGenerated time.Time
+	prevHash      *chainhash.Hash
+	minTimestamp  time.Time
+	template      *mining.BlockTemplate
+	notifyMap     map[chainhash.Hash]map[int64]chan struct{}
+	timeSource    blockchain.MedianTimeSource
+}
+
+// newGbtWorkState returns a new instance of a gbtWorkState with all internal
+// fields initialized and ready to use.
+func newGbtWorkState(timeSource blockchain.MedianTimeSource) *gbtWorkState {
+	return &gbtWorkState{
+		notifyMap:  make(map[chainhash.Hash]map[int64]chan struct{}),
+		timeSource: timeSource,
+	}
+}
+
+// handleUnimplemented is the handler for commands that should ultimately be
+// supported but are not yet implemented.
+func handleUnimplemented(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	return nil, ErrRPCUnimplemented
+}
+
+// handleAskWallet is the handler for commands that are recognized as valid, but
+// are unable to answer correctly since it involves wallet state.
+// These commands will be implemented in btcwallet.
+func handleAskWallet(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	return nil, ErrRPCNoWallet
+}
+
+// handleAddNode handles addnode commands.
+func handleAddNode(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.AddNodeCmd)
+
+	addr := normalizeAddress(c.Addr, activeNetParams.DefaultPort)
+	var err error
+	switch c.SubCmd {
+	case "add":
+		err = s.server.ConnectNode(addr, true)
+	case "remove":
+		err = s.server.RemoveNodeByAddr(addr)
+	case "onetry":
+		err = s.server.ConnectNode(addr, false)
+	default:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "invalid subcommand for addnode",
+		}
+	}
+
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	}
+
+	// no data returned unless an error.
+	return nil, nil
+}
+
+// handleAddSignedCheckpoint implements the addsignedcheckpoint command.  It
+// verifies the passed checkpoint is signed by a trusted checkpoint key and
+// enforces it from then on.
+func handleAddSignedCheckpoint(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.AddSignedCheckpointCmd)
+
+	serialized, err := hex.DecodeString(c.HexCheckpoint)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.HexCheckpoint)
+	}
+	checkpoint, err := blockchain.DeserializeSignedCheckpoint(serialized)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDeserialization,
+			Message: "Checkpoint decode failed: " + err.Error(),
+		}
+	}
+
+	// Record the checkpoint in the audit log before enforcing it, so the
+	// chain is never pinned without a record of the signer.
+	if l := s.server.auditLog; l != nil {
+		err := l.Append(auditlog.EventRPC, &auditlog.RPCData{
+			Method: "addsignedcheckpoint",
+			PubKeys: []string{hex.EncodeToString(
+				checkpoint.PubKey.SerializeCompressed())},
+		})
+		if err != nil {
+			context := "Failed to write audit log"
+			return nil, internalRPCError(err.Error(), context)
+		}
+	}
+	if err := s.chain.AddSignedCheckpoint(checkpoint); err != nil {
+		if _, ok := err.(blockchain.RuleError); ok {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCVerify,
+				Message: "Rejected checkpoint: " + err.Error(),
+			}
+		}
+		context := "Failed to store checkpoint"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	return nil, nil
+}
+
+// handleNode handles node commands.
+func handleNode(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.NodeCmd)
+
+	var addr string
+	var nodeID uint64
+	var errN, err error
+	switch c.SubCmd {
+	case "disconnect":
+		// If we have a valid uint disconnect by node id. Otherwise,
+		// attempt to disconnect by address, returning an error if a
+		// valid IP address is not supplied.
+		if nodeID, errN = strconv.ParseUint(c.Target, 10, 32); errN == nil {
+			err = s.server.DisconnectNodeByID(int32(nodeID))
+		} else {
+			if _, _, errP := net.SplitHostPort(c.Target); errP == nil || net.ParseIP(c.Target) != nil {
+				addr = normalizeAddress(c.Target, activeNetParams.DefaultPort)
+				err = s.server.DisconnectNodeByAddr(addr)
+			} else {
+				return nil, &btcjson.RPCError{
+					Code:    btcjson.ErrRPCInvalidParameter,
+					Message: "invalid address or node ID",
+				}
+			}
+		}
+		if err != nil && peerExists(s.server.Peers(), addr, int32(nodeID)) {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCMisc,
+				Message: "can't disconnect a permanent peer, use remove",
+			}
+		}
+	case "remove":
+		// If we have a valid uint disconnect by node id. Otherwise,
+		// attempt to disconnect by address, returning an error if a
+		// valid IP address is not supplied.
+		if nodeID, errN = strconv.ParseUint(c.Target, 10, 32); errN == nil {
+			err = s.server.RemoveNodeByID(int32(nodeID))
+		} else {
+			if _, _, errP := net.SplitHostPort(c.Target); errP == nil || net.ParseIP(c.Target) != nil {
+				addr = normalizeAddress(c.Target, activeNetParams.DefaultPort)
+				err = s.server.RemoveNodeByAddr(addr)
+			} else {
+				return nil, &btcjson.RPCError{
+					Code:    btcjson.ErrRPCInvalidParameter,
+					Message: "invalid address or node ID",
+				}
+			}
+		}
+		if err != nil && peerExists(s.server.Peers(), addr, int32(nodeID)) {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCMisc,
+				Message: "can't remove a temporary peer, use disconnect",
+			}
+		}
+	case "connect":
+		addr = normalizeAddress(c.Target, activeNetParams.DefaultPort)
+
+		// Default to temporary connections.
+		subCmd := "temp"
+		if c.ConnectSubCmd != nil {
+			subCmd = *c.ConnectSubCmd
+		}
+
+		switch subCmd {
+		case "perm", "temp":
+			err = s.server.ConnectNode(addr, subCmd == "perm")
+		default:
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: "invalid subcommand for node connect",
+			}
+		}
+	default:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "invalid subcommand for node",
+		}
+	}
+
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	}
+
+	// no data returned unless an error.
+	return nil, nil
+}
+
+// peerExists determines if a certain peer is currently connected given
+// information about all currently connected peers. Peer existence is
+// determined using either a target address or node id.
+func peerExists(peers []*serverPeer, addr string, nodeID int32) bool {
+	for _, p := range peers {
+		if p.ID() == nodeID || p.Addr() == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// messageToHex serializes a message to the wire protocol encoding using the
+// latest protocol version and returns a hex-encoded string of the result.
+func messageToHex(msg wire.Message) (string, error) {
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, maxProtocolVersion); err != nil {
+		context := fmt.Sprintf("Failed to encode msg of type %T", msg)
+		return "", internalRPCError(err.Error(), context)
+	}
+
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+// handleCheckCanonicalEncoding implements the checkcanonicalencoding command.
+func handleCheckCanonicalEncoding(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.CheckCanonicalEncodingCmd)
+
+	hexStr := c.HexData
+	if len(hexStr)%2 != 0 {
+		hexStr = "0" + hexStr
+	}
+	serialized, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hexStr)
+	}
+
+	var msg wire.Message
+	var msgTx wire.MsgTx
+	var msgBlock wire.MsgBlock
+	msg = &msgTx
+	if *c.Block {
+		msg = &msgBlock
+	}
+	issues, err := wire.CheckCanonicalEncoding(msg, serialized)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDeserialization,
+			Message: "Decode failed: " + err.Error(),
+		}
+	}
+
+	result := &btcjson.CheckCanonicalEncodingResult{
+		Canonical:     len(issues) == 0,
+		NonCanonicals: make([]btcjson.NonCanonicalEncodingResult, 0, len(issues)),
+	}
+	if *c.Block {
+		result.Hash = msgBlock.BlockHash().String()
+	} else {
+		result.Hash = msgTx.TxHash().String()
+	}
+	for _, issue := range issues {
+		result.NonCanonicals = append(result.NonCanonicals,
+			btcjson.NonCanonicalEncodingResult{
+				Kind:        issue.Kind.String(),
+				Offset:      issue.Offset,
+				Tx:          issue.Tx,
+				Field:       issue.Field,
+				Description: issue.Description,
+			})
+	}
+	return result, nil
+}
+
+// handleCreateRawTransaction handles createrawtransaction commands.
+func handleCreateRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.CreateRawTransactionCmd)
+
+	// Validate the locktime, if given.
+	if c.LockTime != nil &&
+		(*c.LockTime < 0 || *c.LockTime > int64(wire.MaxTxInSequenceNum)) {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Locktime out of range",
+		}
+	}
+
+	// Add all transaction inputs to a new transaction after performing
+	// some validity checks.
+	mtx := wire.NewMsgTx(wire.TxVersion)
+	for _, input := range c.Inputs {
+		txHash, err := chainhash.NewHashFromStr(input.Txid)
+		if err != nil {
+			return nil, rpcDecodeHexError(input.Txid)
+		}
+
+		prevOut := wire.NewOutPoint(txHash, input.Vout)
+		txIn := wire.NewTxIn(prevOut, []byte{})
+		if c.LockTime != nil && *c.LockTime != 0 {
+			txIn.Sequence = wire.MaxTxInSequenceNum - 1
+		}
+		mtx.AddTxIn(txIn)
+	}
+
+	// Add all transaction outputs to the transaction after performing
+	// some validity checks.
+	for encodedAddr, amount := range c.Amounts {
+		// Ensure amount is in the valid range for monetary amounts.
+		if amount <= 0 || amount > provautil.MaxAtoms {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCType,
+				Message: "Invalid amount",
+			}
+		}
+
+		// Decode the provided address.
+		addr, err := provautil.DecodeAddress(encodedAddr,
+			activeNetParams.Params)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "Invalid address or key: " + err.Error(),
+			}
+		}
+
+		// Ensure the address is one of the supported types and that
+		// the network encoded with the address matches the network the
+		// server is currently on.
+		switch addr.(type) {
+		default:
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "Invalid address or key",
+			}
+		}
+		if !addr.IsForNet(s.server.chainParams) {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "Invalid address: " + encodedAddr +
+					" is for the wrong network",
+			}
+		}
+
+		// Create a new script which pays to the provided address.
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			context := "Failed to generate pay-to-address script"
+			return nil, internalRPCError(err.Error(), context)
+		}
+
+		// Convert the amount to atoms.
+		atoms, err := provautil.NewAmount(amount)
+		if err != nil {
+			context := "Failed to convert amount"
+			return nil, internalRPCError(err.Error(), context)
+		}
+
+		txOut := wire.NewTxOut(int64(atoms), pkScript)
+		mtx.AddTxOut(txOut)
+	}
+
+	// Set the Locktime, if given.
+	if c.LockTime != nil {
+		mtx.LockTime = uint32(*c.LockTime)
+	}
+
+	// Return the serialized and hex-encoded transaction.  Note that this
+	// is intentionally not directly returning because the first return
+	// value is a string and it would result in returning an empty string to
+	// the client instead of nothing (nil) in the case of an error.
+	mtxHex, err := messageToHex(mtx)
+	if err != nil {
+		return nil, err
+	}
+	return mtxHex, nil
+}
+
+type addressToKey struct {
+	key        *btcec.PrivateKey
+	compressed bool
+}
+
+// handleDebugLevel handles debuglevel commands.
+func handleDebugLevel(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.DebugLevelCmd)
+
+	// Special show command to list supported subsystems.
+	if c.LevelSpec == "show" {
+		return fmt.Sprintf("Supported subsystems %v",
+			supportedSubsystems()), nil
+	}
+
+	err := parseAndSetDebugLevels(c.LevelSpec)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParams.Code,
+			Message: err.Error(),
+		}
+	}
+
+	return "Done.", nil
+}
+
+// createVinList returns a slice of JSON objects for the inputs of the passed
+// transaction.
+func createVinList(mtx *wire.MsgTx) []btcjson.Vin {
+	// Coinbase transactions only have a single txin by definition.
+	vinList := make([]btcjson.Vin, len(mtx.TxIn))
+	if blockchain.IsCoinBaseTx(mtx) {
+		txIn := mtx.TxIn[0]
+		vinList[0].Coinbase = hex.EncodeToString(txIn.SignatureScript)
+		vinList[0].Sequence = txIn.Sequence
+		return vinList
+	}
+
+	for i, txIn := range mtx.TxIn {
+		// The disassembled string will contain [error] inline
+		// if the script doesn't fully parse, so ignore the
+		// error here.
+		disbuf, _ := txscript.DisasmString(txIn.SignatureScript)
+
+		vinEntry := &vinList[i]
+		vinEntry.Txid = txIn.PreviousOutPoint.Hash.String()
+		vinEntry.Vout = txIn.PreviousOutPoint.Index
+		vinEntry.Sequence = txIn.Sequence
+		vinEntry.ScriptSig = &btcjson.ScriptSig{
+			Asm: disbuf,
+			Hex: hex.EncodeToString(txIn.SignatureScript),
+		}
+	}
+
+	return vinList
+}
+
+// createVoutList returns a slice of JSON objects for the outputs of the passed
+// transaction.
+func createVoutList(mtx *wire.MsgTx, chainParams *chaincfg.Params, filterAddrMap map[string]struct{}) []btcjson.Vout {
+	voutList := make([]btcjson.Vout, 0, len(mtx.TxOut))
+	threadInt, _ := txscript.GetAdminDetailsMsgTx(mtx)
+	isAdmin := provautil.ThreadID(threadInt) == provautil.RootThread || provautil.ThreadID(threadInt) == provautil.ProvisionThread
+	for i, v := range mtx.TxOut {
+		// The disassembled string will contain [error] inline if the
+		// script doesn't fully parse, so ignore the error here.
+		disbuf, _ := txscript.DisasmString(v.PkScript)
+
+		// Ignore the error here since an error means the script
+		// couldn't parse and there is no additional information about
+		// it anyways.
+		scriptClass, addrs, reqSigs, _ := txscript.ExtractPkScriptAddrs(
+			v.PkScript, chainParams)
+
+		// Encode the addresses while checking if the address passes the
+		// filter when needed.
+		passesFilter := len(filterAddrMap) == 0
+		encodedAddrs := make([]string, len(addrs))
+		for j, addr := range addrs {
+			encodedAddr := addr.EncodeAddress()
+			encodedAddrs[j] = encodedAddr
+
+			// No need to check the map again if the filter already
+			// passes.
+			if passesFilter {
+				continue
+			}
+			if _, exists := filterAddrMap[encodedAddr]; exists {
+				passesFilter = true
+			}
+		}
+
+		if !passesFilter {
+			continue
+		}
+
+		var vout btcjson.Vout
+		vout.N = uint32(i)
+		vout.Value = provautil.Amount(v.Value).ToDMG()
+		vout.ScriptPubKey.Addresses = encodedAddrs
+		vout.ScriptPubKey.Asm = disbuf
+		vout.ScriptPubKey.Hex = hex.EncodeToString(v.PkScript)
+		vout.ScriptPubKey.Type = scriptClass.String()
+		vout.ScriptPubKey.ReqSigs = int32(reqSigs)
+
+		if isAdmin && scriptClass == txscript.NullDataTy {
+			vout.ScriptPubKey.AdminOp = txscript.AdminOpString(v.PkScript)
+		}
+
+		voutList = append(voutList, vout)
+	}
+
+	return voutList
+}
+
+// createTxRawResult converts the passed transaction and associated parameters
+// to a raw transaction JSON object.
+func createTxRawResult(chainParams *chaincfg.Params, mtx *wire.MsgTx,
+	txHash string, blkHeader *wire.BlockHeader, blkHash string,
+	blkHeight uint32, chainHeight uint32) (*btcjson.TxRawResult, error) {
+
+	mtxHex, err := messageToHex(mtx)
+	if err != nil {
+		return nil, err
+	}
+
+	txReply := &btcjson.TxRawResult{
+		Hex:      mtxHex,
+		Txid:     txHash,
+		Vin:      createVinList(mtx),
+		Vout:     createVoutList(mtx, chainParams, nil),
+		Version:  mtx.Version,
+		LockTime: mtx.LockTime,
+	}
+
+	if blkHeader != nil {
+		// This is not a typo, they are identical in bitcoind as well.
+		txReply.Time = blkHeader.Timestamp.Unix()
+		txReply.Blocktime = blkHeader.Timestamp.Unix()
+		txReply.BlockHash = blkHash
+		txReply.Confirmations = uint64(1 + chainHeight - blkHeight)
+	}
+
+	return txReply, nil
+}
+
+// handleDecodeRawTransaction handles decoderawtransaction commands.
+func handleDecodeRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.DecodeRawTransactionCmd)
+
+	// Deserialize the transaction.
+	hexStr := c.HexTx
+	if len(hexStr)%2 != 0 {
+		hexStr = "0" + hexStr
+	}
+	serializedTx, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hexStr)
+	}
+	var mtx wire.MsgTx
+	err = mtx.Deserialize(bytes.NewReader(serializedTx))
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDeserialization,
+			Message: "TX decode failed: " + err.Error(),
+		}
+	}
+
+	// Create and return the result.
+	txReply := btcjson.TxRawDecodeResult{
+		Txid:     mtx.TxHash().String(),
+		Version:  mtx.Version,
+		Locktime: mtx.LockTime,
+		Vin:      createVinList(&mtx),
+		Vout:     createVoutList(&mtx, s.server.chainParams, nil),
+	}
+	return txReply, nil
+}
+
+// fundingCandidate describes an unspent output which may be selected to fund
+// a transaction by the fundrawtransaction command.
+type fundingCandidate struct {
+	outPoint wire.OutPoint
+	amount   int64
+}
+
+// fetchFundingCandidates uses the address index to find the unspent outputs
+// paying to the passed addresses which are mature and not already spent by a
+// transaction in the memory pool.  The candidates are returned largest first.
+func fetchFundingCandidates(s *rpcServer, addrs []provautil.Address) ([]fundingCandidate, error) {
+	addrIndex := s.server.addrIndex
+	chain := s.server.blockManager.chain
+	nextHeight := chain.BestSnapshot().Height + 1
+	maturity := uint32(s.server.chainParams.CoinbaseMaturity)
+
+	seen := make(map[wire.OutPoint]struct{})
+	var candidates []fundingCandidate
+	for _, addr := range addrs {
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		var serializedTxns [][]byte
+		err = s.server.db.View(func(dbTx database.Tx) error {
+			regions, err := addrIndex.BoundedTxRegionsForAddress(
+				dbTx, addr, 0, 1<<32-1)
+			if err != nil {
+				return err
+			}
+			serializedTxns, err = dbTx.FetchBlockRegions(regions)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, serializedTx := range serializedTxns {
+			var mtx wire.MsgTx
+			err := mtx.Deserialize(bytes.NewReader(serializedTx))
+			if err != nil {
+				return nil, err
+			}
+			txHash := mtx.TxHash()
+			var entry *blockchain.UtxoEntry
+			for i, txOut := range mtx.TxOut {
+				if !bytes.Equal(txOut.PkScript, pkScript) {
+					continue
+				}
+				op := wire.OutPoint{Hash: txHash, Index: uint32(i)}
+				if _, ok := seen[op]; ok {
+					continue
+				}
+				seen[op] = struct{}{}
+
+				// Only outputs which are still unspent in the
+				// main chain and not spent by the memory pool can
+				// be used.
+				if entry == nil {
+					entry, err = chain.FetchUtxoEntry(&txHash)
+					if err != nil {
+						return nil, err
+					}
+					if entry == nil {
+						break
+					}
+				}
+				if entry.IsOutputSpent(op.Index) {
+					continue
+				}
+				if entry.IsCoinBase() &&
+					nextHeight-entry.BlockHeight() < maturity {
+					continue
+				}
+				if s.server.txMemPool.CheckSpend(op) != nil {
+					continue
+				}
+
+				candidates = append(candidates, fundingCandidate{
+					outPoint: op,
+					amount:   entry.AmountByIndex(op.Index),
+				})
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].amount > candidates[j].amount
+	})
+	return candidates, nil
+}
+
+// handleFundRawTransaction handles fundrawtransaction commands.  Inputs
+// spending outputs paying to the passed addresses are added to the transaction
+// until they cover its outputs and the fee, and any change is paid to the
+// passed change address.
+func handleFundRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// Respond with an error if the address index is not enabled.
+	if s.server.addrIndex == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Address index must be enabled (--addrindex)",
+		}
+	}
+
+	c := cmd.(*btcjson.FundRawTransactionCmd)
+
+	// Deserialize the transaction.
+	hexStr := c.HexTx
+	if len(hexStr)%2 != 0 {
+		hexStr = "0" + hexStr
+	}
+	serializedTx, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hexStr)
+	}
+	var mtx wire.MsgTx
+	err = mtx.Deserialize(bytes.NewReader(serializedTx))
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDeserialization,
+			Message: "TX decode failed: " + err.Error(),
+		}
+	}
+
+	// Decode the funding and change addresses.  Only Prova addresses can be
+	// funded from or paid to.
+	decodeAddr := func(encodedAddr string) (provautil.Address, error) {
+		addr, err := provautil.DecodeAddress(encodedAddr,
+			s.server.chainParams)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "Invalid address or key: " + err.Error(),
+			}
+		}
+		if _, ok := addr.(*provautil.AddressProva); !ok ||
+			!addr.IsForNet(s.server.chainParams) {
+
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "Invalid address: " + encodedAddr +
+					" is not a prova address for this network",
+			}
+		}
+		return addr, nil
+	}
+	addrs := make([]provautil.Address, 0, len(c.Addresses))
+	for _, encodedAddr := range c.Addresses {
+		addr, err := decodeAddr(encodedAddr)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, addr)
+	}
+	changeAddr, err := decodeAddr(c.ChangeAddress)
+	if err != nil {
+		return nil, err
+	}
+	changeScript, err := txscript.PayToAddrScript(changeAddr)
+	if err != nil {
+		context := "Failed to generate change script"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	feeRate, dustRelayFee := s.server.txMemPool.RelayFees()
+	if c.FeeRate != nil {
+		feeRate, err = provautil.NewAmount(*c.FeeRate)
+		if err != nil || feeRate < 0 {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: "Invalid fee rate",
+			}
+		}
+	}
+	calcFee := func(size int) int64 {
+		return int64(size) * int64(feeRate) / 1000
+	}
+
+	// Sum the outputs to fund and the inputs the transaction already
+	// spends.  Existing inputs which have not been signed yet are assumed
+	// to be signed like any other Prova input.
+	var outputTotal, inputTotal int64
+	for _, txOut := range mtx.TxOut {
+		outputTotal += txOut.Value
+	}
+	size := mtx.SerializeSize()
+	if len(mtx.TxIn) != 0 {
+		utxoView, err := s.server.blockManager.chain.FetchUtxoView(
+			provautil.NewTx(&mtx))
+		if err != nil {
+			context := "Failed to fetch inputs"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		for _, txIn := range mtx.TxIn {
+			prevOut := &txIn.PreviousOutPoint
+			entry := utxoView.LookupEntry(&prevOut.Hash)
+			if entry == nil || entry.IsOutputSpent(prevOut.Index) {
+				return nil, &btcjson.RPCError{
+					Code: btcjson.ErrRPCInvalidParameter,
+					Message: fmt.Sprintf("Input %v is not an "+
+						"unspent output", prevOut),
+				}
+			}
+			inputTotal += entry.AmountByIndex(prevOut.Index)
+			if len(txIn.SignatureScript) == 0 {
+				size += fundRawTxInputSize - fundRawTxEmptyInputSize
+			}
+		}
+	}
+
+	candidates, err := fetchFundingCandidates(s, addrs)
+	if err != nil {
+		context := "Failed to load unspent outputs"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	// Select inputs largest first until they cover the outputs and the fee
+	// of the transaction including a change output.
+	changeSize := wire.NewTxOut(0, changeScript).SerializeSize()
+	for _, candidate := range candidates {
+		if inputTotal >= outputTotal+calcFee(size+changeSize) {
+			break
+		}
+		op := candidate.outPoint
+		mtx.AddTxIn(wire.NewTxIn(&op, nil))
+		inputTotal += candidate.amount
+		size += fundRawTxInputSize
+	}
+	fee := calcFee(size + changeSize)
+	change := inputTotal - outputTotal - fee
+	if change < 0 {
+		// The transaction may still be funded if no change output is
+		// needed.
+		fee = calcFee(size)
+		if inputTotal < outputTotal+fee {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCWalletInsufficientFunds,
+				Message: "Insufficient funds",
+			}
+		}
+		change = 0
+	}
+
+	// Pay the change to the change address unless it is too small to be
+	// worth spending, in which case it is left as part of the fee.
+	changePos := int32(-1)
+	changeOut := wire.NewTxOut(change, changeScript)
+	if change > 0 && !mempool.IsDust(changeOut, dustRelayFee) {
+		mtx.AddTxOut(changeOut)
+		changePos = int32(len(mtx.TxOut) - 1)
+	} else {
+		fee = inputTotal - outputTotal
+	}
+
+	mtxHex, err := messageToHex(&mtx)
+	if err != nil {
+		return nil, err
+	}
+	return &btcjson.FundRawTransactionResult{
+		Hex:       mtxHex,
+		Fee:       provautil.Amount(fee).ToDMG(),
+		ChangePos: changePos,
+	}, nil
+}
+
+// handleGenerate handles generate commands.
+func handleGenerate(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// Respond with an error if there are no addresses to pay the
+	// created blocks to.
+	if len(cfg.miningAddrs) == 0 {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInternal.Code,
+			Message: "No payment addresses specified " +
+				"via --miningaddr",
+		}
+	}
+
+	// Respond with an error if there's virtually 0 chance of mining a block
+	// with the CPU.
+	params := s.server.chainParams
+	if !s.server.chainParams.GenerateSupported {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCDifficulty,
+			Message: fmt.Sprintf("No support for `generate` on "+
+				"the current network, %s, as it's unlikely to "+
+				"be possible to main a block with the CPU.",
+				params.Net),
+		}
+	}
+
+	c := cmd.(*btcjson.GenerateCmd)
+
+	// Respond with an error if the client is requesting 0 blocks to be generated.
+	if c.NumBlocks == 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInternal.Code,
+			Message: "Please request a nonzero number of blocks to generate.",
+		}
+	}
+
+	// Check that there are validate keys set
+	if len(s.server.cpuMiner.ValidateKeys()) == 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInternal.Code,
+			Message: "No validate keys provided via setvalidatekeys",
+		}
+	}
+
+	// Create a reply
+	reply := make([]string, c.NumBlocks)
+
+	blockHashes, err := s.server.cpuMiner.GenerateNBlocks(c.NumBlocks)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInternal.Code,
+			Message: err.Error(),
+		}
+	}
+
+	// Mine the correct number of blocks, assigning the hex representation of the
+	// hash of each one to its place in the reply.
+	for i, hash := range blockHashes {
+		reply[i] = hash.String()
+	}
+
+	return reply, nil
+}
+
+// handleGetAddedNodeInfo handles getaddednodeinfo commands.
+func handleGetAddedNodeInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetAddedNodeInfoCmd)
+
+	// Retrieve a list of persistent (added) peers from the bitcoin server
+	// and filter the list of peers per the specified address (if any).
+	peers := s.server.AddedNodeInfo()
+	if c.Node != nil {
+		node := *c.Node
+		found := false
+		for i, peer := range peers {
+			if peer.Addr() == node {
+				peers = peers[i : i+1]
+				found = true
+			}
+		}
+		if !found {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCClientNodeNotAdded,
+				Message: "Node has not been added",
+			}
+		}
+	}
+
+	// Without the dns flag, the result is just a slice of the addresses as
+	// strings.
+	if !c.DNS {
+		results := make([]string, 0, len(peers))
+		for _, peer := range peers {
+			results = append(results, peer.Addr())
+		}
+		return results, nil
+	}
+
+	// With the dns flag, the result is an array of JSON objects which
+	// include the result of DNS lookups for each peer.
+	results := make([]*btcjson.GetAddedNodeInfoResult, 0, len(peers))
+	for _, peer := range peers {
+		// Set the "address" of the peer which could be an ip address
+		// or a domain name.
+		var result btcjson.GetAddedNodeInfoResult
+		result.AddedNode = peer.Addr()
+		result.Connected = btcjson.Bool(peer.Connected())
+
+		// Split the address into host and port portions so we can do
+		// a DNS lookup against the host.  When no port is specified in
+		// the address, just use the address as the host.
+		host, _, err := net.SplitHostPort(peer.Addr())
+		if err != nil {
+			host = peer.Addr()
+		}
+
+		var ipList []string
+		switch {
+		case net.ParseIP(host) != nil, strings.HasSuffix(host, ".onion"):
+			ipList = make([]string, 1)
+			ipList[0] = host
+		default:
+			// Do a DNS lookup for the address.  If the lookup fails, just
+			// use the host.
+			ips, err := btcdLookup(host)
+			if err != nil {
+				ipList = make([]string, 1)
+				ipList[0] = host
+				break
+			}
+			ipList = make([]string, 0, len(ips))
+			for _, ip := range ips {
+				ipList = append(ipList, ip.String())
+			}
+		}
+
+		// Add the addresses and connection info to the result.
+		addrs := make([]btcjson.GetAddedNodeInfoResultAddr, 0, len(ipList))
+		for _, ip := range ipList {
+			var addr btcjson.GetAddedNodeInfoResultAddr
+			addr.Address = ip
+			addr.Connected = "false"
+			if ip == host && peer.Connected() {
+				addr.Connected = directionString(peer.Inbound())
+			}
+			addrs = append(addrs, addr)
+		}
+		result.Addresses = &addrs
+		results = append(results, &result)
+	}
+	return results, nil
+}
+
+// addressHistoryTx houses a transaction of the history of an address along
+// with the header of the block it is contained in, which is nil for a
+// transaction in the memory pool.
+type addressHistoryTx struct {
+	tx     *provautil.Tx
+	header *wire.BlockHeader
+	added  time.Time // Only set when transaction is in the mempool.
+}
+
+// pkScriptPaysAddress returns whether the passed public key script pays the
+// address with the passed public key hash.
+func pkScriptPaysAddress(s *rpcServer, pkScript []byte, pkHash []byte) bool {
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript,
+		s.server.chainParams)
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if bytes.Equal(addr.ScriptAddress(), pkHash) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleGetAddressHistory implements the getaddresshistory command.  The
+// history consists of the confirmed transactions of the address followed by
+// those in the memory pool, and is paged newest first.  The running balance of
+// an entry is the balance of the address after its transaction.
+func handleGetAddressHistory(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// Respond with an error if the address index is not enabled.
+	addrIndex := s.server.addrIndex
+	if addrIndex == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Address index must be enabled (--addrindex)",
+		}
+	}
+
+	c := cmd.(*btcjson.GetAddressHistoryCmd)
+	addr, err := provautil.DecodeAddress(c.Address, s.server.chainParams)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + err.Error(),
+		}
+	}
+	page := *c.Page
+	if page < 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Page must not be negative",
+		}
+	}
+	pageSize := *c.PageSize
+	if pageSize < 1 || pageSize > maxAddressHistoryPageSize {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Page size must be between 1 and %d",
+				maxAddressHistoryPageSize),
+		}
+	}
+
+	// Load the confirmed transactions of the address oldest first, along
+	// with the headers of their blocks.
+	var history []addressHistoryTx
+	headers := make(map[chainhash.Hash]*wire.BlockHeader)
+	err = s.server.db.View(func(dbTx database.Tx) error {
+		regions, _, err := addrIndex.TxRegionsForAddress(dbTx, addr, 0,
+			math.MaxUint32, false)
+		if err != nil {
+			return err
+		}
+
+		// Load the raw transaction bytes from the database.
+		serializedTxns, err := dbTx.FetchBlockRegions(regions)
+		if err != nil {
+			return err
+		}
+
+		for i, serializedTx := range serializedTxns {
+			tx, err := provautil.NewTxFromBytes(serializedTx)
+			if err != nil {
+				return err
+			}
+			header, ok := headers[*regions[i].Hash]
+			if !ok {
+				blockHeader, err := s.chain.FetchHeader(regions[i].Hash)
+				if err != nil {
+					return err
+				}
+				header = &blockHeader
+				headers[*regions[i].Hash] = header
+			}
+			history = append(history, addressHistoryTx{
+				tx:     tx,
+				header: header,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		context := "Failed to load address index entries"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	numConfirmed := len(history)
+
+	// Add the transactions in the memory pool in the order they were
+	// added to it.
+	mpTxns := addrIndex.UnconfirmedTxnsForAddress(addr)
+	if len(mpTxns) > 0 {
+		added := make(map[chainhash.Hash]time.Time)
+		for _, desc := range s.server.txMemPool.TxDescs() {
+			added[*desc.Tx.Hash()] = desc.Added
+		}
+		for _, tx := range mpTxns {
+			history = append(history, addressHistoryTx{
+				tx:    tx,
+				added: added[*tx.Hash()],
+			})
+		}
+		mempoolHistory := history[numConfirmed:]
+		sort.SliceStable(mempoolHistory, func(i, j int) bool {
+			return mempoolHistory[i].added.Before(
+				mempoolHistory[j].added)
+		})
+	}
+
+	// Collect the outputs paying the address, so the inputs spending them
+	// can be debited regardless of the order of the transactions.
+	pkHash := addr.ScriptAddress()
+	outputs := make(map[wire.OutPoint]int64)
+	for _, htx := range history {
+		for i, txOut := range htx.tx.MsgTx().TxOut {
+			if pkScriptPaysAddress(s, txOut.PkScript, pkHash) {
+				outPoint := wire.OutPoint{Hash: *htx.tx.Hash(),
+					Index: uint32(i)}
+				outputs[outPoint] = txOut.Value
+			}
+		}
+	}
+
+	// Compute the net change and running balance of each transaction.
+	amounts := make([]int64, len(history))
+	balances := make([]int64, len(history))
+	var balance, confirmedBalance int64
+	for i, htx := range history {
+		msgTx := htx.tx.MsgTx()
+		for _, txIn := range msgTx.TxIn {
+			amounts[i] -= outputs[txIn.PreviousOutPoint]
+		}
+		for j := range msgTx.TxOut {
+			outPoint := wire.OutPoint{Hash: *htx.tx.Hash(),
+				Index: uint32(j)}
+			amounts[i] += outputs[outPoint]
+		}
+		balance += amounts[i]
+		balances[i] = balance
+		if i < numConfirmed {
+			confirmedBalance = balance
+		}
+	}
+
+	// Return the requested page of the history newest first.
+	best := s.chain.BestSnapshot()
+	entries := make([]btcjson.AddressHistoryEntry, 0, pageSize)
+	first := len(history) - 1 - page*pageSize
+	for i := first; i >= 0 && i > first-pageSize; i-- {
+		htx := &history[i]
+		entry := btcjson.AddressHistoryEntry{
+			TxID:    htx.tx.Hash().String(),
+			Amount:  provautil.Amount(amounts[i]).ToDMG(),
+			Balance: provautil.Amount(balances[i]).ToDMG(),
+		}
+		if htx.header != nil {
+			entry.BlockHash = htx.header.BlockHash().String()
+			entry.BlockHeight = htx.header.Height
+			entry.Confirmations = int64(best.Height) -
+				int64(htx.header.Height) + 1
+			entry.Time = htx.header.Timestamp.Unix()
+		} else {
+			entry.Time = htx.added.Unix()
+		}
+		entries = append(entries, entry)
+	}
+
+	return &btcjson.GetAddressHistoryResult{
+		Address:          c.Address,
+		Balance:          provautil.Amount(balance).ToDMG(),
+		ConfirmedBalance: provautil.Amount(confirmedBalance).ToDMG(),
+		TotalEntries:     len(history),
+		Page:             page,
+		PageSize:         pageSize,
+		Entries:          entries,
+	}, nil
+}
+
+// spendableAddressUtxo returns whether the outputs paying to the passed script
+// can be swept: the script must pay to an account which is not frozen, and
+// when a keyID is passed, the keyID must be able to co-sign spending it.
+func spendableAddressUtxo(s *rpcServer, pkScript []byte, keyID *uint32) (bool, error) {
+	pops, err := txscript.ParseScript(pkScript)
+	if err != nil {
+		return false, err
+	}
+	for _, pkHash := range txscript.ExtractPkHashes(pops) {
+		if s.chain.IsFrozen(pkHash) {
+			return false, nil
+		}
+	}
+	if keyID == nil {
+		return true, nil
+	}
+	keyIDs, err := txscript.ExtractKeyIDs(pops)
+	if err != nil {
+		return false, nil
+	}
+	for _, id := range keyIDs {
+		if id == btcec.KeyID(*keyID) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// handleGetAddressUtxos implements the getaddressutxos command.  It returns
+// the outputs paying to the passed addresses which have at least the minimum
+// number of confirmations and can be spent: they are unspent in the main
+// chain and in the memory pool, mature, and do not belong to a frozen
+// account.
+func handleGetAddressUtxos(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// Respond with an error if the address index is not enabled.
+	addrIndex := s.server.addrIndex
+	if addrIndex == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Address index must be enabled (--addrindex)",
+		}
+	}
+
+	c := cmd.(*btcjson.GetAddressUtxosCmd)
+	addrs := make([]provautil.Address, 0, len(c.Addresses))
+	for _, addrStr := range c.Addresses {
+		addr, err := provautil.DecodeAddress(addrStr,
+			s.server.chainParams)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "Invalid address or key: " + err.Error(),
+			}
+		}
+		if !addr.IsForNet(s.server.chainParams) {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "Invalid address: " + addrStr +
+					" is for the wrong network",
+			}
+		}
+		addrs = append(addrs, addr)
+	}
+
+	best := s.chain.BestSnapshot()
+	maturity := uint32(s.server.chainParams.CoinbaseMaturity)
+	seen := make(map[wire.OutPoint]struct{})
+	results := make([]btcjson.AddressUtxoResult, 0)
+	for _, addr := range addrs {
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			context := "Failed to create address script"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		spendable, err := spendableAddressUtxo(s, pkScript, c.KeyID)
+		if err != nil {
+			context := "Failed to parse address script"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		if !spendable {
+			continue
+		}
+
+		var serializedTxns [][]byte
+		err = s.server.db.View(func(dbTx database.Tx) error {
+			regions, err := addrIndex.BoundedTxRegionsForAddress(
+				dbTx, addr, 0, 1<<32-1)
+			if err != nil {
+				return err
+			}
+			serializedTxns, err = dbTx.FetchBlockRegions(regions)
+			return err
+		})
+		if err != nil {
+			context := "Failed to load address index entries"
+			return nil, internalRPCError(err.Error(), context)
+		}
+
+		for _, serializedTx := range serializedTxns {
+			var mtx wire.MsgTx
+			err := mtx.Deserialize(bytes.NewReader(serializedTx))
+			if err != nil {
+				context := "Failed to deserialize transaction"
+				return nil, internalRPCError(err.Error(), context)
+			}
+			txHash := mtx.TxHash()
+			var entry *blockchain.UtxoEntry
+			for i, txOut := range mtx.TxOut {
+				if !bytes.Equal(txOut.PkScript, pkScript) {
+					continue
+				}
+				op := wire.OutPoint{Hash: txHash, Index: uint32(i)}
+				if _, ok := seen[op]; ok {
+					continue
+				}
+				seen[op] = struct{}{}
+
+				if entry == nil {
+					entry, err = s.chain.FetchUtxoEntry(&txHash)
+					if err != nil {
+						context := "Failed to fetch utxo entry"
+						return nil, internalRPCError(err.Error(),
+							context)
+					}
+					if entry == nil {
+						break
+					}
+				}
+				if entry.IsOutputSpent(op.Index) {
+					continue
+				}
+				confirmations := int64(best.Height) -
+					int64(entry.BlockHeight()) + 1
+				if confirmations < int64(*c.MinConf) {
+					continue
+				}
+				if entry.IsCoinBase() &&
+					best.Height+1-entry.BlockHeight() < maturity {
+					continue
+				}
+				if s.server.txMemPool.CheckSpend(op) != nil {
+					continue
+				}
+
+				results = append(results, btcjson.AddressUtxoResult{
+					Address:      addr.EncodeAddress(),
+					TxID:         txHash.String(),
+					Vout:         op.Index,
+					ScriptPubKey: hex.EncodeToString(pkScript),
+					Amount: provautil.Amount(
+						entry.AmountByIndex(op.Index)).ToDMG(),
+					AssetID: blockchain.UtxoAssetID(entry,
+						s.server.chainParams),
+					Height:        entry.BlockHeight(),
+					Confirmations: confirmations,
+				})
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// handleGetSweepPlan implements the getsweepplan command.  It returns unsigned
+// transactions moving the unspent outputs referencing the old keyID to
+// scripts referencing the new keyID, along with the outputs which can not be
+// swept.
+func handleGetSweepPlan(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetSweepPlanCmd)
+
+	feeRate, dustRelayFee := s.server.txMemPool.RelayFees()
+	if c.FeeRate != nil {
+		var err error
+		feeRate, err = provautil.NewAmount(*c.FeeRate)
+		if err != nil || feeRate < 0 {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: "Invalid fee rate",
+			}
+		}
+	}
+	maxTxSize := cfg.MaxStdTxSize
+	if c.MaxTxSize != nil {
+		if *c.MaxTxSize <= 0 || *c.MaxTxSize > cfg.MaxStdTxSize {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCInvalidParameter,
+				Message: fmt.Sprintf("Maximum transaction size "+
+					"must be between 1 and %d",
+					cfg.MaxStdTxSize),
+			}
+		}
+		maxTxSize = *c.MaxTxSize
+	}
+
+	outPoints, err := s.chain.FetchKeyIDOutPoints(
+		[]btcec.KeyID{btcec.KeyID(c.OldKeyID)}, -1)
+	if err != nil {
+		context := "Failed to scan unspent outputs"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	// Outputs which are immature, frozen or already spent in the memory
+	// pool can not be swept now and are reported as skipped.
+	best := s.chain.BestSnapshot()
+	maturity := uint32(s.server.chainParams.CoinbaseMaturity)
+	skipped := make([]string, 0)
+	outputs := make([]sweep.Output, 0, len(outPoints))
+	for _, op := range outPoints {
+		entry, err := s.chain.FetchUtxoEntry(&op.Hash)
+		if err != nil {
+			context := "Failed to fetch utxo entry"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		if entry == nil || entry.IsOutputSpent(op.Index) {
+			continue
+		}
+		pkScript := entry.PkScriptByIndex(op.Index)
+		spendable, err := spendableAddressUtxo(s, pkScript, nil)
+		if err != nil || !spendable ||
+			(entry.IsCoinBase() &&
+				best.Height+1-entry.BlockHeight() < maturity) ||
+			s.server.txMemPool.CheckSpend(op) != nil {
+
+			skipped = append(skipped, op.String())
+			continue
+		}
+		outputs = append(outputs, sweep.Output{
+			OutPoint: op,
+			PkScript: pkScript,
+			Amount:   entry.AmountByIndex(op.Index),
+			AssetID:  blockchain.UtxoAssetID(entry, s.server.chainParams),
+		})
+	}
+
+	plan, err := sweep.NewPlan(outputs, &sweep.Config{
+		OldKeyID:     btcec.KeyID(c.OldKeyID),
+		NewKeyID:     btcec.KeyID(c.NewKeyID),
+		Params:       s.server.chainParams,
+		MaxTxSize:    maxTxSize,
+		FeeRate:      feeRate,
+		DustRelayFee: dustRelayFee,
+	})
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: err.Error(),
+		}
+	}
+	for _, output := range plan.Skipped {
+		skipped = append(skipped, output.OutPoint.String())
+	}
+
+	txs := make([]btcjson.SweepTxResult, 0, len(plan.Txs))
+	for _, tx := range plan.Txs {
+		mtxHex, err := messageToHex(tx.MsgTx)
+		if err != nil {
+			return nil, err
+		}
+		var amount int64
+		for _, txOut := range tx.MsgTx.TxOut {
+			amount += txOut.Value
+		}
+		txs = append(txs, btcjson.SweepTxResult{
+			Hex:     mtxHex,
+			AssetID: tx.AssetID,
+			Inputs:  len(tx.Inputs),
+			Amount:  provautil.Amount(amount).ToDMG(),
+			Fee:     provautil.Amount(tx.Fee).ToDMG(),
+		})
+	}
+
+	return &btcjson.GetSweepPlanResult{
+		Txs:     txs,
+		Skipped: skipped,
+	}, nil
+}
+
+// handleGetKeyIDCensus implements the getkeyidcensus command.  It returns the
+// number and value of the unspent outputs referencing each key ID found by the
+// last key ID census, and starts a new census in the background when a rescan
+// is requested.  Provisioned ASP key IDs without outputs are reported with no
+// outputs, as is the requested key ID.
+func handleGetKeyIDCensus(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetKeyIDCensusCmd)
+
+	if *c.Rescan {
+		s.server.keyIDCensus.request(time.Now())
+	}
+	status := s.server.keyIDCensus.status()
+	result := &btcjson.GetKeyIDCensusResult{
+		Running: status.running,
+		KeyIDs:  make([]btcjson.KeyIDUsageResult, 0),
+	}
+	if status.lastErr != nil {
+		result.Error = status.lastErr.Error()
+	}
+	if status.last == nil {
+		return result, nil
+	}
+	result.Hash = status.last.Hash.String()
+	result.Height = status.last.Height
+	result.Time = status.lastTime.Unix()
+	result.Duration = int64(status.lastDuration / time.Millisecond)
+
+	wanted := func(keyID btcec.KeyID) bool {
+		return c.KeyID == nil || keyID == btcec.KeyID(*c.KeyID)
+	}
+	provisioned := s.chain.KeyIDs()
+	used := make(map[btcec.KeyID]struct{})
+	for _, usage := range status.last.Usage {
+		if !wanted(usage.KeyID) {
+			continue
+		}
+		used[usage.KeyID] = struct{}{}
+		_, isProvisioned := provisioned[usage.KeyID]
+		result.KeyIDs = append(result.KeyIDs, btcjson.KeyIDUsageResult{
+			KeyID:       uint32(usage.KeyID),
+			Provisioned: isProvisioned,
+			AssetID:     usage.AssetID,
+			Outputs:     usage.Outputs,
+			Amount:      provautil.Amount(usage.Amount).ToDMG(),
+		})
+	}
+	for keyID := range provisioned {
+		if _, ok := used[keyID]; ok || !wanted(keyID) {
+			continue
+		}
+		used[keyID] = struct{}{}
+		result.KeyIDs = append(result.KeyIDs, btcjson.KeyIDUsageResult{
+			KeyID:       uint32(keyID),
+			Provisioned: true,
+		})
+	}
+	if c.KeyID != nil && len(used) == 0 {
+		result.KeyIDs = append(result.KeyIDs, btcjson.KeyIDUsageResult{
+			KeyID: *c.KeyID,
+		})
+	}
+	sort.Slice(result.KeyIDs, func(i, j int) bool {
+		a, b := &result.KeyIDs[i], &result.KeyIDs[j]
+		if a.KeyID != b.KeyID {
+			return a.KeyID < b.KeyID
+		}
+		return a.AssetID < b.AssetID
+	})
+
+	return result, nil
+}
+
+// handleGetAddressTxIds implements the getaddresstxids command.
+func handleGetAddressTxIds(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// Respond with an error if the address index is not enabled.
+	addrIndex := s.server.addrIndex
+	if addrIndex == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Address index must be enabled (--addrindex)",
+		}
+	}
+
+	c := cmd.(*btcjson.GetAddressTxIdsCmd)
+
+	start := uint32(0)
+	if c.Request.Start > 0 {
+		start = c.Request.Start + 1
+	}
+
+	end := uint32(1<<32 - 1)
+	if c.Request.End > 0 {
+		end = c.Request.End + 1
+	}
+
+	if start > end {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "End height must not be less than the start height.",
+		}
+	}
+
+	// Attempt to decode the supplied addresses.
+	addressTxns := make([]retrievedTx, 0)
+	for _, address := range c.Request.Addresses {
+		addr, err := provautil.DecodeAddress(address, s.server.chainParams)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "Invalid address or key: " + err.Error(),
+			}
+		}
+
+		err = s.server.db.View(func(dbTx database.Tx) error {
+			regions, err := addrIndex.BoundedTxRegionsForAddress(
+				dbTx, addr, start, end)
+			if err != nil {
+				return err
+			}
+
+			// Load the raw transaction bytes from the database.
+			serializedTxns, err := dbTx.FetchBlockRegions(regions)
+			if err != nil {
+				return err
+			}
+
+			// Add the transaction and the hash of the block it is
+			// contained in to the list.
+			for i, serializedTx := range serializedTxns {
+				addressTxns = append(addressTxns, retrievedTx{
+					txBytes: serializedTx,
+					blkHash: regions[i].Hash,
+				})
+			}
+
+			return nil
+		})
+		if err != nil {
+			context := "Failed to load address index entries"
+			return nil, internalRPCError(err.Error(), context)
+		}
+
+	}
+
+	// Create a reply
+	reply := make([]string, len(addressTxns))
+
+	for i := range addressTxns {
+		// The deserialized transaction is needed, so deserialize the
+		// retrieved transaction if it's in serialized form (which will
+		// be the case when it was lookup up from the database).
+		// Otherwise, use the existing deserialized transaction.
+		rtx := &addressTxns[i]
+		var mtx *wire.MsgTx
+		if rtx.tx == nil {
+			// Deserialize the transaction.
+			mtx = new(wire.MsgTx)
+			err := mtx.Deserialize(bytes.NewReader(rtx.txBytes))
+			if err != nil {
+				context := "Failed to deserialize transaction"
+				return nil, internalRPCError(err.Error(),
+					context)
+			}
+		} else {
+			mtx = rtx.tx.MsgTx()
+		}
+		reply[i] = mtx.TxHash().String()
+	}
+
+	return reply, nil
+}
+
+// threadTipResults returns the passed tips of the admin threads in the order
+// of the threads.
+func threadTipResults(threadTips map[provautil.ThreadID]*wire.OutPoint) []btcjson.ThreadTipResult {
+	rootTip := threadTips[provautil.RootThread]
+	provisionTip := threadTips[provautil.ProvisionThread]
+	issueTip := threadTips[provautil.IssueThread]
+	return []btcjson.ThreadTipResult{
+		{
+			ID:       uint32(provautil.RootThread),
+			Name:     "root",
+			OutPoint: rootTip.String(),
+		},
+		{
+			ID:       uint32(provautil.ProvisionThread),
+			Name:     "provision",
+			OutPoint: provisionTip.String(),
+		},
+		{
+			ID:       uint32(provautil.IssueThread),
+			Name:     "issue",
+			OutPoint: issueTip.String(),
+		},
+	}
+}
+
+// handleGetAdminInfo implements the getadmininfo command.
+func handleGetAdminInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	best := s.chain.BestSnapshot()
+	adminKeySets := s.chain.AdminKeySets()
+	aspKeyIdMap := s.chain.KeyIDs()
+	threadTipObj := threadTipResults(s.chain.ThreadTips())
+	aspObj := make([]btcjson.ASPKeyIdResult, len(aspKeyIdMap))
+	i := 0
+	for k, v := range aspKeyIdMap {
+		aspObj[i] = btcjson.ASPKeyIdResult{
+			KeyID:  uint32(k),
+			PubKey: hex.EncodeToString(v.SerializeCompressed()),
+		}
+		i++
+	}
+	issueDests := make([]string, 0, len(s.chain.IssueDests()))
+	for dest := range s.chain.IssueDests() {
+		keyIDs := []btcec.KeyID{
+			btcec.KeyIDFromAddressBuffer(dest[20:24]),
+			btcec.KeyIDFromAddressBuffer(dest[24:28]),
+		}
+		addr, err := provautil.NewAddressProva(dest[:20], keyIDs,
+			s.server.chainParams)
+		if err != nil {
+			return nil, internalRPCError(err.Error(),
+				"Failed to encode issuance destination")
+		}
+		issueDests = append(issueDests, addr.EncodeAddress())
+	}
+	sort.Strings(issueDests)
+	assetSupplies := s.chain.AssetSupplies()
+	assetObj := make([]btcjson.AssetSupplyResult, 0, len(assetSupplies))
+	for assetID, supply := range assetSupplies {
+		assetObj = append(assetObj, btcjson.AssetSupplyResult{
+			AssetID: assetID,
+			Supply:  supply,
+		})
+	}
+	sort.Slice(assetObj, func(i, j int) bool {
+		return assetObj[i].AssetID < assetObj[j].AssetID
+	})
+	delegates := s.chain.Delegates()
+	delegateObj := make([]btcjson.DelegateResult, 0, len(delegates))
+	for pubKey, delegation := range delegates {
+		delegateObj = append(delegateObj, btcjson.DelegateResult{
+			PubKey:   hex.EncodeToString(pubKey[:]),
+			Scope:    uint8(delegation.Scope),
+			MinKeyID: uint32(delegation.MinKeyID),
+			MaxKeyID: uint32(delegation.MaxKeyID),
+		})
+	}
+	sort.Slice(delegateObj, func(i, j int) bool {
+		return delegateObj[i].PubKey < delegateObj[j].PubKey
+	})
+	result := &btcjson.GetAdminInfoResult{
+		Hash:          best.Hash.String(),
+		Height:        best.Height,
+		ThreadTips:    threadTipObj,
+		TotalSupply:   s.chain.TotalSupply(),
+		AssetSupplies: assetObj,
+		LastKeyID:     uint32(s.chain.LastKeyID()),
+		RootKeys:      adminKeySets[btcec.RootKeySet].ToStringArray(),
+		ProvisionKeys: adminKeySets[btcec.ProvisionKeySet].ToStringArray(),
+		IssueKeys:     adminKeySets[btcec.IssueKeySet].ToStringArray(),
+		ValidateKeys:  adminKeySets[btcec.ValidateKeySet].ToStringArray(),
+		ASPKeys:       aspObj,
+		IssueDests:    issueDests,
+		Delegates:     delegateObj,
+	}
+	return result, nil
+}
+
+// watchLabelSelected returns whether the passed label of a watched address is
+// selected by the passed account parameter of a watch-only RPC.  A missing
+// account and "*" select all labels.
+func watchLabelSelected(account *string, label string) bool {
+	return account == nil || *account == "*" || *account == label
+}
+
+// watchedAddress returns the encoded address the passed public key script of a
+// watched output pays.
+func watchedAddress(s *rpcServer, pkScript []byte) string {
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript,
+		s.server.chainParams)
+	if err != nil || len(addrs) == 0 {
+		return ""
+	}
+	return addrs[0].EncodeAddress()
+}
+
+// announcementResult converts the passed governance announcement into the
+// result returned by the getannouncements command and the announcement
+// notification.
+func announcementResult(ann *announcement) (*btcjson.AnnouncementResult, error) {
+	var buf bytes.Buffer
+	if err := ann.msg.BtcEncode(&buf, wire.AnnounceVersion); err != nil {
+		return nil, err
+	}
+	signers := make([]string, 0, len(ann.signers))
+	for _, pubKey := range ann.signers {
+		signers = append(signers,
+			hex.EncodeToString(pubKey.SerializeCompressed()))
+	}
+	return &btcjson.AnnouncementResult{
+		Hash:       ann.hash.String(),
+		Category:   ann.msg.Category.String(),
+		Time:       ann.msg.Timestamp.Unix(),
+		Expiration: ann.msg.Expiration.Unix(),
+		Message:    ann.msg.Message,
+		KeySet:     ann.keySet.String(),
+		Signers:    signers,
+		Hex:        hex.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// handleGetAnnouncements implements the getannouncements command.  It returns
+// the unexpired governance announcements known to the node, newest first.
+func handleGetAnnouncements(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	announcements := s.server.Announcements()
+	results := make([]btcjson.AnnouncementResult, 0, len(announcements))
+	for _, ann := range announcements {
+		result, err := announcementResult(ann)
+		if err != nil {
+			context := "Failed to serialize announcement"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+// handleGetBalance implements the getbalance command for the addresses watched
+// by the watch-only index.  The account selects the watched addresses with that
+// label.
+func handleGetBalance(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	watchIndex := s.server.watchIndex
+	if watchIndex == nil {
+		return nil, rpcNoWatchIndexError()
+	}
+
+	c := cmd.(*btcjson.GetBalanceCmd)
+	minConf := 1
+	if c.MinConf != nil {
+		minConf = *c.MinConf
+	}
+	outputs, err := watchIndex.UnspentOutputs()
+	if err != nil {
+		context := "Failed to load watched outputs"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	best := s.chain.BestSnapshot()
+	var balance provautil.Amount
+	for _, output := range outputs {
+		confirmations := int64(best.Height) - int64(output.Height) + 1
+		if confirmations < int64(minConf) ||
+			!watchLabelSelected(c.Account, output.Label) {
+
+			continue
+		}
+		balance += provautil.Amount(output.Amount)
+	}
+
+	return balance.ToDMG(), nil
+}
+
+// handleGetBestBlock implements the getbestblock command.
+func handleGetBestBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// All other "get block" commands give either the height, the
+	// hash, or both but require the block SHA.  This gets both for
+	// the best block.
+	best := s.chain.BestSnapshot()
+	result := &btcjson.GetBestBlockResult{
+		Hash:   best.Hash.String(),
+		Height: best.Height,
+	}
+	return result, nil
+}
+
+// handleGetBestBlockHash implements the getbestblockhash command.
+func handleGetBestBlockHash(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	best := s.chain.BestSnapshot()
+	return best.Hash.String(), nil
+}
+
+// getDifficultyRatio returns the proof-of-work difficulty as a multiple of the
+// minimum difficulty using the passed bits field from the header of a block.
+func getDifficultyRatio(bits uint32) float64 {
+	// The minimum difficulty is the max possible proof-of-work limit bits
+	// converted back to a number.  Note this is not the same as the proof of
+	// work limit directly because the block difficulty is encoded in a block
+	// with the compact form which loses precision.
+	max := blockchain.CompactToBig(activeNetParams.PowLimitBits)
+	target := blockchain.CompactToBig(bits)
+
+	difficulty := new(big.Rat).SetFrac(max, target)
+	outString := difficulty.FloatString(8)
+	diff, err := strconv.ParseFloat(outString, 64)
+	if err != nil {
+		rpcsLog.Errorf("Cannot get difficulty: %v", err)
+		return 0
+	}
+	return diff
+}
+
+// handleGetBlock implements the getblock command.
+func handleGetBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetBlockCmd)
+
+	// Load the raw block bytes from the database.
+	hash, err := chainhash.NewHashFromStr(c.Hash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Hash)
+	}
+	var blkBytes []byte
+	err = s.server.db.View(func(dbTx database.Tx) error {
+		var err error
+		blkBytes, err = dbTx.FetchBlock(hash)
+		return err
+	})
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCBlockNotFound,
+			Message: "Block not found",
+		}
+	}
+
+	// When the verbose flag isn't set, simply return the serialized block
+	// as a hex-encoded string.
+	if c.Verbose != nil && !*c.Verbose {
+		return hex.EncodeToString(blkBytes), nil
+	}
+
+	// The verbose flag is set, so generate the JSON object and return it.
+
+	// Deserialize the block.
+	blk, err := provautil.NewBlockFromBytes(blkBytes)
+	if err != nil {
+		context := "Failed to deserialize block"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	// Get the block height from chain.
+	blockHeight, err := s.chain.BlockHeightByHash(hash)
+	if err != nil {
+		context := "Failed to obtain block height"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	best := s.chain.BestSnapshot()
+
+	// Get next block hash unless there are none.
+	var nextHashString string
+	if blockHeight < best.Height {
+		nextHash, err := s.chain.BlockHashByHeight(blockHeight + 1)
+		if err != nil {
+			context := "No next block"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		nextHashString = nextHash.String()
+	}
+
+	blockHeader := &blk.MsgBlock().Header
+	blockReply := btcjson.GetBlockVerboseResult{
+		Hash:             c.Hash,
+		Version:          blockHeader.Version,
+		MerkleRoot:       blockHeader.MerkleRoot.String(),
+		PreviousHash:     blockHeader.PrevBlock.String(),
+		Nonce:            blockHeader.Nonce,
+		Time:             blockHeader.Timestamp.Unix(),
+		Confirmations:    uint64(1 + best.Height - blockHeight),
+		Height:           int64(blockHeader.Height),
+		Size:             int32(blockHeader.Size),
+		Bits:             strconv.FormatInt(int64(blockHeader.Bits), 16),
+		Difficulty:       getDifficultyRatio(blockHeader.Bits),
+		NextHash:         nextHashString,
+		ValidatingPubKey: blockHeader.ValidatingPubKey.String(),
+		Signature:        blockHeader.Signature.String(),
+	}
+
+	if c.VerboseTx == nil || !*c.VerboseTx {
+		transactions := blk.Transactions()
+		txNames := make([]string, len(transactions))
+		for i, tx := range transactions {
+			txNames[i] = tx.Hash().String()
+		}
+
+		blockReply.Tx = txNames
+	} else {
+		txns := blk.Transactions()
+		rawTxns := make([]btcjson.TxRawResult, len(txns))
+		for i, tx := range txns {
+			rawTxn, err := createTxRawResult(s.server.chainParams,
+				tx.MsgTx(), tx.Hash().String(), blockHeader,
+				hash.String(), blockHeight, best.Height)
+			if err != nil {
+				return nil, err
+			}
+			rawTxns[i] = *rawTxn
+		}
+		blockReply.RawTx = rawTxns
+	}
+
+	return blockReply, nil
+}
+
+// handleGetBlockAtHeightByTime implements the getblockatheightbytime command.
+func handleGetBlockAtHeightByTime(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetBlockAtHeightByTimeCmd)
+
+	blockTime, err := s.chain.BlockAtTime(time.Unix(c.Time, 0))
+	if err != nil {
+		context := "Failed to find block by time"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	if blockTime == nil {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCOutOfRange,
+			Message: "Time is before the median time past of the " +
+				"genesis block",
+		}
+	}
+	return &btcjson.GetBlockAtHeightByTimeResult{
+		Hash:       blockTime.Hash.String(),
+		Height:     blockTime.Height,
+		Time:       blockTime.Timestamp.Unix(),
+		MedianTime: blockTime.MedianTime.Unix(),
+	}, nil
+}
+
+// handleGetBlockChainInfo implements the getblockchaininfo command.
+func handleGetBlockChainInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// The headers of the stored blocks a reindex rebuilds the chain state
+	// from are already known, so the progress is reported against them.
+	best := s.chain.BestSnapshot()
+	headers := best.Height
+	reindexHeight, reindexing := s.chain.ReindexTarget()
+	if reindexing && reindexHeight > headers {
+		headers = reindexHeight
+	}
+	progress := 1.0
+	if headers > 0 {
+		progress = float64(best.Height) / float64(headers)
+	}
+
+	// The optional indexes are synced once they are caught up to the best
+	// block.
+	indexes := []btcjson.IndexTipResult{}
+	if s.server.indexManager != nil {
+		tips, err := s.server.indexManager.Tips()
+		if err != nil {
+			context := "Failed to obtain index tips"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		for _, tip := range tips {
+			indexes = append(indexes, btcjson.IndexTipResult{
+				Name:   tip.Name,
+				Hash:   tip.Hash.String(),
+				Height: tip.Height,
+				Synced: tip.Hash == *best.Hash,
+			})
+		}
+	}
+
+	// Blocks are never pruned, so the chain is always complete.
+	unknownRulesStatus := s.chain.UnknownRules()
+	unknownRules := unknownRulesResult(&unknownRulesStatus)
+	return &btcjson.GetBlockChainInfoResult{
+		Chain:                s.server.chainParams.Name,
+		Blocks:               int32(best.Height),
+		Headers:              int32(headers),
+		BestBlockHash:        best.Hash.String(),
+		Difficulty:           getDifficultyRatio(best.Bits),
+		VerificationProgress: progress,
+		ChainWork:            fmt.Sprintf("%064x", best.WorkSum),
+		Reindexing:           reindexing,
+		KeyStateHash:         s.chain.KeyStateHash().String(),
+		ThreadTips:           threadTipResults(s.chain.ThreadTips()),
+		LastKeyID:            uint32(s.chain.LastKeyID()),
+		Pruned:               false,
+		Indexes:              indexes,
+		UnknownRules:         unknownRules,
+		Warnings:             unknownRulesWarning(unknownRules),
+	}, nil
+}
+
+// unknownRulesResult converts the passed unknown rules status of the main chain
+// into the JSON result of the getblockchaininfo command and the unknownrules
+// notification.
+func unknownRulesResult(status *blockchain.UnknownRulesStatus) btcjson.UnknownRulesResult {
+	return btcjson.UnknownRulesResult{
+		State:      status.State.String(),
+		Height:     int32(status.Height),
+		Blocks:     status.Blocks,
+		Window:     status.Window,
+		Threshold:  status.Threshold,
+		MaxVersion: status.MaxVersion,
+	}
+}
+
+// unknownRulesWarning returns the warning operators should act on for the
+// passed unknown rules status, which is empty when the node knows the rules of
+// the recent blocks.
+func unknownRulesWarning(unknownRules btcjson.UnknownRulesResult) string {
+	switch unknownRules.State {
+	case blockchain.UnknownRulesSignaled.String():
+		return fmt.Sprintf("%d of the last %d blocks signal unknown "+
+			"consensus rules, which are enforced from %d of them: "+
+			"upgrade the node", unknownRules.Blocks,
+			unknownRules.Window, unknownRules.Threshold)
+	case blockchain.UnknownRulesActive.String():
+		return fmt.Sprintf("unknown consensus rules are enforced by %d "+
+			"of the last %d blocks: the node cannot fully validate "+
+			"the chain and does not follow blocks using them until "+
+			"it is upgraded", unknownRules.Blocks,
+			unknownRules.Window)
+	}
+	return ""
+}
+
+// handleGetBlockCount implements the getblockcount command.
+func handleGetBlockCount(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	best := s.chain.BestSnapshot()
+	return int64(best.Height), nil
+}
+
+// handleGetBlockHash implements the getblockhash command.
+func handleGetBlockHash(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetBlockHashCmd)
+	hash, err := s.chain.BlockHashByHeight(uint32(c.Index))
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCOutOfRange,
+			Message: "Block number out of range",
+		}
+	}
+
+	return hash.String(), nil
+}
+
+// handleGetBlockHeader implements the getblockheader command.
+func handleGetBlockHeader(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetBlockHeaderCmd)
+
+	// Fetch the header from chain.
+	hash, err := chainhash.NewHashFromStr(c.Hash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Hash)
+	}
+	blockHeader, err := s.chain.FetchHeader(hash)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCBlockNotFound,
+			Message: "Block not found",
+		}
+	}
+
+	// When the verbose flag isn't set, simply return the serialized block
+	// header as a hex-encoded string.
+	if c.Verbose != nil && !*c.Verbose {
+		var headerBuf bytes.Buffer
+		err := blockHeader.Serialize(&headerBuf)
+		if err != nil {
+			context := "Failed to serialize block header"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		return hex.EncodeToString(headerBuf.Bytes()), nil
+	}
+
+	// The verbose flag is set, so generate the JSON object and return it.
+
+	// Get the block height from chain.
+	blockHeight, err := s.chain.BlockHeightByHash(hash)
+	if err != nil {
+		context := "Failed to obtain block height"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	best := s.chain.BestSnapshot()
+	blockWork, err := s.chain.BlockWork(hash)
+	if err != nil {
+		context := "Failed to obtain block work"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	// Get next block hash unless there are none.
+	var nextHashString string
+	if blockHeight < best.Height {
+		nextHash, err := s.chain.BlockHashByHeight(blockHeight + 1)
+		if err != nil {
+			context := "No next block"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		nextHashString = nextHash.String()
+	}
+
+	blockHeaderReply := btcjson.GetBlockHeaderVerboseResult{
+		Hash:             c.Hash,
+		Confirmations:    uint64(1 + best.Height - blockHeight),
+		Height:           int32(blockHeader.Height),
+		Version:          blockHeader.Version,
+		MerkleRoot:       blockHeader.MerkleRoot.String(),
+		NextHash:         nextHashString,
+		PreviousHash:     blockHeader.PrevBlock.String(),
+		Nonce:            uint64(blockHeader.Nonce),
+		Time:             blockHeader.Timestamp.Unix(),
+		Bits:             strconv.FormatInt(int64(blockHeader.Bits), 16),
+		Difficulty:       getDifficultyRatio(blockHeader.Bits),
+		Work:             fmt.Sprintf("%064x", blockWork.Work),
+		ChainWork:        fmt.Sprintf("%064x", blockWork.WorkSum),
+		Signature:        blockHeader.Signature.String(),
+		ValidatingPubKey: blockHeader.ValidatingPubKey.String(),
+	}
+	return blockHeaderReply, nil
+}
+
+// handleGetBlockPropagation implements the getblockpropagation command.
+func handleGetBlockPropagation(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetBlockPropagationCmd)
+
+	count := *c.Count
+	if count < 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Count must not be negative",
+		}
+	}
+	return s.server.BlockPropagation(count), nil
+}
+
+// encodeTemplateID encodes the passed details into an ID that can be used to
+// uniquely identify a block template.
+func encodeTemplateID(prevHash *chainhash.Hash, lastGenerated time.Time) string {
+	return fmt.Sprintf("%s-%d", prevHash.String(), lastGenerated.Unix())
+}
+
+// decodeTemplateID decodes an ID that is used to uniquely identify a block
+// template.  This is mainly used as a mechanism to track when to update clients
+// that are using long polling for block templates.  The ID consists of the
+// previous block hash for the associated template and the time the associated
+// template was generated.
+func decodeTemplateID(templateID string) (*chainhash.Hash, int64, error) {
+	fields := strings.Split(templateID, "-")
+	if len(fields) != 2 {
+		return nil, 0, errors.New("invalid longpollid format")
+	}
+
+	prevHash, err := chainhash.NewHashFromStr(fields[0])
+	if err != nil {
+		return nil, 0, errors.New("invalid longpollid format")
+	}
+	lastGenerated, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, 0, errors.New("invalid longpollid format")
+	}
+
+	return prevHash, lastGenerated, nil
+}
+
+// notifyLongPollers notifies any channels that have been registered to be
+// notified when block templates are stale.
+//
+// This function MUST be called with the state locked.
+func (state *gbtWorkState) notifyLongPollers(latestHash *chainhash.Hash, lastGenerated time.Time) {
+	// Notify anything that is waiting for a block template update from a
+	// hash which is not the hash of the tip of the best chain since their
+	// work is now invalid.
+	for hash, channels := range state.notifyMap {
+		if !hash.IsEqual(latestHash) {
+			for _, c := range channels {
+				close(c)
+			}
+			delete(state.notifyMap, hash)
+		}
+	}
+
+	// Return now if the provided last generated timestamp has not been
+	// initialized.
+	if lastGenerated.IsZero() {
+		return
+	}
+
+	// Return now if there is nothing registered for updates to the current
+	// best block hash.
+	channels, ok := state.notifyMap[*latestHash]
+	if !ok {
+		return
+	}
+
+	// Notify anything that is waiting for a block template update from a
+	// block template generated before the most recently generated block
+	// template.
+	lastGeneratedUnix := lastGenerated.Unix()
+	for lastGen, c := range channels {
+		if lastGen < lastGeneratedUnix {
+			close(c)
+			delete(channels, lastGen)
+		}
+	}
+
+	// Remove the entry altogether if there are no more registered
+	// channels.
+	if len(channels) == 0 {
+		delete(state.notifyMap, *latestHash)
+	}
+}
+
+// NotifyBlockConnected uses the newly-connected block to notify any long poll
+// clients with a new block template when their existing block template is
+// stale due to the newly connected block.
+func (state *gbtWorkState) NotifyBlockConnected(blockHash *chainhash.Hash) {
+	go func() {
+		state.Lock()
+		defer state.Unlock()
+
+		state.notifyLongPollers(blockHash, state.lastTxUpdate)
+	}()
+}
+
+// discardTemplate drops the current block template so a new one is generated
+// for the next request, such as when the transactions it may include change
+// without a change to the memory pool.
+func (state *gbtWorkState) discardTemplate() {
+	state.Lock()
+	state.template = nil
+	state.Unlock()
+}
+
+// NotifyMempoolTx uses the new last updated time for the transaction memory
+// pool to notify any long poll clients with a new block template when their
+// existing block template is stale due to enough time passing and the contents
+// of the memory pool changing.
+func (state *gbtWorkState) NotifyMempoolTx(lastUpdated time.Time) {
+	go func() {
+		state.Lock()
+		defer state.Unlock()
+
+		// No need to notify anything if no block templates have been generated
+		// yet.
+		if state.prevHash == nil || state.lastGenerated.IsZero() {
+			return
+		}
+
+		if time.Now().After(state.lastGenerated.Add(time.Second *
+			gbtRegenerateSeconds)) {
+
+			state.notifyLongPollers(state.prevHash, lastUpdated)
+		}
+	}()
+}
+
+// templateUpdateChan returns a channel that will be closed once the block
+// template associated with the passed previous hash and last generated time
+// is stale.  The function will return existing channels for duplicate
+// parameters which allows multiple clients to wait for the same block template
+// without requiring a different channel for each client.
+//
+// This function MUST be called with the state locked.
+func (state *gbtWorkState) templateUpdateChan(prevHash *chainhash.Hash, lastGenerated int64) chan struct{} {
+	// Either get the current list of channels waiting for updates about
+	// changes to block template for the previous hash or create a new one.
+	channels, ok := state.notifyMap[*prevHash]
+	if !ok {
+		m := make(map[int64]chan struct{})
+		state.notifyMap[*prevHash] = m
+		channels = m
+	}
+
+	// Get the current channel associated with the time the block template
+	// was last generated or create a new one.
+	c, ok := channels[lastGenerated]
+	if !ok {
+		c = make(chan struct{})
+		channels[lastGenerated] = c
+	}
+
+	return c
+}
+
+// updateBlockTemplate creates or updates a block template for the work state.
+// A new block template will be generated when the current best block has
+// changed or the transactions in the memory pool have been updated and it has
+// been long enough since the last template was generated.  Otherwise, the
+// timestamp for the existing block template is updated (and possibly the
+// difficulty on testnet per the consesus rules).  Finally, if the
+// useCoinbaseValue flag is false and the existing block template does not
+// already contain a valid payment address, the block template will be updated
+// with a randomly selected payment address from the list of configured
+// addresses.
+//
+// This function MUST be called with the state locked.
+func (state *gbtWorkState) updateBlockTemplate(s *rpcServer, useCoinbaseValue bool) error {
+	lastTxUpdate := s.server.txMemPool.LastUpdated()
+	if lastTxUpdate.IsZero() {
+		lastTxUpdate = time.Now()
+	}
+
+	// Generate a new block template when the current best block has
+	// changed or the transactions in the memory pool have been updated and
+	// it has been at least gbtRegenerateSecond since the last template was
+	// generated.
+	var msgBlock *wire.MsgBlock
+	var targetDifficulty string
+	latestHash := s.server.blockManager.chain.BestSnapshot().Hash
+	template := state.template
+	if template == nil || state.prevHash == nil ||
+		!state.prevHash.IsEqual(latestHash) ||
+		(state.lastTxUpdate != lastTxUpdate &&
+			time.Now().After(state.lastGenerated.Add(time.Second*
+				gbtRegenerateSeconds))) {
+
+		// Reset the previous best hash the block template was generated
+		// against so any errors below cause the next invocation to try
+		// again.
+		state.prevHash = nil
+
+		// Choose a payment address at random if the caller requests a
+		// full coinbase as opposed to only the pertinent details needed
+		// to create their own coinbase.
+		var payAddr provautil.Address
+		if !useCoinbaseValue {
+			payAddr = cfg.miningAddrs[rand.Intn(len(cfg.miningAddrs))]
+		}
+
+		// Create a new block template that has a coinbase which anyone
+		// can redeem.  This is only acceptable because the returned
+		// block template doesn't include the coinbase, so the caller
+		// will ultimately create their own coinbase which pays to the
+		// appropriate address(es).
+		blkTemplate, err := s.generator.NewBlockTemplate(payAddr, nil)
+		if err != nil {
+			return internalRPCError("Failed to create new block "+
+				"template: "+err.Error(), "")
+		}
+		template = blkTemplate
+		msgBlock = template.Block
+		targetDifficulty = fmt.Sprintf("%064x",
+			blockchain.CompactToBig(msgBlock.Header.Bits))
+
+		// Get the minimum allowed timestamp for the block based on the
+		// median timestamp of the last several blocks per the chain
+		// consensus rules.
+		best := s.server.blockManager.chain.BestSnapshot()
+		minTimestamp := mining.MinimumMedianTime(best)
+
+		// Update work state to ensure another block template isn't
+		// generated until needed.
+		state.template = template
+		state.lastGenerated = time.Now()
+		state.lastTxUpdate = lastTxUpdate
+		state.prevHash = latestHash
+		state.minTimestamp = minTimestamp
+
+		rpcsLog.Debugf("Generated block template (timestamp %v, "+
+			"target %s, merkle root %s)",
+			msgBlock.Header.Timestamp, targetDifficulty,
+			msgBlock.Header.MerkleRoot)
+
+		// Notify any clients that are long polling about the new
+		// template.
+		state.notifyLongPollers(latestHash, lastTxUpdate)
+	} else {
+		// At this point, there is a saved block template and another
+		// request for a template was made, but either the available
+		// transactions haven't change or it hasn't been long enough to
+		// trigger a new block template to be generated.  So, update the
+		// existing block template.
+
+		// When the caller requires a full coinbase as opposed to only
+		// the pertinent details needed to create their own coinbase,
+		// add a payment address to the output of the coinbase of the
+		// template if it doesn't already have one.  Since this requires
+		// mining addresses to be specified via the config, an error is
+		// returned if none have been specified.
+		if !useCoinbaseValue && !template.ValidPayAddress {
+			// Choose a payment address at random.
+			payToAddr := cfg.miningAddrs[rand.Intn(len(cfg.miningAddrs))]
+
+			// Update the block coinbase output of the template to
+			// pay to the randomly selected payment address.
+			pkScript, err := txscript.PayToAddrScript(payToAddr)
+			if err != nil {
+				context := "Failed to create pay-to-addr script"
+				return internalRPCError(err.Error(), context)
+			}
+			template.Block.Transactions[0].TxOut[0].PkScript = pkScript
+			template.ValidPayAddress = true
+
+			// Update the merkle root.
+			block := provautil.NewBlock(template.Block)
+			merkles := blockchain.BuildMerkleTreeStore(block.Transactions())
+			template.Block.Header.MerkleRoot = *merkles[len(merkles)-1]
+		}
+
+		// Set locals for convenience.
+		msgBlock = template.Block
+		targetDifficulty = fmt.Sprintf("%064x",
+			blockchain.CompactToBig(msgBlock.Header.Bits))
+
+		// Update the time of the block template to the current time
+		// while accounting for the median time of the past several
+		// blocks per the chain consensus rules.
+		s.generator.UpdateBlockTime(msgBlock, nil)
+		msgBlock.Header.Nonce = 0
+
+		rpcsLog.Debugf("Updated block template (timestamp %v, "+
+			"target %s)", msgBlock.Header.Timestamp,
+			targetDifficulty)
+	}
+
+	return nil
+}
+
+// blockTemplateResult returns the current block template associated with the
+// state as a btcjson.GetBlockTemplateResult that is ready to be encoded to JSON
+// and returned to the caller.
+//
+// This function MUST be called with the state locked.
+func (state *gbtWorkState) blockTemplateResult(useCoinbaseValue bool, submitOld *bool) (*btcjson.GetBlockTemplateResult, error) {
+	// Ensure the timestamps are still in valid range for the template.
+	// This should really only ever happen if the local clock is changed
+	// after the template is generated, but it's important to avoid serving
+	// invalid block templates.
+	template := state.template
+	msgBlock := template.Block
+	header := &msgBlock.Header
+	adjustedTime := state.timeSource.AdjustedTime()
+	maxTime := adjustedTime.Add(time.Second * blockchain.MaxTimeOffsetSeconds)
+	if header.Timestamp.After(maxTime) {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCOutOfRange,
+			Message: fmt.Sprintf("The template time is after the "+
+				"maximum allowed time for a block - template "+
+				"time %v, maximum time %v", adjustedTime,
+				maxTime),
+		}
+	}
+
+	// Convert each transaction in the block template to a template result
+	// transaction.  The result does not include the coinbase, so notice
+	// the adjustments to the various lengths and indices.
+	numTx := len(msgBlock.Transactions)
+	transactions := make([]btcjson.GetBlockTemplateResultTx, 0, numTx-1)
+	txIndex := make(map[chainhash.Hash]int64, numTx)
+	for i, tx := range msgBlock.Transactions {
+		txHash := tx.TxHash()
+		txIndex[txHash] = int64(i)
+
+		// Skip the coinbase transaction.
+		if i == 0 {
+			continue
+		}
+
+		// Create an array of 1-based indices to transactions that come
+		// before this one in the transactions list which this one
+		// depends on.  This is necessary since the created block must
+		// ensure proper ordering of the dependencies.  A map is used
+		// before creating the final array to prevent duplicate entries
+		// when multiple inputs reference the same transaction.
+		dependsMap := make(map[int64]struct{})
+		for _, txIn := range tx.TxIn {
+			if idx, ok := txIndex[txIn.PreviousOutPoint.Hash]; ok {
+				dependsMap[idx] = struct{}{}
+			}
+		}
+		depends := make([]int64, 0, len(dependsMap))
+		for idx := range dependsMap {
+			depends = append(depends, idx)
+		}
+
+		// Serialize the transaction for later conversion to hex.
+		txBuf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+		if err := tx.Serialize(txBuf); err != nil {
+			context := "Failed to serialize transaction"
+			return nil, internalRPCError(err.Error(), context)
+		}
+
+		resultTx := btcjson.GetBlockTemplateResultTx{
+			Data:    hex.EncodeToString(txBuf.Bytes()),
+			Hash:    txHash.String(),
+			Depends: depends,
+			Fee:     template.Fees[i],
+			SigOps:  template.SigOpCounts[i],
+		}
+		transactions = append(transactions, resultTx)
+	}
+
+	// Generate the block template reply.  Note that following mutations are
+	// implied by the included or omission of fields:
+	//  Including MinTime -> time/decrement
+	//  Omitting CoinbaseTxn -> coinbase, generation
+	targetDifficulty := fmt.Sprintf("%064x", blockchain.CompactToBig(header.Bits))
+	templateID := encodeTemplateID(state.prevHash, state.lastGenerated)
+	sizeLimit := activeNetParams.MaxBlockSizeAtHeight(template.Height)
+	reply := btcjson.GetBlockTemplateResult{
+		Bits:         strconv.FormatInt(int64(header.Bits), 16),
+		CurTime:      header.Timestamp.Unix(),
+		Height:       int64(template.Height),
+		PreviousHash: header.PrevBlock.String(),
+		SigOpLimit:   blockchain.MaxSigOpsPerBlock,
+		SizeLimit:    int64(sizeLimit),
+		Transactions: transactions,
+		Version:      header.Version,
+		LongPollID:   templateID,
+		SubmitOld:    submitOld,
+		Target:       targetDifficulty,
+		MinTime:      state.minTimestamp.Unix(),
+		MaxTime:      maxTime.Unix(),
+		Mutable:      gbtMutableFields,
+		NonceRange:   gbtNonceRange,
+		Capabilities: gbtCapabilities,
+	}
+	if useCoinbaseValue {
+		reply.CoinbaseAux = gbtCoinbaseAux
+		reply.CoinbaseValue = &msgBlock.Transactions[0].TxOut[0].Value
+	} else {
+		// Ensure the template has a valid payment address associated
+		// with it when a full coinbase is requested.
+		if !template.ValidPayAddress {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCInternal.Code,
+				Message: "A coinbase transaction has been " +
+					"requested, but the server has not " +
+					"been configured with any payment " +
+					"addresses via --miningaddr",
+			}
+		}
+
+		// Serialize the transaction for conversion to hex.
+		tx := msgBlock.Transactions[0]
+		txBuf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+		if err := tx.Serialize(txBuf); err != nil {
+			context := "Failed to serialize transaction"
+			return nil, internalRPCError(err.Error(), context)
+		}
+
+		resultTx := btcjson.GetBlockTemplateResultTx{
+			Data:    hex.EncodeToString(txBuf.Bytes()),
+			Hash:    tx.TxHash().String(),
+			Depends: []int64{},
+			Fee:     template.Fees[0],
+			SigOps:  template.SigOpCounts[0],
+		}
+
+		reply.CoinbaseTxn = &resultTx
+	}
+
+	return &reply, nil
+}
+
+// handleGetBlockTemplateLongPoll is a helper for handleGetBlockTemplateRequest
+// which deals with handling long polling for block templates.  When a caller
+// sends a request with a long poll ID that was previously returned, a response
+// is not sent until the caller should stop working on the previous block
+// template in favor of the new one.  In particular, this is the case when the
+// old block template is no longer valid due to a solution already being found
+// and added to the block chain, or new transactions have shown up and some time
+// has passed without finding a solution.
+//
+// See https://en.bitcoin.it/wiki/BIP_0022 for more details.
+func handleGetBlockTemplateLongPoll(s *rpcServer, longPollID string, useCoinbaseValue bool, closeChan <-chan struct{}) (interface{}, error) {
+	state := s.gbtWorkState
+	state.Lock()
+	// The state unlock is intentionally not deferred here since it needs to
+	// be manually unlocked before waiting for a notification about block
+	// template changes.
+
+	if err := state.updateBlockTemplate(s, useCoinbaseValue); err != nil {
+		state.Unlock()
+		return nil, err
+	}
+
+	// Just return the current block template if the long poll ID provided by
+	// the caller is invalid.
+	prevHash, lastGenerated, err := decodeTemplateID(longPollID)
+	if err != nil {
+		result, err := state.blockTemplateResult(useCoinbaseValue, nil)
+		if err != nil {
+			state.Unlock()
+			return nil, err
+		}
+
+		state.Unlock()
+		return result, nil
+	}
+
+	// Return the block template now if the specific block template
+	// identified by the long poll ID no longer matches the current block
+	// template as this means the provided template is stale.
+	prevTemplateHash := &state.template.Block.Header.PrevBlock
+	if !prevHash.IsEqual(prevTemplateHash) ||
+		lastGenerated != state.lastGenerated.Unix() {
+
+		// Include whether or not it is valid to submit work against the
+		// old block template depending on whether or not a solution has
+		// already been found and added to the block chain.
+		submitOld := prevHash.IsEqual(prevTemplateHash)
+		result, err := state.blockTemplateResult(useCoinbaseValue,
+			&submitOld)
+		if err != nil {
+			state.Unlock()
+			return nil, err
+		}
+
+		state.Unlock()
+		return result, nil
+	}
+
+	// Register the previous hash and last generated time for notifications
+	// Get a channel that will be notified when the template associated with
+	// the provided ID is stale and a new block template should be returned to
+	// the caller.
+	longPollChan := state.templateUpdateChan(prevHash, lastGenerated)
+	state.Unlock()
+
+	select {
+	// When the client closes before it's time to send a reply, just return
+	// now so the goroutine doesn't hang around.
+	case <-closeChan:
+		return nil, ErrClientQuit
+
+	// Wait until signal received to send the reply.
+	case <-longPollChan:
+		// Fallthrough
+	}
+
+	// Get the lastest block template
+	state.Lock()
+	defer state.Unlock()
+
+	if err := state.updateBlockTemplate(s, useCoinbaseValue); err != nil {
+		return nil, err
+	}
+
+	// Include whether or not it is valid to submit work against the old
+	// block template depending on whether or not a solution has already
+	// been found and added to the block chain.
+	submitOld := prevHash.IsEqual(&state.template.Block.Header.PrevBlock)
+	result, err := state.blockTemplateResult(useCoinbaseValue, &submitOld)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// handleGetBlockTemplateRequest is a helper for handleGetBlockTemplate which
+// deals with generating and returning block templates to the caller.  It
+// handles both long poll requests as specified by BIP 0022 as well as regular
+// requests.  In addition, it detects the capabilities reported by the caller
+// in regards to whether or not it supports creating its own coinbase (the
+// coinbasetxn and coinbasevalue capabilities) and modifies the returned block
+// template accordingly.
+func handleGetBlockTemplateRequest(s *rpcServer, request *btcjson.TemplateRequest, closeChan <-chan struct{}) (interface{}, error) {
+	// Extract the relevant passed capabilities and restrict the result to
+	// either a coinbase value or a coinbase transaction object depending on
+	// the request.  Default to only providing a coinbase value.
+	useCoinbaseValue := true
+	if request != nil {
+		var hasCoinbaseValue, hasCoinbaseTxn bool
+		for _, capability := range request.Capabilities {
+			switch capability {
+			case "coinbasetxn":
+				hasCoinbaseTxn = true
+			case "coinbasevalue":
+				hasCoinbaseValue = true
+			}
+		}
+
+		if hasCoinbaseTxn && !hasCoinbaseValue {
+			useCoinbaseValue = false
+		}
+	}
+
+	// When a coinbase transaction has been requested, respond with an error
+	// if there are no addresses to pay the created block template to.
+	if !useCoinbaseValue && len(cfg.miningAddrs) == 0 {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInternal.Code,
+			Message: "A coinbase transaction has been requested, " +
+				"but the server has not been configured with " +
+				"any payment addresses via --miningaddr",
+		}
+	}
+
+	// Return an error if there are no peers connected since there is no
+	// way to relay a found block or receive transactions to work on.
+	// However, allow this state when running in the regression test or
+	// simulation test mode.
+	if !(cfg.RegressionTest || cfg.SimNet) && s.server.ConnectedCount() == 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCClientNotConnected,
+			Message: "Bitcoin is not connected",
+		}
+	}
+
+	// No point in generating or accepting work before the chain is synced.
+	currentHeight := s.server.blockManager.chain.BestSnapshot().Height
+	if currentHeight != 0 && !s.server.blockManager.IsCurrent() {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCClientInInitialDownload,
+			Message: "Bitcoin is downloading blocks...",
+		}
+	}
+
+	// When a long poll ID was provided, this is a long poll request by the
+	// client to be notified when block template referenced by the ID should
+	// be replaced with a new one.
+	if request != nil && request.LongPollID != "" {
+		return handleGetBlockTemplateLongPoll(s, request.LongPollID,
+			useCoinbaseValue, closeChan)
+	}
+
+	// Protect concurrent access when updating block templates.
+	state := s.gbtWorkState
+	state.Lock()
+	defer state.Unlock()
+
+	// Get and return a block template.  A new block template will be
+	// generated when the current best block has changed or the transactions
+	// in the memory pool have been updated and it has been at least five
+	// seconds since the last template was generated.  Otherwise, the
+	// timestamp for the existing block template is updated (and possibly
+	// the difficulty on testnet per the consesus rules).
+	if err := state.updateBlockTemplate(s, useCoinbaseValue); err != nil {
+		return nil, err
+	}
+	return state.blockTemplateResult(useCoinbaseValue, nil)
+}
+
+// chainErrToGBTErrString converts an error returned from btcchain to a string
+// which matches the reasons and format described in BIP0022 for rejection
+// reasons.
+func chainErrToGBTErrString(err error) string {
+	// When the passed error is not a RuleError, just return a generic
+	// rejected string with the error text.
+	ruleErr, ok := err.(blockchain.RuleError)
+	if !ok {
+		return "rejected: " + err.Error()
+	}
+
+	switch ruleErr.ErrorCode {
+	case blockchain.ErrDuplicateBlock:
+		return "duplicate"
+	case blockchain.ErrBlockTooBig:
+		return "bad-block-size"
+	case blockchain.ErrBlockVersionTooOld:
+		return "bad-version"
+	case blockchain.ErrInvalidTime:
+		return "bad-time"
+	case blockchain.ErrTimeTooOld:
+		return "time-too-old"
+	case blockchain.ErrTimeTooNew:
+		return "time-too-new"
+	case blockchain.ErrDifficultyTooLow:
+		return "bad-diffbits"
+	case blockchain.ErrUnexpectedDifficulty:
+		return "bad-diffbits"
+	case blockchain.ErrBadHeight:
+		return "bad-height"
+	case blockchain.ErrBadBlockSignature:
+		return "bad-block-signature"
+	case blockchain.ErrHighHash:
+		return "high-hash"
+	case blockchain.ErrBadMerkleRoot:
+		return "bad-txnmrklroot"
+	case blockchain.ErrBadCheckpoint:
+		return "bad-checkpoint"
+	case blockchain.ErrForkTooOld:
+		return "fork-too-old"
+	case blockchain.ErrCheckpointTimeTooOld:
+		return "checkpoint-time-too-old"
+	case blockchain.ErrNoTransactions:
+		return "bad-txns-none"
+	case blockchain.ErrTooManyTransactions:
+		return "bad-txns-toomany"
+	case blockchain.ErrNoTxInputs:
+		return "bad-txns-noinputs"
+	case blockchain.ErrNoTxOutputs:
+		return "bad-txns-nooutputs"
+	case blockchain.ErrTxTooBig:
+		return "bad-txns-size"
+	case blockchain.ErrBadTxOutValue:
+		return "bad-txns-outputvalue"
+	case blockchain.ErrDuplicateTxInputs:
+		return "bad-txns-dupinputs"
+	case blockchain.ErrBadTxInput:
+		return "bad-txns-badinput"
+	case blockchain.ErrMissingTx:
+		return "bad-txns-missinginput"
+	case blockchain.ErrUnfinalizedTx:
+		return "bad-txns-unfinalizedtx"
+	case blockchain.ErrDuplicateTx:
+		return "bad-txns-duplicate"
+	case blockchain.ErrOverwriteTx:
+		return "bad-txns-overwrite"
+	case blockchain.ErrImmatureSpend:
+		return "bad-txns-maturity"
+	case blockchain.ErrDoubleSpend:
+		return "bad-txns-dblspend"
+	case blockchain.ErrSpendTooHigh:
+		return "bad-txns-highspend"
+	case blockchain.ErrBadFees:
+		return "bad-txns-fees"
+	case blockchain.ErrTooManySigOps:
+		return "high-sigops"
+	case blockchain.ErrFirstTxNotCoinbase:
+		return "bad-txns-nocoinbase"
+	case blockchain.ErrMultipleCoinbases:
+		return "bad-txns-multicoinbase"
+	case blockchain.ErrBadCoinbaseScriptLen:
+		return "bad-cb-length"
+	case blockchain.ErrBadCoinbaseValue:
+		return "bad-cb-value"
+	case blockchain.ErrScriptMalformed:
+		return "bad-script-malformed"
+	case blockchain.ErrScriptValidation:
+		return "bad-script-validate"
+	case blockchain.ErrExcessiveChainShare:
+		return "excessive-chain-share"
+	case blockchain.ErrInconsistentBlkSize:
+		return "bad-size-value"
+	case blockchain.ErrInvalidValidateKey:
+		return "invalid-validate-key"
+	case blockchain.ErrFeeTooHigh:
+		return "bad-txns-highfee"
+	case blockchain.ErrSupplyCapExceeded:
+		return "bad-txns-supplycap"
+	case blockchain.ErrEpochIssuanceExceeded:
+		return "bad-blk-epochissuance"
+	case blockchain.ErrIssueDestNotAllowed:
+		return "bad-txns-issuedest"
+	case blockchain.ErrFrozenOutput:
+		return "bad-txns-frozen"
+	}
+
+	return "rejected: " + err.Error()
+}
+
+// handleGetBlockTemplateProposal is a helper for handleGetBlockTemplate which
+// deals with block proposals.
+//
+// See https://en.bitcoin.it/wiki/BIP_0023 for more details.
+func handleGetBlockTemplateProposal(s *rpcServer, request *btcjson.TemplateRequest) (interface{}, error) {
+	hexData := request.Data
+	if hexData == "" {
+		return false, &btcjson.RPCError{
+			Code: btcjson.ErrRPCType,
+			Message: fmt.Sprintf("Data must contain the " +
+				"hex-encoded serialized block that is being " +
+				"proposed"),
+		}
+	}
+
+	// Ensure the provided data is sane and deserialize the proposed block.
+	if len(hexData)%2 != 0 {
+		hexData = "0" + hexData
+	}
+	dataBytes, err := hex.DecodeString(hexData)
+	if err != nil {
+		return false, &btcjson.RPCError{
+			Code: btcjson.ErrRPCDeserialization,
+			Message: fmt.Sprintf("Data must be "+
+				"hexadecimal string (not %q)", hexData),
+		}
+	}
+	var msgBlock wire.MsgBlock
+	if err := msgBlock.Deserialize(bytes.NewReader(dataBytes)); err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDeserialization,
+			Message: "Block decode failed: " + err.Error(),
+		}
+	}
+	block := provautil.NewBlock(&msgBlock)
+
+	// Ensure the block is building from the expected previous block.
+	expectedPrevHash := s.server.blockManager.chain.BestSnapshot().Hash
+	prevHash := &block.MsgBlock().Header.PrevBlock
+	if expectedPrevHash == nil || !expectedPrevHash.IsEqual(prevHash) {
+		return "bad-prevblk", nil
+	}
+
+	flags := blockchain.BFDryRun | blockchain.BFNoPoWCheck
+	isOrphan, err := s.server.blockManager.ProcessBlock(block, flags)
+	if err != nil {
+		if _, ok := err.(blockchain.RuleError); !ok {
+			err := rpcsLog.Errorf("Failed to process block "+
+				"proposal: %v", err)
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCVerify,
+				Message: err.Error(),
+			}
+		}
+
+		rpcsLog.Infof("Rejected block proposal: %v", err)
+		return chainErrToGBTErrString(err), nil
+	}
+	if isOrphan {
+		return "orphan", nil
+	}
+
+	return nil, nil
+}
+
+// handleGetBlockTemplate implements the getblocktemplate command.
+//
+// See https://en.bitcoin.it/wiki/BIP_0022 and
+// https://en.bitcoin.it/wiki/BIP_0023 for more details.
+func handleGetBlockTemplate(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetBlockTemplateCmd)
+	request := c.Request
+
+	// Set the default mode and override it if supplied.
+	mode := "template"
+	if request != nil && request.Mode != "" {
+		mode = request.Mode
+	}
+
+	switch mode {
+	case "template":
+		return handleGetBlockTemplateRequest(s, request, closeChan)
+	case "proposal":
+		return handleGetBlockTemplateProposal(s, request)
+	}
+
+	return nil, &btcjson.RPCError{
+		Code:    btcjson.ErrRPCInvalidParameter,
+		Message: "Invalid mode",
+	}
+}
+
+// handleGetChainStats implements the getchainstats command.
+func handleGetChainStats(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// Respond with an error if the chain statistics index is not enabled.
+	statsIndex := s.server.statsIndex
+	if statsIndex == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Chain statistics index must be enabled (--statsindex)",
+		}
+	}
+
+	c := cmd.(*btcjson.GetChainStatsCmd)
+	from, err := time.Parse(chainStatsDateFormat, c.From)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Invalid from date: " + err.Error(),
+		}
+	}
+	to, err := time.Parse(chainStatsDateFormat, c.To)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Invalid to date: " + err.Error(),
+		}
+	}
+	if to.Before(from) {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "The to date must not be before the from date",
+		}
+	}
+
+	days, err := statsIndex.DailyStats(from, to)
+	if err != nil {
+		context := "Failed to load chain statistics"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	results := make([]btcjson.ChainStatsResult, 0, len(days))
+	for _, stats := range days {
+		results = append(results, btcjson.ChainStatsResult{
+			Date:            stats.Day.Format(chainStatsDateFormat),
+			Time:            stats.Day.Unix(),
+			Blocks:          stats.Blocks,
+			Transactions:    stats.Transactions,
+			ActiveAddresses: stats.ActiveAddresses,
+			Issued:          provautil.Amount(stats.Issued).ToDMG(),
+			Destroyed:       provautil.Amount(stats.Destroyed).ToDMG(),
+			Fees:            provautil.Amount(stats.Fees).ToDMG(),
+		})
+	}
+
+	return results, nil
+}
+
+// handleGetChainTips implements the getchaintips command.  Besides the fields
+// of the standard command, it reports the work of the blocks of each side
+// chain after its fork from the main chain and the number of validate keys
+// which signed them, along with those of the main chain blocks after the fork.
+func handleGetChainTips(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	tips, err := s.chain.ChainTips()
+	if err != nil {
+		context := "Failed to obtain chain tips"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	result := make([]btcjson.GetChainTipsResult, 0, len(tips))
+	for _, tip := range tips {
+		status := "valid-headers"
+		if tip.MainChain {
+			status = "active"
+		}
+		result = append(result, btcjson.GetChainTipsResult{
+			Height:           tip.Height,
+			Hash:             tip.Hash.String(),
+			BranchLen:        tip.BranchLen,
+			Status:           status,
+			ChainWork:        fmt.Sprintf("%064x", tip.WorkSum),
+			ForkHeight:       tip.ForkHeight,
+			BranchWork:       fmt.Sprintf("%064x", tip.BranchWork),
+			BranchValidators: tip.BranchValidators,
+			MainWork:         fmt.Sprintf("%064x", tip.MainWork),
+			MainValidators:   tip.MainValidators,
+		})
+	}
+	return result, nil
+}
+
+// handleGetCommitments implements the getcommitments command.
+func handleGetCommitments(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// Respond with an error if the commitment index is not enabled.
+	commitmentIndex := s.server.commitmentIndex
+	if commitmentIndex == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Commitment index must be enabled (--commitmentindex)",
+		}
+	}
+
+	c := cmd.(*btcjson.GetCommitmentsCmd)
+	decoded, err := hex.DecodeString(c.Commitment)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Commitment)
+	}
+	if len(decoded) != txscript.CommitmentSize {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Commitment must be %d bytes",
+				txscript.CommitmentSize),
+		}
+	}
+	var commitment [txscript.CommitmentSize]byte
+	copy(commitment[:], decoded)
+
+	// Load the raw transaction bytes of the committing transactions from
+	// the database.
+	regions, err := commitmentIndex.TxRegionsForCommitment(commitment)
+	if err != nil {
+		context := "Failed to load commitment index entries"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	var serializedTxns [][]byte
+	err = s.server.db.View(func(dbTx database.Tx) error {
+		var err error
+		serializedTxns, err = dbTx.FetchBlockRegions(regions)
+		return err
+	})
+	if err != nil {
+		context := "Failed to load transactions"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	results := make([]btcjson.CommitmentResult, 0, len(serializedTxns))
+	for i, serializedTx := range serializedTxns {
+		var mtx wire.MsgTx
+		err := mtx.Deserialize(bytes.NewReader(serializedTx))
+		if err != nil {
+			context := "Failed to deserialize transaction"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		blockHeight, err := s.chain.BlockHeightByHash(regions[i].Hash)
+		if err != nil {
+			context := "Failed to obtain block height"
+			return nil, internalRPCError(err.Error(), context)
+		}
+
+		txHash := mtx.TxHash()
+		for vout, txOut := range mtx.TxOut {
+			txCommitment, ok := txscript.ExtractCommitment(
+				txOut.PkScript)
+			if !ok || txCommitment != commitment {
+				continue
+			}
+			results = append(results, btcjson.CommitmentResult{
+				TxID:        txHash.String(),
+				Vout:        uint32(vout),
+				BlockHash:   regions[i].Hash.String(),
+				BlockHeight: blockHeight,
+			})
+		}
+	}
+
+	// Return the commitments in the order they were mined in.
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].BlockHeight < results[j].BlockHeight
+	})
+	return results, nil
+}
+
+// handleGetConnectionCount implements the getconnectioncount command.
+func handleGetConnectionCount(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	return s.server.ConnectedCount(), nil
+}
+
+// handleGetCurrentNet implements the getcurrentnet command.
+func handleGetCurrentNet(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	return s.server.chainParams.Net, nil
+}
+
+// handleGetDifficulty implements the getdifficulty command.
+func handleGetDifficulty(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	best := s.chain.BestSnapshot()
+	return getDifficultyRatio(best.Bits), nil
+}
+
+// handleGetFinalityStatus implements the getfinalitystatus command.  It reports
+// the confirmations of the passed main chain block, or of the block containing
+// the passed transaction, along with the number of distinct validate keys which
+// signed the blocks built on top of it up to the configured irreversible depth.
+func handleGetFinalityStatus(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetFinalityStatusCmd)
+
+	hash, err := chainhash.NewHashFromStr(c.Hash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Hash)
+	}
+	result := &btcjson.GetFinalityStatusResult{
+		Hash:              hash.String(),
+		Type:              "block",
+		IrreversibleDepth: cfg.FinalityDepth,
+	}
+
+	// The hash is either the hash of a main chain block, of a transaction
+	// in the memory pool, which is not confirmed yet, or of a transaction
+	// in a main chain block.
+	blockHash := hash
+	isBlock, err := s.chain.MainChainHasBlock(hash)
+	if err != nil {
+		context := "Failed to look up block"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	if !isBlock {
+		result.Type = "transaction"
+		if s.server.txMemPool.HaveTransaction(hash) {
+			return result, nil
+		}
+
+		txIndex := s.server.txIndex
+		if txIndex == nil {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCNoTxInfo,
+				Message: "The transaction index must be " +
+					"enabled to query the blockchain " +
+					"(specify --txindex)",
+			}
+		}
+		blockRegion, err := txIndex.TxBlockRegion(hash)
+		if err != nil {
+			context := "Failed to retrieve transaction location"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		if blockRegion == nil {
+			return nil, btcjson.NewRPCError(btcjson.ErrRPCNoTxInfo,
+				fmt.Sprintf("No information available about "+
+					"block or transaction %v", hash))
+		}
+		blockHash = blockRegion.Hash
+	}
+	height, err := s.chain.BlockHeightByHash(blockHash)
+	if err != nil {
+		context := "Failed to retrieve block height"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	best := s.chain.BestSnapshot()
+	result.BlockHash = blockHash.String()
+	result.Height = height
+	result.Confirmations = best.Height - height + 1
+	result.Final = result.Confirmations >= cfg.FinalityDepth
+
+	// Count the distinct validate keys of the blocks on top of the block,
+	// stopping at the irreversible depth.
+	last := best.Height
+	if last-height > cfg.FinalityDepth {
+		last = height + cfg.FinalityDepth
+	}
+	validators := make(map[wire.BlockValidatingPubKey]struct{})
+	for h := height + 1; h <= last; h++ {
+		select {
+		case <-closeChan:
+			return nil, ErrClientQuit
+		default:
+		}
+
+		blkHash, err := s.chain.BlockHashByHeight(h)
+		if err != nil {
+			context := "Failed to retrieve block hash"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		header, err := s.chain.FetchHeader(blkHash)
+		if err != nil {
+			context := "Failed to retrieve block header"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		validators[header.ValidatingPubKey] = struct{}{}
+	}
+	result.Validators = uint32(len(validators))
+
+	return result, nil
+}
+
+// handleGetFreezeStatus implements the getfreezestatus command.
+func handleGetFreezeStatus(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetFreezeStatusCmd)
+
+	// Only Prova addresses carry the pubKeyHash accounts are frozen by.
+	addr, err := provautil.DecodeAddress(c.Address, s.server.chainParams)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + err.Error(),
+		}
+	}
+	if _, ok := addr.(*provautil.AddressProva); !ok ||
+		!addr.IsForNet(s.server.chainParams) {
+
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address: " + c.Address +
+				" is not a prova address for this network",
+		}
+	}
+
+	pkHash := addr.ScriptAddress()
+	return &btcjson.GetFreezeStatusResult{
+		Address: addr.EncodeAddress(),
+		PkHash:  hex.EncodeToString(pkHash),
+		Frozen:  s.chain.IsFrozen(pkHash),
+	}, nil
+}
+
+// handleGetGenerate implements the getgenerate command.
+func handleGetGenerate(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	return s.server.cpuMiner.IsMining(), nil
+}
+
+// handleGetHashesPerSec implements the gethashespersec command.
+func handleGetHashesPerSec(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	return int64(s.server.cpuMiner.HashesPerSecond()), nil
+}
+
+// handleGetHeaders implements the getheaders command.
+//
+// NOTE: This is a btcsuite extension ported from
+// github.com/decred/dcrd.
+func handleGetHeaders(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetHeadersCmd)
+	blockLocators := make([]*chainhash.Hash, len(c.BlockLocators))
+	for i := range c.BlockLocators {
+		blockLocator, err := chainhash.NewHashFromStr(c.BlockLocators[i])
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCInvalidParameter,
+				Message: "Failed to decode block locator: " +
+					err.Error(),
+			}
+		}
+		blockLocators[i] = blockLocator
+	}
+	var hashStop chainhash.Hash
+	if c.HashStop != "" {
+		err := chainhash.Decode(&hashStop, c.HashStop)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParameter,
+				Message: "Failed to decode hashstop: " + err.Error(),
+			}
+		}
+	}
+	blockHashes, err := s.server.locateBlocks(blockLocators, &hashStop)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCDatabase,
+			Message: "Failed to fetch hashes of block " +
+				"headers: " + err.Error(),
+		}
+	}
+	blockHeaders, err := fetchHeaders(s.chain, blockHashes)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCDatabase,
+			Message: "Failed to fetch headers of located blocks: " +
+				err.Error(),
+		}
+	}
+
+	hexBlockHeaders := make([]string, len(blockHeaders))
+	var buf bytes.Buffer
+	for i, h := range blockHeaders {
+		err := h.Serialize(&buf)
+		if err != nil {
+			return nil, internalRPCError(err.Error(),
+				"Failed to serialize block header")
+		}
+		hexBlockHeaders[i] = hex.EncodeToString(buf.Bytes())
+		buf.Reset()
+	}
+	return hexBlockHeaders, nil
+}
+
+// handleGetInfo implements the getinfo command. We only return the fields
+// that are not related to wallet functionality.
+func handleGetInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	best := s.chain.BestSnapshot()
+	minRelayTxFee, _ := s.server.txMemPool.RelayFees()
+	ret := &btcjson.InfoChainResult{
+		Version:         int32(1000000*appMajor + 10000*appMinor + 100*appPatch),
+		ProtocolVersion: int32(maxProtocolVersion),
+		Blocks:          best.Height,
+		TimeOffset:      int64(s.server.timeSource.Offset().Seconds()),
+		Connections:     s.server.ConnectedCount(),
+		Proxy:           cfg.Proxy,
+		Difficulty:      getDifficultyRatio(best.Bits),
+		TestNet:         cfg.TestNet,
+		RelayFee:        minRelayTxFee.ToDMG(),
+	}
+
+	return ret, nil
+}
+
+// handleGetKeyExpiries implements the getkeyexpiries command.  It returns the
+// keys provisioned with an expiry height which are still provisioned in the
+// best chain, soonest expiry first.
+func handleGetKeyExpiries(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	best := s.chain.BestSnapshot()
+	pending := s.chain.KeyExpiries().Pending()
+	results := make([]btcjson.KeyExpiryResult, 0, len(pending))
+	for _, expiry := range pending {
+		result := btcjson.KeyExpiryResult{
+			KeySet:       expiry.KeySet.String(),
+			PubKey:       hex.EncodeToString(expiry.PubKey[:]),
+			KeyID:        uint32(expiry.KeyID),
+			AddHeight:    expiry.AddHeight,
+			ExpiryHeight: expiry.Height,
+		}
+		// A key still provisioned at the end of the block at its
+		// expiry height is kept to keep enough validate keys.
+		if expiry.Height > best.Height {
+			result.BlocksLeft = expiry.Height - best.Height
+		} else {
+			result.Overdue = true
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// handleGetKeyStateDiff implements the getkeystatediff command.  It returns the
+// changes of the admin state by the blocks after the first height up to and
+// including the second height, so the state at the second height is the state
+// at the first height with the changes applied in order.
+func handleGetKeyStateDiff(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetKeyStateDiffCmd)
+	best := s.chain.BestSnapshot()
+	if c.Height1 > c.Height2 || c.Height2 > best.Height {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCOutOfRange,
+			Message: fmt.Sprintf("Heights must be ordered and not "+
+				"above the best height %d", best.Height),
+		}
+	}
+
+	result := &btcjson.GetKeyStateDiffResult{
+		Height1:       c.Height1,
+		Height2:       c.Height2,
+		KeyChanges:    []btcjson.KeyChangeResult{},
+		ASPKeyChanges: []btcjson.ASPKeyChangeResult{},
+		SupplyChanges: []btcjson.SupplyChangeResult{},
+	}
+	assetDeltas := make(map[uint32]int64)
+	for height := c.Height1 + 1; height <= c.Height2; height++ {
+		// Stop early when the client goes away, since a large range
+		// of blocks takes a while to load.
+		select {
+		case <-closeChan:
+			return nil, ErrClientQuit
+		default:
+		}
+
+		block, err := s.chain.BlockByHeight(height)
+		if err != nil {
+			context := "Failed to load block"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		for _, tx := range block.Transactions() {
+			threadInt, adminOutputs := txscript.GetAdminDetails(tx)
+			if threadInt < 0 {
+				continue
+			}
+			txID := tx.Hash().String()
+
+			// Transactions of the issue thread only change the
+			// supply of their asset.
+			if provautil.ThreadID(threadInt) == provautil.IssueThread {
+				assetID, delta := blockchain.TxSupplyChange(tx,
+					height, s.server.chainParams)
+				result.SupplyChanges = append(result.SupplyChanges,
+					btcjson.SupplyChangeResult{
+						TxID:    txID,
+						Height:  height,
+						AssetID: assetID,
+						Delta:   delta,
+					})
+				if assetID == 0 {
+					result.TotalSupplyDelta += delta
+				} else {
+					assetDeltas[assetID] += delta
+				}
+				continue
+			}
+
+			for _, adminOutput := range adminOutputs {
+				payload, err := txscript.ExtractAdminPayload(adminOutput)
+				if err != nil ||
+					payload.Schema().Kind != adminop.KindKey {
+
+					continue
+				}
+				schema := payload.Schema()
+				isAddOp, keySetType := schema.IsAdd, schema.KeySet
+				pubKey, keyID := payload.PubKey, payload.KeyID
+				action := "revoke"
+				if isAddOp {
+					action = "add"
+				}
+				var serializedKey string
+				if pubKey != nil {
+					serializedKey = hex.EncodeToString(
+						pubKey.SerializeCompressed())
+				}
+				if keySetType == btcec.ASPKeySet {
+					result.ASPKeyChanges = append(
+						result.ASPKeyChanges,
+						btcjson.ASPKeyChangeResult{
+							TxID:   txID,
+							Height: height,
+							Action: action,
+							PubKey: serializedKey,
+							KeyID:  uint32(keyID),
+						})
+					continue
+				}
+				result.KeyChanges = append(result.KeyChanges,
+					btcjson.KeyChangeResult{
+						TxID:   txID,
+						Height: height,
+						KeySet: keySetType.String(),
+						Action: action,
+						PubKey: serializedKey,
+					})
+			}
+		}
+	}
+
+	for assetID, delta := range assetDeltas {
+		result.AssetSupplyDeltas = append(result.AssetSupplyDeltas,
+			btcjson.AssetSupplyDeltaResult{
+				AssetID: assetID,
+				Delta:   delta,
+			})
+	}
+	sort.Slice(result.AssetSupplyDeltas, func(i, j int) bool {
+		return result.AssetSupplyDeltas[i].AssetID <
+			result.AssetSupplyDeltas[j].AssetID
+	})
+
+	return result, nil
+}
+
+// handleGetMempoolEntry implements the getmempoolentry command.
+func handleGetMempoolEntry(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetMempoolEntryCmd)
+
+	// Convert the provided transaction hash hex to a Hash.
+	txHash, err := chainhash.NewHashFromStr(c.TxID)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.TxID)
+	}
+
+	entry, err := s.server.txMemPool.MempoolEntry(txHash)
+	if err != nil {
+		return nil, rpcNoTxInfoError(txHash)
+	}
+	return entry, nil
+}
+
+// handleGetMempoolInfo implements the getmempoolinfo command.
+func handleGetMempoolInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	mempoolTxns := s.server.txMemPool.TxDescs()
+
+	var numBytes int64
+	for _, txD := range mempoolTxns {
+		numBytes += int64(txD.Tx.MsgTx().SerializeSize())
+	}
+
+	ret := &btcjson.GetMempoolInfoResult{
+		Size:  int64(len(mempoolTxns)),
+		Bytes: numBytes,
+	}
+
+	return ret, nil
+}
+
+// handleGetMiningInfo implements the getmininginfo command. We only return the
+// fields that are not related to wallet functionality.
+func handleGetMiningInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// Create a default getnetworkhashps command to use defaults and make
+	// use of the existing getnetworkhashps handler.
+	gnhpsCmd := btcjson.NewGetNetworkHashPSCmd(nil, nil)
+	networkHashesPerSecIface, err := handleGetNetworkHashPS(s, gnhpsCmd,
+		closeChan)
+	if err != nil {
+		return nil, err
+	}
+	networkHashesPerSec, ok := networkHashesPerSecIface.(int64)
+	if !ok {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInternal.Code,
+			Message: "networkHashesPerSec is not an int64",
+		}
+	}
+
+	best := s.chain.BestSnapshot()
+	result := btcjson.GetMiningInfoResult{
+		Blocks:           int64(best.Height),
+		CurrentBlockSize: best.BlockSize,
+		CurrentBlockTx:   best.NumTxns,
+		Difficulty:       getDifficultyRatio(best.Bits),
+		Generate:         s.server.cpuMiner.IsMining(),
+		GenProcLimit:     s.server.cpuMiner.NumWorkers(),
+		HashesPerSec:     int64(s.server.cpuMiner.HashesPerSecond()),
+		NetworkHashPS:    networkHashesPerSec,
+		PooledTx:         uint64(s.server.txMemPool.Count()),
+		TestNet:          cfg.TestNet,
+	}
+	return &result, nil
+}
+
+// handleGetNetTotals implements the getnettotals command.
+func handleGetNetTotals(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	totalBytesRecv, totalBytesSent := s.server.NetTotals()
+	now := time.Now()
+	reply := &btcjson.GetNetTotalsResult{
+		TotalBytesRecv: totalBytesRecv,
+		TotalBytesSent: totalBytesSent,
+		TimeMillis:     now.UTC().UnixNano() / int64(time.Millisecond),
+		UploadTarget:   s.server.uploadTarget.result(now),
+	}
+	return reply, nil
+}
+
+// handleGetNetworkInfo implements the getnetworkinfo command.  Besides the
+// reachable networks and the local addresses advertised to peers, it reports
+// the state of the mapping of the listening port through the NAT when UPnP or
+// NAT-PMP is enabled.
+func handleGetNetworkInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	minRelayTxFee, _ := s.server.txMemPool.RelayFees()
+
+	// Onion addresses are connected to via the proxy for all connections
+	// unless a proxy specific to them is configured.
+	onionProxy := cfg.OnionProxy
+	if onionProxy == "" {
+		onionProxy = cfg.Proxy
+	}
+	networks := []btcjson.NetworksResult{
+		{Name: "ipv4", Reachable: true, Proxy: cfg.Proxy},
+		{Name: "ipv6", Reachable: true, Proxy: cfg.Proxy},
+		{
+			Name:      "onion",
+			Limited:   cfg.NoOnion,
+			Reachable: !cfg.NoOnion && onionProxy != "",
+			Proxy:     onionProxy,
+		},
+	}
+
+	localAddrs := s.server.addrManager.LocalAddresses()
+	localAddrResults := make([]btcjson.LocalAddressesResult, 0,
+		len(localAddrs))
+	for _, la := range localAddrs {
+		localAddrResults = append(localAddrResults,
+			btcjson.LocalAddressesResult{
+				Address: la.NetAddress.IP.String(),
+				Port:    la.NetAddress.Port,
+				Score:   int32(la.Score),
+			})
+	}
+
+	reply := &btcjson.GetNetworkInfoResult{
+		Version:         int32(1000000*appMajor + 10000*appMinor + 100*appPatch),
+		ProtocolVersion: int32(maxProtocolVersion),
+		TimeOffset:      int64(s.server.timeSource.Offset().Seconds()),
+		Connections:     s.server.ConnectedCount(),
+		Networks:        networks,
+		RelayFee:        minRelayTxFee.ToDMG(),
+		LocalAddresses:  localAddrResults,
+	}
+	if s.server.nat != nil {
+		reply.NAT = s.server.nat.status()
+	}
+	return reply, nil
+}
+
+// handleGetNetworkHashPS implements the getnetworkhashps command.
+func handleGetNetworkHashPS(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// Note: All valid error return paths should return an int64.
+	// Literal zeros are inferred as int, and won't coerce to int64
+	// because the return value is an interface{}.
+
+	c := cmd.(*btcjson.GetNetworkHashPSCmd)
+
+	// When the passed height is too high or zero, just return 0 now
+	// since we can't reasonably calculate the number of network hashes
+	// per second from invalid values.  When it's negative, use the current
+	// best block height.
+	best := s.chain.BestSnapshot()
+	endHeight := int32(-1)
+	if c.Height != nil {
+		endHeight = int32(*c.Height)
+	}
+	if endHeight > int32(best.Height) || endHeight == 0 {
+		return int64(0), nil
+	}
+	if endHeight < 0 {
+		endHeight = int32(best.Height)
+	}
+
+	// Calculate the number of blocks per retarget interval based on the
+	// chain parameters.
+	blocksPerRetarget := int32(s.server.chainParams.PowAveragingWindow)
+
+	// Calculate the starting block height based on the passed number of
+	// blocks.  When the passed value is negative, use the last block the
+	// difficulty changed as the starting height.  Also make sure the
+	// starting height is not before the beginning of the chain.
+	// TODO(prova): adjust this calculation for the rolling difficulty avg.
+	numBlocks := int32(120)
+	if c.Blocks != nil {
+		numBlocks = int32(*c.Blocks)
+	}
+	var startHeight int32
+	if numBlocks <= 0 {
+		startHeight = endHeight - ((endHeight % blocksPerRetarget) + 1)
+	} else {
+		startHeight = endHeight - numBlocks
+	}
+	if startHeight < 0 {
+		startHeight = 0
+	}
+	rpcsLog.Debugf("Calculating network hashes per second from %d to %d",
+		startHeight, endHeight)
+
+	// Find the min and max block timestamps as well as calculate the total
+	// amount of work that happened between the start and end blocks.
+	var minTimestamp, maxTimestamp time.Time
+	totalWork := big.NewInt(0)
+	for curHeight := startHeight; curHeight <= endHeight; curHeight++ {
+		hash, err := s.chain.BlockHashByHeight(uint32(curHeight))
+		if err != nil {
+			context := "Failed to fetch block hash"
+			return nil, internalRPCError(err.Error(), context)
+		}
+
+		// Fetch the header from chain.
+		header, err := s.chain.FetchHeader(hash)
+		if err != nil {
+			context := "Failed to fetch block header"
+			return nil, internalRPCError(err.Error(), context)
+		}
+
+		if curHeight == startHeight {
+			minTimestamp = header.Timestamp
+			maxTimestamp = minTimestamp
+		} else {
+			totalWork.Add(totalWork, blockchain.CalcWork(header.Bits))
+
+			if minTimestamp.After(header.Timestamp) {
+				minTimestamp = header.Timestamp
+			}
+			if maxTimestamp.Before(header.Timestamp) {
+				maxTimestamp = header.Timestamp
+			}
+		}
+	}
+
+	// Calculate the difference in seconds between the min and max block
+	// timestamps and avoid division by zero in the case where there is no
+	// time difference.
+	timeDiff := int64(maxTimestamp.Sub(minTimestamp) / time.Second)
+	if timeDiff == 0 {
+		return int64(0), nil
+	}
+
+	hashesPerSec := new(big.Int).Div(totalWork, big.NewInt(timeDiff))
+	return hashesPerSec.Int64(), nil
+}
+
+// handleGetPeerEvictions implements the getpeerevictions command.
+func handleGetPeerEvictions(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	return s.server.PeerEvictions(), nil
+}
+
+// handleGetPeerInfo implements the getpeerinfo command.
+func handleGetPeerInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	peers := s.server.Peers()
+	syncPeer := s.server.blockManager.SyncPeer()
+	infos := make([]*btcjson.GetPeerInfoResult, 0, len(peers))
+	for _, p := range peers {
+		statsSnap := p.StatsSnapshot()
+		info := &btcjson.GetPeerInfoResult{
+			ID:             statsSnap.ID,
+			Addr:           statsSnap.Addr,
+			AddrLocal:      p.LocalAddr().String(),
+			Services:       fmt.Sprintf("%08d", uint64(statsSnap.Services)),
+			RelayTxes:      !p.disableRelayTx,
+			LastSend:       statsSnap.LastSend.Unix(),
+			LastRecv:       statsSnap.LastRecv.Unix(),
+			BytesSent:      statsSnap.BytesSent,
+			BytesRecv:      statsSnap.BytesRecv,
+			ConnTime:       statsSnap.ConnTime.Unix(),
+			PingTime:       float64(statsSnap.LastPingMicros),
+			TimeOffset:     statsSnap.TimeOffset,
+			Version:        statsSnap.Version,
+			SubVer:         statsSnap.UserAgent,
+			Inbound:        statsSnap.Inbound,
+			StartingHeight: statsSnap.StartingHeight,
+			CurrentHeight:  statsSnap.LastBlock,
+			BanScore:       int32(p.banScore.Int()),
+			FeeFilter:      atomic.LoadInt64(&p.feeFilter),
+			SyncNode:       p == syncPeer,
+
+			BytesSentPerMsg: statsSnap.BytesSentPerMsg,
+			BytesRecvPerMsg: statsSnap.BytesRecvPerMsg,
+		}
+		if p.LastPingNonce() != 0 {
+			wait := float64(time.Since(statsSnap.LastPingTime).Nanoseconds())
+			// We actually want microseconds.
+			info.PingWait = wait / 1000
+		}
+		if p.connReq != nil {
+			info.OutboundGroup = p.connReq.Group
+		}
+
+		// Report the contribution of the peer to the sync of the chain
+		// and its ping history to debug the propagation of blocks.
+		if hash := p.LastAnnouncedBlock(); hash != nil {
+			info.BestBlock = hash.String()
+		}
+		info.LastBlock = atomic.LoadInt64(&p.lastBlockTime)
+		info.LastTransaction = atomic.LoadInt64(&p.lastTxTime)
+		info.BlocksAccepted = atomic.LoadUint64(&p.blocksAccepted)
+		info.MinPing = float64(statsSnap.MinPingMicros)
+		if len(statsSnap.PingHistory) != 0 {
+			info.PingHistory = make([]float64, 0,
+				len(statsSnap.PingHistory))
+			for _, pingMicros := range statsSnap.PingHistory {
+				info.PingHistory = append(info.PingHistory,
+					float64(pingMicros))
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// handleGetRawMempool implements the getrawmempool command.
+func handleGetRawMempool(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetRawMempoolCmd)
+	mp := s.server.txMemPool
+
+	if c.Verbose != nil && *c.Verbose {
+		return mp.RawMempoolVerbose(), nil
+	}
+
+	// The response is simply an array of the transaction hashes if the
+	// verbose flag is not set.
+	descs := mp.TxDescs()
+	hashStrings := make([]string, len(descs))
+	for i := range hashStrings {
+		hashStrings[i] = descs[i].Tx.Hash().String()
+	}
+
+	return hashStrings, nil
+}
+
+// handleGetRawTransaction implements the getrawtransaction command.
+func handleGetRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetRawTransactionCmd)
+
+	// Convert the provided transaction hash hex to a Hash.
+	txHash, err := chainhash.NewHashFromStr(c.Txid)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Txid)
+	}
+
+	verbose := false
+	if c.Verbose != nil {
+		verbose = *c.Verbose != 0
+	}
+
+	// Try to fetch the transaction from the memory pool and if that fails,
+	// try the block database.
+	var mtx *wire.MsgTx
+	var blkHash *chainhash.Hash
+	var blkHeight uint32
+	tx, err := s.server.txMemPool.FetchTransaction(txHash)
+	if err != nil {
+		txIndex := s.server.txIndex
+		if txIndex == nil {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCNoTxInfo,
+				Message: "The transaction index must be " +
+					"enabled to query the blockchain " +
+					"(specify --txindex)",
+			}
+		}
+
+		// Look up the location of the transaction.
+		blockRegion, err := txIndex.TxBlockRegion(txHash)
+		if err != nil {
+			context := "Failed to retrieve transaction location"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		if blockRegion == nil {
+			return nil, rpcNoTxInfoError(txHash)
+		}
+
+		// Load the raw transaction bytes from the database.
+		var txBytes []byte
+		err = s.server.db.View(func(dbTx database.Tx) error {
+			var err error
+			txBytes, err = dbTx.FetchBlockRegion(blockRegion)
+			return err
+		})
+		if err != nil {
+			return nil, rpcNoTxInfoError(txHash)
+		}
+
+		// When the verbose flag isn't set, simply return the serialized
+		// transaction as a hex-encoded string.  This is done here to
+		// avoid deserializing it only to reserialize it again later.
+		if !verbose {
+			return hex.EncodeToString(txBytes), nil
+		}
+
+		// Grab the block height.
+		blkHash = blockRegion.Hash
+		blkHeight, err = s.chain.BlockHeightByHash(blkHash)
+		if err != nil {
+			context := "Failed to retrieve block height"
+			return nil, internalRPCError(err.Error(), context)
+		}
+
+		// Deserialize the transaction
+		var msgTx wire.MsgTx
+		err = msgTx.Deserialize(bytes.NewReader(txBytes))
+		if err != nil {
+			context := "Failed to deserialize transaction"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		mtx = &msgTx
+	} else {
+		// When the verbose flag isn't set, simply return the
+		// network-serialized transaction as a hex-encoded string.
+		if !verbose {
+			// Note that this is intentionally not directly
+			// returning because the first return value is a
+			// string and it would result in returning an empty
+			// string to the client instead of nothing (nil) in the
+			// case of an error.
+			mtxHex, err := messageToHex(tx.MsgTx())
+			if err != nil {
+				return nil, err
+			}
+			return mtxHex, nil
+		}
+
+		mtx = tx.MsgTx()
+	}
+
+	// The verbose flag is set, so generate the JSON object and return it.
+	var blkHeader *wire.BlockHeader
+	var blkHashStr string
+	var chainHeight uint32
+	if blkHash != nil {
+		// Fetch the header from chain.
+		header, err := s.chain.FetchHeader(blkHash)
+		if err != nil {
+			context := "Failed to fetch block header"
+			return nil, internalRPCError(err.Error(), context)
+		}
+
+		blkHeader = &header
+		blkHashStr = blkHash.String()
+		chainHeight = s.chain.BestSnapshot().Height
+	}
+
+	rawTxn, err := createTxRawResult(s.server.chainParams, mtx,
+		txHash.String(), blkHeader, blkHashStr, blkHeight, chainHeight)
+	if err != nil {
+		return nil, err
+	}
+	return *rawTxn, nil
+}
+
+// fetchReserveOutPoints returns the outpoints of the unspent outputs in the
+// main chain paying to the passed addresses or to an address which includes
+// one of the passed keyIDs.  The address index is used to find the outputs
+// paying to addresses, while outputs paying to keyIDs are found by scanning the
+// unspent transaction output set.
+func fetchReserveOutPoints(s *rpcServer, addrs []provautil.Address, keyIDs []btcec.KeyID) ([]wire.OutPoint, error) {
+	seen := make(map[wire.OutPoint]struct{})
+	var outPoints []wire.OutPoint
+	for _, addr := range addrs {
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		var serializedTxns [][]byte
+		err = s.server.db.View(func(dbTx database.Tx) error {
+			regions, err := s.server.addrIndex.BoundedTxRegionsForAddress(
+				dbTx, addr, 0, 1<<32-1)
+			if err != nil {
+				return err
+			}
+			serializedTxns, err = dbTx.FetchBlockRegions(regions)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, serializedTx := range serializedTxns {
+			var mtx wire.MsgTx
+			err := mtx.Deserialize(bytes.NewReader(serializedTx))
+			if err != nil {
+				return nil, err
+			}
+			txHash := mtx.TxHash()
+			for i, txOut := range mtx.TxOut {
+				if !bytes.Equal(txOut.PkScript, pkScript) {
+					continue
+				}
+				op := wire.OutPoint{Hash: txHash, Index: uint32(i)}
+				if _, ok := seen[op]; ok {
+					continue
+				}
+				seen[op] = struct{}{}
+				outPoints = append(outPoints, op)
+			}
+		}
+	}
+
+	if len(keyIDs) > 0 {
+		keyIDOutPoints, err := s.chain.FetchKeyIDOutPoints(keyIDs, -1)
+		if err != nil {
+			return nil, err
+		}
+		for _, op := range keyIDOutPoints {
+			if _, ok := seen[op]; ok {
+				continue
+			}
+			seen[op] = struct{}{}
+			outPoints = append(outPoints, op)
+		}
+	}
+
+	return outPoints, nil
+}
+
+// reorgEventResult converts the passed reorg event into the result returned
+// by the getreorghistory command and the reorganization notification.
+func reorgEventResult(event *blockchain.ReorgEvent) *btcjson.ReorgEventResult {
+	disconnectedTxs := make([]string, 0, len(event.DisconnectedTxs))
+	for i := range event.DisconnectedTxs {
+		disconnectedTxs = append(disconnectedTxs,
+			event.DisconnectedTxs[i].String())
+	}
+	revertedAdminTxs := make([]btcjson.RevertedAdminTxResult, 0,
+		len(event.RevertedAdminTxs))
+	for _, adminTx := range event.RevertedAdminTxs {
+		revertedAdminTxs = append(revertedAdminTxs,
+			btcjson.RevertedAdminTxResult{
+				TxID:     adminTx.Hash.String(),
+				ThreadID: uint32(adminTx.ThreadID),
+			})
+	}
+	return &btcjson.ReorgEventResult{
+		Time:             event.Time.Unix(),
+		Depth:            event.Depth(),
+		ForkHash:         event.ForkHash.String(),
+		ForkHeight:       event.ForkHeight,
+		OldTip:           event.OldTip.String(),
+		OldHeight:        event.OldHeight,
+		NewTip:           event.NewTip.String(),
+		NewHeight:        event.NewHeight,
+		DisconnectedTxs:  disconnectedTxs,
+		RevertedAdminTxs: revertedAdminTxs,
+	}
+}
+
+// handleGetReorgHistory implements the getreorghistory command.  It returns the
+// reorganizations of the main chain recorded in the reorg journal, newest
+// first.
+func handleGetReorgHistory(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetReorgHistoryCmd)
+
+	count := *c.Count
+	if count <= 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Count must be positive",
+		}
+	}
+	skip := *c.Skip
+	if skip < 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Skip must not be negative",
+		}
+	}
+
+	events, err := s.chain.ReorgHistory(skip, count)
+	if err != nil {
+		context := "Failed to load reorg journal"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	results := make([]btcjson.ReorgEventResult, 0, len(events))
+	for _, event := range events {
+		results = append(results, *reorgEventResult(event))
+	}
+	return results, nil
+}
+
+// handleGetReserveProof implements the getreserveproof command.  It returns an
+// unsigned attestation of the unspent outputs paying to the passed addresses
+// and keyIDs at the current best block, with merkle proofs tying each output
+// to the block it was mined in.  The attestation is meant to be signed offline
+// by the issuer.
+func handleGetReserveProof(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetReserveProofCmd)
+
+	// Respond with an error if addresses are passed without the address
+	// index being enabled.
+	if len(c.Addresses) > 0 && s.server.addrIndex == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Address index must be enabled (--addrindex)",
+		}
+	}
+
+	addrs := make([]provautil.Address, 0, len(c.Addresses))
+	for _, addrStr := range c.Addresses {
+		addr, err := provautil.DecodeAddress(addrStr,
+			s.server.chainParams)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "Invalid address or key: " + err.Error(),
+			}
+		}
+		if !addr.IsForNet(s.server.chainParams) {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "Invalid address: " + addrStr +
+					" is for the wrong network",
+			}
+		}
+		addrs = append(addrs, addr)
+	}
+	var keyIDs []btcec.KeyID
+	if c.KeyIDs != nil {
+		for _, keyID := range *c.KeyIDs {
+			keyIDs = append(keyIDs, btcec.KeyID(keyID))
+		}
+	}
+	if len(addrs) == 0 && len(keyIDs) == 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "No addresses or keyids to attest",
+		}
+	}
+
+	// The outputs are gathered without holding the chain lock, so make
+	// sure the best block did not change meanwhile.
+	best := s.chain.BestSnapshot()
+	outPoints, err := fetchReserveOutPoints(s, addrs, keyIDs)
+	if err != nil {
+		context := "Failed to fetch reserve outputs"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	attestation := &reserve.Attestation{
+		BlockHash:   *best.Hash,
+		BlockHeight: best.Height,
+	}
+	blocks := make(map[uint32]*provautil.Block)
+	for _, op := range outPoints {
+		entry, err := s.chain.FetchUtxoEntry(&op.Hash)
+		if err != nil {
+			context := "Failed to fetch utxo entry"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		if entry == nil || entry.IsOutputSpent(op.Index) {
+			continue
+		}
+
+		height := entry.BlockHeight()
+		block, ok := blocks[height]
+		if !ok {
+			block, err = s.chain.BlockByHeight(height)
+			if err != nil {
+				context := "Failed to fetch block"
+				return nil, internalRPCError(err.Error(), context)
+			}
+			blocks[height] = block
+		}
+		txIndex := -1
+		for i, tx := range block.Transactions() {
+			if tx.Hash().IsEqual(&op.Hash) {
+				txIndex = i
+				break
+			}
+		}
+		if txIndex < 0 {
+			return nil, internalRPCError("transaction "+
+				op.Hash.String()+" not found in block "+
+				block.Hash().String(), "")
+		}
+		proof, err := reserve.NewOutputProof(block, txIndex, op.Index)
+		if err != nil {
+			context := "Failed to build output proof"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		attestation.Proofs = append(attestation.Proofs, proof)
+	}
+	if !s.chain.BestSnapshot().Hash.IsEqual(best.Hash) {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Best block changed while building proof, retry",
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := attestation.Serialize(&buf); err != nil {
+		context := "Failed to serialize attestation"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	return &btcjson.GetReserveProofResult{
+		Hex:         hex.EncodeToString(buf.Bytes()),
+		BlockHash:   best.Hash.String(),
+		BlockHeight: best.Height,
+		Outputs:     len(attestation.Proofs),
+		Total:       provautil.Amount(attestation.Total()).ToDMG(),
+	}, nil
+}
+
+// handleGetSignedCheckpoints implements the getsignedcheckpoints command.  It
+// returns the enforced signed checkpoints from the passed height onwards, in
+// ascending order of height.
+func handleGetSignedCheckpoints(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetSignedCheckpointsCmd)
+
+	checkpoints := s.chain.SignedCheckpoints()
+	results := make([]btcjson.SignedCheckpointResult, 0, len(checkpoints))
+	for _, checkpoint := range checkpoints {
+		if checkpoint.Height < *c.FromHeight {
+			continue
+		}
+		results = append(results, btcjson.SignedCheckpointResult{
+			Height:       checkpoint.Height,
+			Hash:         checkpoint.Hash.String(),
+			UtxoHash:     checkpoint.UtxoHash.String(),
+			KeyStateHash: checkpoint.KeyStateHash.String(),
+			PubKey: hex.EncodeToString(
+				checkpoint.PubKey.SerializeCompressed()),
+			Signature: hex.EncodeToString(
+				checkpoint.Signature.Serialize()),
+			Hex: hex.EncodeToString(checkpoint.Serialize()),
+		})
+	}
+	return results, nil
+}
+
+// handleGetSyncStatus implements the getsyncstatus command.
+func handleGetSyncStatus(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	return s.server.blockManager.SyncStatus(), nil
+}
+
+// handleGetTemplateExclusions implements the gettemplateexclusions command.
+func handleGetTemplateExclusions(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	exclusions := s.server.templateExclusions.exclusions
+	return &btcjson.GetTemplateExclusionsResult{
+		TxIDs:     exclusions.TxIDs(),
+		Addresses: exclusions.Addresses(),
+	}, nil
+}
+
+// Limits of the search of the gettransactionstatus command for transactions of
+// the blocks disconnected by reorganizations and for the transactions which
+// conflict with them.
+const (
+	// txStatusMaxReorgs is the number of the most recent reorganizations
+	// whose disconnected blocks are searched.
+	txStatusMaxReorgs = 100
+
+	// txStatusMaxConflictBlocks is the number of main chain blocks after
+	// the fork of a reorganization which are searched for transactions
+	// spending the outputs a disconnected transaction spends.
+	txStatusMaxConflictBlocks = 1000
+)
+
+// adminThreadNames maps the admin threads to their names.
+var adminThreadNames = map[provautil.ThreadID]string{
+	provautil.RootThread:      "root",
+	provautil.ProvisionThread: "provision",
+	provautil.IssueThread:     "issue",
+}
+
+// setTxStatusThread sets the thread of the passed transaction status result
+// when the passed transaction is an admin transaction.
+func setTxStatusThread(result *btcjson.TransactionStatusResult, tx *provautil.Tx) {
+	threadInt, _ := txscript.GetAdminDetails(tx)
+	if threadInt < 0 {
+		return
+	}
+	threadID := uint32(threadInt)
+	result.ThreadID = &threadID
+	result.Thread = adminThreadNames[provautil.ThreadID(threadInt)]
+}
+
+// findTx returns the transaction with the passed hash from the passed block, or
+// nil when the block does not contain it.
+func findTx(block *provautil.Block, hash *chainhash.Hash) *provautil.Tx {
+	for _, tx := range block.Transactions() {
+		if tx.Hash().IsEqual(hash) {
+			return tx
+		}
+	}
+	return nil
+}
+
+// txStatusBlock returns the main chain block containing the transaction with
+// the passed hash, or nil when it is not found.  The transaction is looked up
+// in the transaction index when it is enabled, and in the unspent transaction
+// output set otherwise, which only finds transactions with unspent outputs.
+func txStatusBlock(s *rpcServer, hash *chainhash.Hash) (*provautil.Block, error) {
+	var blockHash *chainhash.Hash
+	if txIndex := s.server.txIndex; txIndex != nil {
+		blockRegion, err := txIndex.TxBlockRegion(hash)
+		if err != nil || blockRegion == nil {
+			return nil, err
+		}
+		blockHash = blockRegion.Hash
+	} else {
+		entry, err := s.chain.FetchUtxoEntry(hash)
+		if err != nil || entry == nil || entry.IsFullySpent() {
+			return nil, err
+		}
+		blockHash, err = s.chain.BlockHashByHeight(entry.BlockHeight())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.chain.BlockByHash(blockHash)
+}
+
+// findDisconnectedTx returns the transaction with the passed hash from the
+// blocks disconnected by the passed reorganizations, along with its block and
+// the reorganization which disconnected it, or nil when it is not found.
+func findDisconnectedTx(s *rpcServer, reorgs []*blockchain.ReorgEvent, hash *chainhash.Hash) (*provautil.Tx, *provautil.Block, *blockchain.ReorgEvent, error) {
+	for _, reorg := range reorgs {
+		disconnected := false
+		for i := range reorg.DisconnectedTxs {
+			if reorg.DisconnectedTxs[i].IsEqual(hash) {
+				disconnected = true
+				break
+			}
+		}
+		if !disconnected {
+			continue
+		}
+
+		// Walk the disconnected blocks back from the old tip.
+		blockHash := reorg.OldTip
+		for i := uint32(0); i < reorg.Depth(); i++ {
+			block, err := s.chain.BlockByHash(&blockHash)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if tx := findTx(block, hash); tx != nil {
+				return tx, block, reorg, nil
+			}
+			blockHash = block.MsgBlock().Header.PrevBlock
+		}
+	}
+	return nil, nil, nil, nil
+}
+
+// txConflicts returns the hashes of the transactions which spend an output the
+// passed transaction, disconnected by the passed reorganization, spends: those
+// in the memory pool, and those in the main chain blocks after the fork of the
+// reorganization, of which at most txStatusMaxConflictBlocks are searched.
+func txConflicts(s *rpcServer, tx *provautil.Tx, reorg *blockchain.ReorgEvent, closeChan <-chan struct{}) ([]string, error) {
+	view, err := s.chain.FetchUtxoView(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the outputs which are no longer unspent in the main chain and
+	// not spent by the memory pool are searched for in the blocks.
+	var conflicts []string
+	seen := make(map[chainhash.Hash]struct{})
+	addConflict := func(hash *chainhash.Hash) {
+		if _, ok := seen[*hash]; !ok {
+			seen[*hash] = struct{}{}
+			conflicts = append(conflicts, hash.String())
+		}
+	}
+	spent := make(map[wire.OutPoint]struct{})
+	for _, txIn := range tx.MsgTx().TxIn {
+		prevOut := txIn.PreviousOutPoint
+		if spender := s.server.txMemPool.CheckSpend(prevOut); spender != nil {
+			addConflict(spender.Hash())
+			continue
+		}
+		entry := view.LookupEntry(&prevOut.Hash)
+		if entry == nil || entry.IsOutputSpent(prevOut.Index) {
+			spent[prevOut] = struct{}{}
+		}
+	}
+
+	best := s.chain.BestSnapshot().Height
+	last := reorg.ForkHeight + txStatusMaxConflictBlocks
+	if last > best {
+		last = best
+	}
+	for height := reorg.ForkHeight + 1; height <= last && len(spent) != 0; height++ {
+		select {
+		case <-closeChan:
+			return nil, ErrClientQuit
+		default:
+		}
+
+		block, err := s.chain.BlockByHeight(height)
+		if err != nil {
+			return nil, err
+		}
+		for _, blockTx := range block.Transactions() {
+			for _, txIn := range blockTx.MsgTx().TxIn {
+				prevOut := txIn.PreviousOutPoint
+				if _, ok := spent[prevOut]; ok {
+					addConflict(blockTx.Hash())
+					delete(spent, prevOut)
+				}
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// handleGetTransactionStatus implements the gettransactionstatus command.  It
+// returns for each of the passed transactions whether it is in the memory pool,
+// confirmed by the main chain, was disconnected from the main chain by a
+// reorganization and conflicts with other transactions, or is unknown.
+func handleGetTransactionStatus(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetTransactionStatusCmd)
+
+	hashes := make([]*chainhash.Hash, 0, len(c.TxIDs))
+	for _, txID := range c.TxIDs {
+		hash, err := chainhash.NewHashFromStr(txID)
+		if err != nil {
+			return nil, rpcDecodeHexError(txID)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	best := s.chain.BestSnapshot()
+	txMemPool := s.server.txMemPool
+	var reorgs []*blockchain.ReorgEvent
+	results := make([]btcjson.TransactionStatusResult, 0, len(hashes))
+	for _, hash := range hashes {
+		select {
+		case <-closeChan:
+			return nil, ErrClientQuit
+		default:
+		}
+
+		result := btcjson.TransactionStatusResult{
+			TxID:   hash.String(),
+			Status: "unknown",
+		}
+
+		// The memory pool is checked before the main chain, so a
+		// transaction mined in between is still found.
+		if tx, err := txMemPool.FetchTransaction(hash); err == nil {
+			result.Status = "mempool"
+			setTxStatusThread(&result, tx)
+			results = append(results, result)
+			continue
+		}
+		if tx, err := txMemPool.FetchHeldAdminTx(hash); err == nil {
+			result.Status = "held"
+			setTxStatusThread(&result, tx)
+			results = append(results, result)
+			continue
+		}
+		if txMemPool.IsOrphanInPool(hash) {
+			result.Status = "orphan"
+			results = append(results, result)
+			continue
+		}
+
+		block, err := txStatusBlock(s, hash)
+		if err != nil {
+			context := "Failed to look up transaction block"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		if block != nil {
+			header := &block.MsgBlock().Header
+			result.Status = "confirmed"
+			result.BlockHash = block.Hash().String()
+			result.Height = header.Height
+			result.Confirmations = best.Height - header.Height + 1
+			result.BlockTime = header.Timestamp.Unix()
+			if tx := findTx(block, hash); tx != nil {
+				setTxStatusThread(&result, tx)
+			}
+			results = append(results, result)
+			continue
+		}
+
+		// Look the transaction up in the blocks disconnected by the
+		// recent reorganizations, which are loaded once per call.
+		if reorgs == nil {
+			reorgs, err = s.chain.ReorgHistory(0, txStatusMaxReorgs)
+			if err != nil {
+				context := "Failed to load reorg journal"
+				return nil, internalRPCError(err.Error(), context)
+			}
+		}
+		tx, block, reorg, err := findDisconnectedTx(s, reorgs, hash)
+		if err != nil {
+			context := "Failed to load disconnected block"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		if tx != nil {
+			header := &block.MsgBlock().Header
+			result.Status = "disconnected"
+			result.BlockHash = block.Hash().String()
+			result.Height = header.Height
+			result.BlockTime = header.Timestamp.Unix()
+			setTxStatusThread(&result, tx)
+
+			conflicts, err := txConflicts(s, tx, reorg, closeChan)
+			if err != nil {
+				if err == ErrClientQuit {
+					return nil, err
+				}
+				context := "Failed to look up conflicting " +
+					"transactions"
+				return nil, internalRPCError(err.Error(), context)
+			}
+			if len(conflicts) != 0 {
+				result.Status = "conflicted"
+				result.ConflictingTxs = conflicts
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// handleGetTxOut handles gettxout commands.
+func handleGetTxOut(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetTxOutCmd)
+
+	// Convert the provided transaction hash hex to a Hash.
+	txHash, err := chainhash.NewHashFromStr(c.Txid)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Txid)
+	}
+
+	// If requested and the tx is available in the mempool try to fetch it
+	// from there, otherwise attempt to fetch from the block database.
+	var bestBlockHash string
+	var confirmations uint32
+	var txVersion int32
+	var value int64
+	var pkScript []byte
+	var isCoinbase bool
+	var assetID uint32
+	var utxoHeight uint32
+	utxoView := blockchain.NewUtxoViewpoint()
+	includeMempool := true
+	if c.IncludeMempool != nil {
+		includeMempool = *c.IncludeMempool
+	}
+
+	// Outputs spent by a transaction in the mempool are treated as spent
+	// when the mempool is included.
+	outPoint := wire.OutPoint{Hash: *txHash, Index: c.Vout}
+	if includeMempool && s.server.txMemPool.CheckSpend(outPoint) != nil {
+		return nil, nil
+	}
+
+	// TODO: This is racy.  It should attempt to fetch it directly and check
+	// the error.
+	if includeMempool && s.server.txMemPool.HaveTransaction(txHash) {
+		tx, err := s.server.txMemPool.FetchTransaction(txHash)
+		if err != nil {
+			return nil, rpcNoTxInfoError(txHash)
+		}
+
+		mtx := tx.MsgTx()
+		if c.Vout > uint32(len(mtx.TxOut)-1) {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCInvalidTxVout,
+				Message: "Output index number (vout) does not " +
+					"exist for transaction.",
+			}
+		}
+
+		txOut := mtx.TxOut[c.Vout]
+		if txOut == nil {
+			errStr := fmt.Sprintf("Output index: %d for txid: %s "+
+				"does not exist", c.Vout, txHash)
+			return nil, internalRPCError(errStr, "")
+		}
+
+		best := s.chain.BestSnapshot()
+		bestBlockHash = best.Hash.String()
+		confirmations = 0
+		txVersion = mtx.Version
+		value = txOut.Value
+		pkScript = txOut.PkScript
+		isCoinbase = blockchain.IsCoinBaseTx(mtx)
+		utxoHeight = mining.UnminedHeight
+		assetID = blockchain.TxAssetID(mtx, utxoHeight,
+			s.server.chainParams)
+		utxoView.AddTxOuts(tx, utxoHeight)
+	} else {
+		entry, err := s.chain.FetchUtxoEntry(txHash)
+		if err != nil {
+			return nil, rpcNoTxInfoError(txHash)
+		}
+
+		// To match the behavior of the reference client, return nil
+		// (JSON null) if the transaction output is spent by another
+		// transaction already in the main chain.  Mined transactions
+		// that are spent by a mempool transaction are not affected by
+		// this.
+		if entry == nil || entry.IsOutputSpent(c.Vout) {
+			return nil, nil
+		}
+
+		best := s.chain.BestSnapshot()
+		bestBlockHash = best.Hash.String()
+		confirmations = 1 + best.Height - entry.BlockHeight()
+		txVersion = entry.Version()
+		value = entry.AmountByIndex(c.Vout)
+		pkScript = entry.PkScriptByIndex(c.Vout)
+		isCoinbase = entry.IsCoinBase()
+		assetID = blockchain.UtxoAssetID(entry, s.server.chainParams)
+		utxoHeight = entry.BlockHeight()
+		utxoView.Entries()[*txHash] = entry
+	}
+
+	// Compute when the output can be spent by a transaction spending it
+	// with the requested sequence number, which includes the relative
+	// lock-time the sequence number sets.
+	sequence := wire.MaxTxInSequenceNum
+	if c.Sequence != nil {
+		sequence = *c.Sequence
+	}
+	spendTx := wire.NewMsgTx(2)
+	spendTx.AddTxIn(&wire.TxIn{PreviousOutPoint: outPoint, Sequence: sequence})
+	lock, err := s.chain.CalcSequenceLock(provautil.NewTx(spendTx), utxoView,
+		true)
+	if err != nil {
+		context := "Failed to calculate sequence lock"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	sp := outputSpendability(utxoHeight, isCoinbase,
+		s.server.chainParams.CoinbaseMaturity, lock, s.chain.BestSnapshot())
+
+	// Disassemble script into single line printable format.
+	// The disassembled string will contain [error] inline if the script
+	// doesn't fully parse, so ignore the error here.
+	disbuf, _ := txscript.DisasmString(pkScript)
+
+	// Get further info about the script.
+	// Ignore the error here since an error means the script couldn't parse
+	// and there is no additional information about it anyways.
+	scriptClass, addrs, reqSigs, _ := txscript.ExtractPkScriptAddrs(pkScript,
+		s.server.chainParams)
+	addresses := make([]string, len(addrs))
+	for i, addr := range addrs {
+		addresses[i] = addr.EncodeAddress()
+	}
+
+	// Decode the keyIDs of Prova scripts.  Other scripts have none, so
+	// the error is ignored.
+	var keyIDs []uint32
+	if pops, err := txscript.ParseScript(pkScript); err == nil {
+		ids, _ := txscript.ExtractKeyIDs(pops)
+		for _, keyID := range ids {
+			keyIDs = append(keyIDs, uint32(keyID))
+		}
+	}
+
+	txOutReply := &btcjson.GetTxOutResult{
+		BestBlock:     bestBlockHash,
+		Confirmations: int64(confirmations),
+		Value:         provautil.Amount(value).ToDMG(),
+		Version:       txVersion,
+		ScriptPubKey: btcjson.ScriptPubKeyResult{
+			Asm:       disbuf,
+			Hex:       hex.EncodeToString(pkScript),
+			ReqSigs:   int32(reqSigs),
+			Type:      scriptClass.String(),
+			Addresses: addresses,
+		},
+		Coinbase:        isCoinbase,
+		AssetID:         assetID,
+		KeyIDs:          keyIDs,
+		Mature:          sp.mature,
+		SpendableHeight: sp.height,
+		SpendableTime:   sp.time,
+		Spendable:       sp.spendable,
+	}
+	return txOutReply, nil
+}
+
+// handleGetUnconfirmedBroadcasts implements the getunconfirmedbroadcasts
+// command.
+func handleGetUnconfirmedBroadcasts(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	pending := s.server.UnconfirmedBroadcasts()
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].firstBroadcast.Before(pending[j].firstBroadcast)
+	})
+
+	results := make([]btcjson.UnconfirmedBroadcastResult, 0, len(pending))
+	for _, pb := range pending {
+		announcedBy := make([]btcjson.BroadcastAnnouncementResult, 0,
+			len(pb.announcedBy))
+		for addr, announced := range pb.announcedBy {
+			announcedBy = append(announcedBy,
+				btcjson.BroadcastAnnouncementResult{
+					Addr: addr,
+					Time: announced.Unix(),
+				})
+		}
+		sort.Slice(announcedBy, func(i, j int) bool {
+			return announcedBy[i].Time < announcedBy[j].Time
+		})
+
+		results = append(results, btcjson.UnconfirmedBroadcastResult{
+			TxID:           pb.invVect.Hash.String(),
+			FirstBroadcast: pb.firstBroadcast.Unix(),
+			LastBroadcast:  pb.lastBroadcast.Unix(),
+			Rebroadcasts:   pb.rebroadcasts,
+			AnnouncedBy:    announcedBy,
+		})
+	}
+	return results, nil
+}
+
+// handleGetValidatorViolations implements the getvalidatorviolations command.
+// It returns the recorded evidence of validate keys which signed two blocks at
+// the same height, from the highest height down.
+func handleGetValidatorViolations(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.GetValidatorViolationsCmd)
+
+	count := *c.Count
+	if count <= 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Count must be positive",
+		}
+	}
+	skip := *c.Skip
+	if skip < 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Skip must not be negative",
+		}
+	}
+
+	violations, err := s.chain.ValidatorViolations(skip, count)
+	if err != nil {
+		context := "Failed to load validator violations"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	results := make([]btcjson.ValidatorViolationResult, 0, len(violations))
+	for _, v := range violations {
+		blockHashes := make([]string, 0, len(v.Headers))
+		for i := range v.Headers {
+			blockHashes = append(blockHashes,
+				v.Headers[i].BlockHash().String())
+		}
+		results = append(results, btcjson.ValidatorViolationResult{
+			Time:             v.Time.Unix(),
+			Height:           v.Height(),
+			ValidatingPubKey: v.ValidatingPubKey().String(),
+			BlockHashes:      blockHashes,
+			Evidence:         hex.EncodeToString(v.Evidence()),
+		})
+	}
+	return results, nil
+}
+
+// handleGetXpubInfo implements the getxpubinfo command.
+func handleGetXpubInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	watchIndex := s.server.watchIndex
+	if watchIndex == nil {
+		return nil, rpcNoWatchIndexError()
+	}
+
+	c := cmd.(*btcjson.GetXpubInfoCmd)
+	xpub, err := hdkeychain.NewKeyFromString(c.Xpub)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + err.Error(),
+		}
+	}
+	info, ok := watchIndex.XpubInfo(xpub.String())
+	if !ok {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Extended key is not watched",
+		}
+	}
+
+	keyIDs := make([]uint32, 0, len(info.KeyIDs))
+	for _, keyID := range info.KeyIDs {
+		keyIDs = append(keyIDs, uint32(keyID))
+	}
+	nextAddr, err := provautil.NewAddressProva(info.NextPkHash,
+		info.KeyIDs, s.server.chainParams)
+	if err != nil {
+		context := "Failed to create next address"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	return &btcjson.GetXpubInfoResult{
+		Xpub:        xpub.String(),
+		KeyIDs:      keyIDs,
+		Label:       info.Label,
+		GapLimit:    info.GapLimit,
+		Used:        info.Used,
+		Derived:     info.Derived,
+		NextAddress: nextAddr.EncodeAddress(),
+	}, nil
+}
+
+// handleHelp implements the help command.
+func handleHelp(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.HelpCmd)
+
+	// Provide a usage overview of all commands when no specific command
+	// was specified.
+	var command string
+	if c.Command != nil {
+		command = *c.Command
+	}
+	if command == "" {
+		usage, err := s.helpCacher.rpcUsage(false)
+		if err != nil {
+			context := "Failed to generate RPC usage"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		return usage, nil
+	}
+
+	// Check that the command asked for is supported and implemented.  Only
+	// search the main list of handlers since help should not be provided
+	// for commands that are unimplemented or related to wallet
+	// functionality.
+	if _, ok := rpcHandlers[command]; !ok {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Unknown command: " + command,
+		}
+	}
+
+	// Get the help for the command.
+	help, err := s.helpCacher.rpcMethodHelp(command)
+	if err != nil {
+		context := "Failed to generate help"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	return help, nil
+}
+
+// importWatched validates the passed label and rescan flag, and invokes the
+// passed function with them to add addresses to the addresses watched by the
+// watch-only index while the processing of blocks is paused.  It implements the
+// importaddress, importpubkey and importxpub commands.
+func importWatched(s *rpcServer, rescan *bool, label *string, watch func(watchIndex *indexers.WatchIndex, label string, rescan bool) error) error {
+	watchIndex := s.server.watchIndex
+	if watchIndex == nil {
+		return rpcNoWatchIndexError()
+	}
+
+	doRescan := rescan == nil || *rescan
+	var labelStr string
+	if label != nil {
+		labelStr = *label
+	}
+	if labelStr == "*" {
+		return &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Invalid label name: *",
+		}
+	}
+
+	// The main chain is rescanned by height, which is not possible while
+	// the chain state is being rebuilt.
+	if _, reindexing := s.chain.ReindexTarget(); doRescan && reindexing {
+		return &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Unable to rescan during a reindex",
+		}
+	}
+
+	// Pause the processing of blocks, so no block is connected between
+	// rescanning the main chain and watching the addresses.
+	pauseGuard := s.server.blockManager.Pause()
+	defer close(pauseGuard)
+	if err := watch(watchIndex, labelStr, doRescan); err != nil {
+		context := "Failed to import watch-only address"
+		return internalRPCError(err.Error(), context)
+	}
+
+	return nil
+}
+
+// handleImportAddress implements the importaddress command.
+func handleImportAddress(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.ImportAddressCmd)
+
+	addr, err := provautil.DecodeAddress(c.Address, s.server.chainParams)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + err.Error(),
+		}
+	}
+	if _, ok := addr.(*provautil.AddressProva); !ok ||
+		!addr.IsForNet(s.server.chainParams) {
+
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address: " + c.Address +
+				" is not a prova address for this network",
+		}
+	}
+
+	err = importWatched(s, c.Rescan, c.Label,
+		func(watchIndex *indexers.WatchIndex, label string, rescan bool) error {
+			return watchIndex.Watch(s.chain, addr.ScriptAddress(),
+				label, rescan, closeChan)
+		})
+	return nil, err
+}
+
+// handleImportPubKey implements the importpubkey command.
+func handleImportPubKey(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.ImportPubKeyCmd)
+
+	serializedPubKey, err := hex.DecodeString(c.PubKey)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.PubKey)
+	}
+	pubKey, err := btcec.ParsePubKey(serializedPubKey, btcec.S256())
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + err.Error(),
+		}
+	}
+
+	pkHash := provautil.Hash160(pubKey.SerializeCompressed())
+	err = importWatched(s, c.Rescan, c.Label,
+		func(watchIndex *indexers.WatchIndex, label string, rescan bool) error {
+			return watchIndex.Watch(s.chain, pkHash, label, rescan,
+				closeChan)
+		})
+	return nil, err
+}
+
+// handleImportXpub implements the importxpub command.
+func handleImportXpub(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.ImportXpubCmd)
+
+	xpub, err := hdkeychain.NewKeyFromString(c.Xpub)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + err.Error(),
+		}
+	}
+	if xpub.IsPrivate() || !xpub.IsForNet(s.server.chainParams) {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + c.Xpub +
+				" is not an extended public key for this " +
+				"network",
+		}
+	}
+
+	// Ensure the key ids form valid Prova addresses.
+	keyIDs := make([]btcec.KeyID, 0, len(c.KeyIDs))
+	for _, keyID := range c.KeyIDs {
+		keyIDs = append(keyIDs, btcec.KeyID(keyID))
+	}
+	_, err = provautil.NewAddressProva(make([]byte, 20), keyIDs,
+		s.server.chainParams)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Invalid key ids: " + err.Error(),
+		}
+	}
+
+	gapLimit := uint32(20)
+	if c.GapLimit != nil {
+		gapLimit = *c.GapLimit
+	}
+	if gapLimit < 1 || gapLimit > indexers.MaxWatchGapLimit {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Gap limit must be between 1 "+
+				"and %d", indexers.MaxWatchGapLimit),
+		}
+	}
+
+	err = importWatched(s, c.Rescan, c.Label,
+		func(watchIndex *indexers.WatchIndex, label string, rescan bool) error {
+			return watchIndex.WatchXpub(s.chain, xpub, keyIDs,
+				gapLimit, label, rescan, closeChan)
+		})
+	return nil, err
+}
+
+// watchedTxHeader returns the header of the main chain block at the passed
+// height.  The headers are cached in the passed map.
+func watchedTxHeader(s *rpcServer, height uint32, headers map[uint32]*wire.BlockHeader) (*wire.BlockHeader, error) {
+	if header, ok := headers[height]; ok {
+		return header, nil
+	}
+
+	hash, err := s.chain.BlockHashByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	header, err := s.chain.FetchHeader(hash)
+	if err != nil {
+		return nil, err
+	}
+	headers[height] = &header
+	return &header, nil
+}
+
+// watchedTxResult returns the listtransactions result for the passed watch-only
+// transaction entry of the block with the passed header.
+func watchedTxResult(s *rpcServer, entry *indexers.WatchedTxEntry, header *wire.BlockHeader, confirmations int64) *btcjson.ListTransactionsResult {
+	amount := provautil.Amount(entry.Output.Amount).ToDMG()
+	if entry.Category == indexers.WatchSend {
+		amount = -amount
+	}
+	blockIndex := int64(entry.TxIndex)
+	blockTime := header.Timestamp.Unix()
+	return &btcjson.ListTransactionsResult{
+		Account:           entry.Output.Label,
+		Address:           watchedAddress(s, entry.Output.PkScript),
+		Amount:            amount,
+		BlockHash:         header.BlockHash().String(),
+		BlockIndex:        &blockIndex,
+		BlockTime:         blockTime,
+		Category:          entry.Category.String(),
+		Confirmations:     confirmations,
+		Generated:         entry.TxIndex == 0,
+		InvolvesWatchOnly: true,
+		Time:              blockTime,
+		TimeReceived:      blockTime,
+		Trusted:           true,
+		TxID:              entry.TxHash.String(),
+		Vout:              entry.Output.OutPoint.Index,
+		WalletConflicts:   []string{},
+	}
+}
+
+// handleListSinceBlock implements the listsinceblock command for the addresses
+// watched by the watch-only index.  When the passed block is no longer in the
+// main chain, the transactions of the disconnected blocks back to the main
+// chain are returned as well with the removed flag set.
+func handleListSinceBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	watchIndex := s.server.watchIndex
+	if watchIndex == nil {
+		return nil, rpcNoWatchIndexError()
+	}
+
+	c := cmd.(*btcjson.ListSinceBlockCmd)
+	targetConfs := 1
+	if c.TargetConfirmations != nil {
+		targetConfs = *c.TargetConfirmations
+	}
+	if targetConfs < 1 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Target confirmations must be at least 1",
+		}
+	}
+
+	// Find the main chain block the passed block descends from, which is
+	// the block itself unless it was disconnected by a reorganization.
+	var sinceHeight uint32
+	var removedBlocks []chainhash.Hash
+	if c.BlockHash != nil {
+		hash, err := chainhash.NewHashFromStr(*c.BlockHash)
+		if err != nil {
+			return nil, rpcDecodeHexError(*c.BlockHash)
+		}
+		for {
+			inMainChain, err := s.chain.MainChainHasBlock(hash)
+			if err != nil {
+				context := "Failed to check block"
+				return nil, internalRPCError(err.Error(),
+					context)
+			}
+			if inMainChain {
+				break
+			}
+
+			header, err := s.chain.FetchHeader(hash)
+			if err != nil {
+				return nil, &btcjson.RPCError{
+					Code:    btcjson.ErrRPCBlockNotFound,
+					Message: "Block not found",
+				}
+			}
+			removedBlocks = append(removedBlocks, *hash)
+			hash = &header.PrevBlock
+		}
+		sinceHeight, err = s.chain.BlockHeightByHash(hash)
+		if err != nil {
+			context := "Failed to load block height"
+			return nil, internalRPCError(err.Error(), context)
+		}
+	}
+
+	best := s.chain.BestSnapshot()
+	entries, err := watchIndex.TxEntriesAfter(sinceHeight)
+	if err != nil {
+		context := "Failed to load watched transactions"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	headers := make(map[uint32]*wire.BlockHeader)
+	results := make([]btcjson.ListTransactionsResult, 0, len(entries))
+	for i := range entries {
+		// Skip the entries of blocks connected in the meantime.
+		entry := &entries[i]
+		if entry.Height > best.Height {
+			break
+		}
+
+		header, err := watchedTxHeader(s, entry.Height, headers)
+		if err != nil {
+			context := "Failed to load block header"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		confirmations := int64(best.Height) - int64(entry.Height) + 1
+		results = append(results, *watchedTxResult(s, entry, header,
+			confirmations))
+	}
+
+	// Add the entries of the disconnected blocks, oldest first.
+	for i := len(removedBlocks) - 1; i >= 0; i-- {
+		hash := &removedBlocks[i]
+		removed, err := watchIndex.RemovedTxEntries(hash)
+		if err != nil {
+			context := "Failed to load removed transactions"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		if len(removed) == 0 {
+			continue
+		}
+
+		header, err := s.chain.FetchHeader(hash)
+		if err != nil {
+			context := "Failed to load block header"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		for j := range removed {
+			result := watchedTxResult(s, &removed[j], &header, 0)
+			result.Trusted = false
+			result.Removed = true
+			results = append(results, *result)
+		}
+	}
+
+	// The last block is the block to pass to the next call, so the
+	// transactions with fewer than the target confirmations are returned
+	// again.
+	lastHeight := int64(best.Height) + 1 - int64(targetConfs)
+	if lastHeight < 0 {
+		lastHeight = 0
+	}
+	lastBlock, err := s.chain.BlockHashByHeight(uint32(lastHeight))
+	if err != nil {
+		context := "Failed to load block hash"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	return &btcjson.ListSinceBlockResult{
+		Transactions: results,
+		LastBlock:    lastBlock.String(),
+	}, nil
+}
+
+// handleListTransactions implements the listtransactions command for the
+// addresses watched by the watch-only index.  The account selects the watched
+// addresses with that label.
+func handleListTransactions(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	watchIndex := s.server.watchIndex
+	if watchIndex == nil {
+		return nil, rpcNoWatchIndexError()
+	}
+
+	c := cmd.(*btcjson.ListTransactionsCmd)
+	count := 10
+	if c.Count != nil {
+		count = *c.Count
+	}
+	from := 0
+	if c.From != nil {
+		from = *c.From
+	}
+	if count < 0 || from < 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Count and from must not be negative",
+		}
+	}
+
+	entries, err := watchIndex.TxEntries()
+	if err != nil {
+		context := "Failed to load watched transactions"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	// Select the entries of the requested label, and skip the most recent
+	// from entries before returning up to count entries, oldest first.
+	selected := entries[:0]
+	for _, entry := range entries {
+		if watchLabelSelected(c.Account, entry.Output.Label) {
+			selected = append(selected, entry)
+		}
+	}
+	end := len(selected) - from
+	if end < 0 {
+		end = 0
+	}
+	start := end - count
+	if start < 0 {
+		start = 0
+	}
+	selected = selected[start:end]
+
+	best := s.chain.BestSnapshot()
+	headers := make(map[uint32]*wire.BlockHeader)
+	results := make([]btcjson.ListTransactionsResult, 0, len(selected))
+	for i := range selected {
+		entry := &selected[i]
+		header, err := watchedTxHeader(s, entry.Height, headers)
+		if err != nil {
+			context := "Failed to load block header"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		confirmations := int64(best.Height) - int64(entry.Height) + 1
+		results = append(results, *watchedTxResult(s, entry, header,
+			confirmations))
+	}
+
+	return results, nil
+}
+
+// handleListUnspent implements the listunspent command for the addresses
+// watched by the watch-only index.
+func handleListUnspent(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	watchIndex := s.server.watchIndex
+	if watchIndex == nil {
+		return nil, rpcNoWatchIndexError()
+	}
+
+	c := cmd.(*btcjson.ListUnspentCmd)
+	minConf := 1
+	if c.MinConf != nil {
+		minConf = *c.MinConf
+	}
+	maxConf := 9999999
+	if c.MaxConf != nil {
+		maxConf = *c.MaxConf
+	}
+
+	// Only list the outputs paying the requested addresses, if any.
+	var addrs map[string]struct{}
+	if c.Addresses != nil {
+		addrs = make(map[string]struct{}, len(*c.Addresses))
+		for _, encodedAddr := range *c.Addresses {
+			addr, err := provautil.DecodeAddress(encodedAddr,
+				s.server.chainParams)
+			if err != nil {
+				return nil, &btcjson.RPCError{
+					Code: btcjson.ErrRPCInvalidAddressOrKey,
+					Message: "Invalid address or key: " +
+						err.Error(),
+				}
+			}
+			addrs[addr.EncodeAddress()] = struct{}{}
+		}
+	}
+
+	outputs, err := watchIndex.UnspentOutputs()
+	if err != nil {
+		context := "Failed to load watched outputs"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	best := s.chain.BestSnapshot()
+	noLock := &blockchain.SequenceLock{Seconds: -1, BlockHeight: -1}
+	coinbases := make(map[chainhash.Hash]bool)
+	results := make([]btcjson.ListUnspentResult, 0, len(outputs))
+	for _, output := range outputs {
+		confirmations := int64(best.Height) - int64(output.Height) + 1
+		if confirmations < int64(minConf) ||
+			confirmations > int64(maxConf) {
+
+			continue
+		}
+		address := watchedAddress(s, output.PkScript)
+		if _, ok := addrs[address]; addrs != nil && !ok {
+			continue
+		}
+
+		// Coinbase outputs can't be spent before they are mature.  The
+		// watch index doesn't record whether an output is a coinbase,
+		// so it is looked up once per transaction.
+		txHash := output.OutPoint.Hash
+		coinbase, ok := coinbases[txHash]
+		if !ok {
+			entry, err := s.chain.FetchUtxoEntry(&txHash)
+			if err != nil {
+				context := "Failed to fetch utxo entry"
+				return nil, internalRPCError(err.Error(), context)
+			}
+			coinbase = entry != nil && entry.IsCoinBase()
+			coinbases[txHash] = coinbase
+		}
+		sp := outputSpendability(output.Height, coinbase,
+			s.server.chainParams.CoinbaseMaturity, noLock, best)
+
+		results = append(results, btcjson.ListUnspentResult{
+			TxID:            txHash.String(),
+			Vout:            output.OutPoint.Index,
+			Address:         address,
+			Account:         output.Label,
+			ScriptPubKey:    hex.EncodeToString(output.PkScript),
+			Amount:          provautil.Amount(output.Amount).ToDMG(),
+			Confirmations:   confirmations,
+			Coinbase:        coinbase,
+			Mature:          sp.mature,
+			SpendableHeight: sp.height,
+		})
+	}
+
+	return results, nil
+}
+
+// handleLockValidateKeys implements the lockvalidatekeys command.
+func handleLockValidateKeys(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if err := auditValidateKeys(s, "lockvalidatekeys", nil); err != nil {
+		return nil, err
+	}
+	s.server.validateKeyStore.lock()
+	rpcsLog.Infof("Locked validate keys")
+
+	return nil, nil
+}
+
+// handlePing implements the ping command.
+func handlePing(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// Ask server to ping \o_
+	nonce, err := wire.RandomUint64()
+	if err != nil {
+		return nil, internalRPCError("Not sending ping - failed to "+
+			"generate nonce: "+err.Error(), "")
+	}
+	s.server.BroadcastMessage(wire.NewMsgPing(nonce))
+
+	return nil, nil
+}
+
+// handlePrioritiseTransaction implements the prioritisetransaction command.
+func handlePrioritiseTransaction(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.PrioritiseTransactionCmd)
+
+	txHash, err := chainhash.NewHashFromStr(c.TxID)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.TxID)
+	}
+
+	feeDelta := s.server.txMemPool.PrioritiseTransaction(txHash, c.FeeDelta)
+	rpcsLog.Infof("Fee delta of transaction %v set to %d atoms", txHash,
+		feeDelta)
+
+	// Potentially notify any getblocktemplate long poll clients about
+	// stale block templates due to the changed selection.
+	s.gbtWorkState.NotifyMempoolTx(s.server.txMemPool.LastUpdated())
+
+	return feeDelta, nil
+}
+
+// handleGetRPCInfo implements the getrpcinfo command.
+func handleGetRPCInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	return s.tracker.info(), nil
+}
+
+// handleReloadConfig implements the reloadconfig command.
+func handleReloadConfig(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if err := s.server.ReloadConfig(); err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: err.Error(),
+		}
+	}
+
+	return "Done.", nil
+}
+
+// retrievedTx represents a transaction that was either loaded from the
+// transaction memory pool or from the database.  When a transaction is loaded
+// from the database, it is loaded with the raw serialized bytes while the
+// mempool has the fully deserialized structure.  This structure therefore will
+// have one of the two fields set depending on where is was retrieved from.
+// This is mainly done for efficiency to avoid extra serialization steps when
+// possible.
+type retrievedTx struct {
+	txBytes []byte
+	blkHash *chainhash.Hash // Only set when transaction is in a block.
+	tx      *provautil.Tx
+}
+
+// fetchInputTxos fetches the outpoints from all transactions referenced by the
+// inputs to the passed transaction by checking the transaction mempool first
+// then the transaction index for those already mined into blocks.
+func fetchInputTxos(s *rpcServer, tx *wire.MsgTx) (map[wire.OutPoint]wire.TxOut, error) {
+	mp := s.server.txMemPool
+	originOutputs := make(map[wire.OutPoint]wire.TxOut)
+	for txInIndex, txIn := range tx.TxIn {
+		// Attempt to fetch and use the referenced transaction from the
+		// memory pool.
+		origin := &txIn.PreviousOutPoint
+		originTx, err := mp.FetchTransaction(&origin.Hash)
+		if err == nil {
+			txOuts := originTx.MsgTx().TxOut
+			if origin.Index >= uint32(len(txOuts)) {
+				errStr := fmt.Sprintf("unable to find output "+
+					"%v referenced from transaction %s:%d",
+					origin, tx.TxHash(), txInIndex)
+				return nil, internalRPCError(errStr, "")
+			}
+
+			originOutputs[*origin] = *txOuts[origin.Index]
+			continue
+		}
+
+		// Look up the location of the transaction.
+		blockRegion, err := s.server.txIndex.TxBlockRegion(&origin.Hash)
+		if err != nil {
+			context := "Failed to retrieve transaction location"
+			return nil, internalRPCError(err.Error(), context)
+		}
+		if blockRegion == nil {
+			return nil, rpcNoTxInfoError(&origin.Hash)
+		}
+
+		// Load the raw transaction bytes from the database.
+		var txBytes []byte
+		err = s.server.db.View(func(dbTx database.Tx) error {
+			var err error
+			txBytes, err = dbTx.FetchBlockRegion(blockRegion)
+			return err
+		})
+		if err != nil {
+			return nil, rpcNoTxInfoError(&origin.Hash)
+		}
+
+		// Deserialize the transaction
+		var msgTx wire.MsgTx
+		err = msgTx.Deserialize(bytes.NewReader(txBytes))
+		if err != nil {
+			context := "Failed to deserialize transaction"
+			return nil, internalRPCError(err.Error(), context)
+		}
+
+		// Add the referenced output to the map.
+		if origin.Index >= uint32(len(msgTx.TxOut)) {
+			errStr := fmt.Sprintf("unable to find output %v "+
+				"referenced from transaction %s:%d", origin,
+				tx.TxHash(), txInIndex)
+			return nil, internalRPCError(errStr, "")
+		}
+		originOutputs[*origin] = *msgTx.TxOut[origin.Index]
+	}
+
+	return originOutputs, nil
+}
+
+// createVinListPrevOut returns a slice of JSON objects for the inputs of the
+// passed transaction.
+func createVinListPrevOut(s *rpcServer, mtx *wire.MsgTx, chainParams *chaincfg.Params, vinExtra bool, filterAddrMap map[string]struct{}) ([]btcjson.VinPrevOut, error) {
+	// Coinbase transactions only have a single txin by definition.
+	if blockchain.IsCoinBaseTx(mtx) {
+		// Only include the transaction if the filter map is empty
+		// because a coinbase input has no addresses and so would never
+		// match a non-empty filter.
+		if len(filterAddrMap) != 0 {
+			return nil, nil
+		}
+
+		txIn := mtx.TxIn[0]
+		vinList := make([]btcjson.VinPrevOut, 1)
+		vinList[0].Coinbase = hex.EncodeToString(txIn.SignatureScript)
+		vinList[0].Sequence = txIn.Sequence
+		return vinList, nil
+	}
+
+	// Use a dynamically sized list to accommodate the address filter.
+	vinList := make([]btcjson.VinPrevOut, 0, len(mtx.TxIn))
+
+	// Lookup all of the referenced transaction outputs needed to populate
+	// the previous output information if requested.
+	var originOutputs map[wire.OutPoint]wire.TxOut
+	if vinExtra || len(filterAddrMap) > 0 {
+		var err error
+		originOutputs, err = fetchInputTxos(s, mtx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, txIn := range mtx.TxIn {
+		// The disassembled string will contain [error] inline
+		// if the script doesn't fully parse, so ignore the
+		// error here.
+		disbuf, _ := txscript.DisasmString(txIn.SignatureScript)
+
+		// Create the basic input entry without the additional optional
+		// previous output details which will be added later if
+		// requested and available.
+		prevOut := &txIn.PreviousOutPoint
+		vinEntry := btcjson.VinPrevOut{
+			Txid:     prevOut.Hash.String(),
+			Vout:     prevOut.Index,
+			Sequence: txIn.Sequence,
+			ScriptSig: &btcjson.ScriptSig{
+				Asm: disbuf,
+				Hex: hex.EncodeToString(txIn.SignatureScript),
+			},
+		}
+
+		// Add the entry to the list now if it already passed the filter
+		// since the previous output might not be available.
+		passesFilter := len(filterAddrMap) == 0
+		if passesFilter {
+			vinList = append(vinList, vinEntry)
+		}
+
+		// Only populate previous output information if requested and
+		// available.
+		if len(originOutputs) == 0 {
+			continue
+		}
+		originTxOut, ok := originOutputs[*prevOut]
+		if !ok {
+			continue
+		}
+
+		// Ignore the error here since an error means the script
+		// couldn't parse and there is no additional information about
+		// it anyways.
+		_, addrs, _, _ := txscript.ExtractPkScriptAddrs(
+			originTxOut.PkScript, chainParams)
+
+		// Encode the addresses while checking if the address passes the
+		// filter when needed.
+		encodedAddrs := make([]string, len(addrs))
+		for j, addr := range addrs {
+			encodedAddr := addr.EncodeAddress()
+			encodedAddrs[j] = encodedAddr
+
+			// No need to check the map again if the filter already
+			// passes.
+			if passesFilter {
+				continue
+			}
+			if _, exists := filterAddrMap[encodedAddr]; exists {
+				passesFilter = true
+			}
+		}
+
+		// Ignore the entry if it doesn't pass the filter.
+		if !passesFilter {
+			continue
+		}
+
+		// Add entry to the list if it wasn't already done above.
+		if len(filterAddrMap) != 0 {
+			vinList = append(vinList, vinEntry)
+		}
+
+		// Update the entry with previous output information if
+		// requested.
+		if vinExtra {
+			vinListEntry := &vinList[len(vinList)-1]
+			vinListEntry.PrevOut = &btcjson.PrevOut{
+				Addresses: encodedAddrs,
+				Value:     provautil.Amount(originTxOut.Value).ToDMG(),
+			}
+		}
+	}
+
+	return vinList, nil
+}
+
+// fetchMempoolTxnsForAddress queries the address index for all unconfirmed
+// transactions that involve the provided address.  The results will be limited
+// by the number to skip and the number requested.
+func fetchMempoolTxnsForAddress(s *rpcServer, addr provautil.Address, numToSkip, numRequested uint32) ([]*provautil.Tx, uint32) {
+	// There are no entries to return when there are less available than the
+	// number being skipped.
+	mpTxns := s.server.addrIndex.UnconfirmedTxnsForAddress(addr)
+	numAvailable := uint32(len(mpTxns))
+	if numToSkip > numAvailable {
+		return nil, numAvailable
+	}
+
+	// Filter the available entries based on the number to skip and number
+	// requested.
+	rangeEnd := numToSkip + numRequested
+	if rangeEnd > numAvailable {
+		rangeEnd = numAvailable
+	}
+	return mpTxns[numToSkip:rangeEnd], numToSkip
+}
+
+// handleRPCDiscover implements the rpc.discover command.
+func handleRPCDiscover(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	doc, err := s.helpCacher.rpcOpenRPC()
+	if err != nil {
+		context := "Failed to generate OpenRPC document"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	return doc, nil
+}
+
+// handleSearchRawTransactions implements the searchrawtransactions command.
+func handleSearchRawTransactions(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// Respond with an error if the address index is not enabled.
+	addrIndex := s.server.addrIndex
+	if addrIndex == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Address index must be enabled (--addrindex)",
+		}
+	}
+
+	// Override the flag for including extra previous output information in
+	// each input if needed.
+	c := cmd.(*btcjson.SearchRawTransactionsCmd)
+	vinExtra := false
+	if c.VinExtra != nil {
+		vinExtra = *c.VinExtra != 0
+	}
+
+	// Including the extra previous output information requires the
+	// transaction index.  Currently the address index relies on the
+	// transaction index, so this check is redundant, but it's better to be
+	// safe in case the address index is ever changed to not rely on it.
+	if vinExtra && s.server.txIndex == nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCMisc,
+			Message: "Transaction index must be enabled (--txindex)",
+		}
+	}
+
+	// Attempt to decode the supplied address.
+	addr, err := provautil.DecodeAddress(c.Address, s.server.chainParams)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidAddressOrKey,
+			Message: "Invalid address or key: " + err.Error(),
+		}
+	}
+
+	// Override the default number of requested entries if needed.  Also,
+	// just return now if the number of requested entries is zero to avoid
+	// extra work.
+	numRequested := 100
+	if c.Count != nil {
+		numRequested = *c.Count
+		if numRequested < 0 {
+			numRequested = 1
+		}
+	}
+	if numRequested == 0 {
+		return nil, nil
+	}
+
+	// Override the default number of entries to skip if needed.
+	var numToSkip int
+	if c.Skip != nil {
+		numToSkip = *c.Skip
+		if numToSkip < 0 {
+			numToSkip = 0
+		}
+	}
+
+	// Override the reverse flag if needed.
+	var reverse bool
+	if c.Reverse != nil {
+		reverse = *c.Reverse
+	}
+
+	// Add transactions from mempool first if client asked for reverse
+	// order.  Otherwise, they will be added last (as needed depending on
+	// the requested counts).
+	//
+	// NOTE: This code doesn't sort by dependency.  This might be something
+	// to do in the future for the client's convenience, or leave it to the
+	// client.
+	numSkipped := uint32(0)
+	addressTxns := make([]retrievedTx, 0, numRequested)
+	if reverse {
+		// Transactions in the mempool are not in a block header yet,
+		// so the block header field in the retieved transaction struct
+		// is left nil.
+		mpTxns, mpSkipped := fetchMempoolTxnsForAddress(s, addr,
+			uint32(numToSkip), uint32(numRequested))
+		numSkipped += mpSkipped
+		for _, tx := range mpTxns {
+			addressTxns = append(addressTxns, retrievedTx{tx: tx})
+		}
+	}
+
+	// Fetch transactions from the database in the desired order if more are
+	// needed.
+	if len(addressTxns) < numRequested {
+		err = s.server.db.View(func(dbTx database.Tx) error {
+			regions, dbSkipped, err := addrIndex.TxRegionsForAddress(
+				dbTx, addr, uint32(numToSkip)-numSkipped,
+				uint32(numRequested-len(addressTxns)), reverse)
+			if err != nil {
+				return err
+			}
+
+			// Load the raw transaction bytes from the database.
+			serializedTxns, err := dbTx.FetchBlockRegions(regions)
+			if err != nil {
+				return err
+			}
+
+			// Add the transaction and the hash of the block it is
+			// contained in to the list.  Note that the transaction
+			// is left serialized here since the caller might have
+			// requested non-verbose output and hence there would be
+			// no point in deserializing it just to reserialize it
+			// later.
+			for i, serializedTx := range serializedTxns {
+				addressTxns = append(addressTxns, retrievedTx{
+					txBytes: serializedTx,
+					blkHash: regions[i].Hash,
+				})
+			}
+			numSkipped += dbSkipped
+
+			return nil
+		})
+		if err != nil {
+			context := "Failed to load address index entries"
+			return nil, internalRPCError(err.Error(), context)
+		}
+
+	}
+
+	// Add transactions from mempool last if client did not request reverse
+	// order and the number of results is still under the number requested.
+	if !reverse && len(addressTxns) < numRequested {
+		// Transactions in the mempool are not in a block header yet,
+		// so the block header field in the retieved transaction struct
+		// is left nil.
+		mpTxns, mpSkipped := fetchMempoolTxnsForAddress(s, addr,
+			uint32(numToSkip)-numSkipped, uint32(numRequested-
+				len(addressTxns)))
+		numSkipped += mpSkipped
+		for _, tx := range mpTxns {
+			addressTxns = append(addressTxns, retrievedTx{tx: tx})
+		}
+	}
+
+	// Address has never been used if neither source yielded any results.
+	if len(addressTxns) == 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCNoTxInfo,
+			Message: "No information available about address",
+		}
+	}
+
+	// Serialize all of the transactions to hex.
+	hexTxns := make([]string, len(addressTxns))
+	for i := range addressTxns {
+		// Simply encode the raw bytes to hex when the retrieved
+		// transaction is already in serialized form.
+		rtx := &addressTxns[i]
+		if rtx.txBytes != nil {
+			hexTxns[i] = hex.EncodeToString(rtx.txBytes)
+			continue
+		}
+
+		// Serialize the transaction first and convert to hex when the
+		// retrieved transaction is the deserialized structure.
+		hexTxns[i], err = messageToHex(rtx.tx.MsgTx())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// When not in verbose mode, simply return a list of serialized txns.
+	if c.Verbose != nil && *c.Verbose == 0 {
+		return hexTxns, nil
+	}
+
+	// Normalize the provided filter addresses (if any) to ensure there are
+	// no duplicates.
+	filterAddrMap := make(map[string]struct{})
+	if c.FilterAddrs != nil && len(*c.FilterAddrs) > 0 {
+		for _, addr := range *c.FilterAddrs {
+			filterAddrMap[addr] = struct{}{}
+		}
+	}
+
+	// The verbose flag is set, so generate the JSON object and return it.
+	best := s.chain.BestSnapshot()
+	chainParams := s.server.chainParams
+	srtList := make([]btcjson.SearchRawTransactionsResult, len(addressTxns))
+	for i := range addressTxns {
+		// The deserialized transaction is needed, so deserialize the
+		// retrieved transaction if it's in serialized form (which will
+		// be the case when it was lookup up from the database).
+		// Otherwise, use the existing deserialized transaction.
+		rtx := &addressTxns[i]
+		var mtx *wire.MsgTx
+		if rtx.tx == nil {
+			// Deserialize the transaction.
+			mtx = new(wire.MsgTx)
+			err := mtx.Deserialize(bytes.NewReader(rtx.txBytes))
+			if err != nil {
+				context := "Failed to deserialize transaction"
+				return nil, internalRPCError(err.Error(),
+					context)
+			}
+		} else {
+			mtx = rtx.tx.MsgTx()
+		}
+
+		result := &srtList[i]
+		result.Hex = hexTxns[i]
+		result.Txid = mtx.TxHash().String()
+		result.Vin, err = createVinListPrevOut(s, mtx, chainParams,
+			vinExtra, filterAddrMap)
+		if err != nil {
+			return nil, err
+		}
+		result.Vout = createVoutList(mtx, chainParams, filterAddrMap)
+		result.Version = mtx.Version
+		result.LockTime = mtx.LockTime
+
+		// Transactions grabbed from the mempool aren't yet in a block,
+		// so conditionally fetch block details here.  This will be
+		// reflected in the final JSON output (mempool won't have
+		// confirmations or block information).
+		var blkHeader *wire.BlockHeader
+		var blkHashStr string
+		var blkHeight uint32
+		if blkHash := rtx.blkHash; blkHash != nil {
+			// Fetch the header from chain.
+			header, err := s.chain.FetchHeader(blkHash)
+			if err != nil {
+				return nil, &btcjson.RPCError{
+					Code:    btcjson.ErrRPCBlockNotFound,
+					Message: "Block not found",
+				}
+			}
+
+			// Get the block height from chain.
+			height, err := s.chain.BlockHeightByHash(blkHash)
+			if err != nil {
+				context := "Failed to obtain block height"
+				return nil, internalRPCError(err.Error(), context)
+			}
+
+			blkHeader = &header
+			blkHashStr = blkHash.String()
+			blkHeight = height
+		}
+
+		// Add the block information to the result if there is any.
+		if blkHeader != nil {
+			// This is not a typo, they are identical in Bitcoin
+			// Core as well.
+			result.Time = blkHeader.Timestamp.Unix()
+			result.Blocktime = blkHeader.Timestamp.Unix()
+			result.BlockHash = blkHashStr
+			result.Confirmations = uint64(1 + best.Height - blkHeight)
+		}
+	}
+
+	return srtList, nil
+}
+
+// handleSendRawTransaction implements the sendrawtransaction command.
+func handleSendRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.SendRawTransactionCmd)
+	// Deserialize and send off to tx relay
+	hexStr := c.HexTx
+	if len(hexStr)%2 != 0 {
+		hexStr = "0" + hexStr
+	}
+	serializedTx, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hexStr)
+	}
+	var msgTx wire.MsgTx
+	err = msgTx.Deserialize(bytes.NewReader(serializedTx))
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDeserialization,
+			Message: "TX decode failed: " + err.Error(),
+		}
+	}
+
+	// User 0 for the tag to represent local node
+	tx := provautil.NewTx(&msgTx)
+	acceptedTxs, err := s.server.txMemPool.ProcessTransaction(tx, false, false, 0)
+	if err != nil {
+		// When the error is a rule error, it means the transaction was
+		// simply rejected as opposed to something actually going wrong,
+		// so log it as such.  Otherwise, something really did go wrong,
+		// so log it as an actual error.  In both cases, a JSON-RPC
+		// error is returned to the client with the deserialization
+		// error code (to match bitcoind behavior).
+		if _, ok := err.(mempool.RuleError); ok {
+			rpcsLog.Debugf("Rejected transaction %v: %v", tx.Hash(),
+				err)
+		} else {
+			rpcsLog.Errorf("Failed to process transaction %v: %v",
+				tx.Hash(), err)
+		}
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDeserialization,
+			Message: "TX rejected: " + err.Error(),
+		}
+	}
+
+	// Admin transactions which spend a thread tip with fewer than the
+	// confirmations set with --minadmintxconfs are held instead of being
+	// accepted, and are relayed once it has them.
+	if len(acceptedTxs) == 0 && s.server.txMemPool.IsAdminTxHeld(tx.Hash()) {
+		rpcsLog.Infof("Holding admin transaction %v until the thread "+
+			"tip it spends has %d confirmations", tx.Hash(),
+			cfg.MinAdminTxConfs)
+		return tx.Hash().String(), nil
+	}
+
+	// When the transaction was accepted it should be the first item in the
+	// returned array of accepted transactions.  The only way this will not
+	// be true is if the API for ProcessTransaction changes and this code is
+	// not properly updated, but ensure the condition holds as a safeguard.
+	//
+	// Also, since an error is being returned to the caller, ensure the
+	// transaction is removed from the memory pool.
+	if len(acceptedTxs) == 0 || !acceptedTxs[0].Tx.Hash().IsEqual(tx.Hash()) {
+		s.server.txMemPool.RemoveTransaction(tx, true)
+
+		errStr := fmt.Sprintf("transaction %v is not in accepted list",
+			tx.Hash())
+		return nil, internalRPCError(errStr, "")
+	}
+
+	s.server.AnnounceNewTransactions(acceptedTxs)
+
+	// Keep track of all the sendrawtransaction request txns so that they
+	// can be rebroadcast if they don't make their way into a block.
+	txD := acceptedTxs[0]
+	s.server.AddRebroadcastInventory(txInvVect(txD.Tx), txD)
+
+	return tx.Hash().String(), nil
+}
+
+// handleSendAnnouncement implements the sendannouncement command.  The signed
+// governance announcement is verified against the admin keys of the main chain
+// and relayed to the network, and its hash is returned.
+func handleSendAnnouncement(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.SendAnnouncementCmd)
+
+	serialized, err := hex.DecodeString(c.HexAnnouncement)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.HexAnnouncement)
+	}
+	var msg wire.MsgAnnounce
+	r := bytes.NewReader(serialized)
+	err = msg.BtcDecode(r, wire.AnnounceVersion)
+	if err == nil && r.Len() != 0 {
+		err = fmt.Errorf("%d bytes after the announcement", r.Len())
+	}
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDeserialization,
+			Message: "Announcement decode failed: " + err.Error(),
+		}
+	}
+
+	if _, err := s.server.ProcessAnnouncement(&msg, nil); err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCVerify,
+			Message: "Rejected announcement: " + err.Error(),
+		}
+	}
+	return msg.AnnounceHash().String(), nil
+}
+
+// handleSendRawPackage implements the sendrawpackage command.  The
+// transactions are accepted into the memory pool atomically and relayed to
+// the network in the passed order.
+func handleSendRawPackage(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.SendRawPackageCmd)
+
+	// Deserialize all of the transactions before attempting to process
+	// any of them.
+	txns := make([]*provautil.Tx, 0, len(c.HexTxs))
+	for _, hexStr := range c.HexTxs {
+		if len(hexStr)%2 != 0 {
+			hexStr = "0" + hexStr
+		}
+		serializedTx, err := hex.DecodeString(hexStr)
+		if err != nil {
+			return nil, rpcDecodeHexError(hexStr)
+		}
+		var msgTx wire.MsgTx
+		err = msgTx.Deserialize(bytes.NewReader(serializedTx))
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCDeserialization,
+				Message: "TX decode failed: " + err.Error(),
+			}
+		}
+		txns = append(txns, provautil.NewTx(&msgTx))
+	}
+
+	acceptedTxs, err := s.server.txMemPool.ProcessPackage(txns, false)
+	if err != nil {
+		// When the error is a rule error, it means the package was
+		// simply rejected as opposed to something actually going wrong,
+		// so log it as such.
+		if _, ok := err.(mempool.RuleError); ok {
+			rpcsLog.Debugf("Rejected package of %d transactions: %v",
+				len(txns), err)
+		} else {
+			rpcsLog.Errorf("Failed to process package of %d "+
+				"transactions: %v", len(txns), err)
+		}
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDeserialization,
+			Message: "Package rejected: " + err.Error(),
+		}
+	}
+
+	s.server.AnnounceNewTransactions(acceptedTxs)
+
+	// Keep track of all the package transactions so that they can be
+	// rebroadcast if they don't make their way into a block.  The accepted
+	// list starts with the package transactions in the passed order.
+	hashes := make([]string, 0, len(txns))
+	for _, txD := range acceptedTxs[:len(txns)] {
+		s.server.AddRebroadcastInventory(txInvVect(txD.Tx), txD)
+		hashes = append(hashes, txD.Tx.Hash().String())
+	}
+
+	return hashes, nil
+}
+
+// handleSetGenerate implements the setgenerate command.
+func handleSetGenerate(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.SetGenerateCmd)
+
+	// Disable generation regardless of the provided generate flag if the
+	// maximum number of threads (goroutines for our purposes) is 0.
+	// Otherwise enable or disable it depending on the provided flag.
+	generate := c.Generate
+	genProcLimit := -1
+	if c.GenProcLimit != nil {
+		genProcLimit = *c.GenProcLimit
+	}
+	if genProcLimit == 0 {
+		generate = false
+	}
+
+	if !generate {
+		s.server.cpuMiner.Stop()
+
+		return nil, nil
+	}
+
+	// Respond with an error if there are no addresses to pay the
+	// created blocks to.
+	if len(cfg.miningAddrs) == 0 {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInternal.Code,
+			Message: "No payment addresses specified " +
+				"via --miningaddr",
+		}
+	}
+
+	// Respond with an error if there are no validate keys available to
+	// sign the created blocks.
+	if len(s.server.cpuMiner.ValidateKeys()) == 0 {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInternal.Code,
+			Message: "No validate keys found. Set validate keys " +
+				"using setvalidatekeys",
+		}
+	}
+
+	// It's safe to call start even if it's already started.
+	s.server.cpuMiner.SetNumWorkers(int32(genProcLimit))
+	s.server.cpuMiner.Start()
+
+	return nil, nil
+}
+
+// handleSetTemplateExclusion implements the settemplateexclusion command.
+func handleSetTemplateExclusion(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.SetTemplateExclusionCmd)
+
+	var add bool
+	switch c.SubCmd {
+	case btcjson.TEAdd:
+		add = true
+	case btcjson.TERemove:
+	default:
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "invalid subcommand for settemplateexclusion",
+		}
+	}
+
+	// The target is a transaction hash when it is 64 hex characters, and
+	// an address otherwise.
+	data := &auditlog.TemplateExclusionData{Command: string(c.SubCmd)}
+	var txHash *chainhash.Hash
+	var addr provautil.Address
+	if _, err := hex.DecodeString(c.Target); err == nil &&
+		len(c.Target) == chainhash.MaxHashStringSize {
+
+		txHash, err = chainhash.NewHashFromStr(c.Target)
+		if err != nil {
+			return nil, rpcDecodeHexError(c.Target)
+		}
+		data.TxID = txHash.String()
+	} else {
+		var err error
+		addr, err = provautil.DecodeAddress(c.Target,
+			s.server.chainParams)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "Invalid address or transaction " +
+					"hash: " + err.Error(),
+			}
+		}
+		if !addr.IsForNet(s.server.chainParams) {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "Invalid address: " + c.Target +
+					" is for the wrong network",
+			}
+		}
+		data.Address = addr.EncodeAddress()
+	}
+
+	// Record the change in the audit log before applying it, so the
+	// exclusions never change without a record of it.
+	if l := s.server.auditLog; l != nil {
+		err := l.Append(auditlog.EventTemplateExclusion, data)
+		if err != nil {
+			context := "Failed to write audit log"
+			return nil, internalRPCError(err.Error(), context)
+		}
+	}
+	changed, err := s.server.templateExclusions.update(add, txHash, addr)
+	if err != nil {
+		context := "Failed to store template exclusions"
+		return nil, internalRPCError(err.Error(), context)
+	}
+	if changed {
+		rpcsLog.Infof("Template exclusion %s: %s", c.SubCmd, c.Target)
+
+		// Drop the cached block template, which may include the
+		// transactions excluded now.
+		s.gbtWorkState.discardTemplate()
+	}
+
+	return changed, nil
+}
+
+// handleSetValidateKeys implements the setvalidatekeys command.
+func handleSetValidateKeys(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.SetValidateKeysCmd)
+
+	if c.PrivKeys == nil || len(c.PrivKeys) == 0 {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInternal.Code,
+			Message: "No validate keys provided",
+		}
+	}
+	if c.Passphrase != nil && *c.Passphrase == "" {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCInvalidParameter,
+			Message: "Passphrase must not be empty",
+		}
+	}
+	validateKeys := make([]*btcec.PrivateKey, len(c.PrivKeys))
+	for i, privKeyStr := range c.PrivKeys {
+		privKeyBytes, err := hex.DecodeString(privKeyStr)
+		if err != nil {
+			return nil, rpcDecodeHexError(privKeyStr)
+		}
+		privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), privKeyBytes)
+		validateKeys[i] = privKey
+	}
+
+	if err := auditValidateKeys(s, "setvalidatekeys", validateKeys); err != nil {
+		return nil, err
+	}
+
+	// Store the keys encrypted on disk when a passphrase is supplied, so
+	// they can be unlocked after a restart.
+	if c.Passphrase != nil {
+		err := s.server.validateKeyStore.save(*c.Passphrase, validateKeys)
+		if err != nil {
+			context := "Failed to store validate keys"
+			return nil, internalRPCError(err.Error(), context)
+		}
+	}
+	s.server.validateKeyStore.set(validateKeys, 0)
+	plural := ""
+	if len(c.PrivKeys) != 1 {
+		plural = "s"
+	}
+	if c.Passphrase != nil {
+		rpcsLog.Infof("Set and stored %d validate key%s",
+			len(c.PrivKeys), plural)
+	} else {
+		rpcsLog.Infof("Set %d validate key%s", len(c.PrivKeys), plural)
+	}
+
+	return nil, nil
+}
+
+// auditValidateKeys records a change of the validate keys by the passed RPC
+// method in the audit log, when it is enabled.  The change is recorded before
+// it is applied, so keys are never used without a record of them.
+func auditValidateKeys(s *rpcServer, method string, validateKeys []*btcec.PrivateKey) error {
+	l := s.server.auditLog
+	if l == nil {
+		return nil
+	}
+
+	var pubKeys []string
+	for _, privKey := range validateKeys {
+		pubKeys = append(pubKeys, hex.EncodeToString(
+			privKey.PubKey().SerializeCompressed()))
+	}
+	err := l.Append(auditlog.EventRPC, &auditlog.RPCData{
+		Method:  method,
+		PubKeys: pubKeys,
+	})
+	if err != nil {
+		context := "Failed to write audit log"
+		return internalRPCError(err.Error(), context)
+	}
+	return nil
+}
+
+// handleStop implements the stop command.
+func handleStop(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	select {
+	case s.requestProcessShutdown <- struct{}{}:
+	default:
+	}
+	return "Dmgd stopping.", nil
+}
+
+// handleSubmitBlock implements the submitblock command.
+func handleSubmitBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.SubmitBlockCmd)
+
+	// Deserialize the submitted block.
+	hexStr := c.HexBlock
+	if len(hexStr)%2 != 0 {
+		hexStr = "0" + c.HexBlock
+	}
+	serializedBlock, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hexStr)
+	}
+
+	block, err := provautil.NewBlockFromBytes(serializedBlock)
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDeserialization,
+			Message: "Block decode failed: " + err.Error(),
+		}
+	}
+
+	_, err = s.server.blockManager.ProcessBlock(block, blockchain.BFNone)
+	if err != nil {
+		return fmt.Sprintf("rejected: %s", err.Error()), nil
+	}
+
+	rpcsLog.Infof("Accepted block %s via submitblock", block.Hash())
+	return nil, nil
+}
+
+// handleUnlockValidateKeys implements the unlockvalidatekeys command.
+func handleUnlockValidateKeys(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.UnlockValidateKeysCmd)
+
+	if c.Timeout < 0 || c.Timeout > maxValidateKeysUnlockTimeout {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCInvalidParameter,
+			Message: fmt.Sprintf("Timeout must be between 0 and %d "+
+				"seconds", maxValidateKeysUnlockTimeout),
+		}
+	}
+	if !s.server.validateKeyStore.exists() {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCWalletWrongEncState,
+			Message: "No validate keys stored, store them with " +
+				"setvalidatekeys and a passphrase",
+		}
+	}
+	validateKeys, err := s.server.validateKeyStore.load(c.Passphrase)
+	if err == keystore.ErrWrongPassphrase {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCWalletPassphraseIncorrect,
+			Message: "The passphrase entered was incorrect",
+		}
+	}
+	if err != nil {
+		context := "Failed to load validate keys"
+		return nil, internalRPCError(err.Error(), context)
+	}
+
+	if err := auditValidateKeys(s, "unlockvalidatekeys", validateKeys); err != nil {
+		return nil, err
+	}
+	timeout := time.Duration(c.Timeout) * time.Second
+	s.server.validateKeyStore.set(validateKeys, timeout)
+	plural := ""
+	if len(validateKeys) != 1 {
+		plural = "s"
+	}
+	if timeout > 0 {
+		rpcsLog.Infof("Unlocked %d validate key%s for %v",
+			len(validateKeys), plural, timeout)
+	} else {
+		rpcsLog.Infof("Unlocked %d validate key%s", len(validateKeys),
+			plural)
+	}
+
+	return nil, nil
+}
+
+// handleValidateAddress implements the validateaddress command.
+func handleValidateAddress(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.ValidateAddressCmd)
+
+	result := btcjson.ValidateAddressChainResult{}
+	addr, err := provautil.DecodeAddress(c.Address, activeNetParams.Params)
+	if err != nil {
+		// Return the default value (false) for IsValid.
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	result.Address = addr.EncodeAddress()
+	provaAddr, isProva := addr.(*provautil.AddressProva)
+	if isProva {
+		result.Bech32m = provaAddr.EncodeBech32m()
+	}
+	result.Network = addressNetwork(addr)
+	if !addr.IsForNet(activeNetParams.Params) {
+		result.Error = fmt.Sprintf("address is for %s, not %s",
+			result.Network, activeNetParams.Params.Name)
+		return result, nil
+	}
+
+	script, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	result.ScriptType = txscript.GetScriptClass(script).String()
+	result.ScriptPubKey = hex.EncodeToString(script)
+
+	// Report whether the key ids of the address are assigned to ASP keys,
+	// since outputs paying an address with an unassigned key id can not
+	// be spent.
+	if isProva {
+		keyIDMap := s.chain.KeyIDs()
+		for _, keyID := range provaAddr.ScriptKeyIDs() {
+			keyIDResult := btcjson.ValidateAddressKeyIDResult{
+				KeyID: uint32(keyID),
+			}
+			if pubKey, ok := keyIDMap[keyID]; ok && pubKey != nil {
+				keyIDResult.Provisioned = true
+				keyIDResult.PubKey = hex.EncodeToString(
+					pubKey.SerializeCompressed())
+			}
+			result.KeyIDs = append(result.KeyIDs, keyIDResult)
+		}
+	}
+
+	if watchIndex := s.server.watchIndex; watchIndex != nil {
+		info, watched := watchIndex.AddrInfo(addr.ScriptAddress())
+		result.IsWatchOnly = &watched
+		if watched {
+			result.Label = info.Label
+			if info.Xpub != "" {
+				result.Xpub = info.Xpub
+				result.XpubIndex = &info.Index
+			}
+		}
+	}
+
+	result.IsValid = true
+	return result, nil
+}
+
+// addressNetwork returns the name of the network the passed address is encoded
+// for.  The active network is preferred when several networks share the same
+// address encoding.
+func addressNetwork(addr provautil.Address) string {
+	nets := []*chaincfg.Params{activeNetParams.Params,
+		&chaincfg.MainNetParams, &chaincfg.TestNetParams,
+		&chaincfg.RegressionNetParams, &chaincfg.SimNetParams}
+	for _, net := range nets {
+		if addr.IsForNet(net) {
+			return net.Name
+		}
+	}
+	return "unknown"
+}
+
+func verifyChain(s *rpcServer, level int32, depth uint32) error {
+	best := s.chain.BestSnapshot()
+	finishHeight := best.Height - depth
+	if finishHeight < 0 {
+		finishHeight = 0
+	}
+	rpcsLog.Infof("Verifying chain for %d blocks at level %d",
+		best.Height-finishHeight, level)
+
+	for height := best.Height; height > finishHeight; height-- {
+		// Level 0 just looks up the block.
+		block, err := s.chain.BlockByHeight(height)
+		if err != nil {
+			rpcsLog.Errorf("Verify is unable to fetch block at "+
+				"height %d: %v", height, err)
+			return err
+		}
+
+		// Level 1 does basic chain sanity checks.
+		if level > 0 {
+			err := blockchain.CheckBlockSanity(block,
+				activeNetParams.Params, s.server.timeSource)
+			if err != nil {
+				rpcsLog.Errorf("Verify is unable to validate "+
+					"block at hash %v height %d: %v",
+					block.Hash(), height, err)
+				return err
+			}
+		}
+	}
+	rpcsLog.Infof("Chain verify completed successfully")
+
+	return nil
+}
+
+// handleVerifyChain implements the verifychain command.
+func handleVerifyChain(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.VerifyChainCmd)
+
+	var checkLevel, checkDepth int32
+	if c.CheckLevel != nil {
+		checkLevel = *c.CheckLevel
+	}
+	if c.CheckDepth != nil {
+		checkDepth = *c.CheckDepth
+	}
+
+	err := verifyChain(s, checkLevel, uint32(checkDepth))
+	return err == nil, nil
+}
+
+// handleVerifyReserveAttestation implements the verifyreserveattestation
+// command.  The attestation must be signed and all blocks it references must
+// be in the main chain.
+func handleVerifyReserveAttestation(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*btcjson.VerifyReserveAttestationCmd)
+
+	hexStr := c.HexAttestation
+	if len(hexStr)%2 != 0 {
+		hexStr = "0" + hexStr
+	}
+	serialized, err := hex.DecodeString(hexStr)
+	if err != nil {
+		return nil, rpcDecodeHexError(hexStr)
+	}
+	var attestation reserve.Attestation
+	err = attestation.Deserialize(bytes.NewReader(serialized))
+	if err != nil {
+		return nil, &btcjson.RPCError{
+			Code:    btcjson.ErrRPCDeserialization,
+			Message: "Attestation decode failed: " + err.Error(),
+		}
+	}
+
+	result := &btcjson.VerifyReserveAttestationResult{
+		BlockHash:   attestation.BlockHash.String(),
+		BlockHeight: attestation.BlockHeight,
+		Outputs:     len(attestation.Proofs),
+		Total:       provautil.Amount(attestation.Total()).ToDMG(),
+	}
+	if attestation.PubKey != nil {
+		result.PubKey = hex.EncodeToString(
+			attestation.PubKey.SerializeCompressed())
+	}
+
+	// Only blocks of the main chain can back an attestation.
+	fetchHeader := func(hash *chainhash.Hash) (wire.BlockHeader, error) {
+		ok, err := s.chain.MainChainHasBlock(hash)
+		if err != nil {
+			return wire.BlockHeader{}, err
+		}
+		if !ok {
+			return wire.BlockHeader{}, errors.New("block is not in " +
+				"the main chain")
+		}
+		return s.chain.FetchHeader(hash)
+	}
+	if err := attestation.Verify(fetchHeader); err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	result.Valid = true
+	return result, nil
+}
+
+// rpcServer holds the items the rpc server may need to access (config,
+// shutdown, main server, etc.)
+type rpcServer struct {
+	started                int32
+	shutdown               int32
+	generator              *mining.BlkTmplGenerator
+	server                 *server
+	chain                  *blockchain.BlockChain
+	authMtx                sync.RWMutex
+	auths                  []rpcAuth
+	cookieFile             string
+	cookieAuth             *rpcAuth
+	tls                    *rpcTLS
+	tracker                *rpcTracker
+	ntfnMgr                *wsNotificationManager
+	numClients             int32
+	statusLines            map[int]string
+	statusLock             sync.RWMutex
+	wg                     sync.WaitGroup
+	listeners              []net.Listener
+	gbtWorkState           *gbtWorkState
+	helpCacher             *helpCacher
+	requestProcessShutdown chan struct{}
+	quit                   chan int
+}
+
+// httpStatusLine returns a response Status-Line (RFC 2616 Section 6.1)
+// for the given request and response status code.  This function was lifted and
+// adapted from the standard library HTTP server code since it's not exported.
+func (s *rpcServer) httpStatusLine(req *http.Request, code int) string {
+	// Fast path:
+	key := code
+	proto11 := req.ProtoAtLeast(1, 1)
+	if !proto11 {
+		key = -key
+	}
+	s.statusLock.RLock()
+	line, ok := s.statusLines[key]
+	s.statusLock.RUnlock()
+	if ok {
+		return line
+	}
+
+	// Slow path:
+	proto := "HTTP/1.0"
+	if proto11 {
+		proto = "HTTP/1.1"
+	}
+	codeStr := strconv.Itoa(code)
+	text := http.StatusText(code)
+	if text != "" {
+		line = proto + " " + codeStr + " " + text + "\r\n"
+		s.statusLock.Lock()
+		s.statusLines[key] = line
+		s.statusLock.Unlock()
+	} else {
+		text = "status code " + codeStr
+		line = proto + " " + codeStr + " " + text + "\r\n"
+	}
+
+	return line
+}
+
+// writeHTTPResponseHeaders writes the necessary response headers prior to
+// writing an HTTP body given a request to use for protocol negotiation, headers
+// to write, a status code, and a writer.
+func (s *rpcServer) writeHTTPResponseHeaders(req *http.Request, headers http.Header, code int, w io.Writer) error {
+	_, err := io.WriteString(w, s.httpStatusLine(req, code))
+	if err != nil {
+		return err
+	}
+
+	err = headers.Write(w)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\r\n")
+	return err
+}
+
+// Stop is used by server.go to stop the rpc listener.
+func (s *rpcServer) Stop() error {
+	if atomic.AddInt32(&s.shutdown, 1) != 1 {
+		rpcsLog.Infof("RPC server is already in the process of shutting down")
+		return nil
+	}
+	rpcsLog.Warnf("RPC server shutting down")
+	for _, listener := range s.listeners {
+		err := listener.Close()
+		if err != nil {
+			rpcsLog.Errorf("Problem shutting down rpc: %v", err)
+			return err
+		}
+	}
+	if s.cookieFile != "" {
+		if err := os.Remove(s.cookieFile); err != nil {
+			rpcsLog.Errorf("Unable to remove RPC cookie file: %v", err)
+		}
+	}
+	s.ntfnMgr.Shutdown()
+	s.ntfnMgr.WaitForShutdown()
+	close(s.quit)
+	s.wg.Wait()
+	rpcsLog.Infof("RPC server shutdown complete")
+	return nil
+}
+
+// RequestedProcessShutdown returns a channel that is sent to when an authorized
+// RPC client requests the process to shutdown.  A request is kept until it is
+// read, and further requests are dropped meanwhile.
+func (s *rpcServer) RequestedProcessShutdown() <-chan struct{} {
+	return s.requestProcessShutdown
+}
+
+// Call runs the passed command, which must be one of the registered btcjson
+// command types, with the rights of an admin user and returns its result.  It
+// allows programs which embed the node to issue RPC commands in-process.
+// Since there is no client connection which may close, long polling commands
+// only return once they are answered.
+//
+// This function is safe for concurrent access.
+func (s *rpcServer) Call(cmd interface{}) (interface{}, error) {
+	method, err := btcjson.CmdMethod(cmd)
+	if err != nil {
+		return nil, err
+	}
+	parsedCmd := parsedRPCCmd{method: method, cmd: cmd}
+	return s.standardCmdResult(&parsedCmd, nil)
+}
+
+// limitConnections responds with a 503 service unavailable and returns true if
+// adding another client would exceed the maximum allow RPC clients.
+//
+// This function is safe for concurrent access.
+func (s *rpcServer) limitConnections(w http.ResponseWriter, remoteAddr string) bool {
+	if int(atomic.LoadInt32(&s.numClients)+1) > cfg.RPCMaxClients {
+		rpcsLog.Infof("Max RPC clients exceeded [%d] - "+
+			"disconnecting client %s", cfg.RPCMaxClients,
+			remoteAddr)
+		http.Error(w, "503 Too busy.  Try again later.",
+			http.StatusServiceUnavailable)
+		return true
+	}
+	return false
+}
+
+// incrementClients adds one to the number of connected RPC clients.  Note
+// this only applies to standard clients.  Websocket clients have their own
+// limits and are tracked separately.
+//
+// This function is safe for concurrent access.
+func (s *rpcServer) incrementClients() {
+	atomic.AddInt32(&s.numClients, 1)
+}
+
+// decrementClients subtracts one from the number of connected RPC clients.
+// Note this only applies to standard clients.  Websocket clients have their own
+// limits and are tracked separately.
+//
+// This function is safe for concurrent access.
+func (s *rpcServer) decrementClients() {
+	atomic.AddInt32(&s.numClients, -1)
+}
+
+// checkAuth checks the HTTP Basic authentication supplied by a wallet
+// or RPC client in the HTTP request r.  If the supplied authentication
+// does not match the username and password expected, a non-nil error is
+// returned.
+//
+// This check is time-constant.
+//
+// The returned credentials specify the methods the user may call.  They are
+// nil when no authentication was supplied and it is not required.
+func (s *rpcServer) checkAuth(r *http.Request, require bool) (*rpcAuth, error) {
+	authhdr := r.Header["Authorization"]
+	if len(authhdr) <= 0 {
+		if require {
+			rpcsLog.Warnf("RPC authentication failure from %s",
+				r.RemoteAddr)
+			return nil, errors.New("auth failure")
+		}
+
+		return nil, nil
+	}
+
+	authsha := sha256.Sum256([]byte(authhdr[0]))
+	auth := s.lookupAuth(authsha)
+	if auth == nil {
+		// Request's auth doesn't match any user
+		rpcsLog.Warnf("RPC authentication failure from %s", r.RemoteAddr)
+		return nil, errors.New("auth failure")
+	}
+	return auth, nil
+}
+
+// parsedRPCCmd represents a JSON-RPC request object that has been parsed into
+// a known concrete command along with any error that might have happened while
+// parsing it.
+type parsedRPCCmd struct {
+	id     interface{}
+	method string
+	cmd    interface{}
+	err    *btcjson.RPCError
+}
+
+// standardCmdResult checks that a parsed command is a standard Bitcoin JSON-RPC
+// command and runs the appropriate handler to reply to the command.  Any
+// commands which are not recognized or not implemented will return an error
+// suitable for use in replies.
+func (s *rpcServer) standardCmdResult(cmd *parsedRPCCmd, closeChan <-chan struct{}) (interface{}, error) {
+	handler, ok := rpcHandlers[cmd.method]
+	if ok {
+		goto handled
+	}
+	_, ok = rpcAskWallet[cmd.method]
+	if ok {
+		handler = handleAskWallet
+		goto handled
+	}
+	_, ok = rpcUnimplemented[cmd.method]
+	if ok {
+		handler = handleUnimplemented
+		goto handled
+	}
+	return nil, btcjson.ErrRPCMethodNotFound
+handled:
+
+	return handler(s, cmd.cmd, closeChan)
+}
+
+// parseCmd parses a JSON-RPC request object into known concrete command.  The
+// err field of the returned parsedRPCCmd struct will contain an RPC error that
+// is suitable for use in replies if the command is invalid in some way such as
+// an unregistered command or invalid parameters.
+func parseCmd(request *btcjson.Request) *parsedRPCCmd {
+	var parsedCmd parsedRPCCmd
+	parsedCmd.id = request.ID
+	parsedCmd.method = request.Method
+
+	cmd, err := btcjson.UnmarshalCmd(request)
+	if err != nil {
+		// When the error is because the method is not registered,
+		// produce a method not found RPC error.
+		if jerr, ok := err.(btcjson.Error); ok &&
+			jerr.ErrorCode == btcjson.ErrUnregisteredMethod {
+
+			parsedCmd.err = btcjson.ErrRPCMethodNotFound
+			return &parsedCmd
+		}
+
+		// Otherwise, some type of invalid parameters is the
+		// cause, so produce the equivalent RPC error.
+		parsedCmd.err = btcjson.NewRPCError(
+			btcjson.ErrRPCInvalidParams.Code, err.Error())
+		return &parsedCmd
+	}
+
+	parsedCmd.cmd = cmd
+	return &parsedCmd
+}
+
+// createMarshalledReply returns a new marshalled JSON-RPC response given the
+// passed parameters.  It will automatically convert errors that are not of
+// the type *btcjson.RPCError to the appropriate type as needed.
+func createMarshalledReply(id, result interface{}, replyErr error) ([]byte, error) {
+	var jsonErr *btcjson.RPCError
+	if replyErr != nil {
+		if jErr, ok := replyErr.(*btcjson.RPCError); ok {
+			jsonErr = jErr
+		} else {
+			jsonErr = internalRPCError(replyErr.Error(), "")
+		}
+	}
+
+	return btcjson.MarshalResponse(id, result, jsonErr)
+}
+
+// jsonRPCRead handles reading and responding to RPC messages.
+func (s *rpcServer) jsonRPCRead(w http.ResponseWriter, r *http.Request, auth *rpcAuth) {
+	if atomic.LoadInt32(&s.shutdown) != 0 {
+		return
+	}
+
+	// Read and close the JSON-RPC request body from the caller.
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		errCode := http.StatusBadRequest
+		http.Error(w, fmt.Sprintf("%d error reading JSON message: %v",
+			errCode, err), errCode)
+		return
+	}
+
+	// Unfortunately, the http server doesn't provide the ability to
+	// change the read deadline for the new connection and having one breaks
+	// long polling.  However, not having a read deadline on the initial
+	// connection would mean clients can connect and idle forever.  Thus,
+	// hijack the connecton from the HTTP server, clear the read deadline,
+	// and handle writing the response manually.
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		errMsg := "webserver doesn't support hijacking"
+		rpcsLog.Warnf(errMsg)
+		errCode := http.StatusInternalServerError
+		http.Error(w, strconv.Itoa(errCode)+" "+errMsg, errCode)
+		return
+	}
+	conn, buf, err := hj.Hijack()
+	if err != nil {
+		rpcsLog.Warnf("Failed to hijack HTTP connection: %v", err)
+		errCode := http.StatusInternalServerError
+		http.Error(w, strconv.Itoa(errCode)+" "+err.Error(), errCode)
+		return
+	}
+	defer conn.Close()
+	defer buf.Flush()
+	conn.SetReadDeadline(timeZeroVal)
+
+	// Attempt to parse the raw body into a JSON-RPC request.
+	var responseID interface{}
+	var jsonErr error
+	var result interface{}
+	var request btcjson.Request
+	if err := json.Unmarshal(body, &request); err != nil {
+		jsonErr = &btcjson.RPCError{
+			Code:    btcjson.ErrRPCParse.Code,
+			Message: "Failed to parse request: " + err.Error(),
+		}
+	}
+	if jsonErr == nil {
+		// The JSON-RPC 1.0 spec defines that notifications must have their "id"
+		// set to null and states that notifications do not have a response.
+		//
+		// A JSON-RPC 2.0 notification is a request with "json-rpc":"2.0", and
+		// without an "id" member. The specification states that notifications
+		// must not be responded to. JSON-RPC 2.0 permits the null value as a
+		// valid request id, therefore such requests are not notifications.
+		//
+		// Bitcoin Core serves requests with "id":null or even an absent "id",
+		// and responds to such requests with "id":null in the response.
+		//
+		// Btcd does not respond to any request without and "id" or "id":null,
+		// regardless the indicated JSON-RPC protocol version unless RPC quirks
+		// are enabled. With RPC quirks enabled, such requests will be responded
+		// to if the reqeust does not indicate JSON-RPC version.
+		//
+		// RPC quirks can be enabled by the user to avoid compatibility issues
+		// with software relying on Core's behavior.
+		if request.ID == nil && !(cfg.RPCQuirks && request.Jsonrpc == "") {
+			return
+		}
+
+		// The parse was at least successful enough to have an ID so
+		// set it for the response.
+		responseID = request.ID
+
+		// Setup a close notifier.  Since the connection is hijacked,
+		// the CloseNotifer on the ResponseWriter is not available.
+		closeChan := make(chan struct{}, 1)
+		go func() {
+			_, err := conn.Read(make([]byte, 1))
+			if err != nil {
+				close(closeChan)
+			}
+		}()
+
+		// Check if the user is limited and set error if method unauthorized
+		if !auth.allowed(request.Method) {
+			jsonErr = &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParams.Code,
+				Message: "limited user not authorized for this method",
+			}
+		}
+
+		if jsonErr == nil {
+			// Attempt to parse the JSON-RPC request into a known concrete
+			// command.
+			parsedCmd := parseCmd(&request)
+			if parsedCmd.err != nil {
+				jsonErr = parsedCmd.err
+			} else {
+				// The ID of the call is returned to the client,
+				// so it can be correlated with the log.
+				call := s.tracker.start(parsedCmd.method,
+					r.RemoteAddr)
+				w.Header().Set("X-Request-Id",
+					strconv.FormatUint(call.id, 10))
+				result, jsonErr = s.standardCmdResult(parsedCmd, closeChan)
+				s.tracker.finish(call)
+			}
+		}
+	}
+
+	// Marshal the response.
+	msg, err := createMarshalledReply(responseID, result, jsonErr)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal reply: %v", err)
+		return
+	}
+
+	// Write the response.
+	err = s.writeHTTPResponseHeaders(r, w.Header(), http.StatusOK, buf)
+	if err != nil {
+		rpcsLog.Error(err)
+		return
+	}
+	if _, err := buf.Write(msg); err != nil {
+		rpcsLog.Errorf("Failed to write marshalled reply: %v", err)
+	}
+
+	// Terminate with newline to maintain compatibility with Bitcoin Core.
+	if err := buf.WriteByte('\n'); err != nil {
+		rpcsLog.Errorf("Failed to append terminating newline to reply: %v", err)
+	}
+}
+
+// jsonAuthFail sends a message back to the client if the http auth is rejected.
+func jsonAuthFail(w http.ResponseWriter) {
+	w.Header().Add("WWW-Authenticate", `Basic realm="Dmgd RPC"`)
+	http.Error(w, "401 Unauthorized.", http.StatusUnauthorized)
+}
+
+// Start is used by server.go to start the rpc listener.
+func (s *rpcServer) Start() {
+	if atomic.AddInt32(&s.started, 1) != 1 {
+		return
+	}
+
+	rpcsLog.Trace("Starting RPC server")
+	for _, listener := range s.listeners {
+		// Connections to limited listeners may only call the methods
+		// of the limited user.
+		_, limited := listener.(rpcLimitedListener)
+		httpServer := &http.Server{
+			Handler: s.serveMux(limited),
+
+			// Timeout connections which don't complete the initial
+			// handshake within the allowed timeframe.
+			ReadTimeout: time.Second * rpcAuthTimeoutSeconds,
+		}
+
+		s.wg.Add(1)
+		go func(listener net.Listener) {
+			rpcsLog.Infof("RPC server listening on %s", listener.Addr())
+			httpServer.Serve(listener)
+			rpcsLog.Tracef("RPC listener done for %s", listener.Addr())
+			s.wg.Done()
+		}(listener)
+	}
+
+	s.ntfnMgr.Start()
+}
+
+// serveMux returns the handler of the HTTP POST and websocket requests to a
+// listener of the RPC server.  The credentials of the requests to limited
+// listeners may only call the methods of the limited user.
+func (s *rpcServer) serveMux(limited bool) *http.ServeMux {
+	rpcServeMux := http.NewServeMux()
+	rpcServeMux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Connection", "close")
+		w.Header().Set("Content-Type", "application/json")
+		r.Close = true
+
+		// Limit the number of connections to max allowed.
+		if s.limitConnections(w, r.RemoteAddr) {
+			return
+		}
+
+		// Keep track of the number of connected clients.
+		s.incrementClients()
+		defer s.decrementClients()
+		auth, err := s.checkAuth(r, true)
+		if err != nil {
+			jsonAuthFail(w)
+			return
+		}
+		if limited {
+			auth = auth.limit()
+		}
+
+		// Read and respond to the request.
+		s.jsonRPCRead(w, r, auth)
+	})
+
+	// Websocket endpoint.
+	rpcServeMux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		auth, err := s.checkAuth(r, false)
+		if err != nil {
+			jsonAuthFail(w)
+			return
+		}
+		if limited && auth != nil {
+			auth = auth.limit()
+		}
+
+		// Attempt to upgrade the connection to a websocket connection
+		// using the default size for read/write buffers.
+		ws, err := websocket.Upgrade(w, r, nil, 0, 0)
+		if err != nil {
+			if _, ok := err.(websocket.HandshakeError); !ok {
+				rpcsLog.Errorf("Unexpected websocket error: %v",
+					err)
+			}
+			http.Error(w, "400 Bad Request.", http.StatusBadRequest)
+			return
+		}
+		s.WebsocketHandler(ws, r.RemoteAddr, auth, limited)
+	})
+
+	return rpcServeMux
+}
+
+// genCertPair generates a key/cert pair to the paths provided.
+func genCertPair(certFile, keyFile string) error {
+	rpcsLog.Infof("Generating TLS certificates...")
+
+	org := "Dmgd autogenerated cert"
+	validUntil := time.Now().Add(10 * 365 * 24 * time.Hour)
+	cert, key, err := provautil.NewTLSCertPair(org, validUntil, nil)
+	if err != nil {
+		return err
+	}
+
+	// Write cert and key files.
+	if err = ioutil.WriteFile(certFile, cert, 0666); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(keyFile, key, 0600); err != nil {
+		os.Remove(certFile)
+		return err
+	}
+
+	rpcsLog.Infof("Done generating TLS certificates")
+	return nil
+}
+
+// rpcAuth holds the hash of the authorization header of a set of RPC
+// credentials along with the methods they may call.
+type rpcAuth struct {
+	// user is the username of the credentials.  It is empty when only the
+	// hash of the credentials is configured.
+	user string
+
+	// sha is the hash of the authorization header.
+	sha [sha256.Size]byte
+
+	// methods holds the methods the credentials may call.  It is nil when
+	// all methods may be called, as for the admin user.
+	methods map[string]struct{}
+}
+
+// allowed returns whether the credentials may call the passed method.
+func (a *rpcAuth) allowed(method string) bool {
+	if a.methods == nil {
+		return true
+	}
+	_, ok := a.methods[method]
+	return ok
+}
+
+// limit returns the credentials restricted to the methods of the limited user
+// which they may call, for connections to limited listeners.
+func (a *rpcAuth) limit() *rpcAuth {
+	methods := make(map[string]struct{}, len(rpcLimited))
+	for method := range rpcLimited {
+		if a.allowed(method) {
+			methods[method] = struct{}{}
+		}
+	}
+	return &rpcAuth{user: a.user, sha: a.sha, methods: methods}
+}
+
+// rpcLimitedListener is a listener of the RPC server whose connections may
+// only call the methods of the limited user.
+type rpcLimitedListener struct {
+	net.Listener
+}
+
+// rpcAuthSha returns the hash of the HTTP basic authorization header of the
+// passed username and password.
+func rpcAuthSha(user, pass string) [sha256.Size]byte {
+	login := user + ":" + pass
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
+	return sha256.Sum256([]byte(auth))
+}
+
+// parseRPCAuthHash decodes the passed hex-encoded hash of RPC credentials.
+func parseRPCAuthHash(hash string) ([sha256.Size]byte, error) {
+	var sha [sha256.Size]byte
+	if len(hash) != hex.EncodedLen(sha256.Size) {
+		return sha, errors.New("invalid hash length")
+	}
+	decoded, err := hex.DecodeString(hash)
+	if err != nil {
+		return sha, err
+	}
+	copy(sha[:], decoded)
+	return sha, nil
+}
+
+// parseRPCAuth parses an rpcauth option of the form
+// <user>:<password>:<method>,<method>,...  The keyword limited allows the
+// methods of the limited user and * allows all methods.
+func parseRPCAuth(entry string) (*rpcAuth, error) {
+	fields := strings.Split(entry, ":")
+	if len(fields) < 3 || fields[0] == "" || fields[len(fields)-1] == "" {
+		return nil, errors.New("rpcauth must be of the form " +
+			"<user>:<password>:<method>,<method>,...")
+	}
+	user := fields[0]
+	pass := strings.Join(fields[1:len(fields)-1], ":")
+	if pass == "" {
+		return nil, fmt.Errorf("rpcauth user %s has no password", user)
+	}
+
+	methods := make(map[string]struct{})
+	for _, method := range strings.Split(fields[len(fields)-1], ",") {
+		switch method {
+		case "*":
+			return &rpcAuth{user: user, sha: rpcAuthSha(user, pass)},
+				nil
+
+		case "limited":
+			for method := range rpcLimited {
+				methods[method] = struct{}{}
+			}
+
+		default:
+			_, isRPC := rpcHandlers[method]
+			_, isWS := wsHandlers[method]
+			if !isRPC && !isWS {
+				return nil, fmt.Errorf("rpcauth user %s: unknown "+
+					"method %s", user, method)
+			}
+			methods[method] = struct{}{}
+		}
+	}
+
+	return &rpcAuth{
+		user:    user,
+		sha:     rpcAuthSha(user, pass),
+		methods: methods,
+	}, nil
+}
+
+// rpcAuths returns the credentials of the admin user, the limited user and the
+// rpcauth options of the passed configuration, skipping any which are not
+// configured.
+func rpcAuths(c *config) ([]rpcAuth, error) {
+	var auths []rpcAuth
+
+	// (Admin RPC User) First check for hash, then for user/password
+	if c.RPCHash != "" {
+		sha, err := parseRPCAuthHash(c.RPCHash)
+		if err != nil {
+			return nil, fmt.Errorf("RPCS: Invalid RPCHash: %v", err)
+		}
+		auths = append(auths, rpcAuth{sha: sha})
+	} else if c.RPCUser != "" && c.RPCPass != "" {
+		auths = append(auths, rpcAuth{
+			user: c.RPCUser,
+			sha:  rpcAuthSha(c.RPCUser, c.RPCPass),
+		})
+	}
+
+	// (Limited RPC User) First check for hash, then for user/password
+	if c.RPCLimitHash != "" {
+		sha, err := parseRPCAuthHash(c.RPCLimitHash)
+		if err != nil {
+			return nil, fmt.Errorf("RPCS: Invalid RPCLimitHash: %v",
+				err)
+		}
+		auths = append(auths, rpcAuth{sha: sha, methods: rpcLimited})
+	} else if c.RPCLimitUser != "" && c.RPCLimitPass != "" {
+		auths = append(auths, rpcAuth{
+			user:    c.RPCLimitUser,
+			sha:     rpcAuthSha(c.RPCLimitUser, c.RPCLimitPass),
+			methods: rpcLimited,
+		})
+	}
+
+	// Credentials with per-method permissions.
+	for _, entry := range c.RPCAuths {
+		auth, err := parseRPCAuth(entry)
+		if err != nil {
+			return nil, err
+		}
+		auths = append(auths, *auth)
+	}
+
+	// Usernames and credentials must be unique, so the permissions of a
+	// user are never ambiguous.
+	users := make(map[string]struct{}, len(auths))
+	shas := make(map[[sha256.Size]byte]struct{}, len(auths))
+	for _, auth := range auths {
+		if _, ok := shas[auth.sha]; ok {
+			return nil, errors.New("RPC credentials are specified " +
+				"more than once")
+		}
+		shas[auth.sha] = struct{}{}
+		if auth.user == "" {
+			continue
+		}
+		if auth.user == rpcCookieUser {
+			return nil, fmt.Errorf("RPC user %s is reserved for the "+
+				"cookie file", rpcCookieUser)
+		}
+		if _, ok := users[auth.user]; ok {
+			return nil, fmt.Errorf("RPC user %s is specified more "+
+				"than once", auth.user)
+		}
+		users[auth.user] = struct{}{}
+	}
+
+	return auths, nil
+}
+
+// rpcCookieUser is the username of the credentials written to the cookie file.
+const rpcCookieUser = "__cookie__"
+
+// writeRPCCookie generates random admin credentials and writes them to the
+// cookie file at the passed path as <user>:<password>.  Only the user running
+// dmgd may read the file.
+func writeRPCCookie(path string) (*rpcAuth, error) {
+	var secret [32]byte
+	if _, err := crand.Read(secret[:]); err != nil {
+		return nil, err
+	}
+	pass := hex.EncodeToString(secret[:])
+
+	// Write to a temporary file first, so clients never read a partially
+	// written cookie.
+	tmpPath := path + ".tmp"
+	err := ioutil.WriteFile(tmpPath, []byte(rpcCookieUser+":"+pass), 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+
+	return &rpcAuth{
+		user: rpcCookieUser,
+		sha:  rpcAuthSha(rpcCookieUser, pass),
+	}, nil
+}
+
+// listenUnix listens for RPC connections on a Unix domain socket at the passed
+// path which only the user running dmgd may access.  A socket left behind by a
+// previous instance which is no longer running is replaced.
+func listenUnix(path string) (net.Listener, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket",
+				path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is already in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// setAuth replaces the credentials which may connect to the RPC server.
+// Connections which have already authenticated are not affected.
+//
+// This function is safe for concurrent access.
+func (s *rpcServer) setAuth(auths []rpcAuth) {
+	// The cookie credentials are generated at startup rather than
+	// configured, so they are kept when the configuration is reloaded.
+	if s.cookieAuth != nil {
+		auths = append(auths[:len(auths):len(auths)], *s.cookieAuth)
+	}
+
+	s.authMtx.Lock()
+	s.auths = auths
+	s.authMtx.Unlock()
+}
+
+// lookupAuth returns the credentials whose authorization header has the
+// passed hash, or nil when there are none.
+//
+// This function is safe for concurrent access.
+func (s *rpcServer) lookupAuth(sha [sha256.Size]byte) *rpcAuth {
+	s.authMtx.RLock()
+	defer s.authMtx.RUnlock()
+
+	// All credentials are compared in constant time to avoid leaking which
+	// of them matched.
+	var match *rpcAuth
+	for i := range s.auths {
+		if subtle.ConstantTimeCompare(sha[:], s.auths[i].sha[:]) == 1 {
+			match = &s.auths[i]
+		}
+	}
+	return match
+}
+
+// newRPCServer returns a new instance of the rpcServer struct.
+func newRPCServer(listenAddrs []listenAddr, generator *mining.BlkTmplGenerator, s *server) (*rpcServer, error) {
+	rpc := rpcServer{
+		server:                 s,
+		generator:              generator,
+		chain:                  s.blockManager.chain,
+		statusLines:            make(map[int]string),
+		gbtWorkState:           newGbtWorkState(s.timeSource),
+		helpCacher:             newHelpCacher(),
+		tracker:                newRPCTracker(cfg.RPCSlowCall),
+		requestProcessShutdown: make(chan struct{}, 1),
+		quit: make(chan int),
+	}
+
+	auths, err := rpcAuths(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.RPCCookie {
+		cookieFile := filepath.Join(cfg.DataDir, rpcCookieFilename)
+		rpc.cookieAuth, err = writeRPCCookie(cookieFile)
+		if err != nil {
+			return nil, fmt.Errorf("RPCS: Unable to write cookie "+
+				"file: %v", err)
+		}
+		rpc.cookieFile = cookieFile
+		rpcsLog.Infof("RPC cookie written to %s", cookieFile)
+	}
+	rpc.setAuth(auths)
+	rpc.ntfnMgr = newWsNotificationManager(&rpc)
+
+	// Setup TLS if not disabled.
+	listenFunc := net.Listen
+	if !cfg.DisableTLS {
+		// Generate the TLS cert and key file if both don't already
+		// exist.
+		if !fileExists(cfg.RPCKey) && !fileExists(cfg.RPCCert) {
+			err := genCertPair(cfg.RPCCert, cfg.RPCKey)
+			if err != nil {
+				return nil, err
+			}
+		}
+		tlsConfig, err := loadRPCTLSConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		rpc.tls = &rpcTLS{}
+		rpc.tls.set(tlsConfig)
+
+		// Change the standard net.Listen function to the tls one.  The
+		// certificates are looked up for every connection, so they can
+		// be reloaded.
+		listenerConfig := rpc.tls.listenerConfig()
+		listenFunc = func(net string, laddr string) (net.Listener, error) {
+			return tls.Listen(net, laddr, listenerConfig)
+		}
+	}
+
+	// TODO: this code is similar to that in server, should be
+	// factored into something shared.
+	listeners := make([]net.Listener, 0, len(listenAddrs)*2)
+	for _, la := range listenAddrs {
+		ipv4ListenAddrs, ipv6ListenAddrs, _, err :=
+			parseListeners([]string{la.addr})
+		if err != nil {
+			return nil, err
+		}
+		listen := func(network, addr string) {
+			listener, err := listenFunc(network, addr)
+			if err != nil {
+				rpcsLog.Warnf("Can't listen on %s: %v", addr, err)
+				return
+			}
+			if la.limited {
+				listener = rpcLimitedListener{listener}
+			}
+			listeners = append(listeners, listener)
+		}
+		for _, addr := range ipv4ListenAddrs {
+			listen("tcp4", addr)
+		}
+		for _, addr := range ipv6ListenAddrs {
+			listen("tcp6", addr)
+		}
+	}
+
+	// The Unix domain socket is only accessible locally and protected by
+	// its file permissions, so it is served without TLS.
+	if cfg.RPCUnixSocket != "" {
+		listener, err := listenUnix(cfg.RPCUnixSocket)
+		if err != nil {
+			rpcsLog.Warnf("Can't listen on %s: %v",
+				cfg.RPCUnixSocket, err)
+		} else {
+			listeners = append(listeners, listener)
+		}
+	}
+	if len(listeners) == 0 {
+		return nil, errors.New("RPCS: No valid listen address")
+	}
+
+	rpc.listeners = listeners
+
+	return &rpc, nil
+}
+
+func init() {
+	rpcHandlers = rpcHandlersBeforeInit
+	rand.Seed(time.Now().UnixNano())
+}
@@ -0,0 +1,327 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/btcjson"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/mempool"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// TestRPCAuths ensures RPC credentials are parsed with the methods they may
+// call and that invalid or ambiguous credentials are rejected.
+func TestRPCAuths(t *testing.T) {
+	c := &config{
+		RPCUser:      "admin",
+		RPCPass:      "adminpass",
+		RPCLimitUser: "limited",
+		RPCLimitPass: "limitedpass",
+		RPCAuths: []string{
+			"explorer:explorerpass:limited",
+			"ops:ops:pass:getinfo,setvalidatekeys",
+			"root:rootpass:*",
+		},
+	}
+	auths, err := rpcAuths(c)
+	if err != nil {
+		t.Fatalf("rpcAuths: unexpected error: %v", err)
+	}
+	if len(auths) != 5 {
+		t.Fatalf("rpcAuths: got %d credentials, want 5", len(auths))
+	}
+
+	// The credentials are restricted to the methods of the limited user on
+	// limited listeners.
+	tests := []struct {
+		user, pass  string
+		method      string
+		want        bool
+		wantLimited bool
+	}{
+		{"admin", "adminpass", "setvalidatekeys", true, false},
+		{"admin", "adminpass", "getblock", true, true},
+		{"limited", "limitedpass", "getblock", true, true},
+		{"limited", "limitedpass", "setvalidatekeys", false, false},
+		{"explorer", "explorerpass", "getblock", true, true},
+		{"explorer", "explorerpass", "setvalidatekeys", false, false},
+		{"ops", "ops:pass", "setvalidatekeys", true, false},
+		{"ops", "ops:pass", "getblock", false, false},
+		{"root", "rootpass", "stop", true, false},
+	}
+	for _, test := range tests {
+		sha := rpcAuthSha(test.user, test.pass)
+		var auth *rpcAuth
+		for i := range auths {
+			if auths[i].sha == sha {
+				auth = &auths[i]
+			}
+		}
+		if auth == nil {
+			t.Errorf("%s: credentials not found", test.user)
+			continue
+		}
+		if got := auth.allowed(test.method); got != test.want {
+			t.Errorf("%s allowed %s: got %v, want %v", test.user,
+				test.method, got, test.want)
+		}
+		got := auth.limit().allowed(test.method)
+		if got != test.wantLimited {
+			t.Errorf("%s allowed %s on limited listener: got %v, "+
+				"want %v", test.user, test.method, got,
+				test.wantLimited)
+		}
+	}
+
+	invalid := [][]string{
+		{"explorer:explorerpass"},
+		{"explorer::limited"},
+		{"explorer:explorerpass:nosuchmethod"},
+		{"admin:otherpass:limited"},
+		{"ops:opspass:getinfo", "ops:otherpass:getblock"},
+	}
+	for _, entries := range invalid {
+		c.RPCAuths = entries
+		if _, err := rpcAuths(c); err == nil {
+			t.Errorf("rpcAuths(%q): expected error", entries)
+		}
+	}
+}
+
+// TestRPCCookie ensures the cookie file holds credentials with full access
+// which are kept when the configured credentials are replaced.
+func TestRPCCookie(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpccookie")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, rpcCookieFilename)
+
+	cookieAuth, err := writeRPCCookie(path)
+	if err != nil {
+		t.Fatalf("writeRPCCookie: %v", err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if runtime.GOOS != "windows" && fi.Mode().Perm() != 0600 {
+		t.Errorf("cookie file mode: got %v, want 0600", fi.Mode().Perm())
+	}
+	cookie, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	parts := strings.SplitN(string(cookie), ":", 2)
+	if len(parts) != 2 || parts[0] != rpcCookieUser {
+		t.Fatalf("malformed cookie %q", cookie)
+	}
+	sha := rpcAuthSha(parts[0], parts[1])
+
+	s := &rpcServer{cookieAuth: cookieAuth}
+	auths, err := rpcAuths(&config{RPCUser: "admin", RPCPass: "adminpass"})
+	if err != nil {
+		t.Fatalf("rpcAuths: %v", err)
+	}
+	s.setAuth(auths)
+	s.setAuth(auths)
+	auth := s.lookupAuth(sha)
+	if auth == nil || !auth.allowed("stop") {
+		t.Errorf("cookie credentials not allowed to call stop")
+	}
+	if len(s.auths) != 2 {
+		t.Errorf("got %d credentials, want 2", len(s.auths))
+	}
+
+	// The cookie user may not be configured.
+	_, err = rpcAuths(&config{RPCAuths: []string{rpcCookieUser + ":pass:*"}})
+	if err == nil {
+		t.Errorf("rpcAuths: expected error for cookie user")
+	}
+}
+
+// regtestIssueKeys returns a key database signing with the issue keys of the
+// regression test network.
+func regtestIssueKeys() txscript.KeyClosure {
+	var keys []txscript.PrivateKey
+	for _, keyHex := range []string{
+		"3f9222ab4d30b1795941d9815e5833a4da70cb04bff59a5fd2ddc4641e58607e",
+		"0a40defde0e49e1f78edb9cea5c499f704fabc140d6fd1a4df8405365e2e4f0f",
+	} {
+		keyBytes, _ := hex.DecodeString(keyHex)
+		privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), keyBytes)
+		keys = append(keys, txscript.PrivateKey{Key: privKey,
+			Compressed: true})
+	}
+	return func(provautil.Address) ([]txscript.PrivateKey, error) {
+		return keys, nil
+	}
+}
+
+// TestHandleGetTxOutMempoolSpend ensures gettxout treats an output spent by a
+// transaction in the mempool as spent only when the mempool is included.
+func TestHandleGetTxOutMempoolSpend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gettxout")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The genesis thread outputs are coinbase outputs, which may be spent
+	// right away without a maturity.
+	params := chaincfg.RegressionNetParams
+	params.CoinbaseMaturity = 0
+	db, err := database.Create("ffldb", filepath.Join(dir, "db"),
+		params.Net)
+	if err != nil {
+		t.Fatalf("database.Create: %v", err)
+	}
+	defer db.Close()
+	chain, err := blockchain.New(&blockchain.Config{
+		DB:          db,
+		ChainParams: &params,
+		TimeSource:  blockchain.NewMedianTime(),
+	})
+	if err != nil {
+		t.Fatalf("blockchain.New: %v", err)
+	}
+	txPool := mempool.New(&mempool.Config{
+		Policy: mempool.Policy{
+			MaxOrphanTxs:    defaultMaxOrphanTransactions,
+			MaxOrphanTxSize: defaultMaxOrphanTxSize,
+			MaxSigOpsPerTx:  blockchain.MaxSigOpsPerBlock / 5,
+			MinRelayTxFee:   mempool.DefaultMinRelayTxFee,
+			DustRelayFee:    mempool.DefaultMinRelayTxFee,
+			MaxTxVersion:    2,
+
+			MaxStandardTxSize:        mempool.DefaultMaxStandardTxSize,
+			MaxStandardSigScriptSize: mempool.DefaultMaxStandardSigScriptSize,
+			MaxRevokedKeyIDOutputs:   params.MaxRevokedKeyIDOutputs,
+		},
+		ChainParams:         &params,
+		FetchUtxoView:       chain.FetchUtxoView,
+		FetchKeyIDOutPoints: chain.FetchKeyIDOutPoints,
+		ThreadTips:          chain.ThreadTips,
+		LastKeyID:           chain.LastKeyID,
+		TotalSupply:         chain.TotalSupply,
+		GetKeyIDs:           chain.KeyIDs,
+		GetAdminKeySets:     chain.AdminKeySets,
+		GetIssueDests:       chain.IssueDests,
+		GetFrozen:           chain.Frozen,
+		GetDelegates:        chain.Delegates,
+		BestHeight:          func() uint32 { return chain.BestSnapshot().Height },
+		MedianTimePast:      func() time.Time { return chain.BestSnapshot().MedianTime },
+		SigCache:            txscript.NewSigCache(100),
+		HashCache:           txscript.NewHashCache(100),
+		TimeSource:          blockchain.NewMedianTime(),
+		CalcSequenceLock: func(tx *provautil.Tx, view *blockchain.UtxoViewpoint) (*blockchain.SequenceLock, error) {
+			return chain.CalcSequenceLock(tx, view, true)
+		},
+	})
+	s := &rpcServer{
+		server: &server{chainParams: &params, txMemPool: txPool},
+		chain:  chain,
+	}
+
+	// Issue funds to a Prova address, spending the issue thread tip of the
+	// genesis block.
+	genesisHash := params.GenesisBlock.Transactions[0].TxHash()
+	issueTip := wire.NewOutPoint(&genesisHash, 2)
+	entry, err := chain.FetchUtxoEntry(&genesisHash)
+	if err != nil || entry == nil {
+		t.Fatalf("FetchUtxoEntry: %v", err)
+	}
+	threadScript, err := txscript.ProvaThreadScript(provautil.IssueThread)
+	if err != nil {
+		t.Fatalf("ProvaThreadScript: %v", err)
+	}
+	addr, err := provautil.NewAddressProva(make([]byte, 20),
+		[]btcec.KeyID{1, 2}, &params)
+	if err != nil {
+		t.Fatalf("NewAddressProva: %v", err)
+	}
+	payScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("PayToAddrScript: %v", err)
+	}
+	issueTx := wire.NewMsgTx(1)
+	issueTx.AddTxIn(wire.NewTxIn(issueTip, nil))
+	issueTx.AddTxOut(wire.NewTxOut(0, threadScript))
+	issueTx.AddTxOut(wire.NewTxOut(provautil.AtomsPerGram, payScript))
+	sigScript, err := txscript.SignTxOutput(&params, issueTx, 0, 0,
+		entry.PkScriptByIndex(issueTip.Index), txscript.SigHashAll,
+		regtestIssueKeys(), nil)
+	if err != nil {
+		t.Fatalf("SignTxOutput: %v", err)
+	}
+	issueTx.TxIn[0].SignatureScript = sigScript
+	if _, err := txPool.ProcessTransaction(provautil.NewTx(issueTx), false,
+		false, 0); err != nil {
+		t.Fatalf("ProcessTransaction: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		includeMempool *bool
+		wantSpent      bool
+	}{
+		{
+			name:      "mempool included by default",
+			wantSpent: true,
+		},
+		{
+			name:           "mempool included",
+			includeMempool: btcjson.Bool(true),
+			wantSpent:      true,
+		},
+		{
+			name:           "mempool excluded",
+			includeMempool: btcjson.Bool(false),
+		},
+	}
+
+	for _, test := range tests {
+		cmd := btcjson.NewGetTxOutCmd(genesisHash.String(),
+			issueTip.Index, test.includeMempool, nil)
+		result, err := handleGetTxOut(s, cmd, nil)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if test.wantSpent {
+			if result != nil {
+				t.Errorf("%s: got %v for a spent output",
+					test.name, result)
+			}
+			continue
+		}
+		reply, ok := result.(*btcjson.GetTxOutResult)
+		if !ok || reply == nil {
+			t.Errorf("%s: got %v for an unspent output", test.name,
+				result)
+			continue
+		}
+		if reply.Confirmations != 1 || !reply.Coinbase {
+			t.Errorf("%s: got %d confirmations (coinbase %v), want "+
+				"the genesis coinbase output", test.name,
+				reply.Confirmations, reply.Coinbase)
+		}
+	}
+}
@@ -5,7 +5,7 @@
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
-package main
+package node
 
 import (
 	"errors"
@@ -105,10 +105,6 @@ var helpDescsEnUS = map[string]string{
 	"decoderawtransaction--synopsis": "Returns a JSON object representing the provided serialized, hex-encoded transaction.",
 	"decoderawtransaction-hextx":     "Serialized, hex-encoded transaction",
 
-	// SetValidateKeysCmd help.
-	"setvalidatekeys--synopsis": "Sets the private keys to use to sign generated blocks",
-	"setvalidatekeys-privkeys":  "Hex-encoded 32 byte private keys",
-
 	// DecodeScriptResult help.
 	"decodescriptresult-asm":       "Disassembly of the script",
 	"decodescriptresult-reqSigs":   "The number of required signatures",
@@ -156,6 +152,13 @@ var helpDescsEnUS = map[string]string{
 	"addresstxrequest-start":     "The block to start at",
 	"addresstxrequest-end":       "The block to end at",
 
+	// GetBalanceCmd help.
+	"getbalance--synopsis": "Returns the balance of the addresses watched by the watch-only index, which is the total value of their unspent outputs in the main chain.\n" +
+		"Requires the watch-only index to be enabled (--watchindex).",
+	"getbalance-account":  "The label of the watched addresses to include, or \"*\" for all watched addresses",
+	"getbalance-minconf":  "The minimum number of confirmations of the included outputs",
+	"getbalance--result0": "The balance in DMG",
+
 	// GetBestBlockResult help.
 	"getbestblockresult-hash":   "Hex-encoded bytes of the best block hash",
 	"getbestblockresult-height": "Height of the best block",
@@ -173,17 +176,30 @@ var helpDescsEnUS = map[string]string{
 	"threadtipresult-name":     "Name of admin thread",
 	"threadtipresult-outpoint": "Outpoint of current tip of admin thread",
 
+	// AssetSupplyResult help.
+	"assetsupplyresult-assetid": "ID of the asset",
+	"assetsupplyresult-supply":  "Net issuance value of the asset",
+
+	// DelegateResult help.
+	"delegateresult-pubkey":   "Compressed, serialized pubKey of the delegate key",
+	"delegateresult-scope":    "Bit field of the provision thread ops delegated to the key",
+	"delegateresult-minkeyid": "Lowest keyID of the ASP key ops delegated to the key",
+	"delegateresult-maxkeyid": "Highest keyID of the ASP key ops delegated to the key",
+
 	// GetAdminInfoResult help.
 	"getadmininforesult-hash":          "Block hash at which returned admin state is valid",
 	"getadmininforesult-height":        "Height of the block at which returned admin state is valid",
 	"getadmininforesult-threadtips":    "Unspent tx ids for admin threads",
 	"getadmininforesult-totalsupply":   "Net chain issuance value",
+	"getadmininforesult-assetsupplies": "Net issuance values of the assets other than DMG",
 	"getadmininforesult-lastkeyid":     "Last provisioned keyID",
 	"getadmininforesult-rootkeys":      "List of root pubKeys",
 	"getadmininforesult-provisionkeys": "List of provision pubKeys",
 	"getadmininforesult-issuekeys":     "List of issue pubKeys",
 	"getadmininforesult-validatekeys":  "List of validate pubKeys",
 	"getadmininforesult-aspkeys":       "Mapping of keyIDs to ASP pubKeys",
+	"getadmininforesult-issuedests":    "Addresses newly issued tokens may be paid to, empty when unrestricted",
+	"getadmininforesult-delegates":     "Delegate keys and the scope of provision thread ops delegated to them",
 
 	// GetAdminInfoCmd help.
 	"getadmininfo--synopsis": "Returns general admin data: thread tips, keys, issuance.",
@@ -243,6 +259,40 @@ var helpDescsEnUS = map[string]string{
 	"getblockverboseresult-validatingpubkey":  "The validating public key signing the block",
 	"getblockverboseresult-signature":         "The signature of the block generator",
 
+	// GetBlockChainInfoCmd help.
+	"getblockchaininfo--synopsis": "Returns information about the state of the block chain, including the progress of a reindex, the admin state and the state of the optional indexes, for use as a health check.",
+
+	// GetBlockChainInfoResult help.
+	"getblockchaininforesult-chain":                "The name of the network",
+	"getblockchaininforesult-blocks":               "The height of the best block in the main chain",
+	"getblockchaininforesult-headers":              "The height of the best known block header, which includes the stored blocks a reindex has yet to connect",
+	"getblockchaininforesult-bestblockhash":        "The hash of the best block in the main chain",
+	"getblockchaininforesult-difficulty":           "The proof-of-work difficulty as a multiple of the minimum difficulty",
+	"getblockchaininforesult-verificationprogress": "The fraction of the known blocks which have been connected",
+	"getblockchaininforesult-chainwork":            "The total work of the main chain in hex",
+	"getblockchaininforesult-reindexing":           "Whether the chain state is being rebuilt from the stored blocks",
+	"getblockchaininforesult-keystatehash":         "The hash of the admin key state of the best block, which covers the admin and ASP key sets, the thread tips and the supply, as committed to by signed checkpoints",
+	"getblockchaininforesult-threadtips":           "The tips of the admin threads",
+	"getblockchaininforesult-lastkeyid":            "The last ASP key ID assigned",
+	"getblockchaininforesult-pruned":               "Whether blocks are pruned, which is never the case",
+	"getblockchaininforesult-indexes":              "The optional indexes which are enabled",
+	"getblockchaininforesult-unknownrules":         "Whether the recent blocks signal consensus rules the node does not know",
+	"getblockchaininforesult-warnings":             "The warning operators should act on, empty when there is none",
+
+	// UnknownRulesResult help.
+	"unknownrulesresult-state":      "none, signaled when recent blocks have a block version newer than the node knows, or active when enough of them do for the rules they signal to be enforced, in which case the node cannot fully validate the chain and does not follow blocks with an unknown version",
+	"unknownrulesresult-height":     "The height of the best block the state is for",
+	"unknownrulesresult-blocks":     "The number of blocks with an unknown version among the last window blocks",
+	"unknownrulesresult-window":     "The number of recent blocks whose versions are counted",
+	"unknownrulesresult-threshold":  "The number of blocks with an unknown version from which the rules they signal are enforced",
+	"unknownrulesresult-maxversion": "The highest block version among the last window blocks",
+
+	// IndexTipResult help.
+	"indextipresult-name":   "The name of the index",
+	"indextipresult-hash":   "The hash of the block the index is caught up to",
+	"indextipresult-height": "The height of the block the index is caught up to",
+	"indextipresult-synced": "Whether the index is caught up to the best block",
+
 	// GetBlockCountCmd help.
 	"getblockcount--synopsis": "Returns the number of blocks in the longest block chain.",
 	"getblockcount--result0":  "The current block count",
@@ -270,6 +320,8 @@ var helpDescsEnUS = map[string]string{
 	"getblockheaderverboseresult-nonce":             "The block nonce",
 	"getblockheaderverboseresult-bits":              "The bits which represent the block difficulty",
 	"getblockheaderverboseresult-difficulty":        "The proof-of-work difficulty as a multiple of the minimum difficulty",
+	"getblockheaderverboseresult-work":              "The hex-encoded work of the block",
+	"getblockheaderverboseresult-chainwork":         "The hex-encoded total work of the chain up to and including the block",
 	"getblockheaderverboseresult-previousblockhash": "The hash of the previous block",
 	"getblockheaderverboseresult-nextblockhash":     "The hash of the next block (only if there is one)",
 	"getblockheaderverboseresult-signature":         "The signature of this block by the validator who created it",
@@ -330,6 +382,22 @@ var helpDescsEnUS = map[string]string{
 	"getblocktemplate--condition2": "mode=proposal, accepted",
 	"getblocktemplate--result1":    "An error string which represents why the proposal was rejected or nothing if accepted",
 
+	// GetChainTipsCmd help.
+	"getchaintips--synopsis": "Returns the tip of the main chain and the tips of the known side chains, ordered by descending height.\n" +
+		"The work and signers of the blocks of each side chain after its fork are reported along with those of the main chain blocks after the fork, so a minority branch catching up with the main chain can be detected.",
+
+	// GetChainTipsResult help.
+	"getchaintipsresult-height":           "The height of the tip",
+	"getchaintipsresult-hash":             "The hash of the tip",
+	"getchaintipsresult-branchlen":        "The number of blocks of the branch after its fork from the main chain, 0 for the main chain tip",
+	"getchaintipsresult-status":           "The status of the branch: active for the main chain, valid-headers for a side chain which was not connected",
+	"getchaintipsresult-chainwork":        "The hex-encoded total work of the chain up to and including the tip",
+	"getchaintipsresult-forkheight":       "The height of the main chain block the branch forks from",
+	"getchaintipsresult-branchwork":       "The hex-encoded work of the blocks of the branch after the fork",
+	"getchaintipsresult-branchvalidators": "The number of distinct validate keys which signed the blocks of the branch after the fork",
+	"getchaintipsresult-mainwork":         "The hex-encoded work of the main chain blocks after the fork",
+	"getchaintipsresult-mainvalidators":   "The number of distinct validate keys which signed the main chain blocks after the fork",
+
 	// GetConnectionCountCmd help.
 	"getconnectioncount--synopsis": "Returns the number of active connections to other peers.",
 	"getconnectioncount--result0":  "The number of connections",
@@ -389,6 +457,28 @@ var helpDescsEnUS = map[string]string{
 	// GetInfoCmd help.
 	"getinfo--synopsis": "Returns a JSON object containing various state info.",
 
+	// GetMempoolEntryCmd help.
+	"getmempoolentry--synopsis": "Returns information about a transaction in the memory pool.",
+	"getmempoolentry-txid":      "The hash of the transaction",
+
+	// GetMempoolEntryResult help.
+	"getmempoolentryresult-size":              "Transaction size in bytes",
+	"getmempoolentryresult-fee":               "Transaction fee in grams",
+	"getmempoolentryresult-modifiedfee":       "Transaction fee in grams used for mining priority",
+	"getmempoolentryresult-time":              "Local time transaction entered pool in seconds since 1 Jan 1970 GMT",
+	"getmempoolentryresult-height":            "Block height when transaction entered the pool",
+	"getmempoolentryresult-startingpriority":  "Priority when transaction entered the pool",
+	"getmempoolentryresult-currentpriority":   "Current priority",
+	"getmempoolentryresult-descendantcount":   "Number of in-mempool descendant transactions, including this one",
+	"getmempoolentryresult-descendantsize":    "Size in bytes of in-mempool descendants, including this one",
+	"getmempoolentryresult-descendantfees":    "Fees in grams of in-mempool descendants, including this one",
+	"getmempoolentryresult-ancestorcount":     "Number of in-mempool ancestor transactions, including this one",
+	"getmempoolentryresult-ancestorsize":      "Size in bytes of in-mempool ancestors, including this one",
+	"getmempoolentryresult-ancestorfees":      "Fees in grams of in-mempool ancestors, including this one",
+	"getmempoolentryresult-spendsadminthread": "Whether the transaction spends the tip of an admin thread",
+	"getmempoolentryresult-depends":           "Unconfirmed transactions used as inputs for this transaction",
+	"getmempoolentryresult-spentby":           "Unconfirmed transactions spending outputs of this transaction",
+
 	// GetMempoolInfoCmd help.
 	"getmempoolinfo--synopsis": "Returns memory pool information",
 
@@ -418,6 +508,39 @@ var helpDescsEnUS = map[string]string{
 	"getnetworkhashps-height":    "Perform estimate ending with this height or -1 for current best chain block height",
 	"getnetworkhashps--result0":  "Estimated hashes per second",
 
+	// GetNetworkInfoCmd help.
+	"getnetworkinfo--synopsis": "Returns a JSON object containing network-related information, including the state of the port mapping through the NAT when UPnP or NAT-PMP is enabled.",
+
+	// GetNetworkInfoResult help.
+	"getnetworkinforesult-version":         "The version of the server",
+	"getnetworkinforesult-protocolversion": "The latest supported protocol version",
+	"getnetworkinforesult-timeoffset":      "The time offset",
+	"getnetworkinforesult-connections":     "The number of connected peers",
+	"getnetworkinforesult-networks":        "The networks peers are connected over",
+	"getnetworkinforesult-relayfee":        "The minimum relay fee for non-free transactions in DMG/KB",
+	"getnetworkinforesult-localaddresses":  "The local addresses advertised to peers",
+	"getnetworkinforesult-nat":             "The state of the port mapping through the NAT, omitted when neither --upnp nor --natpmp is enabled or external IPs are specified",
+
+	// NetworksResult help.
+	"networksresult-name":      "The name of the network (ipv4, ipv6 or onion)",
+	"networksresult-limited":   "Whether connections over the network are disabled",
+	"networksresult-reachable": "Whether peers are reachable over the network",
+	"networksresult-proxy":     "The proxy used for the network, if any",
+
+	// LocalAddressesResult help.
+	"localaddressesresult-address": "The local address",
+	"localaddressesresult-port":    "The port of the local address",
+	"localaddressesresult-score":   "The priority of the method the address was discovered with, higher for addresses specified with --externalip and mapped through the NAT",
+
+	// NATStatusResult help.
+	"natstatusresult-method":       "The method the NAT gateway was discovered with (upnp or natpmp), or none when no gateway was found",
+	"natstatusresult-internalport": "The listening port mapped through the NAT",
+	"natstatusresult-externalip":   "The external address reported by the gateway",
+	"natstatusresult-externalport": "The external port mapped by the gateway",
+	"natstatusresult-mapped":       "Whether the last attempt to map the port succeeded",
+	"natstatusresult-lastmapped":   "The time of the last successful mapping in seconds since 1 Jan 1970 GMT",
+	"natstatusresult-error":        "The error of the last attempt to map the port, if it failed",
+
 	// GetNetTotalsCmd help.
 	"getnettotals--synopsis": "Returns a JSON object containing network traffic statistics.",
 
@@ -425,6 +548,15 @@ var helpDescsEnUS = map[string]string{
 	"getnettotalsresult-totalbytesrecv": "Total bytes received",
 	"getnettotalsresult-totalbytessent": "Total bytes sent",
 	"getnettotalsresult-timemillis":     "Number of milliseconds since 1 Jan 1970 GMT",
+	"getnettotalsresult-uploadtarget":   "The upload target set with --maxuploadtarget",
+
+	// UploadTargetResult help.
+	"uploadtargetresult-timeframe":             "The length of the upload cycle in seconds",
+	"uploadtargetresult-target":                "The number of bytes which may be sent per cycle, 0 for no limit",
+	"uploadtargetresult-targetreached":         "Whether the target is reached in the current cycle",
+	"uploadtargetresult-servehistoricalblocks": "Whether blocks older than a week are served to peers which are not whitelisted",
+	"uploadtargetresult-bytesleftincycle":      "The number of bytes which may still be sent in the current cycle, 0 without a target",
+	"uploadtargetresult-timeleftincycle":       "The number of seconds until the next cycle starts, 0 without a target",
 
 	// GetPeerInfoResult help.
 	"getpeerinforesult-id":             "A unique node ID",
@@ -449,17 +581,46 @@ var helpDescsEnUS = map[string]string{
 	"getpeerinforesult-feefilter":      "The requested minimum fee a transaction must have to be announced to the peer",
 	"getpeerinforesult-syncnode":       "Whether or not the peer is the sync peer",
 
+	// The per-message byte counters of GetPeerInfoResult.
+	"getpeerinforesult-bytessentpermsg":        "JSON object with the message commands as keys and the bytes sent as values",
+	"getpeerinforesult-bytessentpermsg--key":   "command",
+	"getpeerinforesult-bytessentpermsg--value": "n",
+	"getpeerinforesult-bytessentpermsg--desc":  "Total bytes sent by message command",
+	"getpeerinforesult-bytesrecvpermsg":        "JSON object with the message commands as keys and the bytes received as values",
+	"getpeerinforesult-bytesrecvpermsg--key":   "command",
+	"getpeerinforesult-bytesrecvpermsg--value": "n",
+	"getpeerinforesult-bytesrecvpermsg--desc":  "Total bytes received by message command, with messages which failed to decode under *other*",
+
+	// The outbound group of GetPeerInfoResult.
+	"getpeerinforesult-outboundgroup": "The outbound group the connection is kept for (validator, archival or explorer), omitted for the other peers",
+
+	// The block relay and ping statistics of GetPeerInfoResult.
+	"getpeerinforesult-bestblock":       "The hash of the latest block the peer announced, omitted when it announced none",
+	"getpeerinforesult-lastblock":       "Time the peer last relayed a block which was accepted to the chain in seconds since 1 Jan 1970 GMT, omitted when it relayed none",
+	"getpeerinforesult-lasttransaction": "Time the peer last relayed a transaction which was accepted to the mempool in seconds since 1 Jan 1970 GMT, omitted when it relayed none",
+	"getpeerinforesult-blocksaccepted":  "The number of blocks relayed by the peer which were accepted to the chain",
+	"getpeerinforesult-minping":         "Number of microseconds the fastest ping of the connection took",
+	"getpeerinforesult-pinghistory":     "Number of microseconds each of the most recent pings took, oldest first",
+
 	// GetPeerInfoCmd help.
 	"getpeerinfo--synopsis": "Returns data about each connected network peer as an array of json objects.",
 
 	// GetRawMempoolVerboseResult help.
-	"getrawmempoolverboseresult-size":             "Transaction size in bytes",
-	"getrawmempoolverboseresult-fee":              "Transaction fee in grams",
-	"getrawmempoolverboseresult-time":             "Local time transaction entered pool in seconds since 1 Jan 1970 GMT",
-	"getrawmempoolverboseresult-height":           "Block height when transaction entered the pool",
-	"getrawmempoolverboseresult-startingpriority": "Priority when transaction entered the pool",
-	"getrawmempoolverboseresult-currentpriority":  "Current priority",
-	"getrawmempoolverboseresult-depends":          "Unconfirmed transactions used as inputs for this transaction",
+	"getrawmempoolverboseresult-size":              "Transaction size in bytes",
+	"getrawmempoolverboseresult-fee":               "Transaction fee in grams",
+	"getrawmempoolverboseresult-time":              "Local time transaction entered pool in seconds since 1 Jan 1970 GMT",
+	"getrawmempoolverboseresult-height":            "Block height when transaction entered the pool",
+	"getrawmempoolverboseresult-startingpriority":  "Priority when transaction entered the pool",
+	"getrawmempoolverboseresult-currentpriority":   "Current priority",
+	"getrawmempoolverboseresult-descendantcount":   "Number of in-mempool descendant transactions, including this one",
+	"getrawmempoolverboseresult-descendantsize":    "Size in bytes of in-mempool descendants, including this one",
+	"getrawmempoolverboseresult-descendantfees":    "Fees in grams of in-mempool descendants, including this one",
+	"getrawmempoolverboseresult-ancestorcount":     "Number of in-mempool ancestor transactions, including this one",
+	"getrawmempoolverboseresult-ancestorsize":      "Size in bytes of in-mempool ancestors, including this one",
+	"getrawmempoolverboseresult-ancestorfees":      "Fees in grams of in-mempool ancestors, including this one",
+	"getrawmempoolverboseresult-spendsadminthread": "Whether the transaction spends the tip of an admin thread",
+	"getrawmempoolverboseresult-depends":           "Unconfirmed transactions used as inputs for this transaction",
+	"getrawmempoolverboseresult-spentby":           "Unconfirmed transactions spending outputs of this transaction",
 
 	// GetRawMempoolCmd help.
 	"getrawmempool--synopsis":   "Returns information about all of the transactions currently in the memory pool.",
@@ -477,18 +638,41 @@ var helpDescsEnUS = map[string]string{
 	"getrawtransaction--result0":    "Hex-encoded bytes of the serialized transaction",
 
 	// GetTxOutResult help.
-	"gettxoutresult-bestblock":     "The block hash that contains the transaction output",
-	"gettxoutresult-confirmations": "The number of confirmations",
-	"gettxoutresult-value":         "The transaction amount in DMG",
-	"gettxoutresult-scriptPubKey":  "The public key script used to pay coins as a JSON object",
-	"gettxoutresult-version":       "The transaction version",
-	"gettxoutresult-coinbase":      "Whether or not the transaction is a coinbase",
+	"gettxoutresult-bestblock":       "The block hash that contains the transaction output",
+	"gettxoutresult-confirmations":   "The number of confirmations",
+	"gettxoutresult-value":           "The transaction amount in DMG",
+	"gettxoutresult-scriptPubKey":    "The public key script used to pay coins as a JSON object",
+	"gettxoutresult-version":         "The transaction version",
+	"gettxoutresult-coinbase":        "Whether or not the transaction is a coinbase",
+	"gettxoutresult-assetid":         "The ID of the asset held by the output, omitted for DMG",
+	"gettxoutresult-keyids":          "The ASP keyIDs of the Prova script the output pays to",
+	"gettxoutresult-mature":          "Whether the output is not a coinbase or has reached the coinbase maturity for a spend in the next block",
+	"gettxoutresult-spendableheight": "The height of the first block which can include a transaction spending the output with the requested sequence number",
+	"gettxoutresult-spendabletime":   "The median time past the block before the spending block must exceed due to a time-based relative lock-time, omitted when there is none",
+	"gettxoutresult-spendable":       "Whether a transaction spending the output with the requested sequence number can be included in the next block",
+
+	// GetRPCInfoCmd help.
+	"getrpcinfo--synopsis": "Returns the RPC calls which are being serviced and the most recent slow calls.\n" +
+		"Every call is assigned an ID which is included in its log messages and returned in the X-Request-Id header of HTTP POST responses.",
+
+	// GetRPCInfoResult help.
+	"getrpcinforesult-activecommands": "The RPC calls which are being serviced, ordered by ID",
+	"getrpcinforesult-slowcalls":      "The most recent RPC calls which took longer than the slow call threshold, oldest first",
+	"getrpcinforesult-slowthreshold":  "The slow call threshold set with --rpcslowcall in microseconds, or 0 when slow calls are not tracked",
+
+	// RPCCallResult help.
+	"rpccallresult-id":         "The ID of the call",
+	"rpccallresult-method":     "The method called",
+	"rpccallresult-remoteaddr": "The address of the client",
+	"rpccallresult-starttime":  "The time the call started in seconds since 1 Jan 1970 GMT",
+	"rpccallresult-duration":   "How long the call took, or has taken so far, in microseconds",
 
 	// GetTxOutCmd help.
 	"gettxout--synopsis":      "Returns information about an unspent transaction output..",
 	"gettxout-txid":           "The hash of the transaction",
 	"gettxout-vout":           "The index of the output",
-	"gettxout-includemempool": "Include the mempool when true",
+	"gettxout-includemempool": "Include the mempool when true, which also treats outputs spent by mempool transactions as spent",
+	"gettxout-sequence":       "The sequence number of the input spending the output, whose relative lock-time is included in the spendability",
 
 	// HelpCmd help.
 	"help--synopsis":   "Returns a list of all commands or help for a specified command.",
@@ -498,10 +682,97 @@ var helpDescsEnUS = map[string]string{
 	"help--result0":    "List of commands",
 	"help--result1":    "Help for specified command",
 
+	// ImportAddressCmd help.
+	"importaddress--synopsis": "Adds an address and all addresses sharing its public key hash to the addresses watched by the watch-only index, or changes their label when they are already watched.\n" +
+		"Requires the watch-only index to be enabled (--watchindex).",
+	"importaddress-address": "The Prova address to watch",
+	"importaddress-rescan":  "Scan the main chain for the transactions of the address before it is watched",
+	"importaddress-label":   "The label of the address, which the account parameter of the watch-only RPCs selects addresses by",
+
+	// ImportPubKeyCmd help.
+	"importpubkey--synopsis": "Adds the addresses of a public key to the addresses watched by the watch-only index, or changes their label when they are already watched.\n" +
+		"Requires the watch-only index to be enabled (--watchindex).",
+	"importpubkey-pubkey": "The hex-encoded public key whose addresses to watch",
+	"importpubkey-rescan": "Scan the main chain for the transactions of the addresses before they are watched",
+	"importpubkey-label":  "The label of the addresses, which the account parameter of the watch-only RPCs selects addresses by",
+
+	// ListSinceBlockCmd help.
+	"listsinceblock--synopsis": "Returns the transactions in the main chain since a block which pay or spend outputs of the addresses watched by the watch-only index, oldest first.\n" +
+		"When the block was disconnected from the main chain by a reorganization, the transactions of the disconnected blocks are returned as well with the removed field set.\n" +
+		"Requires the watch-only index to be enabled (--watchindex).",
+	"listsinceblock-blockhash":           "The hash of the block to list the transactions after, or all transactions when omitted",
+	"listsinceblock-targetconfirmations": "The number of confirmations the transactions of the returned last block have",
+	"listsinceblock-includewatchonly":    "Unused, since all addresses are watch-only",
+
+	// ListSinceBlockResult help.
+	"listsinceblockresult-transactions": "The entries for the transactions, in the same form as listtransactions",
+	"listsinceblockresult-lastblock":    "The hash of the block to pass to the next call, so transactions with fewer than the target confirmations are returned again",
+
+	// ListTransactionsCmd help.
+	"listtransactions--synopsis": "Returns the most recent transactions in the main chain which pay or spend outputs of the addresses watched by the watch-only index, oldest first.\n" +
+		"Each output a transaction pays to or spends from a watched address is listed as a separate receive or send entry.\n" +
+		"Requires the watch-only index to be enabled (--watchindex).",
+	"listtransactions-account":          "The label of the watched addresses to include, or \"*\" for all watched addresses",
+	"listtransactions-count":            "The maximum number of entries to return",
+	"listtransactions-from":             "The number of most recent entries to skip",
+	"listtransactions-includewatchonly": "Unused, since all addresses are watch-only",
+
+	// ListTransactionsResult help.
+	"listtransactionsresult-abandoned":          "Unused",
+	"listtransactionsresult-account":            "The label of the watched address",
+	"listtransactionsresult-address":            "The watched address",
+	"listtransactionsresult-amount":             "The value of the output in DMG, which is negative for the send category",
+	"listtransactionsresult-bip125-replaceable": "Unused",
+	"listtransactionsresult-blockhash":          "The hash of the block containing the transaction",
+	"listtransactionsresult-blockindex":         "The index of the transaction in the block",
+	"listtransactionsresult-blocktime":          "The block time in seconds since 1 Jan 1970 GMT",
+	"listtransactionsresult-category":           "The category of the entry (send or receive)",
+	"listtransactionsresult-confirmations":      "The number of confirmations of the transaction",
+	"listtransactionsresult-fee":                "Unused",
+	"listtransactionsresult-generated":          "Whether the transaction is a coinbase",
+	"listtransactionsresult-involveswatchonly":  "Always true, since all addresses are watch-only",
+	"listtransactionsresult-time":               "The block time in seconds since 1 Jan 1970 GMT",
+	"listtransactionsresult-timereceived":       "The block time in seconds since 1 Jan 1970 GMT",
+	"listtransactionsresult-trusted":            "Whether the transaction is in the main chain",
+	"listtransactionsresult-txid":               "The hash of the transaction",
+	"listtransactionsresult-vout":               "The index of the output paying the watched address in the transaction creating it",
+	"listtransactionsresult-walletconflicts":    "Unused",
+	"listtransactionsresult-comment":            "Unused",
+	"listtransactionsresult-otheraccount":       "Unused",
+	"listtransactionsresult-removed":            "Whether the transaction is in a block which was disconnected from the main chain by a reorganization, which is only set by listsinceblock",
+
+	// ListUnspentCmd help.
+	"listunspent--synopsis": "Returns the unspent outputs in the main chain which pay the addresses watched by the watch-only index.\n" +
+		"Requires the watch-only index to be enabled (--watchindex).",
+	"listunspent-minconf":   "The minimum number of confirmations of the returned outputs",
+	"listunspent-maxconf":   "The maximum number of confirmations of the returned outputs",
+	"listunspent-addresses": "Only return the outputs paying these watched addresses",
+
+	// ListUnspentResult help.
+	"listunspentresult-txid":            "The hash of the transaction creating the output",
+	"listunspentresult-vout":            "The index of the output in the transaction",
+	"listunspentresult-address":         "The watched address the output pays",
+	"listunspentresult-account":         "The label of the watched address",
+	"listunspentresult-scriptPubKey":    "The hex-encoded public key script of the output",
+	"listunspentresult-redeemScript":    "Unused",
+	"listunspentresult-amount":          "The value of the output in DMG",
+	"listunspentresult-confirmations":   "The number of confirmations of the transaction creating the output",
+	"listunspentresult-spendable":       "Always false, since all addresses are watch-only",
+	"listunspentresult-coinbase":        "Whether the output was created by a coinbase transaction",
+	"listunspentresult-mature":          "Whether the output is not a coinbase or has reached the coinbase maturity for a spend in the next block",
+	"listunspentresult-spendableheight": "The height of the first block which can include a transaction spending the output",
+
 	// PingCmd help.
 	"ping--synopsis": "Queues a ping to be sent to each connected peer.\n" +
 		"Ping times are provided by getpeerinfo via the pingtime and pingwait fields.",
 
+	// ReloadConfigCmd help.
+	"reloadconfig--synopsis": "Reloads the options which can be changed at runtime from the configuration file and command line.\n" +
+		"These are the relay fees, the peer whitelist, the RPC credentials and the debug levels.\n" +
+		"The RPC TLS certificates, keys and client certificate authorities are also read again from their files for new connections.\n" +
+		"All other options require a restart.",
+	"reloadconfig--result0": "The string 'Done.'",
+
 	// SearchRawTransactionsCmd help.
 	"searchrawtransactions--synopsis": "Returns raw data for transactions involving the passed address.\n" +
 		"Returned transactions are pulled from both the database, and transactions currently in the mempool.\n" +
@@ -546,11 +817,26 @@ var helpDescsEnUS = map[string]string{
 	"submitblock--result1":    "The reason the block was rejected",
 
 	// ValidateAddressResult help.
-	"validateaddresschainresult-isvalid": "Whether or not the address is valid",
-	"validateaddresschainresult-address": "The bitcoin address (only when isvalid is true)",
+	"validateaddresschainresult-isvalid":      "Whether or not the address is valid for the active network",
+	"validateaddresschainresult-error":        "The reason the address is not valid (only when isvalid is false)",
+	"validateaddresschainresult-address":      "The bitcoin address (only when the address could be decoded)",
+	"validateaddresschainresult-bech32m":      "The bech32m encoding of the address (only when the address has one)",
+	"validateaddresschainresult-network":      "The name of the network the address is encoded for",
+	"validateaddresschainresult-scripttype":   "The class of the public key script paying the address",
+	"validateaddresschainresult-scriptpubkey": "The hex-encoded public key script paying the address",
+	"validateaddresschainresult-keyids":       "The key ids embedded in the address",
+	"validateaddresschainresult-iswatchonly":  "Whether the address is watched by the watch-only index (only when the index is enabled)",
+	"validateaddresschainresult-label":        "The label of the watched address",
+	"validateaddresschainresult-xpub":         "The watched account extended public key the address is derived from",
+	"validateaddresschainresult-xpubindex":    "The index the address is derived at from the external branch of the extended key",
+
+	// ValidateAddressKeyIDResult help.
+	"validateaddresskeyidresult-keyid":       "The key id",
+	"validateaddresskeyidresult-provisioned": "Whether the key id is assigned to an ASP key in the best chain",
+	"validateaddresskeyidresult-pubkey":      "The hex-encoded ASP public key the key id is assigned to (only when provisioned)",
 
 	// ValidateAddressCmd help.
-	"validateaddress--synopsis": "Verify an address is valid.",
+	"validateaddress--synopsis": "Verify an address is valid for the active network and describe the network, script and key ids it encodes, along with whether the key ids are provisioned and the address is watched.",
 	"validateaddress-address":   "Bitcoin address to validate",
 
 	// VerifyChainCmd help.
@@ -577,11 +863,20 @@ var helpDescsEnUS = map[string]string{
 	"sessionresult-sessionid": "The unique session ID for a client's websocket connection.",
 
 	// NotifyBlocksCmd help.
-	"notifyblocks--synopsis": "Request notifications for whenever a block is connected or disconnected from the main (best) chain.",
+	"notifyblocks--synopsis": "Request notifications for whenever a block is connected or disconnected from the main (best) chain.\n" +
+		"When a block hash is passed, the notifications of the blocks disconnected from and connected to the main chain since that block are sent first, so clients resuming after a restart do not miss any block.",
+	"notifyblocks-fromhash": "Hash of the last block the client was notified of",
 
 	// StopNotifyBlocksCmd help.
 	"stopnotifyblocks--synopsis": "Cancel registered notifications for whenever a block is connected or disconnected from the main (best) chain.",
 
+	// NotifyHeadersCmd help.
+	"notifyheaders--synopsis": "Request a headerconnected notification with the serialized header and the decoded validate key and signature whenever a block is connected to the main (best) chain.\n" +
+		"The notifications carry no transactions, for clients which only monitor the chain tip and the validators signing it.",
+
+	// StopNotifyHeadersCmd help.
+	"stopnotifyheaders--synopsis": "Cancel registered headerconnected notifications.",
+
 	// NotifyNewTransactionsCmd help.
 	"notifynewtransactions--synopsis": "Send either a txaccepted or a txacceptedverbose notification when a new transaction is accepted into the mempool.",
 	"notifynewtransactions-verbose":   "Specifies which type of notification to receive. If verbose is true, then the caller receives txacceptedverbose, otherwise the caller receives txaccepted",
@@ -615,6 +910,7 @@ var helpDescsEnUS = map[string]string{
 	"loadtxfilter-reload":    "Load a new filter instead of adding data to an existing one",
 	"loadtxfilter-addresses": "Array of addresses to add to the transaction filter",
 	"loadtxfilter-outpoints": "Array of outpoints to add to the transaction filter",
+	"loadtxfilter-keyids":    "Array of ASP key ids; outputs paying to any address which includes one of them match the transaction filter",
 
 	// Rescan help.
 	"rescan--synopsis": "Rescan block chain for transactions to addresses.\n" +
@@ -634,6 +930,16 @@ var helpDescsEnUS = map[string]string{
 	// RescannedBlock help.
 	"rescannedblock-hash":         "Hash of the matching block.",
 	"rescannedblock-transactions": "List of matching transactions, serialized and hex-encoded.",
+
+	// RescanChain help.
+	"rescanchain--synopsis": "Rescan the main chain blocks within a height range for transactions paying to the passed addresses or key ids, or spending the passed outpoints.\n" +
+		"Matching blocks are sent with rescanchainmatch notifications and progress is reported with rescanchainprogress notifications.\n" +
+		"Outputs paying to the addresses or key ids are added to the rescan so that later spends of them also match.",
+	"rescanchain-addresses":   "List of addresses to include in the rescan",
+	"rescanchain-outpoints":   "List of transaction outpoints to include in the rescan",
+	"rescanchain-beginheight": "Height of the first block to rescan",
+	"rescanchain-endheight":   "Height of the final block to rescan (default: the current best block)",
+	"rescanchain-keyids":      "List of ASP key ids to include in the rescan",
 }
 
 // rpcResultTypes specifies the result types that each RPC command can return.
@@ -649,13 +955,16 @@ var rpcResultTypes = map[string][]interface{}{
 	"getaddednodeinfo":      {(*[]string)(nil), (*[]btcjson.GetAddedNodeInfoResult)(nil)},
 	"getaddresstxids":       {(*[]string)(nil)},
 	"getadmininfo":          {(*btcjson.GetAdminInfoResult)(nil)},
+	"getbalance":            {(*float64)(nil)},
 	"getbestblock":          {(*btcjson.GetBestBlockResult)(nil)},
 	"getbestblockhash":      {(*string)(nil)},
 	"getblock":              {(*string)(nil), (*btcjson.GetBlockVerboseResult)(nil)},
+	"getblockchaininfo":     {(*btcjson.GetBlockChainInfoResult)(nil)},
 	"getblockcount":         {(*int64)(nil)},
 	"getblockhash":          {(*string)(nil)},
 	"getblockheader":        {(*string)(nil), (*btcjson.GetBlockHeaderVerboseResult)(nil)},
 	"getblocktemplate":      {(*btcjson.GetBlockTemplateResult)(nil), (*string)(nil), nil},
+	"getchaintips":          {(*[]btcjson.GetChainTipsResult)(nil)},
 	"getconnectioncount":    {(*int32)(nil)},
 	"getcurrentnet":         {(*uint32)(nil)},
 	"getdifficulty":         {(*float64)(nil)},
@@ -663,21 +972,29 @@ var rpcResultTypes = map[string][]interface{}{
 	"gethashespersec":       {(*float64)(nil)},
 	"getheaders":            {(*[]string)(nil)},
 	"getinfo":               {(*btcjson.InfoChainResult)(nil)},
+	"getmempoolentry":       {(*btcjson.GetMempoolEntryResult)(nil)},
 	"getmempoolinfo":        {(*btcjson.GetMempoolInfoResult)(nil)},
 	"getmininginfo":         {(*btcjson.GetMiningInfoResult)(nil)},
 	"getnettotals":          {(*btcjson.GetNetTotalsResult)(nil)},
+	"getnetworkinfo":        {(*btcjson.GetNetworkInfoResult)(nil)},
 	"getnetworkhashps":      {(*int64)(nil)},
 	"getpeerinfo":           {(*[]btcjson.GetPeerInfoResult)(nil)},
 	"getrawmempool":         {(*[]string)(nil), (*btcjson.GetRawMempoolVerboseResult)(nil)},
 	"getrawtransaction":     {(*string)(nil), (*btcjson.TxRawResult)(nil)},
+	"getrpcinfo":            {(*btcjson.GetRPCInfoResult)(nil)},
 	"gettxout":              {(*btcjson.GetTxOutResult)(nil)},
 	"node":                  nil,
 	"help":                  {(*string)(nil), (*string)(nil)},
+	"importaddress":         nil,
+	"importpubkey":          nil,
+	"listsinceblock":        {(*btcjson.ListSinceBlockResult)(nil)},
+	"listtransactions":      {(*[]btcjson.ListTransactionsResult)(nil)},
+	"listunspent":           {(*[]btcjson.ListUnspentResult)(nil)},
 	"ping":                  nil,
+	"reloadconfig":          {(*string)(nil)},
 	"searchrawtransactions": {(*string)(nil), (*[]btcjson.SearchRawTransactionsResult)(nil)},
 	"sendrawtransaction":    {(*string)(nil)},
 	"setgenerate":           nil,
-	"setvalidatekeys":       nil,
 	"stop":                  {(*string)(nil)},
 	"submitblock":           {nil, (*string)(nil)},
 	"validateaddress":       {(*btcjson.ValidateAddressChainResult)(nil)},
@@ -689,6 +1006,8 @@ var rpcResultTypes = map[string][]interface{}{
 	"session":                   {(*btcjson.SessionResult)(nil)},
 	"notifyblocks":              nil,
 	"stopnotifyblocks":          nil,
+	"notifyheaders":             nil,
+	"stopnotifyheaders":         nil,
 	"notifynewtransactions":     nil,
 	"stopnotifynewtransactions": nil,
 	"notifyreceived":            nil,
@@ -697,6 +1016,7 @@ var rpcResultTypes = map[string][]interface{}{
 	"stopnotifyspent":           nil,
 	"rescan":                    nil,
 	"rescanblocks":              {(*[]btcjson.RescannedBlock)(nil)},
+	"rescanchain":               nil,
 }
 
 // helpCacher provides a concurrent safe type that provides help and usage for
@@ -705,6 +1025,21 @@ type helpCacher struct {
 	sync.Mutex
 	usage      string
 	methodHelp map[string]string
+	openRPC    *btcjson.OpenRPCDocument
+}
+
+// rpcMethodResultTypes returns the result types of the provided method, which
+// are registered with the btcjson package for its documented extension methods.
+func rpcMethodResultTypes(method string) ([]interface{}, error) {
+	if resultTypes, ok := rpcResultTypes[method]; ok {
+		return resultTypes, nil
+	}
+	doc, err := btcjson.RegisteredMethodDoc(method)
+	if err != nil {
+		return nil, errors.New("no result types specified for method " +
+			method)
+	}
+	return doc.ResultTypes(), nil
 }
 
 // rpcMethodHelp returns an RPC help string for the provided method.
@@ -720,10 +1055,9 @@ func (c *helpCacher) rpcMethodHelp(method string) (string, error) {
 	}
 
 	// Look up the result types for the method.
-	resultTypes, ok := rpcResultTypes[method]
-	if !ok {
-		return "", errors.New("no result types specified for method " +
-			method)
+	resultTypes, err := rpcMethodResultTypes(method)
+	if err != nil {
+		return "", err
 	}
 
 	// Generate, cache, and return the help.
@@ -773,6 +1107,40 @@ func (c *helpCacher) rpcUsage(includeWebsockets bool) (string, error) {
 	return c.usage, nil
 }
 
+// rpcOpenRPC returns an OpenRPC document describing all supported RPC
+// commands.
+//
+// This function is safe for concurrent access.
+func (c *helpCacher) rpcOpenRPC() (*btcjson.OpenRPCDocument, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	// Return the cached document if it is available.
+	if c.openRPC != nil {
+		return c.openRPC, nil
+	}
+
+	methods := make([]string, 0, len(rpcHandlers))
+	resultTypes := make(map[string][]interface{}, len(rpcHandlers))
+	for k := range rpcHandlers {
+		types, err := rpcMethodResultTypes(k)
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, k)
+		resultTypes[k] = types
+	}
+
+	info := btcjson.OpenRPCInfo{Title: "dmgd", Version: version()}
+	doc, err := btcjson.GenerateOpenRPC(info, methods, helpDescsEnUS,
+		resultTypes)
+	if err != nil {
+		return nil, err
+	}
+	c.openRPC = doc
+	return doc, nil
+}
+
 // newHelpCacher returns a new instance of a help cacher which provides help and
 // usage for the RPC server commands and caches the results for future calls.
 func newHelpCacher() *helpCacher {
@@ -4,17 +4,17 @@
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
-package main
+package node
 
 import "testing"
 
 // TestHelp ensures the help is reasonably accurate by checking that every
 // command specified also has result types defined and the one-line usage and
-// help text can be generated for them.
+// help text and OpenRPC document can be generated for them.
 func TestHelp(t *testing.T) {
 	// Ensure there are result types specified for every handler.
 	for k := range rpcHandlers {
-		if _, ok := rpcResultTypes[k]; !ok {
+		if _, err := rpcMethodResultTypes(k); err != nil {
 			t.Errorf("RPC handler defined for method '%v' without "+
 				"also specifying result types", k)
 			continue
@@ -22,7 +22,7 @@ func TestHelp(t *testing.T) {
 
 	}
 	for k := range wsHandlers {
-		if _, ok := rpcResultTypes[k]; !ok {
+		if _, err := rpcMethodResultTypes(k); err != nil {
 			t.Errorf("RPC handler defined for method '%v' without "+
 				"also specifying result types", k)
 			continue
@@ -64,4 +64,15 @@ func TestHelp(t *testing.T) {
 			continue
 		}
 	}
+
+	// Ensure the OpenRPC document can be generated without errors and
+	// describes every command.
+	doc, err := helpCacher.rpcOpenRPC()
+	if err != nil {
+		t.Fatalf("Failed to generate OpenRPC document: %v", err)
+	}
+	if len(doc.Methods) != len(rpcHandlers) {
+		t.Fatalf("OpenRPC document describes %d methods, want %d",
+			len(doc.Methods), len(rpcHandlers))
+	}
 }
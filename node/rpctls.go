@@ -0,0 +1,101 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+)
+
+// rpcTLS holds the TLS configuration of the RPC server, which is replaced when
+// the certificates are reloaded, so they can be rotated without a restart.
+// Connections which are already established keep the certificates they were
+// made with.
+type rpcTLS struct {
+	mtx    sync.RWMutex
+	config *tls.Config
+}
+
+// loadRPCTLSConfig reads the certificates and keys of the RPC server and the
+// client certificate authorities named by the passed configuration and returns
+// the TLS configuration serving them.  The certificate of the --rpccert option
+// is served to clients which request none of the names of the certificates of
+// the --rpcsnicert options with server name indication (SNI).
+func loadRPCTLSConfig(cfg *config) (*tls.Config, error) {
+	if len(cfg.RPCSNICerts) != len(cfg.RPCSNIKeys) {
+		return nil, fmt.Errorf("%d --rpcsnicert options specified "+
+			"but %d --rpcsnikey options", len(cfg.RPCSNICerts),
+			len(cfg.RPCSNIKeys))
+	}
+
+	keypair, err := tls.LoadX509KeyPair(cfg.RPCCert, cfg.RPCKey)
+	if err != nil {
+		return nil, err
+	}
+	certs := []tls.Certificate{keypair}
+	for i, certFile := range cfg.RPCSNICerts {
+		keypair, err := tls.LoadX509KeyPair(certFile, cfg.RPCSNIKeys[i])
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, keypair)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: certs,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	// Require clients to present a certificate issued by one of the
+	// client certificate authorities when they are configured.
+	if cfg.RPCClientCA != "" {
+		pem, err := ioutil.ReadFile(cfg.RPCClientCA)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no valid certificates found in " +
+				cfg.RPCClientCA)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// set replaces the TLS configuration used for new connections.
+//
+// This function is safe for concurrent access.
+func (t *rpcTLS) set(config *tls.Config) {
+	t.mtx.Lock()
+	t.config = config
+	t.mtx.Unlock()
+}
+
+// getConfigForClient returns the current TLS configuration for a new
+// connection.  It is used as the GetConfigForClient callback of the listener
+// configuration.
+//
+// This function is safe for concurrent access.
+func (t *rpcTLS) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	return t.config, nil
+}
+
+// listenerConfig returns the TLS configuration of the listeners of the RPC
+// server, which defers to the current configuration for every connection.
+func (t *rpcTLS) listenerConfig() *tls.Config {
+	return &tls.Config{
+		GetConfigForClient: t.getConfigForClient,
+		MinVersion:         tls.VersionTLS12,
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/provautil"
+)
+
+// writeTestCertPair writes a new self-signed certificate for the passed host
+// and its key to files in the passed directory and returns their paths and the
+// certificate.
+func writeTestCertPair(t *testing.T, dir, name, host string) (string, string, []byte) {
+	validUntil := time.Now().Add(time.Hour)
+	cert, key, err := provautil.NewTLSCertPair(name, validUntil,
+		[]string{host})
+	if err != nil {
+		t.Fatalf("NewTLSCertPair: %v", err)
+	}
+	certFile := filepath.Join(dir, name+".cert")
+	keyFile := filepath.Join(dir, name+".key")
+	if err := ioutil.WriteFile(certFile, cert, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := ioutil.WriteFile(keyFile, key, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return certFile, keyFile, cert
+}
+
+// TestRPCTLS ensures the RPC server serves the certificate matching the server
+// name requested by clients, serves reloaded certificates to new connections
+// and requires client certificates when client certificate authorities are
+// configured.
+func TestRPCTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpctls")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile, defaultCert := writeTestCertPair(t, dir, "default",
+		"rpc.example")
+	sniCertFile, sniKeyFile, sniCert := writeTestCertPair(t, dir, "sni",
+		"sni.example")
+	testCfg := &config{
+		RPCCert:     certFile,
+		RPCKey:      keyFile,
+		RPCSNICerts: []string{sniCertFile},
+		RPCSNIKeys:  []string{sniKeyFile},
+	}
+	tlsConfig, err := loadRPCTLSConfig(testCfg)
+	if err != nil {
+		t.Fatalf("loadRPCTLSConfig: %v", err)
+	}
+	rpcTLS := &rpcTLS{}
+	rpcTLS.set(tlsConfig)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0",
+		rpcTLS.listenerConfig())
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	// dial connects to the listener requesting the passed server name and
+	// returns the certificate the server presented.
+	dial := func(serverName string, clientCerts ...tls.Certificate) ([]byte, error) {
+		conn, err := tls.Dial("tcp", listener.Addr().String(),
+			&tls.Config{
+				ServerName:         serverName,
+				InsecureSkipVerify: true,
+				Certificates:       clientCerts,
+			})
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+
+		// The server may only verify the client certificate after the
+		// client finished its handshake, so wait for the server to
+		// close the connection to learn whether it rejected it.
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+			return nil, err
+		}
+		return conn.ConnectionState().PeerCertificates[0].Raw, nil
+	}
+	checkCert := func(serverName string, want []byte) {
+		t.Helper()
+		got, err := dial(serverName)
+		if err != nil {
+			t.Fatalf("dial %s: %v", serverName, err)
+		}
+		if !bytes.Equal(got, pemCertDER(t, want)) {
+			t.Fatalf("dial %s: server presented the wrong "+
+				"certificate", serverName)
+		}
+	}
+	checkCert("rpc.example", defaultCert)
+	checkCert("sni.example", sniCert)
+	checkCert("unknown.example", defaultCert)
+
+	// Replace the default certificate and ensure new connections are
+	// served the new one once it is reloaded.
+	_, _, rotatedCert := writeTestCertPair(t, dir, "default", "rpc.example")
+	checkCert("rpc.example", defaultCert)
+	tlsConfig, err = loadRPCTLSConfig(testCfg)
+	if err != nil {
+		t.Fatalf("loadRPCTLSConfig: %v", err)
+	}
+	rpcTLS.set(tlsConfig)
+	checkCert("rpc.example", rotatedCert)
+
+	// Require client certificates issued by the SNI certificate, which is
+	// its own certificate authority.
+	testCfg.RPCClientCA = sniCertFile
+	tlsConfig, err = loadRPCTLSConfig(testCfg)
+	if err != nil {
+		t.Fatalf("loadRPCTLSConfig: %v", err)
+	}
+	rpcTLS.set(tlsConfig)
+	if _, err := dial("rpc.example"); err == nil {
+		t.Fatal("dial: no error without a client certificate")
+	}
+	clientCert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("LoadX509KeyPair: %v", err)
+	}
+	if _, err := dial("rpc.example", clientCert); err == nil {
+		t.Fatal("dial: no error with an untrusted client certificate")
+	}
+	clientCert, err = tls.LoadX509KeyPair(sniCertFile, sniKeyFile)
+	if err != nil {
+		t.Fatalf("LoadX509KeyPair: %v", err)
+	}
+	if _, err := dial("rpc.example", clientCert); err != nil {
+		t.Fatalf("dial: unexpected error with a trusted client "+
+			"certificate: %v", err)
+	}
+
+	// Ensure invalid files and unpaired options are rejected.
+	testCfg.RPCClientCA = filepath.Join(dir, "missing.cert")
+	if _, err := loadRPCTLSConfig(testCfg); err == nil {
+		t.Fatal("loadRPCTLSConfig: no error for missing client CA file")
+	}
+	testCfg.RPCClientCA = ""
+	testCfg.RPCSNIKeys = nil
+	if _, err := loadRPCTLSConfig(testCfg); err == nil {
+		t.Fatal("loadRPCTLSConfig: no error for unpaired SNI " +
+			"certificate")
+	}
+}
+
+// pemCertDER returns the DER encoding of the first certificate in the passed
+// PEM data.
+func pemCertDER(t *testing.T, pemCert []byte) []byte {
+	block, _ := pem.Decode(pemCert)
+	if block == nil {
+		t.Fatal("invalid PEM certificate")
+	}
+	return block.Bytes
+}
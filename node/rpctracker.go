@@ -0,0 +1,142 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcjson"
+)
+
+// maxSlowRPCCalls is the number of most recent slow RPC calls which are kept
+// for the getrpcinfo command.
+const maxSlowRPCCalls = 100
+
+// rpcCall describes an RPC call which is being or has been serviced.
+type rpcCall struct {
+	id         uint64
+	method     string
+	remoteAddr string
+	start      time.Time
+	duration   time.Duration
+}
+
+// result returns the getrpcinfo description of the call.  The duration of
+// calls which are still being serviced is measured up to the passed time.
+func (c *rpcCall) result(now time.Time) btcjson.RPCCallResult {
+	duration := c.duration
+	if duration == 0 {
+		duration = now.Sub(c.start)
+	}
+	return btcjson.RPCCallResult{
+		ID:         c.id,
+		Method:     c.method,
+		RemoteAddr: c.remoteAddr,
+		StartTime:  c.start.Unix(),
+		Duration:   int64(duration / time.Microsecond),
+	}
+}
+
+// rpcTracker assigns IDs to RPC calls, which correlate the log messages of a
+// call, and records how long their handlers take.  It keeps the calls which
+// are being serviced and the most recent calls which took longer than the
+// slow call threshold.
+type rpcTracker struct {
+	slowThreshold time.Duration
+
+	mtx    sync.Mutex
+	nextID uint64
+	active map[uint64]*rpcCall
+	slow   []*rpcCall
+}
+
+// newRPCTracker returns a new RPC call tracker which logs and keeps the calls
+// which take longer than the passed threshold.  Slow calls are not tracked
+// when the threshold is zero.
+func newRPCTracker(slowThreshold time.Duration) *rpcTracker {
+	return &rpcTracker{
+		slowThreshold: slowThreshold,
+		active:        make(map[uint64]*rpcCall),
+	}
+}
+
+// start records that a call of the passed method from the passed address is
+// about to be serviced and returns it with its assigned ID.
+//
+// This function is safe for concurrent access.
+func (t *rpcTracker) start(method, remoteAddr string) *rpcCall {
+	t.mtx.Lock()
+	t.nextID++
+	call := &rpcCall{
+		id:         t.nextID,
+		method:     method,
+		remoteAddr: remoteAddr,
+		start:      time.Now(),
+	}
+	t.active[call.id] = call
+	t.mtx.Unlock()
+
+	rpcsLog.Debugf("RPC call %d <%s> from %s", call.id, method, remoteAddr)
+	return call
+}
+
+// finish records that the passed call has been serviced and logs it when it
+// was slow.
+//
+// This function is safe for concurrent access.
+func (t *rpcTracker) finish(call *rpcCall) {
+	duration := time.Since(call.start)
+	slow := t.slowThreshold > 0 && duration > t.slowThreshold
+
+	t.mtx.Lock()
+	delete(t.active, call.id)
+	call.duration = duration
+	if slow {
+		if len(t.slow) == maxSlowRPCCalls {
+			copy(t.slow, t.slow[1:])
+			t.slow = t.slow[:len(t.slow)-1]
+		}
+		t.slow = append(t.slow, call)
+	}
+	t.mtx.Unlock()
+
+	if slow {
+		rpcsLog.Warnf("Slow RPC call %d <%s> from %s took %v", call.id,
+			call.method, call.remoteAddr, duration)
+		return
+	}
+	rpcsLog.Tracef("RPC call %d <%s> took %v", call.id, call.method,
+		duration)
+}
+
+// info returns the calls which are being serviced, ordered by ID, and the most
+// recent slow calls.
+//
+// This function is safe for concurrent access.
+func (t *rpcTracker) info() *btcjson.GetRPCInfoResult {
+	now := time.Now()
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	result := &btcjson.GetRPCInfoResult{
+		ActiveCommands: make([]btcjson.RPCCallResult, 0, len(t.active)),
+		SlowCalls:      make([]btcjson.RPCCallResult, 0, len(t.slow)),
+		SlowThreshold:  int64(t.slowThreshold / time.Microsecond),
+	}
+	for _, call := range t.active {
+		result.ActiveCommands = append(result.ActiveCommands,
+			call.result(now))
+	}
+	sort.Slice(result.ActiveCommands, func(i, j int) bool {
+		return result.ActiveCommands[i].ID < result.ActiveCommands[j].ID
+	})
+	for _, call := range t.slow {
+		result.SlowCalls = append(result.SlowCalls, call.result(now))
+	}
+	return result
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRPCTracker ensures RPC calls are assigned increasing IDs, reported while
+// they are being serviced and kept when they are slow.
+func TestRPCTracker(t *testing.T) {
+	tracker := newRPCTracker(time.Hour)
+	fast := tracker.start("getinfo", "127.0.0.1:1")
+	slow := tracker.start("searchrawtransactions", "127.0.0.1:2")
+	if fast.id != 1 || slow.id != 2 {
+		t.Fatalf("got call IDs %d and %d, want 1 and 2", fast.id,
+			slow.id)
+	}
+
+	info := tracker.info()
+	if len(info.ActiveCommands) != 2 ||
+		info.ActiveCommands[0].Method != "getinfo" ||
+		info.ActiveCommands[1].Method != "searchrawtransactions" {
+
+		t.Fatalf("got active commands %+v, want both calls",
+			info.ActiveCommands)
+	}
+	if info.SlowThreshold != int64(time.Hour/time.Microsecond) {
+		t.Errorf("got slow threshold %d", info.SlowThreshold)
+	}
+
+	// Pretend the second call started long enough ago to be slow.
+	slow.start = slow.start.Add(-2 * time.Hour)
+	tracker.finish(fast)
+	tracker.finish(slow)
+	info = tracker.info()
+	if len(info.ActiveCommands) != 0 {
+		t.Errorf("got %d active commands after finishing, want 0",
+			len(info.ActiveCommands))
+	}
+	if len(info.SlowCalls) != 1 || info.SlowCalls[0].ID != slow.id ||
+		info.SlowCalls[0].Duration < int64(2*time.Hour/time.Microsecond) {
+
+		t.Fatalf("got slow calls %+v, want the second call",
+			info.SlowCalls)
+	}
+
+	// Only the most recent slow calls are kept.
+	for i := 0; i < maxSlowRPCCalls; i++ {
+		call := tracker.start("getblock", "127.0.0.1:3")
+		call.start = call.start.Add(-2 * time.Hour)
+		tracker.finish(call)
+	}
+	info = tracker.info()
+	if len(info.SlowCalls) != maxSlowRPCCalls ||
+		info.SlowCalls[0].Method != "getblock" {
+
+		t.Errorf("got %d slow calls starting with <%s>, want %d "+
+			"getblock calls", len(info.SlowCalls),
+			info.SlowCalls[0].Method, maxSlowRPCCalls)
+	}
+
+	// Slow calls are not kept when tracking is disabled.
+	tracker = newRPCTracker(0)
+	call := tracker.start("getblock", "127.0.0.1:3")
+	call.start = call.start.Add(-2 * time.Hour)
+	tracker.finish(call)
+	if info := tracker.info(); len(info.SlowCalls) != 0 {
+		t.Errorf("got %d slow calls with tracking disabled, want 0",
+			len(info.SlowCalls))
+	}
+}
@@ -5,14 +5,11 @@
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
-package main
+package node
 
 import (
 	"bytes"
 	"container/list"
-	"crypto/sha256"
-	"crypto/subtle"
-	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -23,9 +20,11 @@ import (
 	"time"
 
 	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/btcec"
 	"github.com/pyx-partners/dmgd/btcjson"
 	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
 	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/issuancemonitor"
 	"github.com/pyx-partners/dmgd/provautil"
 	"github.com/pyx-partners/dmgd/txscript"
 	"github.com/pyx-partners/dmgd/wire"
@@ -67,25 +66,29 @@ var wsHandlersBeforeInit = map[string]wsCommandHandler{
 	"loadtxfilter":              handleLoadTxFilter,
 	"help":                      handleWebsocketHelp,
 	"notifyblocks":              handleNotifyBlocks,
+	"notifyheaders":             handleNotifyHeaders,
 	"notifynewtransactions":     handleNotifyNewTransactions,
 	"notifyreceived":            handleNotifyReceived,
 	"notifyspent":               handleNotifySpent,
 	"session":                   handleSession,
 	"stopnotifyblocks":          handleStopNotifyBlocks,
+	"stopnotifyheaders":         handleStopNotifyHeaders,
 	"stopnotifynewtransactions": handleStopNotifyNewTransactions,
 	"stopnotifyspent":           handleStopNotifySpent,
 	"stopnotifyreceived":        handleStopNotifyReceived,
 	"rescan":                    handleRescan,
 	"rescanblocks":              handleRescanBlocks,
+	"rescanchain":               handleRescanChain,
 }
 
 // WebsocketHandler handles a new websocket client by creating a new wsClient,
 // starting it, and blocking until the connection closes.  Since it blocks, it
 // must be run in a separate goroutine.  It should be invoked from the websocket
 // server handler which runs each new connection in a new goroutine thereby
-// satisfying the requirement.
+// satisfying the requirement.  Clients connected to a limited listener may
+// only call the methods of the limited user.
 func (s *rpcServer) WebsocketHandler(conn *websocket.Conn, remoteAddr string,
-	authenticated bool, isAdmin bool) {
+	auth *rpcAuth, limited bool) {
 
 	// Clear the read deadline that was set before the websocket hijacked
 	// the connection.
@@ -104,7 +107,7 @@ func (s *rpcServer) WebsocketHandler(conn *websocket.Conn, remoteAddr string,
 	// Create a new websocket client to handle the new websocket connection
 	// and wait for it to shutdown.  Once it has shutdown (and hence
 	// disconnected), remove it and any notifications it registered for.
-	client, err := newWebsocketClient(s, conn, remoteAddr, authenticated, isAdmin)
+	client, err := newWebsocketClient(s, conn, remoteAddr, auth, limited)
 	if err != nil {
 		rpcsLog.Errorf("Failed to serve client %s: %v", remoteAddr, err)
 		conn.Close()
@@ -227,6 +230,58 @@ func (m *wsNotificationManager) NotifyBlockDisconnected(block *provautil.Block)
 	}
 }
 
+// NotifyReorganization passes a reorganization of the best chain to the
+// notification manager for block notification processing.
+func (m *wsNotificationManager) NotifyReorganization(event *blockchain.ReorgEvent) {
+	// As NotifyReorganization will be called by the block manager
+	// and the RPC server may no longer be running, use a select
+	// statement to unblock enqueuing the notification once the RPC
+	// server has begun shutting down.
+	select {
+	case m.queueNotification <- (*notificationReorganization)(event):
+	case <-m.quit:
+	}
+}
+
+// NotifyAnnouncement passes a new governance announcement to the notification
+// manager for block notification processing.
+func (m *wsNotificationManager) NotifyAnnouncement(ann *announcement) {
+	// As NotifyAnnouncement will be called by peers and the RPC server
+	// may no longer be running, use a select statement to unblock
+	// enqueuing the notification once the RPC server has begun shutting
+	// down.
+	select {
+	case m.queueNotification <- (*notificationAnnouncement)(ann):
+	case <-m.quit:
+	}
+}
+
+// NotifyUnknownRules passes a change of the unknown rules state of the main
+// chain to the notification manager for block notification processing.
+func (m *wsNotificationManager) NotifyUnknownRules(status *blockchain.UnknownRulesStatus) {
+	// As NotifyUnknownRules will be called by the block manager and the
+	// RPC server may no longer be running, use a select statement to
+	// unblock enqueuing the notification once the RPC server has begun
+	// shutting down.
+	select {
+	case m.queueNotification <- (*notificationUnknownRules)(status):
+	case <-m.quit:
+	}
+}
+
+// NotifyIssuanceAlert passes an alert of the issuance monitor to the
+// notification manager for block notification processing.
+func (m *wsNotificationManager) NotifyIssuanceAlert(alert *issuancemonitor.Alert) {
+	// As NotifyIssuanceAlert will be called by the issuance monitor and
+	// the RPC server may no longer be running, use a select statement to
+	// unblock enqueuing the notification once the RPC server has begun
+	// shutting down.
+	select {
+	case m.queueNotification <- (*notificationIssuanceAlert)(alert):
+	case <-m.quit:
+	}
+}
+
 // NotifyMempoolTx passes a transaction accepted by mempool to the
 // notification manager for transaction notification processing.  If
 // isNew is true, the tx is is a new transaction, rather than one
@@ -250,6 +305,10 @@ func (m *wsNotificationManager) NotifyMempoolTx(tx *provautil.Tx, isNew bool) {
 // Notification types
 type notificationBlockConnected provautil.Block
 type notificationBlockDisconnected provautil.Block
+type notificationReorganization blockchain.ReorgEvent
+type notificationAnnouncement announcement
+type notificationUnknownRules blockchain.UnknownRulesStatus
+type notificationIssuanceAlert issuancemonitor.Alert
 type notificationTxAcceptedByMempool struct {
 	isNew bool
 	tx    *provautil.Tx
@@ -260,6 +319,8 @@ type notificationRegisterClient wsClient
 type notificationUnregisterClient wsClient
 type notificationRegisterBlocks wsClient
 type notificationUnregisterBlocks wsClient
+type notificationRegisterHeaders wsClient
+type notificationUnregisterHeaders wsClient
 type notificationRegisterNewMempoolTxs wsClient
 type notificationUnregisterNewMempoolTxs wsClient
 type notificationRegisterSpent struct {
@@ -293,6 +354,7 @@ func (m *wsNotificationManager) notificationHandler() {
 	// Where possible, the quit channel is used as the unique id for a client
 	// since it is quite a bit more efficient than using the entire struct.
 	blockNotifications := make(map[chan struct{}]*wsClient)
+	headerNotifications := make(map[chan struct{}]*wsClient)
 	txNotifications := make(map[chan struct{}]*wsClient)
 	watchedOutPoints := make(map[wire.OutPoint]map[chan struct{}]*wsClient)
 	watchedAddrs := make(map[string]map[chan struct{}]*wsClient)
@@ -324,6 +386,10 @@ out:
 					m.notifyFilteredBlockConnected(blockNotifications,
 						block)
 				}
+				if len(headerNotifications) != 0 {
+					m.notifyHeaderConnected(headerNotifications,
+						block)
+				}
 
 			case *notificationBlockDisconnected:
 				block := (*provautil.Block)(n)
@@ -335,6 +401,22 @@ out:
 						block)
 				}
 
+			case *notificationReorganization:
+				m.notifyReorganization(blockNotifications,
+					(*blockchain.ReorgEvent)(n))
+
+			case *notificationAnnouncement:
+				m.notifyAnnouncement(blockNotifications,
+					(*announcement)(n))
+
+			case *notificationUnknownRules:
+				m.notifyUnknownRules(blockNotifications,
+					(*blockchain.UnknownRulesStatus)(n))
+
+			case *notificationIssuanceAlert:
+				m.notifyIssuanceAlert(blockNotifications,
+					(*issuancemonitor.Alert)(n))
+
 			case *notificationTxAcceptedByMempool:
 				if n.isNew && len(txNotifications) != 0 {
 					m.notifyForNewTx(txNotifications, n.tx)
@@ -350,6 +432,14 @@ out:
 				wsc := (*wsClient)(n)
 				delete(blockNotifications, wsc.quit)
 
+			case *notificationRegisterHeaders:
+				wsc := (*wsClient)(n)
+				headerNotifications[wsc.quit] = wsc
+
+			case *notificationUnregisterHeaders:
+				wsc := (*wsClient)(n)
+				delete(headerNotifications, wsc.quit)
+
 			case *notificationRegisterClient:
 				wsc := (*wsClient)(n)
 				clients[wsc.quit] = wsc
@@ -359,6 +449,7 @@ out:
 				// Remove any requests made by the client as well as
 				// the client itself.
 				delete(blockNotifications, wsc.quit)
+				delete(headerNotifications, wsc.quit)
 				delete(txNotifications, wsc.quit)
 				for k := range wsc.spentRequests {
 					op := k
@@ -425,6 +516,11 @@ type wsClientFilter struct {
 	// there's a good chance a fast path should be added.
 	otherAddresses map[string]struct{}
 
+	// ASP key ids.  Any address which includes one of these key ids is
+	// considered relevant, which allows clients to watch every address
+	// issued under an ASP without listing them individually.
+	keyIDs map[btcec.KeyID]struct{}
+
 	// Outpoints of unspent outputs.
 	unspent map[wire.OutPoint]struct{}
 }
@@ -433,13 +529,16 @@ type wsClientFilter struct {
 // for a websocket client.
 //
 // NOTE: This extension was ported from github.com/decred/dcrd
-func newWSClientFilter(addresses []string, unspentOutPoints []wire.OutPoint) *wsClientFilter {
+func newWSClientFilter(addresses []string, unspentOutPoints []wire.OutPoint,
+	keyIDs []btcec.KeyID) *wsClientFilter {
+
 	filter := &wsClientFilter{
 		pubKeyHashes:        map[[ripemd160.Size]byte]struct{}{},
 		scriptHashes:        map[[ripemd160.Size]byte]struct{}{},
 		compressedPubKeys:   map[[33]byte]struct{}{},
 		uncompressedPubKeys: map[[65]byte]struct{}{},
 		otherAddresses:      map[string]struct{}{},
+		keyIDs:              make(map[btcec.KeyID]struct{}, len(keyIDs)),
 		unspent:             make(map[wire.OutPoint]struct{}, len(unspentOutPoints)),
 	}
 
@@ -449,6 +548,9 @@ func newWSClientFilter(addresses []string, unspentOutPoints []wire.OutPoint) *ws
 	for i := range unspentOutPoints {
 		filter.addUnspentOutPoint(&unspentOutPoints[i])
 	}
+	for _, keyID := range keyIDs {
+		filter.addKeyID(keyID)
+	}
 
 	return filter
 }
@@ -506,6 +608,25 @@ func (f *wsClientFilter) removeAddressStr(s string) {
 	}
 }
 
+// addKeyID adds an ASP key id to the wsClientFilter.
+func (f *wsClientFilter) addKeyID(keyID btcec.KeyID) {
+	f.keyIDs[keyID] = struct{}{}
+}
+
+// relevantAddress returns true if the passed address has been added to the
+// wsClientFilter or includes any of the key ids which have been added to it.
+func (f *wsClientFilter) relevantAddress(a provautil.Address) bool {
+	if f.existsAddress(a) {
+		return true
+	}
+	for _, keyID := range a.ScriptKeyIDs() {
+		if _, ok := f.keyIDs[keyID]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 // addUnspentOutPoint adds an outpoint to the wsClientFilter.
 //
 // NOTE: This extension was ported from github.com/decred/dcrd
@@ -551,6 +672,18 @@ func (m *wsNotificationManager) UnregisterBlockUpdates(wsc *wsClient) {
 	m.queueNotification <- (*notificationUnregisterBlocks)(wsc)
 }
 
+// RegisterHeaderUpdates requests header notifications to the passed websocket
+// client.
+func (m *wsNotificationManager) RegisterHeaderUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationRegisterHeaders)(wsc)
+}
+
+// UnregisterHeaderUpdates removes header notifications for the passed
+// websocket client.
+func (m *wsNotificationManager) UnregisterHeaderUpdates(wsc *wsClient) {
+	m.queueNotification <- (*notificationUnregisterHeaders)(wsc)
+}
+
 // subscribedClients returns the set of all websocket client quit channels that
 // are registered to receive notifications regarding tx, either due to tx
 // spending a watched output or outputting to a watched address.  Matching
@@ -597,7 +730,7 @@ func (m *wsNotificationManager) subscribedClients(tx *provautil.Tx,
 			}
 			filter.mu.Lock()
 			for _, a := range addrs {
-				if filter.existsAddress(a) {
+				if filter.relevantAddress(a) {
 					subscribed[quitChan] = struct{}{}
 					op := wire.OutPoint{
 						Hash:  *tx.Hash(),
@@ -632,6 +765,38 @@ func (*wsNotificationManager) notifyBlockConnected(clients map[chan struct{}]*ws
 	}
 }
 
+// notifyHeaderConnected notifies websocket clients that have registered for
+// header updates when a block is connected to the main chain.  Only the header
+// is sent, along with the validate key and signature decoded from it, for
+// clients which monitor the chain tip without needing the transactions.
+func (*wsNotificationManager) notifyHeaderConnected(clients map[chan struct{}]*wsClient,
+	block *provautil.Block) {
+
+	header := &block.MsgBlock().Header
+	var w bytes.Buffer
+	if err := header.Serialize(&w); err != nil {
+		rpcsLog.Errorf("Failed to serialize header for header connected "+
+			"notification: %v", err)
+		return
+	}
+	ntfn := btcjson.NewHeaderConnectedNtfn(btcjson.HeaderConnectedResult{
+		Hash:             block.Hash().String(),
+		Height:           block.Height(),
+		Header:           hex.EncodeToString(w.Bytes()),
+		ValidatingPubKey: header.ValidatingPubKey.String(),
+		Signature:        header.Signature.String(),
+	})
+	marshalledJSON, err := btcjson.MarshalCmd(nil, ntfn)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal header connected notification: "+
+			"%v", err)
+		return
+	}
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
 // notifyBlockDisconnected notifies websocket clients that have registered for
 // block updates when a block is disconnected from the main chain (due to a
 // reorganize).
@@ -733,6 +898,112 @@ func (*wsNotificationManager) notifyFilteredBlockDisconnected(clients map[chan s
 	}
 }
 
+// notifyReorganization notifies websocket clients that have registered for
+// block updates when the main chain is reorganized.
+func (*wsNotificationManager) notifyReorganization(clients map[chan struct{}]*wsClient, event *blockchain.ReorgEvent) {
+	// Skip notification creation if no clients have requested block
+	// notifications.
+	if len(clients) == 0 {
+		return
+	}
+
+	// Notify interested websocket clients about the reorganization.
+	ntfn := btcjson.NewReorganizationNtfn(*reorgEventResult(event))
+	marshalledJSON, err := btcjson.MarshalCmd(nil, ntfn)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal reorganization notification: "+
+			"%v", err)
+		return
+	}
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
+// notifyAnnouncement notifies websocket clients that have registered for block
+// updates of a new governance announcement.
+func (*wsNotificationManager) notifyAnnouncement(clients map[chan struct{}]*wsClient, ann *announcement) {
+	// Skip notification creation if no clients have requested block
+	// notifications.
+	if len(clients) == 0 {
+		return
+	}
+
+	// Notify interested websocket clients about the announcement.
+	result, err := announcementResult(ann)
+	if err != nil {
+		rpcsLog.Errorf("Failed to serialize announcement: %v", err)
+		return
+	}
+	ntfn := btcjson.NewAnnouncementNtfn(*result)
+	marshalledJSON, err := btcjson.MarshalCmd(nil, ntfn)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal announcement notification: "+
+			"%v", err)
+		return
+	}
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
+// notifyUnknownRules notifies websocket clients that have registered for block
+// updates when the main chain starts or stops signaling or enforcing consensus
+// rules the node does not know.
+func (*wsNotificationManager) notifyUnknownRules(clients map[chan struct{}]*wsClient, status *blockchain.UnknownRulesStatus) {
+	// Skip notification creation if no clients have requested block
+	// notifications.
+	if len(clients) == 0 {
+		return
+	}
+
+	// Notify interested websocket clients about the unknown rules.
+	ntfn := btcjson.NewUnknownRulesNtfn(unknownRulesResult(status))
+	marshalledJSON, err := btcjson.MarshalCmd(nil, ntfn)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal unknown rules notification: "+
+			"%v", err)
+		return
+	}
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
+// notifyIssuanceAlert notifies websocket clients that have registered for block
+// updates when the issuance monitor raises an alert.
+func (*wsNotificationManager) notifyIssuanceAlert(clients map[chan struct{}]*wsClient, alert *issuancemonitor.Alert) {
+	// Skip notification creation if no clients have requested block
+	// notifications.
+	if len(clients) == 0 {
+		return
+	}
+
+	// Notify interested websocket clients about the issuance alert.
+	ntfn := btcjson.NewIssuanceAlertNtfn(btcjson.IssuanceAlertResult{
+		Kind:         alert.Kind,
+		TxID:         alert.TxID,
+		BlockHash:    alert.BlockHash,
+		Height:       alert.Height,
+		Time:         alert.Time,
+		AssetID:      alert.AssetID,
+		Amount:       provautil.Amount(alert.Amount).ToDMG(),
+		Window:       alert.Window,
+		WindowAmount: provautil.Amount(alert.WindowAmount).ToDMG(),
+		MaxAmount:    provautil.Amount(alert.MaxAmount).ToDMG(),
+		Message:      alert.Message,
+	})
+	marshalledJSON, err := btcjson.MarshalCmd(nil, ntfn)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal issuance alert notification: "+
+			"%v", err)
+		return
+	}
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
 // RegisterNewMempoolTxsUpdates requests notifications to the passed websocket
 // client when new transactions are added to the memory pool.
 func (m *wsNotificationManager) RegisterNewMempoolTxsUpdates(wsc *wsClient) {
@@ -1175,9 +1446,15 @@ type wsClient struct {
 	// and therefore is allowed to communicated over the websocket.
 	authenticated bool
 
-	// isAdmin specifies whether a client may change the state of the server;
-	// false means its access is only to the limited set of RPC calls.
-	isAdmin bool
+	// auth holds the credentials the client authenticated with, which
+	// specify the RPC calls it may make.  It is nil until the client is
+	// authenticated.
+	auth *rpcAuth
+
+	// limited specifies whether the client connected to a limited
+	// listener, so the credentials it authenticates with may only call
+	// the methods of the limited user.
+	limited bool
 
 	// sessionID is a random ID generated for each client when connected.
 	// These IDs may be queried by a client using the session RPC.  A change
@@ -1313,17 +1590,17 @@ out:
 			break out
 		case !c.authenticated:
 			// Check credentials.
-			login := authCmd.Username + ":" + authCmd.Passphrase
-			auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
-			authSha := sha256.Sum256([]byte(auth))
-			cmp := subtle.ConstantTimeCompare(authSha[:], c.server.authsha[:])
-			limitcmp := subtle.ConstantTimeCompare(authSha[:], c.server.limitauthsha[:])
-			if cmp != 1 && limitcmp != 1 {
+			authSha := rpcAuthSha(authCmd.Username, authCmd.Passphrase)
+			auth := c.server.lookupAuth(authSha)
+			if auth == nil {
 				rpcsLog.Warnf("Auth failure.")
 				break out
 			}
+			if c.limited {
+				auth = auth.limit()
+			}
 			c.authenticated = true
-			c.isAdmin = cmp == 1
+			c.auth = auth
 
 			// Marshal and send response.
 			reply, err := createMarshalledReply(cmd.id, nil, nil)
@@ -1338,22 +1615,20 @@ out:
 
 		// Check if the client is using limited RPC credentials and
 		// error when not authorized to call this RPC.
-		if !c.isAdmin {
-			if _, ok := rpcLimited[request.Method]; !ok {
-				jsonErr := &btcjson.RPCError{
-					Code:    btcjson.ErrRPCInvalidParams.Code,
-					Message: "limited user not authorized for this method",
-				}
-				// Marshal and send response.
-				reply, err := createMarshalledReply(request.ID, nil, jsonErr)
-				if err != nil {
-					rpcsLog.Errorf("Failed to marshal parse failure "+
-						"reply: %v", err)
-					continue
-				}
-				c.SendMessage(reply, nil)
+		if !c.auth.allowed(request.Method) {
+			jsonErr := &btcjson.RPCError{
+				Code:    btcjson.ErrRPCInvalidParams.Code,
+				Message: "limited user not authorized for this method",
+			}
+			// Marshal and send response.
+			reply, err := createMarshalledReply(request.ID, nil, jsonErr)
+			if err != nil {
+				rpcsLog.Errorf("Failed to marshal parse failure "+
+					"reply: %v", err)
 				continue
 			}
+			c.SendMessage(reply, nil)
+			continue
 		}
 
 		// Asynchronously handle the request.  A semaphore is used to
@@ -1400,12 +1675,14 @@ func (c *wsClient) serviceRequest(r *parsedRPCCmd) {
 
 	// Lookup the websocket extension for the command and if it doesn't
 	// exist fallback to handling the command as a standard command.
+	call := c.server.tracker.start(r.method, c.addr)
 	wsHandler, ok := wsHandlers[r.method]
 	if ok {
 		result, err = wsHandler(c, r.cmd)
 	} else {
 		result, err = c.server.standardCmdResult(r, nil)
 	}
+	c.server.tracker.finish(call)
 	reply, err := createMarshalledReply(r.id, result, err)
 	if err != nil {
 		rpcsLog.Errorf("Failed to marshal reply for <%s> "+
@@ -1614,13 +1891,14 @@ func (c *wsClient) WaitForShutdown() {
 }
 
 // newWebsocketClient returns a new websocket client given the notification
-// manager, websocket connection, remote address, and whether or not the client
-// has already been authenticated (via HTTP Basic access authentication).  The
+// manager, websocket connection, remote address, the credentials the client
+// has already authenticated with (via HTTP Basic access authentication) if any,
+// and whether it connected to a limited listener.  The
 // returned client is ready to start.  Once started, the client will process
 // incoming and outgoing messages in separate goroutines complete with queuing
 // and asynchrous handling for long-running operations.
 func newWebsocketClient(server *rpcServer, conn *websocket.Conn,
-	remoteAddr string, authenticated bool, isAdmin bool) (*wsClient, error) {
+	remoteAddr string, auth *rpcAuth, limited bool) (*wsClient, error) {
 
 	sessionID, err := wire.RandomUint64()
 	if err != nil {
@@ -1630,8 +1908,9 @@ func newWebsocketClient(server *rpcServer, conn *websocket.Conn,
 	client := &wsClient{
 		conn:              conn,
 		addr:              remoteAddr,
-		authenticated:     authenticated,
-		isAdmin:           isAdmin,
+		authenticated:     auth != nil,
+		auth:              auth,
+		limited:           limited,
 		sessionID:         sessionID,
 		server:            server,
 		addrRequests:      make(map[string]struct{}),
@@ -1713,9 +1992,18 @@ func handleLoadTxFilter(wsc *wsClient, icmd interface{}) (interface{}, error) {
 		}
 	}
 
+	var keyIDs []btcec.KeyID
+	if cmd.KeyIDs != nil {
+		keyIDs = make([]btcec.KeyID, len(*cmd.KeyIDs))
+		for i, keyID := range *cmd.KeyIDs {
+			keyIDs[i] = btcec.KeyID(keyID)
+		}
+	}
+
 	wsc.Lock()
 	if cmd.Reload || wsc.filterData == nil {
-		wsc.filterData = newWSClientFilter(cmd.Addresses, outPoints)
+		wsc.filterData = newWSClientFilter(cmd.Addresses, outPoints,
+			keyIDs)
 		wsc.Unlock()
 	} else {
 		wsc.Unlock()
@@ -1727,6 +2015,9 @@ func handleLoadTxFilter(wsc *wsClient, icmd interface{}) (interface{}, error) {
 		for i := range outPoints {
 			wsc.filterData.addUnspentOutPoint(&outPoints[i])
 		}
+		for _, keyID := range keyIDs {
+			wsc.filterData.addKeyID(keyID)
+		}
 		wsc.filterData.mu.Unlock()
 	}
 
@@ -1736,8 +2027,205 @@ func handleLoadTxFilter(wsc *wsClient, icmd interface{}) (interface{}, error) {
 // handleNotifyBlocks implements the notifyblocks command extension for
 // websocket connections.
 func handleNotifyBlocks(wsc *wsClient, icmd interface{}) (interface{}, error) {
-	wsc.server.ntfnMgr.RegisterBlockUpdates(wsc)
-	return nil, nil
+	cmd, ok := icmd.(*btcjson.NotifyBlocksCmd)
+	if !ok {
+		return nil, btcjson.ErrRPCInternal
+	}
+
+	if cmd.FromHash == nil {
+		wsc.server.ntfnMgr.RegisterBlockUpdates(wsc)
+		return nil, nil
+	}
+	hash, err := chainhash.NewHashFromStr(*cmd.FromHash)
+	if err != nil {
+		return nil, rpcDecodeHexError(*cmd.FromHash)
+	}
+
+	// A client already registered for block updates is registered again
+	// once the replay is done, so the live notifications do not interleave
+	// with the replayed ones.
+	wsc.server.ntfnMgr.UnregisterBlockUpdates(wsc)
+	return nil, replayBlockNotifications(wsc, hash)
+}
+
+// queueReplayedBlockConnected sends the blockconnected and
+// filteredblockconnected notifications of the passed block to the websocket
+// client, as they are sent to clients registered for block updates when the
+// block is connected to the main chain.
+func queueReplayedBlockConnected(wsc *wsClient, block *provautil.Block) error {
+	ntfn := btcjson.NewBlockConnectedNtfn(block.Hash().String(),
+		int32(block.Height()), block.MsgBlock().Header.Timestamp.Unix())
+	marshalledJSON, err := btcjson.MarshalCmd(nil, ntfn)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal block connected notification: "+
+			"%v", err)
+		return nil
+	}
+	if err := wsc.QueueNotification(marshalledJSON); err != nil {
+		return err
+	}
+
+	var w bytes.Buffer
+	if err := block.MsgBlock().Header.Serialize(&w); err != nil {
+		rpcsLog.Errorf("Failed to serialize header for filtered block "+
+			"connected notification: %v", err)
+		return nil
+	}
+	wsc.Lock()
+	filter := wsc.filterData
+	wsc.Unlock()
+	var subscribedTxs []string
+	if filter != nil {
+		subscribedTxs = rescanBlockFilter(filter, block)
+	}
+	filteredNtfn := btcjson.NewFilteredBlockConnectedNtfn(
+		int32(block.Height()), hex.EncodeToString(w.Bytes()),
+		subscribedTxs)
+	marshalledJSON, err = btcjson.MarshalCmd(nil, filteredNtfn)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal filtered block connected "+
+			"notification: %v", err)
+		return nil
+	}
+	return wsc.QueueNotification(marshalledJSON)
+}
+
+// queueReplayedBlockDisconnected sends the blockdisconnected and
+// filteredblockdisconnected notifications of the passed block to the
+// websocket client, as they are sent to clients registered for block updates
+// when the block is disconnected from the main chain.
+func queueReplayedBlockDisconnected(wsc *wsClient, block *provautil.Block) error {
+	ntfn := btcjson.NewBlockDisconnectedNtfn(block.Hash().String(),
+		int32(block.Height()), block.MsgBlock().Header.Timestamp.Unix())
+	marshalledJSON, err := btcjson.MarshalCmd(nil, ntfn)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal block disconnected "+
+			"notification: %v", err)
+		return nil
+	}
+	if err := wsc.QueueNotification(marshalledJSON); err != nil {
+		return err
+	}
+
+	var w bytes.Buffer
+	if err := block.MsgBlock().Header.Serialize(&w); err != nil {
+		rpcsLog.Errorf("Failed to serialize header for filtered block "+
+			"disconnected notification: %v", err)
+		return nil
+	}
+	filteredNtfn := btcjson.NewFilteredBlockDisconnectedNtfn(
+		int32(block.Height()), hex.EncodeToString(w.Bytes()))
+	marshalledJSON, err = btcjson.MarshalCmd(nil, filteredNtfn)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal filtered block disconnected "+
+			"notification: %v", err)
+		return nil
+	}
+	return wsc.QueueNotification(marshalledJSON)
+}
+
+// replayBlockNotifications sends the block notifications the websocket client
+// missed since the block with the passed hash, which is the last block it was
+// notified of, and then registers it for block updates.  The blocks of the
+// passed block's branch which are no longer in the main chain are reported as
+// disconnected first, from the passed block back to the fork, followed by
+// each main chain block after the fork as connected.  The transactions of the
+// connected blocks matching the filter loaded with loadtxfilter are included
+// in the filteredblockconnected notifications.
+//
+// The client is registered while the block manager is paused once the replay
+// reached the best block, so every block connected or disconnected afterwards
+// is notified exactly once, after the replayed notifications.
+func replayBlockNotifications(wsc *wsClient, from *chainhash.Hash) error {
+	chain := wsc.server.chain
+	last, err := chain.BlockByHash(from)
+	if err != nil {
+		return &btcjson.RPCError{
+			Code:    btcjson.ErrRPCBlockNotFound,
+			Message: "Block not found",
+		}
+	}
+
+	rpcsLog.Debugf("Replaying block notifications after block %v (height "+
+		"%d)", from, last.Height())
+	for {
+		// A select statement is used to stop replays if the client
+		// requesting the replay has disconnected.
+		select {
+		case <-wsc.quit:
+			rpcsLog.Debugf("Stopped replay at height %v for "+
+				"disconnected client", last.Height())
+			return nil
+		default:
+		}
+
+		// Walk back from a block which is no longer in the main chain,
+		// which also handles reorganizations while the replay is
+		// underway.
+		inMainChain, err := chain.MainChainHasBlock(last.Hash())
+		if err != nil {
+			return &btcjson.RPCError{
+				Code:    btcjson.ErrRPCDatabase,
+				Message: "Database error: " + err.Error(),
+			}
+		}
+		if !inMainChain {
+			if queueReplayedBlockDisconnected(wsc, last) == ErrClientQuit {
+				return nil
+			}
+			last, err = chain.BlockByHash(
+				&last.MsgBlock().Header.PrevBlock)
+			if err != nil {
+				return &btcjson.RPCError{
+					Code:    btcjson.ErrRPCDatabase,
+					Message: "Database error: " + err.Error(),
+				}
+			}
+			continue
+		}
+
+		// Register the client once the replay reached the best block.
+		// The block manager is paused so no block is connected before
+		// the registration is queued, which happens after the
+		// notifications of the blocks connected so far.
+		if last.Height() >= chain.BestSnapshot().Height {
+			blockManager := wsc.server.server.blockManager
+			pauseGuard := blockManager.Pause()
+			caughtUp := *chain.BestSnapshot().Hash == *last.Hash()
+			if caughtUp {
+				wsc.server.ntfnMgr.RegisterBlockUpdates(wsc)
+			}
+			close(pauseGuard)
+			if caughtUp {
+				rpcsLog.Debugf("Finished replaying block "+
+					"notifications at height %d",
+					last.Height())
+				return nil
+			}
+			continue
+		}
+
+		// The main chain may have been reorganized since the last block
+		// was found in it, in which case the next block does not
+		// extend it.
+		block, err := chain.BlockByHeight(last.Height() + 1)
+		if err != nil {
+			if chain.BestSnapshot().Height <= last.Height() {
+				continue
+			}
+			return &btcjson.RPCError{
+				Code:    btcjson.ErrRPCDatabase,
+				Message: "Database error: " + err.Error(),
+			}
+		}
+		if block.MsgBlock().Header.PrevBlock != *last.Hash() {
+			continue
+		}
+		if queueReplayedBlockConnected(wsc, block) == ErrClientQuit {
+			return nil
+		}
+		last = block
+	}
 }
 
 // handleSession implements the session command extension for websocket
@@ -1753,6 +2241,20 @@ func handleStopNotifyBlocks(wsc *wsClient, icmd interface{}) (interface{}, error
 	return nil, nil
 }
 
+// handleNotifyHeaders implements the notifyheaders command extension for
+// websocket connections.
+func handleNotifyHeaders(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.RegisterHeaderUpdates(wsc)
+	return nil, nil
+}
+
+// handleStopNotifyHeaders implements the stopnotifyheaders command extension
+// for websocket connections.
+func handleStopNotifyHeaders(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.UnregisterHeaderUpdates(wsc)
+	return nil, nil
+}
+
 // handleNotifySpent implements the notifyspent command extension for
 // websocket connections.
 func handleNotifySpent(wsc *wsClient, icmd interface{}) (interface{}, error) {
@@ -2041,7 +2543,7 @@ func rescanBlockFilter(filter *wsClientFilter, block *provautil.Block) []string
 				continue
 			}
 			for _, a := range addrs {
-				if !filter.existsAddress(a) {
+				if !filter.relevantAddress(a) {
 					continue
 				}
 
@@ -2470,6 +2972,202 @@ fetchRange:
 	return nil, nil
 }
 
+// queueRescanChainProgress sends a rescanchainprogress notification for the
+// passed block to the websocket client.
+func queueRescanChainProgress(wsc *wsClient, blk *provautil.Block, finished bool) error {
+	n := btcjson.NewRescanChainProgressNtfn(blk.Hash().String(),
+		int32(blk.Height()), blk.MsgBlock().Header.Timestamp.Unix(),
+		finished)
+	mn, err := btcjson.MarshalCmd(nil, n)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal rescanchain progress "+
+			"notification: %v", err)
+		return nil
+	}
+	return wsc.QueueNotification(mn)
+}
+
+// handleRescanChain implements the rescanchain command extension for websocket
+// connections.  The main chain blocks within the requested height range are
+// scanned for transactions paying to the passed addresses or key ids, or
+// spending the passed outpoints.  Each block with relevant transactions
+// results in a rescanchainmatch notification, and rescanchainprogress
+// notifications are sent periodically and once the rescan has finished.
+//
+// The rescan uses its own transaction filter, so the filter loaded with
+// loadtxfilter is left untouched.
+func handleRescanChain(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	cmd, ok := icmd.(*btcjson.RescanChainCmd)
+	if !ok {
+		return nil, btcjson.ErrRPCInternal
+	}
+
+	for _, addrStr := range cmd.Addresses {
+		_, err := provautil.DecodeAddress(addrStr, activeNetParams.Params)
+		if err != nil {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCInvalidAddressOrKey,
+				Message: "Rescan address " + addrStr + ": " +
+					err.Error(),
+			}
+		}
+	}
+	outPoints := make([]wire.OutPoint, len(cmd.OutPoints))
+	for i := range cmd.OutPoints {
+		hash, err := chainhash.NewHashFromStr(cmd.OutPoints[i].Hash)
+		if err != nil {
+			return nil, rpcDecodeHexError(cmd.OutPoints[i].Hash)
+		}
+		outPoints[i] = wire.OutPoint{
+			Hash:  *hash,
+			Index: cmd.OutPoints[i].Index,
+		}
+	}
+	var keyIDs []btcec.KeyID
+	if cmd.KeyIDs != nil {
+		keyIDs = make([]btcec.KeyID, len(*cmd.KeyIDs))
+		for i, keyID := range *cmd.KeyIDs {
+			keyIDs[i] = btcec.KeyID(keyID)
+		}
+	}
+	filter := newWSClientFilter(cmd.Addresses, outPoints, keyIDs)
+
+	// The rescan ends at the current best block unless an earlier end
+	// height was requested.
+	chain := wsc.server.chain
+	bestHeight := chain.BestSnapshot().Height
+	endHeight := bestHeight
+	if cmd.EndHeight != nil {
+		if *cmd.EndHeight < 0 || uint32(*cmd.EndHeight) > bestHeight {
+			return nil, &btcjson.RPCError{
+				Code: btcjson.ErrRPCOutOfRange,
+				Message: fmt.Sprintf("End height %d is out of "+
+					"range [0, %d]", *cmd.EndHeight,
+					bestHeight),
+			}
+		}
+		endHeight = uint32(*cmd.EndHeight)
+	}
+	if cmd.BeginHeight < 0 || uint32(cmd.BeginHeight) > endHeight {
+		return nil, &btcjson.RPCError{
+			Code: btcjson.ErrRPCOutOfRange,
+			Message: fmt.Sprintf("Begin height %d is out of range "+
+				"[0, %d]", cmd.BeginHeight, endHeight),
+		}
+	}
+
+	rpcsLog.Infof("Beginning rescan of heights %d-%d for %d addresses, "+
+		"%d key ids and %d outpoints", cmd.BeginHeight, endHeight,
+		len(cmd.Addresses), len(keyIDs), len(outPoints))
+
+	// A ticker is created to wait at least 10 seconds before notifying the
+	// websocket client of the current progress completed by the rescan.
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	// Fetch the block hashes in chunks to ensure large rescans consume a
+	// limited amount of memory.  Every block must be a child of the one
+	// scanned before it, otherwise the chain was reorganized while the
+	// rescan was underway and the client must resume it from a block that
+	// is still in the main chain.
+	var lastBlock *provautil.Block
+	minBlock := uint32(cmd.BeginHeight)
+	for minBlock <= endHeight {
+		maxLoopBlock := endHeight + 1
+		if maxLoopBlock-minBlock > wire.MaxInvPerMsg {
+			maxLoopBlock = minBlock + wire.MaxInvPerMsg
+		}
+		hashList, err := chain.HeightRange(minBlock, maxLoopBlock)
+		if err != nil {
+			rpcsLog.Errorf("Error looking up block range: %v", err)
+			return nil, &btcjson.RPCError{
+				Code:    btcjson.ErrRPCDatabase,
+				Message: "Database error: " + err.Error(),
+			}
+		}
+		if len(hashList) == 0 {
+			// The main chain was shortened by a reorganize.
+			break
+		}
+
+		for i := range hashList {
+			blk, err := chain.BlockByHash(&hashList[i])
+			if err != nil {
+				rpcsLog.Errorf("Error looking up block: %v", err)
+				return nil, &btcjson.RPCError{
+					Code:    btcjson.ErrRPCDatabase,
+					Message: "Database error: " + err.Error(),
+				}
+			}
+			if lastBlock != nil {
+				jsonErr := descendantBlock(lastBlock.Hash(), blk)
+				if jsonErr != nil {
+					return nil, jsonErr
+				}
+			}
+			lastBlock = blk
+
+			// A select statement is used to stop rescans if the
+			// client requesting the rescan has disconnected.
+			select {
+			case <-wsc.quit:
+				rpcsLog.Debugf("Stopped rescan at height %v "+
+					"for disconnected client", blk.Height())
+				return nil, nil
+			default:
+			}
+
+			transactions := rescanBlockFilter(filter, blk)
+			if len(transactions) != 0 {
+				n := btcjson.NewRescanChainMatchNtfn(
+					blk.Hash().String(), int32(blk.Height()),
+					transactions)
+				mn, err := btcjson.MarshalCmd(nil, n)
+				if err != nil {
+					rpcsLog.Errorf("Failed to marshal "+
+						"rescanchain match notification: %v",
+						err)
+				} else if wsc.QueueNotification(mn) == ErrClientQuit {
+					rpcsLog.Debugf("Stopped rescan at "+
+						"height %v for disconnected "+
+						"client", blk.Height())
+					return nil, nil
+				}
+			}
+
+			// Periodically notify the client of the progress
+			// completed.  Continue with next block if no progress
+			// notification is needed yet.
+			select {
+			case <-ticker.C: // fallthrough
+			default:
+				continue
+			}
+
+			err = queueRescanChainProgress(wsc, blk, false)
+			if err == ErrClientQuit {
+				rpcsLog.Debugf("Stopped rescan at height %v "+
+					"for disconnected client", blk.Height())
+				return nil, nil
+			}
+		}
+
+		minBlock += uint32(len(hashList))
+	}
+
+	// Notify the websocket client of the finished rescan.  Notifications
+	// are queued asynchronously, so this is the only way for clients to
+	// know that every match notification for the rescan has been sent.
+	if lastBlock != nil {
+		// The rescan is finished, so we don't care whether the client
+		// has disconnected at this point, so discard error.
+		_ = queueRescanChainProgress(wsc, lastBlock, true)
+	}
+
+	rpcsLog.Info("Finished rescan")
+	return nil, nil
+}
+
 func init() {
 	wsHandlers = wsHandlersBeforeInit
 }
@@ -0,0 +1,88 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"testing"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// TestWSClientFilterKeyIDs ensures transactions paying Prova addresses are
+// relevant to a websocket client filter when the addresses include one of the
+// keyIDs registered with it, and irrelevant otherwise.
+func TestWSClientFilterKeyIDs(t *testing.T) {
+	tests := []struct {
+		name       string
+		registered []btcec.KeyID
+		payKeyIDs  []btcec.KeyID
+		want       bool
+	}{
+		{
+			name:       "first keyID registered",
+			registered: []btcec.KeyID{1},
+			payKeyIDs:  []btcec.KeyID{1, 2},
+			want:       true,
+		},
+		{
+			name:       "second keyID registered",
+			registered: []btcec.KeyID{2, 9},
+			payKeyIDs:  []btcec.KeyID{1, 2},
+			want:       true,
+		},
+		{
+			name:       "other keyIDs registered",
+			registered: []btcec.KeyID{3, 9},
+			payKeyIDs:  []btcec.KeyID{1, 2},
+		},
+		{
+			name:      "no keyIDs registered",
+			payKeyIDs: []btcec.KeyID{1, 2},
+		},
+	}
+
+	for _, test := range tests {
+		addr, err := provautil.NewAddressProva(make([]byte, 20),
+			test.payKeyIDs, activeNetParams.Params)
+		if err != nil {
+			t.Fatalf("%s: NewAddressProva: %v", test.name, err)
+		}
+		pkScript, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			t.Fatalf("%s: PayToAddrScript: %v", test.name, err)
+		}
+		msgTx := wire.NewMsgTx(1)
+		msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1},
+			0), nil))
+		msgTx.AddTxOut(wire.NewTxOut(provautil.AtomsPerGram, pkScript))
+		block := provautil.NewBlock(&wire.MsgBlock{
+			Transactions: []*wire.MsgTx{msgTx},
+		})
+
+		filter := newWSClientFilter(nil, nil, test.registered)
+		if got := filter.relevantAddress(addr); got != test.want {
+			t.Errorf("%s: relevantAddress: got %v, want %v",
+				test.name, got, test.want)
+		}
+		matched := rescanBlockFilter(filter, block)
+		if got := len(matched) == 1; got != test.want {
+			t.Errorf("%s: rescanBlockFilter: got %d transactions, "+
+				"want match %v", test.name, len(matched),
+				test.want)
+		}
+
+		// The outputs of matching transactions are watched for their
+		// spends.
+		op := wire.OutPoint{Hash: msgTx.TxHash(), Index: 0}
+		if got := filter.existsUnspentOutPoint(&op); got != test.want {
+			t.Errorf("%s: existsUnspentOutPoint: got %v, want %v",
+				test.name, got, test.want)
+		}
+	}
+}
@@ -5,16 +5,18 @@
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
-package main
+package node
 
 import (
 	"bytes"
 	"crypto/rand"
+	"crypto/tls"
 	"encoding/binary"
 	"errors"
 	"fmt"
 	"math"
 	"net"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -23,19 +25,25 @@ import (
 	"time"
 
 	"github.com/pyx-partners/dmgd/addrmgr"
+	"github.com/pyx-partners/dmgd/auditlog"
 	"github.com/pyx-partners/dmgd/blockchain"
 	"github.com/pyx-partners/dmgd/blockchain/indexers"
+	"github.com/pyx-partners/dmgd/btcjson"
 	"github.com/pyx-partners/dmgd/chaincfg"
 	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
 	"github.com/pyx-partners/dmgd/connmgr"
 	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/eventbridge"
+	"github.com/pyx-partners/dmgd/issuancemonitor"
 	"github.com/pyx-partners/dmgd/mempool"
 	"github.com/pyx-partners/dmgd/mining"
 	"github.com/pyx-partners/dmgd/mining/cpuminer"
 	"github.com/pyx-partners/dmgd/peer"
 	"github.com/pyx-partners/dmgd/provautil"
 	"github.com/pyx-partners/dmgd/provautil/bloom"
+	"github.com/pyx-partners/dmgd/sqlexport"
 	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/webhook"
 	"github.com/pyx-partners/dmgd/wire"
 )
 
@@ -104,6 +112,45 @@ type broadcastInventoryAdd relayMsg
 // needs to be removed from the rebroadcast map
 type broadcastInventoryDel *wire.InvVect
 
+// broadcastInventoryAnnounced is a type used to declare that a peer announced
+// the InvVects it contains, so any of them in the rebroadcast map are known to
+// have reached that peer.
+type broadcastInventoryAnnounced struct {
+	invVects []*wire.InvVect
+	peerAddr string
+}
+
+// broadcastInventoryQuery is a type used to request the current contents of
+// the rebroadcast map.  The details are sent on the reply channel.
+type broadcastInventoryQuery struct {
+	reply chan []*pendingBroadcast
+}
+
+// pendingBroadcast houses a locally submitted inventory item which has not made
+// it into a block yet along with details about its delivery to peers.
+type pendingBroadcast struct {
+	invVect        wire.InvVect
+	data           interface{}
+	firstBroadcast time.Time
+	lastBroadcast  time.Time
+	rebroadcasts   int32
+
+	// announcedBy maps the address of each peer that announced the item
+	// back to us to the time it was first announced.
+	announcedBy map[string]time.Time
+}
+
+// clone returns a deep copy of the pending broadcast so it can be handed out of
+// the rebroadcast handler.
+func (pb *pendingBroadcast) clone() *pendingBroadcast {
+	clone := *pb
+	clone.announcedBy = make(map[string]time.Time, len(pb.announcedBy))
+	for addr, announced := range pb.announcedBy {
+		clone.announcedBy[addr] = announced
+	}
+	return &clone
+}
+
 // relayMsg packages an inventory vector along with the newly discovered
 // inventory so the relay has access to that information.
 type relayMsg struct {
@@ -170,6 +217,10 @@ type server struct {
 	shutdown      int32
 	shutdownSched int32
 
+	// pendingBroadcasts is the number of locally submitted inventory
+	// items waiting to make it into a block.
+	pendingBroadcasts int32
+
 	chainParams          *chaincfg.Params
 	addrManager          *addrmgr.AddrManager
 	connManager          *connmgr.ConnManager
@@ -189,17 +240,77 @@ type server struct {
 	peerHeightsUpdate    chan updatePeerHeightsMsg
 	wg                   sync.WaitGroup
 	quit                 chan struct{}
-	nat                  NAT
+	nat                  *natTraversal
 	db                   database.DB
 	timeSource           blockchain.MedianTimeSource
 	services             wire.ServiceFlag
 
+	// auditLog records admin events for compliance reviews.  It is nil
+	// when the audit log is not enabled.
+	auditLog *auditlog.Log
+
+	// templateExclusions keeps the transactions and addresses the node
+	// never includes in the block templates it generates.
+	templateExclusions *templateExclusions
+
+	// eventBridge publishes the events of the main chain to a message
+	// broker.  It is nil when no event publisher is configured.
+	eventBridge *eventbridge.Bridge
+
+	// sqlExporter mirrors the main chain into a SQL database.  It is nil
+	// when no SQL export database is configured.
+	sqlExporter *sqlexport.Exporter
+
+	// issuanceMonitor raises alerts for issuance above its thresholds or
+	// outside the business hours.  It is nil when neither is configured.
+	issuanceMonitor *issuancemonitor.Monitor
+
+	// webhooks delivers the selected events of the main chain to the
+	// configured webhooks.  It is nil when no webhook is configured.
+	webhooks *webhook.Dispatcher
+
+	// validateKeyStore keeps the validate keys used by the CPU miner and
+	// stores them encrypted on disk when a passphrase is supplied.
+	validateKeyStore *validateKeyStore
+
+	// uploadTarget keeps track of the bytes sent to peers against the
+	// configured upload target.
+	uploadTarget *uploadTarget
+
+	// evictions keeps the most recent inbound peers evicted to make room
+	// for new inbound peers.
+	evictions peerEvictions
+
+	// propagations keeps when the most recently announced blocks were
+	// first seen, received, validated and relayed onward.
+	propagations blockPropagations
+
+	// announcements keeps the unexpired governance announcements relayed
+	// between peers.
+	announcements *announcements
+
+	// keyIDCensus keeps the result of the last scan of the unspent
+	// transaction output set for the usage of each keyID.
+	keyIDCensus *keyIDCensus
+
+	// The following fields hold the options which can be changed at
+	// runtime by reloading the configuration.  The whitelisted IP networks
+	// of peers which are never banned must only be accessed with
+	// whitelistMtx held.
+	reloadMtx    sync.Mutex
+	whitelistMtx sync.RWMutex
+	whitelists   []*net.IPNet
+
 	// The following fields are used for optional indexes.  They will be nil
 	// if the associated index is not enabled.  These fields are set during
 	// initial creation of the server and never changed afterwards, so they
 	// do not need to be protected for concurrent access.
-	txIndex   *indexers.TxIndex
-	addrIndex *indexers.AddrIndex
+	txIndex         *indexers.TxIndex
+	addrIndex       *indexers.AddrIndex
+	commitmentIndex *indexers.CommitmentIndex
+	watchIndex      *indexers.WatchIndex
+	statsIndex      *indexers.StatsIndex
+	indexManager    *indexers.Manager
 }
 
 // serverPeer extends the peer to maintain state shared by the server and
@@ -208,6 +319,17 @@ type serverPeer struct {
 	// The following variables must only be used atomically
 	feeFilter int64
 
+	// lastBlockTime and lastTxTime are the unix times the peer last relayed
+	// a block accepted to the chain and a transaction accepted to the
+	// mempool, which are used to protect useful inbound peers from
+	// eviction.
+	lastBlockTime int64
+	lastTxTime    int64
+
+	// blocksAccepted is the number of blocks relayed by the peer which
+	// were accepted to the chain.
+	blocksAccepted uint64
+
 	*peer.Peer
 
 	connReq         *connmgr.ConnReq
@@ -310,8 +432,9 @@ func (sp *serverPeer) pushAddrMsg(addresses []*wire.NetAddress) {
 // the score is above the ban threshold, the peer will be banned and
 // disconnected.
 func (sp *serverPeer) addBanScore(persistent, transient uint32, reason string) {
-	// No warning is logged and no score is calculated if banning is disabled.
-	if cfg.DisableBanning {
+	// No warning is logged and no score is calculated if banning is disabled
+	// or the peer is whitelisted.
+	if cfg.DisableBanning || sp.server.isWhitelisted(sp.Addr()) {
 		return
 	}
 	warnThreshold := cfg.BanThreshold >> 1
@@ -338,6 +461,27 @@ func (sp *serverPeer) addBanScore(persistent, transient uint32, reason string) {
 	}
 }
 
+// evictionCandidate returns the description of the peer used to select the
+// inbound peer to evict once the maximum number of peers is reached.
+// Whitelisted peers are never evicted.
+func (sp *serverPeer) evictionCandidate() connmgr.EvictionCandidate {
+	relayTime := func(unix int64) time.Time {
+		if unix == 0 {
+			return time.Time{}
+		}
+		return time.Unix(unix, 0)
+	}
+	stats := sp.StatsSnapshot()
+	return connmgr.EvictionCandidate{
+		ConnTime: stats.ConnTime,
+		PingTime: time.Duration(stats.LastPingMicros) *
+			time.Microsecond,
+		LastBlockTime: relayTime(atomic.LoadInt64(&sp.lastBlockTime)),
+		LastTxTime:    relayTime(atomic.LoadInt64(&sp.lastTxTime)),
+		Protected:     sp.server.isWhitelisted(sp.Addr()),
+	}
+}
+
 // OnVersion is invoked when a peer receives a version bitcoin message
 // and is used to negotiate the protocol version details as well as kick start
 // the communications.
@@ -388,6 +532,14 @@ func (sp *serverPeer) OnVersion(_ *peer.Peer, msg *wire.MsgVersion) {
 		}
 	}
 
+	// Send the unexpired governance announcements to peers which
+	// understand them.
+	if sp.ProtocolVersion() >= wire.AnnounceVersion {
+		for _, ann := range sp.server.announcements.active(time.Now()) {
+			sp.QueueMessage(ann.msg, nil)
+		}
+	}
+
 	// Add valid peer to the server.
 	sp.server.AddPeer(sp)
 }
@@ -426,7 +578,7 @@ func (sp *serverPeer) OnMemPool(_ *peer.Peer, msg *wire.MsgMemPool) {
 		// or only the transactions that match the filter when there is
 		// one.
 		if !sp.filter.IsLoaded() || sp.filter.MatchTxAndUpdate(txDesc.Tx) {
-			iv := wire.NewInvVect(wire.InvTypeTx, txDesc.Tx.Hash())
+			iv := sp.peerInvVect(txInvVect(txDesc.Tx))
 			invMsg.AddInvVect(iv)
 			if len(invMsg.InvList)+1 > wire.MaxInvPerMsg {
 				break
@@ -445,18 +597,20 @@ func (sp *serverPeer) OnMemPool(_ *peer.Peer, msg *wire.MsgMemPool) {
 // handler this does not serialize all transactions through a single thread
 // transactions don't rely on the previous one in a linear fashion like blocks.
 func (sp *serverPeer) OnTx(_ *peer.Peer, msg *wire.MsgTx) {
-	if cfg.BlocksOnly {
+	// Convert the raw MsgTx to a provautil.Tx which provides some convenience
+	// methods and things such as hash caching.  Admin transactions are
+	// accepted even when blocksonly is enabled so governance changes still
+	// propagate.
+	tx := provautil.NewTx(msg)
+	iv := txInvVect(tx)
+	if cfg.BlocksOnly && iv.Type != wire.InvTypeAdminTx {
 		peerLog.Tracef("Ignoring tx %v from %v - blocksonly enabled",
-			msg.TxHash(), sp)
+			tx.Hash(), sp)
 		return
 	}
 
 	// Add the transaction to the known inventory for the peer.
-	// Convert the raw MsgTx to a provautil.Tx which provides some convenience
-	// methods and things such as hash caching.
-	tx := provautil.NewTx(msg)
-	iv := wire.NewInvVect(wire.InvTypeTx, tx.Hash())
-	sp.AddKnownInventory(iv)
+	sp.AddKnownInventory(sp.peerInvVect(iv))
 
 	// Queue the transaction up to be handled by the block manager and
 	// intentionally block further receives until the transaction is fully
@@ -473,6 +627,7 @@ func (sp *serverPeer) OnBlock(_ *peer.Peer, msg *wire.MsgBlock, buf []byte) {
 	// Convert the raw MsgBlock to a provautil.Block which provides some
 	// convenience methods and things such as hash caching.
 	block := provautil.NewBlockFromBlockAndBytes(msg, buf)
+	sp.server.propagations.received(block.Hash(), sp.Addr(), time.Now())
 
 	// Add the block to the known inventory for the peer.
 	iv := wire.NewInvVect(wire.InvTypeBlock, block.Hash())
@@ -498,13 +653,18 @@ func (sp *serverPeer) OnBlock(_ *peer.Peer, msg *wire.MsgBlock, buf []byte) {
 // accordingly.  We pass the message down to blockmanager which will call
 // QueueMessage with any appropriate responses.
 func (sp *serverPeer) OnInv(_ *peer.Peer, msg *wire.MsgInv) {
+	sp.server.propagations.announced(msg.InvList, sp.Addr(), time.Now())
+
 	if !cfg.BlocksOnly {
 		if len(msg.InvList) > 0 {
+			sp.server.AnnouncedInventory(msg.InvList, sp)
 			sp.server.blockManager.QueueInv(msg, sp)
 		}
 		return
 	}
 
+	// Admin transactions are still requested, since they are relayed even
+	// when blocksonly is enabled.
 	newInv := wire.NewMsgInvSizeHint(uint(len(msg.InvList)))
 	for _, invVect := range msg.InvList {
 		if invVect.Type == wire.InvTypeTx {
@@ -564,7 +724,7 @@ func (sp *serverPeer) OnGetData(_ *peer.Peer, msg *wire.MsgGetData) {
 		}
 		var err error
 		switch iv.Type {
-		case wire.InvTypeTx:
+		case wire.InvTypeTx, wire.InvTypeAdminTx:
 			err = sp.server.pushTxMsg(sp, &iv.Hash, c, waitChan)
 		case wire.InvTypeBlock:
 			err = sp.server.pushBlockMsg(sp, &iv.Hash, c, waitChan)
@@ -881,6 +1041,49 @@ func (sp *serverPeer) OnFilterLoad(_ *peer.Peer, msg *wire.MsgFilterLoad) {
 	sp.filter.Reload(msg)
 }
 
+// OnAlert is invoked when a peer receives an alert bitcoin message.  Alert
+// messages carry the evidence of validate keys which signed two blocks at the
+// same height, which is verified and recorded, and relayed to the other peers
+// when it is new and the server is configured to relay violations.
+func (sp *serverPeer) OnAlert(_ *peer.Peer, msg *wire.MsgAlert) {
+	violation, err := blockchain.ParseValidatorViolation(
+		msg.SerializedPayload)
+	if err != nil {
+		peerLog.Debugf("Unable to parse the alert from %s: %v", sp, err)
+		return
+	}
+	chain := sp.server.blockManager.chain
+	stored, err := chain.RecordValidatorViolation(violation)
+	if err != nil {
+		peerLog.Debugf("Ignoring the validator violation from %s: %v",
+			sp, err)
+		return
+	}
+	if !stored {
+		return
+	}
+
+	peerLog.Warnf("Validate key %v signed blocks %v and %v at height %d "+
+		"according to %s", violation.ValidatingPubKey(),
+		violation.Headers[0].BlockHash(), violation.Headers[1].BlockHash(),
+		violation.Height(), sp)
+	if cfg.RelayViolations {
+		msg := wire.NewMsgAlert(violation.Evidence(), nil)
+		sp.server.BroadcastMessage(msg, sp)
+	}
+}
+
+// OnAnnounce is invoked when a peer receives an announce bitcoin message.
+// Announcements signed by a quorum of root or provision keys are kept and
+// relayed to the other peers, while invalid and expired ones are ignored.
+func (sp *serverPeer) OnAnnounce(_ *peer.Peer, msg *wire.MsgAnnounce) {
+	_, err := sp.server.ProcessAnnouncement(msg, sp)
+	if err != nil {
+		peerLog.Debugf("Ignoring announcement %v from %s: %v",
+			msg.AnnounceHash(), sp, err)
+	}
+}
+
 // OnGetAddr is invoked when a peer receives a getaddr bitcoin message
 // and is used to provide the peer with known addresses from the address
 // manager.
@@ -977,6 +1180,27 @@ func (sp *serverPeer) OnRead(_ *peer.Peer, bytesRead int, msg wire.Message, err
 // the bytes sent by the server.
 func (sp *serverPeer) OnWrite(_ *peer.Peer, bytesWritten int, msg wire.Message, err error) {
 	sp.server.AddBytesSent(uint64(bytesWritten))
+	if err != nil {
+		return
+	}
+
+	// Track blocks relayed onward to the peer.
+	switch m := msg.(type) {
+	case *wire.MsgInv:
+		sp.server.propagations.relayed(blockInvHashes(m.InvList),
+			time.Now())
+
+	case *wire.MsgHeaders:
+		if len(m.Headers) > maxPropagationInvBlocks {
+			break
+		}
+		hashes := make([]*chainhash.Hash, 0, len(m.Headers))
+		for _, header := range m.Headers {
+			hash := header.BlockHash()
+			hashes = append(hashes, &hash)
+		}
+		sp.server.propagations.relayed(hashes, time.Now())
+	}
 }
 
 // randomUint16Number returns a random uint16 in a specified input range.  Note
@@ -1019,6 +1243,72 @@ func (s *server) RemoveRebroadcastInventory(iv *wire.InvVect) {
 	s.modifyRebroadcastInv <- broadcastInventoryDel(iv)
 }
 
+// AnnouncedInventory notes that the passed peer announced the passed inventory
+// vectors so locally submitted transactions which are pending rebroadcast can
+// be marked as having reached it.  Nothing is done when there are no pending
+// broadcasts.
+func (s *server) AnnouncedInventory(invList []*wire.InvVect, sp *serverPeer) {
+	// Ignore if shutting down or nothing is pending.
+	if atomic.LoadInt32(&s.shutdown) != 0 ||
+		atomic.LoadInt32(&s.pendingBroadcasts) == 0 {
+
+		return
+	}
+
+	var txInvs []*wire.InvVect
+	for _, iv := range invList {
+		if iv.Type == wire.InvTypeTx || iv.Type == wire.InvTypeAdminTx {
+			txInvs = append(txInvs, iv)
+		}
+	}
+	if len(txInvs) == 0 {
+		return
+	}
+
+	select {
+	case s.modifyRebroadcastInv <- broadcastInventoryAnnounced{
+		invVects: txInvs,
+		peerAddr: sp.Addr(),
+	}:
+	case <-s.quit:
+	}
+}
+
+// UnconfirmedBroadcasts returns details about the locally submitted inventory
+// items which are being rebroadcast since they have not made it into a block
+// yet.
+func (s *server) UnconfirmedBroadcasts() []*pendingBroadcast {
+	reply := make(chan []*pendingBroadcast, 1)
+	select {
+	case s.modifyRebroadcastInv <- broadcastInventoryQuery{reply: reply}:
+	case <-s.quit:
+		return nil
+	}
+	return <-reply
+}
+
+// txInvVect returns the inventory vector used to announce the passed
+// transaction.  Admin transactions have their own inventory type, so peers can
+// relay them with priority and even when they do not relay other transactions.
+func txInvVect(tx *provautil.Tx) *wire.InvVect {
+	if threadInt, _ := txscript.GetAdminDetails(tx); threadInt >= 0 {
+		return wire.NewInvVect(wire.InvTypeAdminTx, tx.Hash())
+	}
+	return wire.NewInvVect(wire.InvTypeTx, tx.Hash())
+}
+
+// peerInvVect returns the passed inventory vector as understood by the peer.
+// Peers which predate the admin transaction inventory type are announced admin
+// transactions as regular transactions.
+func (sp *serverPeer) peerInvVect(iv *wire.InvVect) *wire.InvVect {
+	if iv.Type == wire.InvTypeAdminTx &&
+		sp.ProtocolVersion() < wire.AdminTxInvVersion {
+
+		return wire.NewInvVect(wire.InvTypeTx, &iv.Hash)
+	}
+	return iv
+}
+
 // AnnounceNewTransactions generates and relays inventory vectors and notifies
 // both websocket and getblocktemplate long poll clients of the passed
 // transactions.  This function should be called whenever new transactions
@@ -1029,7 +1319,7 @@ func (s *server) AnnounceNewTransactions(newTxs []*mempool.TxDesc) {
 	// accepted.
 	for _, txD := range newTxs {
 		// Generate the inventory vector and relay it.
-		iv := wire.NewInvVect(wire.InvTypeTx, txD.Tx.Hash())
+		iv := txInvVect(txD.Tx)
 		s.RelayInventory(iv, txD)
 
 		if s.rpcServer != nil {
@@ -1071,6 +1361,24 @@ func (s *server) pushTxMsg(sp *serverPeer, hash *chainhash.Hash, doneChan chan<-
 	return nil
 }
 
+// checkHistoricBlock returns an error and disconnects the peer when it requests
+// a block older than historicBlockAge after the upload target is reached,
+// unless the peer is whitelisted.  Recent blocks are always served so the
+// network keeps up with the chain.
+func (s *server) checkHistoricBlock(sp *serverPeer, header *wire.BlockHeader) error {
+	now := time.Now()
+	if now.Sub(header.Timestamp) <= historicBlockAge ||
+		!s.uploadTarget.reached(now) || s.isWhitelisted(sp.Addr()) {
+
+		return nil
+	}
+
+	peerLog.Infof("Upload target reached -- disconnecting peer %v "+
+		"requesting historic block %v", sp, header.BlockHash())
+	sp.Disconnect()
+	return errors.New("upload target reached")
+}
+
 // pushBlockMsg sends a block message for the provided block hash to the
 // connected peer.  An error is returned if the block hash is not known.
 func (s *server) pushBlockMsg(sp *serverPeer, hash *chainhash.Hash, doneChan chan<- struct{}, waitChan <-chan struct{}) error {
@@ -1104,6 +1412,14 @@ func (s *server) pushBlockMsg(sp *serverPeer, hash *chainhash.Hash, doneChan cha
 		return err
 	}
 
+	err = sp.server.checkHistoricBlock(sp, &msgBlock.Header)
+	if err != nil {
+		if doneChan != nil {
+			doneChan <- struct{}{}
+		}
+		return err
+	}
+
 	// Once we have fetched data wait for any previous operation to finish.
 	if waitChan != nil {
 		<-waitChan
@@ -1160,6 +1476,14 @@ func (s *server) pushMerkleBlockMsg(sp *serverPeer, hash *chainhash.Hash, doneCh
 		return err
 	}
 
+	err = sp.server.checkHistoricBlock(sp, &blk.MsgBlock().Header)
+	if err != nil {
+		if doneChan != nil {
+			doneChan <- struct{}{}
+		}
+		return err
+	}
+
 	// Generate a merkle block by filtering the requested block according
 	// to the filter for the peer.
 	merkle, matchedTxIndices := bloom.NewMerkleBlock(blk, sp.filter)
@@ -1243,7 +1567,7 @@ func (s *server) handleAddPeerMsg(state *peerState, sp *serverPeer) bool {
 		return false
 	}
 	if banEnd, ok := state.banned[host]; ok {
-		if time.Now().Before(banEnd) {
+		if time.Now().Before(banEnd) && !s.isWhitelisted(sp.Addr()) {
 			srvrLog.Debugf("Peer %s is banned for another %v - disconnecting",
 				host, banEnd.Sub(time.Now()))
 			sp.Disconnect()
@@ -1256,8 +1580,11 @@ func (s *server) handleAddPeerMsg(state *peerState, sp *serverPeer) bool {
 
 	// TODO: Check for max peers from a single IP.
 
-	// Limit max number of total peers.
-	if state.Count() >= cfg.MaxPeers {
+	// Limit max number of total peers.  New inbound peers replace the least
+	// useful inbound peer instead when one can be evicted.
+	if state.Count() >= cfg.MaxPeers &&
+		!(sp.Inbound() && s.evictInboundPeer(state, sp)) {
+
 		srvrLog.Infof("Max peers reached [%d] - disconnecting peer %s",
 			cfg.MaxPeers, sp)
 		sp.Disconnect()
@@ -1282,6 +1609,55 @@ func (s *server) handleAddPeerMsg(state *peerState, sp *serverPeer) bool {
 	return true
 }
 
+// evictInboundPeer makes room for the passed new inbound peer once the maximum
+// number of peers is reached by disconnecting the least useful inbound peer
+// which is not whitelisted.  It returns whether the new peer may be added.  It
+// is invoked from the peerHandler goroutine.
+func (s *server) evictInboundPeer(state *peerState, newPeer *serverPeer) bool {
+	// Peers which are already disconnected, such as the ones evicted
+	// before, are counted until they are done, but they make room as well.
+	disconnected := 0
+	state.forAllPeers(func(sp *serverPeer) {
+		if !sp.Connected() {
+			disconnected++
+		}
+	})
+	if state.Count()-disconnected < cfg.MaxPeers {
+		return true
+	}
+
+	var peers []*serverPeer
+	var candidates []connmgr.EvictionCandidate
+	for _, sp := range state.inboundPeers {
+		if !sp.Connected() {
+			continue
+		}
+		peers = append(peers, sp)
+		candidates = append(candidates, sp.evictionCandidate())
+	}
+	i, reason := connmgr.SelectEviction(candidates)
+	if i < 0 {
+		return false
+	}
+
+	evicted, candidate := peers[i], &candidates[i]
+	srvrLog.Infof("Max peers reached [%d] - evicting inbound peer %s for "+
+		"new peer %s: %s", cfg.MaxPeers, evicted, newPeer, reason)
+	s.evictions.add(btcjson.PeerEvictionResult{
+		Time:      time.Now().Unix(),
+		ID:        evicted.ID(),
+		Addr:      evicted.Addr(),
+		ConnTime:  candidate.ConnTime.Unix(),
+		PingTime:  float64(candidate.PingTime / time.Microsecond),
+		LastBlock: atomic.LoadInt64(&evicted.lastBlockTime),
+		LastTx:    atomic.LoadInt64(&evicted.lastTxTime),
+		Reason:    reason,
+		NewPeer:   newPeer.Addr(),
+	})
+	evicted.Disconnect()
+	return true
+}
+
 // handleDonePeerMsg deals with peers that have signalled they are done.  It is
 // invoked from the peerHandler goroutine.
 func (s *server) handleDonePeerMsg(state *peerState, sp *serverPeer) {
@@ -1361,7 +1737,26 @@ func (s *server) handleRelayInvMsg(state *peerState, msg relayMsg) {
 			return
 		}
 
-		if msg.invVect.Type == wire.InvTypeTx {
+		// Admin transactions are announced right away and regardless
+		// of the transaction relay settings and fee filter of the peer,
+		// unless the peer predates their inventory type.
+		iv := sp.peerInvVect(msg.invVect)
+		if iv.Type == wire.InvTypeAdminTx {
+			txD, ok := msg.data.(*mempool.TxDesc)
+			if !ok {
+				peerLog.Warnf("Underlying data for admin tx inv "+
+					"relay is not a *mempool.TxDesc: %T",
+					msg.data)
+				return
+			}
+			if sp.filter.IsLoaded() && !sp.filter.MatchTxAndUpdate(txD.Tx) {
+				return
+			}
+			sp.QueueInventoryImmediate(iv)
+			return
+		}
+
+		if iv.Type == wire.InvTypeTx {
 			// Don't relay the transaction to the peer when it has
 			// transaction relaying disabled.
 			if sp.relayTxDisabled() {
@@ -1395,7 +1790,7 @@ func (s *server) handleRelayInvMsg(state *peerState, msg relayMsg) {
 		// Queue the inventory to be relayed with the next batch.
 		// It will be ignored if the peer is already known to
 		// have the inventory.
-		sp.QueueInventory(msg.invVect)
+		sp.QueueInventory(iv)
 	})
 }
 
@@ -1413,6 +1808,12 @@ func (s *server) handleBroadcastMsg(state *peerState, bmsg *broadcastMsg) {
 			}
 		}
 
+		// Peers which predate announcements can not decode them.
+		_, isAnnounce := bmsg.message.(*wire.MsgAnnounce)
+		if isAnnounce && sp.ProtocolVersion() < wire.AnnounceVersion {
+			return
+		}
+
 		sp.QueueMessage(bmsg.message, nil)
 	})
 }
@@ -1606,12 +2007,8 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 			OnAddr:        sp.OnAddr,
 			OnRead:        sp.OnRead,
 			OnWrite:       sp.OnWrite,
-
-			// Note: The reference client currently bans peers that send alerts
-			// not signed with its key.  We could verify against their key, but
-			// since the reference client is currently unwilling to support
-			// other implementations' alert messages, we will not relay theirs.
-			OnAlert: nil,
+			OnAlert:       sp.OnAlert,
+			OnAnnounce:    sp.OnAnnounce,
 		},
 		NewestBlock:      sp.newestBlock,
 		HostToNetAddress: sp.server.addrManager.HostToNetAddress,
@@ -1621,7 +2018,7 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 		ChainParams:      sp.server.chainParams,
 		Services:         sp.server.services,
 		DisableRelayTx:   cfg.BlocksOnly,
-		ProtocolVersion:  wire.FeeFilterVersion,
+		ProtocolVersion:  wire.AnnounceVersion,
 	}
 }
 
@@ -1757,6 +2154,38 @@ out:
 	s.blockManager.Stop()
 	s.addrManager.Stop()
 
+	// Stop delivering issuance alerts now that no more blocks are
+	// processed.
+	if s.issuanceMonitor != nil {
+		s.issuanceMonitor.Stop()
+	}
+
+	// Stop delivering events to the webhooks.
+	if s.webhooks != nil {
+		s.webhooks.Stop()
+	}
+
+	// Close the audit log now that no more blocks are processed.
+	if s.auditLog != nil {
+		if err := s.auditLog.Close(); err != nil {
+			srvrLog.Errorf("Unable to close audit log: %v", err)
+		}
+	}
+
+	// Stop publishing events now that no more blocks are processed.
+	if s.eventBridge != nil {
+		if err := s.eventBridge.Stop(); err != nil {
+			srvrLog.Errorf("Unable to stop event bridge: %v", err)
+		}
+	}
+
+	// Stop mirroring the main chain into the SQL database.
+	if s.sqlExporter != nil {
+		if err := s.sqlExporter.Stop(); err != nil {
+			srvrLog.Errorf("Unable to stop SQL exporter: %v", err)
+		}
+	}
+
 	// Drain channels before exiting so nothing is left waiting around
 	// to send.
 cleanup:
@@ -1786,6 +2215,88 @@ func (s *server) BanPeer(sp *serverPeer) {
 	s.banPeers <- sp
 }
 
+// isWhitelisted returns whether the IP of the passed peer address is in one of
+// the whitelisted IP networks, in which case the peer is never banned.
+//
+// This function is safe for concurrent access.
+func (s *server) isWhitelisted(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		srvrLog.Debugf("Unable to SplitHostPort on '%s': %v", addr, err)
+		return false
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	s.whitelistMtx.RLock()
+	defer s.whitelistMtx.RUnlock()
+	for _, ipnet := range s.whitelists {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReloadConfig reads the options which can be changed at runtime from the
+// config file and command line again and applies them.  These are the relay
+// fees of the memory pool, the peer whitelist, the RPC credentials and the
+// debug levels.  The RPC TLS certificates and keys and the client certificate
+// authorities are read again from the files configured at startup, so they can
+// be rotated by replacing the files.  The transactions in the memory pool and
+// the connected peers are kept, and nothing is changed when any of the options
+// or files is invalid.
+//
+// This function is safe for concurrent access.
+func (s *server) ReloadConfig() error {
+	s.reloadMtx.Lock()
+	defer s.reloadMtx.Unlock()
+
+	newCfg, err := reloadConfig(cfg)
+	if err != nil {
+		return err
+	}
+	auths, err := rpcAuths(newCfg)
+	if err != nil {
+		return err
+	}
+	var rpcTLSConfig *tls.Config
+	if s.rpcServer != nil && s.rpcServer.tls != nil {
+		rpcTLSConfig, err = loadRPCTLSConfig(cfg)
+		if err != nil {
+			return fmt.Errorf("unable to reload RPC TLS "+
+				"certificates: %v", err)
+		}
+	}
+
+	// The debug levels replace any set by the debuglevel command.
+	setLogLevels(defaultLogLevel)
+	if err := parseAndSetDebugLevels(newCfg.DebugLevel); err != nil {
+		return err
+	}
+
+	s.txMemPool.SetRelayFees(newCfg.minRelayTxFee, newCfg.dustRelayFee)
+
+	s.whitelistMtx.Lock()
+	s.whitelists = newCfg.whitelists
+	s.whitelistMtx.Unlock()
+
+	if s.rpcServer != nil {
+		s.rpcServer.setAuth(auths)
+	}
+	if rpcTLSConfig != nil {
+		s.rpcServer.tls.set(rpcTLSConfig)
+	}
+
+	srvrLog.Infof("Reloaded configuration (minrelaytxfee %v, "+
+		"dustrelayfee %v, %d whitelisted networks)",
+		newCfg.minRelayTxFee, newCfg.dustRelayFee,
+		len(newCfg.whitelists))
+	return nil
+}
+
 // RelayInventory relays the passed inventory vector to all connected peers
 // that are not already known to have it.
 func (s *server) RelayInventory(invVect *wire.InvVect, data interface{}) {
@@ -1904,6 +2415,7 @@ func (s *server) ConnectNode(addr string, permanent bool) error {
 // for the server.  It is safe for concurrent access.
 func (s *server) AddBytesSent(bytesSent uint64) {
 	atomic.AddUint64(&s.bytesSent, bytesSent)
+	s.uploadTarget.add(bytesSent, time.Now())
 }
 
 // AddBytesReceived adds the passed number of bytes to the total bytes received
@@ -1919,6 +2431,55 @@ func (s *server) NetTotals() (uint64, uint64) {
 		atomic.LoadUint64(&s.bytesSent)
 }
 
+// PeerEvictions returns the most recent inbound peer evictions, newest first.
+func (s *server) PeerEvictions() []btcjson.PeerEvictionResult {
+	return s.evictions.results()
+}
+
+// BlockPropagation returns the aggregated propagation of the most recently
+// announced blocks along with the propagation of up to the passed number of
+// them, newest first.
+func (s *server) BlockPropagation(count int) *btcjson.GetBlockPropagationResult {
+	return s.propagations.result(count)
+}
+
+// ProcessAnnouncement verifies the passed governance announcement against the
+// admin keys of the main chain and keeps it.  New announcements are logged,
+// passed to websocket clients and relayed to all peers but the passed one,
+// which sent it and is nil for announcements submitted over RPC.  It returns
+// whether the announcement is new.
+func (s *server) ProcessAnnouncement(msg *wire.MsgAnnounce, sp *serverPeer) (bool, error) {
+	ann, err := s.announcements.add(msg,
+		s.blockManager.chain.AdminKeySets(), time.Now())
+	if err != nil || ann == nil {
+		return false, err
+	}
+
+	str := fmt.Sprintf("Governance %v announcement %v signed by %d %v "+
+		"keys, expiring %v: %q", msg.Category, ann.hash,
+		len(ann.signers), ann.keySet, msg.Expiration, msg.Message)
+	if msg.Category == wire.AnnounceEmergency {
+		srvrLog.Warn(str)
+	} else {
+		srvrLog.Info(str)
+	}
+
+	if s.rpcServer != nil {
+		s.rpcServer.ntfnMgr.NotifyAnnouncement(ann)
+	}
+	var excludePeers []*serverPeer
+	if sp != nil {
+		excludePeers = append(excludePeers, sp)
+	}
+	s.BroadcastMessage(msg, excludePeers...)
+	return true, nil
+}
+
+// Announcements returns the unexpired governance announcements, newest first.
+func (s *server) Announcements() []*announcement {
+	return s.announcements.active(time.Now())
+}
+
 // UpdatePeerHeights updates the heights of all peers who have have announced
 // the latest connected main chain block, or a recognized orphan. These height
 // updates allow us to dynamically refresh peer heights, ensuring sync peer
@@ -1937,7 +2498,7 @@ func (s *server) UpdatePeerHeights(latestBlkHash *chainhash.Hash, latestHeight u
 func (s *server) rebroadcastHandler() {
 	// Wait 5 min before first tx rebroadcast.
 	timer := time.NewTimer(5 * time.Minute)
-	pendingInvs := make(map[wire.InvVect]interface{})
+	pendingInvs := make(map[wire.InvVect]*pendingBroadcast)
 
 out:
 	for {
@@ -1945,8 +2506,20 @@ out:
 		case riv := <-s.modifyRebroadcastInv:
 			switch msg := riv.(type) {
 			// Incoming InvVects are added to our map of RPC txs.
+			// They have just been relayed by the caller.
 			case broadcastInventoryAdd:
-				pendingInvs[*msg.invVect] = msg.data
+				if pb, ok := pendingInvs[*msg.invVect]; ok {
+					pb.data = msg.data
+					break
+				}
+				now := time.Now()
+				pendingInvs[*msg.invVect] = &pendingBroadcast{
+					invVect:        *msg.invVect,
+					data:           msg.data,
+					firstBroadcast: now,
+					lastBroadcast:  now,
+					announcedBy:    make(map[string]time.Time),
+				}
 
 			// When an InvVect has been added to a block, we can
 			// now remove it, if it was present.
@@ -1954,14 +2527,50 @@ out:
 				if _, ok := pendingInvs[*msg]; ok {
 					delete(pendingInvs, *msg)
 				}
+
+			// Record the peer as having received any of the
+			// announced InvVects that are still pending.
+			case broadcastInventoryAnnounced:
+				for _, iv := range msg.invVects {
+					pb, ok := pendingInvs[*iv]
+					if !ok && iv.Type == wire.InvTypeTx {
+						// Peers which predate the admin
+						// transaction inventory type
+						// announce them as regular
+						// transactions.
+						pb, ok = pendingInvs[wire.InvVect{
+							Type: wire.InvTypeAdminTx,
+							Hash: iv.Hash,
+						}]
+					}
+					if !ok {
+						continue
+					}
+					if _, ok := pb.announcedBy[msg.peerAddr]; !ok {
+						pb.announcedBy[msg.peerAddr] = time.Now()
+					}
+				}
+
+			case broadcastInventoryQuery:
+				pending := make([]*pendingBroadcast, 0,
+					len(pendingInvs))
+				for _, pb := range pendingInvs {
+					pending = append(pending, pb.clone())
+				}
+				msg.reply <- pending
 			}
+			atomic.StoreInt32(&s.pendingBroadcasts,
+				int32(len(pendingInvs)))
 
 		case <-timer.C:
 			// Any inventory we have has not made it into a block
 			// yet. We periodically resubmit them until they have.
-			for iv, data := range pendingInvs {
+			now := time.Now()
+			for iv, pb := range pendingInvs {
 				ivCopy := iv
-				s.RelayInventory(&ivCopy, data)
+				s.RelayInventory(&ivCopy, pb.data)
+				pb.lastBroadcast = now
+				pb.rebroadcasts++
 			}
 
 			// Process at a random time up to 30mins (in seconds)
@@ -2003,9 +2612,25 @@ func (s *server) Start() {
 	s.wg.Add(1)
 	go s.peerHandler()
 
-	if s.nat != nil {
+	if s.eventBridge != nil {
+		s.eventBridge.Start()
+	}
+
+	if s.sqlExporter != nil {
+		s.sqlExporter.Start()
+	}
+
+	if s.issuanceMonitor != nil {
+		s.issuanceMonitor.Start()
+	}
+
+	if s.webhooks != nil {
+		s.webhooks.Start()
+	}
+
+	if s.nat != nil && s.nat.gateway != nil {
 		s.wg.Add(1)
-		go s.upnpUpdateThread()
+		go s.natUpdateThread()
 	}
 
 	if !cfg.DisableRPC {
@@ -2015,6 +2640,11 @@ func (s *server) Start() {
 		// the RPC server are rebroadcast until being included in a block.
 		go s.rebroadcastHandler()
 
+		// Start the keyIDCensusHandler, which runs the key ID census
+		// scans reported by the getkeyidcensus RPC.
+		s.wg.Add(1)
+		go s.keyIDCensusHandler(cfg.KeyIDCensusInterval)
+
 		s.rpcServer.Start()
 	}
 
@@ -2025,7 +2655,10 @@ func (s *server) Start() {
 }
 
 // Stop gracefully shuts down the server by stopping and disconnecting all
-// peers and the main listener.
+// peers and the main listener.  The miner and RPC server are stopped first so
+// no new blocks are submitted, then the peer handler disconnects the peers and
+// stops the block manager, which finishes connecting the block it is
+// processing before the database is closed by the caller.
 func (s *server) Stop() error {
 	// Make sure this only happens once.
 	if atomic.AddInt32(&s.shutdown, 1) != 1 {
@@ -2143,153 +2776,77 @@ func parseListeners(addrs []string) ([]string, []string, bool, error) {
 	return ipv4ListenAddrs, ipv6ListenAddrs, haveWildcard, nil
 }
 
-func (s *server) upnpUpdateThread() {
-	// Go off immediately to prevent code duplication, thereafter we renew
-	// lease every 15 minutes.
-	timer := time.NewTimer(0 * time.Second)
-	lport, _ := strconv.ParseInt(activeNetParams.DefaultPort, 10, 16)
-	first := true
-out:
-	for {
-		select {
-		case <-timer.C:
-			// TODO: pick external port  more cleverly
-			// TODO: know which ports we are listening to on an external net.
-			// TODO: if specific listen port doesn't work then ask for wildcard
-			// listen port?
-			// XXX this assumes timeout is in seconds.
-			listenPort, err := s.nat.AddPortMapping("tcp", int(lport), int(lport),
-				"Dmgd listen port", 20*60)
+// initListeners creates the listeners of the peer server on the passed listen
+// addresses and adds the addresses to advertise to peers to the passed address
+// manager.  Wildcard listeners advertise the addresses of all interfaces with
+// their port, while the addresses of other listeners are advertised as bound,
+// unless the listener specifies another address to advertise or none.  The
+// external IPs specified with --externalip replace the discovered addresses.
+//
+// It returns the listeners, along with the NAT traversal mapping the port of
+// the first advertised IPv4 listener when UPnP or NAT-PMP is enabled.
+func initListeners(amgr *addrmgr.AddrManager, listenAddrs []listenAddr, services wire.ServiceFlag) ([]net.Listener, *natTraversal, error) {
+	// Use the port of the first listener for external IPs specified
+	// without a port and for NAT traversal, unless another listener is
+	// better suited for NAT traversal below.
+	defaultPort, _ := strconv.ParseUint(activeNetParams.DefaultPort, 10, 16)
+	if len(listenAddrs) != 0 {
+		_, portStr, _ := net.SplitHostPort(listenAddrs[0].addr)
+		if port, err := strconv.ParseUint(portStr, 10, 16); err == nil {
+			defaultPort = port
+		}
+	}
+	natPort := uint16(defaultPort)
+
+	discover := true
+	if len(cfg.ExternalIPs) != 0 {
+		discover = false
+
+		for _, sip := range cfg.ExternalIPs {
+			eport := uint16(defaultPort)
+			host, portstr, err := net.SplitHostPort(sip)
 			if err != nil {
-				srvrLog.Warnf("can't add UPnP port mapping: %v", err)
-			}
-			if first && err == nil {
-				// TODO: look this up periodically to see if upnp domain changed
-				// and so did ip.
-				externalip, err := s.nat.GetExternalAddress()
-				if err != nil {
-					srvrLog.Warnf("UPnP can't get external address: %v", err)
-					continue out
-				}
-				na := wire.NewNetAddressIPPort(externalip, uint16(listenPort),
-					s.services)
-				err = s.addrManager.AddLocalAddress(na, addrmgr.UpnpPrio)
-				if err != nil {
-					// XXX DeletePortMapping?
-				}
-				srvrLog.Warnf("Successfully bound via UPnP to %s", addrmgr.NetAddressKey(na))
-				first = false
-			}
-			timer.Reset(time.Minute * 15)
-		case <-s.quit:
-			break out
-		}
-	}
-
-	timer.Stop()
-
-	if err := s.nat.DeletePortMapping("tcp", int(lport), int(lport)); err != nil {
-		srvrLog.Warnf("unable to remove UPnP port mapping: %v", err)
-	} else {
-		srvrLog.Debugf("successfully disestablished UPnP port mapping")
-	}
-
-	s.wg.Done()
-}
-
-// newServer returns a new dmgd server configured to listen on addr for the
-// bitcoin network type specified by chainParams.  Use start to begin accepting
-// connections from peers.
-func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Params) (*server, error) {
-	services := defaultServices
-	if cfg.NoPeerBloomFilters {
-		services &^= wire.SFNodeBloom
-	}
-
-	amgr := addrmgr.New(cfg.DataDir, btcdLookup)
-
-	var listeners []net.Listener
-	var nat NAT
-	if !cfg.DisableListen {
-		ipv4Addrs, ipv6Addrs, wildcard, err :=
-			parseListeners(listenAddrs)
-		if err != nil {
-			return nil, err
-		}
-		listeners = make([]net.Listener, 0, len(ipv4Addrs)+len(ipv6Addrs))
-		discover := true
-		if len(cfg.ExternalIPs) != 0 {
-			discover = false
-			// if this fails we have real issues.
-			port, _ := strconv.ParseUint(
-				activeNetParams.DefaultPort, 10, 16)
-
-			for _, sip := range cfg.ExternalIPs {
-				eport := uint16(port)
-				host, portstr, err := net.SplitHostPort(sip)
-				if err != nil {
-					// no port, use default.
-					host = sip
-				} else {
-					port, err := strconv.ParseUint(
-						portstr, 10, 16)
-					if err != nil {
-						srvrLog.Warnf("Can not parse "+
-							"port from %s for "+
-							"externalip: %v", sip,
-							err)
-						continue
-					}
-					eport = uint16(port)
-				}
-				na, err := amgr.HostToNetAddress(host, eport,
-					services)
+				// no port, use default.
+				host = sip
+			} else {
+				port, err := strconv.ParseUint(portstr, 10, 16)
 				if err != nil {
-					srvrLog.Warnf("Not adding %s as "+
-						"externalip: %v", sip, err)
+					srvrLog.Warnf("Can not parse port from %s "+
+						"for externalip: %v", sip, err)
 					continue
 				}
-
-				err = amgr.AddLocalAddress(na, addrmgr.ManualPrio)
-				if err != nil {
-					amgrLog.Warnf("Skipping specified external IP: %v", err)
-				}
+				eport = uint16(port)
 			}
-		} else if discover && cfg.Upnp {
-			nat, err = Discover()
+			na, err := amgr.HostToNetAddress(host, eport, services)
 			if err != nil {
-				srvrLog.Warnf("Can't discover upnp: %v", err)
+				srvrLog.Warnf("Not adding %s as externalip: %v",
+					sip, err)
+				continue
 			}
-			// nil nat here is fine, just means no upnp on network.
-		}
 
-		// TODO: nonstandard port...
-		if wildcard {
-			port, err :=
-				strconv.ParseUint(activeNetParams.DefaultPort,
-					10, 16)
+			err = amgr.AddLocalAddress(na, addrmgr.ManualPrio)
 			if err != nil {
-				// I can't think of a cleaner way to do this...
-				goto nowc
-			}
-			addrs, err := net.InterfaceAddrs()
-			for _, a := range addrs {
-				ip, _, err := net.ParseCIDR(a.String())
-				if err != nil {
-					continue
-				}
-				na := wire.NewNetAddressIPPort(ip,
-					uint16(port), services)
-				if discover {
-					err = amgr.AddLocalAddress(na, addrmgr.InterfacePrio)
-					if err != nil {
-						amgrLog.Debugf("Skipping local address: %v", err)
-					}
-				}
+				amgrLog.Warnf("Skipping specified external IP: %v", err)
 			}
 		}
-	nowc:
+	}
 
+	var natFound bool
+	listeners := make([]net.Listener, 0, len(listenAddrs)*2)
+	for _, la := range listenAddrs {
+		ipv4Addrs, ipv6Addrs, wildcard, err :=
+			parseListeners([]string{la.addr})
+		if err != nil {
+			return nil, nil, err
+		}
+		_, portStr, _ := net.SplitHostPort(la.addr)
+		port, err := strconv.ParseUint(portStr, 10, 16)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid port in listen "+
+				"address %s", la.addr)
+		}
+
+		var bound []string
 		for _, addr := range ipv4Addrs {
 			listener, err := net.Listen("tcp4", addr)
 			if err != nil {
@@ -2298,17 +2855,8 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 				continue
 			}
 			listeners = append(listeners, listener)
-
-			if discover {
-				if na, err := amgr.DeserializeNetAddress(addr); err == nil {
-					err = amgr.AddLocalAddress(na, addrmgr.BoundPrio)
-					if err != nil {
-						amgrLog.Warnf("Skipping bound address: %v", err)
-					}
-				}
-			}
+			bound = append(bound, addr)
 		}
-
 		for _, addr := range ipv6Addrs {
 			listener, err := net.Listen("tcp6", addr)
 			if err != nil {
@@ -2317,18 +2865,112 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 				continue
 			}
 			listeners = append(listeners, listener)
-			if discover {
-				if na, err := amgr.DeserializeNetAddress(addr); err == nil {
-					err = amgr.AddLocalAddress(na, addrmgr.BoundPrio)
-					if err != nil {
-						amgrLog.Debugf("Skipping bound address: %v", err)
-					}
+			bound = append(bound, addr)
+		}
+		if len(bound) == 0 {
+			continue
+		}
+
+		switch {
+		// Advertise the address specified for the listener instead of
+		// its own.
+		case la.advertise != "":
+			host, portStr, _ := net.SplitHostPort(la.advertise)
+			eport, err := strconv.ParseUint(portStr, 10, 16)
+			if err != nil {
+				srvrLog.Warnf("Can not parse port from %s for "+
+					"listener %s", la.advertise, la.addr)
+				continue
+			}
+			na, err := amgr.HostToNetAddress(host, uint16(eport),
+				services)
+			if err != nil {
+				srvrLog.Warnf("Not advertising %s for listener "+
+					"%s: %v", la.advertise, la.addr, err)
+				continue
+			}
+			err = amgr.AddLocalAddress(na, addrmgr.ManualPrio)
+			if err != nil {
+				amgrLog.Warnf("Skipping advertised address: %v",
+					err)
+			}
+
+		// Advertise nothing for listeners which must not be
+		// advertised or when the external IPs replace the discovered
+		// addresses.
+		case la.noAdvertise || !discover:
+
+		// Advertise the addresses of all interfaces with the port of
+		// wildcard listeners.
+		case wildcard:
+			addrs, _ := net.InterfaceAddrs()
+			for _, a := range addrs {
+				ip, _, err := net.ParseCIDR(a.String())
+				if err != nil {
+					continue
+				}
+				na := wire.NewNetAddressIPPort(ip, uint16(port),
+					services)
+				err = amgr.AddLocalAddress(na, addrmgr.InterfacePrio)
+				if err != nil {
+					amgrLog.Debugf("Skipping local address: %v", err)
+				}
+			}
+
+		default:
+			for _, addr := range bound {
+				na, err := amgr.DeserializeNetAddress(addr)
+				if err != nil {
+					continue
+				}
+				err = amgr.AddLocalAddress(na, addrmgr.BoundPrio)
+				if err != nil {
+					amgrLog.Debugf("Skipping bound address: %v", err)
 				}
 			}
 		}
 
-		if len(listeners) == 0 {
-			return nil, errors.New("no valid listen address")
+		// Map the port of the first listener which is reachable on
+		// IPv4 and advertised with its own address through the NAT.
+		if !natFound && !la.noAdvertise && la.advertise == "" &&
+			len(ipv4Addrs) != 0 {
+
+			natPort = uint16(port)
+			natFound = true
+		}
+	}
+	if len(listeners) == 0 {
+		return nil, nil, errors.New("no valid listen address")
+	}
+
+	var nat *natTraversal
+	if discover && (cfg.Upnp || cfg.NATPMP) {
+		nat = discoverNAT(natPort)
+	}
+
+	return listeners, nat, nil
+}
+
+// newServer returns a new dmgd server configured to listen on addr for the
+// bitcoin network type specified by chainParams.  Use start to begin accepting
+// connections from peers.  Closing the interrupt channel interrupts catching up
+// the indexes, in which case an error is returned.
+func newServer(listenAddrs []listenAddr, db database.DB, chainParams *chaincfg.Params, interrupt <-chan struct{}) (*server, error) {
+	services := defaultServices
+	if cfg.NoPeerBloomFilters {
+		services &^= wire.SFNodeBloom
+	}
+
+	amgr := addrmgr.New(cfg.DataDir, btcdLookup)
+
+	var listeners []net.Listener
+	var nat *natTraversal
+	if !cfg.DisableListen {
+		var err error
+		listeners, nat, err = initListeners(amgr, listenAddrs,
+			services)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -2350,6 +2992,21 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		services:             services,
 		sigCache:             txscript.NewSigCache(cfg.SigCacheMaxSize),
 		hashCache:            txscript.NewHashCache(cfg.SigCacheMaxSize),
+		whitelists:           cfg.whitelists,
+		uploadTarget: newUploadTarget(cfg.MaxUploadTarget*1024*1024,
+			time.Now()),
+		announcements: newAnnouncements(),
+		keyIDCensus:   newKeyIDCensus(),
+	}
+
+	// Open the audit log if requested.
+	if cfg.AuditLogFile != "" {
+		auditLog, err := auditlog.Open(cfg.AuditLogFile,
+			chainParams)
+		if err != nil {
+			return nil, err
+		}
+		s.auditLog = auditLog
 	}
 
 	// Create the transaction and address indexes if needed.
@@ -2359,12 +3016,13 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 	// addrindex is run first, it may not have the transactions from the
 	// current block indexed.
 	var indexes []indexers.Indexer
-	if cfg.TxIndex || cfg.AddrIndex {
-		// Enable transaction index if address index is enabled since it
-		// requires it.
+	if cfg.TxIndex || cfg.AddrIndex || cfg.CommitmentIndex || cfg.StatsIndex {
+		// Enable transaction index if the address, commitment or chain
+		// statistics index is enabled since they require it.
 		if !cfg.TxIndex {
 			indxLog.Infof("Transaction index enabled because it " +
-				"is required by the address index")
+				"is required by the address, commitment or chain " +
+				"statistics index")
 			cfg.TxIndex = true
 		} else {
 			indxLog.Info("Transaction index is enabled")
@@ -2378,18 +3036,108 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		s.addrIndex = indexers.NewAddrIndex(db, chainParams)
 		indexes = append(indexes, s.addrIndex)
 	}
+	if cfg.CommitmentIndex {
+		indxLog.Info("Commitment index is enabled")
+		s.commitmentIndex = indexers.NewCommitmentIndex(db)
+		indexes = append(indexes, s.commitmentIndex)
+	}
+	if cfg.WatchIndex {
+		indxLog.Info("Watch-only index is enabled")
+		s.watchIndex = indexers.NewWatchIndex(db, chainParams)
+		indexes = append(indexes, s.watchIndex)
+	}
+	if cfg.StatsIndex {
+		indxLog.Info("Chain statistics index is enabled")
+		s.statsIndex = indexers.NewStatsIndex(db, chainParams)
+		indexes = append(indexes, s.statsIndex)
+	}
 
 	// Create an index manager if any of the optional indexes are enabled.
 	var indexManager blockchain.IndexManager
 	if len(indexes) > 0 {
-		indexManager = indexers.NewManager(db, indexes)
+		s.indexManager = indexers.NewManager(db, indexes)
+		indexManager = s.indexManager
 	}
-	bm, err := newBlockManager(&s, indexManager)
+	bm, err := newBlockManager(&s, indexManager, interrupt)
 	if err != nil {
 		return nil, err
 	}
 	s.blockManager = bm
 
+	// Verify the database at the requested level before anything else
+	// uses it.
+	if cfg.CheckDB >= checkDBBlocks {
+		if err := bm.chain.VerifyBlocks(interrupt); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.CheckDB >= checkDBIndexes && len(indexes) > 0 {
+		// The indexes are only caught up with the chain once a reindex
+		// is complete.
+		if _, reindexing := bm.chain.ReindexTarget(); reindexing {
+			srvrLog.Infof("Not verifying the indexes during a " +
+				"reindex")
+		} else {
+			err := s.indexManager.Verify(bm.chain, interrupt)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if cfg.CheckDB >= checkDBKeyState {
+		if err := bm.chain.VerifyKeyState(interrupt); err != nil {
+			return nil, err
+		}
+	}
+
+	// Publish the events of the main chain if requested.
+	if cfg.eventPublisher != nil {
+		s.eventBridge, err = eventbridge.New(&eventbridge.Config{
+			Chain:     bm.chain,
+			Publisher: cfg.eventPublisher,
+			CursorFile: filepath.Join(cfg.DataDir,
+				eventCursorFilename),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Mirror the main chain into the SQL export database if requested.
+	if cfg.sqlExportDB != nil {
+		s.sqlExporter, err = sqlexport.New(&sqlexport.Config{
+			Chain:       bm.chain,
+			ChainParams: chainParams,
+			DB:          cfg.sqlExportDB,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Monitor the issuance of the main chain if requested.
+	if len(cfg.issuanceThresholds) != 0 || cfg.issuanceHours != nil {
+		s.issuanceMonitor, err = newIssuanceMonitor(&s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Deliver the events of the main chain to webhooks if requested.
+	if len(cfg.webhookTargets) != 0 {
+		s.webhooks = webhook.New(&webhook.Config{
+			Targets: cfg.webhookTargets,
+			Secret:  []byte(cfg.WebhookSecret),
+		})
+	}
+
+	// Revoking ASP keys whose keyIDs are still in use is rejected when
+	// opted into, unless explicitly allowed.
+	maxRevokedKeyIDOutputs := cfg.MaxRevokedKeyIDOuts
+	if cfg.AllowUsedKeyIDRevoke {
+		maxRevokedKeyIDOutputs = -1
+	}
+
 	txC := mempool.Config{
 		Policy: mempool.Policy{
 			DisableRelayPriority: !cfg.RelayPriority,
@@ -2399,21 +3147,31 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 			MaxOrphanTxSize:      defaultMaxOrphanTxSize,
 			MaxSigOpsPerTx:       blockchain.MaxSigOpsPerBlock / 5,
 			MinRelayTxFee:        cfg.minRelayTxFee,
+			DustRelayFee:         cfg.dustRelayFee,
 			MaxTxVersion:         2,
+
+			MaxStandardTxSize:        cfg.MaxStdTxSize,
+			MaxStandardSigScriptSize: cfg.MaxStdSigScriptSize,
+			MinAdminTxConfirmations:  cfg.MinAdminTxConfs,
+			MaxRevokedKeyIDOutputs:   maxRevokedKeyIDOutputs,
 		},
-		ChainParams:     chainParams,
-		FetchUtxoView:   s.blockManager.chain.FetchUtxoView,
-		ThreadTips:      bm.chain.ThreadTips,
-		LastKeyID:       bm.chain.LastKeyID,
-		TotalSupply:     bm.chain.TotalSupply,
-		GetKeyIDs:       bm.chain.KeyIDs,
-		GetAdminKeySets: bm.chain.AdminKeySets,
-		BestHeight:      func() uint32 { return bm.chain.BestSnapshot().Height },
-		MedianTimePast:  func() time.Time { return bm.chain.BestSnapshot().MedianTime },
-		SigCache:        s.sigCache,
-		HashCache:       s.hashCache,
-		TimeSource:      s.timeSource,
-		AddrIndex:       s.addrIndex,
+		ChainParams:         chainParams,
+		FetchUtxoView:       s.blockManager.chain.FetchUtxoView,
+		FetchKeyIDOutPoints: bm.chain.FetchKeyIDOutPoints,
+		ThreadTips:          bm.chain.ThreadTips,
+		LastKeyID:           bm.chain.LastKeyID,
+		TotalSupply:         bm.chain.TotalSupply,
+		GetKeyIDs:           bm.chain.KeyIDs,
+		GetAdminKeySets:     bm.chain.AdminKeySets,
+		GetIssueDests:       bm.chain.IssueDests,
+		GetFrozen:           bm.chain.Frozen,
+		GetDelegates:        bm.chain.Delegates,
+		BestHeight:          func() uint32 { return bm.chain.BestSnapshot().Height },
+		MedianTimePast:      func() time.Time { return bm.chain.BestSnapshot().MedianTime },
+		SigCache:            s.sigCache,
+		HashCache:           s.hashCache,
+		TimeSource:          s.timeSource,
+		AddrIndex:           s.addrIndex,
 		CalcSequenceLock: func(tx *provautil.Tx, view *blockchain.UtxoViewpoint) (*blockchain.SequenceLock, error) {
 			return bm.chain.CalcSequenceLock(tx, view, true)
 		},
@@ -2425,11 +3183,17 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 	//
 	// NOTE: The CPU miner relies on the mempool, so the mempool has to be
 	// created before calling the function to create the CPU miner.
+	s.templateExclusions, err = loadTemplateExclusions(filepath.Join(
+		cfg.DataDir, templateExclusionsFilename), s.chainParams)
+	if err != nil {
+		return nil, err
+	}
 	policy := mining.Policy{
 		BlockMinSize:      cfg.BlockMinSize,
 		BlockMaxSize:      cfg.BlockMaxSize,
 		BlockPrioritySize: cfg.BlockPrioritySize,
 		TxMinFreeFee:      cfg.minRelayTxFee,
+		Exclusions:        s.templateExclusions.exclusions,
 	}
 
 	blockTemplateGenerator := mining.NewBlkTmplGenerator(&policy, s.chainParams,
@@ -2444,22 +3208,28 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		IsValidateKeyRateLimited: bm.chain.IsValidateKeyRateLimited,
 		AdminKeySets:             bm.chain.AdminKeySets,
 	})
-
-	// Only setup a function to return new addresses to connect to when
-	// not running in connect-only mode.  The simulation network is always
-	// in connect-only mode since it is only intended to connect to
-	// specified peers and actively avoid advertising and connecting to
-	// discovered peers in order to prevent it from becoming a public test
-	// network.
-	var newAddressFunc func() (net.Addr, error)
-	if !cfg.SimNet && len(cfg.ConnectPeers) == 0 {
-		newAddressFunc = func() (net.Addr, error) {
+	s.validateKeyStore = newValidateKeyStore(filepath.Join(cfg.DataDir,
+		validateKeyStoreFilename), s.cpuMiner.SetValidateKeys)
+	if s.validateKeyStore.exists() {
+		srvrLog.Infof("Encrypted validate keys found in %s, unlock "+
+			"them with unlockvalidatekeys", s.validateKeyStore.path)
+	}
+
+	// addrManagerAddressFunc returns a function which returns addresses
+	// from the address manager to connect to, restricted to the peers
+	// advertising the passed services.
+	addrManagerAddressFunc := func(services wire.ServiceFlag) func() (net.Addr, error) {
+		return func() (net.Addr, error) {
 			for tries := 0; tries < 100; tries++ {
 				addr := s.addrManager.GetAddress()
 				if addr == nil {
 					break
 				}
 
+				if addr.NetAddress().Services&services != services {
+					continue
+				}
+
 				// Address will not be invalid, local or unroutable
 				// because addrmanager rejects those on addition.
 				// Just check that we don't already have an address
@@ -2490,6 +3260,66 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 			return nil, errors.New("no valid connect address")
 		}
 	}
+	// Only setup a function to return new addresses to connect to when
+	// not running in connect-only mode.  The simulation network is always
+	// in connect-only mode since it is only intended to connect to
+	// specified peers and actively avoid advertising and connecting to
+	// discovered peers in order to prevent it from becoming a public test
+	// network.
+	var newAddressFunc func() (net.Addr, error)
+	if !cfg.SimNet && len(cfg.ConnectPeers) == 0 {
+		newAddressFunc = addrManagerAddressFunc(0)
+	}
+
+	// listedAddressFunc returns a function which returns the addresses of
+	// the passed peers in turn, skipping the ones which are already
+	// connected.
+	listedAddressFunc := func(addrs []string) func() (net.Addr, error) {
+		var next uint32
+		return func() (net.Addr, error) {
+			connected := make(map[string]struct{})
+			for _, sp := range s.Peers() {
+				connected[sp.Addr()] = struct{}{}
+			}
+			start := atomic.AddUint32(&next, 1)
+			for i := range addrs {
+				addr := addrs[(int(start)+i)%len(addrs)]
+				if _, ok := connected[addr]; ok {
+					continue
+				}
+				return addrStringToNetAddr(addr)
+			}
+
+			return nil, errors.New("all listed peers are connected")
+		}
+	}
+
+	// Keep outbound connections to each group of peers in addition to the
+	// other outbound peers, so the node is always connected to validators,
+	// archival peers and explorer-facing peers.
+	var outboundGroups []connmgr.OutboundGroup
+	addListedGroup := func(name string, addrs []string, target uint32) {
+		if uint32(len(addrs)) < target {
+			target = uint32(len(addrs))
+		}
+		if target == 0 {
+			return
+		}
+		outboundGroups = append(outboundGroups, connmgr.OutboundGroup{
+			Name:           name,
+			TargetOutbound: target,
+			GetNewAddress:  listedAddressFunc(addrs),
+		})
+	}
+	addListedGroup("validator", cfg.ValidatorPeers, cfg.ValidatorOutbound)
+	addListedGroup("explorer", cfg.ExplorerPeers, cfg.ExplorerOutbound)
+	if newAddressFunc != nil && cfg.ArchivalOutbound > 0 {
+		outboundGroups = append(outboundGroups, connmgr.OutboundGroup{
+			Name:           "archival",
+			TargetOutbound: cfg.ArchivalOutbound,
+			GetNewAddress:  addrManagerAddressFunc(wire.SFNodeNetwork),
+		})
+	}
 
 	// Create a connection manager.
 	targetOutbound := defaultTargetOutbound
@@ -2504,6 +3334,7 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		Dial:           btcdDial,
 		OnConnection:   s.outboundPeerConnected,
 		GetNewAddress:  newAddressFunc,
+		OutboundGroups: outboundGroups,
 	})
 	if err != nil {
 		return nil, err
@@ -2528,7 +3359,7 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 	}
 
 	if !cfg.DisableRPC {
-		s.rpcServer, err = newRPCServer(cfg.RPCListeners,
+		s.rpcServer, err = newRPCServer(cfg.rpcListenAddrs,
 			blockTemplateGenerator, &s)
 		if err != nil {
 			return nil, err
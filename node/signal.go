@@ -4,7 +4,7 @@
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
-package main
+package node
 
 import (
 	"os"
@@ -19,6 +19,10 @@ var shutdownRequestChannel = make(chan struct{})
 // shutdown.  This may be modified during init depending on the platform.
 var interruptSignals = []os.Signal{os.Interrupt}
 
+// reloadSignals defines the signals to catch in order to reload the
+// configuration.  This may be modified during init depending on the platform.
+var reloadSignals []os.Signal
+
 // interruptListener listens for OS Signals such as SIGINT (Ctrl+C) and shutdown
 // requests from shutdownRequestChannel.  It returns a channel that is closed
 // when either signal is received.
@@ -59,6 +63,25 @@ func interruptListener() <-chan struct{} {
 	return c
 }
 
+// shutdownListener listens for shutdown requests from shutdownRequestChannel
+// without catching any OS signals, which are left to the program embedding the
+// node.  It returns a channel that is closed when one is received.
+func shutdownListener() <-chan struct{} {
+	c := make(chan struct{})
+	go func() {
+		<-shutdownRequestChannel
+		btcdLog.Info("Shutdown requested.  Shutting down...")
+		close(c)
+
+		for range shutdownRequestChannel {
+			btcdLog.Info("Shutdown requested.  Already shutting " +
+				"down...")
+		}
+	}()
+
+	return c
+}
+
 // interruptRequested returns true when the channel returned by
 // interruptListener was closed.  This simplifies early shutdown slightly since
 // the caller can just use an if statement instead of a select.
@@ -71,3 +94,34 @@ func interruptRequested(interrupted <-chan struct{}) bool {
 
 	return false
 }
+
+// reloadListener listens for OS signals such as SIGHUP and reloads the
+// configuration of the passed server each time one is received, until the
+// passed interrupted channel is closed.  It does nothing on platforms without
+// reload signals.
+func reloadListener(s *server, interrupted <-chan struct{}) {
+	if len(reloadSignals) == 0 {
+		return
+	}
+
+	go func() {
+		reloadChannel := make(chan os.Signal, 1)
+		signal.Notify(reloadChannel, reloadSignals...)
+		defer signal.Stop(reloadChannel)
+
+		for {
+			select {
+			case sig := <-reloadChannel:
+				btcdLog.Infof("Received signal (%s).  Reloading "+
+					"configuration...", sig)
+				if err := s.ReloadConfig(); err != nil {
+					btcdLog.Errorf("Unable to reload "+
+						"configuration: %v", err)
+				}
+
+			case <-interrupted:
+				return
+			}
+		}
+	}()
+}
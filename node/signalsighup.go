@@ -0,0 +1,16 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package node
+
+import (
+	"os"
+	"syscall"
+)
+
+func init() {
+	reloadSignals = []os.Signal{syscall.SIGHUP}
+}
@@ -6,7 +6,7 @@
 
 // +build darwin dragonfly freebsd linux netbsd openbsd solaris
 
-package main
+package node
 
 import (
 	"os"
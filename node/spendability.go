@@ -0,0 +1,58 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/mining"
+)
+
+// spendability describes when an unspent output can be spent, so clients
+// don't create transactions which are rejected as premature.
+type spendability struct {
+	// mature is whether the output is not a coinbase output or has reached
+	// the coinbase maturity for a spend in the next block.
+	mature bool
+
+	// height is the height of the first block which can include a spend.
+	height uint32
+
+	// time is the median time past the block before the spending block
+	// must exceed, or zero when there is no time-based relative lock.
+	time int64
+
+	// spendable is whether a spend can be included in the next block.
+	spendable bool
+}
+
+// outputSpendability returns when an output created at the passed height can
+// be spent by a transaction with the passed sequence lock, which can be
+// computed with CalcSequenceLock.  Outputs in the memory pool have the height
+// mining.UnminedHeight and are assumed to be mined in the next block.
+func outputSpendability(height uint32, coinbase bool, maturity uint16,
+	lock *blockchain.SequenceLock, best *blockchain.BestState) spendability {
+
+	nextHeight := best.Height + 1
+	var sp spendability
+	switch {
+	case height == mining.UnminedHeight:
+		sp.height = nextHeight
+	case coinbase:
+		sp.height = height + uint32(maturity)
+	default:
+		sp.height = height + 1
+	}
+	sp.mature = sp.height <= nextHeight
+
+	if lock.BlockHeight >= 0 && uint32(lock.BlockHeight)+1 > sp.height {
+		sp.height = uint32(lock.BlockHeight) + 1
+	}
+	if lock.Seconds >= 0 {
+		sp.time = lock.Seconds + 1
+	}
+	sp.spendable = sp.mature && blockchain.SequenceLockActive(lock,
+		int32(nextHeight), best.MedianTime)
+	return sp
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/mining"
+)
+
+// TestOutputSpendability ensures coinbase maturity and sequence locks are
+// reflected in when an output can be spent.
+func TestOutputSpendability(t *testing.T) {
+	best := &blockchain.BestState{
+		Height:     200,
+		MedianTime: time.Unix(1500000000, 0),
+	}
+	noLock := &blockchain.SequenceLock{Seconds: -1, BlockHeight: -1}
+	tests := []struct {
+		name     string
+		height   uint32
+		coinbase bool
+		lock     *blockchain.SequenceLock
+		want     spendability
+	}{
+		{"confirmed", 150, false, noLock,
+			spendability{true, 151, 0, true}},
+		{"unmined", mining.UnminedHeight, false, noLock,
+			spendability{true, 201, 0, true}},
+		{"mature coinbase", 101, true, noLock,
+			spendability{true, 201, 0, true}},
+		{"immature coinbase", 150, true, noLock,
+			spendability{false, 250, 0, false}},
+		{"height lock", 150, false,
+			&blockchain.SequenceLock{Seconds: -1, BlockHeight: 209},
+			spendability{true, 210, 0, false}},
+		{"satisfied height lock", 150, false,
+			&blockchain.SequenceLock{Seconds: -1, BlockHeight: 199},
+			spendability{true, 200, 0, true}},
+		{"time lock", 150, false,
+			&blockchain.SequenceLock{Seconds: 1500000511, BlockHeight: -1},
+			spendability{true, 151, 1500000512, false}},
+		{"satisfied time lock", 150, false,
+			&blockchain.SequenceLock{Seconds: 1499999999, BlockHeight: -1},
+			spendability{true, 151, 1500000000, true}},
+	}
+	for _, test := range tests {
+		got := outputSpendability(test.height, test.coinbase, 100,
+			test.lock, best)
+		if got != test.want {
+			t.Errorf("%s: got %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcjson"
+)
+
+const (
+	// stallCheckInterval is the interval at which the watchdog checks the
+	// sync peer and the block handler for stalls.
+	stallCheckInterval = 30 * time.Second
+
+	// syncStallTimeout is how long the sync peer may go without delivering
+	// a block while blocks are expected from it before it is considered
+	// stalled and replaced.
+	syncStallTimeout = 3 * time.Minute
+
+	// handlerStallTimeout is how long the block handler may take to handle
+	// a single message before it is considered stuck.
+	handlerStallTimeout = 5 * time.Minute
+)
+
+// syncWatchdog detects a stalled sync with the network.  The sync stalls when
+// the sync peer stops delivering the blocks expected from it, which happens on
+// flaky links, or when the block handler stops taking messages off its
+// channel.  The stalls are counted for the getsyncstatus RPC.
+type syncWatchdog struct {
+	mtx sync.Mutex
+
+	// syncPeer is the address of the current sync peer, and lastProgress
+	// is the last time it delivered a block or was not expected to.
+	syncPeer     string
+	lastProgress time.Time
+
+	// handlerMsg is the message the block handler is handling, which it
+	// started at handlerBusySince.  handlerStalled is set once handling it
+	// is counted as a stall, so each stall is only counted once.
+	handlerMsg       interface{}
+	handlerBusySince time.Time
+	handlerStalled   bool
+
+	syncStalls    uint64
+	handlerStalls uint64
+	lastStall     time.Time
+	lastStallPeer string
+}
+
+// setSyncPeer records the address of the new sync peer, or an empty address
+// when there is none, and restarts the time it has to deliver blocks.
+//
+// This function is safe for concurrent access.
+func (w *syncWatchdog) setSyncPeer(addr string, now time.Time) {
+	w.mtx.Lock()
+	w.syncPeer = addr
+	w.lastProgress = now
+	w.mtx.Unlock()
+}
+
+// progress records the sync peer delivered a block.
+//
+// This function is safe for concurrent access.
+func (w *syncWatchdog) progress(now time.Time) {
+	w.mtx.Lock()
+	w.lastProgress = now
+	w.mtx.Unlock()
+}
+
+// syncStalled returns whether the sync peer stalled, which is the case when it
+// is expected to deliver blocks and did not deliver any for syncStallTimeout.
+// The stall is counted when it did.
+//
+// This function is safe for concurrent access.
+func (w *syncWatchdog) syncStalled(waiting bool, now time.Time) bool {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	// The time the sync peer has to deliver a block only runs while blocks
+	// are expected from it.
+	if w.syncPeer == "" || !waiting {
+		w.lastProgress = now
+		return false
+	}
+	if now.Sub(w.lastProgress) < syncStallTimeout {
+		return false
+	}
+
+	w.syncStalls++
+	w.lastStall = now
+	w.lastStallPeer = w.syncPeer
+	return true
+}
+
+// handlerBusy records the block handler started handling the passed message.
+//
+// This function is safe for concurrent access.
+func (w *syncWatchdog) handlerBusy(msg interface{}, now time.Time) {
+	w.mtx.Lock()
+	w.handlerMsg = msg
+	w.handlerBusySince = now
+	w.handlerStalled = false
+	w.mtx.Unlock()
+}
+
+// handlerIdle records the block handler finished handling its message.
+//
+// This function is safe for concurrent access.
+func (w *syncWatchdog) handlerIdle() {
+	w.mtx.Lock()
+	w.handlerMsg = nil
+	w.handlerStalled = false
+	w.mtx.Unlock()
+}
+
+// handlerStall returns the message the block handler is stuck on, along with
+// how long it has been handling it, when it has been handling it for at least
+// handlerStallTimeout.  Each stall is only returned, and counted, once.
+//
+// This function is safe for concurrent access.
+func (w *syncWatchdog) handlerStall(now time.Time) (interface{}, time.Duration) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	if w.handlerMsg == nil || w.handlerStalled {
+		return nil, 0
+	}
+	busy := now.Sub(w.handlerBusySince)
+	if busy < handlerStallTimeout {
+		return nil, 0
+	}
+
+	w.handlerStalled = true
+	w.handlerStalls++
+	w.lastStall = now
+	return w.handlerMsg, busy
+}
+
+// status returns the sync state and stall counters reported by the
+// getsyncstatus RPC.  It does not depend on the block handler, so it can be
+// reported while the handler is stuck.
+//
+// This function is safe for concurrent access.
+func (w *syncWatchdog) status(now time.Time) *btcjson.GetSyncStatusResult {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	result := &btcjson.GetSyncStatusResult{
+		SyncPeer:      w.syncPeer,
+		SyncStalls:    w.syncStalls,
+		HandlerStalls: w.handlerStalls,
+		LastStallPeer: w.lastStallPeer,
+	}
+	if w.syncPeer != "" {
+		result.LastProgress = w.lastProgress.Unix()
+	}
+	if !w.lastStall.IsZero() {
+		result.LastStall = w.lastStall.Unix()
+	}
+	if w.handlerMsg != nil {
+		result.HandlerBusy = now.Sub(w.handlerBusySince).Seconds()
+	}
+	return result
+}
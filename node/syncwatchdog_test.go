@@ -0,0 +1,77 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSyncWatchdog ensures the watchdog only considers the sync peer stalled
+// once it delivered no block for the stall timeout while blocks are expected
+// from it, and counts each stall of the block handler once.
+func TestSyncWatchdog(t *testing.T) {
+	var w syncWatchdog
+	now := time.Unix(1000000, 0)
+
+	// Without a sync peer nothing stalls.
+	if w.syncStalled(true, now.Add(time.Hour)) {
+		t.Fatalf("sync stalled without a sync peer")
+	}
+
+	w.setSyncPeer("127.0.0.1:1", now)
+	if w.syncStalled(true, now.Add(syncStallTimeout-time.Second)) {
+		t.Fatalf("sync stalled before the timeout")
+	}
+	w.progress(now.Add(syncStallTimeout - time.Second))
+	if w.syncStalled(true, now.Add(syncStallTimeout)) {
+		t.Fatalf("sync stalled right after a block was delivered")
+	}
+
+	// The timeout only runs while blocks are expected.
+	now = now.Add(time.Hour)
+	if w.syncStalled(false, now) {
+		t.Fatalf("sync stalled while no blocks are expected")
+	}
+	if !w.syncStalled(true, now.Add(syncStallTimeout)) {
+		t.Fatalf("sync not stalled after the timeout")
+	}
+	status := w.status(now)
+	if status.SyncStalls != 1 || status.LastStallPeer != "127.0.0.1:1" ||
+		status.LastStall != now.Add(syncStallTimeout).Unix() {
+		t.Fatalf("got %d sync stalls, last by %q at %d", status.SyncStalls,
+			status.LastStallPeer, status.LastStall)
+	}
+
+	// The block handler is stuck once it handles a message for the
+	// timeout, and the stall is only counted once.
+	msg := &newPeerMsg{}
+	w.handlerBusy(msg, now)
+	if got, _ := w.handlerStall(now.Add(handlerStallTimeout - time.Second)); got != nil {
+		t.Fatalf("handler stuck before the timeout")
+	}
+	got, busy := w.handlerStall(now.Add(handlerStallTimeout))
+	if got != msg || busy != handlerStallTimeout {
+		t.Fatalf("got handler stuck on %v for %v, want %v for %v", got,
+			busy, msg, handlerStallTimeout)
+	}
+	if got, _ := w.handlerStall(now.Add(2 * handlerStallTimeout)); got != nil {
+		t.Fatalf("handler stall counted twice")
+	}
+	status = w.status(now.Add(2 * handlerStallTimeout))
+	if status.HandlerStalls != 1 ||
+		status.HandlerBusy != (2*handlerStallTimeout).Seconds() {
+		t.Fatalf("got %d handler stalls, busy for %v seconds",
+			status.HandlerStalls, status.HandlerBusy)
+	}
+
+	w.handlerIdle()
+	if got, _ := w.handlerStall(now.Add(3 * handlerStallTimeout)); got != nil {
+		t.Fatalf("idle handler stuck")
+	}
+	if status := w.status(now); status.HandlerBusy != 0 {
+		t.Fatalf("idle handler busy for %v seconds", status.HandlerBusy)
+	}
+}
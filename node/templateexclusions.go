@@ -0,0 +1,131 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/mining"
+	"github.com/pyx-partners/dmgd/provautil"
+)
+
+// templateExclusionsFilename is the name of the file in the data directory the
+// block template exclusions are stored in.
+const templateExclusionsFilename = "templateexclusions.json"
+
+// templateExclusionsFile is the format of the file the block template
+// exclusions are stored in.
+type templateExclusionsFile struct {
+	TxIDs     []string `json:"txids"`
+	Addresses []string `json:"addresses"`
+}
+
+// templateExclusions keeps the transactions and addresses excluded from the
+// block templates generated by the node.  They are stored on disk with every
+// change, so they stay excluded after a restart.
+type templateExclusions struct {
+	path       string
+	exclusions *mining.Exclusions
+
+	// mtx serializes the changes along with storing them.
+	mtx sync.Mutex
+}
+
+// loadTemplateExclusions returns the block template exclusions stored at the
+// passed path, which are empty when the file does not exist.  The addresses
+// are decoded for the passed network.
+func loadTemplateExclusions(path string, params *chaincfg.Params) (*templateExclusions, error) {
+	t := &templateExclusions{
+		path:       path,
+		exclusions: mining.NewExclusions(),
+	}
+	serialized, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file templateExclusionsFile
+	if err := json.Unmarshal(serialized, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for _, txID := range file.TxIDs {
+		txHash, err := chainhash.NewHashFromStr(txID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: transaction %q: %v", path,
+				txID, err)
+		}
+		t.exclusions.AddTx(txHash)
+	}
+	for _, encoded := range file.Addresses {
+		addr, err := provautil.DecodeAddress(encoded, params)
+		if err != nil {
+			return nil, fmt.Errorf("%s: address %q: %v", path,
+				encoded, err)
+		}
+		t.exclusions.AddAddress(addr)
+	}
+	return t, nil
+}
+
+// save stores the exclusions on disk, replacing the file atomically so a crash
+// never leaves it partially written.
+//
+// This function MUST be called with the mutex held.
+func (t *templateExclusions) save() error {
+	serialized, err := json.MarshalIndent(&templateExclusionsFile{
+		TxIDs:     t.exclusions.TxIDs(),
+		Addresses: t.exclusions.Addresses(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := t.path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, serialized, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, t.path)
+}
+
+// update adds the passed transaction or address to the exclusions when add is
+// set and removes it otherwise, then stores the exclusions on disk.  Exactly
+// one of the transaction hash and the address must be passed.  It returns
+// whether the exclusions changed.  A change which can't be stored is undone.
+//
+// This function is safe for concurrent access.
+func (t *templateExclusions) update(add bool, txHash *chainhash.Hash, addr provautil.Address) (bool, error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	change := func(add bool) bool {
+		switch {
+		case txHash != nil && add:
+			return t.exclusions.AddTx(txHash)
+		case txHash != nil:
+			return t.exclusions.RemoveTx(txHash)
+		case add:
+			return t.exclusions.AddAddress(addr)
+		default:
+			return t.exclusions.RemoveAddress(addr)
+		}
+	}
+	if !change(add) {
+		return false, nil
+	}
+	if err := t.save(); err != nil {
+		change(!add)
+		return false, err
+	}
+	return true, nil
+}
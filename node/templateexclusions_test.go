@@ -0,0 +1,101 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/provautil"
+)
+
+// TestTemplateExclusions ensures block template exclusions are stored with
+// every change and loaded again, and that changes which can't be stored are
+// undone.
+func TestTemplateExclusions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "templateexclusions")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	params := &chaincfg.RegressionNetParams
+	path := filepath.Join(dir, templateExclusionsFilename)
+	exclusions, err := loadTemplateExclusions(path, params)
+	if err != nil {
+		t.Fatalf("loadTemplateExclusions: %v", err)
+	}
+	if len(exclusions.exclusions.TxIDs()) != 0 ||
+		len(exclusions.exclusions.Addresses()) != 0 {
+
+		t.Fatal("loadTemplateExclusions: exclusions without a file")
+	}
+
+	txHash := &chainhash.Hash{1}
+	addr, err := provautil.NewAddressProva(make([]byte, 20),
+		[]btcec.KeyID{1, 2}, params)
+	if err != nil {
+		t.Fatalf("NewAddressProva: %v", err)
+	}
+	for _, change := range []struct {
+		add    bool
+		txHash *chainhash.Hash
+		addr   provautil.Address
+		want   bool
+	}{
+		{true, txHash, nil, true},
+		{true, txHash, nil, false},
+		{true, nil, addr, true},
+		{true, &chainhash.Hash{2}, nil, true},
+		{false, &chainhash.Hash{2}, nil, true},
+		{false, &chainhash.Hash{2}, nil, false},
+	} {
+		changed, err := exclusions.update(change.add, change.txHash,
+			change.addr)
+		if err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		if changed != change.want {
+			t.Fatalf("update: got changed %v, want %v", changed,
+				change.want)
+		}
+	}
+
+	loaded, err := loadTemplateExclusions(path, params)
+	if err != nil {
+		t.Fatalf("loadTemplateExclusions: %v", err)
+	}
+	if got, want := loaded.exclusions.TxIDs(), []string{txHash.String()}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("loadTemplateExclusions: got transactions %v, want %v",
+			got, want)
+	}
+	if got, want := loaded.exclusions.Addresses(), []string{addr.EncodeAddress()}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("loadTemplateExclusions: got addresses %v, want %v",
+			got, want)
+	}
+
+	// A change which can't be stored is undone.
+	loaded.path = filepath.Join(dir, "missing", templateExclusionsFilename)
+	if _, err := loaded.update(false, txHash, nil); err == nil {
+		t.Fatal("update: no error for unwritable file")
+	}
+	if len(loaded.exclusions.TxIDs()) != 1 {
+		t.Fatal("update: failed change was not undone")
+	}
+
+	// Files which can't be decoded are rejected.
+	if err := ioutil.WriteFile(path, []byte(`{"txids": ["xyz"]}`), 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := loadTemplateExclusions(path, params); err == nil {
+		t.Fatal("loadTemplateExclusions: no error for invalid hash")
+	}
+}
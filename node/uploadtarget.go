@@ -0,0 +1,113 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcjson"
+)
+
+const (
+	// uploadCycle is the time frame over which the bytes sent to peers are
+	// measured against the upload target.
+	uploadCycle = 24 * time.Hour
+
+	// historicBlockAge is the age of the blocks which are no longer served
+	// to peers which are not whitelisted once the upload target is reached.
+	historicBlockAge = 7 * 24 * time.Hour
+)
+
+// uploadTarget keeps track of the bytes sent to peers during the current upload
+// cycle.  Once they reach the target, the server stops serving historic blocks
+// to peers which are not whitelisted until the next cycle starts, so recent
+// blocks and transactions are still relayed.
+type uploadTarget struct {
+	target uint64
+
+	mtx        sync.Mutex
+	cycleStart time.Time
+	cycleBytes uint64
+}
+
+// newUploadTarget returns a new upload target of the passed number of bytes
+// per cycle, with the first cycle starting at the passed time.  There is no
+// limit when the target is zero.
+func newUploadTarget(target uint64, now time.Time) *uploadTarget {
+	return &uploadTarget{
+		target:     target,
+		cycleStart: now,
+	}
+}
+
+// updateCycle starts a new cycle when the current one has passed at the passed
+// time.
+//
+// This function MUST be called with the mutex held.
+func (u *uploadTarget) updateCycle(now time.Time) {
+	if now.Sub(u.cycleStart) >= uploadCycle {
+		u.cycleStart = now
+		u.cycleBytes = 0
+	}
+}
+
+// add records the passed number of bytes as sent at the passed time.
+//
+// This function is safe for concurrent access.
+func (u *uploadTarget) add(bytesSent uint64, now time.Time) {
+	if u.target == 0 {
+		return
+	}
+
+	u.mtx.Lock()
+	u.updateCycle(now)
+	u.cycleBytes += bytesSent
+	u.mtx.Unlock()
+}
+
+// reached returns whether the bytes sent during the cycle of the passed time
+// have reached the target.
+//
+// This function is safe for concurrent access.
+func (u *uploadTarget) reached(now time.Time) bool {
+	if u.target == 0 {
+		return false
+	}
+
+	u.mtx.Lock()
+	u.updateCycle(now)
+	reached := u.cycleBytes >= u.target
+	u.mtx.Unlock()
+	return reached
+}
+
+// result returns the getnettotals description of the upload target at the
+// passed time.
+//
+// This function is safe for concurrent access.
+func (u *uploadTarget) result(now time.Time) btcjson.UploadTargetResult {
+	result := btcjson.UploadTargetResult{
+		TimeFrame:             int64(uploadCycle / time.Second),
+		Target:                u.target,
+		ServeHistoricalBlocks: true,
+	}
+	if u.target == 0 {
+		return result
+	}
+
+	u.mtx.Lock()
+	u.updateCycle(now)
+	if u.cycleBytes < u.target {
+		result.BytesLeftInCycle = u.target - u.cycleBytes
+	}
+	result.TimeLeftInCycle = int64(u.cycleStart.Add(uploadCycle).Sub(now) /
+		time.Second)
+	u.mtx.Unlock()
+
+	result.TargetReached = result.BytesLeftInCycle == 0
+	result.ServeHistoricalBlocks = !result.TargetReached
+	return result
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcjson"
+)
+
+// TestUploadTarget ensures the upload target is reached once enough bytes are
+// sent during a cycle and resets with the next cycle.
+func TestUploadTarget(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+	target := newUploadTarget(1000, start)
+
+	target.add(600, start.Add(time.Hour))
+	if target.reached(start.Add(time.Hour)) {
+		t.Fatalf("target reached after 600 of 1000 bytes")
+	}
+	want := btcjson.UploadTargetResult{
+		TimeFrame:             int64(uploadCycle / time.Second),
+		Target:                1000,
+		ServeHistoricalBlocks: true,
+		BytesLeftInCycle:      400,
+		TimeLeftInCycle:       int64(23 * time.Hour / time.Second),
+	}
+	if got := target.result(start.Add(time.Hour)); got != want {
+		t.Fatalf("got result %+v, want %+v", got, want)
+	}
+
+	target.add(400, start.Add(2*time.Hour))
+	if !target.reached(start.Add(2 * time.Hour)) {
+		t.Fatalf("target not reached after 1000 of 1000 bytes")
+	}
+	got := target.result(start.Add(2 * time.Hour))
+	if !got.TargetReached || got.ServeHistoricalBlocks ||
+		got.BytesLeftInCycle != 0 {
+
+		t.Fatalf("got result %+v for reached target", got)
+	}
+
+	// The next cycle starts with nothing sent.
+	next := start.Add(uploadCycle)
+	if target.reached(next) {
+		t.Fatalf("target still reached in the next cycle")
+	}
+	if got := target.result(next); got.BytesLeftInCycle != 1000 ||
+		got.TimeLeftInCycle != int64(uploadCycle/time.Second) {
+
+		t.Fatalf("got result %+v for the next cycle", got)
+	}
+
+	// There is no limit without a target.
+	unlimited := newUploadTarget(0, start)
+	unlimited.add(1<<40, start)
+	if unlimited.reached(start) {
+		t.Fatalf("target reached without a limit")
+	}
+	want = btcjson.UploadTargetResult{
+		TimeFrame:             int64(uploadCycle / time.Second),
+		ServeHistoricalBlocks: true,
+	}
+	if got := unlimited.result(start); got != want {
+		t.Fatalf("got result %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/keystore"
+)
+
+// validateKeyStoreFilename is the name of the file in the data directory the
+// encrypted validate keys are stored in.
+const validateKeyStoreFilename = "validatekeys.json"
+
+// validateKeyStore keeps the validate keys used to sign generated blocks.  The
+// keys can be stored encrypted on disk, so they are unlocked with a passphrase
+// after a restart instead of being sent again, and are locked again once the
+// timeout of the unlock expires.
+type validateKeyStore struct {
+	path    string
+	setKeys func([]*btcec.PrivateKey)
+
+	mtx         sync.Mutex
+	keys        []*btcec.PrivateKey
+	relockTimer *time.Timer
+}
+
+// newValidateKeyStore returns a new validate keystore stored at the passed
+// path which sets the keys used to sign blocks with the passed function.
+func newValidateKeyStore(path string, setKeys func([]*btcec.PrivateKey)) *validateKeyStore {
+	return &validateKeyStore{
+		path:    path,
+		setKeys: setKeys,
+	}
+}
+
+// exists returns whether encrypted validate keys are stored on disk.
+func (ks *validateKeyStore) exists() bool {
+	_, err := os.Stat(ks.path)
+	return err == nil
+}
+
+// save encrypts the passed validate keys with the passed passphrase and stores
+// them on disk, replacing the keys stored before.
+func (ks *validateKeyStore) save(passphrase string, privKeys []*btcec.PrivateKey) error {
+	return keystore.Save(ks.path, []byte(passphrase), privKeys)
+}
+
+// load returns the validate keys stored on disk decrypted with the passed
+// passphrase.  The keys are not used until they are passed to set.
+func (ks *validateKeyStore) load(passphrase string) ([]*btcec.PrivateKey, error) {
+	return keystore.Load(ks.path, []byte(passphrase))
+}
+
+// set starts signing blocks with the passed validate keys.  They are locked
+// again once the passed timeout expires, unless it is zero.  Any earlier
+// timeout is cancelled.
+//
+// This function is safe for concurrent access.
+func (ks *validateKeyStore) set(privKeys []*btcec.PrivateKey, timeout time.Duration) {
+	ks.mtx.Lock()
+	defer ks.mtx.Unlock()
+
+	ks.stopRelock()
+	ks.replaceKeys(privKeys)
+	if timeout <= 0 {
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(timeout, func() {
+		ks.mtx.Lock()
+		defer ks.mtx.Unlock()
+
+		// Only lock the keys when they have not been replaced since the
+		// timer was started.
+		if ks.relockTimer != timer {
+			return
+		}
+		ks.relockTimer = nil
+		ks.replaceKeys(nil)
+		srvrLog.Infof("Validate keys locked after the unlock timeout")
+	})
+	ks.relockTimer = timer
+}
+
+// lock stops signing blocks with the validate keys until they are set again.
+//
+// This function is safe for concurrent access.
+func (ks *validateKeyStore) lock() {
+	ks.mtx.Lock()
+	defer ks.mtx.Unlock()
+
+	ks.stopRelock()
+	ks.replaceKeys(nil)
+}
+
+// replaceKeys starts signing blocks with the passed validate keys instead of
+// the keys set before, which are zeroed unless they are passed again, so they
+// do not linger in memory.
+//
+// This function MUST be called with the mutex held.
+func (ks *validateKeyStore) replaceKeys(privKeys []*btcec.PrivateKey) {
+	oldKeys := ks.keys
+	ks.keys = privKeys
+	ks.setKeys(privKeys)
+
+	for _, oldKey := range oldKeys {
+		kept := false
+		for _, privKey := range privKeys {
+			if privKey == oldKey {
+				kept = true
+				break
+			}
+		}
+		if !kept {
+			oldKey.Zero()
+		}
+	}
+}
+
+// stopRelock cancels locking the validate keys once the timeout of the last
+// unlock expires.
+//
+// This function MUST be called with the mutex held.
+func (ks *validateKeyStore) stopRelock() {
+	if ks.relockTimer != nil {
+		ks.relockTimer.Stop()
+		ks.relockTimer = nil
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcec"
+)
+
+// TestValidateKeyStore ensures validate keys stored with a passphrase are
+// unlocked with it, locked again once the unlock timeout expires and that
+// setting or locking the keys cancels the timeout.
+func TestValidateKeyStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validatekeystore")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var mtx sync.Mutex
+	var current []*btcec.PrivateKey
+	ks := newValidateKeyStore(filepath.Join(dir, validateKeyStoreFilename),
+		func(privKeys []*btcec.PrivateKey) {
+			mtx.Lock()
+			current = privKeys
+			mtx.Unlock()
+		})
+	numKeys := func() int {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return len(current)
+	}
+
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("NewPrivateKey: %v", err)
+	}
+	if ks.exists() {
+		t.Fatalf("keystore exists before keys are stored")
+	}
+	if err := ks.save("pass", []*btcec.PrivateKey{privKey}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if !ks.exists() {
+		t.Fatalf("keystore does not exist after keys are stored")
+	}
+	privKeys, err := ks.load("pass")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(privKeys) != 1 || privKeys[0].D.Cmp(privKey.D) != 0 {
+		t.Fatalf("got %d loaded keys, want the stored key",
+			len(privKeys))
+	}
+
+	// The keys are locked once the timeout expires, and zeroed since they
+	// are no longer used.
+	ks.set(privKeys, 50*time.Millisecond)
+	if numKeys() != 1 {
+		t.Fatalf("keys not set when unlocked")
+	}
+	time.Sleep(200 * time.Millisecond)
+	if numKeys() != 0 {
+		t.Fatalf("keys not locked after the timeout")
+	}
+	if privKeys[0].D.Sign() != 0 {
+		t.Fatalf("keys not zeroed after the timeout")
+	}
+
+	// Setting the keys again cancels the timeout.
+	privKeys, err = ks.load("pass")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	ks.set(privKeys, 50*time.Millisecond)
+	ks.set(privKeys, 0)
+	time.Sleep(200 * time.Millisecond)
+	if numKeys() != 1 {
+		t.Fatalf("keys locked after the timeout was cancelled")
+	}
+
+	if privKeys[0].D.Sign() == 0 {
+		t.Fatalf("keys zeroed when set again")
+	}
+
+	ks.lock()
+	if numKeys() != 0 {
+		t.Fatalf("keys not locked")
+	}
+	if privKeys[0].D.Sign() != 0 {
+		t.Fatalf("keys not zeroed when locked")
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package node
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/blockchain/indexers"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/webhook"
+)
+
+// webhookDeposit is the data of a deposit event: an output paying a watched
+// address.
+type webhookDeposit struct {
+	TxID      string  `json:"txid"`
+	Vout      uint32  `json:"vout"`
+	Address   string  `json:"address"`
+	Label     string  `json:"label"`
+	Amount    float64 `json:"amount"`
+	BlockHash string  `json:"blockhash"`
+	Height    uint32  `json:"height"`
+}
+
+// webhookAdminOp is the data of an admin operation event.
+type webhookAdminOp struct {
+	TxID      string `json:"txid"`
+	Thread    uint32 `json:"thread"`
+	Op        string `json:"op"`
+	BlockHash string `json:"blockhash"`
+	Height    uint32 `json:"height"`
+}
+
+// webhookBlockConnected dispatches the deposit and admin operation events of
+// the passed block connected to the main chain to the webhooks.  The event IDs
+// include the hash of the block, so a transaction confirmed again by another
+// block after a reorganization yields new events.
+func (s *server) webhookBlockConnected(block *provautil.Block) error {
+	var events []*webhook.Event
+	now := time.Now()
+	blockHash := block.Hash().String()
+	height := block.MsgBlock().Header.Height
+
+	if s.watchIndex != nil && s.webhooks.Wants(webhook.EventDeposit) {
+		entries, err := s.watchIndex.BlockTxEntries(height)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.Category != indexers.WatchReceive {
+				continue
+			}
+			output := &entry.Output
+			var address string
+			_, addrs, _, err := txscript.ExtractPkScriptAddrs(
+				output.PkScript, s.chainParams)
+			if err == nil && len(addrs) != 0 {
+				address = addrs[0].EncodeAddress()
+			}
+			event, err := webhook.NewEvent(webhook.EventDeposit,
+				fmt.Sprintf("%s:%s", blockHash, output.OutPoint),
+				now, &webhookDeposit{
+					TxID:      entry.TxHash.String(),
+					Vout:      output.OutPoint.Index,
+					Address:   address,
+					Label:     output.Label,
+					Amount:    provautil.Amount(output.Amount).ToDMG(),
+					BlockHash: blockHash,
+					Height:    height,
+				})
+			if err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+	}
+
+	if s.webhooks.Wants(webhook.EventAdminOp) {
+		for _, tx := range block.Transactions() {
+			// Issue thread transactions issue or destroy coins
+			// instead of carrying admin operations.
+			threadInt, _ := txscript.GetAdminDetails(tx)
+			threadID := provautil.ThreadID(threadInt)
+			if threadInt < 0 || threadID == provautil.IssueThread {
+				continue
+			}
+			for i, txOut := range tx.MsgTx().TxOut[1:] {
+				event, err := webhook.NewEvent(webhook.EventAdminOp,
+					fmt.Sprintf("%s:%s:%d", blockHash, tx.Hash(),
+						i+1), now, &webhookAdminOp{
+						TxID:      tx.Hash().String(),
+						Thread:    uint32(threadID),
+						Op:        txscript.AdminOpString(txOut.PkScript),
+						BlockHash: blockHash,
+						Height:    height,
+					})
+				if err != nil {
+					return err
+				}
+				events = append(events, event)
+			}
+		}
+	}
+
+	s.webhooks.Dispatch(events...)
+	return nil
+}
+
+// webhookReorg dispatches the event of the passed reorganization of the main
+// chain to the webhooks.
+func (s *server) webhookReorg(reorg *blockchain.ReorgEvent) error {
+	if !s.webhooks.Wants(webhook.EventReorg) {
+		return nil
+	}
+	event, err := webhook.NewEvent(webhook.EventReorg,
+		fmt.Sprintf("%s:%s", reorg.OldTip, reorg.NewTip), time.Now(),
+		reorgEventResult(reorg))
+	if err != nil {
+		return err
+	}
+	s.webhooks.Dispatch(event)
+	return nil
+}
@@ -18,3 +18,11 @@ package peer
 func TstAllowSelfConns() {
 	allowSelfConns = true
 }
+
+// TstRecordPing records a ping which took the passed number of microseconds as
+// if its pong was received.
+func (p *Peer) TstRecordPing(pingMicros int64) {
+	p.statsMtx.Lock()
+	p.recordPing(pingMicros)
+	p.statsMtx.Unlock()
+}
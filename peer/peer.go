@@ -29,7 +29,11 @@ import (
 
 const (
 	// MaxProtocolVersion is the max protocol version the peer supports.
-	MaxProtocolVersion = wire.FeeFilterVersion
+	MaxProtocolVersion = wire.AnnounceVersion
+
+	// otherMsgCommand is the command the bytes of messages which failed to
+	// decode are accounted to in the per-message statistics.
+	otherMsgCommand = "*other*"
 
 	// outputBufferSize is the number of elements the output channels use.
 	outputBufferSize = 50
@@ -66,6 +70,10 @@ const (
 	// trickleTimeout is the duration of the ticker which trickles down the
 	// inventory to a peer.
 	trickleTimeout = 8 * time.Second
+
+	// pingHistorySize is the number of the most recent ping times kept
+	// for each peer.
+	pingHistorySize = 16
 )
 
 var (
@@ -173,6 +181,10 @@ type MessageListeners struct {
 	// message.
 	OnSendHeaders func(p *Peer, msg *wire.MsgSendHeaders)
 
+	// OnAnnounce is invoked when a peer receives an announce bitcoin
+	// message.
+	OnAnnounce func(p *Peer, msg *wire.MsgAnnounce)
+
 	// OnRead is invoked when a peer receives a bitcoin message.  It
 	// consists of the number of bytes read, the message, and whether or not
 	// an error in the read occurred.  Typically, callers will opt to use
@@ -341,6 +353,17 @@ type StatsSnap struct {
 	LastPingNonce  uint64
 	LastPingTime   time.Time
 	LastPingMicros int64
+
+	// PingHistory holds the microseconds the most recent pings took,
+	// oldest first, and MinPingMicros the microseconds the fastest ping
+	// of the connection took.
+	PingHistory   []int64
+	MinPingMicros int64
+
+	// BytesSentPerMsg and BytesRecvPerMsg are the bytes sent to and
+	// received from the peer by message command.
+	BytesSentPerMsg map[string]uint64
+	BytesRecvPerMsg map[string]uint64
 }
 
 // HashFunc is a function which returns a block hash, height and error
@@ -429,6 +452,13 @@ type Peer struct {
 	lastPingNonce      uint64    // Set to nonce if we have a pending ping.
 	lastPingTime       time.Time // Time we sent last ping.
 	lastPingMicros     int64     // Time for last ping to return.
+	pingHistory        []int64   // Times for recent pings to return.
+	minPingMicros      int64     // Time for fastest ping to return.
+
+	// The bytes sent to and received from the peer by message command are
+	// protected by the statsMtx mutex as well.
+	bytesSentPerMsg map[string]uint64
+	bytesRecvPerMsg map[string]uint64
 
 	stallControl  chan stallControlMsg
 	outputQueue   chan outMsg
@@ -513,6 +543,18 @@ func (p *Peer) StatsSnapshot() *StatsSnap {
 		LastPingNonce:  p.lastPingNonce,
 		LastPingMicros: p.lastPingMicros,
 		LastPingTime:   p.lastPingTime,
+		PingHistory:    append([]int64(nil), p.pingHistory...),
+		MinPingMicros:  p.minPingMicros,
+		BytesSentPerMsg: make(map[string]uint64,
+			len(p.bytesSentPerMsg)),
+		BytesRecvPerMsg: make(map[string]uint64,
+			len(p.bytesRecvPerMsg)),
+	}
+	for command, n := range p.bytesSentPerMsg {
+		statsSnap.BytesSentPerMsg[command] = n
+	}
+	for command, n := range p.bytesRecvPerMsg {
+		statsSnap.BytesRecvPerMsg[command] = n
 	}
 
 	p.statsMtx.RUnlock()
@@ -1072,19 +1114,53 @@ func (p *Peer) handlePongMsg(msg *wire.MsgPong) {
 	if p.ProtocolVersion() > wire.BIP0031Version {
 		p.statsMtx.Lock()
 		if p.lastPingNonce != 0 && msg.Nonce == p.lastPingNonce {
-			p.lastPingMicros = time.Since(p.lastPingTime).Nanoseconds()
-			p.lastPingMicros /= 1000 // convert to usec.
+			pingMicros := time.Since(p.lastPingTime).Nanoseconds()
+			pingMicros /= 1000 // convert to usec.
+			p.recordPing(pingMicros)
 			p.lastPingNonce = 0
 		}
 		p.statsMtx.Unlock()
 	}
 }
 
+// recordPing records the passed number of microseconds a ping took as the last
+// ping time and in the ping history.
+//
+// This function MUST be called with the stats mutex held (for writes).
+func (p *Peer) recordPing(pingMicros int64) {
+	p.lastPingMicros = pingMicros
+	if p.minPingMicros == 0 || pingMicros < p.minPingMicros {
+		p.minPingMicros = pingMicros
+	}
+	if len(p.pingHistory) == pingHistorySize {
+		copy(p.pingHistory, p.pingHistory[1:])
+		p.pingHistory = p.pingHistory[:pingHistorySize-1]
+	}
+	p.pingHistory = append(p.pingHistory, pingMicros)
+}
+
+// addMsgBytes adds the passed number of bytes to the entry of the command of
+// the passed message in the passed per-message statistics.  The bytes of
+// messages which failed to decode are accounted to otherMsgCommand.
+func addMsgBytes(perMsg map[string]uint64, msg wire.Message, n int) {
+	if n == 0 {
+		return
+	}
+	command := otherMsgCommand
+	if msg != nil {
+		command = msg.Command()
+	}
+	perMsg[command] += uint64(n)
+}
+
 // readMessage reads the next bitcoin message from the peer with logging.
 func (p *Peer) readMessage() (wire.Message, []byte, error) {
 	n, msg, buf, err := wire.ReadMessageN(p.conn, p.ProtocolVersion(),
 		p.cfg.ChainParams.Net)
 	atomic.AddUint64(&p.bytesReceived, uint64(n))
+	p.statsMtx.Lock()
+	addMsgBytes(p.bytesRecvPerMsg, msg, n)
+	p.statsMtx.Unlock()
 	if p.cfg.Listeners.OnRead != nil {
 		p.cfg.Listeners.OnRead(p, n, msg, err)
 	}
@@ -1148,6 +1224,9 @@ func (p *Peer) writeMessage(msg wire.Message) error {
 	n, err := wire.WriteMessageN(p.conn, msg, p.ProtocolVersion(),
 		p.cfg.ChainParams.Net)
 	atomic.AddUint64(&p.bytesSent, uint64(n))
+	p.statsMtx.Lock()
+	addMsgBytes(p.bytesSentPerMsg, msg, n)
+	p.statsMtx.Unlock()
 	if p.cfg.Listeners.OnWrite != nil {
 		p.cfg.Listeners.OnWrite(p, n, msg, err)
 	}
@@ -1580,6 +1659,11 @@ out:
 				p.cfg.Listeners.OnSendHeaders(p, msg)
 			}
 
+		case *wire.MsgAnnounce:
+			if p.cfg.Listeners.OnAnnounce != nil {
+				p.cfg.Listeners.OnAnnounce(p, msg)
+			}
+
 		default:
 			log.Debugf("Received unhandled message of type %v "+
 				"from %v", rmsg.Command(), p)
@@ -1880,6 +1964,30 @@ func (p *Peer) QueueInventory(invVect *wire.InvVect) {
 	p.outputInvChan <- invVect
 }
 
+// QueueInventoryImmediate adds the passed inventory to the send queue to be
+// sent right away instead of with the next batch of inventory.  It will be
+// ignored if the peer is already known to have the inventory.
+//
+// This function is safe for concurrent access.
+func (p *Peer) QueueInventoryImmediate(invVect *wire.InvVect) {
+	// Don't send the inventory if the peer is already known to have it.
+	if p.knownInventory.Exists(invVect) {
+		return
+	}
+
+	// Avoid risk of deadlock if goroutine already exited.  The goroutine
+	// we will be sending to hangs around until it knows for a fact that
+	// it is marked as disconnected and *then* it drains the channels.
+	if !p.Connected() {
+		return
+	}
+
+	invMsg := wire.NewMsgInvSizeHint(1)
+	invMsg.AddInvVect(invVect)
+	p.AddKnownInventory(invVect)
+	p.QueueMessage(invMsg, nil)
+}
+
 // AssociateConnection associates the given conn to the peer.   Calling this
 // function when the peer is already connected will have no effect.
 func (p *Peer) AssociateConnection(conn net.Conn) {
@@ -2082,6 +2190,8 @@ func newPeerBase(origCfg *Config, inbound bool) *Peer {
 		cfg:             cfg, // Copy so caller can't mutate.
 		services:        cfg.Services,
 		protocolVersion: cfg.ProtocolVersion,
+		bytesSentPerMsg: make(map[string]uint64),
+		bytesRecvPerMsg: make(map[string]uint64),
 	}
 	return &p
 }
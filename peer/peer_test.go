@@ -203,6 +203,20 @@ func testPeer(t *testing.T, p *peer.Peer, s peerStats) {
 		t.Errorf("testPeer: wrong LastRecv - got %v, want %v", p.LastRecv(), stats.LastRecv)
 		return
 	}
+
+	// The bytes by message command must add up to the totals.
+	var sent, recv uint64
+	for _, n := range stats.BytesSentPerMsg {
+		sent += n
+	}
+	for _, n := range stats.BytesRecvPerMsg {
+		recv += n
+	}
+	if sent != s.wantBytesSent || recv != s.wantBytesReceived {
+		t.Errorf("testPeer: wrong bytes per message - got %v sent and %v received, want %v and %v",
+			sent, recv, s.wantBytesSent, s.wantBytesReceived)
+		return
+	}
 }
 
 // TestPeerConnection tests connection between inbound and outbound peers.
@@ -388,6 +402,9 @@ func TestPeerListeners(t *testing.T) {
 			OnSendHeaders: func(p *peer.Peer, msg *wire.MsgSendHeaders) {
 				ok <- msg
 			},
+			OnAnnounce: func(p *peer.Peer, msg *wire.MsgAnnounce) {
+				ok <- msg
+			},
 		},
 		UserAgentName:    "peer",
 		UserAgentVersion: "1.0",
@@ -512,6 +529,11 @@ func TestPeerListeners(t *testing.T) {
 			"OnSendHeaders",
 			wire.NewMsgSendHeaders(),
 		},
+		{
+			"OnAnnounce",
+			wire.NewMsgAnnounce(wire.AnnounceNotice, time.Now(),
+				time.Now().Add(time.Hour), "notice"),
+		},
 	}
 	t.Logf("Running %d tests", len(tests))
 	for _, test := range tests {
@@ -670,6 +692,44 @@ func TestOutboundPeer(t *testing.T) {
 	p2.Disconnect()
 }
 
+// TestPingHistory ensures the most recent ping times and the fastest ping time
+// are kept in the stats of peers.
+func TestPingHistory(t *testing.T) {
+	p := peer.NewInboundPeer(&peer.Config{
+		ChainParams: &chaincfg.MainNetParams,
+	})
+	stats := p.StatsSnapshot()
+	if len(stats.PingHistory) != 0 || stats.MinPingMicros != 0 {
+		t.Fatalf("unexpected ping stats before pings: history %v, "+
+			"min %d", stats.PingHistory, stats.MinPingMicros)
+	}
+
+	// Record more pings than the history holds, with the fastest one
+	// dropping out of the history.
+	for i := int64(0); i < 20; i++ {
+		p.TstRecordPing(1000 + i*10)
+	}
+	stats = p.StatsSnapshot()
+	if len(stats.PingHistory) != 16 {
+		t.Fatalf("wrong ping history length - got %d, want 16",
+			len(stats.PingHistory))
+	}
+	for i, pingMicros := range stats.PingHistory {
+		if want := 1040 + int64(i)*10; pingMicros != want {
+			t.Fatalf("wrong ping history entry %d - got %d, want %d",
+				i, pingMicros, want)
+		}
+	}
+	if stats.MinPingMicros != 1000 {
+		t.Fatalf("wrong MinPingMicros - got %d, want 1000",
+			stats.MinPingMicros)
+	}
+	if stats.LastPingMicros != 1190 {
+		t.Fatalf("wrong LastPingMicros - got %d, want 1190",
+			stats.LastPingMicros)
+	}
+}
+
 func init() {
 	// Allow self connection when running the tests.
 	peer.TstAllowSelfConns()
@@ -0,0 +1,12 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+//go:build postgres
+// +build postgres
+
+package main
+
+// Register the PostgreSQL driver used by the SQL export when dmgd is built
+// with the postgres build tag.
+import _ "github.com/lib/pq"
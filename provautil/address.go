@@ -10,10 +10,12 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"errors"
+	"strings"
 
 	"github.com/pyx-partners/dmgd/btcec"
 	"github.com/pyx-partners/dmgd/chaincfg"
 	"github.com/pyx-partners/dmgd/provautil/base58"
+	"github.com/pyx-partners/dmgd/provautil/bech32"
 	"github.com/btcsuite/golangcrypto/ripemd160"
 )
 
@@ -35,9 +37,21 @@ var (
 	// than assuming or defaulting to one or the other, this error is
 	// returned and the caller must decide how to decode the address.
 	ErrAddressCollision = errors.New("address collision")
+
+	// ErrUnknownAddressVersion describes an error where a bech32m encoded
+	// address can not be decoded since its version is reserved for an
+	// address type which is not known yet.
+	ErrUnknownAddressVersion = errors.New("unknown address version")
 )
 
-func encodeProvaAddress(keyIDs []btcec.KeyID, hash160 []byte, netID byte) string {
+// AddressVersionProva is the version of bech32m encoded standard Prova
+// addresses, which is the first 5-bit group of their data part.  The other
+// versions are reserved for future address types.
+const AddressVersionProva = 0
+
+// provaAddressData returns the payload of a Prova address, which is the
+// public key hash followed by the key ids.
+func provaAddressData(keyIDs []btcec.KeyID, hash160 []byte) []byte {
 	data := make([]byte, len(keyIDs)*btcec.KeyIDSize+ripemd160.Size)
 	copy(data[0:], hash160)
 	offset := ripemd160.Size
@@ -45,7 +59,40 @@ func encodeProvaAddress(keyIDs []btcec.KeyID, hash160 []byte, netID byte) string
 		binary.LittleEndian.PutUint32(data[offset:], uint32(keyID))
 		offset += btcec.KeyIDSize
 	}
-	return base58.CheckEncode(data, netID)
+	return data
+}
+
+func encodeProvaAddress(keyIDs []btcec.KeyID, hash160 []byte, netID byte) string {
+	return base58.CheckEncode(provaAddressData(keyIDs, hash160), netID)
+}
+
+// encodeProvaAddressBech32m returns the bech32m encoding of a Prova address
+// with the passed human-readable part.
+func encodeProvaAddressBech32m(keyIDs []btcec.KeyID, hash160 []byte, hrp string) (string, error) {
+	grouped, err := bech32.ConvertBits(provaAddressData(keyIDs, hash160),
+		8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	data := append([]byte{AddressVersionProva}, grouped...)
+	return bech32.Encode(hrp, data, bech32.Bech32m)
+}
+
+// checkProvaAddressSize returns an error if the passed payload size of a Prova
+// address is not the size of a public key hash followed by 2 to 19 key ids.
+func checkProvaAddressSize(decodedLen int) error {
+	mininumKeyIdsCount := 2
+	maximumKeyIdsCount := 19
+	if decodedLen < ripemd160.Size+(mininumKeyIdsCount*btcec.KeyIDSize) {
+		return errors.New("decoded address is of unknown size")
+	}
+	if decodedLen > ripemd160.Size+(maximumKeyIdsCount*btcec.KeyIDSize) {
+		return errors.New("decoded address exceeds maximum size")
+	}
+	if (decodedLen-ripemd160.Size)%btcec.KeyIDSize != 0 {
+		return errors.New("decoded address has invalid size")
+	}
+	return nil
 }
 
 // TODO(prova): Modify this interface to handle only Prova-form addresses. No need
@@ -86,15 +133,34 @@ type Address interface {
 }
 
 // DecodeAddress decodes the string encoding of an address and returns
-// the Address if addr is a valid encoding for a known address type.
+// the Address if addr is a valid encoding for a known address type.  Prova
+// addresses are accepted in both their base58 and bech32m encodings.
 //
 // The bitcoin network the address is associated with is extracted if possible.
 // When the address does not encode the network, such as in the case of a raw
 // public key, the address will be associated with the passed defaultNet.
 func DecodeAddress(addr string, defaultNet *chaincfg.Params) (Address, error) {
+	// Bech32m encoded addresses start with the human-readable part of
+	// their network followed by the separator.  Base58 encoded addresses
+	// may start the same way by chance, so they are still tried when the
+	// address does not decode as bech32m.
+	var bech32Err error
+	if sep := strings.LastIndexByte(addr, '1'); sep > 0 {
+		if _, ok := chaincfg.ProvaAddrIDForHRP(addr[:sep]); ok {
+			a, err := decodeBech32mAddress(addr)
+			if err == nil {
+				return a, nil
+			}
+			bech32Err = err
+		}
+	}
+
 	// Switch on decoded length to determine the type.
 	decoded, netID, err := base58.CheckDecode(addr)
 	if err != nil {
+		if bech32Err != nil {
+			return nil, bech32Err
+		}
 		if err == base58.ErrChecksum {
 			return nil, ErrChecksumMismatch
 		}
@@ -102,35 +168,74 @@ func DecodeAddress(addr string, defaultNet *chaincfg.Params) (Address, error) {
 	}
 
 	if chaincfg.IsProvaAddrID(netID) {
-		decodedLen := len(decoded)
-		mininumKeyIdsCount := 2
-		maximumKeyIdsCount := 19
-		if decodedLen < ripemd160.Size+(mininumKeyIdsCount*btcec.KeyIDSize) {
-			return nil, errors.New("decoded address is of unknown size")
+		if err := checkProvaAddressSize(len(decoded)); err != nil {
+			return nil, err
 		}
-		if decodedLen > ripemd160.Size+(maximumKeyIdsCount*btcec.KeyIDSize) {
-			return nil, errors.New("decoded address exceeds maximum size")
+		a, err := newAddressProvaFromBytes(decoded, netID)
+		if err != nil {
+			return nil, err
 		}
-		if (decodedLen-ripemd160.Size)%btcec.KeyIDSize != 0 {
-			return nil, errors.New("decoded address has invalid size")
+		if defaultNet != nil && defaultNet.ProvaAddrID == netID {
+			a.hrp = defaultNet.Bech32HRPProva
 		}
-		return newAddressProvaFromBytes(decoded, netID)
+		return a, nil
 	}
 
 	return nil, errors.New("decoded address is of unknown size")
 }
 
+// decodeBech32mAddress decodes the bech32m encoding of a Prova address.
+func decodeBech32mAddress(addr string) (*AddressProva, error) {
+	hrp, data, version, err := bech32.Decode(addr)
+	if err != nil {
+		if err == bech32.ErrChecksum {
+			return nil, ErrChecksumMismatch
+		}
+		return nil, err
+	}
+	if version != bech32.Bech32m {
+		return nil, errors.New("address is not bech32m encoded")
+	}
+	if len(data) == 0 {
+		return nil, errors.New("decoded address is of unknown size")
+	}
+	if data[0] != AddressVersionProva {
+		return nil, ErrUnknownAddressVersion
+	}
+	decoded, err := bech32.ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkProvaAddressSize(len(decoded)); err != nil {
+		return nil, err
+	}
+
+	netID, _ := chaincfg.ProvaAddrIDForHRP(hrp)
+	a, err := newAddressProvaFromBytes(decoded, netID)
+	if err != nil {
+		return nil, err
+	}
+	a.hrp = hrp
+	return a, nil
+}
+
 // AddressProva is a standard n-1 of n Prova address with n-1 keyids
 type AddressProva struct {
 	keyIDs []btcec.KeyID
 	hash   [ripemd160.Size]byte
 	netID  byte
+	hrp    string
 }
 
 // NewAddressProva returns a new AddressProva.  pkHash mustbe 20
 // bytes.
 func NewAddressProva(pkHash []byte, keyIDs []btcec.KeyID, net *chaincfg.Params) (*AddressProva, error) {
-	return newAddressProva(pkHash, keyIDs, net.ProvaAddrID)
+	addr, err := newAddressProva(pkHash, keyIDs, net.ProvaAddrID)
+	if err != nil {
+		return nil, err
+	}
+	addr.hrp = net.Bech32HRPProva
+	return addr, nil
 }
 
 // newAddressProva is the internal API to create an Prova address
@@ -181,6 +286,21 @@ func (a *AddressProva) EncodeAddress() string {
 	return encodeProvaAddress(a.keyIDs[:], a.hash[:], a.netID)
 }
 
+// EncodeBech32m returns the bech32m encoding of the Prova address, which
+// carries the same payload as the base58 encoding returned by EncodeAddress
+// along with a checksum detecting more errors.  An empty string is returned
+// when the network of the address has no bech32m human-readable part.
+func (a *AddressProva) EncodeBech32m() string {
+	if a.hrp == "" {
+		return ""
+	}
+	encoded, err := encodeProvaAddressBech32m(a.keyIDs, a.hash[:], a.hrp)
+	if err != nil {
+		return ""
+	}
+	return encoded
+}
+
 // ScriptAddress returns the bytes to be included in a txout script for an Prova address.
 // Part of the Address interface.
 func (a *AddressProva) ScriptAddress() []byte {
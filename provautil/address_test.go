@@ -11,7 +11,9 @@ import (
 	"github.com/pyx-partners/dmgd/btcec"
 	"github.com/pyx-partners/dmgd/chaincfg"
 	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/provautil/bech32"
 	"github.com/btcsuite/golangcrypto/ripemd160"
+	"strings"
 	"testing"
 )
 
@@ -157,3 +159,127 @@ func TestAddresses(t *testing.T) {
 		}
 	}
 }
+
+// TestBech32mAddresses ensures Prova addresses are encoded to and decoded from
+// bech32m as expected, and that addresses with a bad checksum, an unknown
+// version or the bech32 checksum are rejected.
+func TestBech32mAddresses(t *testing.T) {
+	pkHash := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
+	keyIDs := []btcec.KeyID{1, 2}
+
+	// encode returns the passed data part grouped into 5 bits, prefixed
+	// with the passed version, and encoded with the passed checksum.
+	payload := append(append([]byte{}, pkHash...), 1, 0, 0, 0, 2, 0, 0, 0)
+	encode := func(hrp string, addrVersion byte, version bech32.Version) string {
+		grouped, err := bech32.ConvertBits(payload, 8, 5, true)
+		if err != nil {
+			t.Fatalf("ConvertBits: %v", err)
+		}
+		encoded, err := bech32.Encode(hrp,
+			append([]byte{addrVersion}, grouped...), version)
+		if err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+		return encoded
+	}
+
+	tests := []struct {
+		name   string
+		addr   string
+		net    *chaincfg.Params
+		base58 string
+		err    error
+	}{
+		{
+			name:   "mainnet standard address",
+			addr:   "dmg1qqypqxpq9qcrsszg2pvxq6rs0zqg3yyc5qyqqqqqzqqqqqd9urhc",
+			net:    &chaincfg.MainNetParams,
+			base58: "G9n66A3tweNBdnrWHtPQhojDvgtTHpKp5Ke5EeHqZM1pv",
+		},
+		{
+			name:   "testnet standard address",
+			addr:   "tdmg1qqypqxpq9qcrsszg2pvxq6rs0zqg3yyc5qyqqqqqzqqqqqqq5n4h",
+			net:    &chaincfg.TestNetParams,
+			base58: "T9GooXEi927U4tuUkHsyfxtuDwAGFP2RaDXNGVNchBSz3",
+		},
+		{
+			name:   "uppercase mainnet address",
+			addr:   "DMG1QQYPQXPQ9QCRSSZG2PVXQ6RS0ZQG3YYC5QYQQQQQZQQQQQD9URHC",
+			net:    &chaincfg.MainNetParams,
+			base58: "G9n66A3tweNBdnrWHtPQhojDvgtTHpKp5Ke5EeHqZM1pv",
+		},
+		{
+			name: "bad checksum",
+			addr: "dmg1qqypqxpq9qcrsszg2pvxq6rs0zqg3yyc5qyqqqqqzqqqqqd9urhd",
+			net:  &chaincfg.MainNetParams,
+			err:  provautil.ErrChecksumMismatch,
+		},
+		{
+			name: "unknown address version",
+			addr: encode("dmg", 1, bech32.Bech32m),
+			net:  &chaincfg.MainNetParams,
+			err:  provautil.ErrUnknownAddressVersion,
+		},
+		{
+			name: "bech32 checksum",
+			addr: encode("dmg", provautil.AddressVersionProva, bech32.Bech32),
+			net:  &chaincfg.MainNetParams,
+		},
+	}
+
+	for _, test := range tests {
+		decoded, err := provautil.DecodeAddress(test.addr, test.net)
+		if test.base58 == "" {
+			if err == nil {
+				t.Errorf("%v: decoding succeeded for an invalid "+
+					"address", test.name)
+				continue
+			}
+			if test.err != nil && err != test.err {
+				t.Errorf("%v: unexpected error: got %v, want %v",
+					test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: decoding test failed: %v", test.name, err)
+			continue
+		}
+
+		// The decoded address is the same as its base58 encoding,
+		// which remains the canonical encoding.
+		if encoded := decoded.EncodeAddress(); encoded != test.base58 {
+			t.Errorf("%v: got base58 address %v, want %v", test.name,
+				encoded, test.base58)
+			continue
+		}
+		if !decoded.IsForNet(test.net) {
+			t.Errorf("%v: calculated network does not match expected",
+				test.name)
+			continue
+		}
+
+		// Both the address created from its parts and the address
+		// decoded from base58 encode to the lowercase bech32m address.
+		want := strings.ToLower(test.addr)
+		addr, err := provautil.NewAddressProva(pkHash, keyIDs, test.net)
+		if err != nil {
+			t.Errorf("%v: NewAddressProva: %v", test.name, err)
+			continue
+		}
+		if encoded := addr.EncodeBech32m(); encoded != want {
+			t.Errorf("%v: got bech32m address %v, want %v",
+				test.name, encoded, want)
+		}
+		fromBase58, err := provautil.DecodeAddress(test.base58, test.net)
+		if err != nil {
+			t.Errorf("%v: decoding test failed: %v", test.name, err)
+			continue
+		}
+		encoded := fromBase58.(*provautil.AddressProva).EncodeBech32m()
+		if encoded != want {
+			t.Errorf("%v: got bech32m address %v from base58, want %v",
+				test.name, encoded, want)
+		}
+	}
+}
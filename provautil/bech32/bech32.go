@@ -0,0 +1,198 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bech32
+
+import (
+	"errors"
+	"strings"
+)
+
+// charset is the alphabet of the data part of bech32 strings.
+const charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// Version identifies the checksum constant of a bech32 string.
+type Version int
+
+const (
+	// Bech32 is the original encoding specified in BIP 173.
+	Bech32 Version = iota
+
+	// Bech32m is the modified encoding specified in BIP 350.
+	Bech32m
+)
+
+// checksumConsts maps each encoding version to the constant its checksum is
+// combined with.
+var checksumConsts = map[Version]uint32{
+	Bech32:  1,
+	Bech32m: 0x2bc830a3,
+}
+
+var (
+	// ErrMixedCase indicates that a string mixes lowercase and uppercase
+	// characters.
+	ErrMixedCase = errors.New("string is mixed case")
+
+	// ErrInvalidFormat indicates that a string has no separator, an empty
+	// human-readable part or a data part too short for the checksum.
+	ErrInvalidFormat = errors.New("invalid format: separator, " +
+		"human-readable part or checksum missing")
+
+	// ErrInvalidCharacter indicates that a string holds a character which
+	// is not allowed in its human-readable or data part.
+	ErrInvalidCharacter = errors.New("invalid character")
+
+	// ErrChecksum indicates that the checksum of a string does not verify
+	// for either encoding.
+	ErrChecksum = errors.New("checksum error")
+
+	// ErrInvalidPadding indicates that converting groups of bits left
+	// incomplete or non-zero padding.
+	ErrInvalidPadding = errors.New("invalid padding")
+)
+
+// polymod computes the BCH checksum of the passed 5-bit values.
+func polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd,
+		0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+// hrpExpand expands the passed human-readable part for the checksum
+// computation.
+func hrpExpand(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	return expanded
+}
+
+// checksum returns the six 5-bit values of the checksum of the passed
+// human-readable part and data for the passed encoding version.
+func checksum(hrp string, data []byte, version Version) []byte {
+	values := append(hrpExpand(hrp), data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+	mod := polymod(values) ^ checksumConsts[version]
+	result := make([]byte, 6)
+	for i := range result {
+		result[i] = byte(mod>>uint(5*(5-i))) & 31
+	}
+	return result
+}
+
+// Encode encodes the passed 5-bit values with the passed human-readable part
+// using the passed encoding version.  The human-readable part is lowercased.
+func Encode(hrp string, data []byte, version Version) (string, error) {
+	if _, ok := checksumConsts[version]; !ok {
+		return "", errors.New("unknown bech32 version")
+	}
+	if len(hrp) == 0 {
+		return "", ErrInvalidFormat
+	}
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", ErrInvalidCharacter
+		}
+	}
+	for _, v := range data {
+		if v > 31 {
+			return "", ErrInvalidCharacter
+		}
+	}
+
+	hrp = strings.ToLower(hrp)
+	var sb strings.Builder
+	sb.Grow(len(hrp) + 1 + len(data) + 6)
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, v := range data {
+		sb.WriteByte(charset[v])
+	}
+	for _, v := range checksum(hrp, data, version) {
+		sb.WriteByte(charset[v])
+	}
+	return sb.String(), nil
+}
+
+// Decode decodes the passed bech32 or bech32m string and returns its lowercase
+// human-readable part, its 5-bit values without the checksum and the encoding
+// version its checksum verifies for.
+func Decode(s string) (string, []byte, Version, error) {
+	lower := strings.ToLower(s)
+	if lower != s && strings.ToUpper(s) != s {
+		return "", nil, 0, ErrMixedCase
+	}
+	sep := strings.LastIndexByte(lower, '1')
+	if sep < 1 || sep+7 > len(lower) {
+		return "", nil, 0, ErrInvalidFormat
+	}
+
+	hrp := lower[:sep]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, 0, ErrInvalidCharacter
+		}
+	}
+	data := make([]byte, 0, len(lower)-sep-1)
+	for i := sep + 1; i < len(lower); i++ {
+		v := strings.IndexByte(charset, lower[i])
+		if v < 0 {
+			return "", nil, 0, ErrInvalidCharacter
+		}
+		data = append(data, byte(v))
+	}
+
+	mod := polymod(append(hrpExpand(hrp), data...))
+	for version, c := range checksumConsts {
+		if mod == c {
+			return hrp, data[:len(data)-6], version, nil
+		}
+	}
+	return "", nil, 0, ErrChecksum
+}
+
+// ConvertBits regroups the bits of the passed values from groups of fromBits
+// to groups of toBits.  When pad is true, the last group is padded with zero
+// bits, otherwise incomplete or non-zero padding is an error.
+func ConvertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	var acc, bits uint
+	maxValue := uint(1)<<toBits - 1
+	result := make([]byte, 0, (len(data)*int(fromBits)+int(toBits)-1)/
+		int(toBits))
+	for _, v := range data {
+		if uint(v)>>fromBits != 0 {
+			return nil, ErrInvalidCharacter
+		}
+		acc = acc<<fromBits | uint(v)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			result = append(result, byte(acc>>bits&maxValue))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			result = append(result, byte(acc<<(toBits-bits)&maxValue))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxValue != 0 {
+		return nil, ErrInvalidPadding
+	}
+	return result, nil
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bech32_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/provautil/bech32"
+)
+
+// TestDecode ensures the test vectors of BIP 173 and BIP 350 decode with the
+// expected encoding version and encode back to the same string.
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		s       string
+		version bech32.Version
+		err     error
+	}{
+		{"A12UEL5L", bech32.Bech32, nil},
+		{"a12uel5l", bech32.Bech32, nil},
+		{"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", bech32.Bech32, nil},
+		{"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w", bech32.Bech32, nil},
+		{"?1ezyfcl", bech32.Bech32, nil},
+		{"A1LQFN3A", bech32.Bech32m, nil},
+		{"a1lqfn3a", bech32.Bech32m, nil},
+		{"abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx", bech32.Bech32m, nil},
+		{"split1checkupstagehandshakeupstreamerranterredcaperredlc445v", bech32.Bech32m, nil},
+		{"?1v759aa", bech32.Bech32m, nil},
+		{"A1G7SGD8", 0, bech32.ErrChecksum},
+		{"a12uel5m", 0, bech32.ErrChecksum},
+		{"A12uEL5L", 0, bech32.ErrMixedCase},
+		{"pzry9x0s0muk", 0, bech32.ErrInvalidFormat},
+		{"1pzry9x0s0muk", 0, bech32.ErrInvalidFormat},
+		{"li1dgmt3", 0, bech32.ErrInvalidFormat},
+		{"x1b4n0q5v", 0, bech32.ErrInvalidCharacter},
+		{"\x201nwldj5", 0, bech32.ErrInvalidCharacter},
+	}
+
+	for _, test := range tests {
+		hrp, data, version, err := bech32.Decode(test.s)
+		if err != test.err {
+			t.Errorf("Decode(%q): got error %v, want %v", test.s, err,
+				test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if version != test.version {
+			t.Errorf("Decode(%q): got version %d, want %d", test.s,
+				version, test.version)
+			continue
+		}
+		encoded, err := bech32.Encode(hrp, data, version)
+		if err != nil {
+			t.Errorf("Encode(%q): %v", test.s, err)
+			continue
+		}
+		if encoded != strings.ToLower(test.s) {
+			t.Errorf("Encode(%q): got %q", test.s, encoded)
+		}
+	}
+}
+
+// TestConvertBits ensures bytes converted to 5-bit groups convert back to the
+// same bytes, and that invalid padding is rejected.
+func TestConvertBits(t *testing.T) {
+	data := []byte{0x00, 0x01, 0x7f, 0x80, 0xff, 0x12, 0x34}
+	grouped, err := bech32.ConvertBits(data, 8, 5, true)
+	if err != nil {
+		t.Fatalf("ConvertBits: %v", err)
+	}
+	regrouped, err := bech32.ConvertBits(grouped, 5, 8, false)
+	if err != nil {
+		t.Fatalf("ConvertBits: %v", err)
+	}
+	if !bytes.Equal(regrouped, data) {
+		t.Fatalf("got %x, want %x", regrouped, data)
+	}
+
+	grouped[len(grouped)-1] |= 1
+	if _, err := bech32.ConvertBits(grouped, 5, 8, false); err != bech32.ErrInvalidPadding {
+		t.Fatalf("got error %v, want %v", err, bech32.ErrInvalidPadding)
+	}
+}
@@ -0,0 +1,28 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package bech32 provides an API for working with the bech32 and bech32m
+encodings specified in BIP 173 and BIP 350.
+
+Bech32 Encoding
+
+A bech32 string is made of a human-readable part, which identifies what the
+string is for, the separator 1 and the data part, which holds the encoded data
+as 5-bit groups followed by a six character checksum.  The data part only uses
+a 32 character alphabet which excludes the 1, b, i and o characters, and the
+whole string is either lowercase or uppercase, which makes it easy to read out
+and type.  The checksum is a BCH code guaranteeing the detection of up to four
+errors in strings of up to 90 characters.
+
+The bech32m encoding only differs from bech32 by the constant its checksum is
+combined with, which fixes a weakness of bech32 to the insertion or deletion of
+q characters before a final p.  Decode reports which of the two encodings a
+string uses.
+
+Unlike BIP 173, the length of the strings is not limited to 90 characters, so
+longer payloads such as Prova addresses with many key ids can be encoded.  The
+checksum still detects errors in longer strings with very high probability.
+*/
+package bech32
@@ -0,0 +1,285 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package canonjson
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// TxIn is the canonical JSON form of a transaction input.
+type TxIn struct {
+	TxID      string `json:"txid"`
+	Vout      uint32 `json:"vout"`
+	ScriptSig string `json:"scriptsig"`
+	Sequence  uint32 `json:"sequence"`
+}
+
+// TxOut is the canonical JSON form of a transaction output.  The script class,
+// the addresses and the admin operation are decoded from the public key script
+// and are not used when decoding.
+type TxOut struct {
+	Value        int64    `json:"value"`
+	ScriptPubKey string   `json:"scriptpubkey"`
+	Type         string   `json:"type"`
+	Addresses    []string `json:"addresses,omitempty"`
+	AdminOp      string   `json:"adminop,omitempty"`
+}
+
+// Tx is the canonical JSON form of a transaction.  TxID is the hash of the
+// transaction without its signature scripts and Hash the hash including them.
+// The thread id of admin transactions, and the size, are decoded from the
+// transaction and are not used when decoding.
+type Tx struct {
+	TxID     string  `json:"txid"`
+	Hash     string  `json:"hash"`
+	Version  int32   `json:"version"`
+	LockTime uint32  `json:"locktime"`
+	Size     int     `json:"size"`
+	ThreadID *uint32 `json:"threadid,omitempty"`
+	Vin      []TxIn  `json:"vin"`
+	Vout     []TxOut `json:"vout"`
+}
+
+// Block is the canonical JSON form of a block, made of the fields of its header
+// followed by its transactions.
+type Block struct {
+	Hash             string `json:"hash"`
+	Version          uint32 `json:"version"`
+	PrevBlock        string `json:"prevblock"`
+	MerkleRoot       string `json:"merkleroot"`
+	Time             int64  `json:"time"`
+	Bits             uint32 `json:"bits"`
+	Height           uint32 `json:"height"`
+	Size             uint32 `json:"size"`
+	Nonce            uint64 `json:"nonce"`
+	ValidatingPubKey string `json:"validatingpubkey"`
+	Signature        string `json:"signature"`
+	Tx               []Tx   `json:"tx"`
+}
+
+// NewTx returns the canonical JSON form of the passed transaction.  The
+// addresses of the outputs are encoded for the passed network.
+func NewTx(msgTx *wire.MsgTx, params *chaincfg.Params) *Tx {
+	txHash := msgTx.TxHash()
+	txHashWithSig := msgTx.TxHashWithSig()
+	tx := &Tx{
+		TxID:     txHash.String(),
+		Hash:     txHashWithSig.String(),
+		Version:  msgTx.Version,
+		LockTime: msgTx.LockTime,
+		Size:     msgTx.SerializeSize(),
+		Vin:      make([]TxIn, 0, len(msgTx.TxIn)),
+		Vout:     make([]TxOut, 0, len(msgTx.TxOut)),
+	}
+
+	// Only the outputs following the thread output of root and provision
+	// thread transactions are admin operations, since the outputs of issue
+	// thread transactions issue or destroy coins.
+	threadInt, _ := txscript.GetAdminDetailsMsgTx(msgTx)
+	threadID := provautil.ThreadID(threadInt)
+	if threadInt >= 0 {
+		id := uint32(threadID)
+		tx.ThreadID = &id
+	}
+	hasAdminOps := threadInt >= 0 && threadID != provautil.IssueThread
+
+	for _, txIn := range msgTx.TxIn {
+		tx.Vin = append(tx.Vin, TxIn{
+			TxID:      txIn.PreviousOutPoint.Hash.String(),
+			Vout:      txIn.PreviousOutPoint.Index,
+			ScriptSig: hex.EncodeToString(txIn.SignatureScript),
+			Sequence:  txIn.Sequence,
+		})
+	}
+	for i, txOut := range msgTx.TxOut {
+		// Ignore the error since a script which does not parse is
+		// simply of the nonstandard class, without addresses.
+		class, addrs, _, _ := txscript.ExtractPkScriptAddrs(
+			txOut.PkScript, params)
+		out := TxOut{
+			Value:        txOut.Value,
+			ScriptPubKey: hex.EncodeToString(txOut.PkScript),
+			Type:         class.String(),
+		}
+		for _, addr := range addrs {
+			out.Addresses = append(out.Addresses, addr.EncodeAddress())
+		}
+		if hasAdminOps && i > 0 {
+			out.AdminOp = txscript.AdminOpString(txOut.PkScript)
+		}
+		tx.Vout = append(tx.Vout, out)
+	}
+	return tx
+}
+
+// MsgTx returns the transaction in the canonical JSON form.  An error is
+// returned when a field is malformed or when the hashes do not match the
+// transaction.
+func (tx *Tx) MsgTx() (*wire.MsgTx, error) {
+	msgTx := wire.NewMsgTx(tx.Version)
+	msgTx.LockTime = tx.LockTime
+	for i, in := range tx.Vin {
+		prevHash, err := chainhash.NewHashFromStr(in.TxID)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: malformed txid: %v",
+				i, err)
+		}
+		sigScript, err := hex.DecodeString(in.ScriptSig)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: malformed scriptsig: "+
+				"%v", i, err)
+		}
+		prevOut := wire.NewOutPoint(prevHash, in.Vout)
+		txIn := wire.NewTxIn(prevOut, sigScript)
+		txIn.Sequence = in.Sequence
+		msgTx.AddTxIn(txIn)
+	}
+	for i, out := range tx.Vout {
+		pkScript, err := hex.DecodeString(out.ScriptPubKey)
+		if err != nil {
+			return nil, fmt.Errorf("output %d: malformed "+
+				"scriptpubkey: %v", i, err)
+		}
+		msgTx.AddTxOut(wire.NewTxOut(out.Value, pkScript))
+	}
+
+	if txHash := msgTx.TxHash(); txHash.String() != tx.TxID {
+		return nil, fmt.Errorf("txid %s does not match transaction %v",
+			tx.TxID, txHash)
+	}
+	if hash := msgTx.TxHashWithSig(); hash.String() != tx.Hash {
+		return nil, fmt.Errorf("hash %s does not match transaction %v",
+			tx.Hash, hash)
+	}
+	return msgTx, nil
+}
+
+// NewBlock returns the canonical JSON form of the passed block.  The addresses
+// of the outputs are encoded for the passed network.
+func NewBlock(msgBlock *wire.MsgBlock, params *chaincfg.Params) *Block {
+	header := &msgBlock.Header
+	blockHash := header.BlockHash()
+	block := &Block{
+		Hash:             blockHash.String(),
+		Version:          header.Version,
+		PrevBlock:        header.PrevBlock.String(),
+		MerkleRoot:       header.MerkleRoot.String(),
+		Time:             header.Timestamp.Unix(),
+		Bits:             header.Bits,
+		Height:           header.Height,
+		Size:             header.Size,
+		Nonce:            header.Nonce,
+		ValidatingPubKey: hex.EncodeToString(header.ValidatingPubKey[:]),
+		Signature:        hex.EncodeToString(header.Signature[:]),
+		Tx:               make([]Tx, 0, len(msgBlock.Transactions)),
+	}
+	for _, msgTx := range msgBlock.Transactions {
+		block.Tx = append(block.Tx, *NewTx(msgTx, params))
+	}
+	return block
+}
+
+// MsgBlock returns the block in the canonical JSON form.  An error is returned
+// when a field is malformed or when the hashes do not match the block or its
+// transactions.
+func (b *Block) MsgBlock() (*wire.MsgBlock, error) {
+	prevBlock, err := chainhash.NewHashFromStr(b.PrevBlock)
+	if err != nil {
+		return nil, fmt.Errorf("malformed prevblock: %v", err)
+	}
+	merkleRoot, err := chainhash.NewHashFromStr(b.MerkleRoot)
+	if err != nil {
+		return nil, fmt.Errorf("malformed merkleroot: %v", err)
+	}
+	header := wire.BlockHeader{
+		Version:    b.Version,
+		PrevBlock:  *prevBlock,
+		MerkleRoot: *merkleRoot,
+		Timestamp:  time.Unix(b.Time, 0),
+		Bits:       b.Bits,
+		Height:     b.Height,
+		Size:       b.Size,
+		Nonce:      b.Nonce,
+	}
+	err = decodeHexArray(header.ValidatingPubKey[:], b.ValidatingPubKey)
+	if err != nil {
+		return nil, fmt.Errorf("malformed validatingpubkey: %v", err)
+	}
+	if err := decodeHexArray(header.Signature[:], b.Signature); err != nil {
+		return nil, fmt.Errorf("malformed signature: %v", err)
+	}
+	if blockHash := header.BlockHash(); blockHash.String() != b.Hash {
+		return nil, fmt.Errorf("hash %s does not match block %v", b.Hash,
+			blockHash)
+	}
+
+	msgBlock := wire.NewMsgBlock(&header)
+	for i := range b.Tx {
+		msgTx, err := b.Tx[i].MsgTx()
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %v", i, err)
+		}
+		msgBlock.AddTransaction(msgTx)
+	}
+	return msgBlock, nil
+}
+
+// decodeHexArray decodes the passed hex string into the passed fixed size
+// array, and returns an error when it is not of the size of the array.
+func decodeHexArray(dst []byte, s string) error {
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	if len(decoded) != len(dst) {
+		return fmt.Errorf("got %d bytes, want %d", len(decoded),
+			len(dst))
+	}
+	copy(dst, decoded)
+	return nil
+}
+
+// TxToJSON returns the canonical JSON serialization of the passed transaction.
+// The addresses of the outputs are encoded for the passed network.
+func TxToJSON(msgTx *wire.MsgTx, params *chaincfg.Params) ([]byte, error) {
+	return json.Marshal(NewTx(msgTx, params))
+}
+
+// TxFromJSON decodes the passed canonical JSON serialization of a transaction.
+// An error is returned when it is malformed or when its hashes do not match the
+// transaction.
+func TxFromJSON(data []byte) (*wire.MsgTx, error) {
+	var tx Tx
+	if err := json.Unmarshal(data, &tx); err != nil {
+		return nil, err
+	}
+	return tx.MsgTx()
+}
+
+// BlockToJSON returns the canonical JSON serialization of the passed block.
+// The addresses of the outputs are encoded for the passed network.
+func BlockToJSON(msgBlock *wire.MsgBlock, params *chaincfg.Params) ([]byte, error) {
+	return json.Marshal(NewBlock(msgBlock, params))
+}
+
+// BlockFromJSON decodes the passed canonical JSON serialization of a block.  An
+// error is returned when it is malformed or when the hashes do not match the
+// block or its transactions.
+func BlockFromJSON(data []byte) (*wire.MsgBlock, error) {
+	var block Block
+	if err := json.Unmarshal(data, &block); err != nil {
+		return nil, err
+	}
+	return block.MsgBlock()
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package canonjson_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/provautil/canonjson"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// TestTxJSON ensures a transaction is serialized to the expected canonical
+// JSON, and decoded back to the same transaction.
+func TestTxJSON(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	pkHash := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+		16, 17, 18, 19, 20}
+	addr, err := provautil.NewAddressProva(pkHash, []btcec.KeyID{1, 2},
+		params)
+	if err != nil {
+		t.Fatalf("NewAddressProva: %v", err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("PayToAddrScript: %v", err)
+	}
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	msgTx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: 1},
+		SignatureScript:  []byte{0x51},
+		Sequence:         wire.MaxTxInSequenceNum,
+	})
+	msgTx.AddTxOut(wire.NewTxOut(1000, pkScript))
+
+	serialized, err := canonjson.TxToJSON(msgTx, params)
+	if err != nil {
+		t.Fatalf("TxToJSON: %v", err)
+	}
+	txHash := msgTx.TxHash()
+	hash := msgTx.TxHashWithSig()
+	want := `{"txid":"` + txHash.String() + `","hash":"` + hash.String() +
+		`","version":1,"locktime":0,"size":87,"vin":[{"txid":"` +
+		strings.Repeat("0", 64) + `","vout":1,` +
+		`"scriptsig":"51","sequence":4294967295}],"vout":[{"value":1000,` +
+		`"scriptpubkey":"5214` + "0102030405060708090a0b0c0d0e0f1011121314" +
+		`515253ba","type":"safe_multisig","addresses":["` +
+		addr.EncodeAddress() + `"]}]}`
+	if string(serialized) != want {
+		t.Fatalf("got serialization %s, want %s", serialized, want)
+	}
+
+	decoded, err := canonjson.TxFromJSON(serialized)
+	if err != nil {
+		t.Fatalf("TxFromJSON: %v", err)
+	}
+	if decoded.TxHashWithSig() != hash {
+		t.Fatalf("decoded transaction %v, want %v",
+			decoded.TxHashWithSig(), hash)
+	}
+}
+
+// TestBlockJSON ensures a block is serialized to canonical JSON along with the
+// admin operations of its admin transactions, decoded back to the same block,
+// and that altered serializations are rejected.
+func TestBlockJSON(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("NewPrivateKey: %v", err)
+	}
+	opData := append([]byte{txscript.AdminOpProvisionKeyAdd},
+		privKey.PubKey().SerializeCompressed()...)
+	opScript, err := txscript.NullDataScript(opData)
+	if err != nil {
+		t.Fatalf("NullDataScript: %v", err)
+	}
+	threadScript, err := txscript.ProvaThreadScript(provautil.RootThread)
+	if err != nil {
+		t.Fatalf("ProvaThreadScript: %v", err)
+	}
+	adminTx := wire.NewMsgTx(wire.TxVersion)
+	adminTx.AddTxIn(&wire.TxIn{})
+	adminTx.AddTxOut(wire.NewTxOut(0, threadScript))
+	adminTx.AddTxOut(wire.NewTxOut(0, opScript))
+
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: wire.MaxPrevOutIndex},
+	})
+	coinbase.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_TRUE}))
+	msgBlock := wire.NewMsgBlock(&wire.BlockHeader{
+		Version:   4,
+		Timestamp: time.Unix(1546300800, 0),
+		Height:    1,
+		Nonce:     1 << 40,
+	})
+	msgBlock.AddTransaction(coinbase)
+	msgBlock.AddTransaction(adminTx)
+	if err := msgBlock.Header.Sign(privKey); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	block := canonjson.NewBlock(msgBlock, params)
+	adminJSON := block.Tx[1]
+	if adminJSON.ThreadID == nil || *adminJSON.ThreadID != 0 {
+		t.Fatalf("admin transaction is not of the root thread")
+	}
+	wantOp := txscript.AdminOpString(opScript)
+	if adminJSON.Vout[0].AdminOp != "" || adminJSON.Vout[1].AdminOp != wantOp {
+		t.Fatalf("got admin operations %q and %q, want none and %q",
+			adminJSON.Vout[0].AdminOp, adminJSON.Vout[1].AdminOp, wantOp)
+	}
+	if block.Tx[0].ThreadID != nil {
+		t.Fatalf("coinbase has a thread id")
+	}
+
+	// The serialization is stable, and the block decoded from it is
+	// serialized to the same bytes.
+	serialized, err := canonjson.BlockToJSON(msgBlock, params)
+	if err != nil {
+		t.Fatalf("BlockToJSON: %v", err)
+	}
+	decoded, err := canonjson.BlockFromJSON(serialized)
+	if err != nil {
+		t.Fatalf("BlockFromJSON: %v", err)
+	}
+	if decoded.BlockHash() != msgBlock.BlockHash() {
+		t.Fatalf("decoded block %v, want %v", decoded.BlockHash(),
+			msgBlock.BlockHash())
+	}
+	reserialized, err := canonjson.BlockToJSON(decoded, params)
+	if err != nil {
+		t.Fatalf("BlockToJSON: %v", err)
+	}
+	if !bytes.Equal(serialized, reserialized) {
+		t.Fatalf("got serialization %s after decoding, want %s",
+			reserialized, serialized)
+	}
+
+	// Altering the header, a transaction or a signature script is
+	// detected by the hashes.
+	tests := []struct {
+		name  string
+		alter func(b *canonjson.Block)
+	}{
+		{
+			name:  "altered time",
+			alter: func(b *canonjson.Block) { b.Time++ },
+		},
+		{
+			name:  "altered output value",
+			alter: func(b *canonjson.Block) { b.Tx[1].Vout[1].Value = 1 },
+		},
+		{
+			name:  "altered signature script",
+			alter: func(b *canonjson.Block) { b.Tx[1].Vin[0].ScriptSig = "00" },
+		},
+		{
+			name:  "malformed signature",
+			alter: func(b *canonjson.Block) { b.Signature = "00" },
+		},
+	}
+	for _, test := range tests {
+		altered := canonjson.NewBlock(msgBlock, params)
+		test.alter(altered)
+		if _, err := altered.MsgBlock(); err == nil {
+			t.Errorf("%s: decoding succeeded", test.name)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package canonjson provides a canonical JSON serialization of Prova blocks and
+transactions, so services exchanging them out-of-band agree on a single format.
+
+Canonical Form
+
+The serialization of a block or transaction is always the same sequence of
+bytes: the fields are written in a fixed order without whitespace, scripts,
+hashes, public keys and signatures are lowercase hex strings, hashes are in the
+byte order displayed by the RPC server, amounts are integer atoms and times are
+Unix timestamps.
+
+The serialization holds every field of the wire encoding, so TxFromJSON and
+BlockFromJSON restore the exact block or transaction.  It also holds fields
+decoded from them, such as the script classes and addresses of the outputs and
+the admin operations of admin transactions, which are written for the readers
+but ignored when decoding.  The hashes in the serialization are checked against
+the hashes of the decoded block and transactions, which detects data altered or
+mangled in transit.
+*/
+package canonjson
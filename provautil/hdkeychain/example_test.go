@@ -9,6 +9,7 @@ package hdkeychain_test
 import (
 	"fmt"
 
+	"github.com/pyx-partners/dmgd/btcec"
 	"github.com/pyx-partners/dmgd/chaincfg"
 	"github.com/pyx-partners/dmgd/provautil/hdkeychain"
 )
@@ -118,14 +119,15 @@ func Example_defaultWalletLayout() {
 		return
 	}
 
-	// Get and show the address associated with the extended keys for the
-	// main bitcoin	network.
-	acct0ExtAddr, err := acct0Ext10.Address(&chaincfg.MainNetParams)
+	// Get and show the Prova address with key ids 1 and 2 associated with
+	// the extended keys for the main network.
+	keyIDs := []btcec.KeyID{1, 2}
+	acct0ExtAddr, err := acct0Ext10.Address(keyIDs, &chaincfg.MainNetParams)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
-	acct0IntAddr, err := acct0Int0.Address(&chaincfg.MainNetParams)
+	acct0IntAddr, err := acct0Int0.Address(keyIDs, &chaincfg.MainNetParams)
 	if err != nil {
 		fmt.Println(err)
 		return
@@ -134,8 +136,8 @@ func Example_defaultWalletLayout() {
 	fmt.Println("Account 0 Internal Address 0:", acct0IntAddr)
 
 	// Output:
-	// Account 0 External Address 10: 1HVccubUT8iKTapMJ5AnNA4sLRN27xzQ4F
-	// Account 0 Internal Address 0: 1J5rebbkQaunJTUoNVREDbeB49DqMNFFXk
+	// Account 0 External Address 10: GMtPUGYjeDHQ2d2kP24mniwrJete49cN5omgpF3Bv7UYN
+	// Account 0 Internal Address 0: GNKfggyAPKbi311nkyH2ZJry1hjdpQhu8xRJ6ifnFAph3
 }
 
 // This example demonstrates the audits use case in BIP0032.
@@ -354,11 +354,11 @@ func (k *ExtendedKey) ECPrivKey() (*btcec.PrivateKey, error) {
 	return privKey, nil
 }
 
-// Address converts the extended key to a standard bitcoin pay-to-pubkey-hash
-// address for the passed network.
-func (k *ExtendedKey) Address(net *chaincfg.Params) (*provautil.AddressPubKeyHash, error) {
+// Address converts the extended key to a standard Prova address with the
+// passed key ids for the passed network.
+func (k *ExtendedKey) Address(keyIDs []btcec.KeyID, net *chaincfg.Params) (*provautil.AddressProva, error) {
 	pkHash := provautil.Hash160(k.pubKeyBytes())
-	return provautil.NewAddressPubKeyHash(pkHash, net)
+	return provautil.NewAddressProva(pkHash, keyIDs, net)
 }
 
 // paddedAppend appends the src byte slice to dst, returning the new slice.
@@ -0,0 +1,31 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package reserve provides proof-of-reserves attestations for DMG.
+
+Overview
+
+An attestation lists the unspent outputs an issuer controls at a block of the
+chain, together with the transactions creating them and merkle proofs which
+tie those transactions to the blocks they were mined in.  The issuer signs the
+attestation with a key published for this purpose, so auditors can compare the
+attested total against the outstanding supply at that block.
+
+Verification checks the signature and that every output is created by a
+transaction mined in a block at or below the attested block.  A merkle proof
+can not show that an output is still unspent, so auditors should check the
+attested outpoints against the unspent transaction output set of the attested
+block, for example with the gettxout RPC of a node whose best block it is.
+
+Merkle Proofs
+
+The merkle tree of a Prova block commits to both the hashes of its
+transactions without signatures and with signatures.  Proofs are built for the
+leaf of the transaction hash without signatures, which is the hash outpoints
+reference, and the sibling on each level of the tree is included in the proof.
+Where a level has no sibling, the node itself is included since the tree
+hashes such nodes with themselves.
+*/
+package reserve
@@ -0,0 +1,375 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package reserve
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+const (
+	// maxProofs is the maximum number of output proofs an attestation
+	// can hold.  It limits memory use when deserializing attestations.
+	maxProofs = 1000000
+
+	// maxBranchLen is the maximum number of hashes in a merkle proof.
+	// Blocks hold at most 2^32 leaves.
+	maxBranchLen = 32
+
+	// maxSignatureLen is the maximum length of a DER encoded signature.
+	maxSignatureLen = 72
+)
+
+// OutputProof proves that an output was created by a transaction mined in a
+// block of the chain.
+type OutputProof struct {
+	// Tx is the transaction creating the output.
+	Tx *wire.MsgTx
+
+	// OutputIndex is the index of the output in the transaction.
+	OutputIndex uint32
+
+	// BlockHash is the hash of the block the transaction was mined in.
+	BlockHash chainhash.Hash
+
+	// TxIndex is the index of the transaction in the block.
+	TxIndex uint32
+
+	// MerkleBranch holds the hashes needed to compute the merkle root of
+	// the block from the hash of the transaction, starting at the leaves.
+	MerkleBranch []chainhash.Hash
+}
+
+// NewOutputProof returns a proof for the output at the passed index of the
+// transaction at the passed index of the block.
+func NewOutputProof(block *provautil.Block, txIndex int, outputIndex uint32) (*OutputProof, error) {
+	transactions := block.Transactions()
+	if txIndex < 0 || txIndex >= len(transactions) {
+		return nil, fmt.Errorf("transaction index %d is out of range",
+			txIndex)
+	}
+	msgTx := transactions[txIndex].MsgTx()
+	if outputIndex >= uint32(len(msgTx.TxOut)) {
+		return nil, fmt.Errorf("output index %d is out of range",
+			outputIndex)
+	}
+
+	// The merkle store holds the leaves of the transaction hashes followed
+	// by those with signatures, and then each level of the tree up to the
+	// root.
+	merkles := blockchain.BuildMerkleTreeStore(transactions)
+	var branch []chainhash.Hash
+	index := txIndex
+	offset := 0
+	for levelSize := (len(merkles) + 1) / 2; levelSize > 1; levelSize /= 2 {
+		sibling := merkles[offset+(index^1)]
+		if sibling == nil {
+			sibling = merkles[offset+index]
+		}
+		branch = append(branch, *sibling)
+		index /= 2
+		offset += levelSize
+	}
+
+	return &OutputProof{
+		Tx:           msgTx,
+		OutputIndex:  outputIndex,
+		BlockHash:    *block.Hash(),
+		TxIndex:      uint32(txIndex),
+		MerkleBranch: branch,
+	}, nil
+}
+
+// OutPoint returns the outpoint of the proven output.
+func (p *OutputProof) OutPoint() wire.OutPoint {
+	return wire.OutPoint{Hash: p.Tx.TxHash(), Index: p.OutputIndex}
+}
+
+// Value returns the value of the proven output in atoms.
+func (p *OutputProof) Value() int64 {
+	if p.OutputIndex >= uint32(len(p.Tx.TxOut)) {
+		return 0
+	}
+	return p.Tx.TxOut[p.OutputIndex].Value
+}
+
+// MerkleRoot returns the merkle root computed from the transaction hash and
+// the merkle branch of the proof.
+func (p *OutputProof) MerkleRoot() chainhash.Hash {
+	hash := p.Tx.TxHash()
+	root := &hash
+	index := p.TxIndex
+	for i := range p.MerkleBranch {
+		if index&1 == 0 {
+			root = blockchain.HashMerkleBranches(root, &p.MerkleBranch[i])
+		} else {
+			root = blockchain.HashMerkleBranches(&p.MerkleBranch[i], root)
+		}
+		index >>= 1
+	}
+	return *root
+}
+
+// Attestation is a signed statement of the unspent outputs controlled by the
+// signer at a block of the chain.
+type Attestation struct {
+	// BlockHash and BlockHeight identify the block the attestation was
+	// made at.
+	BlockHash   chainhash.Hash
+	BlockHeight uint32
+
+	// Proofs holds a proof for each attested output.
+	Proofs []*OutputProof
+
+	// PubKey and Signature are set once the attestation is signed.
+	PubKey    *btcec.PublicKey
+	Signature *btcec.Signature
+}
+
+// Total returns the sum of the values of all attested outputs in atoms.
+func (a *Attestation) Total() int64 {
+	var total int64
+	for _, proof := range a.Proofs {
+		total += proof.Value()
+	}
+	return total
+}
+
+// SigHash returns the hash the attestation is signed over.  It commits to all
+// fields but the public key and signature.
+func (a *Attestation) SigHash() (chainhash.Hash, error) {
+	var buf bytes.Buffer
+	if err := a.serializeUnsigned(&buf); err != nil {
+		return chainhash.Hash{}, err
+	}
+	return chainhash.DoubleHashH(buf.Bytes()), nil
+}
+
+// Sign signs the attestation with the passed private key.
+func (a *Attestation) Sign(privKey *btcec.PrivateKey) error {
+	sigHash, err := a.SigHash()
+	if err != nil {
+		return err
+	}
+	signature, err := privKey.Sign(sigHash[:])
+	if err != nil {
+		return err
+	}
+	a.PubKey = privKey.PubKey()
+	a.Signature = signature
+	return nil
+}
+
+// VerifySignature returns an error if the attestation is not signed or the
+// signature is invalid.
+func (a *Attestation) VerifySignature() error {
+	if a.PubKey == nil || a.Signature == nil {
+		return errors.New("attestation is not signed")
+	}
+	sigHash, err := a.SigHash()
+	if err != nil {
+		return err
+	}
+	if !a.Signature.Verify(sigHash[:], a.PubKey) {
+		return errors.New("attestation signature is invalid")
+	}
+	return nil
+}
+
+// HeaderFetcher returns the header of the block with the passed hash in the
+// chain used to verify an attestation, or an error when there is none.
+type HeaderFetcher func(hash *chainhash.Hash) (wire.BlockHeader, error)
+
+// Verify returns an error if the attestation is not validly signed, or if any
+// proof does not tie its output to a block at or below the attested block.
+// Verification can not establish the outputs are still unspent at the
+// attested block.
+func (a *Attestation) Verify(fetchHeader HeaderFetcher) error {
+	if err := a.VerifySignature(); err != nil {
+		return err
+	}
+
+	header, err := fetchHeader(&a.BlockHash)
+	if err != nil {
+		return fmt.Errorf("attested block %v: %v", a.BlockHash, err)
+	}
+	if header.Height != a.BlockHeight {
+		return fmt.Errorf("attested block %v is at height %d, not %d",
+			a.BlockHash, header.Height, a.BlockHeight)
+	}
+
+	seen := make(map[wire.OutPoint]struct{}, len(a.Proofs))
+	for i, proof := range a.Proofs {
+		outPoint := proof.OutPoint()
+		if proof.OutputIndex >= uint32(len(proof.Tx.TxOut)) {
+			return fmt.Errorf("proof %d: output %v does not exist",
+				i, outPoint)
+		}
+		if _, ok := seen[outPoint]; ok {
+			return fmt.Errorf("proof %d: output %v is attested "+
+				"more than once", i, outPoint)
+		}
+		seen[outPoint] = struct{}{}
+
+		header, err := fetchHeader(&proof.BlockHash)
+		if err != nil {
+			return fmt.Errorf("proof %d: block %v: %v", i,
+				proof.BlockHash, err)
+		}
+		if header.Height > a.BlockHeight {
+			return fmt.Errorf("proof %d: block %v is above the "+
+				"attested block", i, proof.BlockHash)
+		}
+		if proof.MerkleRoot() != header.MerkleRoot {
+			return fmt.Errorf("proof %d: merkle proof of output %v "+
+				"does not match block %v", i, outPoint,
+				proof.BlockHash)
+		}
+	}
+	return nil
+}
+
+// serializeUnsigned writes all fields but the public key and signature of the
+// attestation to w.
+func (a *Attestation) serializeUnsigned(w io.Writer) error {
+	if _, err := w.Write(a.BlockHash[:]); err != nil {
+		return err
+	}
+	err := binary.Write(w, binary.LittleEndian, a.BlockHeight)
+	if err != nil {
+		return err
+	}
+	err = wire.WriteVarInt(w, 0, uint64(len(a.Proofs)))
+	if err != nil {
+		return err
+	}
+	for _, proof := range a.Proofs {
+		if err := proof.Tx.Serialize(w); err != nil {
+			return err
+		}
+		err := binary.Write(w, binary.LittleEndian, proof.OutputIndex)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(proof.BlockHash[:]); err != nil {
+			return err
+		}
+		err = binary.Write(w, binary.LittleEndian, proof.TxIndex)
+		if err != nil {
+			return err
+		}
+		err = wire.WriteVarInt(w, 0, uint64(len(proof.MerkleBranch)))
+		if err != nil {
+			return err
+		}
+		for i := range proof.MerkleBranch {
+			_, err := w.Write(proof.MerkleBranch[i][:])
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Serialize writes the attestation to w.  Unsigned attestations are
+// serialized with an empty public key and signature.
+func (a *Attestation) Serialize(w io.Writer) error {
+	if err := a.serializeUnsigned(w); err != nil {
+		return err
+	}
+	var pubKey, signature []byte
+	if a.PubKey != nil && a.Signature != nil {
+		pubKey = a.PubKey.SerializeCompressed()
+		signature = a.Signature.Serialize()
+	}
+	if err := wire.WriteVarBytes(w, 0, pubKey); err != nil {
+		return err
+	}
+	return wire.WriteVarBytes(w, 0, signature)
+}
+
+// Deserialize reads an attestation serialized with Serialize from r.
+func (a *Attestation) Deserialize(r io.Reader) error {
+	if _, err := io.ReadFull(r, a.BlockHash[:]); err != nil {
+		return err
+	}
+	err := binary.Read(r, binary.LittleEndian, &a.BlockHeight)
+	if err != nil {
+		return err
+	}
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return err
+	}
+	if count > maxProofs {
+		return fmt.Errorf("too many output proofs %d, max %d", count,
+			maxProofs)
+	}
+	a.Proofs = make([]*OutputProof, 0, count)
+	for i := uint64(0); i < count; i++ {
+		var proof OutputProof
+		proof.Tx = new(wire.MsgTx)
+		if err := proof.Tx.Deserialize(r); err != nil {
+			return err
+		}
+		err := binary.Read(r, binary.LittleEndian, &proof.OutputIndex)
+		if err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(r, proof.BlockHash[:]); err != nil {
+			return err
+		}
+		err = binary.Read(r, binary.LittleEndian, &proof.TxIndex)
+		if err != nil {
+			return err
+		}
+		branchLen, err := wire.ReadVarInt(r, 0)
+		if err != nil {
+			return err
+		}
+		if branchLen > maxBranchLen {
+			return fmt.Errorf("merkle branch of %d hashes exceeds "+
+				"max %d", branchLen, maxBranchLen)
+		}
+		proof.MerkleBranch = make([]chainhash.Hash, branchLen)
+		for j := range proof.MerkleBranch {
+			_, err := io.ReadFull(r, proof.MerkleBranch[j][:])
+			if err != nil {
+				return err
+			}
+		}
+		a.Proofs = append(a.Proofs, &proof)
+	}
+
+	pubKey, err := wire.ReadVarBytes(r, 0, btcec.PubKeyBytesLenCompressed,
+		"pubkey")
+	if err != nil {
+		return err
+	}
+	signature, err := wire.ReadVarBytes(r, 0, maxSignatureLen, "signature")
+	if err != nil {
+		return err
+	}
+	a.PubKey, a.Signature = nil, nil
+	if len(pubKey) == 0 && len(signature) == 0 {
+		return nil
+	}
+	a.PubKey, err = btcec.ParsePubKey(pubKey, btcec.S256())
+	if err != nil {
+		return err
+	}
+	a.Signature, err = btcec.ParseDERSignature(signature, btcec.S256())
+	return err
+}
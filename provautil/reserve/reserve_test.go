@@ -0,0 +1,233 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package reserve_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/provautil/reserve"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// makeBlock returns a block at the passed height holding the passed number of
+// transactions, with a merkle root committing to them.
+func makeBlock(height uint32, numTxns int) *provautil.Block {
+	msgBlock := wire.NewMsgBlock(&wire.BlockHeader{Height: height})
+	for i := 0; i < numTxns; i++ {
+		tx := wire.NewMsgTx(wire.TxVersion)
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: wire.OutPoint{Index: uint32(i)},
+			SignatureScript:  []byte{byte(height), byte(i)},
+		})
+		tx.AddTxOut(wire.NewTxOut(int64(1000*(i+1)), []byte{0x51}))
+		tx.AddTxOut(wire.NewTxOut(int64(i+1), []byte{0x52}))
+		msgBlock.AddTransaction(tx)
+	}
+	block := provautil.NewBlock(msgBlock)
+	merkles := blockchain.BuildMerkleTreeStore(block.Transactions())
+	msgBlock.Header.MerkleRoot = *merkles[len(merkles)-1]
+	return provautil.NewBlock(msgBlock)
+}
+
+// headerFetcher returns a reserve.HeaderFetcher looking up the passed blocks.
+func headerFetcher(blocks ...*provautil.Block) reserve.HeaderFetcher {
+	return func(hash *chainhash.Hash) (wire.BlockHeader, error) {
+		for _, block := range blocks {
+			if block.Hash().IsEqual(hash) {
+				return block.MsgBlock().Header, nil
+			}
+		}
+		return wire.BlockHeader{}, errors.New("block not found")
+	}
+}
+
+// TestOutputProof ensures output proofs rebuild the merkle root of their block
+// for all transaction counts and positions.
+func TestOutputProof(t *testing.T) {
+	for numTxns := 1; numTxns <= 9; numTxns++ {
+		block := makeBlock(1, numTxns)
+		merkleRoot := block.MsgBlock().Header.MerkleRoot
+		for i := 0; i < numTxns; i++ {
+			proof, err := reserve.NewOutputProof(block, i, 0)
+			if err != nil {
+				t.Fatalf("NewOutputProof(%d, %d): %v", numTxns, i,
+					err)
+			}
+			if got := proof.MerkleRoot(); got != merkleRoot {
+				t.Errorf("MerkleRoot(%d, %d): got %v, want %v",
+					numTxns, i, got, merkleRoot)
+			}
+		}
+	}
+
+	block := makeBlock(1, 2)
+	if _, err := reserve.NewOutputProof(block, 2, 0); err == nil {
+		t.Error("NewOutputProof: expected error for transaction index")
+	}
+	if _, err := reserve.NewOutputProof(block, 0, 2); err == nil {
+		t.Error("NewOutputProof: expected error for output index")
+	}
+}
+
+// TestAttestation ensures attestations are signed, serialized and verified
+// correctly, and that tampered attestations fail to verify.
+func TestAttestation(t *testing.T) {
+	block1 := makeBlock(1, 3)
+	block2 := makeBlock(2, 5)
+	tip := makeBlock(3, 1)
+	fetchHeader := headerFetcher(block1, block2, tip)
+
+	newAttestation := func() *reserve.Attestation {
+		attestation := &reserve.Attestation{
+			BlockHash:   *tip.Hash(),
+			BlockHeight: 3,
+		}
+		for _, p := range []struct {
+			block  *provautil.Block
+			txIdx  int
+			outIdx uint32
+		}{
+			{block1, 2, 0},
+			{block2, 0, 1},
+			{block2, 4, 0},
+		} {
+			proof, err := reserve.NewOutputProof(p.block, p.txIdx,
+				p.outIdx)
+			if err != nil {
+				t.Fatalf("NewOutputProof: %v", err)
+			}
+			attestation.Proofs = append(attestation.Proofs, proof)
+		}
+		return attestation
+	}
+
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("NewPrivateKey: %v", err)
+	}
+
+	attestation := newAttestation()
+	if got, want := attestation.Total(), int64(3000+1+5000); got != want {
+		t.Errorf("Total: got %d, want %d", got, want)
+	}
+	if err := attestation.Verify(fetchHeader); err == nil {
+		t.Error("Verify: expected error for unsigned attestation")
+	}
+
+	// Unsigned attestations round trip, so they can be produced by a node
+	// and signed elsewhere.
+	var buf bytes.Buffer
+	if err := attestation.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	var unsigned reserve.Attestation
+	if err := unsigned.Deserialize(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if unsigned.PubKey != nil || unsigned.Signature != nil {
+		t.Error("Deserialize: unexpected signature")
+	}
+	if err := unsigned.Sign(privKey); err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if err := unsigned.Verify(fetchHeader); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+
+	// Signed attestations round trip.
+	buf.Reset()
+	if err := unsigned.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	var signed reserve.Attestation
+	if err := signed.Deserialize(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("Deserialize: %v", err)
+	}
+	if err := signed.Verify(fetchHeader); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+	if !signed.PubKey.IsEqual(privKey.PubKey()) {
+		t.Error("Deserialize: public key mismatch")
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(a *reserve.Attestation)
+	}{
+		{
+			name: "changed output value",
+			tamper: func(a *reserve.Attestation) {
+				a.Proofs[0].Tx.TxOut[0].Value++
+			},
+		},
+		{
+			name: "changed height",
+			tamper: func(a *reserve.Attestation) {
+				a.BlockHeight = 2
+			},
+		},
+		{
+			name: "duplicate output",
+			tamper: func(a *reserve.Attestation) {
+				a.Proofs = append(a.Proofs, a.Proofs[0])
+			},
+		},
+		{
+			name: "wrong block",
+			tamper: func(a *reserve.Attestation) {
+				a.Proofs[0].BlockHash = *block2.Hash()
+			},
+		},
+		{
+			name: "output above attested block",
+			tamper: func(a *reserve.Attestation) {
+				a.BlockHash = *block1.Hash()
+				a.BlockHeight = 1
+			},
+		},
+		{
+			name: "missing output",
+			tamper: func(a *reserve.Attestation) {
+				a.Proofs[0].OutputIndex = 5
+			},
+		},
+		{
+			name: "wrong transaction index",
+			tamper: func(a *reserve.Attestation) {
+				a.Proofs[0].TxIndex = 1
+			},
+		},
+	}
+
+	for _, test := range tests {
+		// Tampering after signing must invalidate the signature.
+		attestation := newAttestation()
+		if err := attestation.Sign(privKey); err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		test.tamper(attestation)
+		if err := attestation.Verify(fetchHeader); err == nil {
+			t.Errorf("%s: expected error for tampered signed "+
+				"attestation", test.name)
+		}
+
+		// Tampering before signing must fail the proof checks.
+		attestation = newAttestation()
+		test.tamper(attestation)
+		if err := attestation.Sign(privKey); err != nil {
+			t.Fatalf("Sign: %v", err)
+		}
+		if err := attestation.Verify(fetchHeader); err == nil {
+			t.Errorf("%s: expected error for tampered attestation",
+				test.name)
+		}
+	}
+}
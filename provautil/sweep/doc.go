@@ -0,0 +1,31 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package sweep plans the transactions which move unspent outputs from one ASP
+key to another.
+
+Overview
+
+When an ASP rotates its key, every account output whose Prova script
+references the old keyID must be re-addressed to a script referencing the new
+keyID before the old key is revoked.  Given the unspent outputs referencing
+the old keyID, a plan holds unsigned transactions which spend them and pay
+each account, identified by its pkScript, a single output with the old keyID
+replaced by the new one.  The transactions still need to be signed by the
+account key and the new ASP key like any other Prova transaction.
+
+Outputs of each asset are swept by their own transactions, since a
+transaction can only spend outputs of the asset it pays.  Transactions are
+filled with accounts until they reach the maximum size, and an account whose
+outputs do not fit into a single transaction is split over several.
+
+Fees
+
+Every account pays the fee for its own inputs and output at the configured
+fee rate, and the first account of a transaction also pays for the fixed
+part of the transaction.  Accounts whose swept amount would be dust after the
+fee are left out of the plan and reported as skipped.
+*/
+package sweep
@@ -0,0 +1,302 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sweep
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/mempool"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+const (
+	// SignedInputSize is the estimated serialized size of a signed input
+	// spending a standard Prova output.
+	//
+	//   36 prev outpoint, 3 script len, 216 script [2x (1 OP_DATA_33,
+	//   33 compressed pubkey, 1 OP_DATA_73, 73 sig)], 4 sequence
+	SignedInputSize = 36 + 3 + 216 + 4
+
+	// txOverhead is the largest serialized size of the fixed part of a
+	// transaction.
+	//
+	//   4 version, 3 input count, 3 output count, 4 lock time
+	txOverhead = 4 + 3 + 3 + 4
+)
+
+var (
+	// ErrSameKeyID is returned when the old and new keyIDs of a plan are
+	// the same.
+	ErrSameKeyID = errors.New("old and new keyID are the same")
+
+	// ErrTxSizeTooSmall is returned when the maximum transaction size can
+	// not hold a single input and output.
+	ErrTxSizeTooSmall = errors.New("maximum transaction size too small " +
+		"to sweep an output")
+)
+
+// Output is an unspent output to sweep.
+type Output struct {
+	OutPoint wire.OutPoint
+	PkScript []byte
+	Amount   int64
+	AssetID  uint32
+}
+
+// Config holds the parameters of a sweep plan.
+type Config struct {
+	// OldKeyID is the keyID the swept outputs reference.
+	OldKeyID btcec.KeyID
+
+	// NewKeyID is the keyID replacing OldKeyID in the new outputs.
+	NewKeyID btcec.KeyID
+
+	// Params are the parameters of the network the outputs belong to.
+	Params *chaincfg.Params
+
+	// MaxTxSize is the maximum estimated serialized size of a signed
+	// transaction of the plan.
+	MaxTxSize int
+
+	// FeeRate is the fee paid per kilobyte of signed transaction.
+	FeeRate provautil.Amount
+
+	// DustRelayFee is the minimum relay fee used to decide whether a new
+	// output is dust.
+	DustRelayFee provautil.Amount
+}
+
+// Tx is an unsigned transaction of a sweep plan.
+type Tx struct {
+	// MsgTx is the unsigned transaction.
+	MsgTx *wire.MsgTx
+
+	// AssetID is the asset of the swept outputs.
+	AssetID uint32
+
+	// Inputs are the outputs spent by the transaction, in input order.
+	Inputs []Output
+
+	// Fee is the fee paid by the transaction.
+	Fee int64
+
+	// size is the estimated serialized size of the signed transaction.
+	size int
+}
+
+// Plan is a batch of transactions sweeping outputs to a new keyID.
+type Plan struct {
+	// Txs are the sweep transactions.
+	Txs []*Tx
+
+	// Skipped are the outputs left out of the plan, either because they
+	// are not standard Prova outputs referencing the old keyID or because
+	// their amount does not cover the fee of sweeping them.
+	Skipped []Output
+}
+
+// account holds the outputs paying to the same pkScript.
+type account struct {
+	assetID   uint32
+	pkScript  []byte
+	newScript []byte
+	outputs   []Output
+}
+
+// accountKey identifies an account of an asset.
+type accountKey struct {
+	assetID  uint32
+	pkScript string
+}
+
+// RekeyScript returns the standard Prova script paying to the same account
+// as the passed script with oldKeyID replaced by newKeyID.  An error is
+// returned if the script is not a standard Prova script referencing
+// oldKeyID.
+func RekeyScript(pkScript []byte, oldKeyID, newKeyID btcec.KeyID,
+	params *chaincfg.Params) ([]byte, error) {
+
+	if txscript.GetScriptClass(pkScript) != txscript.ProvaTy {
+		return nil, errors.New("script is not a standard Prova script")
+	}
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) != 1 {
+		return nil, errors.New("script has no Prova address")
+	}
+	addr, ok := addrs[0].(*provautil.AddressProva)
+	if !ok {
+		return nil, errors.New("script has no Prova address")
+	}
+
+	found := false
+	keyIDs := make([]btcec.KeyID, 0, len(addr.ScriptKeyIDs()))
+	for _, keyID := range addr.ScriptKeyIDs() {
+		if keyID == oldKeyID {
+			keyID = newKeyID
+			found = true
+		}
+		keyIDs = append(keyIDs, keyID)
+	}
+	if !found {
+		return nil, errors.New("script does not reference the old keyID")
+	}
+	newAddr, err := provautil.NewAddressProva(addr.ScriptAddress(), keyIDs,
+		params)
+	if err != nil {
+		return nil, err
+	}
+	return txscript.PayToAddrScript(newAddr)
+}
+
+// outPointLess returns whether outpoint a sorts before outpoint b.
+func outPointLess(a, b *wire.OutPoint) bool {
+	if c := bytes.Compare(a.Hash[:], b.Hash[:]); c != 0 {
+		return c < 0
+	}
+	return a.Index < b.Index
+}
+
+// NewPlan returns a plan sweeping the passed outputs from the old to the new
+// keyID of the configuration.  Accounts and their outputs are swept in a
+// deterministic order, so the same outputs always result in the same plan.
+func NewPlan(outputs []Output, cfg *Config) (*Plan, error) {
+	if cfg.OldKeyID == cfg.NewKeyID {
+		return nil, ErrSameKeyID
+	}
+
+	// Group the outputs by asset and account.
+	plan := &Plan{}
+	accounts := make(map[accountKey]*account)
+	for _, output := range outputs {
+		key := accountKey{output.AssetID, string(output.PkScript)}
+		acct, ok := accounts[key]
+		if !ok {
+			newScript, err := RekeyScript(output.PkScript,
+				cfg.OldKeyID, cfg.NewKeyID, cfg.Params)
+			if err != nil {
+				plan.Skipped = append(plan.Skipped, output)
+				continue
+			}
+			acct = &account{
+				assetID:   output.AssetID,
+				pkScript:  output.PkScript,
+				newScript: newScript,
+			}
+			accounts[key] = acct
+		}
+		acct.outputs = append(acct.outputs, output)
+	}
+	sorted := make([]*account, 0, len(accounts))
+	for _, acct := range accounts {
+		sort.Slice(acct.outputs, func(i, j int) bool {
+			return outPointLess(&acct.outputs[i].OutPoint,
+				&acct.outputs[j].OutPoint)
+		})
+		sorted = append(sorted, acct)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].assetID != sorted[j].assetID {
+			return sorted[i].assetID < sorted[j].assetID
+		}
+		return bytes.Compare(sorted[i].pkScript, sorted[j].pkScript) < 0
+	})
+
+	var tx *Tx
+	flush := func() {
+		if tx != nil && len(tx.Inputs) != 0 {
+			plan.Txs = append(plan.Txs, tx)
+		}
+		tx = nil
+	}
+	for _, acct := range sorted {
+		if tx != nil && tx.AssetID != acct.assetID {
+			flush()
+		}
+		outSize := wire.NewTxOut(0, acct.newScript).SerializeSize()
+		remaining := acct.outputs
+		for len(remaining) != 0 {
+			if tx == nil {
+				var err error
+				tx, err = newTx(acct.assetID)
+				if err != nil {
+					return nil, err
+				}
+			}
+			room := (cfg.MaxTxSize - tx.size - outSize) / SignedInputSize
+			if room < 1 {
+				if len(tx.Inputs) == 0 {
+					return nil, ErrTxSizeTooSmall
+				}
+				flush()
+				continue
+			}
+			if room > len(remaining) {
+				room = len(remaining)
+			}
+			chunk := remaining[:room]
+			remaining = remaining[room:]
+
+			// The chunk pays for its inputs and output, and the
+			// first chunk of a transaction for its fixed part.
+			size := room*SignedInputSize + outSize
+			var amount int64
+			for _, output := range chunk {
+				amount += output.Amount
+			}
+			fee := int64(size) * int64(cfg.FeeRate) / 1000
+			if len(tx.Inputs) == 0 {
+				fee = int64(size+tx.size) * int64(cfg.FeeRate) /
+					1000
+			}
+			txOut := wire.NewTxOut(amount-fee, acct.newScript)
+			if txOut.Value <= 0 ||
+				mempool.IsDust(txOut, cfg.DustRelayFee) {
+
+				plan.Skipped = append(plan.Skipped, chunk...)
+				continue
+			}
+			for _, output := range chunk {
+				op := output.OutPoint
+				tx.MsgTx.AddTxIn(wire.NewTxIn(&op, nil))
+			}
+			tx.MsgTx.AddTxOut(txOut)
+			tx.Inputs = append(tx.Inputs, chunk...)
+			tx.Fee += fee
+			tx.size += size
+		}
+	}
+	flush()
+
+	return plan, nil
+}
+
+// newTx returns an empty sweep transaction for the passed asset, which holds
+// the asset marker output of assets other than DMG.
+func newTx(assetID uint32) (*Tx, error) {
+	tx := &Tx{
+		MsgTx:   wire.NewMsgTx(wire.TxVersion),
+		AssetID: assetID,
+		size:    txOverhead,
+	}
+	if assetID != 0 {
+		markerScript, err := txscript.AssetIDScript(assetID)
+		if err != nil {
+			return nil, err
+		}
+		marker := wire.NewTxOut(0, markerScript)
+		tx.MsgTx.AddTxOut(marker)
+		tx.size += marker.SerializeSize()
+	}
+	return tx, nil
+}
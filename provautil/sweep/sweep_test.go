@@ -0,0 +1,163 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sweep_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/provautil/sweep"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// provaScript returns the standard Prova script of the account with the
+// passed pkHash byte and keyIDs.
+func provaScript(t *testing.T, account byte, keyIDs ...btcec.KeyID) []byte {
+	pkHash := bytes.Repeat([]byte{account}, 20)
+	addr, err := provautil.NewAddressProva(pkHash, keyIDs,
+		&chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("NewAddressProva: %v", err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("PayToAddrScript: %v", err)
+	}
+	return pkScript
+}
+
+// TestRekeyScript ensures the old keyID of a Prova script is replaced and
+// scripts not referencing it are rejected.
+func TestRekeyScript(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	got, err := sweep.RekeyScript(provaScript(t, 1, 7, 9), 9, 11, params)
+	if err != nil {
+		t.Fatalf("RekeyScript: %v", err)
+	}
+	if want := provaScript(t, 1, 7, 11); !bytes.Equal(got, want) {
+		t.Fatalf("got script %x, want %x", got, want)
+	}
+
+	if _, err := sweep.RekeyScript(provaScript(t, 1, 7, 8), 9, 11,
+		params); err == nil {
+		t.Fatal("RekeyScript: rekeyed script without the old keyID")
+	}
+	if _, err := sweep.RekeyScript([]byte{txscript.OP_TRUE}, 9, 11,
+		params); err == nil {
+		t.Fatal("RekeyScript: rekeyed non-Prova script")
+	}
+}
+
+// TestNewPlan ensures plans group outputs by asset and account, split
+// transactions at the maximum size, and skip outputs which can not be swept.
+func TestNewPlan(t *testing.T) {
+	const oldKeyID, newKeyID = 9, 11
+	cfg := &sweep.Config{
+		OldKeyID:     oldKeyID,
+		NewKeyID:     newKeyID,
+		Params:       &chaincfg.RegressionNetParams,
+		MaxTxSize:    1000,
+		FeeRate:      1000,
+		DustRelayFee: 1000,
+	}
+	output := func(index uint32, pkScript []byte, amount int64,
+		assetID uint32) sweep.Output {
+		return sweep.Output{
+			OutPoint: wire.OutPoint{Index: index},
+			PkScript: pkScript,
+			Amount:   amount,
+			AssetID:  assetID,
+		}
+	}
+	accountA := provaScript(t, 1, 7, oldKeyID)
+	accountB := provaScript(t, 2, oldKeyID, 8)
+	outputs := []sweep.Output{
+		// Account A holds five outputs, more than fit into a single
+		// transaction of the maximum size.
+		output(0, accountA, 1e6, 0),
+		output(1, accountA, 1e6, 0),
+		output(2, accountA, 1e6, 0),
+		output(3, accountA, 1e6, 0),
+		output(4, accountA, 1e6, 0),
+		// Account B holds an output of another asset and one too small
+		// to pay for sweeping it.
+		output(5, accountB, 1e6, 1),
+		output(6, accountB, 100, 0),
+		// Not referencing the old keyID.
+		output(7, provaScript(t, 3, 7, 8), 1e6, 0),
+	}
+
+	plan, err := sweep.NewPlan(outputs, cfg)
+	if err != nil {
+		t.Fatalf("NewPlan: %v", err)
+	}
+	if len(plan.Skipped) != 2 {
+		t.Fatalf("got %d skipped outputs, want 2", len(plan.Skipped))
+	}
+	if len(plan.Txs) != 3 {
+		t.Fatalf("got %d transactions, want 3", len(plan.Txs))
+	}
+
+	newScriptA := provaScript(t, 1, 7, newKeyID)
+	newScriptB := provaScript(t, 2, newKeyID, 8)
+	var swept int
+	for i, tx := range plan.Txs {
+		msgTx := tx.MsgTx
+		if len(msgTx.TxIn) != len(tx.Inputs) {
+			t.Fatalf("tx %d: got %d inputs, want %d", i,
+				len(msgTx.TxIn), len(tx.Inputs))
+		}
+		size := msgTx.SerializeSize() + len(msgTx.TxIn)*
+			(sweep.SignedInputSize-msgTx.TxIn[0].SerializeSize())
+		if size > cfg.MaxTxSize {
+			t.Fatalf("tx %d: estimated size %d exceeds %d", i, size,
+				cfg.MaxTxSize)
+		}
+		var in, out int64
+		for _, input := range tx.Inputs {
+			in += input.Amount
+		}
+		for _, txOut := range msgTx.TxOut {
+			out += txOut.Value
+		}
+		if in-out != tx.Fee || tx.Fee <= 0 {
+			t.Fatalf("tx %d: got fee %d, inputs %d, outputs %d", i,
+				tx.Fee, in, out)
+		}
+		if got := txscript.TxAssetID(msgTx); got != tx.AssetID {
+			t.Fatalf("tx %d: got asset %d, want %d", i, got,
+				tx.AssetID)
+		}
+		newScript := newScriptA
+		if tx.AssetID == 1 {
+			newScript = newScriptB
+		}
+		last := msgTx.TxOut[len(msgTx.TxOut)-1]
+		if !bytes.Equal(last.PkScript, newScript) {
+			t.Fatalf("tx %d: got output script %x, want %x", i,
+				last.PkScript, newScript)
+		}
+		swept += len(tx.Inputs)
+	}
+	if swept != 6 {
+		t.Fatalf("got %d swept outputs, want 6", swept)
+	}
+
+	cfg.MaxTxSize = 100
+	if _, err := sweep.NewPlan(outputs, cfg); err != sweep.ErrTxSizeTooSmall {
+		t.Fatalf("NewPlan: got error %v, want %v", err,
+			sweep.ErrTxSizeTooSmall)
+	}
+	cfg.MaxTxSize = 1000
+	cfg.NewKeyID = oldKeyID
+	if _, err := sweep.NewPlan(outputs, cfg); err != sweep.ErrSameKeyID {
+		t.Fatalf("NewPlan: got error %v, want %v", err,
+			sweep.ErrSameKeyID)
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcclient
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcjson"
+)
+
+// defaultTimeout is the timeout of the requests of clients created without a
+// custom HTTP client.
+const defaultTimeout = 5 * time.Minute
+
+// ConnConfig describes the connection configuration parameters for the client.
+type ConnConfig struct {
+	// Host is the IP address and port of the RPC server, such as
+	// "localhost:8334".
+	Host string
+
+	// UnixSocket is the path of the Unix domain socket of the RPC server.
+	// When it is set, the client connects to it instead of Host.
+	UnixSocket string
+
+	// User and Pass are the credentials of the RPC server.
+	User string
+	Pass string
+
+	// DisableTLS specifies whether transport layer security should be
+	// disabled.  It is recommended to always use TLS if the RPC server is
+	// not running on the same host.
+	DisableTLS bool
+
+	// Certificates are the bytes of a PEM-encoded certificate chain used
+	// to verify the certificate of the RPC server.  The system roots are
+	// used when it is empty.
+	Certificates []byte
+
+	// ClientCertificate and ClientKey are the bytes of a PEM-encoded
+	// certificate and its key presented to an RPC server which requires
+	// client certificates.  They are not used when empty.
+	ClientCertificate []byte
+	ClientKey         []byte
+
+	// HTTPClient is the HTTP client used for the requests.  When it is
+	// set, UnixSocket, Certificates and the client certificate are
+	// ignored.
+	HTTPClient *http.Client
+}
+
+// Client is a client of the JSON-RPC API of dmgd which sends each command in
+// its own HTTP POST request.
+//
+// It is safe for concurrent access.
+type Client struct {
+	id         uint64
+	cfg        ConnConfig
+	url        string
+	httpClient *http.Client
+}
+
+// New returns a client of the RPC server described by the passed connection
+// configuration.  No connection is made until the first command is sent.
+func New(config *ConnConfig) (*Client, error) {
+	if config.Host == "" && config.UnixSocket == "" {
+		return nil, errors.New("no RPC server host or Unix socket " +
+			"specified")
+	}
+	protocol := "https"
+	if config.DisableTLS {
+		protocol = "http"
+	}
+	host := config.Host
+	if host == "" {
+		// The host only serves to verify the certificate of the
+		// server, which is issued for localhost by default.
+		host = "localhost"
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		var err error
+		httpClient, err = newHTTPClient(config)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Client{
+		cfg:        *config,
+		url:        protocol + "://" + host,
+		httpClient: httpClient,
+	}, nil
+}
+
+// newHTTPClient returns a new HTTP client that is configured according to the
+// Unix socket and TLS settings in the passed connection configuration.
+func newHTTPClient(config *ConnConfig) (*http.Client, error) {
+	var dial func(network, addr string) (net.Conn, error)
+	if config.UnixSocket != "" {
+		dial = func(network, addr string) (net.Conn, error) {
+			return net.Dial("unix", config.UnixSocket)
+		}
+	}
+
+	var tlsConfig *tls.Config
+	if !config.DisableTLS {
+		tlsConfig = &tls.Config{}
+	}
+	if tlsConfig != nil && len(config.Certificates) != 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(config.Certificates) {
+			return nil, errors.New("no valid certificates found")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if tlsConfig != nil && len(config.ClientCertificate) != 0 {
+		keypair, err := tls.X509KeyPair(config.ClientCertificate,
+			config.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{keypair}
+	}
+
+	return &http.Client{
+		Timeout: defaultTimeout,
+		Transport: &http.Transport{
+			Dial:            dial,
+			TLSClientConfig: tlsConfig,
+		},
+	}, nil
+}
+
+// NextID returns the next ID to be used when sending a JSON-RPC message.
+func (c *Client) NextID() uint64 {
+	return atomic.AddUint64(&c.id, 1)
+}
+
+// SendCmd sends the passed command, which must be a registered command type
+// of the btcjson package, and returns the raw result.  Errors returned by the
+// server are of type *btcjson.RPCError.
+func (c *Client) SendCmd(cmd interface{}) (json.RawMessage, error) {
+	marshalledJSON, err := btcjson.MarshalCmd(c.NextID(), cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	httpRequest, err := http.NewRequest("POST", c.url,
+		bytes.NewReader(marshalledJSON))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.SetBasicAuth(c.cfg.User, c.cfg.Pass)
+
+	httpResponse, err := c.httpClient.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	respBytes, err := ioutil.ReadAll(httpResponse.Body)
+	httpResponse.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error reading json reply: %v", err)
+	}
+
+	// Failed commands are answered with a JSON-RPC error, while failed
+	// requests, such as unauthorized ones, are answered with an HTTP error
+	// status and a plain text body.
+	if httpResponse.StatusCode < 200 || httpResponse.StatusCode >= 300 {
+		if len(respBytes) == 0 {
+			return nil, fmt.Errorf("%d %s", httpResponse.StatusCode,
+				http.StatusText(httpResponse.StatusCode))
+		}
+		return nil, fmt.Errorf("%s", bytes.TrimSpace(respBytes))
+	}
+	var resp btcjson.Response
+	if err := json.Unmarshal(respBytes, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return resp.Result, nil
+}
+
+// Call sends the command of the passed method with the passed parameters,
+// which are converted as by btcjson.NewCmd, and unmarshals its result into the
+// value pointed to by result unless it is nil.  It allows sending any
+// registered command, including those without a typed method.
+func (c *Client) Call(result interface{}, method string, args ...interface{}) error {
+	cmd, err := btcjson.NewCmd(method, args...)
+	if err != nil {
+		return err
+	}
+	return c.call(result, cmd)
+}
+
+// call sends the passed command and unmarshals its result into the value
+// pointed to by result unless it is nil.
+func (c *Client) call(result interface{}, cmd interface{}) error {
+	res, err := c.SendCmd(cmd)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(res, result)
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcjson"
+	"github.com/pyx-partners/dmgd/provautil"
+)
+
+// newTestServer returns an RPC server which checks the credentials of the
+// requests and answers each command by passing it to the passed handler.
+func newTestServer(t *testing.T, handler func(cmd interface{}) (interface{}, *btcjson.RPCError)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" ||
+			pass != "pass" {
+
+			http.Error(w, "401 Unauthorized.", http.StatusUnauthorized)
+			return
+		}
+		var request btcjson.Request
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Decode: %v", err)
+			return
+		}
+		cmd, err := btcjson.UnmarshalCmd(&request)
+		if err != nil {
+			t.Errorf("UnmarshalCmd: %v", err)
+			return
+		}
+		result, rpcErr := handler(cmd)
+		reply, err := btcjson.MarshalResponse(request.ID, result, rpcErr)
+		if err != nil {
+			t.Errorf("MarshalResponse: %v", err)
+			return
+		}
+		w.Write(reply)
+	}))
+}
+
+// TestClient ensures the typed methods and Call send the expected commands and
+// unmarshal their results, and that errors of the server are returned.
+func TestClient(t *testing.T) {
+	var got interface{}
+	server := newTestServer(t, func(cmd interface{}) (interface{}, *btcjson.RPCError) {
+		got = cmd
+		switch cmd.(type) {
+		case *btcjson.GetAdminInfoCmd:
+			return &btcjson.GetAdminInfoResult{
+				Height:    12,
+				LastKeyID: 3,
+				RootKeys:  []string{"key"},
+			}, nil
+		case *btcjson.GetTransactionStatusCmd:
+			return []btcjson.TransactionStatusResult{
+				{TxID: "a", Status: "mempool"},
+				{TxID: "b", Status: "unknown"},
+			}, nil
+		case *btcjson.GetBlockCountCmd:
+			return 12, nil
+		case *btcjson.GetFreezeStatusCmd:
+			return nil, btcjson.NewRPCError(
+				btcjson.ErrRPCInvalidAddressOrKey, "invalid address")
+		}
+		return nil, nil
+	})
+	defer server.Close()
+
+	client, err := New(&ConnConfig{
+		Host:       strings.TrimPrefix(server.URL, "http://"),
+		User:       "user",
+		Pass:       "pass",
+		DisableTLS: true,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	info, err := client.GetAdminInfo()
+	if err != nil {
+		t.Fatalf("GetAdminInfo: %v", err)
+	}
+	if info.Height != 12 || info.LastKeyID != 3 ||
+		!reflect.DeepEqual(info.RootKeys, []string{"key"}) {
+
+		t.Fatalf("GetAdminInfo: got %+v", info)
+	}
+
+	statuses, err := client.GetTransactionStatus([]string{"a", "b"})
+	if err != nil {
+		t.Fatalf("GetTransactionStatus: %v", err)
+	}
+	if !reflect.DeepEqual(got, btcjson.NewGetTransactionStatusCmd(
+		[]string{"a", "b"})) {
+
+		t.Fatalf("GetTransactionStatus: sent %+v", got)
+	}
+	if len(statuses) != 2 || statuses[0].Status != "mempool" ||
+		statuses[1].TxID != "b" {
+
+		t.Fatalf("GetTransactionStatus: got %+v", statuses)
+	}
+
+	keyID := uint32(7)
+	if err := client.ImportXpub("xpub", []uint32{1, 2}, nil, nil,
+		nil); err != nil {
+
+		t.Fatalf("ImportXpub: %v", err)
+	}
+	// The defaults of the omitted parameters are filled in by the server.
+	gapLimit, rescan := uint32(20), true
+	if !reflect.DeepEqual(got, btcjson.NewImportXpubCmd("xpub",
+		[]uint32{1, 2}, &gapLimit, &rescan, nil)) {
+
+		t.Fatalf("ImportXpub: sent %+v", got)
+	}
+	if _, err := client.GetKeyIDCensus(&keyID, nil); err != nil {
+		t.Fatalf("GetKeyIDCensus: %v", err)
+	}
+	rescan = false
+	if !reflect.DeepEqual(got, btcjson.NewGetKeyIDCensusCmd(&keyID,
+		&rescan)) {
+
+		t.Fatalf("GetKeyIDCensus: sent %+v", got)
+	}
+
+	var count int64
+	if err := client.Call(&count, "getblockcount"); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if count != 12 {
+		t.Fatalf("Call: got block count %d, want 12", count)
+	}
+	if err := client.Call(nil, "getfreezestatus"); err == nil {
+		t.Fatalf("Call: no error for missing parameters")
+	}
+
+	_, err = client.GetFreezeStatus("address")
+	rpcErr, ok := err.(*btcjson.RPCError)
+	if !ok || rpcErr.Code != btcjson.ErrRPCInvalidAddressOrKey {
+		t.Fatalf("GetFreezeStatus: got error %v, want RPC error %d", err,
+			btcjson.ErrRPCInvalidAddressOrKey)
+	}
+
+	client, err = New(&ConnConfig{
+		Host:       strings.TrimPrefix(server.URL, "http://"),
+		User:       "user",
+		Pass:       "wrong",
+		DisableTLS: true,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	err = client.LockValidateKeys()
+	if err == nil || err.Error() != "401 Unauthorized." {
+		t.Fatalf("LockValidateKeys: got error %v, want 401 Unauthorized.",
+			err)
+	}
+
+	if _, err := New(&ConnConfig{}); err == nil {
+		t.Fatalf("New: no error without host")
+	}
+}
+
+// TestClientCertificate ensures the client presents its client certificate to
+// servers which require one and verifies the server with the passed
+// certificates.
+func TestClientCertificate(t *testing.T) {
+	validUntil := time.Now().Add(time.Hour)
+	serverCert, serverKey, err := provautil.NewTLSCertPair("server",
+		validUntil, nil)
+	if err != nil {
+		t.Fatalf("NewTLSCertPair: %v", err)
+	}
+	clientCert, clientKey, err := provautil.NewTLSCertPair("client",
+		validUntil, nil)
+	if err != nil {
+		t.Fatalf("NewTLSCertPair: %v", err)
+	}
+	keypair, err := tls.X509KeyPair(serverCert, serverKey)
+	if err != nil {
+		t.Fatalf("X509KeyPair: %v", err)
+	}
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM(clientCert)
+
+	server := newTestServer(t, func(cmd interface{}) (interface{}, *btcjson.RPCError) {
+		return 12, nil
+	})
+	server.Close()
+	server = httptest.NewUnstartedServer(server.Config.Handler)
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{keypair},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	config := &ConnConfig{
+		Host:         strings.TrimPrefix(server.URL, "https://"),
+		User:         "user",
+		Pass:         "pass",
+		Certificates: serverCert,
+	}
+	client, err := New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	var count int64
+	if err := client.Call(&count, "getblockcount"); err == nil {
+		t.Fatal("Call: no error without a client certificate")
+	}
+
+	config.ClientCertificate = clientCert
+	config.ClientKey = clientKey
+	client, err = New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := client.Call(&count, "getblockcount"); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if count != 12 {
+		t.Fatalf("Call: got block count %d, want 12", count)
+	}
+}
@@ -0,0 +1,382 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcclient
+
+import (
+	"github.com/pyx-partners/dmgd/btcjson"
+)
+
+// This file holds the typed methods of the commands which are extensions of
+// dmgd.  Their parameters follow the New<Foo>Cmd functions of the btcjson
+// package, with nil pointers selecting the default of optional parameters.
+
+// SetValidateKeys sets the private keys used to sign generated blocks.  A
+// non-nil passphrase also stores them on disk encrypted with it.
+func (c *Client) SetValidateKeys(privKeys []string, passphrase *string) error {
+	return c.call(nil, btcjson.NewSetValidateKeysCmd(privKeys, passphrase))
+}
+
+// UnlockValidateKeys decrypts the private keys stored by SetValidateKeys with
+// the passed passphrase and uses them to sign generated blocks until the passed
+// number of seconds elapsed, or until they are locked when it is 0.
+func (c *Client) UnlockValidateKeys(passphrase string, timeout int64) error {
+	return c.call(nil, btcjson.NewUnlockValidateKeysCmd(passphrase, timeout))
+}
+
+// LockValidateKeys stops signing generated blocks until the keys are set or
+// unlocked again.
+func (c *Client) LockValidateKeys() error {
+	return c.call(nil, btcjson.NewLockValidateKeysCmd())
+}
+
+// GetUnconfirmedBroadcasts returns the locally submitted transactions which
+// are being rebroadcast until they are included in a block.
+func (c *Client) GetUnconfirmedBroadcasts() ([]btcjson.UnconfirmedBroadcastResult, error) {
+	var result []btcjson.UnconfirmedBroadcastResult
+	err := c.call(&result, btcjson.NewGetUnconfirmedBroadcastsCmd())
+	return result, err
+}
+
+// FundRawTransaction adds unsigned inputs spending unspent outputs of the
+// passed addresses to the passed serialized transaction until its outputs and
+// fee are covered, paying any change to the change address.
+func (c *Client) FundRawTransaction(hexTx string, addresses []string,
+	changeAddress string, feeRate *float64) (*btcjson.FundRawTransactionResult, error) {
+
+	var result btcjson.FundRawTransactionResult
+	err := c.call(&result, btcjson.NewFundRawTransactionCmd(hexTx,
+		addresses, changeAddress, feeRate))
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SendRawPackage atomically submits the passed serialized transactions, each of
+// which may depend on those before it, and returns their hashes.
+func (c *Client) SendRawPackage(hexTxs []string) ([]string, error) {
+	var result []string
+	err := c.call(&result, btcjson.NewSendRawPackageCmd(hexTxs))
+	return result, err
+}
+
+// GetFreezeStatus returns whether the account of the passed address is frozen
+// in the best chain.
+func (c *Client) GetFreezeStatus(address string) (*btcjson.GetFreezeStatusResult, error) {
+	var result btcjson.GetFreezeStatusResult
+	err := c.call(&result, btcjson.NewGetFreezeStatusCmd(address))
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetReserveProof returns an unsigned proof-of-reserves attestation of the
+// unspent outputs paying to the passed addresses and key IDs.
+func (c *Client) GetReserveProof(addresses []string, keyIDs *[]uint32) (*btcjson.GetReserveProofResult, error) {
+	var result btcjson.GetReserveProofResult
+	err := c.call(&result, btcjson.NewGetReserveProofCmd(addresses, keyIDs))
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// VerifyReserveAttestation verifies the signature and merkle proofs of the
+// passed serialized signed attestation against the main chain.
+func (c *Client) VerifyReserveAttestation(hexAttestation string) (*btcjson.VerifyReserveAttestationResult, error) {
+	var result btcjson.VerifyReserveAttestationResult
+	err := c.call(&result,
+		btcjson.NewVerifyReserveAttestationCmd(hexAttestation))
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetCommitments returns the transactions in the main chain with an output
+// committing to the passed metadata commitment.
+func (c *Client) GetCommitments(commitment string) ([]btcjson.CommitmentResult, error) {
+	var result []btcjson.CommitmentResult
+	err := c.call(&result, btcjson.NewGetCommitmentsCmd(commitment))
+	return result, err
+}
+
+// ImportXpub adds the passed account extended public key to the keys watched by
+// the watch-only index, or changes its settings when it is already watched.
+func (c *Client) ImportXpub(xpub string, keyIDs []uint32, gapLimit *uint32,
+	rescan *bool, label *string) error {
+
+	return c.call(nil, btcjson.NewImportXpubCmd(xpub, keyIDs, gapLimit,
+		rescan, label))
+}
+
+// GetXpubInfo returns the state of the passed account extended public key
+// watched by the watch-only index.
+func (c *Client) GetXpubInfo(xpub string) (*btcjson.GetXpubInfoResult, error) {
+	var result btcjson.GetXpubInfoResult
+	err := c.call(&result, btcjson.NewGetXpubInfoCmd(xpub))
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetAddressHistory returns a page of the transactions involving the passed
+// address.
+func (c *Client) GetAddressHistory(address string, page, pageSize *int) (*btcjson.GetAddressHistoryResult, error) {
+	var result btcjson.GetAddressHistoryResult
+	err := c.call(&result, btcjson.NewGetAddressHistoryCmd(address, page,
+		pageSize))
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetAddressTxIds returns the hashes of the transactions involving the
+// addresses of the passed request.
+func (c *Client) GetAddressTxIds(request *btcjson.AddressTxRequest) ([]string, error) {
+	var result []string
+	err := c.call(&result, &btcjson.GetAddressTxIdsCmd{Request: request})
+	return result, err
+}
+
+// GetAdminInfo returns the general admin data of the best chain: the thread
+// tips, keys and issuance.
+func (c *Client) GetAdminInfo() (*btcjson.GetAdminInfoResult, error) {
+	var result btcjson.GetAdminInfoResult
+	err := c.call(&result, btcjson.NewGetAdminInfoCmd())
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetChainStats returns the daily aggregates of the main chain blocks between
+// the passed UTC days in the format YYYY-MM-DD.
+func (c *Client) GetChainStats(from, to string) ([]btcjson.ChainStatsResult, error) {
+	var result []btcjson.ChainStatsResult
+	err := c.call(&result, btcjson.NewGetChainStatsCmd(from, to))
+	return result, err
+}
+
+// GetKeyStateDiff returns the key and supply changes of the blocks after the
+// first passed height up to and including the second one.
+func (c *Client) GetKeyStateDiff(height1, height2 uint32) (*btcjson.GetKeyStateDiffResult, error) {
+	var result btcjson.GetKeyStateDiffResult
+	err := c.call(&result, btcjson.NewGetKeyStateDiffCmd(height1, height2))
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetReorgHistory returns the reorganizations of the main chain recorded by the
+// node, newest first.
+func (c *Client) GetReorgHistory(count, skip *int) ([]btcjson.ReorgEventResult, error) {
+	var result []btcjson.ReorgEventResult
+	err := c.call(&result, btcjson.NewGetReorgHistoryCmd(count, skip))
+	return result, err
+}
+
+// GetFinalityStatus returns the finality of the main chain block with the
+// passed hash, or of the block containing the transaction with it.
+func (c *Client) GetFinalityStatus(hash string) (*btcjson.GetFinalityStatusResult, error) {
+	var result btcjson.GetFinalityStatusResult
+	err := c.call(&result, btcjson.NewGetFinalityStatusCmd(hash))
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetSignedCheckpoints returns the enforced signed checkpoints from the passed
+// height.
+func (c *Client) GetSignedCheckpoints(fromHeight *uint32) ([]btcjson.SignedCheckpointResult, error) {
+	var result []btcjson.SignedCheckpointResult
+	err := c.call(&result, btcjson.NewGetSignedCheckpointsCmd(fromHeight))
+	return result, err
+}
+
+// AddSignedCheckpoint verifies the passed serialized signed checkpoint and
+// enforces it from then on.
+func (c *Client) AddSignedCheckpoint(hexCheckpoint string) error {
+	return c.call(nil, btcjson.NewAddSignedCheckpointCmd(hexCheckpoint))
+}
+
+// GetPeerEvictions returns the most recent inbound peers evicted to make room
+// for new ones, newest first.
+func (c *Client) GetPeerEvictions() ([]btcjson.PeerEvictionResult, error) {
+	var result []btcjson.PeerEvictionResult
+	err := c.call(&result, btcjson.NewGetPeerEvictionsCmd())
+	return result, err
+}
+
+// GetSyncStatus returns the sync peer and the stalls of the sync detected by
+// the watchdog.
+func (c *Client) GetSyncStatus() (*btcjson.GetSyncStatusResult, error) {
+	var result btcjson.GetSyncStatusResult
+	err := c.call(&result, btcjson.NewGetSyncStatusCmd())
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CheckCanonicalEncoding returns the non-canonical encodings of the passed
+// serialized transaction, or block when block is true.
+func (c *Client) CheckCanonicalEncoding(hexData string, block *bool) (*btcjson.CheckCanonicalEncodingResult, error) {
+	var result btcjson.CheckCanonicalEncodingResult
+	err := c.call(&result, btcjson.NewCheckCanonicalEncodingCmd(hexData,
+		block))
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetValidatorViolations returns the recorded evidence of validate keys which
+// signed two different blocks at the same height.
+func (c *Client) GetValidatorViolations(count, skip *int) ([]btcjson.ValidatorViolationResult, error) {
+	var result []btcjson.ValidatorViolationResult
+	err := c.call(&result, btcjson.NewGetValidatorViolationsCmd(count, skip))
+	return result, err
+}
+
+// GetAnnouncements returns the unexpired governance announcements known to the
+// node, newest first.
+func (c *Client) GetAnnouncements() ([]btcjson.AnnouncementResult, error) {
+	var result []btcjson.AnnouncementResult
+	err := c.call(&result, btcjson.NewGetAnnouncementsCmd())
+	return result, err
+}
+
+// SendAnnouncement verifies the passed serialized signed announcement, relays
+// it to the network and returns its hash.
+func (c *Client) SendAnnouncement(hexAnnouncement string) (string, error) {
+	var result string
+	err := c.call(&result, btcjson.NewSendAnnouncementCmd(hexAnnouncement))
+	return result, err
+}
+
+// GetKeyExpiries returns the ASP and validate keys provisioned with an expiry
+// height, soonest expiry first.
+func (c *Client) GetKeyExpiries() ([]btcjson.KeyExpiryResult, error) {
+	var result []btcjson.KeyExpiryResult
+	err := c.call(&result, btcjson.NewGetKeyExpiriesCmd())
+	return result, err
+}
+
+// GetAddressUtxos returns the spendable outputs paying to the passed addresses
+// with at least the passed number of confirmations, optionally only those which
+// can be co-signed by the ASP key with the passed key ID.
+func (c *Client) GetAddressUtxos(addresses []string, minConf *int,
+	keyID *uint32) ([]btcjson.AddressUtxoResult, error) {
+
+	var result []btcjson.AddressUtxoResult
+	err := c.call(&result, btcjson.NewGetAddressUtxosCmd(addresses, minConf,
+		keyID))
+	return result, err
+}
+
+// GetSweepPlan returns the unsigned transactions moving all unspent outputs
+// referencing the passed old key ID to the same accounts with the new one.
+func (c *Client) GetSweepPlan(oldKeyID, newKeyID uint32, feeRate *float64,
+	maxTxSize *int) (*btcjson.GetSweepPlanResult, error) {
+
+	var result btcjson.GetSweepPlanResult
+	err := c.call(&result, btcjson.NewGetSweepPlanCmd(oldKeyID, newKeyID,
+		feeRate, maxTxSize))
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetKeyIDCensus returns the number and value of the unspent outputs
+// referencing each key ID, or the passed one only, found by the last key ID
+// census.  A true rescan requests a new census.
+func (c *Client) GetKeyIDCensus(keyID *uint32, rescan *bool) (*btcjson.GetKeyIDCensusResult, error) {
+	var result btcjson.GetKeyIDCensusResult
+	err := c.call(&result, btcjson.NewGetKeyIDCensusCmd(keyID, rescan))
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetTransactionStatus returns the status of each of the transactions with the
+// passed hashes in the memory pool and the chain.
+func (c *Client) GetTransactionStatus(txIDs []string) ([]btcjson.TransactionStatusResult, error) {
+	var result []btcjson.TransactionStatusResult
+	err := c.call(&result, btcjson.NewGetTransactionStatusCmd(txIDs))
+	return result, err
+}
+
+// GetBlockPropagation returns the aggregated propagation of the most recently
+// announced blocks through the node along with the propagation of up to count
+// of them, newest first.
+func (c *Client) GetBlockPropagation(count *int) (*btcjson.GetBlockPropagationResult, error) {
+	var result btcjson.GetBlockPropagationResult
+	err := c.call(&result, btcjson.NewGetBlockPropagationCmd(count))
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// PrioritiseTransaction adds the passed fee delta in atoms to the fee of the
+// transaction with the passed hash when transactions are selected for block
+// templates, and returns the resulting fee delta of the transaction.
+func (c *Client) PrioritiseTransaction(txID string, feeDelta int64) (int64, error) {
+	var result int64
+	err := c.call(&result, btcjson.NewPrioritiseTransactionCmd(txID,
+		feeDelta))
+	return result, err
+}
+
+// SetTemplateExclusion adds the transaction with the passed hash or the passed
+// address to the exclusions of the block templates generated by the node, or
+// removes it, and returns whether the exclusions changed.
+func (c *Client) SetTemplateExclusion(target string, subCmd btcjson.TemplateExclusionSubCmd) (bool, error) {
+	var result bool
+	err := c.call(&result, btcjson.NewSetTemplateExclusionCmd(target,
+		subCmd))
+	return result, err
+}
+
+// GetTemplateExclusions returns the transactions and addresses excluded from
+// the block templates generated by the node.
+func (c *Client) GetTemplateExclusions() (*btcjson.GetTemplateExclusionsResult, error) {
+	var result btcjson.GetTemplateExclusionsResult
+	err := c.call(&result, btcjson.NewGetTemplateExclusionsCmd())
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetBlockAtHeightByTime returns the last main chain block whose median time
+// past is at or before the passed time in seconds since 1 Jan 1970 GMT.
+func (c *Client) GetBlockAtHeightByTime(time int64) (*btcjson.GetBlockAtHeightByTimeResult, error) {
+	var result btcjson.GetBlockAtHeightByTimeResult
+	err := c.call(&result, btcjson.NewGetBlockAtHeightByTimeCmd(time))
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RPCDiscover returns the OpenRPC document describing the parameters and
+// results of all methods of the server.
+func (c *Client) RPCDiscover() (*btcjson.OpenRPCDocument, error) {
+	var result btcjson.OpenRPCDocument
+	err := c.call(&result, btcjson.NewRPCDiscoverCmd())
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package rpcclient implements a client of the JSON-RPC API of dmgd.
+
+Overview
+
+The client marshals the commands of the btcjson package, sends each of them to
+the RPC server in an HTTP POST request and unmarshals the result into the
+result types of the btcjson package.  Unlike the websocket clients of the btcd
+ecosystem, it registers for no notifications and keeps no connection open.
+
+The commands which are extensions of dmgd, such as setvalidatekeys and the admin
+and chain state queries, have typed methods:
+
+	client, err := rpcclient.New(&rpcclient.ConnConfig{
+		Host:         "localhost:8334",
+		User:         "user",
+		Pass:         "pass",
+		Certificates: cert,
+	})
+	if err != nil {
+		return err
+	}
+	info, err := client.GetAdminInfo()
+
+Any other registered command can be sent with Call, which creates the command
+from its method and parameters like btcjson.NewCmd, or with SendCmd, which
+returns the raw result of a command created with one of the New<Foo>Cmd
+functions:
+
+	var count int64
+	err := client.Call(&count, "getblockcount")
+
+Errors
+
+Errors returned by the RPC server for a command are of type *btcjson.RPCError,
+so callers can check their code.  Failures of the HTTP request, such as invalid
+credentials, are returned as other errors.
+*/
+package rpcclient
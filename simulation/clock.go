@@ -0,0 +1,86 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package simulation
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+)
+
+// Clock is a virtual clock which only moves when it is advanced.  It is the
+// time source of the chain and the memory pool of a simulation, which makes
+// the rules depending on the current time reproducible.
+type Clock struct {
+	mtx sync.Mutex
+	now time.Time
+}
+
+// Ensure the Clock type implements the blockchain.MedianTimeSource interface.
+var _ blockchain.MedianTimeSource = (*Clock)(nil)
+
+// NewClock returns a virtual clock set to the passed time.
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+// Now returns the current time of the clock.
+//
+// This function is safe for concurrent access.
+func (c *Clock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by the passed duration.  Negative durations
+// are ignored, so the clock never goes backwards.
+//
+// This function is safe for concurrent access.
+func (c *Clock) Advance(d time.Duration) {
+	c.mtx.Lock()
+	if d > 0 {
+		c.now = c.now.Add(d)
+	}
+	c.mtx.Unlock()
+}
+
+// AdvanceTo moves the clock forward to the passed time.  Times before the
+// current time of the clock are ignored.
+//
+// This function is safe for concurrent access.
+func (c *Clock) AdvanceTo(t time.Time) {
+	c.mtx.Lock()
+	if t.After(c.now) {
+		c.now = t
+	}
+	c.mtx.Unlock()
+}
+
+// AdjustedTime returns the current time of the clock with 1 second precision,
+// as the time sources adjusted by the network do.  There are no peers in a
+// simulation, so the time is never adjusted.
+//
+// This function is safe for concurrent access and is part of the
+// blockchain.MedianTimeSource interface implementation.
+func (c *Clock) AdjustedTime() time.Time {
+	return time.Unix(c.Now().Unix(), 0)
+}
+
+// AddTimeSample ignores the passed time sample, since the time of the clock is
+// not adjusted.
+//
+// This function is part of the blockchain.MedianTimeSource interface
+// implementation.
+func (c *Clock) AddTimeSample(id string, timeVal time.Time) {}
+
+// Offset always returns zero, since the time of the clock is not adjusted.
+//
+// This function is part of the blockchain.MedianTimeSource interface
+// implementation.
+func (c *Clock) Offset() time.Duration {
+	return 0
+}
@@ -0,0 +1,234 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package simulation runs a chain and a memory pool against a virtual clock and
+// a scripted schedule of blocks and transactions, without networking.  Since
+// the clock only moves to the time of the next scheduled event, simulations of
+// the rules depending on the current time, such as the rate limiting of free
+// transactions, sequence locks and block timestamp rules, are reproducible.
+package simulation
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/database"
+	"github.com/pyx-partners/dmgd/mempool"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+)
+
+const (
+	// sigCacheMaxSize is the maximum number of entries of the signature
+	// cache shared by the chain and the memory pool.
+	sigCacheMaxSize = 50000
+
+	// hashCacheMaxSize is the maximum number of entries of the transaction
+	// hash mid-state cache shared by the chain and the memory pool.
+	hashCacheMaxSize = 50000
+)
+
+// Config is a descriptor containing the simulation configuration.
+type Config struct {
+	// DB is the database to store the chain in.  It is expected to be
+	// empty, so the simulation starts with the genesis block.
+	DB database.DB
+
+	// ChainParams identifies which chain parameters the chain is
+	// associated with.
+	ChainParams *chaincfg.Params
+
+	// Start is the time of the virtual clock when the simulation starts.
+	Start time.Time
+
+	// Policy defines the memory pool policy.
+	Policy mempool.Policy
+}
+
+// Event is a block or a transaction of the schedule of a simulation.
+type Event struct {
+	// At is the time after the start of the simulation at which the block
+	// or the transaction is processed.
+	At time.Duration
+
+	// Block is the block to process.  It is nil when the event is a
+	// transaction.
+	Block *provautil.Block
+
+	// Tx is the transaction to submit to the memory pool when the event is
+	// not a block.
+	Tx *provautil.Tx
+
+	// AllowOrphan and RateLimit are passed to the memory pool along with
+	// the transaction, as for the transactions relayed by peers.
+	AllowOrphan bool
+	RateLimit   bool
+}
+
+// Result is the outcome of processing an event of the schedule.
+type Result struct {
+	// Event is the processed event.
+	Event *Event
+
+	// Time is the time of the virtual clock when the event was processed.
+	Time time.Time
+
+	// IsMainChain and IsOrphan report whether a processed block extends
+	// the main chain or is an orphan.
+	IsMainChain bool
+	IsOrphan    bool
+
+	// Accepted are the transactions accepted to the memory pool, which
+	// are the submitted transaction followed by the orphans it made
+	// acceptable.
+	Accepted []*mempool.TxDesc
+
+	// Err is the error the block or the transaction was rejected with.
+	Err error
+}
+
+// Simulator runs a chain and a memory pool against a virtual clock.
+type Simulator struct {
+	start  time.Time
+	clock  *Clock
+	chain  *blockchain.BlockChain
+	txPool *mempool.TxPool
+}
+
+// New returns a simulator with a new chain stored in the configured database
+// and an empty memory pool, both using a virtual clock set to the start time.
+func New(cfg *Config) (*Simulator, error) {
+	s := &Simulator{
+		start: cfg.Start,
+		clock: NewClock(cfg.Start),
+	}
+	sigCache := txscript.NewSigCache(sigCacheMaxSize)
+	hashCache := txscript.NewHashCache(hashCacheMaxSize)
+	chain, err := blockchain.New(&blockchain.Config{
+		DB:            cfg.DB,
+		ChainParams:   cfg.ChainParams,
+		TimeSource:    s.clock,
+		Notifications: s.handleNotification,
+		SigCache:      sigCache,
+		HashCache:     hashCache,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.chain = chain
+
+	s.txPool = mempool.New(&mempool.Config{
+		Policy:          cfg.Policy,
+		ChainParams:     cfg.ChainParams,
+		FetchUtxoView:   chain.FetchUtxoView,
+		ThreadTips:      chain.ThreadTips,
+		LastKeyID:       chain.LastKeyID,
+		TotalSupply:     chain.TotalSupply,
+		GetKeyIDs:       chain.KeyIDs,
+		GetAdminKeySets: chain.AdminKeySets,
+		GetIssueDests:   chain.IssueDests,
+		GetFrozen:       chain.Frozen,
+		GetDelegates:    chain.Delegates,
+		BestHeight:      func() uint32 { return chain.BestSnapshot().Height },
+		MedianTimePast:  func() time.Time { return chain.BestSnapshot().MedianTime },
+		CalcSequenceLock: func(tx *provautil.Tx, view *blockchain.UtxoViewpoint) (*blockchain.SequenceLock, error) {
+			return chain.CalcSequenceLock(tx, view, true)
+		},
+		SigCache:   sigCache,
+		HashCache:  hashCache,
+		TimeSource: s.clock,
+		Now:        s.clock.Now,
+	})
+	return s, nil
+}
+
+// Clock returns the virtual clock of the simulation.
+func (s *Simulator) Clock() *Clock {
+	return s.clock
+}
+
+// Chain returns the chain of the simulation.
+func (s *Simulator) Chain() *blockchain.BlockChain {
+	return s.chain
+}
+
+// TxPool returns the memory pool of the simulation.
+func (s *Simulator) TxPool() *mempool.TxPool {
+	return s.txPool
+}
+
+// handleNotification keeps the memory pool in sync with the main chain as
+// blocks are connected and disconnected, as the block manager of a node does.
+func (s *Simulator) handleNotification(notification *blockchain.Notification) {
+	switch notification.Type {
+	case blockchain.NTBlockConnected:
+		block, ok := notification.Data.(*provautil.Block)
+		if !ok {
+			break
+		}
+
+		// Remove the transactions of the block and the ones they
+		// double spend from the memory pool, and accept the orphans
+		// which spend them.
+		for _, tx := range block.Transactions()[1:] {
+			s.txPool.RemoveTransaction(tx, false)
+			s.txPool.RemoveDoubleSpends(tx)
+			s.txPool.RemoveOrphan(tx)
+			s.txPool.ProcessOrphans(tx)
+		}
+		s.txPool.ProcessHeldAdminTxns()
+
+	case blockchain.NTBlockDisconnected:
+		block, ok := notification.Data.(*provautil.Block)
+		if !ok {
+			break
+		}
+
+		// Reinsert the transactions of the block into the memory pool.
+		for _, tx := range block.Transactions()[1:] {
+			_, _, err := s.txPool.MaybeAcceptTransaction(tx, false,
+				false)
+			if err != nil {
+				s.txPool.RemoveTransaction(tx, true)
+			}
+		}
+	}
+}
+
+// Run processes the passed events in the order of their time, advancing the
+// virtual clock to the time of each event before processing it.  Events with
+// the same time are processed in the order they are passed.  The clock never
+// goes backwards, so events scheduled before the time the clock was advanced
+// to by a previous run are processed at the current time.  The results are
+// returned in the order the events were processed.
+func (s *Simulator) Run(events []Event) []Result {
+	order := make([]int, len(events))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return events[order[i]].At < events[order[j]].At
+	})
+
+	results := make([]Result, 0, len(events))
+	for _, i := range order {
+		event := &events[i]
+		s.clock.AdvanceTo(s.start.Add(event.At))
+		result := Result{
+			Event: event,
+			Time:  s.clock.Now(),
+		}
+		if event.Block != nil {
+			result.IsMainChain, result.IsOrphan, result.Err =
+				s.chain.ProcessBlock(event.Block, blockchain.BFNone)
+		} else {
+			result.Accepted, result.Err = s.txPool.ProcessTransaction(
+				event.Tx, event.AllowOrphan, event.RateLimit, 0)
+		}
+		results = append(results, result)
+	}
+	return results
+}
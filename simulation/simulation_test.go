@@ -0,0 +1,187 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package simulation
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/blockchain/fullblocktests"
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/database"
+	_ "github.com/pyx-partners/dmgd/database/ffldb"
+	"github.com/pyx-partners/dmgd/mempool"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// TestClock ensures the virtual clock only moves forward when it is advanced.
+func TestClock(t *testing.T) {
+	start := time.Unix(1546300800, 500)
+	clock := NewClock(start)
+	if now := clock.Now(); !now.Equal(start) {
+		t.Fatalf("Now: got %v, want %v", now, start)
+	}
+	if adjusted := clock.AdjustedTime(); !adjusted.Equal(time.Unix(1546300800, 0)) {
+		t.Fatalf("AdjustedTime: got %v, want it truncated to seconds",
+			adjusted)
+	}
+
+	clock.AddTimeSample("peer", start.Add(time.Hour))
+	clock.Advance(-time.Minute)
+	clock.AdvanceTo(start.Add(-time.Minute))
+	if now := clock.Now(); !now.Equal(start) || clock.Offset() != 0 {
+		t.Fatalf("Now: got %v with offset %v, want %v without offset",
+			now, clock.Offset(), start)
+	}
+	clock.Advance(time.Minute)
+	clock.AdvanceTo(start.Add(time.Hour))
+	if now := clock.Now(); !now.Equal(start.Add(time.Hour)) {
+		t.Fatalf("Now: got %v, want %v", now, start.Add(time.Hour))
+	}
+}
+
+// newOrphanTx returns a transaction spending an output which does not exist.
+func newOrphanTx(t *testing.T, prevHash byte) *provautil.Tx {
+	keyIDs := []btcec.KeyID{btcec.KeyID(1), btcec.KeyID(2)}
+	addr, err := provautil.NewAddressProva(make([]byte, 20), keyIDs,
+		&chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("NewAddressProva: %v", err)
+	}
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("PayToAddrScript: %v", err)
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	prevOut := wire.NewOutPoint(&chainhash.Hash{prevHash}, 0)
+	tx.AddTxIn(wire.NewTxIn(prevOut, nil))
+	tx.AddTxOut(wire.NewTxOut(1000000, pkScript))
+	return provautil.NewTx(tx)
+}
+
+// TestSimulation ensures the chain and the memory pool of a simulation expire
+// their orphans according to the virtual clock.
+func TestSimulation(t *testing.T) {
+	tests, err := fullblocktests.Generate(false)
+	if err != nil {
+		t.Fatalf("failed to generate tests: %v", err)
+	}
+	var blocks []*provautil.Block
+	for _, test := range tests {
+		for _, instance := range test {
+			item, ok := instance.(fullblocktests.AcceptedBlock)
+			if ok && item.IsMainChain && len(blocks) < 3 &&
+				item.Height == uint32(len(blocks)+1) {
+
+				block := provautil.NewBlock(item.Block)
+				block.SetHeight(item.Height)
+				blocks = append(blocks, block)
+			}
+		}
+	}
+	if len(blocks) != 3 {
+		t.Fatalf("got %d blocks extending the main chain, want 3",
+			len(blocks))
+	}
+
+	dbPath, err := ioutil.TempDir("", "simulation")
+	if err != nil {
+		t.Fatalf("TempDir: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	params := &chaincfg.RegressionNetParams
+	db, err := database.Create("ffldb", filepath.Join(dbPath, "db"),
+		params.Net)
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	defer db.Close()
+
+	start := blocks[2].MsgBlock().Header.Timestamp
+	sim, err := New(&Config{
+		DB:          db,
+		ChainParams: params,
+		Start:       start,
+		Policy: mempool.Policy{
+			AcceptNonStd:    true,
+			MaxOrphanTxs:    5,
+			MaxOrphanTxSize: 1000,
+			MaxSigOpsPerTx:  blockchain.MaxSigOpsPerBlock / 5,
+			MaxTxVersion:    wire.TxVersion,
+		},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// The second block is an orphan which expires an hour later, so it is
+	// removed when the third block is added as an orphan.  The events are
+	// processed in the order of their time, and those at the same time in
+	// the passed order.
+	results := sim.Run([]Event{
+		{At: 2 * time.Hour, Block: blocks[0]},
+		{At: 90 * time.Minute, Block: blocks[2]},
+		{At: 0, Block: blocks[1]},
+		{At: 2 * time.Hour, Block: blocks[1]},
+	})
+	wantResults := []struct {
+		block                 *provautil.Block
+		at                    time.Duration
+		isMainChain, isOrphan bool
+	}{
+		{blocks[1], 0, false, true},
+		{blocks[2], 90 * time.Minute, false, true},
+		{blocks[0], 2 * time.Hour, true, false},
+		{blocks[1], 2 * time.Hour, true, false},
+	}
+	for i, want := range wantResults {
+		result := results[i]
+		if result.Event.Block != want.block ||
+			!result.Time.Equal(start.Add(want.at)) {
+
+			t.Fatalf("result #%d: got block %v at %v, want block %v "+
+				"at %v", i, result.Event.Block.Hash(), result.Time,
+				want.block.Hash(), start.Add(want.at))
+		}
+		if result.Err != nil || result.IsMainChain != want.isMainChain ||
+			result.IsOrphan != want.isOrphan {
+
+			t.Fatalf("result #%d: got main chain %v, orphan %v, "+
+				"error %v, want main chain %v, orphan %v", i,
+				result.IsMainChain, result.IsOrphan, result.Err,
+				want.isMainChain, want.isOrphan)
+		}
+	}
+	if height := sim.Chain().BestSnapshot().Height; height != 3 {
+		t.Fatalf("got best height %d, want 3", height)
+	}
+
+	// Orphan transactions expire 15 minutes after they are added, and
+	// are removed when the pool is scanned for expired orphans.
+	tx1, tx2 := newOrphanTx(t, 1), newOrphanTx(t, 2)
+	results = sim.Run([]Event{{At: 3 * time.Hour, Tx: tx1, AllowOrphan: true}})
+	if results[0].Err != nil || len(results[0].Accepted) != 0 ||
+		!sim.TxPool().IsOrphanInPool(tx1.Hash()) {
+
+		t.Fatalf("got error %v, want the transaction added as an orphan",
+			results[0].Err)
+	}
+	results = sim.Run([]Event{{At: 3*time.Hour + 20*time.Minute, Tx: tx2,
+		AllowOrphan: true}})
+	if results[0].Err != nil || sim.TxPool().IsOrphanInPool(tx1.Hash()) ||
+		!sim.TxPool().IsOrphanInPool(tx2.Hash()) {
+
+		t.Fatalf("got error %v, want the first orphan expired",
+			results[0].Err)
+	}
+}
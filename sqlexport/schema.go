@@ -0,0 +1,76 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sqlexport
+
+// schema holds the statements creating the tables and views the main chain is
+// mirrored into.  Every row belongs to a block and is removed along with it
+// when the block is disconnected from the main chain.  The schema is described
+// in docs/sql_export.md, which must be kept in sync with it.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS blocks (
+		hash TEXT PRIMARY KEY,
+		height BIGINT NOT NULL UNIQUE,
+		prev_hash TEXT NOT NULL,
+		time BIGINT NOT NULL,
+		validating_pub_key TEXT NOT NULL,
+		num_tx INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS transactions (
+		block_hash TEXT NOT NULL REFERENCES blocks (hash) ON DELETE CASCADE,
+		txid TEXT PRIMARY KEY,
+		tx_index INTEGER NOT NULL,
+		version INTEGER NOT NULL,
+		lock_time BIGINT NOT NULL,
+		size INTEGER NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS inputs (
+		block_hash TEXT NOT NULL REFERENCES blocks (hash) ON DELETE CASCADE,
+		txid TEXT NOT NULL,
+		vin INTEGER NOT NULL,
+		prev_txid TEXT NOT NULL,
+		prev_vout INTEGER NOT NULL,
+		PRIMARY KEY (txid, vin)
+	)`,
+	`CREATE INDEX IF NOT EXISTS inputs_prev_out ON inputs (prev_txid, prev_vout)`,
+	`CREATE TABLE IF NOT EXISTS outputs (
+		block_hash TEXT NOT NULL REFERENCES blocks (hash) ON DELETE CASCADE,
+		txid TEXT NOT NULL,
+		vout INTEGER NOT NULL,
+		value BIGINT NOT NULL,
+		script_class TEXT NOT NULL,
+		address TEXT,
+		pk_script BYTEA NOT NULL,
+		PRIMARY KEY (txid, vout)
+	)`,
+	`CREATE INDEX IF NOT EXISTS outputs_address ON outputs (address)`,
+	`CREATE TABLE IF NOT EXISTS admin_ops (
+		block_hash TEXT NOT NULL REFERENCES blocks (hash) ON DELETE CASCADE,
+		txid TEXT NOT NULL,
+		op_index INTEGER NOT NULL,
+		thread INTEGER NOT NULL,
+		op TEXT NOT NULL,
+		PRIMARY KEY (txid, op_index)
+	)`,
+	`CREATE TABLE IF NOT EXISTS key_changes (
+		block_hash TEXT NOT NULL REFERENCES blocks (hash) ON DELETE CASCADE,
+		height BIGINT NOT NULL,
+		txid TEXT NOT NULL,
+		tx_index INTEGER NOT NULL,
+		op_index INTEGER NOT NULL,
+		key_set TEXT NOT NULL,
+		pub_key TEXT NOT NULL,
+		key_id BIGINT NOT NULL,
+		added BOOLEAN NOT NULL,
+		PRIMARY KEY (txid, op_index)
+	)`,
+	`CREATE OR REPLACE VIEW admin_keys AS
+		SELECT key_set, pub_key, key_id, height FROM (
+			SELECT DISTINCT ON (key_set, pub_key, key_id) key_set,
+				pub_key, key_id, height, added
+			FROM key_changes
+			ORDER BY key_set, pub_key, key_id, height DESC,
+				tx_index DESC, op_index DESC
+		) latest WHERE added`,
+}
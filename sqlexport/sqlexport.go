@@ -0,0 +1,343 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sqlexport
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/txscript/adminop"
+)
+
+// defaultRetryInterval is the interval at which mirroring the main chain is
+// retried after it failed.
+const defaultRetryInterval = 10 * time.Second
+
+// Chain provides the blocks of the main chain to the exporter.  It is
+// implemented by blockchain.BlockChain.
+type Chain interface {
+	BestSnapshot() *blockchain.BestState
+	BlockByHeight(height uint32) (*provautil.Block, error)
+	MainChainHasBlock(hash *chainhash.Hash) (bool, error)
+}
+
+// Config is a descriptor containing the configuration of an exporter.
+type Config struct {
+	// Chain is the block chain which is mirrored.
+	Chain Chain
+
+	// ChainParams identifies the network of the chain, which the
+	// addresses of the outputs are encoded for.
+	ChainParams *chaincfg.Params
+
+	// DB is the PostgreSQL database the chain is mirrored into.  It is
+	// closed when the exporter is stopped.
+	DB *sql.DB
+
+	// RetryInterval is the interval at which mirroring is retried after
+	// it failed.  It defaults to 10 seconds.
+	RetryInterval time.Duration
+}
+
+// Exporter mirrors the blocks, transactions, outputs, admin operations and key
+// changes of the main chain into a PostgreSQL database, so they can be queried
+// with SQL.  Each block is written in a single database transaction, and the
+// highest block in the database is where mirroring resumes.  A block which is
+// no longer in the main chain after a reorganization is deleted along with all
+// of its rows before the blocks of the new main chain are written.
+type Exporter struct {
+	cfg    Config
+	notify chan struct{}
+	quit   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New creates the schema in the database of the passed configuration when it
+// does not exist yet and returns an exporter which mirrors the chain into it.
+func New(cfg *Config) (*Exporter, error) {
+	for _, stmt := range schema {
+		if _, err := cfg.DB.Exec(stmt); err != nil {
+			return nil, err
+		}
+	}
+
+	e := &Exporter{
+		cfg:    *cfg,
+		notify: make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+	}
+	if e.cfg.RetryInterval == 0 {
+		e.cfg.RetryInterval = defaultRetryInterval
+	}
+	return e, nil
+}
+
+// Start begins mirroring the main chain.
+func (e *Exporter) Start() {
+	e.wg.Add(1)
+	go e.handler()
+}
+
+// Stop stops mirroring the main chain and closes the database.
+func (e *Exporter) Stop() error {
+	close(e.quit)
+	e.wg.Wait()
+	return e.cfg.DB.Close()
+}
+
+// Notify wakes the exporter up to mirror the blocks connected to or
+// disconnected from the main chain.  It does not block.
+//
+// This function is safe for concurrent access.
+func (e *Exporter) Notify() {
+	select {
+	case e.notify <- struct{}{}:
+	default:
+	}
+}
+
+// handler mirrors the main chain each time the exporter is notified and
+// retries after a failure.  It must be run as a goroutine.
+func (e *Exporter) handler() {
+	defer e.wg.Done()
+
+	retry := time.NewTimer(0)
+	defer retry.Stop()
+	for {
+		select {
+		case <-e.notify:
+		case <-retry.C:
+		case <-e.quit:
+			return
+		}
+
+		if err := e.catchUp(); err != nil {
+			log.Errorf("Unable to export blocks: %v", err)
+			retry.Reset(e.cfg.RetryInterval)
+		}
+	}
+}
+
+// tip returns the hash and the height of the highest block in the database,
+// or nil when it holds no blocks.
+func (e *Exporter) tip() (*chainhash.Hash, uint32, error) {
+	var hashStr string
+	var height uint32
+	err := e.cfg.DB.QueryRow("SELECT hash, height FROM blocks "+
+		"ORDER BY height DESC LIMIT 1").Scan(&hashStr, &height)
+	if err == sql.ErrNoRows {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	hash, err := chainhash.NewHashFromStr(hashStr)
+	if err != nil {
+		return nil, 0, err
+	}
+	return hash, height, nil
+}
+
+// catchUp deletes the blocks in the database which were disconnected from the
+// main chain and writes the blocks connected since, one block at a time.  It
+// returns once the database holds the tip of the main chain or the exporter is
+// stopped.
+func (e *Exporter) catchUp() error {
+	for {
+		select {
+		case <-e.quit:
+			return nil
+		default:
+		}
+
+		tipHash, tipHeight, err := e.tip()
+		if err != nil {
+			return err
+		}
+
+		// Delete a tip which is no longer in the main chain after a
+		// reorganization.
+		if tipHash != nil {
+			inMainChain, err := e.cfg.Chain.MainChainHasBlock(tipHash)
+			if err != nil {
+				return err
+			}
+			if !inMainChain {
+				_, err := e.cfg.DB.Exec("DELETE FROM blocks WHERE "+
+					"hash = $1", tipHash.String())
+				if err != nil {
+					return err
+				}
+				log.Debugf("Deleted disconnected block %v (height "+
+					"%d)", tipHash, tipHeight)
+				continue
+			}
+		}
+
+		height := uint32(0)
+		if tipHash != nil {
+			if tipHeight >= e.cfg.Chain.BestSnapshot().Height {
+				return nil
+			}
+			height = tipHeight + 1
+		}
+		block, err := e.cfg.Chain.BlockByHeight(height)
+		if err != nil {
+			return err
+		}
+
+		// The main chain may have been reorganized since the tip was
+		// found in it, in which case the block does not extend it.
+		prevHash := &block.MsgBlock().Header.PrevBlock
+		if tipHash != nil && !prevHash.IsEqual(tipHash) {
+			continue
+		}
+		if err := e.writeBlock(block); err != nil {
+			return err
+		}
+	}
+}
+
+// writeBlock writes the passed block along with its transactions, inputs,
+// outputs, admin operations and key changes in a single database transaction.
+func (e *Exporter) writeBlock(block *provautil.Block) error {
+	dbTx, err := e.cfg.DB.Begin()
+	if err != nil {
+		return err
+	}
+	if err := e.insertBlock(dbTx, block); err != nil {
+		dbTx.Rollback()
+		return err
+	}
+	if err := dbTx.Commit(); err != nil {
+		return err
+	}
+
+	log.Debugf("Exported block %v (height %d)", block.Hash(),
+		block.MsgBlock().Header.Height)
+	return nil
+}
+
+// insertBlock inserts the rows of the passed block using the passed database
+// transaction.
+func (e *Exporter) insertBlock(dbTx *sql.Tx, block *provautil.Block) error {
+	header := &block.MsgBlock().Header
+	blockHash := block.Hash().String()
+	_, err := dbTx.Exec("INSERT INTO blocks (hash, height, prev_hash, "+
+		"time, validating_pub_key, num_tx) VALUES ($1, $2, $3, $4, $5, "+
+		"$6)", blockHash, header.Height, header.PrevBlock.String(),
+		header.Timestamp.Unix(),
+		hex.EncodeToString(header.ValidatingPubKey[:]),
+		len(block.Transactions()))
+	if err != nil {
+		return err
+	}
+
+	for txIndex, tx := range block.Transactions() {
+		msgTx := tx.MsgTx()
+		txid := tx.Hash().String()
+		_, err := dbTx.Exec("INSERT INTO transactions (block_hash, "+
+			"txid, tx_index, version, lock_time, size) VALUES ($1, "+
+			"$2, $3, $4, $5, $6)", blockHash, txid, txIndex,
+			msgTx.Version, msgTx.LockTime, msgTx.SerializeSize())
+		if err != nil {
+			return err
+		}
+
+		if !blockchain.IsCoinBase(tx) {
+			for vin, txIn := range msgTx.TxIn {
+				prevOut := &txIn.PreviousOutPoint
+				_, err := dbTx.Exec("INSERT INTO inputs "+
+					"(block_hash, txid, vin, prev_txid, "+
+					"prev_vout) VALUES ($1, $2, $3, $4, $5)",
+					blockHash, txid, vin, prevOut.Hash.String(),
+					prevOut.Index)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		for vout, txOut := range msgTx.TxOut {
+			class, addrs, _, _ := txscript.ExtractPkScriptAddrs(
+				txOut.PkScript, e.cfg.ChainParams)
+			var address sql.NullString
+			if len(addrs) == 1 {
+				address.String = addrs[0].EncodeAddress()
+				address.Valid = true
+			}
+			_, err := dbTx.Exec("INSERT INTO outputs (block_hash, "+
+				"txid, vout, value, script_class, address, "+
+				"pk_script) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+				blockHash, txid, vout, txOut.Value, class.String(),
+				address, txOut.PkScript)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := insertAdminOps(dbTx, block, txIndex, tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertAdminOps inserts the admin operations of the passed transaction of the
+// passed block, along with the key changes they apply, using the passed
+// database transaction.  Nothing is inserted for other transactions.
+func insertAdminOps(dbTx *sql.Tx, block *provautil.Block, txIndex int, tx *provautil.Tx) error {
+	// Issue thread transactions issue or destroy coins instead of
+	// carrying admin operations.
+	threadInt, adminOutputs := txscript.GetAdminDetails(tx)
+	threadID := provautil.ThreadID(threadInt)
+	if threadInt < 0 || threadID == provautil.IssueThread {
+		return nil
+	}
+
+	blockHash := block.Hash().String()
+	height := block.MsgBlock().Header.Height
+	txid := tx.Hash().String()
+	for i, pops := range adminOutputs {
+		pkScript := tx.MsgTx().TxOut[i+1].PkScript
+		_, err := dbTx.Exec("INSERT INTO admin_ops (block_hash, txid, "+
+			"op_index, thread, op) VALUES ($1, $2, $3, $4, $5)",
+			blockHash, txid, i, uint32(threadID),
+			txscript.AdminOpString(pkScript))
+		if err != nil {
+			return err
+		}
+
+		// Only the operations on the admin key sets change the key
+		// state.
+		payload, err := txscript.ExtractAdminPayload(pops)
+		if err != nil || payload.Schema().Kind != adminop.KindKey {
+			continue
+		}
+		schema := payload.Schema()
+		isAddOp, keySetType := schema.IsAdd, schema.KeySet
+		pubKey, keyID := payload.PubKey, payload.KeyID
+		var serializedKey string
+		if pubKey != nil {
+			serializedKey = hex.EncodeToString(pubKey.SerializeCompressed())
+		}
+		_, err = dbTx.Exec("INSERT INTO key_changes (block_hash, "+
+			"height, txid, tx_index, op_index, key_set, pub_key, "+
+			"key_id, added) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, "+
+			"$9)", blockHash, height, txid, txIndex, i,
+			keySetType.String(), serializedKey, uint32(keyID), isAddOp)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,361 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package sqlexport_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/blockchain"
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/sqlexport"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// fakeChain is a main chain of blocks which can be reorganized.
+type fakeChain struct {
+	mtx  sync.Mutex
+	main []*provautil.Block
+}
+
+// extend connects a block with the passed transactions on top of the passed
+// parent, which disconnects the main chain blocks after the parent.
+func (c *fakeChain) extend(parent *provautil.Block, nonce uint32, txs ...*wire.MsgTx) *provautil.Block {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	header := &wire.BlockHeader{Nonce: uint64(nonce)}
+	if parent != nil {
+		header.PrevBlock = *parent.Hash()
+		header.Height = parent.MsgBlock().Header.Height + 1
+	}
+	msgBlock := wire.NewMsgBlock(header)
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{Index: wire.MaxPrevOutIndex},
+		Sequence:         nonce,
+	})
+	coinbase.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_TRUE}))
+	msgBlock.AddTransaction(coinbase)
+	for _, tx := range txs {
+		msgBlock.AddTransaction(tx)
+	}
+	block := provautil.NewBlock(msgBlock)
+
+	c.main = append(c.main[:header.Height], block)
+	return block
+}
+
+func (c *fakeChain) BestSnapshot() *blockchain.BestState {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return &blockchain.BestState{Height: uint32(len(c.main) - 1)}
+}
+
+func (c *fakeChain) BlockByHeight(height uint32) (*provautil.Block, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if int(height) >= len(c.main) {
+		return nil, errors.New("height beyond the main chain")
+	}
+	return c.main[height], nil
+}
+
+func (c *fakeChain) MainChainHasBlock(hash *chainhash.Hash) (bool, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for _, block := range c.main {
+		if block.Hash().IsEqual(hash) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeDB is a database/sql connector to an in-memory database which
+// understands the statements of the exporter.  The rows of each table are
+// kept as their inserted values, the first of which is the hash of the block
+// they belong to.  The requested number of commits fail first.
+type fakeDB struct {
+	mtx      sync.Mutex
+	tables   map[string][][]driver.Value
+	failures int
+}
+
+// rows returns the rows of the passed table.
+func (db *fakeDB) rows(table string) [][]driver.Value {
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+	return db.tables[table]
+}
+
+// waitBlocks waits until the database holds exactly the blocks with the passed
+// hashes, in order of their height.
+func (db *fakeDB) waitBlocks(t *testing.T, hashes ...string) {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var got []string
+		for _, row := range db.rows("blocks") {
+			got = append(got, row[0].(string))
+		}
+		if strings.Join(got, " ") == strings.Join(hashes, " ") {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got blocks %v, want %v", got, hashes)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// apply applies the passed statement with the passed arguments.
+//
+// This function MUST be called with the database lock held.
+func (db *fakeDB) apply(query string, args []driver.Value) {
+	fields := strings.Fields(query)
+	switch fields[0] {
+	case "INSERT":
+		db.tables[fields[2]] = append(db.tables[fields[2]], args)
+
+	case "DELETE":
+		for table, rows := range db.tables {
+			var kept [][]driver.Value
+			for _, row := range rows {
+				if row[0] != args[0] {
+					kept = append(kept, row)
+				}
+			}
+			db.tables[table] = kept
+		}
+	}
+}
+
+func (db *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{db: db}, nil
+}
+
+func (db *fakeDB) Driver() driver.Driver {
+	return nil
+}
+
+// fakeConn is a connection to a fake database.  The statements executed in a
+// transaction are applied when it is committed.
+type fakeConn struct {
+	db      *fakeDB
+	inTx    bool
+	pending []func()
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.inTx = true
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.db.mtx.Lock()
+	defer c.db.mtx.Unlock()
+
+	pending := c.pending
+	c.inTx, c.pending = false, nil
+	if c.db.failures > 0 {
+		c.db.failures--
+		return errors.New("database unavailable")
+	}
+	for _, apply := range pending {
+		apply()
+	}
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.inTx, c.pending = false, nil
+	return nil
+}
+
+// fakeStmt is a statement of a fake database connection.
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	db := s.conn.db
+	apply := func() { db.apply(s.query, args) }
+	if s.conn.inTx {
+		s.conn.pending = append(s.conn.pending, apply)
+		return driver.RowsAffected(1), nil
+	}
+	db.mtx.Lock()
+	apply()
+	db.mtx.Unlock()
+	return driver.RowsAffected(1), nil
+}
+
+// Query answers the query for the highest block, which is the only query of
+// the exporter.
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	db := s.conn.db
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	rows := &fakeRows{}
+	for _, row := range db.tables["blocks"] {
+		if rows.row == nil || row[1].(int64) > rows.row[1].(int64) {
+			rows.row = []driver.Value{row[0], row[1]}
+		}
+	}
+	return rows, nil
+}
+
+// fakeRows holds the result of a query for the highest block.
+type fakeRows struct {
+	row []driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"hash", "height"}
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.row == nil {
+		return io.EOF
+	}
+	copy(dest, r.row)
+	r.row = nil
+	return nil
+}
+
+// TestExporter ensures the exporter mirrors the blocks of the main chain along
+// with their transactions, inputs, outputs, admin operations and key changes,
+// retries after a failure and resumes after the highest block in the
+// database, and that the blocks disconnected by a reorganization while it was
+// stopped are deleted.
+func TestExporter(t *testing.T) {
+	// The first block after the genesis block holds a root thread
+	// transaction provisioning a key.
+	privKey, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("NewPrivateKey: %v", err)
+	}
+	opData := append([]byte{txscript.AdminOpProvisionKeyAdd},
+		privKey.PubKey().SerializeCompressed()...)
+	opScript, err := txscript.NullDataScript(opData)
+	if err != nil {
+		t.Fatalf("NullDataScript: %v", err)
+	}
+	threadScript, err := txscript.ProvaThreadScript(provautil.RootThread)
+	if err != nil {
+		t.Fatalf("ProvaThreadScript: %v", err)
+	}
+	adminTx := wire.NewMsgTx(wire.TxVersion)
+	adminTx.AddTxIn(&wire.TxIn{})
+	adminTx.AddTxOut(wire.NewTxOut(0, threadScript))
+	adminTx.AddTxOut(wire.NewTxOut(0, opScript))
+
+	chain := &fakeChain{}
+	genesis := chain.extend(nil, 0)
+	block1 := chain.extend(genesis, 1, adminTx)
+
+	db := &fakeDB{tables: make(map[string][][]driver.Value), failures: 1}
+	config := &sqlexport.Config{
+		Chain:         chain,
+		ChainParams:   &chaincfg.RegressionNetParams,
+		DB:            sql.OpenDB(db),
+		RetryInterval: 10 * time.Millisecond,
+	}
+	exporter, err := sqlexport.New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	exporter.Start()
+	db.waitBlocks(t, genesis.Hash().String(), block1.Hash().String())
+	if err := exporter.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	wantRows := map[string]int{
+		"transactions": 3,
+		"inputs":       1,
+		"outputs":      4,
+		"admin_ops":    1,
+		"key_changes":  1,
+	}
+	for table, want := range wantRows {
+		if got := len(db.rows(table)); got != want {
+			t.Fatalf("got %d rows in %s, want %d", got, table, want)
+		}
+	}
+	keyChange := db.rows("key_changes")[0]
+	wantPubKey := privKey.PubKey().SerializeCompressed()
+	if keyChange[5] != btcec.ProvisionKeySet.String() ||
+		keyChange[8] != true || keyChange[6] != hex.EncodeToString(wantPubKey) {
+
+		t.Fatalf("unexpected key change %v", keyChange)
+	}
+
+	// Reorganize the chain while the exporter is stopped.  The new
+	// exporter deletes the disconnected block along with its rows before
+	// writing the new main chain blocks.
+	block1b := chain.extend(genesis, 2)
+	block2b := chain.extend(block1b, 3)
+	config.DB = sql.OpenDB(db)
+	exporter, err = sqlexport.New(config)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	exporter.Start()
+	exporter.Notify()
+	db.waitBlocks(t, genesis.Hash().String(), block1b.Hash().String(),
+		block2b.Hash().String())
+	if err := exporter.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	wantRows = map[string]int{
+		"transactions": 3,
+		"inputs":       0,
+		"outputs":      3,
+		"admin_ops":    0,
+		"key_changes":  0,
+	}
+	for table, want := range wantRows {
+		if got := len(db.rows(table)); got != want {
+			t.Fatalf("got %d rows in %s after the reorganization, "+
+				"want %d", got, table, want)
+		}
+	}
+}
@@ -0,0 +1,589 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package adminop implements the payload formats of the admin ops, which admin
+// transactions carry in the data pushed by their <OP_RETURN><OP_DATA> outputs.
+//
+// Every payload starts with the op byte, followed by the fields of the schema
+// registered for the op.  The schemas are versioned and looked up in a
+// registry, so new ops are added by registering their schema.
+package adminop
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/golangcrypto/ripemd160"
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/provautil"
+)
+
+// Version is the latest version of the admin op schemas known to this
+// software.  It is increased whenever ops are added, and the schemas of the
+// added ops carry the new version.
+const Version = 3
+
+// Op is the type byte which starts every admin op payload.
+type Op byte
+
+// These constants define the op bytes of the admin ops.  The first nybble is
+// the thread the op is valid on.
+const (
+	IssueKeyAdd        Op = 0x01 // 1
+	IssueKeyRevoke     Op = 0x02 // 2
+	ProvisionKeyAdd    Op = 0x03 // 3
+	ProvisionKeyRevoke Op = 0x04 // 4
+	IssueDestAdd       Op = 0x05 // 5
+	IssueDestRevoke    Op = 0x06 // 6
+	ValidateKeyAdd     Op = 0x11 // 17
+	ValidateKeyRevoke  Op = 0x12 // 18
+	ASPKeyAdd          Op = 0x13 // 19
+	ASPKeyRevoke       Op = 0x14 // 20
+	AccountFreeze      Op = 0x15 // 21
+	AccountUnfreeze    Op = 0x16 // 22
+	ValidateKeyAddExp  Op = 0x17 // 23
+	ASPKeyAddExp       Op = 0x18 // 24
+	DelegateKeyAdd     Op = 0x19 // 25
+	DelegateKeyRevoke  Op = 0x1a // 26
+	AssetID            Op = 0x21 // 33
+)
+
+// String returns the name of the op, or its value when it has no registered
+// schema.
+func (op Op) String() string {
+	if schema := Lookup(op); schema != nil {
+		return schema.Name
+	}
+	return fmt.Sprintf("Unknown Op (0x%02x)", byte(op))
+}
+
+const (
+	// IssueDestLen is the length of an issuance destination: the
+	// pubKeyHash of a standard Prova address followed by its two keyIDs.
+	IssueDestLen = ripemd160.Size + 2*btcec.KeyIDSize
+
+	// AssetIDLen is the length of an asset ID.
+	AssetIDLen = 4
+
+	// ExpiryLen is the length of an expiry height.
+	ExpiryLen = 4
+
+	// ScopeLen is the length of a delegation scope.
+	ScopeLen = 1
+)
+
+// Scope is the set of provision thread ops a delegate key may sign on its
+// own, as granted by a DelegateKeyAdd op.
+type Scope uint8
+
+// These constants define the ops a delegation scope can grant.
+const (
+	// ScopeASPKeyAdd grants the provisioning of ASP keys, with or without
+	// an expiry height, whose keyIDs are in the delegated range.
+	ScopeASPKeyAdd Scope = 1 << iota
+
+	// ScopeASPKeyRevoke grants the revocation of ASP keys whose keyIDs
+	// are in the delegated range.
+	ScopeASPKeyRevoke
+
+	// ScopeAccountFreeze grants freezing accounts.
+	ScopeAccountFreeze
+
+	// ScopeAccountUnfreeze grants unfreezing accounts.
+	ScopeAccountUnfreeze
+
+	// scopeAll holds all ops a scope can grant.
+	scopeAll = ScopeASPKeyAdd | ScopeASPKeyRevoke | ScopeAccountFreeze |
+		ScopeAccountUnfreeze
+)
+
+// scopeOps maps the ops which can be delegated to the scope granting them.
+var scopeOps = map[Op]Scope{
+	ASPKeyAdd:       ScopeASPKeyAdd,
+	ASPKeyAddExp:    ScopeASPKeyAdd,
+	ASPKeyRevoke:    ScopeASPKeyRevoke,
+	AccountFreeze:   ScopeAccountFreeze,
+	AccountUnfreeze: ScopeAccountUnfreeze,
+}
+
+// IsValid returns whether the scope grants at least one op and only ops which
+// can be delegated.
+func (s Scope) IsValid() bool {
+	return s != 0 && s&^scopeAll == 0
+}
+
+// Allows returns whether the scope grants the passed op.  Ops which can not be
+// delegated, such as the delegation ops themselves, are never granted.
+func (s Scope) Allows(op Op) bool {
+	scope, ok := scopeOps[op]
+	return ok && s&scope != 0
+}
+
+// Kind identifies the admin state an op changes, which determines how the op
+// is applied.
+type Kind uint8
+
+// These constants define the kinds of admin ops.
+const (
+	// KindKey ops add a key to or revoke a key from an admin key set.
+	KindKey Kind = iota
+
+	// KindIssueDest ops add or revoke an issuance destination.
+	KindIssueDest
+
+	// KindFreeze ops freeze or unfreeze an account.
+	KindFreeze
+
+	// KindAssetID ops are asset markers, tagging the outputs of their
+	// transaction with an asset ID.  They are not valid on any admin
+	// thread.
+	KindAssetID
+
+	// KindDelegate ops grant a key a scope of provision thread ops it
+	// may sign on its own, or revoke the grant.
+	KindDelegate
+)
+
+// Field identifies a field of an admin op payload.
+type Field uint8
+
+// These constants define the fields of admin op payloads.
+const (
+	// FieldPubKey is a compressed public key, decoded into Payload.PubKey.
+	FieldPubKey Field = iota
+
+	// FieldKeyID is a keyID in address format, decoded into
+	// Payload.KeyID.
+	FieldKeyID
+
+	// FieldIssueDest is an issuance destination, decoded into
+	// Payload.IssueDest.
+	FieldIssueDest
+
+	// FieldPubKeyHash is the pubKeyHash of an account, decoded into
+	// Payload.PubKeyHash.
+	FieldPubKeyHash
+
+	// FieldAssetID is a little endian asset ID, decoded into
+	// Payload.AssetID.
+	FieldAssetID
+
+	// FieldExpiry is the little endian height of the last block a key is
+	// valid in, decoded into Payload.Expiry.
+	FieldExpiry
+
+	// FieldScope is a delegation scope, decoded into Payload.Scope.
+	FieldScope
+
+	// FieldMaxKeyID is the highest keyID of a delegated keyID range in
+	// address format, decoded into Payload.MaxKeyID.  The lowest one is
+	// carried in a FieldKeyID.
+	FieldMaxKeyID
+)
+
+// fieldLens maps the fields to their serialized length.
+var fieldLens = map[Field]int{
+	FieldPubKey:     btcec.PubKeyBytesLenCompressed,
+	FieldKeyID:      btcec.KeyIDSize,
+	FieldIssueDest:  IssueDestLen,
+	FieldPubKeyHash: ripemd160.Size,
+	FieldAssetID:    AssetIDLen,
+	FieldExpiry:     ExpiryLen,
+	FieldScope:      ScopeLen,
+	FieldMaxKeyID:   btcec.KeyIDSize,
+}
+
+// Len returns the serialized length of the field.
+func (f Field) Len() int {
+	return fieldLens[f]
+}
+
+// Schema describes the payload format of an admin op and where it is valid.
+type Schema struct {
+	Op   Op
+	Name string
+	Kind Kind
+
+	// IsAdd is true for the ops which add a key or issuance destination,
+	// freeze an account or delegate a scope, and false for the ops which
+	// undo them.
+	IsAdd bool
+
+	// KeySet is the admin key set modified by KindKey ops.
+	KeySet btcec.KeySetType
+
+	// Threads are the admin threads the op is valid on.
+	Threads []provautil.ThreadID
+
+	// Fields are the fields following the op byte, in order.
+	// OptionalFields may follow them as a whole or be omitted, and are
+	// never encoded.  They only exist for payloads which were accepted
+	// before the schemas were made strict.
+	Fields         []Field
+	OptionalFields []Field
+
+	// Version is the schema version which introduced the op.
+	Version uint32
+}
+
+// fieldsLen returns the serialized length of the passed fields.
+func fieldsLen(fields []Field) int {
+	var n int
+	for _, field := range fields {
+		n += fieldLens[field]
+	}
+	return n
+}
+
+// Len returns the length of the encoded payload, including the op byte.
+func (s *Schema) Len() int {
+	return 1 + fieldsLen(s.Fields)
+}
+
+// MaxLen returns the length of a payload carrying the optional fields,
+// including the op byte.
+func (s *Schema) MaxLen() int {
+	return s.Len() + fieldsLen(s.OptionalFields)
+}
+
+// ValidOn returns whether the op is valid on the passed admin thread.
+func (s *Schema) ValidOn(threadID provautil.ThreadID) bool {
+	for _, t := range s.Threads {
+		if t == threadID {
+			return true
+		}
+	}
+	return false
+}
+
+// registry holds the registered schemas by op byte.
+var registry = make(map[Op]*Schema)
+
+// Register registers the schema of a new admin op, after which its payloads
+// are decoded and encoded.  New ops are introduced by registering their schema
+// with an increased Version, without changing the code decoding the existing
+// ops.
+//
+// Register must be called from an init function, since the registry is not
+// safe for concurrent modification.
+func Register(schema *Schema) error {
+	if _, ok := registry[schema.Op]; ok {
+		str := fmt.Sprintf("op 0x%02x is already registered",
+			byte(schema.Op))
+		return payloadError(ErrDuplicateOp, str)
+	}
+	if schema.Version == 0 || schema.Version > Version {
+		str := fmt.Sprintf("schema of op 0x%02x has version %d, want "+
+			"1 to %d", byte(schema.Op), schema.Version, Version)
+		return payloadError(ErrInvalidSchema, str)
+	}
+	for _, fields := range [][]Field{schema.Fields, schema.OptionalFields} {
+		for _, field := range fields {
+			if _, ok := fieldLens[field]; !ok {
+				str := fmt.Sprintf("schema of op 0x%02x has "+
+					"unknown field %d", byte(schema.Op), field)
+				return payloadError(ErrInvalidSchema, str)
+			}
+		}
+	}
+	registry[schema.Op] = schema
+	return nil
+}
+
+// mustRegister registers the passed schema and panics on error.  It is only
+// used for the schemas known to this package.
+func mustRegister(schema *Schema) {
+	if err := Register(schema); err != nil {
+		panic(fmt.Sprintf("failed to register schema: %v", err))
+	}
+}
+
+// Lookup returns the schema registered for the passed op, or nil when there
+// is none.
+func Lookup(op Op) *Schema {
+	return registry[op]
+}
+
+// Schemas returns the registered schemas ordered by op byte.
+func Schemas() []*Schema {
+	schemas := make([]*Schema, 0, len(registry))
+	for _, schema := range registry {
+		schemas = append(schemas, schema)
+	}
+	sort.Slice(schemas, func(i, j int) bool {
+		return schemas[i].Op < schemas[j].Op
+	})
+	return schemas
+}
+
+// Payload is a decoded admin op.  Only the fields of the schema of its op are
+// set.
+type Payload struct {
+	Op         Op
+	PubKey     *btcec.PublicKey
+	KeyID      btcec.KeyID
+	IssueDest  [IssueDestLen]byte
+	PubKeyHash [ripemd160.Size]byte
+	AssetID    uint32
+	Expiry     uint32
+	Scope      Scope
+	MaxKeyID   btcec.KeyID
+}
+
+// Schema returns the schema of the op of the payload, or nil when there is
+// none.
+func (p *Payload) Schema() *Schema {
+	return Lookup(p.Op)
+}
+
+// decodeField decodes the passed field from the start of data, which is long
+// enough to hold it, into the payload.
+func (p *Payload) decodeField(field Field, data []byte) error {
+	switch field {
+	case FieldPubKey:
+		pubKey, err := btcec.ParsePubKey(data[:btcec.PubKeyBytesLenCompressed],
+			btcec.S256())
+		if err != nil {
+			str := fmt.Sprintf("invalid public key: %v", err)
+			return payloadError(ErrInvalidPubKey, str)
+		}
+		p.PubKey = pubKey
+	case FieldKeyID:
+		p.KeyID = btcec.KeyIDFromAddressBuffer(data[:btcec.KeyIDSize])
+	case FieldIssueDest:
+		copy(p.IssueDest[:], data)
+	case FieldPubKeyHash:
+		copy(p.PubKeyHash[:], data)
+	case FieldAssetID:
+		p.AssetID = binary.LittleEndian.Uint32(data)
+	case FieldExpiry:
+		p.Expiry = binary.LittleEndian.Uint32(data)
+	case FieldScope:
+		p.Scope = Scope(data[0])
+		if !p.Scope.IsValid() {
+			str := fmt.Sprintf("invalid delegation scope 0x%02x",
+				data[0])
+			return payloadError(ErrInvalidScope, str)
+		}
+	case FieldMaxKeyID:
+		p.MaxKeyID = btcec.KeyIDFromAddressBuffer(data[:btcec.KeyIDSize])
+	}
+	return nil
+}
+
+// encodeField appends the passed field of the payload to buf.
+func (p *Payload) encodeField(buf []byte, field Field) ([]byte, error) {
+	switch field {
+	case FieldPubKey:
+		if p.PubKey == nil {
+			return nil, payloadError(ErrInvalidPubKey,
+				"payload has no public key")
+		}
+		buf = append(buf, p.PubKey.SerializeCompressed()...)
+	case FieldKeyID:
+		var keyID [btcec.KeyIDSize]byte
+		p.KeyID.ToAddressFormat(keyID[:])
+		buf = append(buf, keyID[:]...)
+	case FieldIssueDest:
+		buf = append(buf, p.IssueDest[:]...)
+	case FieldPubKeyHash:
+		buf = append(buf, p.PubKeyHash[:]...)
+	case FieldAssetID:
+		var assetID [AssetIDLen]byte
+		binary.LittleEndian.PutUint32(assetID[:], p.AssetID)
+		buf = append(buf, assetID[:]...)
+	case FieldExpiry:
+		var expiry [ExpiryLen]byte
+		binary.LittleEndian.PutUint32(expiry[:], p.Expiry)
+		buf = append(buf, expiry[:]...)
+	case FieldScope:
+		buf = append(buf, byte(p.Scope))
+	case FieldMaxKeyID:
+		var keyID [btcec.KeyIDSize]byte
+		p.MaxKeyID.ToAddressFormat(keyID[:])
+		buf = append(buf, keyID[:]...)
+	}
+	return buf, nil
+}
+
+// DecodeVersion decodes the passed admin op payload, which starts with the op
+// byte, with the schemas of the passed version.  The decoding is strict: the
+// op must have a schema of at most the passed version, the payload must be
+// exactly as long as its fields, with or without all of the optional ones,
+// public keys must be valid compressed keys and delegation scopes must be
+// valid.
+func DecodeVersion(data []byte, version uint32) (*Payload, error) {
+	if len(data) == 0 {
+		return nil, payloadError(ErrInvalidLength, "empty payload")
+	}
+	op := Op(data[0])
+	schema := Lookup(op)
+	if schema == nil || schema.Version > version {
+		str := fmt.Sprintf("unknown admin op 0x%02x", byte(op))
+		return nil, payloadError(ErrUnknownOp, str)
+	}
+	fields := schema.Fields
+	switch len(data) {
+	case schema.Len():
+	case schema.MaxLen():
+		fields = append(fields[:len(fields):len(fields)],
+			schema.OptionalFields...)
+	default:
+		str := fmt.Sprintf("%s payload is %d bytes, want %d",
+			schema.Name, len(data), schema.Len())
+		if schema.MaxLen() != schema.Len() {
+			str += fmt.Sprintf(" or %d", schema.MaxLen())
+		}
+		return nil, payloadError(ErrInvalidLength, str)
+	}
+
+	payload := &Payload{Op: op}
+	offset := 1
+	for _, field := range fields {
+		if err := payload.decodeField(field, data[offset:]); err != nil {
+			return nil, err
+		}
+		offset += fieldLens[field]
+	}
+	return payload, nil
+}
+
+// Decode decodes the passed admin op payload with the latest schemas.  See
+// DecodeVersion for the checks applied.
+func Decode(data []byte) (*Payload, error) {
+	return DecodeVersion(data, Version)
+}
+
+// Encode returns the serialized payload of the passed admin op, starting with
+// the op byte.  The optional fields of its schema are not encoded.
+func Encode(p *Payload) ([]byte, error) {
+	schema := p.Schema()
+	if schema == nil {
+		str := fmt.Sprintf("unknown admin op 0x%02x", byte(p.Op))
+		return nil, payloadError(ErrUnknownOp, str)
+	}
+	buf := make([]byte, 1, schema.Len())
+	buf[0] = byte(p.Op)
+	for _, field := range schema.Fields {
+		var err error
+		buf, err = p.encodeField(buf, field)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func init() {
+	root := []provautil.ThreadID{provautil.RootThread}
+	provision := []provautil.ThreadID{provautil.ProvisionThread}
+
+	// The keys of the issue, provision and validate key sets may be
+	// followed by a keyID, which is ignored.
+	keyOps := []struct {
+		add, revoke Op
+		name        string
+		keySet      btcec.KeySetType
+		threads     []provautil.ThreadID
+	}{
+		{IssueKeyAdd, IssueKeyRevoke, "Issue", btcec.IssueKeySet, root},
+		{ProvisionKeyAdd, ProvisionKeyRevoke, "Provision",
+			btcec.ProvisionKeySet, root},
+		{ValidateKeyAdd, ValidateKeyRevoke, "Validate",
+			btcec.ValidateKeySet, provision},
+	}
+	for _, keyOp := range keyOps {
+		for _, isAdd := range []bool{true, false} {
+			op, name := keyOp.revoke, keyOp.name+"KeyRevoke"
+			if isAdd {
+				op, name = keyOp.add, keyOp.name+"KeyAdd"
+			}
+			mustRegister(&Schema{
+				Op:             op,
+				Name:           name,
+				Kind:           KindKey,
+				IsAdd:          isAdd,
+				KeySet:         keyOp.keySet,
+				Threads:        keyOp.threads,
+				Fields:         []Field{FieldPubKey},
+				OptionalFields: []Field{FieldKeyID},
+				Version:        1,
+			})
+		}
+	}
+
+	schemas := []*Schema{
+		{Op: ASPKeyAdd, Name: "ASPKeyAdd", Kind: KindKey, IsAdd: true,
+			KeySet: btcec.ASPKeySet, Threads: provision,
+			Fields: []Field{FieldPubKey, FieldKeyID}},
+		{Op: ASPKeyRevoke, Name: "ASPKeyRevoke", Kind: KindKey,
+			KeySet: btcec.ASPKeySet, Threads: provision,
+			Fields: []Field{FieldPubKey, FieldKeyID}},
+		{Op: IssueDestAdd, Name: "IssueDestAdd", Kind: KindIssueDest,
+			IsAdd: true, Threads: root,
+			Fields: []Field{FieldIssueDest}},
+		{Op: IssueDestRevoke, Name: "IssueDestRevoke",
+			Kind: KindIssueDest, Threads: root,
+			Fields: []Field{FieldIssueDest}},
+		{Op: AccountFreeze, Name: "AccountFreeze", Kind: KindFreeze,
+			IsAdd: true, Threads: provision,
+			Fields: []Field{FieldPubKeyHash}},
+		{Op: AccountUnfreeze, Name: "AccountUnfreeze", Kind: KindFreeze,
+			Threads: provision, Fields: []Field{FieldPubKeyHash}},
+		{Op: AssetID, Name: "AssetID", Kind: KindAssetID,
+			Fields: []Field{FieldAssetID}},
+	}
+	for _, schema := range schemas {
+		schema.Version = 1
+		mustRegister(schema)
+	}
+
+	// Version 2 adds the provisioning of validate and ASP keys which are
+	// revoked after an expiry height.
+	mustRegister(&Schema{
+		Op:      ValidateKeyAddExp,
+		Name:    "ValidateKeyAddExp",
+		Kind:    KindKey,
+		IsAdd:   true,
+		KeySet:  btcec.ValidateKeySet,
+		Threads: provision,
+		Fields:  []Field{FieldPubKey, FieldExpiry},
+		Version: 2,
+	})
+	mustRegister(&Schema{
+		Op:      ASPKeyAddExp,
+		Name:    "ASPKeyAddExp",
+		Kind:    KindKey,
+		IsAdd:   true,
+		KeySet:  btcec.ASPKeySet,
+		Threads: provision,
+		Fields:  []Field{FieldPubKey, FieldKeyID, FieldExpiry},
+		Version: 2,
+	})
+
+	// Version 3 adds the delegation of a scope of provision thread ops,
+	// limited to a range of keyIDs for the ASP key ops, to a key which
+	// may then sign them on its own.  The revocation carries the whole
+	// delegation, which must match the one it revokes.
+	delegateFields := []Field{FieldPubKey, FieldScope, FieldKeyID,
+		FieldMaxKeyID}
+	mustRegister(&Schema{
+		Op:      DelegateKeyAdd,
+		Name:    "DelegateKeyAdd",
+		Kind:    KindDelegate,
+		IsAdd:   true,
+		Threads: provision,
+		Fields:  delegateFields,
+		Version: 3,
+	})
+	mustRegister(&Schema{
+		Op:      DelegateKeyRevoke,
+		Name:    "DelegateKeyRevoke",
+		Kind:    KindDelegate,
+		Threads: provision,
+		Fields:  delegateFields,
+		Version: 3,
+	})
+}
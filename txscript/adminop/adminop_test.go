@@ -0,0 +1,289 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package adminop
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/provautil"
+)
+
+// hexToBytes converts the passed hex string into bytes and will panic if there
+// is an error.  This is only provided for the hard-coded constants so errors in
+// the source code can be detected.  It will only (and must only) be called with
+// hard-coded values.
+func hexToBytes(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic("invalid hex in source file: " + s)
+	}
+	return b
+}
+
+// TestDecode ensures payloads are only decoded when they match the schema of
+// their op exactly.
+func TestDecode(t *testing.T) {
+	pubKey := hexToBytes("025ceeba2ab4a635df2c0301a3d773da06ac5a18a7c3e0d09a795d7e57d233edf1")
+	keyID := []byte{0x03, 0x00, 0x00, 0x00}
+	cat := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		err  ErrorCode
+		want *Payload
+	}{
+		{
+			name: "issue key add",
+			data: cat([]byte{byte(IssueKeyAdd)}, pubKey),
+			want: &Payload{Op: IssueKeyAdd},
+		},
+		{
+			name: "provision key add with ignored keyID",
+			data: cat([]byte{byte(ProvisionKeyAdd)}, pubKey, keyID),
+			want: &Payload{Op: ProvisionKeyAdd, KeyID: 3},
+		},
+		{
+			name: "asp key add",
+			data: cat([]byte{byte(ASPKeyAdd)}, pubKey, keyID),
+			want: &Payload{Op: ASPKeyAdd, KeyID: 3},
+		},
+		{
+			name: "asp key add with expiry",
+			data: cat([]byte{byte(ASPKeyAddExp)}, pubKey, keyID,
+				[]byte{0xe8, 0x03, 0x00, 0x00}),
+			want: &Payload{Op: ASPKeyAddExp, KeyID: 3, Expiry: 1000},
+		},
+		{
+			name: "validate key add with expiry",
+			data: cat([]byte{byte(ValidateKeyAddExp)}, pubKey,
+				[]byte{0xe8, 0x03, 0x00, 0x00}),
+			want: &Payload{Op: ValidateKeyAddExp, Expiry: 1000},
+		},
+		{
+			name: "delegate key add",
+			data: cat([]byte{byte(DelegateKeyAdd)}, pubKey,
+				[]byte{byte(ScopeASPKeyAdd | ScopeASPKeyRevoke)},
+				keyID, []byte{0x07, 0x00, 0x00, 0x00}),
+			want: &Payload{Op: DelegateKeyAdd, KeyID: 3, MaxKeyID: 7,
+				Scope: ScopeASPKeyAdd | ScopeASPKeyRevoke},
+		},
+		{
+			name: "delegate key add with empty scope",
+			data: cat([]byte{byte(DelegateKeyAdd)}, pubKey, []byte{0},
+				keyID, keyID),
+			err: ErrInvalidScope,
+		},
+		{
+			name: "delegate key revoke with unknown scope",
+			data: cat([]byte{byte(DelegateKeyRevoke)}, pubKey,
+				[]byte{0x11}, keyID, keyID),
+			err: ErrInvalidScope,
+		},
+		{
+			name: "asp key add without keyID",
+			data: cat([]byte{byte(ASPKeyAdd)}, pubKey),
+			err:  ErrInvalidLength,
+		},
+		{
+			name: "validate key add with partial keyID",
+			data: cat([]byte{byte(ValidateKeyAdd)}, pubKey, keyID[:2]),
+			err:  ErrInvalidLength,
+		},
+		{
+			name: "uncompressed key prefix",
+			data: cat([]byte{byte(IssueKeyRevoke), 0x04}, pubKey[1:]),
+			err:  ErrInvalidPubKey,
+		},
+		{
+			name: "freeze",
+			data: cat([]byte{byte(AccountFreeze)}, bytes.Repeat([]byte{1}, 20)),
+			want: &Payload{Op: AccountFreeze, PubKeyHash: [20]byte{1, 1,
+				1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}},
+		},
+		{
+			name: "issue dest too long",
+			data: cat([]byte{byte(IssueDestAdd)}, make([]byte, 29)),
+			err:  ErrInvalidLength,
+		},
+		{
+			name: "asset ID",
+			data: []byte{byte(AssetID), 0x04, 0x03, 0x02, 0x01},
+			want: &Payload{Op: AssetID, AssetID: 0x01020304},
+		},
+		{
+			name: "unknown op",
+			data: cat([]byte{0x07}, pubKey),
+			err:  ErrUnknownOp,
+		},
+		{
+			name: "empty",
+			data: nil,
+			err:  ErrInvalidLength,
+		},
+	}
+
+	for _, test := range tests {
+		payload, err := Decode(test.data)
+		if test.want == nil {
+			if e, ok := err.(Error); !ok || e.ErrorCode != test.err {
+				t.Errorf("%s: got error %v, want %v", test.name,
+					err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if payload.Op != test.want.Op || payload.KeyID != test.want.KeyID ||
+			payload.PubKeyHash != test.want.PubKeyHash ||
+			payload.AssetID != test.want.AssetID ||
+			payload.Expiry != test.want.Expiry ||
+			payload.Scope != test.want.Scope ||
+			payload.MaxKeyID != test.want.MaxKeyID {
+
+			t.Errorf("%s: got payload %+v, want %+v", test.name,
+				payload, test.want)
+			continue
+		}
+		kind := payload.Schema().Kind
+		if (kind == KindKey || kind == KindDelegate) &&
+			!bytes.Equal(payload.PubKey.SerializeCompressed(), pubKey) {
+
+			t.Errorf("%s: got public key %x, want %x", test.name,
+				payload.PubKey.SerializeCompressed(), pubKey)
+		}
+
+		// The payload encodes back to the data without the optional
+		// fields.
+		encoded, err := Encode(payload)
+		if err != nil {
+			t.Errorf("%s: Encode: %v", test.name, err)
+			continue
+		}
+		if want := test.data[:payload.Schema().Len()]; !bytes.Equal(encoded, want) {
+			t.Errorf("%s: Encode: got %x, want %x", test.name,
+				encoded, want)
+		}
+	}
+
+	// Ops are unknown to the schema versions before the one which
+	// introduced them.
+	_, err := DecodeVersion(tests[0].data, 0)
+	if e, ok := err.(Error); !ok || e.ErrorCode != ErrUnknownOp {
+		t.Errorf("DecodeVersion: got error %v, want %v", err,
+			ErrUnknownOp)
+	}
+	_, err = DecodeVersion(tests[3].data, 1)
+	if e, ok := err.(Error); !ok || e.ErrorCode != ErrUnknownOp {
+		t.Errorf("DecodeVersion: got error %v for an expiring key at "+
+			"version 1, want %v", err, ErrUnknownOp)
+	}
+}
+
+// TestSchemas ensures the registered schemas are valid on the threads of the
+// first nybble of their op, and that schemas which conflict with them or are
+// malformed are not registered.
+func TestSchemas(t *testing.T) {
+	schemas := Schemas()
+	if len(schemas) != 17 {
+		t.Fatalf("got %d schemas, want 17", len(schemas))
+	}
+	for _, schema := range schemas {
+		thread := provautil.ThreadID(schema.Op >> 4)
+		if schema.Kind == KindAssetID {
+			if len(schema.Threads) != 0 {
+				t.Errorf("%v: valid on threads %v", schema.Op,
+					schema.Threads)
+			}
+			continue
+		}
+		if len(schema.Threads) != 1 || !schema.ValidOn(thread) {
+			t.Errorf("%v: valid on threads %v, want %v", schema.Op,
+				schema.Threads, thread)
+		}
+		wantLen := 1 + btcec.PubKeyBytesLenCompressed + btcec.KeyIDSize
+		if schema.Version > 1 {
+			wantLen += ExpiryLen
+		}
+		if schema.Kind == KindKey && schema.KeySet == btcec.ASPKeySet &&
+			schema.Len() != wantLen {
+
+			t.Errorf("%v: payload length %d", schema.Op, schema.Len())
+		}
+	}
+
+	tests := []struct {
+		name   string
+		schema Schema
+		err    ErrorCode
+	}{
+		{
+			name:   "duplicate op",
+			schema: Schema{Op: IssueKeyAdd, Version: 1},
+			err:    ErrDuplicateOp,
+		},
+		{
+			name:   "version 0",
+			schema: Schema{Op: 0x07},
+			err:    ErrInvalidSchema,
+		},
+		{
+			name:   "future version",
+			schema: Schema{Op: 0x07, Version: Version + 1},
+			err:    ErrInvalidSchema,
+		},
+		{
+			name: "unknown field",
+			schema: Schema{Op: 0x07, Version: 1,
+				OptionalFields: []Field{FieldMaxKeyID + 1}},
+			err: ErrInvalidSchema,
+		},
+	}
+	for _, test := range tests {
+		err := Register(&test.schema)
+		if e, ok := err.(Error); !ok || e.ErrorCode != test.err {
+			t.Errorf("%s: got error %v, want %v", test.name, err,
+				test.err)
+		}
+	}
+	if Lookup(0x07) != nil {
+		t.Fatalf("malformed schema was registered")
+	}
+}
+
+// TestScope ensures delegation scopes only grant the provision thread ops they
+// name.
+func TestScope(t *testing.T) {
+	scope := ScopeASPKeyAdd | ScopeAccountUnfreeze
+	tests := []struct {
+		op   Op
+		want bool
+	}{
+		{ASPKeyAdd, true},
+		{ASPKeyAddExp, true},
+		{ASPKeyRevoke, false},
+		{AccountFreeze, false},
+		{AccountUnfreeze, true},
+		{ValidateKeyAdd, false},
+		{DelegateKeyAdd, false},
+		{ProvisionKeyAdd, false},
+	}
+	for _, test := range tests {
+		if got := scope.Allows(test.op); got != test.want {
+			t.Errorf("Allows(%v): got %v, want %v", test.op, got,
+				test.want)
+		}
+	}
+	if !scope.IsValid() || Scope(0).IsValid() || Scope(0x80).IsValid() {
+		t.Errorf("IsValid: only scopes granting known ops are valid")
+	}
+}
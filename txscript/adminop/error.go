@@ -0,0 +1,75 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package adminop
+
+import (
+	"fmt"
+)
+
+// ErrorCode identifies a kind of error.
+type ErrorCode int
+
+// These constants are used to identify a specific Error.
+const (
+	// ErrUnknownOp indicates the op byte of a payload has no registered
+	// schema, or one introduced by a later schema version than the one the
+	// payload is decoded with.
+	ErrUnknownOp ErrorCode = iota
+
+	// ErrInvalidLength indicates the length of a payload does not match
+	// the schema of its op.
+	ErrInvalidLength
+
+	// ErrInvalidPubKey indicates the public key of a payload is missing or
+	// is not a valid compressed public key.
+	ErrInvalidPubKey
+
+	// ErrInvalidSchema indicates a schema can not be registered because it
+	// is malformed.
+	ErrInvalidSchema
+
+	// ErrDuplicateOp indicates a schema can not be registered because its
+	// op byte already has one.
+	ErrDuplicateOp
+
+	// ErrInvalidScope indicates the delegation scope of a payload grants
+	// no op or ops which can not be delegated.
+	ErrInvalidScope
+)
+
+// Map of ErrorCode values back to their constant names for pretty printing.
+var errorCodeStrings = map[ErrorCode]string{
+	ErrUnknownOp:     "ErrUnknownOp",
+	ErrInvalidLength: "ErrInvalidLength",
+	ErrInvalidPubKey: "ErrInvalidPubKey",
+	ErrInvalidSchema: "ErrInvalidSchema",
+	ErrDuplicateOp:   "ErrDuplicateOp",
+	ErrInvalidScope:  "ErrInvalidScope",
+}
+
+// String returns the ErrorCode as a human-readable name.
+func (e ErrorCode) String() string {
+	if s := errorCodeStrings[e]; s != "" {
+		return s
+	}
+	return fmt.Sprintf("Unknown ErrorCode (%d)", int(e))
+}
+
+// Error identifies a payload which does not match the schema of its op, or a
+// schema which can not be registered.
+type Error struct {
+	ErrorCode   ErrorCode // Describes the kind of error
+	Description string    // Human readable description of the issue
+}
+
+// Error satisfies the error interface and prints human-readable errors.
+func (e Error) Error() string {
+	return e.Description
+}
+
+// payloadError creates an Error given a set of arguments.
+func payloadError(c ErrorCode, desc string) Error {
+	return Error{ErrorCode: c, Description: desc}
+}
@@ -0,0 +1,301 @@
+// Copyright (c) 2013-2016 The btcsuite developers
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/txscript/adminop"
+)
+
+// adminOpNames maps the admin op kinds to the names of their add and revoke
+// ops in the textual form.
+var adminOpNames = map[adminop.Kind][2]string{
+	adminop.KindKey:       {"ADD_KEY", "REVOKE_KEY"},
+	adminop.KindIssueDest: {"ADD_ISSUE_DEST", "REVOKE_ISSUE_DEST"},
+	adminop.KindFreeze:    {"FREEZE_ACCOUNT", "UNFREEZE_ACCOUNT"},
+	adminop.KindAssetID:   {"ASSET_ID", "ASSET_ID"},
+	adminop.KindDelegate:  {"ADD_DELEGATE", "REVOKE_DELEGATE"},
+}
+
+// adminOpName returns the name of the op of the passed schema in the textual
+// form.
+func adminOpName(schema *adminop.Schema) string {
+	if schema.IsAdd {
+		return adminOpNames[schema.Kind][0]
+	}
+	return adminOpNames[schema.Kind][1]
+}
+
+var (
+	// assembleOps holds a map of opcode names to values for use by
+	// Assemble.  It is created on first use since OpcodeByName is only
+	// populated when the package is initialized.
+	assembleOps     map[string]byte
+	assembleOpsOnce sync.Once
+)
+
+// initAssembleOps creates the map of the opcode names Assemble accepts.
+func initAssembleOps() {
+	ops := make(map[string]byte)
+	for opcodeName, opcodeValue := range OpcodeByName {
+		ops[opcodeName] = opcodeValue
+
+		// The opcodes named OP_# can't have the OP_ prefix stripped or
+		// they would conflict with the plain numbers.  Also, since
+		// OP_FALSE and OP_TRUE are aliases for the OP_0, and OP_1,
+		// respectively, they have the same value, so detect those by
+		// name and allow them.  The unknown opcodes are only accepted
+		// with their prefix.
+		if strings.Contains(opcodeName, "OP_UNKNOWN") {
+			continue
+		}
+		if (opcodeName == "OP_FALSE" || opcodeName == "OP_TRUE") ||
+			(opcodeValue != OP_0 && (opcodeValue < OP_1 ||
+				opcodeValue > OP_16)) {
+
+			ops[strings.TrimPrefix(opcodeName, "OP_")] = opcodeValue
+		}
+	}
+	assembleOps = ops
+}
+
+// parseHex parses a hex string prefixed with 0x into a []byte.
+func parseHex(tok string) ([]byte, error) {
+	if !strings.HasPrefix(tok, "0x") {
+		return nil, errors.New("not a hex number")
+	}
+	return hex.DecodeString(tok[2:])
+}
+
+// parseAdminField parses the textual form of the passed admin op payload
+// field into its serialized form.  Keys and hashes are in hex, keyIDs, asset
+// IDs, expiry heights and delegation scopes are decimal numbers.
+func parseAdminField(field adminop.Field, str string) ([]byte, error) {
+	switch field {
+	case adminop.FieldScope:
+		num, err := strconv.ParseUint(str, 10, 8)
+		if err != nil {
+			return nil, err
+		}
+		return []byte{byte(num)}, nil
+	case adminop.FieldKeyID, adminop.FieldMaxKeyID, adminop.FieldAssetID,
+		adminop.FieldExpiry:
+		num, err := strconv.ParseUint(str, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, field.Len())
+		if field == adminop.FieldKeyID || field == adminop.FieldMaxKeyID {
+			btcec.KeyID(num).ToAddressFormat(buf)
+		} else {
+			binary.LittleEndian.PutUint32(buf, uint32(num))
+		}
+		return buf, nil
+	}
+	data, err := hex.DecodeString(str)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != field.Len() {
+		return nil, fmt.Errorf("got %d bytes, want %d", len(data),
+			field.Len())
+	}
+	return data, nil
+}
+
+// formatAdminField returns the textual form of the passed field of the
+// passed admin op payload.
+func formatAdminField(payload *adminop.Payload, field adminop.Field) string {
+	switch field {
+	case adminop.FieldPubKey:
+		return hex.EncodeToString(payload.PubKey.SerializeCompressed())
+	case adminop.FieldKeyID:
+		return strconv.FormatUint(uint64(payload.KeyID), 10)
+	case adminop.FieldIssueDest:
+		return hex.EncodeToString(payload.IssueDest[:])
+	case adminop.FieldPubKeyHash:
+		return hex.EncodeToString(payload.PubKeyHash[:])
+	case adminop.FieldExpiry:
+		return strconv.FormatUint(uint64(payload.Expiry), 10)
+	case adminop.FieldScope:
+		return strconv.FormatUint(uint64(payload.Scope), 10)
+	case adminop.FieldMaxKeyID:
+		return strconv.FormatUint(uint64(payload.MaxKeyID), 10)
+	}
+	return strconv.FormatUint(uint64(payload.AssetID), 10)
+}
+
+// assembleAdminOp returns the data of the admin op expressed by the passed
+// token, which is in the form produced by Disassemble, such as
+// ADD_KEY(ASP,<pubkey>,<keyID>) or FREEZE_ACCOUNT(<pubKeyHash>).  The ops
+// which modify a key set take the key set as first argument, followed by the
+// fields of the schema of the op.  Ops which share a name and key set, such as
+// the provisioning of a key with and without an expiry height, are told apart
+// by their number of fields.
+func assembleAdminOp(tok string) ([]byte, error) {
+	open := strings.IndexByte(tok, '(')
+	name := tok[:open]
+	args := strings.Split(tok[open+1:len(tok)-1], ",")
+
+	var schema *adminop.Schema
+	var knownName, keyOp, knownKeySet bool
+	for _, s := range adminop.Schemas() {
+		if adminOpName(s) != name {
+			continue
+		}
+		knownName = true
+		numArgs := len(s.Fields)
+		if s.Kind == adminop.KindKey {
+			keyOp = true
+			if s.KeySet.String() != args[0] {
+				continue
+			}
+			knownKeySet = true
+			numArgs++
+		}
+		if numArgs == len(args) {
+			schema = s
+			break
+		}
+	}
+	switch {
+	case !knownName:
+		return nil, fmt.Errorf("unknown admin op %q", name)
+	case keyOp && !knownKeySet:
+		return nil, fmt.Errorf("no %s op for key set %q", name, args[0])
+	case schema == nil:
+		return nil, fmt.Errorf("%s takes no %d arguments", name, len(args))
+	}
+	if schema.Kind == adminop.KindKey {
+		args = args[1:]
+	}
+
+	data := []byte{byte(schema.Op)}
+	for i, field := range schema.Fields {
+		buf, err := parseAdminField(field, args[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		data = append(data, buf...)
+	}
+	if _, err := adminop.Decode(data); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return data, nil
+}
+
+// Assemble parses a script in its textual form into the script it expresses.
+// The textual form is the short form used in the Bitcoin Core reference tests,
+// extended with the admin ops, and is produced by Disassemble:
+//   - Opcodes other than the push opcodes and unknown are present as
+//     either OP_NAME or just NAME, the unknown opcodes as OP_UNKNOWN#
+//   - Plain numbers are made into push operations
+//   - Numbers beginning with 0x are inserted into the []byte as-is (so
+//     0x14 is OP_DATA_20)
+//   - Single quoted strings are pushed as data
+//   - Admin ops, such as ADD_KEY(ASP,<pubkey>,<keyID>), are pushed as the
+//     data of the op
+//   - Anything else is an error
+//
+// The bytes inserted as-is are not checked, so Assemble can express scripts
+// which do not parse or exceed the maximum script size.
+func Assemble(script string) ([]byte, error) {
+	assembleOpsOnce.Do(initAssembleOps)
+
+	builder := NewScriptBuilder()
+	for _, tok := range strings.Fields(script) {
+		// if parses as a plain number
+		if num, err := strconv.ParseInt(tok, 10, 64); err == nil {
+			builder.AddInt64(num)
+			continue
+		} else if bts, err := parseHex(tok); err == nil {
+			// Concatenate the bytes manually since scripts which
+			// are too large would cause the builder to error
+			// otherwise.
+			if builder.err == nil {
+				builder.script = append(builder.script, bts...)
+			}
+		} else if len(tok) >= 2 &&
+			tok[0] == '\'' && tok[len(tok)-1] == '\'' {
+			builder.AddFullData([]byte(tok[1 : len(tok)-1]))
+		} else if opcode, ok := assembleOps[tok]; ok {
+			builder.AddOp(opcode)
+		} else if strings.IndexByte(tok, '(') > 0 &&
+			strings.HasSuffix(tok, ")") {
+			data, err := assembleAdminOp(tok)
+			if err != nil {
+				return nil, err
+			}
+			builder.AddData(data)
+		} else {
+			return nil, fmt.Errorf("bad token \"%s\"", tok)
+		}
+	}
+	return builder.Script()
+}
+
+// disasmAdminOp returns the textual form of the passed admin op data, and
+// false when the data is not a well-formed admin op without optional fields.
+func disasmAdminOp(data []byte) (string, bool) {
+	payload, err := adminop.Decode(data)
+	if err != nil {
+		return "", false
+	}
+	schema := payload.Schema()
+	if len(data) != schema.Len() {
+		return "", false
+	}
+	var args []string
+	if schema.Kind == adminop.KindKey {
+		args = append(args, schema.KeySet.String())
+	}
+	for _, field := range schema.Fields {
+		args = append(args, formatAdminField(payload, field))
+	}
+	return fmt.Sprintf("%s(%s)", adminOpName(schema),
+		strings.Join(args, ",")), true
+}
+
+// Disassemble returns the textual form of the passed script, which Assemble
+// parses back into the same script.  Data pushes are shown with their push
+// opcode and the pushed data in hex, except that the data pushed right after
+// an OP_RETURN is decoded when it is an admin op, such as
+// ADD_KEY(ASP,<pubkey>,<keyID>).  An error is returned when the script does
+// not parse.
+func Disassemble(script []byte) (string, error) {
+	pops, err := ParseScript(script)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	for i, pop := range pops {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+
+		// Admin ops are always pushed with the smallest push opcode,
+		// which is the one Assemble uses for them.
+		if i > 0 && pops[i-1].opcode.value == OP_RETURN &&
+			pop.opcode.length == len(pop.data)+1 {
+
+			if adminOp, ok := disasmAdminOp(pop.data); ok {
+				buf.WriteString(adminOp)
+				continue
+			}
+		}
+		buf.WriteString(pop.print(false))
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestAssembleDisassemble ensures scripts are disassembled into their textual
+// form, including the Prova opcodes and the admin ops, and assembled back into
+// the same scripts.
+func TestAssembleDisassemble(t *testing.T) {
+	t.Parallel()
+
+	const pubKey = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	tests := []struct {
+		name   string
+		script []byte
+		disasm string
+	}{
+		{
+			name:   "empty",
+			script: nil,
+			disasm: "",
+		},
+		{
+			name:   "admin thread",
+			script: hexToBytes("51bb"),
+			disasm: "OP_1 OP_CHECKTHREAD",
+		},
+		{
+			name: "prova",
+			script: hexToBytes("5214" +
+				"0102030405060708090a0b0c0d0e0f1011121314" +
+				"03000001" + "5853ba"),
+			disasm: "OP_2 OP_DATA_20 " +
+				"0x0102030405060708090a0b0c0d0e0f1011121314 " +
+				"OP_DATA_3 0x000001 OP_8 OP_3 OP_CHECKSAFEMULTISIG",
+		},
+		{
+			name:   "add ASP key",
+			script: hexToBytes("6a2613" + pubKey + "05000000"),
+			disasm: "OP_RETURN ADD_KEY(ASP," + pubKey + ",5)",
+		},
+		{
+			name:   "add ASP key with expiry",
+			script: hexToBytes("6a2a18" + pubKey + "05000000" + "e8030000"),
+			disasm: "OP_RETURN ADD_KEY(ASP," + pubKey + ",5,1000)",
+		},
+		{
+			name:   "add validate key with expiry",
+			script: hexToBytes("6a2617" + pubKey + "e8030000"),
+			disasm: "OP_RETURN ADD_KEY(VALIDATE," + pubKey + ",1000)",
+		},
+		{
+			name:   "revoke validate key",
+			script: hexToBytes("6a2212" + pubKey),
+			disasm: "OP_RETURN REVOKE_KEY(VALIDATE," + pubKey + ")",
+		},
+		{
+			name: "add issue dest",
+			script: hexToBytes("6a1d05" +
+				"0102030405060708090a0b0c0d0e0f1011121314" +
+				"0100000002000000"),
+			disasm: "OP_RETURN ADD_ISSUE_DEST(" +
+				"0102030405060708090a0b0c0d0e0f1011121314" +
+				"0100000002000000)",
+		},
+		{
+			name: "freeze account",
+			script: hexToBytes("6a1515" +
+				"0102030405060708090a0b0c0d0e0f1011121314"),
+			disasm: "OP_RETURN FREEZE_ACCOUNT(" +
+				"0102030405060708090a0b0c0d0e0f1011121314)",
+		},
+		{
+			name: "add delegate",
+			script: hexToBytes("6a2b19" + pubKey + "03" + "05000000" +
+				"09000000"),
+			disasm: "OP_RETURN ADD_DELEGATE(" + pubKey + ",3,5,9)",
+		},
+		{
+			name:   "asset id",
+			script: hexToBytes("6a052107000000"),
+			disasm: "OP_RETURN ASSET_ID(7)",
+		},
+		{
+			name:   "null data",
+			script: hexToBytes("6a03616263"),
+			disasm: "OP_RETURN OP_DATA_3 0x616263",
+		},
+		{
+			name:   "admin op pushed with a larger push opcode",
+			script: hexToBytes("6a4c052107000000"),
+			disasm: "OP_RETURN OP_PUSHDATA1 0x05 0x2107000000",
+		},
+		{
+			name:   "admin op not following OP_RETURN",
+			script: hexToBytes("052107000000"),
+			disasm: "OP_DATA_5 0x2107000000",
+		},
+		{
+			name:   "unknown opcode",
+			script: hexToBytes("fc"),
+			disasm: "OP_UNKNOWN252",
+		},
+	}
+	for _, test := range tests {
+		disasm, err := Disassemble(test.script)
+		if err != nil {
+			t.Errorf("%s: Disassemble: %v", test.name, err)
+			continue
+		}
+		if disasm != test.disasm {
+			t.Errorf("%s: Disassemble: got %q, want %q", test.name,
+				disasm, test.disasm)
+			continue
+		}
+		script, err := Assemble(disasm)
+		if err != nil {
+			t.Errorf("%s: Assemble: %v", test.name, err)
+			continue
+		}
+		if !bytes.Equal(script, test.script) {
+			t.Errorf("%s: Assemble: got %x, want %x", test.name,
+				script, test.script)
+		}
+	}
+
+	// The short form names and numbers are accepted as well.
+	script, err := Assemble("DUP HASH160 'abc' 1000 0x51 OP_TRUE")
+	if err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+	if want := hexToBytes("76a90361626302e8035151"); !bytes.Equal(script, want) {
+		t.Fatalf("Assemble: got %x, want %x", script, want)
+	}
+
+	// Malformed textual forms are rejected.
+	for _, text := range []string{
+		"BOGUS",
+		"ADD_KEY(ROOT," + pubKey + ")",
+		"ADD_KEY(ASP," + pubKey + ")",
+		"REVOKE_KEY(ASP," + pubKey + ",5,1000)",
+		"REVOKE_KEY(ISSUE,0279)",
+		"FREEZE_ACCOUNT(0102)",
+		"ADD_DELEGATE(" + pubKey + ",0,5,9)",
+		"REVOKE_DELEGATE(" + pubKey + ",256,5,9)",
+		"ASSET_ID(-1)",
+		"MINT(1)",
+	} {
+		if _, err := Assemble(text); err == nil {
+			t.Errorf("Assemble: %q succeeded", text)
+		}
+	}
+
+	// Scripts which do not parse can not be disassembled.
+	if _, err := Disassemble(hexToBytes("0201")); err == nil {
+		t.Errorf("Disassemble: truncated push succeeded")
+	}
+}
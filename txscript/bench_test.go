@@ -0,0 +1,81 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package txscript
+
+import (
+	"testing"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+// BenchmarkVerifyProvaScript performs a benchmark on how long it takes to
+// verify a Prova 2-of-3 multisig input signed by a user key and an ASP key.
+func BenchmarkVerifyProvaScript(b *testing.B) {
+	const inputAmt = 5000000000
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 0}, nil))
+	tx.AddTxOut(wire.NewTxOut(inputAmt-100000, nil))
+
+	userKey, userPubKey := btcec.PrivKeyFromBytes(btcec.S256(), []byte{0x03})
+	aspKey, aspPubKey := btcec.PrivKeyFromBytes(btcec.S256(), []byte{0x01})
+	_, asp2PubKey := btcec.PrivKeyFromBytes(btcec.S256(), []byte{0x02})
+	keyID1 := btcec.KeyIDFromAddressBuffer([]byte{0, 0, 1, 0})
+	keyID2 := btcec.KeyIDFromAddressBuffer([]byte{1, 0, 0, 0})
+	addr, err := provautil.NewAddressProva(
+		provautil.Hash160(userPubKey.SerializeCompressed()),
+		[]btcec.KeyID{keyID1, keyID2}, &chaincfg.TestNetParams)
+	if err != nil {
+		b.Fatalf("NewAddressProva: %v", err)
+	}
+	pkScript, err := PayToAddrScript(addr)
+	if err != nil {
+		b.Fatalf("PayToAddrScript: %v", err)
+	}
+
+	lookupKey := func(a provautil.Address) ([]PrivateKey, error) {
+		return []PrivateKey{{userKey, true}, {aspKey, true}}, nil
+	}
+	sigScript, err := SignTxOutput(&chaincfg.TestNetParams, tx, 0, inputAmt,
+		pkScript, SigHashAll, KeyClosure(lookupKey), nil)
+	if err != nil {
+		b.Fatalf("SignTxOutput: %v", err)
+	}
+	tx.TxIn[0].SignatureScript = sigScript
+
+	// Replace the key IDs of the script with the hashes of the ASP keys, as
+	// the chain does before verifying the input.
+	pops, err := ParseScript(pkScript)
+	if err != nil {
+		b.Fatalf("ParseScript: %v", err)
+	}
+	err = ReplaceKeyIDs(pops, map[btcec.KeyID][]byte{
+		keyID1: provautil.Hash160(aspPubKey.SerializeCompressed()),
+		keyID2: provautil.Hash160(asp2PubKey.SerializeCompressed()),
+	})
+	if err != nil {
+		b.Fatalf("ReplaceKeyIDs: %v", err)
+	}
+	pkScript, err = UnparseScript(pops)
+	if err != nil {
+		b.Fatalf("UnparseScript: %v", err)
+	}
+
+	flags := ScriptBip16 | ScriptVerifyDERSignatures |
+		ScriptVerifyCheckLockTimeVerify | ScriptVerifyCheckSequenceVerify
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vm, err := NewEngine(pkScript, tx, 0, flags, nil, nil, inputAmt)
+		if err != nil {
+			b.Fatalf("NewEngine: %v", err)
+		}
+		if err := vm.Execute(); err != nil {
+			b.Fatalf("Execute: %v", err)
+		}
+	}
+}
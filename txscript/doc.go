@@ -32,6 +32,16 @@ is used to prove the the spender is authorized to perform the transaction.
 One benefit of using a scripting language is added flexibility in specifying
 what conditions must be met in order to spend funds.
 
+Textual Form
+
+Scripts can be expressed in a textual form, which Disassemble produces and
+Assemble parses back into the same script.  It names the opcodes, including the
+Prova opcodes such as OP_CHECKSAFEMULTISIG and OP_CHECKTHREAD, shows pushed
+data in hex and decodes the admin ops of admin transaction outputs, for example:
+
+	OP_1 OP_CHECKTHREAD
+	OP_RETURN ADD_KEY(ASP,0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798,5)
+
 Errors
 
 Errors returned by this package are of type txscript.Error.  This allows the
@@ -11,6 +11,7 @@ import (
 	"math/big"
 
 	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
 	"github.com/pyx-partners/dmgd/wire"
 )
 
@@ -75,17 +76,34 @@ const (
 	// ScriptVerifyStrictEncoding defines that signature scripts and
 	// public keys must follow the strict encoding requirements.
 	ScriptVerifyStrictEncoding
+
+	// ScriptVerifyCheckKeyIDVerify defines whether to verify with
+	// OP_CHECKKEYIDVERIFY that a signature is made by the key provisioned
+	// for a keyID, instead of executing it as OP_NOP4.
+	ScriptVerifyCheckKeyIDVerify
 )
 
 const (
 	// MaxStackSize is the maximum combined height of stack and alt stack
-	// during execution.
-	MaxStackSize = 1000
+	// during execution allowed by consensus.
+	MaxStackSize = chaincfg.DefaultMaxStackSize
 
-	// MaxScriptSize is the maximum allowed length of a raw script.
-	MaxScriptSize = 10000
+	// MaxScriptSize is the maximum allowed length of a raw script allowed
+	// by consensus.
+	MaxScriptSize = chaincfg.DefaultMaxScriptSize
 )
 
+// consensusLimits are the limits of engines created by NewEngine.
+var consensusLimits = chaincfg.ScriptLimits{
+	MaxScriptSize:   MaxScriptSize,
+	MaxStackSize:    MaxStackSize,
+	MaxOpsPerScript: MaxOpsPerScript,
+}
+
+// KeyIDLookup returns the ASP key currently provisioned for the passed keyID,
+// or nil when no key is provisioned for it.
+type KeyIDLookup func(keyID btcec.KeyID) *btcec.PublicKey
+
 // halforder is used to tame ECDSA malleability (see BIP0062).
 var halfOrder = new(big.Int).Rsh(btcec.S256().N, 1)
 
@@ -107,6 +125,8 @@ type Engine struct {
 	bip16           bool     // treat execution as pay-to-script-hash
 	savedFirstStack [][]byte // stack from first script for bip16 scripts
 	inputAmount     int64
+	limits          chaincfg.ScriptLimits
+	keyIDLookup     KeyIDLookup
 }
 
 // hasFlag returns whether the script engine instance has the passed flag set.
@@ -146,9 +166,9 @@ func (vm *Engine) executeOpcode(pop *parsedOpcode) error {
 	// Note that this includes OP_RESERVED which counts as a push operation.
 	if pop.opcode.value > OP_16 {
 		vm.numOps++
-		if vm.numOps > MaxOpsPerScript {
+		if vm.numOps > vm.limits.MaxOpsPerScript {
 			str := fmt.Sprintf("exceeded max operation limit of %d",
-				MaxOpsPerScript)
+				vm.limits.MaxOpsPerScript)
 			return scriptError(ErrTooManyOperations, str)
 		}
 
@@ -303,10 +323,10 @@ func (vm *Engine) Step() (done bool, err error) {
 
 	// The number of elements in the combination of the data and alt stacks
 	// must not exceed the maximum number of stack elements allowed.
-	combinedStackSize := vm.dstack.Depth() + vm.astack.Depth()
-	if combinedStackSize > MaxStackSize {
+	combinedStackSize := int(vm.dstack.Depth() + vm.astack.Depth())
+	if combinedStackSize > vm.limits.MaxStackSize {
 		str := fmt.Sprintf("combined stack size %d > max allowed %d",
-			combinedStackSize, MaxStackSize)
+			combinedStackSize, vm.limits.MaxStackSize)
 		return false, scriptError(ErrStackOverflow, str)
 	}
 
@@ -628,11 +648,38 @@ func (vm *Engine) SetAltStack(data [][]byte) {
 	setStack(&vm.astack, data)
 }
 
+// SetKeyIDLookup sets the function OP_CHECKKEYIDVERIFY looks up the keys of
+// keyIDs with.  Without one, no keyID has a key.
+func (vm *Engine) SetKeyIDLookup(lookup KeyIDLookup) {
+	vm.keyIDLookup = lookup
+}
+
 // NewEngine returns a new script engine for the provided public key script,
 // transaction, and input index.  The flags modify the behavior of the script
-// engine according to the description provided by each flag.
+// engine according to the description provided by each flag.  The engine
+// enforces the consensus limits on the size of the scripts and stacks and the
+// number of operations.
 func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int, flags ScriptFlags,
 	sigCache *SigCache, hashCache *TxSigHashes, inputAmount int64) (*Engine, error) {
+
+	return NewEngineWithLimits(scriptPubKey, tx, txIdx, flags, sigCache,
+		hashCache, inputAmount, consensusLimits)
+}
+
+// NewEngineWithLimits returns a new script engine like NewEngine, which
+// enforces the passed limits instead of the consensus limits, such as the
+// limits returned by the ScriptEngineLimits method of the parameters of a
+// network.  All limits must be positive.
+func NewEngineWithLimits(scriptPubKey []byte, tx *wire.MsgTx, txIdx int,
+	flags ScriptFlags, sigCache *SigCache, hashCache *TxSigHashes,
+	inputAmount int64, limits chaincfg.ScriptLimits) (*Engine, error) {
+
+	if limits.MaxScriptSize <= 0 || limits.MaxStackSize <= 0 ||
+		limits.MaxOpsPerScript <= 0 {
+
+		str := fmt.Sprintf("script limits %+v are not positive", limits)
+		return nil, scriptError(ErrInvalidLimits, str)
+	}
 	// The provided transaction input index must refer to a valid input.
 	if txIdx < 0 || txIdx >= len(tx.TxIn) {
 		str := fmt.Sprintf("transaction input index %d is negative or "+
@@ -663,6 +710,7 @@ func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int, flags ScriptFlags
 		sigCache:    sigCache,
 		hashCache:   hashCache,
 		inputAmount: inputAmount,
+		limits:      limits,
 	}
 	if vm.hasFlag(ScriptVerifyCleanStack) && !vm.hasFlag(ScriptBip16) {
 		return nil, scriptError(ErrInvalidFlags,
@@ -683,9 +731,9 @@ func NewEngine(scriptPubKey []byte, tx *wire.MsgTx, txIdx int, flags ScriptFlags
 	scripts := [][]byte{scriptSig, scriptPubKey}
 	vm.scripts = make([][]parsedOpcode, len(scripts))
 	for i, scr := range scripts {
-		if len(scr) > MaxScriptSize {
+		if len(scr) > limits.MaxScriptSize {
 			str := fmt.Sprintf("script size %d is larger than max "+
-				"allowed size %d", len(scr), MaxScriptSize)
+				"allowed size %d", len(scr), limits.MaxScriptSize)
 			return nil, scriptError(ErrScriptTooBig, str)
 		}
 		var err error
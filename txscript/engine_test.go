@@ -9,6 +9,7 @@ package txscript
 import (
 	"testing"
 
+	"github.com/pyx-partners/dmgd/chaincfg"
 	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
 	"github.com/pyx-partners/dmgd/wire"
 )
@@ -204,6 +205,91 @@ func TestInvalidFlagCombinations(t *testing.T) {
 	}
 }
 
+// TestEngineLimits ensures engines enforce the limits they are created with
+// instead of the consensus limits, and reject limits which are not positive.
+func TestEngineLimits(t *testing.T) {
+	t.Parallel()
+
+	tx := &wire.MsgTx{
+		Version: 1,
+		TxIn: []*wire.TxIn{{
+			PreviousOutPoint: wire.OutPoint{Index: 0},
+			SignatureScript:  mustParseShortForm("1 2"),
+			Sequence:         4294967295,
+		}},
+		TxOut: []*wire.TxOut{{Value: 1000000000}},
+	}
+	pkScript := mustParseShortForm("NOP NOP DROP")
+
+	tests := []struct {
+		name   string
+		limits chaincfg.ScriptLimits
+		err    ErrorCode
+	}{
+		{
+			name: "consensus limits",
+			limits: chaincfg.ScriptLimits{
+				MaxScriptSize:   MaxScriptSize,
+				MaxStackSize:    MaxStackSize,
+				MaxOpsPerScript: MaxOpsPerScript,
+			},
+			err: -1,
+		},
+		{
+			name: "script too big",
+			limits: chaincfg.ScriptLimits{
+				MaxScriptSize:   2,
+				MaxStackSize:    MaxStackSize,
+				MaxOpsPerScript: MaxOpsPerScript,
+			},
+			err: ErrScriptTooBig,
+		},
+		{
+			name: "stack too high",
+			limits: chaincfg.ScriptLimits{
+				MaxScriptSize:   MaxScriptSize,
+				MaxStackSize:    1,
+				MaxOpsPerScript: MaxOpsPerScript,
+			},
+			err: ErrStackOverflow,
+		},
+		{
+			name: "too many operations",
+			limits: chaincfg.ScriptLimits{
+				MaxScriptSize:   MaxScriptSize,
+				MaxStackSize:    MaxStackSize,
+				MaxOpsPerScript: 2,
+			},
+			err: ErrTooManyOperations,
+		},
+		{
+			name: "zero limit",
+			limits: chaincfg.ScriptLimits{
+				MaxScriptSize: MaxScriptSize,
+				MaxStackSize:  MaxStackSize,
+			},
+			err: ErrInvalidLimits,
+		},
+	}
+	for _, test := range tests {
+		vm, err := NewEngineWithLimits(pkScript, tx, 0, 0, nil, nil, -1,
+			test.limits)
+		if err == nil {
+			err = vm.Execute()
+		}
+		if test.err == -1 {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+			}
+			continue
+		}
+		if !IsErrorCode(err, test.err) {
+			t.Errorf("%s: got error %v, want %v", test.name, err,
+				test.err)
+		}
+	}
+}
+
 // TestCheckPubKeyEncoding ensures the internal checkPubKeyEncoding function
 // works as expected.
 func TestCheckPubKeyEncoding(t *testing.T) {
@@ -27,6 +27,9 @@ const (
 	// ErrInvalidIndex is returned when an out-of-bounds index is passed to
 	// a function.
 	ErrInvalidIndex
+	// ErrInvalidLimits is returned when the limits passed to
+	// NewEngineWithLimits are not all positive.
+	ErrInvalidLimits
 	// ErrUnsupportedAddress is returned when a concrete type that
 	// implements a btcutil.Address is not a supported type.
 	ErrUnsupportedAddress
@@ -43,6 +46,26 @@ const (
 	// ErrTooMuchNullData is returned from NullDataScript when the length of
 	// the provided data exceeds MaxDataCarrierSize.
 	ErrTooMuchNullData
+	// ErrInvalidCommitmentSize is returned from CommitmentScript when the
+	// provided commitment is not exactly CommitmentSize bytes.
+	ErrInvalidCommitmentSize
+	// ErrInvalidHTLCSecretSize is returned when the secret of a Prova HTLC
+	// or its hash is not exactly HTLCSecretSize bytes.
+	ErrInvalidHTLCSecretSize
+	// ErrNotProvaHTLC is returned from ExtractProvaHTLC when the provided
+	// script is not a Prova HTLC script.
+	ErrNotProvaHTLC
+	// ErrInvalidTimeLock is returned when a Prova time lock script or the
+	// spending transaction of one can not be constructed for the provided
+	// lock time.
+	ErrInvalidTimeLock
+	// ErrNotProvaTimeLock is returned when the provided script is not a
+	// Prova time lock script.
+	ErrNotProvaTimeLock
+	// ErrNotAdminOp is returned when the provided script is not an
+	// <OP_RETURN><OP_DATA> script pushing an admin op payload with the
+	// smallest push opcode.
+	ErrNotAdminOp
 	// ------------------------------------------
 	// Failures related to final execution state.
 	// ------------------------------------------
@@ -209,6 +232,15 @@ const (
 	// reached.
 	ErrUnsatisfiedLockTime
 
+	// ErrCheckKeyIDVerify is returned when OP_CHECKKEYIDVERIFY is executed
+	// with a signature which is not a valid signature of the transaction
+	// by the key of the keyID.
+	ErrCheckKeyIDVerify
+
+	// ErrUnknownKeyID is returned when OP_CHECKKEYIDVERIFY is executed for
+	// a keyID no key is provisioned for.
+	ErrUnknownKeyID
+
 	// numErrorCodes is the maximum error code number used in tests.  This
 	// entry MUST be the last entry in the enum.
 	numErrorCodes
@@ -219,11 +251,18 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrInternal:                 "ErrInternal",
 	ErrInvalidFlags:             "ErrInvalidFlags",
 	ErrInvalidIndex:             "ErrInvalidIndex",
+	ErrInvalidLimits:            "ErrInvalidLimits",
 	ErrUnsupportedAddress:       "ErrUnsupportedAddress",
 	ErrNotMultisigScript:        "ErrNotMultisigScript",
 	ErrTooManyRequiredSigs:      "ErrTooManyRequiredSigs",
 	ErrInvalidNumberOfKeyIds:    "ErrInvalidNumberOfKeyIds",
 	ErrTooMuchNullData:          "ErrTooMuchNullData",
+	ErrInvalidCommitmentSize:    "ErrInvalidCommitmentSize",
+	ErrInvalidHTLCSecretSize:    "ErrInvalidHTLCSecretSize",
+	ErrNotProvaHTLC:             "ErrNotProvaHTLC",
+	ErrInvalidTimeLock:          "ErrInvalidTimeLock",
+	ErrNotProvaTimeLock:         "ErrNotProvaTimeLock",
+	ErrNotAdminOp:               "ErrNotAdminOp",
 	ErrEarlyReturn:              "ErrEarlyReturn",
 	ErrEmptyStack:               "ErrEmptyStack",
 	ErrEvalFalse:                "ErrEvalFalse",
@@ -258,6 +297,8 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrDiscourageUpgradableNOPs: "ErrDiscourageUpgradableNOPs",
 	ErrNegativeLockTime:         "ErrNegativeLockTime",
 	ErrUnsatisfiedLockTime:      "ErrUnsatisfiedLockTime",
+	ErrCheckKeyIDVerify:         "ErrCheckKeyIDVerify",
+	ErrUnknownKeyID:             "ErrUnknownKeyID",
 }
 
 // String returns the ErrorCode as a human-readable name.
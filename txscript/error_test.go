@@ -19,10 +19,17 @@ func TestErrorCodeStringer(t *testing.T) {
 		{ErrInternal, "ErrInternal"},
 		{ErrInvalidFlags, "ErrInvalidFlags"},
 		{ErrInvalidIndex, "ErrInvalidIndex"},
+		{ErrInvalidLimits, "ErrInvalidLimits"},
 		{ErrUnsupportedAddress, "ErrUnsupportedAddress"},
 		{ErrTooManyRequiredSigs, "ErrTooManyRequiredSigs"},
 		{ErrInvalidNumberOfKeyIds, "ErrInvalidNumberOfKeyIds"},
 		{ErrTooMuchNullData, "ErrTooMuchNullData"},
+		{ErrInvalidCommitmentSize, "ErrInvalidCommitmentSize"},
+		{ErrInvalidHTLCSecretSize, "ErrInvalidHTLCSecretSize"},
+		{ErrNotProvaHTLC, "ErrNotProvaHTLC"},
+		{ErrInvalidTimeLock, "ErrInvalidTimeLock"},
+		{ErrNotProvaTimeLock, "ErrNotProvaTimeLock"},
+		{ErrNotAdminOp, "ErrNotAdminOp"},
 		{ErrNotMultisigScript, "ErrNotMultisigScript"},
 		{ErrEarlyReturn, "ErrEarlyReturn"},
 		{ErrEmptyStack, "ErrEmptyStack"},
@@ -58,6 +65,8 @@ func TestErrorCodeStringer(t *testing.T) {
 		{ErrDiscourageUpgradableNOPs, "ErrDiscourageUpgradableNOPs"},
 		{ErrNegativeLockTime, "ErrNegativeLockTime"},
 		{ErrUnsatisfiedLockTime, "ErrUnsatisfiedLockTime"},
+		{ErrCheckKeyIDVerify, "ErrCheckKeyIDVerify"},
+		{ErrUnknownKeyID, "ErrUnknownKeyID"},
 		{0xffff, "Unknown ErrorCode (65535)"},
 	}
 
@@ -16,6 +16,7 @@ import (
 
 	"github.com/pyx-partners/dmgd/btcec"
 	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/txscript/adminop"
 	"github.com/pyx-partners/dmgd/wire"
 	"github.com/btcsuite/golangcrypto/ripemd160"
 )
@@ -219,6 +220,7 @@ const (
 	OP_NOP3                = 0xb2 // 178
 	OP_CHECKSEQUENCEVERIFY = 0xb2 // 178 - AKA OP_NOP3
 	OP_NOP4                = 0xb3 // 179
+	OP_CHECKKEYIDVERIFY    = 0xb3 // 179 - AKA OP_NOP4
 	OP_NOP5                = 0xb4 // 180
 	OP_NOP6                = 0xb5 // 181
 	OP_NOP7                = 0xb6 // 182
@@ -297,16 +299,22 @@ const (
 	OP_INVALIDOPCODE       = 0xff // 255 - bitcoin core internal
 )
 
+// The admin op bytes.  Their payload formats are defined by the schemas of
+// the adminop package.
 const (
-	// First nybble == thread id where op is valid
-	AdminOpIssueKeyAdd        = 0x01 // 1
-	AdminOpIssueKeyRevoke     = 0x02 // 2
-	AdminOpProvisionKeyAdd    = 0x03 // 3
-	AdminOpProvisionKeyRevoke = 0x04 // 4
-	AdminOpValidateKeyAdd     = 0x11 // 17
-	AdminOpValidateKeyRevoke  = 0x12 // 18
-	AdminOpASPKeyAdd          = 0x13 // 19
-	AdminOpASPKeyRevoke       = 0x14 // 20
+	AdminOpIssueKeyAdd        = byte(adminop.IssueKeyAdd)
+	AdminOpIssueKeyRevoke     = byte(adminop.IssueKeyRevoke)
+	AdminOpProvisionKeyAdd    = byte(adminop.ProvisionKeyAdd)
+	AdminOpProvisionKeyRevoke = byte(adminop.ProvisionKeyRevoke)
+	AdminOpIssueDestAdd       = byte(adminop.IssueDestAdd)
+	AdminOpIssueDestRevoke    = byte(adminop.IssueDestRevoke)
+	AdminOpValidateKeyAdd     = byte(adminop.ValidateKeyAdd)
+	AdminOpValidateKeyRevoke  = byte(adminop.ValidateKeyRevoke)
+	AdminOpASPKeyAdd          = byte(adminop.ASPKeyAdd)
+	AdminOpASPKeyRevoke       = byte(adminop.ASPKeyRevoke)
+	AdminOpAccountFreeze      = byte(adminop.AccountFreeze)
+	AdminOpAccountUnfreeze    = byte(adminop.AccountUnfreeze)
+	AdminOpAssetID            = byte(adminop.AssetID)
 )
 
 // Conditional execution constants.
@@ -432,6 +440,7 @@ var opcodeArray = [256]opcode{
 	OP_RETURN:              {OP_RETURN, "OP_RETURN", 1, opcodeReturn},
 	OP_CHECKLOCKTIMEVERIFY: {OP_CHECKLOCKTIMEVERIFY, "OP_CHECKLOCKTIMEVERIFY", 1, opcodeCheckLockTimeVerify},
 	OP_CHECKSEQUENCEVERIFY: {OP_CHECKSEQUENCEVERIFY, "OP_CHECKSEQUENCEVERIFY", 1, opcodeCheckSequenceVerify},
+	OP_CHECKKEYIDVERIFY:    {OP_CHECKKEYIDVERIFY, "OP_CHECKKEYIDVERIFY", 1, opcodeCheckKeyIDVerify},
 
 	// Stack opcodes.
 	OP_TOALTSTACK:   {OP_TOALTSTACK, "OP_TOALTSTACK", 1, opcodeToAltStack},
@@ -514,7 +523,6 @@ var opcodeArray = [256]opcode{
 
 	// Reserved opcodes.
 	OP_NOP1:  {OP_NOP1, "OP_NOP1", 1, opcodeNop},
-	OP_NOP4:  {OP_NOP4, "OP_NOP4", 1, opcodeNop},
 	OP_NOP5:  {OP_NOP5, "OP_NOP5", 1, opcodeNop},
 	OP_NOP6:  {OP_NOP6, "OP_NOP6", 1, opcodeNop},
 	OP_NOP7:  {OP_NOP7, "OP_NOP7", 1, opcodeNop},
@@ -922,7 +930,7 @@ func opcodeN(op *parsedOpcode, vm *Engine) error {
 // the flag to discourage use of NOPs is set for select opcodes.
 func opcodeNop(op *parsedOpcode, vm *Engine) error {
 	switch op.opcode.value {
-	case OP_NOP1, OP_NOP4, OP_NOP5,
+	case OP_NOP1, OP_NOP5,
 		OP_NOP6, OP_NOP7, OP_NOP8, OP_NOP9, OP_NOP10:
 		if vm.hasFlag(ScriptDiscourageUpgradableNops) {
 			str := fmt.Sprintf("OP_NOP%d reserved for soft-fork "+
@@ -1242,6 +1250,111 @@ func opcodeCheckSequenceVerify(op *parsedOpcode, vm *Engine) error {
 		wire.SequenceLockTimeIsSeconds, sequence&lockTimeMask)
 }
 
+// opcodeCheckKeyIDVerify verifies that the second item on the data stack is a
+// signature of the transaction by the ASP key currently provisioned for the
+// keyID on top of the data stack, so scripts can bind to the role of a keyID
+// rather than to the key holding it.  It leaves both items on the stack, so
+// nodes which execute it as OP_NOP4 agree on the result of the script.  If flag
+// ScriptVerifyCheckKeyIDVerify is not set, the code continues as if OP_NOP4
+// were executed.
+//
+// Stack transformation: [... signature keyID] -> [... signature keyID]
+func opcodeCheckKeyIDVerify(op *parsedOpcode, vm *Engine) error {
+	// If the ScriptVerifyCheckKeyIDVerify script flag is not set, treat
+	// opcode as OP_NOP4 instead.
+	if !vm.hasFlag(ScriptVerifyCheckKeyIDVerify) {
+		if vm.hasFlag(ScriptDiscourageUpgradableNops) {
+			return scriptError(ErrDiscourageUpgradableNOPs,
+				"OP_NOP4 reserved for soft-fork upgrades")
+		}
+		return nil
+	}
+
+	// KeyIDs are encoded as in Prova scripts, which limits them to 4-byte
+	// scriptNums.
+	so, err := vm.dstack.PeekByteArray(0)
+	if err != nil {
+		return err
+	}
+	keyIDNum, err := makeScriptNum(so, vm.dstack.verifyMinimalData, 4)
+	if err != nil {
+		return err
+	}
+	if keyIDNum < 0 {
+		str := fmt.Sprintf("negative keyID: %d", keyIDNum)
+		return scriptError(ErrCheckKeyIDVerify, str)
+	}
+	keyID := btcec.KeyID(keyIDNum)
+
+	fullSigBytes, err := vm.dstack.PeekByteArray(1)
+	if err != nil {
+		return err
+	}
+	if len(fullSigBytes) < 1 {
+		str := fmt.Sprintf("empty signature for keyID %d", keyID)
+		return scriptError(ErrCheckKeyIDVerify, str)
+	}
+	hashType := SigHashType(fullSigBytes[len(fullSigBytes)-1])
+	sigBytes := fullSigBytes[:len(fullSigBytes)-1]
+	if err := vm.checkHashTypeEncoding(hashType); err != nil {
+		return err
+	}
+	if err := vm.checkSignatureEncoding(sigBytes); err != nil {
+		return err
+	}
+
+	var pubKey *btcec.PublicKey
+	if vm.keyIDLookup != nil {
+		pubKey = vm.keyIDLookup(keyID)
+	}
+	if pubKey == nil {
+		str := fmt.Sprintf("no key is provisioned for keyID %d", keyID)
+		return scriptError(ErrUnknownKeyID, str)
+	}
+
+	var signature *btcec.Signature
+	if vm.hasFlag(ScriptVerifyStrictEncoding) ||
+		vm.hasFlag(ScriptVerifyDERSignatures) {
+
+		signature, err = btcec.ParseDERSignature(sigBytes, btcec.S256())
+	} else {
+		signature, err = btcec.ParseSignature(sigBytes, btcec.S256())
+	}
+	if err != nil {
+		str := fmt.Sprintf("malformed signature for keyID %d: %v",
+			keyID, err)
+		return scriptError(ErrCheckKeyIDVerify, str)
+	}
+
+	// The signature hash commits to the spent amount like the signatures
+	// checked by OP_CHECKSAFEMULTISIG.
+	sigHashes := vm.hashCache
+	if sigHashes == nil {
+		sigHashes = NewTxSigHashes(&vm.tx)
+	}
+	hash := calcSignatureHashNew(vm.subScript(), sigHashes, hashType,
+		&vm.tx, vm.txIdx, vm.inputAmount)
+
+	var valid bool
+	if vm.sigCache != nil {
+		var sigHash chainhash.Hash
+		copy(sigHash[:], hash)
+
+		valid = vm.sigCache.Exists(sigHash, signature, pubKey)
+		if !valid && signature.Verify(hash, pubKey) {
+			vm.sigCache.Add(sigHash, signature, pubKey)
+			valid = true
+		}
+	} else {
+		valid = signature.Verify(hash, pubKey)
+	}
+	if !valid {
+		str := fmt.Sprintf("signature is not valid for keyID %d", keyID)
+		return scriptError(ErrCheckKeyIDVerify, str)
+	}
+	return nil
+}
+
 // opcodeToAltStack removes the top item from the main data stack and pushes it
 // onto the alternate data stack.
 //
@@ -2167,9 +2280,9 @@ func opcodeCheckSafeMultiSig(op *parsedOpcode, vm *Engine) error {
 		return scriptError(ErrInvalidPubKeyCount, str)
 	}
 	vm.numOps += numKeyHashes
-	if vm.numOps > MaxOpsPerScript {
+	if vm.numOps > vm.limits.MaxOpsPerScript {
 		str := fmt.Sprintf("exceeded max operation limit of %d",
-			MaxOpsPerScript)
+			vm.limits.MaxOpsPerScript)
 		return scriptError(ErrTooManyOperations, str)
 	}
 
@@ -2402,9 +2515,9 @@ func opcodeCheckMultiSig(op *parsedOpcode, vm *Engine) error {
 		return scriptError(ErrInvalidPubKeyCount, str)
 	}
 	vm.numOps += numPubKeys
-	if vm.numOps > MaxOpsPerScript {
+	if vm.numOps > vm.limits.MaxOpsPerScript {
 		str := fmt.Sprintf("exceeded max operation limit of %d",
-			MaxOpsPerScript)
+			vm.limits.MaxOpsPerScript)
 		return scriptError(ErrTooManyOperations, str)
 	}
 
@@ -2597,9 +2710,10 @@ var OpcodeByName = make(map[string]byte)
 
 func init() {
 	// Initialize the opcode name to value map using the contents of the
-	// opcode array.  Also add entries for "OP_FALSE", "OP_TRUE", and
-	// "OP_NOP2" since they are aliases for "OP_0", "OP_1",
-	// and "OP_CHECKLOCKTIMEVERIFY" respectively.
+	// opcode array.  Also add entries for "OP_FALSE", "OP_TRUE",
+	// "OP_NOP2", "OP_NOP3" and "OP_NOP4" since they are aliases for "OP_0",
+	// "OP_1", "OP_CHECKLOCKTIMEVERIFY", "OP_CHECKSEQUENCEVERIFY" and
+	// "OP_CHECKKEYIDVERIFY" respectively.
 	for _, op := range opcodeArray {
 		OpcodeByName[op.name] = op.value
 	}
@@ -2607,4 +2721,5 @@ func init() {
 	OpcodeByName["OP_TRUE"] = OP_TRUE
 	OpcodeByName["OP_NOP2"] = OP_CHECKLOCKTIMEVERIFY
 	OpcodeByName["OP_NOP3"] = OP_CHECKSEQUENCEVERIFY
+	OpcodeByName["OP_NOP4"] = OP_CHECKKEYIDVERIFY
 }
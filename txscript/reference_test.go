@@ -10,10 +10,8 @@ import (
 	"bytes"
 	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
-	"strconv"
 	"strings"
 	"testing"
 
@@ -39,89 +37,6 @@ func testName(test []string) (string, error) {
 	return name, nil
 }
 
-// parse hex string into a []byte.
-func parseHex(tok string) ([]byte, error) {
-	if !strings.HasPrefix(tok, "0x") {
-		return nil, errors.New("not a hex number")
-	}
-	return hex.DecodeString(tok[2:])
-}
-
-// shortFormOps holds a map of opcode names to values for use in short form
-// parsing.  It is declared here so it only needs to be created once.
-var shortFormOps map[string]byte
-
-// parseShortForm parses a string as as used in the Bitcoin Core reference tests
-// into the script it came from.
-//
-// The format used for these tests is pretty simple if ad-hoc:
-//   - Opcodes other than the push opcodes and unknown are present as
-//     either OP_NAME or just NAME
-//   - Plain numbers are made into push operations
-//   - Numbers beginning with 0x are inserted into the []byte as-is (so
-//     0x14 is OP_DATA_20)
-//   - Single quoted strings are pushed as data
-//   - Anything else is an error
-func parseShortForm(script string) ([]byte, error) {
-	// Only create the short form opcode map once.
-	if shortFormOps == nil {
-		ops := make(map[string]byte)
-		for opcodeName, opcodeValue := range OpcodeByName {
-			if strings.Contains(opcodeName, "OP_UNKNOWN") {
-				continue
-			}
-			ops[opcodeName] = opcodeValue
-
-			// The opcodes named OP_# can't have the OP_ prefix
-			// stripped or they would conflict with the plain
-			// numbers.  Also, since OP_FALSE and OP_TRUE are
-			// aliases for the OP_0, and OP_1, respectively, they
-			// have the same value, so detect those by name and
-			// allow them.
-			if (opcodeName == "OP_FALSE" || opcodeName == "OP_TRUE") ||
-				(opcodeValue != OP_0 && (opcodeValue < OP_1 ||
-					opcodeValue > OP_16)) {
-
-				ops[strings.TrimPrefix(opcodeName, "OP_")] = opcodeValue
-			}
-		}
-		shortFormOps = ops
-	}
-
-	// Split only does one separator so convert all \n and tab into  space.
-	script = strings.Replace(script, "\n", " ", -1)
-	script = strings.Replace(script, "\t", " ", -1)
-	tokens := strings.Split(script, " ")
-	builder := NewScriptBuilder()
-
-	for _, tok := range tokens {
-		if len(tok) == 0 {
-			continue
-		}
-		// if parses as a plain number
-		if num, err := strconv.ParseInt(tok, 10, 64); err == nil {
-			builder.AddInt64(num)
-			continue
-		} else if bts, err := parseHex(tok); err == nil {
-			// Concatenate the bytes manually since the test code
-			// intentionally creates scripts that are too large and
-			// would cause the builder to error otherwise.
-			if builder.err == nil {
-				builder.script = append(builder.script, bts...)
-			}
-		} else if len(tok) >= 2 &&
-			tok[0] == '\'' && tok[len(tok)-1] == '\'' {
-			builder.AddFullData([]byte(tok[1 : len(tok)-1]))
-		} else if opcode, ok := shortFormOps[tok]; ok {
-			builder.AddOp(opcode)
-		} else {
-			return nil, fmt.Errorf("bad token \"%s\"", tok)
-		}
-
-	}
-	return builder.Script()
-}
-
 // parseScriptFlags parses the provided flags string from the format used in the
 // reference tests into ScriptFlags suitable for use in the script engine.
 func parseScriptFlags(flagStr string) (ScriptFlags, error) {
@@ -233,12 +148,12 @@ func TestScriptInvalidTests(t *testing.T) {
 				t.Errorf("%s: can't parse input amt: %v", name, err)
 				continue
 			}
-			scriptSig, err := parseShortForm(test[0])
+			scriptSig, err := Assemble(test[0])
 			if err != nil {
 				t.Errorf("%s: can't parse scriptSig; %v", name, err)
 				continue
 			}
-			scriptPubKey, err := parseShortForm(test[1])
+			scriptPubKey, err := Assemble(test[1])
 			if err != nil {
 				t.Errorf("%s: can't parse scriptPubkey; %v", name, err)
 				continue
@@ -305,12 +220,12 @@ func TestScriptValidTests(t *testing.T) {
 				t.Errorf("%s: can't parse input amt: %v", name, err)
 				continue
 			}
-			scriptSig, err := parseShortForm(test[0])
+			scriptSig, err := Assemble(test[0])
 			if err != nil {
 				t.Errorf("%s: can't parse scriptSig; %v", name, err)
 				continue
 			}
-			scriptPubKey, err := parseShortForm(test[1])
+			scriptPubKey, err := Assemble(test[1])
 			if err != nil {
 				t.Errorf("%s: can't parse scriptPubkey; %v", name, err)
 				continue
@@ -462,7 +377,7 @@ testloop:
 				continue testloop
 			}
 
-			script, err := parseShortForm(oscript)
+			script, err := Assemble(oscript)
 			if err != nil {
 				t.Errorf("bad test (%dth input script doesn't "+
 					"parse %v) %d: %v", j, err, i, test)
@@ -619,7 +534,7 @@ testloop:
 				continue
 			}
 
-			script, err := parseShortForm(oscript)
+			script, err := Assemble(oscript)
 			if err != nil {
 				t.Errorf("bad test (%dth input script doesn't "+
 					"parse %v) %d: %v", j, err, i, test)
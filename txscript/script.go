@@ -11,9 +11,12 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"github.com/btcsuite/golangcrypto/ripemd160"
 	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
 	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
 	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript/adminop"
 	"github.com/pyx-partners/dmgd/wire"
 	"time"
 )
@@ -41,9 +44,12 @@ const (
 
 // These are the constants specified for maximums in individual scripts.
 const (
-	MaxOpsPerScript       = 201 // Max number of non-push operations.
 	MaxPubKeysPerMultiSig = 20  // Multisig can't have more sigs than this.
 	MaxScriptElementSize  = 520 // Max bytes pushable to the stack.
+
+	// MaxOpsPerScript is the maximum number of non-push operations allowed
+	// by consensus.
+	MaxOpsPerScript = chaincfg.DefaultMaxOpsPerScript
 )
 
 // isSmallInt returns whether or not the opcode is considered a small integer,
@@ -268,7 +274,20 @@ func asInt32(pop parsedOpcode) (int32, error) {
 // We assume a Prova address structure like this:
 // basic: <2 hash keyID1 keyID2 3 OP_CHECKSAFEMULTISIG>
 // general: <x hash/keyID hash/keyID y OP_CHECKSAFEMULTISIG>
+// The keyIDs of the nested Prova scripts are extracted from Prova HTLC and
+// time lock scripts.
 func ExtractKeyIDs(pkScript []parsedOpcode) ([]btcec.KeyID, error) {
+	if branches := provaBranches(pkScript); branches != nil {
+		var keyIDs []btcec.KeyID
+		for _, branch := range branches {
+			branchKeyIDs, err := ExtractKeyIDs(branch)
+			if err != nil {
+				return nil, err
+			}
+			keyIDs = append(keyIDs, branchKeyIDs...)
+		}
+		return keyIDs, nil
+	}
 	// the basic structure has 6 elements, as described above
 	if len(pkScript) < 6 || !isSmallInt(pkScript[len(pkScript)-2].opcode) {
 		return nil, fmt.Errorf("unable to extract keyIDs from script, "+
@@ -294,7 +313,17 @@ func ExtractKeyIDs(pkScript []parsedOpcode) ([]btcec.KeyID, error) {
 // We assume a Prova address structure like this:
 // basic: <2 hash keyID1 keyID2 3 OP_CHECKSAFEMULTISIG>
 // general: <x hash/keyID hash/keyID y OP_CHECKSAFEMULTISIG>
+// The keyIDs of the nested Prova scripts are replaced in Prova HTLC and time
+// lock scripts.
 func ReplaceKeyIDs(pkScript []parsedOpcode, keyIdMap map[btcec.KeyID][]byte) error {
+	if branches := provaBranches(pkScript); branches != nil {
+		for _, branch := range branches {
+			if err := ReplaceKeyIDs(branch, keyIdMap); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 	// the basic structure has 6 elements, as described above
 	if len(pkScript) < 6 || !isSmallInt(pkScript[len(pkScript)-2].opcode) {
 		return fmt.Errorf("unable to extract keyIDs from script, "+
@@ -336,32 +365,68 @@ func ExtractThreadID(pkScript []parsedOpcode) (provautil.ThreadID, error) {
 	return provautil.ThreadID(asSmallInt(pkScript[0].opcode)), nil
 }
 
-// ThreadPkScript creates a new pkScript with all keyHashes.
-// 2 <pkHash> ... <pkHash> X OP_CHECKTHREAD
-func ThreadPkScript(keyHashes [][]byte) ([]byte, error) {
-	if len(keyHashes) < 2 {
-		return nil, fmt.Errorf("invalid chain state, at least 2 keys required" +
-			" for thread.")
+// ThreadPkScript creates a new pkScript with all keyHashes, which requires
+// signatures of threshold of their keys.
+// threshold <pkHash> ... <pkHash> X OP_CHECKTHREAD
+func ThreadPkScript(keyHashes [][]byte, threshold int) ([]byte, error) {
+	if threshold < 1 {
+		return nil, fmt.Errorf("invalid admin threshold %d", threshold)
 	}
-	// build the new pkScript with 2 of x multi-sig
-	pkScript := NewScriptBuilder().AddOp(OP_2)
+	if len(keyHashes) < threshold {
+		return nil, fmt.Errorf("invalid chain state, at least %d keys "+
+			"required for thread.", threshold)
+	}
+	// build the new pkScript with threshold of x multi-sig
+	pkScript := NewScriptBuilder().AddInt64(int64(threshold))
 	for i := range keyHashes {
 		pkScript.AddData(keyHashes[i])
 	}
 	return pkScript.AddInt64(int64(len(keyHashes))).AddOp(OP_CHECKTHREAD).Script()
 }
 
+// ExtractAdminPayload decodes the admin op payload of the passed
+// <OP_RETURN><OP_DATA> script with the schemas of the adminop package.  The
+// payload must be pushed with the smallest push opcode.  An Error with the
+// error code ErrNotAdminOp is returned for other scripts, and an
+// adminop.Error for payloads which do not match the schema of their op.
+func ExtractAdminPayload(pkScript []parsedOpcode) (*adminop.Payload, error) {
+	if len(pkScript) != 2 || pkScript[0].opcode.value != OP_RETURN ||
+		pkScript[1].opcode.length != len(pkScript[1].data)+1 {
+
+		return nil, scriptError(ErrNotAdminOp,
+			"script is not an <OP_RETURN><OP_DATA> script")
+	}
+	return adminop.Decode(pkScript[1].data)
+}
+
+// validatedAdminPayload returns the admin op payload of the passed script,
+// which is assumed to be validated as admin op, or an empty payload when it
+// is not.
+func validatedAdminPayload(pkScript []parsedOpcode) *adminop.Payload {
+	payload, err := ExtractAdminPayload(pkScript)
+	if err != nil {
+		return &adminop.Payload{}
+	}
+	return payload
+}
+
+// adminOpSchema returns the schema of the op byte of the passed admin op
+// script, or nil when the script carries no known op.
+func adminOpSchema(pkScript []parsedOpcode) *adminop.Schema {
+	if len(pkScript) != 2 || len(pkScript[1].data) == 0 {
+		return nil
+	}
+	return adminop.Lookup(adminop.Op(pkScript[1].data[0]))
+}
+
 // ExtractAdminData can read OP_*KEYADD and OP_*KEYREVOKE from admin outputs.
 // An admin op script of structure <OP_RETURN><OP_DATA> can be assumed from
 // previous validation.
 // This function returns the admin operation type byte, and the parsed
 // public key.
 func ExtractAdminData(pkScript []parsedOpcode) (byte, *btcec.PublicKey, error) {
-	pubKey, err := btcec.ParsePubKey(pkScript[1].data[1:1+btcec.PubKeyBytesLenCompressed], btcec.S256())
-	if err != nil {
-		return 0, nil, err
-	}
-	return pkScript[1].data[0], pubKey, nil
+	op, pubKey, _, err := ExtractASPData(pkScript)
+	return op, pubKey, err
 }
 
 // ExtractASPData can read AdminOpASPKeyAdd and AdminOpASPKeyRevoke from admin outputs.
@@ -370,74 +435,164 @@ func ExtractAdminData(pkScript []parsedOpcode) (byte, *btcec.PublicKey, error) {
 // This function returns the admin operation type byte, the parsed keyID, and
 // the parsed public key.
 func ExtractASPData(pkScript []parsedOpcode) (byte, *btcec.PublicKey, btcec.KeyID, error) {
-	pubKey, err := btcec.ParsePubKey(pkScript[1].data[1:1+btcec.PubKeyBytesLenCompressed], btcec.S256())
+	payload, err := ExtractAdminPayload(pkScript)
 	if err != nil {
 		return 0, nil, 0, err
 	}
-	dataLen := len(pkScript[1].data)
-	keyID := btcec.KeyIDFromAddressBuffer(pkScript[1].data[dataLen-btcec.KeyIDSize : dataLen])
-	return pkScript[1].data[0], pubKey, keyID, nil
+	if payload.PubKey == nil {
+		str := fmt.Sprintf("admin op %v carries no key", payload.Op)
+		return 0, nil, 0, scriptError(ErrNotAdminOp, str)
+	}
+	return byte(payload.Op), payload.PubKey, payload.KeyID, nil
+}
+
+// IssueDestLen is the length of an issuance destination as carried in
+// AdminOpIssueDestAdd and AdminOpIssueDestRevoke ops: the pubKeyHash of a
+// standard Prova address followed by its two keyIDs.
+const IssueDestLen = adminop.IssueDestLen
+
+// IsIssueDestOp returns true if the passed admin op script adds or revokes
+// an issuance destination.
+func IsIssueDestOp(pkScript []parsedOpcode) bool {
+	schema := adminOpSchema(pkScript)
+	return schema != nil && schema.Kind == adminop.KindIssueDest
+}
+
+// ExtractIssueDestData can read AdminOpIssueDestAdd and
+// AdminOpIssueDestRevoke from admin outputs.
+// The function assumes previous validation of the passed opcodes as
+// issuance destination op.
+// This function returns whether the op is an add op, and the destination.
+func ExtractIssueDestData(pkScript []parsedOpcode) (bool, [IssueDestLen]byte) {
+	payload := validatedAdminPayload(pkScript)
+	return payload.Op == adminop.IssueDestAdd, payload.IssueDest
+}
+
+// ExtractIssueDest returns the issuance destination paid to by the passed
+// Prova pkScript. Only standard Prova scripts with two keyIDs have an
+// issuance destination, false is returned for all other scripts.
+// basic: <2 hash keyID1 keyID2 3 OP_CHECKSAFEMULTISIG>
+func ExtractIssueDest(pkScript []parsedOpcode) ([IssueDestLen]byte, bool) {
+	var dest [IssueDestLen]byte
+	if !isProva(pkScript) || len(pkScript) != 6 ||
+		len(pkScript[1].data) != ripemd160.Size {
+		return dest, false
+	}
+	copy(dest[:], pkScript[1].data)
+	offset := ripemd160.Size
+	for i := 2; i < 4; i++ {
+		keyID, err := asInt32(pkScript[i])
+		if err != nil {
+			return dest, false
+		}
+		btcec.KeyID(keyID).ToAddressFormat(dest[offset:])
+		offset += btcec.KeyIDSize
+	}
+	return dest, true
+}
+
+// IsFreezeOp returns true if the passed admin op script freezes or unfreezes
+// an account.
+func IsFreezeOp(pkScript []parsedOpcode) bool {
+	schema := adminOpSchema(pkScript)
+	return schema != nil && schema.Kind == adminop.KindFreeze
+}
+
+// ExtractFreezeData can read AdminOpAccountFreeze and AdminOpAccountUnfreeze
+// from admin outputs.
+// The function assumes previous validation of the passed opcodes as account
+// freeze op.
+// This function returns whether the op is a freeze op, and the pubKeyHash of
+// the account.
+func ExtractFreezeData(pkScript []parsedOpcode) (bool, [ripemd160.Size]byte) {
+	payload := validatedAdminPayload(pkScript)
+	return payload.Op == adminop.AccountFreeze, payload.PubKeyHash
+}
+
+// AssetIDLen is the length of an asset ID as carried in AdminOpAssetID
+// markers.
+const AssetIDLen = adminop.AssetIDLen
+
+// IsAssetIDOp returns true if the passed script is an asset marker, tagging
+// the outputs of its transaction with the asset ID it carries.
+// <OP_RETURN><OP_DATA_5 AdminOpAssetID assetID>
+func IsAssetIDOp(pkScript []parsedOpcode) bool {
+	payload, err := ExtractAdminPayload(pkScript)
+	return err == nil && payload.Op == adminop.AssetID
+}
+
+// ExtractAssetIDData can read the asset ID of AdminOpAssetID markers.
+// The function assumes previous validation of the passed opcodes as asset
+// marker.
+func ExtractAssetIDData(pkScript []parsedOpcode) uint32 {
+	return validatedAdminPayload(pkScript).AssetID
+}
+
+// ExtractPkHashes returns all pubKeyHashes of the passed Prova pkScript.
+// Nil is returned for all other scripts.
+// general: <x hash/keyID hash/keyID y OP_CHECKSAFEMULTISIG>
+// The pubKeyHashes of the nested Prova scripts are returned for Prova HTLC
+// and time lock scripts.
+func ExtractPkHashes(pkScript []parsedOpcode) [][]byte {
+	if branches := provaBranches(pkScript); branches != nil {
+		var pkHashes [][]byte
+		for _, branch := range branches {
+			pkHashes = append(pkHashes, ExtractPkHashes(branch)...)
+		}
+		return pkHashes
+	}
+	if !isGeneralProva(pkScript) {
+		return nil
+	}
+	var pkHashes [][]byte
+	for _, pop := range pkScript[1 : len(pkScript)-2] {
+		if len(pop.data) == ripemd160.Size {
+			pkHashes = append(pkHashes, pop.data)
+		}
+	}
+	return pkHashes
 }
 
 // ExtractAdminOpData extract operation type and values from admin operations
 // in admin transactions.
 // The function assumes previous validation of all passed opcodes as admin ops.
 func ExtractAdminOpData(pkScript []parsedOpcode) (bool, btcec.KeySetType, *btcec.PublicKey, btcec.KeyID) {
-	pubKey, _ := btcec.ParsePubKey(pkScript[1].data[1:1+btcec.PubKeyBytesLenCompressed], btcec.S256())
-	dataLen := len(pkScript[1].data)
-	keyID := btcec.KeyID(0)
-	if dataLen > 1+btcec.PubKeyBytesLenCompressed {
-		keyID = btcec.KeyIDFromAddressBuffer(pkScript[1].data[dataLen-btcec.KeyIDSize : dataLen])
-	}
-	var isAddOp bool
-	keySetType := btcec.KeySetType(0)
-	switch pkScript[1].data[0] {
-	case AdminOpProvisionKeyAdd:
-		isAddOp = true
-		keySetType = btcec.ProvisionKeySet
-	case AdminOpProvisionKeyRevoke:
-		isAddOp = false
-		keySetType = btcec.ProvisionKeySet
-	case AdminOpIssueKeyAdd:
-		isAddOp = true
-		keySetType = btcec.IssueKeySet
-	case AdminOpIssueKeyRevoke:
-		isAddOp = false
-		keySetType = btcec.IssueKeySet
-	case AdminOpValidateKeyAdd:
-		isAddOp = true
-		keySetType = btcec.ValidateKeySet
-	case AdminOpValidateKeyRevoke:
-		isAddOp = false
-		keySetType = btcec.ValidateKeySet
-	case AdminOpASPKeyAdd:
-		isAddOp = true
-		keySetType = btcec.ASPKeySet
-	case AdminOpASPKeyRevoke:
-		isAddOp = false
-		keySetType = btcec.ASPKeySet
-	}
-	return isAddOp, keySetType, pubKey, keyID
+	payload := validatedAdminPayload(pkScript)
+	schema := payload.Schema()
+	if schema == nil || schema.Kind != adminop.KindKey {
+		return false, 0, nil, 0
+	}
+	return schema.IsAdd, schema.KeySet, payload.PubKey, payload.KeyID
 }
 
 // AdminOpString gives a human-readable version of an admin op script.
-// The function assumes previous validation as an actual valid admin op script.
+// An empty string is returned when the script is not a valid admin op.
 func AdminOpString(buf []byte) string {
 	opcodes, err := ParseScript(buf)
 	if err != nil {
 		return ""
 	}
-	isAddOp, keySetType, pubKey, keyID := ExtractAdminOpData(opcodes)
-	op := "REVOKE_KEY"
-	if isAddOp {
-		op = "ADD_KEY"
+	payload, err := ExtractAdminPayload(opcodes)
+	if err != nil {
+		return ""
+	}
+	schema := payload.Schema()
+	if schema.Kind != adminop.KindKey {
+		result := adminOpName(schema)
+		for _, field := range schema.Fields {
+			result += " " + formatAdminField(payload, field)
+		}
+		return result
 	}
 	result := fmt.Sprintf("%s %s %s",
-		op,
-		keySetType.String(),
-		hex.EncodeToString(pubKey.SerializeCompressed()))
-	if keyID > 0 {
-		result = fmt.Sprintf("%s %d", result, uint32(keyID))
+		adminOpName(schema),
+		schema.KeySet.String(),
+		hex.EncodeToString(payload.PubKey.SerializeCompressed()))
+	if payload.KeyID > 0 {
+		result = fmt.Sprintf("%s %d", result, uint32(payload.KeyID))
+	}
+	if payload.Expiry > 0 {
+		result = fmt.Sprintf("%s EXPIRES %d", result, payload.Expiry)
 	}
 	return result
 }
@@ -651,6 +806,16 @@ func calcSignatureHashNew(subScript []parsedOpcode, sigHashes *TxSigHashes,
 		return nil
 	}
 
+	return chainhash.DoubleHashB(calcSignatureHashPreimage(sigHashes,
+		hashType, tx, idx, amt))
+}
+
+// calcSignatureHashPreimage returns the message whose double sha256 hash is the
+// sighash digest calculated by calcSignatureHashNew.  The input index must be
+// valid for the transaction.
+func calcSignatureHashPreimage(sigHashes *TxSigHashes, hashType SigHashType,
+	tx *wire.MsgTx, idx int, amt int64) []byte {
+
 	// For now we only accept SigHashAll transactions
 	if hashType != SigHashAll {
 		fmt.Errorf("calcSignatureHashNew error: idx %d with wrong hashType %v.",
@@ -703,7 +868,7 @@ func calcSignatureHashNew(subScript []parsedOpcode, sigHashes *TxSigHashes,
 	binary.LittleEndian.PutUint32(bHashType[:], uint32(hashType))
 	sigHash.Write(bHashType[:])
 
-	return chainhash.DoubleHashB(sigHash.Bytes())
+	return sigHash.Bytes()
 }
 
 // asSmallInt returns the passed opcode, which must be true according to
@@ -743,7 +908,9 @@ func getSigOpCount(pops []parsedOpcode, precise bool) int {
 				nSigs += MaxPubKeysPerMultiSig
 			}
 		case OP_CHECKSAFEMULTISIG:
-			// TODO(prova): implement
+			// The signatures are only verified when an output
+			// paying to the script is spent, so they are counted
+			// by GetProvaSigOpCount from the spent script.
 			fallthrough
 		default:
 			// Not a sigop.
@@ -809,6 +976,32 @@ func GetPreciseSigOpCount(scriptSig, scriptPubKey []byte, bip16 bool) int {
 	return getSigOpCount(shPops, true)
 }
 
+// GetProvaSigOpCount returns the number of signature operations performed when
+// spending an output paying to the passed public key script with
+// OP_CHECKSAFEMULTISIG.  Each OP_CHECKSAFEMULTISIG counts for the number of
+// keys it declares, which bounds the number of signatures it verifies, or for
+// MaxPubKeysPerMultiSig when the number of keys is not a small integer.  The
+// branches of HTLC and time-locked Prova scripts are all counted.  If the
+// script fails to parse, then the count up to the point of failure is
+// returned.
+func GetProvaSigOpCount(pkScript []byte) int {
+	// Don't check error since parseScript returns the parsed-up-to-error
+	// list of pops.
+	pops, _ := ParseScript(pkScript)
+	nSigs := 0
+	for i, pop := range pops {
+		if pop.opcode.value != OP_CHECKSAFEMULTISIG {
+			continue
+		}
+		if i > 0 && isSmallInt(pops[i-1].opcode) {
+			nSigs += asSmallInt(pops[i-1].opcode)
+		} else {
+			nSigs += MaxPubKeysPerMultiSig
+		}
+	}
+	return nSigs
+}
+
 // IsUnspendable returns whether the passed public key script is unspendable, or
 // guaranteed to fail at execution.  This allows inputs to be pruned instantly
 // when entering the UTXO set.
@@ -3846,6 +3846,63 @@ func TestGetPreciseSigOps(t *testing.T) {
 	}
 }
 
+// TestGetProvaSigOpCount ensures the signature operations of
+// OP_CHECKSAFEMULTISIG are counted from the number of keys they declare.
+func TestGetProvaSigOpCount(t *testing.T) {
+	t.Parallel()
+
+	keyHash := "DATA_20 0x433ec2ac1ffa1b7b7d027f564529c57197f9ae88"
+	tests := []struct {
+		name     string
+		pkScript []byte
+		nSigOps  int
+	}{
+		{
+			name: "prova 2 of 3",
+			pkScript: mustParseShortForm("2 " + keyHash +
+				" 1 2 3 CHECKSAFEMULTISIG"),
+			nSigOps: 3,
+		},
+		{
+			name: "prova branches",
+			pkScript: mustParseShortForm("IF 2 " + keyHash +
+				" 1 2 3 CHECKSAFEMULTISIG ELSE 2 " + keyHash +
+				" " + keyHash + " 1 2 4 CHECKSAFEMULTISIG ENDIF"),
+			nSigOps: 7,
+		},
+		{
+			name:     "undeclared number of keys",
+			pkScript: mustParseShortForm("CHECKSAFEMULTISIG"),
+			nSigOps:  MaxPubKeysPerMultiSig,
+		},
+		{
+			name: "checksig",
+			pkScript: mustParseShortForm("DUP HASH160 " + keyHash +
+				" EQUALVERIFY CHECKSIG"),
+			nSigOps: 0,
+		},
+		{
+			name: "script doesn't parse",
+			pkScript: mustParseShortForm("2 " + keyHash +
+				" 1 2 3 CHECKSAFEMULTISIG PUSHDATA1 0x02"),
+			nSigOps: 3,
+		},
+	}
+	for _, test := range tests {
+		count := GetProvaSigOpCount(test.pkScript)
+		if count != test.nSigOps {
+			t.Errorf("%s: expected count of %d, got %d", test.name,
+				test.nSigOps, count)
+		}
+
+		// The quick count does not include OP_CHECKSAFEMULTISIG.
+		if count := GetSigOpCount(test.pkScript); count > 1 {
+			t.Errorf("%s: expected quick count of at most 1, got %d",
+				test.name, count)
+		}
+	}
+}
+
 // TestRemoveOpcodes ensures that removing opcodes from scripts behaves as
 // expected.
 func TestRemoveOpcodes(t *testing.T) {
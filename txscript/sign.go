@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"github.com/pyx-partners/dmgd/btcec"
 	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
 	"github.com/pyx-partners/dmgd/provautil"
 	"github.com/pyx-partners/dmgd/wire"
 	"sort"
@@ -54,6 +55,46 @@ func RawTxInSignatureNew(tx *wire.MsgTx, idx int, txSigHashes *TxSigHashes, amt
 	return append(signature.Serialize(), byte(hashType)), nil
 }
 
+// CalcSignatureHash returns the hash which the keys spending a previous output
+// with the passed pkScript and amount as the input idx of the given
+// transaction sign for the passed hash type.  It allows the signers of a
+// transaction created elsewhere to verify what they are signing.
+func CalcSignatureHash(tx *wire.MsgTx, idx int, amt int64, subScript []byte,
+	hashType SigHashType) ([]byte, error) {
+
+	preimage, err := CalcSignatureHashPreimage(tx, idx, amt, subScript,
+		hashType)
+	if err != nil {
+		return nil, err
+	}
+	return chainhash.DoubleHashB(preimage), nil
+}
+
+// CalcSignatureHashPreimage returns the message whose double sha256 hash is the
+// signature hash returned by CalcSignatureHash, for signers such as HSMs which
+// hash the message they sign themselves.  The preimage is the serialization
+// of BIP 143 without the script code, which Prova signatures do not commit to:
+//
+//   version (4) || hashPrevOuts (32) || hashSequence (32) ||
+//   outpoint (36) || amount (8) || sequence (4) || hashOutputs (32) ||
+//   locktime (4) || hashType (4)
+//
+// The numbers are little endian.  The script must still parse, so a script
+// which can not be spent is not signed.
+func CalcSignatureHashPreimage(tx *wire.MsgTx, idx int, amt int64,
+	subScript []byte, hashType SigHashType) ([]byte, error) {
+
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return nil, fmt.Errorf("input index %d out of range for "+
+			"transaction with %d inputs", idx, len(tx.TxIn))
+	}
+	if _, err := ParseScript(subScript); err != nil {
+		return nil, fmt.Errorf("cannot parse output script: %v", err)
+	}
+	return calcSignatureHashPreimage(NewTxSigHashes(tx), hashType, tx, idx,
+		amt), nil
+}
+
 // SignatureScript creates an input signature script for tx to spend DMG sent
 // from a previous output to the owner of privKey. tx must include all
 // transaction inputs and outputs, however txin scripts are allowed to be filled
@@ -134,7 +175,13 @@ func sign(chainParams *chaincfg.Params, tx *wire.MsgTx, idx int, inputAmt int64,
 	}
 
 	switch class {
-	case ProvaTy:
+	case ProvaTy, ProvaTimeLockTy:
+		// Time locked Prova scripts are signed like the Prova script
+		// they pay to, which must be a standard one.
+		if len(addresses) == 0 {
+			return nil, class, nil, 0,
+				errors.New("can't sign nonstandard Prova scripts")
+		}
 		// We use the keysDb lookup to get a list of privKeys
 		// that are needed for signing.
 		keys, err := kdb.GetKey(addresses[0])
@@ -156,7 +203,7 @@ func sign(chainParams *chaincfg.Params, tx *wire.MsgTx, idx int, inputAmt int64,
 		script, _ := signSafeMultiSig(tx, idx, txSigHashes, inputAmt, subScript, hashType,
 			keys, nrequired, kdb)
 		return script, class, addresses, nrequired, nil
-	case NullDataTy:
+	case NullDataTy, CommitmentTy:
 		return nil, class, nil, 0,
 			errors.New("can't sign NULLDATA transactions")
 	default:
@@ -176,7 +223,7 @@ func mergeScripts(chainParams *chaincfg.Params, tx *wire.MsgTx, idx int,
 	nRequired int, sigScript, prevScript []byte) []byte {
 
 	switch class {
-	case ProvaTy:
+	case ProvaTy, ProvaTimeLockTy:
 		return mergeProvaSig(tx, idx, addresses, nRequired, pkScript,
 			sigScript, prevScript)
 	case ProvaAdminTy:
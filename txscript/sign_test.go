@@ -7,6 +7,9 @@
 package txscript
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/pyx-partners/dmgd/btcec"
@@ -66,6 +69,11 @@ func (view *keyViewpoint) LookupKeyIDs(keyIDs []btcec.KeyID) map[btcec.KeyID][]b
 	return keyIdMap
 }
 
+// LookupKeyID returns the ASP key registered for the passed keyID.
+func (view *keyViewpoint) LookupKeyID(keyID btcec.KeyID) *btcec.PublicKey {
+	return view.aspKeyIdMap[keyID]
+}
+
 // GetAdminKeyHashes returns pubKeyHashes according to the provided threadID.
 func (view *keyViewpoint) GetAdminKeyHashes(threadID provautil.ThreadID) [][]byte {
 	pubs := view.adminKeySets[btcec.KeySetType(threadID)]
@@ -126,7 +134,8 @@ func checkScripts(msg string, tx *wire.MsgTx, idx int, inputAmt int64, sigScript
 
 	keyView.SetKeys(keySets)
 	// If script is Prova script, we replace all keyIDs with pubKeyHashes.
-	if TypeOfScript(pops) == ProvaTy {
+	switch TypeOfScript(pops) {
+	case ProvaTy, ProvaHTLCTy, ProvaTimeLockTy, ProvaKeyIDTy:
 		keyIDs, err := ExtractKeyIDs(pops)
 		keyIdMap := keyView.LookupKeyIDs(keyIDs)
 		ReplaceKeyIDs(pops, keyIdMap)
@@ -140,18 +149,23 @@ func checkScripts(msg string, tx *wire.MsgTx, idx int, inputAmt int64, sigScript
 	if TypeOfScript(pops) == ProvaAdminTy {
 		threadID, err := ExtractThreadID(pops)
 		keyHashes := keyView.GetAdminKeyHashes(threadID)
-		pkScript, err = ThreadPkScript(keyHashes)
+		pkScript, err = ThreadPkScript(keyHashes,
+			chaincfg.DefaultAdminThreshold)
 		if err != nil {
 			return err
 		}
 	}
 
 	vm, err := NewEngine(pkScript, tx, idx,
-		ScriptBip16|ScriptVerifyDERSignatures, nil, nil, inputAmt)
+		ScriptBip16|ScriptVerifyDERSignatures|
+			ScriptVerifyCheckLockTimeVerify|
+			ScriptVerifyCheckSequenceVerify|
+			ScriptVerifyCheckKeyIDVerify, nil, nil, inputAmt)
 	if err != nil {
 		return fmt.Errorf("failed to make script engine for %s: %v",
 			msg, err)
 	}
+	vm.SetKeyIDLookup(keyView.LookupKeyID)
 
 	err = vm.Execute()
 	if err != nil {
@@ -779,3 +793,502 @@ nexttest:
 		}
 	}
 }
+
+// TestSignProvaHTLC ensures Prova HTLC outputs can be spent through the redeem
+// branch with the secret, and through the refund branch once the lock time
+// has passed.
+func TestSignProvaHTLC(t *testing.T) {
+	t.Parallel()
+
+	keyId1 := btcec.KeyIDFromAddressBuffer([]byte{0, 0, 1, 0})
+	key1, _ := btcec.PrivKeyFromBytes(btcec.S256(), []byte{
+		0xea, 0xf0, 0x2c, 0xa3, 0x48, 0xc5, 0x24, 0xe6,
+		0x39, 0x26, 0x55, 0xba, 0x4d, 0x29, 0x60, 0x3c,
+		0xd1, 0xa7, 0x34, 0x7d, 0x9d, 0x65, 0xcf, 0xe9,
+		0x3c, 0xe1, 0xeb, 0xff, 0xdc, 0xa2, 0x26, 0x94,
+	})
+	keyId2 := btcec.KeyIDFromAddressBuffer([]byte{1, 0, 0, 0})
+
+	// The redeem and refund addresses share the ASP keys, but differ in
+	// the key of the account holder.
+	makeAddr := func() (*btcec.PrivateKey, provautil.Address) {
+		key, err := btcec.NewPrivateKey(btcec.S256())
+		if err != nil {
+			t.Fatalf("failed to make privKey: %v", err)
+		}
+		pkHash := provautil.Hash160(key.PubKey().SerializeCompressed())
+		addr, err := provautil.NewAddressProva(pkHash,
+			[]btcec.KeyID{keyId1, keyId2}, &chaincfg.TestNetParams)
+		if err != nil {
+			t.Fatalf("failed to make Prova address: %v", err)
+		}
+		return key, addr
+	}
+	redeemKey, redeemAddr := makeAddr()
+	refundKey, refundAddr := makeAddr()
+
+	secret := bytes.Repeat([]byte{0x42}, HTLCSecretSize)
+	secretHash := sha256.Sum256(secret)
+	const lockTime = 500000
+	pkScript, err := PayToProvaHTLCScript(secretHash[:], lockTime,
+		redeemAddr, refundAddr)
+	if err != nil {
+		t.Fatalf("PayToProvaHTLCScript: unexpected error: %v", err)
+	}
+	htlc, err := ExtractProvaHTLC(pkScript)
+	if err != nil {
+		t.Fatalf("ExtractProvaHTLC: unexpected error: %v", err)
+	}
+
+	hash, _ := chainhash.NewHashFromStr("08886fe11cc704bc617ebaf50f8bed16a66da84141d26d786a054f2c361c905a")
+	const inputAmt = 5000000000
+	signBranch := func(tx *wire.MsgTx, script []byte, key *btcec.PrivateKey) []byte {
+		lookupKey := func(a provautil.Address) ([]PrivateKey, error) {
+			return []PrivateKey{
+				PrivateKey{key, true},
+				PrivateKey{key1, true},
+			}, nil
+		}
+		sigScript, err := SignTxOutput(&chaincfg.TestNetParams, tx, 0,
+			inputAmt, script, SigHashAll, KeyClosure(lookupKey), nil)
+		if err != nil {
+			t.Fatalf("failed to sign output: %v", err)
+		}
+		return sigScript
+	}
+
+	tests := []struct {
+		name     string
+		lockTime uint32
+		refund   bool
+		key      *btcec.PrivateKey
+		secret   []byte
+		valid    bool
+	}{
+		{"redeem", 0, false, redeemKey, secret, true},
+		{"redeem with wrong secret", 0, false, redeemKey,
+			bytes.Repeat([]byte{0x43}, HTLCSecretSize), false},
+		{"redeem with refund key", 0, false, refundKey, secret, false},
+		{"refund", lockTime, true, refundKey, nil, true},
+		{"refund before lock time", lockTime - 1, true, refundKey, nil,
+			false},
+		{"refund with redeem key", lockTime, true, redeemKey, nil, false},
+	}
+
+	for _, test := range tests {
+		// The refund branch requires a non-final input sequence for
+		// the lock time to be enforced.
+		tx := &wire.MsgTx{
+			Version: 1,
+			TxIn: []*wire.TxIn{{
+				PreviousOutPoint: wire.OutPoint{Hash: *hash},
+				Sequence:         0,
+			}},
+			TxOut:    []*wire.TxOut{{Value: 1000000000}},
+			LockTime: test.lockTime,
+		}
+
+		var sigScript []byte
+		if test.refund {
+			sigScript, err = ProvaHTLCRefundSigScript(
+				signBranch(tx, htlc.RefundScript, test.key))
+		} else {
+			sigScript, err = ProvaHTLCRedeemSigScript(
+				signBranch(tx, htlc.RedeemScript, test.key),
+				test.secret)
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", test.name, err)
+		}
+
+		err = checkScripts(test.name, tx, 0, inputAmt, sigScript,
+			pkScript)
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("%s: spend is valid", test.name)
+		}
+	}
+}
+
+// TestSignProvaTimeLock ensures time locked Prova outputs are signed like the
+// Prova script they pay to, and can only be spent once their lock time has
+// passed.
+func TestSignProvaTimeLock(t *testing.T) {
+	t.Parallel()
+
+	keyId1 := btcec.KeyIDFromAddressBuffer([]byte{0, 0, 1, 0})
+	key1, _ := btcec.PrivKeyFromBytes(btcec.S256(), []byte{
+		0xea, 0xf0, 0x2c, 0xa3, 0x48, 0xc5, 0x24, 0xe6,
+		0x39, 0x26, 0x55, 0xba, 0x4d, 0x29, 0x60, 0x3c,
+		0xd1, 0xa7, 0x34, 0x7d, 0x9d, 0x65, 0xcf, 0xe9,
+		0x3c, 0xe1, 0xeb, 0xff, 0xdc, 0xa2, 0x26, 0x94,
+	})
+	keyId2 := btcec.KeyIDFromAddressBuffer([]byte{1, 0, 0, 0})
+	key, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("failed to make privKey: %v", err)
+	}
+	pkHash := provautil.Hash160(key.PubKey().SerializeCompressed())
+	addr, err := provautil.NewAddressProva(pkHash,
+		[]btcec.KeyID{keyId1, keyId2}, &chaincfg.TestNetParams)
+	if err != nil {
+		t.Fatalf("failed to make Prova address: %v", err)
+	}
+
+	const lockTime = 500000
+	lockTimeScript, err := PayToProvaLockTimeScript(lockTime, addr)
+	if err != nil {
+		t.Fatalf("PayToProvaLockTimeScript: unexpected error: %v", err)
+	}
+	const sequence = 144
+	sequenceScript, err := PayToProvaSequenceLockScript(sequence, addr)
+	if err != nil {
+		t.Fatalf("PayToProvaSequenceLockScript: unexpected error: %v",
+			err)
+	}
+
+	hash, _ := chainhash.NewHashFromStr("08886fe11cc704bc617ebaf50f8bed16a66da84141d26d786a054f2c361c905a")
+	const inputAmt = 5000000000
+	lookupKey := func(a provautil.Address) ([]PrivateKey, error) {
+		return []PrivateKey{
+			PrivateKey{key, true},
+			PrivateKey{key1, true},
+		}, nil
+	}
+
+	tests := []struct {
+		name     string
+		pkScript []byte
+		modify   func(tx *wire.MsgTx)
+		valid    bool
+	}{
+		{"absolute", lockTimeScript, nil, true},
+		{"absolute before lock time", lockTimeScript,
+			func(tx *wire.MsgTx) { tx.LockTime = lockTime - 1 }, false},
+		{"absolute with final input", lockTimeScript,
+			func(tx *wire.MsgTx) {
+				tx.TxIn[0].Sequence = wire.MaxTxInSequenceNum
+			}, false},
+		{"relative", sequenceScript, nil, true},
+		{"relative before lock time", sequenceScript,
+			func(tx *wire.MsgTx) { tx.TxIn[0].Sequence = sequence - 1 },
+			false},
+		{"relative with version 1", sequenceScript,
+			func(tx *wire.MsgTx) { tx.Version = 1 }, false},
+	}
+
+	for _, test := range tests {
+		tx := &wire.MsgTx{
+			Version: 1,
+			TxIn: []*wire.TxIn{{
+				PreviousOutPoint: wire.OutPoint{Hash: *hash},
+				Sequence:         wire.MaxTxInSequenceNum,
+			}},
+			TxOut: []*wire.TxOut{{Value: 1000000000}},
+		}
+		err := PrepareProvaTimeLockSpend(tx, 0, test.pkScript)
+		if err != nil {
+			t.Fatalf("%s: PrepareProvaTimeLockSpend: unexpected "+
+				"error: %v", test.name, err)
+		}
+		if test.modify != nil {
+			test.modify(tx)
+		}
+
+		sigScript, err := SignTxOutput(&chaincfg.TestNetParams, tx, 0,
+			inputAmt, test.pkScript, SigHashAll, KeyClosure(lookupKey),
+			nil)
+		if err != nil {
+			t.Fatalf("%s: failed to sign output: %v", test.name, err)
+		}
+		err = checkScripts(test.name, tx, 0, inputAmt, sigScript,
+			test.pkScript)
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("%s: spend is valid", test.name)
+		}
+	}
+}
+
+// TestSignProvaKeyID ensures Prova keyID outputs are spent with the signatures
+// of their Prova script and of the key provisioned for their keyID, which is
+// only checked when OP_CHECKKEYIDVERIFY is active.
+func TestSignProvaKeyID(t *testing.T) {
+	t.Parallel()
+
+	// The keys provisioned for keyId1 and keyId2 by checkScripts.
+	keyId1 := btcec.KeyIDFromAddressBuffer([]byte{0, 0, 1, 0})
+	key1, _ := btcec.PrivKeyFromBytes(btcec.S256(), []byte{
+		0xea, 0xf0, 0x2c, 0xa3, 0x48, 0xc5, 0x24, 0xe6,
+		0x39, 0x26, 0x55, 0xba, 0x4d, 0x29, 0x60, 0x3c,
+		0xd1, 0xa7, 0x34, 0x7d, 0x9d, 0x65, 0xcf, 0xe9,
+		0x3c, 0xe1, 0xeb, 0xff, 0xdc, 0xa2, 0x26, 0x94,
+	})
+	keyId2 := btcec.KeyIDFromAddressBuffer([]byte{1, 0, 0, 0})
+	key2, _ := btcec.PrivKeyFromBytes(btcec.S256(), []byte{
+		0x2b, 0x8c, 0x52, 0xb7, 0x7b, 0x32, 0x7c, 0x75,
+		0x5b, 0x9b, 0x37, 0x55, 0x00, 0xd3, 0xf4, 0xb2,
+		0xda, 0x9b, 0x0a, 0x1f, 0xf6, 0x5f, 0x68, 0x91,
+		0xd3, 0x11, 0xfe, 0x94, 0x29, 0x5b, 0xc2, 0x6a,
+	})
+	key, err := btcec.NewPrivateKey(btcec.S256())
+	if err != nil {
+		t.Fatalf("failed to make privKey: %v", err)
+	}
+	pkHash := provautil.Hash160(key.PubKey().SerializeCompressed())
+	addr, err := provautil.NewAddressProva(pkHash,
+		[]btcec.KeyID{keyId1, keyId2}, &chaincfg.TestNetParams)
+	if err != nil {
+		t.Fatalf("failed to make Prova address: %v", err)
+	}
+	provaScript, err := PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("PayToAddrScript: unexpected error: %v", err)
+	}
+
+	hash, _ := chainhash.NewHashFromStr("08886fe11cc704bc617ebaf50f8bed16a66da84141d26d786a054f2c361c905a")
+	const inputAmt = 5000000000
+	lookupKey := func(a provautil.Address) ([]PrivateKey, error) {
+		return []PrivateKey{
+			PrivateKey{key, true},
+			PrivateKey{key1, true},
+		}, nil
+	}
+
+	tests := []struct {
+		name   string
+		keyID  btcec.KeyID
+		key    *btcec.PrivateKey
+		amount int64
+		valid  bool
+	}{
+		{"key of keyID", keyId2, key2, inputAmt, true},
+		{"other ASP key", keyId2, key1, inputAmt, false},
+		{"account key", keyId2, key, inputAmt, false},
+		{"unknown keyID", 7, key2, inputAmt, false},
+		{"wrong amount", keyId2, key2, inputAmt - 1, false},
+	}
+
+	for _, test := range tests {
+		pkScript, err := PayToProvaKeyIDScript(test.keyID, addr)
+		if err != nil {
+			t.Fatalf("%s: PayToProvaKeyIDScript: unexpected error: %v",
+				test.name, err)
+		}
+		tx := &wire.MsgTx{
+			Version: 1,
+			TxIn: []*wire.TxIn{{
+				PreviousOutPoint: wire.OutPoint{Hash: *hash},
+				Sequence:         wire.MaxTxInSequenceNum,
+			}},
+			TxOut: []*wire.TxOut{{Value: 1000000000}},
+		}
+
+		provaSigScript, err := SignTxOutput(&chaincfg.TestNetParams, tx,
+			0, inputAmt, provaScript, SigHashAll, KeyClosure(lookupKey),
+			nil)
+		if err != nil {
+			t.Fatalf("%s: failed to sign output: %v", test.name, err)
+		}
+		keyIDSig, err := RawTxInSignatureNew(tx, 0, NewTxSigHashes(tx),
+			test.amount, pkScript, SigHashAll, test.key)
+		if err != nil {
+			t.Fatalf("%s: failed to sign keyID: %v", test.name, err)
+		}
+		sigScript, err := ProvaKeyIDSigScript(provaSigScript, keyIDSig)
+		if err != nil {
+			t.Fatalf("%s: ProvaKeyIDSigScript: unexpected error: %v",
+				test.name, err)
+		}
+
+		err = checkScripts(test.name, tx, 0, inputAmt, sigScript,
+			pkScript)
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("%s: spend is valid", test.name)
+		}
+
+		// Before OP_CHECKKEYIDVERIFY is active, the output is spent
+		// with the signatures of the Prova script alone.
+		pops, _ := ParseScript(pkScript)
+		ReplaceKeyIDs(pops, map[btcec.KeyID][]byte{
+			keyId1: provautil.Hash160(
+				key1.PubKey().SerializeCompressed()),
+			keyId2: provautil.Hash160(
+				key2.PubKey().SerializeCompressed()),
+		})
+		execScript, _ := UnparseScript(pops)
+		vm, err := NewEngine(execScript, tx, 0, ScriptBip16|
+			ScriptVerifyDERSignatures, nil, nil, inputAmt)
+		if err == nil {
+			err = vm.Execute()
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error before activation: %v",
+				test.name, err)
+		}
+		vm, err = NewEngine(execScript, tx, 0, StandardVerifyFlags, nil,
+			nil, inputAmt)
+		if err == nil {
+			err = vm.Execute()
+		}
+		if !IsErrorCode(err, ErrDiscourageUpgradableNOPs) {
+			t.Errorf("%s: got error %v before activation, want %v",
+				test.name, err, ErrDiscourageUpgradableNOPs)
+		}
+	}
+}
+
+// TestCalcInputSignatureHash ensures the signature hash CalcSignatureHash
+// returns for an input is the hash signed by RawTxInSignatureNew, and that it
+// depends on the amount of the input.
+func TestCalcInputSignatureHash(t *testing.T) {
+	t.Parallel()
+
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), privKeyD)
+	pkScript, err := payToProvaScript(provautil.Hash160(
+		privKey.PubKey().SerializeCompressed()),
+		[]btcec.KeyID{1, 2})
+	if err != nil {
+		t.Fatalf("payToProvaScript: %v", err)
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(coinbaseOutPoint, nil))
+	tx.AddTxOut(wire.NewTxOut(500, []byte{OP_RETURN}))
+
+	hash, err := CalcSignatureHash(tx, 0, 1000, pkScript, SigHashAll)
+	if err != nil {
+		t.Fatalf("CalcSignatureHash: %v", err)
+	}
+	sigBytes, err := RawTxInSignatureNew(tx, 0, NewTxSigHashes(tx), 1000,
+		pkScript, SigHashAll, privKey)
+	if err != nil {
+		t.Fatalf("RawTxInSignatureNew: %v", err)
+	}
+	sig, err := btcec.ParseDERSignature(sigBytes[:len(sigBytes)-1],
+		btcec.S256())
+	if err != nil {
+		t.Fatalf("ParseDERSignature: %v", err)
+	}
+	if !sig.Verify(hash, privKey.PubKey()) {
+		t.Fatalf("signature does not verify for the signature hash")
+	}
+
+	otherHash, err := CalcSignatureHash(tx, 0, 1001, pkScript, SigHashAll)
+	if err != nil {
+		t.Fatalf("CalcSignatureHash: %v", err)
+	}
+	if bytes.Equal(hash, otherHash) {
+		t.Fatalf("signature hash does not commit to the input amount")
+	}
+	if _, err := CalcSignatureHash(tx, 1, 1000, pkScript, SigHashAll); err == nil {
+		t.Fatalf("CalcSignatureHash: no error for out of range input")
+	}
+}
+
+// TestCalcSignatureHashPreimage checks the signature hash preimages of the
+// inputs of a fixed transaction against test vectors, and that the signature
+// hash is their double sha256 hash.
+func TestCalcSignatureHashPreimage(t *testing.T) {
+	t.Parallel()
+
+	var prevHash1, prevHash2 chainhash.Hash
+	for i := range prevHash1 {
+		prevHash1[i] = 0x01
+		prevHash2[i] = 0x02
+	}
+	tx := wire.NewMsgTx(1)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{
+			Hash:  prevHash1,
+			Index: 0,
+		},
+		Sequence: 0xffffffff,
+	})
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: wire.OutPoint{
+			Hash:  prevHash2,
+			Index: 3,
+		},
+		Sequence: 0xfffffffe,
+	})
+	tx.AddTxOut(wire.NewTxOut(0, []byte{OP_1, OP_CHECKTHREAD}))
+	tx.AddTxOut(wire.NewTxOut(1500000, []byte{OP_RETURN}))
+	tx.LockTime = 500000
+
+	tests := []struct {
+		idx      int
+		amt      int64
+		script   string
+		preimage string
+		sigHash  string
+	}{
+		{
+			idx:    0,
+			amt:    0,
+			script: "51bb",
+			preimage: "0100000024edb8016d7b393bb2d8c8d626aac0f32b06ba0" +
+				"46abdfb6bc5f9dc23e48fc4919a9ce82897468e42685eb3e0d50" +
+				"9dd5039530c4bcc11e453fd5eda5ca5c9490d010101010101010" +
+				"1010101010101010101010101010101010101010101010101000" +
+				"000000000000000000000ffffffff6fa04325c9160c3168eaaa3" +
+				"59deb38b063e3195e4873af7184f3e418701f5d9b20a10700010" +
+				"00000",
+			sigHash: "ef79e0b97916895aceebb2c064542cc2665480e96aa09a7a" +
+				"158b55c5959427c8",
+		},
+		{
+			idx:    1,
+			amt:    150000000,
+			script: "6a",
+			preimage: "0100000024edb8016d7b393bb2d8c8d626aac0f32b06ba0" +
+				"46abdfb6bc5f9dc23e48fc4919a9ce82897468e42685eb3e0d50" +
+				"9dd5039530c4bcc11e453fd5eda5ca5c9490d020202020202020" +
+				"2020202020202020202020202020202020202020202020202030" +
+				"0000080d1f00800000000feffffff6fa04325c9160c3168eaaa3" +
+				"59deb38b063e3195e4873af7184f3e418701f5d9b20a10700010" +
+				"00000",
+			sigHash: "3936bdffa319d0a4d57245f382336c715563d5ad387f04cb" +
+				"b870881f0c9a822e",
+		},
+	}
+	for i, test := range tests {
+		script := hexToBytes(test.script)
+		preimage, err := CalcSignatureHashPreimage(tx, test.idx,
+			test.amt, script, SigHashAll)
+		if err != nil {
+			t.Fatalf("#%d: CalcSignatureHashPreimage: %v", i, err)
+		}
+		if got := hex.EncodeToString(preimage); got != test.preimage {
+			t.Errorf("#%d: preimage %s, want %s", i, got,
+				test.preimage)
+		}
+		sigHash, err := CalcSignatureHash(tx, test.idx, test.amt,
+			script, SigHashAll)
+		if err != nil {
+			t.Fatalf("#%d: CalcSignatureHash: %v", i, err)
+		}
+		if got := hex.EncodeToString(sigHash); got != test.sigHash {
+			t.Errorf("#%d: signature hash %s, want %s", i, got,
+				test.sigHash)
+		}
+		if !bytes.Equal(sigHash, chainhash.DoubleHashB(preimage)) {
+			t.Errorf("#%d: signature hash is not the hash of the "+
+				"preimage", i)
+		}
+	}
+
+	if _, err := CalcSignatureHashPreimage(tx, 2, 0, nil, SigHashAll); err == nil {
+		t.Errorf("CalcSignatureHashPreimage: no error for out of range " +
+			"input")
+	}
+	if _, err := CalcSignatureHashPreimage(tx, 0, 0, []byte{OP_DATA_1},
+		SigHashAll); err == nil {
+
+		t.Errorf("CalcSignatureHashPreimage: no error for unparsable " +
+			"script")
+	}
+}
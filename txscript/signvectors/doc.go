@@ -0,0 +1,33 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package signvectors provides deterministic test vectors for signing the inputs
+which spend Prova outputs and admin thread tips, whose scripts are checked with
+OP_CHECKSAFEMULTISIG and OP_CHECKTHREAD.
+
+The vectors are generated by Generate from fixed keys and transactions, with
+the signing code of the txscript package, and are published as vectors.json in
+this directory, which the tests keep identical to the generated vectors.
+Wallet libraries in other languages can check their signing against them
+without running a node.
+
+Each vector holds:
+
+  - The signing keys, with their role and, for ASP keys, their keyID
+  - The script of the spent output and its amount
+  - The script executed in its place, in which the node replaced the keyIDs of
+    a Prova script by the hashes of the public keys of their ASP keys, or the
+    thread of an admin thread script by the hashes of the keys of its key set
+    and their threshold
+  - The unsigned transaction and the index of the signed input
+  - The signature hash preimage and the signature hash
+  - The DER signatures of the keys, followed by the hash type, which use RFC
+    6979 nonces and low S values
+  - The signature script, which pushes the compressed public key and the
+    signature of each key in order, and the signed transaction
+
+Generate the JSON file with the gensignvectors command.
+*/
+package signvectors
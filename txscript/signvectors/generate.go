@@ -0,0 +1,521 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package signvectors
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript"
+	"github.com/pyx-partners/dmgd/txscript/adminop"
+	"github.com/pyx-partners/dmgd/wire"
+)
+
+const (
+	// Version is the version of the format of the vectors, which is
+	// increased whenever a field changes meaning or is removed.
+	Version = 1
+
+	// FileName is the name of the JSON file holding the published vectors.
+	FileName = "vectors.json"
+)
+
+// These constants define the roles of the keys of the vectors.
+const (
+	RoleAccount   = "account"
+	RoleASP       = "asp"
+	RoleProvision = "provision"
+	RoleIssue     = "issue"
+)
+
+// threadRoles maps the admin threads to the role of the keys signing them.
+var threadRoles = map[provautil.ThreadID]string{
+	provautil.ProvisionThread: RoleProvision,
+	provautil.IssueThread:     RoleIssue,
+}
+
+// Vectors is the JSON encoding of the test vectors.  All signatures are made
+// with the parameters of the named network.
+type Vectors struct {
+	Version int       `json:"version"`
+	Network string    `json:"network"`
+	Vectors []*Vector `json:"vectors"`
+}
+
+// Encode returns the contents of the JSON file of the vectors.
+func (v *Vectors) Encode() ([]byte, error) {
+	serialized, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(serialized, '\n'), nil
+}
+
+// Key is the JSON encoding of a key of a vector.
+type Key struct {
+	Role    string `json:"role"`
+	KeyID   uint32 `json:"keyid,omitempty"`
+	PrivKey string `json:"privkey"`
+	PubKey  string `json:"pubkey"`
+}
+
+// Vector is the JSON encoding of the signing of one input.  Signers are the
+// indexes in Keys of the keys signing the input, in the order of their
+// signatures in the signature script.  Thread is the admin thread spent by the
+// input, if any.
+type Vector struct {
+	Name       string   `json:"name"`
+	Comment    string   `json:"comment"`
+	Keys       []Key    `json:"keys"`
+	Thread     string   `json:"thread,omitempty"`
+	PkScript   string   `json:"pkscript"`
+	ExecScript string   `json:"execscript"`
+	Amount     int64    `json:"amount"`
+	UnsignedTx string   `json:"unsignedtx"`
+	Index      int      `json:"index"`
+	HashType   uint32   `json:"hashtype"`
+	Preimage   string   `json:"sighashpreimage"`
+	SigHash    string   `json:"sighash"`
+	Signers    []int    `json:"signers"`
+	Signatures []string `json:"signatures"`
+	ScriptSig  string   `json:"scriptsig"`
+	SignedTx   string   `json:"signedtx"`
+}
+
+// testKey is a key of the vectors with its role.
+type testKey struct {
+	role    string
+	keyID   btcec.KeyID
+	privKey *btcec.PrivateKey
+}
+
+// newTestKey derives the private key of the passed label.
+func newTestKey(role, label string, keyID btcec.KeyID) *testKey {
+	seed := sha256.Sum256([]byte("dmgd signvectors " + label))
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), seed[:])
+	return &testKey{role: role, keyID: keyID, privKey: privKey}
+}
+
+// pubKeyHash returns the hash of the compressed public key of the key.
+func (k *testKey) pubKeyHash() []byte {
+	return provautil.Hash160(k.privKey.PubKey().SerializeCompressed())
+}
+
+// spec defines a vector before it is signed.
+type spec struct {
+	name     string
+	comment  string
+	keys     []*testKey
+	thread   provautil.ThreadID
+	isThread bool
+	pkScript []byte
+	amount   int64
+	tx       *wire.MsgTx
+	idx      int
+	signers  []int
+}
+
+// serializeTx returns the hex-encoded serialization of the passed transaction.
+func serializeTx(tx *wire.MsgTx) (string, error) {
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+// execScript returns the script the node executes in place of the passed
+// pkScript, with the keyIDs of a Prova script replaced by the hashes of their
+// ASP keys, or the thread of an admin thread script by the hashes of the keys
+// of its role and their threshold.
+func execScript(params *chaincfg.Params, pkScript []byte, keys []*testKey,
+	thread provautil.ThreadID, isThread bool) ([]byte, error) {
+
+	if isThread {
+		role, ok := threadRoles[thread]
+		if !ok {
+			return nil, fmt.Errorf("no keys sign thread %d", thread)
+		}
+		var keyHashes [][]byte
+		for _, key := range keys {
+			if key.role == role {
+				keyHashes = append(keyHashes, key.pubKeyHash())
+			}
+		}
+		return txscript.ThreadPkScript(keyHashes, params.AdminThreshold(
+			btcec.KeySetType(thread)))
+	}
+
+	keyIDMap := make(map[btcec.KeyID][]byte)
+	for _, key := range keys {
+		if key.role == RoleASP {
+			keyIDMap[key.keyID] = key.pubKeyHash()
+		}
+	}
+	pops, err := txscript.ParseScript(pkScript)
+	if err != nil {
+		return nil, err
+	}
+	if err := txscript.ReplaceKeyIDs(pops, keyIDMap); err != nil {
+		return nil, err
+	}
+	return txscript.UnparseScript(pops)
+}
+
+// sign signs the vector defined by the passed spec.
+func (s *spec) sign(params *chaincfg.Params) (*Vector, error) {
+	v := &Vector{
+		Name:     s.name,
+		Comment:  s.comment,
+		PkScript: hex.EncodeToString(s.pkScript),
+		Amount:   s.amount,
+		Index:    s.idx,
+		HashType: uint32(txscript.SigHashAll),
+		Signers:  s.signers,
+	}
+	for _, key := range s.keys {
+		v.Keys = append(v.Keys, Key{
+			Role:    key.role,
+			KeyID:   uint32(key.keyID),
+			PrivKey: hex.EncodeToString(key.privKey.Serialize()),
+			PubKey: hex.EncodeToString(
+				key.privKey.PubKey().SerializeCompressed()),
+		})
+	}
+	if s.isThread {
+		v.Thread = threadRoles[s.thread]
+	}
+	script, err := execScript(params, s.pkScript, s.keys, s.thread,
+		s.isThread)
+	if err != nil {
+		return nil, err
+	}
+	v.ExecScript = hex.EncodeToString(script)
+	if v.UnsignedTx, err = serializeTx(s.tx); err != nil {
+		return nil, err
+	}
+
+	preimage, err := txscript.CalcSignatureHashPreimage(s.tx, s.idx,
+		s.amount, s.pkScript, txscript.SigHashAll)
+	if err != nil {
+		return nil, err
+	}
+	v.Preimage = hex.EncodeToString(preimage)
+	v.SigHash = hex.EncodeToString(chainhash.DoubleHashB(preimage))
+
+	// The signature script is made by the signing code of the wallets,
+	// which signs with the keys in the order they are looked up.
+	signers := make([]txscript.PrivateKey, 0, len(s.signers))
+	for _, i := range s.signers {
+		privKey := s.keys[i].privKey
+		sig, err := txscript.RawTxInSignatureNew(s.tx, s.idx,
+			txscript.NewTxSigHashes(s.tx), s.amount, s.pkScript,
+			txscript.SigHashAll, privKey)
+		if err != nil {
+			return nil, err
+		}
+		v.Signatures = append(v.Signatures, hex.EncodeToString(sig))
+		signers = append(signers, txscript.PrivateKey{Key: privKey,
+			Compressed: true})
+	}
+	sigScript, err := txscript.SignTxOutput(params, s.tx, s.idx, s.amount,
+		s.pkScript, txscript.SigHashAll, txscript.KeyClosure(
+			func(provautil.Address) ([]txscript.PrivateKey, error) {
+				return signers, nil
+			}), nil)
+	if err != nil {
+		return nil, err
+	}
+	v.ScriptSig = hex.EncodeToString(sigScript)
+
+	signedTx := s.tx.Copy()
+	signedTx.TxIn[s.idx].SignatureScript = sigScript
+	if v.SignedTx, err = serializeTx(signedTx); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// fundingOutPoint returns a fixed outpoint of a transaction which does not
+// exist, named by the passed label.
+func fundingOutPoint(label string, index uint32) *wire.OutPoint {
+	hash := chainhash.DoubleHashH([]byte("dmgd signvectors " + label))
+	return wire.NewOutPoint(&hash, index)
+}
+
+// provaScript returns the Prova script of the passed account key and ASP
+// keyIDs.
+func provaScript(params *chaincfg.Params, account *testKey,
+	keyIDs ...btcec.KeyID) ([]byte, error) {
+
+	addr, err := provautil.NewAddressProva(account.pubKeyHash(), keyIDs,
+		params)
+	if err != nil {
+		return nil, err
+	}
+	return txscript.PayToAddrScript(addr)
+}
+
+// specs returns the definitions of the vectors.
+func specs(params *chaincfg.Params) ([]*spec, error) {
+	account := newTestKey(RoleAccount, "account", 0)
+	asp1 := newTestKey(RoleASP, "asp 1", 1)
+	asp2 := newTestKey(RoleASP, "asp 2", 2)
+	payee := newTestKey(RoleAccount, "payee", 0)
+	provaKeys := []*testKey{account, asp1, asp2}
+
+	spent, err := provaScript(params, account, asp1.keyID, asp2.keyID)
+	if err != nil {
+		return nil, err
+	}
+	payTo, err := provaScript(params, payee, asp1.keyID, asp2.keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A payment spending a single Prova output.
+	payment := wire.NewMsgTx(1)
+	payment.AddTxIn(wire.NewTxIn(fundingOutPoint("funding 1", 0), nil))
+	payment.AddTxOut(wire.NewTxOut(90000000, payTo))
+
+	// A payment spending two Prova outputs with change.
+	change := wire.NewMsgTx(1)
+	change.AddTxIn(wire.NewTxIn(fundingOutPoint("funding 2", 1), nil))
+	change.AddTxIn(wire.NewTxIn(fundingOutPoint("funding 3", 0), nil))
+	change.AddTxOut(wire.NewTxOut(300000000, payTo))
+	change.AddTxOut(wire.NewTxOut(140000000, spent))
+	change.LockTime = 1000
+
+	// A provision thread transaction adding an ASP key.
+	provisionKeys := []*testKey{
+		newTestKey(RoleProvision, "provision 1", 0),
+		newTestKey(RoleProvision, "provision 2", 0),
+		newTestKey(RoleProvision, "provision 3", 0),
+	}
+	provisionScript, err := txscript.ProvaThreadScript(
+		provautil.ProvisionThread)
+	if err != nil {
+		return nil, err
+	}
+	opScript, err := txscript.AdminOpScript(&adminop.Payload{
+		Op:     adminop.ASPKeyAdd,
+		PubKey: newTestKey(RoleASP, "asp 3", 3).privKey.PubKey(),
+		KeyID:  3,
+	})
+	if err != nil {
+		return nil, err
+	}
+	provision := wire.NewMsgTx(1)
+	provision.AddTxIn(wire.NewTxIn(fundingOutPoint("provision tip", 0),
+		nil))
+	provision.AddTxOut(wire.NewTxOut(0, provisionScript))
+	provision.AddTxOut(wire.NewTxOut(0, opScript))
+
+	// An issue thread transaction issuing to a Prova address.
+	issueKeys := []*testKey{
+		newTestKey(RoleIssue, "issue 1", 0),
+		newTestKey(RoleIssue, "issue 2", 0),
+	}
+	issueScript, err := txscript.ProvaThreadScript(provautil.IssueThread)
+	if err != nil {
+		return nil, err
+	}
+	issue := wire.NewMsgTx(1)
+	issue.AddTxIn(wire.NewTxIn(fundingOutPoint("issue tip", 0), nil))
+	issue.AddTxOut(wire.NewTxOut(0, issueScript))
+	issue.AddTxOut(wire.NewTxOut(500000000, payTo))
+
+	return []*spec{
+		{
+			name:     "prova-account-asp",
+			comment:  "Prova output signed by the account key and an ASP key",
+			keys:     provaKeys,
+			pkScript: spent,
+			amount:   100000000,
+			tx:       payment,
+			signers:  []int{0, 1},
+		},
+		{
+			name:     "prova-asp-asp",
+			comment:  "Prova output signed by both ASP keys",
+			keys:     provaKeys,
+			pkScript: spent,
+			amount:   100000000,
+			tx:       payment,
+			signers:  []int{1, 2},
+		},
+		{
+			name:     "prova-second-input",
+			comment:  "Second input of a transaction with change and a lock time, signed by the account key and the second ASP key",
+			keys:     provaKeys,
+			pkScript: spent,
+			amount:   450000000,
+			tx:       change,
+			idx:      1,
+			signers:  []int{0, 2},
+		},
+		{
+			name:     "provision-thread",
+			comment:  "Provision thread tip spent by two of three provision keys to add an ASP key",
+			keys:     provisionKeys,
+			thread:   provautil.ProvisionThread,
+			isThread: true,
+			pkScript: provisionScript,
+			tx:       provision,
+			signers:  []int{0, 2},
+		},
+		{
+			name:     "issue-thread",
+			comment:  "Issue thread tip spent by both issue keys to issue to a Prova address",
+			keys:     issueKeys,
+			thread:   provautil.IssueThread,
+			isThread: true,
+			pkScript: issueScript,
+			tx:       issue,
+			signers:  []int{0, 1},
+		},
+	}, nil
+}
+
+// Generate returns the test vectors, signed with the parameters of the
+// regression test network.
+func Generate() (*Vectors, error) {
+	params := &chaincfg.RegressionNetParams
+	specs, err := specs(params)
+	if err != nil {
+		return nil, err
+	}
+	vectors := &Vectors{Version: Version, Network: params.Name}
+	for _, s := range specs {
+		v, err := s.sign(params)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", s.name, err)
+		}
+		vectors.Vectors = append(vectors.Vectors, v)
+	}
+	return vectors, nil
+}
+
+// Verify checks the passed vector against the signing and script execution
+// code of the txscript package: the signature hash, signatures and signature
+// script must be reproduced from the keys and the unsigned transaction, and
+// the signed transaction must execute the executed script successfully.
+func Verify(v *Vector, params *chaincfg.Params) error {
+	serializedTx, err := hex.DecodeString(v.UnsignedTx)
+	if err != nil {
+		return err
+	}
+	var tx wire.MsgTx
+	if err := tx.Deserialize(bytes.NewReader(serializedTx)); err != nil {
+		return err
+	}
+	pkScript, err := hex.DecodeString(v.PkScript)
+	if err != nil {
+		return err
+	}
+	hashType := txscript.SigHashType(v.HashType)
+
+	keys := make([]*testKey, 0, len(v.Keys))
+	for _, key := range v.Keys {
+		privKeyBytes, err := hex.DecodeString(key.PrivKey)
+		if err != nil {
+			return err
+		}
+		privKey, pubKey := btcec.PrivKeyFromBytes(btcec.S256(),
+			privKeyBytes)
+		if hex.EncodeToString(pubKey.SerializeCompressed()) != key.PubKey {
+			return fmt.Errorf("public key %s does not match its "+
+				"private key", key.PubKey)
+		}
+		keys = append(keys, &testKey{role: key.Role,
+			keyID: btcec.KeyID(key.KeyID), privKey: privKey})
+	}
+	var thread provautil.ThreadID
+	isThread := v.Thread != ""
+	if isThread {
+		found := false
+		for threadID, role := range threadRoles {
+			if role == v.Thread {
+				thread, found = threadID, true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown thread %s", v.Thread)
+		}
+	}
+	script, err := execScript(params, pkScript, keys, thread, isThread)
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(script) != v.ExecScript {
+		return errors.New("executed script mismatch")
+	}
+
+	preimage, err := txscript.CalcSignatureHashPreimage(&tx, v.Index,
+		v.Amount, pkScript, hashType)
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(preimage) != v.Preimage {
+		return errors.New("signature hash preimage mismatch")
+	}
+	sigHash := chainhash.DoubleHashB(preimage)
+	if hex.EncodeToString(sigHash) != v.SigHash {
+		return errors.New("signature hash mismatch")
+	}
+
+	if len(v.Signers) != len(v.Signatures) {
+		return errors.New("number of signers and signatures differ")
+	}
+	builder := txscript.NewScriptBuilder()
+	for i, signer := range v.Signers {
+		if signer < 0 || signer >= len(keys) {
+			return fmt.Errorf("signer %d out of range", signer)
+		}
+		privKey := keys[signer].privKey
+		sig, err := txscript.RawTxInSignatureNew(&tx, v.Index,
+			txscript.NewTxSigHashes(&tx), v.Amount, pkScript,
+			hashType, privKey)
+		if err != nil {
+			return err
+		}
+		if hex.EncodeToString(sig) != v.Signatures[i] {
+			return fmt.Errorf("signature %d mismatch", i)
+		}
+		builder.AddData(privKey.PubKey().SerializeCompressed())
+		builder.AddData(sig)
+	}
+	sigScript, err := builder.Script()
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(sigScript) != v.ScriptSig {
+		return errors.New("signature script mismatch")
+	}
+
+	signedTx := tx.Copy()
+	signedTx.TxIn[v.Index].SignatureScript = sigScript
+	serialized, err := serializeTx(signedTx)
+	if err != nil {
+		return err
+	}
+	if serialized != v.SignedTx {
+		return errors.New("signed transaction mismatch")
+	}
+	vm, err := txscript.NewEngine(script, signedTx, v.Index,
+		txscript.StandardVerifyFlags, nil, nil, v.Amount)
+	if err != nil {
+		return err
+	}
+	return vm.Execute()
+}
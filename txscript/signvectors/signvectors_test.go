@@ -0,0 +1,77 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package signvectors
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/chaincfg"
+)
+
+// TestPublishedVectors ensures the published vectors are the generated ones,
+// so they are regenerated whenever the signing code changes them.
+func TestPublishedVectors(t *testing.T) {
+	published, err := ioutil.ReadFile(FileName)
+	if err != nil {
+		t.Fatalf("unable to read published vectors: %v", err)
+	}
+	vectors, err := Generate()
+	if err != nil {
+		t.Fatalf("unable to generate vectors: %v", err)
+	}
+	generated, err := vectors.Encode()
+	if err != nil {
+		t.Fatalf("unable to encode vectors: %v", err)
+	}
+	if !bytes.Equal(published, generated) {
+		t.Fatalf("%s differs from the generated vectors; regenerate it "+
+			"with gensignvectors", FileName)
+	}
+}
+
+// TestVerifyVectors ensures the published vectors verify, and that a vector
+// with a changed signature or amount does not.
+func TestVerifyVectors(t *testing.T) {
+	published, err := ioutil.ReadFile(FileName)
+	if err != nil {
+		t.Fatalf("unable to read published vectors: %v", err)
+	}
+	var vectors Vectors
+	if err := json.Unmarshal(published, &vectors); err != nil {
+		t.Fatalf("unable to decode published vectors: %v", err)
+	}
+	if vectors.Version != Version {
+		t.Fatalf("version: got %d, want %d", vectors.Version, Version)
+	}
+	params := &chaincfg.RegressionNetParams
+	if vectors.Network != params.Name {
+		t.Fatalf("network: got %s, want %s", vectors.Network,
+			params.Name)
+	}
+
+	for _, v := range vectors.Vectors {
+		if err := Verify(v, params); err != nil {
+			t.Errorf("%s: %v", v.Name, err)
+			continue
+		}
+
+		changed := *v
+		changed.Amount++
+		if err := Verify(&changed, params); err == nil {
+			t.Errorf("%s: verified with a changed amount", v.Name)
+		}
+
+		changed = *v
+		changed.Signers = append([]int(nil), v.Signers...)
+		changed.Signers[0], changed.Signers[1] = v.Signers[1],
+			v.Signers[0]
+		if err := Verify(&changed, params); err == nil {
+			t.Errorf("%s: verified with swapped signers", v.Name)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/pyx-partners/dmgd/btcec"
 	"github.com/pyx-partners/dmgd/chaincfg"
 	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript/adminop"
 	"github.com/pyx-partners/dmgd/wire"
 )
 
@@ -20,6 +21,14 @@ const (
 	// data to be considered a nulldata transaction
 	MaxDataCarrierSize = 80
 
+	// CommitmentSize is the number of bytes pushed by a metadata commitment
+	// script.
+	CommitmentSize = 32
+
+	// HTLCSecretSize is the number of bytes of the secret unlocking the
+	// redeem branch of a Prova HTLC script, as well as of its hash.
+	HTLCSecretSize = 32
+
 	// StandardVerifyFlags are the script flags which are used when
 	// executing transaction scripts to enforce additional checks which
 	// are required for the script to be considered standard.  These checks
@@ -48,26 +57,34 @@ type ScriptClass byte
 
 // Classes of script payment known about in the blockchain.
 const (
-	NonStandardTy  ScriptClass = iota // None of the recognized forms.
-	PubKeyTy                          // Pay pubkey.
-	PubKeyHashTy                      // Pay pubkey hash.
-	ScriptHashTy                      // Pay to script hash.
-	MultiSigTy                        // Multi signature.
-	NullDataTy                        // Empty data-only (provably prunable).
-	ProvaTy                           // Prova standard 2-of-3 type (subset of GeneralProvaTy)
-	GeneralProvaTy                    // Prova (generalized m-of-n) script
-	ProvaAdminTy                      // Prova Admin Operations
+	NonStandardTy   ScriptClass = iota // None of the recognized forms.
+	PubKeyTy                           // Pay pubkey.
+	PubKeyHashTy                       // Pay pubkey hash.
+	ScriptHashTy                       // Pay to script hash.
+	MultiSigTy                         // Multi signature.
+	NullDataTy                         // Empty data-only (provably prunable).
+	ProvaTy                            // Prova standard 2-of-3 type (subset of GeneralProvaTy)
+	GeneralProvaTy                     // Prova (generalized m-of-n) script
+	ProvaAdminTy                       // Prova Admin Operations
+	CommitmentTy                       // Metadata commitment (subset of NullDataTy)
+	ProvaHTLCTy                        // Prova hash and time locked contract
+	ProvaTimeLockTy                    // Prova script behind a lock time
+	ProvaKeyIDTy                       // Prova script co-signed by the key of a keyID
 )
 
 // scriptClassToName houses the human-readable strings which describe each
 // script class.
 var scriptClassToName = []string{
 	// TODO(prova): clean up non-used types
-	NonStandardTy:  "nonstandard",
-	NullDataTy:     "nulldata",
-	ProvaTy:        "safe_multisig",
-	GeneralProvaTy: "safe_multisig",
-	ProvaAdminTy:   "admin",
+	NonStandardTy:   "nonstandard",
+	NullDataTy:      "nulldata",
+	ProvaTy:         "safe_multisig",
+	GeneralProvaTy:  "safe_multisig",
+	ProvaAdminTy:    "admin",
+	CommitmentTy:    "commitment",
+	ProvaHTLCTy:     "htlc",
+	ProvaTimeLockTy: "timelock",
+	ProvaKeyIDTy:    "keyid",
 }
 
 // String implements the Stringer interface by returning the name of
@@ -179,9 +196,171 @@ func isProva(pops []parsedOpcode) bool {
 	return m == n-1
 }
 
+// provaHTLCBranches returns the Prova scripts of the redeem and refund
+// branches of the passed script and true if it is a Prova HTLC script, false
+// otherwise.  The returned scripts share the opcodes of the passed script.
+//
+//	OP_IF
+//	  OP_SIZE 32 OP_EQUALVERIFY OP_SHA256 <secret hash> OP_EQUALVERIFY
+//	  <redeem Prova script>
+//	OP_ELSE
+//	  <locktime> OP_CHECKLOCKTIMEVERIFY OP_DROP
+//	  <refund Prova script>
+//	OP_ENDIF
+func provaHTLCBranches(pops []parsedOpcode) ([]parsedOpcode, []parsedOpcode, bool) {
+	// The absolute minimum is two Prova scripts of 6 opcodes each, with
+	// 7 opcodes before the redeem script, 4 before the refund script
+	// and the closing OP_ENDIF.
+	sLen := len(pops)
+	if sLen < 7+6+4+6+1 {
+		return nil, nil, false
+	}
+	if pops[0].opcode.value != OP_IF ||
+		pops[1].opcode.value != OP_SIZE ||
+		pops[2].opcode.value != OP_DATA_1 ||
+		pops[2].data[0] != HTLCSecretSize ||
+		pops[3].opcode.value != OP_EQUALVERIFY ||
+		pops[4].opcode.value != OP_SHA256 ||
+		pops[5].opcode.value != OP_DATA_32 ||
+		pops[6].opcode.value != OP_EQUALVERIFY ||
+		pops[sLen-1].opcode.value != OP_ENDIF {
+		return nil, nil, false
+	}
+
+	// Prova scripts do not contain OP_ELSE, so the first one ends the
+	// redeem branch.
+	elseIdx := -1
+	for i := 7; i < sLen-1; i++ {
+		if pops[i].opcode.value == OP_ELSE {
+			elseIdx = i
+			break
+		}
+	}
+	if elseIdx < 0 || elseIdx+4 >= sLen-1 {
+		return nil, nil, false
+	}
+	if _, ok := scriptLockTime(pops[elseIdx+1]); !ok ||
+		pops[elseIdx+2].opcode.value != OP_CHECKLOCKTIMEVERIFY ||
+		pops[elseIdx+3].opcode.value != OP_DROP {
+		return nil, nil, false
+	}
+
+	redeem := pops[7:elseIdx:elseIdx]
+	refund := pops[elseIdx+4 : sLen-1 : sLen-1]
+	if !isGeneralProva(redeem) || !isGeneralProva(refund) {
+		return nil, nil, false
+	}
+	return redeem, refund, true
+}
+
+// scriptLockTime returns the lock time or sequence pushed by the passed opcode
+// and true, or false when it does not push a valid lock time.
+func scriptLockTime(pop parsedOpcode) (uint32, bool) {
+	if isSmallInt(pop.opcode) {
+		return uint32(asSmallInt(pop.opcode)), true
+	}
+	if pop.opcode.value > OP_PUSHDATA4 {
+		return 0, false
+	}
+	lockTime, err := makeScriptNum(pop.data, true, 5)
+	if err != nil || lockTime < 0 || lockTime > 0xffffffff {
+		return 0, false
+	}
+	return uint32(lockTime), true
+}
+
+// isProvaHTLC returns true if the passed script is a Prova HTLC script.
+func isProvaHTLC(pops []parsedOpcode) bool {
+	_, _, ok := provaHTLCBranches(pops)
+	return ok
+}
+
+// provaTimeLockScript returns the Prova script of the passed script and true
+// if it is a Prova time lock script, false otherwise.  The returned script
+// shares the opcodes of the passed script.
+// <lock time> OP_CHECKLOCKTIMEVERIFY OP_DROP <Prova script>
+// <sequence> OP_CHECKSEQUENCEVERIFY OP_DROP <Prova script>
+func provaTimeLockScript(pops []parsedOpcode) ([]parsedOpcode, bool) {
+	if len(pops) < 3+6 {
+		return nil, false
+	}
+	if _, ok := scriptLockTime(pops[0]); !ok ||
+		(pops[1].opcode.value != OP_CHECKLOCKTIMEVERIFY &&
+			pops[1].opcode.value != OP_CHECKSEQUENCEVERIFY) ||
+		pops[2].opcode.value != OP_DROP {
+		return nil, false
+	}
+	script := pops[3:len(pops):len(pops)]
+	if !isGeneralProva(script) {
+		return nil, false
+	}
+	return script, true
+}
+
+// isProvaTimeLock returns true if the passed script is a Prova time lock
+// script.
+func isProvaTimeLock(pops []parsedOpcode) bool {
+	_, ok := provaTimeLockScript(pops)
+	return ok
+}
+
+// scriptKeyID returns the keyID pushed by the passed opcode and true, or false
+// if it does not push a keyID.  KeyIDs are pushed as in Prova scripts.
+func scriptKeyID(pop parsedOpcode) (btcec.KeyID, bool) {
+	if !isUint32(pop.opcode) {
+		return 0, false
+	}
+	keyID, err := asInt32(pop)
+	if err != nil || keyID < 0 {
+		return 0, false
+	}
+	return btcec.KeyID(keyID), true
+}
+
+// provaKeyIDScript returns the Prova script of the passed script and true if
+// it is a Prova keyID script, false otherwise.  The returned script shares the
+// opcodes of the passed script.
+// <keyID> OP_CHECKKEYIDVERIFY OP_2DROP <Prova script>
+func provaKeyIDScript(pops []parsedOpcode) ([]parsedOpcode, bool) {
+	if len(pops) < 3+6 {
+		return nil, false
+	}
+	if _, ok := scriptKeyID(pops[0]); !ok ||
+		pops[1].opcode.value != OP_CHECKKEYIDVERIFY ||
+		pops[2].opcode.value != OP_2DROP {
+		return nil, false
+	}
+	script := pops[3:len(pops):len(pops)]
+	if !isGeneralProva(script) {
+		return nil, false
+	}
+	return script, true
+}
+
+// isProvaKeyID returns true if the passed script is a Prova keyID script.
+func isProvaKeyID(pops []parsedOpcode) bool {
+	_, ok := provaKeyIDScript(pops)
+	return ok
+}
+
+// provaBranches returns the Prova scripts nested in the passed Prova HTLC,
+// time lock or keyID script, or nil for all other scripts.
+func provaBranches(pops []parsedOpcode) [][]parsedOpcode {
+	if redeem, refund, ok := provaHTLCBranches(pops); ok {
+		return [][]parsedOpcode{redeem, refund}
+	}
+	if script, ok := provaTimeLockScript(pops); ok {
+		return [][]parsedOpcode{script}
+	}
+	if script, ok := provaKeyIDScript(pops); ok {
+		return [][]parsedOpcode{script}
+	}
+	return nil
+}
+
 // IsProvaTx determines if a transaction is a standard prova transaction
-// consisting of only outputs to standard prova scripts and 0-value nulldata
-// scripts.
+// consisting of only outputs to standard prova scripts, Prova HTLC and time
+// lock scripts and 0-value nulldata scripts.
 func IsProvaTx(tx *provautil.Tx) bool {
 	msgTx := tx.MsgTx()
 
@@ -206,7 +385,7 @@ func IsProvaTx(tx *provautil.Tx) bool {
 			if atoms != 0 {
 				return false
 			}
-		} else if !isGeneralProva(pops) {
+		} else if !isGeneralProva(pops) && provaBranches(pops) == nil {
 			return false
 		}
 	}
@@ -263,51 +442,40 @@ func isProvaAdmin(pops []parsedOpcode) bool {
 	return true
 }
 
+// AdminOpActive returns whether the passed admin op may be carried by the
+// admin transactions of the block at the passed height.  The ops introduced
+// after the launch of a network are only valid from their activation height.
+func AdminOpActive(op adminop.Op, height uint32,
+	chainParams *chaincfg.Params) bool {
+
+	switch op {
+	case adminop.AccountFreeze, adminop.AccountUnfreeze:
+		return chainParams.FreezeActive(height)
+	case adminop.IssueDestAdd, adminop.IssueDestRevoke:
+		return chainParams.IssueDestsActive(height)
+	case adminop.ValidateKeyAddExp, adminop.ASPKeyAddExp:
+		return chainParams.KeyExpiryActive(height)
+	case adminop.DelegateKeyAdd, adminop.DelegateKeyRevoke:
+		return chainParams.DelegatesActive(height)
+	}
+	return true
+}
+
 // IsValidAdminOp returns true if the passed script is a valid admin
-// operation at the given thread.
-func IsValidAdminOp(pops []parsedOpcode, threadID provautil.ThreadID) bool {
+// operation at the given thread in the block at the passed height.
+func IsValidAdminOp(pops []parsedOpcode, threadID provautil.ThreadID,
+	height uint32, chainParams *chaincfg.Params) bool {
+
 	// always expect two ops
 	// <OP_RETURN><OP_DATA>
-	if len(pops) != 2 {
-		return false
-	}
-	if pops[0].opcode.value != OP_RETURN {
-		return false
-	}
-	if pops[1].opcode.value != OP_DATA_34 &&
-		pops[1].opcode.value != OP_DATA_38 {
-		return false
-	}
-	// read op type byte and check valid key
-	op, _, err := ExtractAdminData(pops)
+	// The payload must match the schema of its op, which also lists the
+	// threads the op is valid on.
+	payload, err := ExtractAdminPayload(pops)
 	if err != nil {
 		return false
 	}
-	// check thread specific operations
-	switch threadID {
-	case provautil.RootThread:
-		if op == AdminOpProvisionKeyAdd ||
-			op == AdminOpProvisionKeyRevoke ||
-			op == AdminOpIssueKeyAdd ||
-			op == AdminOpIssueKeyRevoke {
-			return true
-		}
-	case provautil.ProvisionThread:
-		if op == AdminOpValidateKeyAdd ||
-			op == AdminOpValidateKeyRevoke {
-			return true
-		}
-		if op == AdminOpASPKeyAdd ||
-			op == AdminOpASPKeyRevoke {
-			// check length of data for ASP ops
-			if len(pops[1].data) == 1+btcec.PubKeyBytesLenCompressed+btcec.KeyIDSize {
-				return true
-			}
-		}
-	case provautil.IssueThread:
-		return false
-	}
-	return false
+	return payload.Schema().ValidOn(threadID) &&
+		AdminOpActive(payload.Op, height, chainParams)
 }
 
 // isNullData returns true if the passed script is a null data transaction,
@@ -328,6 +496,16 @@ func isNullData(pops []parsedOpcode) bool {
 		len(pops[1].data) <= MaxDataCarrierSize
 }
 
+// isCommitment returns true if the passed script is a metadata commitment,
+// false otherwise.  A commitment is a nulldata script pushing exactly
+// CommitmentSize bytes, which commit to an external reference such as the hash
+// of a bar serial number.
+func isCommitment(pops []parsedOpcode) bool {
+	return len(pops) == 2 &&
+		pops[0].opcode.value == OP_RETURN &&
+		pops[1].opcode.value == OP_DATA_32
+}
+
 // TypeOfScript returns the type of the script being inspected from the known
 // standard types.
 func TypeOfScript(pops []parsedOpcode) ScriptClass {
@@ -337,12 +515,20 @@ func TypeOfScript(pops []parsedOpcode) ScriptClass {
 // typeOfScript returns the type of the script being inspected from the known
 // standard types.
 func typeOfScript(pops []parsedOpcode) ScriptClass {
-	if isNullData(pops) {
+	if isCommitment(pops) {
+		return CommitmentTy
+	} else if isNullData(pops) {
 		return NullDataTy
 	} else if isProva(pops) {
 		return ProvaTy
 	} else if isGeneralProva(pops) {
 		return GeneralProvaTy
+	} else if isProvaHTLC(pops) {
+		return ProvaHTLCTy
+	} else if isProvaTimeLock(pops) {
+		return ProvaTimeLockTy
+	} else if isProvaKeyID(pops) {
+		return ProvaKeyIDTy
 	} else if isProvaAdmin(pops) {
 		return ProvaAdminTy
 	}
@@ -456,6 +642,340 @@ func NullDataScript(data []byte) ([]byte, error) {
 	return NewScriptBuilder().AddOp(OP_RETURN).AddData(data).Script()
 }
 
+// CommitmentScript creates a metadata commitment script containing OP_RETURN
+// followed by the passed commitment.  An Error with the error code
+// ErrInvalidCommitmentSize will be returned if the commitment is not exactly
+// CommitmentSize bytes.
+func CommitmentScript(commitment []byte) ([]byte, error) {
+	if len(commitment) != CommitmentSize {
+		str := fmt.Sprintf("commitment size %d is not the required "+
+			"size %d", len(commitment), CommitmentSize)
+		return nil, scriptError(ErrInvalidCommitmentSize, str)
+	}
+
+	return NewScriptBuilder().AddOp(OP_RETURN).AddData(commitment).Script()
+}
+
+// ExtractCommitment returns the commitment of the passed metadata commitment
+// script and true, or false when the script is not a commitment script.
+func ExtractCommitment(pkScript []byte) ([CommitmentSize]byte, bool) {
+	var commitment [CommitmentSize]byte
+	pops, err := ParseScript(pkScript)
+	if err != nil || !isCommitment(pops) {
+		return commitment, false
+	}
+	copy(commitment[:], pops[1].data)
+	return commitment, true
+}
+
+// AssetIDScript creates an asset marker script containing OP_RETURN followed
+// by the passed asset ID.  Transactions carrying the marker move the asset
+// instead of DMG, which is asset 0.
+func AssetIDScript(assetID uint32) ([]byte, error) {
+	return AdminOpScript(&adminop.Payload{
+		Op:      adminop.AssetID,
+		AssetID: assetID,
+	})
+}
+
+// ExtractAssetID returns the asset ID of the passed asset marker script and
+// true, or false when the script is not an asset marker.
+func ExtractAssetID(pkScript []byte) (uint32, bool) {
+	pops, err := ParseScript(pkScript)
+	if err != nil || !IsAssetIDOp(pops) {
+		return 0, false
+	}
+	return ExtractAssetIDData(pops), true
+}
+
+// AdminOpScript creates an admin op script containing OP_RETURN followed by
+// the encoded payload of the passed admin op.
+// <OP_RETURN><OP_DATA payload>
+func AdminOpScript(payload *adminop.Payload) ([]byte, error) {
+	data, err := adminop.Encode(payload)
+	if err != nil {
+		return nil, err
+	}
+	return NewScriptBuilder().AddOp(OP_RETURN).AddData(data).Script()
+}
+
+// TxAssetID returns the asset the outputs of the passed transaction belong
+// to.  This is the asset ID of its first asset marker output, or 0 (DMG) when
+// it has none.  Asset markers are only recognised by the chain from their
+// activation height, so the chain uses blockchain.TxAssetID instead.
+func TxAssetID(msgTx *wire.MsgTx) uint32 {
+	for _, txOut := range msgTx.TxOut {
+		if assetID, ok := ExtractAssetID(txOut.PkScript); ok {
+			return assetID
+		}
+	}
+	return 0
+}
+
+// PayToProvaHTLCScript creates a new script to pay a transaction output to a
+// Prova hash and time locked contract.  The output can be spent by the redeem
+// address revealing the secret hashing to secretHash with SHA256, or by the
+// refund address once lockTime has passed.  An Error with the error code
+// ErrInvalidHTLCSecretSize will be returned if the secret hash is not exactly
+// HTLCSecretSize bytes.
+func PayToProvaHTLCScript(secretHash []byte, lockTime uint32, redeemAddr, refundAddr provautil.Address) ([]byte, error) {
+	if len(secretHash) != HTLCSecretSize {
+		str := fmt.Sprintf("secret hash size %d is not the required "+
+			"size %d", len(secretHash), HTLCSecretSize)
+		return nil, scriptError(ErrInvalidHTLCSecretSize, str)
+	}
+	redeemScript, err := PayToAddrScript(redeemAddr)
+	if err != nil {
+		return nil, err
+	}
+	refundScript, err := PayToAddrScript(refundAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewScriptBuilder().
+		AddOp(OP_IF).
+		AddOp(OP_SIZE).AddInt64(HTLCSecretSize).AddOp(OP_EQUALVERIFY).
+		AddOp(OP_SHA256).AddData(secretHash).AddOp(OP_EQUALVERIFY).
+		AddOps(redeemScript).
+		AddOp(OP_ELSE).
+		AddInt64(int64(lockTime)).AddOp(OP_CHECKLOCKTIMEVERIFY).
+		AddOp(OP_DROP).
+		AddOps(refundScript).
+		AddOp(OP_ENDIF).
+		Script()
+}
+
+// ProvaHTLC describes the terms of a Prova hash and time locked contract.
+type ProvaHTLC struct {
+	// SecretHash is the SHA256 hash of the secret unlocking the redeem
+	// branch.
+	SecretHash [HTLCSecretSize]byte
+
+	// LockTime is the lock time after which the refund branch can be
+	// spent.
+	LockTime uint32
+
+	// RedeemScript and RefundScript are the Prova scripts of the redeem
+	// and refund branches.  Signature scripts for them are wrapped with
+	// ProvaHTLCRedeemSigScript and ProvaHTLCRefundSigScript to spend the
+	// contract.
+	RedeemScript []byte
+	RefundScript []byte
+}
+
+// ExtractProvaHTLC returns the terms of the passed Prova HTLC script.  An
+// Error with the error code ErrNotProvaHTLC will be returned if the script is
+// not a Prova HTLC script.
+func ExtractProvaHTLC(pkScript []byte) (*ProvaHTLC, error) {
+	pops, err := ParseScript(pkScript)
+	if err != nil {
+		return nil, err
+	}
+	redeem, refund, ok := provaHTLCBranches(pops)
+	if !ok {
+		str := fmt.Sprintf("script %x is not a Prova HTLC script",
+			pkScript)
+		return nil, scriptError(ErrNotProvaHTLC, str)
+	}
+
+	var htlc ProvaHTLC
+	copy(htlc.SecretHash[:], pops[5].data)
+	htlc.LockTime, _ = scriptLockTime(pops[len(redeem)+8])
+	htlc.RedeemScript, err = UnparseScript(redeem)
+	if err != nil {
+		return nil, err
+	}
+	htlc.RefundScript, err = UnparseScript(refund)
+	if err != nil {
+		return nil, err
+	}
+	return &htlc, nil
+}
+
+// ProvaHTLCRedeemSigScript returns the signature script spending a Prova HTLC
+// output through its redeem branch, given the signature script satisfying the
+// Prova script of the branch and the secret.  An Error with the error code
+// ErrInvalidHTLCSecretSize will be returned if the secret is not exactly
+// HTLCSecretSize bytes.
+func ProvaHTLCRedeemSigScript(sigScript, secret []byte) ([]byte, error) {
+	if len(secret) != HTLCSecretSize {
+		str := fmt.Sprintf("secret size %d is not the required size %d",
+			len(secret), HTLCSecretSize)
+		return nil, scriptError(ErrInvalidHTLCSecretSize, str)
+	}
+
+	return NewScriptBuilder().AddOps(sigScript).AddData(secret).
+		AddOp(OP_TRUE).Script()
+}
+
+// ProvaHTLCRefundSigScript returns the signature script spending a Prova HTLC
+// output through its refund branch, given the signature script satisfying the
+// Prova script of the branch.  The spending transaction must have a lock time
+// of at least the lock time of the contract, and a non-final input sequence.
+func ProvaHTLCRefundSigScript(sigScript []byte) ([]byte, error) {
+	return NewScriptBuilder().AddOps(sigScript).AddOp(OP_FALSE).Script()
+}
+
+// PayToProvaLockTimeScript creates a script paying to the passed Prova address
+// which can not be spent before the passed absolute lock time, interpreted as
+// a block height below LockTimeThreshold and as a unix time otherwise.
+func PayToProvaLockTimeScript(lockTime uint32, addr provautil.Address) ([]byte, error) {
+	script, err := PayToAddrScript(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewScriptBuilder().
+		AddInt64(int64(lockTime)).AddOp(OP_CHECKLOCKTIMEVERIFY).
+		AddOp(OP_DROP).
+		AddOps(script).
+		Script()
+}
+
+// PayToProvaSequenceLockScript creates a script paying to the passed Prova
+// address which can not be spent before the relative lock time encoded by
+// the passed sequence has passed since the output was mined.  Sequences are
+// built from a number of blocks or seconds with blockchain.LockTimeToSequence.
+// An Error with the error code ErrInvalidTimeLock will be returned if the
+// sequence has the lock time disabled flag set.
+//
+// NOTE: Relative lock times are currently only enforced by the standardness
+// rules of the memory pool.
+func PayToProvaSequenceLockScript(sequence uint32, addr provautil.Address) ([]byte, error) {
+	if sequence&wire.SequenceLockTimeDisabled != 0 {
+		str := fmt.Sprintf("sequence 0x%x has the lock time disabled "+
+			"flag set", sequence)
+		return nil, scriptError(ErrInvalidTimeLock, str)
+	}
+	script, err := PayToAddrScript(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewScriptBuilder().
+		AddInt64(int64(sequence)).AddOp(OP_CHECKSEQUENCEVERIFY).
+		AddOp(OP_DROP).
+		AddOps(script).
+		Script()
+}
+
+// ProvaTimeLock describes the terms of a Prova time lock script.
+type ProvaTimeLock struct {
+	// LockTime is the absolute lock time, or the sequence encoding the
+	// relative lock time, after which the output can be spent.
+	LockTime uint32
+
+	// Relative is true if LockTime is a sequence checked with
+	// OP_CHECKSEQUENCEVERIFY, and false if it is an absolute lock time
+	// checked with OP_CHECKLOCKTIMEVERIFY.
+	Relative bool
+
+	// Script is the Prova script the output pays to.  Signature scripts
+	// satisfying it also spend the time locked output.
+	Script []byte
+}
+
+// ExtractProvaTimeLock returns the terms of the passed Prova time lock
+// script.  An Error with the error code ErrNotProvaTimeLock will be returned
+// if the script is not a Prova time lock script.
+func ExtractProvaTimeLock(pkScript []byte) (*ProvaTimeLock, error) {
+	pops, err := ParseScript(pkScript)
+	if err != nil {
+		return nil, err
+	}
+	script, ok := provaTimeLockScript(pops)
+	if !ok {
+		str := fmt.Sprintf("script %x is not a Prova time lock script",
+			pkScript)
+		return nil, scriptError(ErrNotProvaTimeLock, str)
+	}
+
+	var timeLock ProvaTimeLock
+	timeLock.LockTime, _ = scriptLockTime(pops[0])
+	timeLock.Relative = pops[1].opcode.value == OP_CHECKSEQUENCEVERIFY
+	timeLock.Script, err = UnparseScript(script)
+	if err != nil {
+		return nil, err
+	}
+	return &timeLock, nil
+}
+
+// PrepareProvaTimeLockSpend sets the lock time, version and input sequence of
+// the passed transaction so input idx can spend an output paying to the passed
+// Prova time lock script, and must be called before the input is signed.  An
+// absolute lock time raises the lock time of the transaction and makes the
+// input non-final, while a relative lock time sets the sequence of the input.
+// An Error with the error code ErrInvalidTimeLock will be returned if the lock
+// time of the transaction is of a different kind than the one of the script,
+// and one with the error code ErrNotProvaTimeLock if the script is not a
+// Prova time lock script.
+func PrepareProvaTimeLockSpend(tx *wire.MsgTx, idx int, pkScript []byte) error {
+	if idx < 0 || idx >= len(tx.TxIn) {
+		str := fmt.Sprintf("transaction input index %d is negative or "+
+			">= %d", idx, len(tx.TxIn))
+		return scriptError(ErrInvalidIndex, str)
+	}
+	timeLock, err := ExtractProvaTimeLock(pkScript)
+	if err != nil {
+		return err
+	}
+
+	txIn := tx.TxIn[idx]
+	if timeLock.Relative {
+		if tx.Version < 2 {
+			tx.Version = 2
+		}
+		txIn.Sequence = timeLock.LockTime
+		return nil
+	}
+
+	// Lock times below the threshold are block heights and the others are
+	// unix times, and the two can not be compared.
+	if tx.LockTime != 0 && (tx.LockTime < LockTimeThreshold) !=
+		(timeLock.LockTime < LockTimeThreshold) {
+
+		str := fmt.Sprintf("transaction lock time %d and script lock "+
+			"time %d are of different kinds", tx.LockTime,
+			timeLock.LockTime)
+		return scriptError(ErrInvalidTimeLock, str)
+	}
+	if tx.LockTime < timeLock.LockTime {
+		tx.LockTime = timeLock.LockTime
+	}
+	if txIn.Sequence == wire.MaxTxInSequenceNum {
+		txIn.Sequence = wire.MaxTxInSequenceNum - 1
+	}
+	return nil
+}
+
+// PayToProvaKeyIDScript creates a script paying to the passed Prova address
+// which must also be signed by the ASP key provisioned for the passed keyID
+// when it is spent, whichever key that is at the time.  The signature of the
+// key of the keyID is checked with OP_CHECKKEYIDVERIFY, so the script is only
+// standard once the opcode is active; before, it is spent like the Prova
+// script alone.
+func PayToProvaKeyIDScript(keyID btcec.KeyID, addr provautil.Address) ([]byte, error) {
+	script, err := PayToAddrScript(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewScriptBuilder().
+		AddInt64(int64(keyID)).AddOp(OP_CHECKKEYIDVERIFY).
+		AddOp(OP_2DROP).
+		AddOps(script).
+		Script()
+}
+
+// ProvaKeyIDSigScript returns the signature script spending a Prova keyID
+// output, given the signature script satisfying its Prova script and the
+// signature of the key of its keyID, followed by the hash type.  The signature
+// is made like the signatures of the Prova script, with RawTxInSignatureNew.
+func ProvaKeyIDSigScript(sigScript, keyIDSig []byte) ([]byte, error) {
+	return NewScriptBuilder().AddOps(sigScript).AddData(keyIDSig).Script()
+}
+
 // MultiSigScript returns a valid script for a multisignature redemption where
 // nrequired of the keys in pubkeys are required to have signed the transaction
 // for success.  An ErrBadNumRequired will be returned if nrequired is larger
@@ -535,10 +1055,49 @@ func ExtractPkScriptAddrs(pkScript []byte, chainParams *chaincfg.Params) (Script
 	case GeneralProvaTy:
 		// TODO(prova): define what to do for generalized prova scripts
 
+	case ProvaHTLCTy, ProvaTimeLockTy:
+		// The addresses of the nested Prova scripts, when they are
+		// standard Prova scripts.
+		for _, branch := range provaBranches(pops) {
+			script, err := UnparseScript(branch)
+			if err != nil {
+				return scriptClass, nil, 0, err
+			}
+			_, branchAddrs, branchSigs, err :=
+				ExtractPkScriptAddrs(script, chainParams)
+			if err != nil {
+				return scriptClass, nil, 0, err
+			}
+			addrs = append(addrs, branchAddrs...)
+			if branchSigs > requiredSigs {
+				requiredSigs = branchSigs
+			}
+		}
+
+	case ProvaKeyIDTy:
+		// The addresses of the nested Prova script, when it is a
+		// standard Prova script, which is signed along with the key of
+		// the keyID.
+		script, err := UnparseScript(provaBranches(pops)[0])
+		if err != nil {
+			return scriptClass, nil, 0, err
+		}
+		_, addrs, requiredSigs, err = ExtractPkScriptAddrs(script,
+			chainParams)
+		if err != nil {
+			return scriptClass, nil, 0, err
+		}
+		requiredSigs++
+
 	case ProvaAdminTy:
-		requiredSigs = 2
+		threadID, err := ExtractThreadID(pops)
+		if err != nil {
+			return scriptClass, nil, 0, err
+		}
+		requiredSigs = chainParams.AdminThreshold(
+			btcec.KeySetType(threadID))
 
-	case NullDataTy:
+	case NullDataTy, CommitmentTy:
 		// Null data transactions have no addresses or required
 		// signatures.
 
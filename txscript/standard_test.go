@@ -12,6 +12,7 @@ import (
 	"github.com/pyx-partners/dmgd/btcec"
 	"github.com/pyx-partners/dmgd/chaincfg"
 	"github.com/pyx-partners/dmgd/provautil"
+	"github.com/pyx-partners/dmgd/txscript/adminop"
 	"github.com/pyx-partners/dmgd/wire"
 	"reflect"
 	"testing"
@@ -35,7 +36,7 @@ func decodeHex(hexStr string) []byte {
 // tests as a helper since the only way it can fail is if there is an error in
 // the test source code.
 func mustParseShortForm(script string) []byte {
-	s, err := parseShortForm(script)
+	s, err := Assemble(script)
 	if err != nil {
 		panic("invalid short form script in test source: err " +
 			err.Error() + ", script: " + script)
@@ -122,6 +123,71 @@ func TestExtractPkScriptAddrs(t *testing.T) {
 	}
 }
 
+// TestAdminThreshold ensures admin threads require the signatures of the
+// admin thresholds of the chain parameters.
+func TestAdminThreshold(t *testing.T) {
+	t.Parallel()
+
+	params := chaincfg.Params{
+		AdminThresholds: map[btcec.KeySetType]int{
+			btcec.ProvisionKeySet: 2,
+			btcec.IssueKeySet:     3,
+		},
+	}
+	keyHashes := [][]byte{
+		bytes.Repeat([]byte{1}, 20),
+		bytes.Repeat([]byte{2}, 20),
+		bytes.Repeat([]byte{3}, 20),
+	}
+	tests := []struct {
+		name    string
+		thread  provautil.ThreadID
+		reqSigs int
+	}{
+		{"root thread", provautil.RootThread, chaincfg.DefaultAdminThreshold},
+		{"provision thread", provautil.ProvisionThread, 2},
+		{"issue thread", provautil.IssueThread, 3},
+	}
+	for _, test := range tests {
+		script, err := NewScriptBuilder().AddInt64(int64(test.thread)).
+			AddOp(OP_CHECKTHREAD).Script()
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		class, _, reqSigs, err := ExtractPkScriptAddrs(script, &params)
+		if err != nil || class != ProvaAdminTy || reqSigs != test.reqSigs {
+			t.Errorf("%s: got class %v, %d required signatures, "+
+				"error %v, want class %v, %d required signatures",
+				test.name, class, reqSigs, err, ProvaAdminTy,
+				test.reqSigs)
+		}
+
+		pkScript, err := ThreadPkScript(keyHashes, reqSigs)
+		if err != nil {
+			t.Errorf("%s: ThreadPkScript: %v", test.name, err)
+			continue
+		}
+		pops, err := ParseScript(pkScript)
+		if err != nil {
+			t.Errorf("%s: ParseScript: %v", test.name, err)
+			continue
+		}
+		if !isSmallInt(pops[0].opcode) ||
+			asSmallInt(pops[0].opcode) != test.reqSigs {
+			t.Errorf("%s: thread script %x does not require %d "+
+				"signatures", test.name, pkScript, test.reqSigs)
+		}
+	}
+
+	// A thread can not be spent with more signatures than keys, or none.
+	if _, err := ThreadPkScript(keyHashes[:2], 3); err == nil {
+		t.Errorf("ThreadPkScript: no error with threshold above keys")
+	}
+	if _, err := ThreadPkScript(keyHashes, 0); err == nil {
+		t.Errorf("ThreadPkScript: no error with zero threshold")
+	}
+}
+
 // TestIsValidAdminOp tests the IsValidAdminOp function.
 func TestIsValidAdminOp(t *testing.T) {
 	// Create some dummy admin op output.
@@ -140,6 +206,21 @@ func TestIsValidAdminOp(t *testing.T) {
 		Value:    0,
 		PkScript: adminOpPkScript,
 	}
+	// provision key add with a trailing keyID, which is ignored
+	legacyData := make([]byte, len(data)+btcec.KeyIDSize)
+	copy(legacyData, data)
+	legacyOpPkScript, _ := NewScriptBuilder().AddOp(OP_RETURN).AddData(legacyData).Script()
+	legacyOpTxOut := wire.TxOut{
+		Value:    0,
+		PkScript: legacyOpPkScript,
+	}
+	// provision key add not pushed with the smallest push opcode
+	pushData1PkScript := append([]byte{OP_RETURN, OP_PUSHDATA1,
+		byte(len(data))}, data...)
+	pushData1TxOut := wire.TxOut{
+		Value:    0,
+		PkScript: pushData1PkScript,
+	}
 	// asp add
 	aspData := make([]byte, 1+btcec.PubKeyBytesLenCompressed+btcec.KeyIDSize)
 	aspData[0] = AdminOpASPKeyAdd
@@ -150,6 +231,42 @@ func TestIsValidAdminOp(t *testing.T) {
 		Value:    0,
 		PkScript: provOpPkScript,
 	}
+	// issuance destination add
+	destData := make([]byte, 1+IssueDestLen)
+	destData[0] = AdminOpIssueDestAdd
+	copy(destData[1:], provautil.Hash160(pubKey.SerializeCompressed()))
+	btcec.KeyID(1).ToAddressFormat(destData[21:])
+	btcec.KeyID(2).ToAddressFormat(destData[25:])
+	destOpPkScript, _ := NewScriptBuilder().AddOp(OP_RETURN).AddData(destData).Script()
+	destOpTxOut := wire.TxOut{
+		Value:    0,
+		PkScript: destOpPkScript,
+	}
+	// asp key add with expiry
+	expOpPkScript, _ := AdminOpScript(&adminop.Payload{
+		Op: adminop.ASPKeyAddExp, PubKey: pubKey, KeyID: 1,
+		Expiry: 1000})
+	expOpTxOut := wire.TxOut{
+		Value:    0,
+		PkScript: expOpPkScript,
+	}
+	// delegate key add
+	delegateOpPkScript, _ := AdminOpScript(&adminop.Payload{
+		Op: adminop.DelegateKeyAdd, PubKey: pubKey,
+		Scope: adminop.ScopeASPKeyAdd, KeyID: 1, MaxKeyID: 9})
+	delegateOpTxOut := wire.TxOut{
+		Value:    0,
+		PkScript: delegateOpPkScript,
+	}
+	// account freeze
+	freezeData := make([]byte, 21)
+	freezeData[0] = AdminOpAccountFreeze
+	copy(freezeData[1:], provautil.Hash160(pubKey.SerializeCompressed()))
+	freezeOpPkScript, _ := NewScriptBuilder().AddOp(OP_RETURN).AddData(freezeData).Script()
+	freezeOpTxOut := wire.TxOut{
+		Value:    0,
+		PkScript: freezeOpPkScript,
+	}
 	// create root tx out
 	rootPkScript, _ := ProvaThreadScript(provautil.RootThread)
 	rootTxOut := wire.TxOut{
@@ -163,9 +280,19 @@ func TestIsValidAdminOp(t *testing.T) {
 		PkScript: provisionPkScript,
 	}
 
+	// The ops are checked at the activation heights of the ops introduced
+	// after launch, unless the test sets a height.
+	const activationHeight = 100
+	params := chaincfg.RegressionNetParams
+	params.FreezeHeight = activationHeight
+	params.IssueDestHeight = activationHeight
+	params.KeyExpiryHeight = activationHeight
+	params.DelegateHeight = activationHeight
+
 	tests := []struct {
 		name    string
 		tx      wire.MsgTx
+		height  uint32
 		isValid bool
 	}{
 		{
@@ -174,18 +301,94 @@ func TestIsValidAdminOp(t *testing.T) {
 				TxOut: []*wire.TxOut{&rootTxOut, &adminOpTxOut},
 			},
 			isValid: true,
+		}, {
+			name: "Admin transaction with ignored keyID",
+			tx: wire.MsgTx{
+				TxOut: []*wire.TxOut{&rootTxOut, &legacyOpTxOut},
+			},
+			isValid: true,
+		}, {
+			name: "Admin op pushed with OP_PUSHDATA1",
+			tx: wire.MsgTx{
+				TxOut: []*wire.TxOut{&rootTxOut, &pushData1TxOut},
+			},
+			isValid: false,
 		}, {
 			name: "Admin transaction adding asp",
 			tx: wire.MsgTx{
 				TxOut: []*wire.TxOut{&provisionTxOut, &provOpTxOut},
 			},
 			isValid: true,
+		}, {
+			name: "Admin transaction adding asp with expiry",
+			tx: wire.MsgTx{
+				TxOut: []*wire.TxOut{&provisionTxOut, &expOpTxOut},
+			},
+			isValid: true,
+		}, {
+			name: "Asp key with expiry before activation",
+			tx: wire.MsgTx{
+				TxOut: []*wire.TxOut{&provisionTxOut, &expOpTxOut},
+			},
+			height:  activationHeight - 1,
+			isValid: false,
+		}, {
+			name: "Admin transaction adding delegate key",
+			tx: wire.MsgTx{
+				TxOut: []*wire.TxOut{&provisionTxOut, &delegateOpTxOut},
+			},
+			isValid: true,
+		}, {
+			name: "Delegate key operation before activation",
+			tx: wire.MsgTx{
+				TxOut: []*wire.TxOut{&provisionTxOut, &delegateOpTxOut},
+			},
+			height:  activationHeight - 1,
+			isValid: false,
 		}, {
 			name: "Admin transaction with operation on wrong thread",
 			tx: wire.MsgTx{
 				TxOut: []*wire.TxOut{&provisionTxOut, &adminOpTxOut},
 			},
 			isValid: false,
+		}, {
+			name: "Admin transaction adding issuance destination",
+			tx: wire.MsgTx{
+				TxOut: []*wire.TxOut{&rootTxOut, &destOpTxOut},
+			},
+			isValid: true,
+		}, {
+			name: "Issuance destination operation on wrong thread",
+			tx: wire.MsgTx{
+				TxOut: []*wire.TxOut{&provisionTxOut, &destOpTxOut},
+			},
+			isValid: false,
+		}, {
+			name: "Issuance destination operation before activation",
+			tx: wire.MsgTx{
+				TxOut: []*wire.TxOut{&rootTxOut, &destOpTxOut},
+			},
+			height:  activationHeight - 1,
+			isValid: false,
+		}, {
+			name: "Admin transaction freezing account",
+			tx: wire.MsgTx{
+				TxOut: []*wire.TxOut{&provisionTxOut, &freezeOpTxOut},
+			},
+			isValid: true,
+		}, {
+			name: "Account freeze operation on wrong thread",
+			tx: wire.MsgTx{
+				TxOut: []*wire.TxOut{&rootTxOut, &freezeOpTxOut},
+			},
+			isValid: false,
+		}, {
+			name: "Account freeze operation before activation",
+			tx: wire.MsgTx{
+				TxOut: []*wire.TxOut{&provisionTxOut, &freezeOpTxOut},
+			},
+			height:  activationHeight - 1,
+			isValid: false,
 		},
 	}
 
@@ -198,7 +401,12 @@ func TestIsValidAdminOp(t *testing.T) {
 				" when it should be", test.name)
 			continue
 		}
-		isValid := IsValidAdminOp(adminOutputs[0], provautil.ThreadID(threadInt))
+		height := test.height
+		if height == 0 {
+			height = activationHeight
+		}
+		isValid := IsValidAdminOp(adminOutputs[0],
+			provautil.ThreadID(threadInt), height, &params)
 		if isValid == test.isValid {
 			// Test passes since function returned valid for an
 			// op which is intended to be valid.
@@ -545,6 +753,20 @@ var scriptClassTests = []struct {
 			"962e0ea1f61deb649f6bc3f4cef3",
 		class: NullDataTy,
 	},
+	{
+		// Nulldata with a 32-byte push is a metadata commitment.
+		name: "commitment",
+		script: "RETURN DATA_32 0x046708afdb0fe5548271967f1a67130b7105" +
+			"cd6a828e03909a67962e0ea1f61d",
+		class: CommitmentTy,
+	},
+	{
+		// Non-canonical 32-byte push is plain nulldata.
+		name: "non-canonical commitment",
+		script: "RETURN PUSHDATA1 0x20 0x046708afdb0fe5548271967f1a67" +
+			"130b7105cd6a828e03909a67962e0ea1f61d",
+		class: NullDataTy,
+	},
 	{
 		// Nulldata with more than max allowed data to be considered
 		// standard (so therefore nonstandard)
@@ -672,6 +894,21 @@ func TestStringifyClass(t *testing.T) {
 			class:    NullDataTy,
 			stringed: "nulldata",
 		},
+		{
+			name:     "commitmentty",
+			class:    CommitmentTy,
+			stringed: "commitment",
+		},
+		{
+			name:     "provahtlcty",
+			class:    ProvaHTLCTy,
+			stringed: "htlc",
+		},
+		{
+			name:     "provatimelockty",
+			class:    ProvaTimeLockTy,
+			stringed: "timelock",
+		},
 		{
 			name:     "broken",
 			class:    ScriptClass(255),
@@ -773,3 +1010,462 @@ func TestNullDataScript(t *testing.T) {
 		}
 	}
 }
+
+// TestCommitmentScript tests whether CommitmentScript returns a valid script
+// which ExtractCommitment recovers the commitment from.
+func TestCommitmentScript(t *testing.T) {
+	commitment := hexToBytes("046708afdb0fe5548271967f1a67130b7105cd6a8" +
+		"28e03909a67962e0ea1f61d")
+	script, err := CommitmentScript(commitment)
+	if err != nil {
+		t.Fatalf("CommitmentScript: unexpected error: %v", err)
+	}
+	expected := mustParseShortForm("RETURN DATA_32 0x046708afdb0fe5548" +
+		"271967f1a67130b7105cd6a828e03909a67962e0ea1f61d")
+	if !bytes.Equal(script, expected) {
+		t.Fatalf("CommitmentScript: wrong result\ngot: %x\nwant: %x",
+			script, expected)
+	}
+	if class := GetScriptClass(script); class != CommitmentTy {
+		t.Fatalf("GetScriptClass: got %v, want %v", class, CommitmentTy)
+	}
+
+	extracted, ok := ExtractCommitment(script)
+	if !ok || !bytes.Equal(extracted[:], commitment) {
+		t.Fatalf("ExtractCommitment: got %x (%v), want %x", extracted,
+			ok, commitment)
+	}
+
+	// Commitments of any other size are rejected.
+	for _, size := range []int{0, CommitmentSize - 1, CommitmentSize + 1} {
+		_, err := CommitmentScript(make([]byte, size))
+		err = tstCheckScriptError(err,
+			scriptError(ErrInvalidCommitmentSize, ""))
+		if err != nil {
+			t.Errorf("CommitmentScript(%d bytes): %v", size, err)
+		}
+	}
+
+	// Plain nulldata scripts do not carry a commitment.
+	nullData := mustParseShortForm("RETURN DATA_8 0x046708afdb0fe554")
+	if _, ok := ExtractCommitment(nullData); ok {
+		t.Error("ExtractCommitment: unexpected commitment in nulldata " +
+			"script")
+	}
+}
+
+// TestAssetIDScript ensures asset marker scripts are created, classified and
+// read as expected.
+func TestAssetIDScript(t *testing.T) {
+	script, err := AssetIDScript(0x01020304)
+	if err != nil {
+		t.Fatalf("AssetIDScript: unexpected error: %v", err)
+	}
+	expected := mustParseShortForm("RETURN DATA_5 0x2104030201")
+	if !bytes.Equal(script, expected) {
+		t.Fatalf("AssetIDScript: wrong result\ngot: %x\nwant: %x",
+			script, expected)
+	}
+	if class := GetScriptClass(script); class != NullDataTy {
+		t.Fatalf("GetScriptClass: got %v, want %v", class, NullDataTy)
+	}
+
+	assetID, ok := ExtractAssetID(script)
+	if !ok || assetID != 0x01020304 {
+		t.Fatalf("ExtractAssetID: got %d (%v), want %d", assetID, ok,
+			0x01020304)
+	}
+	if got := AdminOpString(script); got != "ASSET_ID 16909060" {
+		t.Errorf("AdminOpString: got %q", got)
+	}
+
+	// Transactions without a marker move DMG.
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	msgTx.AddTxOut(wire.NewTxOut(1, mustParseShortForm("RETURN DATA_5 "+
+		"0x2004030201")))
+	if got := TxAssetID(msgTx); got != 0 {
+		t.Errorf("TxAssetID: got %d, want 0", got)
+	}
+	msgTx.AddTxOut(wire.NewTxOut(0, script))
+	if got := TxAssetID(msgTx); got != 0x01020304 {
+		t.Errorf("TxAssetID: got %d, want %d", got, 0x01020304)
+	}
+}
+
+// TestProvaHTLCScript ensures Prova HTLC scripts are created, classified and
+// read as expected.
+func TestProvaHTLCScript(t *testing.T) {
+	t.Parallel()
+
+	redeemAddr, err := provautil.NewAddressProva(
+		decodeHex("35dbbf04bca061e49dace08f858d8775c0a57c8e"),
+		[]btcec.KeyID{0x10000, 1}, &chaincfg.TestNetParams)
+	if err != nil {
+		t.Fatalf("Unable to create prova address: %v", err)
+	}
+	refundAddr, err := provautil.NewAddressProva(
+		decodeHex("660d4ef3a743e3e696ad990364e555c271ad504b"),
+		[]btcec.KeyID{2, 3}, &chaincfg.TestNetParams)
+	if err != nil {
+		t.Fatalf("Unable to create prova address: %v", err)
+	}
+	secretHash := bytes.Repeat([]byte{0x11}, HTLCSecretSize)
+
+	script, err := PayToProvaHTLCScript(secretHash, 500000, redeemAddr,
+		refundAddr)
+	if err != nil {
+		t.Fatalf("PayToProvaHTLCScript: unexpected error: %v", err)
+	}
+	expected := mustParseShortForm("IF SIZE DATA_1 0x20 EQUALVERIFY " +
+		"SHA256 DATA_32 0x111111111111111111111111111111111111111111" +
+		"1111111111111111111111 EQUALVERIFY 2 DATA_20 0x35dbbf04bca0" +
+		"61e49dace08f858d8775c0a57c8e DATA_3 0x000001 1 3 " +
+		"CHECKSAFEMULTISIG ELSE DATA_3 0x20a107 CHECKLOCKTIMEVERIFY " +
+		"DROP 2 DATA_20 0x660d4ef3a743e3e696ad990364e555c271ad504b 2 " +
+		"3 3 CHECKSAFEMULTISIG ENDIF")
+	if !bytes.Equal(script, expected) {
+		t.Fatalf("PayToProvaHTLCScript: wrong result\ngot: %x\nwant: %x",
+			script, expected)
+	}
+	if class := GetScriptClass(script); class != ProvaHTLCTy {
+		t.Fatalf("GetScriptClass: got %v, want %v", class, ProvaHTLCTy)
+	}
+
+	// The terms and both branches of the contract can be read.
+	htlc, err := ExtractProvaHTLC(script)
+	if err != nil {
+		t.Fatalf("ExtractProvaHTLC: unexpected error: %v", err)
+	}
+	redeemScript, _ := PayToAddrScript(redeemAddr)
+	refundScript, _ := PayToAddrScript(refundAddr)
+	if !bytes.Equal(htlc.SecretHash[:], secretHash) ||
+		htlc.LockTime != 500000 ||
+		!bytes.Equal(htlc.RedeemScript, redeemScript) ||
+		!bytes.Equal(htlc.RefundScript, refundScript) {
+		t.Fatalf("ExtractProvaHTLC: wrong result %+v", htlc)
+	}
+	pops, _ := ParseScript(script)
+	keyIDs, err := ExtractKeyIDs(pops)
+	if err != nil {
+		t.Fatalf("ExtractKeyIDs: unexpected error: %v", err)
+	}
+	wantKeyIDs := []btcec.KeyID{0x10000, 1, 2, 3}
+	if !reflect.DeepEqual(keyIDs, wantKeyIDs) {
+		t.Errorf("ExtractKeyIDs: got %v, want %v", keyIDs, wantKeyIDs)
+	}
+	if pkHashes := ExtractPkHashes(pops); len(pkHashes) != 2 {
+		t.Errorf("ExtractPkHashes: got %d hashes, want 2",
+			len(pkHashes))
+	}
+	class, addrs, reqSigs, err := ExtractPkScriptAddrs(script,
+		&chaincfg.TestNetParams)
+	if err != nil || class != ProvaHTLCTy || reqSigs != 2 ||
+		len(addrs) != 2 ||
+		addrs[0].EncodeAddress() != redeemAddr.EncodeAddress() ||
+		addrs[1].EncodeAddress() != refundAddr.EncodeAddress() {
+		t.Errorf("ExtractPkScriptAddrs: got %v %v %d %v", class, addrs,
+			reqSigs, err)
+	}
+
+	// Lock times round trip over the whole range.
+	for _, lockTime := range []uint32{0, 16, 17, 0x7fffffff, 0xffffffff} {
+		script, err := PayToProvaHTLCScript(secretHash, lockTime,
+			redeemAddr, refundAddr)
+		if err != nil {
+			t.Fatalf("PayToProvaHTLCScript(%d): unexpected error: %v",
+				lockTime, err)
+		}
+		htlc, err := ExtractProvaHTLC(script)
+		if err != nil || htlc.LockTime != lockTime {
+			t.Errorf("ExtractProvaHTLC(%d): got %v (%v)", lockTime,
+				htlc, err)
+		}
+	}
+
+	// Secrets and hashes of any other size are rejected.
+	for _, size := range []int{0, HTLCSecretSize - 1, HTLCSecretSize + 1} {
+		_, err := PayToProvaHTLCScript(make([]byte, size), 500000,
+			redeemAddr, refundAddr)
+		err = tstCheckScriptError(err,
+			scriptError(ErrInvalidHTLCSecretSize, ""))
+		if err != nil {
+			t.Errorf("PayToProvaHTLCScript(%d bytes): %v", size, err)
+		}
+		_, err = ProvaHTLCRedeemSigScript(nil, make([]byte, size))
+		err = tstCheckScriptError(err,
+			scriptError(ErrInvalidHTLCSecretSize, ""))
+		if err != nil {
+			t.Errorf("ProvaHTLCRedeemSigScript(%d bytes): %v", size,
+				err)
+		}
+	}
+
+	// Scripts deviating from the template are not Prova HTLC scripts.
+	noSizeCheck := mustParseShortForm("IF SHA256 DATA_32 0x1111111111" +
+		"111111111111111111111111111111111111111111111111111111 " +
+		"EQUALVERIFY 2 DATA_20 0x35dbbf04bca061e49dace08f858d8775c0a5" +
+		"7c8e DATA_3 0x000001 1 3 CHECKSAFEMULTISIG ELSE DATA_3 " +
+		"0x20a107 CHECKLOCKTIMEVERIFY DROP 2 DATA_20 0x660d4ef3a743e3" +
+		"e696ad990364e555c271ad504b 2 3 3 CHECKSAFEMULTISIG ENDIF")
+	for _, script := range [][]byte{redeemScript, noSizeCheck} {
+		if class := GetScriptClass(script); class == ProvaHTLCTy {
+			t.Errorf("GetScriptClass(%x): unexpected class %v",
+				script, class)
+		}
+		_, err := ExtractProvaHTLC(script)
+		err = tstCheckScriptError(err, scriptError(ErrNotProvaHTLC, ""))
+		if err != nil {
+			t.Errorf("ExtractProvaHTLC(%x): %v", script, err)
+		}
+	}
+}
+
+// TestProvaTimeLockScript ensures Prova time lock scripts are built and
+// recognized correctly, and that the lock time and sequence of transactions
+// spending them are set as required by their script.
+func TestProvaTimeLockScript(t *testing.T) {
+	t.Parallel()
+
+	addr, err := provautil.NewAddressProva(
+		decodeHex("35dbbf04bca061e49dace08f858d8775c0a57c8e"),
+		[]btcec.KeyID{0x10000, 1}, &chaincfg.TestNetParams)
+	if err != nil {
+		t.Fatalf("Unable to create prova address: %v", err)
+	}
+	provaScript, _ := PayToAddrScript(addr)
+
+	lockTimeScript, err := PayToProvaLockTimeScript(500000, addr)
+	if err != nil {
+		t.Fatalf("PayToProvaLockTimeScript: unexpected error: %v", err)
+	}
+	sequenceScript, err := PayToProvaSequenceLockScript(144, addr)
+	if err != nil {
+		t.Fatalf("PayToProvaSequenceLockScript: unexpected error: %v",
+			err)
+	}
+	tests := []struct {
+		name     string
+		script   []byte
+		expected []byte
+		lockTime uint32
+		relative bool
+	}{
+		{
+			name:   "absolute",
+			script: lockTimeScript,
+			expected: mustParseShortForm("DATA_3 0x20a107 " +
+				"CHECKLOCKTIMEVERIFY DROP 2 DATA_20 0x35dbbf04" +
+				"bca061e49dace08f858d8775c0a57c8e DATA_3 0x000001 " +
+				"1 3 CHECKSAFEMULTISIG"),
+			lockTime: 500000,
+		},
+		{
+			name:   "relative",
+			script: sequenceScript,
+			expected: mustParseShortForm("DATA_2 0x9000 " +
+				"CHECKSEQUENCEVERIFY DROP 2 DATA_20 0x35dbbf04" +
+				"bca061e49dace08f858d8775c0a57c8e DATA_3 0x000001 " +
+				"1 3 CHECKSAFEMULTISIG"),
+			lockTime: 144,
+			relative: true,
+		},
+	}
+	for _, test := range tests {
+		if !bytes.Equal(test.script, test.expected) {
+			t.Errorf("%s: wrong script\ngot: %x\nwant: %x",
+				test.name, test.script, test.expected)
+			continue
+		}
+		if class := GetScriptClass(test.script); class != ProvaTimeLockTy {
+			t.Errorf("%s: GetScriptClass: got %v, want %v",
+				test.name, class, ProvaTimeLockTy)
+		}
+		timeLock, err := ExtractProvaTimeLock(test.script)
+		if err != nil {
+			t.Errorf("%s: ExtractProvaTimeLock: unexpected error: %v",
+				test.name, err)
+			continue
+		}
+		if timeLock.LockTime != test.lockTime ||
+			timeLock.Relative != test.relative ||
+			!bytes.Equal(timeLock.Script, provaScript) {
+			t.Errorf("%s: ExtractProvaTimeLock: wrong result %+v",
+				test.name, timeLock)
+		}
+
+		pops, _ := ParseScript(test.script)
+		keyIDs, err := ExtractKeyIDs(pops)
+		wantKeyIDs := []btcec.KeyID{0x10000, 1}
+		if err != nil || !reflect.DeepEqual(keyIDs, wantKeyIDs) {
+			t.Errorf("%s: ExtractKeyIDs: got %v (%v), want %v",
+				test.name, keyIDs, err, wantKeyIDs)
+		}
+		if pkHashes := ExtractPkHashes(pops); len(pkHashes) != 1 {
+			t.Errorf("%s: ExtractPkHashes: got %d hashes, want 1",
+				test.name, len(pkHashes))
+		}
+		class, addrs, reqSigs, err := ExtractPkScriptAddrs(test.script,
+			&chaincfg.TestNetParams)
+		if err != nil || class != ProvaTimeLockTy || reqSigs != 2 ||
+			len(addrs) != 1 ||
+			addrs[0].EncodeAddress() != addr.EncodeAddress() {
+			t.Errorf("%s: ExtractPkScriptAddrs: got %v %v %d %v",
+				test.name, class, addrs, reqSigs, err)
+		}
+	}
+
+	// Sequences with the lock time disabled flag set are rejected.
+	_, err = PayToProvaSequenceLockScript(wire.SequenceLockTimeDisabled|144,
+		addr)
+	err = tstCheckScriptError(err, scriptError(ErrInvalidTimeLock, ""))
+	if err != nil {
+		t.Errorf("PayToProvaSequenceLockScript: %v", err)
+	}
+
+	// Scripts deviating from the template are not time lock scripts.
+	for _, script := range [][]byte{provaScript,
+		mustParseShortForm("DATA_3 0x20a107 CHECKLOCKTIMEVERIFY 2 " +
+			"DATA_20 0x35dbbf04bca061e49dace08f858d8775c0a57c8e " +
+			"DATA_3 0x000001 1 3 CHECKSAFEMULTISIG"),
+	} {
+		if class := GetScriptClass(script); class == ProvaTimeLockTy {
+			t.Errorf("GetScriptClass(%x): unexpected class %v",
+				script, class)
+		}
+		_, err := ExtractProvaTimeLock(script)
+		err = tstCheckScriptError(err,
+			scriptError(ErrNotProvaTimeLock, ""))
+		if err != nil {
+			t.Errorf("ExtractProvaTimeLock(%x): %v", script, err)
+		}
+	}
+
+	// Spending transactions are prepared according to the script.
+	newTx := func(lockTime uint32) *wire.MsgTx {
+		return &wire.MsgTx{
+			Version: 1,
+			TxIn: []*wire.TxIn{{
+				Sequence: wire.MaxTxInSequenceNum,
+			}},
+			LockTime: lockTime,
+		}
+	}
+	tx := newTx(0)
+	if err := PrepareProvaTimeLockSpend(tx, 0, lockTimeScript); err != nil {
+		t.Fatalf("PrepareProvaTimeLockSpend: unexpected error: %v", err)
+	}
+	if tx.LockTime != 500000 ||
+		tx.TxIn[0].Sequence != wire.MaxTxInSequenceNum-1 {
+		t.Errorf("PrepareProvaTimeLockSpend: got lock time %d and "+
+			"sequence %x", tx.LockTime, tx.TxIn[0].Sequence)
+	}
+	tx = newTx(600000)
+	if err := PrepareProvaTimeLockSpend(tx, 0, lockTimeScript); err != nil {
+		t.Fatalf("PrepareProvaTimeLockSpend: unexpected error: %v", err)
+	}
+	if tx.LockTime != 600000 {
+		t.Errorf("PrepareProvaTimeLockSpend: lock time lowered to %d",
+			tx.LockTime)
+	}
+	tx = newTx(0)
+	if err := PrepareProvaTimeLockSpend(tx, 0, sequenceScript); err != nil {
+		t.Fatalf("PrepareProvaTimeLockSpend: unexpected error: %v", err)
+	}
+	if tx.Version != 2 || tx.TxIn[0].Sequence != 144 {
+		t.Errorf("PrepareProvaTimeLockSpend: got version %d and "+
+			"sequence %x", tx.Version, tx.TxIn[0].Sequence)
+	}
+	err = PrepareProvaTimeLockSpend(newTx(LockTimeThreshold), 0,
+		lockTimeScript)
+	err = tstCheckScriptError(err, scriptError(ErrInvalidTimeLock, ""))
+	if err != nil {
+		t.Errorf("PrepareProvaTimeLockSpend: %v", err)
+	}
+	err = PrepareProvaTimeLockSpend(newTx(0), 1, lockTimeScript)
+	err = tstCheckScriptError(err, scriptError(ErrInvalidIndex, ""))
+	if err != nil {
+		t.Errorf("PrepareProvaTimeLockSpend: %v", err)
+	}
+	err = PrepareProvaTimeLockSpend(newTx(0), 0, provaScript)
+	err = tstCheckScriptError(err, scriptError(ErrNotProvaTimeLock, ""))
+	if err != nil {
+		t.Errorf("PrepareProvaTimeLockSpend: %v", err)
+	}
+}
+
+// TestProvaKeyIDScript ensures Prova keyID scripts are built and recognized
+// correctly, and that only the keyIDs of their Prova script are replaced by
+// key hashes.
+func TestProvaKeyIDScript(t *testing.T) {
+	t.Parallel()
+
+	addr, err := provautil.NewAddressProva(
+		decodeHex("35dbbf04bca061e49dace08f858d8775c0a57c8e"),
+		[]btcec.KeyID{0x10000, 1}, &chaincfg.TestNetParams)
+	if err != nil {
+		t.Fatalf("Unable to create prova address: %v", err)
+	}
+
+	script, err := PayToProvaKeyIDScript(7, addr)
+	if err != nil {
+		t.Fatalf("PayToProvaKeyIDScript: unexpected error: %v", err)
+	}
+	expected := mustParseShortForm("7 CHECKKEYIDVERIFY 2DROP 2 " +
+		"DATA_20 0x35dbbf04bca061e49dace08f858d8775c0a57c8e " +
+		"DATA_3 0x000001 1 3 CHECKSAFEMULTISIG")
+	if !bytes.Equal(script, expected) {
+		t.Fatalf("wrong script\ngot: %x\nwant: %x", script, expected)
+	}
+	if class := GetScriptClass(script); class != ProvaKeyIDTy {
+		t.Errorf("GetScriptClass: got %v, want %v", class, ProvaKeyIDTy)
+	}
+
+	pops, _ := ParseScript(script)
+	keyIDs, err := ExtractKeyIDs(pops)
+	wantKeyIDs := []btcec.KeyID{0x10000, 1}
+	if err != nil || !reflect.DeepEqual(keyIDs, wantKeyIDs) {
+		t.Errorf("ExtractKeyIDs: got %v (%v), want %v", keyIDs, err,
+			wantKeyIDs)
+	}
+	keyHash := bytes.Repeat([]byte{0x11}, 20)
+	err = ReplaceKeyIDs(pops, map[btcec.KeyID][]byte{7: keyHash,
+		0x10000: keyHash, 1: keyHash})
+	if err != nil {
+		t.Fatalf("ReplaceKeyIDs: unexpected error: %v", err)
+	}
+	replaced, _ := UnparseScript(pops)
+	wantReplaced := mustParseShortForm("7 CHECKKEYIDVERIFY 2DROP 2 " +
+		"DATA_20 0x35dbbf04bca061e49dace08f858d8775c0a57c8e " +
+		"DATA_20 0x1111111111111111111111111111111111111111 " +
+		"DATA_20 0x1111111111111111111111111111111111111111 " +
+		"3 CHECKSAFEMULTISIG")
+	if !bytes.Equal(replaced, wantReplaced) {
+		t.Errorf("ReplaceKeyIDs: wrong script\ngot: %x\nwant: %x",
+			replaced, wantReplaced)
+	}
+
+	class, addrs, reqSigs, err := ExtractPkScriptAddrs(script,
+		&chaincfg.TestNetParams)
+	if err != nil || class != ProvaKeyIDTy || reqSigs != 3 ||
+		len(addrs) != 1 ||
+		addrs[0].EncodeAddress() != addr.EncodeAddress() {
+		t.Errorf("ExtractPkScriptAddrs: got %v %v %d %v", class, addrs,
+			reqSigs, err)
+	}
+
+	// Scripts deviating from the template are not keyID scripts.
+	for _, script := range [][]byte{
+		mustParseShortForm("7 CHECKKEYIDVERIFY DROP 2 " +
+			"DATA_20 0x35dbbf04bca061e49dace08f858d8775c0a57c8e " +
+			"DATA_3 0x000001 1 3 CHECKSAFEMULTISIG"),
+		mustParseShortForm("-1 CHECKKEYIDVERIFY 2DROP 2 " +
+			"DATA_20 0x35dbbf04bca061e49dace08f858d8775c0a57c8e " +
+			"DATA_3 0x000001 1 3 CHECKSAFEMULTISIG"),
+		mustParseShortForm("7 CHECKKEYIDVERIFY 2DROP 1"),
+	} {
+		if class := GetScriptClass(script); class == ProvaKeyIDTy {
+			t.Errorf("GetScriptClass(%x): unexpected class %v",
+				script, class)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event types delivered to webhooks.
+const (
+	// EventDeposit is delivered for every output paying a watched address
+	// created by a block connected to the main chain.
+	EventDeposit = "deposit"
+
+	// EventAdminOp is delivered for every admin operation applied by a
+	// block connected to the main chain.
+	EventAdminOp = "adminop"
+
+	// EventReorg is delivered for every reorganization of the main chain.
+	// The deposits and admin operations of the disconnected blocks which
+	// are not confirmed again by the new main chain no longer apply.
+	EventReorg = "reorg"
+)
+
+// eventTypes are the event types targets may select.
+var eventTypes = map[string]bool{
+	EventDeposit: true,
+	EventAdminOp: true,
+	EventReorg:   true,
+}
+
+// Headers of the requests delivering events.
+const (
+	// HeaderEvent holds the type of the delivered event.
+	HeaderEvent = "X-Dmgd-Event"
+
+	// HeaderDelivery holds the ID of the delivered event, which stays the
+	// same when the delivery is retried.
+	HeaderDelivery = "X-Dmgd-Delivery"
+
+	// HeaderTimestamp holds the time of the delivery attempt in seconds
+	// since 1 Jan 1970 GMT.
+	HeaderTimestamp = "X-Dmgd-Timestamp"
+
+	// HeaderSignature holds the signature of the timestamp and the body
+	// made with the secret, as returned by Sign.
+	HeaderSignature = "X-Dmgd-Signature"
+)
+
+// Defaults of the delivery configuration.
+const (
+	defaultTimeout          = 10 * time.Second
+	defaultMaxAttempts      = 8
+	defaultRetryInterval    = time.Second
+	defaultMaxRetryInterval = 5 * time.Minute
+	defaultMaxQueue         = 10000
+)
+
+// Event is an event of the block chain delivered to webhooks.  Its ID is
+// derived from the chain data it describes, so events delivered again after a
+// retry can be recognized by the receiver.
+type Event struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Time int64           `json:"time"`
+	Data json.RawMessage `json:"data"`
+}
+
+// NewEvent returns the event of the passed type and ID with the passed data,
+// created at the passed time.
+func NewEvent(eventType, id string, now time.Time, data interface{}) (*Event, error) {
+	serializedData, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{
+		ID:   id,
+		Type: eventType,
+		Time: now.Unix(),
+		Data: serializedData,
+	}, nil
+}
+
+// Target is a URL events of the selected types are POSTed to.
+type Target struct {
+	URL    string
+	Events map[string]bool
+}
+
+// ParseTarget parses a target of the form <event>[,<event>...]@<url>, such as
+// "deposit,reorg@https://example.com/hook".  The URL must use HTTPS, unless its
+// host is a loopback address.
+func ParseTarget(s string) (*Target, error) {
+	i := strings.Index(s, "@")
+	if i < 0 {
+		return nil, fmt.Errorf("webhook %q is not of the form "+
+			"<event>[,<event>...]@<url>", s)
+	}
+	target := &Target{URL: s[i+1:], Events: make(map[string]bool)}
+	for _, eventType := range strings.Split(s[:i], ",") {
+		if !eventTypes[eventType] {
+			return nil, fmt.Errorf("webhook %q has an unknown "+
+				"event %q", s, eventType)
+		}
+		target.Events[eventType] = true
+	}
+
+	u, err := url.Parse(target.URL)
+	if err != nil {
+		return nil, fmt.Errorf("webhook %q: %v", s, err)
+	}
+	switch {
+	case u.Scheme == "https":
+	case u.Scheme == "http" && isLoopback(u.Hostname()):
+	default:
+		return nil, fmt.Errorf("webhook %q does not use HTTPS", s)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("webhook %q has no host", s)
+	}
+	return target, nil
+}
+
+// isLoopback returns whether the passed host is localhost or a loopback
+// address.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Sign returns the signature of the request body delivered at the passed time
+// with the passed secret: the hex-encoded HMAC-SHA256 of the decimal timestamp,
+// a dot and the body, prefixed with "sha256=".  Receivers verify it with the
+// timestamp and signature headers and should reject old timestamps to prevent
+// replays.
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Config is a descriptor containing the configuration of a dispatcher.
+type Config struct {
+	// Targets are the webhooks the events are delivered to.
+	Targets []*Target
+
+	// Secret is the key the deliveries are signed with.
+	Secret []byte
+
+	// MaxAttempts is the number of times the delivery of an event is
+	// attempted before it is dropped.  It defaults to 8.
+	MaxAttempts int
+
+	// RetryInterval is the interval after which a failed delivery is
+	// retried the first time.  It doubles with every further attempt up to
+	// MaxRetryInterval.  They default to 1 second and 5 minutes.
+	RetryInterval    time.Duration
+	MaxRetryInterval time.Duration
+
+	// MaxQueue is the number of events queued for a target, after which
+	// the oldest are dropped.  It defaults to 10000.
+	MaxQueue int
+
+	// Client is the HTTP client used for the deliveries.  It defaults to a
+	// client with a timeout of 10 seconds.
+	Client *http.Client
+}
+
+// target is the delivery state of a Target.
+type target struct {
+	*Target
+
+	mtx    sync.Mutex
+	queue  []*Event
+	notify chan struct{}
+}
+
+// Dispatcher delivers events to webhooks.  Each target has its own queue and
+// goroutine, so events are delivered to it in order and a slow or failing
+// target does not hold up the others.  A failed delivery is retried with
+// exponential backoff, and the events after it wait until it succeeds or is
+// dropped.  Queued events are kept in memory only, so they are lost when the
+// node stops.
+type Dispatcher struct {
+	cfg     Config
+	targets []*target
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// New returns a dispatcher with the passed configuration.
+func New(cfg *Config) *Dispatcher {
+	d := &Dispatcher{
+		cfg:  *cfg,
+		quit: make(chan struct{}),
+	}
+	if d.cfg.MaxAttempts == 0 {
+		d.cfg.MaxAttempts = defaultMaxAttempts
+	}
+	if d.cfg.RetryInterval == 0 {
+		d.cfg.RetryInterval = defaultRetryInterval
+	}
+	if d.cfg.MaxRetryInterval == 0 {
+		d.cfg.MaxRetryInterval = defaultMaxRetryInterval
+	}
+	if d.cfg.MaxQueue == 0 {
+		d.cfg.MaxQueue = defaultMaxQueue
+	}
+	if d.cfg.Client == nil {
+		d.cfg.Client = &http.Client{Timeout: defaultTimeout}
+	}
+	for _, t := range cfg.Targets {
+		d.targets = append(d.targets, &target{
+			Target: t,
+			notify: make(chan struct{}, 1),
+		})
+	}
+	return d
+}
+
+// Start begins delivering events.
+func (d *Dispatcher) Start() {
+	for _, t := range d.targets {
+		d.wg.Add(1)
+		go d.handler(t)
+	}
+}
+
+// Stop stops delivering events.  Events which were not delivered yet are
+// dropped.
+func (d *Dispatcher) Stop() {
+	close(d.quit)
+	d.wg.Wait()
+}
+
+// Wants returns whether any target selected the passed event type, so callers
+// can skip creating events nobody receives.
+func (d *Dispatcher) Wants(eventType string) bool {
+	for _, t := range d.targets {
+		if t.Events[eventType] {
+			return true
+		}
+	}
+	return false
+}
+
+// Dispatch queues the passed events for delivery to the targets which selected
+// their types.  It does not block.
+//
+// This function is safe for concurrent access.
+func (d *Dispatcher) Dispatch(events ...*Event) {
+	for _, t := range d.targets {
+		t.mtx.Lock()
+		queued := false
+		for _, event := range events {
+			if t.Events[event.Type] {
+				t.queue = append(t.queue, event)
+				queued = true
+			}
+		}
+		if dropped := len(t.queue) - d.cfg.MaxQueue; dropped > 0 {
+			log.Errorf("Dropping %d events queued for webhook %s",
+				dropped, t.URL)
+			t.queue = t.queue[dropped:]
+		}
+		t.mtx.Unlock()
+
+		if queued {
+			select {
+			case t.notify <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// handler delivers the events queued for the passed target each time it is
+// notified.  It must be run as a goroutine.
+func (d *Dispatcher) handler(t *target) {
+	defer d.wg.Done()
+
+	for {
+		select {
+		case <-t.notify:
+		case <-d.quit:
+			return
+		}
+
+		for {
+			t.mtx.Lock()
+			if len(t.queue) == 0 {
+				t.mtx.Unlock()
+				break
+			}
+			event := t.queue[0]
+			t.mtx.Unlock()
+
+			if !d.deliverWithRetries(t, event) {
+				return
+			}
+
+			// The queue may have been trimmed while the event was
+			// delivered, in which case it was already dropped.
+			t.mtx.Lock()
+			if len(t.queue) != 0 && t.queue[0] == event {
+				t.queue = t.queue[1:]
+			}
+			t.mtx.Unlock()
+		}
+	}
+}
+
+// deliverWithRetries delivers the passed event to the passed target, retrying
+// with exponential backoff until it succeeds or the maximum number of attempts
+// is reached, in which case the event is dropped.  It returns false when the
+// dispatcher is stopped.
+func (d *Dispatcher) deliverWithRetries(t *target, event *Event) bool {
+	interval := d.cfg.RetryInterval
+	for attempt := 1; ; attempt++ {
+		err := d.deliver(t, event)
+		if err == nil {
+			return true
+		}
+		if attempt == d.cfg.MaxAttempts {
+			log.Errorf("Dropping %s event %s for webhook %s after %d "+
+				"attempts: %v", event.Type, event.ID, t.URL,
+				attempt, err)
+			return true
+		}
+		log.Warnf("Unable to deliver %s event %s to webhook %s, "+
+			"retrying in %v: %v", event.Type, event.ID, t.URL,
+			interval, err)
+
+		select {
+		case <-time.After(interval):
+		case <-d.quit:
+			return false
+		}
+		interval *= 2
+		if interval > d.cfg.MaxRetryInterval {
+			interval = d.cfg.MaxRetryInterval
+		}
+	}
+}
+
+// deliver POSTs the passed event to the passed target once.  Any status other
+// than 2xx is a failure.
+func (d *Dispatcher) deliver(t *target, event *Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, t.URL,
+		bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, event.Type)
+	req.Header.Set(HeaderDelivery, event.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(d.cfg.Secret, timestamp, body))
+
+	resp, err := d.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Drain the body so the connection can be reused.
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %s",
+			resp.Status)
+	}
+	return nil
+}
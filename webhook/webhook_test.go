@@ -0,0 +1,151 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestParseTarget ensures targets are parsed with their events and only accept
+// HTTPS URLs or loopback hosts.
+func TestParseTarget(t *testing.T) {
+	target, err := ParseTarget("deposit,reorg@https://example.com/hook@1")
+	if err != nil {
+		t.Fatalf("ParseTarget: %v", err)
+	}
+	if target.URL != "https://example.com/hook@1" ||
+		len(target.Events) != 2 || !target.Events[EventDeposit] ||
+		!target.Events[EventReorg] {
+
+		t.Fatalf("got target %+v", target)
+	}
+	for _, s := range []string{"adminop@http://127.0.0.1:8080/hook",
+		"adminop@http://localhost/hook", "adminop@http://[::1]/hook"} {
+
+		if _, err := ParseTarget(s); err != nil {
+			t.Errorf("ParseTarget(%q): %v", s, err)
+		}
+	}
+	for _, s := range []string{"https://example.com/hook",
+		"transfer@https://example.com/hook",
+		"deposit@http://example.com/hook", "deposit@https://",
+		"deposit@ftp://example.com/hook"} {
+
+		if _, err := ParseTarget(s); err == nil {
+			t.Errorf("ParseTarget(%q): no error", s)
+		}
+	}
+}
+
+// delivery is a request received by a test webhook.
+type delivery struct {
+	event     Event
+	header    http.Header
+	signature string
+}
+
+// TestDispatcher ensures events are delivered in order to the targets which
+// selected their types with valid signatures, and that failed deliveries are
+// retried until the maximum number of attempts.
+func TestDispatcher(t *testing.T) {
+	secret := []byte("secret")
+	deliveries := make(chan delivery, 10)
+	failures := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+
+		body, _ := ioutil.ReadAll(r.Body)
+		var d delivery
+		if err := json.Unmarshal(body, &d.event); err != nil {
+			t.Errorf("Unmarshal: %v", err)
+		}
+		timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp),
+			10, 64)
+		d.header = r.Header
+		d.signature = Sign(secret, timestamp, body)
+		if d.event.ID == "fail" || (d.event.ID == "retry" &&
+			failures["retry"] == 0) {
+
+			failures[d.event.ID]++
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		deliveries <- d
+	}))
+	defer server.Close()
+
+	reorgs, err := ParseTarget("reorg@" + server.URL)
+	if err != nil {
+		t.Fatalf("ParseTarget: %v", err)
+	}
+	deposits, err := ParseTarget("deposit@" + server.URL + "/deposits")
+	if err != nil {
+		t.Fatalf("ParseTarget: %v", err)
+	}
+	d := New(&Config{
+		Targets:       []*Target{reorgs, deposits},
+		Secret:        secret,
+		MaxAttempts:   3,
+		RetryInterval: time.Millisecond,
+	})
+	d.Start()
+	defer d.Stop()
+
+	if !d.Wants(EventDeposit) || d.Wants(EventAdminOp) {
+		t.Fatalf("dispatcher wants the wrong event types")
+	}
+
+	now := time.Unix(1546300800, 0)
+	var events []*Event
+	for _, id := range []string{"fail", "retry", "ok"} {
+		event, err := NewEvent(EventDeposit, id, now,
+			map[string]string{"txid": id})
+		if err != nil {
+			t.Fatalf("NewEvent: %v", err)
+		}
+		events = append(events, event)
+	}
+	d.Dispatch(events...)
+
+	for _, id := range []string{"retry", "ok"} {
+		select {
+		case got := <-deliveries:
+			if got.event.ID != id || got.event.Type != EventDeposit ||
+				got.event.Time != now.Unix() ||
+				string(got.event.Data) != `{"txid":"`+id+`"}` {
+
+				t.Fatalf("got event %+v, want %s", got.event, id)
+			}
+			if got.header.Get(HeaderSignature) != got.signature {
+				t.Fatalf("got signature %s, want %s",
+					got.header.Get(HeaderSignature),
+					got.signature)
+			}
+			if got.header.Get(HeaderEvent) != EventDeposit ||
+				got.header.Get(HeaderDelivery) != id {
+
+				t.Fatalf("got headers %v for event %s",
+					got.header, id)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("event %s not delivered", id)
+		}
+	}
+	select {
+	case got := <-deliveries:
+		t.Fatalf("unexpected delivery of event %+v", got.event)
+	default:
+	}
+	if failures["fail"] != 3 || failures["retry"] != 1 {
+		t.Fatalf("got failed deliveries %v, want 3 for the dropped "+
+			"event and 1 for the retried one", failures)
+	}
+}
@@ -335,6 +335,75 @@ func BenchmarkDeserializeTxLarge(b *testing.B) {
 	}
 }
 
+// benchmarkBlockBytes returns a serialized block with the passed number of
+// copies of the transaction of block one.
+func benchmarkBlockBytes(b *testing.B, numTxs int) []byte {
+	block := MsgBlock{Header: blockOne.Header}
+	for i := 0; i < numTxs; i++ {
+		block.AddTransaction(blockOne.Transactions[0])
+	}
+	var buf bytes.Buffer
+	if err := block.Serialize(&buf); err != nil {
+		b.Fatalf("Serialize: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// BenchmarkDeserializeBlock performs a benchmark on how long it takes to
+// deserialize a block with many transactions.
+func BenchmarkDeserializeBlock(b *testing.B) {
+	buf := benchmarkBlockBytes(b, 2000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	r := bytes.NewReader(buf)
+	var block MsgBlock
+	for i := 0; i < b.N; i++ {
+		r.Seek(0, 0)
+		block.Deserialize(r)
+	}
+}
+
+// BenchmarkBlockDecoder performs a benchmark on how long it takes to stream the
+// transactions of a block with many transactions without keeping them.
+func BenchmarkBlockDecoder(b *testing.B) {
+	buf := benchmarkBlockBytes(b, 2000)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	r := bytes.NewReader(buf)
+	for i := 0; i < b.N; i++ {
+		r.Seek(0, 0)
+		d, err := NewBlockDecoder(r, 0)
+		if err != nil {
+			b.Fatalf("NewBlockDecoder: %v", err)
+		}
+		for {
+			if _, err := d.Next(); err != nil {
+				break
+			}
+		}
+	}
+}
+
+// BenchmarkSerializeBlock performs a benchmark on how long it takes to
+// serialize a block with many transactions.
+func BenchmarkSerializeBlock(b *testing.B) {
+	buf := benchmarkBlockBytes(b, 2000)
+	var block MsgBlock
+	if err := block.Deserialize(bytes.NewReader(buf)); err != nil {
+		b.Fatalf("Deserialize: %v", err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	w := bytes.NewBuffer(make([]byte, 0, len(buf)))
+	for i := 0; i < b.N; i++ {
+		w.Reset()
+		block.Serialize(w)
+	}
+}
+
 // BenchmarkSerializeTx performs a benchmark on how long it takes to serialize
 // a transaction.
 func BenchmarkSerializeTx(b *testing.B) {
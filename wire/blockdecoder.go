@@ -0,0 +1,97 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+)
+
+// BlockDecoder decodes a serialized block from a stream one transaction at a
+// time.  Only the header and the transaction being decoded need to be held in
+// memory, so large blocks can be processed without first reading them in their
+// entirety, and the transactions which are not needed are never kept around.
+//
+// The transactions returned by Next share larger allocations in order to reduce
+// the pressure on the garbage collector, but each of them remains valid after
+// the following calls and may be kept or modified independently.
+type BlockDecoder struct {
+	r         io.Reader
+	pver      uint32
+	header    BlockHeader
+	txCount   uint64
+	remaining uint64
+	alloc     txAllocator
+}
+
+// NewBlockDecoder reads the header and the number of transactions of the block
+// serialized in r using the passed protocol version, and returns a decoder
+// which decodes its transactions on demand.  Protocol version 0 decodes the
+// long-term storage format, as Deserialize does.
+func NewBlockDecoder(r io.Reader, pver uint32) (*BlockDecoder, error) {
+	d := &BlockDecoder{r: r, pver: pver}
+	err := readBlockHeader(r, pver, &d.header)
+	if err != nil {
+		return nil, err
+	}
+
+	txCount, err := ReadVarInt(r, pver)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prevent more transactions than could possibly fit into a block.
+	// It would be possible to cause memory exhaustion and panics without
+	// a sane upper bound on this count.
+	if txCount > maxTxPerBlock {
+		str := fmt.Sprintf("too many transactions to fit into a block "+
+			"[count %d, max %d]", txCount, maxTxPerBlock)
+		return nil, messageError("NewBlockDecoder", str)
+	}
+
+	d.txCount = txCount
+	d.remaining = txCount
+	return d, nil
+}
+
+// Header returns the header of the block.
+func (d *BlockDecoder) Header() *BlockHeader {
+	return &d.header
+}
+
+// TxCount returns the number of transactions of the block.
+func (d *BlockDecoder) TxCount() uint64 {
+	return d.txCount
+}
+
+// Next decodes and returns the next transaction of the block.  It returns
+// io.EOF once all of the transactions are decoded, and io.ErrUnexpectedEOF when
+// the stream ends before that.
+func (d *BlockDecoder) Next() (*MsgTx, error) {
+	if d.remaining == 0 {
+		return nil, io.EOF
+	}
+
+	tx, err := d.decodeTx()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return tx, err
+}
+
+// decodeTx decodes the next transaction of the block, which must not have been
+// decoded entirely yet.  Unlike Next, it returns the errors of the transaction
+// decoding unchanged.
+func (d *BlockDecoder) decodeTx() (*MsgTx, error) {
+	d.alloc.remaining = d.remaining
+	tx := d.alloc.newTx()
+	err := tx.decode(d.r, d.pver, &d.alloc)
+	if err != nil {
+		d.remaining = 0
+		return nil, err
+	}
+	d.remaining--
+	return tx, nil
+}
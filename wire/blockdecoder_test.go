@@ -0,0 +1,98 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestBlockDecoder ensures the block decoder streams the header and each of the
+// transactions of a block and reports truncated blocks.
+func TestBlockDecoder(t *testing.T) {
+	block := MsgBlock{Header: blockOne.Header}
+	for i := 0; i < 3; i++ {
+		block.AddTransaction(blockOne.Transactions[0])
+	}
+	var buf bytes.Buffer
+	if err := block.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	serialized := buf.Bytes()
+
+	for _, pver := range []uint32{0, ProtocolVersion} {
+		d, err := NewBlockDecoder(bytes.NewReader(serialized), pver)
+		if err != nil {
+			t.Fatalf("NewBlockDecoder pver %d: %v", pver, err)
+		}
+		if !reflect.DeepEqual(d.Header(), &block.Header) {
+			t.Fatalf("pver %d: got header %s, want %s", pver,
+				spew.Sdump(d.Header()), spew.Sdump(&block.Header))
+		}
+		if d.TxCount() != uint64(len(block.Transactions)) {
+			t.Fatalf("pver %d: got %d transactions, want %d", pver,
+				d.TxCount(), len(block.Transactions))
+		}
+
+		var txs []*MsgTx
+		for {
+			tx, err := d.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("Next pver %d: %v", pver, err)
+			}
+			txs = append(txs, tx)
+		}
+		if !reflect.DeepEqual(txs, block.Transactions) {
+			t.Fatalf("pver %d: got transactions %s, want %s", pver,
+				spew.Sdump(txs), spew.Sdump(block.Transactions))
+		}
+		if _, err := d.Next(); err != io.EOF {
+			t.Fatalf("pver %d: got error %v after the last "+
+				"transaction, want io.EOF", pver, err)
+		}
+
+		// The transactions share allocations, but modifying one of
+		// them must not affect the others.
+		txs[0].AddTxIn(&TxIn{})
+		txs[0].AddTxOut(&TxOut{})
+		txs[0].TxIn[0].SignatureScript = append(
+			txs[0].TxIn[0].SignatureScript, 0x01)
+		for i, tx := range txs[1:] {
+			if !reflect.DeepEqual(tx, blockOne.Transactions[0]) {
+				t.Fatalf("pver %d: transaction #%d changed to %s",
+					pver, i+1, spew.Sdump(tx))
+			}
+		}
+	}
+
+	// A block which ends right after a transaction must not be mistaken
+	// for a complete block.
+	txLen := blockOne.Transactions[0].SerializeSize()
+	end := len(serialized) - txLen
+	d, err := NewBlockDecoder(bytes.NewReader(serialized[:end]), 0)
+	if err != nil {
+		t.Fatalf("NewBlockDecoder: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := d.Next(); err != nil {
+			t.Fatalf("Next #%d: %v", i, err)
+		}
+	}
+	if _, err := d.Next(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("got error %v for a truncated block, want "+
+			"io.ErrUnexpectedEOF", err)
+	}
+	if _, err := d.Next(); err != io.EOF {
+		t.Fatalf("got error %v after a failed transaction, want io.EOF",
+			err)
+	}
+}
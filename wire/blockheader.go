@@ -45,6 +45,7 @@ func (s BlockSignature) String() string {
 const BlockVersion = 4
 
 // MaxBlockHeaderPayload is the maximum number of bytes a block header can be.
+// It must cover the largest of the block header formats.
 const MaxBlockHeaderPayload = 32 + (chainhash.HashSize * 2) + BlockValidatingPubKeySize + BlockSignatureSize
 
 // BlockHeader defines information about a block and is used in the bitcoin
@@ -81,9 +82,48 @@ type BlockHeader struct {
 	Signature BlockSignature
 }
 
-// blockHeaderLen is a constant that represents the number of bytes for a block
-// header.
-const blockHeaderLen = MaxBlockHeaderPayload
+// blockHeaderFormat describes the serialization of the fields following the
+// version of the block headers of a range of block versions.
+type blockHeaderFormat struct {
+	// minVersion is the first block version serialized with the format.
+	minVersion uint32
+
+	// size is the number of bytes of a serialized block header, including
+	// its version.
+	size int
+
+	// read and write decode and encode the fields following the version.
+	read  func(r io.Reader, pver uint32, bh *BlockHeader) error
+	write func(w io.Writer, pver uint32, bh *BlockHeader) error
+}
+
+// blockHeaderFormats holds the block header formats in ascending order of their
+// first block version.  Block headers are decoded with the format of the version
+// they start with, so a change to the block header is made by adding a format
+// for a new block version while the headers of older versions keep decoding as
+// before.  The first format must start at version 0.
+//
+// All blocks up to now use the same format, which includes the height, size
+// and validator signature of the block.
+var blockHeaderFormats = []blockHeaderFormat{
+	{
+		minVersion: 0,
+		size:       MaxBlockHeaderPayload,
+		read:       readBlockHeaderV0,
+		write:      writeBlockHeaderV0,
+	},
+}
+
+// blockHeaderFormatOf returns the format of the block headers of the passed
+// block version.
+func blockHeaderFormatOf(version uint32) *blockHeaderFormat {
+	for i := len(blockHeaderFormats) - 1; i > 0; i-- {
+		if version >= blockHeaderFormats[i].minVersion {
+			return &blockHeaderFormats[i]
+		}
+	}
+	return &blockHeaderFormats[0]
+}
 
 // BlockHash computes the block identifier hash for the given block header.
 func (h *BlockHeader) BlockHash() chainhash.Hash {
@@ -133,6 +173,12 @@ func (h *BlockHeader) Serialize(w io.Writer) error {
 	return writeBlockHeader(w, 0, h)
 }
 
+// SerializeSize returns the number of bytes it would take to serialize the
+// block header, which depends on the format of its version.
+func (h *BlockHeader) SerializeSize() int {
+	return blockHeaderFormatOf(h.Version).size
+}
+
 // hashForSigning gets the double SHA256 hash of (Version|Timestamp|PrevBlock|MerkleRoot)
 // which is used for the validator's signature.
 func (h *BlockHeader) hashForSigning() []byte {
@@ -187,6 +233,41 @@ func (h *BlockHeader) Verify(pubKey *btcec.PublicKey) bool {
 	return ret
 }
 
+// SigningHash returns the hash of the block header which the validating key
+// signs.  It is exported for signers which do not sign with Sign, such as the
+// signers sharing a threshold key.
+func (h *BlockHeader) SigningHash() []byte {
+	return h.hashForSigning()
+}
+
+// SetSchnorrSignature sets the passed Schnorr signature of the signing hash by
+// the passed public key as the signature of the block.  The signature fills the
+// start of the Signature field and the rest is zeroed.
+func (h *BlockHeader) SetSchnorrSignature(pubKey *btcec.PublicKey,
+	sig *btcec.SchnorrSignature) {
+
+	copy(h.ValidatingPubKey[:], pubKey.SerializeCompressed())
+	h.Signature = BlockSignature{}
+	copy(h.Signature[:], sig.Serialize())
+}
+
+// VerifySchnorr checks the Schnorr signature on the block using the supplied
+// public key.  The bytes of the Signature field after the signature must be
+// zero.
+func (h *BlockHeader) VerifySchnorr(pubKey *btcec.PublicKey) bool {
+	for _, b := range h.Signature[btcec.SchnorrSignatureSize:] {
+		if b != 0 {
+			return false
+		}
+	}
+	sig, err := btcec.ParseSchnorrSignature(
+		h.Signature[:btcec.SchnorrSignatureSize])
+	if err != nil {
+		return false
+	}
+	return sig.Verify(h.hashForSigning(), pubKey)
+}
+
 // NewBlockHeader returns a new BlockHeader using the provided previous block
 // hash, merkle root hash, difficulty bits, and nonce used to generate the
 // block with defaults for the remaining fields.
@@ -205,18 +286,38 @@ func NewBlockHeader(prevHash *chainhash.Hash, merkleRootHash *chainhash.Hash,
 	}
 }
 
-// readBlockHeader reads a bitcoin block header from r.  See Deserialize for
-// decoding block headers stored to disk, such as in a database, as opposed to
-// decoding from the wire.
+// readBlockHeader reads a bitcoin block header from r using the format of its
+// version.  See Deserialize for decoding block headers stored to disk, such as
+// in a database, as opposed to decoding from the wire.
 func readBlockHeader(r io.Reader, pver uint32, bh *BlockHeader) error {
-	return readElements(r, &bh.Version, &bh.PrevBlock, &bh.MerkleRoot,
-		(*int64Time)(&bh.Timestamp), &bh.Bits, &bh.Height, &bh.Size, &bh.Nonce, &bh.ValidatingPubKey, &bh.Signature)
+	err := readElement(r, &bh.Version)
+	if err != nil {
+		return err
+	}
+	return blockHeaderFormatOf(bh.Version).read(r, pver, bh)
 }
 
-// writeBlockHeader writes a bitcoin block header to w.  See Serialize for
-// encoding block headers to be stored to disk, such as in a database, as
-// opposed to encoding for the wire.
+// writeBlockHeader writes a bitcoin block header to w using the format of its
+// version.  See Serialize for encoding block headers to be stored to disk, such
+// as in a database, as opposed to encoding for the wire.
 func writeBlockHeader(w io.Writer, pver uint32, bh *BlockHeader) error {
-	return writeElements(w, bh.Version, &bh.PrevBlock, &bh.MerkleRoot,
+	err := writeElement(w, bh.Version)
+	if err != nil {
+		return err
+	}
+	return blockHeaderFormatOf(bh.Version).write(w, pver, bh)
+}
+
+// readBlockHeaderV0 reads the fields following the version of a block header
+// of the format starting at block version 0 from r.
+func readBlockHeaderV0(r io.Reader, pver uint32, bh *BlockHeader) error {
+	return readElements(r, &bh.PrevBlock, &bh.MerkleRoot,
+		(*int64Time)(&bh.Timestamp), &bh.Bits, &bh.Height, &bh.Size, &bh.Nonce, &bh.ValidatingPubKey, &bh.Signature)
+}
+
+// writeBlockHeaderV0 writes the fields following the version of a block header
+// of the format starting at block version 0 to w.
+func writeBlockHeaderV0(w io.Writer, pver uint32, bh *BlockHeader) error {
+	return writeElements(w, &bh.PrevBlock, &bh.MerkleRoot,
 		bh.Timestamp.Unix(), bh.Bits, bh.Height, bh.Size, bh.Nonce, bh.ValidatingPubKey, bh.Signature)
 }
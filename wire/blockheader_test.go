@@ -8,11 +8,17 @@ package wire
 
 import (
 	"bytes"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/pyx-partners/dmgd/btcec"
 )
 
 // TestBlockHeader tests the BlockHeader API.
@@ -288,3 +294,249 @@ func TestBlockHeaderSerialize(t *testing.T) {
 		}
 	}
 }
+
+// goldenBlockHeaders returns the block headers of the golden files in the
+// testdata directory along with their block hashes.  Every block header format
+// has at least one golden file, so changing the serialization of existing
+// block headers is detected.
+func goldenBlockHeaders() []struct {
+	file   string
+	header *BlockHeader
+	hash   string
+} {
+	signedHdr := &BlockHeader{
+		Version:    4,
+		PrevBlock:  mainNetGenesisHash,
+		MerkleRoot: mainNetGenesisMerkleRoot,
+		Timestamp:  time.Unix(0x58dc307c, 0), // 2017-03-29 22:09:00 +0000 UTC
+		Bits:       0x1d00ffff,
+		Height:     1,
+		Size:       326,
+		Nonce:      0xab821115,
+	}
+	for i := range signedHdr.ValidatingPubKey {
+		signedHdr.ValidatingPubKey[i] = byte(i + 1)
+	}
+	for i := range signedHdr.Signature {
+		signedHdr.Signature[i] = byte(0xff - i)
+	}
+
+	return []struct {
+		file   string
+		header *BlockHeader
+		hash   string
+	}{
+		{
+			file: "blockheader_v1.hex",
+			header: &BlockHeader{
+				Version:    1,
+				PrevBlock:  mainNetGenesisHash,
+				MerkleRoot: mainNetGenesisMerkleRoot,
+				Timestamp:  time.Unix(0x495fab29, 0), // 2009-01-03 12:15:05 -0600 CST
+				Bits:       0x1d00ffff,
+				Nonce:      123123,
+			},
+			hash: "3173ca62fe259ef2765a2a98449c76d379c5186d26277493a6f9f3b084f66ef7",
+		},
+		{
+			file:   "blockheader_v4.hex",
+			header: signedHdr,
+			hash:   "4c88f80edc666370e1804cf026c3737a07432694c8e1571bfd3a1282c1e7d373",
+		},
+	}
+}
+
+// readGoldenFile returns the decoded contents of the passed hex-encoded golden
+// file in the testdata directory.
+func readGoldenFile(t *testing.T, file string) []byte {
+	contents, err := ioutil.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	golden, err := hex.DecodeString(strings.TrimSpace(string(contents)))
+	if err != nil {
+		t.Fatalf("DecodeString %s: %v", file, err)
+	}
+	return golden
+}
+
+// TestBlockHeaderGolden ensures the block headers of the golden files decode
+// and encode the same way at every protocol version and in storage, and keep
+// their block hashes.
+func TestBlockHeaderGolden(t *testing.T) {
+	pvers := []uint32{0, MultipleAddressVersion, NetAddressTimeVersion,
+		BIP0031Version, BIP0035Version, BIP0037Version, RejectVersion,
+		BIP0111Version, SendHeadersVersion, FeeFilterVersion,
+		AdminTxInvVersion, ProtocolVersion}
+
+	for _, test := range goldenBlockHeaders() {
+		golden := readGoldenFile(t, test.file)
+		if size := test.header.SerializeSize(); size != len(golden) {
+			t.Errorf("%s: got serialize size %d, want %d", test.file,
+				size, len(golden))
+		}
+		if hash := test.header.BlockHash(); hash.String() != test.hash {
+			t.Errorf("%s: got block hash %v, want %v", test.file, hash,
+				test.hash)
+		}
+
+		for _, pver := range pvers {
+			var buf bytes.Buffer
+			err := test.header.BtcEncode(&buf, pver)
+			if err != nil {
+				t.Errorf("%s: BtcEncode pver %d: %v", test.file, pver,
+					err)
+				continue
+			}
+			if !bytes.Equal(buf.Bytes(), golden) {
+				t.Errorf("%s: BtcEncode pver %d\n got: %x want: %x",
+					test.file, pver, buf.Bytes(), golden)
+			}
+
+			var bh BlockHeader
+			err = bh.BtcDecode(bytes.NewReader(golden), pver)
+			if err != nil {
+				t.Errorf("%s: BtcDecode pver %d: %v", test.file, pver,
+					err)
+				continue
+			}
+			if !reflect.DeepEqual(&bh, test.header) {
+				t.Errorf("%s: BtcDecode pver %d\n got: %s want: %s",
+					test.file, pver, spew.Sdump(&bh),
+					spew.Sdump(test.header))
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := test.header.Serialize(&buf); err != nil {
+			t.Errorf("%s: Serialize: %v", test.file, err)
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), golden) {
+			t.Errorf("%s: Serialize\n got: %x want: %x", test.file,
+				buf.Bytes(), golden)
+		}
+		var bh BlockHeader
+		if err := bh.Deserialize(bytes.NewReader(golden)); err != nil {
+			t.Errorf("%s: Deserialize: %v", test.file, err)
+			continue
+		}
+		if !reflect.DeepEqual(&bh, test.header) {
+			t.Errorf("%s: Deserialize\n got: %s want: %s", test.file,
+				spew.Sdump(&bh), spew.Sdump(test.header))
+		}
+	}
+}
+
+// TestBlockHeaderFormats ensures block headers are decoded with the format of
+// their version, so the headers of older versions keep decoding the same way
+// once a format is added for a new block version.
+func TestBlockHeaderFormats(t *testing.T) {
+	if blockHeaderFormats[0].minVersion != 0 {
+		t.Fatalf("first block header format starts at version %d",
+			blockHeaderFormats[0].minVersion)
+	}
+	for i := 1; i < len(blockHeaderFormats); i++ {
+		if blockHeaderFormats[i].minVersion <=
+			blockHeaderFormats[i-1].minVersion {
+
+			t.Fatalf("block header format #%d is out of order", i)
+		}
+	}
+
+	// Add a format for version 10 headers which appends an extra field to
+	// the fields of the current format.
+	const extVersion = 10
+	var extField uint32
+	saved := blockHeaderFormats
+	defer func() {
+		blockHeaderFormats = saved
+	}()
+	blockHeaderFormats = append(saved[:len(saved):len(saved)],
+		blockHeaderFormat{
+			minVersion: extVersion,
+			size:       MaxBlockHeaderPayload + 4,
+			read: func(r io.Reader, pver uint32, bh *BlockHeader) error {
+				err := readBlockHeaderV0(r, pver, bh)
+				if err != nil {
+					return err
+				}
+				return readElement(r, &extField)
+			},
+			write: func(w io.Writer, pver uint32, bh *BlockHeader) error {
+				err := writeBlockHeaderV0(w, pver, bh)
+				if err != nil {
+					return err
+				}
+				return writeElement(w, extField)
+			},
+		})
+
+	// The golden headers of the older versions are unaffected.
+	for _, test := range goldenBlockHeaders() {
+		golden := readGoldenFile(t, test.file)
+		var bh BlockHeader
+		err := bh.BtcDecode(bytes.NewReader(golden), ProtocolVersion)
+		if err != nil {
+			t.Errorf("%s: BtcDecode: %v", test.file, err)
+			continue
+		}
+		if !reflect.DeepEqual(&bh, test.header) {
+			t.Errorf("%s: BtcDecode\n got: %s want: %s", test.file,
+				spew.Sdump(&bh), spew.Sdump(test.header))
+		}
+	}
+
+	// Headers of the new version use the new format.
+	extHdr := *goldenBlockHeaders()[1].header
+	extHdr.Version = extVersion
+	extField = 0xdeadbeef
+	var buf bytes.Buffer
+	if err := extHdr.BtcEncode(&buf, ProtocolVersion); err != nil {
+		t.Fatalf("BtcEncode: %v", err)
+	}
+	if buf.Len() != extHdr.SerializeSize() {
+		t.Fatalf("got %d bytes, want %d", buf.Len(),
+			extHdr.SerializeSize())
+	}
+	extField = 0
+	var bh BlockHeader
+	if err := bh.BtcDecode(&buf, ProtocolVersion); err != nil {
+		t.Fatalf("BtcDecode: %v", err)
+	}
+	if !reflect.DeepEqual(bh, extHdr) || extField != 0xdeadbeef {
+		t.Fatalf("BtcDecode\n got: %s want: %s", spew.Sdump(&bh),
+			spew.Sdump(&extHdr))
+	}
+}
+
+// TestBlockHeaderSchnorr ensures a Schnorr signed block header verifies for the
+// signing key only, and not when the bytes after the signature are changed.
+func TestBlockHeaderSchnorr(t *testing.T) {
+	hash := mainNetGenesisHash
+	merkleHash := mainNetGenesisMerkleRoot
+	bh := NewBlockHeader(&hash, &merkleHash, 0x1d00ffff, 1)
+	privKey, pubKey := btcec.PrivKeyFromBytes(btcec.S256(), []byte{0x01})
+	_, otherKey := btcec.PrivKeyFromBytes(btcec.S256(), []byte{0x02})
+
+	copy(bh.ValidatingPubKey[:], pubKey.SerializeCompressed())
+	sig, err := privKey.SignSchnorr(bh.SigningHash())
+	if err != nil {
+		t.Fatalf("SignSchnorr: %v", err)
+	}
+	bh.SetSchnorrSignature(pubKey, sig)
+	if !bh.VerifySchnorr(pubKey) {
+		t.Fatalf("VerifySchnorr: signature does not verify")
+	}
+	if bh.VerifySchnorr(otherKey) {
+		t.Fatalf("VerifySchnorr: signature verifies for another key")
+	}
+	if bh.Verify(pubKey) {
+		t.Fatalf("Verify: schnorr signature verifies as ecdsa signature")
+	}
+
+	bh.Signature[len(bh.Signature)-1] = 0x01
+	if bh.VerifySchnorr(pubKey) {
+		t.Fatalf("VerifySchnorr: signature with trailing data verifies")
+	}
+}
@@ -0,0 +1,400 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/pyx-partners/dmgd/btcec"
+)
+
+// NonCanonicalKind identifies the kind of a non-canonical encoding found by
+// CheckCanonicalEncoding.
+type NonCanonicalKind int
+
+const (
+	// NonCanonicalVarInt identifies a variable length integer which is not
+	// encoded with the fewest possible bytes.
+	NonCanonicalVarInt NonCanonicalKind = iota
+
+	// NonCanonicalPush identifies a script data push which does not use
+	// the smallest possible opcode.
+	NonCanonicalPush
+
+	// NonCanonicalSignature identifies a signature in a signature script
+	// which is not strictly DER encoded with a low S value and a defined
+	// hash type.
+	NonCanonicalSignature
+)
+
+// Map of non-canonical kinds back to their constant names for pretty printing.
+var nonCanonicalKindStrings = map[NonCanonicalKind]string{
+	NonCanonicalVarInt:    "varint",
+	NonCanonicalPush:      "push",
+	NonCanonicalSignature: "signature",
+}
+
+// String returns the NonCanonicalKind in human-readable form.
+func (k NonCanonicalKind) String() string {
+	if s, ok := nonCanonicalKindStrings[k]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown NonCanonicalKind (%d)", int(k))
+}
+
+// NonCanonicalEncoding describes a non-canonical encoding found in a serialized
+// transaction or block.
+type NonCanonicalEncoding struct {
+	Kind NonCanonicalKind
+
+	// Offset is the offset of the encoding in the serialized transaction
+	// or block.
+	Offset int
+
+	// Tx is the index of the transaction holding the encoding in the
+	// block, which is always 0 for a transaction.
+	Tx int
+
+	// Field describes the field holding the encoding, such as
+	// "input 0 signature script".
+	Field string
+
+	Description string
+}
+
+// String returns the non-canonical encoding in human-readable form.
+func (e NonCanonicalEncoding) String() string {
+	return fmt.Sprintf("non-canonical %s at offset %d in transaction %d "+
+		"%s: %s", e.Kind, e.Offset, e.Tx, e.Field, e.Description)
+}
+
+// Signature hash types of signatures in signature scripts.  They are defined in
+// txscript, which depends on this package.
+const (
+	sigHashAll          = 0x1
+	sigHashSingle       = 0x3
+	sigHashAnyOneCanPay = 0x80
+)
+
+// halfOrder is used to tame ECDSA malleability when checking for low S values.
+var halfOrder = new(big.Int).Rsh(btcec.S256().N, 1)
+
+// canonicalChecker walks a serialized transaction or block while recording the
+// non-canonical encodings it holds.
+type canonicalChecker struct {
+	raw    []byte
+	r      *bytes.Reader
+	tx     int
+	issues []NonCanonicalEncoding
+}
+
+// offset returns the offset of the next byte to read.
+func (c *canonicalChecker) offset() int {
+	return len(c.raw) - c.r.Len()
+}
+
+// flag records a non-canonical encoding at the passed offset.
+func (c *canonicalChecker) flag(kind NonCanonicalKind, offset int, field, desc string) {
+	c.issues = append(c.issues, NonCanonicalEncoding{
+		Kind:        kind,
+		Offset:      offset,
+		Tx:          c.tx,
+		Field:       field,
+		Description: desc,
+	})
+}
+
+// read reads exactly len(b) bytes.
+func (c *canonicalChecker) read(b []byte) error {
+	_, err := io.ReadFull(c.r, b)
+	return err
+}
+
+// readVarInt reads a variable length integer, which is flagged instead of
+// rejected when it is not canonically encoded.
+func (c *canonicalChecker) readVarInt(field string) (uint64, error) {
+	offset := c.offset()
+	discriminant, err := c.r.ReadByte()
+	if err != nil {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	var rv, min uint64
+	switch discriminant {
+	case 0xff:
+		var b [8]byte
+		if err := c.read(b[:]); err != nil {
+			return 0, err
+		}
+		rv, min = binary.LittleEndian.Uint64(b[:]), 0x100000000
+	case 0xfe:
+		var b [4]byte
+		if err := c.read(b[:]); err != nil {
+			return 0, err
+		}
+		rv, min = uint64(binary.LittleEndian.Uint32(b[:])), 0x10000
+	case 0xfd:
+		var b [2]byte
+		if err := c.read(b[:]); err != nil {
+			return 0, err
+		}
+		rv, min = uint64(binary.LittleEndian.Uint16(b[:])), 0xfd
+	default:
+		return uint64(discriminant), nil
+	}
+
+	if rv < min {
+		c.flag(NonCanonicalVarInt, offset, field, fmt.Sprintf(
+			errNonCanonicalVarInt, rv, discriminant, min))
+	}
+	return rv, nil
+}
+
+// readScript reads a script prefixed by its length, and checks its data pushes
+// and, for signature scripts, the signatures it pushes.
+func (c *canonicalChecker) readScript(field string, isSigScript bool) ([]byte, error) {
+	count, err := c.readVarInt(field)
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(c.r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	offset := c.offset()
+	script := make([]byte, count)
+	if err := c.read(script); err != nil {
+		return nil, err
+	}
+	c.checkScript(script, offset, field, isSigScript)
+	return script, nil
+}
+
+// checkScript flags the data pushes of the passed script at the passed offset
+// which do not use the smallest possible opcode, and, for signature scripts,
+// the pushed signatures which are not canonical.  The rest of a script which
+// does not parse is ignored, since such scripts are nonstandard rather than
+// non-canonical.
+func (c *canonicalChecker) checkScript(script []byte, offset int, field string, isSigScript bool) {
+	for i := 0; i < len(script); {
+		opOffset := offset + i
+		opcode := script[i]
+		i++
+
+		// Only the opcodes from OP_DATA_1 to OP_PUSHDATA4 push data.
+		var dataLen, lenSize int
+		switch {
+		case opcode >= 0x01 && opcode <= 0x4b:
+			dataLen = int(opcode)
+		case opcode == 0x4c && i+1 <= len(script):
+			dataLen, lenSize = int(script[i]), 1
+		case opcode == 0x4d && i+2 <= len(script):
+			dataLen = int(binary.LittleEndian.Uint16(script[i:]))
+			lenSize = 2
+		case opcode == 0x4e && i+4 <= len(script):
+			dataLen = int(binary.LittleEndian.Uint32(script[i:]))
+			lenSize = 4
+		case opcode >= 0x4c && opcode <= 0x4e:
+			return
+		default:
+			continue
+		}
+		i += lenSize
+		if dataLen < 0 || dataLen > len(script)-i {
+			return
+		}
+		data := script[i : i+dataLen]
+		i += dataLen
+
+		switch {
+		case opcode <= 0x4b && dataLen == 1 && data[0] <= 16:
+			c.flag(NonCanonicalPush, opOffset, field, fmt.Sprintf(
+				"push of small integer %d must use OP_%d",
+				data[0], data[0]))
+		case opcode == 0x4c && dataLen <= 0x4b:
+			c.flag(NonCanonicalPush, opOffset, field, fmt.Sprintf(
+				"OP_PUSHDATA1 push of %d bytes must use "+
+					"OP_DATA_%d", dataLen, dataLen))
+		case opcode == 0x4d && dataLen <= 0xff:
+			c.flag(NonCanonicalPush, opOffset, field, fmt.Sprintf(
+				"OP_PUSHDATA2 push of %d bytes must use a "+
+					"smaller opcode", dataLen))
+		case opcode == 0x4e && dataLen <= 0xffff:
+			c.flag(NonCanonicalPush, opOffset, field, fmt.Sprintf(
+				"OP_PUSHDATA4 push of %d bytes must use a "+
+					"smaller opcode", dataLen))
+		}
+
+		// Pushes starting with the ASN.1 sequence identifier of a DER
+		// encoded signature are taken for signatures.
+		if isSigScript && dataLen > 1 && data[0] == 0x30 {
+			if err := checkSignatureEncoding(data); err != nil {
+				c.flag(NonCanonicalSignature, opOffset, field,
+					err.Error())
+			}
+		}
+	}
+}
+
+// checkSignatureEncoding returns an error when the passed signature, followed
+// by its hash type, is not strictly DER encoded with a low S value and a
+// defined hash type.
+func checkSignatureEncoding(sigWithHashType []byte) error {
+	hashType := sigWithHashType[len(sigWithHashType)-1]
+	sigHashType := hashType &^ sigHashAnyOneCanPay
+	if sigHashType < sigHashAll || sigHashType > sigHashSingle {
+		return fmt.Errorf("invalid hash type 0x%x", hashType)
+	}
+
+	sig := sigWithHashType[:len(sigWithHashType)-1]
+	signature, err := btcec.ParseDERSignature(sig, btcec.S256())
+	if err != nil {
+		return err
+	}
+	if signature.S.Cmp(halfOrder) > 0 {
+		return fmt.Errorf("signature S value is higher than half " +
+			"the order of the curve")
+	}
+	return nil
+}
+
+// readTx reads a transaction.
+func (c *canonicalChecker) readTx() (*MsgTx, error) {
+	var b [8]byte
+	if err := c.read(b[:4]); err != nil {
+		return nil, err
+	}
+	msgTx := NewMsgTx(int32(binary.LittleEndian.Uint32(b[:4])))
+
+	count, err := c.readVarInt("input count")
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(maxTxInPerMessage) {
+		return nil, fmt.Errorf("too many input transactions to fit "+
+			"into max message size [count %d, max %d]", count,
+			maxTxInPerMessage)
+	}
+	for i := uint64(0); i < count; i++ {
+		txIn := &TxIn{}
+		if err := c.read(txIn.PreviousOutPoint.Hash[:]); err != nil {
+			return nil, err
+		}
+		if err := c.read(b[:4]); err != nil {
+			return nil, err
+		}
+		txIn.PreviousOutPoint.Index = binary.LittleEndian.Uint32(b[:4])
+		field := fmt.Sprintf("input %d signature script", i)
+		txIn.SignatureScript, err = c.readScript(field, true)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.read(b[:4]); err != nil {
+			return nil, err
+		}
+		txIn.Sequence = binary.LittleEndian.Uint32(b[:4])
+		msgTx.AddTxIn(txIn)
+	}
+
+	count, err = c.readVarInt("output count")
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(maxTxOutPerMessage) {
+		return nil, fmt.Errorf("too many output transactions to fit "+
+			"into max message size [count %d, max %d]", count,
+			maxTxOutPerMessage)
+	}
+	for i := uint64(0); i < count; i++ {
+		if err := c.read(b[:]); err != nil {
+			return nil, err
+		}
+		value := int64(binary.LittleEndian.Uint64(b[:]))
+		field := fmt.Sprintf("output %d public key script", i)
+		pkScript, err := c.readScript(field, false)
+		if err != nil {
+			return nil, err
+		}
+		msgTx.AddTxOut(NewTxOut(value, pkScript))
+	}
+
+	if err := c.read(b[:4]); err != nil {
+		return nil, err
+	}
+	msgTx.LockTime = binary.LittleEndian.Uint32(b[:4])
+	return msgTx, nil
+}
+
+// readBlock reads a block.
+func (c *canonicalChecker) readBlock(msg *MsgBlock) error {
+	var header BlockHeader
+	if err := readBlockHeader(c.r, 0, &header); err != nil {
+		return err
+	}
+	count, err := c.readVarInt("transaction count")
+	if err != nil {
+		return err
+	}
+	if count > maxTxPerBlock {
+		return fmt.Errorf("too many transactions to fit into a block "+
+			"[count %d, max %d]", count, maxTxPerBlock)
+	}
+
+	msg.Header = header
+	msg.Transactions = make([]*MsgTx, 0, count)
+	for i := uint64(0); i < count; i++ {
+		c.tx = int(i)
+		msgTx, err := c.readTx()
+		if err != nil {
+			return err
+		}
+		msg.Transactions = append(msg.Transactions, msgTx)
+	}
+	return nil
+}
+
+// CheckCanonicalEncoding decodes the passed serialized transaction or block
+// into msg, which must be a *MsgTx or a *MsgBlock, and returns the
+// non-canonical encodings it holds.  Those are the variable length integers not
+// encoded with the fewest possible bytes, which BtcDecode rejects, and the
+// script data pushes and signatures which do not have the canonical encoding
+// standard transactions are required to have, which BtcDecode accepts since it
+// does not interpret scripts.  Any of them lets a third party change the
+// serialization of a transaction without invalidating it.
+//
+// An error is returned when the serialization can not be decoded at all, or is
+// followed by extra bytes.
+func CheckCanonicalEncoding(msg Message, raw []byte) ([]NonCanonicalEncoding, error) {
+	c := &canonicalChecker{raw: raw, r: bytes.NewReader(raw)}
+	var err error
+	switch msg := msg.(type) {
+	case *MsgTx:
+		var msgTx *MsgTx
+		msgTx, err = c.readTx()
+		if err == nil {
+			*msg = *msgTx
+		}
+	case *MsgBlock:
+		err = c.readBlock(msg)
+	default:
+		str := fmt.Sprintf("unsupported message type %T", msg)
+		return nil, messageError("CheckCanonicalEncoding", str)
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, messageError("CheckCanonicalEncoding", err.Error())
+	}
+	if c.r.Len() != 0 {
+		str := fmt.Sprintf("%d extra bytes after the serialization",
+			c.r.Len())
+		return nil, messageError("CheckCanonicalEncoding", str)
+	}
+	return c.issues, nil
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+)
+
+// derSignature returns the DER encoding of the signature with the passed R and
+// S values followed by the passed hash type, without normalizing S.
+func derSignature(r, s *big.Int, hashType byte) []byte {
+	encodeInt := func(v *big.Int) []byte {
+		b := v.Bytes()
+		if b[0]&0x80 != 0 {
+			b = append([]byte{0x00}, b...)
+		}
+		return append([]byte{0x02, byte(len(b))}, b...)
+	}
+	rb, sb := encodeInt(r), encodeInt(s)
+	sig := append([]byte{0x30, byte(len(rb) + len(sb))}, rb...)
+	sig = append(sig, sb...)
+	return append(sig, hashType)
+}
+
+// TestCheckCanonicalEncoding ensures the non-canonical varints, script pushes
+// and signatures of serialized transactions and blocks are reported, and that
+// malformed serializations are rejected.
+func TestCheckCanonicalEncoding(t *testing.T) {
+	privKey, _ := btcec.PrivKeyFromBytes(btcec.S256(), []byte{0x01})
+	signature, err := privKey.Sign(chainhash.DoubleHashB([]byte("msg")))
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	lowS := derSignature(signature.R, signature.S, 0x01)
+	highS := derSignature(signature.R,
+		new(big.Int).Sub(btcec.S256().N, signature.S), 0x01)
+	badHashType := derSignature(signature.R, signature.S, 0x04)
+
+	// txWithSigScript returns the serialization of multiTx with the
+	// passed signature script.
+	txWithSigScript := func(sigScript []byte) []byte {
+		tx := multiTx.Copy()
+		tx.TxIn[0].SignatureScript = sigScript
+		var buf bytes.Buffer
+		if err := tx.Serialize(&buf); err != nil {
+			t.Fatalf("Serialize: %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	// The input count of multiTx is its fifth byte.
+	nonCanonicalCount := append([]byte{}, multiTxEncoded[:4]...)
+	nonCanonicalCount = append(nonCanonicalCount, 0xfd, 0x01, 0x00)
+	nonCanonicalCount = append(nonCanonicalCount, multiTxEncoded[5:]...)
+
+	// The signature script of multiTx starts at offset 42, after its
+	// length.
+	tests := []struct {
+		name   string
+		raw    []byte
+		kinds  []NonCanonicalKind
+		offset int
+		field  string
+	}{
+		{
+			name: "canonical transaction",
+			raw:  multiTxEncoded,
+		},
+		{
+			name:   "non-canonical input count",
+			raw:    nonCanonicalCount,
+			kinds:  []NonCanonicalKind{NonCanonicalVarInt},
+			offset: 4,
+			field:  "input count",
+		},
+		{
+			name:   "small integer pushed with OP_DATA_1",
+			raw:    txWithSigScript([]byte{0x01, 0x05}),
+			kinds:  []NonCanonicalKind{NonCanonicalPush},
+			offset: 42,
+			field:  "input 0 signature script",
+		},
+		{
+			name:   "short push with OP_PUSHDATA1",
+			raw:    txWithSigScript([]byte{0x51, 0x4c, 0x02, 0x20, 0x20}),
+			kinds:  []NonCanonicalKind{NonCanonicalPush},
+			offset: 43,
+			field:  "input 0 signature script",
+		},
+		{
+			name: "low S signature",
+			raw: txWithSigScript(append([]byte{byte(len(lowS))},
+				lowS...)),
+		},
+		{
+			name: "high S signature",
+			raw: txWithSigScript(append([]byte{byte(len(highS))},
+				highS...)),
+			kinds:  []NonCanonicalKind{NonCanonicalSignature},
+			offset: 42,
+			field:  "input 0 signature script",
+		},
+		{
+			name: "undefined hash type",
+			raw: txWithSigScript(append([]byte{byte(len(badHashType))},
+				badHashType...)),
+			kinds:  []NonCanonicalKind{NonCanonicalSignature},
+			offset: 42,
+			field:  "input 0 signature script",
+		},
+		{
+			name: "signature pushed with OP_PUSHDATA1",
+			raw: txWithSigScript(append([]byte{0x4c, byte(len(lowS))},
+				lowS...)),
+			kinds:  []NonCanonicalKind{NonCanonicalPush},
+			offset: 42,
+			field:  "input 0 signature script",
+		},
+	}
+
+	for _, test := range tests {
+		var msgTx MsgTx
+		issues, err := CheckCanonicalEncoding(&msgTx, test.raw)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		var kinds []NonCanonicalKind
+		for _, issue := range issues {
+			kinds = append(kinds, issue.Kind)
+		}
+		if !reflect.DeepEqual(kinds, test.kinds) {
+			t.Errorf("%s: got non-canonical encodings %v, want %v",
+				test.name, issues, test.kinds)
+			continue
+		}
+		if len(issues) != 0 && (issues[0].Offset != test.offset ||
+			issues[0].Field != test.field) {
+
+			t.Errorf("%s: got non-canonical encoding at offset %d "+
+				"in %q, want offset %d in %q", test.name,
+				issues[0].Offset, issues[0].Field, test.offset,
+				test.field)
+		}
+
+		// The decoded canonical transactions serialize to the same
+		// bytes.
+		if len(issues) == 0 &&
+			msgTx.TxHashWithSig() != chainhash.DoubleHashH(test.raw) {
+
+			t.Errorf("%s: decoded transaction does not match the "+
+				"serialization", test.name)
+		}
+	}
+
+	// A non-canonical transaction count of a block is reported.
+	block := MsgBlock{Header: blockOne.Header}
+	block.AddTransaction(multiTx)
+	var buf bytes.Buffer
+	if err := block.Serialize(&buf); err != nil {
+		t.Fatalf("Serialize: %v", err)
+	}
+	serialized := buf.Bytes()
+	headerLen := block.Header.SerializeSize()
+	raw := append([]byte{}, serialized[:headerLen]...)
+	raw = append(raw, 0xfe, 0x01, 0x00, 0x00, 0x00)
+	raw = append(raw, serialized[headerLen+1:]...)
+	var decoded MsgBlock
+	issues, err := CheckCanonicalEncoding(&decoded, raw)
+	if err != nil {
+		t.Fatalf("CheckCanonicalEncoding: %v", err)
+	}
+	if len(issues) != 1 || issues[0].Kind != NonCanonicalVarInt ||
+		issues[0].Offset != headerLen {
+
+		t.Fatalf("got non-canonical encodings %v, want a varint at "+
+			"offset %d", issues, headerLen)
+	}
+	if decoded.BlockHash() != block.BlockHash() ||
+		len(decoded.Transactions) != 1 {
+
+		t.Fatalf("decoded block does not match the serialization")
+	}
+
+	// Truncated serializations, extra bytes and unsupported messages are
+	// rejected.
+	var msgTx MsgTx
+	_, err = CheckCanonicalEncoding(&msgTx, multiTxEncoded[:50])
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("truncated transaction: got error %v, want a "+
+			"MessageError", err)
+	}
+	_, err = CheckCanonicalEncoding(&msgTx, append(multiTxEncoded, 0x00))
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("extra bytes: got error %v, want a MessageError", err)
+	}
+	_, err = CheckCanonicalEncoding(&MsgPing{}, multiTxEncoded)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("unsupported message: got error %v, want a "+
+			"MessageError", err)
+	}
+}
@@ -79,6 +79,14 @@ latest protocol version this package supports and is typically the value to use
 for all outbound connections before a potentially lower protocol version is
 negotiated.
 
+Block Header Formats
+
+The serialization of a block header is selected by the block version it starts
+with, both on the wire and in storage.  A change to the block header is made by
+adding a format for a new block version, so the headers of older versions keep
+decoding as before and both can coexist on the same chain.  The golden files in
+the testdata directory hold the serialization of a header of every format.
+
 Bitcoin Network
 
 The bitcoin network is a magic number which is used to identify the start of a
@@ -24,6 +24,8 @@ func TestInvTypeStringer(t *testing.T) {
 		{InvTypeError, "ERROR"},
 		{InvTypeTx, "MSG_TX"},
 		{InvTypeBlock, "MSG_BLOCK"},
+		{InvTypeFilteredBlock, "MSG_FILTERED_BLOCK"},
+		{InvTypeAdminTx, "MSG_ADMIN_TX"},
 		{0xffffffff, "Unknown InvType (4294967295)"},
 	}
 
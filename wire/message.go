@@ -53,6 +53,7 @@ const (
 	CmdReject      = "reject"
 	CmdSendHeaders = "sendheaders"
 	CmdFeeFilter   = "feefilter"
+	CmdAnnounce    = "announce"
 )
 
 // Message is an interface that describes a bitcoin message.  A type that
@@ -140,6 +141,9 @@ func makeEmptyMessage(command string) (Message, error) {
 	case CmdFeeFilter:
 		msg = &MsgFeeFilter{}
 
+	case CmdAnnounce:
+		msg = &MsgAnnounce{}
+
 	default:
 		return nil, fmt.Errorf("unhandled command [%s]", command)
 	}
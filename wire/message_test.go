@@ -71,6 +71,8 @@ func TestMessage(t *testing.T) {
 	bh := NewBlockHeader(&chainhash.Hash{}, &chainhash.Hash{}, 0, 0)
 	msgMerkleBlock := NewMsgMerkleBlock(bh)
 	msgReject := NewMsgReject("block", RejectDuplicate, "duplicate block")
+	msgAnnounce := NewMsgAnnounce(AnnounceNotice, time.Unix(0x495fab29, 0),
+		time.Unix(0x495fab29+3600, 0), "notice")
 
 	tests := []struct {
 		in     Message    // Value to encode
@@ -100,6 +102,7 @@ func TestMessage(t *testing.T) {
 		{msgFilterLoad, msgFilterLoad, pver, MainNet, 35},
 		{msgMerkleBlock, msgMerkleBlock, pver, MainNet, 239},
 		{msgReject, msgReject, pver, MainNet, 79},
+		{msgAnnounce, msgAnnounce, pver, MainNet, 49},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -0,0 +1,294 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcec"
+	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
+)
+
+const (
+	// MaxAnnounceMessageLen is the maximum number of bytes of the text of
+	// an announcement.
+	MaxAnnounceMessageLen = 4096
+
+	// MaxAnnounceSignatures is the maximum number of signatures of an
+	// announcement.
+	MaxAnnounceSignatures = 16
+
+	// maxAnnounceSignatureLen is the maximum number of bytes of a DER
+	// encoded signature of an announcement.
+	maxAnnounceSignatureLen = 72
+)
+
+// AnnounceCategory identifies the purpose of a governance announcement.
+type AnnounceCategory uint8
+
+// These constants define the supported announcement categories.
+const (
+	// AnnounceNotice is a general notice to the operators of nodes.
+	AnnounceNotice AnnounceCategory = iota
+
+	// AnnounceUpgrade announces a planned software or consensus upgrade.
+	AnnounceUpgrade
+
+	// AnnounceKeyCeremony announces a key ceremony, such as the rotation
+	// of admin keys.
+	AnnounceKeyCeremony
+
+	// AnnounceEmergency carries instructions operators are expected to
+	// act on right away.
+	AnnounceEmergency
+)
+
+// Map of announcement categories back to their names for pretty printing.
+var announceCategoryStrings = map[AnnounceCategory]string{
+	AnnounceNotice:      "notice",
+	AnnounceUpgrade:     "upgrade",
+	AnnounceKeyCeremony: "keyceremony",
+	AnnounceEmergency:   "emergency",
+}
+
+// String returns the AnnounceCategory in human-readable form.
+func (c AnnounceCategory) String() string {
+	if s, ok := announceCategoryStrings[c]; ok {
+		return s
+	}
+
+	return fmt.Sprintf("Unknown AnnounceCategory (%d)", uint8(c))
+}
+
+// AnnounceSignature is a signature of an announcement along with the public
+// key which made it.
+type AnnounceSignature struct {
+	PubKey    [btcec.PubKeyBytesLenCompressed]byte
+	Signature []byte
+}
+
+// MsgAnnounce implements the Message interface and represents a signed
+// governance announcement, used to notify the operators of all nodes of planned
+// upgrades, key ceremonies or emergency instructions.  The signatures cover all
+// fields but the signatures themselves, so nodes can verify the announcement
+// was made by the holders of admin keys before relaying it.
+//
+// This message was not added until protocol version AnnounceVersion.
+type MsgAnnounce struct {
+	Category   AnnounceCategory
+	Timestamp  time.Time
+	Expiration time.Time
+	Message    string
+	Signatures []AnnounceSignature
+}
+
+// AnnounceHash returns the hash of the announcement without its signatures,
+// which identifies the announcement and is the hash its signatures sign.
+func (msg *MsgAnnounce) AnnounceHash() chainhash.Hash {
+	var buf bytes.Buffer
+	// Writing to a bytes.Buffer can not fail.
+	msg.writeContent(&buf, ProtocolVersion)
+	return chainhash.DoubleHashH(buf.Bytes())
+}
+
+// AddSignature signs the announcement with the passed key and adds the
+// signature to it.
+func (msg *MsgAnnounce) AddSignature(key *btcec.PrivateKey) error {
+	if len(msg.Signatures)+1 > MaxAnnounceSignatures {
+		str := fmt.Sprintf("too many signatures for message "+
+			"[max %v]", MaxAnnounceSignatures)
+		return messageError("MsgAnnounce.AddSignature", str)
+	}
+
+	hash := msg.AnnounceHash()
+	sig, err := key.Sign(hash[:])
+	if err != nil {
+		return err
+	}
+	var signature AnnounceSignature
+	copy(signature.PubKey[:], key.PubKey().SerializeCompressed())
+	signature.Signature = sig.Serialize()
+	msg.Signatures = append(msg.Signatures, signature)
+	return nil
+}
+
+// SigningKeys returns the public keys which signed the announcement, in the
+// order of the signatures.  An error is returned when a signature is invalid
+// or a key signed more than once.
+func (msg *MsgAnnounce) SigningKeys() ([]*btcec.PublicKey, error) {
+	hash := msg.AnnounceHash()
+	keys := make([]*btcec.PublicKey, 0, len(msg.Signatures))
+	seen := make(map[[btcec.PubKeyBytesLenCompressed]byte]struct{},
+		len(msg.Signatures))
+	for i, signature := range msg.Signatures {
+		if _, ok := seen[signature.PubKey]; ok {
+			return nil, fmt.Errorf("signature %d is by a key which "+
+				"already signed", i)
+		}
+		seen[signature.PubKey] = struct{}{}
+
+		pubKey, err := btcec.ParsePubKey(signature.PubKey[:],
+			btcec.S256())
+		if err != nil {
+			return nil, fmt.Errorf("signature %d has an invalid "+
+				"public key: %v", i, err)
+		}
+		sig, err := btcec.ParseDERSignature(signature.Signature,
+			btcec.S256())
+		if err != nil {
+			return nil, fmt.Errorf("signature %d is malformed: %v",
+				i, err)
+		}
+		if !sig.Verify(hash[:], pubKey) {
+			return nil, fmt.Errorf("signature %d is invalid", i)
+		}
+		keys = append(keys, pubKey)
+	}
+	return keys, nil
+}
+
+// writeContent writes the fields of the announcement covered by its signatures
+// to w.
+func (msg *MsgAnnounce) writeContent(w io.Writer, pver uint32) error {
+	err := binarySerializer.PutUint8(w, uint8(msg.Category))
+	if err != nil {
+		return err
+	}
+	err = writeElements(w, msg.Timestamp.Unix(), msg.Expiration.Unix())
+	if err != nil {
+		return err
+	}
+	return WriteVarString(w, pver, msg.Message)
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgAnnounce) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < AnnounceVersion {
+		str := fmt.Sprintf("announce message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgAnnounce.BtcDecode", str)
+	}
+
+	category, err := binarySerializer.Uint8(r)
+	if err != nil {
+		return err
+	}
+	msg.Category = AnnounceCategory(category)
+	err = readElements(r, (*int64Time)(&msg.Timestamp),
+		(*int64Time)(&msg.Expiration))
+	if err != nil {
+		return err
+	}
+	message, err := ReadVarBytes(r, pver, MaxAnnounceMessageLen,
+		"announcement message")
+	if err != nil {
+		return err
+	}
+	msg.Message = string(message)
+
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > MaxAnnounceSignatures {
+		str := fmt.Sprintf("too many signatures for message "+
+			"[count %v, max %v]", count, MaxAnnounceSignatures)
+		return messageError("MsgAnnounce.BtcDecode", str)
+	}
+	msg.Signatures = nil
+	if count > 0 {
+		msg.Signatures = make([]AnnounceSignature, count)
+	}
+	for i := range msg.Signatures {
+		signature := &msg.Signatures[i]
+		_, err := io.ReadFull(r, signature.PubKey[:])
+		if err != nil {
+			return err
+		}
+		signature.Signature, err = ReadVarBytes(r, pver,
+			maxAnnounceSignatureLen, "announcement signature")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgAnnounce) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < AnnounceVersion {
+		str := fmt.Sprintf("announce message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgAnnounce.BtcEncode", str)
+	}
+
+	if len(msg.Message) > MaxAnnounceMessageLen {
+		str := fmt.Sprintf("announcement message is too long "+
+			"[len %v, max %v]", len(msg.Message), MaxAnnounceMessageLen)
+		return messageError("MsgAnnounce.BtcEncode", str)
+	}
+	if len(msg.Signatures) > MaxAnnounceSignatures {
+		str := fmt.Sprintf("too many signatures for message "+
+			"[count %v, max %v]", len(msg.Signatures),
+			MaxAnnounceSignatures)
+		return messageError("MsgAnnounce.BtcEncode", str)
+	}
+
+	err := msg.writeContent(w, pver)
+	if err != nil {
+		return err
+	}
+	err = WriteVarInt(w, pver, uint64(len(msg.Signatures)))
+	if err != nil {
+		return err
+	}
+	for _, signature := range msg.Signatures {
+		_, err := w.Write(signature.PubKey[:])
+		if err != nil {
+			return err
+		}
+		err = WriteVarBytes(w, pver, signature.Signature)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgAnnounce) Command() string {
+	return CmdAnnounce
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgAnnounce) MaxPayloadLength(pver uint32) uint32 {
+	// Category 1 byte + timestamp 8 bytes + expiration 8 bytes + message
+	// length and text + signature count + signatures.
+	return 17 + MaxVarIntPayload + MaxAnnounceMessageLen +
+		MaxVarIntPayload + MaxAnnounceSignatures*
+		(btcec.PubKeyBytesLenCompressed+1+maxAnnounceSignatureLen)
+}
+
+// NewMsgAnnounce returns a new unsigned announce message that conforms to the
+// Message interface.  See MsgAnnounce for details.
+func NewMsgAnnounce(category AnnounceCategory, timestamp, expiration time.Time,
+	message string) *MsgAnnounce {
+
+	// Limit the times to one second precision since the protocol doesn't
+	// support better.
+	return &MsgAnnounce{
+		Category:   category,
+		Timestamp:  time.Unix(timestamp.Unix(), 0),
+		Expiration: time.Unix(expiration.Unix(), 0),
+		Message:    message,
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pyx-partners/dmgd/btcec"
+)
+
+// TestAnnounce tests the MsgAnnounce API, encoding and decoding, and the
+// verification of its signatures.
+func TestAnnounce(t *testing.T) {
+	key1, _ := btcec.PrivKeyFromBytes(btcec.S256(), []byte{0x01})
+	key2, _ := btcec.PrivKeyFromBytes(btcec.S256(), []byte{0x02})
+	timestamp := time.Unix(0x495fab29, 0)
+	msg := NewMsgAnnounce(AnnounceUpgrade, timestamp,
+		timestamp.Add(24*time.Hour), "upgrade to 0.2 before block 1000")
+
+	// Ensure the command is expected value.
+	if cmd := msg.Command(); cmd != "announce" {
+		t.Errorf("NewMsgAnnounce: wrong command - got %v want %v",
+			cmd, "announce")
+	}
+	if s := msg.Category.String(); s != "upgrade" {
+		t.Errorf("String: got category %q, want %q", s, "upgrade")
+	}
+
+	// Signatures do not change the hash of the announcement.
+	hash := msg.AnnounceHash()
+	for _, key := range []*btcec.PrivateKey{key1, key2} {
+		if err := msg.AddSignature(key); err != nil {
+			t.Fatalf("AddSignature: %v", err)
+		}
+	}
+	if msg.AnnounceHash() != hash {
+		t.Fatalf("AnnounceHash: signing changed the hash")
+	}
+	keys, err := msg.SigningKeys()
+	if err != nil {
+		t.Fatalf("SigningKeys: %v", err)
+	}
+	if len(keys) != 2 || !keys[0].IsEqual(key1.PubKey()) ||
+		!keys[1].IsEqual(key2.PubKey()) {
+
+		t.Fatalf("SigningKeys: got %v, want the keys of both signatures",
+			keys)
+	}
+
+	// Round trip the signed announcement.
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, AnnounceVersion); err != nil {
+		t.Fatalf("BtcEncode: %v", err)
+	}
+	if uint32(buf.Len()) > msg.MaxPayloadLength(AnnounceVersion) {
+		t.Fatalf("BtcEncode: encoded %d bytes, more than the max payload "+
+			"length %d", buf.Len(), msg.MaxPayloadLength(AnnounceVersion))
+	}
+	var decoded MsgAnnounce
+	if err := decoded.BtcDecode(&buf, AnnounceVersion); err != nil {
+		t.Fatalf("BtcDecode: %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, msg) {
+		t.Fatalf("BtcDecode: got %v, want %v", decoded, msg)
+	}
+
+	// Altered announcements and repeated signatures are rejected.
+	tests := []struct {
+		name  string
+		alter func(msg *MsgAnnounce)
+	}{
+		{
+			name:  "altered message",
+			alter: func(msg *MsgAnnounce) { msg.Message += "0" },
+		},
+		{
+			name: "altered expiration",
+			alter: func(msg *MsgAnnounce) {
+				msg.Expiration = msg.Expiration.Add(time.Second)
+			},
+		},
+		{
+			name: "repeated signature",
+			alter: func(msg *MsgAnnounce) {
+				msg.Signatures[1] = msg.Signatures[0]
+			},
+		},
+		{
+			name: "malformed signature",
+			alter: func(msg *MsgAnnounce) {
+				msg.Signatures[1].Signature = []byte{0x30}
+			},
+		},
+	}
+	for _, test := range tests {
+		altered := decoded
+		altered.Signatures = append([]AnnounceSignature{},
+			decoded.Signatures...)
+		test.alter(&altered)
+		if _, err := altered.SigningKeys(); err == nil {
+			t.Errorf("%s: SigningKeys succeeded", test.name)
+		}
+	}
+
+	// Older protocol versions and oversized announcements are rejected.
+	buf.Reset()
+	err = msg.BtcEncode(&buf, AdminTxInvVersion)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcEncode: got error %v for an old protocol version, "+
+			"want a MessageError", err)
+	}
+	err = decoded.BtcDecode(bytes.NewReader(nil), AdminTxInvVersion)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcDecode: got error %v for an old protocol version, "+
+			"want a MessageError", err)
+	}
+	long := NewMsgAnnounce(AnnounceNotice, timestamp, timestamp,
+		strings.Repeat("a", MaxAnnounceMessageLen+1))
+	err = long.BtcEncode(&buf, AnnounceVersion)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcEncode: got error %v for a long message, want a "+
+			"MessageError", err)
+	}
+	buf.Reset()
+	long.Message = long.Message[1:]
+	if err := long.BtcEncode(&buf, AnnounceVersion); err != nil {
+		t.Fatalf("BtcEncode: %v", err)
+	}
+	// The length of the message, which follows the category and the
+	// times, is raised above the maximum.
+	encoded := buf.Bytes()
+	encoded[17] = 0xfd
+	encoded[18]++
+	err = decoded.BtcDecode(bytes.NewReader(encoded), AnnounceVersion)
+	if _, ok := err.(*MessageError); !ok {
+		t.Errorf("BtcDecode: got error %v for a long message, want a "+
+			"MessageError", err)
+	}
+}
@@ -8,7 +8,6 @@ package wire
 
 import (
 	"bytes"
-	"fmt"
 	"io"
 
 	"github.com/pyx-partners/dmgd/chaincfg/chainhash"
@@ -63,33 +62,19 @@ func (msg *MsgBlock) ClearTransactions() {
 // See Deserialize for decoding blocks stored to disk, such as in a database, as
 // opposed to decoding blocks from the wire.
 func (msg *MsgBlock) BtcDecode(r io.Reader, pver uint32) error {
-	err := readBlockHeader(r, pver, &msg.Header)
+	d, err := NewBlockDecoder(r, pver)
 	if err != nil {
 		return err
 	}
+	msg.Header = *d.Header()
 
-	txCount, err := ReadVarInt(r, pver)
-	if err != nil {
-		return err
-	}
-
-	// Prevent more transactions than could possibly fit into a block.
-	// It would be possible to cause memory exhaustion and panics without
-	// a sane upper bound on this count.
-	if txCount > maxTxPerBlock {
-		str := fmt.Sprintf("too many transactions to fit into a block "+
-			"[count %d, max %d]", txCount, maxTxPerBlock)
-		return messageError("MsgBlock.BtcDecode", str)
-	}
-
-	msg.Transactions = make([]*MsgTx, 0, txCount)
-	for i := uint64(0); i < txCount; i++ {
-		tx := MsgTx{}
-		err := tx.BtcDecode(r, pver)
+	msg.Transactions = make([]*MsgTx, 0, d.TxCount())
+	for i := uint64(0); i < d.TxCount(); i++ {
+		tx, err := d.decodeTx()
 		if err != nil {
 			return err
 		}
-		msg.Transactions = append(msg.Transactions, &tx)
+		msg.Transactions = append(msg.Transactions, tx)
 	}
 
 	return nil
@@ -121,37 +106,23 @@ func (msg *MsgBlock) DeserializeTxLoc(r *bytes.Buffer) ([]TxLoc, error) {
 	// At the current time, there is no difference between the wire encoding
 	// at protocol version 0 and the stable long-term storage format.  As
 	// a result, make use of existing wire protocol functions.
-	err := readBlockHeader(r, 0, &msg.Header)
+	d, err := NewBlockDecoder(r, 0)
 	if err != nil {
 		return nil, err
 	}
-
-	txCount, err := ReadVarInt(r, 0)
-	if err != nil {
-		return nil, err
-	}
-
-	// Prevent more transactions than could possibly fit into a block.
-	// It would be possible to cause memory exhaustion and panics without
-	// a sane upper bound on this count.
-	if txCount > maxTxPerBlock {
-		str := fmt.Sprintf("too many transactions to fit into a block "+
-			"[count %d, max %d]", txCount, maxTxPerBlock)
-		return nil, messageError("MsgBlock.DeserializeTxLoc", str)
-	}
+	msg.Header = *d.Header()
 
 	// Deserialize each transaction while keeping track of its location
 	// within the byte stream.
-	msg.Transactions = make([]*MsgTx, 0, txCount)
-	txLocs := make([]TxLoc, txCount)
-	for i := uint64(0); i < txCount; i++ {
+	msg.Transactions = make([]*MsgTx, 0, d.TxCount())
+	txLocs := make([]TxLoc, d.TxCount())
+	for i := range txLocs {
 		txLocs[i].TxStart = fullLen - r.Len()
-		tx := MsgTx{}
-		err := tx.Deserialize(r)
+		tx, err := d.decodeTx()
 		if err != nil {
 			return nil, err
 		}
-		msg.Transactions = append(msg.Transactions, &tx)
+		msg.Transactions = append(msg.Transactions, tx)
 		txLocs[i].TxLen = (fullLen - r.Len()) - txLocs[i].TxStart
 	}
 
@@ -204,7 +175,8 @@ func (msg *MsgBlock) Serialize(w io.Writer) error {
 func (msg *MsgBlock) SerializeSize() int {
 	// Block header bytes + Serialized varint size for the number of
 	// transactions.
-	n := blockHeaderLen + VarIntSerializeSize(uint64(len(msg.Transactions)))
+	n := msg.Header.SerializeSize() +
+		VarIntSerializeSize(uint64(len(msg.Transactions)))
 
 	for _, tx := range msg.Transactions {
 		n += tx.SerializeSize()
@@ -354,6 +354,13 @@ func (msg *MsgTx) Copy() *MsgTx {
 // See Deserialize for decoding transactions stored to disk, such as in a
 // database, as opposed to decoding transactions from the wire.
 func (msg *MsgTx) BtcDecode(r io.Reader, pver uint32) error {
+	return msg.decode(r, pver, nil)
+}
+
+// decode decodes r using the bitcoin protocol encoding into the receiver like
+// BtcDecode, taking the inputs, outputs and scripts of the transaction from
+// the passed allocator.
+func (msg *MsgTx) decode(r io.Reader, pver uint32, alloc *txAllocator) error {
 	version, err := binarySerializer.Uint32(r, littleEndian)
 	if err != nil {
 		return err
@@ -397,13 +404,11 @@ func (msg *MsgTx) BtcDecode(r io.Reader, pver uint32) error {
 
 	// Deserialize the inputs.
 	var totalScriptSize uint64
-	txIns := make([]TxIn, count)
-	msg.TxIn = make([]*TxIn, count)
+	// The pointers are set now in case a script buffer is borrowed and
+	// needs to be returned to the pool on error.
+	msg.TxIn = alloc.newTxIns(count)
 	for i := uint64(0); i < count; i++ {
-		// The pointer is set now in case a script buffer is borrowed
-		// and needs to be returned to the pool on error.
-		ti := &txIns[i]
-		msg.TxIn[i] = ti
+		ti := msg.TxIn[i]
 		err = readTxIn(r, pver, msg.Version, ti)
 		if err != nil {
 			returnScriptBuffers()
@@ -430,13 +435,11 @@ func (msg *MsgTx) BtcDecode(r io.Reader, pver uint32) error {
 	}
 
 	// Deserialize the outputs.
-	txOuts := make([]TxOut, count)
-	msg.TxOut = make([]*TxOut, count)
+	// The pointers are set now in case a script buffer is borrowed and
+	// needs to be returned to the pool on error.
+	msg.TxOut = alloc.newTxOuts(count)
 	for i := uint64(0); i < count; i++ {
-		// The pointer is set now in case a script buffer is borrowed
-		// and needs to be returned to the pool on error.
-		to := &txOuts[i]
-		msg.TxOut[i] = to
+		to := msg.TxOut[i]
 		err = readTxOut(r, pver, msg.Version, to)
 		if err != nil {
 			returnScriptBuffers()
@@ -466,7 +469,7 @@ func (msg *MsgTx) BtcDecode(r io.Reader, pver uint32) error {
 	// scripts in the transaction inputs and outputs no longer point to the
 	// buffers.
 	var offset uint64
-	scripts := make([]byte, totalScriptSize)
+	scripts := alloc.newScripts(totalScriptSize)
 	for i := 0; i < len(msg.TxIn); i++ {
 		// Copy the signature script into the contiguous buffer at the
 		// appropriate offset.
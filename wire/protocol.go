@@ -14,7 +14,7 @@ import (
 
 const (
 	// ProtocolVersion is the latest protocol version this package supports.
-	ProtocolVersion uint32 = 70013
+	ProtocolVersion uint32 = 70015
 
 	// MultipleAddressVersion is the protocol version which added multiple
 	// addresses per message (pver >= MultipleAddressVersion).
@@ -52,6 +52,16 @@ const (
 	// FeeFilterVersion is the protocol version which added a new
 	// feefilter message.
 	FeeFilterVersion uint32 = 70013
+
+	// AdminTxInvVersion is the protocol version which added the admin
+	// transaction inventory type, which is relayed ahead of and separately
+	// from other transactions (pver >= AdminTxInvVersion).
+	AdminTxInvVersion uint32 = 70014
+
+	// AnnounceVersion is the protocol version which added the announce
+	// message carrying signed governance announcements
+	// (pver >= AnnounceVersion).
+	AnnounceVersion uint32 = 70015
 )
 
 // ServiceFlag identifies services supported by a bitcoin peer.
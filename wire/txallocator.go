@@ -0,0 +1,137 @@
+// Copyright (c) 2019 Tranquility Node Ltd
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+const (
+	// txAllocMaxChunk is the maximum number of transactions, inputs or
+	// outputs allocated at once by a transaction allocator.
+	txAllocMaxChunk = 256
+
+	// txAllocMaxScriptChunk is the maximum number of script bytes
+	// allocated at once by a transaction allocator.
+	txAllocMaxScriptChunk = 64 * 1024
+
+	// txAllocScriptsPerTx is the expected number of script bytes of a
+	// transaction, which sizes the script chunks of small blocks.
+	txAllocScriptsPerTx = 512
+)
+
+// txAllocator hands out the structures and scripts of the transactions of a
+// block being decoded from chunks shared by several transactions, so decoding
+// a block takes a few large allocations instead of several small ones for each
+// transaction, which greatly reduces the work of the garbage collector during
+// the initial block download.
+//
+// The chunks are sized according to the number of transactions left to decode,
+// but never beyond a small maximum, so a block claiming a large number of
+// transactions cannot force large allocations before its data is read.  Every
+// slice handed out has its capacity limited to its length, so appending to it
+// never overwrites the parts of the other transactions.
+//
+// A nil allocator allocates each part separately.
+type txAllocator struct {
+	// remaining is the number of transactions left to decode, including
+	// the one being decoded.
+	remaining uint64
+
+	txs       []MsgTx
+	txIns     []TxIn
+	txInPtrs  []*TxIn
+	txOuts    []TxOut
+	txOutPtrs []*TxOut
+	scripts   []byte
+}
+
+// chunkSize returns the number of elements to allocate in order to hand out the
+// passed number of them, given the expected number per transaction and the
+// maximum chunk size.
+func (a *txAllocator) chunkSize(count, perTx, max uint64) uint64 {
+	size := a.remaining * perTx
+	if size > max {
+		size = max
+	}
+	if size < count {
+		size = count
+	}
+	return size
+}
+
+// newTx returns a new transaction.
+func (a *txAllocator) newTx() *MsgTx {
+	if a == nil {
+		return new(MsgTx)
+	}
+	if len(a.txs) == 0 {
+		a.txs = make([]MsgTx, a.chunkSize(1, 1, txAllocMaxChunk))
+	}
+	tx := &a.txs[0]
+	a.txs = a.txs[1:]
+	return tx
+}
+
+// newTxIns returns pointers to the passed number of new transaction inputs.
+func (a *txAllocator) newTxIns(count uint64) []*TxIn {
+	var txIns []TxIn
+	var ptrs []*TxIn
+	if a == nil || count > txAllocMaxChunk {
+		txIns = make([]TxIn, count)
+		ptrs = make([]*TxIn, count)
+	} else {
+		if uint64(len(a.txIns)) < count {
+			a.txIns = make([]TxIn, a.chunkSize(count, 2,
+				txAllocMaxChunk))
+		}
+		if uint64(len(a.txInPtrs)) < count {
+			a.txInPtrs = make([]*TxIn, a.chunkSize(count, 2,
+				txAllocMaxChunk))
+		}
+		txIns, a.txIns = a.txIns[:count:count], a.txIns[count:]
+		ptrs, a.txInPtrs = a.txInPtrs[:count:count], a.txInPtrs[count:]
+	}
+	for i := range ptrs {
+		ptrs[i] = &txIns[i]
+	}
+	return ptrs
+}
+
+// newTxOuts returns pointers to the passed number of new transaction outputs.
+func (a *txAllocator) newTxOuts(count uint64) []*TxOut {
+	var txOuts []TxOut
+	var ptrs []*TxOut
+	if a == nil || count > txAllocMaxChunk {
+		txOuts = make([]TxOut, count)
+		ptrs = make([]*TxOut, count)
+	} else {
+		if uint64(len(a.txOuts)) < count {
+			a.txOuts = make([]TxOut, a.chunkSize(count, 2,
+				txAllocMaxChunk))
+		}
+		if uint64(len(a.txOutPtrs)) < count {
+			a.txOutPtrs = make([]*TxOut, a.chunkSize(count, 2,
+				txAllocMaxChunk))
+		}
+		txOuts, a.txOuts = a.txOuts[:count:count], a.txOuts[count:]
+		ptrs, a.txOutPtrs = a.txOutPtrs[:count:count], a.txOutPtrs[count:]
+	}
+	for i := range ptrs {
+		ptrs[i] = &txOuts[i]
+	}
+	return ptrs
+}
+
+// newScripts returns a new buffer of the passed size to hold the scripts of a
+// transaction.
+func (a *txAllocator) newScripts(size uint64) []byte {
+	if a == nil || size > txAllocMaxScriptChunk/4 {
+		return make([]byte, size)
+	}
+	if uint64(len(a.scripts)) < size {
+		a.scripts = make([]byte, a.chunkSize(size, txAllocScriptsPerTx,
+			txAllocMaxScriptChunk))
+	}
+	buf := a.scripts[:size:size]
+	a.scripts = a.scripts[size:]
+	return buf
+}